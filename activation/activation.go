@@ -15,7 +15,6 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/activation/metrics"
-	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/events"
@@ -37,6 +36,59 @@ type PoetConfig struct {
 	RequestTimeout    time.Duration `mapstructure:"poet-request-timeout"`
 	RequestRetryDelay time.Duration `mapstructure:"retry-delay"`
 	MaxRequestRetries int           `mapstructure:"retry-max"`
+	// Overrides of the round timing for poets that run a different schedule.
+	Overrides []PoetOverride `mapstructure:"overrides"`
+}
+
+// PoetTiming is the round timing of the poet service.
+type PoetTiming struct {
+	PhaseShift  time.Duration `mapstructure:"phase-shift"`
+	CycleGap    time.Duration `mapstructure:"cycle-gap"`
+	GracePeriod time.Duration `mapstructure:"grace-period"`
+}
+
+// PoetOverride overrides the round timing of the poet with the address.
+// Zero durations are inherited from the PoetConfig.
+type PoetOverride struct {
+	Address    string `mapstructure:"address"`
+	PoetTiming `mapstructure:",squash"`
+}
+
+// Timing returns the round timing of the poet with the address.
+func (c PoetConfig) Timing(address string) PoetTiming {
+	timing := PoetTiming{
+		PhaseShift:  c.PhaseShift,
+		CycleGap:    c.CycleGap,
+		GracePeriod: c.GracePeriod,
+	}
+	for _, override := range c.Overrides {
+		if override.Address != address {
+			continue
+		}
+		if override.PhaseShift != 0 {
+			timing.PhaseShift = override.PhaseShift
+		}
+		if override.CycleGap != 0 {
+			timing.CycleGap = override.CycleGap
+		}
+		if override.GracePeriod != 0 {
+			timing.GracePeriod = override.GracePeriod
+		}
+	}
+	return timing
+}
+
+// timings returns the round timings of the poets with the addresses.
+// The timing of the PoetConfig is returned if there are no addresses.
+func (c PoetConfig) timings(addresses []string) []PoetTiming {
+	if len(addresses) == 0 {
+		return []PoetTiming{c.Timing("")}
+	}
+	rst := make([]PoetTiming, 0, len(addresses))
+	for _, address := range addresses {
+		rst = append(rst, c.Timing(address))
+	}
+	return rst
 }
 
 func DefaultPoetConfig() PoetConfig {
@@ -48,6 +100,8 @@ func DefaultPoetConfig() PoetConfig {
 
 const (
 	defaultPoetRetryInterval = 5 * time.Second
+	// defaultMalfeasanceCheckInterval is the interval of checking if the smeshing identity was proven malicious.
+	defaultMalfeasanceCheckInterval = time.Minute
 
 	// Jitter added to the wait time before building a nipost challenge.
 	// It is expressed as % of poet grace period which translates to:
@@ -56,11 +110,46 @@ const (
 	maxNipostChallengeBuildJitter = 1.0
 )
 
+// LatePublishStrategy decides what the builder does with the nipost challenge if the atx couldn't be built
+// in time for the publish epoch of the challenge.
+type LatePublishStrategy string
+
+const (
+	// LatePublishSkip discards the challenge, a new challenge is built for the next poet round.
+	LatePublishSkip LatePublishStrategy = "skip"
+	// LatePublishTargetNext keeps the contents of the challenge (previous, positioning and commitment atxs,
+	// initial post) and retargets it to the nearest publish epoch whose poet round didn't start yet.
+	// The challenge is discarded if the retargeted challenge would be invalid.
+	LatePublishTargetNext LatePublishStrategy = "target-next"
+)
+
+// Validate returns an error if the strategy is unknown. Empty strategy is the same as LatePublishSkip.
+func (s LatePublishStrategy) Validate() error {
+	switch s {
+	case "", LatePublishSkip, LatePublishTargetNext:
+		return nil
+	}
+	return fmt.Errorf("unknown late publish strategy %q, expected %q or %q",
+		s, LatePublishSkip, LatePublishTargetNext)
+}
+
 // Config defines configuration for Builder.
 type Config struct {
 	GoldenATXID      types.ATXID
 	LabelsPerUnit    uint64
 	RegossipInterval time.Duration
+	// RegossipProbePeers is the number of peers that are asked which of the atxs of the node they have
+	// before the atxs are re-gossiped. Atxs that most of them have are not re-gossiped. Zero disables probing.
+	RegossipProbePeers int
+	// RegossipRate is the largest number of atxs re-gossiped per second by all identities of the node.
+	// Zero disables the limit.
+	RegossipRate float64
+	// LatePublish is the strategy for the challenge that expired before the atx was published.
+	LatePublish LatePublishStrategy
+	// VRFKey is the index of the vrf key derived from the identity key that the identities use, the identity key
+	// is used while it is zero. Identities that use another key rotate it in their next atx, rotation needs atxs
+	// of the second version and signers that hold the identity key. The index must only be increased.
+	VRFKey uint32
 }
 
 // Builder struct is the struct that orchestrates the creation of activation transactions
@@ -80,19 +169,31 @@ type Builder struct {
 	log               *zap.Logger
 	parentCtx         context.Context
 	poetCfg           PoetConfig
+	poetMu            sync.RWMutex
+	poetAddresses     []string
 	poetRetryInterval time.Duration
+	// interval of checking if the identity was proven malicious
+	malfeasanceCheckInterval time.Duration
 	// delay before PoST in ATX is considered valid (counting from the time it was received)
 	postValidityDelay time.Duration
 
 	// states of each known identity
 	postStates PostStates
+	golden     *types.GoldenATXs
+	versions   types.AtxVersions
+
+	prober     hashProber
+	regossiper *regossiper
 
 	// smeshingMutex protects methods like `StartSmeshing` and `StopSmeshing` from concurrent execution
 	// since they (can) modify the fields below.
 	smeshingMutex sync.Mutex
-	signers       map[types.NodeID]*signing.EdSigner
+	signers       map[types.NodeID]signing.Signer
 	eg            errgroup.Group
 	stop          context.CancelFunc
+
+	resizeMu sync.Mutex
+	resizes  map[types.NodeID]func() // resizes scheduled for the identities, see ScheduleResize.
 }
 
 type BuilderOption func(*Builder)
@@ -111,6 +212,13 @@ func WithPoetRetryInterval(interval time.Duration) BuilderOption {
 	}
 }
 
+// WithMalfeasanceCheckInterval modifies how often builder checks if the smeshing identity was proven malicious.
+func WithMalfeasanceCheckInterval(interval time.Duration) BuilderOption {
+	return func(b *Builder) {
+		b.malfeasanceCheckInterval = interval
+	}
+}
+
 // WithContext modifies parent context for background job.
 func WithContext(ctx context.Context) BuilderOption {
 	return func(b *Builder) {
@@ -125,6 +233,28 @@ func WithPoetConfig(c PoetConfig) BuilderOption {
 	}
 }
 
+// WithPoetAddresses sets the addresses of the poets that the builder submits challenges to,
+// so that the challenge is built in time for the earliest round of the poets.
+func WithPoetAddresses(addresses ...string) BuilderOption {
+	return func(b *Builder) {
+		b.poetAddresses = addresses
+	}
+}
+
+// SetPoetAddresses replaces the addresses of the poets that the builder submits challenges to.
+func (b *Builder) SetPoetAddresses(addresses ...string) {
+	b.poetMu.Lock()
+	defer b.poetMu.Unlock()
+	b.poetAddresses = addresses
+}
+
+// poetTimings returns the round timings of the poets that the builder submits challenges to.
+func (b *Builder) poetTimings() []PoetTiming {
+	b.poetMu.RLock()
+	defer b.poetMu.RUnlock()
+	return b.poetCfg.timings(b.poetAddresses)
+}
+
 func WithValidator(v nipostValidator) BuilderOption {
 	return func(b *Builder) {
 		b.validator = v
@@ -137,6 +267,30 @@ func WithPostStates(ps PostStates) BuilderOption {
 	}
 }
 
+// WithBuilderGoldenATXs sets the golden atxs of the network upgrades, the golden atx from the config
+// is used in all epochs by default.
+func WithBuilderGoldenATXs(golden *types.GoldenATXs) BuilderOption {
+	return func(b *Builder) {
+		b.golden = golden
+	}
+}
+
+// WithBuilderAtxVersions sets the wire versions of the published atxs by epoch,
+// atxs of the first version are published by default.
+func WithBuilderAtxVersions(versions types.AtxVersions) BuilderOption {
+	return func(b *Builder) {
+		b.versions = versions
+	}
+}
+
+// WithHashProber sets the prober that is used to check which atxs of the node peers already have
+// before they are re-gossiped.
+func WithHashProber(prober hashProber) BuilderOption {
+	return func(b *Builder) {
+		b.prober = prober
+	}
+}
+
 // NewBuilder returns an atx builder that will start a routine that will attempt to create an atx upon each new layer.
 func NewBuilder(
 	conf Config,
@@ -151,7 +305,8 @@ func NewBuilder(
 ) *Builder {
 	b := &Builder{
 		parentCtx:         context.Background(),
-		signers:           make(map[types.NodeID]*signing.EdSigner),
+		signers:           make(map[types.NodeID]signing.Signer),
+		resizes:           make(map[types.NodeID]func()),
 		conf:              conf,
 		cdb:               cdb,
 		localDB:           localDB,
@@ -163,14 +318,18 @@ func NewBuilder(
 		poetRetryInterval: defaultPoetRetryInterval,
 		postValidityDelay: 12 * time.Hour,
 		postStates:        NewPostStates(log),
+		golden:            types.NewGoldenATXs(conf.GoldenATXID),
+
+		malfeasanceCheckInterval: defaultMalfeasanceCheckInterval,
 	}
 	for _, opt := range opts {
 		opt(b)
 	}
+	b.regossiper = newRegossiper(log, cdb, layerClock, publisher, b.prober, conf)
 	return b
 }
 
-func (b *Builder) Register(sig *signing.EdSigner) {
+func (b *Builder) Register(sig signing.Signer) {
 	b.smeshingMutex.Lock()
 	defer b.smeshingMutex.Unlock()
 	if _, exists := b.signers[sig.NodeID()]; exists {
@@ -181,12 +340,60 @@ func (b *Builder) Register(sig *signing.EdSigner) {
 	b.log.Info("registered signing key", log.ZShortStringer("id", sig.NodeID()))
 	b.signers[sig.NodeID()] = sig
 	b.postStates.Set(sig.NodeID(), types.PostStateIdle)
+	b.restoreVRFKeys(sig)
 
 	if b.stop != nil {
 		b.startID(b.parentCtx, sig)
 	}
 }
 
+// restoreVRFKeys makes the signer prove with the vrf keys that were rotated by the atxs of the identity.
+func (b *Builder) restoreVRFKeys(sig signing.Signer) {
+	rotator, ok := sig.(vrfKeyRotator)
+	if !ok {
+		return
+	}
+	err := atxs.IterateVRFKeys(b.cdb, sig.NodeID(), func(publish types.EpochID, key types.NodeID) bool {
+		for n := b.conf.VRFKey; n > 0; n-- {
+			if rotator.VRFKey(n) == key {
+				rotator.UseVRFKey(publish+1, n)
+				return true
+			}
+		}
+		b.log.Error("rotated vrf key is not derived from the identity key with the configured index",
+			log.ZShortStringer("smesherID", sig.NodeID()),
+			zap.Uint32("publish_epoch", publish.Uint32()),
+			zap.Stringer("vrf_key", key),
+		)
+		return true
+	})
+	if err != nil {
+		b.log.Error("failed to restore rotated vrf keys", log.ZShortStringer("smesherID", sig.NodeID()), zap.Error(err))
+	}
+}
+
+// rotatedVRFKey returns the vrf key that the atx rotates to, nil if the identity already uses the configured key.
+func (b *Builder) rotatedVRFKey(sig signing.Signer, target types.EpochID) *types.NodeID {
+	if b.conf.VRFKey == 0 {
+		return nil
+	}
+	rotator, ok := sig.(vrfKeyRotator)
+	if !ok {
+		b.log.Warn("signer can't rotate the vrf key", log.ZShortStringer("smesherID", sig.NodeID()))
+		return nil
+	}
+	current, err := atxs.VRFKey(b.cdb, sig.NodeID(), target)
+	if err != nil {
+		b.log.Warn("failed to get vrf key for ATX", zap.Error(err), log.ZShortStringer("smesherID", sig.NodeID()))
+		return nil
+	}
+	key := rotator.VRFKey(b.conf.VRFKey)
+	if key == current {
+		return nil
+	}
+	return &key
+}
+
 // Smeshing returns true iff atx builder is smeshing.
 func (b *Builder) Smeshing() bool {
 	b.smeshingMutex.Lock()
@@ -229,31 +436,20 @@ func (b *Builder) StartSmeshing(coinbase types.Address) error {
 	for _, sig := range b.signers {
 		b.startID(ctx, sig)
 	}
+	if b.conf.RegossipInterval != 0 {
+		b.eg.Go(func() error {
+			return b.regossiper.run(ctx, b.conf.RegossipInterval)
+		})
+	}
 	return nil
 }
 
-func (b *Builder) startID(ctx context.Context, sig *signing.EdSigner) {
+func (b *Builder) startID(ctx context.Context, sig signing.Signer) {
 	b.eg.Go(func() error {
 		b.run(ctx, sig)
 		return nil
 	})
-	if b.conf.RegossipInterval == 0 {
-		return
-	}
-	b.eg.Go(func() error {
-		ticker := time.NewTicker(b.conf.RegossipInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-ticker.C:
-				if err := b.Regossip(ctx, sig.NodeID()); err != nil {
-					b.log.Warn("failed to re-gossip", zap.Error(err))
-				}
-			}
-		}
-	})
+	b.regossiper.register(sig.NodeID())
 }
 
 // StopSmeshing stops the atx builder.
@@ -283,7 +479,7 @@ func (b *Builder) StopSmeshing(deleteFiles bool) error {
 				resetErr = errors.Join(resetErr, err)
 				continue
 			}
-			if err := nipost.RemoveChallenge(b.localDB, sig.NodeID()); err != nil {
+			if err := nipost.ResetPhase(b.localDB, sig.NodeID(), nipost.PhaseNone); err != nil {
 				b.log.Error("failed to remove nipost challenge", zap.Error(err))
 				err = fmt.Errorf("remove nipost challenge for id %s: %w", sig.NodeID().ShortString(), err)
 				resetErr = errors.Join(resetErr, err)
@@ -295,6 +491,22 @@ func (b *Builder) StopSmeshing(deleteFiles bool) error {
 	}
 }
 
+// NIPostPhase returns the persisted phase of the nipost construction of the identity.
+func (b *Builder) NIPostPhase(nodeID types.NodeID) (nipost.PhaseState, error) {
+	return nipost.GetPhase(b.localDB, nodeID)
+}
+
+// ResetNIPostPhase discards the state of the nipost construction of the identity after the phase,
+// so that the construction continues from the phase when smeshing is started. It fails if smeshing is running.
+func (b *Builder) ResetNIPostPhase(nodeID types.NodeID, phase nipost.Phase) error {
+	b.smeshingMutex.Lock()
+	defer b.smeshingMutex.Unlock()
+	if b.stop != nil {
+		return errors.New("can't reset nipost phase while smeshing")
+	}
+	return nipost.ResetPhase(b.localDB, nodeID, phase)
+}
+
 // SmesherID returns the ID of the smesher that created this activation.
 func (b *Builder) SmesherIDs() []types.NodeID {
 	b.smeshingMutex.Lock()
@@ -353,9 +565,60 @@ func (b *Builder) buildInitialPost(ctx context.Context, nodeID types.NodeID) err
 	return nipost.AddInitialPost(b.localDB, nodeID, initialPost)
 }
 
-func (b *Builder) run(ctx context.Context, sig *signing.EdSigner) {
+// watchMalfeasance cancels the context of the identity once it is proven malicious.
+func (b *Builder) watchMalfeasance(ctx context.Context, cancel context.CancelCauseFunc, nodeID types.NodeID) {
+	ticker := time.NewTicker(b.malfeasanceCheckInterval)
+	defer ticker.Stop()
+	for {
+		malicious, err := b.cdb.IsMalicious(nodeID)
+		if err != nil {
+			b.log.Warn("failed to check if identity is malicious",
+				log.ZShortStringer("smesherID", nodeID),
+				zap.Error(err),
+			)
+		} else if malicious {
+			cancel(ErrMaliciousIdentity)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cancelMalicious reports that smeshing was canceled for the malicious identity.
+func (b *Builder) cancelMalicious(nodeID types.NodeID) {
+	b.log.Error("identity is proven malicious, smeshing canceled", log.ZShortStringer("smesherID", nodeID))
+	b.postStates.Set(nodeID, types.PostStateMalicious)
+	proof, err := b.cdb.GetMalfeasanceProof(nodeID)
+	if err != nil {
+		b.log.Error("failed to get malfeasance proof", log.ZShortStringer("smesherID", nodeID), zap.Error(err))
+	}
+	events.EmitSmeshingCanceled(nodeID, proof)
+}
+
+func (b *Builder) run(ctx context.Context, sig signing.Signer) {
 	defer b.log.Info("atx builder stopped")
 
+	ctx, cancel := context.WithCancelCause(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.watchMalfeasance(ctx, cancel, sig.NodeID())
+	}()
+	defer func() {
+		cancel(nil)
+		wg.Wait()
+		if errors.Is(context.Cause(ctx), ErrMaliciousIdentity) {
+			b.cancelMalicious(sig.NodeID())
+		}
+		metrics.Identities.Remove(sig.NodeID())
+	}()
+
+	metrics.Identities.SetPhase(sig.NodeID(), metrics.PhaseInitialPost, time.Time{})
 	for {
 		err := b.buildInitialPost(ctx, sig.NodeID())
 		if err == nil {
@@ -373,6 +636,7 @@ func (b *Builder) run(ctx context.Context, sig *signing.EdSigner) {
 	for {
 		err := b.PublishActivationTx(ctx, sig)
 		if err == nil {
+			b.resize(sig.NodeID())
 			continue
 		} else if errors.Is(err, context.Canceled) {
 			return
@@ -386,8 +650,14 @@ func (b *Builder) run(ctx context.Context, sig *signing.EdSigner) {
 			if err := b.nipostBuilder.ResetState(sig.NodeID()); err != nil {
 				b.log.Error("failed to reset nipost builder state", zap.Error(err))
 			}
-			if err := nipost.RemoveChallenge(b.localDB, sig.NodeID()); err != nil {
-				b.log.Error("failed to discard challenge", zap.Error(err))
+			retargeted, err := b.retargetChallenge(ctx, sig.NodeID())
+			if err != nil {
+				b.log.Error("failed to retarget challenge", zap.Error(err))
+			}
+			if !retargeted {
+				if err := nipost.ResetPhase(b.localDB, sig.NodeID(), nipost.PhaseNone); err != nil {
+					b.log.Error("failed to discard challenge", zap.Error(err))
+				}
 			}
 			// give node some time to sync in case selecting the positioning ATX caused the challenge to expire
 			currentLayer := b.layerClock.CurrentLayer()
@@ -416,8 +686,29 @@ func (b *Builder) run(ctx context.Context, sig *signing.EdSigner) {
 	}
 }
 
+// ScheduleResize schedules the resize of the post data of the identity. The resize is executed after
+// the builder publishes the atx that it is building for the identity, so that the atx is proven
+// with the size it was started with. Scheduling the resize again replaces the previous one.
+func (b *Builder) ScheduleResize(nodeID types.NodeID, resize func()) {
+	b.resizeMu.Lock()
+	defer b.resizeMu.Unlock()
+	b.resizes[nodeID] = resize
+}
+
+func (b *Builder) resize(nodeID types.NodeID) {
+	b.resizeMu.Lock()
+	resize, exists := b.resizes[nodeID]
+	delete(b.resizes, nodeID)
+	b.resizeMu.Unlock()
+	if exists {
+		b.log.Info("resizing post data after published atx", log.ZShortStringer("smesherID", nodeID))
+		resize()
+	}
+}
+
 func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID) (*types.NIPostChallenge, error) {
 	logger := b.log.With(log.ZShortStringer("smesherID", nodeID))
+	metrics.Identities.SetPhase(nodeID, metrics.PhaseWaitingForSync, time.Time{})
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -443,7 +734,7 @@ func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID)
 		if err := b.nipostBuilder.ResetState(nodeID); err != nil {
 			return nil, fmt.Errorf("reset nipost builder state: %w", err)
 		}
-		if err := nipost.RemoveChallenge(b.localDB, nodeID); err != nil {
+		if err := nipost.ResetPhase(b.localDB, nodeID, nipost.PhaseNone); err != nil {
 			return nil, fmt.Errorf("remove stale nipost challenge: %w", err)
 		}
 	default:
@@ -456,6 +747,7 @@ func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID)
 	switch {
 	case err == nil:
 		current = max(current, prev.PublishEpoch)
+		metrics.Identities.SetPublished(nodeID, prev.PublishEpoch)
 	case errors.Is(err, sql.ErrNotFound):
 		// no previous ATX
 	case err != nil:
@@ -469,7 +761,8 @@ func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID)
 		until = time.Until(b.poetRoundStart(current))
 	}
 	metrics.PublishOntimeWindowLatency.Observe(until.Seconds())
-	wait := buildNipostChallengeStartDeadline(b.poetRoundStart(current), b.poetCfg.GracePeriod)
+	roundStart, wait := b.poetRound(current)
+	metrics.Identities.SetPhase(nodeID, metrics.PhaseWaitingForRound, roundStart)
 	if time.Until(wait) > 0 {
 		logger.Debug("waiting for fresh atxs",
 			zap.Duration("till poet round", until),
@@ -484,7 +777,7 @@ func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID)
 		}
 	}
 
-	posAtx, err := b.getPositioningAtx(ctx, nodeID)
+	posAtx, err := b.getPositioningAtx(ctx, nodeID, current+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get positioning ATX: %w", err)
 	}
@@ -538,12 +831,91 @@ func (b *Builder) BuildNIPostChallenge(ctx context.Context, nodeID types.NodeID)
 		}
 	}
 
-	if err := nipost.AddChallenge(b.localDB, nodeID, challenge); err != nil {
+	if err := b.localDB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := nipost.AddChallenge(tx, nodeID, challenge); err != nil {
+			return err
+		}
+		return nipost.SetPhase(tx, nodeID, nipost.PhaseChallenge, challenge.PublishEpoch)
+	}); err != nil {
 		return nil, fmt.Errorf("add nipost challenge: %w", err)
 	}
 	return challenge, nil
 }
 
+// retargetChallenge moves the expired challenge of the identity to the nearest publish epoch whose poet round
+// didn't start yet, keeping the rest of its contents. It returns false if the challenge was not retargeted
+// and needs to be discarded.
+func (b *Builder) retargetChallenge(ctx context.Context, nodeID types.NodeID) (bool, error) {
+	challenge, err := nipost.Challenge(b.localDB, nodeID)
+	if errors.Is(err, sql.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("get nipost challenge: %w", err)
+	}
+	logger := b.log.With(
+		log.ZShortStringer("smesherID", nodeID),
+		zap.Uint32("expired_publish_epoch", challenge.PublishEpoch.Uint32()),
+	)
+	current := b.layerClock.CurrentLayer().GetEpoch()
+	if b.conf.LatePublish != LatePublishTargetNext {
+		logger.Info("discarding expired challenge")
+		events.EmitAtxChallengeDiscarded(challenge.PublishEpoch, current, "late publish strategy is skip")
+		return false, nil
+	}
+
+	publish := current + 1
+	if !time.Now().Before(b.poetRoundStart(current)) {
+		publish++
+	}
+	publish = max(publish, challenge.PublishEpoch+1)
+	if err := b.validRetarget(nodeID, challenge, publish); err != nil {
+		logger.Info("discarding expired challenge that can't be retargeted",
+			zap.Uint32("publish_epoch", publish.Uint32()),
+			zap.Error(err),
+		)
+		events.EmitAtxChallengeDiscarded(challenge.PublishEpoch, current, err.Error())
+		return false, nil
+	}
+	expired := challenge.PublishEpoch
+	challenge.PublishEpoch = publish
+	if err := b.localDB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := nipost.UpdateChallenge(tx, nodeID, challenge); err != nil {
+			return err
+		}
+		return nipost.SetPhase(tx, nodeID, nipost.PhaseChallenge, publish)
+	}); err != nil {
+		return false, fmt.Errorf("update nipost challenge: %w", err)
+	}
+	logger.Info("retargeted expired challenge", zap.Uint32("publish_epoch", publish.Uint32()))
+	events.EmitAtxRetargeted(expired, publish, b.poetRoundStart(publish-1))
+	return true, nil
+}
+
+// validRetarget returns an error if the contents of the challenge are not valid for the atx published in the epoch.
+func (b *Builder) validRetarget(nodeID types.NodeID, challenge *types.NIPostChallenge, publish types.EpochID) error {
+	golden := b.golden.ForEpoch(publish)
+	if golden != b.golden.ForEpoch(challenge.PublishEpoch) {
+		return fmt.Errorf("golden atx changed in epoch %d", golden.Epoch)
+	}
+	prev, err := b.cdb.GetLastAtx(nodeID)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		if challenge.PrevATXID != types.EmptyATXID {
+			return fmt.Errorf("previous atx %s not found", challenge.PrevATXID.ShortString())
+		}
+	case err != nil:
+		return fmt.Errorf("get last atx: %w", err)
+	case prev.ID != challenge.PrevATXID:
+		return fmt.Errorf("atx %s was published after the challenge was built", prev.ID.ShortString())
+	}
+	if challenge.PositioningATX != golden.ID {
+		if _, err := b.cdb.GetAtxHeader(challenge.PositioningATX); err != nil {
+			return fmt.Errorf("positioning atx %s: %w", challenge.PositioningATX.ShortString(), err)
+		}
+	}
+	return nil
+}
+
 // SetCoinbase sets the address rewardAddress to be the coinbase account written into the activation transaction
 // the rewards for blocks made by this miner will go to this address.
 func (b *Builder) SetCoinbase(rewardAddress types.Address) {
@@ -560,7 +932,7 @@ func (b *Builder) Coinbase() types.Address {
 }
 
 // PublishActivationTx attempts to publish an atx, it returns an error if an atx cannot be created.
-func (b *Builder) PublishActivationTx(ctx context.Context, sig *signing.EdSigner) error {
+func (b *Builder) PublishActivationTx(ctx context.Context, sig signing.Signer) error {
 	challenge, err := b.BuildNIPostChallenge(ctx, sig.NodeID())
 	if err != nil {
 		return err
@@ -572,13 +944,16 @@ func (b *Builder) PublishActivationTx(ctx context.Context, sig *signing.EdSigner
 		zap.Uint32("publish_epoch", challenge.PublishEpoch.Uint32()),
 		zap.Uint32("target_epoch", challenge.TargetEpoch().Uint32()),
 	)
-	ctx, cancel := context.WithDeadline(ctx, b.layerClock.LayerToTime((challenge.TargetEpoch()).FirstLayer()))
+	publishDeadline := b.layerClock.LayerToTime((challenge.TargetEpoch()).FirstLayer())
+	ctx, cancel := context.WithDeadline(ctx, publishDeadline)
 	defer cancel()
 	atx, err := b.createAtx(ctx, sig, challenge)
 	if err != nil {
 		return fmt.Errorf("create ATX: %w", err)
 	}
 
+	metrics.Identities.SetPhase(sig.NodeID(), metrics.PhasePublishing, publishDeadline)
+
 	for {
 		size, err := b.broadcast(ctx, atx)
 		if err == nil {
@@ -594,12 +969,27 @@ func (b *Builder) PublishActivationTx(ctx context.Context, sig *signing.EdSigner
 		}
 	}
 
+	b.verifyPublishedAtx(atx)
+	if key := atx.VRFKey(); key != nil {
+		// signers that can't rotate the key don't declare it
+		sig.(vrfKeyRotator).UseVRFKey(atx.TargetEpoch(), b.conf.VRFKey)
+		b.log.Info("vrf key rotated",
+			log.ZShortStringer("smesherID", sig.NodeID()),
+			zap.Uint32("target_epoch", atx.TargetEpoch().Uint32()),
+			zap.Stringer("vrf_key", key),
+		)
+	}
+
+	if err := nipost.SetPhase(b.localDB, sig.NodeID(), nipost.PhasePublished, atx.PublishEpoch); err != nil {
+		return fmt.Errorf("set published phase: %w", err)
+	}
 	if err := b.nipostBuilder.ResetState(sig.NodeID()); err != nil {
 		return fmt.Errorf("reset nipost builder state: %w", err)
 	}
 	if err := nipost.RemoveChallenge(b.localDB, sig.NodeID()); err != nil {
 		return fmt.Errorf("discarding challenge after published ATX: %w", err)
 	}
+	metrics.Identities.SetPublished(sig.NodeID(), atx.PublishEpoch)
 	events.EmitAtxPublished(
 		atx.PublishEpoch, atx.TargetEpoch(),
 		atx.ID(),
@@ -608,13 +998,32 @@ func (b *Builder) PublishActivationTx(ctx context.Context, sig *signing.EdSigner
 	return nil
 }
 
+// poetRoundStart returns the start of the earliest round of the poets in the epoch.
 func (b *Builder) poetRoundStart(epoch types.EpochID) time.Time {
-	return b.layerClock.LayerToTime(epoch.FirstLayer()).Add(b.poetCfg.PhaseShift)
+	start, _ := b.poetRound(epoch)
+	return start
+}
+
+// poetRound returns the start of the earliest round of the poets in the epoch and the time when
+// the builder starts building the challenge, so that it is ready within the grace period of every poet.
+func (b *Builder) poetRound(epoch types.EpochID) (start, wait time.Time) {
+	epochStart := b.layerClock.LayerToTime(epoch.FirstLayer())
+	for _, timing := range b.poetTimings() {
+		roundStart := epochStart.Add(timing.PhaseShift)
+		if start.IsZero() || roundStart.Before(start) {
+			start = roundStart
+		}
+		deadline := buildNipostChallengeStartDeadline(roundStart, timing.GracePeriod)
+		if wait.IsZero() || deadline.Before(wait) {
+			wait = deadline
+		}
+	}
+	return start, wait
 }
 
 func (b *Builder) createAtx(
 	ctx context.Context,
-	sig *signing.EdSigner,
+	sig signing.Signer,
 	challenge *types.NIPostChallenge,
 ) (*types.ActivationTx, error) {
 	pubEpoch := challenge.PublishEpoch
@@ -624,6 +1033,7 @@ func (b *Builder) createAtx(
 		return nil, fmt.Errorf("build NIPost: %w", err)
 	}
 
+	metrics.Identities.SetPhase(sig.NodeID(), metrics.PhaseWaitingForPublish, time.Time{})
 	b.log.Info("awaiting atx publication epoch",
 		zap.Uint32("pub_epoch", pubEpoch.Uint32()),
 		zap.Uint32("pub_epoch_first_layer", pubEpoch.FirstLayer().Uint32()),
@@ -638,7 +1048,8 @@ func (b *Builder) createAtx(
 	b.log.Debug("publication epoch has arrived!", log.ZShortStringer("smesherID", sig.NodeID()))
 
 	if challenge.PublishEpoch < b.layerClock.CurrentLayer().GetEpoch() {
-		if challenge.InitialPost != nil {
+		// retargeted challenge keeps the initial post
+		if challenge.InitialPost != nil && b.conf.LatePublish != LatePublishTargetNext {
 			// initial post is not discarded; don't return ErrATXChallengeExpired
 			return nil, errors.New("atx publish epoch has passed during nipost construction")
 		}
@@ -670,15 +1081,20 @@ func (b *Builder) createAtx(
 		nipostState.NumUnits,
 		nonce,
 	)
-	atx.InnerActivationTx.NodeID = atxNodeID
-	if err = SignAndFinalizeAtx(sig, atx); err != nil {
+	if version := b.versions.Version(pubEpoch); version == types.AtxV1 {
+		atx.InnerActivationTx.NodeID = atxNodeID
+	} else {
+		atx.SetVersion(version)
+		atx.SetVRFKey(b.rotatedVRFKey(sig, challenge.TargetEpoch()))
+	}
+	if err = signAndFinalizeAtx(ctx, sig, atx); err != nil {
 		return nil, fmt.Errorf("sign atx: %w", err)
 	}
 	return atx, nil
 }
 
 func (b *Builder) broadcast(ctx context.Context, atx *types.ActivationTx) (int, error) {
-	buf, err := codec.Encode(atx)
+	buf, err := atx.Blob()
 	if err != nil {
 		return 0, fmt.Errorf("failed to serialize ATX: %w", err)
 	}
@@ -688,13 +1104,70 @@ func (b *Builder) broadcast(ctx context.Context, atx *types.ActivationTx) (int,
 	return len(buf), nil
 }
 
-// getPositioningAtx returns atx id with the highest tick height.
-func (b *Builder) getPositioningAtx(ctx context.Context, nodeID types.NodeID) (types.ATXID, error) {
+// verifyPublishedAtx reads back the atx that was stored after the published atx was processed
+// and compares it with the atx built by the node. A mismatch means that the atx was altered after
+// it was built (by a bug or a malicious post service) and is reported to the operator.
+func (b *Builder) verifyPublishedAtx(intended *types.ActivationTx) {
+	logger := b.log.With(log.ZShortStringer("smesherID", intended.SmesherID), zap.Stringer("atx_id", intended.ID()))
+	id, err := atxs.GetIDByEpochAndNodeID(b.cdb, intended.PublishEpoch, intended.SmesherID)
+	if errors.Is(err, sql.ErrNotFound) {
+		logger.Warn("published atx is not stored yet, skipping verification")
+		return
+	} else if err != nil {
+		logger.Warn("failed to read back published atx", zap.Error(err))
+		return
+	}
+	stored, err := atxs.Get(b.cdb, id)
+	if err != nil {
+		logger.Warn("failed to read back published atx", zap.Error(err))
+		return
+	}
+	mismatched := compareAtx(intended, stored.ActivationTx)
+	if len(mismatched) == 0 {
+		logger.Debug("published atx matches the stored atx")
+		return
+	}
+	logger.Error("published atx doesn't match the stored atx",
+		zap.Strings("mismatched", mismatched),
+		zap.Inline(intended),
+		zap.Stringer("stored_atx_id", stored.ID()),
+	)
+	events.EmitAtxMismatch(intended.PublishEpoch, intended.TargetEpoch(), intended.ID(), mismatched)
+}
+
+// compareAtx returns the names of the fields that are different in the stored atx.
+func compareAtx(intended, stored *types.ActivationTx) []string {
+	var mismatched []string
+	check := func(name string, equal bool) {
+		if !equal {
+			mismatched = append(mismatched, name)
+		}
+	}
+	check("id", intended.ID() == stored.ID())
+	check("coinbase", intended.Coinbase == stored.Coinbase)
+	check("num_units", intended.NumUnits == stored.NumUnits)
+	check("sequence", intended.Sequence == stored.Sequence)
+	check("prev_atx", intended.PrevATXID == stored.PrevATXID)
+	check("positioning_atx", intended.PositioningATX == stored.PositioningATX)
+	check("commitment_atx", (intended.CommitmentATX == nil) == (stored.CommitmentATX == nil) &&
+		(intended.CommitmentATX == nil || *intended.CommitmentATX == *stored.CommitmentATX))
+	check("vrf_key", (intended.VRFKey() == nil) == (stored.VRFKey() == nil) &&
+		(intended.VRFKey() == nil || *intended.VRFKey() == *stored.VRFKey()))
+	return mismatched
+}
+
+// getPositioningAtx returns atx id with the highest tick height that can be used in the publish epoch.
+func (b *Builder) getPositioningAtx(
+	ctx context.Context,
+	nodeID types.NodeID,
+	publish types.EpochID,
+) (types.ATXID, error) {
+	golden := b.golden.ForEpoch(publish)
 	id, err := findFullyValidHighTickAtx(
 		ctx,
 		b.cdb,
 		nodeID,
-		b.conf.GoldenATXID,
+		golden,
 		b.validator,
 		b.log,
 		VerifyChainOpts.AssumeValidBefore(time.Now().Add(-b.postValidityDelay)),
@@ -703,26 +1176,18 @@ func (b *Builder) getPositioningAtx(ctx context.Context, nodeID types.NodeID) (t
 	)
 	if errors.Is(err, sql.ErrNotFound) {
 		b.log.Info("using golden atx as positioning atx", log.ZShortStringer("smesherID", nodeID))
-		return b.conf.GoldenATXID, nil
+		return golden.ID, nil
 	}
 	return id, err
 }
 
+// Regossip publishes the atx of the identity in the current epoch, without checking if peers have it.
 func (b *Builder) Regossip(ctx context.Context, nodeID types.NodeID) error {
 	epoch := b.layerClock.CurrentLayer().GetEpoch()
-	atx, err := atxs.GetIDByEpochAndNodeID(b.cdb, epoch, nodeID)
-	if errors.Is(err, sql.ErrNotFound) {
-		return nil
-	} else if err != nil {
+	atx, blob, err := atxBlobForRegossip(ctx, b.cdb, epoch, nodeID)
+	if err != nil || blob == nil {
 		return err
 	}
-	blob, err := atxs.GetBlob(ctx, b.cdb, atx.Bytes())
-	if err != nil {
-		return fmt.Errorf("get blob %s: %w", atx.ShortString(), err)
-	}
-	if len(blob) == 0 {
-		return nil // checkpoint
-	}
 	if err := b.publisher.Publish(ctx, pubsub.AtxProtocol, blob); err != nil {
 		return fmt.Errorf("republish %s: %w", atx.ShortString(), err)
 	}
@@ -732,7 +1197,15 @@ func (b *Builder) Regossip(ctx context.Context, nodeID types.NodeID) error {
 
 // SignAndFinalizeAtx signs the atx with specified signer and calculates the ID of the ATX.
 func SignAndFinalizeAtx(signer *signing.EdSigner, atx *types.ActivationTx) error {
-	atx.Signature = signer.Sign(signing.ATX, atx.SignedBytes())
+	return signAndFinalizeAtx(context.Background(), signer, atx)
+}
+
+func signAndFinalizeAtx(ctx context.Context, signer signing.Signer, atx *types.ActivationTx) error {
+	sig, err := signer.SignContext(ctx, signing.ATX, atx.SignedBytes())
+	if err != nil {
+		return err
+	}
+	atx.Signature = sig
 	atx.SmesherID = signer.NodeID()
 	return atx.Initialize()
 }
@@ -746,7 +1219,7 @@ func findFullyValidHighTickAtx(
 	ctx context.Context,
 	db sql.Executor,
 	prefNodeID types.NodeID,
-	goldenATXID types.ATXID,
+	golden types.GoldenATX,
 	validator nipostValidator,
 	log *zap.Logger,
 	opts ...VerifyChainOption,
@@ -756,6 +1229,16 @@ func findFullyValidHighTickAtx(
 		_, ok := rejectedAtxs[id]
 		return !ok
 	}
+	// atxs published before the golden atx reset the chain can't be used. candidates are selected
+	// only from the last two epochs, so it is enough to reject atxs from two epochs before the reset.
+	for epoch := golden.Epoch - min(golden.Epoch, 2); epoch < golden.Epoch; epoch++ {
+		if err := atxs.IterateIDsByEpoch(db, epoch, func(id types.ATXID) bool {
+			rejectedAtxs[id] = struct{}{}
+			return true
+		}); err != nil {
+			return types.ATXID{}, fmt.Errorf("get atxs before golden atx: %w", err)
+		}
+	}
 
 	for {
 		select {
@@ -768,7 +1251,7 @@ func findFullyValidHighTickAtx(
 			return types.ATXID{}, err
 		}
 
-		if err := validator.VerifyChain(ctx, id, goldenATXID, opts...); err != nil {
+		if err := validator.VerifyChain(ctx, id, golden.ID, opts...); err != nil {
 			log.Info("rejecting candidate for high-tick atx", zap.Error(err), zap.Stringer("atx_id", id))
 			rejectedAtxs[id] = struct{}{}
 		} else {
@@ -12,6 +12,10 @@ var (
 	ErrPoetServiceUnstable = &PoetSvcUnstableError{}
 	// ErrPoetProofNotReceived is returned when no poet proof was received.
 	ErrPoetProofNotReceived = errors.New("builder: didn't receive any poet proof")
+	// ErrMaliciousIdentity is the cause of canceled smeshing for the identity that was proven malicious.
+	ErrMaliciousIdentity = errors.New("builder: identity is malicious")
+	// ErrInvalidResize is returned when the proposed number of space units can't be applied to the post data.
+	ErrInvalidResize = errors.New("post supervisor: invalid resize")
 )
 
 // PoetSvcUnstableError means there was a problem communicating
@@ -29,6 +29,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/localsql"
 	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
 )
@@ -113,6 +114,7 @@ type testAtxBuilder struct {
 	cdb         *datastore.CachedDB
 	localDb     *localsql.Database
 	goldenATXID types.ATXID
+	signers     map[types.NodeID]*signing.EdSigner
 
 	mctrl       *gomock.Controller
 	mpub        *mocks.MockPublisher
@@ -131,6 +133,7 @@ func newTestBuilder(tb testing.TB, numSigners int, opts ...BuilderOption) *testA
 		cdb:         datastore.NewCachedDB(sql.InMemory(), lg),
 		localDb:     localsql.InMemory(sql.WithConnections(numSigners)),
 		goldenATXID: types.ATXID(types.HexToHash32("77777")),
+		signers:     make(map[types.NodeID]*signing.EdSigner),
 
 		mctrl:       ctrl,
 		mpub:        mocks.NewMockPublisher(ctrl),
@@ -167,6 +170,7 @@ func newTestBuilder(tb testing.TB, numSigners int, opts ...BuilderOption) *testA
 		sig, err := signing.NewEdSigner()
 		require.NoError(tb, err)
 		tab.Register(sig)
+		tab.signers[sig.NodeID()] = sig
 	}
 
 	return tab
@@ -204,8 +208,9 @@ func publishAtx(
 		LabelsPerUnit: DefaultPostConfig().LabelsPerUnit,
 	}, nil).AnyTimes()
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			*currLayer = currLayer.Add(buildNIPostLayerDuration)
+			nipostBuilt(tb, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(tb, challenge.Hash(), []byte("66666")), nil
 		})
 	ch := make(chan struct{})
@@ -312,6 +317,46 @@ func TestBuilder_RestartSmeshing(t *testing.T) {
 	})
 }
 
+func TestBuilder_CancelMaliciousIdentity(t *testing.T) {
+	tab := newTestBuilder(t, 2, WithMalfeasanceCheckInterval(10*time.Millisecond))
+	sigs := maps.Values(tab.signers)
+	malicious, honest := sigs[0].NodeID(), sigs[1].NodeID()
+
+	proving := make(chan types.NodeID, 2)
+	tab.mnipost.EXPECT().Proof(gomock.Any(), gomock.Any(), shared.ZeroChallenge).Times(2).DoAndReturn(
+		func(ctx context.Context, id types.NodeID, _ []byte) (*types.Post, *types.PostInfo, error) {
+			proving <- id
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		})
+	tab.mclock.EXPECT().CurrentLayer().Return(types.LayerID(0)).AnyTimes()
+	tab.mclock.EXPECT().AwaitLayer(gomock.Any()).Return(make(chan struct{})).AnyTimes()
+	require.NoError(t, tab.StartSmeshing(types.Address{}))
+	<-proving
+	<-proving
+
+	proof := &types.MalfeasanceProof{
+		Layer: types.LayerID(11),
+		Proof: types.Proof{
+			Type: types.MultipleBallots,
+			Data: &types.BallotProof{},
+		},
+	}
+	encoded, err := codec.Encode(proof)
+	require.NoError(t, err)
+	require.NoError(t, identities.SetMalicious(tab.cdb, malicious, encoded, time.Now()))
+	tab.cdb.CacheMalfeasanceProof(malicious, proof)
+
+	require.Eventually(t, func() bool {
+		return tab.postStates.Get()[malicious] == types.PostStateMalicious
+	}, time.Second, 10*time.Millisecond)
+	states := tab.PostStates()
+	require.Equal(t, types.PostStateMalicious, states[tab.signers[malicious]])
+	require.Equal(t, types.PostStateIdle, states[tab.signers[honest]])
+
+	require.NoError(t, tab.StopSmeshing(false))
+}
+
 func TestBuilder_StopSmeshing_Delete(t *testing.T) {
 	tab := newTestBuilder(t, 1)
 	sig := maps.Values(tab.signers)[0]
@@ -424,6 +469,45 @@ func TestBuilder_PublishActivationTx_HappyFlow(t *testing.T) {
 	require.ErrorIs(t, err, sql.ErrNotFound)
 }
 
+func TestBuilder_RotatedVRFKey(t *testing.T) {
+	tab := newTestBuilder(t, 1)
+	sig := maps.Values(tab.signers)[0]
+
+	require.Nil(t, tab.rotatedVRFKey(sig, 3))
+
+	tab.conf.VRFKey = 2
+	key := tab.rotatedVRFKey(sig, 3)
+	require.NotNil(t, key)
+	require.Equal(t, sig.VRFKey(2), *key)
+
+	ch := types.NIPostChallenge{PublishEpoch: 2, PositioningATX: tab.goldenATXID}
+	atx := newAtx(ch, nil, 2, types.Address{})
+	atx.SetVersion(types.AtxV2)
+	atx.SetVRFKey(key)
+	require.NoError(t, SignAndFinalizeAtx(sig, atx))
+	vatx, err := atx.Verify(0, 1)
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(tab.cdb, vatx))
+
+	// the identity already uses the configured key
+	require.Nil(t, tab.rotatedVRFKey(sig, 4))
+
+	// the key is restored when the identity is registered again
+	restored, err := signing.NewEdSigner(signing.WithPrivateKey(sig.PrivateKey()))
+	require.NoError(t, err)
+	tab.restoreVRFKeys(restored)
+
+	msg := []byte("message")
+	proof, err := restored.SignVRFContext(context.Background(), 3, msg)
+	require.NoError(t, err)
+	verifier := signing.NewVRFVerifier()
+	require.True(t, verifier.Verify(*key, signing.VRFMessage(sig.NodeID(), *key, msg), proof))
+
+	proof, err = restored.SignVRFContext(context.Background(), 2, msg)
+	require.NoError(t, err)
+	require.True(t, verifier.Verify(sig.NodeID(), msg, proof))
+}
+
 // TestBuilder_Loop_WaitsOnStaleChallenge checks if loop waits between attempts
 // failing with ErrATXChallengeExpired.
 func TestBuilder_Loop_WaitsOnStaleChallenge(t *testing.T) {
@@ -522,8 +606,9 @@ func TestBuilder_PublishActivationTx_FaultyNet(t *testing.T) {
 		LabelsPerUnit: DefaultPostConfig().LabelsPerUnit,
 	}, nil).AnyTimes()
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			currLayer = currLayer.Add(layersPerEpoch)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), []byte("66666")), nil
 		})
 	done := make(chan struct{})
@@ -613,8 +698,9 @@ func TestBuilder_PublishActivationTx_UsesExistingChallengeOnLatePublish(t *testi
 		LabelsPerUnit: DefaultPostConfig().LabelsPerUnit,
 	}, nil).AnyTimes()
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			currLayer = currLayer.Add(1)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), []byte("66666")), nil
 		})
 	done := make(chan struct{})
@@ -660,6 +746,144 @@ func TestBuilder_PublishActivationTx_UsesExistingChallengeOnLatePublish(t *testi
 	require.ErrorIs(t, err, sql.ErrNotFound)
 }
 
+func TestBuilder_RetargetChallenge(t *testing.T) {
+	setup := func(t *testing.T, strategy LatePublishStrategy, current types.LayerID) (*testAtxBuilder,
+		*signing.EdSigner, *types.NIPostChallenge,
+	) {
+		tab := newTestBuilder(t, 1, WithPoetConfig(PoetConfig{PhaseShift: layerDuration * 4}))
+		tab.conf.LatePublish = strategy
+		sig := maps.Values(tab.signers)[0]
+		prev := newActivationTx(t, sig, 0, types.EmptyATXID, tab.goldenATXID, &tab.goldenATXID,
+			1, 0, 1, types.Address{}, 1, &types.NIPost{})
+		require.NoError(t, atxs.Add(tab.cdb, prev))
+		challenge := &types.NIPostChallenge{
+			PublishEpoch:   2,
+			Sequence:       1,
+			PrevATXID:      prev.ID(),
+			PositioningATX: prev.ID(),
+		}
+		require.NoError(t, nipost.AddChallenge(tab.localDB, sig.NodeID(), challenge))
+
+		tab.mclock.EXPECT().CurrentLayer().Return(current).AnyTimes()
+		tab.mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(
+			func(got types.LayerID) time.Time {
+				// time.Now() ~= current
+				genesis := time.Now().Add(-time.Duration(current) * layerDuration)
+				return genesis.Add(layerDuration * time.Duration(got))
+			}).AnyTimes()
+		return tab, sig, challenge
+	}
+
+	t.Run("retargeted to the next epoch", func(t *testing.T) {
+		tab, sig, challenge := setup(t, LatePublishTargetNext, types.EpochID(3).FirstLayer())
+		retargeted, err := tab.retargetChallenge(context.Background(), sig.NodeID())
+		require.NoError(t, err)
+		require.True(t, retargeted)
+
+		got, err := nipost.Challenge(tab.localDB, sig.NodeID())
+		require.NoError(t, err)
+		expected := *challenge
+		expected.PublishEpoch = 4
+		require.Equal(t, expected, *got)
+	})
+
+	t.Run("poet round already started", func(t *testing.T) {
+		tab, sig, _ := setup(t, LatePublishTargetNext, types.EpochID(3).FirstLayer().Add(5))
+		retargeted, err := tab.retargetChallenge(context.Background(), sig.NodeID())
+		require.NoError(t, err)
+		require.True(t, retargeted)
+
+		got, err := nipost.Challenge(tab.localDB, sig.NodeID())
+		require.NoError(t, err)
+		require.EqualValues(t, 5, got.PublishEpoch)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		tab, sig, challenge := setup(t, LatePublishSkip, types.EpochID(3).FirstLayer())
+		retargeted, err := tab.retargetChallenge(context.Background(), sig.NodeID())
+		require.NoError(t, err)
+		require.False(t, retargeted)
+
+		got, err := nipost.Challenge(tab.localDB, sig.NodeID())
+		require.NoError(t, err)
+		require.Equal(t, challenge, got)
+	})
+
+	t.Run("atx published after the challenge", func(t *testing.T) {
+		tab, sig, challenge := setup(t, LatePublishTargetNext, types.EpochID(3).FirstLayer())
+		next := newActivationTx(t, sig, 1, challenge.PrevATXID, challenge.PositioningATX, nil,
+			2, 0, 1, types.Address{}, 1, &types.NIPost{})
+		require.NoError(t, atxs.Add(tab.cdb, next))
+		retargeted, err := tab.retargetChallenge(context.Background(), sig.NodeID())
+		require.NoError(t, err)
+		require.False(t, retargeted)
+	})
+
+	t.Run("golden atx changed", func(t *testing.T) {
+		tab, sig, _ := setup(t, LatePublishTargetNext, types.EpochID(3).FirstLayer())
+		require.NoError(t, tab.golden.Add(4, types.RandomATXID()))
+		retargeted, err := tab.retargetChallenge(context.Background(), sig.NodeID())
+		require.NoError(t, err)
+		require.False(t, retargeted)
+	})
+}
+
+func TestBuilder_Loop_RetargetsExpiredChallenge(t *testing.T) {
+	tab := newTestBuilder(t, 1, WithPoetConfig(PoetConfig{PhaseShift: layerDuration * 4}))
+	tab.conf.LatePublish = LatePublishTargetNext
+	sig := maps.Values(tab.signers)[0]
+
+	prev := newActivationTx(t, sig, 0, types.EmptyATXID, tab.goldenATXID, &tab.goldenATXID,
+		postGenesisEpoch-1, 0, 1, types.Address{}, 1, &types.NIPost{})
+	require.NoError(t, atxs.Add(tab.cdb, prev))
+	ch := &types.NIPostChallenge{
+		PublishEpoch:   postGenesisEpoch,
+		Sequence:       1,
+		PrevATXID:      prev.ID(),
+		PositioningATX: prev.ID(),
+	}
+	require.NoError(t, nipost.AddChallenge(tab.localDB, sig.NodeID(), ch))
+
+	currLayer := postGenesisEpoch.FirstLayer()
+	tab.mclock.EXPECT().CurrentLayer().DoAndReturn(func() types.LayerID { return currLayer }).AnyTimes()
+	tab.mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(
+		func(got types.LayerID) time.Time {
+			// time.Now() ~= currentLayer
+			genesis := time.Now().Add(-time.Duration(currLayer) * layerDuration)
+			return genesis.Add(layerDuration * time.Duration(got))
+		}).AnyTimes()
+
+	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), ch).DoAndReturn(
+		func(context.Context, signing.Signer, *types.NIPostChallenge) (*nipost.NIPostState, error) {
+			// nipost is completed after the publish epoch has passed
+			currLayer = (postGenesisEpoch + 1).FirstLayer()
+			return nil, ErrATXChallengeExpired
+		})
+	tab.mnipost.EXPECT().ResetState(sig.NodeID()).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tab.mclock.EXPECT().AwaitLayer(gomock.Any()).DoAndReturn(func(got types.LayerID) <-chan struct{} {
+		cancel()
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	})
+	var eg errgroup.Group
+	eg.Go(func() error {
+		tab.run(ctx, sig)
+		return nil
+	})
+	require.NoError(t, eg.Wait())
+
+	// challenge is preserved and retargeted to the next epoch
+	challenge, err := nipost.Challenge(tab.localDB, sig.NodeID())
+	require.NoError(t, err)
+	expected := *ch
+	expected.PublishEpoch = postGenesisEpoch + 2
+	require.Equal(t, expected, *challenge)
+}
+
 func TestBuilder_PublishActivationTx_RebuildNIPostWhenTargetEpochPassed(t *testing.T) {
 	tab := newTestBuilder(t, 1, WithPoetConfig(PoetConfig{PhaseShift: layerDuration * 4}))
 	sig := maps.Values(tab.signers)[0]
@@ -692,8 +916,9 @@ func TestBuilder_PublishActivationTx_RebuildNIPostWhenTargetEpochPassed(t *testi
 			return genesis.Add(layerDuration * time.Duration(got))
 		}).AnyTimes()
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			currLayer = currLayer.Add(layersPerEpoch)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), []byte("66666")), nil
 		})
 	done := make(chan struct{})
@@ -991,8 +1216,9 @@ func TestBuilder_PublishActivationTx_PrevATXWithoutPrevATX(t *testing.T) {
 	}, nil).AnyTimes()
 
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			currentLayer = currentLayer.Add(5)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), poetBytes), nil
 		})
 
@@ -1091,8 +1317,9 @@ func TestBuilder_PublishActivationTx_TargetsEpochBasedOnPosAtx(t *testing.T) {
 	}, nil).AnyTimes()
 
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			currentLayer = currentLayer.Add(layersPerEpoch)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), poetBytes), nil
 		})
 
@@ -1262,6 +1489,54 @@ func TestBuilder_SignAtx(t *testing.T) {
 	require.Equal(t, sig.NodeID(), atx.SmesherID)
 }
 
+func TestBuilder_VerifyPublishedAtx(t *testing.T) {
+	events.InitializeReporter()
+	sub, err := events.SubscribeMatched(func(t *events.UserEvent) bool {
+		return t.Event.Failure
+	}, events.WithBuffer(10))
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	tab := newTestBuilder(t, 1)
+	sig := maps.Values(tab.signers)[0]
+	challenge := types.NIPostChallenge{
+		Sequence:       1,
+		PrevATXID:      types.RandomATXID(),
+		PublishEpoch:   2,
+		PositioningATX: types.RandomATXID(),
+	}
+	nipost := newNIPostWithChallenge(t, types.HexToHash32("55555"), []byte("66666"))
+	intended := newAtx(challenge, nipost.NIPost, 4, types.Address{1})
+	require.NoError(t, SignAndFinalizeAtx(sig, intended))
+
+	t.Run("not stored", func(t *testing.T) {
+		tab.verifyPublishedAtx(intended)
+		require.Empty(t, sub.Out())
+	})
+	t.Run("matches", func(t *testing.T) {
+		stored := *intended
+		vatx, err := stored.Verify(0, 1)
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(tab.cdb, vatx))
+		tab.verifyPublishedAtx(intended)
+		require.Empty(t, sub.Out())
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		built := newAtx(challenge, nipost.NIPost, 8, types.Address{2})
+		require.NoError(t, SignAndFinalizeAtx(sig, built))
+		tab.verifyPublishedAtx(built)
+		select {
+		case ev := <-sub.Out():
+			require.Contains(t, ev.Event.Help, "coinbase, num_units")
+			published := ev.Event.GetAtxPublished()
+			require.NotNil(t, published)
+			require.Equal(t, built.ID().Bytes(), published.Id)
+		case <-time.After(time.Second):
+			require.FailNow(t, "mismatch event wasn't emitted")
+		}
+	})
+}
+
 func TestBuilder_RetryPublishActivationTx(t *testing.T) {
 	events.InitializeReporter()
 	sub, err := events.SubscribeMatched(func(t *events.UserEvent) bool {
@@ -1324,7 +1599,7 @@ func TestBuilder_RetryPublishActivationTx(t *testing.T) {
 	var last time.Time
 	builderConfirmation := make(chan struct{})
 	tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).Times(expectedTries).DoAndReturn(
-		func(_ context.Context, _ *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+		func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 			now := time.Now()
 			if now.Sub(last) < retryInterval {
 				require.FailNow(t, "retry interval not respected")
@@ -1336,6 +1611,7 @@ func TestBuilder_RetryPublishActivationTx(t *testing.T) {
 				return nil, ErrPoetServiceUnstable
 			}
 			close(builderConfirmation)
+			nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
 			return newNIPostWithChallenge(t, challenge.Hash(), poetBytes), nil
 		},
 	)
@@ -1537,7 +1813,11 @@ func TestWaitPositioningAtx(t *testing.T) {
 			// everything else are stubs that are irrelevant for the test
 			tab.mpostClient.EXPECT().Info(gomock.Any()).Return(&types.PostInfo{}, nil).AnyTimes()
 			tab.mnipost.EXPECT().ResetState(sig.NodeID()).Return(nil)
-			tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(&nipost.NIPostState{}, nil)
+			tab.mnipost.EXPECT().BuildNIPost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+					nipostBuilt(t, tab.localDb, sig.NodeID(), challenge.PublishEpoch)
+					return &nipost.NIPostState{}, nil
+				})
 			closed := make(chan struct{})
 			close(closed)
 			tab.mclock.EXPECT().AwaitLayer(types.EpochID(1).FirstLayer()).Return(closed).AnyTimes()
@@ -1577,6 +1857,19 @@ func TestWaitPositioningAtx(t *testing.T) {
 	}
 }
 
+// nipostBuilt moves the identity through the phases of the nipost construction as the nipost builder does.
+func nipostBuilt(tb testing.TB, db sql.Executor, nodeID types.NodeID, publish types.EpochID) {
+	tb.Helper()
+	state, err := nipost.GetPhase(db, nodeID)
+	require.NoError(tb, err)
+	if state.Phase == nipost.PhasePublished {
+		state.Phase = nipost.PhaseNone
+	}
+	for phase := state.Phase + 1; phase <= nipost.PhasePostBuilt; phase++ {
+		require.NoError(tb, nipost.SetPhase(db, nodeID, phase, publish))
+	}
+}
+
 func TestWaitingToBuildNipostChallengeWithJitter(t *testing.T) {
 	t.Run("before grace period", func(t *testing.T) {
 		//          ┌──grace period──┐
@@ -1610,6 +1903,75 @@ func TestWaitingToBuildNipostChallengeWithJitter(t *testing.T) {
 	})
 }
 
+func TestPoetConfigTiming(t *testing.T) {
+	cfg := PoetConfig{
+		PhaseShift:  time.Hour,
+		CycleGap:    10 * time.Minute,
+		GracePeriod: time.Minute,
+		Overrides: []PoetOverride{
+			{Address: "https://poet-1", PoetTiming: PoetTiming{PhaseShift: 2 * time.Hour}},
+			{Address: "https://poet-2", PoetTiming: PoetTiming{CycleGap: time.Minute, GracePeriod: time.Second}},
+		},
+	}
+	require.Equal(t, PoetTiming{PhaseShift: time.Hour, CycleGap: 10 * time.Minute, GracePeriod: time.Minute},
+		cfg.Timing("https://poet-3"))
+	require.Equal(t, PoetTiming{PhaseShift: 2 * time.Hour, CycleGap: 10 * time.Minute, GracePeriod: time.Minute},
+		cfg.Timing("https://poet-1"))
+	require.Equal(t, PoetTiming{PhaseShift: time.Hour, CycleGap: time.Minute, GracePeriod: time.Second},
+		cfg.Timing("https://poet-2"))
+}
+
+func TestBuilderPoetRound(t *testing.T) {
+	cfg := PoetConfig{
+		PhaseShift:  4 * time.Hour,
+		GracePeriod: time.Hour,
+		Overrides: []PoetOverride{
+			{Address: "https://poet-1", PoetTiming: PoetTiming{PhaseShift: 2 * time.Hour}},
+			{Address: "https://poet-2", PoetTiming: PoetTiming{GracePeriod: 3 * time.Hour}},
+		},
+	}
+	epochStart := time.Now()
+	for _, tc := range []struct {
+		desc      string
+		addresses []string
+		start     time.Time
+		wait      time.Time // without jitter
+	}{
+		{"no addresses", nil, epochStart.Add(4 * time.Hour), epochStart.Add(3 * time.Hour)},
+		{
+			"earliest round",
+			[]string{"https://poet-1", "https://poet-3"},
+			epochStart.Add(2 * time.Hour),
+			epochStart.Add(time.Hour),
+		},
+		{
+			"longest grace period",
+			[]string{"https://poet-2", "https://poet-3"},
+			epochStart.Add(4 * time.Hour),
+			epochStart.Add(time.Hour),
+		},
+		{
+			"all",
+			[]string{"https://poet-1", "https://poet-2"},
+			epochStart.Add(2 * time.Hour),
+			epochStart.Add(time.Hour),
+		},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			tab := newTestBuilder(t, 1, WithPoetConfig(cfg), WithPoetAddresses(tc.addresses...))
+			tab.mclock.EXPECT().LayerToTime(types.EpochID(2).FirstLayer()).Return(epochStart).AnyTimes()
+
+			start, wait := tab.poetRound(2)
+			require.Equal(t, tc.start, start)
+			require.GreaterOrEqual(t, wait, tc.wait)
+			// jitter is at most 1% of the grace period
+			require.LessOrEqual(t, wait, tc.wait.Add(2*time.Minute))
+			require.Equal(t, tc.start, tab.poetRoundStart(2))
+		})
+	}
+}
+
 // Test if GetPositioningAtx disregards ATXs with invalid POST in their chain.
 // It should pick an ATX with valid POST even though it's a lower height.
 func TestGetPositioningAtxPicksAtxWithValidChain(t *testing.T) {
@@ -1649,7 +2011,7 @@ func TestGetPositioningAtxPicksAtxWithValidChain(t *testing.T) {
 	tab.mValidator.EXPECT().
 		VerifyChain(gomock.Any(), validAtx.ID(), tab.goldenATXID, gomock.Any())
 
-	posAtxID, err := tab.getPositioningAtx(context.Background(), sig.NodeID())
+	posAtxID, err := tab.getPositioningAtx(context.Background(), sig.NodeID(), 1)
 	require.NoError(t, err)
 	require.Equal(t, posAtxID, vValidAtx.ID())
 }
@@ -1664,7 +2026,23 @@ func TestGetPositioningAtxDbFailed(t *testing.T) {
 	expected := errors.New("db error")
 	db.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).Return(0, expected)
 
-	none, err := tab.getPositioningAtx(context.Background(), sig.NodeID())
+	none, err := tab.getPositioningAtx(context.Background(), sig.NodeID(), 1)
 	require.ErrorIs(t, err, expected)
 	require.Equal(t, types.ATXID{}, none)
 }
+
+func TestBuilder_ScheduleResize(t *testing.T) {
+	tab := newTestBuilder(t, 1)
+	sig := maps.Values(tab.signers)[0]
+
+	var applied []int
+	tab.ScheduleResize(sig.NodeID(), func() { applied = append(applied, 1) })
+	tab.ScheduleResize(sig.NodeID(), func() { applied = append(applied, 2) })
+	tab.resize(types.RandomNodeID())
+	require.Empty(t, applied)
+
+	tab.resize(sig.NodeID())
+	require.Equal(t, []int{2}, applied)
+	tab.resize(sig.NodeID())
+	require.Equal(t, []int{2}, applied)
+}
@@ -0,0 +1,129 @@
+package activation
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
+)
+
+// AtxValidationConfig limits the resources used for syntactic validation of received atxs.
+type AtxValidationConfig struct {
+	// CPUShare is the fraction of cpus that validate atxs concurrently. At least one atx
+	// is always validated. Atxs published in the current epoch are validated before
+	// the atxs from previous epochs that are downloaded during sync.
+	CPUShare float64 `mapstructure:"cpu-share"`
+	// DependencyDepth is the number of referenced atxs in a chain that are fetched while handling
+	// the received atx. An atx deeper in the chain is dropped if its own references are missing,
+	// it is fetched again once the chain is resolved.
+	DependencyDepth int `mapstructure:"dependency-depth"`
+	// QuarantineSize is the number of atxs that are kept after they failed validation because
+	// their references could not be fetched. A quarantined atx is validated again once the missing
+	// reference arrives, the oldest atx is evicted if the quarantine is full. Zero disables the quarantine.
+	QuarantineSize int `mapstructure:"quarantine-size"`
+}
+
+// DefaultAtxValidationConfig returns the default config for atx validation.
+func DefaultAtxValidationConfig() AtxValidationConfig {
+	return AtxValidationConfig{
+		CPUShare:        0.5,
+		DependencyDepth: 8,
+		QuarantineSize:  1000,
+	}
+}
+
+// Workers returns the number of atxs that are validated concurrently.
+func (c AtxValidationConfig) Workers() int {
+	return max(1, int(math.Ceil(float64(runtime.NumCPU())*c.CPUShare)))
+}
+
+type prioritizedVerificationKey struct{}
+
+// withPrioritizedVerification marks post proofs verified with the context as prioritized.
+func withPrioritizedVerification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, prioritizedVerificationKey{}, struct{}{})
+}
+
+func isPrioritizedVerification(ctx context.Context) bool {
+	return ctx.Value(prioritizedVerificationKey{}) != nil
+}
+
+type dependencyDepthKey struct{}
+
+// withDependencyDepth marks atxs fetched with the context as references at the depth
+// in the chain of the atx that is being handled.
+func withDependencyDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, dependencyDepthKey{}, depth)
+}
+
+func dependencyDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(dependencyDepthKey{}).(int)
+	return depth
+}
+
+// validationSlots limits the number of atxs that are syntactically validated concurrently.
+// A released slot is handed over to the prioritized atxs before all others, in the order
+// in which they started waiting.
+type validationSlots struct {
+	mu          sync.Mutex
+	free        int
+	prioritized []chan struct{}
+	regular     []chan struct{}
+}
+
+func newValidationSlots(n int) *validationSlots {
+	return &validationSlots{free: n}
+}
+
+func (s *validationSlots) acquire(ctx context.Context, prioritized bool) error {
+	s.mu.Lock()
+	if s.free > 0 {
+		s.free--
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	queue := &s.regular
+	if prioritized {
+		queue = &s.prioritized
+	}
+	*queue = append(*queue, ready)
+	s.mu.Unlock()
+
+	metrics.AtxValidationQueue.Inc()
+	defer metrics.AtxValidationQueue.Dec()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	if i := slices.Index(*queue, ready); i >= 0 {
+		*queue = slices.Delete(*queue, i, i+1)
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+	s.mu.Unlock()
+	// the slot was handed over while the context was canceled
+	s.release()
+	return ctx.Err()
+}
+
+func (s *validationSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next chan struct{}
+	switch {
+	case len(s.prioritized) > 0:
+		next, s.prioritized = s.prioritized[0], s.prioritized[1:]
+	case len(s.regular) > 0:
+		next, s.regular = s.regular[0], s.regular[1:]
+	default:
+		s.free++
+		return
+	}
+	close(next)
+}
@@ -0,0 +1,69 @@
+package activation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationSlots_Prioritized(t *testing.T) {
+	slots := newValidationSlots(1)
+	require.NoError(t, slots.acquire(context.Background(), false))
+
+	order := make(chan string, 3)
+	wait := func(name string, prioritized bool) {
+		go func() {
+			require.NoError(t, slots.acquire(context.Background(), prioritized))
+			order <- name
+			slots.release()
+		}()
+	}
+	waiting := func(n int) func() bool {
+		return func() bool {
+			slots.mu.Lock()
+			defer slots.mu.Unlock()
+			return len(slots.prioritized)+len(slots.regular) == n
+		}
+	}
+	wait("regular", false)
+	require.Eventually(t, waiting(1), time.Second, time.Millisecond)
+	wait("first", true)
+	require.Eventually(t, waiting(2), time.Second, time.Millisecond)
+	wait("second", true)
+	require.Eventually(t, waiting(3), time.Second, time.Millisecond)
+
+	slots.release()
+	for _, expected := range []string{"first", "second", "regular"} {
+		select {
+		case name := <-order:
+			require.Equal(t, expected, name)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for slot")
+		}
+	}
+	require.Eventually(t, func() bool {
+		slots.mu.Lock()
+		defer slots.mu.Unlock()
+		return slots.free == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestValidationSlots_Canceled(t *testing.T) {
+	slots := newValidationSlots(1)
+	require.NoError(t, slots.acquire(context.Background(), false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, slots.acquire(ctx, true), context.Canceled)
+	require.Empty(t, slots.prioritized)
+
+	slots.release()
+	require.Equal(t, 1, slots.free)
+}
+
+func TestAtxValidationConfig_Workers(t *testing.T) {
+	require.Equal(t, 1, AtxValidationConfig{}.Workers())
+	require.GreaterOrEqual(t, DefaultAtxValidationConfig().Workers(), 1)
+}
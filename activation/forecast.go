@@ -0,0 +1,144 @@
+package activation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
+)
+
+// PublishForecast is the forecast of the next atx publication of the identity
+// based on its nipost construction state and the poet config.
+type PublishForecast struct {
+	NodeID types.NodeID
+	// Phase is the current phase of the nipost construction.
+	Phase        nipost.Phase
+	PublishEpoch types.EpochID
+	TargetEpoch  types.EpochID
+
+	// ChallengeStart is when the builder starts building the challenge, without the jitter.
+	ChallengeStart time.Time
+	// PoetRoundStart is the start of the earliest poet round the challenge is submitted to.
+	PoetRoundStart time.Time
+	// PoetRoundEnd is the end of the latest poet round, the poet proof is available after it.
+	PoetRoundEnd time.Time
+	// ProvingStart is when the post proving is expected to start.
+	ProvingStart time.Time
+	// PublishDeadline is the end of the publish epoch. The atx published after it doesn't receive rewards.
+	PublishDeadline time.Time
+
+	// RegistrationMargin is the time left to submit the challenge to the poets, negative if the round started.
+	RegistrationMargin time.Duration
+	// ProvingWindow is the time between the expected start of the proving and the publish deadline.
+	ProvingWindow time.Duration
+	// PublishMargin is the time left until the publish deadline.
+	PublishMargin time.Duration
+	// ProvingDuration is the duration of the last proving of the identity, 0 if it wasn't measured
+	// since the node started.
+	ProvingDuration time.Duration
+}
+
+func (f *PublishForecast) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", f.NodeID.ShortString())
+	enc.AddString("phase", f.Phase.String())
+	enc.AddUint32("publish epoch", f.PublishEpoch.Uint32())
+	enc.AddUint32("target epoch", f.TargetEpoch.Uint32())
+	enc.AddTime("challenge start", f.ChallengeStart)
+	enc.AddTime("poet round start", f.PoetRoundStart)
+	enc.AddTime("poet round end", f.PoetRoundEnd)
+	enc.AddTime("proving start", f.ProvingStart)
+	enc.AddTime("publish deadline", f.PublishDeadline)
+	enc.AddDuration("registration margin", f.RegistrationMargin)
+	enc.AddDuration("proving window", f.ProvingWindow)
+	enc.AddDuration("publish margin", f.PublishMargin)
+	enc.AddDuration("proving duration", f.ProvingDuration)
+	return nil
+}
+
+// PublishForecast returns the forecast of the next atx publication of the identity.
+// The forecast is for the challenge that is being built, or for the challenge that will be built next
+// if there is none.
+func (b *Builder) PublishForecast(nodeID types.NodeID) (*PublishForecast, error) {
+	state, err := nipost.GetPhase(b.localDB, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var publish types.EpochID
+	challenge, err := nipost.Challenge(b.localDB, nodeID)
+	switch {
+	case err == nil:
+		publish = challenge.PublishEpoch
+	case errors.Is(err, sql.ErrNotFound):
+		publish, err = b.nextPublishEpoch(nodeID, now)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("get nipost challenge: %w", err)
+	}
+
+	forecast := &PublishForecast{
+		NodeID:          nodeID,
+		Phase:           state.Phase,
+		PublishEpoch:    publish,
+		TargetEpoch:     publish + 1,
+		PublishDeadline: b.layerClock.LayerToTime((publish + 1).FirstLayer()),
+	}
+	roundEpochStart := b.layerClock.LayerToTime((publish - 1).FirstLayer())
+	publishEpochStart := b.layerClock.LayerToTime(publish.FirstLayer())
+	for _, timing := range b.poetTimings() {
+		roundStart := roundEpochStart.Add(timing.PhaseShift)
+		if forecast.PoetRoundStart.IsZero() || roundStart.Before(forecast.PoetRoundStart) {
+			forecast.PoetRoundStart = roundStart
+		}
+		challengeStart := roundStart.Add(-timing.GracePeriod)
+		if forecast.ChallengeStart.IsZero() || challengeStart.Before(forecast.ChallengeStart) {
+			forecast.ChallengeStart = challengeStart
+		}
+		forecast.PoetRoundEnd = latest(forecast.PoetRoundEnd, publishEpochStart.Add(timing.PhaseShift-timing.CycleGap))
+	}
+	forecast.ProvingStart = forecast.PoetRoundEnd
+	if state.Phase >= nipost.PhaseRegistered && state.Phase < nipost.PhasePublished {
+		// the poets reported the actual ends of the rounds the challenge is registered in
+		registrations, err := nipost.PoetRegistrations(b.localDB, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		var roundEnd time.Time
+		for _, registration := range registrations {
+			roundEnd = latest(roundEnd, registration.RoundEnd)
+		}
+		if !roundEnd.IsZero() {
+			forecast.ProvingStart = roundEnd
+		}
+	}
+	forecast.RegistrationMargin = forecast.PoetRoundStart.Sub(now)
+	forecast.ProvingWindow = forecast.PublishDeadline.Sub(forecast.ProvingStart)
+	forecast.PublishMargin = forecast.PublishDeadline.Sub(now)
+	forecast.ProvingDuration = b.postStates.ProvingDuration(nodeID)
+	return forecast, nil
+}
+
+// nextPublishEpoch returns the publish epoch of the challenge that the builder builds next for the identity.
+func (b *Builder) nextPublishEpoch(nodeID types.NodeID, now time.Time) (types.EpochID, error) {
+	current := b.layerClock.CurrentLayer().GetEpoch()
+	prev, err := b.cdb.GetLastAtx(nodeID)
+	switch {
+	case err == nil:
+		current = max(current, prev.PublishEpoch)
+	case errors.Is(err, sql.ErrNotFound):
+		// no previous ATX
+	default:
+		return 0, fmt.Errorf("get last ATX: %w", err)
+	}
+	if !now.Before(b.poetRoundStart(current)) {
+		current++
+	}
+	return current + 1, nil
+}
@@ -0,0 +1,86 @@
+package activation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/exp/maps"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
+)
+
+func TestBuilder_PublishForecast(t *testing.T) {
+	cfg := PoetConfig{
+		PhaseShift:  layerDuration * layersPerEpoch / 2,
+		CycleGap:    layerDuration * 2,
+		GracePeriod: layerDuration,
+	}
+	setup := func(tb testing.TB, current types.LayerID) (*testAtxBuilder, time.Time) {
+		tab := newTestBuilder(tb, 1, WithPoetConfig(cfg))
+		genesis := time.Now().Add(-time.Duration(current) * layerDuration)
+		tab.mclock.EXPECT().CurrentLayer().Return(current).AnyTimes()
+		tab.mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(func(lid types.LayerID) time.Time {
+			return genesis.Add(time.Duration(lid) * layerDuration)
+		}).AnyTimes()
+		return tab, genesis
+	}
+	epochStart := func(genesis time.Time, epoch types.EpochID) time.Time {
+		return genesis.Add(time.Duration(epoch.FirstLayer()) * layerDuration)
+	}
+
+	t.Run("before poet round", func(t *testing.T) {
+		tab, genesis := setup(t, types.EpochID(2).FirstLayer()+1)
+		sig := maps.Values(tab.signers)[0]
+
+		forecast, err := tab.PublishForecast(sig.NodeID())
+		require.NoError(t, err)
+		require.Equal(t, nipost.PhaseNone, forecast.Phase)
+		require.Equal(t, types.EpochID(3), forecast.PublishEpoch)
+		require.Equal(t, types.EpochID(4), forecast.TargetEpoch)
+		require.Equal(t, epochStart(genesis, 2).Add(cfg.PhaseShift), forecast.PoetRoundStart)
+		require.Equal(t, forecast.PoetRoundStart.Add(-cfg.GracePeriod), forecast.ChallengeStart)
+		require.Equal(t, epochStart(genesis, 3).Add(cfg.PhaseShift-cfg.CycleGap), forecast.PoetRoundEnd)
+		require.Equal(t, forecast.PoetRoundEnd, forecast.ProvingStart)
+		require.Equal(t, epochStart(genesis, 4), forecast.PublishDeadline)
+		require.Positive(t, forecast.RegistrationMargin)
+		require.Equal(t, cfg.CycleGap+layerDuration*layersPerEpoch/2, forecast.ProvingWindow)
+		require.Equal(t, time.Until(forecast.PublishDeadline).Round(time.Second), forecast.PublishMargin.Round(time.Second))
+		require.Zero(t, forecast.ProvingDuration)
+	})
+	t.Run("poet round started", func(t *testing.T) {
+		tab, _ := setup(t, types.EpochID(2).FirstLayer()+layersPerEpoch/2+1)
+		sig := maps.Values(tab.signers)[0]
+
+		forecast, err := tab.PublishForecast(sig.NodeID())
+		require.NoError(t, err)
+		require.Equal(t, types.EpochID(4), forecast.PublishEpoch)
+		require.Positive(t, forecast.RegistrationMargin)
+	})
+	t.Run("registered challenge", func(t *testing.T) {
+		tab, genesis := setup(t, types.EpochID(2).FirstLayer()+layersPerEpoch/2+1)
+		sig := maps.Values(tab.signers)[0]
+
+		challenge := &types.NIPostChallenge{PublishEpoch: 3}
+		require.NoError(t, nipost.AddChallenge(tab.localDb, sig.NodeID(), challenge))
+		require.NoError(t, nipost.SetPhase(tab.localDb, sig.NodeID(), nipost.PhaseChallenge, 3))
+		roundEnd := epochStart(genesis, 3).Add(time.Second).Round(time.Second)
+		require.NoError(t, nipost.AddPoetRegistration(tab.localDb, sig.NodeID(), nipost.PoETRegistration{
+			ChallengeHash: challenge.Hash(),
+			Address:       "http://poet1.com",
+			RoundID:       "1",
+			RoundEnd:      roundEnd,
+		}))
+		require.NoError(t, nipost.SetPhase(tab.localDb, sig.NodeID(), nipost.PhaseRegistered, 3))
+
+		forecast, err := tab.PublishForecast(sig.NodeID())
+		require.NoError(t, err)
+		require.Equal(t, nipost.PhaseRegistered, forecast.Phase)
+		require.Equal(t, types.EpochID(3), forecast.PublishEpoch)
+		require.Negative(t, forecast.RegistrationMargin)
+		require.Equal(t, roundEnd, forecast.ProvingStart)
+		require.Equal(t, epochStart(genesis, 4).Sub(roundEnd), forecast.ProvingWindow)
+	})
+}
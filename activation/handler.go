@@ -7,10 +7,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spacemeshos/post/shared"
 	"github.com/spacemeshos/post/verifying"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/maps"
 
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
 	"github.com/spacemeshos/go-spacemesh/atxsdata"
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
@@ -23,14 +27,34 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/poets"
 	"github.com/spacemeshos/go-spacemesh/system"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
+type atxVersionMetrics struct {
+	accepted, rejected, malformed prometheus.Counter
+}
+
+var atxVersionsMetrics = map[types.AtxVersion]atxVersionMetrics{
+	types.AtxV1: {metrics.AtxV1Accepted, metrics.AtxV1Rejected, metrics.AtxV1Malformed},
+	types.AtxV2: {metrics.AtxV2Accepted, metrics.AtxV2Rejected, metrics.AtxV2Malformed},
+}
+
+func versionMetrics(version types.AtxVersion) atxVersionMetrics {
+	return atxVersionsMetrics[version]
+}
+
 var (
 	errKnownAtx      = errors.New("known atx")
 	errMalformedData = fmt.Errorf("%w: malformed data", pubsub.ErrValidationReject)
 	errWrongHash     = fmt.Errorf("%w: incorrect hash", pubsub.ErrValidationReject)
 	errMaliciousATX  = errors.New("malicious atx")
+	// errDependencyDepth is returned if the references of the atx are missing and it is too deep
+	// in the chain of references to fetch them.
+	errDependencyDepth = errors.New("dependency depth exceeded")
+	// errMissingReferences is returned if the references of the atx could not be fetched.
+	errMissingReferences = errors.New("missing references")
 )
 
 // Handler processes the atxs received from all nodes and their validity status.
@@ -42,21 +66,66 @@ type Handler struct {
 	clock           layerClock
 	publisher       pubsub.Publisher
 	tickSize        uint64
-	goldenATXID     types.ATXID
+	golden          *types.GoldenATXs
 	nipostValidator nipostValidator
 	beacon          AtxReceiver
+	receivers       []AtxReceiver
 	tortoise        system.Tortoise
 	log             log.Log
 	mu              sync.Mutex
 	fetcher         system.Fetcher
 
 	signerMtx sync.Mutex
-	signers   map[types.NodeID]*signing.EdSigner
+	signers   map[types.NodeID]signing.Signer
 
 	// inProgress map gathers ATXs that are currently being processed.
 	// It's used to avoid processing the same ATX twice.
 	inProgress   map[types.ATXID][]chan error
 	inProgressMu sync.Mutex
+
+	validationSlots *validationSlots
+	dependencyDepth int
+	versions        types.AtxVersions
+	// quarantine is nil if atxs with missing references are dropped.
+	quarantine *atxQuarantine
+}
+
+// HandlerOption modifies Handler.
+type HandlerOption func(*Handler)
+
+// WithAtxValidationConfig sets the limits of syntactic validation of received atxs.
+func WithAtxValidationConfig(cfg AtxValidationConfig) HandlerOption {
+	return func(h *Handler) {
+		h.validationSlots = newValidationSlots(cfg.Workers())
+		h.dependencyDepth = cfg.DependencyDepth
+		h.quarantine = nil
+		if cfg.QuarantineSize > 0 {
+			h.quarantine = newAtxQuarantine(cfg.QuarantineSize)
+		}
+	}
+}
+
+// WithHandlerGoldenATXs sets the golden atxs of the network upgrades, the golden atx passed
+// to NewHandler is used in all epochs by default.
+func WithHandlerGoldenATXs(golden *types.GoldenATXs) HandlerOption {
+	return func(h *Handler) {
+		h.golden = golden
+	}
+}
+
+// WithHandlerAtxVersions sets the wire versions of the received atxs by publish epoch.
+// The version of the atx is selected by its publish epoch, atxs of the first version are accepted by default.
+func WithHandlerAtxVersions(versions types.AtxVersions) HandlerOption {
+	return func(h *Handler) {
+		h.versions = versions
+	}
+}
+
+// WithAtxReceiver adds the receiver that is notified about every stored atx, after the beacon.
+func WithAtxReceiver(receiver AtxReceiver) HandlerOption {
+	return func(h *Handler) {
+		h.receivers = append(h.receivers, receiver)
+	}
 }
 
 // NewHandler returns a data handler for ATX.
@@ -74,8 +143,9 @@ func NewHandler(
 	beacon AtxReceiver,
 	tortoise system.Tortoise,
 	log log.Log,
+	opts ...HandlerOption,
 ) *Handler {
-	return &Handler{
+	h := &Handler{
 		local:           local,
 		cdb:             cdb,
 		atxsdata:        atxsdata,
@@ -83,19 +153,27 @@ func NewHandler(
 		clock:           c,
 		publisher:       pub,
 		tickSize:        tickSize,
-		goldenATXID:     goldenATXID,
+		golden:          types.NewGoldenATXs(goldenATXID),
 		nipostValidator: nipostValidator,
 		log:             log,
 		fetcher:         fetcher,
 		beacon:          beacon,
 		tortoise:        tortoise,
 
-		signers:    make(map[types.NodeID]*signing.EdSigner),
+		signers:    make(map[types.NodeID]signing.Signer),
 		inProgress: make(map[types.ATXID][]chan error),
+
+		validationSlots: newValidationSlots(DefaultAtxValidationConfig().Workers()),
+		dependencyDepth: DefaultAtxValidationConfig().DependencyDepth,
+		quarantine:      newAtxQuarantine(DefaultAtxValidationConfig().QuarantineSize),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
-func (h *Handler) Register(sig *signing.EdSigner) {
+func (h *Handler) Register(sig signing.Signer) {
 	h.signerMtx.Lock()
 	defer h.signerMtx.Unlock()
 	if _, exists := h.signers[sig.NodeID()]; exists {
@@ -147,64 +225,81 @@ func (h *Handler) processVerifiedATX(
 }
 
 func (h *Handler) SyntacticallyValidate(ctx context.Context, atx *types.ActivationTx) error {
+	_, err := h.syntacticallyValidate(ctx, atx)
+	return err
+}
+
+// syntacticallyValidate returns true if the atx is published in the current epoch. Such atxs are needed
+// for the next epoch, they are validated before the atxs from previous epochs that are downloaded during sync.
+func (h *Handler) syntacticallyValidate(ctx context.Context, atx *types.ActivationTx) (bool, error) {
 	if atx.NIPost == nil {
-		return fmt.Errorf("nil nipst for atx %s", atx.ShortString())
+		return false, fmt.Errorf("nil nipst for atx %s", atx.ShortString())
 	}
 	current := h.clock.CurrentLayer().GetEpoch()
 	if atx.PublishEpoch > current+1 {
-		return fmt.Errorf("atx publish epoch is too far in the future: %d > %d", atx.PublishEpoch, current+1)
+		return false, fmt.Errorf("atx publish epoch is too far in the future: %d > %d", atx.PublishEpoch, current+1)
 	}
+	prioritized := atx.PublishEpoch >= current
 	if atx.PositioningATX == types.EmptyATXID {
-		return fmt.Errorf("empty positioning atx")
+		return false, fmt.Errorf("empty positioning atx")
+	}
+	if key := atx.VRFKey(); key != nil && *key == atx.SmesherID {
+		return false, fmt.Errorf("rotated vrf key is the smesher id")
 	}
 
 	switch {
 	case atx.PrevATXID == types.EmptyATXID:
 		if atx.InitialPost == nil {
-			return fmt.Errorf("no prev atx declared, but initial post is not included")
+			return false, fmt.Errorf("no prev atx declared, but initial post is not included")
 		}
-		if atx.InnerActivationTx.NodeID == nil {
-			return fmt.Errorf("no prev atx declared, but node id is missing")
+		if atx.Version() == types.AtxV1 && atx.InnerActivationTx.NodeID == nil {
+			return false, fmt.Errorf("no prev atx declared, but node id is missing")
 		}
 		if atx.VRFNonce == nil {
-			return fmt.Errorf("no prev atx declared, but vrf nonce is missing")
+			return false, fmt.Errorf("no prev atx declared, but vrf nonce is missing")
 		}
 		if atx.CommitmentATX == nil {
-			return fmt.Errorf("no prev atx declared, but commitment atx is missing")
+			return false, fmt.Errorf("no prev atx declared, but commitment atx is missing")
 		}
 		if *atx.CommitmentATX == types.EmptyATXID {
-			return fmt.Errorf("empty commitment atx")
+			return false, fmt.Errorf("empty commitment atx")
 		}
 		if atx.Sequence != 0 {
-			return fmt.Errorf("no prev atx declared, but sequence number not zero")
+			return false, fmt.Errorf("no prev atx declared, but sequence number not zero")
 		}
 
 		// Use the NIPost's Post metadata, while overriding the challenge to a zero challenge,
 		// as expected from the initial Post.
 		initialPostMetadata := *atx.NIPost.PostMetadata
 		initialPostMetadata.Challenge = shared.ZeroChallenge
-		if err := h.nipostValidator.VRFNonce(
-			atx.SmesherID, *atx.CommitmentATX, atx.VRFNonce, &initialPostMetadata, atx.NumUnits,
-		); err != nil {
-			return fmt.Errorf("invalid vrf nonce: %w", err)
-		}
-		if err := h.nipostValidator.Post(
-			ctx, atx.SmesherID, *atx.CommitmentATX, atx.InitialPost, &initialPostMetadata, atx.NumUnits,
-		); err != nil {
-			return fmt.Errorf("invalid initial post: %w", err)
+		err := h.withValidationSlot(ctx, prioritized, func(ctx context.Context) error {
+			if err := h.nipostValidator.VRFNonce(
+				atx.SmesherID, *atx.CommitmentATX, atx.VRFNonce, &initialPostMetadata, atx.NumUnits,
+			); err != nil {
+				return fmt.Errorf("invalid vrf nonce: %w", err)
+			}
+			if err := h.nipostValidator.Post(
+				ctx, atx.SmesherID, *atx.CommitmentATX, atx.InitialPost, &initialPostMetadata, atx.NumUnits,
+			); err != nil {
+				return fmt.Errorf("invalid initial post: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return false, err
 		}
 	default:
 		if atx.InnerActivationTx.NodeID != nil {
-			return fmt.Errorf("prev atx declared, but node id is included")
+			return false, fmt.Errorf("prev atx declared, but node id is included")
 		}
 		if atx.InitialPost != nil {
-			return fmt.Errorf("prev atx declared, but initial post is included")
+			return false, fmt.Errorf("prev atx declared, but initial post is included")
 		}
 		if atx.CommitmentATX != nil {
-			return fmt.Errorf("prev atx declared, but commitment atx is included")
+			return false, fmt.Errorf("prev atx declared, but commitment atx is included")
 		}
 	}
-	return nil
+	return prioritized, nil
 }
 
 func (h *Handler) SyntacticallyValidateDeps(
@@ -230,12 +325,13 @@ func (h *Handler) SyntacticallyValidateDeps(
 		}
 	}
 
-	if err := h.nipostValidator.PositioningAtx(atx.PositioningATX, h.cdb, h.goldenATXID, atx.PublishEpoch); err != nil {
+	golden := h.golden.ForEpoch(atx.PublishEpoch).ID
+	if err := h.nipostValidator.PositioningAtx(atx.PositioningATX, h.cdb, golden, atx.PublishEpoch); err != nil {
 		return nil, nil, err
 	}
 
 	var baseTickHeight uint64
-	if atx.PositioningATX != h.goldenATXID {
+	if atx.PositioningATX != golden {
 		posAtx, _ := h.cdb.GetAtxHeader(atx.PositioningATX) // cannot fail as pos atx is already verified
 		baseTickHeight = posAtx.TickHeight()
 	}
@@ -286,7 +382,8 @@ func (h *Handler) SyntacticallyValidateDeps(
 }
 
 func (h *Handler) validateInitialAtx(ctx context.Context, atx *types.ActivationTx) error {
-	if err := h.nipostValidator.InitialNIPostChallenge(&atx.NIPostChallenge, h.cdb, h.goldenATXID); err != nil {
+	golden := h.golden.ForEpoch(atx.PublishEpoch).ID
+	if err := h.nipostValidator.InitialNIPostChallenge(&atx.NIPostChallenge, h.cdb, golden); err != nil {
 		return err
 	}
 	atx.SetEffectiveNumUnits(atx.NumUnits)
@@ -394,12 +491,17 @@ func (h *Handler) cacheAtx(ctx context.Context, atx *types.ActivationTxHeader) *
 			h.log.With().Error("failed vrf nonce read", log.Err(err), log.Context(ctx))
 			return nil
 		}
+		key, err := h.cdb.VRFKey(atx.NodeID, atx.TargetEpoch())
+		if err != nil {
+			h.log.With().Error("failed vrf key read", log.Err(err), log.Context(ctx))
+			return nil
+		}
 		malicious, err := h.cdb.IsMalicious(atx.NodeID)
 		if err != nil {
 			h.log.With().Error("failed is malicious read", log.Err(err), log.Context(ctx))
 			return nil
 		}
-		return h.atxsdata.AddFromHeader(atx, nonce, malicious)
+		return h.atxsdata.AddFromHeader(atx, nonce, key, malicious)
 	}
 	return nil
 }
@@ -413,10 +515,7 @@ func (h *Handler) storeAtx(ctx context.Context, atx *types.VerifiedActivationTx)
 	var proof *types.MalfeasanceProof
 	if err := h.cdb.WithTx(ctx, func(tx *sql.Tx) error {
 		if malicious {
-			if err := atxs.Add(tx, atx); err != nil && !errors.Is(err, sql.ErrObjectExists) {
-				return fmt.Errorf("add atx to db: %w", err)
-			}
-			return nil
+			return addAtx(tx, atx)
 		}
 
 		prev, err := atxs.GetByEpochAndNodeID(tx, atx.PublishEpoch, atx.SmesherID)
@@ -465,8 +564,8 @@ func (h *Handler) storeAtx(ctx context.Context, atx *types.VerifiedActivationTx)
 			)
 		}
 
-		if err := atxs.Add(tx, atx); err != nil && !errors.Is(err, sql.ErrObjectExists) {
-			return fmt.Errorf("add atx to db: %w", err)
+		if err := addAtx(tx, atx); err != nil {
+			return err
 		}
 		return nil
 	}); err != nil {
@@ -479,6 +578,9 @@ func (h *Handler) storeAtx(ctx context.Context, atx *types.VerifiedActivationTx)
 	header := atx.ToHeader()
 	added := h.cacheAtx(ctx, header)
 	h.beacon.OnAtx(header)
+	for _, receiver := range h.receivers {
+		receiver.OnAtx(header)
+	}
 	if added != nil {
 		h.tortoise.OnAtx(atx.TargetEpoch(), atx.ID(), added)
 	}
@@ -488,6 +590,18 @@ func (h *Handler) storeAtx(ctx context.Context, atx *types.VerifiedActivationTx)
 	return proof, nil
 }
 
+// addAtx stores the header, blob and nonce of the atx in a savepoint, so that they are stored atomically
+// and a failed insert of an already known atx doesn't affect the rest of the enclosing transaction.
+func addAtx(db sql.Executor, atx *types.VerifiedActivationTx) error {
+	err := db.WithSavepoint(func(db sql.Executor) error {
+		return atxs.Add(db, atx)
+	})
+	if err != nil && !errors.Is(err, sql.ErrObjectExists) {
+		return fmt.Errorf("add atx to db: %w", err)
+	}
+	return nil
+}
+
 // GetEpochAtxs returns all valid ATXs received in the epoch epochID.
 func (h *Handler) GetEpochAtxs(ctx context.Context, epochID types.EpochID) (ids []types.ATXID, err error) {
 	ids, err = atxs.GetIDsByEpoch(ctx, h.cdb, epochID)
@@ -515,7 +629,7 @@ func (h *Handler) HandleSyncedAtx(ctx context.Context, expHash types.Hash32, pee
 func (h *Handler) registerHashes(atx *types.ActivationTx, peer p2p.Peer) {
 	hashes := map[types.Hash32]struct{}{}
 	for _, id := range []types.ATXID{atx.PositioningATX, atx.PrevATXID} {
-		if id != types.EmptyATXID && id != h.goldenATXID {
+		if id != types.EmptyATXID && !h.golden.Contains(id) {
 			hashes[id.Hash32()] = struct{}{}
 		}
 	}
@@ -559,10 +673,16 @@ func (h *Handler) handleAtx(
 	msg []byte,
 ) (*types.MalfeasanceProof, error) {
 	receivedTime := time.Now()
-	var atx types.ActivationTx
-	if err := codec.Decode(msg, &atx); err != nil {
+	publish, err := types.AtxPublishEpoch(msg)
+	if err != nil {
 		return nil, fmt.Errorf("%w: %w", errMalformedData, err)
 	}
+	version := h.versions.Version(publish)
+	atx, err := types.DecodeAtx(version, msg)
+	if err != nil {
+		versionMetrics(version).malformed.Inc()
+		return nil, fmt.Errorf("%w: atx version %d: %w", errMalformedData, version, err)
+	}
 	atx.SetReceived(receivedTime.Local())
 	if err := atx.Initialize(); err != nil {
 		return nil, fmt.Errorf("failed to derive ID from atx: %w", err)
@@ -586,19 +706,105 @@ func (h *Handler) handleAtx(
 
 	h.inProgress[atx.ID()] = []chan error{}
 	h.inProgressMu.Unlock()
+	if h.quarantine != nil {
+		// the atx was received again, it is quarantined again if its references are still missing
+		h.quarantine.remove(atx.ID())
+	}
 	h.log.WithContext(ctx).With().Info("handling incoming atx", atx.ID(), log.Int("size", len(msg)))
 
-	proof, err := h.processATX(ctx, expHash, peer, atx)
+	ctx, span := tracing.StartSpan(ctx, "atx.process", trace.WithAttributes(
+		attribute.Stringer("atx", atx.ID()),
+		attribute.Stringer("peer", peer),
+	))
+	proof, err := h.processATX(ctx, expHash, peer, *atx)
+	tracing.EndSpan(span, err)
+	switch {
+	case err == nil:
+		versionMetrics(version).accepted.Inc()
+	case !errors.Is(err, errKnownAtx):
+		versionMetrics(version).rejected.Inc()
+	}
 	h.inProgressMu.Lock()
-	defer h.inProgressMu.Unlock()
 	for _, ch := range h.inProgress[atx.ID()] {
 		ch <- err
 		close(ch)
 	}
 	delete(h.inProgress, atx.ID())
+	h.inProgressMu.Unlock()
+
+	if h.quarantine != nil {
+		switch {
+		case err == nil:
+			h.releaseQuarantined(ctx, atx.ID().Hash32(), atx.GetPoetProofRef())
+		case errors.Is(err, errMissingReferences):
+			h.quarantineAtx(ctx, atx, peer, msg, err)
+		}
+	}
 	return proof, err
 }
 
+// quarantineAtx keeps the atx that failed validation because of the missing reference
+// until the reference arrives.
+func (h *Handler) quarantineAtx(
+	ctx context.Context,
+	atx *types.ActivationTx,
+	peer p2p.Peer,
+	msg []byte,
+	reason error,
+) {
+	missing, exists, err := h.missingReference(atx)
+	if err != nil {
+		h.log.WithContext(ctx).With().Warning("failed to check references of atx", atx.ID(), log.Err(err))
+		return
+	}
+	if !exists {
+		// the reference arrived concurrently, the atx is fetched again
+		return
+	}
+	h.quarantine.add(QuarantinedAtx{
+		ID:           atx.ID(),
+		SmesherID:    atx.SmesherID,
+		PublishEpoch: atx.PublishEpoch,
+		Missing:      missing,
+		Peer:         peer,
+		Quarantined:  time.Now(),
+		Attempts:     1,
+		Reason:       reason.Error(),
+	}, msg)
+	h.log.WithContext(ctx).With().Debug("quarantined atx",
+		atx.ID(),
+		log.Stringer("missing", missing),
+	)
+}
+
+// releaseQuarantined validates again the quarantined atxs that were missing one of the references.
+func (h *Handler) releaseQuarantined(ctx context.Context, refs ...types.Hash32) {
+	for _, ref := range refs {
+		for _, atx := range h.quarantine.release(ref) {
+			_, err := h.handleAtx(ctx, atx.ID.Hash32(), atx.Peer, atx.msg)
+			switch {
+			case err == nil:
+				metrics.AtxQuarantineValidated.Inc()
+			case errors.Is(err, errKnownAtx):
+			default:
+				h.log.WithContext(ctx).With().Debug("quarantined atx failed validation",
+					atx.ID,
+					log.Stringer("released_by", ref),
+					log.Err(err),
+				)
+			}
+		}
+	}
+}
+
+// Quarantined returns the atxs that wait for their missing references to be validated again.
+func (h *Handler) Quarantined() []QuarantinedAtx {
+	if h.quarantine == nil {
+		return nil
+	}
+	return h.quarantine.list()
+}
+
 func (h *Handler) processATX(
 	ctx context.Context,
 	expHash types.Hash32,
@@ -614,16 +820,32 @@ func (h *Handler) processATX(
 		return nil, fmt.Errorf("%w atx %s", errKnownAtx, atx.ID())
 	}
 
-	if err := h.SyntacticallyValidate(ctx, &atx); err != nil {
+	spanCtx, span := tracing.StartSpan(ctx, "atx.syntactic_validation")
+	prioritized, err := h.syntacticallyValidate(spanCtx, &atx)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		return nil, fmt.Errorf("atx %v syntactically invalid: %w", atx.ShortString(), err)
 	}
 
 	h.registerHashes(&atx, peer)
-	if err := h.FetchReferences(ctx, &atx); err != nil {
-		return nil, err
+	spanCtx, span = tracing.StartSpan(ctx, "atx.fetch_references")
+	err = h.FetchReferences(spanCtx, &atx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMissingReferences, err)
 	}
 
-	vAtx, proof, err := h.SyntacticallyValidateDeps(ctx, &atx)
+	spanCtx, span = tracing.StartSpan(ctx, "atx.validate_dependencies")
+	var (
+		vAtx  *types.VerifiedActivationTx
+		proof *types.MalfeasanceProof
+	)
+	err = h.withValidationSlot(spanCtx, prioritized, func(ctx context.Context) error {
+		var err error
+		vAtx, proof, err = h.SyntacticallyValidateDeps(ctx, &atx)
+		return err
+	})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("atx %v syntactically invalid based on deps: %w", atx.ShortString(), err)
 	}
@@ -641,7 +863,9 @@ func (h *Handler) processATX(
 		)
 	}
 
-	proof, err = h.processVerifiedATX(ctx, vAtx)
+	spanCtx, span = tracing.StartSpan(ctx, "atx.store")
+	proof, err = h.processVerifiedATX(spanCtx, vAtx)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("cannot process atx %v: %w", atx.ShortString(), err)
 	}
@@ -652,38 +876,116 @@ func (h *Handler) processATX(
 	return proof, err
 }
 
-// FetchReferences fetches referenced ATXs from peers if they are not found in db.
-func (h *Handler) FetchReferences(ctx context.Context, atx *types.ActivationTx) error {
-	if err := h.fetcher.GetPoetProof(ctx, atx.GetPoetProofRef()); err != nil {
-		return fmt.Errorf("atx (%s) missing poet proof (%s): %w",
-			atx.ShortString(), atx.GetPoetProofRef().ShortString(), err,
-		)
+// withValidationSlot runs the cpu heavy part of atx validation once the number of concurrently
+// validated atxs drops below the configured limit. Post proofs of prioritized atxs are verified
+// before the others.
+func (h *Handler) withValidationSlot(
+	ctx context.Context,
+	prioritized bool,
+	validate func(context.Context) error,
+) error {
+	if err := h.validationSlots.acquire(ctx, prioritized); err != nil {
+		return fmt.Errorf("waiting for validation: %w", err)
 	}
-
-	atxIDs := make(map[types.ATXID]struct{}, 3)
-	if atx.PositioningATX != types.EmptyATXID && atx.PositioningATX != h.goldenATXID {
-		atxIDs[atx.PositioningATX] = struct{}{}
+	defer h.validationSlots.release()
+	if prioritized {
+		ctx = withPrioritizedVerification(ctx)
 	}
+	return validate(ctx)
+}
 
-	if atx.PrevATXID != types.EmptyATXID {
-		atxIDs[atx.PrevATXID] = struct{}{}
-	}
-	if atx.CommitmentATX != nil && *atx.CommitmentATX != h.goldenATXID {
-		atxIDs[*atx.CommitmentATX] = struct{}{}
-	}
+// FetchReferences fetches the poet proof and the atxs referenced by the atx if they are not in the database.
+// Referenced atxs are handled with the depth in the chain of references incremented. If the atx is at
+// the maximal depth its references are not fetched, and it is dropped if any of them is missing.
+func (h *Handler) FetchReferences(ctx context.Context, atx *types.ActivationTx) error {
+	poet := atx.GetPoetProofRef()
+	atxIDs := h.referencedAtxs(atx)
 
-	if len(atxIDs) == 0 {
-		return nil
+	depth := dependencyDepth(ctx)
+	if depth >= h.dependencyDepth {
+		return h.checkReferences(atx, depth, poet, maps.Keys(atxIDs))
 	}
+	ctx = withDependencyDepth(ctx, depth+1)
 
-	if err := h.fetcher.GetAtxs(ctx, maps.Keys(atxIDs), system.WithoutLimiting()); err != nil {
-		dbg := fmt.Sprintf("prev %v pos %v commit %v", atx.PrevATXID, atx.PositioningATX, atx.CommitmentATX)
-		return fmt.Errorf("fetch referenced atxs (%s): %w", dbg, err)
+	if err := h.fetcher.GetPoetProof(ctx, poet); err != nil {
+		metrics.AtxDependenciesFailed.Inc()
+		return fmt.Errorf("atx (%s) missing poet proof (%s): %w", atx.ShortString(), poet.ShortString(), err)
+	}
+	if len(atxIDs) > 0 {
+		if err := h.fetcher.GetAtxs(ctx, maps.Keys(atxIDs), system.WithoutLimiting()); err != nil {
+			metrics.AtxDependenciesFailed.Inc()
+			dbg := fmt.Sprintf("prev %v pos %v commit %v", atx.PrevATXID, atx.PositioningATX, atx.CommitmentATX)
+			return fmt.Errorf("fetch referenced atxs (%s): %w", dbg, err)
+		}
 	}
+	metrics.AtxDependenciesResolved.Inc()
 
 	h.log.WithContext(ctx).With().Debug("done fetching references for atx",
 		atx.ID(),
 		log.Int("num fetched", len(atxIDs)),
+		log.Int("depth", depth),
 	)
 	return nil
 }
+
+// referencedAtxs returns the atxs referenced by the atx, except for the golden atxs.
+func (h *Handler) referencedAtxs(atx *types.ActivationTx) map[types.ATXID]struct{} {
+	atxIDs := make(map[types.ATXID]struct{}, 3)
+	for _, id := range []types.ATXID{atx.PositioningATX, atx.PrevATXID} {
+		if id != types.EmptyATXID && !h.golden.Contains(id) {
+			atxIDs[id] = struct{}{}
+		}
+	}
+	if atx.CommitmentATX != nil && !h.golden.Contains(*atx.CommitmentATX) {
+		atxIDs[*atx.CommitmentATX] = struct{}{}
+	}
+	return atxIDs
+}
+
+// missingReference returns the reference of the poet proof or the id of the atx referenced by the atx
+// that is not in the database, the poet proof is checked first.
+func (h *Handler) missingReference(atx *types.ActivationTx) (types.Hash32, bool, error) {
+	poet := atx.GetPoetProofRef()
+	exists, err := poets.Has(h.cdb, types.PoetProofRef(poet))
+	if err != nil {
+		return types.Hash32{}, false, fmt.Errorf("check poet proof %s: %w", poet.ShortString(), err)
+	}
+	if !exists {
+		return poet, true, nil
+	}
+	for id := range h.referencedAtxs(atx) {
+		exists, err := atxs.Has(h.cdb, id)
+		if err != nil {
+			return types.Hash32{}, false, fmt.Errorf("check referenced atx %s: %w", id.ShortString(), err)
+		}
+		if !exists {
+			return id.Hash32(), true, nil
+		}
+	}
+	return types.Hash32{}, false, nil
+}
+
+// checkReferences returns errDependencyDepth if any reference of the atx is not in the database.
+func (h *Handler) checkReferences(atx *types.ActivationTx, depth int, poet types.Hash32, ids []types.ATXID) error {
+	exists, err := poets.Has(h.cdb, types.PoetProofRef(poet))
+	if err != nil {
+		return fmt.Errorf("check poet proof %s: %w", poet.ShortString(), err)
+	}
+	if !exists {
+		metrics.AtxDependenciesDropped.Inc()
+		return fmt.Errorf("%w: atx %s at depth %d misses poet proof %s",
+			errDependencyDepth, atx.ShortString(), depth, poet.ShortString())
+	}
+	for _, id := range ids {
+		exists, err := atxs.Has(h.cdb, id)
+		if err != nil {
+			return fmt.Errorf("check referenced atx %s: %w", id.ShortString(), err)
+		}
+		if !exists {
+			metrics.AtxDependenciesDropped.Inc()
+			return fmt.Errorf("%w: atx %s at depth %d misses atx %s",
+				errDependencyDepth, atx.ShortString(), depth, id.ShortString())
+		}
+	}
+	return nil
+}
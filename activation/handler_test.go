@@ -32,6 +32,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
+	"github.com/spacemeshos/go-spacemesh/sql/poets"
 	"github.com/spacemeshos/go-spacemesh/system"
 	"github.com/spacemeshos/go-spacemesh/system/mocks"
 )
@@ -98,7 +99,7 @@ type testHandler struct {
 	mtortoise  *mocks.MockTortoise
 }
 
-func newTestHandler(tb testing.TB, goldenATXID types.ATXID) *testHandler {
+func newTestHandler(tb testing.TB, goldenATXID types.ATXID, opts ...HandlerOption) *testHandler {
 	lg := logtest.New(tb)
 	cdb := datastore.NewCachedDB(sql.InMemory(), lg)
 	ctrl := gomock.NewController(tb)
@@ -124,6 +125,7 @@ func newTestHandler(tb testing.TB, goldenATXID types.ATXID) *testHandler {
 		mbeacon,
 		mtortoise,
 		lg,
+		opts...,
 	)
 	return &testHandler{
 		Handler: atxHdlr,
@@ -803,6 +805,27 @@ func TestHandler_SyntacticallyValidateAtx(t *testing.T) {
 		require.ErrorContains(t, err, "failed post validation")
 	})
 
+	t.Run("vrf key rotated to the smesher id", func(t *testing.T) {
+		t.Parallel()
+
+		atxHdlr := newTestHandler(t, goldenATXID)
+		challenge := types.NIPostChallenge{
+			Sequence:       prevAtx.Sequence + 1,
+			PrevATXID:      prevAtx.ID(),
+			PublishEpoch:   currentLayer.GetEpoch(),
+			PositioningATX: prevAtx.ID(),
+		}
+		atx := newAtx(challenge, &types.NIPost{}, 100, types.GenerateAddress([]byte("aaaa")))
+		atx.SetVersion(types.AtxV2)
+		key := sig.NodeID()
+		atx.SetVRFKey(&key)
+		require.NoError(t, SignAndFinalizeAtx(sig, atx))
+
+		atxHdlr.mclock.EXPECT().CurrentLayer().Return(currentLayer)
+		err := atxHdlr.SyntacticallyValidate(context.Background(), atx)
+		require.EqualError(t, err, "rotated vrf key is the smesher id")
+	})
+
 	t.Run("prevAtx declared but initial Post is included", func(t *testing.T) {
 		t.Parallel()
 
@@ -1516,6 +1539,74 @@ func TestHandler_HandleGossipAtx(t *testing.T) {
 	require.NoError(t, atxHdlr.HandleGossipAtx(context.Background(), "", secondData))
 }
 
+func TestHandler_HandleGossipAtxVersions(t *testing.T) {
+	goldenATXID := types.ATXID{2, 3, 4}
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	nipost := newNIPostWithChallenge(t, types.HexToHash32("0x3333"), []byte{0xba, 0xbe})
+	vrfNonce := types.VRFPostIndex(12345)
+	atx := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{
+				PublishEpoch:   2,
+				PositioningATX: goldenATXID,
+				CommitmentATX:  &goldenATXID,
+				InitialPost:    nipost.Post,
+			},
+			Coinbase: types.Address{2, 3, 4},
+			NumUnits: 2,
+			NIPost:   nipost.NIPost,
+			VRFNonce: &vrfNonce,
+		},
+		SmesherID: sig.NodeID(),
+	}
+	atx.SetVersion(types.AtxV2)
+	atx.Signature = sig.Sign(signing.ATX, atx.SignedBytes())
+	require.NoError(t, atx.Initialize())
+	data, err := atx.Blob()
+	require.NoError(t, err)
+
+	t.Run("accepted in configured epoch", func(t *testing.T) {
+		atxHdlr := newTestHandler(t, goldenATXID, WithHandlerAtxVersions(types.AtxVersions{2: types.AtxV2}))
+		atxHdlr.mclock.EXPECT().CurrentLayer().Return(atx.PublishEpoch.FirstLayer())
+		atxHdlr.mValidator.EXPECT().
+			Post(gomock.Any(), sig.NodeID(), goldenATXID, atx.InitialPost, gomock.Any(), atx.NumUnits, gomock.Any())
+		atxHdlr.mValidator.EXPECT().VRFNonce(sig.NodeID(), goldenATXID, &vrfNonce, gomock.Any(), atx.NumUnits)
+		atxHdlr.mockFetch.EXPECT().RegisterPeerHashes(gomock.Any(), gomock.Any())
+		atxHdlr.mockFetch.EXPECT().GetPoetProof(gomock.Any(), atx.GetPoetProofRef())
+		atxHdlr.mValidator.EXPECT().InitialNIPostChallenge(&atx.NIPostChallenge, gomock.Any(), goldenATXID)
+		atxHdlr.mValidator.EXPECT().PositioningAtx(goldenATXID, gomock.Any(), goldenATXID, atx.PublishEpoch)
+		atxHdlr.mValidator.EXPECT().
+			NIPost(gomock.Any(), sig.NodeID(), goldenATXID, atx.NIPost, gomock.Any(), atx.NumUnits, gomock.Any())
+		atxHdlr.mValidator.EXPECT().IsVerifyingFullPost().AnyTimes().Return(true)
+		atxHdlr.mbeacon.EXPECT().OnAtx(gomock.Any())
+		atxHdlr.mtortoise.EXPECT().OnAtx(gomock.Any(), gomock.Any(), gomock.Any())
+		require.NoError(t, atxHdlr.HandleGossipAtx(context.Background(), "", data))
+
+		stored, err := atxs.Get(atxHdlr.cdb, atx.ID())
+		require.NoError(t, err)
+		require.Equal(t, types.AtxV2, stored.Version())
+		blob, err := atxs.GetBlob(context.Background(), atxHdlr.cdb, atx.ID().Bytes())
+		require.NoError(t, err)
+		require.Equal(t, data, blob)
+	})
+	t.Run("rejected before configured epoch", func(t *testing.T) {
+		atxHdlr := newTestHandler(t, goldenATXID, WithHandlerAtxVersions(types.AtxVersions{3: types.AtxV2}))
+		require.ErrorIs(t, atxHdlr.HandleGossipAtx(context.Background(), "", data), pubsub.ErrValidationReject)
+	})
+	t.Run("v1 rejected in configured epoch", func(t *testing.T) {
+		atxHdlr := newTestHandler(t, goldenATXID, WithHandlerAtxVersions(types.AtxVersions{1: types.AtxV2}))
+		nodeID := sig.NodeID()
+		v1 := *atx
+		v1.SetVersion(types.AtxV1)
+		v1.InnerActivationTx.NodeID = &nodeID
+		v1.Signature = sig.Sign(signing.ATX, v1.SignedBytes())
+		v1Data, err := codec.Encode(&v1)
+		require.NoError(t, err)
+		require.ErrorIs(t, atxHdlr.HandleGossipAtx(context.Background(), "", v1Data), pubsub.ErrValidationReject)
+	})
+}
+
 func TestHandler_HandleParallelGossipAtx(t *testing.T) {
 	goldenATXID := types.ATXID{2, 3, 4}
 	atxHdlr := newTestHandler(t, goldenATXID)
@@ -2099,6 +2190,64 @@ func TestHandler_FetchReferences(t *testing.T) {
 		atxHdlr.mockFetch.EXPECT().GetAtxs(gomock.Any(), []types.ATXID{atx.PrevATXID}, gomock.Any()).Return(errors.New("oh"))
 		require.Error(t, atxHdlr.FetchReferences(context.Background(), atx))
 	})
+
+	t.Run("references are fetched with incremented depth", func(t *testing.T) {
+		t.Parallel()
+		atxHdlr := newTestHandler(t, goldenATXID)
+
+		challenge := types.NIPostChallenge{
+			Sequence:       1,
+			PrevATXID:      prevATX,
+			PublishEpoch:   postGenesisEpoch,
+			PositioningATX: prevATX,
+		}
+		nipost := newNIPostWithChallenge(t, types.HexToHash32("55555"), []byte("66666"))
+		atx := newAtx(challenge, nipost.NIPost, 2, types.Address{2, 4, 5})
+
+		ctx := withDependencyDepth(context.Background(), 3)
+		atxHdlr.mockFetch.EXPECT().GetPoetProof(gomock.Any(), atx.GetPoetProofRef()).DoAndReturn(
+			func(ctx context.Context, _ types.Hash32) error {
+				require.Equal(t, 4, dependencyDepth(ctx))
+				return nil
+			})
+		atxHdlr.mockFetch.EXPECT().GetAtxs(gomock.Any(), []types.ATXID{atx.PrevATXID}, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ []types.ATXID, _ ...system.GetAtxOpt) error {
+				require.Equal(t, 4, dependencyDepth(ctx))
+				return nil
+			})
+		require.NoError(t, atxHdlr.FetchReferences(ctx, atx))
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		t.Parallel()
+		atxHdlr := newTestHandler(t, goldenATXID)
+
+		challenge := types.NIPostChallenge{
+			Sequence:       1,
+			PrevATXID:      prevATX,
+			PublishEpoch:   postGenesisEpoch,
+			PositioningATX: posATX,
+		}
+		nipost := newNIPostWithChallenge(t, types.HexToHash32("55555"), []byte("66666"))
+		atx := newAtx(challenge, nipost.NIPost, 2, types.Address{2, 4, 5})
+		ctx := withDependencyDepth(context.Background(), DefaultAtxValidationConfig().DependencyDepth)
+
+		// references are not fetched
+		require.ErrorIs(t, atxHdlr.FetchReferences(ctx, atx), errDependencyDepth)
+
+		ref := types.PoetProofRef(atx.GetPoetProofRef())
+		require.NoError(t, poets.Add(atxHdlr.cdb, ref, []byte("proof"), []byte("service"), "1"))
+		require.ErrorIs(t, atxHdlr.FetchReferences(ctx, atx), errDependencyDepth)
+
+		for _, id := range []types.ATXID{prevATX, posATX} {
+			ref := newAtx(types.NIPostChallenge{PublishEpoch: postGenesisEpoch - 1}, nil, 2, types.Address{})
+			ref.SetID(id)
+			vref, err := ref.Verify(0, 1)
+			require.NoError(t, err)
+			require.NoError(t, atxs.Add(atxHdlr.cdb, vref))
+		}
+		require.NoError(t, atxHdlr.FetchReferences(ctx, atx))
+	})
 }
 
 func TestHandler_AtxWeight(t *testing.T) {
@@ -2317,3 +2466,99 @@ func TestHandler_MarksAtxValid(t *testing.T) {
 	})
 	require.NoError(t, err)
 }
+
+func TestHandler_QuarantineAtx(t *testing.T) {
+	goldenATXID := types.ATXID{2, 3, 4}
+	atxHdlr := newTestHandler(t, goldenATXID)
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	nodeID := sig.NodeID()
+	nipost := newNIPostWithChallenge(t, types.HexToHash32("0x3333"), []byte{0xba, 0xbe})
+	vrfNonce := types.VRFPostIndex(12345)
+	first := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{
+				PublishEpoch:   1,
+				PositioningATX: goldenATXID,
+				CommitmentATX:  &goldenATXID,
+				InitialPost:    nipost.Post,
+			},
+			Coinbase: types.Address{2, 3, 4},
+			NumUnits: 2,
+			NIPost:   nipost.NIPost,
+			NodeID:   &nodeID,
+			VRFNonce: &vrfNonce,
+		},
+		SmesherID: nodeID,
+	}
+	first.Signature = sig.Sign(signing.ATX, first.SignedBytes())
+	firstData, err := codec.Encode(first)
+	require.NoError(t, err)
+	require.NoError(t, first.Initialize())
+
+	second := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{
+				PublishEpoch:   2,
+				Sequence:       1,
+				PrevATXID:      first.ID(),
+				PositioningATX: first.ID(),
+			},
+			Coinbase: types.Address{2, 3, 4},
+			NumUnits: 2,
+			NIPost:   nipost.NIPost,
+		},
+		SmesherID: nodeID,
+	}
+	second.Signature = sig.Sign(signing.ATX, second.SignedBytes())
+	secondData, err := codec.Encode(second)
+	require.NoError(t, err)
+	require.NoError(t, second.Initialize())
+
+	poet := types.PoetProofRef(second.GetPoetProofRef())
+	require.NoError(t, poets.Add(atxHdlr.cdb, poet, []byte("proof"), []byte("service"), "1"))
+	atxHdlr.mValidator.EXPECT().IsVerifyingFullPost().AnyTimes().Return(true)
+	atxHdlr.mockFetch.EXPECT().RegisterPeerHashes(gomock.Any(), gomock.Any()).AnyTimes()
+	atxHdlr.mockFetch.EXPECT().GetPoetProof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	atxHdlr.mclock.EXPECT().CurrentLayer().Return(second.PublishEpoch.FirstLayer())
+	atxHdlr.mockFetch.EXPECT().GetAtxs(gomock.Any(), []types.ATXID{first.ID()}, gomock.Any()).
+		Return(errors.New("not found"))
+	err = atxHdlr.HandleGossipAtx(context.Background(), "peer", secondData)
+	require.ErrorIs(t, err, errMissingReferences)
+
+	quarantined := atxHdlr.Quarantined()
+	require.Len(t, quarantined, 1)
+	require.Equal(t, second.ID(), quarantined[0].ID)
+	require.Equal(t, nodeID, quarantined[0].SmesherID)
+	require.Equal(t, second.PublishEpoch, quarantined[0].PublishEpoch)
+	require.Equal(t, first.ID().Hash32(), quarantined[0].Missing)
+	require.Equal(t, p2p.Peer("peer"), quarantined[0].Peer)
+	require.Equal(t, 1, quarantined[0].Attempts)
+
+	// the second atx is validated again once the first arrives
+	atxHdlr.mclock.EXPECT().CurrentLayer().Return(first.PublishEpoch.FirstLayer())
+	atxHdlr.mValidator.EXPECT().
+		Post(gomock.Any(), nodeID, goldenATXID, first.InitialPost, gomock.Any(), first.NumUnits, gomock.Any())
+	atxHdlr.mValidator.EXPECT().VRFNonce(nodeID, goldenATXID, &vrfNonce, gomock.Any(), first.NumUnits)
+	atxHdlr.mValidator.EXPECT().InitialNIPostChallenge(&first.NIPostChallenge, gomock.Any(), goldenATXID)
+	atxHdlr.mValidator.EXPECT().PositioningAtx(goldenATXID, gomock.Any(), goldenATXID, first.PublishEpoch)
+	atxHdlr.mValidator.EXPECT().
+		NIPost(gomock.Any(), nodeID, goldenATXID, first.NIPost, gomock.Any(), first.NumUnits, gomock.Any())
+
+	atxHdlr.mclock.EXPECT().CurrentLayer().Return(second.PublishEpoch.FirstLayer())
+	atxHdlr.mockFetch.EXPECT().GetAtxs(gomock.Any(), []types.ATXID{first.ID()}, gomock.Any())
+	atxHdlr.mValidator.EXPECT().NIPostChallenge(&second.NIPostChallenge, gomock.Any(), nodeID)
+	atxHdlr.mValidator.EXPECT().
+		NIPost(gomock.Any(), nodeID, goldenATXID, second.NIPost, gomock.Any(), second.NumUnits, gomock.Any())
+	atxHdlr.mValidator.EXPECT().PositioningAtx(second.PositioningATX, gomock.Any(), goldenATXID, second.PublishEpoch)
+	atxHdlr.mbeacon.EXPECT().OnAtx(gomock.Any()).Times(2)
+	atxHdlr.mtortoise.EXPECT().OnAtx(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+	require.NoError(t, atxHdlr.HandleGossipAtx(context.Background(), "", firstData))
+
+	require.Empty(t, atxHdlr.Quarantined())
+	stored, err := atxs.Get(atxHdlr.cdb, second.ID())
+	require.NoError(t, err)
+	require.Equal(t, second.ID(), stored.ID())
+}
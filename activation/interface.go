@@ -10,6 +10,7 @@ import (
 	"github.com/spacemeshos/post/verifying"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
 )
@@ -80,7 +81,7 @@ type layerClock interface {
 }
 
 type nipostBuilder interface {
-	BuildNIPost(ctx context.Context, sig *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error)
+	BuildNIPost(ctx context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error)
 	Proof(ctx context.Context, nodeID types.NodeID, challenge []byte) (*types.Post, *types.PostInfo, error)
 	ResetState(types.NodeID) error
 }
@@ -89,6 +90,23 @@ type syncer interface {
 	RegisterForATXSynced() <-chan struct{}
 }
 
+// hashProber asks up to n peers which of the hashes they have. It returns the number of peers
+// that have each hash and the number of peers that responded.
+type hashProber interface {
+	ProbeHashes(
+		ctx context.Context,
+		hint datastore.Hint,
+		hashes []types.Hash32,
+		n int,
+	) (map[types.Hash32]int, int, error)
+}
+
+// vrfKeyRotator is implemented by signers that derive rotated vrf keys from the identity key.
+type vrfKeyRotator interface {
+	VRFKey(n uint32) types.NodeID
+	UseVRFKey(first types.EpochID, n uint32)
+}
+
 type atxProvider interface {
 	GetAtxHeader(id types.ATXID) (*types.ActivationTxHeader, error)
 }
@@ -113,9 +131,9 @@ type SmeshingProvider interface {
 	SetCoinbase(coinbase types.Address)
 }
 
-// poetClient servers as an interface to communicate with a PoET server.
+// PoetClient servers as an interface to communicate with a PoET server.
 // It is used to submit challenges and fetch proofs.
-type poetClient interface {
+type PoetClient interface {
 	Address() string
 
 	PowParams(ctx context.Context) (*PoetPowParams, error)
@@ -145,7 +163,11 @@ var (
 )
 
 type AtxBuilder interface {
-	Register(sig *signing.EdSigner)
+	Register(sig signing.Signer)
+	PublishForecast(nodeID types.NodeID) (*PublishForecast, error)
+	// ScheduleResize schedules the resize of the post data of the identity. It is called after
+	// the builder publishes the next atx of the identity, before it starts building the following one.
+	ScheduleResize(nodeID types.NodeID, resize func())
 }
 
 type postService interface {
@@ -160,4 +182,6 @@ type PostClient interface {
 type PostStates interface {
 	Set(id types.NodeID, state types.PostState)
 	Get() map[types.NodeID]types.PostState
+	// ProvingDuration returns the duration of the last completed proving of the identity, 0 if unknown.
+	ProvingDuration(id types.NodeID) time.Duration
 }
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+// Phase of the atx pipeline an identity is in.
+type Phase string
+
+const (
+	PhaseInitialPost       Phase = "initial_post"
+	PhaseWaitingForSync    Phase = "waiting_for_sync"
+	PhaseWaitingForRound   Phase = "waiting_for_poet_round"
+	PhasePoetRegistration  Phase = "poet_registration"
+	PhaseWaitingForPoet    Phase = "waiting_for_poet"
+	PhaseProving           Phase = "proving"
+	PhaseWaitingForPublish Phase = "waiting_for_publish_epoch"
+	PhasePublishing        Phase = "publishing"
+)
+
+var phases = []Phase{
+	PhaseInitialPost,
+	PhaseWaitingForSync,
+	PhaseWaitingForRound,
+	PhasePoetRegistration,
+	PhaseWaitingForPoet,
+	PhaseProving,
+	PhaseWaitingForPublish,
+	PhasePublishing,
+}
+
+// Identities tracks the progress of every smeshing identity through the atx pipeline,
+// so that operators of multi-identity nodes can alert on a single identity that is stuck.
+// Identities are labeled with a hash of the node id, node ids are not exposed.
+var Identities = newIdentityCollector()
+
+func init() {
+	prometheus.MustRegister(Identities)
+}
+
+type identityState struct {
+	phase       Phase
+	since       time.Time
+	deadline    time.Time
+	published   types.EpochID
+	isPublished bool
+}
+
+// IdentityCollector is a prometheus Collector for the per-identity state of the atx pipeline.
+type IdentityCollector struct {
+	now func() time.Time
+
+	mu         sync.Mutex
+	identities map[string]*identityState
+
+	phase         *prometheus.Desc
+	phaseSeconds  *prometheus.Desc
+	phaseDeadline *prometheus.Desc
+	publishEpoch  *prometheus.Desc
+}
+
+func newIdentityCollector() *IdentityCollector {
+	return &IdentityCollector{
+		now:        time.Now,
+		identities: map[string]*identityState{},
+		phase: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, namespace, "identity_phase"),
+			"1 for the phase of the atx pipeline the identity is in, 0 for other phases",
+			[]string{"identity", "phase"}, nil),
+		phaseSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, namespace, "identity_phase_seconds"),
+			"time in seconds the identity spent in the current phase",
+			[]string{"identity", "phase"}, nil),
+		phaseDeadline: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, namespace, "identity_phase_deadline_seconds"),
+			"time in seconds left until the deadline of the current phase (poet round start for registration, "+
+				"poet proof deadline while waiting for poet), negative if the deadline passed",
+			[]string{"identity", "phase"}, nil),
+		publishEpoch: prometheus.NewDesc(
+			prometheus.BuildFQName(metrics.Namespace, namespace, "identity_last_publish_epoch"),
+			"publish epoch of the last atx of the identity",
+			[]string{"identity"}, nil),
+	}
+}
+
+// IdentityLabel returns the label that is used for the identity in metrics.
+func IdentityLabel(id types.NodeID) string {
+	sum := hash.Sum(id.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+func (c *IdentityCollector) get(id types.NodeID) *identityState {
+	label := IdentityLabel(id)
+	state, exists := c.identities[label]
+	if !exists {
+		state = &identityState{}
+		c.identities[label] = state
+	}
+	return state
+}
+
+// SetPhase records that the identity entered the phase. Zero deadline means that the phase has no deadline.
+// Time spent in the phase is not reset if the identity is already in this phase.
+func (c *IdentityCollector) SetPhase(id types.NodeID, phase Phase, deadline time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.get(id)
+	if state.phase != phase {
+		state.phase = phase
+		state.since = c.now()
+	}
+	state.deadline = deadline
+}
+
+// SetPublished records the publish epoch of the last atx of the identity.
+func (c *IdentityCollector) SetPublished(id types.NodeID, epoch types.EpochID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.get(id)
+	state.published = epoch
+	state.isPublished = true
+}
+
+// Remove stops reporting metrics for the identity.
+func (c *IdentityCollector) Remove(id types.NodeID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.identities, IdentityLabel(id))
+}
+
+// Describe implements Collector.
+func (c *IdentityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.phase
+	ch <- c.phaseSeconds
+	ch <- c.phaseDeadline
+	ch <- c.publishEpoch
+}
+
+// Collect implements Collector.
+func (c *IdentityCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for label, state := range c.identities {
+		if state.isPublished {
+			ch <- prometheus.MustNewConstMetric(
+				c.publishEpoch, prometheus.GaugeValue, float64(state.published), label)
+		}
+		if state.phase == "" {
+			continue
+		}
+		for _, phase := range phases {
+			value := 0.0
+			if phase == state.phase {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.phase, prometheus.GaugeValue, value, label, string(phase))
+		}
+		ch <- prometheus.MustNewConstMetric(c.phaseSeconds, prometheus.GaugeValue,
+			now.Sub(state.since).Seconds(), label, string(state.phase))
+		if !state.deadline.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.phaseDeadline, prometheus.GaugeValue,
+				state.deadline.Sub(now).Seconds(), label, string(state.phase))
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestIdentityCollector(t *testing.T) {
+	now := time.Now()
+	c := newIdentityCollector()
+	c.now = func() time.Time { return now }
+
+	id := types.RandomNodeID()
+	label := IdentityLabel(id)
+	require.Len(t, label, 16)
+	require.NotContains(t, id.String(), label)
+
+	c.SetPublished(id, 3)
+	c.SetPhase(id, PhaseWaitingForPoet, now.Add(time.Hour))
+	now = now.Add(10 * time.Minute)
+	// time spent in the phase isn't reset when the deadline is updated
+	c.SetPhase(id, PhaseWaitingForPoet, now.Add(30*time.Minute))
+
+	expected := fmt.Sprintf(`
+# HELP spacemesh_activation_identity_last_publish_epoch publish epoch of the last atx of the identity
+# TYPE spacemesh_activation_identity_last_publish_epoch gauge
+spacemesh_activation_identity_last_publish_epoch{identity="%[1]s"} 3
+# HELP spacemesh_activation_identity_phase_deadline_seconds %[2]s
+# TYPE spacemesh_activation_identity_phase_deadline_seconds gauge
+spacemesh_activation_identity_phase_deadline_seconds{identity="%[1]s",phase="waiting_for_poet"} 1800
+# HELP spacemesh_activation_identity_phase_seconds time in seconds the identity spent in the current phase
+# TYPE spacemesh_activation_identity_phase_seconds gauge
+spacemesh_activation_identity_phase_seconds{identity="%[1]s",phase="waiting_for_poet"} 600
+`, label, "time in seconds left until the deadline of the current phase (poet round start for registration, "+
+		"poet proof deadline while waiting for poet), negative if the deadline passed")
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"spacemesh_activation_identity_last_publish_epoch",
+		"spacemesh_activation_identity_phase_deadline_seconds",
+		"spacemesh_activation_identity_phase_seconds",
+	))
+	require.Equal(t, len(phases)+3, testutil.CollectAndCount(c))
+
+	// phase without deadline
+	c.SetPhase(types.RandomNodeID(), PhaseProving, time.Time{})
+	require.Equal(t, 2*len(phases)+4, testutil.CollectAndCount(c))
+
+	c.Remove(id)
+	require.Equal(t, len(phases)+1, testutil.CollectAndCount(c))
+}
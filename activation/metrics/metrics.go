@@ -31,6 +31,112 @@ var PostVerificationQueue = metrics.NewGauge(
 	[]string{},
 ).WithLabelValues()
 
+var AtxValidationQueue = metrics.NewGauge(
+	"atx_validation_waiting_total",
+	namespace,
+	"the number of atxs waiting for a free slot to be syntactically validated",
+	[]string{},
+).WithLabelValues()
+
+var PoetValidationQueue = metrics.NewGauge(
+	"poet_validation_waiting_total",
+	namespace,
+	"the number of poet proofs waiting to be validated",
+	[]string{},
+).WithLabelValues()
+
+var (
+	poetValidations = metrics.NewCounter(
+		"poet_proof_validations",
+		namespace,
+		"poet proofs that were queued for validation, joined a pending validation or dropped on a full queue",
+		[]string{"outcome"},
+	)
+	PoetValidationQueued    = poetValidations.WithLabelValues("queued")
+	PoetValidationDuplicate = poetValidations.WithLabelValues("duplicate")
+	PoetValidationDropped   = poetValidations.WithLabelValues("dropped")
+)
+
+var (
+	poetFraudReports = metrics.NewCounter(
+		"poet_fraud_reports",
+		namespace,
+		"reports of invalid poet proofs generated by the node or received from peers",
+		[]string{"source"},
+	)
+	PoetFraudGenerated = poetFraudReports.WithLabelValues("generated")
+	PoetFraudReceived  = poetFraudReports.WithLabelValues("received")
+)
+
+var (
+	atxDependencies = metrics.NewCounter(
+		"atx_dependencies",
+		namespace,
+		"received atxs whose missing references were fetched, failed to fetch or dropped as too deep",
+		[]string{"outcome"},
+	)
+	AtxDependenciesResolved = atxDependencies.WithLabelValues("resolved")
+	AtxDependenciesFailed   = atxDependencies.WithLabelValues("failed")
+	AtxDependenciesDropped  = atxDependencies.WithLabelValues("dropped")
+)
+
+var AtxQuarantineSize = metrics.NewGauge(
+	"atx_quarantine_size",
+	namespace,
+	"the number of atxs quarantined until their missing references arrive",
+	[]string{},
+).WithLabelValues()
+
+var (
+	atxQuarantine = metrics.NewCounter(
+		"atx_quarantine",
+		namespace,
+		"atxs that were quarantined, released for validation once their reference arrived or evicted",
+		[]string{"outcome"},
+	)
+	AtxQuarantined         = atxQuarantine.WithLabelValues("quarantined")
+	AtxQuarantineReleased  = atxQuarantine.WithLabelValues("released")
+	AtxQuarantineEvicted   = atxQuarantine.WithLabelValues("evicted")
+	AtxQuarantineValidated = atxQuarantine.WithLabelValues("validated")
+)
+
+var (
+	regossipAtxs = metrics.NewCounter(
+		"regossip_atxs",
+		namespace,
+		"atxs of the node that were re-gossiped or skipped because enough peers have them",
+		[]string{"outcome"},
+	)
+	RegossipPublished = regossipAtxs.WithLabelValues("published")
+	RegossipSkipped   = regossipAtxs.WithLabelValues("skipped")
+)
+
+var (
+	receivedAtxs = metrics.NewCounter(
+		"received_atxs",
+		namespace,
+		"received atxs by wire version and outcome of the validation",
+		[]string{"version", "outcome"},
+	)
+	AtxV1Accepted  = receivedAtxs.WithLabelValues("v1", "accepted")
+	AtxV1Rejected  = receivedAtxs.WithLabelValues("v1", "rejected")
+	AtxV1Malformed = receivedAtxs.WithLabelValues("v1", "malformed")
+	AtxV2Accepted  = receivedAtxs.WithLabelValues("v2", "accepted")
+	AtxV2Rejected  = receivedAtxs.WithLabelValues("v2", "rejected")
+	AtxV2Malformed = receivedAtxs.WithLabelValues("v2", "malformed")
+)
+
+var (
+	watchdogAlerts = metrics.NewCounter(
+		"watchdog_alerts",
+		namespace,
+		"alerts about missing and unexpected atxs of the watched identities",
+		[]string{"kind"},
+	)
+	WatchdogMissing    = watchdogAlerts.WithLabelValues("missing")
+	WatchdogUnexpected = watchdogAlerts.WithLabelValues("unexpected")
+)
+
 var (
 	publishWindowLatency = metrics.NewHistogramWithBuckets(
 		"publish_window_seconds",
@@ -15,6 +15,7 @@ import (
 	time "time"
 
 	types "github.com/spacemeshos/go-spacemesh/common/types"
+	datastore "github.com/spacemeshos/go-spacemesh/datastore"
 	signing "github.com/spacemeshos/go-spacemesh/signing"
 	nipost "github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
 	shared "github.com/spacemeshos/post/shared"
@@ -824,7 +825,7 @@ func (m *MocknipostBuilder) EXPECT() *MocknipostBuilderMockRecorder {
 }
 
 // BuildNIPost mocks base method.
-func (m *MocknipostBuilder) BuildNIPost(ctx context.Context, sig *signing.EdSigner, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
+func (m *MocknipostBuilder) BuildNIPost(ctx context.Context, sig signing.Signer, challenge *types.NIPostChallenge) (*nipost.NIPostState, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "BuildNIPost", ctx, sig, challenge)
 	ret0, _ := ret[0].(*nipost.NIPostState)
@@ -851,13 +852,13 @@ func (c *MocknipostBuilderBuildNIPostCall) Return(arg0 *nipost.NIPostState, arg1
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MocknipostBuilderBuildNIPostCall) Do(f func(context.Context, *signing.EdSigner, *types.NIPostChallenge) (*nipost.NIPostState, error)) *MocknipostBuilderBuildNIPostCall {
+func (c *MocknipostBuilderBuildNIPostCall) Do(f func(context.Context, signing.Signer, *types.NIPostChallenge) (*nipost.NIPostState, error)) *MocknipostBuilderBuildNIPostCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MocknipostBuilderBuildNIPostCall) DoAndReturn(f func(context.Context, *signing.EdSigner, *types.NIPostChallenge) (*nipost.NIPostState, error)) *MocknipostBuilderBuildNIPostCall {
+func (c *MocknipostBuilderBuildNIPostCall) DoAndReturn(f func(context.Context, signing.Signer, *types.NIPostChallenge) (*nipost.NIPostState, error)) *MocknipostBuilderBuildNIPostCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -1001,6 +1002,166 @@ func (c *MocksyncerRegisterForATXSyncedCall) DoAndReturn(f func() <-chan struct{
 	return c
 }
 
+// MockhashProber is a mock of hashProber interface.
+type MockhashProber struct {
+	ctrl     *gomock.Controller
+	recorder *MockhashProberMockRecorder
+}
+
+// MockhashProberMockRecorder is the mock recorder for MockhashProber.
+type MockhashProberMockRecorder struct {
+	mock *MockhashProber
+}
+
+// NewMockhashProber creates a new mock instance.
+func NewMockhashProber(ctrl *gomock.Controller) *MockhashProber {
+	mock := &MockhashProber{ctrl: ctrl}
+	mock.recorder = &MockhashProberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockhashProber) EXPECT() *MockhashProberMockRecorder {
+	return m.recorder
+}
+
+// ProbeHashes mocks base method.
+func (m *MockhashProber) ProbeHashes(ctx context.Context, hint datastore.Hint, hashes []types.Hash32, n int) (map[types.Hash32]int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProbeHashes", ctx, hint, hashes, n)
+	ret0, _ := ret[0].(map[types.Hash32]int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ProbeHashes indicates an expected call of ProbeHashes.
+func (mr *MockhashProberMockRecorder) ProbeHashes(ctx, hint, hashes, n any) *MockhashProberProbeHashesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeHashes", reflect.TypeOf((*MockhashProber)(nil).ProbeHashes), ctx, hint, hashes, n)
+	return &MockhashProberProbeHashesCall{Call: call}
+}
+
+// MockhashProberProbeHashesCall wrap *gomock.Call
+type MockhashProberProbeHashesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockhashProberProbeHashesCall) Return(arg0 map[types.Hash32]int, arg1 int, arg2 error) *MockhashProberProbeHashesCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockhashProberProbeHashesCall) Do(f func(context.Context, datastore.Hint, []types.Hash32, int) (map[types.Hash32]int, int, error)) *MockhashProberProbeHashesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockhashProberProbeHashesCall) DoAndReturn(f func(context.Context, datastore.Hint, []types.Hash32, int) (map[types.Hash32]int, int, error)) *MockhashProberProbeHashesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockvrfKeyRotator is a mock of vrfKeyRotator interface.
+type MockvrfKeyRotator struct {
+	ctrl     *gomock.Controller
+	recorder *MockvrfKeyRotatorMockRecorder
+}
+
+// MockvrfKeyRotatorMockRecorder is the mock recorder for MockvrfKeyRotator.
+type MockvrfKeyRotatorMockRecorder struct {
+	mock *MockvrfKeyRotator
+}
+
+// NewMockvrfKeyRotator creates a new mock instance.
+func NewMockvrfKeyRotator(ctrl *gomock.Controller) *MockvrfKeyRotator {
+	mock := &MockvrfKeyRotator{ctrl: ctrl}
+	mock.recorder = &MockvrfKeyRotatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockvrfKeyRotator) EXPECT() *MockvrfKeyRotatorMockRecorder {
+	return m.recorder
+}
+
+// UseVRFKey mocks base method.
+func (m *MockvrfKeyRotator) UseVRFKey(first types.EpochID, n uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UseVRFKey", first, n)
+}
+
+// UseVRFKey indicates an expected call of UseVRFKey.
+func (mr *MockvrfKeyRotatorMockRecorder) UseVRFKey(first, n any) *MockvrfKeyRotatorUseVRFKeyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseVRFKey", reflect.TypeOf((*MockvrfKeyRotator)(nil).UseVRFKey), first, n)
+	return &MockvrfKeyRotatorUseVRFKeyCall{Call: call}
+}
+
+// MockvrfKeyRotatorUseVRFKeyCall wrap *gomock.Call
+type MockvrfKeyRotatorUseVRFKeyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockvrfKeyRotatorUseVRFKeyCall) Return() *MockvrfKeyRotatorUseVRFKeyCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockvrfKeyRotatorUseVRFKeyCall) Do(f func(types.EpochID, uint32)) *MockvrfKeyRotatorUseVRFKeyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockvrfKeyRotatorUseVRFKeyCall) DoAndReturn(f func(types.EpochID, uint32)) *MockvrfKeyRotatorUseVRFKeyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// VRFKey mocks base method.
+func (m *MockvrfKeyRotator) VRFKey(n uint32) types.NodeID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VRFKey", n)
+	ret0, _ := ret[0].(types.NodeID)
+	return ret0
+}
+
+// VRFKey indicates an expected call of VRFKey.
+func (mr *MockvrfKeyRotatorMockRecorder) VRFKey(n any) *MockvrfKeyRotatorVRFKeyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VRFKey", reflect.TypeOf((*MockvrfKeyRotator)(nil).VRFKey), n)
+	return &MockvrfKeyRotatorVRFKeyCall{Call: call}
+}
+
+// MockvrfKeyRotatorVRFKeyCall wrap *gomock.Call
+type MockvrfKeyRotatorVRFKeyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockvrfKeyRotatorVRFKeyCall) Return(arg0 types.NodeID) *MockvrfKeyRotatorVRFKeyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockvrfKeyRotatorVRFKeyCall) Do(f func(uint32) types.NodeID) *MockvrfKeyRotatorVRFKeyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockvrfKeyRotatorVRFKeyCall) DoAndReturn(f func(uint32) types.NodeID) *MockvrfKeyRotatorVRFKeyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockatxProvider is a mock of atxProvider interface.
 type MockatxProvider struct {
 	ctrl     *gomock.Controller
@@ -1487,31 +1648,31 @@ func (c *MockSmeshingProviderStopSmeshingCall) DoAndReturn(f func(bool) error) *
 	return c
 }
 
-// MockpoetClient is a mock of poetClient interface.
-type MockpoetClient struct {
+// MockPoetClient is a mock of PoetClient interface.
+type MockPoetClient struct {
 	ctrl     *gomock.Controller
-	recorder *MockpoetClientMockRecorder
+	recorder *MockPoetClientMockRecorder
 }
 
-// MockpoetClientMockRecorder is the mock recorder for MockpoetClient.
-type MockpoetClientMockRecorder struct {
-	mock *MockpoetClient
+// MockPoetClientMockRecorder is the mock recorder for MockPoetClient.
+type MockPoetClientMockRecorder struct {
+	mock *MockPoetClient
 }
 
-// NewMockpoetClient creates a new mock instance.
-func NewMockpoetClient(ctrl *gomock.Controller) *MockpoetClient {
-	mock := &MockpoetClient{ctrl: ctrl}
-	mock.recorder = &MockpoetClientMockRecorder{mock}
+// NewMockPoetClient creates a new mock instance.
+func NewMockPoetClient(ctrl *gomock.Controller) *MockPoetClient {
+	mock := &MockPoetClient{ctrl: ctrl}
+	mock.recorder = &MockPoetClientMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockpoetClient) EXPECT() *MockpoetClientMockRecorder {
+func (m *MockPoetClient) EXPECT() *MockPoetClientMockRecorder {
 	return m.recorder
 }
 
 // Address mocks base method.
-func (m *MockpoetClient) Address() string {
+func (m *MockPoetClient) Address() string {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Address")
 	ret0, _ := ret[0].(string)
@@ -1519,37 +1680,37 @@ func (m *MockpoetClient) Address() string {
 }
 
 // Address indicates an expected call of Address.
-func (mr *MockpoetClientMockRecorder) Address() *MockpoetClientAddressCall {
+func (mr *MockPoetClientMockRecorder) Address() *MockPoetClientAddressCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockpoetClient)(nil).Address))
-	return &MockpoetClientAddressCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockPoetClient)(nil).Address))
+	return &MockPoetClientAddressCall{Call: call}
 }
 
-// MockpoetClientAddressCall wrap *gomock.Call
-type MockpoetClientAddressCall struct {
+// MockPoetClientAddressCall wrap *gomock.Call
+type MockPoetClientAddressCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockpoetClientAddressCall) Return(arg0 string) *MockpoetClientAddressCall {
+func (c *MockPoetClientAddressCall) Return(arg0 string) *MockPoetClientAddressCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockpoetClientAddressCall) Do(f func() string) *MockpoetClientAddressCall {
+func (c *MockPoetClientAddressCall) Do(f func() string) *MockPoetClientAddressCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockpoetClientAddressCall) DoAndReturn(f func() string) *MockpoetClientAddressCall {
+func (c *MockPoetClientAddressCall) DoAndReturn(f func() string) *MockPoetClientAddressCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // PowParams mocks base method.
-func (m *MockpoetClient) PowParams(ctx context.Context) (*PoetPowParams, error) {
+func (m *MockPoetClient) PowParams(ctx context.Context) (*PoetPowParams, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PowParams", ctx)
 	ret0, _ := ret[0].(*PoetPowParams)
@@ -1558,37 +1719,37 @@ func (m *MockpoetClient) PowParams(ctx context.Context) (*PoetPowParams, error)
 }
 
 // PowParams indicates an expected call of PowParams.
-func (mr *MockpoetClientMockRecorder) PowParams(ctx any) *MockpoetClientPowParamsCall {
+func (mr *MockPoetClientMockRecorder) PowParams(ctx any) *MockPoetClientPowParamsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PowParams", reflect.TypeOf((*MockpoetClient)(nil).PowParams), ctx)
-	return &MockpoetClientPowParamsCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PowParams", reflect.TypeOf((*MockPoetClient)(nil).PowParams), ctx)
+	return &MockPoetClientPowParamsCall{Call: call}
 }
 
-// MockpoetClientPowParamsCall wrap *gomock.Call
-type MockpoetClientPowParamsCall struct {
+// MockPoetClientPowParamsCall wrap *gomock.Call
+type MockPoetClientPowParamsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockpoetClientPowParamsCall) Return(arg0 *PoetPowParams, arg1 error) *MockpoetClientPowParamsCall {
+func (c *MockPoetClientPowParamsCall) Return(arg0 *PoetPowParams, arg1 error) *MockPoetClientPowParamsCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockpoetClientPowParamsCall) Do(f func(context.Context) (*PoetPowParams, error)) *MockpoetClientPowParamsCall {
+func (c *MockPoetClientPowParamsCall) Do(f func(context.Context) (*PoetPowParams, error)) *MockPoetClientPowParamsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockpoetClientPowParamsCall) DoAndReturn(f func(context.Context) (*PoetPowParams, error)) *MockpoetClientPowParamsCall {
+func (c *MockPoetClientPowParamsCall) DoAndReturn(f func(context.Context) (*PoetPowParams, error)) *MockPoetClientPowParamsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // Proof mocks base method.
-func (m *MockpoetClient) Proof(ctx context.Context, roundID string) (*types.PoetProofMessage, []types.Member, error) {
+func (m *MockPoetClient) Proof(ctx context.Context, roundID string) (*types.PoetProofMessage, []types.Member, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Proof", ctx, roundID)
 	ret0, _ := ret[0].(*types.PoetProofMessage)
@@ -1598,37 +1759,37 @@ func (m *MockpoetClient) Proof(ctx context.Context, roundID string) (*types.Poet
 }
 
 // Proof indicates an expected call of Proof.
-func (mr *MockpoetClientMockRecorder) Proof(ctx, roundID any) *MockpoetClientProofCall {
+func (mr *MockPoetClientMockRecorder) Proof(ctx, roundID any) *MockPoetClientProofCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Proof", reflect.TypeOf((*MockpoetClient)(nil).Proof), ctx, roundID)
-	return &MockpoetClientProofCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Proof", reflect.TypeOf((*MockPoetClient)(nil).Proof), ctx, roundID)
+	return &MockPoetClientProofCall{Call: call}
 }
 
-// MockpoetClientProofCall wrap *gomock.Call
-type MockpoetClientProofCall struct {
+// MockPoetClientProofCall wrap *gomock.Call
+type MockPoetClientProofCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockpoetClientProofCall) Return(arg0 *types.PoetProofMessage, arg1 []types.Member, arg2 error) *MockpoetClientProofCall {
+func (c *MockPoetClientProofCall) Return(arg0 *types.PoetProofMessage, arg1 []types.Member, arg2 error) *MockPoetClientProofCall {
 	c.Call = c.Call.Return(arg0, arg1, arg2)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockpoetClientProofCall) Do(f func(context.Context, string) (*types.PoetProofMessage, []types.Member, error)) *MockpoetClientProofCall {
+func (c *MockPoetClientProofCall) Do(f func(context.Context, string) (*types.PoetProofMessage, []types.Member, error)) *MockPoetClientProofCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockpoetClientProofCall) DoAndReturn(f func(context.Context, string) (*types.PoetProofMessage, []types.Member, error)) *MockpoetClientProofCall {
+func (c *MockPoetClientProofCall) DoAndReturn(f func(context.Context, string) (*types.PoetProofMessage, []types.Member, error)) *MockPoetClientProofCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // Submit mocks base method.
-func (m *MockpoetClient) Submit(ctx context.Context, deadline time.Time, prefix, challenge []byte, signature types.EdSignature, nodeID types.NodeID, pow PoetPoW) (*types.PoetRound, error) {
+func (m *MockPoetClient) Submit(ctx context.Context, deadline time.Time, prefix, challenge []byte, signature types.EdSignature, nodeID types.NodeID, pow PoetPoW) (*types.PoetRound, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Submit", ctx, deadline, prefix, challenge, signature, nodeID, pow)
 	ret0, _ := ret[0].(*types.PoetRound)
@@ -1637,31 +1798,31 @@ func (m *MockpoetClient) Submit(ctx context.Context, deadline time.Time, prefix,
 }
 
 // Submit indicates an expected call of Submit.
-func (mr *MockpoetClientMockRecorder) Submit(ctx, deadline, prefix, challenge, signature, nodeID, pow any) *MockpoetClientSubmitCall {
+func (mr *MockPoetClientMockRecorder) Submit(ctx, deadline, prefix, challenge, signature, nodeID, pow any) *MockPoetClientSubmitCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockpoetClient)(nil).Submit), ctx, deadline, prefix, challenge, signature, nodeID, pow)
-	return &MockpoetClientSubmitCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockPoetClient)(nil).Submit), ctx, deadline, prefix, challenge, signature, nodeID, pow)
+	return &MockPoetClientSubmitCall{Call: call}
 }
 
-// MockpoetClientSubmitCall wrap *gomock.Call
-type MockpoetClientSubmitCall struct {
+// MockPoetClientSubmitCall wrap *gomock.Call
+type MockPoetClientSubmitCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockpoetClientSubmitCall) Return(arg0 *types.PoetRound, arg1 error) *MockpoetClientSubmitCall {
+func (c *MockPoetClientSubmitCall) Return(arg0 *types.PoetRound, arg1 error) *MockPoetClientSubmitCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockpoetClientSubmitCall) Do(f func(context.Context, time.Time, []byte, []byte, types.EdSignature, types.NodeID, PoetPoW) (*types.PoetRound, error)) *MockpoetClientSubmitCall {
+func (c *MockPoetClientSubmitCall) Do(f func(context.Context, time.Time, []byte, []byte, types.EdSignature, types.NodeID, PoetPoW) (*types.PoetRound, error)) *MockPoetClientSubmitCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockpoetClientSubmitCall) DoAndReturn(f func(context.Context, time.Time, []byte, []byte, types.EdSignature, types.NodeID, PoetPoW) (*types.PoetRound, error)) *MockpoetClientSubmitCall {
+func (c *MockPoetClientSubmitCall) DoAndReturn(f func(context.Context, time.Time, []byte, []byte, types.EdSignature, types.NodeID, PoetPoW) (*types.PoetRound, error)) *MockPoetClientSubmitCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -1790,8 +1951,47 @@ func (m *MockAtxBuilder) EXPECT() *MockAtxBuilderMockRecorder {
 	return m.recorder
 }
 
+// PublishForecast mocks base method.
+func (m *MockAtxBuilder) PublishForecast(nodeID types.NodeID) (*PublishForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishForecast", nodeID)
+	ret0, _ := ret[0].(*PublishForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishForecast indicates an expected call of PublishForecast.
+func (mr *MockAtxBuilderMockRecorder) PublishForecast(nodeID any) *MockAtxBuilderPublishForecastCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishForecast", reflect.TypeOf((*MockAtxBuilder)(nil).PublishForecast), nodeID)
+	return &MockAtxBuilderPublishForecastCall{Call: call}
+}
+
+// MockAtxBuilderPublishForecastCall wrap *gomock.Call
+type MockAtxBuilderPublishForecastCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAtxBuilderPublishForecastCall) Return(arg0 *PublishForecast, arg1 error) *MockAtxBuilderPublishForecastCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAtxBuilderPublishForecastCall) Do(f func(types.NodeID) (*PublishForecast, error)) *MockAtxBuilderPublishForecastCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAtxBuilderPublishForecastCall) DoAndReturn(f func(types.NodeID) (*PublishForecast, error)) *MockAtxBuilderPublishForecastCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Register mocks base method.
-func (m *MockAtxBuilder) Register(sig *signing.EdSigner) {
+func (m *MockAtxBuilder) Register(sig signing.Signer) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "Register", sig)
 }
@@ -1815,13 +2015,49 @@ func (c *MockAtxBuilderRegisterCall) Return() *MockAtxBuilderRegisterCall {
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockAtxBuilderRegisterCall) Do(f func(*signing.EdSigner)) *MockAtxBuilderRegisterCall {
+func (c *MockAtxBuilderRegisterCall) Do(f func(signing.Signer)) *MockAtxBuilderRegisterCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockAtxBuilderRegisterCall) DoAndReturn(f func(*signing.EdSigner)) *MockAtxBuilderRegisterCall {
+func (c *MockAtxBuilderRegisterCall) DoAndReturn(f func(signing.Signer)) *MockAtxBuilderRegisterCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ScheduleResize mocks base method.
+func (m *MockAtxBuilder) ScheduleResize(nodeID types.NodeID, resize func()) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ScheduleResize", nodeID, resize)
+}
+
+// ScheduleResize indicates an expected call of ScheduleResize.
+func (mr *MockAtxBuilderMockRecorder) ScheduleResize(nodeID, resize any) *MockAtxBuilderScheduleResizeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleResize", reflect.TypeOf((*MockAtxBuilder)(nil).ScheduleResize), nodeID, resize)
+	return &MockAtxBuilderScheduleResizeCall{Call: call}
+}
+
+// MockAtxBuilderScheduleResizeCall wrap *gomock.Call
+type MockAtxBuilderScheduleResizeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAtxBuilderScheduleResizeCall) Return() *MockAtxBuilderScheduleResizeCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAtxBuilderScheduleResizeCall) Do(f func(types.NodeID, func())) *MockAtxBuilderScheduleResizeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAtxBuilderScheduleResizeCall) DoAndReturn(f func(types.NodeID, func())) *MockAtxBuilderScheduleResizeCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -2051,6 +2287,44 @@ func (c *MockPostStatesGetCall) DoAndReturn(f func() map[types.NodeID]types.Post
 	return c
 }
 
+// ProvingDuration mocks base method.
+func (m *MockPostStates) ProvingDuration(id types.NodeID) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvingDuration", id)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// ProvingDuration indicates an expected call of ProvingDuration.
+func (mr *MockPostStatesMockRecorder) ProvingDuration(id any) *MockPostStatesProvingDurationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvingDuration", reflect.TypeOf((*MockPostStates)(nil).ProvingDuration), id)
+	return &MockPostStatesProvingDurationCall{Call: call}
+}
+
+// MockPostStatesProvingDurationCall wrap *gomock.Call
+type MockPostStatesProvingDurationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPostStatesProvingDurationCall) Return(arg0 time.Duration) *MockPostStatesProvingDurationCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPostStatesProvingDurationCall) Do(f func(types.NodeID) time.Duration) *MockPostStatesProvingDurationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPostStatesProvingDurationCall) DoAndReturn(f func(types.NodeID) time.Duration) *MockPostStatesProvingDurationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Set mocks base method.
 func (m *MockPostStates) Set(id types.NodeID, state types.PostState) {
 	m.ctrl.T.Helper()
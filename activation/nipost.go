@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/spacemeshos/merkle-tree"
 	"github.com/spacemeshos/poet/shared"
 	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/activation/metrics"
@@ -46,7 +48,10 @@ const (
 type NIPostBuilder struct {
 	localDB *localsql.Database
 
-	poetProvers map[string]poetClient
+	poetMu      sync.RWMutex
+	poetProvers map[string]PoetClient
+	// poets are fixed if the clients are provided by WithPoetClients.
+	fixedPoets  bool
 	poetDB      poetDbAPI
 	postService postService
 	log         *zap.Logger
@@ -57,13 +62,14 @@ type NIPostBuilder struct {
 
 type NIPostBuilderOption func(*NIPostBuilder)
 
-// withPoetClients allows to pass in clients directly (for testing purposes).
-func withPoetClients(clients []poetClient) NIPostBuilderOption {
+// WithPoetClients sets the clients of the poets, replacing the clients created for the poet servers.
+func WithPoetClients(clients ...PoetClient) NIPostBuilderOption {
 	return func(nb *NIPostBuilder) {
-		nb.poetProvers = make(map[string]poetClient, len(clients))
+		nb.poetProvers = make(map[string]PoetClient, len(clients))
 		for _, client := range clients {
 			nb.poetProvers[client.Address()] = client
 		}
+		nb.fixedPoets = true
 	}
 }
 
@@ -84,13 +90,9 @@ func NewNIPostBuilder(
 	layerClock layerClock,
 	opts ...NIPostBuilderOption,
 ) (*NIPostBuilder, error) {
-	poetClients := make(map[string]poetClient, len(poetServers))
-	for _, server := range poetServers {
-		client, err := NewHTTPPoetClient(server, poetCfg, WithLogger(lg.Named("poet")))
-		if err != nil {
-			return nil, fmt.Errorf("cannot create poet client: %w", err)
-		}
-		poetClients[client.Address()] = client
+	poetClients, err := newPoetClients(poetServers, poetCfg, lg)
+	if err != nil {
+		return nil, err
 	}
 
 	b := &NIPostBuilder{
@@ -111,16 +113,89 @@ func NewNIPostBuilder(
 	return b, nil
 }
 
-func (nb *NIPostBuilder) ResetState(nodeId types.NodeID) error {
-	if err := nipost.ClearPoetRegistrations(nb.localDB, nodeId); err != nil {
-		return fmt.Errorf("clear poet registrations: %w", err)
+func newPoetClients(servers []types.PoetServer, cfg PoetConfig, lg *zap.Logger) (map[string]PoetClient, error) {
+	clients := make(map[string]PoetClient, len(servers))
+	for _, server := range servers {
+		client, err := NewHTTPPoetClient(server, cfg, WithLogger(lg.Named("poet")))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create poet client: %w", err)
+		}
+		clients[client.Address()] = client
+	}
+	return clients, nil
+}
+
+// SetPoetServers replaces the poets that the challenges are submitted to. Proofs are not queried
+// from the removed poets, even for the challenges that were submitted to them before.
+// Returns an error if the poets were fixed with WithPoetClients.
+func (nb *NIPostBuilder) SetPoetServers(servers []types.PoetServer) error {
+	if nb.fixedPoets {
+		return errors.New("poet clients are fixed and can't be replaced")
 	}
-	if err := nipost.RemoveNIPost(nb.localDB, nodeId); err != nil {
-		return fmt.Errorf("remove nipost: %w", err)
+	clients, err := newPoetClients(servers, nb.poetCfg, nb.log)
+	if err != nil {
+		return err
+	}
+	nb.poetMu.Lock()
+	defer nb.poetMu.Unlock()
+	for address, client := range nb.poetProvers {
+		// keep the existing clients, so that their state (e.g. cached poet info) isn't lost
+		if _, exists := clients[address]; exists {
+			clients[address] = client
+		}
 	}
+	nb.poetProvers = clients
+	nb.log.Info("poet servers updated", zap.Strings("addresses", maps.Keys(clients)))
 	return nil
 }
 
+func (nb *NIPostBuilder) poetClients() []PoetClient {
+	nb.poetMu.RLock()
+	defer nb.poetMu.RUnlock()
+	return maps.Values(nb.poetProvers)
+}
+
+// ResetState discards the state of the nipost construction of the identity, keeping the challenge.
+func (nb *NIPostBuilder) ResetState(nodeId types.NodeID) error {
+	if err := nipost.ResetPhase(nb.localDB, nodeId, nipost.PhaseChallenge); err != nil {
+		return fmt.Errorf("reset nipost phase: %w", err)
+	}
+	return nil
+}
+
+// phase returns the phase of the nipost construction for the challenge. The construction of the nipost
+// for a new challenge starts from the challenge phase.
+func (nb *NIPostBuilder) phase(
+	ctx context.Context,
+	nodeID types.NodeID,
+	challenge *types.NIPostChallenge,
+) (nipost.Phase, error) {
+	state, err := nipost.GetPhase(nb.localDB, nodeID)
+	if err != nil {
+		return nipost.PhaseNone, err
+	}
+	switch {
+	case state.Phase == nipost.PhaseNone || state.Phase == nipost.PhasePublished:
+	case state.PublishEpoch != challenge.PublishEpoch:
+		nb.log.Info("nipost phase is for another publish epoch, starting from the challenge",
+			log.ZContext(ctx),
+			log.ZShortStringer("smesherID", nodeID),
+			zap.Stringer("phase", state.Phase),
+			zap.Uint32("phase publish epoch", state.PublishEpoch.Uint32()),
+			zap.Uint32("publish epoch", challenge.PublishEpoch.Uint32()),
+		)
+		if err := nipost.ResetPhase(nb.localDB, nodeID, nipost.PhaseChallenge); err != nil {
+			return nipost.PhaseNone, err
+		}
+	default:
+		return state.Phase, nil
+	}
+	if err := nipost.SetPhase(nb.localDB, nodeID, nipost.PhaseChallenge, challenge.PublishEpoch); err != nil {
+		return nipost.PhaseNone, err
+	}
+	return nipost.PhaseChallenge, nil
+}
+
 func (nb *NIPostBuilder) Proof(
 	ctx context.Context,
 	nodeID types.NodeID,
@@ -177,7 +252,7 @@ func (nb *NIPostBuilder) Proof(
 // publish a proof - a process that takes about an epoch.
 func (nb *NIPostBuilder) BuildNIPost(
 	ctx context.Context,
-	signer *signing.EdSigner,
+	signer signing.Signer,
 	challenge *types.NIPostChallenge,
 ) (*nipost.NIPostState, error) {
 	logger := nb.log.With(log.ZContext(ctx), log.ZShortStringer("smesherID", signer.NodeID()))
@@ -195,18 +270,23 @@ func (nb *NIPostBuilder) BuildNIPost(
 	//                           AVAILABLE               DEADLINE
 
 	publishEpoch := challenge.PublishEpoch
-	poetRoundStart := nb.layerClock.LayerToTime((publishEpoch - 1).FirstLayer()).Add(nb.poetCfg.PhaseShift)
-	poetRoundEnd := nb.layerClock.LayerToTime(publishEpoch.FirstLayer()).
-		Add(nb.poetCfg.PhaseShift).
-		Add(-nb.poetCfg.CycleGap)
+	epochStart := nb.layerClock.LayerToTime((publishEpoch - 1).FirstLayer())
+	publishEpochStart := nb.layerClock.LayerToTime(publishEpoch.FirstLayer())
 
 	// we want to publish before the publish epoch ends or we won't receive rewards
 	publishEpochEnd := nb.layerClock.LayerToTime((publishEpoch + 1).FirstLayer())
 
-	// we want to fetch the PoET proof latest 1 CycleGap before the publish epoch ends
-	// so that a node that is setup correctly (i.e. can generate a PoST proof within the cycle gap)
-	// has enough time left to generate a post proof and publish
-	poetProofDeadline := publishEpochEnd.Add(-nb.poetCfg.CycleGap)
+	// poets may run different schedules: the challenge can be submitted until the latest poet round starts
+	// and the proof can be fetched until the latest deadline of the poets.
+	var poetRoundStart, poetRoundEnd, poetProofDeadline time.Time
+	for _, timing := range nb.poetCfg.timings(nb.poetAddresses()) {
+		poetRoundStart = latest(poetRoundStart, epochStart.Add(timing.PhaseShift))
+		poetRoundEnd = latest(poetRoundEnd, publishEpochStart.Add(timing.PhaseShift).Add(-timing.CycleGap))
+		// we want to fetch the PoET proof latest 1 CycleGap before the publish epoch ends
+		// so that a node that is setup correctly (i.e. can generate a PoST proof within the cycle gap)
+		// has enough time left to generate a post proof and publish
+		poetProofDeadline = latest(poetProofDeadline, publishEpochEnd.Add(-timing.CycleGap))
+	}
 
 	logger.Info("building nipost",
 		zap.Time("poet round start", poetRoundStart),
@@ -216,14 +296,16 @@ func (nb *NIPostBuilder) BuildNIPost(
 		zap.Uint32("target epoch", challenge.TargetEpoch().Uint32()),
 	)
 
-	// Phase 0: Submit challenge to PoET services.
-	count, err := nipost.PoetRegistrationCount(nb.localDB, signer.NodeID())
+	phase, err := nb.phase(ctx, signer.NodeID(), challenge)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get poet registration count: %w", err)
+		return nil, err
 	}
-	if count == 0 {
+
+	// Phase 0: Submit challenge to PoET services.
+	if phase < nipost.PhaseRegistered {
+		metrics.Identities.SetPhase(signer.NodeID(), metrics.PhasePoetRegistration, poetRoundStart)
 		now := time.Now()
-		// Deadline: start of PoET round for publish epoch. PoET won't accept registrations after that.
+		// Deadline: start of the latest PoET round for publish epoch. PoETs won't accept registrations after that.
 		if poetRoundStart.Before(now) {
 			return nil, fmt.Errorf(
 				"%w: poet round has already started at %s (now: %s)",
@@ -235,7 +317,8 @@ func (nb *NIPostBuilder) BuildNIPost(
 
 		submitCtx, cancel := context.WithDeadline(ctx, poetRoundStart)
 		defer cancel()
-		if err := nb.submitPoetChallenges(submitCtx, signer, poetProofDeadline, challenge.Hash().Bytes()); err != nil {
+		err := nb.submitPoetChallenges(submitCtx, signer, epochStart, publishEpochEnd, challenge.Hash().Bytes())
+		if err != nil {
 			return nil, fmt.Errorf("submitting to poets: %w", err)
 		}
 		count, err := nipost.PoetRegistrationCount(nb.localDB, signer.NodeID())
@@ -243,16 +326,32 @@ func (nb *NIPostBuilder) BuildNIPost(
 			return nil, fmt.Errorf("failed to get poet registration count: %w", err)
 		}
 		if count == 0 {
-			return nil, &PoetSvcUnstableError{msg: "failed to submit challenge to any PoET", source: submitCtx.Err()}
+			err := &PoetSvcUnstableError{msg: "failed to submit challenge to any PoET", source: submitCtx.Err()}
+			events.EmitPoetUnreachable(signer.NodeID(), publishEpoch, err)
+			return nil, err
+		}
+		if err := nipost.SetPhase(nb.localDB, signer.NodeID(), nipost.PhaseRegistered, publishEpoch); err != nil {
+			return nil, err
 		}
 	}
 
 	// Phase 1: query PoET services for proofs
-	poetProofRef, membership, err := nipost.PoetProofRef(nb.localDB, signer.NodeID())
-	if err != nil && !errors.Is(err, sql.ErrNotFound) {
-		nb.log.Warn("cannot get poet proof ref", zap.Error(err))
+	var (
+		poetProofRef types.PoetProofRef
+		membership   *types.MerkleProof
+	)
+	if phase >= nipost.PhaseProofAvailable {
+		poetProofRef, membership, err = nipost.PoetProofRef(nb.localDB, signer.NodeID())
+		if err != nil {
+			logger.Warn("cannot get poet proof ref, querying poets again", zap.Stringer("phase", phase), zap.Error(err))
+			if err := nipost.ResetPhase(nb.localDB, signer.NodeID(), nipost.PhaseRegistered); err != nil {
+				return nil, err
+			}
+			phase = nipost.PhaseRegistered
+		}
 	}
-	if poetProofRef == types.EmptyPoetProofRef {
+	if phase < nipost.PhaseProofAvailable {
+		metrics.Identities.SetPhase(signer.NodeID(), metrics.PhaseWaitingForPoet, poetProofDeadline)
 		now := time.Now()
 		// Deadline: the end of the publish epoch minus the cycle gap. A node that is setup correctly (i.e. can
 		// generate a PoST proof within the cycle gap) has enough time left to generate a post proof and publish.
@@ -274,17 +373,29 @@ func (nb *NIPostBuilder) BuildNIPost(
 		if poetProofRef == types.EmptyPoetProofRef {
 			return nil, &PoetSvcUnstableError{source: ErrPoetProofNotReceived}
 		}
-		if err := nipost.UpdatePoetProofRef(nb.localDB, signer.NodeID(), poetProofRef, membership); err != nil {
+		if err := nb.localDB.WithTx(ctx, func(tx *sql.Tx) error {
+			if err := nipost.UpdatePoetProofRef(tx, signer.NodeID(), poetProofRef, membership); err != nil {
+				return err
+			}
+			return nipost.SetPhase(tx, signer.NodeID(), nipost.PhaseProofAvailable, publishEpoch)
+		}); err != nil {
 			nb.log.Warn("cannot persist poet proof ref", zap.Error(err))
 		}
 	}
 
 	// Phase 2: Post execution.
-	nipostState, err := nipost.NIPost(nb.localDB, signer.NodeID())
-	if err != nil && !errors.Is(err, sql.ErrNotFound) {
-		nb.log.Warn("cannot get nipost", zap.Error(err))
+	var nipostState *nipost.NIPostState
+	if phase >= nipost.PhasePostBuilt {
+		nipostState, err = nipost.NIPost(nb.localDB, signer.NodeID())
+		if err != nil {
+			logger.Warn("cannot get nipost, building it again", zap.Stringer("phase", phase), zap.Error(err))
+			if err := nipost.ResetPhase(nb.localDB, signer.NodeID(), nipost.PhaseProofAvailable); err != nil {
+				return nil, err
+			}
+		}
 	}
 	if nipostState == nil {
+		metrics.Identities.SetPhase(signer.NodeID(), metrics.PhaseProving, publishEpochEnd)
 		now := time.Now()
 		// Deadline: the end of the publish epoch. If we do not publish within
 		// the publish epoch we won't receive any rewards in the target epoch.
@@ -323,7 +434,12 @@ func (nb *NIPostBuilder) BuildNIPost(
 			NumUnits: postInfo.NumUnits,
 			VRFNonce: *postInfo.Nonce,
 		}
-		if err := nipost.AddNIPost(nb.localDB, signer.NodeID(), nipostState); err != nil {
+		if err := nb.localDB.WithTx(ctx, func(tx *sql.Tx) error {
+			if err := nipost.AddNIPost(tx, signer.NodeID(), nipostState); err != nil {
+				return err
+			}
+			return nipost.SetPhase(tx, signer.NodeID(), nipost.PhasePostBuilt, publishEpoch)
+		}); err != nil {
 			nb.log.Warn("cannot persist nipost state", zap.Error(err))
 		}
 	}
@@ -346,7 +462,7 @@ func (nb *NIPostBuilder) submitPoetChallenge(
 	ctx context.Context,
 	nodeID types.NodeID,
 	deadline time.Time,
-	client poetClient,
+	client PoetClient,
 	prefix, challenge []byte,
 	signature types.EdSignature,
 ) error {
@@ -399,21 +515,30 @@ func (nb *NIPostBuilder) submitPoetChallenge(
 	})
 }
 
-// Submit the challenge to all registered PoETs.
+// Submit the challenge to all registered PoETs. The challenge is submitted to every PoET before its round starts
+// in the epoch and the PoET is asked to keep the proof until the deadline derived from its cycle gap.
 func (nb *NIPostBuilder) submitPoetChallenges(
 	ctx context.Context,
-	signer *signing.EdSigner,
-	deadline time.Time,
+	signer signing.Signer,
+	epochStart, publishEpochEnd time.Time,
 	challenge []byte,
 ) error {
-	signature := signer.Sign(signing.POET, challenge)
+	signature, err := signer.SignContext(ctx, signing.POET, challenge)
+	if err != nil {
+		return fmt.Errorf("sign poet challenge: %w", err)
+	}
 	prefix := bytes.Join([][]byte{signer.Prefix(), {byte(signing.POET)}}, nil)
 	nodeID := signer.NodeID()
 	g, ctx := errgroup.WithContext(ctx)
-	errChan := make(chan error, len(nb.poetProvers))
-	for _, poetClient := range nb.poetProvers {
+	clients := nb.poetClients()
+	errChan := make(chan error, len(clients))
+	for _, poetClient := range clients {
 		client := poetClient
+		timing := nb.poetCfg.Timing(client.Address())
 		g.Go(func() error {
+			ctx, cancel := context.WithDeadline(ctx, epochStart.Add(timing.PhaseShift))
+			defer cancel()
+			deadline := publishEpochEnd.Add(-timing.CycleGap)
 			errChan <- nb.submitPoetChallenge(ctx, nodeID, deadline, client, prefix, challenge, signature)
 			return nil
 		})
@@ -440,13 +565,16 @@ func (nb *NIPostBuilder) submitPoetChallenges(
 	return nil
 }
 
-func (nb *NIPostBuilder) getPoetClient(ctx context.Context, address string) poetClient {
-	for _, client := range nb.poetProvers {
-		if address == client.Address() {
-			return client
-		}
-	}
-	return nil
+func (nb *NIPostBuilder) poetAddresses() []string {
+	nb.poetMu.RLock()
+	defer nb.poetMu.RUnlock()
+	return maps.Keys(nb.poetProvers)
+}
+
+func (nb *NIPostBuilder) getPoetClient(ctx context.Context, address string) PoetClient {
+	nb.poetMu.RLock()
+	defer nb.poetMu.RUnlock()
+	return nb.poetProvers[address]
 }
 
 // membersContainChallenge verifies that the challenge is included in proof's members.
@@ -489,7 +617,7 @@ func (nb *NIPostBuilder) getBestProof(
 			continue
 		}
 		round := r.RoundID
-		waitDeadline := proofDeadline(r.RoundEnd, nb.poetCfg.CycleGap)
+		waitDeadline := proofDeadline(r.RoundEnd, nb.poetCfg.Timing(r.Address).CycleGap)
 		eg.Go(func() error {
 			logger.Info("waiting until poet round end", zap.Duration("wait time", time.Until(waitDeadline)))
 			select {
@@ -592,6 +720,13 @@ func randomDurationInRange(min, max time.Duration) time.Duration {
 	return min + time.Duration(rand.Int63n(int64(max-min+1)))
 }
 
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
 // Calculate the time to wait before querying for the proof
 // We add a jitter to avoid all nodes querying for the proof at the same time.
 func proofDeadline(roundEnd time.Time, cycleGap time.Duration) (waitTime time.Time) {
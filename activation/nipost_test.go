@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,8 +25,8 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
 )
 
-func defaultPoetServiceMock(ctrl *gomock.Controller, id []byte, address string) *MockpoetClient {
-	poetClient := NewMockpoetClient(ctrl)
+func defaultPoetServiceMock(ctrl *gomock.Controller, id []byte, address string) *MockPoetClient {
+	poetClient := NewMockPoetClient(ctrl)
 	poetClient.EXPECT().
 		Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		AnyTimes().
@@ -410,7 +411,7 @@ func Test_NIPostBuilder_WithMocks(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients([]poetClient{poetProvider}),
+		WithPoetClients(poetProvider),
 	)
 	require.NoError(t, err)
 
@@ -452,7 +453,7 @@ func TestPostSetup(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients([]poetClient{poetProvider}),
+		WithPoetClients(poetProvider),
 	)
 	require.NoError(t, err)
 
@@ -516,18 +517,18 @@ func TestNIPostBuilder_BuildNIPost(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients([]poetClient{poetProver}),
+		WithPoetClients(poetProver),
 	)
 	require.NoError(t, err)
 
-	nipost, err := nb.BuildNIPost(context.Background(), sig, &challenge)
+	state, err := nb.BuildNIPost(context.Background(), sig, &challenge)
 	require.NoError(t, err)
-	require.NotNil(t, nipost)
+	require.NotNil(t, state)
 
 	poetDb = NewMockpoetDbAPI(ctrl)
 
 	// fail post exec
-	require.NoError(t, nb.ResetState(sig.NodeID()))
+	require.NoError(t, nipost.ResetPhase(db, sig.NodeID(), nipost.PhaseProofAvailable))
 	nb, err = NewNIPostBuilder(
 		db,
 		poetDb,
@@ -536,15 +537,15 @@ func TestNIPostBuilder_BuildNIPost(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients([]poetClient{poetProver}),
+		WithPoetClients(poetProver),
 	)
 	require.NoError(t, err)
 
 	postClient.EXPECT().Proof(gomock.Any(), gomock.Any()).Return(nil, nil, fmt.Errorf("error"))
 
 	// check that proof ref is not called again
-	nipost, err = nb.BuildNIPost(context.Background(), sig, &challenge)
-	require.Nil(t, nipost)
+	state, err = nb.BuildNIPost(context.Background(), sig, &challenge)
+	require.Nil(t, state)
 	require.Error(t, err)
 
 	// successful post exec
@@ -556,7 +557,7 @@ func TestNIPostBuilder_BuildNIPost(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients([]poetClient{poetProver}),
+		WithPoetClients(poetProver),
 	)
 	require.NoError(t, err)
 	postClient.EXPECT().Proof(gomock.Any(), gomock.Any()).Return(
@@ -568,9 +569,9 @@ func TestNIPostBuilder_BuildNIPost(t *testing.T) {
 	)
 
 	// check that proof ref is not called again
-	nipost, err = nb.BuildNIPost(context.Background(), sig, &challenge)
+	state, err = nb.BuildNIPost(context.Background(), sig, &challenge)
 	require.NoError(t, err)
-	require.NotNil(t, nipost)
+	require.NotNil(t, state)
 }
 
 func Test_NIPostBuilder_InvalidPoetAddresses(t *testing.T) {
@@ -587,6 +588,35 @@ func Test_NIPostBuilder_InvalidPoetAddresses(t *testing.T) {
 	require.Nil(t, nb)
 }
 
+func Test_NIPostBuilder_SetPoetServers(t *testing.T) {
+	nb, err := NewNIPostBuilder(
+		nil,
+		nil,
+		nil,
+		[]types.PoetServer{{Address: "http://poet1"}, {Address: "http://poet2"}},
+		zaptest.NewLogger(t).Named("nipostBuilder"),
+		PoetConfig{},
+		nil,
+	)
+	require.NoError(t, err)
+	poet2 := nb.getPoetClient(context.Background(), "http://poet2")
+	require.NotNil(t, poet2)
+
+	require.NoError(t, nb.SetPoetServers([]types.PoetServer{{Address: "http://poet2"}, {Address: "http://poet3"}}))
+	require.ElementsMatch(t, []string{"http://poet2", "http://poet3"}, nb.poetAddresses())
+	require.Nil(t, nb.getPoetClient(context.Background(), "http://poet1"))
+	require.Same(t, poet2, nb.getPoetClient(context.Background(), "http://poet2"))
+
+	require.ErrorContains(t, nb.SetPoetServers([]types.PoetServer{{Address: ":invalid"}}), "cannot create poet client")
+	require.Len(t, nb.poetAddresses(), 2)
+
+	proxy := NewMockPoetClient(gomock.NewController(t))
+	proxy.EXPECT().Address().Return("http://proxy").AnyTimes()
+	fixed, err := NewNIPostBuilder(nil, nil, nil, nil, zaptest.NewLogger(t), PoetConfig{}, nil, WithPoetClients(proxy))
+	require.NoError(t, err)
+	require.Error(t, fixed.SetPoetServers([]types.PoetServer{{Address: "http://poet1"}}))
+}
+
 func TestNIPostBuilder_ManyPoETs_SubmittingChallenge_DeadlineReached(t *testing.T) {
 	t.Parallel()
 	// Arrange
@@ -601,9 +631,9 @@ func TestNIPostBuilder_ManyPoETs_SubmittingChallenge_DeadlineReached(t *testing.
 	poetDb.EXPECT().ValidateAndStore(gomock.Any(), gomock.Any()).Return(nil)
 	mclock := defaultLayerClockMock(ctrl)
 
-	poets := make([]poetClient, 0, 2)
+	poets := make([]PoetClient, 0, 2)
 	{
-		poet := NewMockpoetClient(ctrl)
+		poet := NewMockPoetClient(ctrl)
 		poet.EXPECT().
 			Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			DoAndReturn(func(
@@ -622,7 +652,7 @@ func TestNIPostBuilder_ManyPoETs_SubmittingChallenge_DeadlineReached(t *testing.
 		poets = append(poets, poet)
 	}
 	{
-		poet := NewMockpoetClient(ctrl)
+		poet := NewMockPoetClient(ctrl)
 		poet.EXPECT().
 			Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(&types.PoetRound{}, nil)
@@ -657,7 +687,7 @@ func TestNIPostBuilder_ManyPoETs_SubmittingChallenge_DeadlineReached(t *testing.
 		zaptest.NewLogger(t),
 		poetCfg,
 		mclock,
-		withPoetClients(poets),
+		WithPoetClients(poets...),
 	)
 	require.NoError(t, err)
 
@@ -670,6 +700,97 @@ func TestNIPostBuilder_ManyPoETs_SubmittingChallenge_DeadlineReached(t *testing.
 	require.Equal(t, ref[:], nipost.PostMetadata.Challenge)
 }
 
+func TestNIPostBuilder_ManyPoETs_TimingOverrides(t *testing.T) {
+	t.Parallel()
+	challenge := types.NIPostChallenge{
+		PublishEpoch: postGenesisEpoch + 1,
+	}
+
+	ctrl := gomock.NewController(t)
+	genesis := time.Now().Add(-time.Duration(postGenesisEpoch.FirstLayer()) * layerDuration)
+	mclock := NewMocklayerClock(ctrl)
+	mclock.EXPECT().LayerToTime(gomock.Any()).AnyTimes().DoAndReturn(
+		func(got types.LayerID) time.Time {
+			return genesis.Add(layerDuration * time.Duration(got))
+		},
+	)
+	epochStart := genesis.Add(layerDuration * time.Duration(postGenesisEpoch.FirstLayer()))
+	publishEpochEnd := genesis.Add(layerDuration * time.Duration((challenge.PublishEpoch + 1).FirstLayer()))
+
+	poetCfg := PoetConfig{
+		PhaseShift: layerDuration * layersPerEpoch / 2,
+		CycleGap:   layerDuration,
+		Overrides: []PoetOverride{{
+			Address: "http://localhost:9998",
+			PoetTiming: PoetTiming{
+				PhaseShift: layerDuration * layersPerEpoch / 4,
+				CycleGap:   2 * layerDuration,
+			},
+		}},
+	}
+
+	type submission struct {
+		submitBy      time.Time
+		proofDeadline time.Time
+	}
+	var (
+		mu          sync.Mutex
+		submissions = map[string]submission{}
+	)
+	poets := make([]PoetClient, 0, 2)
+	for _, address := range []string{"http://localhost:9999", "http://localhost:9998"} {
+		address := address
+		poet := NewMockPoetClient(ctrl)
+		poet.EXPECT().
+			Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				deadline time.Time,
+				_, _ []byte,
+				_ types.EdSignature,
+				_ types.NodeID,
+				_ PoetPoW,
+			) (*types.PoetRound, error) {
+				submitBy, ok := ctx.Deadline()
+				require.True(t, ok)
+				mu.Lock()
+				defer mu.Unlock()
+				submissions[address] = submission{submitBy: submitBy, proofDeadline: deadline}
+				return nil, errors.New("test")
+			})
+		poet.EXPECT().PowParams(gomock.Any()).Return(&PoetPowParams{}, nil)
+		poet.EXPECT().Address().AnyTimes().Return(address)
+		poets = append(poets, poet)
+	}
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	nb, err := NewNIPostBuilder(
+		localsql.InMemory(),
+		NewMockpoetDbAPI(ctrl),
+		NewMockpostService(ctrl),
+		[]types.PoetServer{},
+		zaptest.NewLogger(t),
+		poetCfg,
+		mclock,
+		WithPoetClients(poets...),
+	)
+	require.NoError(t, err)
+
+	_, err = nb.BuildNIPost(context.Background(), sig, &challenge)
+	require.ErrorIs(t, err, ErrPoetServiceUnstable)
+	require.Equal(t, map[string]submission{
+		"http://localhost:9999": {
+			submitBy:      epochStart.Add(layerDuration * layersPerEpoch / 2),
+			proofDeadline: publishEpochEnd.Add(-layerDuration),
+		},
+		"http://localhost:9998": {
+			submitBy:      epochStart.Add(layerDuration * layersPerEpoch / 4),
+			proofDeadline: publishEpochEnd.Add(-2 * layerDuration),
+		},
+	}, submissions)
+}
+
 func TestNIPostBuilder_ManyPoETs_AllFinished(t *testing.T) {
 	t.Parallel()
 
@@ -694,7 +815,7 @@ func TestNIPostBuilder_ManyPoETs_AllFinished(t *testing.T) {
 	poetDb.EXPECT().ValidateAndStore(gomock.Any(), gomock.Any()).Times(2).Return(nil)
 	mclock := defaultLayerClockMock(ctrl)
 
-	poets := make([]poetClient, 0, 2)
+	poets := make([]PoetClient, 0, 2)
 	{
 		poet := defaultPoetServiceMock(ctrl, []byte("poet0"), "http://localhost:9999")
 		poet.EXPECT().Proof(gomock.Any(), "").Return(proofWorse, []types.Member{types.Member(challenge.Hash())}, nil)
@@ -725,7 +846,7 @@ func TestNIPostBuilder_ManyPoETs_AllFinished(t *testing.T) {
 		zaptest.NewLogger(t),
 		PoetConfig{},
 		mclock,
-		withPoetClients(poets),
+		WithPoetClients(poets...),
 	)
 	require.NoError(t, err)
 
@@ -755,7 +876,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		poetDb := NewMockpoetDbAPI(ctrl)
 		mclock := defaultLayerClockMock(ctrl)
-		poetProver := NewMockpoetClient(ctrl)
+		poetProver := NewMockPoetClient(ctrl)
 		poetProver.EXPECT().
 			Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil, errors.New("test"))
@@ -771,7 +892,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 			zaptest.NewLogger(t),
 			poetCfg,
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 
@@ -784,7 +905,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		poetDb := NewMockpoetDbAPI(ctrl)
 		mclock := defaultLayerClockMock(ctrl)
-		poetProver := NewMockpoetClient(ctrl)
+		poetProver := NewMockPoetClient(ctrl)
 		poetProver.EXPECT().
 			Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			DoAndReturn(func(
@@ -810,7 +931,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 			zaptest.NewLogger(t),
 			poetCfg,
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 		nipst, err := nb.BuildNIPost(context.Background(), sig, &challenge)
@@ -834,7 +955,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 			zaptest.NewLogger(t),
 			poetCfg,
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 		nipst, err := nb.BuildNIPost(context.Background(), sig, &challenge)
@@ -861,7 +982,7 @@ func TestNIPSTBuilder_PoetUnstable(t *testing.T) {
 			zaptest.NewLogger(t),
 			poetCfg,
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 		nipst, err := nb.BuildNIPost(context.Background(), sig, &challenge)
@@ -886,7 +1007,7 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		poetDb := NewMockpoetDbAPI(ctrl)
 		mclock := NewMocklayerClock(ctrl)
-		poetProver := NewMockpoetClient(ctrl)
+		poetProver := NewMockPoetClient(ctrl)
 		poetProver.EXPECT().Address().Return("http://localhost:9999")
 		mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(
 			func(got types.LayerID) time.Time {
@@ -903,7 +1024,7 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 			zaptest.NewLogger(t),
 			PoetConfig{},
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 
@@ -917,7 +1038,7 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		poetDb := NewMockpoetDbAPI(ctrl)
 		mclock := NewMocklayerClock(ctrl)
-		poetProver := NewMockpoetClient(ctrl)
+		poetProver := NewMockPoetClient(ctrl)
 		poetProver.EXPECT().Address().Return("http://localhost:9999")
 		mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(
 			func(got types.LayerID) time.Time {
@@ -934,13 +1055,14 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 			zaptest.NewLogger(t),
 			PoetConfig{},
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 
 		challenge := &types.NIPostChallenge{PublishEpoch: currLayer.GetEpoch() - 1}
 		err = nipost.AddChallenge(db, sig.NodeID(), challenge)
 		require.NoError(t, err)
+		require.NoError(t, nipost.SetPhase(db, sig.NodeID(), nipost.PhaseChallenge, challenge.PublishEpoch))
 
 		// successfully registered to at least one poet
 		err = nipost.AddPoetRegistration(db, sig.NodeID(), nipost.PoETRegistration{
@@ -950,6 +1072,7 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 			RoundEnd:      time.Now().Add(10 * time.Second),
 		})
 		require.NoError(t, err)
+		require.NoError(t, nipost.SetPhase(db, sig.NodeID(), nipost.PhaseRegistered, challenge.PublishEpoch))
 
 		nipost, err := nb.BuildNIPost(context.Background(), sig, challenge)
 		require.ErrorIs(t, err, ErrATXChallengeExpired)
@@ -960,7 +1083,7 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		poetDb := NewMockpoetDbAPI(ctrl)
 		mclock := NewMocklayerClock(ctrl)
-		poetProver := NewMockpoetClient(ctrl)
+		poetProver := NewMockPoetClient(ctrl)
 		poetProver.EXPECT().Address().Return("http://localhost:9999")
 		mclock.EXPECT().LayerToTime(gomock.Any()).DoAndReturn(
 			func(got types.LayerID) time.Time {
@@ -977,13 +1100,14 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 			zaptest.NewLogger(t),
 			PoetConfig{},
 			mclock,
-			withPoetClients([]poetClient{poetProver}),
+			WithPoetClients(poetProver),
 		)
 		require.NoError(t, err)
 
 		challenge := &types.NIPostChallenge{PublishEpoch: currLayer.GetEpoch() - 1}
 		err = nipost.AddChallenge(db, sig.NodeID(), challenge)
 		require.NoError(t, err)
+		require.NoError(t, nipost.SetPhase(db, sig.NodeID(), nipost.PhaseChallenge, challenge.PublishEpoch))
 
 		// successfully registered to at least one poet
 		err = nipost.AddPoetRegistration(db, sig.NodeID(), nipost.PoETRegistration{
@@ -993,10 +1117,12 @@ func TestNIPoSTBuilder_StaleChallenge(t *testing.T) {
 			RoundEnd:      time.Now().Add(10 * time.Second),
 		})
 		require.NoError(t, err)
+		require.NoError(t, nipost.SetPhase(db, sig.NodeID(), nipost.PhaseRegistered, challenge.PublishEpoch))
 
 		// received a proof from poet
 		err = nipost.UpdatePoetProofRef(db, sig.NodeID(), [32]byte{1, 2, 3}, &types.MerkleProof{})
 		require.NoError(t, err)
+		require.NoError(t, nipost.SetPhase(db, sig.NodeID(), nipost.PhaseProofAvailable, challenge.PublishEpoch))
 
 		nipost, err := nb.BuildNIPost(context.Background(), sig, challenge)
 		require.ErrorIs(t, err, ErrATXChallengeExpired)
@@ -1028,7 +1154,7 @@ func TestNIPoSTBuilder_Continues_After_Interrupted(t *testing.T) {
 
 	buildCtx, cancel := context.WithCancel(context.Background())
 
-	poet := NewMockpoetClient(ctrl)
+	poet := NewMockPoetClient(ctrl)
 	poet.EXPECT().
 		Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		DoAndReturn(func(
@@ -1069,7 +1195,7 @@ func TestNIPoSTBuilder_Continues_After_Interrupted(t *testing.T) {
 		zaptest.NewLogger(t),
 		poetCfg,
 		mclock,
-		withPoetClients([]poetClient{poet}),
+		WithPoetClients(poet),
 	)
 	require.NoError(t, err)
 
@@ -1158,9 +1284,9 @@ func TestNIPostBuilder_Mainnet_Poet_Workaround(t *testing.T) {
 			}
 
 			ctrl := gomock.NewController(t)
-			poets := make([]poetClient, 0, 2)
+			poets := make([]PoetClient, 0, 2)
 			{
-				poetProvider := NewMockpoetClient(ctrl)
+				poetProvider := NewMockPoetClient(ctrl)
 				poetProvider.EXPECT().Address().Return(tc.from)
 				poetProvider.EXPECT().PowParams(gomock.Any()).AnyTimes().Return(&PoetPowParams{}, nil)
 
@@ -1178,7 +1304,7 @@ func TestNIPostBuilder_Mainnet_Poet_Workaround(t *testing.T) {
 
 			{
 				// PoET fails submission
-				poetProvider := NewMockpoetClient(ctrl)
+				poetProvider := NewMockPoetClient(ctrl)
 				poetProvider.EXPECT().Address().Return(tc.to)
 
 				// proof is still fetched from PoET
@@ -1219,7 +1345,7 @@ func TestNIPostBuilder_Mainnet_Poet_Workaround(t *testing.T) {
 				zaptest.NewLogger(t),
 				poetCfg,
 				mclock,
-				withPoetClients(poets),
+				WithPoetClients(poets...),
 			)
 			require.NoError(t, err)
 
@@ -0,0 +1,107 @@
+package activation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+)
+
+// verifyPoetSignature returns true if the proof is signed by the poet service it claims to be released by.
+func verifyPoetSignature(msg *types.PoetProofMessage) bool {
+	if len(msg.PoetServiceID) != ed25519.PublicKeySize || msg.Signature == types.EmptyEdSignature {
+		return false
+	}
+	signed, err := msg.SignedBytes()
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(msg.PoetServiceID, signed, msg.Signature.Bytes())
+}
+
+func (db *PoetDb) isReported(ref types.PoetProofRef) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	_, exists := db.reported[ref]
+	return exists
+}
+
+// markReported returns false if the proof was already reported.
+func (db *PoetDb) markReported(ref types.PoetProofRef) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, exists := db.reported[ref]; exists {
+		return false
+	}
+	db.reported[ref] = struct{}{}
+	return true
+}
+
+// reportFraud gossips a report for the invalid proof, if it is signed by the poet service.
+// Unsigned proofs can't be attributed to the poet and are not reported.
+func (db *PoetDb) reportFraud(ctx context.Context, ref types.PoetProofRef, msg *types.PoetProofMessage, reason error) {
+	if db.publisher == nil || !verifyPoetSignature(msg) || !db.markReported(ref) {
+		return
+	}
+	logger := db.log.WithContext(ctx).WithFields(
+		log.String("poet_proof_id", fmt.Sprintf("%x", ref[:5])),
+		log.String("poet_service_id", fmt.Sprintf("%x", msg.PoetServiceID[:5])),
+		log.String("round_id", msg.RoundID),
+	)
+	data, err := codec.Encode(&types.PoetFraudReport{Proof: *msg})
+	if err != nil {
+		logger.With().Fatal("failed to encode poet fraud report", log.Err(err))
+	}
+	if err := db.publisher.Publish(ctx, pubsub.PoetFraudProtocol, data); err != nil {
+		logger.With().Warning("failed to publish poet fraud report", log.Err(err))
+		return
+	}
+	metrics.PoetFraudGenerated.Inc()
+	logger.With().Warning("reported invalid proof signed by poet service", log.String("reason", reason.Error()))
+}
+
+// HandleFraudReport is the gossip receiver for reports of invalid poet proofs.
+// The report is accepted only if the proof is signed by the poet service and fails the validation.
+func (db *PoetDb) HandleFraudReport(ctx context.Context, _ peer.ID, data []byte) error {
+	var report types.PoetFraudReport
+	if err := codec.Decode(data, &report); err != nil {
+		return fmt.Errorf("%w: decode poet fraud report: %w", pubsub.ErrValidationReject, err)
+	}
+	msg := &report.Proof
+	if !verifyPoetSignature(msg) {
+		return fmt.Errorf("%w: poet fraud report with invalid signature", pubsub.ErrValidationReject)
+	}
+	ref, err := msg.Ref()
+	if err != nil {
+		return fmt.Errorf("%w: %w", pubsub.ErrValidationReject, err)
+	}
+	if db.isReported(ref) {
+		return nil
+	}
+	err = db.Validate(msg.Statement[:], msg.PoetProof, msg.PoetServiceID, msg.RoundID, msg.Signature)
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: reported poet proof %x is valid", pubsub.ErrValidationReject, ref[:5])
+	case errors.As(err, &types.ProcessingError{}):
+		return fmt.Errorf("%w: %w", pubsub.ErrValidationReject, err)
+	}
+	if !db.markReported(ref) {
+		return nil
+	}
+	metrics.PoetFraudReceived.Inc()
+	db.log.WithContext(ctx).With().Warning("received report of invalid proof signed by poet service",
+		log.String("poet_proof_id", fmt.Sprintf("%x", ref[:5])),
+		log.String("poet_service_id", fmt.Sprintf("%x", msg.PoetServiceID[:5])),
+		log.String("round_id", msg.RoundID),
+		log.String("reason", err.Error()),
+	)
+	return nil
+}
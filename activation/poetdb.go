@@ -2,32 +2,94 @@ package activation
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/spacemeshos/merkle-tree"
 	"github.com/spacemeshos/poet/hash"
 	"github.com/spacemeshos/poet/shared"
 	"github.com/spacemeshos/poet/verifier"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/poets"
 )
 
-var ErrObjectExists = sql.ErrObjectExists
+var (
+	ErrObjectExists = sql.ErrObjectExists
+
+	// ErrPoetValidationQueueFull is returned if the proof can't be queued for validation.
+	ErrPoetValidationQueueFull = errors.New("poet proof validation queue is full")
+	// ErrPoetDbClosed is returned if the proof is submitted for validation after the workers were stopped.
+	ErrPoetDbClosed = errors.New("poet db is closed")
+)
+
+// PoetDbOption is a functional option for the PoetDb.
+type PoetDbOption func(*PoetDb)
+
+// WithPoetValidationQueue makes the validation of proofs asynchronous. Proofs are queued for validation
+// by the given number of workers started with Run, concurrent submissions of the same proof share the result.
+// Proofs submitted when the queue is full are rejected.
+func WithPoetValidationQueue(size, workers int) PoetDbOption {
+	return func(db *PoetDb) {
+		if size > 0 && workers > 0 {
+			db.queue = make(chan *poetValidation, size)
+			db.workers = workers
+		}
+	}
+}
+
+// WithPoetFraudPublisher enables gossip of reports for invalid proofs that are signed by the poet service.
+func WithPoetFraudPublisher(publisher pubsub.Publisher) PoetDbOption {
+	return func(db *PoetDb) {
+		db.publisher = publisher
+	}
+}
+
+type poetValidation struct {
+	ref  types.PoetProofRef
+	msg  *types.PoetProofMessage
+	done chan struct{}
+	err  error
+}
 
 // PoetDb is a database for PoET proofs.
 type PoetDb struct {
 	sqlDB *sql.Database
 	log   log.Log
+
+	// queue is nil if proofs are validated synchronously.
+	queue     chan *poetValidation
+	workers   int
+	publisher pubsub.Publisher
+
+	mu      sync.Mutex
+	closed  bool
+	pending map[types.PoetProofRef]*poetValidation
+	// reported are the references of the invalid proofs that were reported.
+	reported map[types.PoetProofRef]struct{}
 }
 
 // NewPoetDb returns a new PoET handler.
-func NewPoetDb(db *sql.Database, log log.Log) *PoetDb {
-	return &PoetDb{sqlDB: db, log: log}
+func NewPoetDb(db *sql.Database, log log.Log, opts ...PoetDbOption) *PoetDb {
+	pdb := &PoetDb{
+		sqlDB:    db,
+		log:      log,
+		pending:  map[types.PoetProofRef]*poetValidation{},
+		reported: map[types.PoetProofRef]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(pdb)
+	}
+	return pdb
 }
 
 // HasProof returns true if the database contains a proof with the given reference, or false otherwise.
@@ -37,6 +99,7 @@ func (db *PoetDb) HasProof(proofRef types.PoetProofRef) bool {
 }
 
 // ValidateAndStore validates and stores a new PoET proof.
+// If the validation queue is enabled the proof is validated by one of the workers.
 func (db *PoetDb) ValidateAndStore(ctx context.Context, proofMessage *types.PoetProofMessage) error {
 	ref, err := proofMessage.Ref()
 	if err != nil {
@@ -46,18 +109,106 @@ func (db *PoetDb) ValidateAndStore(ctx context.Context, proofMessage *types.Poet
 	if db.HasProof(ref) {
 		return nil
 	}
+	if db.queue == nil {
+		return db.validateAndStore(ctx, ref, proofMessage)
+	}
 
-	if err := db.Validate(
-		proofMessage.Statement[:],
-		proofMessage.PoetProof,
-		proofMessage.PoetServiceID,
-		proofMessage.RoundID,
-		proofMessage.Signature,
-	); err != nil {
+	v, err := db.submit(ref, proofMessage)
+	if err != nil {
 		return err
 	}
+	select {
+	case <-v.done:
+		return v.err
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for poet proof validation: %w", ctx.Err())
+	}
+}
+
+// submit queues the proof for validation, unless the same proof is already pending.
+func (db *PoetDb) submit(ref types.PoetProofRef, proofMessage *types.PoetProofMessage) (*poetValidation, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil, ErrPoetDbClosed
+	}
+	if v, exists := db.pending[ref]; exists {
+		metrics.PoetValidationDuplicate.Inc()
+		return v, nil
+	}
+	v := &poetValidation{ref: ref, msg: proofMessage, done: make(chan struct{})}
+	select {
+	case db.queue <- v:
+	default:
+		metrics.PoetValidationDropped.Inc()
+		return nil, ErrPoetValidationQueueFull
+	}
+	db.pending[ref] = v
+	metrics.PoetValidationQueued.Inc()
+	metrics.PoetValidationQueue.Inc()
+	return v, nil
+}
 
-	return db.StoreProof(ctx, ref, proofMessage)
+// Run validates the queued proofs until the context is canceled.
+// Validations that are still pending when it returns fail with the context error.
+func (db *PoetDb) Run(ctx context.Context) error {
+	if db.queue == nil {
+		return nil
+	}
+	var eg errgroup.Group
+	for i := 0; i < db.workers; i++ {
+		eg.Go(func() error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case v := <-db.queue:
+					if ctx.Err() != nil {
+						return nil
+					}
+					db.finish(v, db.validateAndStore(ctx, v.ref, v.msg))
+				}
+			}
+		})
+	}
+	eg.Wait()
+
+	db.mu.Lock()
+	db.closed = true
+	pending := make([]*poetValidation, 0, len(db.pending))
+	for _, v := range db.pending {
+		pending = append(pending, v)
+	}
+	db.mu.Unlock()
+	for _, v := range pending {
+		db.finish(v, ctx.Err())
+	}
+	return nil
+}
+
+func (db *PoetDb) finish(v *poetValidation, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, exists := db.pending[v.ref]; !exists {
+		return
+	}
+	delete(db.pending, v.ref)
+	metrics.PoetValidationQueue.Dec()
+	v.err = err
+	close(v.done)
+}
+
+func (db *PoetDb) validateAndStore(ctx context.Context, ref types.PoetProofRef, msg *types.PoetProofMessage) error {
+	if db.isReported(ref) {
+		return fmt.Errorf("poet proof %x was reported as invalid", ref[:5])
+	}
+	if err := db.Validate(msg.Statement[:], msg.PoetProof, msg.PoetServiceID, msg.RoundID, msg.Signature); err != nil {
+		if !errors.As(err, &types.ProcessingError{}) {
+			db.reportFraud(ctx, ref, msg, err)
+		}
+		return err
+	}
+	return db.StoreProof(ctx, ref, msg)
 }
 
 // ValidateAndStoreMsg validates and stores a new PoET proof.
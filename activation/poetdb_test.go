@@ -8,15 +8,21 @@ import (
 	"testing"
 	"time"
 
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
 	poetHash "github.com/spacemeshos/poet/hash"
 	"github.com/spacemeshos/poet/prover"
 	"github.com/spacemeshos/poet/shared"
 	"github.com/spacemeshos/poet/verifier"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
 	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
@@ -132,3 +138,138 @@ func TestPoetDbNonExistingKeys(t *testing.T) {
 		),
 	)
 }
+
+func signPoetProof(t *testing.T, msg *types.PoetProofMessage) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	msg.PoetServiceID = pub
+	signed, err := msg.SignedBytes()
+	require.NoError(t, err)
+	copy(msg.Signature[:], ed25519.Sign(priv, signed))
+}
+
+func TestPoetDb_ValidationQueue(t *testing.T) {
+	t.Run("concurrent submissions share validation", func(t *testing.T) {
+		msg := getPoetProof(t)
+		poetDb := NewPoetDb(sql.InMemory(), logtest.New(t), WithPoetValidationQueue(10, 2))
+		ctx, cancel := context.WithCancel(context.Background())
+		var eg errgroup.Group
+		eg.Go(func() error { return poetDb.Run(ctx) })
+		t.Cleanup(func() {
+			cancel()
+			require.NoError(t, eg.Wait())
+		})
+
+		var submitters errgroup.Group
+		for i := 0; i < 10; i++ {
+			submitters.Go(func() error {
+				msg := msg
+				return poetDb.ValidateAndStore(context.Background(), &msg)
+			})
+		}
+		require.NoError(t, submitters.Wait())
+		ref, err := msg.Ref()
+		require.NoError(t, err)
+		require.True(t, poetDb.HasProof(ref))
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		poetDb := NewPoetDb(sql.InMemory(), logtest.New(t), WithPoetValidationQueue(1, 1))
+		first := getPoetProof(t)
+		ref, err := first.Ref()
+		require.NoError(t, err)
+		v, err := poetDb.submit(ref, &first)
+		require.NoError(t, err)
+
+		// the same proof joins the pending validation
+		duplicate, err := poetDb.submit(ref, &first)
+		require.NoError(t, err)
+		require.Equal(t, v, duplicate)
+
+		second := getPoetProof(t)
+		second.LeafCount++
+		ref, err = second.Ref()
+		require.NoError(t, err)
+		_, err = poetDb.submit(ref, &second)
+		require.ErrorIs(t, err, ErrPoetValidationQueueFull)
+	})
+
+	t.Run("pending validations fail on stop", func(t *testing.T) {
+		msg := getPoetProof(t)
+		poetDb := NewPoetDb(sql.InMemory(), logtest.New(t), WithPoetValidationQueue(1, 1))
+		ref, err := msg.Ref()
+		require.NoError(t, err)
+		v, err := poetDb.submit(ref, &msg)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, poetDb.Run(ctx))
+		<-v.done
+		require.ErrorIs(t, v.err, context.Canceled)
+
+		require.ErrorIs(t, poetDb.ValidateAndStore(context.Background(), &msg), ErrPoetDbClosed)
+	})
+}
+
+func TestPoetDb_FraudReport(t *testing.T) {
+	invalid := getPoetProof(t)
+	invalid.PoetProof.Root = []byte("some other root")
+	signPoetProof(t, &invalid)
+
+	var report []byte
+	ctrl := gomock.NewController(t)
+	publisher := mocks.NewMockPublisher(ctrl)
+	publisher.EXPECT().Publish(gomock.Any(), pubsub.PoetFraudProtocol, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, data []byte) error {
+			report = data
+			return nil
+		})
+	poetDb := NewPoetDb(sql.InMemory(), logtest.New(t), WithPoetFraudPublisher(publisher))
+
+	require.ErrorContains(t, poetDb.ValidateAndStore(context.Background(), &invalid), "merkle proof not valid")
+	require.NotNil(t, report)
+	// reported proofs are rejected without validation and reported only once
+	require.ErrorContains(t, poetDb.ValidateAndStore(context.Background(), &invalid), "reported as invalid")
+
+	t.Run("unsigned proof is not reported", func(t *testing.T) {
+		msg := getPoetProof(t)
+		msg.PoetProof.Root = []byte("some other root")
+		poetDb := NewPoetDb(sql.InMemory(), logtest.New(t), WithPoetFraudPublisher(mocks.NewMockPublisher(ctrl)))
+		require.Error(t, poetDb.ValidateAndStore(context.Background(), &msg))
+	})
+
+	t.Run("valid report is accepted", func(t *testing.T) {
+		receiver := NewPoetDb(sql.InMemory(), logtest.New(t))
+		require.NoError(t, receiver.HandleFraudReport(context.Background(), p2p.NoPeer, report))
+		ref, err := invalid.Ref()
+		require.NoError(t, err)
+		require.True(t, receiver.isReported(ref))
+	})
+
+	t.Run("report of a valid proof is rejected", func(t *testing.T) {
+		valid := getPoetProof(t)
+		signPoetProof(t, &valid)
+		data, err := codec.Encode(&types.PoetFraudReport{Proof: valid})
+		require.NoError(t, err)
+		receiver := NewPoetDb(sql.InMemory(), logtest.New(t))
+		err = receiver.HandleFraudReport(context.Background(), p2p.NoPeer, data)
+		require.ErrorIs(t, err, pubsub.ErrValidationReject)
+	})
+
+	t.Run("report of an unsigned proof is rejected", func(t *testing.T) {
+		msg := invalid
+		msg.Signature = types.EmptyEdSignature
+		data, err := codec.Encode(&types.PoetFraudReport{Proof: msg})
+		require.NoError(t, err)
+		receiver := NewPoetDb(sql.InMemory(), logtest.New(t))
+		err = receiver.HandleFraudReport(context.Background(), p2p.NoPeer, data)
+		require.ErrorIs(t, err, pubsub.ErrValidationReject)
+	})
+
+	t.Run("malformed report is rejected", func(t *testing.T) {
+		receiver := NewPoetDb(sql.InMemory(), logtest.New(t))
+		err := receiver.HandleFraudReport(context.Background(), p2p.NoPeer, []byte("bad"))
+		require.ErrorIs(t, err, pubsub.ErrValidationReject)
+	})
+}
@@ -0,0 +1,211 @@
+// Package poetproxy lets the nodes of one operator register at poets and fetch poet proofs through
+// a shared local proxy.
+//
+// Large operators run many nodes that register at the same public poets and download the same proofs.
+// Server sends the registrations of the nodes to the poets in batches of bounded size, fetches the proof
+// of a round once for all nodes and caches pow params. Nodes talk to the proxy with scale encoded messages
+// over http.
+package poetproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// maxResponseSize is the largest response of the proxy, it fits the proof with the largest number of members.
+const maxResponseSize = 1 << 30
+
+// Config of the poet proxy.
+type Config struct {
+	// Endpoint is the address of the proxy used by the node, poets are contacted directly if empty.
+	// Http is used if the scheme is not specified.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Listen is the address on which the node serves the proxy for the configured poet servers.
+	// The proxy is not served if empty.
+	Listen string `mapstructure:"listen"`
+	// BatchSize is the largest number of registrations sent to the poet together.
+	BatchSize int `mapstructure:"batch-size"`
+	// BatchDelay is the time the first registration of the batch waits for the other ones.
+	BatchDelay time.Duration `mapstructure:"batch-delay"`
+	// PowParamsTTL is the time pow params of the poet are cached.
+	PowParamsTTL time.Duration `mapstructure:"pow-params-ttl"`
+	// ProofCacheSize is the number of proofs of poet rounds kept in memory.
+	ProofCacheSize int `mapstructure:"proof-cache-size"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:      100,
+		BatchDelay:     time.Second,
+		PowParamsTTL:   time.Minute,
+		ProofCacheSize: 8,
+	}
+}
+
+// normalizeAddress returns the address of the poet in the form used by activation.HTTPPoetClient,
+// registrations of the node are stored with it.
+func normalizeAddress(address string) (*url.URL, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing address: %w", err)
+	}
+	if parsed.Scheme == "" {
+		parsed.Scheme = "http"
+	}
+	return parsed, nil
+}
+
+// Client talks to the poet through the proxy. It implements activation.PoetClient.
+type Client struct {
+	proxy  *url.URL
+	poet   string
+	client *retryablehttp.Client
+}
+
+var _ activation.PoetClient = (*Client)(nil)
+
+// NewClient creates the client of the poet server that sends requests to the proxy.
+// Requests are retried only if the proxy is unreachable, the proxy retries requests to the poet.
+func NewClient(logger *zap.Logger, proxy string, server types.PoetServer, cfg activation.PoetConfig) (*Client, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	poetURL, err := normalizeAddress(server.Address)
+	if err != nil {
+		return nil, fmt.Errorf("poet: %w", err)
+	}
+	client := retryablehttp.NewClient()
+	client.RetryMax = cfg.MaxRequestRetries
+	client.RetryWaitMin = cfg.RequestRetryDelay
+	client.RetryWaitMax = 2 * cfg.RequestRetryDelay
+	client.Backoff = retryablehttp.LinearJitterBackoff
+	client.Logger = nil
+	client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if resp != nil {
+			return false, nil
+		}
+		return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+	}
+	logger.Info("created poet client through proxy",
+		zap.Stringer("proxy", proxyURL),
+		zap.Stringer("poet", poetURL),
+	)
+	return &Client{proxy: proxyURL, poet: poetURL.String(), client: client}, nil
+}
+
+// Address returns the address of the poet, not of the proxy.
+func (c *Client) Address() string {
+	return c.poet
+}
+
+func (c *Client) PowParams(ctx context.Context) (*activation.PoetPowParams, error) {
+	var resp PowParamsResponse
+	if err := c.req(ctx, PowParamsPath, &PowParamsRequest{Poet: c.poet}, &resp); err != nil {
+		return nil, fmt.Errorf("querying PoW params: %w", err)
+	}
+	return &activation.PoetPowParams{
+		Challenge:  resp.Challenge,
+		Difficulty: uint(resp.Difficulty),
+	}, nil
+}
+
+// Submit registers a challenge in the open round of the poet.
+func (c *Client) Submit(
+	ctx context.Context,
+	deadline time.Time,
+	prefix, challenge []byte,
+	signature types.EdSignature,
+	nodeID types.NodeID,
+	pow activation.PoetPoW,
+) (*types.PoetRound, error) {
+	req := &SubmitRequest{
+		Poet:          c.poet,
+		Deadline:      uint64(deadline.Unix()),
+		Prefix:        prefix,
+		Challenge:     challenge,
+		Signature:     signature,
+		NodeID:        nodeID,
+		Nonce:         pow.Nonce,
+		PowChallenge:  pow.Params.Challenge,
+		PowDifficulty: uint32(pow.Params.Difficulty),
+	}
+	var resp SubmitResponse
+	if err := c.req(ctx, SubmitPath, req, &resp); err != nil {
+		return nil, fmt.Errorf("submitting challenge: %w", err)
+	}
+	round := &types.PoetRound{ID: resp.RoundID}
+	if resp.RoundEnd != 0 {
+		round.End = types.RoundEnd(time.Unix(0, int64(resp.RoundEnd)))
+	}
+	return round, nil
+}
+
+// Proof returns the proof of the round and its members.
+func (c *Client) Proof(ctx context.Context, roundID string) (*types.PoetProofMessage, []types.Member, error) {
+	var resp ProofResponse
+	if err := c.req(ctx, ProofPath, &ProofRequest{Poet: c.poet, RoundID: roundID}, &resp); err != nil {
+		return nil, nil, fmt.Errorf("getting proof: %w", err)
+	}
+	return &resp.Proof, resp.Members, nil
+}
+
+func (c *Client) req(ctx context.Context, path string, req codec.Encodable, resp codec.Decodable) error {
+	body, err := codec.Encode(req)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	httpReq, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, c.proxy.JoinPath(path).String(), body)
+	if err != nil {
+		return fmt.Errorf("creating HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+
+	res, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("doing request: %w", err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: response status code: %s, body: %s", statusError(res.StatusCode), res.Status, data)
+	}
+	if err := codec.Decode(data, resp); err != nil {
+		return fmt.Errorf("decoding response body: %w", err)
+	}
+	return nil
+}
+
+// statusError returns the error of the poet client that is mapped to the status code by the proxy.
+func statusError(code int) error {
+	switch code {
+	case http.StatusNotFound:
+		return activation.ErrNotFound
+	case http.StatusServiceUnavailable:
+		return activation.ErrUnavailable
+	case http.StatusBadRequest:
+		return activation.ErrInvalidRequest
+	default:
+		return errors.New("proxy error")
+	}
+}
@@ -0,0 +1,26 @@
+package poetproxy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+const namespace = "poet_proxy"
+
+var (
+	requests = metrics.NewCounter(
+		"requests",
+		namespace,
+		"number of requests by kind received from the nodes and sent to the poets",
+		[]string{"kind", "source"},
+	)
+
+	batchSize = metrics.NewHistogramWithBuckets(
+		"batch_size",
+		namespace,
+		"number of registrations sent to the poet together",
+		[]string{"poet"},
+		prometheus.ExponentialBuckets(1, 2, 10),
+	)
+)
@@ -0,0 +1,175 @@
+package poetproxy_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+const poetAddress = "http://poet.local"
+
+func launchProxy(tb testing.TB, cfg poetproxy.Config, poets ...activation.PoetClient) string {
+	srv, err := poetproxy.NewServer(zaptest.NewLogger(tb), cfg, poets)
+	require.NoError(tb, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return srv.Serve(ctx, lis)
+	})
+	tb.Cleanup(func() {
+		cancel()
+		require.NoError(tb, eg.Wait())
+	})
+	return lis.Addr().String()
+}
+
+func newClient(tb testing.TB, proxy, poet string) *poetproxy.Client {
+	cfg := activation.DefaultPoetConfig()
+	cfg.MaxRequestRetries = 0
+	client, err := poetproxy.NewClient(zaptest.NewLogger(tb), proxy, types.PoetServer{Address: poet}, cfg)
+	require.NoError(tb, err)
+	return client
+}
+
+func mockPoet(ctrl *gomock.Controller) *activation.MockPoetClient {
+	poet := activation.NewMockPoetClient(ctrl)
+	poet.EXPECT().Address().Return(poetAddress).AnyTimes()
+	return poet
+}
+
+func TestProxy_SubmitBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	poet := mockPoet(ctrl)
+	cfg := poetproxy.DefaultConfig()
+	cfg.BatchSize = 3
+	cfg.BatchDelay = time.Hour
+	client := newClient(t, launchProxy(t, cfg, poet), poetAddress)
+	require.Equal(t, poetAddress, client.Address())
+
+	end := time.Now().Add(time.Hour).Round(time.Millisecond)
+	deadline := time.Now().Add(time.Minute).Truncate(time.Second)
+	pow := activation.PoetPoW{Nonce: 7, Params: activation.PoetPowParams{Challenge: []byte{1}, Difficulty: 3}}
+	poet.EXPECT().Submit(gomock.Any(), deadline, []byte("prefix"), gomock.Any(), gomock.Any(), gomock.Any(), pow).
+		DoAndReturn(func(
+			_ context.Context,
+			_ time.Time,
+			_, challenge []byte,
+			_ types.EdSignature,
+			_ types.NodeID,
+			_ activation.PoetPoW,
+		) (*types.PoetRound, error) {
+			return &types.PoetRound{ID: string(challenge), End: types.RoundEnd(end)}, nil
+		}).
+		Times(cfg.BatchSize)
+
+	// the batch delay is too long, registrations are sent only when the batch is full
+	var eg errgroup.Group
+	for i := 0; i < cfg.BatchSize; i++ {
+		challenge := []byte{'a' + byte(i)}
+		eg.Go(func() error {
+			round, err := client.Submit(context.Background(), deadline, []byte("prefix"), challenge,
+				types.RandomEdSignature(), types.RandomNodeID(), pow)
+			if err != nil {
+				return err
+			}
+			require.Equal(t, string(challenge), round.ID)
+			require.True(t, end.Equal(round.End.IntoTime()))
+			return nil
+		})
+	}
+	require.NoError(t, eg.Wait())
+}
+
+func TestProxy_SubmitDelay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	poet := mockPoet(ctrl)
+	cfg := poetproxy.DefaultConfig()
+	cfg.BatchDelay = 10 * time.Millisecond
+	client := newClient(t, launchProxy(t, cfg, poet), poetAddress)
+
+	poet.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, activation.ErrInvalidRequest)
+	_, err := client.Submit(context.Background(), time.Now(), nil, []byte{1},
+		types.RandomEdSignature(), types.RandomNodeID(), activation.PoetPoW{})
+	require.ErrorIs(t, err, activation.ErrInvalidRequest)
+}
+
+func TestProxy_Proof(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	poet := mockPoet(ctrl)
+	client := newClient(t, launchProxy(t, poetproxy.DefaultConfig(), poet), poetAddress)
+
+	proof := &types.PoetProofMessage{
+		PoetProof:     types.PoetProof{LeafCount: 2},
+		PoetServiceID: []byte("poet"),
+		RoundID:       "1",
+		Statement:     types.RandomHash(),
+	}
+	members := []types.Member{{1}, {2}}
+	unblock := make(chan struct{})
+	poet.EXPECT().Proof(gomock.Any(), "1").DoAndReturn(
+		func(context.Context, string) (*types.PoetProofMessage, []types.Member, error) {
+			<-unblock
+			return proof, members, nil
+		})
+	poet.EXPECT().Proof(gomock.Any(), "2").Return(nil, nil, activation.ErrNotFound)
+
+	// concurrent requests for the same round are served with one request to the poet
+	var eg errgroup.Group
+	for i := 0; i < 5; i++ {
+		eg.Go(func() error {
+			got, gotMembers, err := client.Proof(context.Background(), "1")
+			if err != nil {
+				return err
+			}
+			require.Equal(t, proof, got)
+			require.Equal(t, members, gotMembers)
+			return nil
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	require.NoError(t, eg.Wait())
+
+	// cached
+	got, _, err := client.Proof(context.Background(), "1")
+	require.NoError(t, err)
+	require.Equal(t, proof, got)
+
+	_, _, err = client.Proof(context.Background(), "2")
+	require.ErrorIs(t, err, activation.ErrNotFound)
+}
+
+func TestProxy_PowParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	poet := mockPoet(ctrl)
+	client := newClient(t, launchProxy(t, poetproxy.DefaultConfig(), poet), poetAddress)
+
+	params := &activation.PoetPowParams{Challenge: []byte{1, 2}, Difficulty: 4}
+	poet.EXPECT().PowParams(gomock.Any()).Return(params, nil)
+	for i := 0; i < 3; i++ {
+		got, err := client.PowParams(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, params, got)
+	}
+}
+
+func TestProxy_UnknownPoet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := newClient(t, launchProxy(t, poetproxy.DefaultConfig(), mockPoet(ctrl)), "http://poet.other")
+
+	_, err := client.PowParams(context.Background())
+	require.ErrorContains(t, err, "403")
+}
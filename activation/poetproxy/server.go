@@ -0,0 +1,337 @@
+package poetproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// maxRequestSize is the largest request accepted by the proxy.
+const maxRequestSize = 1 << 12
+
+var errUnknownPoet = errors.New("poet is not configured on the proxy")
+
+type submitResult struct {
+	round *types.PoetRound
+	err   error
+}
+
+type pendingSubmit struct {
+	req    *SubmitRequest
+	result chan submitResult
+}
+
+type upstream struct {
+	client  activation.PoetClient
+	submits chan *pendingSubmit
+
+	powMu      sync.Mutex
+	pow        *activation.PoetPowParams
+	powFetched time.Time
+}
+
+// Server forwards the requests of the nodes to the poets.
+//
+// Registrations are queued per poet and sent in batches, a batch is sent when it is full or when
+// the first registration in it waited for the batch delay. The next batch is sent after all
+// registrations of the previous one completed. Proof of the round is fetched from the poet once,
+// concurrent requests for the same proof wait for the first one and the result is cached.
+type Server struct {
+	logger    *zap.Logger
+	cfg       Config
+	upstreams map[string]*upstream
+
+	proofs      *lru.Cache[ProofRequest, *ProofResponse]
+	proofsGroup singleflight.Group
+}
+
+// NewServer creates the proxy of the poets.
+func NewServer(logger *zap.Logger, cfg Config, poets []activation.PoetClient) (*Server, error) {
+	if cfg.BatchSize <= 0 {
+		return nil, fmt.Errorf("batch size must be positive, got %d", cfg.BatchSize)
+	}
+	proofs, err := lru.New[ProofRequest, *ProofResponse](max(cfg.ProofCacheSize, 1))
+	if err != nil {
+		return nil, fmt.Errorf("create proofs cache: %w", err)
+	}
+	s := &Server{
+		logger:    logger,
+		cfg:       cfg,
+		upstreams: make(map[string]*upstream, len(poets)),
+		proofs:    proofs,
+	}
+	for _, client := range poets {
+		s.upstreams[client.Address()] = &upstream{
+			client:  client,
+			submits: make(chan *pendingSubmit, cfg.BatchSize),
+		}
+	}
+	return s, nil
+}
+
+// NewHTTPServer creates the proxy of the poet servers that are contacted with activation.HTTPPoetClient.
+func NewHTTPServer(
+	logger *zap.Logger,
+	cfg Config,
+	servers []types.PoetServer,
+	poetCfg activation.PoetConfig,
+) (*Server, error) {
+	poets := make([]activation.PoetClient, 0, len(servers))
+	for _, server := range servers {
+		client, err := activation.NewHTTPPoetClient(server, poetCfg, activation.WithLogger(logger.Named("poet")))
+		if err != nil {
+			return nil, fmt.Errorf("create poet client: %w", err)
+		}
+		poets = append(poets, client)
+	}
+	return NewServer(logger, cfg, poets)
+}
+
+// Handler returns the http handler of the proxy requests.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(SubmitPath, handle(s, "submit", s.submit))
+	mux.HandleFunc(ProofPath, handle(s, "proof", s.proof))
+	mux.HandleFunc(PowParamsPath, handle(s, "pow_params", s.powParams))
+	return mux
+}
+
+// Run serves the proxy on the listen address until the context is canceled.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.cfg.Listen, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the proxy on the listener until the context is canceled.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	var eg errgroup.Group
+	for address, u := range s.upstreams {
+		address, u := address, u
+		eg.Go(func() error {
+			s.batch(ctx, address, u)
+			return nil
+		})
+	}
+	eg.Go(func() error {
+		<-ctx.Done()
+		return srv.Shutdown(context.Background())
+	})
+	s.logger.Info("serving poet proxy", zap.Stringer("address", ln.Addr()))
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return eg.Wait()
+}
+
+func handle[Req any, Resp any, ReqPtr interface {
+	*Req
+	codec.Decodable
+}, RespPtr interface {
+	*Resp
+	codec.Encodable
+}](
+	s *Server,
+	kind string,
+	call func(context.Context, ReqPtr) (RespPtr, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		requests.WithLabelValues(kind, "node").Inc()
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := ReqPtr(new(Req))
+		if err := codec.Decode(data, req); err != nil {
+			http.Error(w, fmt.Sprintf("decode request: %s", err), http.StatusBadRequest)
+			return
+		}
+		resp, err := call(r.Context(), req)
+		if err != nil {
+			s.logger.Debug("poet proxy request failed", zap.String("kind", kind), zap.Error(err))
+			http.Error(w, err.Error(), errorStatus(r.Context(), err))
+			return
+		}
+		buf, err := codec.Encode(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(buf)
+	}
+}
+
+// errorStatus maps the error of the poet client to the status code that is mapped back by Client.
+func errorStatus(ctx context.Context, err error) int {
+	switch {
+	case errors.Is(err, errUnknownPoet):
+		return http.StatusForbidden
+	case errors.Is(err, activation.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, activation.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, activation.ErrUnavailable), ctx.Err() != nil:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func (s *Server) upstream(poet string) (*upstream, error) {
+	u, exists := s.upstreams[poet]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errUnknownPoet, poet)
+	}
+	return u, nil
+}
+
+func (s *Server) submit(ctx context.Context, req *SubmitRequest) (*SubmitResponse, error) {
+	u, err := s.upstream(req.Poet)
+	if err != nil {
+		return nil, err
+	}
+	pending := &pendingSubmit{req: req, result: make(chan submitResult, 1)}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case u.submits <- pending:
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-pending.result:
+		if result.err != nil {
+			return nil, result.err
+		}
+		resp := &SubmitResponse{RoundID: result.round.ID}
+		if end := result.round.End.IntoTime(); !end.IsZero() {
+			resp.RoundEnd = uint64(end.UnixNano())
+		}
+		return resp, nil
+	}
+}
+
+// batch collects the registrations for the poet and sends them in batches until the context is canceled.
+func (s *Server) batch(ctx context.Context, address string, u *upstream) {
+	for {
+		var batch []*pendingSubmit
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-u.submits:
+			batch = append(batch, pending)
+		}
+		timer := time.NewTimer(s.cfg.BatchDelay)
+	collect:
+		for len(batch) < s.cfg.BatchSize {
+			select {
+			case <-ctx.Done():
+				break collect
+			case pending := <-u.submits:
+				batch = append(batch, pending)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+		s.logger.Debug("sending registrations to poet", zap.String("poet", address), zap.Int("size", len(batch)))
+		batchSize.WithLabelValues(address).Observe(float64(len(batch)))
+		var wg sync.WaitGroup
+		for _, pending := range batch {
+			pending := pending
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := pending.req
+				requests.WithLabelValues("submit", "poet").Inc()
+				round, err := u.client.Submit(ctx, time.Unix(int64(req.Deadline), 0),
+					req.Prefix, req.Challenge, req.Signature, req.NodeID,
+					activation.PoetPoW{
+						Nonce: req.Nonce,
+						Params: activation.PoetPowParams{
+							Challenge:  req.PowChallenge,
+							Difficulty: uint(req.PowDifficulty),
+						},
+					},
+				)
+				pending.result <- submitResult{round: round, err: err}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func (s *Server) proof(ctx context.Context, req *ProofRequest) (*ProofResponse, error) {
+	u, err := s.upstream(req.Poet)
+	if err != nil {
+		return nil, err
+	}
+	if resp, exists := s.proofs.Get(*req); exists {
+		return resp, nil
+	}
+	// the proof is fetched for all waiting nodes, it is not canceled if the first of them gives up
+	result := s.proofsGroup.DoChan(req.Poet+"/"+req.RoundID, func() (any, error) {
+		requests.WithLabelValues("proof", "poet").Inc()
+		proof, members, err := u.client.Proof(context.WithoutCancel(ctx), req.RoundID)
+		if err != nil {
+			return nil, err
+		}
+		resp := &ProofResponse{Proof: *proof, Members: members}
+		s.proofs.Add(*req, resp)
+		return resp, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*ProofResponse), nil
+	}
+}
+
+func (s *Server) powParams(ctx context.Context, req *PowParamsRequest) (*PowParamsResponse, error) {
+	u, err := s.upstream(req.Poet)
+	if err != nil {
+		return nil, err
+	}
+	u.powMu.Lock()
+	defer u.powMu.Unlock()
+	if u.pow == nil || time.Since(u.powFetched) > s.cfg.PowParamsTTL {
+		requests.WithLabelValues("pow_params", "poet").Inc()
+		params, err := u.client.PowParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		u.pow, u.powFetched = params, time.Now()
+	}
+	return &PowParamsResponse{Challenge: u.pow.Challenge, Difficulty: uint32(u.pow.Difficulty)}, nil
+}
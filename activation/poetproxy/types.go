@@ -0,0 +1,65 @@
+package poetproxy
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+//go:generate scalegen
+
+const (
+	// SubmitPath registers the challenge in the open round of the poet.
+	SubmitPath = "/v1/submit"
+	// ProofPath returns the proof of the poet round.
+	ProofPath = "/v1/proof"
+	// PowParamsPath returns the pow params of the poet.
+	PowParamsPath = "/v1/pow-params"
+
+	// contentType is the content type of the scale encoded requests and responses.
+	contentType = "application/scale"
+)
+
+// SubmitRequest registers the challenge of the node in the open round of the poet.
+type SubmitRequest struct {
+	// Poet is the address of the upstream poet.
+	Poet string `scale:"max=256"`
+	// Deadline is the unix time in seconds after which the registration is not useful for the node.
+	Deadline  uint64
+	Prefix    []byte `scale:"max=64"`
+	Challenge []byte `scale:"max=64"`
+	Signature types.EdSignature
+	NodeID    types.NodeID
+
+	Nonce         uint64
+	PowChallenge  []byte `scale:"max=64"`
+	PowDifficulty uint32
+}
+
+// SubmitResponse is the round in which the challenge was registered.
+type SubmitResponse struct {
+	RoundID string `scale:"max=32"`
+	// RoundEnd is the unix time in nanoseconds at which the round ends, zero if unknown.
+	RoundEnd uint64
+}
+
+// ProofRequest requests the proof of the poet round.
+type ProofRequest struct {
+	Poet    string `scale:"max=256"`
+	RoundID string `scale:"max=32"`
+}
+
+// ProofResponse is the proof of the poet round and the members of the round.
+type ProofResponse struct {
+	Proof   types.PoetProofMessage
+	Members []types.Member `scale:"max=16777216"`
+}
+
+// PowParamsRequest requests the pow params of the poet.
+type PowParamsRequest struct {
+	Poet string `scale:"max=256"`
+}
+
+// PowParamsResponse is the pow params of the poet.
+type PowParamsResponse struct {
+	Challenge  []byte `scale:"max=64"`
+	Difficulty uint32
+}
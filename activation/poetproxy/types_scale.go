@@ -0,0 +1,324 @@
+// Code generated by github.com/spacemeshos/go-scale/scalegen. DO NOT EDIT.
+
+// nolint
+package poetproxy
+
+import (
+	"github.com/spacemeshos/go-scale"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func (t *SubmitRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.Poet), 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.Deadline))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteSliceWithLimit(enc, t.Prefix, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteSliceWithLimit(enc, t.Challenge, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Signature[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.NodeID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.Nonce))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteSliceWithLimit(enc, t.PowChallenge, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.PowDifficulty))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *SubmitRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Poet = string(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Deadline = uint64(field)
+	}
+	{
+		field, n, err := scale.DecodeByteSliceWithLimit(dec, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Prefix = field
+	}
+	{
+		field, n, err := scale.DecodeByteSliceWithLimit(dec, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Challenge = field
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Signature[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.NodeID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Nonce = uint64(field)
+	}
+	{
+		field, n, err := scale.DecodeByteSliceWithLimit(dec, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.PowChallenge = field
+	}
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.PowDifficulty = uint32(field)
+	}
+	return total, nil
+}
+
+func (t *SubmitResponse) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.RoundID), 32)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.RoundEnd))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *SubmitResponse) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 32)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.RoundID = string(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.RoundEnd = uint64(field)
+	}
+	return total, nil
+}
+
+func (t *ProofRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.Poet), 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.RoundID), 32)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *ProofRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Poet = string(field)
+	}
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 32)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.RoundID = string(field)
+	}
+	return total, nil
+}
+
+func (t *ProofResponse) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := t.Proof.EncodeScale(enc)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Members, 16777216)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *ProofResponse) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := t.Proof.DecodeScale(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.Member](dec, 16777216)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Members = field
+	}
+	return total, nil
+}
+
+func (t *PowParamsRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.Poet), 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *PowParamsRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Poet = string(field)
+	}
+	return total, nil
+}
+
+func (t *PowParamsResponse) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeByteSliceWithLimit(enc, t.Challenge, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Difficulty))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *PowParamsResponse) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeByteSliceWithLimit(dec, 64)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Challenge = field
+	}
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Difficulty = uint32(field)
+	}
+	return total, nil
+}
@@ -401,7 +401,7 @@ func (mgr *PostSetupManager) findCommitmentAtx(ctx context.Context) (types.ATXID
 		context.Background(),
 		mgr.db,
 		types.EmptyNodeID,
-		mgr.goldenATXID,
+		types.GoldenATX{ID: mgr.goldenATXID},
 		mgr.validator,
 		mgr.logger,
 		VerifyChainOpts.AssumeValidBefore(time.Now().Add(-mgr.postValidityDelay)),
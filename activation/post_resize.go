@@ -0,0 +1,179 @@
+package activation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/post/config"
+	"github.com/spacemeshos/post/initialization"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// ResizePreview is the validation of a change of the number of space units of the supervised identity.
+type ResizePreview struct {
+	NodeID types.NodeID
+	// CurrentUnits is the number of space units of the initialized post data.
+	CurrentUnits  uint32
+	ProposedUnits uint32
+
+	// LabelsToInitialize is the number of labels that are initialized before the identity can prove
+	// with the proposed size. The post service doesn't prove while it initializes.
+	LabelsToInitialize uint64
+	// ExpectedProvingTime is the duration of the last proving scaled to the proposed size, 0 if unknown.
+	ExpectedProvingTime time.Duration
+	// ProvingWindow is the time between the expected start of the proving and the publish deadline.
+	ProvingWindow time.Duration
+
+	// PublishEpoch is the publish epoch of the first atx proven with the proposed size.
+	PublishEpoch types.EpochID
+	// WeightEpoch is the first target epoch in which the weight of the identity is based on the proposed size.
+	WeightEpoch types.EpochID
+
+	// Warnings describe the effects of the resize that need the attention of the operator.
+	Warnings []string
+}
+
+func (p *ResizePreview) warn(format string, args ...any) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// PreviewResize validates the change of the number of space units of the supervised identity
+// against the post data and the publication forecast of the identity, without applying it.
+// It returns ErrInvalidResize if the post data can't be resized to the proposed size.
+func (ps *PostSupervisor) PreviewResize(numUnits uint32) (*ResizePreview, error) {
+	ps.mtx.Lock()
+	opts, sig := ps.opts, ps.sig
+	ps.mtx.Unlock()
+	if sig == nil {
+		return nil, fmt.Errorf("%w: post service was not started", ErrInvalidResize)
+	}
+	return ps.previewResize(opts, sig.NodeID(), numUnits)
+}
+
+// Resize validates the change of the number of space units of the supervised identity and schedules it
+// with the atx builder. The post service is restarted with the proposed size after the builder publishes
+// the atx that it is building, so that the proof of that atx isn't affected by the resize.
+func (ps *PostSupervisor) Resize(numUnits uint32) (*ResizePreview, error) {
+	ps.mtx.Lock()
+	opts, sig := ps.opts, ps.sig
+	ps.mtx.Unlock()
+	if sig == nil {
+		return nil, fmt.Errorf("%w: post service was not started", ErrInvalidResize)
+	}
+	preview, err := ps.previewResize(opts, sig.NodeID(), numUnits)
+	if err != nil {
+		return nil, err
+	}
+	ps.atxBuilder.ScheduleResize(sig.NodeID(), func() {
+		if err := ps.applyResize(numUnits); err != nil {
+			ps.logger.Error("failed to resize post data", zap.Uint32("num_units", numUnits), zap.Error(err))
+		}
+	})
+	ps.logger.Info("scheduled post data resize",
+		log.ZShortStringer("smesherID", sig.NodeID()),
+		zap.Uint32("current_units", preview.CurrentUnits),
+		zap.Uint32("proposed_units", numUnits),
+		zap.Uint32("publish_epoch", preview.PublishEpoch.Uint32()),
+	)
+	return preview, nil
+}
+
+func (ps *PostSupervisor) previewResize(opts PostSetupOpts, id types.NodeID, numUnits uint32) (*ResizePreview, error) {
+	if numUnits < ps.postCfg.MinNumUnits || numUnits > ps.postCfg.MaxNumUnits {
+		return nil, fmt.Errorf("%w: %d units is outside of the range [%d, %d]",
+			ErrInvalidResize, numUnits, ps.postCfg.MinNumUnits, ps.postCfg.MaxNumUnits)
+	}
+	meta, err := initialization.LoadMetadata(opts.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: load post metadata: %w", ErrInvalidResize, err)
+	}
+	if !bytes.Equal(meta.NodeId, id.Bytes()) {
+		return nil, fmt.Errorf("%w: post data in %s belongs to %x", ErrInvalidResize, opts.DataDir, meta.NodeId)
+	}
+	if meta.NumUnits == numUnits {
+		return nil, fmt.Errorf("%w: post data already has %d units", ErrInvalidResize, numUnits)
+	}
+	written, err := initialization.NewDiskState(opts.DataDir, config.BitsPerLabel).NumLabelsWritten()
+	if err != nil {
+		return nil, fmt.Errorf("%w: read post data: %w", ErrInvalidResize, err)
+	}
+	if written < uint64(meta.NumUnits)*meta.LabelsPerUnit || meta.Nonce == nil {
+		return nil, fmt.Errorf("%w: initialization of the post data is not complete", ErrInvalidResize)
+	}
+	forecast, err := ps.atxBuilder.PublishForecast(id)
+	if err != nil {
+		return nil, fmt.Errorf("forecast publication: %w", err)
+	}
+
+	preview := &ResizePreview{
+		NodeID:        id,
+		CurrentUnits:  meta.NumUnits,
+		ProposedUnits: numUnits,
+		ProvingWindow: forecast.ProvingWindow,
+		PublishEpoch:  forecast.PublishEpoch + 1,
+		WeightEpoch:   forecast.PublishEpoch + 2,
+	}
+	if forecast.ProvingDuration > 0 {
+		scale := float64(numUnits) / float64(meta.NumUnits)
+		preview.ExpectedProvingTime = time.Duration(float64(forecast.ProvingDuration) * scale)
+	}
+	preview.warn("post data is resized after the atx of epoch %d is published, the atx of epoch %d "+
+		"requires a new proof over %d units", forecast.PublishEpoch, preview.PublishEpoch, numUnits)
+	if numUnits > meta.NumUnits {
+		preview.LabelsToInitialize = uint64(numUnits-meta.NumUnits) * meta.LabelsPerUnit
+		// the effective size of the atx is the smaller one of the sizes of the atx and the previous atx
+		preview.WeightEpoch++
+		preview.warn("%d labels must be initialized before the proving of the atx of epoch %d starts, "+
+			"the post service doesn't prove while it initializes", preview.LabelsToInitialize, preview.PublishEpoch)
+		preview.warn("a new vrf nonce is searched over the increased data")
+		preview.warn("the atx of epoch %d is weighted with %d units, the increased weight applies from epoch %d",
+			preview.PublishEpoch, meta.NumUnits, preview.WeightEpoch)
+	} else {
+		preview.warn("post data of %d units is deleted, the decreased weight applies from epoch %d",
+			meta.NumUnits-numUnits, preview.WeightEpoch)
+	}
+	if preview.ExpectedProvingTime > preview.ProvingWindow {
+		preview.warn("expected proving time %s exceeds the proving window %s",
+			preview.ExpectedProvingTime, preview.ProvingWindow)
+	}
+	return preview, nil
+}
+
+// applyResize restarts the post service with the proposed number of space units.
+func (ps *PostSupervisor) applyResize(numUnits uint32) error {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if ps.stop == nil {
+		return errors.New("post service is not running")
+	}
+	if err := ps.stopLocked(false); err != nil {
+		return err
+	}
+	opts := ps.opts
+	meta, err := initialization.LoadMetadata(opts.DataDir)
+	if err != nil {
+		return fmt.Errorf("load post metadata: %w", err)
+	}
+	if numUnits > meta.NumUnits {
+		// the initializer extends the data only up to the size recorded in the metadata.
+		// vrf nonce is searched again, its difficulty depends on the size of the data.
+		meta.NumUnits = numUnits
+		meta.Nonce = nil
+		meta.NonceValue = nil
+		meta.LastPosition = nil
+		if err := initialization.SaveMetadata(opts.DataDir, meta); err != nil {
+			return fmt.Errorf("save post metadata: %w", err)
+		}
+	}
+	opts.NumUnits = numUnits
+	ps.logger.Info("resizing post data",
+		log.ZShortStringer("smesherID", ps.sig.NodeID()),
+		zap.Uint32("num_units", numUnits),
+	)
+	return ps.start(opts, ps.sig)
+}
@@ -0,0 +1,103 @@
+package activation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/post/config"
+	"github.com/spacemeshos/post/initialization"
+	"github.com/spacemeshos/post/shared"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+func writeTestPostData(tb testing.TB, dir string, meta *shared.PostMetadata, labels uint64) {
+	tb.Helper()
+	require.NoError(tb, initialization.SaveMetadata(dir, meta))
+	data := make([]byte, labels*config.BitsPerLabel/8)
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "postdata_0.bin"), data, 0o600))
+}
+
+func TestPostSupervisor_PreviewResize(t *testing.T) {
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	ctrl := gomock.NewController(t)
+	builder := NewMockAtxBuilder(ctrl)
+
+	postCfg := DefaultPostConfig()
+	postCfg.LabelsPerUnit = 32
+	postCfg.MinNumUnits = 2
+	postCfg.MaxNumUnits = 8
+	opts := DefaultPostSetupOpts()
+	opts.DataDir = t.TempDir()
+	opts.NumUnits = 4
+	ps := &PostSupervisor{
+		logger:     zaptest.NewLogger(t),
+		postCfg:    postCfg,
+		atxBuilder: builder,
+	}
+
+	_, err = ps.PreviewResize(6)
+	require.ErrorIs(t, err, ErrInvalidResize)
+
+	ps.opts = opts
+	ps.sig = sig
+	nonce := uint64(7)
+	meta := &shared.PostMetadata{
+		NodeId:        sig.NodeID().Bytes(),
+		LabelsPerUnit: postCfg.LabelsPerUnit,
+		NumUnits:      opts.NumUnits,
+		MaxFileSize:   opts.MaxFileSize,
+		Nonce:         &nonce,
+	}
+	writeTestPostData(t, opts.DataDir, meta, 3*postCfg.LabelsPerUnit)
+	_, err = ps.PreviewResize(6)
+	require.ErrorIs(t, err, ErrInvalidResize, "initialization is not complete")
+
+	writeTestPostData(t, opts.DataDir, meta, 4*postCfg.LabelsPerUnit)
+	for _, units := range []uint32{1, 4, 9} {
+		_, err = ps.PreviewResize(units)
+		require.ErrorIs(t, err, ErrInvalidResize, "units %d", units)
+	}
+
+	forecast := &PublishForecast{
+		NodeID:          sig.NodeID(),
+		PublishEpoch:    5,
+		ProvingWindow:   time.Hour,
+		ProvingDuration: 40 * time.Minute,
+	}
+	builder.EXPECT().PublishForecast(sig.NodeID()).Return(forecast, nil).AnyTimes()
+
+	t.Run("increase", func(t *testing.T) {
+		preview, err := ps.PreviewResize(8)
+		require.NoError(t, err)
+		require.Equal(t, uint32(4), preview.CurrentUnits)
+		require.Equal(t, uint32(8), preview.ProposedUnits)
+		require.Equal(t, 4*postCfg.LabelsPerUnit, preview.LabelsToInitialize)
+		require.Equal(t, 80*time.Minute, preview.ExpectedProvingTime)
+		require.EqualValues(t, 6, preview.PublishEpoch)
+		require.EqualValues(t, 8, preview.WeightEpoch)
+		require.Len(t, preview.Warnings, 5)
+		require.Contains(t, preview.Warnings[len(preview.Warnings)-1], "exceeds the proving window")
+	})
+	t.Run("decrease", func(t *testing.T) {
+		preview, err := ps.PreviewResize(2)
+		require.NoError(t, err)
+		require.Zero(t, preview.LabelsToInitialize)
+		require.Equal(t, 20*time.Minute, preview.ExpectedProvingTime)
+		require.EqualValues(t, 6, preview.PublishEpoch)
+		require.EqualValues(t, 7, preview.WeightEpoch)
+		require.Len(t, preview.Warnings, 2)
+	})
+	t.Run("schedule", func(t *testing.T) {
+		builder.EXPECT().ScheduleResize(sig.NodeID(), gomock.Any())
+		preview, err := ps.Resize(2)
+		require.NoError(t, err)
+		require.EqualValues(t, 6, preview.PublishEpoch)
+	})
+}
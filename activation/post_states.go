@@ -3,6 +3,7 @@ package activation
 import (
 	"maps"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -13,17 +14,32 @@ type postStates struct {
 	log    *zap.Logger
 	mu     sync.RWMutex
 	states map[types.NodeID]types.PostState
+
+	// provingStart is when the identity entered the proving state, and proving is the duration
+	// of the last proving that completed.
+	provingStart map[types.NodeID]time.Time
+	proving      map[types.NodeID]time.Duration
 }
 
 func NewPostStates(log *zap.Logger) *postStates {
 	return &postStates{
 		log:    log,
 		states: make(map[types.NodeID]types.PostState),
+
+		provingStart: make(map[types.NodeID]time.Time),
+		proving:      make(map[types.NodeID]time.Duration),
 	}
 }
 
 func (s *postStates) Set(id types.NodeID, state types.PostState) {
 	s.mu.Lock()
+	switch {
+	case state == types.PostStateProving:
+		s.provingStart[id] = time.Now()
+	case s.states[id] == types.PostStateProving && state == types.PostStateIdle:
+		s.proving[id] = time.Since(s.provingStart[id])
+		delete(s.provingStart, id)
+	}
 	s.states[id] = state
 	s.mu.Unlock()
 
@@ -37,3 +53,11 @@ func (s *postStates) Get() map[types.NodeID]types.PostState {
 	maps.Copy(copy, s.states)
 	return copy
 }
+
+// ProvingDuration returns the duration of the last completed proving of the identity,
+// or 0 if the identity didn't complete proving since the node started.
+func (s *postStates) ProvingDuration(id types.NodeID) time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.proving[id]
+}
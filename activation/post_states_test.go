@@ -3,6 +3,7 @@ package activation
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -71,3 +72,16 @@ func TestPostState_OnProof(t *testing.T) {
 	_, _, err = nb.Proof(context.Background(), id, []byte("abc"))
 	require.NoError(t, err)
 }
+
+func TestPostStates_ProvingDuration(t *testing.T) {
+	postStates := NewPostStates(zaptest.NewLogger(t))
+	id := types.RandomNodeID()
+	require.Zero(t, postStates.ProvingDuration(id))
+
+	postStates.Set(id, types.PostStateProving)
+	time.Sleep(10 * time.Millisecond)
+	require.Zero(t, postStates.ProvingDuration(id))
+
+	postStates.Set(id, types.PostStateIdle)
+	require.GreaterOrEqual(t, postStates.ProvingDuration(id), 10*time.Millisecond)
+}
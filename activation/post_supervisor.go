@@ -78,6 +78,11 @@ type PostSupervisor struct {
 	mtx  sync.Mutex         // protects fields below
 	eg   errgroup.Group     // eg manages post service goroutines.
 	stop context.CancelFunc // stops the running command.
+
+	// opts and sig of the last Start, used to resume the paused initialization.
+	opts   PostSetupOpts
+	sig    *signing.EdSigner
+	paused bool
 }
 
 // NewPostSupervisor returns a new post service.
@@ -140,9 +145,16 @@ func (ps *PostSupervisor) Status() *PostSetupStatus {
 func (ps *PostSupervisor) Start(opts PostSetupOpts, sig *signing.EdSigner) error {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
+	return ps.start(opts, sig)
+}
+
+func (ps *PostSupervisor) start(opts PostSetupOpts, sig *signing.EdSigner) error {
 	if ps.stop != nil {
 		return fmt.Errorf("post service already started")
 	}
+	ps.opts = opts
+	ps.sig = sig
+	ps.paused = false
 
 	// TODO(mafa): verify that opts don't delete existing files
 
@@ -176,11 +188,43 @@ func (ps *PostSupervisor) Start(opts PostSetupOpts, sig *signing.EdSigner) error
 	return nil
 }
 
+// Pause stops the post initialization if it is in progress. Initialization continues
+// from the data that was already written when Resume is called.
+// Proving isn't paused, the returned value is false if initialization wasn't in progress.
+func (ps *PostSupervisor) Pause() (bool, error) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if ps.stop == nil || ps.postSetupProvider.Status().State != PostSetupStateInProgress {
+		return false, nil
+	}
+	if err := ps.stopLocked(false); err != nil {
+		return false, err
+	}
+	ps.paused = true
+	ps.logger.Info("post initialization paused", log.ZShortStringer("smesherID", ps.sig.NodeID()))
+	return true, nil
+}
+
+// Resume restarts the initialization stopped by Pause. It is a no-op if initialization wasn't paused.
+func (ps *PostSupervisor) Resume() error {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if !ps.paused {
+		return nil
+	}
+	ps.logger.Info("resuming post initialization", log.ZShortStringer("smesherID", ps.sig.NodeID()))
+	return ps.start(ps.opts, ps.sig)
+}
+
 // Stop stops the post service.
 func (ps *PostSupervisor) Stop(deleteFiles bool) error {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
+	ps.paused = false
+	return ps.stopLocked(deleteFiles)
+}
 
+func (ps *PostSupervisor) stopLocked(deleteFiles bool) error {
 	if ps.stop == nil {
 		return nil
 	}
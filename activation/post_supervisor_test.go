@@ -325,6 +325,57 @@ func Test_PostSupervisor_StopOnError(t *testing.T) {
 	require.ErrorIs(t, ps.Stop(true), testErr)
 }
 
+func Test_PostSupervisor_PauseResume(t *testing.T) {
+	log := zaptest.NewLogger(t)
+
+	cmdCfg := DefaultTestPostServiceConfig()
+	// service is never started, initialization doesn't complete
+	cmdCfg.PostServiceCmd = filepath.Join(t.TempDir(), "service")
+	require.NoError(t, os.WriteFile(cmdCfg.PostServiceCmd, nil, 0o755))
+	postCfg := DefaultPostConfig()
+	postOpts := DefaultPostSetupOpts()
+	provingOpts := DefaultPostProvingOpts()
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	mgr := NewMockpostSetupProvider(ctrl)
+	builder := NewMockAtxBuilder(ctrl)
+	ps, err := NewPostSupervisor(log.Named("supervisor"), cmdCfg, postCfg, provingOpts, mgr, builder)
+	require.NoError(t, err)
+
+	paused, err := ps.Pause()
+	require.NoError(t, err)
+	require.False(t, paused, "not started")
+
+	started := make(chan struct{}, 2)
+	mgr.EXPECT().PrepareInitializer(gomock.Any(), postOpts, sig.NodeID()).Return(nil).Times(2)
+	mgr.EXPECT().StartSession(gomock.Any(), sig.NodeID()).DoAndReturn(func(ctx context.Context, _ types.NodeID) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}).Times(2)
+	mgr.EXPECT().Status().Return(&PostSetupStatus{State: PostSetupStateInProgress}).AnyTimes()
+
+	require.NoError(t, ps.Start(postOpts, sig))
+	t.Cleanup(func() { assert.NoError(t, ps.Stop(false)) })
+	<-started
+
+	paused, err = ps.Pause()
+	require.NoError(t, err)
+	require.True(t, paused)
+	require.NoError(t, ps.eg.Wait())
+
+	require.NoError(t, ps.Resume())
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "initialization wasn't resumed")
+	}
+	// resume is no-op if not paused
+	require.NoError(t, ps.Resume())
+}
+
 func Test_PostSupervisor_Providers_includesCPU(t *testing.T) {
 	log := zaptest.NewLogger(t)
 
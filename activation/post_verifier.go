@@ -2,6 +2,7 @@ package activation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
@@ -16,6 +17,31 @@ import (
 	"github.com/spacemeshos/go-spacemesh/events"
 )
 
+// ErrVerifierClosed is returned for proofs that are submitted or pending when the verifier is closed.
+var ErrVerifierClosed = errors.New("verifier is closed")
+
+type postVerifyRequestKey struct{}
+
+// PostVerifyRequest describes the verification options that can't be inspected from the opaque
+// verifying.OptionFunc. It is attached to the context by Validator.Post so that the verifiers that
+// offload proofs to remote services can reproduce the verification. Proofs verified with other
+// options (i.e. without the request in the context) are always verified locally.
+type PostVerifyRequest struct {
+	// SubsetSeed selects the random subset of K3 indices that are verified, all indices if nil.
+	SubsetSeed []byte
+}
+
+// WithPostVerifyRequest attaches the description of the verification options to the context.
+func WithPostVerifyRequest(ctx context.Context, req PostVerifyRequest) context.Context {
+	return context.WithValue(ctx, postVerifyRequestKey{}, req)
+}
+
+// PostVerifyRequestFromContext returns the request attached to the context by Validator.Post.
+func PostVerifyRequestFromContext(ctx context.Context) (PostVerifyRequest, bool) {
+	req, ok := ctx.Value(postVerifyRequestKey{}).(PostVerifyRequest)
+	return req, ok
+}
+
 type verifyPostJob struct {
 	ctx      context.Context // context of Verify() call
 	proof    *shared.Proof
@@ -254,6 +280,7 @@ func (v *offloadingPostVerifier) Verify(
 
 	var jobChannel chan<- *verifyPostJob
 	_, prioritize := v.prioritizedIds[types.BytesToNodeID(m.NodeId)]
+	prioritize = prioritize || isPrioritizedVerification(ctx)
 	switch {
 	case prioritize:
 		v.log.Debug("prioritizing post verification", zap.Stringer("proof_node_id", types.BytesToNodeID(m.NodeId)))
@@ -265,7 +292,7 @@ func (v *offloadingPostVerifier) Verify(
 	select {
 	case jobChannel <- job:
 	case <-v.stop:
-		return fmt.Errorf("verifier is closed")
+		return ErrVerifierClosed
 	case <-ctx.Done():
 		return fmt.Errorf("submitting verifying job: %w", ctx.Err())
 	}
@@ -274,7 +301,7 @@ func (v *offloadingPostVerifier) Verify(
 	case res := <-job.result:
 		return res
 	case <-v.stop:
-		return fmt.Errorf("verifier is closed")
+		return ErrVerifierClosed
 	case <-ctx.Done():
 		return fmt.Errorf("waiting for verification result: %w", ctx.Err())
 	}
@@ -0,0 +1,142 @@
+package activation
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+// QuarantinedAtx is the atx that failed validation because its reference is missing.
+type QuarantinedAtx struct {
+	ID           types.ATXID
+	SmesherID    types.NodeID
+	PublishEpoch types.EpochID
+	// Missing is the id of the referenced atx or the reference of the poet proof that is missing.
+	Missing types.Hash32
+	Peer    p2p.Peer
+	// Quarantined is when the atx was quarantined the last time.
+	Quarantined time.Time
+	// Attempts is the number of times the atx failed validation because of the missing reference.
+	Attempts int
+	Reason   string
+}
+
+type quarantined struct {
+	QuarantinedAtx
+	msg []byte
+}
+
+// atxQuarantine keeps the encoded atxs that failed validation by the reference that is missing,
+// so that they are validated again once the reference arrives instead of waiting for them to be
+// gossiped or synced again.
+type atxQuarantine struct {
+	size int
+
+	mu      sync.Mutex
+	atxs    map[types.ATXID]*quarantined
+	missing map[types.Hash32]map[types.ATXID]struct{}
+}
+
+func newAtxQuarantine(size int) *atxQuarantine {
+	return &atxQuarantine{
+		size:    size,
+		atxs:    make(map[types.ATXID]*quarantined),
+		missing: make(map[types.Hash32]map[types.ATXID]struct{}),
+	}
+}
+
+// add quarantines the atx until the missing reference arrives. The atx that was quarantined
+// earliest is evicted if the quarantine is full.
+func (q *atxQuarantine) add(atx QuarantinedAtx, msg []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.atxs[atx.ID]; ok {
+		q.unlink(existing)
+		atx.Attempts += existing.Attempts
+	} else if len(q.atxs) >= q.size {
+		q.evictOldest()
+	}
+	q.atxs[atx.ID] = &quarantined{QuarantinedAtx: atx, msg: msg}
+	ids, ok := q.missing[atx.Missing]
+	if !ok {
+		ids = make(map[types.ATXID]struct{})
+		q.missing[atx.Missing] = ids
+	}
+	ids[atx.ID] = struct{}{}
+	metrics.AtxQuarantined.Inc()
+	metrics.AtxQuarantineSize.Set(float64(len(q.atxs)))
+}
+
+// release removes the atxs that wait for the reference from the quarantine and returns them.
+func (q *atxQuarantine) release(ref types.Hash32) []*quarantined {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := q.missing[ref]
+	if len(ids) == 0 {
+		return nil
+	}
+	rst := make([]*quarantined, 0, len(ids))
+	for id := range ids {
+		rst = append(rst, q.atxs[id])
+		delete(q.atxs, id)
+	}
+	delete(q.missing, ref)
+	slices.SortFunc(rst, func(a, b *quarantined) int {
+		return a.Quarantined.Compare(b.Quarantined)
+	})
+	metrics.AtxQuarantineReleased.Add(float64(len(rst)))
+	metrics.AtxQuarantineSize.Set(float64(len(q.atxs)))
+	return rst
+}
+
+// remove drops the atx from the quarantine, e.g. if it was stored after it was received again.
+func (q *atxQuarantine) remove(id types.ATXID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.atxs[id]; ok {
+		q.unlink(existing)
+		delete(q.atxs, id)
+		metrics.AtxQuarantineSize.Set(float64(len(q.atxs)))
+	}
+}
+
+// list returns the quarantined atxs in the order in which they were quarantined.
+func (q *atxQuarantine) list() []QuarantinedAtx {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rst := make([]QuarantinedAtx, 0, len(q.atxs))
+	for _, atx := range q.atxs {
+		rst = append(rst, atx.QuarantinedAtx)
+	}
+	slices.SortFunc(rst, func(a, b QuarantinedAtx) int {
+		return a.Quarantined.Compare(b.Quarantined)
+	})
+	return rst
+}
+
+func (q *atxQuarantine) evictOldest() {
+	var oldest *quarantined
+	for _, atx := range q.atxs {
+		if oldest == nil || atx.Quarantined.Before(oldest.Quarantined) {
+			oldest = atx
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	q.unlink(oldest)
+	delete(q.atxs, oldest.ID)
+	metrics.AtxQuarantineEvicted.Inc()
+}
+
+func (q *atxQuarantine) unlink(atx *quarantined) {
+	ids := q.missing[atx.Missing]
+	delete(ids, atx.ID)
+	if len(ids) == 0 {
+		delete(q.missing, atx.Missing)
+	}
+}
@@ -0,0 +1,50 @@
+package activation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestAtxQuarantine(t *testing.T) {
+	q := newAtxQuarantine(2)
+	now := time.Now()
+	missing := types.RandomHash()
+	first := QuarantinedAtx{ID: types.RandomATXID(), Missing: missing, Quarantined: now, Attempts: 1}
+	second := QuarantinedAtx{ID: types.RandomATXID(), Missing: missing, Quarantined: now.Add(time.Second), Attempts: 1}
+	q.add(first, []byte("first"))
+	q.add(second, []byte("second"))
+	require.Equal(t, []QuarantinedAtx{first, second}, q.list())
+
+	// the atx is quarantined again by another missing reference
+	other := types.RandomHash()
+	first.Missing = other
+	first.Quarantined = now.Add(2 * time.Second)
+	q.add(first, []byte("first"))
+	listed := q.list()
+	require.Len(t, listed, 2)
+	require.Equal(t, second, listed[0])
+	require.Equal(t, other, listed[1].Missing)
+	require.Equal(t, 2, listed[1].Attempts)
+
+	// the earliest quarantined atx is evicted
+	third := QuarantinedAtx{ID: types.RandomATXID(), Missing: other, Quarantined: now.Add(3 * time.Second), Attempts: 1}
+	q.add(third, []byte("third"))
+	require.Empty(t, q.release(missing))
+
+	released := q.release(other)
+	require.Len(t, released, 2)
+	require.Equal(t, first.ID, released[0].ID)
+	require.Equal(t, []byte("first"), released[0].msg)
+	require.Equal(t, third.ID, released[1].ID)
+	require.Empty(t, q.list())
+	require.Empty(t, q.missing)
+
+	q.add(third, []byte("third"))
+	q.remove(third.ID)
+	require.Empty(t, q.list())
+	require.Empty(t, q.release(other))
+}
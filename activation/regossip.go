@@ -0,0 +1,191 @@
+package activation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+)
+
+// regossipQuorum is the fraction of the probed peers that must have the atx for its re-gossip to be skipped.
+const regossipQuorum = 2.0 / 3
+
+// regossiper periodically re-gossips the atxs of all identities of the node that were published
+// in the current epoch. If probing is enabled the peers are asked first which of the atxs they have,
+// atxs that most of them have are skipped and the rest are published starting with the least known.
+// Publishing is rate limited across all identities, so that large multi-identity nodes don't flood the network.
+type regossiper struct {
+	logger    *zap.Logger
+	db        sql.Executor
+	clock     layerClock
+	publisher pubsub.Publisher
+	// prober is nil if probing is disabled.
+	prober  hashProber
+	peers   int
+	limiter *rate.Limiter
+
+	mu  sync.Mutex
+	ids []types.NodeID
+}
+
+func newRegossiper(
+	logger *zap.Logger,
+	db sql.Executor,
+	clock layerClock,
+	publisher pubsub.Publisher,
+	prober hashProber,
+	conf Config,
+) *regossiper {
+	limit := rate.Inf
+	if conf.RegossipRate > 0 {
+		limit = rate.Limit(conf.RegossipRate)
+	}
+	r := &regossiper{
+		logger:    logger,
+		db:        db,
+		clock:     clock,
+		publisher: publisher,
+		peers:     conf.RegossipProbePeers,
+		limiter:   rate.NewLimiter(limit, 1),
+	}
+	if conf.RegossipProbePeers > 0 {
+		r.prober = prober
+	}
+	return r
+}
+
+// register adds the identity to the set of identities whose atxs are re-gossiped.
+func (r *regossiper) register(id types.NodeID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.ids, id) {
+		r.ids = append(r.ids, id)
+	}
+}
+
+func (r *regossiper) run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.regossip(ctx); err != nil {
+				r.logger.Warn("failed to re-gossip", zap.Error(err))
+			}
+		}
+	}
+}
+
+type regossipAtx struct {
+	id   types.ATXID
+	blob []byte
+	have int
+}
+
+// regossip publishes the atxs of the registered identities that not enough peers have.
+func (r *regossiper) regossip(ctx context.Context) error {
+	r.mu.Lock()
+	ids := slices.Clone(r.ids)
+	r.mu.Unlock()
+
+	epoch := r.clock.CurrentLayer().GetEpoch()
+	var pending []*regossipAtx
+	for _, id := range ids {
+		atx, blob, err := atxBlobForRegossip(ctx, r.db, epoch, id)
+		if err != nil {
+			return err
+		}
+		if blob != nil {
+			pending = append(pending, &regossipAtx{id: atx, blob: blob})
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	pending = r.probe(ctx, pending)
+	for _, atx := range pending {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := r.publisher.Publish(ctx, pubsub.AtxProtocol, atx.blob); err != nil {
+			return fmt.Errorf("republish %s: %w", atx.id.ShortString(), err)
+		}
+		metrics.RegossipPublished.Inc()
+		r.logger.Debug("re-gossipped atx", log.ZShortStringer("atx", atx.id), zap.Int("peers", atx.have))
+	}
+	return nil
+}
+
+// probe removes the atxs that most of the probed peers have and orders the rest by the number of peers
+// that have them. All atxs are returned as is if the peers can't be probed.
+func (r *regossiper) probe(ctx context.Context, pending []*regossipAtx) []*regossipAtx {
+	if r.prober == nil {
+		return pending
+	}
+	hashes := make([]types.Hash32, 0, len(pending))
+	for _, atx := range pending {
+		hashes = append(hashes, atx.id.Hash32())
+	}
+	have, responded, err := r.prober.ProbeHashes(ctx, datastore.ATXDB, hashes, r.peers)
+	if err != nil || responded == 0 {
+		r.logger.Debug("failed to probe peers for atxs, re-gossiping all", zap.Error(err))
+		return pending
+	}
+	rst := pending[:0]
+	for _, atx := range pending {
+		atx.have = have[atx.id.Hash32()]
+		if float64(atx.have) >= regossipQuorum*float64(responded) {
+			metrics.RegossipSkipped.Inc()
+			continue
+		}
+		rst = append(rst, atx)
+	}
+	slices.SortStableFunc(rst, func(a, b *regossipAtx) int {
+		return a.have - b.have
+	})
+	r.logger.Debug("probed peers for atxs",
+		zap.Int("atxs", len(pending)),
+		zap.Int("peers", responded),
+		zap.Int("regossip", len(rst)),
+	)
+	return rst
+}
+
+// atxBlobForRegossip returns the atx of the identity published in the epoch and its blob.
+// Blob is nil if there is nothing to re-gossip.
+func atxBlobForRegossip(
+	ctx context.Context,
+	db sql.Executor,
+	epoch types.EpochID,
+	nodeID types.NodeID,
+) (types.ATXID, []byte, error) {
+	atx, err := atxs.GetIDByEpochAndNodeID(db, epoch, nodeID)
+	if errors.Is(err, sql.ErrNotFound) {
+		return types.EmptyATXID, nil, nil
+	} else if err != nil {
+		return types.EmptyATXID, nil, err
+	}
+	blob, err := atxs.GetBlob(ctx, db, atx.Bytes())
+	if err != nil {
+		return atx, nil, fmt.Errorf("get blob %s: %w", atx.ShortString(), err)
+	}
+	if len(blob) == 0 {
+		return atx, nil, nil // checkpoint
+	}
+	return atx, blob, nil
+}
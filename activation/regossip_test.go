@@ -0,0 +1,120 @@
+package activation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+)
+
+type testRegossiper struct {
+	*regossiper
+	db     *datastore.CachedDB
+	mclock *MocklayerClock
+	mpub   *mocks.MockPublisher
+	mprobe *MockhashProber
+	blobs  map[types.ATXID][]byte
+}
+
+func newTestRegossiper(t *testing.T, conf Config, layer types.LayerID, smeshers int) (*testRegossiper, []types.ATXID) {
+	ctrl := gomock.NewController(t)
+	tr := &testRegossiper{
+		db:     datastore.NewCachedDB(sql.InMemory(), logtest.New(t)),
+		mclock: NewMocklayerClock(ctrl),
+		mpub:   mocks.NewMockPublisher(ctrl),
+		mprobe: NewMockhashProber(ctrl),
+		blobs:  map[types.ATXID][]byte{},
+	}
+	tr.regossiper = newRegossiper(logtest.New(t).Zap(), tr.db, tr.mclock, tr.mpub, tr.mprobe, conf)
+	tr.mclock.EXPECT().CurrentLayer().Return(layer).AnyTimes()
+
+	var ids []types.ATXID
+	for i := 0; i < smeshers; i++ {
+		sig, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		atx := newActivationTx(t,
+			sig, 0, types.EmptyATXID, types.EmptyATXID, nil,
+			layer.GetEpoch(), 0, 1, types.Address{}, 1, &types.NIPost{})
+		require.NoError(t, atxs.Add(tr.db, atx))
+		blob, err := atxs.GetBlob(context.Background(), tr.db, atx.ID().Bytes())
+		require.NoError(t, err)
+		tr.blobs[atx.ID()] = blob
+		tr.register(sig.NodeID())
+		ids = append(ids, atx.ID())
+	}
+	// atxs of identities that are not registered are not re-gossiped
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(tr.db, newActivationTx(t,
+		sig, 0, types.EmptyATXID, types.EmptyATXID, nil,
+		layer.GetEpoch(), 0, 1, types.Address{}, 1, &types.NIPost{})))
+	return tr, ids
+}
+
+func (tr *testRegossiper) expectPublished(t *testing.T, ids ...types.ATXID) {
+	var published []types.ATXID
+	tr.mpub.EXPECT().Publish(gomock.Any(), pubsub.AtxProtocol, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, blob []byte) error {
+			for id, expected := range tr.blobs {
+				if string(expected) == string(blob) {
+					published = append(published, id)
+				}
+			}
+			return nil
+		}).Times(len(ids))
+	t.Cleanup(func() {
+		require.Equal(t, ids, published)
+	})
+}
+
+func TestRegossiper(t *testing.T) {
+	layer := types.LayerID(10)
+
+	t.Run("without probing", func(t *testing.T) {
+		tr, ids := newTestRegossiper(t, Config{}, layer, 3)
+		tr.expectPublished(t, ids...)
+		require.NoError(t, tr.regossip(context.Background()))
+	})
+
+	t.Run("skips atxs that peers have", func(t *testing.T) {
+		tr, ids := newTestRegossiper(t, Config{RegossipProbePeers: 3}, layer, 3)
+		tr.mprobe.EXPECT().ProbeHashes(gomock.Any(), datastore.ATXDB, gomock.Len(3), 3).
+			Return(map[types.Hash32]int{ids[0].Hash32(): 2, ids[1].Hash32(): 1}, 3, nil)
+		// the least known atx is published first
+		tr.expectPublished(t, ids[2], ids[1])
+		require.NoError(t, tr.regossip(context.Background()))
+	})
+
+	t.Run("publishes all if peers can't be probed", func(t *testing.T) {
+		tr, ids := newTestRegossiper(t, Config{RegossipProbePeers: 3}, layer, 2)
+		tr.mprobe.EXPECT().ProbeHashes(gomock.Any(), datastore.ATXDB, gomock.Len(2), 3).
+			Return(nil, 0, errors.New("no peers"))
+		tr.expectPublished(t, ids...)
+		require.NoError(t, tr.regossip(context.Background()))
+	})
+
+	t.Run("nothing to regossip", func(t *testing.T) {
+		tr, _ := newTestRegossiper(t, Config{RegossipProbePeers: 3}, layer, 0)
+		require.NoError(t, tr.regossip(context.Background()))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		tr, _ := newTestRegossiper(t, Config{RegossipRate: 0.001}, layer, 2)
+		tr.mpub.EXPECT().Publish(gomock.Any(), pubsub.AtxProtocol, gomock.Any())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.Error(t, tr.regossip(ctx))
+	})
+}
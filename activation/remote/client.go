@@ -0,0 +1,221 @@
+// Package remote offloads verification of post proofs to the verifiers on other hosts.
+//
+// Nodes on small machines may not keep up with validation of atxs when many of them are published
+// at once. Verifier sends the proofs to the pool of trusted remote verifiers and verifies them
+// locally only when none of the remote verifiers is available.
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/spacemeshos/post/shared"
+	"github.com/spacemeshos/post/verifying"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Config of the remote verifiers.
+type Config struct {
+	// Endpoints of the remote verifiers, proofs are verified locally if empty.
+	// Remote verifiers must be trusted, the node accepts their results without checking them.
+	Endpoints []string `mapstructure:"endpoints"`
+	// CACert is the certificate of the authority that signed the certificates of the remote verifiers.
+	// Connections are not encrypted if CACert, Cert and Key are empty.
+	CACert string `mapstructure:"ca-cert"`
+	// Cert and Key are used to authenticate the node to the remote verifiers.
+	Cert string `mapstructure:"cert"`
+	Key  string `mapstructure:"key"`
+
+	// Timeout of a single request.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Cooldown is the time during which the failed endpoint is not used.
+	Cooldown time.Duration `mapstructure:"cooldown"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:  30 * time.Second,
+		Cooldown: time.Minute,
+	}
+}
+
+type endpoint struct {
+	address string
+	conn    *grpc.ClientConn
+	// failedUntil is the unix time in nanoseconds until which the endpoint is not used.
+	failedUntil atomic.Int64
+}
+
+// Verifier sends post proofs to the remote verifiers in round robin order. Failed endpoints are skipped
+// for the cooldown period, proofs are verified with the local verifier if all endpoints failed.
+// It implements activation.PostVerifier.
+type Verifier struct {
+	logger    *zap.Logger
+	local     activation.PostVerifier
+	endpoints []*endpoint
+	next      atomic.Uint64
+	timeout   time.Duration
+	cooldown  time.Duration
+}
+
+var _ activation.PostVerifier = (*Verifier)(nil)
+
+// NewVerifier creates the verifier for the configured endpoints. Connections are established lazily.
+// Local verifier is closed together with the Verifier.
+func NewVerifier(logger *zap.Logger, cfg Config, local activation.PostVerifier) (*Verifier, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errors.New("no remote verifiers")
+	}
+	creds, err := clientCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verifier{
+		logger:   logger,
+		local:    local,
+		timeout:  cfg.Timeout,
+		cooldown: cfg.Cooldown,
+	}
+	for _, address := range cfg.Endpoints {
+		conn, err := grpc.Dial(address,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithDefaultCallOptions(grpc.CallContentSubtype(rpc.Scale)),
+		)
+		if err != nil {
+			v.closeConns()
+			return nil, fmt.Errorf("dial remote verifier %s: %w", address, err)
+		}
+		v.endpoints = append(v.endpoints, &endpoint{address: address, conn: conn})
+	}
+	logger.Info("verifying post proofs remotely", zap.Strings("endpoints", cfg.Endpoints))
+	return v, nil
+}
+
+func clientCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	if cfg.CACert == "" && cfg.Cert == "" && cfg.Key == "" {
+		return insecure.NewCredentials(), nil
+	}
+	if cfg.CACert == "" || cfg.Cert == "" || cfg.Key == "" {
+		return nil, errors.New("ca certificate, certificate and key are required for tls")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	caCert, err := os.ReadFile(cfg.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("load ca certificate: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("setup ca certificate")
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      certPool,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// Verify sends the proof to the remote verifiers. Proofs without activation.PostVerifyRequest
+// in the context are verified locally.
+func (v *Verifier) Verify(
+	ctx context.Context,
+	p *shared.Proof,
+	m *shared.ProofMetadata,
+	opts ...verifying.OptionFunc,
+) error {
+	verifyReq, ok := activation.PostVerifyRequestFromContext(ctx)
+	if !ok {
+		verifiedLocally.Inc()
+		return v.local.Verify(ctx, p, m, opts...)
+	}
+	req := &VerifyPostRequest{
+		NodeID:          types.BytesToNodeID(m.NodeId),
+		CommitmentAtxID: types.BytesToATXID(m.CommitmentAtxId),
+		Proof:           types.Post(*p),
+		NumUnits:        m.NumUnits,
+		Challenge:       m.Challenge,
+		LabelsPerUnit:   m.LabelsPerUnit,
+		SubsetSeed:      verifyReq.SubsetSeed,
+	}
+	for range v.endpoints {
+		e := v.pick()
+		if e == nil {
+			break
+		}
+		resp, err := v.invoke(ctx, e, req)
+		if err == nil {
+			verifiedRemotely.Inc()
+			return resp.err()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		endpointFailures.WithLabelValues(e.address).Inc()
+		e.failedUntil.Store(time.Now().Add(v.cooldown).UnixNano())
+		v.logger.Warn("remote post verification failed",
+			zap.String("endpoint", e.address),
+			zap.Duration("cooldown", v.cooldown),
+			zap.Error(err),
+		)
+	}
+	fallbacks.Inc()
+	return v.local.Verify(ctx, p, m, opts...)
+}
+
+// pick returns the next endpoint that is not cooling down after failure, nil if all endpoints failed.
+func (v *Verifier) pick() *endpoint {
+	now := time.Now().UnixNano()
+	for range v.endpoints {
+		e := v.endpoints[v.next.Add(1)%uint64(len(v.endpoints))]
+		if e.failedUntil.Load() <= now {
+			return e
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) invoke(ctx context.Context, e *endpoint, req *VerifyPostRequest) (*VerifyPostResponse, error) {
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+	return rpc.Invoke[VerifyPostRequest, VerifyPostResponse](ctx, e.conn, serviceName, "VerifyPost", rpc.Scale, req)
+}
+
+func (r *VerifyPostResponse) err() error {
+	switch r.Result {
+	case ResultValid:
+		return nil
+	case ResultInvalidIndex:
+		return &verifying.ErrInvalidIndex{Index: int(r.InvalidIndex)}
+	}
+	return fmt.Errorf("remote verifier: %s", r.Error)
+}
+
+func (v *Verifier) closeConns() {
+	for _, e := range v.endpoints {
+		e.conn.Close()
+	}
+}
+
+// Close closes the connections to the remote verifiers and the local verifier.
+func (v *Verifier) Close() error {
+	v.closeConns()
+	return v.local.Close()
+}
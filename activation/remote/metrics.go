@@ -0,0 +1,26 @@
+package remote
+
+import (
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+const namespace = "remote_post_verifier"
+
+var (
+	verifications = metrics.NewCounter(
+		"verifications",
+		namespace,
+		"number of post proofs by the verifier that produced the result",
+		[]string{"verifier"},
+	)
+	verifiedRemotely = verifications.WithLabelValues("remote")
+	verifiedLocally  = verifications.WithLabelValues("local")
+	fallbacks        = verifications.WithLabelValues("fallback")
+
+	endpointFailures = metrics.NewCounter(
+		"endpoint_failures",
+		namespace,
+		"number of failed requests to the remote verifier",
+		[]string{"endpoint"},
+	)
+)
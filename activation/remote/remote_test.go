@@ -0,0 +1,151 @@
+package remote_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/spacemeshos/post/shared"
+	"github.com/spacemeshos/post/verifying"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/remote"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func launchVerifier(tb testing.TB, svc *remote.Service) string {
+	server := grpc.NewServer()
+	svc.RegisterService(server)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return server.Serve(lis)
+	})
+	tb.Cleanup(func() {
+		server.Stop()
+		require.NoError(tb, eg.Wait())
+	})
+	return lis.Addr().String()
+}
+
+// closedAddress returns the address on which nothing is listening.
+func closedAddress(tb testing.TB) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	addr := lis.Addr().String()
+	require.NoError(tb, lis.Close())
+	return addr
+}
+
+func proof() (*shared.Proof, *shared.ProofMetadata) {
+	id := types.RandomNodeID()
+	return &shared.Proof{Nonce: 7, Indices: []byte{1, 2, 3}, Pow: 11}, &shared.ProofMetadata{
+		NodeId:          id.Bytes(),
+		CommitmentAtxId: types.RandomATXID().Bytes(),
+		NumUnits:        4,
+		Challenge:       types.RandomHash().Bytes(),
+		LabelsPerUnit:   1024,
+	}
+}
+
+func TestRemoteVerifier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	served := activation.NewMockPostVerifier(ctrl)
+	addr := launchVerifier(t, remote.NewService(zaptest.NewLogger(t), served,
+		activation.DefaultPostConfig(), activation.DefaultPostSetupOpts().Scrypt))
+
+	local := activation.NewMockPostVerifier(ctrl)
+	rcfg := remote.DefaultConfig()
+	rcfg.Endpoints = []string{addr}
+	verifier, err := remote.NewVerifier(zaptest.NewLogger(t), rcfg, local)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		local.EXPECT().Close()
+		require.NoError(t, verifier.Close())
+	})
+
+	p, m := proof()
+	ctx := activation.WithPostVerifyRequest(context.Background(), activation.PostVerifyRequest{
+		SubsetSeed: []byte("seed"),
+	})
+	t.Run("valid", func(t *testing.T) {
+		served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any(), gomock.Any()).Return(nil)
+		require.NoError(t, verifier.Verify(ctx, p, m))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any(), gomock.Any()).Return(errors.New("bad proof"))
+		require.ErrorContains(t, verifier.Verify(ctx, p, m), "bad proof")
+	})
+	t.Run("invalid index", func(t *testing.T) {
+		served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any(), gomock.Any()).
+			Return(&verifying.ErrInvalidIndex{Index: 5})
+		err := verifier.Verify(ctx, p, m)
+		var invalidIdx *verifying.ErrInvalidIndex
+		require.ErrorAs(t, err, &invalidIdx)
+		require.Equal(t, 5, invalidIdx.Index)
+	})
+	t.Run("full proof", func(t *testing.T) {
+		// without subset seed only the scrypt params are passed
+		served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any()).Return(nil)
+		ctx := activation.WithPostVerifyRequest(context.Background(), activation.PostVerifyRequest{})
+		require.NoError(t, verifier.Verify(ctx, p, m))
+	})
+	t.Run("without request", func(t *testing.T) {
+		local.EXPECT().Verify(gomock.Any(), p, m, gomock.Any()).Return(nil)
+		require.NoError(t, verifier.Verify(context.Background(), p, m, verifying.SelectedIndex(1)))
+	})
+	t.Run("verifier closed", func(t *testing.T) {
+		served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any(), gomock.Any()).Return(activation.ErrVerifierClosed)
+		local.EXPECT().Verify(gomock.Any(), p, m).Return(nil)
+		require.NoError(t, verifier.Verify(ctx, p, m))
+	})
+}
+
+func TestRemoteVerifier_Fallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	served := activation.NewMockPostVerifier(ctrl)
+	addr := launchVerifier(t, remote.NewService(zaptest.NewLogger(t), served,
+		activation.DefaultPostConfig(), activation.DefaultPostSetupOpts().Scrypt))
+
+	local := activation.NewMockPostVerifier(ctrl)
+	cfg := remote.DefaultConfig()
+	cfg.Endpoints = []string{closedAddress(t), addr}
+	verifier, err := remote.NewVerifier(zaptest.NewLogger(t), cfg, local)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		local.EXPECT().Close()
+		require.NoError(t, verifier.Close())
+	})
+
+	p, m := proof()
+	ctx := activation.WithPostVerifyRequest(context.Background(), activation.PostVerifyRequest{})
+	// the failed endpoint is skipped during the cooldown
+	served.EXPECT().Verify(gomock.Any(), p, m, gomock.Any()).Return(nil).Times(3)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, verifier.Verify(ctx, p, m))
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, verifier.Verify(canceled, p, m), context.Canceled)
+
+	t.Run("no endpoints available", func(t *testing.T) {
+		cfg.Endpoints = []string{closedAddress(t)}
+		verifier, err := remote.NewVerifier(zaptest.NewLogger(t), cfg, local)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			local.EXPECT().Close()
+			require.NoError(t, verifier.Close())
+		})
+		local.EXPECT().Verify(gomock.Any(), p, m).Return(nil).Times(2)
+		for i := 0; i < 2; i++ {
+			require.NoError(t, verifier.Verify(ctx, p, m))
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package remote
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/spacemeshos/post/config"
+	"github.com/spacemeshos/post/shared"
+	"github.com/spacemeshos/post/verifying"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+const serviceName = "spacemesh.postverifier.v1.PostVerifierService"
+
+type verifierServer interface {
+	VerifyPost(context.Context, *VerifyPostRequest) (*VerifyPostResponse, error)
+}
+
+// serviceDesc describes the service without protobuf definitions, messages are encoded with scale.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*verifierServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(serviceName, "VerifyPost", verifierServer.VerifyPost),
+	},
+	Metadata: "activation/remote/types.go",
+}
+
+// Service verifies post proofs with the local verifier on behalf of the remote nodes.
+// It is meant to run on the node with spare cpu that is trusted by the nodes that use it.
+type Service struct {
+	logger   *zap.Logger
+	verifier activation.PostVerifier
+	k3       uint
+	scrypt   config.ScryptParams
+}
+
+// NewService creates the service. Proofs are verified with the post config and scrypt parameters
+// of the network, the same that are used by Validator.Post.
+func NewService(
+	logger *zap.Logger,
+	verifier activation.PostVerifier,
+	cfg activation.PostConfig,
+	scrypt config.ScryptParams,
+) *Service {
+	return &Service{
+		logger:   logger,
+		verifier: verifier,
+		k3:       cfg.K3,
+		scrypt:   scrypt,
+	}
+}
+
+// RegisterService registers the service with the grpc server.
+func (s *Service) RegisterService(server *grpc.Server) {
+	server.RegisterService(&serviceDesc, s)
+}
+
+// RegisterHandlerService is a no-op, the service is not exposed over the json api.
+func (s *Service) RegisterHandlerService(*runtime.ServeMux) error {
+	return nil
+}
+
+// String returns the name of this service.
+func (s *Service) String() string {
+	return "PostVerifierService"
+}
+
+func (s *Service) VerifyPost(ctx context.Context, req *VerifyPostRequest) (*VerifyPostResponse, error) {
+	opts := []verifying.OptionFunc{verifying.WithLabelScryptParams(s.scrypt)}
+	if len(req.SubsetSeed) > 0 {
+		opts = append(opts, verifying.Subset(s.k3, req.SubsetSeed))
+	}
+	err := s.verifier.Verify(ctx,
+		(*shared.Proof)(&req.Proof),
+		&shared.ProofMetadata{
+			NodeId:          req.NodeID.Bytes(),
+			CommitmentAtxId: req.CommitmentAtxID.Bytes(),
+			NumUnits:        req.NumUnits,
+			Challenge:       req.Challenge,
+			LabelsPerUnit:   req.LabelsPerUnit,
+		},
+		opts...,
+	)
+	var invalidIdx *verifying.ErrInvalidIndex
+	switch {
+	case err == nil:
+		return &VerifyPostResponse{Result: ResultValid}, nil
+	case ctx.Err() != nil:
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, activation.ErrVerifierClosed):
+		return nil, status.Error(codes.Unavailable, err.Error())
+	case errors.As(err, &invalidIdx):
+		s.logger.Debug("invalid post index", log.ZShortStringer("proof_node_id", req.NodeID), zap.Error(err))
+		return &VerifyPostResponse{
+			Result:       ResultInvalidIndex,
+			InvalidIndex: uint32(invalidIdx.Index),
+			Error:        errorMessage(err),
+		}, nil
+	}
+	s.logger.Debug("invalid post", log.ZShortStringer("proof_node_id", req.NodeID), zap.Error(err))
+	return &VerifyPostResponse{Result: ResultInvalid, Error: errorMessage(err)}, nil
+}
+
+// errorMessage truncates the error to the size allowed in the response.
+func errorMessage(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrorSize {
+		msg = msg[:maxErrorSize]
+	}
+	return msg
+}
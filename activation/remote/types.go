@@ -0,0 +1,40 @@
+package remote
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+//go:generate scalegen
+
+// maxErrorSize is the largest error message in the response.
+const maxErrorSize = 1024
+
+const (
+	// ResultValid is returned for the valid proof.
+	ResultValid uint8 = iota
+	// ResultInvalid is returned for the invalid proof, the reason is in the error.
+	ResultInvalid
+	// ResultInvalidIndex is returned for the proof with the invalid index that is used
+	// in the malfeasance proof.
+	ResultInvalidIndex
+)
+
+// VerifyPostRequest requests verification of the post proof. The proof is verified with
+// the post config and scrypt parameters of the remote verifier.
+type VerifyPostRequest struct {
+	NodeID          types.NodeID
+	CommitmentAtxID types.ATXID
+	Proof           types.Post
+	NumUnits        uint32
+	Challenge       []byte `scale:"max=32"`
+	LabelsPerUnit   uint64
+	// SubsetSeed selects the random subset of indices that are verified, all indices if empty.
+	SubsetSeed []byte `scale:"max=256"`
+}
+
+// VerifyPostResponse is the result of the verification.
+type VerifyPostResponse struct {
+	Result       uint8
+	InvalidIndex uint32
+	Error        string `scale:"max=1024"`
+}
@@ -58,6 +58,18 @@ type Validator struct {
 	cfg          PostConfig
 	scrypt       config.ScryptParams
 	postVerifier PostVerifier
+	golden       *types.GoldenATXs
+}
+
+// ValidatorOpt modifies Validator.
+type ValidatorOpt func(*Validator)
+
+// WithValidatorGoldenATXs sets the golden atxs of the network upgrades. Without it the validator knows only
+// the golden atx that is passed to the methods.
+func WithValidatorGoldenATXs(golden *types.GoldenATXs) ValidatorOpt {
+	return func(v *Validator) {
+		v.golden = golden
+	}
 }
 
 // NewValidator returns a new NIPost validator.
@@ -67,8 +79,24 @@ func NewValidator(
 	cfg PostConfig,
 	scrypt config.ScryptParams,
 	postVerifier PostVerifier,
+	opts ...ValidatorOpt,
 ) *Validator {
-	return &Validator{db, poetDb, cfg, scrypt, postVerifier}
+	v := &Validator{
+		db:           db,
+		poetDb:       poetDb,
+		cfg:          cfg,
+		scrypt:       scrypt,
+		postVerifier: postVerifier,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// isGolden returns true if the id is the golden atx for the atxs published in the epoch.
+func (v *Validator) isGolden(id, goldenATXID types.ATXID, epoch types.EpochID) bool {
+	return id == goldenATXID || v.golden.Valid(id, epoch)
 }
 
 // NIPost validates a NIPost, given a node id and expected challenge. It returns an error if the NIPost is invalid.
@@ -175,6 +203,8 @@ func (v *Validator) Post(
 		verifyOpts = append(verifyOpts, verifying.Subset(v.cfg.K3, options.postSubsetSeed))
 	}
 
+	ctx = WithPostVerifyRequest(ctx, PostVerifyRequest{SubsetSeed: options.postSubsetSeed})
+
 	start := time.Now()
 	if err := v.postVerifier.Verify(ctx, p, m, verifyOpts...); err != nil {
 		return fmt.Errorf("verify PoST: %w", err)
@@ -238,7 +268,7 @@ func (v *Validator) InitialNIPostChallenge(
 		return errors.New("nil commitment atx in initial post challenge")
 	}
 
-	if *challenge.CommitmentATX != goldenATXID {
+	if !v.isGolden(*challenge.CommitmentATX, goldenATXID, challenge.PublishEpoch) {
 		commitmentAtx, err := atxs.GetAtxHeader(*challenge.CommitmentATX)
 		if err != nil {
 			return &ErrAtxNotFound{Id: *challenge.CommitmentATX, source: err}
@@ -299,6 +329,13 @@ func (v *Validator) PositioningAtx(
 	if posAtx.PublishEpoch >= pubepoch {
 		return fmt.Errorf("positioning atx epoch (%v) must be before %v", posAtx.PublishEpoch, pubepoch)
 	}
+	if v.golden != nil {
+		// the chain is reset by the upgrade, atxs published before it can't be used as positioning atxs
+		if golden := v.golden.ForEpoch(pubepoch); posAtx.PublishEpoch < golden.Epoch {
+			return fmt.Errorf("positioning atx epoch (%v) must not be before the golden atx epoch (%v)",
+				posAtx.PublishEpoch, golden.Epoch)
+		}
+	}
 	return nil
 }
 
@@ -448,12 +485,12 @@ func (v *Validator) verifyChainDeps(
 			return fmt.Errorf("validating previous ATX %s chain: %w", atx.PrevATXID.ShortString(), err)
 		}
 	}
-	if atx.PositioningATX != goldenATXID {
+	if !v.isGolden(atx.PositioningATX, goldenATXID, atx.PublishEpoch) {
 		if err := v.verifyChainWithOpts(ctx, atx.PositioningATX, goldenATXID, opts); err != nil {
 			return fmt.Errorf("validating positioning ATX %s chain: %w", atx.PositioningATX.ShortString(), err)
 		}
 	}
-	if atx.CommitmentATX != nil && *atx.CommitmentATX != goldenATXID {
+	if atx.CommitmentATX != nil && !v.isGolden(*atx.CommitmentATX, goldenATXID, atx.PublishEpoch) {
 		if err := v.verifyChainWithOpts(ctx, *atx.CommitmentATX, goldenATXID, opts); err != nil {
 			return fmt.Errorf("validating commitment ATX %s chain: %w", atx.CommitmentATX.ShortString(), err)
 		}
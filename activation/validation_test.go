@@ -451,6 +451,43 @@ func Test_Validation_PositioningAtx(t *testing.T) {
 	})
 }
 
+func Test_Validation_GoldenATXs(t *testing.T) {
+	genesis := types.ATXID{9, 9, 9}
+	upgrade := types.ATXID{8, 8, 8}
+	golden := types.NewGoldenATXs(genesis)
+	require.NoError(t, golden.Add(10, upgrade))
+
+	ctrl := gomock.NewController(t)
+	v := NewValidator(nil, nil, DefaultPostConfig(), config.ScryptParams{}, nil, WithValidatorGoldenATXs(golden))
+
+	t.Run("positioning atx before the upgrade", func(t *testing.T) {
+		posAtxId := types.ATXID{1, 2, 3}
+		atxProvider := NewMockatxProvider(ctrl)
+		atxProvider.EXPECT().GetAtxHeader(posAtxId).Return(&types.ActivationTxHeader{
+			NIPostChallenge: types.NIPostChallenge{PublishEpoch: 8},
+		}, nil).Times(2)
+
+		require.NoError(t, v.PositioningAtx(posAtxId, atxProvider, genesis, 9))
+		err := v.PositioningAtx(posAtxId, atxProvider, upgrade, 10)
+		require.ErrorContains(t, err, "must not be before the golden atx epoch")
+	})
+	t.Run("golden atx as positioning atx", func(t *testing.T) {
+		atxProvider := NewMockatxProvider(ctrl)
+		require.NoError(t, v.PositioningAtx(upgrade, atxProvider, upgrade, 10))
+	})
+	t.Run("commitment to the golden atx before the upgrade", func(t *testing.T) {
+		atxProvider := NewMockatxProvider(ctrl)
+		challenge := &types.NIPostChallenge{PublishEpoch: 11, CommitmentATX: &genesis}
+		require.NoError(t, v.InitialNIPostChallenge(challenge, atxProvider, upgrade))
+	})
+	t.Run("commitment to the golden atx of the future upgrade", func(t *testing.T) {
+		atxProvider := NewMockatxProvider(ctrl)
+		atxProvider.EXPECT().GetAtxHeader(upgrade).Return(nil, sql.ErrNotFound)
+		challenge := &types.NIPostChallenge{PublishEpoch: 5, CommitmentATX: &upgrade}
+		require.ErrorIs(t, v.InitialNIPostChallenge(challenge, atxProvider, genesis), sql.ErrNotFound)
+	})
+}
+
 func Test_Validate_NumUnits(t *testing.T) {
 	// Arrange
 	layers := types.GetLayersPerEpoch()
@@ -610,7 +647,7 @@ func TestVerifyChainDeps(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		v := NewMockPostVerifier(ctrl)
-		v.EXPECT().Verify(ctx, (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
+		v.EXPECT().Verify(gomock.Any(), (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
 
 		validator := NewValidator(db, nil, DefaultPostConfig(), config.ScryptParams{}, v)
 		err = validator.VerifyChain(ctx, vAtx.ID(), goldenATXID)
@@ -635,7 +672,7 @@ func TestVerifyChainDeps(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		v := NewMockPostVerifier(ctrl)
-		v.EXPECT().Verify(ctx, (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
+		v.EXPECT().Verify(gomock.Any(), (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
 
 		validator := NewValidator(db, nil, DefaultPostConfig(), config.ScryptParams{}, v)
 		err = validator.VerifyChain(ctx, vAtx.ID(), goldenATXID)
@@ -661,7 +698,7 @@ func TestVerifyChainDeps(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		v := NewMockPostVerifier(ctrl)
-		v.EXPECT().Verify(ctx, (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
+		v.EXPECT().Verify(gomock.Any(), (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any())
 		validator := NewValidator(db, nil, DefaultPostConfig(), config.ScryptParams{}, v)
 		err = validator.VerifyChain(ctx, vAtx.ID(), goldenATXID)
 		require.ErrorIs(t, err, &InvalidChainError{ID: invalidAtx.ID()})
@@ -733,7 +770,7 @@ func TestVerifyChainDeps(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		v := NewMockPostVerifier(ctrl)
 		expected := errors.New("post is invalid")
-		v.EXPECT().Verify(ctx, (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any()).Return(expected)
+		v.EXPECT().Verify(gomock.Any(), (*shared.Proof)(atx.NIPost.Post), gomock.Any(), gomock.Any()).Return(expected)
 		validator := NewValidator(db, nil, DefaultPostConfig(), config.ScryptParams{}, v)
 		err = validator.VerifyChain(ctx, vAtx.ID(), goldenATXID)
 		require.ErrorIs(t, err, &InvalidChainError{ID: vAtx.ID()})
@@ -0,0 +1,210 @@
+package activation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/activation/metrics"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+)
+
+// maxWatchdogAlerts is the number of the most recent alerts kept for every watched identity.
+const maxWatchdogAlerts = 10
+
+// WatchdogConfig configures the watchdog of the identities of the operator.
+type WatchdogConfig struct {
+	// Identities are the watched identities, they don't have to be registered on this node.
+	// Every identity is expected to publish an atx in every epoch.
+	Identities []types.NodeID `mapstructure:"identities"`
+	// Coinbase is the coinbase of the atxs published by the watched identities.
+	// Atxs with any other coinbase are unexpected. Not checked if empty.
+	Coinbase string `mapstructure:"coinbase"`
+}
+
+// UnexpectedAtx is an atx of the watched identity that wasn't expected to be published.
+type UnexpectedAtx struct {
+	ID      types.ATXID
+	Publish types.EpochID
+	Reason  string
+}
+
+// WatchdogStatus is the state of the watched identity.
+type WatchdogStatus struct {
+	ID types.NodeID
+	// LastAtx is the most recent atx of the identity, empty if no atx was seen.
+	LastAtx     types.ATXID
+	LastPublish types.EpochID
+	// Missing are the most recent publish epochs in which the identity didn't publish an atx.
+	Missing []types.EpochID
+	// Unexpected are the most recent atxs of the identity that weren't expected.
+	Unexpected []UnexpectedAtx
+}
+
+// Watchdog watches the atxs of the identities of the operator, including identities running on other nodes.
+// It alerts if an identity didn't publish an atx in an epoch and if an atx appears that wasn't expected
+// to be published, which might mean that the key of the identity is compromised.
+type Watchdog struct {
+	logger   *zap.Logger
+	db       sql.Executor
+	clock    layerClock
+	coinbase *types.Address
+
+	mu       sync.Mutex
+	ids      []types.NodeID
+	status   map[types.NodeID]*WatchdogStatus
+	received map[types.NodeID]map[types.EpochID]types.ATXID
+}
+
+// NewWatchdog creates a watchdog of the identities.
+// Coinbase is the expected coinbase of the atxs, it isn't checked if nil.
+func NewWatchdog(
+	logger *zap.Logger,
+	db sql.Executor,
+	clock layerClock,
+	ids []types.NodeID,
+	coinbase *types.Address,
+) *Watchdog {
+	w := &Watchdog{
+		logger:   logger,
+		db:       db,
+		clock:    clock,
+		coinbase: coinbase,
+		status:   map[types.NodeID]*WatchdogStatus{},
+		received: map[types.NodeID]map[types.EpochID]types.ATXID{},
+	}
+	for _, id := range ids {
+		if _, exists := w.status[id]; exists {
+			continue
+		}
+		w.ids = append(w.ids, id)
+		w.status[id] = &WatchdogStatus{ID: id}
+		w.received[id] = map[types.EpochID]types.ATXID{}
+	}
+	return w
+}
+
+// OnAtx checks the atx if it belongs to one of the watched identities.
+func (w *Watchdog) OnAtx(header *types.ActivationTxHeader) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status, exists := w.status[header.NodeID]
+	if !exists {
+		return
+	}
+	if header.PublishEpoch >= status.LastPublish {
+		status.LastAtx = header.ID
+		status.LastPublish = header.PublishEpoch
+	}
+	var reason string
+	if prev, exists := w.received[header.NodeID][header.PublishEpoch]; exists && prev != header.ID {
+		reason = fmt.Sprintf("second atx in the epoch, first %s", prev.ShortString())
+	} else if w.coinbase != nil && header.Coinbase != *w.coinbase {
+		reason = fmt.Sprintf("coinbase %s", header.Coinbase)
+	}
+	w.received[header.NodeID][header.PublishEpoch] = header.ID
+	if reason == "" {
+		return
+	}
+	status.Unexpected = appendBounded(status.Unexpected, UnexpectedAtx{
+		ID:      header.ID,
+		Publish: header.PublishEpoch,
+		Reason:  reason,
+	})
+	metrics.WatchdogUnexpected.Inc()
+	w.logger.Error("unexpected atx of watched identity, the key of the identity might be compromised",
+		log.ZShortStringer("smesherID", header.NodeID),
+		log.ZShortStringer("atx", header.ID),
+		zap.Uint32("publish_epoch", header.PublishEpoch.Uint32()),
+		zap.String("reason", reason),
+	)
+	events.EmitWatchedAtxUnexpected(header.NodeID, header.PublishEpoch, header.ID, reason)
+}
+
+// Status returns the state of all watched identities in the order of the configuration.
+func (w *Watchdog) Status() []WatchdogStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rst := make([]WatchdogStatus, 0, len(w.ids))
+	for _, id := range w.ids {
+		status := *w.status[id]
+		status.Missing = slices.Clone(status.Missing)
+		status.Unexpected = slices.Clone(status.Unexpected)
+		rst = append(rst, status)
+	}
+	return rst
+}
+
+// Run checks at the start of every epoch that all watched identities published an atx in the previous one.
+func (w *Watchdog) Run(ctx context.Context) error {
+	if len(w.ids) == 0 {
+		return nil
+	}
+	for epoch := w.clock.CurrentLayer().GetEpoch(); ; epoch++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.clock.AwaitLayer((epoch + 1).FirstLayer()):
+		}
+		w.prune(epoch)
+		if err := w.check(epoch); err != nil {
+			w.logger.Warn("failed to check atxs of watched identities",
+				zap.Uint32("epoch", epoch.Uint32()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// check alerts about the watched identities that didn't publish an atx in the epoch.
+func (w *Watchdog) check(publish types.EpochID) error {
+	for _, id := range w.ids {
+		_, err := atxs.GetIDByEpochAndNodeID(w.db, publish, id)
+		switch {
+		case err == nil:
+			continue
+		case !errors.Is(err, sql.ErrNotFound):
+			return fmt.Errorf("atx of %s: %w", id.ShortString(), err)
+		}
+		w.mu.Lock()
+		status := w.status[id]
+		status.Missing = appendBounded(status.Missing, publish)
+		w.mu.Unlock()
+		metrics.WatchdogMissing.Inc()
+		w.logger.Error("watched identity didn't publish an atx",
+			log.ZShortStringer("smesherID", id),
+			zap.Uint32("publish_epoch", publish.Uint32()),
+		)
+		events.EmitWatchedAtxMissing(id, publish)
+	}
+	return nil
+}
+
+// prune forgets the atxs received for the epochs before the publish epoch.
+func (w *Watchdog) prune(publish types.EpochID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, received := range w.received {
+		for epoch := range received {
+			if epoch < publish {
+				delete(received, epoch)
+			}
+		}
+	}
+}
+
+func appendBounded[T any](items []T, item T) []T {
+	items = append(items, item)
+	if len(items) > maxWatchdogAlerts {
+		items = slices.Delete(items, 0, len(items)-maxWatchdogAlerts)
+	}
+	return items
+}
@@ -0,0 +1,96 @@
+package activation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+)
+
+func watchedHeader(
+	id types.ATXID,
+	node types.NodeID,
+	publish types.EpochID,
+	coinbase types.Address,
+) *types.ActivationTxHeader {
+	return &types.ActivationTxHeader{
+		NIPostChallenge: types.NIPostChallenge{PublishEpoch: publish},
+		ID:              id,
+		NodeID:          node,
+		Coinbase:        coinbase,
+	}
+}
+
+func TestWatchdog_OnAtx(t *testing.T) {
+	coinbase := types.Address{1}
+	watched, other := types.NodeID{1}, types.NodeID{2}
+	w := NewWatchdog(logtest.New(t).Zap(), sql.InMemory(), nil, []types.NodeID{watched, watched}, &coinbase)
+
+	w.OnAtx(watchedHeader(types.ATXID{1}, watched, 3, coinbase))
+	w.OnAtx(watchedHeader(types.ATXID{1}, watched, 3, coinbase))
+	w.OnAtx(watchedHeader(types.ATXID{2}, other, 3, types.Address{2}))
+	require.Equal(t, []WatchdogStatus{{ID: watched, LastAtx: types.ATXID{1}, LastPublish: 3}}, w.Status())
+
+	w.OnAtx(watchedHeader(types.ATXID{3}, watched, 3, coinbase))
+	w.OnAtx(watchedHeader(types.ATXID{4}, watched, 4, types.Address{2}))
+	w.OnAtx(watchedHeader(types.ATXID{5}, watched, 2, coinbase))
+	status := w.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, types.ATXID{4}, status[0].LastAtx)
+	require.Equal(t, types.EpochID(4), status[0].LastPublish)
+	require.Len(t, status[0].Unexpected, 2)
+	require.Equal(t, types.ATXID{3}, status[0].Unexpected[0].ID)
+	require.Contains(t, status[0].Unexpected[0].Reason, "second atx")
+	require.Equal(t, types.ATXID{4}, status[0].Unexpected[1].ID)
+	require.Contains(t, status[0].Unexpected[1].Reason, "coinbase")
+}
+
+func TestWatchdog_Missing(t *testing.T) {
+	db := sql.InMemory()
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	missing := types.RandomNodeID()
+	require.NoError(t, atxs.Add(db, newActivationTx(t,
+		sig, 0, types.EmptyATXID, types.EmptyATXID, nil,
+		1, 0, 1, types.Address{}, 1, &types.NIPost{})))
+
+	mclock := NewMocklayerClock(gomock.NewController(t))
+	mclock.EXPECT().CurrentLayer().Return(types.EpochID(1).FirstLayer())
+	epochs := map[types.LayerID]chan struct{}{}
+	for epoch := types.EpochID(2); epoch <= 3; epoch++ {
+		epochs[epoch.FirstLayer()] = make(chan struct{})
+	}
+	mclock.EXPECT().AwaitLayer(gomock.Any()).DoAndReturn(func(layer types.LayerID) <-chan struct{} {
+		return epochs[layer]
+	}).AnyTimes()
+	w := NewWatchdog(logtest.New(t).Zap(), db, mclock, []types.NodeID{sig.NodeID(), missing}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return w.Run(ctx)
+	})
+	close(epochs[types.EpochID(2).FirstLayer()])
+	require.Eventually(t, func() bool {
+		return len(w.Status()[1].Missing) == 1
+	}, time.Second, 10*time.Millisecond)
+	close(epochs[types.EpochID(3).FirstLayer()])
+	require.Eventually(t, func() bool {
+		return len(w.Status()[1].Missing) == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, eg.Wait())
+
+	status := w.Status()
+	require.Equal(t, []types.EpochID{2}, status[0].Missing)
+	require.Equal(t, []types.EpochID{1, 2}, status[1].Missing)
+}
@@ -0,0 +1,169 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+const (
+	// ActiveSetProjectionPath serves the projection of the active set of the epoch, from the atxs received
+	// by the node so far. Defaults to the next epoch. Repeated smesher parameters are hex encoded node ids,
+	// whose share of the projected weight is estimated.
+	ActiveSetProjectionPath = "/v1/activeset/projection"
+
+	// MaxProjectionSmeshers is the largest number of smeshers that can be requested at once.
+	MaxProjectionSmeshers = 100
+)
+
+// ActiveSetProjection is the weight of the atxs that target the epoch. Atxs received after the deadline
+// are not included into the active set generated by the node, atxs of the smeshers that are proven
+// malicious before the epoch start are excluded later.
+type ActiveSetProjection struct {
+	Epoch      uint32    `json:"epoch"`
+	Deadline   time.Time `json:"deadline"`
+	ATXs       int       `json:"atxs"`
+	Weight     uint64    `json:"weight"`
+	LateATXs   int       `json:"late_atxs"`
+	LateWeight uint64    `json:"late_weight"`
+	// Smeshers are listed in the order of the request.
+	Smeshers []SmesherProjection `json:"smeshers,omitempty"`
+}
+
+// SmesherProjection is the share of the smesher in the projected weight of the epoch.
+// ATX is empty if the node didn't receive the atx of the smesher that targets the epoch.
+type SmesherProjection struct {
+	Smesher string  `json:"smesher"`
+	ATX     string  `json:"atx,omitempty"`
+	Weight  uint64  `json:"weight"`
+	Late    bool    `json:"late"`
+	Share   float64 `json:"share"`
+}
+
+// ActiveSetService exposes the projection of the active set that is maintained as atxs are received.
+// It is served only on the json gateway.
+type ActiveSetService struct {
+	tracker activeSetProjection
+	clock   layerClock
+}
+
+// NewActiveSetService creates a new active set service.
+func NewActiveSetService(tracker activeSetProjection, clock layerClock) *ActiveSetService {
+	return &ActiveSetService{tracker: tracker, clock: clock}
+}
+
+// RegisterService is a no-op, active set service doesn't have a grpc api.
+func (s *ActiveSetService) RegisterService(*grpc.Server) {}
+
+// RegisterHandlerService registers the active set routes with the json gateway.
+func (s *ActiveSetService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	return mux.HandlePath(http.MethodGet, ActiveSetProjectionPath, s.handleProjection)
+}
+
+// String returns the name of this service.
+func (s *ActiveSetService) String() string {
+	return "ActiveSetService"
+}
+
+// Projection returns the projected active set of the epoch and the shares of the smeshers in it.
+func (s *ActiveSetService) Projection(
+	ctx context.Context,
+	epoch types.EpochID,
+	smeshers []types.NodeID,
+) (*ActiveSetProjection, error) {
+	if !s.tracker.Ready() {
+		return nil, apiError(codes.Unavailable, ReasonNotSynced, "active set candidates are not loaded yet")
+	}
+	summary, exists := s.tracker.Projection(epoch)
+	if !exists {
+		return nil, apiError(codes.NotFound, ReasonNotFound, fmt.Sprintf("epoch %d is not tracked", epoch))
+	}
+	rst := &ActiveSetProjection{
+		Epoch:      epoch.Uint32(),
+		Deadline:   s.tracker.Deadline(epoch).UTC(),
+		ATXs:       summary.ATXs,
+		Weight:     summary.Weight,
+		LateATXs:   summary.LateATXs,
+		LateWeight: summary.LateWeight,
+	}
+	for _, smesher := range smeshers {
+		projection := SmesherProjection{Smesher: hex.EncodeToString(smesher[:])}
+		candidate, err := s.tracker.Candidate(epoch, smesher)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+		default:
+			projection.ATX = hex.EncodeToString(candidate.ID[:])
+			projection.Weight = candidate.Weight
+			projection.Late = !candidate.Received.Before(rst.Deadline)
+			if !projection.Late && summary.Weight > 0 {
+				projection.Share = float64(candidate.Weight) / float64(summary.Weight)
+			}
+		}
+		rst.Smeshers = append(rst.Smeshers, projection)
+	}
+	return rst, nil
+}
+
+func (s *ActiveSetService) handleProjection(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epoch, exists, err := epochParam(r, "epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if !exists {
+		epoch = s.clock.CurrentLayer().GetEpoch() + 1
+	}
+	smeshers, err := smeshersParam(r, "smesher")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.Projection(r.Context(), epoch, smeshers)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+// smeshersParam parses the repeated query parameter with hex encoded node ids.
+func smeshersParam(r *http.Request, name string) ([]types.NodeID, error) {
+	return parseSmeshers(name, r.URL.Query()[name])
+}
+
+// parseSmeshers parses hex encoded node ids, at most MaxProjectionSmeshers.
+func parseSmeshers(name string, values []string) ([]types.NodeID, error) {
+	if len(values) > MaxProjectionSmeshers {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("too many smeshers (%d), at most %d", len(values), MaxProjectionSmeshers))
+	}
+	smeshers := make([]types.NodeID, 0, len(values))
+	for _, value := range values {
+		parsed, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("parse %s: %s", name, err))
+		}
+		if l := len(parsed); l != types.NodeIDSize {
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("invalid %s id length (%d), expected (%d)", name, l, types.NodeIDSize))
+		}
+		smeshers = append(smeshers, types.BytesToNodeID(parsed))
+	}
+	return smeshers, nil
+}
@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
+)
+
+func TestActiveSetService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracker := NewMockactiveSetProjection(ctrl)
+	clock := NewMocklayerClock(ctrl)
+	svc := NewActiveSetService(tracker, clock)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	deadline := time.Unix(1000, 0).UTC()
+	tracker.EXPECT().Deadline(gomock.Any()).Return(deadline).AnyTimes()
+	summary := activecandidates.Summary{ATXs: 3, Weight: 400, LateATXs: 1, LateWeight: 50}
+	smesher, late, missing := types.NodeID{1}, types.NodeID{2}, types.NodeID{3}
+	tracker.EXPECT().Candidate(types.EpochID(5), smesher).Return(&activecandidates.Candidate{
+		ID: types.ATXID{1}, Node: smesher, Weight: 100, Received: deadline.Add(-time.Second),
+	}, nil).AnyTimes()
+	tracker.EXPECT().Candidate(types.EpochID(5), late).Return(&activecandidates.Candidate{
+		ID: types.ATXID{2}, Node: late, Weight: 50, Received: deadline,
+	}, nil).AnyTimes()
+	tracker.EXPECT().Candidate(types.EpochID(5), missing).Return(nil, sql.ErrNotFound).AnyTimes()
+
+	t.Run("not ready", func(t *testing.T) {
+		tracker.EXPECT().Ready().Return(false)
+		_, err := svc.Projection(context.Background(), 5, nil)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonNotSynced, reason)
+	})
+	t.Run("not tracked", func(t *testing.T) {
+		tracker.EXPECT().Ready().Return(true)
+		tracker.EXPECT().Projection(types.EpochID(7)).Return(activecandidates.Summary{}, false)
+		_, err := svc.Projection(context.Background(), 7, nil)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonNotFound, reason)
+	})
+	t.Run("projection", func(t *testing.T) {
+		tracker.EXPECT().Ready().Return(true)
+		tracker.EXPECT().Projection(types.EpochID(5)).Return(summary, true)
+		rst, err := svc.Projection(context.Background(), 5, []types.NodeID{smesher, late, missing})
+		require.NoError(t, err)
+		require.Equal(t, &ActiveSetProjection{
+			Epoch:      5,
+			Deadline:   deadline,
+			ATXs:       3,
+			Weight:     400,
+			LateATXs:   1,
+			LateWeight: 50,
+			Smeshers: []SmesherProjection{
+				{
+					Smesher: hex.EncodeToString(smesher[:]),
+					ATX:     hex.EncodeToString(types.ATXID{1}.Bytes()),
+					Weight:  100,
+					Share:   0.25,
+				},
+				{
+					Smesher: hex.EncodeToString(late[:]),
+					ATX:     hex.EncodeToString(types.ATXID{2}.Bytes()),
+					Weight:  50,
+					Late:    true,
+				},
+				{Smesher: hex.EncodeToString(missing[:])},
+			},
+		}, rst)
+	})
+	t.Run("json", func(t *testing.T) {
+		tracker.EXPECT().Ready().Return(true)
+		tracker.EXPECT().Projection(types.EpochID(5)).Return(summary, true)
+		clock.EXPECT().CurrentLayer().Return(types.EpochID(4).FirstLayer())
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?smesher=%s",
+			cfg.JSONListener, ActiveSetProjectionPath, hex.EncodeToString(smesher[:])))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst ActiveSetProjection
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.EqualValues(t, 5, rst.Epoch)
+		require.Len(t, rst.Smeshers, 1)
+		require.Equal(t, 0.25, rst.Smeshers[0].Share)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?epoch=5&smesher=0102", cfg.JSONListener, ActiveSetProjectionPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,200 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/beacon"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+const (
+	// BeaconStatePath serves the state of the beacon protocol in the epoch selected by the epoch parameter,
+	// the current epoch is used if it is not set. The response is BeaconProtocolState.
+	BeaconStatePath = "/v1/admin/beacon/state"
+	// BeaconInjectPath sets the beacon of the epoch, so that a stalled private network can proceed.
+	// It is refused unless unsafe beacon injection is enabled in the config of the node.
+	// The request body is BeaconInjectRequest, the response is BeaconProtocolState of the epoch.
+	BeaconInjectPath = "/v1/admin/beacon/inject"
+)
+
+// BeaconProtocolState is the state of the beacon protocol in the epoch. Beacons are hex encoded
+// and empty if they are not known. Counters of the protocol run are set only if Running is true.
+type BeaconProtocolState struct {
+	Epoch                     uint32               `json:"epoch"`
+	Beacon                    string               `json:"beacon,omitempty"`
+	NextBeacon                string               `json:"next_beacon,omitempty"`
+	InProtocol                bool                 `json:"in_protocol"`
+	Running                   bool                 `json:"running"`
+	Round                     uint32               `json:"round"`
+	EpochWeight               uint64               `json:"epoch_weight"`
+	Participants              int                  `json:"participants"`
+	Miners                    int                  `json:"miners"`
+	ValidProposals            int                  `json:"valid_proposals"`
+	PotentiallyValidProposals int                  `json:"potentially_valid_proposals"`
+	Proposers                 int                  `json:"proposers"`
+	Voters                    int                  `json:"voters"`
+	BallotBeacons             []BeaconBallotWeight `json:"ballot_beacons"`
+}
+
+// BeaconBallotWeight is the beacon reported in ballots of the epoch with the weight of the ballots.
+type BeaconBallotWeight struct {
+	Beacon        string  `json:"beacon"`
+	Ballots       int     `json:"ballots"`
+	Eligibilities int     `json:"eligibilities"`
+	Weight        float64 `json:"weight"`
+}
+
+// BeaconStateRequest selects the epoch of the BeaconState method, defaults to the current epoch.
+type BeaconStateRequest struct {
+	Epoch *uint32 `json:"epoch,omitempty"`
+}
+
+// BeaconInjectRequest is the hex encoded beacon that is used in the epoch.
+// Reason is recorded in the log of the node for the audit.
+type BeaconInjectRequest struct {
+	Epoch  uint32 `json:"epoch"`
+	Beacon string `json:"beacon"`
+	Reason string `json:"reason"`
+}
+
+// WithBeaconProtocol enables inspection of the beacon protocol.
+func WithBeaconProtocol(protocol beaconProtocol, clock layerClock) AdminServiceOpt {
+	return func(s *AdminService) {
+		s.beacon = protocol
+		s.clock = clock
+	}
+}
+
+// BeaconState returns the state of the beacon protocol in the epoch.
+func (a AdminService) BeaconState(epoch types.EpochID) (*BeaconProtocolState, error) {
+	st, err := a.beacon.ProtocolState(epoch)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := &BeaconProtocolState{
+		Epoch:                     st.Epoch.Uint32(),
+		InProtocol:                st.InProtocol,
+		Running:                   st.Running,
+		Round:                     uint32(st.Round),
+		EpochWeight:               st.EpochWeight,
+		Participants:              st.Participants,
+		Miners:                    st.Miners,
+		ValidProposals:            st.ValidProposals,
+		PotentiallyValidProposals: st.PotentiallyValidProposals,
+		Proposers:                 st.Proposers,
+		Voters:                    st.Voters,
+		BallotBeacons:             make([]BeaconBallotWeight, 0, len(st.BallotBeacons)),
+	}
+	if st.Beacon != types.EmptyBeacon {
+		rst.Beacon = hex.EncodeToString(st.Beacon[:])
+	}
+	if st.NextBeacon != types.EmptyBeacon {
+		rst.NextBeacon = hex.EncodeToString(st.NextBeacon[:])
+	}
+	for _, reported := range st.BallotBeacons {
+		rst.BallotBeacons = append(rst.BallotBeacons, BeaconBallotWeight{
+			Beacon:        hex.EncodeToString(reported.Beacon[:]),
+			Ballots:       reported.Ballots,
+			Eligibilities: reported.Eligibilities,
+			Weight:        reported.Weight,
+		})
+	}
+	return rst, nil
+}
+
+// InjectBeacon sets the beacon of the epoch if unsafe beacon injection is enabled.
+// Requester is recorded in the log together with the reason.
+func (a AdminService) InjectBeacon(req *BeaconInjectRequest, requester string) error {
+	if req.Reason == "" {
+		return apiError(codes.InvalidArgument, ReasonMissingArgument, "reason is required")
+	}
+	var value types.Beacon
+	if err := decodeHexParam("beacon", req.Beacon, value[:]); err != nil {
+		return err
+	}
+	reason := fmt.Sprintf("%s (requested by %s)", req.Reason, requester)
+	err := a.beacon.InjectBeacon(types.EpochID(req.Epoch), value, reason)
+	switch {
+	case errors.Is(err, beacon.ErrInjectionDisabled):
+		return apiError(codes.FailedPrecondition, ReasonBeaconInjectionDisabled, err.Error())
+	case err != nil:
+		return apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return nil
+}
+
+var errBeaconDisabled = apiError(codes.Unavailable, ReasonInternal, "inspection of the beacon protocol is not enabled")
+
+func (a AdminService) beaconState(_ context.Context, req *BeaconStateRequest) (*BeaconProtocolState, error) {
+	if a.beacon == nil {
+		return nil, errBeaconDisabled
+	}
+	if req.Epoch != nil {
+		return a.BeaconState(types.EpochID(*req.Epoch))
+	}
+	return a.BeaconState(a.clock.CurrentLayer().GetEpoch())
+}
+
+func (a AdminService) injectBeacon(ctx context.Context, req *BeaconInjectRequest) (*BeaconProtocolState, error) {
+	requester := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		requester = p.Addr.String()
+	}
+	return a.injectBeaconBy(req, requester)
+}
+
+// injectBeaconBy injects the beacon and returns the state of the beacon protocol in the epoch.
+func (a AdminService) injectBeaconBy(req *BeaconInjectRequest, requester string) (*BeaconProtocolState, error) {
+	if a.beacon == nil {
+		return nil, errBeaconDisabled
+	}
+	if err := a.InjectBeacon(req, requester); err != nil {
+		return nil, err
+	}
+	return a.BeaconState(types.EpochID(req.Epoch))
+}
+
+func (a AdminService) handleBeaconState(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epoch, exists, err := epochParam(r, "epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	req := &BeaconStateRequest{}
+	if exists {
+		value := epoch.Uint32()
+		req.Epoch = &value
+	}
+	rst, err := a.beaconState(r.Context(), req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (a AdminService) handleInjectBeacon(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req BeaconInjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := a.injectBeaconBy(&req, r.RemoteAddr)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
@@ -0,0 +1,320 @@
+package grpcserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// redacted replaces values of the config fields that may identify the operator.
+const redacted = "<redacted>"
+
+// sensitiveConfigKeys are substrings of the config keys (case insensitive) whose values are
+// redacted in the diagnostics bundle: local paths, addresses of the operator's machines,
+// credentials and accounts.
+var sensitiveConfigKeys = []string{
+	"dir", "folder", "file", "path",
+	"key", "secret", "token", "password",
+	"coinbase", "address", "listener", "direct", "url", "endpoint",
+	"testing",
+}
+
+// bundleInfo is the node information that is added to the diagnostics bundle.
+type bundleInfo struct {
+	config  any
+	version string
+	commit  string
+}
+
+// BundleNode is the summary of the node in the diagnostics bundle.
+type BundleNode struct {
+	Version    string    `json:"version"`
+	Commit     string    `json:"commit"`
+	GoVersion  string    `json:"go_version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	CPUs       int       `json:"cpus"`
+	Goroutines int       `json:"goroutines"`
+	Created    time.Time `json:"created"`
+	// Errors of the sections that couldn't be collected.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// BundlePeers is the summary of the connected peers in the diagnostics bundle.
+// Addresses of the peers are not included.
+type BundlePeers struct {
+	Total    int          `json:"total"`
+	Inbound  int          `json:"inbound"`
+	Outbound int          `json:"outbound"`
+	Peers    []BundlePeer `json:"peers"`
+}
+
+// BundlePeer is a single connected peer.
+type BundlePeer struct {
+	ID          string             `json:"id"`
+	Tags        []string           `json:"tags,omitempty"`
+	Connections []BundleConnection `json:"connections"`
+}
+
+// BundleConnection is a connection to the peer.
+type BundleConnection struct {
+	Outbound bool          `json:"outbound"`
+	Uptime   time.Duration `json:"uptime"`
+}
+
+// BundleDatabase is the summary of the state database in the diagnostics bundle.
+type BundleDatabase struct {
+	SchemaVersion int   `json:"schema_version"`
+	PageSize      int64 `json:"page_size"`
+	Pages         int64 `json:"pages"`
+	FreePages     int64 `json:"free_pages"`
+	Size          int64 `json:"size"`
+	Queries       int   `json:"queries"`
+}
+
+// DiagnosticsBundleRequest is the request of the DiagnosticsBundle method.
+type DiagnosticsBundleRequest struct{}
+
+// DiagnosticsBundleResponse is the diagnostics bundle with the suggested name of the archive.
+type DiagnosticsBundleResponse struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// DiagnosticsBundle writes the gzipped tar archive with the recent events, anonymized config,
+// connected peers, database stats and goroutine and heap profiles.
+// Sections that fail are skipped, the errors are listed in node.json.
+func (a AdminService) DiagnosticsBundle(ctx context.Context) ([]byte, error) {
+	node := BundleNode{
+		Version:   a.bundle.version,
+		Commit:    a.bundle.commit,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		Created:   time.Now(),
+		Errors:    map[string]string{},
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	add := func(name string, collect func() ([]byte, error)) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := collect()
+		if err != nil {
+			node.Errors[name] = err.Error()
+			return nil
+		}
+		return writeTarFile(archive, name, node.Created, data)
+	}
+	sections := []struct {
+		name    string
+		collect func() ([]byte, error)
+	}{
+		{"config.json", a.bundleConfig},
+		{"events.json", bundleEvents},
+		{"peers.json", a.bundlePeers},
+		{"database.json", a.bundleDatabase},
+		{"goroutine.txt", bundleProfile("goroutine", 2)},
+		{"heap.pb.gz", bundleProfile("heap", 0)},
+	}
+	for _, section := range sections {
+		if err := add(section.name, section.collect); err != nil {
+			return nil, err
+		}
+	}
+	node.Goroutines = runtime.NumGoroutine()
+	if err := add("node.json", func() ([]byte, error) { return json.MarshalIndent(node, "", "  ") }); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("close archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("close gzip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (a AdminService) diagnosticsBundle(
+	ctx context.Context,
+	_ *DiagnosticsBundleRequest,
+) (*DiagnosticsBundleResponse, error) {
+	data, err := a.DiagnosticsBundle(ctx)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return &DiagnosticsBundleResponse{
+		Name: fmt.Sprintf("spacemesh-bundle-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z")),
+		Data: data,
+	}, nil
+}
+
+func (a AdminService) handleBundle(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	bundle, err := a.diagnosticsBundle(r.Context(), &DiagnosticsBundleRequest{})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.Name))
+	w.Write(bundle.Data)
+}
+
+func writeTarFile(archive *tar.Writer, name string, modified time.Time, data []byte) error {
+	if err := archive.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modified,
+	}); err != nil {
+		return fmt.Errorf("write header for %s: %w", name, err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a AdminService) bundleConfig() ([]byte, error) {
+	if a.bundle.config == nil {
+		return nil, fmt.Errorf("config is not available")
+	}
+	encoded, err := json.Marshal(a.bundle.config)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	var cfg any
+	if err := json.Unmarshal(encoded, &cfg); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return json.MarshalIndent(anonymizeConfig("", cfg), "", "  ")
+}
+
+// anonymizeConfig redacts non-empty values of the sensitive keys in the decoded json config.
+func anonymizeConfig(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, field := range v {
+			v[k] = anonymizeConfig(k, field)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = anonymizeConfig(key, item)
+		}
+		return v
+	case nil:
+		return nil
+	}
+	lower := strings.ToLower(key)
+	for _, sensitive := range sensitiveConfigKeys {
+		if strings.Contains(lower, sensitive) {
+			if s, ok := value.(string); ok && s == "" {
+				return value
+			}
+			return redacted
+		}
+	}
+	return value
+}
+
+func bundleEvents() ([]byte, error) {
+	recent := events.RecentUserEvents()
+	encoded := make([]json.RawMessage, 0, len(recent))
+	for _, ev := range recent {
+		data, err := protojson.Marshal(ev.Event)
+		if err != nil {
+			return nil, fmt.Errorf("encode event %d: %w", ev.Seq, err)
+		}
+		encoded = append(encoded, data)
+	}
+	return json.MarshalIndent(encoded, "", "  ")
+}
+
+func (a AdminService) bundlePeers() ([]byte, error) {
+	if a.p == nil {
+		return nil, fmt.Errorf("peers are not available")
+	}
+	var rst BundlePeers
+	for _, p := range a.p.GetPeers() {
+		info := a.p.ConnectedPeerInfo(p)
+		if info == nil {
+			continue
+		}
+		peer := BundlePeer{ID: info.ID.String(), Tags: info.Tags}
+		for _, c := range info.Connections {
+			peer.Connections = append(peer.Connections, BundleConnection{Outbound: c.Outbound, Uptime: c.Uptime})
+			if c.Outbound {
+				rst.Outbound++
+			} else {
+				rst.Inbound++
+			}
+		}
+		rst.Peers = append(rst.Peers, peer)
+	}
+	rst.Total = len(rst.Peers)
+	return json.MarshalIndent(rst, "", "  ")
+}
+
+func (a AdminService) bundleDatabase() ([]byte, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database is not available")
+	}
+	var rst BundleDatabase
+	for _, pragma := range []struct {
+		name  string
+		value *int64
+	}{
+		{"page_size", &rst.PageSize},
+		{"page_count", &rst.Pages},
+		{"freelist_count", &rst.FreePages},
+	} {
+		if _, err := a.db.Exec(fmt.Sprintf("PRAGMA %s;", pragma.name), nil, func(stmt *sql.Statement) bool {
+			*pragma.value = stmt.ColumnInt64(0)
+			return true
+		}); err != nil {
+			return nil, fmt.Errorf("read %s: %w", pragma.name, err)
+		}
+	}
+	if _, err := a.db.Exec("PRAGMA user_version;", nil, func(stmt *sql.Statement) bool {
+		rst.SchemaVersion = stmt.ColumnInt(0)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("read user_version: %w", err)
+	}
+	rst.Size = rst.PageSize * rst.Pages
+	rst.Queries = a.db.QueryCount()
+	return json.MarshalIndent(rst, "", "  ")
+}
+
+func bundleProfile(name string, debug int) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return nil, fmt.Errorf("profile %s not found", name)
+		}
+		var buf bytes.Buffer
+		if err := profile.WriteTo(&buf, debug); err != nil {
+			return nil, fmt.Errorf("write profile %s: %w", name, err)
+		}
+		return buf.Bytes(), nil
+	}
+}
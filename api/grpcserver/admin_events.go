@@ -0,0 +1,108 @@
+package grpcserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/events"
+)
+
+// EventRecord is the user event with its sequence number, severity and cause,
+// which are not part of the api event.
+type EventRecord struct {
+	Seq      uint64          `json:"seq"`
+	Severity events.Severity `json:"severity"`
+	Cause    events.Cause    `json:"cause,omitempty"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// RecentEvents returns the most recent user events kept in memory with a sequence number greater than after,
+// that are selected by the filter.
+func (a AdminService) RecentEvents(after uint64, filter events.UserEventFilter) ([]EventRecord, error) {
+	rst := []EventRecord{}
+	for _, ev := range events.RecentUserEvents() {
+		if ev.Seq <= after || !filter.Match(&ev) {
+			continue
+		}
+		data, err := protojson.Marshal(ev.Event)
+		if err != nil {
+			return nil, apiError(codes.Internal, ReasonInternal, fmt.Sprintf("encode event %d: %s", ev.Seq, err))
+		}
+		rst = append(rst, EventRecord{Seq: ev.Seq, Severity: ev.Severity, Cause: ev.Cause, Event: data})
+	}
+	return rst, nil
+}
+
+func (a AdminService) handleEvents(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	query := r.URL.Query()
+	var after uint64
+	if value := query.Get("after"); value != "" {
+		var err error
+		after, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("parse after: %s", err)))
+			return
+		}
+	}
+	filter, err := parseEventFilter(query["severity"], query["cause"])
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := a.RecentEvents(after, filter)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+// parseEventFilter parses the filter from the lowest severity and the causes of the selected events.
+// Only the first severity is used.
+func parseEventFilter(severities, causes []string) (events.UserEventFilter, error) {
+	var filter events.UserEventFilter
+	if len(severities) > 0 {
+		severity, err := events.ParseSeverity(severities[0])
+		if err != nil {
+			return filter, apiError(codes.InvalidArgument, ReasonInvalidArgument, err.Error())
+		}
+		filter.MinSeverity = severity
+	}
+	for _, cause := range causes {
+		filter.Causes = append(filter.Causes, events.Cause(cause))
+	}
+	return filter, nil
+}
+
+// streamEvents sends the events selected by the filter until the stream or the subscription is closed.
+func streamEvents(
+	sub *events.BufferedSubscription[events.UserEvent],
+	filter events.UserEventFilter,
+	stream pb.AdminService_EventsStreamServer,
+) error {
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Full():
+			return status.Errorf(codes.Canceled, "buffer is full")
+		case ev := <-sub.Out():
+			if !filter.Match(&ev) {
+				continue
+			}
+			if err := stream.Send(ev.Event); err != nil {
+				return fmt.Errorf("send to stream: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// LoggingPath serves the level and the encoder of the module loggers on GET and changes them on POST.
+// The request body of POST is LoggingRequest, the response of both is the list of log.ModuleState.
+const LoggingPath = "/v1/admin/logging"
+
+// AllModules selects all modules in LoggingRequest.
+const AllModules = "*"
+
+// LoggingRequest changes the level and the encoder of the module, empty values are not changed.
+// If Reset is true the configured level and encoder of the module are restored instead.
+// Changes are kept after restart of the node.
+type LoggingRequest struct {
+	Module  string `json:"module"`
+	Level   string `json:"level"`
+	Encoder string `json:"encoder"`
+	Reset   bool   `json:"reset"`
+}
+
+// LoggingStateRequest is the request of the Logging method.
+type LoggingStateRequest struct{}
+
+// LoggingResponse is the state of the module loggers.
+type LoggingResponse struct {
+	Modules []log.ModuleState `json:"modules"`
+}
+
+// WithLogModules enables changing the loggers of the modules.
+func WithLogModules(modules *log.Modules) AdminServiceOpt {
+	return func(s *AdminService) {
+		s.logModules = modules
+	}
+}
+
+// UpdateLogging applies the change of the request to the module loggers and returns their state.
+func (a AdminService) UpdateLogging(req LoggingRequest) ([]log.ModuleState, error) {
+	if a.logModules == nil {
+		return nil, errLoggingDisabled
+	}
+	if req.Module == "" {
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, "module is required")
+	}
+	names := []string{req.Module}
+	if req.Module == AllModules {
+		names = names[:0]
+		for _, module := range a.logModules.List() {
+			names = append(names, module.Name)
+		}
+	}
+	for _, name := range names {
+		var err error
+		if req.Reset {
+			err = a.logModules.Reset(name)
+		} else {
+			err = a.logModules.Set(name, log.ModuleConfig{Level: req.Level, Encoder: req.Encoder})
+		}
+		switch {
+		case errors.Is(err, log.ErrUnknownModule):
+			return nil, apiError(codes.NotFound, ReasonNotFound, err.Error())
+		case errors.Is(err, log.ErrInvalidModuleConfig):
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, err.Error())
+		case err != nil:
+			return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+	}
+	return a.logModules.List(), nil
+}
+
+var errLoggingDisabled = apiError(codes.Unavailable, ReasonInternal, "changing the loggers is not enabled")
+
+func (a AdminService) logging(context.Context, *LoggingStateRequest) (*LoggingResponse, error) {
+	if a.logModules == nil {
+		return nil, errLoggingDisabled
+	}
+	return &LoggingResponse{Modules: a.logModules.List()}, nil
+}
+
+func (a AdminService) updateLogging(_ context.Context, req *LoggingRequest) (*LoggingResponse, error) {
+	modules, err := a.UpdateLogging(*req)
+	if err != nil {
+		return nil, err
+	}
+	return &LoggingResponse{Modules: modules}, nil
+}
+
+func (a AdminService) handleLogging(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	rst, err := a.logging(r.Context(), &LoggingStateRequest{})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst.Modules)
+}
+
+func (a AdminService) handleUpdateLogging(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req LoggingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := a.updateLogging(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst.Modules)
+}
@@ -0,0 +1,81 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+)
+
+// AtxQuarantinePath serves the atxs that failed validation because their references are missing.
+// They are validated again once the missing reference arrives. The response is AtxQuarantine.
+const AtxQuarantinePath = "/v1/admin/atx/quarantine"
+
+// AtxQuarantineRequest is the request of the AtxQuarantine method.
+type AtxQuarantineRequest struct{}
+
+// AtxQuarantine is the list of quarantined atxs in the order in which they were quarantined.
+type AtxQuarantine struct {
+	ATXs []QuarantinedATX `json:"atxs"`
+}
+
+// QuarantinedATX is the json encoding of the quarantined atx. Missing is the hex encoded id
+// of the referenced atx or the reference of the poet proof that is missing.
+type QuarantinedATX struct {
+	ID           string    `json:"id"`
+	Smesher      string    `json:"smesher"`
+	PublishEpoch uint32    `json:"publish_epoch"`
+	Missing      string    `json:"missing"`
+	Peer         string    `json:"peer,omitempty"`
+	Quarantined  time.Time `json:"quarantined"`
+	Attempts     int       `json:"attempts"`
+	Reason       string    `json:"reason"`
+}
+
+// WithAtxQuarantine enables inspection of the atx quarantine.
+func WithAtxQuarantine(quarantine atxQuarantine) AdminServiceOpt {
+	return func(s *AdminService) {
+		s.quarantine = quarantine
+	}
+}
+
+// AtxQuarantine returns the quarantined atxs.
+func (a AdminService) AtxQuarantine() *AtxQuarantine {
+	quarantined := a.quarantine.Quarantined()
+	rst := &AtxQuarantine{ATXs: make([]QuarantinedATX, 0, len(quarantined))}
+	for _, atx := range quarantined {
+		rst.ATXs = append(rst.ATXs, QuarantinedATX{
+			ID:           hex.EncodeToString(atx.ID[:]),
+			Smesher:      hex.EncodeToString(atx.SmesherID[:]),
+			PublishEpoch: atx.PublishEpoch.Uint32(),
+			Missing:      hex.EncodeToString(atx.Missing[:]),
+			Peer:         atx.Peer.String(),
+			Quarantined:  atx.Quarantined,
+			Attempts:     atx.Attempts,
+			Reason:       atx.Reason,
+		})
+	}
+	return rst
+}
+
+func (a AdminService) atxQuarantine(context.Context, *AtxQuarantineRequest) (*AtxQuarantine, error) {
+	if a.quarantine == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "inspection of the atx quarantine is not enabled")
+	}
+	return a.AtxQuarantine(), nil
+}
+
+func (a AdminService) handleAtxQuarantine(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	rst, err := a.atxQuarantine(r.Context(), &AtxQuarantineRequest{})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
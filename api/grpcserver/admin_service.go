@@ -2,10 +2,14 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
@@ -19,30 +23,146 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/export"
+	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
 const (
 	chunksize      = 1024
 	defaultNumAtxs = 4
+
+	// LastEventSeqHeader is the request metadata key used to replay events after the given sequence number.
+	LastEventSeqHeader = "last-event-seq"
+	// EventSeqHeader is the response metadata key with the sequence number of the first streamed event.
+	EventSeqHeader = "event-seq"
+	// EventSeverityHeader is the request metadata key with the lowest severity (info, warning or critical)
+	// of the streamed events.
+	EventSeverityHeader = "event-severity"
+	// EventCauseHeader is the request metadata key with the cause of the streamed events.
+	// It can be set multiple times to stream events with any of the causes.
+	EventCauseHeader = "event-cause"
+	// CheckpointBaseHeader is the request metadata key with the snapshot layer of the previously generated checkpoint.
+	// If set, the streamed checkpoint contains only changes since that checkpoint.
+	CheckpointBaseHeader = "checkpoint-base"
+
+	// CheckpointGeneratePath starts generation of the checkpoint in the background.
+	// The request body is CheckpointGenerateRequest, the response is CheckpointJob with the id of the job.
+	CheckpointGeneratePath = "/v1/admin/checkpoint/generate"
+	// CheckpointProgressPath serves the progress of the checkpoint generation job selected by the id parameter.
+	// If the id is not set, the progress of all recent jobs is returned.
+	CheckpointProgressPath = "/v1/admin/checkpoint/progress"
+	// DiagnosticsBundlePath serves the gzipped tar archive with the state of the node
+	// that users attach to bug reports.
+	DiagnosticsBundlePath = "/v1/admin/bundle"
+	// EventsPath serves the most recent user events kept in memory with their severities and causes.
+	// The events are selected with the after, severity and cause query parameters, see RecentEvents.
+	EventsPath = "/v1/admin/events"
+	// MeshExportPath exports the mesh data of the layer range into the exports directory of the node.
+	// The request body is MeshExportRequest, the response is MeshExport.
+	MeshExportPath = "/v1/admin/export"
+	// AdminGrpcService is the name of the grpc service that serves the admin methods that are not defined
+	// in the AdminService protobuf. Messages of the service are encoded in json (see rpc.JSON).
+	AdminGrpcService = "spacemesh.node.v1.AdminService"
+
+	exportsDir = "exports"
 )
 
+// CheckpointGenerateRequest selects the layer of the generated checkpoint.
+type CheckpointGenerateRequest struct {
+	SnapshotLayer uint32 `json:"snapshot_layer"`
+	NumAtxs       uint32 `json:"num_atxs"`
+}
+
+// CheckpointProgressRequest selects the checkpoint generation job by its id.
+type CheckpointProgressRequest struct {
+	ID uint64 `json:"id"`
+}
+
+// CheckpointJobsRequest is the request of the CheckpointJobs method.
+type CheckpointJobsRequest struct{}
+
+// CheckpointJobsResponse is the progress of the most recent checkpoint generation jobs.
+type CheckpointJobsResponse struct {
+	Jobs []CheckpointJob `json:"jobs"`
+}
+
+// CheckpointJob is the progress of the checkpoint generation.
+// Done and Total are the number of processed items in the current stage.
+type CheckpointJob struct {
+	ID            uint64     `json:"id"`
+	SnapshotLayer uint32     `json:"snapshot_layer"`
+	Stage         string     `json:"stage"`
+	Done          int        `json:"done"`
+	Total         int        `json:"total"`
+	Started       *time.Time `json:"started,omitempty"`
+	Finished      *time.Time `json:"finished,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// MeshExportRequest selects the layer range and the format of the mesh export.
+// Format is either ndjson or parquet, ndjson is used if it is empty.
+type MeshExportRequest struct {
+	From   uint32 `json:"from"`
+	To     uint32 `json:"to"`
+	Format string `json:"format"`
+}
+
+// MeshExport is the directory with the exported files and their manifest.
+type MeshExport struct {
+	Dir      string           `json:"dir"`
+	Manifest *export.Manifest `json:"manifest"`
+}
+
 // AdminService exposes endpoints for node administration.
 type AdminService struct {
 	db      *sql.Database
 	dataDir string
 	recover func()
 	p       peers
+
+	checkpoints checkpointScheduler
+	bundle      bundleInfo
+	logModules  *log.Modules
+	beacon      beaconProtocol
+	clock       layerClock
+	quarantine  atxQuarantine
+}
+
+// AdminServiceOpt modifies AdminService.
+type AdminServiceOpt func(*AdminService)
+
+// WithBundleConfig sets the node config that is included in the diagnostics bundle after anonymization.
+func WithBundleConfig(cfg any) AdminServiceOpt {
+	return func(s *AdminService) {
+		s.bundle.config = cfg
+	}
+}
+
+// WithBundleVersion sets the version of the node that is included in the diagnostics bundle.
+func WithBundleVersion(version, commit string) AdminServiceOpt {
+	return func(s *AdminService) {
+		s.bundle.version = version
+		s.bundle.commit = commit
+	}
 }
 
 // NewAdminService creates a new admin grpc service.
-func NewAdminService(db *sql.Database, dataDir string, p peers) *AdminService {
-	return &AdminService{
-		db:      db,
-		dataDir: dataDir,
+func NewAdminService(
+	db *sql.Database,
+	dataDir string,
+	p peers,
+	checkpoints checkpointScheduler,
+	opts ...AdminServiceOpt,
+) *AdminService {
+	s := &AdminService{
+		db:          db,
+		dataDir:     dataDir,
+		checkpoints: checkpoints,
 		recover: func() {
 			go func() {
 				// Allow time for the response to be sent.
@@ -52,14 +172,90 @@ func NewAdminService(db *sql.Database, dataDir string, p peers) *AdminService {
 		},
 		p: p,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // RegisterService registers this service with a grpc server instance.
 func (a AdminService) RegisterService(server *grpc.Server) {
 	pb.RegisterAdminServiceServer(server, a)
+	server.RegisterService(&adminDesc, a)
+}
+
+type adminServer interface {
+	generateCheckpoint(context.Context, *CheckpointGenerateRequest) (*CheckpointJob, error)
+	checkpointProgress(context.Context, *CheckpointProgressRequest) (*CheckpointJob, error)
+	checkpointJobs(context.Context, *CheckpointJobsRequest) (*CheckpointJobsResponse, error)
+	diagnosticsBundle(context.Context, *DiagnosticsBundleRequest) (*DiagnosticsBundleResponse, error)
+	meshExport(context.Context, *MeshExportRequest) (*MeshExport, error)
+	logging(context.Context, *LoggingStateRequest) (*LoggingResponse, error)
+	updateLogging(context.Context, *LoggingRequest) (*LoggingResponse, error)
+	beaconState(context.Context, *BeaconStateRequest) (*BeaconProtocolState, error)
+	injectBeacon(context.Context, *BeaconInjectRequest) (*BeaconProtocolState, error)
+	atxQuarantine(context.Context, *AtxQuarantineRequest) (*AtxQuarantine, error)
 }
 
+var adminDesc = grpc.ServiceDesc{
+	ServiceName: AdminGrpcService,
+	HandlerType: (*adminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(AdminGrpcService, "GenerateCheckpoint", adminServer.generateCheckpoint),
+		rpc.UnaryMethod(AdminGrpcService, "CheckpointProgress", adminServer.checkpointProgress),
+		rpc.UnaryMethod(AdminGrpcService, "CheckpointJobs", adminServer.checkpointJobs),
+		rpc.UnaryMethod(AdminGrpcService, "DiagnosticsBundle", adminServer.diagnosticsBundle),
+		rpc.UnaryMethod(AdminGrpcService, "MeshExport", adminServer.meshExport),
+		rpc.UnaryMethod(AdminGrpcService, "Logging", adminServer.logging),
+		rpc.UnaryMethod(AdminGrpcService, "UpdateLogging", adminServer.updateLogging),
+		rpc.UnaryMethod(AdminGrpcService, "BeaconState", adminServer.beaconState),
+		rpc.UnaryMethod(AdminGrpcService, "InjectBeacon", adminServer.injectBeacon),
+		rpc.UnaryMethod(AdminGrpcService, "AtxQuarantine", adminServer.atxQuarantine),
+	},
+	Metadata: "api/grpcserver/admin_service.go",
+}
+
+// RegisterHandlerService registers the admin routes with the json gateway.
+// Routes of the asynchronous checkpoint generation, the diagnostics bundle, the mesh export,
+// the logging settings, the beacon protocol and the atx quarantine serve the methods of AdminGrpcService,
+// the recent events are served only on the json gateway.
 func (s AdminService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodPost, CheckpointGeneratePath, s.handleGenerateCheckpoint); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, CheckpointProgressPath, s.handleCheckpointProgress); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, DiagnosticsBundlePath, s.handleBundle); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodPost, MeshExportPath, s.handleMeshExport); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, EventsPath, s.handleEvents); err != nil {
+		return err
+	}
+	if s.logModules != nil {
+		if err := mux.HandlePath(http.MethodGet, LoggingPath, s.handleLogging); err != nil {
+			return err
+		}
+		if err := mux.HandlePath(http.MethodPost, LoggingPath, s.handleUpdateLogging); err != nil {
+			return err
+		}
+	}
+	if s.beacon != nil {
+		if err := mux.HandlePath(http.MethodGet, BeaconStatePath, s.handleBeaconState); err != nil {
+			return err
+		}
+		if err := mux.HandlePath(http.MethodPost, BeaconInjectPath, s.handleInjectBeacon); err != nil {
+			return err
+		}
+	}
+	if s.quarantine != nil {
+		if err := mux.HandlePath(http.MethodGet, AtxQuarantinePath, s.handleAtxQuarantine); err != nil {
+			return err
+		}
+	}
 	return pb.RegisterAdminServiceHandlerServer(context.Background(), mux, s)
 }
 
@@ -80,7 +276,18 @@ func (a AdminService) CheckpointStream(
 	if numAtxs < defaultNumAtxs {
 		numAtxs = defaultNumAtxs
 	}
-	err := checkpoint.Generate(stream.Context(), afero.NewOsFs(), a.db, a.dataDir, snapshot, numAtxs)
+	var err error
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if values := md.Get(CheckpointBaseHeader); len(values) > 0 {
+		base, perr := strconv.ParseUint(values[0], 10, 32)
+		if perr != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %s", CheckpointBaseHeader, values[0])
+		}
+		err = checkpoint.GenerateIncremental(
+			stream.Context(), afero.NewOsFs(), a.db, a.dataDir, types.LayerID(base), snapshot, numAtxs)
+	} else {
+		err = checkpoint.Generate(stream.Context(), afero.NewOsFs(), a.db, a.dataDir, snapshot, numAtxs)
+	}
 	if err != nil {
 		return status.Errorf(codes.Internal, fmt.Sprintf("failed to create checkpoint: %s", err.Error()))
 	}
@@ -116,13 +323,204 @@ func (a AdminService) CheckpointStream(
 	}
 }
 
+// GenerateCheckpoint starts generation of the checkpoint at the snapshot layer in the background.
+func (a AdminService) GenerateCheckpoint(
+	_ context.Context,
+	snapshot types.LayerID,
+	numAtxs int,
+) (*CheckpointJob, error) {
+	if a.checkpoints == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "checkpoint generation is not enabled")
+	}
+	if numAtxs < defaultNumAtxs {
+		numAtxs = defaultNumAtxs
+	}
+	id, err := a.checkpoints.Generate(snapshot, numAtxs)
+	if errors.Is(err, checkpoint.ErrAlreadyScheduled) {
+		return nil, apiError(codes.AlreadyExists, ReasonCheckpointInProgress, err.Error())
+	} else if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	progress, exists := a.checkpoints.Progress(id)
+	if !exists {
+		return &CheckpointJob{ID: id, SnapshotLayer: snapshot.Uint32(), Stage: string(checkpoint.StagePending)}, nil
+	}
+	return toCheckpointJob(progress), nil
+}
+
+// CheckpointProgress returns the progress of the checkpoint generation job.
+func (a AdminService) CheckpointProgress(_ context.Context, id uint64) (*CheckpointJob, error) {
+	if a.checkpoints == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "checkpoint generation is not enabled")
+	}
+	progress, exists := a.checkpoints.Progress(id)
+	if !exists {
+		return nil, apiError(codes.NotFound, ReasonNotFound, fmt.Sprintf("checkpoint job %d not found", id))
+	}
+	return toCheckpointJob(progress), nil
+}
+
+// CheckpointJobs returns the progress of the most recent checkpoint generation jobs.
+func (a AdminService) CheckpointJobs(context.Context) ([]CheckpointJob, error) {
+	if a.checkpoints == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "checkpoint generation is not enabled")
+	}
+	jobs := a.checkpoints.Jobs()
+	rst := make([]CheckpointJob, 0, len(jobs))
+	for _, progress := range jobs {
+		rst = append(rst, *toCheckpointJob(progress))
+	}
+	return rst, nil
+}
+
+func (a AdminService) generateCheckpoint(ctx context.Context, req *CheckpointGenerateRequest) (*CheckpointJob, error) {
+	return a.GenerateCheckpoint(ctx, types.LayerID(req.SnapshotLayer), int(req.NumAtxs))
+}
+
+func (a AdminService) checkpointProgress(ctx context.Context, req *CheckpointProgressRequest) (*CheckpointJob, error) {
+	return a.CheckpointProgress(ctx, req.ID)
+}
+
+func (a AdminService) checkpointJobs(ctx context.Context, _ *CheckpointJobsRequest) (*CheckpointJobsResponse, error) {
+	jobs, err := a.CheckpointJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointJobsResponse{Jobs: jobs}, nil
+}
+
+func toCheckpointJob(progress checkpoint.Progress) *CheckpointJob {
+	job := &CheckpointJob{
+		ID:            progress.ID,
+		SnapshotLayer: progress.Snapshot.Uint32(),
+		Stage:         string(progress.Stage),
+		Done:          progress.Done,
+		Total:         progress.Total,
+	}
+	if !progress.Started.IsZero() {
+		job.Started = &progress.Started
+	}
+	if !progress.Finished.IsZero() {
+		job.Finished = &progress.Finished
+	}
+	if progress.Err != nil {
+		job.Error = progress.Err.Error()
+	}
+	return job
+}
+
+func (a AdminService) handleGenerateCheckpoint(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req CheckpointGenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := a.generateCheckpoint(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (a AdminService) handleCheckpointProgress(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var (
+		rst any
+		err error
+	)
+	if value := r.URL.Query().Get("id"); value != "" {
+		id, perr := strconv.ParseUint(value, 10, 64)
+		if perr != nil {
+			rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("parse id: %s", perr)))
+			return
+		}
+		rst, err = a.CheckpointProgress(r.Context(), id)
+	} else {
+		rst, err = a.CheckpointJobs(r.Context())
+	}
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+// MeshExport exports layers, blocks, transactions and rewards in the layer range [from, to]
+// into a new directory in the exports directory of the node.
+func (a AdminService) MeshExport(ctx context.Context, from, to types.LayerID, format string) (*MeshExport, error) {
+	if format == "" {
+		format = string(export.NDJSON)
+	}
+	parsed, err := export.ParseFormat(format)
+	if err != nil {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, err.Error())
+	}
+	dir := filepath.Join(a.dataDir, exportsDir,
+		fmt.Sprintf("%d-%d-%s", from, to, time.Now().UTC().Format("20060102T150405")))
+	manifest, err := export.Export(ctx, a.db, dir, from, to, parsed)
+	switch {
+	case errors.Is(err, export.ErrInvalidRange):
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, err.Error())
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return &MeshExport{Dir: dir, Manifest: manifest}, nil
+}
+
+func (a AdminService) meshExport(ctx context.Context, req *MeshExportRequest) (*MeshExport, error) {
+	return a.MeshExport(ctx, types.LayerID(req.From), types.LayerID(req.To), req.Format)
+}
+
+func (a AdminService) handleMeshExport(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req MeshExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := a.meshExport(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
 func (a AdminService) Recover(ctx context.Context, _ *pb.RecoverRequest) (*emptypb.Empty, error) {
 	ctxzap.Info(ctx, "going to recover from checkpoint")
 	a.recover()
 	return &emptypb.Empty{}, nil
 }
 
+// EventsStream streams user events emitted by the node.
+//
+// By default the stream starts with the most recent events kept in memory. Clients that want to recover
+// events missed while they were disconnected set the LastEventSeqHeader in the request metadata
+// to the sequence number of the last event they received (or 0 to receive all retained events).
+// In that case the response header contains the sequence number of the first event sent on the stream
+// in EventSeqHeader, every following event is numbered consecutively.
+//
+// Events can be selected by the lowest severity in EventSeverityHeader and by causes in EventCauseHeader.
+// Events that are not selected still take their sequence numbers, so the numbering of the streamed events
+// is not consecutive if a filter is set.
 func (a AdminService) EventsStream(req *pb.EventStreamRequest, stream pb.AdminService_EventsStreamServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	filter, err := parseEventFilter(md.Get(EventSeverityHeader), md.Get(EventCauseHeader))
+	if err != nil {
+		return err
+	}
+	if values := md.Get(LastEventSeqHeader); len(values) > 0 {
+		seq, err := strconv.ParseUint(values[0], 10, 64)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %s", LastEventSeqHeader, values[0])
+		}
+		return a.replayEvents(seq, filter, stream)
+	}
 	sub, buf, err := events.SubscribeUserEvents(events.WithBuffer(1000))
 	if err != nil {
 		return status.Errorf(codes.FailedPrecondition, err.Error())
@@ -134,24 +532,42 @@ func (a AdminService) EventsStream(req *pb.EventStreamRequest, stream pb.AdminSe
 		return status.Errorf(codes.Unavailable, "can't send header")
 	}
 	buf.Iterate(func(ev events.UserEvent) bool {
-		err = stream.Send(ev.Event)
+		if filter.Match(&ev) {
+			err = stream.Send(ev.Event)
+		}
 		return err == nil
 	})
 	if err != nil {
 		return fmt.Errorf("send buffered to stream: %w", err)
 	}
-	for {
-		select {
-		case <-stream.Context().Done():
-			return nil
-		case <-sub.Full():
-			return status.Errorf(codes.Canceled, "buffer is full")
-		case ev := <-sub.Out():
-			if err := stream.Send(ev.Event); err != nil {
-				return fmt.Errorf("send to stream: %w", err)
-			}
+	return streamEvents(sub, filter, stream)
+}
+
+func (a AdminService) replayEvents(
+	seq uint64,
+	filter events.UserEventFilter,
+	stream pb.AdminService_EventsStreamServer,
+) error {
+	sub, replay, next, err := events.SubscribeUserEventsAfter(seq, events.WithBuffer(1000))
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	}
+	if sub == nil {
+		return status.Errorf(codes.FailedPrecondition, "events are not reported")
+	}
+	defer sub.Close()
+	if err := stream.SendHeader(metadata.Pairs(EventSeqHeader, strconv.FormatUint(next, 10))); err != nil {
+		return status.Errorf(codes.Unavailable, "can't send header")
+	}
+	for _, ev := range replay {
+		if !filter.Match(&ev) {
+			continue
+		}
+		if err := stream.Send(ev.Event); err != nil {
+			return fmt.Errorf("send replayed to stream: %w", err)
 		}
 	}
+	return streamEvents(sub, filter, stream)
 }
 
 func (a AdminService) PeerInfoStream(_ *emptypb.Empty, stream pb.AdminService_PeerInfoStreamServer) error {
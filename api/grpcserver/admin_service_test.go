@@ -1,20 +1,44 @@
 package grpcserver
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	ma "github.com/multiformats/go-multiaddr"
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/exp/maps"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/beacon"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/export"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
 )
 
 const snapshot uint32 = 15
@@ -61,7 +85,7 @@ func createMesh(tb testing.TB, db *sql.Database) {
 func TestAdminService_Checkpoint(t *testing.T) {
 	db := sql.InMemory()
 	createMesh(t, db)
-	svc := NewAdminService(db, t.TempDir(), nil)
+	svc := NewAdminService(db, t.TempDir(), nil, nil)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -97,7 +121,7 @@ func TestAdminService_Checkpoint(t *testing.T) {
 
 func TestAdminService_CheckpointError(t *testing.T) {
 	db := sql.InMemory()
-	svc := NewAdminService(db, t.TempDir(), nil)
+	svc := NewAdminService(db, t.TempDir(), nil, nil)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -112,10 +136,40 @@ func TestAdminService_CheckpointError(t *testing.T) {
 	require.ErrorContains(t, err, sql.ErrNotFound.Error())
 }
 
+func TestAdminService_CheckpointInvalidBase(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db)
+	svc := NewAdminService(db, t.TempDir(), nil, nil)
+	cfg, cleanup := launchServer(t, svc)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn := dialGrpc(ctx, t, cfg)
+	c := pb.NewAdminServiceClient(conn)
+
+	stream, err := c.CheckpointStream(
+		metadata.AppendToOutgoingContext(ctx, CheckpointBaseHeader, "invalid"),
+		&pb.CheckpointStreamRequest{SnapshotLayer: snapshot},
+	)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// base checkpoint was never generated
+	stream, err = c.CheckpointStream(
+		metadata.AppendToOutgoingContext(ctx, CheckpointBaseHeader, "10"),
+		&pb.CheckpointStreamRequest{SnapshotLayer: snapshot},
+	)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
 func TestAdminService_Recovery(t *testing.T) {
 	db := sql.InMemory()
 	recoveryCalled := atomic.Bool{}
-	svc := NewAdminService(db, t.TempDir(), nil)
+	svc := NewAdminService(db, t.TempDir(), nil, nil)
 	svc.recover = func() { recoveryCalled.Store(true) }
 
 	cfg, cleanup := launchServer(t, svc)
@@ -130,3 +184,792 @@ func TestAdminService_Recovery(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, recoveryCalled.Load())
 }
+
+func TestAdminService_EventsStreamReplay(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	require.NoError(t, events.EnableJournal(localsql.InMemory(), 3))
+	for epoch := types.EpochID(1); epoch <= 5; epoch++ {
+		events.EmitBeacon(epoch, types.RandomBeacon())
+	}
+
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil)
+	cfg, cleanup := launchServer(t, svc)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn := dialGrpc(ctx, t, cfg)
+	c := pb.NewAdminServiceClient(conn)
+
+	t.Run("invalid sequence", func(t *testing.T) {
+		stream, err := c.EventsStream(
+			metadata.AppendToOutgoingContext(ctx, LastEventSeqHeader, "first"),
+			&pb.EventStreamRequest{},
+		)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	stream, err := c.EventsStream(
+		metadata.AppendToOutgoingContext(ctx, LastEventSeqHeader, "1"),
+		&pb.EventStreamRequest{},
+	)
+	require.NoError(t, err)
+	header, err := stream.Header()
+	require.NoError(t, err)
+	// only the last 3 events are retained in the journal
+	require.Equal(t, []string{"3"}, header.Get(EventSeqHeader))
+	for epoch := types.EpochID(3); epoch <= 5; epoch++ {
+		ev, err := stream.Recv()
+		require.NoError(t, err)
+		require.EqualValues(t, epoch, ev.GetBeacon().Epoch)
+	}
+
+	events.EmitBeacon(6, types.RandomBeacon())
+	ev, err := stream.Recv()
+	require.NoError(t, err)
+	require.EqualValues(t, 6, ev.GetBeacon().Epoch)
+}
+
+func TestAdminService_EventsFilter(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	events.EmitBeacon(1, types.RandomBeacon())
+	events.EmitClockSkew(time.Minute, 10*time.Second, false)
+	events.EmitPostFailure(types.RandomNodeID())
+
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil)
+	cfg, cleanup := launchServer(t, svc)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn := dialGrpc(ctx, t, cfg)
+	c := pb.NewAdminServiceClient(conn)
+
+	t.Run("invalid severity", func(t *testing.T) {
+		stream, err := c.EventsStream(
+			metadata.AppendToOutgoingContext(ctx, EventSeverityHeader, "fatal"),
+			&pb.EventStreamRequest{},
+		)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("severity", func(t *testing.T) {
+		stream, err := c.EventsStream(
+			metadata.AppendToOutgoingContext(ctx, EventSeverityHeader, "warning"),
+			&pb.EventStreamRequest{},
+		)
+		require.NoError(t, err)
+		for _, expected := range []string{"clock", "PoST"} {
+			ev, err := stream.Recv()
+			require.NoError(t, err)
+			require.True(t, ev.Failure)
+			require.Contains(t, ev.Help, expected)
+		}
+		events.EmitBeacon(2, types.RandomBeacon())
+		events.EmitInvalidPostProof()
+		ev, err := stream.Recv()
+		require.NoError(t, err)
+		require.Contains(t, ev.Help, "invalid POST")
+	})
+	t.Run("cause after sequence", func(t *testing.T) {
+		stream, err := c.EventsStream(
+			metadata.AppendToOutgoingContext(ctx,
+				LastEventSeqHeader, "0",
+				EventCauseHeader, string(events.CauseClockSkew),
+				EventCauseHeader, string(events.CauseInvalidPost),
+			),
+			&pb.EventStreamRequest{},
+		)
+		require.NoError(t, err)
+		for _, expected := range []string{"clock", "invalid POST"} {
+			ev, err := stream.Recv()
+			require.NoError(t, err)
+			require.Contains(t, ev.Help, expected)
+		}
+	})
+	t.Run("recent", func(t *testing.T) {
+		rst, err := svc.RecentEvents(2, events.UserEventFilter{MinSeverity: events.SeverityCritical})
+		require.NoError(t, err)
+		require.Len(t, rst, 2)
+		require.EqualValues(t, 3, rst[0].Seq)
+		require.Equal(t, events.CausePostFailed, rst[0].Cause)
+		require.EqualValues(t, 5, rst[1].Seq)
+		require.Equal(t, events.CauseInvalidPost, rst[1].Cause)
+	})
+}
+
+func TestAdminService_EventsJSON(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	events.EmitBeacon(1, types.RandomBeacon())
+	events.EmitClockSkew(time.Minute, 10*time.Second, false)
+
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	get := func(query string) ([]byte, int) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?%s", cfg.JSONListener, EventsPath, query))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return buf, resp.StatusCode
+	}
+	buf, code := get("severity=warning")
+	require.Equal(t, http.StatusOK, code, string(buf))
+	var rst []EventRecord
+	require.NoError(t, json.Unmarshal(buf, &rst))
+	require.Len(t, rst, 1)
+	require.EqualValues(t, 2, rst[0].Seq)
+	require.Equal(t, events.SeverityWarning, rst[0].Severity)
+	require.Equal(t, events.CauseClockSkew, rst[0].Cause)
+	require.Contains(t, string(buf), `"severity":"warning"`)
+
+	buf, code = get("after=1&cause=poet_unreachable")
+	require.Equal(t, http.StatusOK, code, string(buf))
+	require.JSONEq(t, "[]", string(buf))
+
+	_, code = get("severity=fatal")
+	require.Equal(t, http.StatusBadRequest, code)
+	_, code = get("after=first")
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminService_GenerateCheckpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scheduler := NewMockcheckpointScheduler(ctrl)
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, scheduler)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	do := func(method, path string, body []byte) ([]byte, int) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", cfg.JSONListener, path), bytes.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return buf, resp.StatusCode
+	}
+	started := time.Now()
+	pending := checkpoint.Progress{ID: 1, Snapshot: 15, Stage: checkpoint.StagePending}
+	done := checkpoint.Progress{
+		ID:       1,
+		Snapshot: 15,
+		Stage:    checkpoint.StageDone,
+		Done:     10,
+		Total:    10,
+		Started:  started,
+		Finished: started.Add(time.Second),
+	}
+
+	t.Run("generate", func(t *testing.T) {
+		scheduler.EXPECT().Generate(types.LayerID(15), 10).Return(uint64(1), nil)
+		scheduler.EXPECT().Progress(uint64(1)).Return(pending, true)
+		buf, code := do(http.MethodPost, CheckpointGeneratePath, []byte(`{"snapshot_layer":15,"num_atxs":10}`))
+		require.Equal(t, http.StatusOK, code, string(buf))
+		var rst CheckpointJob
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Equal(t, CheckpointJob{ID: 1, SnapshotLayer: 15, Stage: "pending"}, rst)
+	})
+	t.Run("default num atxs", func(t *testing.T) {
+		scheduler.EXPECT().Generate(types.LayerID(15), defaultNumAtxs).Return(uint64(1), nil)
+		scheduler.EXPECT().Progress(uint64(1)).Return(pending, true)
+		_, code := do(http.MethodPost, CheckpointGeneratePath, []byte(`{"snapshot_layer":15}`))
+		require.Equal(t, http.StatusOK, code)
+	})
+	t.Run("already scheduled", func(t *testing.T) {
+		scheduler.EXPECT().Generate(types.LayerID(15), defaultNumAtxs).Return(uint64(0), checkpoint.ErrAlreadyScheduled)
+		_, code := do(http.MethodPost, CheckpointGeneratePath, []byte(`{"snapshot_layer":15}`))
+		require.Equal(t, http.StatusConflict, code)
+	})
+	t.Run("invalid request", func(t *testing.T) {
+		_, code := do(http.MethodPost, CheckpointGeneratePath, []byte(`{"snapshot_layer":"first"}`))
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+	t.Run("progress", func(t *testing.T) {
+		scheduler.EXPECT().Progress(uint64(1)).Return(done, true)
+		buf, code := do(http.MethodGet, CheckpointProgressPath+"?id=1", nil)
+		require.Equal(t, http.StatusOK, code, string(buf))
+		var rst CheckpointJob
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Equal(t, uint64(1), rst.ID)
+		require.Equal(t, "done", rst.Stage)
+		require.Equal(t, 10, rst.Done)
+		require.True(t, rst.Started.Equal(done.Started))
+		require.True(t, rst.Finished.Equal(done.Finished))
+		require.Empty(t, rst.Error)
+
+		scheduler.EXPECT().Progress(uint64(2)).Return(checkpoint.Progress{}, false)
+		_, code = do(http.MethodGet, CheckpointProgressPath+"?id=2", nil)
+		require.Equal(t, http.StatusNotFound, code)
+
+		_, code = do(http.MethodGet, CheckpointProgressPath+"?id=last", nil)
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+	t.Run("jobs", func(t *testing.T) {
+		failed := checkpoint.Progress{
+			ID:       2,
+			Snapshot: 16,
+			Stage:    checkpoint.StageFailed,
+			Started:  started,
+			Finished: started,
+			Err:      errors.New("test"),
+		}
+		scheduler.EXPECT().Jobs().Return([]checkpoint.Progress{done, failed})
+		buf, code := do(http.MethodGet, CheckpointProgressPath, nil)
+		require.Equal(t, http.StatusOK, code, string(buf))
+		var rst []CheckpointJob
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Len(t, rst, 2)
+		require.Equal(t, uint32(15), rst[0].SnapshotLayer)
+		require.Equal(t, "failed", rst[1].Stage)
+		require.Equal(t, "test", rst[1].Error)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		scheduler.EXPECT().Generate(types.LayerID(15), 10).Return(uint64(1), nil)
+		scheduler.EXPECT().Progress(uint64(1)).Return(pending, true)
+		job, err := rpc.Invoke[CheckpointGenerateRequest, CheckpointJob](
+			ctx, conn, AdminGrpcService, "GenerateCheckpoint", rpc.JSON,
+			&CheckpointGenerateRequest{SnapshotLayer: 15, NumAtxs: 10},
+		)
+		require.NoError(t, err)
+		require.Equal(t, &CheckpointJob{ID: 1, SnapshotLayer: 15, Stage: "pending"}, job)
+
+		scheduler.EXPECT().Progress(uint64(2)).Return(checkpoint.Progress{}, false)
+		_, err = rpc.Invoke[CheckpointProgressRequest, CheckpointJob](
+			ctx, conn, AdminGrpcService, "CheckpointProgress", rpc.JSON, &CheckpointProgressRequest{ID: 2},
+		)
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		scheduler.EXPECT().Jobs().Return([]checkpoint.Progress{done})
+		jobs, err := rpc.Invoke[CheckpointJobsRequest, CheckpointJobsResponse](
+			ctx, conn, AdminGrpcService, "CheckpointJobs", rpc.JSON, &CheckpointJobsRequest{},
+		)
+		require.NoError(t, err)
+		require.Len(t, jobs.Jobs, 1)
+		require.Equal(t, "done", jobs.Jobs[0].Stage)
+	})
+}
+
+func readBundle(tb testing.TB, r io.Reader) map[string][]byte {
+	gz, err := gzip.NewReader(r)
+	require.NoError(tb, err)
+	archive := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(tb, err)
+		data, err := io.ReadAll(archive)
+		require.NoError(tb, err)
+		files[header.Name] = data
+	}
+}
+
+func TestAdminService_DiagnosticsBundle(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	events.EmitBeacon(1, types.RandomBeacon())
+
+	type smeshing struct {
+		CoinbaseAccount string
+		DataDir         string
+		NumUnits        uint32
+	}
+	cfg := struct {
+		DataDirParent string
+		LayerDuration time.Duration
+		Bootnodes     []string
+		Direct        []string
+		Smeshing      smeshing
+	}{
+		DataDirParent: "/home/operator/spacemesh",
+		LayerDuration: 5 * time.Minute,
+		Bootnodes:     []string{"/dns4/bootnode/tcp/5000"},
+		Direct:        []string{"/ip4/10.0.0.1/tcp/7513"},
+		Smeshing:      smeshing{CoinbaseAccount: "sm1qqqqqq", NumUnits: 4},
+	}
+
+	ctrl := gomock.NewController(t)
+	peers := NewMockpeers(ctrl)
+	peer := p2p.Peer("peer")
+	peers.EXPECT().GetPeers().Return([]p2p.Peer{peer})
+	peers.EXPECT().ConnectedPeerInfo(peer).Return(&p2p.PeerInfo{
+		ID: peer,
+		Connections: []p2p.ConnectionInfo{
+			{Address: ma.StringCast("/ip4/10.0.0.2/tcp/7513"), Uptime: time.Minute, Outbound: true},
+		},
+	})
+
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), peers, nil,
+		WithBundleConfig(cfg), WithBundleVersion("v1.0.0", "abcdef"))
+	jsonCfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", jsonCfg.JSONListener, DiagnosticsBundlePath))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/gzip", resp.Header.Get("Content-Type"))
+	files := readBundle(t, resp.Body)
+	require.ElementsMatch(t, []string{
+		"node.json", "config.json", "events.json", "peers.json", "database.json", "goroutine.txt", "heap.pb.gz",
+	}, maps.Keys(files))
+
+	var node BundleNode
+	require.NoError(t, json.Unmarshal(files["node.json"], &node))
+	require.Equal(t, "v1.0.0", node.Version)
+	require.Equal(t, "abcdef", node.Commit)
+	require.Empty(t, node.Errors)
+
+	var anonymized map[string]any
+	require.NoError(t, json.Unmarshal(files["config.json"], &anonymized))
+	require.Equal(t, map[string]any{
+		"DataDirParent": redacted,
+		"LayerDuration": float64(5 * time.Minute),
+		"Bootnodes":     []any{"/dns4/bootnode/tcp/5000"},
+		"Direct":        []any{redacted},
+		"Smeshing": map[string]any{
+			"CoinbaseAccount": redacted,
+			"DataDir":         "",
+			"NumUnits":        float64(4),
+		},
+	}, anonymized)
+
+	var recent []map[string]any
+	require.NoError(t, json.Unmarshal(files["events.json"], &recent))
+	require.Len(t, recent, 1)
+	require.Contains(t, recent[0], "beacon")
+
+	var connected BundlePeers
+	require.NoError(t, json.Unmarshal(files["peers.json"], &connected))
+	require.Equal(t, BundlePeers{
+		Total:    1,
+		Outbound: 1,
+		Peers: []BundlePeer{{
+			ID:          peer.String(),
+			Connections: []BundleConnection{{Outbound: true, Uptime: time.Minute}},
+		}},
+	}, connected)
+	require.NotContains(t, string(files["peers.json"]), "10.0.0.2")
+
+	var db BundleDatabase
+	require.NoError(t, json.Unmarshal(files["database.json"], &db))
+	require.NotZero(t, db.SchemaVersion)
+	require.NotZero(t, db.PageSize)
+	require.Equal(t, db.PageSize*db.Pages, db.Size)
+
+	require.Contains(t, string(files["goroutine.txt"]), "goroutine")
+	require.NotEmpty(t, files["heap.pb.gz"])
+
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		peers.EXPECT().GetPeers().Return(nil)
+		bundle, err := rpc.Invoke[DiagnosticsBundleRequest, DiagnosticsBundleResponse](
+			ctx, conn, AdminGrpcService, "DiagnosticsBundle", rpc.JSON, &DiagnosticsBundleRequest{},
+		)
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(bundle.Name, ".tar.gz"))
+		files := readBundle(t, bytes.NewReader(bundle.Data))
+		require.Contains(t, files, "node.json")
+		require.Contains(t, files, "peers.json")
+	})
+}
+
+func TestAdminService_MeshExport(t *testing.T) {
+	db := sql.InMemory()
+	for lid := types.LayerID(1); lid <= 3; lid++ {
+		require.NoError(t, rewards.Add(db, &types.Reward{Layer: lid, SmesherID: types.NodeID{1}, TotalReward: 10}))
+	}
+	dataDir := t.TempDir()
+	svc := NewAdminService(db, dataDir, nil, nil)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	do := func(body string) ([]byte, int) {
+		resp, err := http.Post(fmt.Sprintf("http://%s%s", cfg.JSONListener, MeshExportPath),
+			"application/json", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return buf, resp.StatusCode
+	}
+
+	t.Run("export", func(t *testing.T) {
+		buf, code := do(`{"from":2,"to":3,"format":"parquet"}`)
+		require.Equal(t, http.StatusOK, code, string(buf))
+		var rst MeshExport
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Equal(t, filepath.Join(dataDir, exportsDir), filepath.Dir(rst.Dir))
+		require.Equal(t, export.Parquet, rst.Manifest.Format)
+		manifest, err := export.ReadManifest(rst.Dir)
+		require.NoError(t, err)
+		require.Equal(t, rst.Manifest.Tables, manifest.Tables)
+		for _, table := range manifest.Tables {
+			if table.Name == "rewards" {
+				require.Equal(t, 2, table.Rows)
+			}
+		}
+	})
+	t.Run("default format", func(t *testing.T) {
+		buf, code := do(`{"from":1,"to":1}`)
+		require.Equal(t, http.StatusOK, code, string(buf))
+		var rst MeshExport
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Equal(t, export.NDJSON, rst.Manifest.Format)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, code := do(`{"from":1,"to":1,"format":"csv"}`)
+		require.Equal(t, http.StatusBadRequest, code)
+		_, code = do(`{"from":3,"to":1}`)
+		require.Equal(t, http.StatusBadRequest, code)
+		_, code = do(`{"from":"first"}`)
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[MeshExportRequest, MeshExport](
+			ctx, conn, AdminGrpcService, "MeshExport", rpc.JSON, &MeshExportRequest{From: 1, To: 2},
+		)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dataDir, exportsDir), filepath.Dir(rst.Dir))
+		require.Equal(t, export.NDJSON, rst.Manifest.Format)
+
+		_, err = rpc.Invoke[MeshExportRequest, MeshExport](
+			ctx, conn, AdminGrpcService, "MeshExport", rpc.JSON, &MeshExportRequest{From: 3, To: 1},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestAdminService_Logging(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logging.json")
+	modules, err := log.NewModules(log.ModulesConfig{
+		Modules: map[string]log.ModuleConfig{"hare": {Level: "warn"}},
+		File:    file,
+	})
+	require.NoError(t, err)
+	modules.Get("hare")
+	modules.Get("sync")
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil, WithLogModules(modules))
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+	url := fmt.Sprintf("http://%s%s", cfg.JSONListener, LoggingPath)
+
+	decode := func(resp *http.Response) []log.ModuleState {
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst []log.ModuleState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		return rst
+	}
+	update := func(body string) *http.Response {
+		resp, err := http.Post(url, "application/json", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	require.Equal(t, []log.ModuleState{
+		{Name: "hare", Level: "warn"},
+		{Name: "sync", Level: "info"},
+	}, decode(resp))
+
+	require.Equal(t, []log.ModuleState{
+		{Name: "hare", Level: "debug", Encoder: log.JSONEncoder, Overridden: true},
+		{Name: "sync", Level: "info"},
+	}, decode(update(`{"module":"hare","level":"debug","encoder":"json"}`)))
+
+	// persisted
+	restarted, err := log.NewModules(log.ModulesConfig{File: file})
+	require.NoError(t, err)
+	require.Equal(t, log.JSONEncoder, restarted.Get("hare").Encoder())
+
+	require.Equal(t, []log.ModuleState{
+		{Name: "hare", Level: "error", Encoder: log.JSONEncoder, Overridden: true},
+		{Name: "sync", Level: "error", Overridden: true},
+	}, decode(update(`{"module":"*","level":"error"}`)))
+
+	require.Equal(t, []log.ModuleState{
+		{Name: "hare", Level: "warn"},
+		{Name: "sync", Level: "error", Overridden: true},
+	}, decode(update(`{"module":"hare","reset":true}`)))
+
+	for body, code := range map[string]int{
+		`{"module":"unknown","level":"debug"}`: http.StatusNotFound,
+		`{"module":"hare","level":"loud"}`:     http.StatusBadRequest,
+		`{"module":"hare","encoder":"xml"}`:    http.StatusBadRequest,
+		`{"level":"debug"}`:                    http.StatusBadRequest,
+	} {
+		resp := update(body)
+		resp.Body.Close()
+		require.Equal(t, code, resp.StatusCode, body)
+	}
+
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[LoggingRequest, LoggingResponse](
+			ctx, conn, AdminGrpcService, "UpdateLogging", rpc.JSON, &LoggingRequest{Module: "sync", Reset: true},
+		)
+		require.NoError(t, err)
+		expected := []log.ModuleState{
+			{Name: "hare", Level: "warn"},
+			{Name: "sync", Level: "info"},
+		}
+		require.Equal(t, expected, rst.Modules)
+
+		rst, err = rpc.Invoke[LoggingStateRequest, LoggingResponse](
+			ctx, conn, AdminGrpcService, "Logging", rpc.JSON, &LoggingStateRequest{},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst.Modules)
+
+		_, err = rpc.Invoke[LoggingRequest, LoggingResponse](
+			ctx, conn, AdminGrpcService, "UpdateLogging", rpc.JSON, &LoggingRequest{Module: "unknown"},
+		)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, NewAdminService(sql.InMemory(), t.TempDir(), nil, nil))
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		_, err := rpc.Invoke[LoggingStateRequest, LoggingResponse](
+			ctx, conn, AdminGrpcService, "Logging", rpc.JSON, &LoggingStateRequest{},
+		)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestAdminService_Beacon(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	protocol := NewMockbeaconProtocol(ctrl)
+	clock := NewMocklayerClock(ctrl)
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil, WithBeaconProtocol(protocol, clock))
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	const current = types.EpochID(7)
+	clock.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	state := &beacon.ProtocolState{
+		Epoch:       current,
+		Beacon:      types.Beacon{1},
+		Running:     true,
+		Round:       3,
+		EpochWeight: 100,
+		Miners:      10,
+		BallotBeacons: []beacon.BallotBeacon{
+			{Beacon: types.Beacon{2}, Ballots: 3, Eligibilities: 4, Weight: 2.5},
+		},
+	}
+	expected := &BeaconProtocolState{
+		Epoch:       current.Uint32(),
+		Beacon:      "01000000",
+		Running:     true,
+		Round:       3,
+		EpochWeight: 100,
+		Miners:      10,
+		BallotBeacons: []BeaconBallotWeight{
+			{Beacon: "02000000", Ballots: 3, Eligibilities: 4, Weight: 2.5},
+		},
+	}
+	decode := func(resp *http.Response) *BeaconProtocolState {
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst BeaconProtocolState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		return &rst
+	}
+	inject := func(body string) *http.Response {
+		resp, err := http.Post(fmt.Sprintf("http://%s%s", cfg.JSONListener, BeaconInjectPath),
+			"application/json", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("state", func(t *testing.T) {
+		protocol.EXPECT().ProtocolState(current).Return(state, nil)
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, BeaconStatePath))
+		require.NoError(t, err)
+		require.Equal(t, expected, decode(resp))
+
+		protocol.EXPECT().ProtocolState(current-1).Return(&beacon.ProtocolState{Epoch: current - 1}, nil)
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?epoch=%d", cfg.JSONListener, BeaconStatePath, current-1))
+		require.NoError(t, err)
+		require.Equal(t, &BeaconProtocolState{
+			Epoch:         (current - 1).Uint32(),
+			BallotBeacons: []BeaconBallotWeight{},
+		}, decode(resp))
+	})
+	t.Run("inject", func(t *testing.T) {
+		protocol.EXPECT().
+			InjectBeacon(current, types.Beacon{3}, gomock.Any()).
+			DoAndReturn(func(_ types.EpochID, _ types.Beacon, reason string) error {
+				require.Contains(t, reason, "devnet stalled")
+				require.Contains(t, reason, "requested by 127.0.0.1")
+				return nil
+			})
+		protocol.EXPECT().ProtocolState(current).Return(state, nil)
+		require.Equal(t, expected, decode(inject(`{"epoch":7,"beacon":"03000000","reason":"devnet stalled"}`)))
+	})
+	t.Run("inject disabled", func(t *testing.T) {
+		protocol.EXPECT().InjectBeacon(current, types.Beacon{3}, gomock.Any()).Return(beacon.ErrInjectionDisabled)
+		err := svc.InjectBeacon(&BeaconInjectRequest{Epoch: 7, Beacon: "03000000", Reason: "test"}, "test")
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonBeaconInjectionDisabled, reason)
+	})
+	t.Run("invalid inject", func(t *testing.T) {
+		for _, body := range []string{
+			`{"epoch":7,"beacon":"03000000"}`,
+			`{"epoch":7,"beacon":"0300","reason":"test"}`,
+			`{"epoch":`,
+		} {
+			resp := inject(body)
+			resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		protocol.EXPECT().ProtocolState(current).Return(state, nil)
+		rst, err := rpc.Invoke[BeaconStateRequest, BeaconProtocolState](
+			ctx, conn, AdminGrpcService, "BeaconState", rpc.JSON, &BeaconStateRequest{},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst)
+
+		protocol.EXPECT().
+			InjectBeacon(current, types.Beacon{3}, gomock.Any()).
+			DoAndReturn(func(_ types.EpochID, _ types.Beacon, reason string) error {
+				require.Contains(t, reason, "requested by 127.0.0.1")
+				return nil
+			})
+		protocol.EXPECT().ProtocolState(current).Return(state, nil)
+		rst, err = rpc.Invoke[BeaconInjectRequest, BeaconProtocolState](
+			ctx, conn, AdminGrpcService, "InjectBeacon", rpc.JSON,
+			&BeaconInjectRequest{Epoch: 7, Beacon: "03000000", Reason: "devnet stalled"},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst)
+
+		_, err = rpc.Invoke[BeaconInjectRequest, BeaconProtocolState](
+			ctx, conn, AdminGrpcService, "InjectBeacon", rpc.JSON,
+			&BeaconInjectRequest{Epoch: 7, Beacon: "03000000"},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, NewAdminService(sql.InMemory(), t.TempDir(), nil, nil))
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		_, err := rpc.Invoke[BeaconStateRequest, BeaconProtocolState](
+			ctx, conn, AdminGrpcService, "BeaconState", rpc.JSON, &BeaconStateRequest{},
+		)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestAdminService_AtxQuarantine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	quarantine := NewMockatxQuarantine(ctrl)
+	svc := NewAdminService(sql.InMemory(), t.TempDir(), nil, nil, WithAtxQuarantine(quarantine))
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	quarantined := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	quarantine.EXPECT().Quarantined().Return([]activation.QuarantinedAtx{{
+		ID:           types.ATXID{1},
+		SmesherID:    types.NodeID{2},
+		PublishEpoch: 3,
+		Missing:      types.Hash32{4},
+		Quarantined:  quarantined,
+		Attempts:     2,
+		Reason:       "missing references",
+	}})
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, AtxQuarantinePath))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rst AtxQuarantine
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+	require.Equal(t, AtxQuarantine{ATXs: []QuarantinedATX{{
+		ID:           "01" + strings.Repeat("00", 31),
+		Smesher:      "02" + strings.Repeat("00", 31),
+		PublishEpoch: 3,
+		Missing:      "04" + strings.Repeat("00", 31),
+		Quarantined:  quarantined,
+		Attempts:     2,
+		Reason:       "missing references",
+	}}}, rst)
+
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		quarantine.EXPECT().Quarantined().Return([]activation.QuarantinedAtx{{ID: types.ATXID{1}}})
+		rst, err := rpc.Invoke[AtxQuarantineRequest, AtxQuarantine](
+			ctx, conn, AdminGrpcService, "AtxQuarantine", rpc.JSON, &AtxQuarantineRequest{},
+		)
+		require.NoError(t, err)
+		require.Len(t, rst.ATXs, 1)
+		require.Equal(t, "01"+strings.Repeat("00", 31), rst.ATXs[0].ID)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, NewAdminService(sql.InMemory(), t.TempDir(), nil, nil))
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		_, err := rpc.Invoke[AtxQuarantineRequest, AtxQuarantine](
+			ctx, conn, AdminGrpcService, "AtxQuarantine", rpc.JSON, &AtxQuarantineRequest{},
+		)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -0,0 +1,349 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	grpctags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	spacemeshv2alpha1 "github.com/spacemeshos/api/release/go/spacemesh/v2alpha1"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+)
+
+// Role is a set of permissions granted to an api caller.
+// Roles are ordered, every role is allowed to call everything that a lower role can call.
+type Role string
+
+const (
+	// RoleNone doesn't allow to call any method.
+	RoleNone Role = "none"
+	// RoleRead allows to query public data about the node and the network.
+	RoleRead Role = "read"
+	// RoleWallet additionally allows to submit transactions.
+	RoleWallet Role = "wallet"
+	// RoleAdmin allows to call every method, including smeshing and node administration.
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleNone:   0,
+	RoleRead:   1,
+	RoleWallet: 2,
+	RoleAdmin:  3,
+}
+
+func (r Role) validate() error {
+	if _, exists := roleRank[r]; !exists {
+		return fmt.Errorf("unknown role %q", r)
+	}
+	return nil
+}
+
+// allows returns true if the role is sufficient to call a method that requires the other role.
+func (r Role) allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// defaultPolicies defines the role required to call each of the services and methods
+// exposed by the node. Methods not found here are checked against the policy of their service.
+// Services not listed here require RoleAdmin.
+//
+// Routes that are served only on the json gateway are named after their paths, and are checked
+// against the policies of the parent paths if they are not found (e.g. "v1/hare/results" against "v1/hare").
+// A policy for a single http method is prefixed with the method (e.g. "POST v1/beacon/fallback").
+var defaultPolicies = map[string]Role{
+	pb.NodeService_ServiceDesc.ServiceName:                            RoleRead,
+	pb.MeshService_ServiceDesc.ServiceName:                            RoleRead,
+	pb.GlobalStateService_ServiceDesc.ServiceName:                     RoleRead,
+	pb.ActivationService_ServiceDesc.ServiceName:                      RoleRead,
+	pb.TransactionService_ServiceDesc.ServiceName:                     RoleRead,
+	pb.TransactionService_SubmitTransaction_FullMethodName:            RoleWallet,
+	TransactionBatchService + "/SubmitBatch":                          RoleWallet,
+	HareGrpcService:                                                   RoleRead,
+	BeaconGrpcService:                                                 RoleRead,
+	StateGrpcService:                                                  RoleRead,
+	GlobalStateGrpcService:                                            RoleRead,
+	spacemeshv2alpha1.ActivationService_ServiceDesc.ServiceName:       RoleRead,
+	spacemeshv2alpha1.RewardService_ServiceDesc.ServiceName:           RoleRead,
+	spacemeshv2alpha1.ActivationStreamService_ServiceDesc.ServiceName: RoleRead,
+	spacemeshv2alpha1.RewardStreamService_ServiceDesc.ServiceName:     RoleRead,
+	grpc_reflection_v1.ServerReflection_ServiceDesc.ServiceName:       RoleRead,
+	grpc_reflection_v1alpha.ServerReflection_ServiceDesc.ServiceName:  RoleRead,
+	// health is usually queried by probes that don't present credentials
+	grpc_health_v1.Health_ServiceDesc.ServiceName: RoleNone,
+
+	"v1/node":                    RoleRead,
+	"v1/mesh":                    RoleRead,
+	"v1/globalstate":             RoleRead,
+	"v1/transaction":             RoleRead,
+	"v1/transaction/submitbatch": RoleWallet,
+	"v1/activeset":               RoleRead,
+	"v1/hare":                    RoleRead,
+	"v1/beacon":                  RoleRead,
+	"POST v1/beacon/fallback":    RoleAdmin,
+	"v1/state":                   RoleRead,
+	"v1/explorer":                RoleRead,
+	"v1/clock":                   RoleRead,
+	"v2alpha1/reward":            RoleRead,
+	"v1/health":                  RoleNone,
+}
+
+// Authorizer authenticates api callers and checks if they are allowed to call a method.
+type Authorizer struct {
+	defaultRole  Role
+	tokens       map[string]Role
+	certificates map[string]Role
+	policies     map[string]Role
+	// routes maps the paths of the json gateway routes to the names of their grpc methods.
+	routes map[string]string
+}
+
+// NewAuthorizer creates an Authorizer from the config.
+func NewAuthorizer(cfg AuthConfig) (*Authorizer, error) {
+	a := &Authorizer{
+		defaultRole:  cfg.DefaultRole,
+		tokens:       make(map[string]Role, len(cfg.Tokens)),
+		certificates: make(map[string]Role, len(cfg.Certificates)),
+		policies:     make(map[string]Role, len(defaultPolicies)+len(cfg.Policies)),
+		routes:       gatewayRoutes(),
+	}
+	if err := a.defaultRole.validate(); err != nil {
+		return nil, fmt.Errorf("default role: %w", err)
+	}
+	for token, role := range cfg.Tokens {
+		if len(token) == 0 {
+			return nil, fmt.Errorf("empty token for role %s", role)
+		}
+		if err := role.validate(); err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		a.tokens[token] = role
+	}
+	for name, role := range cfg.Certificates {
+		if err := role.validate(); err != nil {
+			return nil, fmt.Errorf("certificate %s: %w", name, err)
+		}
+		a.certificates[name] = role
+	}
+	for name, role := range defaultPolicies {
+		a.policies[strings.TrimPrefix(name, "/")] = role
+	}
+	for name, role := range cfg.Policies {
+		if err := role.validate(); err != nil {
+			return nil, fmt.Errorf("policy %s: %w", name, err)
+		}
+		a.policies[strings.TrimPrefix(name, "/")] = role
+	}
+	return a, nil
+}
+
+// required returns the role that is required to call the method.
+// fullMethod is in the format "/package.service/method" or a path of the json gateway route.
+func (a *Authorizer) required(fullMethod string) Role {
+	name := strings.TrimPrefix(fullMethod, "/")
+	for {
+		if role, exists := a.policies[name]; exists {
+			return role
+		}
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return RoleAdmin
+		}
+		name = name[:i]
+	}
+}
+
+// requiredRoute returns the role that is required to call the json gateway route.
+// Routes generated from grpc services require the same role as their grpc methods.
+func (a *Authorizer) requiredRoute(method, path string) Role {
+	name := strings.TrimPrefix(path, "/")
+	if fullMethod, exists := a.routes[name]; exists {
+		name = fullMethod
+	}
+	if role, exists := a.policies[method+" "+name]; exists {
+		return role
+	}
+	return a.required(name)
+}
+
+// gatewayRoutes returns the paths of the json gateway routes that are bound to grpc methods
+// with http annotations (e.g. "v1/node/status"). Methods without annotations are served
+// on the paths named after them (e.g. "spacemesh.v1.NodeService/NodeInfo").
+func gatewayRoutes() map[string]string {
+	routes := map[string]string{}
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			svc := fd.Services().Get(i)
+			for j := 0; j < svc.Methods().Len(); j++ {
+				method := svc.Methods().Get(j)
+				rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				name := fmt.Sprintf("%s/%s", svc.FullName(), method.Name())
+				for _, binding := range append([]*annotations.HttpRule{rule}, rule.AdditionalBindings...) {
+					if path := httpRulePath(binding); path != "" {
+						routes[strings.TrimPrefix(path, "/")] = name
+					}
+				}
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+func httpRulePath(rule *annotations.HttpRule) string {
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		return pattern.Get
+	case *annotations.HttpRule_Put:
+		return pattern.Put
+	case *annotations.HttpRule_Post:
+		return pattern.Post
+	case *annotations.HttpRule_Delete:
+		return pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return pattern.Patch
+	}
+	return ""
+}
+
+// role returns the role granted to the caller based on the credentials found in the context.
+func (a *Authorizer) role(ctx context.Context) (Role, error) {
+	var (
+		role          = a.defaultRole
+		authenticated bool
+	)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range md.Get("authorization") {
+			token, found := strings.CutPrefix(header, "Bearer ")
+			if !found {
+				return RoleNone, status.Error(codes.Unauthenticated, "unsupported authorization scheme")
+			}
+			granted, exists := a.lookupToken(token)
+			if !exists {
+				return RoleNone, status.Error(codes.Unauthenticated, "invalid token")
+			}
+			if !authenticated || granted.allows(role) {
+				role = granted
+			}
+			authenticated = true
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			for _, chain := range info.State.VerifiedChains {
+				if len(chain) == 0 {
+					continue
+				}
+				granted, exists := a.certificates[chain[0].Subject.CommonName]
+				if !exists {
+					continue
+				}
+				if !authenticated || granted.allows(role) {
+					role = granted
+				}
+				authenticated = true
+			}
+		}
+	}
+	return role, nil
+}
+
+// lookupToken compares the token against every known token in constant time.
+func (a *Authorizer) lookupToken(token string) (Role, bool) {
+	var (
+		role  Role
+		found bool
+	)
+	for known, granted := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			role = granted
+			found = true
+		}
+	}
+	return role, found
+}
+
+func (a *Authorizer) authorize(ctx context.Context, fullMethod string) error {
+	role, err := a.role(ctx)
+	if err != nil {
+		return err
+	}
+	grpctags.Extract(ctx).Set("grpc.auth.role", string(role))
+	required := a.required(fullMethod)
+	if !role.allows(required) {
+		return status.Errorf(codes.PermissionDenied, "method %s requires role %s", fullMethod, required)
+	}
+	return nil
+}
+
+func (a *Authorizer) unaryInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Authorizer) streamInterceptor(
+	srv any,
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := a.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// ServerOptions returns grpc server options that enforce the authorization policies on every call.
+func (a *Authorizer) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unaryInterceptor),
+		grpc.ChainStreamInterceptor(a.streamInterceptor),
+	}
+}
+
+// Handler returns an http handler that enforces the authorization policies before calling next.
+// Callers authenticate with the same bearer tokens that are accepted on the grpc listeners.
+func (a *Authorizer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := metadata.MD{"authorization": r.Header.Values("Authorization")}
+		role, err := a.role(metadata.NewIncomingContext(r.Context(), md))
+		if err != nil {
+			rpc.WriteError(w, err)
+			return
+		}
+		required := a.requiredRoute(r.Method, r.URL.Path)
+		if !role.allows(required) {
+			rpc.WriteError(w, status.Errorf(codes.PermissionDenied,
+				"%s %s requires role %s", r.Method, r.URL.Path, required))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
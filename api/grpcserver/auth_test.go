@@ -0,0 +1,180 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestAuthorizer_Required(t *testing.T) {
+	a, err := NewAuthorizer(AuthConfig{
+		DefaultRole: RoleRead,
+		Policies: map[string]Role{
+			"spacemesh.v1.MeshService/LayersQuery": RoleWallet,
+			"/spacemesh.v1.DebugService":           RoleRead,
+		},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		method   string
+		required Role
+	}{
+		{"/spacemesh.v1.NodeService/Echo", RoleRead},
+		{"/spacemesh.v1.TransactionService/TransactionsState", RoleRead},
+		{"/spacemesh.v1.TransactionService/SubmitTransaction", RoleWallet},
+		{"/spacemesh.v1.SmesherService/StartSmeshing", RoleAdmin},
+		{"/spacemesh.v1.AdminService/Recover", RoleAdmin},
+		{"/spacemesh.v1.MeshService/LayersQuery", RoleWallet},
+		{"/spacemesh.v1.MeshService/CurrentLayer", RoleRead},
+		{"/spacemesh.v1.DebugService/NetworkInfo", RoleRead},
+		{"/unknown.Service/Method", RoleAdmin},
+		{HareResultsPath, RoleRead},
+		{SubmitBatchPath, RoleWallet},
+		{LivenessPath, RoleNone},
+		{EventsPath, RoleAdmin},
+		{TortoiseProgressPath, RoleAdmin},
+	} {
+		t.Run(tc.method, func(t *testing.T) {
+			require.Equal(t, tc.required, a.required(tc.method))
+		})
+	}
+	require.Equal(t, RoleRead, a.requiredRoute(http.MethodPost, "/v1/node/status"))
+	require.Equal(t, RoleWallet, a.requiredRoute(http.MethodPost, "/v1/mesh/layersquery"))
+	require.Equal(t, RoleAdmin, a.requiredRoute(http.MethodPost, "/v1/smesher/startsmeshing"))
+	require.Equal(t, RoleWallet, a.requiredRoute(http.MethodPost, "/v1/transaction/submittransaction"))
+	require.Equal(t, RoleRead, a.requiredRoute(http.MethodGet, BeaconFallbackPath))
+	require.Equal(t, RoleAdmin, a.requiredRoute(http.MethodPost, BeaconFallbackPath))
+}
+
+func TestAuthorizer_InvalidConfig(t *testing.T) {
+	_, err := NewAuthorizer(AuthConfig{DefaultRole: "root"})
+	require.ErrorContains(t, err, "unknown role")
+
+	_, err = NewAuthorizer(AuthConfig{DefaultRole: RoleRead, Tokens: map[string]Role{"": RoleAdmin}})
+	require.ErrorContains(t, err, "empty token")
+
+	_, err = NewAuthorizer(AuthConfig{DefaultRole: RoleRead, Tokens: map[string]Role{"secret": "root"}})
+	require.ErrorContains(t, err, "unknown role")
+
+	_, err = NewAuthorizer(AuthConfig{
+		DefaultRole: RoleRead,
+		Policies:    map[string]Role{"spacemesh.v1.NodeService": "root"},
+	})
+	require.ErrorContains(t, err, "unknown role")
+}
+
+func TestAuthorizer_Role(t *testing.T) {
+	a, err := NewAuthorizer(AuthConfig{
+		DefaultRole: RoleNone,
+		Tokens: map[string]Role{
+			"reader": RoleRead,
+			"admin":  RoleAdmin,
+		},
+	})
+	require.NoError(t, err)
+
+	role, err := a.role(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, RoleNone, role)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer reader"))
+	role, err = a.role(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RoleRead, role)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", "Bearer reader",
+		"authorization", "Bearer admin",
+	))
+	role, err = a.role(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RoleAdmin, role)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer unknown"))
+	_, err = a.role(ctx)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic admin"))
+	_, err = a.role(ctx)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthorizer_ServerOptions(t *testing.T) {
+	a, err := NewAuthorizer(AuthConfig{
+		DefaultRole: RoleRead,
+		Tokens:      map[string]Role{"secret": RoleAdmin},
+		Policies:    map[string]Role{"spacemesh.v1.NodeService/Version": RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	cfg := DefaultTestConfig()
+	svc := NewNodeService(nil, nil, nil, nil, "v0.0.0", "cafebabe")
+	server, err := NewWithServices(
+		cfg.PublicListener, zaptest.NewLogger(t), cfg, []ServiceAPI{svc}, a.ServerOptions()...)
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { require.NoError(t, server.Close()) })
+	cfg.PublicListener = server.BoundAddress
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := pb.NewNodeServiceClient(dialGrpc(ctx, t, cfg))
+
+	_, err = client.Echo(ctx, &pb.EchoRequest{Msg: &pb.SimpleString{Value: "hello"}})
+	require.NoError(t, err)
+
+	_, err = client.Version(ctx, &emptypb.Empty{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Version(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer invalid"), &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err := client.Version(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret"), &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, "v0.0.0", res.VersionString.Value)
+}
+
+func TestAuthorizer_Handler(t *testing.T) {
+	a, err := NewAuthorizer(AuthConfig{
+		DefaultRole: RoleRead,
+		Tokens:      map[string]Role{"secret": RoleAdmin},
+		Policies:    map[string]Role{"spacemesh.v1.NodeService/Version": RoleAdmin},
+	})
+	require.NoError(t, err)
+
+	svc := NewNodeService(nil, nil, nil, nil, "v0.0.0", "cafebabe")
+	server := NewJSONHTTPServer("127.0.0.1:0", zaptest.NewLogger(t), WithAuthorizer(a))
+	require.NoError(t, server.StartService(context.Background(), svc))
+	t.Cleanup(func() { require.NoError(t, server.Shutdown(context.Background())) })
+
+	call := func(path, token string) int {
+		body := strings.NewReader(`{"msg":{"value":"hello"}}`)
+		req, err := http.NewRequest(http.MethodPost, "http://"+server.BoundAddress+path, body)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, call("/v1/node/echo", ""))
+	require.Equal(t, http.StatusForbidden, call("/v1/node/version", ""))
+	require.Equal(t, http.StatusUnauthorized, call("/v1/node/version", "invalid"))
+	require.Equal(t, http.StatusOK, call("/v1/node/version", "secret"))
+	// routes that are not served by the gateway require admin, like unknown grpc services
+	require.Equal(t, http.StatusForbidden, call(CheckpointGeneratePath, ""))
+	require.Equal(t, http.StatusNotFound, call(CheckpointGeneratePath, "secret"))
+}
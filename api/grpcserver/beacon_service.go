@@ -0,0 +1,425 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/beacon"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+	"github.com/spacemeshos/go-spacemesh/sql/beaconstats"
+)
+
+const (
+	// BeaconFallbackPath serves fallback beacons used by the node on GET,
+	// and accepts fallback beacons signed by the authorities on POST.
+	BeaconFallbackPath = "/v1/beacon/fallback"
+	// BeaconStatsPath serves statistics of the beacon protocol runs in the range of epochs
+	// [start_epoch, end_epoch]. Statistics are recorded only by nodes in the beacon observer mode.
+	BeaconStatsPath = "/v1/beacon/stats"
+	// BeaconProvenancePath serves beacons in the range of epochs [start_epoch, end_epoch]
+	// with the record of how they were obtained.
+	BeaconProvenancePath = "/v1/beacon/provenance"
+	// BeaconGrpcService is the name of the grpc service that serves the Provenance method.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	BeaconGrpcService = "spacemesh.node.v1.BeaconService"
+
+	// MaxBeaconStats is the largest range of epochs that can be requested at once.
+	MaxBeaconStats = 100
+
+	// maxFallbackBody limits the size of the json body accepted on BeaconFallbackPath.
+	maxFallbackBody = 1 << 16
+)
+
+// FallbackBeacon is the beacon signed by the quorum of authorities. Bytes are hex encoded.
+type FallbackBeacon struct {
+	Epoch      uint32              `json:"epoch"`
+	Beacon     string              `json:"beacon"`
+	Signatures []FallbackSignature `json:"signatures"`
+	// Received and Active are set only in responses. Active is false if the beacon
+	// for the epoch was changed after the fallback was received.
+	Received time.Time `json:"received,omitempty"`
+	Active   bool      `json:"active,omitempty"`
+}
+
+// FallbackSignature is the signature of the authority on the fallback beacon.
+type FallbackSignature struct {
+	Authority string `json:"authority"`
+	Signature string `json:"signature"`
+}
+
+// BeaconStats is the statistics of the beacon protocol that was run in the epoch,
+// to compute the beacon for the target epoch.
+type BeaconStats struct {
+	Epoch       uint32 `json:"epoch"`
+	TargetEpoch uint32 `json:"target_epoch"`
+	Weight      uint64 `json:"weight"`
+	// Proposals that were received, by validity.
+	ValidProposals            uint32 `json:"valid_proposals"`
+	PotentiallyValidProposals uint32 `json:"potentially_valid_proposals"`
+	InvalidProposals          uint32 `json:"invalid_proposals"`
+	// Beacon is empty if the protocol failed.
+	Beacon string             `json:"beacon,omitempty"`
+	Rounds []BeaconRoundStats `json:"rounds"`
+}
+
+// BeaconRoundStats is the statistics of the voting round. Votes are counted by the number of messages
+// and by the weight of the voters. Proposals are counted by the local opinion at the end of the round.
+type BeaconRoundStats struct {
+	Round     uint32 `json:"round"`
+	Votes     uint32 `json:"votes"`
+	Weight    uint64 `json:"weight"`
+	Support   uint32 `json:"support"`
+	Against   uint32 `json:"against"`
+	Undecided uint32 `json:"undecided"`
+	// Coin is the weak coin used for undecided proposals, it is not set in the first round.
+	Coin *bool `json:"coin,omitempty"`
+}
+
+// BeaconProvenance is the beacon for the epoch with the record of how it was obtained.
+type BeaconProvenance struct {
+	Epoch  uint32 `json:"epoch"`
+	Beacon string `json:"beacon"`
+	// Source is one of protocol, ballots, fallback, bootstrap or unknown for beacons recorded
+	// before the provenance was tracked.
+	Source string `json:"source"`
+	// Support is the weight that supported the beacon out of the total Weight. For the protocol it is
+	// the weight of the voters in the last round out of the epoch weight, for ballots it is the weight
+	// of the ballots with the beacon out of the weight of all ballots. Both are not set for other sources.
+	Support uint64 `json:"support,omitempty"`
+	Weight  uint64 `json:"weight,omitempty"`
+}
+
+// BeaconProvenanceRequest selects the range of epochs [start_epoch, end_epoch], end_epoch defaults to start_epoch.
+type BeaconProvenanceRequest struct {
+	StartEpoch uint32 `json:"start_epoch"`
+	EndEpoch   uint32 `json:"end_epoch"`
+}
+
+// BeaconProvenanceResponse is the response of the Provenance method.
+type BeaconProvenanceResponse struct {
+	Beacons []BeaconProvenance `json:"beacons"`
+}
+
+// BeaconService exposes fallback beacons, so that their use is auditable, statistics of the beacon protocol
+// and provenance of the beacons. Provenance is served on the json gateway and as BeaconGrpcService,
+// fallback beacons and statistics only on the json gateway.
+type BeaconService struct {
+	db       sql.Executor
+	fallback fallbackBeacon
+}
+
+// NewBeaconService creates a new beacon service.
+func NewBeaconService(db sql.Executor, fallback fallbackBeacon) *BeaconService {
+	return &BeaconService{db: db, fallback: fallback}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *BeaconService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&beaconDesc, s)
+}
+
+type beaconServer interface {
+	provenance(context.Context, *BeaconProvenanceRequest) (*BeaconProvenanceResponse, error)
+}
+
+var beaconDesc = grpc.ServiceDesc{
+	ServiceName: BeaconGrpcService,
+	HandlerType: (*beaconServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(BeaconGrpcService, "Provenance", beaconServer.provenance),
+	},
+	Metadata: "api/grpcserver/beacon_service.go",
+}
+
+// RegisterHandlerService registers the beacon routes with the json gateway.
+func (s *BeaconService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, BeaconFallbackPath, s.handleFallbacks); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodPost, BeaconFallbackPath, s.handleSubmit); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, BeaconStatsPath, s.handleStats); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, BeaconProvenancePath, s.handleProvenance)
+}
+
+// String returns the name of this service.
+func (s *BeaconService) String() string {
+	return "BeaconService"
+}
+
+// Fallbacks returns all fallback beacons recorded by the node.
+func (s *BeaconService) Fallbacks(ctx context.Context) ([]FallbackBeacon, error) {
+	fallbacks, err := beacons.Fallbacks(s.db)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]FallbackBeacon, 0, len(fallbacks))
+	for _, fallback := range fallbacks {
+		var certificate beacon.FallbackBeacon
+		if err := codec.Decode(fallback.Certificate, &certificate); err != nil {
+			return nil, apiError(codes.Internal, ReasonInternal,
+				fmt.Sprintf("decode fallback certificate for epoch %d: %s", fallback.Epoch, err))
+		}
+		current, err := beacons.Get(s.db, fallback.Epoch)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+		encoded := toFallbackBeacon(&certificate)
+		encoded.Received = fallback.Received.UTC()
+		encoded.Active = current == fallback.Beacon
+		rst = append(rst, encoded)
+	}
+	return rst, nil
+}
+
+// SubmitFallback uses the fallback beacon and publishes it to the network if it is signed by the quorum.
+func (s *BeaconService) SubmitFallback(ctx context.Context, fallback *FallbackBeacon) error {
+	decoded, err := fromFallbackBeacon(fallback)
+	if err != nil {
+		return err
+	}
+	if err := s.fallback.SubmitFallbackBeacon(ctx, decoded); err != nil {
+		if errors.Is(err, pubsub.ErrValidationReject) {
+			return apiError(codes.InvalidArgument, ReasonInvalidArgument, err.Error())
+		}
+		return apiError(codes.FailedPrecondition, ReasonFallbackRejected, err.Error())
+	}
+	return nil
+}
+
+// Stats returns statistics of the beacon protocol runs in the range of epochs [start, end].
+func (s *BeaconService) Stats(ctx context.Context, start, end types.EpochID) ([]BeaconStats, error) {
+	if err := checkEpochRange(start, end); err != nil {
+		return nil, err
+	}
+	stored, err := beaconstats.Range(s.db, start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]BeaconStats, 0, len(stored))
+	for _, stats := range stored {
+		encoded := BeaconStats{
+			Epoch:                     stats.Epoch.Uint32(),
+			TargetEpoch:               stats.Epoch.Uint32() + 1,
+			Weight:                    stats.Weight,
+			ValidProposals:            stats.Valid,
+			PotentiallyValidProposals: stats.PotentiallyValid,
+			InvalidProposals:          stats.Invalid,
+			Rounds:                    make([]BeaconRoundStats, 0, len(stats.Rounds)),
+		}
+		if stats.Beacon != types.EmptyBeacon {
+			encoded.Beacon = hex.EncodeToString(stats.Beacon[:])
+		}
+		for _, round := range stats.Rounds {
+			encoded.Rounds = append(encoded.Rounds, BeaconRoundStats{
+				Round:     uint32(round.Round),
+				Votes:     round.Votes,
+				Weight:    round.Weight,
+				Support:   round.Support,
+				Against:   round.Against,
+				Undecided: round.Undecided,
+				Coin:      round.Coin,
+			})
+		}
+		rst = append(rst, encoded)
+	}
+	return rst, nil
+}
+
+// Provenance returns beacons with their provenance in the range of epochs [start, end].
+func (s *BeaconService) Provenance(ctx context.Context, start, end types.EpochID) ([]BeaconProvenance, error) {
+	if err := checkEpochRange(start, end); err != nil {
+		return nil, err
+	}
+	stored, err := beacons.Provenances(s.db, start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]BeaconProvenance, 0, len(stored))
+	for _, provenance := range stored {
+		rst = append(rst, BeaconProvenance{
+			Epoch:   provenance.Epoch.Uint32(),
+			Beacon:  hex.EncodeToString(provenance.Beacon[:]),
+			Source:  provenance.Source.String(),
+			Support: provenance.Support,
+			Weight:  provenance.Weight,
+		})
+	}
+	return rst, nil
+}
+
+func checkEpochRange(start, end types.EpochID) error {
+	switch {
+	case end < start:
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument, "end epoch is before start epoch")
+	case end-start >= MaxBeaconStats:
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("epoch range is capped at %d", MaxBeaconStats))
+	}
+	return nil
+}
+
+func toFallbackBeacon(fallback *beacon.FallbackBeacon) FallbackBeacon {
+	rst := FallbackBeacon{
+		Epoch:      fallback.Epoch.Uint32(),
+		Beacon:     hex.EncodeToString(fallback.Beacon[:]),
+		Signatures: make([]FallbackSignature, 0, len(fallback.Signatures)),
+	}
+	for _, sig := range fallback.Signatures {
+		rst.Signatures = append(rst.Signatures, FallbackSignature{
+			Authority: hex.EncodeToString(sig.Authority[:]),
+			Signature: hex.EncodeToString(sig.Signature[:]),
+		})
+	}
+	return rst
+}
+
+func fromFallbackBeacon(fallback *FallbackBeacon) (*beacon.FallbackBeacon, error) {
+	rst := &beacon.FallbackBeacon{}
+	rst.Epoch = types.EpochID(fallback.Epoch)
+	if err := decodeHexParam("beacon", fallback.Beacon, rst.Beacon[:]); err != nil {
+		return nil, err
+	}
+	for _, sig := range fallback.Signatures {
+		var decoded beacon.FallbackSignature
+		if err := decodeHexParam("authority", sig.Authority, decoded.Authority[:]); err != nil {
+			return nil, err
+		}
+		if err := decodeHexParam("signature", sig.Signature, decoded.Signature[:]); err != nil {
+			return nil, err
+		}
+		rst.Signatures = append(rst.Signatures, decoded)
+	}
+	return rst, nil
+}
+
+func decodeHexParam(name, value string, dst []byte) error {
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != len(dst) {
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("%s must be %d hex encoded bytes", name, len(dst)))
+	}
+	copy(dst, decoded)
+	return nil
+}
+
+func (s *BeaconService) handleFallbacks(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	rst, err := s.Fallbacks(r.Context())
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Fallbacks []FallbackBeacon `json:"fallbacks"`
+	}{rst})
+}
+
+func (s *BeaconService) handleSubmit(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req FallbackBeacon
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxFallbackBody)).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument, fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	if err := s.SubmitFallback(r.Context(), &req); err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct{}{})
+}
+
+func epochParam(r *http.Request, name string) (types.EpochID, bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, false, nil
+	}
+	epoch, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("parse %s: %s", name, err))
+	}
+	return types.EpochID(epoch), true, nil
+}
+
+// epochRange parses the range of epochs [start_epoch, end_epoch], end_epoch defaults to start_epoch.
+func epochRange(r *http.Request) (types.EpochID, types.EpochID, error) {
+	start, _, err := epochParam(r, "start_epoch")
+	if err != nil {
+		return 0, 0, err
+	}
+	end, exists, err := epochParam(r, "end_epoch")
+	if err != nil {
+		return 0, 0, err
+	}
+	if !exists {
+		end = start
+	}
+	return start, end, nil
+}
+
+func (s *BeaconService) handleStats(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, end, err := epochRange(r)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.Stats(r.Context(), start, end)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Stats []BeaconStats `json:"stats"`
+	}{rst})
+}
+
+func (s *BeaconService) provenance(
+	ctx context.Context,
+	req *BeaconProvenanceRequest,
+) (*BeaconProvenanceResponse, error) {
+	end := req.EndEpoch
+	if end == 0 {
+		end = req.StartEpoch
+	}
+	rst, err := s.Provenance(ctx, types.EpochID(req.StartEpoch), types.EpochID(end))
+	if err != nil {
+		return nil, err
+	}
+	return &BeaconProvenanceResponse{Beacons: rst}, nil
+}
+
+func (s *BeaconService) handleProvenance(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, end, err := epochRange(r)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.provenance(r.Context(), &BeaconProvenanceRequest{StartEpoch: start.Uint32(), EndEpoch: end.Uint32()})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
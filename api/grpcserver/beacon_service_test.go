@@ -0,0 +1,230 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/beacon"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+	"github.com/spacemeshos/go-spacemesh/sql/beaconstats"
+)
+
+func TestBeaconService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	submitter := NewMockfallbackBeacon(ctrl)
+	db := sql.InMemory()
+	svc := NewBeaconService(db, submitter)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	certificates := []*beacon.FallbackBeacon{
+		{
+			FallbackBeaconBody: beacon.FallbackBeaconBody{Epoch: 3, Beacon: types.Beacon{1, 2, 3, 4}},
+			Signatures: []beacon.FallbackSignature{
+				{Authority: types.RandomNodeID(), Signature: types.RandomEdSignature()},
+				{Authority: types.RandomNodeID(), Signature: types.RandomEdSignature()},
+			},
+		},
+		{
+			FallbackBeaconBody: beacon.FallbackBeaconBody{Epoch: 4, Beacon: types.Beacon{5, 6, 7, 8}},
+			Signatures: []beacon.FallbackSignature{
+				{Authority: types.RandomNodeID(), Signature: types.RandomEdSignature()},
+			},
+		},
+	}
+	received := time.Unix(1000, 0).UTC()
+	for _, certificate := range certificates {
+		require.NoError(t, beacons.SetFallback(db, &beacons.Fallback{
+			Epoch:       certificate.Epoch,
+			Beacon:      certificate.Beacon,
+			Certificate: codec.MustEncode(certificate),
+			Received:    received,
+		}))
+	}
+	require.NoError(t, beacons.Set(db, 3, certificates[0].Beacon))
+	require.NoError(t, beacons.Set(db, 4, types.Beacon{9}))
+
+	t.Run("fallbacks", func(t *testing.T) {
+		fallbacks, err := svc.Fallbacks(context.Background())
+		require.NoError(t, err)
+		require.Len(t, fallbacks, 2)
+		expected := toFallbackBeacon(certificates[0])
+		expected.Received = received
+		expected.Active = true
+		require.Equal(t, expected, fallbacks[0])
+		require.Equal(t, hex.EncodeToString(certificates[0].Signatures[1].Authority[:]),
+			fallbacks[0].Signatures[1].Authority)
+		require.EqualValues(t, 4, fallbacks[1].Epoch)
+		require.False(t, fallbacks[1].Active)
+	})
+	t.Run("submit", func(t *testing.T) {
+		encoded := toFallbackBeacon(certificates[1])
+		submitter.EXPECT().SubmitFallbackBeacon(gomock.Any(), certificates[1])
+		require.NoError(t, svc.SubmitFallback(context.Background(), &encoded))
+
+		submitter.EXPECT().SubmitFallbackBeacon(gomock.Any(), certificates[1]).
+			Return(fmt.Errorf("%w: not signed", pubsub.ErrValidationReject))
+		reason, _, _ := ErrorReasonOf(svc.SubmitFallback(context.Background(), &encoded))
+		require.Equal(t, ReasonInvalidArgument, reason)
+
+		submitter.EXPECT().SubmitFallbackBeacon(gomock.Any(), certificates[1]).Return(errors.New("disabled"))
+		reason, _, _ = ErrorReasonOf(svc.SubmitFallback(context.Background(), &encoded))
+		require.Equal(t, ReasonFallbackRejected, reason)
+
+		invalid := encoded
+		invalid.Beacon = "0102"
+		reason, _, _ = ErrorReasonOf(svc.SubmitFallback(context.Background(), &invalid))
+		require.Equal(t, ReasonInvalidArgument, reason)
+	})
+	t.Run("json", func(t *testing.T) {
+		url := fmt.Sprintf("http://%s%s", cfg.JSONListener, BeaconFallbackPath)
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var rst struct {
+			Fallbacks []FallbackBeacon `json:"fallbacks"`
+		}
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Len(t, rst.Fallbacks, 2)
+		require.Equal(t, hex.EncodeToString(certificates[0].Beacon[:]), rst.Fallbacks[0].Beacon)
+		require.Equal(t, received, rst.Fallbacks[0].Received)
+
+		body, err := json.Marshal(toFallbackBeacon(certificates[0]))
+		require.NoError(t, err)
+		submitter.EXPECT().SubmitFallbackBeacon(gomock.Any(), certificates[0])
+		resp, err = http.Post(url, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Post(url, "application/json", bytes.NewReader([]byte("{")))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("stats", func(t *testing.T) {
+		coin := true
+		for _, stats := range []*beaconstats.Epoch{
+			{
+				Epoch: 2, Weight: 100, Valid: 3, PotentiallyValid: 1, Invalid: 1, Beacon: types.Beacon{1, 2, 3, 4},
+				Rounds: []beaconstats.Round{
+					{Round: 0, Votes: 4, Weight: 100, Support: 3, Against: 1},
+					{Round: 1, Votes: 4, Weight: 100, Support: 3, Undecided: 1, Coin: &coin},
+				},
+			},
+			{Epoch: 3, Weight: 200},
+		} {
+			require.NoError(t, beaconstats.Add(db, stats))
+		}
+
+		rst, err := svc.Stats(context.Background(), 1, 5)
+		require.NoError(t, err)
+		require.Len(t, rst, 2)
+		require.Equal(t, BeaconStats{
+			Epoch: 2, TargetEpoch: 3, Weight: 100,
+			ValidProposals: 3, PotentiallyValidProposals: 1, InvalidProposals: 1,
+			Beacon: "01020304",
+			Rounds: []BeaconRoundStats{
+				{Round: 0, Votes: 4, Weight: 100, Support: 3, Against: 1},
+				{Round: 1, Votes: 4, Weight: 100, Support: 3, Undecided: 1, Coin: &coin},
+			},
+		}, rst[0])
+		require.Empty(t, rst[1].Beacon)
+		require.Empty(t, rst[1].Rounds)
+
+		_, err = svc.Stats(context.Background(), 3, 2)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+		_, err = svc.Stats(context.Background(), 0, MaxBeaconStats)
+		reason, _, _ = ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?start_epoch=3", cfg.JSONListener, BeaconStatsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var encoded struct {
+			Stats []BeaconStats `json:"stats"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&encoded))
+		require.Len(t, encoded.Stats, 1)
+		require.EqualValues(t, 3, encoded.Stats[0].Epoch)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?start_epoch=x", cfg.JSONListener, BeaconStatsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("provenance", func(t *testing.T) {
+		require.NoError(t, beacons.SetProvenance(db, &beacons.Provenance{
+			Epoch:  3,
+			Beacon: certificates[0].Beacon,
+			Source: beacons.SourceFallback,
+		}))
+		require.NoError(t, beacons.Add(db, 5, types.Beacon{5}))
+		require.NoError(t, beacons.SetProvenance(db, &beacons.Provenance{
+			Epoch:   5,
+			Beacon:  types.Beacon{5},
+			Source:  beacons.SourceProtocol,
+			Support: 70,
+			Weight:  100,
+		}))
+
+		_, err := svc.Provenance(context.Background(), 0, MaxBeaconStats)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?start_epoch=3&end_epoch=6",
+			cfg.JSONListener, BeaconProvenancePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var encoded struct {
+			Beacons []BeaconProvenance `json:"beacons"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&encoded))
+		require.Equal(t, []BeaconProvenance{
+			{Epoch: 3, Beacon: "01020304", Source: "fallback"},
+			{Epoch: 4, Beacon: "09000000", Source: "unknown"},
+			{Epoch: 5, Beacon: "05000000", Source: "protocol", Support: 70, Weight: 100},
+		}, encoded.Beacons)
+
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+		rst, err := rpc.Invoke[BeaconProvenanceRequest, BeaconProvenanceResponse](
+			ctx, conn, BeaconGrpcService, "Provenance", rpc.JSON, &BeaconProvenanceRequest{StartEpoch: 5},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []BeaconProvenance{
+			{Epoch: 5, Beacon: "05000000", Source: "protocol", Support: 70, Weight: 100},
+		}, rst.Beacons)
+		_, err = rpc.Invoke[BeaconProvenanceRequest, BeaconProvenanceResponse](
+			ctx, conn, BeaconGrpcService, "Provenance", rpc.JSON, &BeaconProvenanceRequest{StartEpoch: 5, EndEpoch: 4},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,142 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/timesync"
+)
+
+const (
+	// ClockPath serves the current time and layer of the layer clock.
+	ClockPath = "/v1/clock"
+	// ClockGrpcService is the name of the grpc service that serves Status and Advance methods.
+	// Advance moves the stepped layer clock forward and is not served on the json gateway.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	ClockGrpcService = "spacemesh.node.v1.ClockService"
+)
+
+// ClockStatusRequest is the request of the Status method.
+type ClockStatusRequest struct{}
+
+// ClockStatus is the state of the layer clock.
+type ClockStatus struct {
+	Mode  string    `json:"mode"`
+	Time  time.Time `json:"time"`
+	Layer uint32    `json:"layer"`
+}
+
+// ClockAdvanceRequest moves the clock forward either by the duration (e.g. "90s")
+// or to the start of the layer. Only one of the fields can be set.
+type ClockAdvanceRequest struct {
+	Duration string `json:"duration,omitempty"`
+	Layer    uint32 `json:"layer,omitempty"`
+}
+
+// ClockService allows the test harness to drive the layer clock of the node.
+// The clock can be moved only over grpc, the service should be enabled only on the private listener
+// in integration environments.
+type ClockService struct {
+	source timeSource
+	clock  layerClock
+}
+
+// NewClockService creates a new clock service.
+func NewClockService(source timeSource, clock layerClock) *ClockService {
+	return &ClockService{source: source, clock: clock}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *ClockService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&clockDesc, s)
+}
+
+type clockServer interface {
+	status(context.Context, *ClockStatusRequest) (*ClockStatus, error)
+	advance(context.Context, *ClockAdvanceRequest) (*ClockStatus, error)
+}
+
+var clockDesc = grpc.ServiceDesc{
+	ServiceName: ClockGrpcService,
+	HandlerType: (*clockServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(ClockGrpcService, "Status", clockServer.status),
+		rpc.UnaryMethod(ClockGrpcService, "Advance", clockServer.advance),
+	},
+	Metadata: "api/grpcserver/clock_service.go",
+}
+
+// RegisterHandlerService registers the read-only clock route with the json gateway.
+func (s *ClockService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	return mux.HandlePath(http.MethodGet, ClockPath, s.handleStatus)
+}
+
+// String returns the name of this service.
+func (s *ClockService) String() string {
+	return "ClockService"
+}
+
+// Status returns the current state of the clock.
+func (s *ClockService) Status() *ClockStatus {
+	return &ClockStatus{
+		Mode:  s.source.Mode(),
+		Time:  s.source.Now(),
+		Layer: s.clock.CurrentLayer().Uint32(),
+	}
+}
+
+// Advance moves the stepped clock forward.
+func (s *ClockService) Advance(req *ClockAdvanceRequest) (*ClockStatus, error) {
+	var d time.Duration
+	switch {
+	case req.Duration != "" && req.Layer != 0:
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, "only one of duration or layer can be set")
+	case req.Duration != "":
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil || parsed <= 0 {
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("duration must be positive: %q", req.Duration))
+		}
+		d = parsed
+	case req.Layer != 0:
+		layer := types.LayerID(req.Layer)
+		if !layer.After(s.clock.CurrentLayer()) {
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("layer %d is not after the current layer %d", layer, s.clock.CurrentLayer()))
+		}
+		d = s.clock.LayerToTime(layer).Sub(s.source.Now())
+	default:
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, "duration or layer must be set")
+	}
+	if _, err := s.source.Advance(d); err != nil {
+		if errors.Is(err, timesync.ErrNotStepped) {
+			return nil, apiError(codes.FailedPrecondition, ReasonInvalidArgument,
+				fmt.Sprintf("clock can be advanced only in the %s mode", timesync.SourceStepped))
+		}
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return s.Status(), nil
+}
+
+func (s *ClockService) status(context.Context, *ClockStatusRequest) (*ClockStatus, error) {
+	return s.Status(), nil
+}
+
+func (s *ClockService) advance(_ context.Context, req *ClockAdvanceRequest) (*ClockStatus, error) {
+	return s.Advance(req)
+}
+
+func (s *ClockService) handleStatus(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Status())
+}
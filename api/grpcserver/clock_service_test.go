@@ -0,0 +1,113 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/timesync"
+)
+
+func newClockService(tb testing.TB, source *timesync.TimeSource, genesis time.Time) *ClockService {
+	clock, err := timesync.NewClock(
+		timesync.WithClock(source),
+		timesync.WithLayerDuration(time.Minute),
+		timesync.WithTickInterval(time.Second),
+		timesync.WithGenesisTime(genesis),
+		timesync.WithLogger(zaptest.NewLogger(tb)),
+	)
+	require.NoError(tb, err)
+	tb.Cleanup(clock.Close)
+	return NewClockService(source, clock)
+}
+
+func dialClockService(tb testing.TB, svc *ClockService) *grpc.ClientConn {
+	cfg, cleanup := launchServer(tb, svc)
+	tb.Cleanup(cleanup)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return dialGrpc(ctx, tb, cfg)
+}
+
+func advanceClock(conn *grpc.ClientConn, req ClockAdvanceRequest) (*ClockStatus, error) {
+	return rpc.Invoke[ClockAdvanceRequest, ClockStatus](
+		context.Background(), conn, ClockGrpcService, "Advance", rpc.JSON, &req,
+	)
+}
+
+func TestClockService_Stepped(t *testing.T) {
+	genesis := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source, err := timesync.NewTimeSource(timesync.SourceConfig{
+		Mode:  timesync.SourceStepped,
+		Start: genesis.Format(time.RFC3339),
+	})
+	require.NoError(t, err)
+	svc := newClockService(t, source, genesis)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+	conn := dialClockService(t, svc)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, ClockPath))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var st ClockStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&st))
+	require.Equal(t, ClockStatus{Mode: timesync.SourceStepped, Time: genesis}, st)
+
+	// the clock can't be moved over the json gateway
+	post, err := http.Post(fmt.Sprintf("http://%s%s/advance", cfg.JSONListener, ClockPath),
+		"application/json", bytes.NewReader([]byte(`{"duration":"90s"}`)))
+	require.NoError(t, err)
+	post.Body.Close()
+	require.Equal(t, http.StatusNotFound, post.StatusCode)
+
+	rst, err := rpc.Invoke[ClockStatusRequest, ClockStatus](
+		context.Background(), conn, ClockGrpcService, "Status", rpc.JSON, &ClockStatusRequest{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, genesis, rst.Time)
+
+	rst, err = advanceClock(conn, ClockAdvanceRequest{Duration: "90s"})
+	require.NoError(t, err)
+	require.Equal(t, genesis.Add(90*time.Second), rst.Time)
+	require.Equal(t, uint32(1), rst.Layer)
+
+	rst, err = advanceClock(conn, ClockAdvanceRequest{Layer: 10})
+	require.NoError(t, err)
+	require.Equal(t, genesis.Add(10*time.Minute), rst.Time)
+	require.Equal(t, uint32(10), rst.Layer)
+
+	for _, req := range []ClockAdvanceRequest{
+		{},
+		{Duration: "-1s"},
+		{Duration: "1s", Layer: 20},
+		{Layer: 5},
+	} {
+		_, err = advanceClock(conn, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "request %+v", req)
+	}
+}
+
+func TestClockService_NotStepped(t *testing.T) {
+	source, err := timesync.NewTimeSource(timesync.DefaultSourceConfig())
+	require.NoError(t, err)
+	conn := dialClockService(t, newClockService(t, source, time.Now()))
+
+	_, err = NewClockService(source, nil).Advance(&ClockAdvanceRequest{Duration: "1m"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = advanceClock(conn, ClockAdvanceRequest{Duration: "1m"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
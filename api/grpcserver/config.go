@@ -20,8 +20,66 @@ type Config struct {
 	GrpcSendMsgSize int       `mapstructure:"grpc-send-msg-size"`
 	GrpcRecvMsgSize int       `mapstructure:"grpc-recv-msg-size"`
 	JSONListener    string    `mapstructure:"grpc-json-listener"`
+	// JSONServices are the services served on the JSON gateway, the public services are served if it is empty.
+	// Private services should be added only if the gateway isn't exposed publicly or authorization is enabled.
+	JSONServices []Service `mapstructure:"grpc-json-services"`
 
 	SmesherStreamInterval time.Duration `mapstructure:"smesherstreaminterval"`
+
+	Auth      AuthConfig      `mapstructure:"grpc-auth"`
+	RateLimit RateLimitConfig `mapstructure:"grpc-rate-limit"`
+	Health    HealthConfig    `mapstructure:"grpc-health"`
+}
+
+// AuthConfig configures authentication of api callers and the roles required to call services.
+type AuthConfig struct {
+	// Enabled enforces authorization on the public, private and TLS listeners and the JSON gateway.
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultRole is granted to callers that don't present any known credentials.
+	DefaultRole Role `mapstructure:"default-role"`
+	// Tokens maps bearer tokens (sent as "authorization: Bearer <token>") to roles.
+	Tokens map[string]Role `mapstructure:"tokens"`
+	// Certificates maps the common name of verified mTLS client certificates to roles.
+	Certificates map[string]Role `mapstructure:"certificates"`
+	// Policies overrides the role required to call a service (e.g. "spacemesh.v1.SmesherService")
+	// or a single method (e.g. "spacemesh.v1.SmesherService/IsSmeshing").
+	Policies map[string]Role `mapstructure:"policies"`
+}
+
+// RateLimitConfig configures the quotas of requests to the public listener and the JSON gateway.
+type RateLimitConfig struct {
+	// Enabled enforces the quotas on the public listener and the JSON gateway.
+	Enabled bool `mapstructure:"enabled"`
+	// Quota is the quota of every ip address that calls the api without an api key.
+	Quota `mapstructure:",squash"`
+	// MaxClients limits the number of ip addresses whose quotas are tracked,
+	// the least recently seen addresses are forgotten.
+	MaxClients int `mapstructure:"max-clients"`
+	// Keys maps api keys (sent as "x-api-key" header) to their quotas.
+	// Requests with unknown api keys are rejected.
+	Keys map[string]Quota `mapstructure:"keys"`
+}
+
+// Quota limits the rate of requests of a caller.
+type Quota struct {
+	// Rate is the number of requests per second.
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the number of requests that can be made at once after a period of inactivity.
+	Burst int `mapstructure:"burst"`
+}
+
+// HealthConfig configures the thresholds used by the health service.
+type HealthConfig struct {
+	// CheckTimeout limits the time spent on a single health report.
+	CheckTimeout time.Duration `mapstructure:"check-timeout"`
+	// WatchInterval is the interval between checks for clients that watch the health status.
+	WatchInterval time.Duration `mapstructure:"watch-interval"`
+	// MinPeers is the number of peers below which the peers check warns.
+	MinPeers int `mapstructure:"min-peers"`
+	// WarnFreeDisk is the free space in bytes on the data volume below which the disk check warns.
+	WarnFreeDisk uint64 `mapstructure:"warn-free-disk"`
+	// FailFreeDisk is the free space in bytes on the data volume below which the disk check fails.
+	FailFreeDisk uint64 `mapstructure:"fail-free-disk"`
 }
 
 type Service = string
@@ -37,6 +95,17 @@ const (
 	Post                     Service = "post"
 	PostInfo                 Service = "postInfo"
 	Node                     Service = "node"
+	Health                   Service = "health"
+	Hare                     Service = "hare"
+	State                    Service = "state"
+	Tortoise                 Service = "tortoise"
+	Beacon                   Service = "beacon"
+	ActiveSet                Service = "activeset"
+	Watchdog                 Service = "watchdog"
+	Clock                    Service = "clock"
+	Explorer                 Service = "explorer"
+	PostVerifier             Service = "postverifier"
+	Malfeasance              Service = "malfeasance"
 	ActivationV2Alpha1       Service = "activation_v2alpha1"
 	ActivationStreamV2Alpha1 Service = "activation_stream_v2alpha1"
 	RewardV2Alpha1           Service = "reward_v2alpha1"
@@ -48,10 +117,12 @@ func DefaultConfig() Config {
 	return Config{
 		PublicServices: []Service{
 			GlobalState, Mesh, Transaction, Node, Activation, ActivationV2Alpha1,
-			RewardV2Alpha1,
+			RewardV2Alpha1, Health, Hare, Beacon, ActiveSet, State,
+		},
+		PublicListener: "0.0.0.0:9092",
+		PrivateServices: []Service{
+			Admin, Smesher, Debug, Tortoise, ActivationStreamV2Alpha1, RewardStreamV2Alpha1, Watchdog, Malfeasance,
 		},
-		PublicListener:        "0.0.0.0:9092",
-		PrivateServices:       []Service{Admin, Smesher, Debug, ActivationStreamV2Alpha1, RewardStreamV2Alpha1},
 		PrivateListener:       "127.0.0.1:9093",
 		PostServices:          []Service{Post, PostInfo},
 		PostListener:          "127.0.0.1:9094",
@@ -61,6 +132,20 @@ func DefaultConfig() Config {
 		GrpcSendMsgSize:       1024 * 1024 * 10,
 		GrpcRecvMsgSize:       1024 * 1024 * 10,
 		SmesherStreamInterval: time.Second,
+		Auth: AuthConfig{
+			DefaultRole: RoleRead,
+		},
+		RateLimit: RateLimitConfig{
+			Quota:      Quota{Rate: 50, Burst: 100},
+			MaxClients: 10000,
+		},
+		Health: HealthConfig{
+			CheckTimeout:  5 * time.Second,
+			WatchInterval: 10 * time.Second,
+			MinPeers:      5,
+			WarnFreeDisk:  10 << 30,
+			FailFreeDisk:  1 << 30,
+		},
 	}
 }
 
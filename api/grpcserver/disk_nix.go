@@ -0,0 +1,16 @@
+//go:build !windows
+
+package grpcserver
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// freeDiskSpace returns the number of bytes available to unprivileged users on the volume with the path.
+func freeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
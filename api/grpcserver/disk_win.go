@@ -0,0 +1,20 @@
+//go:build windows
+
+package grpcserver
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// freeDiskSpace returns the number of bytes available to the current user on the volume with the path.
+func freeDiskSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/txs"
+)
+
+// ErrorDomain is the domain of the errdetails.ErrorInfo attached to errors returned by the api.
+const ErrorDomain = "api.spacemesh.io"
+
+// ErrorReason is a machine readable cause of an api error. It is attached to the grpc status
+// as errdetails.ErrorInfo, so that clients can handle errors without parsing messages.
+// Reasons are stable, messages may change between releases.
+type ErrorReason string
+
+const (
+	// ReasonMissingArgument is returned if a required field of the request is not set.
+	ReasonMissingArgument ErrorReason = "MISSING_ARGUMENT"
+	// ReasonInvalidArgument is returned if a field of the request has an invalid value.
+	ReasonInvalidArgument ErrorReason = "INVALID_ARGUMENT"
+	// ReasonInvalidAddress is returned if an account address can't be decoded.
+	ReasonInvalidAddress ErrorReason = "INVALID_ADDRESS"
+	// ReasonNotSynced is returned if the request can't be served until the node is synced.
+	ReasonNotSynced ErrorReason = "NOT_SYNCED"
+	// ReasonNotFound is returned if the requested object is not known to the node.
+	ReasonNotFound ErrorReason = "NOT_FOUND"
+	// ReasonInternal is returned if the node failed to serve a valid request.
+	ReasonInternal ErrorReason = "INTERNAL"
+	// ReasonRateLimited is returned if the caller exceeded its quota of requests.
+	ReasonRateLimited ErrorReason = "RATE_LIMITED"
+	// ReasonInvalidAPIKey is returned if the caller presented an api key that is not known to the node.
+	ReasonInvalidAPIKey ErrorReason = "INVALID_API_KEY"
+
+	// ReasonTxEmpty is returned if the submitted transaction has no payload.
+	ReasonTxEmpty ErrorReason = "TX_EMPTY"
+	// ReasonTxMalformed is returned if the transaction can't be parsed.
+	ReasonTxMalformed ErrorReason = "TX_MALFORMED"
+	// ReasonTxInvalidSignature is returned if the signature of the transaction is invalid.
+	ReasonTxInvalidSignature ErrorReason = "TX_INVALID_SIGNATURE"
+	// ReasonTxNonceTooLow is returned if the nonce of the transaction was already used by the principal.
+	ReasonTxNonceTooLow ErrorReason = "TX_NONCE_TOO_LOW"
+	// ReasonTxDuplicate is returned if the transaction is already known to the node.
+	ReasonTxDuplicate ErrorReason = "TX_DUPLICATE"
+	// ReasonTxMempoolFull is returned if the principal of the transaction has too many pending transactions.
+	// The transaction can be submitted again after earlier transactions of the principal are applied.
+	ReasonTxMempoolFull ErrorReason = "TX_MEMPOOL_FULL"
+	// ReasonTxRejected is returned if the transaction was rejected for any other reason.
+	ReasonTxRejected ErrorReason = "TX_REJECTED"
+	// ReasonTxPublishFailed is returned if a valid transaction couldn't be broadcasted.
+	ReasonTxPublishFailed ErrorReason = "TX_PUBLISH_FAILED"
+	// ReasonAccountNotSpawned is returned if the principal of the transaction is not spawned.
+	ReasonAccountNotSpawned ErrorReason = "ACCOUNT_NOT_SPAWNED"
+	// ReasonBatchTooLarge is returned if the batch exceeds MaxTransactionBatch.
+	ReasonBatchTooLarge ErrorReason = "BATCH_TOO_LARGE"
+
+	// ReasonFallbackRejected is returned if the node doesn't accept fallback beacons,
+	// or a different fallback beacon was already used for the epoch.
+	ReasonFallbackRejected ErrorReason = "FALLBACK_REJECTED"
+	// ReasonBeaconInjectionDisabled is returned if the beacon is injected on the node
+	// without unsafe beacon injection enabled.
+	ReasonBeaconInjectionDisabled ErrorReason = "BEACON_INJECTION_DISABLED"
+
+	// ReasonProofMalformed is returned if the submitted malfeasance proof can't be decoded.
+	ReasonProofMalformed ErrorReason = "PROOF_MALFORMED"
+	// ReasonProofRejected is returned if the submitted malfeasance proof is invalid.
+	ReasonProofRejected ErrorReason = "PROOF_REJECTED"
+	// ReasonKnownMalicious is returned if the identity of the submitted proof is already known to be malicious.
+	ReasonKnownMalicious ErrorReason = "KNOWN_MALICIOUS"
+
+	// ReasonSmeshingNotConfigured is returned if smeshing can't be controlled by this node.
+	ReasonSmeshingNotConfigured ErrorReason = "SMESHING_NOT_CONFIGURED"
+	// ReasonPostSupervisorFailed is returned if the post service couldn't be started or stopped.
+	ReasonPostSupervisorFailed ErrorReason = "POST_SUPERVISOR_FAILED"
+	// ReasonSmeshingFailed is returned if smeshing couldn't be started or stopped.
+	ReasonSmeshingFailed ErrorReason = "SMESHING_FAILED"
+	// ReasonInvalidResize is returned if the post data can't be resized to the requested number of units.
+	ReasonInvalidResize ErrorReason = "INVALID_RESIZE"
+
+	// ReasonLayerInFuture is returned if the requested layer wasn't applied yet.
+	ReasonLayerInFuture ErrorReason = "LAYER_IN_FUTURE"
+	// ReasonLayerPruned is returned if data for the requested layer is no longer retained.
+	ReasonLayerPruned ErrorReason = "LAYER_PRUNED"
+
+	// ReasonCheckpointInProgress is returned if the checkpoint at the same layer is already being generated.
+	ReasonCheckpointInProgress ErrorReason = "CHECKPOINT_IN_PROGRESS"
+)
+
+// apiError creates a grpc status error with the reason attached as errdetails.ErrorInfo.
+// Metadata is a list of key-value pairs with additional details, e.g. the expected value of a field.
+func apiError(code codes.Code, reason ErrorReason, msg string, metadata ...string) error {
+	return apiStatus(code, reason, msg, metadata...).Err()
+}
+
+func apiStatus(code codes.Code, reason ErrorReason, msg string, metadata ...string) *status.Status {
+	info := &errdetails.ErrorInfo{
+		Reason: string(reason),
+		Domain: ErrorDomain,
+	}
+	if len(metadata) > 0 {
+		info.Metadata = make(map[string]string, len(metadata)/2)
+		for i := 0; i+1 < len(metadata); i += 2 {
+			info.Metadata[metadata[i]] = metadata[i+1]
+		}
+	}
+	st := status.New(code, msg)
+	if detailed, err := st.WithDetails(info); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// ErrorReasonOf returns the reason and metadata of an error returned by the api.
+// It returns false if the error doesn't carry ErrorInfo from ErrorDomain.
+func ErrorReasonOf(err error) (ErrorReason, map[string]string, bool) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return "", nil, false
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == ErrorDomain {
+			return ErrorReason(info.Reason), info.Metadata, true
+		}
+	}
+	return "", nil, false
+}
+
+// txError attaches the reason of a failed transaction verification to the error.
+// Invalid transactions are reported with InvalidArgument code, as the transaction itself needs to be changed.
+// Transactions of principals with too many pending transactions are reported with ResourceExhausted,
+// as they can be submitted again later.
+func txError(err error) error {
+	reason := ReasonTxRejected
+	switch {
+	case errors.Is(err, txs.ErrTooManyNonce):
+		return apiError(codes.ResourceExhausted, ReasonTxMempoolFull,
+			fmt.Sprintf("Transaction can't be added to the mempool, try again later: %s", err.Error()))
+	case errors.Is(err, txs.ErrBadNonce):
+		reason = ReasonTxNonceTooLow
+	case errors.Is(err, txs.ErrDuplicateTX):
+		reason = ReasonTxDuplicate
+	case errors.Is(err, txs.ErrVerify):
+		reason = ReasonTxInvalidSignature
+	case errors.Is(err, txs.ErrParse):
+		reason = ReasonTxMalformed
+	}
+	return apiError(codes.InvalidArgument, reason, fmt.Sprintf("Failed to verify transaction: %s", err.Error()))
+}
@@ -0,0 +1,49 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/txs"
+)
+
+func TestErrorReasonOf(t *testing.T) {
+	err := apiError(codes.OutOfRange, ReasonLayerPruned, "pruned", "oldest_layer", "10")
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+	require.Equal(t, "pruned", status.Convert(err).Message())
+	reason, md, ok := ErrorReasonOf(err)
+	require.True(t, ok)
+	require.Equal(t, ReasonLayerPruned, reason)
+	require.Equal(t, map[string]string{"oldest_layer": "10"}, md)
+
+	_, _, ok = ErrorReasonOf(status.Error(codes.Internal, "no details"))
+	require.False(t, ok)
+	_, _, ok = ErrorReasonOf(errors.New("not a status"))
+	require.False(t, ok)
+}
+
+func TestTxError(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		code   codes.Code
+		reason ErrorReason
+	}{
+		{fmt.Errorf("add: %w", txs.ErrBadNonce), codes.InvalidArgument, ReasonTxNonceTooLow},
+		{txs.ErrDuplicateTX, codes.InvalidArgument, ReasonTxDuplicate},
+		{fmt.Errorf("%w: tx", txs.ErrVerify), codes.InvalidArgument, ReasonTxInvalidSignature},
+		{fmt.Errorf("%w: tx", txs.ErrParse), codes.InvalidArgument, ReasonTxMalformed},
+		{fmt.Errorf("%w: addr", txs.ErrTooManyNonce), codes.ResourceExhausted, ReasonTxMempoolFull},
+		{errors.New("unknown"), codes.InvalidArgument, ReasonTxRejected},
+	} {
+		err := txError(tc.err)
+		require.Equal(t, tc.code, status.Code(err))
+		reason, _, ok := ErrorReasonOf(err)
+		require.True(t, ok)
+		require.Equal(t, tc.reason, reason, tc.err.Error())
+	}
+}
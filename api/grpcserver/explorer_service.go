@@ -0,0 +1,250 @@
+package grpcserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/explorer"
+)
+
+const (
+	// ExplorerTransactionsPath serves ids of the transactions that touched the address
+	// in the range of layers [start_layer, end_layer].
+	ExplorerTransactionsPath = "/v1/explorer/transactions"
+	// ExplorerATXsPath serves activations of the smesher.
+	ExplorerATXsPath = "/v1/explorer/atxs"
+	// ExplorerRewardsPath serves rewards of all smeshers in the epoch, summed per smesher.
+	ExplorerRewardsPath = "/v1/explorer/rewards"
+
+	// MaxExplorerResults is the largest number of results returned at once.
+	MaxExplorerResults = 1000
+)
+
+// ExplorerTransaction is the json encoding of the transaction that touched the address.
+type ExplorerTransaction struct {
+	Layer uint32 `json:"layer"`
+	ID    string `json:"id"`
+}
+
+// ExplorerATX is the json encoding of the activation of the smesher.
+type ExplorerATX struct {
+	// Epoch is the epoch targeted by the activation.
+	Epoch    uint32 `json:"epoch"`
+	ID       string `json:"id"`
+	Coinbase string `json:"coinbase"`
+	Units    uint32 `json:"units"`
+	Weight   uint64 `json:"weight"`
+}
+
+// ExplorerReward is the json encoding of the rewards received by the smesher in the epoch.
+type ExplorerReward struct {
+	Smesher     string `json:"smesher"`
+	Coinbase    string `json:"coinbase"`
+	TotalReward uint64 `json:"total_reward"`
+	LayerReward uint64 `json:"layer_reward"`
+	Layers      uint32 `json:"layers"`
+}
+
+// ExplorerService serves queries on the index maintained by the explorer indexer.
+// Responses include the last indexed layer, data of later layers is not yet available.
+// It is served only on the json gateway.
+type ExplorerService struct {
+	db sql.Executor
+}
+
+// NewExplorerService creates a new explorer service.
+func NewExplorerService(db sql.Executor) *ExplorerService {
+	return &ExplorerService{db: db}
+}
+
+// RegisterService is a no-op, explorer service doesn't have a grpc api.
+func (s *ExplorerService) RegisterService(*grpc.Server) {}
+
+// RegisterHandlerService registers the explorer routes with the json gateway.
+func (s *ExplorerService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, ExplorerTransactionsPath, s.handleTransactions); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, ExplorerATXsPath, s.handleATXs); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, ExplorerRewardsPath, s.handleRewards)
+}
+
+// String returns the name of this service.
+func (s *ExplorerService) String() string {
+	return "ExplorerService"
+}
+
+// Transactions returns up to limit transactions that touched the address in the range of layers [start, end].
+func (s *ExplorerService) Transactions(
+	address types.Address,
+	start, end types.LayerID,
+	limit int,
+) ([]ExplorerTransaction, error) {
+	if end < start {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, "end layer is before start layer")
+	}
+	stored, err := explorer.TransactionsByAddress(s.db, address, start, end, limit)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]ExplorerTransaction, 0, len(stored))
+	for _, tx := range stored {
+		rst = append(rst, ExplorerTransaction{
+			Layer: tx.Layer.Uint32(),
+			ID:    hex.EncodeToString(tx.ID[:]),
+		})
+	}
+	return rst, nil
+}
+
+// ATXs returns activations of the smesher.
+func (s *ExplorerService) ATXs(smesherID types.NodeID) ([]ExplorerATX, error) {
+	stored, err := explorer.ATXsBySmesher(s.db, smesherID)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]ExplorerATX, 0, len(stored))
+	for _, atx := range stored {
+		rst = append(rst, ExplorerATX{
+			Epoch:    atx.Epoch.Uint32(),
+			ID:       hex.EncodeToString(atx.ID[:]),
+			Coinbase: atx.Coinbase.String(),
+			Units:    atx.Units,
+			Weight:   atx.Weight,
+		})
+	}
+	return rst, nil
+}
+
+// Rewards returns up to limit rewards of smeshers in the epoch, starting from offset.
+func (s *ExplorerService) Rewards(epoch types.EpochID, offset, limit int) ([]ExplorerReward, error) {
+	stored, err := explorer.RewardsByEpoch(s.db, epoch, offset, limit)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]ExplorerReward, 0, len(stored))
+	for _, reward := range stored {
+		rst = append(rst, ExplorerReward{
+			Smesher:     hex.EncodeToString(reward.SmesherID[:]),
+			Coinbase:    reward.Coinbase.String(),
+			TotalReward: reward.TotalReward,
+			LayerReward: reward.LayerReward,
+			Layers:      reward.Count,
+		})
+	}
+	return rst, nil
+}
+
+func countParam(r *http.Request, name string, def, limit int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 || parsed > limit {
+		return 0, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("%s must be a number between 0 and %d", name, limit))
+	}
+	return parsed, nil
+}
+
+func (s *ExplorerService) writeResult(w http.ResponseWriter, field string, result any) {
+	indexed, err := explorer.LastIndexed(s.db)
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.Internal, ReasonInternal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"indexed_layer": indexed.Uint32(),
+		field:           result,
+	})
+}
+
+func (s *ExplorerService) handleTransactions(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	address, err := types.StringToAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("parse address: %s", err)))
+		return
+	}
+	start, _, err := layerParam(r, "start_layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	end, exists, err := layerParam(r, "end_layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if !exists {
+		end = types.LayerID(math.MaxUint32)
+	}
+	limit, err := countParam(r, "limit", MaxExplorerResults, MaxExplorerResults)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.Transactions(address, start, end, limit)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	s.writeResult(w, "transactions", rst)
+}
+
+func (s *ExplorerService) handleATXs(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var smesherID types.NodeID
+	if err := decodeHexParam("smesher", r.URL.Query().Get("smesher"), smesherID[:]); err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.ATXs(smesherID)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	s.writeResult(w, "atxs", rst)
+}
+
+func (s *ExplorerService) handleRewards(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epoch, exists, err := epochParam(r, "epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if !exists {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonMissingArgument, "epoch must be set"))
+		return
+	}
+	offset, err := countParam(r, "offset", 0, math.MaxInt32)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	limit, err := countParam(r, "limit", MaxExplorerResults, MaxExplorerResults)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.Rewards(epoch, offset, limit)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	s.writeResult(w, "rewards", rst)
+}
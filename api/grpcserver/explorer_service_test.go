@@ -0,0 +1,89 @@
+package grpcserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/explorer"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+func TestExplorerService(t *testing.T) {
+	db := sql.InMemory()
+	svc := NewExplorerService(db)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	smesher := types.RandomNodeID()
+	coinbase := types.GenerateAddress(smesher[:])
+	lid := types.EpochID(2).FirstLayer()
+	block := types.RandomBlockID()
+	require.NoError(t, rewards.Add(db, &types.Reward{
+		Layer: lid, Coinbase: coinbase, SmesherID: smesher, TotalReward: 10, LayerReward: 8,
+	}))
+	require.NoError(t, layers.SetApplied(db, lid, block))
+	require.NoError(t, explorer.IndexLayer(db, lid, block))
+
+	get := func(t *testing.T, path, query string, dst any) int {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?%s", cfg.JSONListener, path, query))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(dst))
+		}
+		return resp.StatusCode
+	}
+
+	t.Run("rewards", func(t *testing.T) {
+		var rst struct {
+			IndexedLayer uint32           `json:"indexed_layer"`
+			Rewards      []ExplorerReward `json:"rewards"`
+		}
+		require.Equal(t, http.StatusOK, get(t, ExplorerRewardsPath, "epoch=2", &rst))
+		require.Equal(t, lid.Uint32(), rst.IndexedLayer)
+		require.Equal(t, []ExplorerReward{{
+			Smesher:     hex.EncodeToString(smesher[:]),
+			Coinbase:    coinbase.String(),
+			TotalReward: 10,
+			LayerReward: 8,
+			Layers:      1,
+		}}, rst.Rewards)
+
+		require.Equal(t, http.StatusOK, get(t, ExplorerRewardsPath, "epoch=2&offset=1", &rst))
+		require.Empty(t, rst.Rewards)
+
+		require.Equal(t, http.StatusBadRequest, get(t, ExplorerRewardsPath, "", nil))
+		require.Equal(t, http.StatusBadRequest,
+			get(t, ExplorerRewardsPath, fmt.Sprintf("epoch=2&limit=%d", MaxExplorerResults+1), nil))
+	})
+	t.Run("transactions", func(t *testing.T) {
+		var rst struct {
+			Transactions []ExplorerTransaction `json:"transactions"`
+		}
+		query := fmt.Sprintf("address=%s&start_layer=1", coinbase.String())
+		require.Equal(t, http.StatusOK, get(t, ExplorerTransactionsPath, query, &rst))
+		require.Empty(t, rst.Transactions)
+
+		require.Equal(t, http.StatusBadRequest, get(t, ExplorerTransactionsPath, "address=invalid", nil))
+		query = fmt.Sprintf("address=%s&start_layer=2&end_layer=1", coinbase.String())
+		require.Equal(t, http.StatusBadRequest, get(t, ExplorerTransactionsPath, query, nil))
+	})
+	t.Run("atxs", func(t *testing.T) {
+		var rst struct {
+			ATXs []ExplorerATX `json:"atxs"`
+		}
+		query := "smesher=" + hex.EncodeToString(smesher[:])
+		require.Equal(t, http.StatusOK, get(t, ExplorerATXsPath, query, &rst))
+		require.Empty(t, rst.ATXs)
+
+		require.Equal(t, http.StatusBadRequest, get(t, ExplorerATXsPath, "smesher=01", nil))
+	})
+}
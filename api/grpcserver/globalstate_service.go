@@ -2,7 +2,10 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -13,23 +16,88 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
 )
 
+// AccountLayerHeader is the request metadata key that selects the layer at which Account
+// returns the state of the account. The response header contains the layer in which that state
+// was last updated under the same key.
+const AccountLayerHeader = "account-layer"
+
+const (
+	// SmesherRewardsPath serves rewards of the smesher in the range of epochs [start_epoch, end_epoch].
+	SmesherRewardsPath = "/v1/globalstate/smesher/rewards"
+
+	// MaxSmesherRewardsEpochs is the largest range of epochs that can be requested at once.
+	MaxSmesherRewardsEpochs = 100
+
+	// GlobalStateGrpcService is the name of the grpc service that serves the global state methods that are
+	// not defined in the GlobalStateService protobuf. Messages of the service are encoded in json (see rpc.JSON).
+	GlobalStateGrpcService = "spacemesh.node.v1.GlobalStateService"
+)
+
+// SmesherRewardsRequest selects the hex encoded smesher and the range of epochs of the SmesherRewards method.
+// End epoch defaults to the start epoch.
+type SmesherRewardsRequest struct {
+	Smesher    string  `json:"smesher"`
+	StartEpoch uint32  `json:"start_epoch"`
+	EndEpoch   *uint32 `json:"end_epoch,omitempty"`
+}
+
+// SmesherRewardsResponse is the list of the rewards of the smesher ordered by layer.
+type SmesherRewardsResponse struct {
+	Rewards []SmesherReward `json:"rewards"`
+}
+
+// SmesherReward is the json encoding of the reward received by the smesher in the layer.
+type SmesherReward struct {
+	Layer       uint32 `json:"layer"`
+	Epoch       uint32 `json:"epoch"`
+	Coinbase    string `json:"coinbase"`
+	TotalReward uint64 `json:"total_reward"`
+	LayerReward uint64 `json:"layer_reward"`
+}
+
 // GlobalStateService exposes global state data, output from the STF.
 type GlobalStateService struct {
-	mesh     meshAPI
-	conState conservativeState
+	db                sql.Executor
+	mesh              meshAPI
+	conState          conservativeState
+	genTime           genesisTimeAPI
+	accountsRetention uint32
 }
 
 // RegisterService registers this service with a grpc server instance.
 func (s GlobalStateService) RegisterService(server *grpc.Server) {
 	pb.RegisterGlobalStateServiceServer(server, s)
+	server.RegisterService(&globalStateDesc, s)
 }
 
+type globalStateServer interface {
+	smesherRewards(context.Context, *SmesherRewardsRequest) (*SmesherRewardsResponse, error)
+}
+
+var globalStateDesc = grpc.ServiceDesc{
+	ServiceName: GlobalStateGrpcService,
+	HandlerType: (*globalStateServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(GlobalStateGrpcService, "SmesherRewards", globalStateServer.smesherRewards),
+	},
+	Metadata: "api/grpcserver/globalstate_service.go",
+}
+
+// RegisterHandlerService registers the global state routes with the json gateway.
+// The route of the smesher rewards serves the method of GlobalStateGrpcService.
 func (s GlobalStateService) RegisterHandlerService(mux *runtime.ServeMux) error {
-	return pb.RegisterGlobalStateServiceHandlerServer(context.Background(), mux, s)
+	if err := pb.RegisterGlobalStateServiceHandlerServer(context.Background(), mux, s); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, SmesherRewardsPath, s.handleSmesherRewards)
 }
 
 // String returns the name of the service.
@@ -38,10 +106,20 @@ func (s GlobalStateService) String() string {
 }
 
 // NewGlobalStateService creates a new grpc service using config data.
-func NewGlobalStateService(msh meshAPI, conState conservativeState) *GlobalStateService {
+// Historical account states are served for the last accountsRetention layers, or for all layers if it is 0.
+func NewGlobalStateService(
+	db sql.Executor,
+	msh meshAPI,
+	conState conservativeState,
+	genTime genesisTimeAPI,
+	accountsRetention uint32,
+) *GlobalStateService {
 	return &GlobalStateService{
-		mesh:     msh,
-		conState: conState,
+		db:                db,
+		mesh:              msh,
+		conState:          conState,
+		genTime:           genTime,
+		accountsRetention: accountsRetention,
 	}
 }
 
@@ -83,21 +161,77 @@ func (s GlobalStateService) getAccount(addr types.Address) (acct *pb.Account, er
 	}, nil
 }
 
+// getAccountAt returns counter and balance of the account as of the end of the layer.
+// Both current and projected states are set to that state.
+func (s GlobalStateService) getAccountAt(
+	ctx context.Context,
+	addr types.Address,
+	layer types.LayerID,
+) (*pb.Account, error) {
+	if applied := s.mesh.LatestLayerInState(); layer > applied {
+		return nil, apiError(codes.InvalidArgument, ReasonLayerInFuture,
+			fmt.Sprintf("state for layer %d is not applied yet, latest applied layer is %d", layer, applied),
+			"latest_layer", strconv.FormatUint(uint64(applied), 10))
+	}
+	if current := s.genTime.CurrentLayer(); s.accountsRetention > 0 && current > types.LayerID(s.accountsRetention) {
+		if oldest := current - types.LayerID(s.accountsRetention); layer < oldest {
+			return nil, apiError(codes.OutOfRange, ReasonLayerPruned,
+				fmt.Sprintf("state for layer %d is pruned, oldest retained layer is %d", layer, oldest),
+				"oldest_layer", strconv.FormatUint(uint64(oldest), 10))
+		}
+	}
+	account, err := accounts.Get(s.db, addr, layer)
+	if err != nil {
+		ctxzap.Error(ctx, "unable to fetch historical account state", zap.Error(err))
+		return nil, apiError(codes.Internal, ReasonInternal, "error fetching historical account data")
+	}
+	header := metadata.Pairs(AccountLayerHeader, strconv.FormatUint(uint64(account.Layer), 10))
+	if err := grpc.SetHeader(ctx, header); err != nil {
+		ctxzap.Debug(ctx, "failed to set header", zap.Error(err))
+	}
+	state := &pb.AccountState{
+		Counter: account.NextNonce,
+		Balance: &pb.Amount{Value: account.Balance},
+	}
+	return &pb.Account{
+		AccountId:      &pb.AccountId{Address: addr.String()},
+		StateCurrent:   state,
+		StateProjected: state,
+	}, nil
+}
+
 // Account returns current and projected counter and balance for one account.
+// If AccountLayerHeader is set in the request metadata, the state as of that layer is returned instead.
 func (s GlobalStateService) Account(ctx context.Context, in *pb.AccountRequest) (*pb.AccountResponse, error) {
 	if in.AccountId == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`AccountId` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`AccountId` must be provided", "field", "AccountId")
 	}
 
 	// Load data
 	addr, err := types.StringToAddress(in.AccountId.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse in.AccountId.Address `%s`: %w", in.AccountId.Address, err)
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse in.AccountId.Address `%s`: %v", in.AccountId.Address, err),
+			"field", "AccountId.Address")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(AccountLayerHeader); len(values) > 0 {
+		layer, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("invalid %s: %s", AccountLayerHeader, values[0]), "field", AccountLayerHeader)
+		}
+		acct, err := s.getAccountAt(ctx, addr, types.LayerID(layer))
+		if err != nil {
+			return nil, err
+		}
+		return &pb.AccountResponse{AccountWrapper: acct}, nil
 	}
 	acct, err := s.getAccount(addr)
 	if err != nil {
 		ctxzap.Error(ctx, "unable to fetch projected account state", zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "error fetching projected account data")
+		return nil, apiError(codes.Internal, ReasonInternal, "error fetching projected account data")
 	}
 
 	ctxzap.Debug(ctx, "GRPC GlobalStateService.Account",
@@ -117,13 +251,16 @@ func (s GlobalStateService) AccountDataQuery(
 	in *pb.AccountDataQueryRequest,
 ) (*pb.AccountDataQueryResponse, error) {
 	if in.Filter == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter` must be provided", "field", "Filter")
 	}
 	if in.Filter.AccountId == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter.AccountId` must be provided", "field", "Filter.AccountId")
 	}
 	if in.Filter.AccountDataFlags == uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_UNSPECIFIED) {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountMeshDataFlags` must set at least one bitfield")
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`Filter.AccountMeshDataFlags` must set at least one bitfield", "field", "Filter.AccountMeshDataFlags")
 	}
 
 	// Read the filter flags
@@ -134,7 +271,9 @@ func (s GlobalStateService) AccountDataQuery(
 
 	addr, err := types.StringToAddress(in.Filter.AccountId.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse in.Filter.AccountId.Address `%s`: %w", in.Filter.AccountId.Address, err)
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse in.Filter.AccountId.Address `%s`: %v", in.Filter.AccountId.Address, err),
+			"field", "Filter.AccountId.Address")
 	}
 	res := &pb.AccountDataQueryResponse{}
 
@@ -145,7 +284,7 @@ func (s GlobalStateService) AccountDataQuery(
 	if filterReward {
 		dbRewards, err := s.mesh.GetRewardsByCoinbase(addr)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "error getting rewards data")
+			return nil, apiError(codes.Internal, ReasonInternal, "error getting rewards data")
 		}
 		for _, r := range dbRewards {
 			res.AccountItem = append(res.AccountItem, &pb.AccountData{Datum: &pb.AccountData_Reward{
@@ -164,7 +303,7 @@ func (s GlobalStateService) AccountDataQuery(
 		acct, err := s.getAccount(addr)
 		if err != nil {
 			ctxzap.Error(ctx, "unable to fetch projected account state", zap.Error(err))
-			return nil, status.Errorf(codes.Internal, "error fetching projected account data")
+			return nil, apiError(codes.Internal, ReasonInternal, "error fetching projected account data")
 		}
 		res.AccountItem = append(res.AccountItem, &pb.AccountData{Datum: &pb.AccountData_AccountWrapper{
 			AccountWrapper: acct,
@@ -202,10 +341,113 @@ func (s GlobalStateService) AccountDataQuery(
 
 // SmesherDataQuery returns historical info on smesher rewards.
 func (s GlobalStateService) SmesherDataQuery(
-	_ context.Context,
+	ctx context.Context,
 	in *pb.SmesherDataQueryRequest,
 ) (*pb.SmesherDataQueryResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "DEPRECATED")
+	if in.SmesherId == nil {
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`SmesherId` must be provided", "field", "SmesherId")
+	}
+	var smesherID types.NodeID
+	if len(in.SmesherId.Id) != len(smesherID) {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("`SmesherId.Id` must be %d bytes", len(smesherID)), "field", "SmesherId.Id")
+	}
+	copy(smesherID[:], in.SmesherId.Id)
+	dbRewards, err := rewards.ListBySmesherId(s.db, smesherID)
+	if err != nil {
+		ctxzap.Error(ctx, "unable to fetch smesher rewards", zap.Error(err))
+		return nil, apiError(codes.Internal, ReasonInternal, "error getting rewards data")
+	}
+	res := &pb.SmesherDataQueryResponse{TotalResults: uint32(len(dbRewards))}
+	offset := in.Offset
+	if offset > uint32(len(dbRewards)) {
+		return res, nil
+	}
+	maxResults := in.MaxResults
+	if maxResults == 0 || offset+maxResults > uint32(len(dbRewards)) {
+		maxResults = uint32(len(dbRewards)) - offset
+	}
+	for _, r := range dbRewards[offset : offset+maxResults] {
+		res.Rewards = append(res.Rewards, &pb.Reward{
+			Layer:       &pb.LayerNumber{Number: r.Layer.Uint32()},
+			Total:       &pb.Amount{Value: r.TotalReward},
+			LayerReward: &pb.Amount{Value: r.LayerReward},
+			Coinbase:    &pb.AccountId{Address: r.Coinbase.String()},
+			Smesher:     &pb.SmesherId{Id: r.SmesherID[:]},
+		})
+	}
+	return res, nil
+}
+
+// SmesherRewards returns rewards of the smesher in the range of epochs [start, end].
+func (s GlobalStateService) SmesherRewards(smesherID types.NodeID, start, end types.EpochID) ([]SmesherReward, error) {
+	switch {
+	case end < start:
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument, "end epoch is before start epoch")
+	case end-start >= MaxSmesherRewardsEpochs:
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("epoch range is capped at %d", MaxSmesherRewardsEpochs))
+	}
+	stored, err := rewards.ListBySmesher(s.db, smesherID, start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]SmesherReward, 0, len(stored))
+	for _, r := range stored {
+		rst = append(rst, SmesherReward{
+			Layer:       r.Layer.Uint32(),
+			Epoch:       r.Layer.GetEpoch().Uint32(),
+			Coinbase:    r.Coinbase.String(),
+			TotalReward: r.TotalReward,
+			LayerReward: r.LayerReward,
+		})
+	}
+	return rst, nil
+}
+
+func (s GlobalStateService) smesherRewards(
+	_ context.Context,
+	req *SmesherRewardsRequest,
+) (*SmesherRewardsResponse, error) {
+	var smesherID types.NodeID
+	if err := decodeHexParam("smesher", req.Smesher, smesherID[:]); err != nil {
+		return nil, err
+	}
+	start, end := types.EpochID(req.StartEpoch), types.EpochID(req.StartEpoch)
+	if req.EndEpoch != nil {
+		end = types.EpochID(*req.EndEpoch)
+	}
+	rst, err := s.SmesherRewards(smesherID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &SmesherRewardsResponse{Rewards: rst}, nil
+}
+
+func (s GlobalStateService) handleSmesherRewards(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, _, err := epochParam(r, "start_epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	end, exists, err := epochParam(r, "end_epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	req := &SmesherRewardsRequest{Smesher: r.URL.Query().Get("smesher"), StartEpoch: start.Uint32()}
+	if exists {
+		value := end.Uint32()
+		req.EndEpoch = &value
+	}
+	rst, err := s.smesherRewards(r.Context(), req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
 }
 
 // STREAMS
@@ -216,17 +458,21 @@ func (s GlobalStateService) AccountDataStream(
 	stream pb.GlobalStateService_AccountDataStreamServer,
 ) error {
 	if in.Filter == nil {
-		return status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+		return apiError(codes.InvalidArgument, ReasonMissingArgument, "`Filter` must be provided", "field", "Filter")
 	}
 	if in.Filter.AccountId == nil {
-		return status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+		return apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter.AccountId` must be provided", "field", "Filter.AccountId")
 	}
 	if in.Filter.AccountDataFlags == uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_UNSPECIFIED) {
-		return status.Errorf(codes.InvalidArgument, "`Filter.AccountDataFlags` must set at least one bitfield")
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`Filter.AccountDataFlags` must set at least one bitfield", "field", "Filter.AccountDataFlags")
 	}
 	addr, err := types.StringToAddress(in.Filter.AccountId.Address)
 	if err != nil {
-		return fmt.Errorf("failed to parse in.Filter.AccountId.Address `%s`: %w", in.Filter.AccountId.Address, err)
+		return apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse in.Filter.AccountId.Address `%s`: %v", in.Filter.AccountId.Address, err),
+			"field", "Filter.AccountId.Address")
 	}
 
 	filterAccount := in.Filter.AccountDataFlags&uint32(pb.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT) != 0
@@ -271,7 +517,7 @@ func (s GlobalStateService) AccountDataStream(
 				acct, err := s.getAccount(addr)
 				if err != nil {
 					ctxzap.Error(stream.Context(), "unable to fetch projected account state", zap.Error(err))
-					return status.Errorf(codes.Internal, "error fetching projected account data")
+					return apiError(codes.Internal, ReasonInternal, "error fetching projected account data")
 				}
 				resp := &pb.AccountDataStreamResponse{Datum: &pb.AccountData{Datum: &pb.AccountData_AccountWrapper{
 					AccountWrapper: acct,
@@ -353,7 +599,8 @@ func (s GlobalStateService) GlobalStateStream(
 	stream pb.GlobalStateService_GlobalStateStreamServer,
 ) error {
 	if in.GlobalStateDataFlags == uint32(pb.GlobalStateDataFlag_GLOBAL_STATE_DATA_FLAG_UNSPECIFIED) {
-		return status.Errorf(codes.InvalidArgument, "`GlobalStateDataFlags` must set at least one bitfield")
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`GlobalStateDataFlags` must set at least one bitfield", "field", "GlobalStateDataFlags")
 	}
 
 	filterAccount := in.GlobalStateDataFlags&uint32(pb.GlobalStateDataFlag_GLOBAL_STATE_DATA_FLAG_ACCOUNT) != 0
@@ -406,7 +653,7 @@ func (s GlobalStateService) GlobalStateStream(
 			acct, err := s.getAccount(updatedAccount.Address)
 			if err != nil {
 				ctxzap.Error(stream.Context(), "unable to fetch projected account state", zap.Error(err))
-				return status.Errorf(codes.Internal, "error fetching projected account data")
+				return apiError(codes.Internal, ReasonInternal, "error fetching projected account data")
 			}
 			resp := &pb.GlobalStateStreamResponse{Datum: &pb.GlobalStateData{Datum: &pb.GlobalStateData_AccountWrapper{
 				AccountWrapper: acct,
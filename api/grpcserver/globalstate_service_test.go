@@ -2,31 +2,45 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math"
+	"net/http"
 	"testing"
 	"time"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
 )
 
 type globalStateServiceConn struct {
 	pb.GlobalStateServiceClient
 
+	db          *sql.Database
 	meshAPI     *MockmeshAPI
 	conStateAPI *MockconservativeState
+	genTime     *MockgenesisTimeAPI
 }
 
 func setupGlobalStateService(t *testing.T) (*globalStateServiceConn, context.Context) {
 	ctrl, mockCtx := gomock.WithContext(context.Background(), t)
 	meshAPI := NewMockmeshAPI(ctrl)
 	conStateAPI := NewMockconservativeState(ctrl)
-	svc := NewGlobalStateService(meshAPI, conStateAPI)
+	genTime := NewMockgenesisTimeAPI(ctrl)
+	db := sql.InMemory()
+	svc := NewGlobalStateService(db, meshAPI, conStateAPI, genTime, 10)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -38,8 +52,10 @@ func setupGlobalStateService(t *testing.T) (*globalStateServiceConn, context.Con
 	return &globalStateServiceConn{
 		GlobalStateServiceClient: client,
 
+		db:          db,
 		meshAPI:     meshAPI,
 		conStateAPI: conStateAPI,
+		genTime:     genTime,
 	}, mockCtx
 }
 
@@ -76,6 +92,50 @@ func TestGlobalStateService(t *testing.T) {
 		require.Equal(t, uint64(accountBalance+1), res.AccountWrapper.StateProjected.Balance.Value)
 		require.Equal(t, uint64(accountCounter+1), res.AccountWrapper.StateProjected.Counter)
 	})
+	t.Run("AccountAtLayer", func(t *testing.T) {
+		t.Parallel()
+		c, ctx := setupGlobalStateService(t)
+
+		for _, lid := range []types.LayerID{12, 15} {
+			require.NoError(t, accounts.Update(c.db, &types.Account{
+				Address:   addr1,
+				Layer:     lid,
+				Balance:   uint64(lid) * 100,
+				NextNonce: uint64(lid),
+			}))
+		}
+		c.meshAPI.EXPECT().LatestLayerInState().Return(types.LayerID(20)).AnyTimes()
+		c.genTime.EXPECT().CurrentLayer().Return(types.LayerID(22)).AnyTimes()
+
+		account := func(layer string) (*pb.AccountResponse, metadata.MD, error) {
+			var header metadata.MD
+			res, err := c.Account(
+				metadata.AppendToOutgoingContext(ctx, AccountLayerHeader, layer),
+				&pb.AccountRequest{AccountId: &pb.AccountId{Address: addr1.String()}},
+				grpc.Header(&header),
+			)
+			return res, header, err
+		}
+
+		res, header, err := account("14")
+		require.NoError(t, err)
+		require.Equal(t, []string{"12"}, header.Get(AccountLayerHeader))
+		require.Equal(t, uint64(1200), res.AccountWrapper.StateCurrent.Balance.Value)
+		require.Equal(t, uint64(12), res.AccountWrapper.StateCurrent.Counter)
+		require.Equal(t, res.AccountWrapper.StateCurrent, res.AccountWrapper.StateProjected)
+
+		res, header, err = account("20")
+		require.NoError(t, err)
+		require.Equal(t, []string{"15"}, header.Get(AccountLayerHeader))
+		require.Equal(t, uint64(1500), res.AccountWrapper.StateCurrent.Balance.Value)
+
+		_, _, err = account("21")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, _, err = account("11")
+		require.Equal(t, codes.OutOfRange, status.Code(err))
+		_, _, err = account("latest")
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 	t.Run("AccountDataQuery_MissingFilter", func(t *testing.T) {
 		t.Parallel()
 		c, ctx := setupGlobalStateService(t)
@@ -216,6 +276,44 @@ func TestGlobalStateService(t *testing.T) {
 		checkAccountDataQueryItemReward(t, res.AccountItem[0].Datum)
 		checkAccountDataQueryItemAccount(t, res.AccountItem[1].Datum)
 	})
+	t.Run("SmesherDataQuery", func(t *testing.T) {
+		t.Parallel()
+		c, ctx := setupGlobalStateService(t)
+
+		smesherID := types.RandomNodeID()
+		coinbase := types.GenerateAddress([]byte{1})
+		for _, layer := range []types.LayerID{3, 7, 11} {
+			require.NoError(t, rewards.Add(c.db, &types.Reward{
+				Layer:       layer,
+				Coinbase:    coinbase,
+				SmesherID:   smesherID,
+				TotalReward: 100 + uint64(layer),
+				LayerReward: 50,
+			}))
+		}
+		require.NoError(t, rewards.Add(c.db, &types.Reward{
+			Layer: 7, Coinbase: coinbase, SmesherID: types.RandomNodeID(), TotalReward: 1, LayerReward: 1,
+		}))
+
+		res, err := c.SmesherDataQuery(ctx, &pb.SmesherDataQueryRequest{
+			SmesherId:  &pb.SmesherId{Id: smesherID.Bytes()},
+			Offset:     1,
+			MaxResults: 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint32(3), res.TotalResults)
+		require.Len(t, res.Rewards, 1)
+		require.Equal(t, uint32(7), res.Rewards[0].Layer.Number)
+		require.Equal(t, uint64(107), res.Rewards[0].Total.Value)
+		require.Equal(t, uint64(50), res.Rewards[0].LayerReward.Value)
+		require.Equal(t, coinbase.String(), res.Rewards[0].Coinbase.Address)
+		require.Equal(t, smesherID.Bytes(), res.Rewards[0].Smesher.Id)
+
+		_, err = c.SmesherDataQuery(ctx, &pb.SmesherDataQueryRequest{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = c.SmesherDataQuery(ctx, &pb.SmesherDataQueryRequest{SmesherId: &pb.SmesherId{Id: []byte{1}}})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
 	t.Run("AppEventStream", func(t *testing.T) {
 		t.Parallel()
 		c, ctx := setupGlobalStateService(t)
@@ -243,7 +341,9 @@ func TestGlobalStateService(t *testing.T) {
 
 		_, err = stream.Recv()
 		statusCode := status.Code(err)
-		require.Equal(t, codes.Unknown, statusCode)
+		require.Equal(t, codes.InvalidArgument, statusCode)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidAddress, reason)
 	})
 	t.Run("AccountDataStream_invalidAddress", func(t *testing.T) {
 		t.Parallel()
@@ -369,3 +469,86 @@ func TestGlobalStateService(t *testing.T) {
 		})
 	})
 }
+
+func TestGlobalStateService_SmesherRewards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := sql.InMemory()
+	svc := NewGlobalStateService(
+		db, NewMockmeshAPI(ctrl), NewMockconservativeState(ctrl), NewMockgenesisTimeAPI(ctrl), 0)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	smesherID := types.RandomNodeID()
+	coinbase := types.GenerateAddress([]byte{1})
+	for _, epoch := range []types.EpochID{1, 2, 3} {
+		require.NoError(t, rewards.Add(db, &types.Reward{
+			Layer:       epoch.FirstLayer(),
+			Coinbase:    coinbase,
+			SmesherID:   smesherID,
+			TotalReward: 100,
+			LayerReward: 50,
+		}))
+	}
+	url := func(query string) string {
+		return fmt.Sprintf("http://%s%s?%s", cfg.JSONListener, SmesherRewardsPath, query)
+	}
+
+	resp, err := http.Get(url(fmt.Sprintf("smesher=%s&start_epoch=2&end_epoch=3", hex.EncodeToString(smesherID[:]))))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var encoded struct {
+		Rewards []SmesherReward `json:"rewards"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&encoded))
+	require.Equal(t, []SmesherReward{
+		{
+			Layer:       types.EpochID(2).FirstLayer().Uint32(),
+			Epoch:       2,
+			Coinbase:    coinbase.String(),
+			TotalReward: 100,
+			LayerReward: 50,
+		},
+		{
+			Layer:       types.EpochID(3).FirstLayer().Uint32(),
+			Epoch:       3,
+			Coinbase:    coinbase.String(),
+			TotalReward: 100,
+			LayerReward: 50,
+		},
+	}, encoded.Rewards)
+
+	for _, query := range []string{
+		"smesher=01",
+		fmt.Sprintf("smesher=%s&start_epoch=3&end_epoch=2", hex.EncodeToString(smesherID[:])),
+		fmt.Sprintf("smesher=%s&start_epoch=1&end_epoch=%d", hex.EncodeToString(smesherID[:]), MaxSmesherRewardsEpochs+1),
+	} {
+		resp, err := http.Get(url(query))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[SmesherRewardsRequest, SmesherRewardsResponse](
+			ctx, conn, GlobalStateGrpcService, "SmesherRewards", rpc.JSON,
+			&SmesherRewardsRequest{Smesher: hex.EncodeToString(smesherID[:]), StartEpoch: 1},
+		)
+		require.NoError(t, err)
+		require.Len(t, rst.Rewards, 1)
+		require.EqualValues(t, 1, rst.Rewards[0].Epoch)
+
+		end := uint32(0)
+		_, err = rpc.Invoke[SmesherRewardsRequest, SmesherRewardsResponse](
+			ctx, conn, GlobalStateGrpcService, "SmesherRewards", rpc.JSON,
+			&SmesherRewardsRequest{Smesher: hex.EncodeToString(smesherID[:]), StartEpoch: 1, EndEpoch: &end},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -59,8 +59,14 @@ func streamingGrpcLogStart(
 }
 
 // NewWithServices creates a new Server listening on the provided address with the given logger and config.
-// Services passed in the svc slice are registered with the server.
-func NewWithServices(listener string, logger *zap.Logger, config Config, svc []ServiceAPI) (*Server, error) {
+// Services passed in the svc slice are registered with the server. Additional grpc options can be passed.
+func NewWithServices(
+	listener string,
+	logger *zap.Logger,
+	config Config,
+	svc []ServiceAPI,
+	grpcOpts ...grpc.ServerOption,
+) (*Server, error) {
 	if len(svc) == 0 {
 		return nil, errors.New("no services to register")
 	}
@@ -75,7 +81,7 @@ func NewWithServices(listener string, logger *zap.Logger, config Config, svc []S
 		logger.Warn("unsecured grpc server is listening on a public IP address", zap.String("address", listener))
 	}
 
-	server := New(listener, logger, config)
+	server := New(listener, logger, config, grpcOpts...)
 	for _, s := range svc {
 		s.RegisterService(server.GrpcServer)
 	}
@@ -83,8 +89,8 @@ func NewWithServices(listener string, logger *zap.Logger, config Config, svc []S
 }
 
 // NewTLS creates a new Server listening on the TLSListener address with the given logger and config.
-// Services passed in the svc slice are registered with the server.
-func NewTLS(logger *zap.Logger, config Config, svc []ServiceAPI) (*Server, error) {
+// Services passed in the svc slice are registered with the server. Additional grpc options can be passed.
+func NewTLS(logger *zap.Logger, config Config, svc []ServiceAPI, grpcOpts ...grpc.ServerOption) (*Server, error) {
 	if len(svc) == 0 {
 		return nil, errors.New("no services to register")
 	}
@@ -109,7 +115,8 @@ func NewTLS(logger *zap.Logger, config Config, svc []ServiceAPI) (*Server, error
 		ClientCAs:    certPool,
 	}
 
-	server := New(config.TLSListener, logger, config, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	server := New(config.TLSListener, logger, config, grpcOpts...)
 	for _, s := range svc {
 		s.RegisterService(server.GrpcServer)
 	}
@@ -1771,7 +1771,7 @@ func TestAccountDataStream_comprehensive(t *testing.T) {
 	events.InitializeReporter()
 	t.Cleanup(events.CloseEventReporter)
 
-	svc := NewGlobalStateService(meshAPIMock, conStateAPI)
+	svc := NewGlobalStateService(nil, meshAPIMock, conStateAPI, nil, 0)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -1831,7 +1831,7 @@ func TestGlobalStateStream_comprehensive(t *testing.T) {
 	events.InitializeReporter()
 	t.Cleanup(events.CloseEventReporter)
 
-	svc := NewGlobalStateService(meshAPIMock, conStateAPI)
+	svc := NewGlobalStateService(nil, meshAPIMock, conStateAPI, nil, 0)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -2262,7 +2262,7 @@ func TestEventsReceived(t *testing.T) {
 	t.Cleanup(events.CloseEventReporter)
 
 	txService := NewTransactionService(sql.InMemory(), nil, meshAPIMock, conStateAPI, nil, nil)
-	gsService := NewGlobalStateService(meshAPIMock, conStateAPI)
+	gsService := NewGlobalStateService(nil, meshAPIMock, conStateAPI, nil, 0)
 	cfg, cleanup := launchServer(t, txService, gsService)
 	t.Cleanup(cleanup)
 
@@ -2351,7 +2351,7 @@ func TestTransactionsRewards(t *testing.T) {
 	events.InitializeReporter()
 	t.Cleanup(events.CloseEventReporter)
 
-	cfg, cleanup := launchServer(t, NewGlobalStateService(meshAPIMock, conStateAPI))
+	cfg, cleanup := launchServer(t, NewGlobalStateService(nil, meshAPIMock, conStateAPI, nil, 0))
 	t.Cleanup(cleanup)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -2418,7 +2418,7 @@ func TestVMAccountUpdates(t *testing.T) {
 	require.NoError(t, err)
 	t.Cleanup(func() { db.Close() })
 	svm := vm.New(db, vm.WithLogger(logtest.New(t)))
-	cfg, cleanup := launchServer(t, NewGlobalStateService(nil, txs.NewConservativeState(svm, db)))
+	cfg, cleanup := launchServer(t, NewGlobalStateService(db, nil, txs.NewConservativeState(svm, db), nil, 0))
 	t.Cleanup(cleanup)
 
 	keys := make([]*signing.EdSigner, 10)
@@ -0,0 +1,383 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
+)
+
+const (
+	// HareResultsPath serves results of hare sessions in the range of layers
+	// [start_layer, end_layer] on the json gateway.
+	HareResultsPath = "/v1/hare/results"
+	// HareResultsStreamPath streams results of hare sessions as newline delimited json.
+	// If start_layer is set stored results starting from that layer are sent first.
+	HareResultsStreamPath = "/v1/hare/results/stream"
+	// HareWeakCoinPath serves weak coins in the range of layers [start_layer, end_layer]
+	// with the preround messages they were derived from.
+	HareWeakCoinPath = "/v1/hare/weakcoin"
+	// HareGrpcService is the name of the grpc service that serves Results, ResultsStream and WeakCoins methods.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	HareGrpcService = "spacemesh.node.v1.HareService"
+
+	// MaxHareResults is the largest range of layers that can be requested at once.
+	MaxHareResults = 1000
+)
+
+// HareResultsRequest selects the range of layers [start_layer, end_layer], end_layer defaults to start_layer.
+type HareResultsRequest struct {
+	StartLayer uint32 `json:"start_layer"`
+	EndLayer   uint32 `json:"end_layer"`
+}
+
+// HareResultsResponse is the response of the Results method.
+type HareResultsResponse struct {
+	Results []HareResult `json:"results"`
+}
+
+// HareResultsStreamRequest is the request of the ResultsStream method.
+// If start_layer is set stored results starting from that layer are sent first.
+type HareResultsStreamRequest struct {
+	StartLayer *uint32 `json:"start_layer,omitempty"`
+}
+
+// HareWeakCoinsRequest selects the range of layers [start_layer, end_layer], end_layer defaults to start_layer.
+type HareWeakCoinsRequest struct {
+	StartLayer uint32 `json:"start_layer"`
+	EndLayer   uint32 `json:"end_layer"`
+}
+
+// HareWeakCoinsResponse is the response of the WeakCoins method.
+type HareWeakCoinsResponse struct {
+	WeakCoins []WeakCoin `json:"weak_coins"`
+}
+
+// HareResult is the outcome of the hare session in the layer as observed by the node.
+type HareResult struct {
+	Layer      uint32 `json:"layer"`
+	Terminated bool   `json:"terminated"`
+	Iterations uint32 `json:"iterations"`
+	// Participation is the sum of eligibilities of the identities that participated in the preround.
+	Participation uint32 `json:"participation"`
+	Threshold     uint32 `json:"threshold"`
+	// Block is the hex encoded id of the certified block. It is empty until the block is certified,
+	// which usually happens after the result is streamed.
+	Block string `json:"block,omitempty"`
+}
+
+// WeakCoin is the weak coin computed by the hare in the layer.
+type WeakCoin struct {
+	Layer uint32 `json:"layer"`
+	Value bool   `json:"value"`
+	// Vrf is the hex encoded smallest vrf from preround messages, the coin is its least significant bit.
+	// Smesher is the hex encoded id of the smesher that sent it. Both are empty if the coin
+	// was recorded before the provenance was tracked.
+	Vrf     string `json:"vrf,omitempty"`
+	Smesher string `json:"smesher,omitempty"`
+}
+
+// HareService exposes results of the hare sessions for monitoring the health of consensus and weak coins.
+// It is served on the json gateway and as HareGrpcService.
+type HareService struct {
+	db      sql.Executor
+	localdb sql.Executor
+}
+
+// NewHareService creates a new hare service.
+// Results of the sessions are loaded from the local database, certified blocks and weak coins from the state.
+func NewHareService(db, localdb sql.Executor) *HareService {
+	return &HareService{db: db, localdb: localdb}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *HareService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&hareDesc, s)
+}
+
+type hareServer interface {
+	results(context.Context, *HareResultsRequest) (*HareResultsResponse, error)
+	resultsStream(*HareResultsStreamRequest, rpc.ServerStream[HareResult]) error
+	weakCoins(context.Context, *HareWeakCoinsRequest) (*HareWeakCoinsResponse, error)
+}
+
+var hareDesc = grpc.ServiceDesc{
+	ServiceName: HareGrpcService,
+	HandlerType: (*hareServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(HareGrpcService, "Results", hareServer.results),
+		rpc.UnaryMethod(HareGrpcService, "WeakCoins", hareServer.weakCoins),
+	},
+	Streams: []grpc.StreamDesc{
+		rpc.ServerStreamMethod("ResultsStream", hareServer.resultsStream),
+	},
+	Metadata: "api/grpcserver/hare_service.go",
+}
+
+// RegisterHandlerService registers the hare routes with the json gateway.
+func (s *HareService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, HareResultsPath, s.handleResults); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, HareResultsStreamPath, s.handleStream); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, HareWeakCoinPath, s.handleWeakCoins)
+}
+
+// String returns the name of this service.
+func (s *HareService) String() string {
+	return "HareService"
+}
+
+// Results returns stored results of hare sessions in the range of layers [start, end].
+func (s *HareService) Results(ctx context.Context, start, end types.LayerID) ([]HareResult, error) {
+	if err := checkLayerRange(start, end); err != nil {
+		return nil, err
+	}
+	stored, err := s.stored(start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	results := make([]HareResult, 0, len(stored))
+	for _, result := range stored {
+		rst, err := s.toResult(result)
+		if err != nil {
+			return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+		results = append(results, rst)
+	}
+	return results, nil
+}
+
+// WeakCoins returns weak coins in the range of layers [start, end].
+func (s *HareService) WeakCoins(ctx context.Context, start, end types.LayerID) ([]WeakCoin, error) {
+	if err := checkLayerRange(start, end); err != nil {
+		return nil, err
+	}
+	stored, err := layers.WeakCoins(s.db, start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]WeakCoin, 0, len(stored))
+	for _, coin := range stored {
+		encoded := WeakCoin{Layer: coin.Layer.Uint32(), Value: coin.Value}
+		if coin.Vrf != (types.VrfSignature{}) {
+			encoded.Vrf = hex.EncodeToString(coin.Vrf[:])
+			encoded.Smesher = hex.EncodeToString(coin.Smesher[:])
+		}
+		rst = append(rst, encoded)
+	}
+	return rst, nil
+}
+
+func checkLayerRange(start, end types.LayerID) error {
+	switch {
+	case end < start:
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument, "end layer is before start layer")
+	case end-start >= MaxHareResults:
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("layer range is capped at %d", MaxHareResults))
+	}
+	return nil
+}
+
+// stored loads results before querying certificates, as the database may not allow nested queries.
+func (s *HareService) stored(start, end types.LayerID) ([]hareresults.Result, error) {
+	var results []hareresults.Result
+	if err := hareresults.IterateRange(s.localdb, start, end, func(result hareresults.Result) bool {
+		results = append(results, result)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *HareService) toResult(result hareresults.Result) (HareResult, error) {
+	rst := HareResult{
+		Layer:         result.Layer.Uint32(),
+		Terminated:    result.Terminated,
+		Iterations:    uint32(result.Iterations),
+		Participation: result.Participation,
+		Threshold:     uint32(result.Threshold),
+	}
+	block, err := certificates.CertifiedBlock(s.db, result.Layer)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+	case err != nil:
+		return HareResult{}, err
+	default:
+		rst.Block = hex.EncodeToString(block[:])
+	}
+	return rst, nil
+}
+
+func layerParam(r *http.Request, name string) (types.LayerID, bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, false, nil
+	}
+	layer, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("parse %s: %s", name, err))
+	}
+	return types.LayerID(layer), true, nil
+}
+
+// layerRange parses the range of layers [start_layer, end_layer], end_layer defaults to start_layer.
+func layerRange(r *http.Request) (types.LayerID, types.LayerID, error) {
+	start, _, err := layerParam(r, "start_layer")
+	if err != nil {
+		return 0, 0, err
+	}
+	end, exists, err := layerParam(r, "end_layer")
+	if err != nil {
+		return 0, 0, err
+	}
+	if !exists {
+		end = start
+	}
+	return start, end, nil
+}
+
+func (s *HareService) results(ctx context.Context, req *HareResultsRequest) (*HareResultsResponse, error) {
+	end := req.EndLayer
+	if end == 0 {
+		end = req.StartLayer
+	}
+	results, err := s.Results(ctx, types.LayerID(req.StartLayer), types.LayerID(end))
+	if err != nil {
+		return nil, err
+	}
+	return &HareResultsResponse{Results: results}, nil
+}
+
+func (s *HareService) handleResults(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, end, err := layerRange(r)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.results(r.Context(), &HareResultsRequest{StartLayer: start.Uint32(), EndLayer: end.Uint32()})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (s *HareService) weakCoins(ctx context.Context, req *HareWeakCoinsRequest) (*HareWeakCoinsResponse, error) {
+	end := req.EndLayer
+	if end == 0 {
+		end = req.StartLayer
+	}
+	coins, err := s.WeakCoins(ctx, types.LayerID(req.StartLayer), types.LayerID(end))
+	if err != nil {
+		return nil, err
+	}
+	return &HareWeakCoinsResponse{WeakCoins: coins}, nil
+}
+
+func (s *HareService) handleWeakCoins(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, end, err := layerRange(r)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.weakCoins(r.Context(), &HareWeakCoinsRequest{StartLayer: start.Uint32(), EndLayer: end.Uint32()})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+// resultsStream sends results of the sessions as they terminate. If the start layer is set
+// stored results starting from that layer are sent first.
+func (s *HareService) resultsStream(req *HareResultsStreamRequest, stream rpc.ServerStream[HareResult]) error {
+	sub, err := events.Subscribe[hareresults.Result]()
+	if err != nil {
+		return apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	defer sub.Close()
+
+	send := func(result hareresults.Result) error {
+		rst, err := s.toResult(result)
+		if err != nil {
+			return apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+		return stream.Send(&rst)
+	}
+	var stored []hareresults.Result
+	replay := req.StartLayer != nil
+	if replay {
+		stored, err = s.stored(types.LayerID(*req.StartLayer), math.MaxUint32)
+		if err != nil {
+			return apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+	}
+	// header is sent after subscribing, so that the client can wait until the stream is initialized
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	// results that were already replayed from the database are skipped
+	var last types.LayerID
+	for _, result := range stored {
+		if err := send(result); err != nil {
+			return err
+		}
+		last = result.Layer
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Full():
+			return apiError(codes.Unavailable, ReasonInternal, "subscriber is too slow")
+		case result := <-sub.Out():
+			if replay && result.Layer <= last {
+				continue
+			}
+			if err := send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *HareService) handleStream(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req HareResultsStreamRequest
+	start, replay, err := layerParam(r, "start_layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if replay {
+		value := start.Uint32()
+		req.StartLayer = &value
+	}
+	rpc.ServeNDJSON(w, r, func(stream rpc.ServerStream[HareResult]) error {
+		return s.resultsStream(&req, stream)
+	})
+}
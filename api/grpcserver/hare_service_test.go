@@ -0,0 +1,202 @@
+package grpcserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
+)
+
+func TestHareService(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	db := sql.InMemory()
+	localdb := localsql.InMemory()
+	for layer := types.LayerID(1); layer <= 3; layer++ {
+		require.NoError(t, hareresults.Add(localdb, hareresults.Result{
+			Layer:         layer,
+			Terminated:    layer != 2,
+			Iterations:    uint8(layer),
+			Participation: 300,
+			Threshold:     401,
+		}))
+	}
+	block := types.BlockID{1, 2, 3}
+	require.NoError(t, certificates.Add(db, 1, &types.Certificate{BlockID: block}))
+	require.NoError(t, layers.SetWeakCoin(db, 1, true))
+	coin := &layers.WeakCoin{
+		Layer:   2,
+		Value:   false,
+		Vrf:     types.RandomVrfSignature(),
+		Smesher: types.RandomNodeID(),
+	}
+	require.NoError(t, layers.SetWeakCoinProvenance(db, coin))
+
+	svc := NewHareService(db, localdb)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	t.Run("results", func(t *testing.T) {
+		results, err := svc.Results(context.Background(), 1, 5)
+		require.NoError(t, err)
+		require.Equal(t, []HareResult{
+			{
+				Layer: 1, Terminated: true, Iterations: 1, Participation: 300, Threshold: 401,
+				Block: hex.EncodeToString(block[:]),
+			},
+			{Layer: 2, Iterations: 2, Participation: 300, Threshold: 401},
+			{Layer: 3, Terminated: true, Iterations: 3, Participation: 300, Threshold: 401},
+		}, results)
+
+		_, err = svc.Results(context.Background(), 5, 1)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+		_, err = svc.Results(context.Background(), 0, MaxHareResults)
+		reason, _, _ = ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+	})
+	t.Run("json", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?start_layer=2&end_layer=3", cfg.JSONListener, HareResultsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var rst struct {
+			Results []HareResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Len(t, rst.Results, 2)
+		require.EqualValues(t, 2, rst.Results[0].Layer)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?start_layer=first", cfg.JSONListener, HareResultsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[HareResultsRequest, HareResultsResponse](
+			ctx, conn, HareGrpcService, "Results", rpc.JSON, &HareResultsRequest{StartLayer: 2, EndLayer: 3},
+		)
+		require.NoError(t, err)
+		require.Len(t, rst.Results, 2)
+		require.EqualValues(t, 2, rst.Results[0].Layer)
+
+		_, err = rpc.Invoke[HareResultsRequest, HareResultsResponse](
+			ctx, conn, HareGrpcService, "Results", rpc.JSON, &HareResultsRequest{StartLayer: 3, EndLayer: 1},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		start := uint32(3)
+		stream, err := rpc.Stream[HareResultsStreamRequest, HareResult](
+			ctx, conn, HareGrpcService, "ResultsStream", rpc.JSON, &HareResultsStreamRequest{StartLayer: &start},
+		)
+		require.NoError(t, err)
+		_, err = stream.Header()
+		require.NoError(t, err)
+		result, err := stream.Recv()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, result.Layer)
+		events.ReportHareResult(hareresults.Result{Layer: 5, Iterations: 1})
+		result, err = stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, &HareResult{Layer: 5, Iterations: 1}, result)
+	})
+	t.Run("weak coins", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?start_layer=1&end_layer=3", cfg.JSONListener, HareWeakCoinPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst struct {
+			WeakCoins []WeakCoin `json:"weak_coins"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, []WeakCoin{
+			{Layer: 1, Value: true},
+			{
+				Layer:   2,
+				Value:   false,
+				Vrf:     hex.EncodeToString(coin.Vrf[:]),
+				Smesher: hex.EncodeToString(coin.Smesher[:]),
+			},
+		}, rst.WeakCoins)
+
+		_, err = svc.WeakCoins(context.Background(), 0, MaxHareResults)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+	})
+	t.Run("weak coins grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[HareWeakCoinsRequest, HareWeakCoinsResponse](
+			ctx, conn, HareGrpcService, "WeakCoins", rpc.JSON, &HareWeakCoinsRequest{StartLayer: 2},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []WeakCoin{{
+			Layer:   2,
+			Value:   false,
+			Vrf:     hex.EncodeToString(coin.Vrf[:]),
+			Smesher: hex.EncodeToString(coin.Smesher[:]),
+		}}, rst.WeakCoins)
+
+		_, err = rpc.Invoke[HareWeakCoinsRequest, HareWeakCoinsResponse](
+			ctx, conn, HareGrpcService, "WeakCoins", rpc.JSON, &HareWeakCoinsRequest{StartLayer: 3, EndLayer: 1},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("stream", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			fmt.Sprintf("http://%s%s?start_layer=2", cfg.JSONListener, HareResultsStreamPath), nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		scanner := bufio.NewScanner(resp.Body)
+		next := func() HareResult {
+			require.True(t, scanner.Scan(), scanner.Err())
+			var rst HareResult
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &rst))
+			return rst
+		}
+		require.EqualValues(t, 2, next().Layer)
+		require.EqualValues(t, 3, next().Layer)
+
+		// already replayed
+		events.ReportHareResult(hareresults.Result{Layer: 3})
+		events.ReportHareResult(hareresults.Result{Layer: 4, Terminated: true, Iterations: 2})
+		require.Equal(t, HareResult{Layer: 4, Terminated: true, Iterations: 2}, next())
+	})
+}
@@ -0,0 +1,283 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// HealthStatus is the outcome of a health check.
+type HealthStatus string
+
+const (
+	// HealthPass means that the subsystem works as expected.
+	HealthPass HealthStatus = "pass"
+	// HealthWarn means that the subsystem is degraded, but the node is still able to serve requests.
+	HealthWarn HealthStatus = "warn"
+	// HealthFail means that the node shouldn't receive traffic until the problem is resolved.
+	HealthFail HealthStatus = "fail"
+)
+
+var healthRank = map[HealthStatus]int{
+	HealthPass: 0,
+	HealthWarn: 1,
+	HealthFail: 2,
+}
+
+// worse returns the more severe of two statuses.
+func (s HealthStatus) worse(other HealthStatus) HealthStatus {
+	if healthRank[other] > healthRank[s] {
+		return other
+	}
+	return s
+}
+
+// HealthCheck reports the health of a single subsystem of the node.
+type HealthCheck struct {
+	Name  string
+	Check func(context.Context) (HealthStatus, string)
+}
+
+// HealthCheckResult is the outcome of a single health check.
+type HealthCheckResult struct {
+	Name    string       `json:"name"`
+	Status  HealthStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+// HealthReport aggregates results of all health checks.
+// Status is the most severe status among all checks.
+type HealthReport struct {
+	Status HealthStatus        `json:"status"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// runHealthChecks runs all checks concurrently. Checks that don't finish before the context
+// is canceled are reported as failed.
+func runHealthChecks(ctx context.Context, checks []HealthCheck) HealthReport {
+	var (
+		mu      sync.Mutex
+		results = make([]HealthCheckResult, len(checks))
+		done    = make([]bool, len(checks))
+		wg      sync.WaitGroup
+		all     = make(chan struct{})
+	)
+	for i, check := range checks {
+		i, check := i, check
+		results[i] = HealthCheckResult{Name: check.Name}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, msg := check.Check(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			results[i].Status = status
+			results[i].Message = msg
+			done[i] = true
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// results are copied so that checks that are still running can't modify the report
+	report := HealthReport{
+		Status: HealthPass,
+		Checks: make([]HealthCheckResult, len(checks)),
+	}
+	for i, result := range results {
+		if !done[i] {
+			result.Status = HealthFail
+			result.Message = "check didn't complete in time"
+		}
+		report.Checks[i] = result
+		report.Status = report.Status.worse(result.Status)
+	}
+	return report
+}
+
+// SyncHealthCheck fails while the node is not synced.
+func SyncHealthCheck(s syncer) HealthCheck {
+	return HealthCheck{
+		Name: "sync",
+		Check: func(ctx context.Context) (HealthStatus, string) {
+			if !s.IsSynced(ctx) {
+				return HealthFail, "node is not synced"
+			}
+			return HealthPass, ""
+		},
+	}
+}
+
+// PeersHealthCheck fails if the node has no peers and warns if it has less than minPeers.
+func PeersHealthCheck(p peerCounter, minPeers int) HealthCheck {
+	return HealthCheck{
+		Name: "peers",
+		Check: func(context.Context) (HealthStatus, string) {
+			count := p.PeerCount()
+			switch {
+			case count == 0:
+				return HealthFail, "no connected peers"
+			case count < uint64(minPeers):
+				return HealthWarn, fmt.Sprintf("%d connected peers, expected at least %d", count, minPeers)
+			}
+			return HealthPass, fmt.Sprintf("%d connected peers", count)
+		},
+	}
+}
+
+// ClockHealthCheck fails if the local clock is further than the max allowed offset from the clocks of peers
+// and warns if it is further than half of it or wasn't compared with peers yet.
+func ClockHealthCheck(c clockOffset) HealthCheck {
+	return HealthCheck{
+		Name: "clock",
+		Check: func(context.Context) (HealthStatus, string) {
+			offset, measured := c.LastOffset()
+			if !measured {
+				return HealthWarn, "clock wasn't compared with peers yet"
+			}
+			msg := fmt.Sprintf("clock offset %v", offset)
+			switch limit := c.MaxClockOffset(); {
+			case offset.Abs() > limit:
+				return HealthFail, fmt.Sprintf("%s exceeds max allowed offset %v", msg, limit)
+			case offset.Abs() > limit/2:
+				return HealthWarn, fmt.Sprintf("%s is close to max allowed offset %v", msg, limit)
+			}
+			return HealthPass, msg
+		},
+	}
+}
+
+// NTPHealthCheck warns if the local clock is further than the max allowed offset from ntp servers.
+// It never fails, as ntp servers may be unreachable from the network of the node.
+func NTPHealthCheck(c clockOffset) HealthCheck {
+	return HealthCheck{
+		Name: "ntp",
+		Check: func(context.Context) (HealthStatus, string) {
+			offset, measured := c.LastOffset()
+			if !measured {
+				return HealthPass, "clock wasn't compared with ntp servers yet"
+			}
+			msg := fmt.Sprintf("clock offset %v", offset)
+			if limit := c.MaxClockOffset(); offset.Abs() > limit {
+				return HealthWarn, fmt.Sprintf("%s exceeds max allowed offset %v", msg, limit)
+			}
+			return HealthPass, msg
+		},
+	}
+}
+
+// PostHealthCheck fails if none of the identities has a connected post service
+// and warns if some of them don't.
+func PostHealthCheck(p postConnections, ids []types.NodeID) HealthCheck {
+	return HealthCheck{
+		Name: "post",
+		Check: func(context.Context) (HealthStatus, string) {
+			var missing []string
+			for _, id := range ids {
+				_, err := p.Client(id)
+				switch {
+				case errors.Is(err, activation.ErrPostClientNotConnected):
+					missing = append(missing, id.ShortString())
+				case err != nil:
+					return HealthFail, fmt.Sprintf("post service for %s: %v", id.ShortString(), err)
+				}
+			}
+			switch {
+			case len(missing) == 0:
+				return HealthPass, ""
+			case len(missing) == len(ids):
+				return HealthFail, "no post service is connected"
+			}
+			return HealthWarn, fmt.Sprintf("post service is not connected for %s", strings.Join(missing, ", "))
+		},
+	}
+}
+
+// PoetHealthCheck fails if none of the poets is reachable and warns if some of them aren't.
+func PoetHealthCheck[T poetPinger](poets []T) HealthCheck {
+	return HealthCheck{
+		Name: "poet",
+		Check: func(ctx context.Context) (HealthStatus, string) {
+			var (
+				mu          sync.Mutex
+				unreachable []string
+				wg          sync.WaitGroup
+			)
+			for _, poet := range poets {
+				poet := poet
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := poet.PowParams(ctx); err != nil {
+						mu.Lock()
+						unreachable = append(unreachable, poet.Address())
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			switch {
+			case len(unreachable) == 0:
+				return HealthPass, ""
+			case len(unreachable) == len(poets):
+				return HealthFail, "no poet is reachable"
+			}
+			return HealthWarn, fmt.Sprintf("unreachable poets: %s", strings.Join(unreachable, ", "))
+		},
+	}
+}
+
+// DatabaseHealthCheck fails if the database doesn't execute queries.
+// The query runs in a deferred transaction that only reads, so the check doesn't take the write lock
+// and doesn't compete with the writes of the node. Lack of space for writes is reported by the disk check.
+func DatabaseHealthCheck(name string, db queryableDatabase) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(ctx context.Context) (HealthStatus, string) {
+			tx, err := db.Tx(ctx)
+			if err != nil {
+				return HealthFail, fmt.Sprintf("database is not available: %v", err)
+			}
+			defer tx.Release()
+			if _, err := tx.Exec("select 1;", nil, nil); err != nil {
+				return HealthFail, fmt.Sprintf("database query failed: %v", err)
+			}
+			return HealthPass, ""
+		},
+	}
+}
+
+// DiskHealthCheck warns if free space on the volume with the given path is lower than warnFree
+// and fails if it is lower than failFree.
+func DiskHealthCheck(path string, warnFree, failFree uint64) HealthCheck {
+	return HealthCheck{
+		Name: "disk",
+		Check: func(context.Context) (HealthStatus, string) {
+			free, err := freeDiskSpace(path)
+			if err != nil {
+				return HealthFail, fmt.Sprintf("get free space for %s: %v", path, err)
+			}
+			msg := fmt.Sprintf("%d MiB free", free/(1<<20))
+			switch {
+			case free < failFree:
+				return HealthFail, msg
+			case free < warnFree:
+				return HealthWarn, msg
+			}
+			return HealthPass, msg
+		},
+	}
+}
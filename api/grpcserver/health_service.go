@@ -0,0 +1,155 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// HealthReportHeader is the header that carries the json encoded HealthReport
+	// in responses of the grpc health service.
+	HealthReportHeader = "health-report"
+
+	// HealthPath is the http path that serves the HealthReport on the json gateway.
+	// It responds with 503 if the node fails any of the checks.
+	HealthPath = "/v1/health"
+	// LivenessPath is the http path that responds with 200 for as long as the api is served.
+	LivenessPath = "/v1/health/live"
+)
+
+// HealthService aggregates the health of the node subsystems. It implements the standard grpc
+// health protocol and serves the detailed report on the json gateway, so that it can be used by
+// load balancers and kubernetes probes.
+type HealthService struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	checks        []HealthCheck
+	timeout       time.Duration
+	watchInterval time.Duration
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *HealthService) RegisterService(server *grpc.Server) {
+	grpc_health_v1.RegisterHealthServer(server, s)
+}
+
+// RegisterHandlerService registers the health routes with the json gateway.
+func (s *HealthService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, HealthPath, s.handleHealth); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, LivenessPath, s.handleLiveness)
+}
+
+// String returns the name of this service.
+func (s *HealthService) String() string {
+	return "HealthService"
+}
+
+// NewHealthService creates a new health service that reports the results of the given checks.
+func NewHealthService(cfg HealthConfig, checks ...HealthCheck) *HealthService {
+	return &HealthService{
+		checks:        checks,
+		timeout:       cfg.CheckTimeout,
+		watchInterval: cfg.WatchInterval,
+	}
+}
+
+// Report runs the checks matching the service name, or all checks if the name is empty.
+// It returns false if no check with such name exists.
+func (s *HealthService) Report(ctx context.Context, service string) (HealthReport, bool) {
+	checks := s.checks
+	if service != "" {
+		checks = nil
+		for _, check := range s.checks {
+			if check.Name == service {
+				checks = append(checks, check)
+			}
+		}
+		if len(checks) == 0 {
+			return HealthReport{}, false
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return runHealthChecks(ctx, checks), true
+}
+
+// Check returns SERVING unless any of the requested checks fails.
+// Service is either empty, to run all checks, or the name of a single check.
+func (s *HealthService) Check(
+	ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest,
+) (*grpc_health_v1.HealthCheckResponse, error) {
+	report, exists := s.Report(ctx, req.Service)
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "unknown health check %q", req.Service)
+	}
+	if encoded, err := json.Marshal(report); err == nil {
+		grpc.SetHeader(ctx, metadata.Pairs(HealthReportHeader, string(encoded)))
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus(report.Status)}, nil
+}
+
+// Watch sends the serving status immediately and then every time it changes.
+func (s *HealthService) Watch(
+	req *grpc_health_v1.HealthCheckRequest,
+	stream grpc_health_v1.Health_WatchServer,
+) error {
+	var (
+		last   grpc_health_v1.HealthCheckResponse_ServingStatus
+		ticker = time.NewTicker(s.watchInterval)
+	)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		current := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		if report, exists := s.Report(stream.Context(), req.Service); exists {
+			current = servingStatus(report.Status)
+		}
+		if first || current != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *HealthService) handleHealth(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	report, exists := s.Report(r.Context(), r.URL.Query().Get("check"))
+	if !exists {
+		http.Error(w, "unknown health check", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == HealthFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func (s *HealthService) handleLiveness(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthReport{Status: HealthPass, Checks: []HealthCheckResult{}})
+}
+
+func servingStatus(s HealthStatus) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if s == HealthFail {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,273 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+func staticCheck(name string, status HealthStatus) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(context.Context) (HealthStatus, string) {
+			return status, string(status)
+		},
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+
+	t.Run("sync", func(t *testing.T) {
+		syncer := NewMocksyncer(ctrl)
+		check := SyncHealthCheck(syncer)
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(false)
+		status, _ := check.Check(ctx)
+		require.Equal(t, HealthFail, status)
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(true)
+		status, _ = check.Check(ctx)
+		require.Equal(t, HealthPass, status)
+	})
+	t.Run("peers", func(t *testing.T) {
+		peers := NewMockpeerCounter(ctrl)
+		check := PeersHealthCheck(peers, 3)
+		for count, expected := range map[uint64]HealthStatus{0: HealthFail, 2: HealthWarn, 3: HealthPass} {
+			peers.EXPECT().PeerCount().Return(count)
+			status, _ := check.Check(ctx)
+			require.Equal(t, expected, status, "peers %d", count)
+		}
+	})
+	t.Run("clock", func(t *testing.T) {
+		clock := NewMockclockOffset(ctrl)
+		check := ClockHealthCheck(clock)
+		clock.EXPECT().MaxClockOffset().Return(10 * time.Second).AnyTimes()
+
+		clock.EXPECT().LastOffset().Return(time.Duration(0), false)
+		status, _ := check.Check(ctx)
+		require.Equal(t, HealthWarn, status)
+		for offset, expected := range map[time.Duration]HealthStatus{
+			time.Second:       HealthPass,
+			-6 * time.Second:  HealthWarn,
+			-11 * time.Second: HealthFail,
+			11 * time.Second:  HealthFail,
+		} {
+			clock.EXPECT().LastOffset().Return(offset, true)
+			status, _ := check.Check(ctx)
+			require.Equal(t, expected, status, "offset %v", offset)
+		}
+	})
+	t.Run("ntp", func(t *testing.T) {
+		clock := NewMockclockOffset(ctrl)
+		check := NTPHealthCheck(clock)
+		clock.EXPECT().MaxClockOffset().Return(3 * time.Second).AnyTimes()
+
+		clock.EXPECT().LastOffset().Return(time.Duration(0), false)
+		status, _ := check.Check(ctx)
+		require.Equal(t, HealthPass, status)
+		for offset, expected := range map[time.Duration]HealthStatus{
+			time.Second:      HealthPass,
+			-4 * time.Second: HealthWarn,
+			4 * time.Second:  HealthWarn,
+		} {
+			clock.EXPECT().LastOffset().Return(offset, true)
+			status, _ := check.Check(ctx)
+			require.Equal(t, expected, status, "offset %v", offset)
+		}
+	})
+	t.Run("post", func(t *testing.T) {
+		posts := NewMockpostConnections(ctrl)
+		ids := []types.NodeID{types.RandomNodeID(), types.RandomNodeID()}
+		check := PostHealthCheck(posts, ids)
+
+		posts.EXPECT().Client(gomock.Any()).Return(nil, nil).Times(2)
+		status, _ := check.Check(ctx)
+		require.Equal(t, HealthPass, status)
+
+		posts.EXPECT().Client(ids[0]).Return(nil, nil)
+		posts.EXPECT().Client(ids[1]).Return(nil, activation.ErrPostClientNotConnected)
+		status, msg := check.Check(ctx)
+		require.Equal(t, HealthWarn, status)
+		require.Contains(t, msg, ids[1].ShortString())
+
+		posts.EXPECT().Client(gomock.Any()).Return(nil, activation.ErrPostClientNotConnected).Times(2)
+		status, _ = check.Check(ctx)
+		require.Equal(t, HealthFail, status)
+	})
+	t.Run("poet", func(t *testing.T) {
+		poets := []*MockpoetPinger{NewMockpoetPinger(ctrl), NewMockpoetPinger(ctrl)}
+		for i, poet := range poets {
+			poet.EXPECT().Address().Return(fmt.Sprintf("http://poet-%d", i)).AnyTimes()
+		}
+		check := PoetHealthCheck(poets)
+
+		poets[0].EXPECT().PowParams(gomock.Any()).Return(&activation.PoetPowParams{}, nil)
+		poets[1].EXPECT().PowParams(gomock.Any()).Return(&activation.PoetPowParams{}, nil)
+		status, _ := check.Check(ctx)
+		require.Equal(t, HealthPass, status)
+
+		poets[0].EXPECT().PowParams(gomock.Any()).Return(&activation.PoetPowParams{}, nil)
+		poets[1].EXPECT().PowParams(gomock.Any()).Return(nil, errors.New("unreachable"))
+		status, msg := check.Check(ctx)
+		require.Equal(t, HealthWarn, status)
+		require.Contains(t, msg, "http://poet-1")
+
+		poets[0].EXPECT().PowParams(gomock.Any()).Return(nil, errors.New("unreachable"))
+		poets[1].EXPECT().PowParams(gomock.Any()).Return(nil, errors.New("unreachable"))
+		status, _ = check.Check(ctx)
+		require.Equal(t, HealthFail, status)
+	})
+	t.Run("database", func(t *testing.T) {
+		db, err := sql.Open("file:" + filepath.Join(t.TempDir(), "state.sql"))
+		require.NoError(t, err)
+		check := DatabaseHealthCheck("db", db)
+		status, msg := check.Check(ctx)
+		require.Equal(t, HealthPass, status, msg)
+
+		// the check doesn't wait for the writer to finish
+		tx, err := db.TxImmediate(ctx)
+		require.NoError(t, err)
+		status, msg = check.Check(ctx)
+		require.Equal(t, HealthPass, status, msg)
+		tx.Release()
+
+		require.NoError(t, db.Close())
+		status, _ = check.Check(ctx)
+		require.Equal(t, HealthFail, status)
+	})
+	t.Run("disk", func(t *testing.T) {
+		dir := t.TempDir()
+		status, _ := DiskHealthCheck(dir, 0, 0).Check(ctx)
+		require.Equal(t, HealthPass, status)
+		status, _ = DiskHealthCheck(dir, 1<<62, 0).Check(ctx)
+		require.Equal(t, HealthWarn, status)
+		status, _ = DiskHealthCheck(dir, 1<<62, 1<<62).Check(ctx)
+		require.Equal(t, HealthFail, status)
+	})
+}
+
+func TestHealthService_Report(t *testing.T) {
+	stuck := HealthCheck{
+		Name: "stuck",
+		Check: func(ctx context.Context) (HealthStatus, string) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return HealthPass, ""
+		},
+	}
+	svc := NewHealthService(HealthConfig{CheckTimeout: 100 * time.Millisecond},
+		staticCheck("a", HealthPass),
+		staticCheck("b", HealthWarn),
+	)
+	report, exists := svc.Report(context.Background(), "")
+	require.True(t, exists)
+	require.Equal(t, HealthWarn, report.Status)
+	require.Equal(t, []HealthCheckResult{
+		{Name: "a", Status: HealthPass, Message: "pass"},
+		{Name: "b", Status: HealthWarn, Message: "warn"},
+	}, report.Checks)
+
+	report, exists = svc.Report(context.Background(), "a")
+	require.True(t, exists)
+	require.Equal(t, HealthPass, report.Status)
+	require.Len(t, report.Checks, 1)
+
+	_, exists = svc.Report(context.Background(), "unknown")
+	require.False(t, exists)
+
+	svc = NewHealthService(HealthConfig{CheckTimeout: 100 * time.Millisecond}, staticCheck("a", HealthPass), stuck)
+	report, _ = svc.Report(context.Background(), "")
+	require.Equal(t, HealthFail, report.Status)
+	require.Equal(t, HealthFail, report.Checks[1].Status)
+}
+
+func TestHealthService_Grpc(t *testing.T) {
+	svc := NewHealthService(HealthConfig{CheckTimeout: time.Second, WatchInterval: 10 * time.Millisecond},
+		staticCheck("ok", HealthWarn),
+		staticCheck("broken", HealthFail),
+	)
+	cfg, cleanup := launchServer(t, svc)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := grpc_health_v1.NewHealthClient(dialGrpc(ctx, t, cfg))
+
+	var header metadata.MD
+	res, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.Status)
+	require.Len(t, header.Get(HealthReportHeader), 1)
+	var report HealthReport
+	require.NoError(t, json.Unmarshal([]byte(header.Get(HealthReportHeader)[0]), &report))
+	require.Equal(t, HealthFail, report.Status)
+	require.Len(t, report.Checks, 2)
+
+	res, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "ok"})
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
+
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	require.NoError(t, err)
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, res.Status)
+}
+
+func TestHealthService_Json(t *testing.T) {
+	svc := NewHealthService(HealthConfig{CheckTimeout: time.Second},
+		staticCheck("ok", HealthPass),
+		staticCheck("broken", HealthFail),
+	)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	get := func(path string) (HealthReport, int) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, path))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var report HealthReport
+		if resp.StatusCode != http.StatusNotFound {
+			require.NoError(t, json.Unmarshal(buf, &report))
+		}
+		return report, resp.StatusCode
+	}
+
+	report, code := get(HealthPath)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, HealthFail, report.Status)
+	require.Len(t, report.Checks, 2)
+
+	report, code = get(HealthPath + "?check=ok")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, HealthPass, report.Status)
+
+	_, code = get(HealthPath + "?check=unknown")
+	require.Equal(t, http.StatusNotFound, code)
+
+	report, code = get(LivenessPath)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, HealthPass, report.Status)
+}
@@ -25,14 +25,38 @@ type JSONHTTPServer struct {
 	BoundAddress string
 	server       *http.Server
 	eg           errgroup.Group
+
+	limiter *RateLimiter
+	auth    *Authorizer
+}
+
+// JSONHTTPServerOpt is an option for the JSONHTTPServer.
+type JSONHTTPServerOpt func(*JSONHTTPServer)
+
+// WithRateLimiter enforces the quotas of the limiter on the requests to the server.
+func WithRateLimiter(limiter *RateLimiter) JSONHTTPServerOpt {
+	return func(s *JSONHTTPServer) {
+		s.limiter = limiter
+	}
+}
+
+// WithAuthorizer enforces the authorization policies of the authorizer on the requests to the server.
+func WithAuthorizer(auth *Authorizer) JSONHTTPServerOpt {
+	return func(s *JSONHTTPServer) {
+		s.auth = auth
+	}
 }
 
 // NewJSONHTTPServer creates a new json http server.
-func NewJSONHTTPServer(listener string, lg *zap.Logger) *JSONHTTPServer {
-	return &JSONHTTPServer{
+func NewJSONHTTPServer(listener string, lg *zap.Logger, opts ...JSONHTTPServerOpt) *JSONHTTPServer {
+	s := &JSONHTTPServer{
 		logger:   lg,
 		listener: listener,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Shutdown stops the server.
@@ -79,8 +103,15 @@ func (s *JSONHTTPServer) StartService(
 		return fmt.Errorf("listening on %s: %w", s.listener, err)
 	}
 	s.BoundAddress = lis.Addr().String()
+	var handler http.Handler = mux
+	if s.auth != nil {
+		handler = s.auth.Handler(handler)
+	}
+	if s.limiter != nil {
+		handler = s.limiter.Handler(handler)
+	}
 	s.server = &http.Server{
-		Handler: mux,
+		Handler: handler,
 	}
 	s.eg.Go(func() error {
 		if err := s.server.Serve(lis); err != nil {
@@ -8,10 +8,16 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/beacon"
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/miner"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
 	"github.com/spacemeshos/go-spacemesh/system"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
 )
 
 //go:generate mockgen -typed -package=grpcserver -destination=./mocks.go -source=./interface.go
@@ -70,6 +76,9 @@ type postSupervisor interface {
 	Status() *activation.PostSetupStatus
 	Providers() ([]activation.PostSetupProvider, error)
 	Benchmark(p activation.PostSetupProvider) (int, error)
+
+	PreviewResize(numUnits uint32) (*activation.ResizePreview, error)
+	Resize(numUnits uint32) (*activation.ResizePreview, error)
 }
 
 // peerCounter is an api to get amount of connected peers.
@@ -83,6 +92,13 @@ type peers interface {
 	GetPeers() []p2p.Peer
 }
 
+// checkpointScheduler generates checkpoints in the background and reports their progress.
+type checkpointScheduler interface {
+	Generate(snapshot types.LayerID, numAtxs int) (uint64, error)
+	Progress(id uint64) (checkpoint.Progress, bool)
+	Jobs() []checkpoint.Progress
+}
+
 // genesisTimeAPI is an API to get genesis time and current layer of the system.
 type genesisTimeAPI interface {
 	GenesisTime() time.Time
@@ -104,3 +120,88 @@ type meshAPI interface {
 type oracle interface {
 	ActiveSet(context.Context, types.EpochID) ([]types.ATXID, error)
 }
+
+// clockOffset reports how far the local clock is from the clocks of peers.
+type clockOffset interface {
+	LastOffset() (time.Duration, bool)
+	MaxClockOffset() time.Duration
+}
+
+// timeSource is the source of time for the layer clock that can be advanced in the stepped mode.
+type timeSource interface {
+	Mode() string
+	Now() time.Time
+	Advance(time.Duration) (time.Time, error)
+}
+
+// layerClock converts between layers and time.
+type layerClock interface {
+	CurrentLayer() types.LayerID
+	LayerToTime(types.LayerID) time.Time
+}
+
+// postConnections is used by the health service to check if post services are connected.
+type postConnections interface {
+	Client(nodeId types.NodeID) (activation.PostClient, error)
+}
+
+// poetPinger is used by the health service to check if a poet is reachable.
+type poetPinger interface {
+	Address() string
+	PowParams(ctx context.Context) (*activation.PoetPowParams, error)
+}
+
+// queryableDatabase is used by the health service to check if a database executes queries.
+type queryableDatabase interface {
+	Tx(ctx context.Context) (*sql.Tx, error)
+}
+
+// fallbackBeacon accepts beacons signed by the fallback authorities.
+type fallbackBeacon interface {
+	SubmitFallbackBeacon(context.Context, *beacon.FallbackBeacon) error
+}
+
+// malfeasanceSubmitter validates and saves malfeasance proofs submitted through the api.
+type malfeasanceSubmitter interface {
+	SubmitMalfeasanceProof(context.Context, *types.MalfeasanceProof) (types.NodeID, error)
+}
+
+// beaconProtocol exposes the state of the beacon protocol for troubleshooting.
+type beaconProtocol interface {
+	ProtocolState(types.EpochID) (*beacon.ProtocolState, error)
+	InjectBeacon(types.EpochID, types.Beacon, string) error
+}
+
+// atxQuarantine lists the atxs that wait for their missing references.
+type atxQuarantine interface {
+	Quarantined() []activation.QuarantinedAtx
+}
+
+// activeSetProjection tracks the candidates for the active set of the epoch.
+type activeSetProjection interface {
+	Ready() bool
+	Deadline(types.EpochID) time.Time
+	Projection(types.EpochID) (activecandidates.Summary, bool)
+	Candidate(types.EpochID, types.NodeID) (*activecandidates.Candidate, error)
+}
+
+// eligibilityEstimator estimates the proposal eligibilities and rewards of the smeshers in the epoch.
+type eligibilityEstimator interface {
+	Estimate(types.EpochID, []types.NodeID) (*miner.EpochEstimate, error)
+}
+
+// publishForecaster forecasts the next atx publication of the identities of the node.
+type publishForecaster interface {
+	PublishForecast(types.NodeID) (*activation.PublishForecast, error)
+}
+
+// atxWatchdog watches the atxs of the identities of the operator.
+type atxWatchdog interface {
+	Status() []activation.WatchdogStatus
+}
+
+// tortoiseIntrospection explains the state of the verification in the tortoise.
+type tortoiseIntrospection interface {
+	Progress(limit int) tortoise.Progress
+	BlockOpinion(types.LayerID, types.BlockID) (tortoise.BlockOpinion, bool)
+}
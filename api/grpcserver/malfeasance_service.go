@@ -0,0 +1,138 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/malfeasance"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+)
+
+const (
+	// MalfeasanceSubmitPath accepts malfeasance proofs observed outside of the node on POST,
+	// e.g. by a watcher of the network. Valid proofs are saved and gossiped to the network.
+	MalfeasanceSubmitPath = "/v1/malfeasance/submit"
+	// MalfeasanceGrpcService is the name of the grpc service that serves Submit method.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	MalfeasanceGrpcService = "spacemesh.node.v1.MalfeasanceService"
+
+	// maxProofBody limits the size of the json body accepted on MalfeasanceSubmitPath.
+	maxProofBody = 1 << 20
+)
+
+// SubmitProofRequest is the hex encoded malfeasance proof, as it is encoded on the wire.
+type SubmitProofRequest struct {
+	Proof string `json:"proof"`
+}
+
+// SubmitProofResponse is the hex encoded id of the identity that was proven to be malicious.
+type SubmitProofResponse struct {
+	Smesher string `json:"smesher"`
+}
+
+// MalfeasanceService accepts malfeasance proofs from external tools.
+// It is served on the json gateway and as MalfeasanceGrpcService.
+type MalfeasanceService struct {
+	submitter malfeasanceSubmitter
+	publisher pubsub.Publisher
+}
+
+// NewMalfeasanceService creates a new malfeasance service.
+func NewMalfeasanceService(submitter malfeasanceSubmitter, publisher pubsub.Publisher) *MalfeasanceService {
+	return &MalfeasanceService{submitter: submitter, publisher: publisher}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *MalfeasanceService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&malfeasanceDesc, s)
+}
+
+type malfeasanceServer interface {
+	submit(context.Context, *SubmitProofRequest) (*SubmitProofResponse, error)
+}
+
+var malfeasanceDesc = grpc.ServiceDesc{
+	ServiceName: MalfeasanceGrpcService,
+	HandlerType: (*malfeasanceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(MalfeasanceGrpcService, "Submit", malfeasanceServer.submit),
+	},
+	Metadata: "api/grpcserver/malfeasance_service.go",
+}
+
+// RegisterHandlerService registers the malfeasance routes with the json gateway.
+func (s *MalfeasanceService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	return mux.HandlePath(http.MethodPost, MalfeasanceSubmitPath, s.handleSubmit)
+}
+
+// String returns the name of this service.
+func (s *MalfeasanceService) String() string {
+	return "MalfeasanceService"
+}
+
+// Submit validates the encoded malfeasance proof, saves and publishes it to the network.
+// It returns the id of the identity that was proven to be malicious.
+func (s *MalfeasanceService) Submit(ctx context.Context, encoded []byte) (types.NodeID, error) {
+	var proof types.MalfeasanceProof
+	if err := codec.Decode(encoded, &proof); err != nil {
+		return types.EmptyNodeID, apiError(codes.InvalidArgument, ReasonProofMalformed,
+			fmt.Sprintf("decode proof: %s", err))
+	}
+	id, err := s.submitter.SubmitMalfeasanceProof(ctx, &proof)
+	switch {
+	case errors.Is(err, malfeasance.ErrKnownProof):
+		return id, apiError(codes.AlreadyExists, ReasonKnownMalicious, err.Error(),
+			"smesher", hex.EncodeToString(id.Bytes()))
+	case errors.Is(err, pubsub.ErrValidationReject):
+		return types.EmptyNodeID, apiError(codes.InvalidArgument, ReasonProofRejected, err.Error())
+	case err != nil:
+		return types.EmptyNodeID, apiError(codes.FailedPrecondition, ReasonProofRejected, err.Error())
+	}
+	gossip := types.MalfeasanceGossip{MalfeasanceProof: proof}
+	if err := s.publisher.Publish(ctx, pubsub.MalfeasanceProof, codec.MustEncode(&gossip)); err != nil {
+		return types.EmptyNodeID, apiError(codes.Internal, ReasonInternal, fmt.Sprintf("publish proof: %s", err))
+	}
+	return id, nil
+}
+
+func (s *MalfeasanceService) submit(ctx context.Context, req *SubmitProofRequest) (*SubmitProofResponse, error) {
+	if req.Proof == "" {
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, "proof is required")
+	}
+	encoded, err := hex.DecodeString(req.Proof)
+	if err != nil {
+		return nil, apiError(codes.InvalidArgument, ReasonProofMalformed, fmt.Sprintf("decode proof: %s", err))
+	}
+	id, err := s.Submit(ctx, encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &SubmitProofResponse{Smesher: hex.EncodeToString(id.Bytes())}, nil
+}
+
+func (s *MalfeasanceService) handleSubmit(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req SubmitProofRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxProofBody)).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument, fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := s.submit(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
@@ -0,0 +1,145 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/malfeasance"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
+)
+
+func TestMalfeasanceService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	submitter := NewMockmalfeasanceSubmitter(ctrl)
+	publisher := pubsubmocks.NewMockPublisher(ctrl)
+	svc := NewMalfeasanceService(submitter, publisher)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	smesher := types.RandomNodeID()
+	proof := &types.MalfeasanceProof{
+		Layer: 11,
+		Proof: types.Proof{
+			Type: types.HareEquivocation,
+			Data: &types.HareProof{Messages: [2]types.HareProofMsg{
+				{SmesherID: smesher, Signature: types.RandomEdSignature()},
+				{SmesherID: smesher, Signature: types.RandomEdSignature()},
+			}},
+		},
+	}
+	encoded := codec.MustEncode(proof)
+	gossip := codec.MustEncode(&types.MalfeasanceGossip{MalfeasanceProof: *proof})
+
+	t.Run("submit", func(t *testing.T) {
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, got *types.MalfeasanceProof) (types.NodeID, error) {
+				require.Equal(t, encoded, codec.MustEncode(got))
+				return smesher, nil
+			})
+		publisher.EXPECT().Publish(gomock.Any(), pubsub.MalfeasanceProof, gossip)
+		id, err := svc.Submit(context.Background(), encoded)
+		require.NoError(t, err)
+		require.Equal(t, smesher, id)
+	})
+	t.Run("rejected", func(t *testing.T) {
+		for _, tc := range []struct {
+			err    error
+			reason ErrorReason
+		}{
+			{fmt.Errorf("%w: invalid signature", pubsub.ErrValidationReject), ReasonProofRejected},
+			{errors.New("unknown identity"), ReasonProofRejected},
+			{malfeasance.ErrKnownProof, ReasonKnownMalicious},
+		} {
+			submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).Return(smesher, tc.err)
+			_, err := svc.Submit(context.Background(), encoded)
+			reason, _, _ := ErrorReasonOf(err)
+			require.Equal(t, tc.reason, reason, tc.err)
+		}
+
+		_, err := svc.Submit(context.Background(), []byte{1, 2, 3})
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonProofMalformed, reason)
+	})
+	t.Run("publish failed", func(t *testing.T) {
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).Return(smesher, nil)
+		publisher.EXPECT().Publish(gomock.Any(), pubsub.MalfeasanceProof, gossip).Return(errors.New("closed"))
+		_, err := svc.Submit(context.Background(), encoded)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInternal, reason)
+	})
+	t.Run("json", func(t *testing.T) {
+		url := fmt.Sprintf("http://%s%s", cfg.JSONListener, MalfeasanceSubmitPath)
+		body, err := json.Marshal(SubmitProofRequest{Proof: hex.EncodeToString(encoded)})
+		require.NoError(t, err)
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).Return(smesher, nil)
+		publisher.EXPECT().Publish(gomock.Any(), pubsub.MalfeasanceProof, gossip)
+		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst SubmitProofResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, hex.EncodeToString(smesher.Bytes()), rst.Smesher)
+
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).
+			Return(smesher, malfeasance.ErrKnownProof)
+		resp, err = http.Post(url, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		for _, body := range []string{"{", `{"proof": ""}`, `{"proof": "zz"}`} {
+			resp, err = http.Post(url, "application/json", bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).Return(smesher, nil)
+		publisher.EXPECT().Publish(gomock.Any(), pubsub.MalfeasanceProof, gossip)
+		rst, err := rpc.Invoke[SubmitProofRequest, SubmitProofResponse](
+			ctx, conn, MalfeasanceGrpcService, "Submit", rpc.JSON,
+			&SubmitProofRequest{Proof: hex.EncodeToString(encoded)},
+		)
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(smesher.Bytes()), rst.Smesher)
+
+		submitter.EXPECT().SubmitMalfeasanceProof(gomock.Any(), gomock.Any()).
+			Return(smesher, malfeasance.ErrKnownProof)
+		_, err = rpc.Invoke[SubmitProofRequest, SubmitProofResponse](
+			ctx, conn, MalfeasanceGrpcService, "Submit", rpc.JSON,
+			&SubmitProofRequest{Proof: hex.EncodeToString(encoded)},
+		)
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		for _, proof := range []string{"", "zz"} {
+			_, err = rpc.Invoke[SubmitProofRequest, SubmitProofResponse](
+				ctx, conn, MalfeasanceGrpcService, "Submit", rpc.JSON, &SubmitProofRequest{Proof: proof},
+			)
+			require.Equal(t, codes.InvalidArgument, status.Code(err), proof)
+		}
+	})
+}
@@ -3,8 +3,10 @@ package grpcserver
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
@@ -16,12 +18,81 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
+// MeshReorgsStreamPath streams reorgs of the applied layers as newline delimited json on the json gateway.
+const MeshReorgsStreamPath = "/v1/mesh/reorgs/stream"
+
+// MeshReorg is reported when the node reverted applied layers, because tortoise changed the block
+// in consensus. Downstream systems should roll back state indexed for layers after Revert.
+type MeshReorg struct {
+	Revert       uint32                 `json:"revert"`
+	Depth        uint32                 `json:"depth"`
+	Layers       []MeshReorgLayer       `json:"layers"`
+	Transactions []MeshReorgTransaction `json:"transactions"`
+}
+
+// MeshReorgLayer is the reverted layer. Blocks are hex encoded, and empty for the empty layer.
+// Applied is false if the layer wasn't applied again yet, in that case New is not known.
+type MeshReorgLayer struct {
+	Layer   uint32 `json:"layer"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	Applied bool   `json:"applied"`
+}
+
+// MeshReorgTransaction is the transaction from the replaced block with its status after the reorg.
+// Layer is set only for applied transactions.
+type MeshReorgTransaction struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	Layer uint32 `json:"layer,omitempty"`
+}
+
+const (
+	// MalfeasanceProofPath returns the decoded malfeasance proof of the smesher (hex encoded ?smesher=)
+	// on the json gateway.
+	MalfeasanceProofPath = "/v1/mesh/malfeasance/proof"
+	// MalfeasanceStreamPath streams decoded malfeasance proofs as newline delimited json on the json gateway.
+	// Proofs that are already known are streamed first, followed by the new proofs as they arrive.
+	MalfeasanceStreamPath = "/v1/mesh/malfeasance/stream"
+)
+
+// MalfeasanceEvidence is the decoded malfeasance proof of the smesher.
+// Messages are set for the built-in types of proofs, Info describes proof of any type.
+type MalfeasanceEvidence struct {
+	Smesher  string               `json:"smesher"`
+	Type     uint8                `json:"type"`
+	Kind     string               `json:"kind"`
+	Version  uint8                `json:"version"`
+	Layer    uint32               `json:"layer"`
+	Epoch    uint32               `json:"epoch"`
+	Received time.Time            `json:"received"`
+	Info     string               `json:"info"`
+	Messages []MalfeasanceMessage `json:"messages,omitempty"`
+	// InvalidIndex is the index of the invalid label in the post of the atx with invalid post index.
+	InvalidIndex *uint32 `json:"invalid_index,omitempty"`
+	// Proof is the hex encoded proof as it is gossiped.
+	Proof string `json:"proof"`
+}
+
+// MalfeasanceMessage is one of the messages signed by the smesher that prove malicious behavior.
+// Hash is the hash of the signed message, or id of the atx with invalid post index.
+type MalfeasanceMessage struct {
+	Smesher   string `json:"smesher"`
+	Signature string `json:"signature"`
+	Hash      string `json:"hash"`
+	Epoch     uint32 `json:"epoch,omitempty"`
+	Layer     uint32 `json:"layer,omitempty"`
+	Round     uint32 `json:"round,omitempty"`
+}
+
 // MeshService exposes mesh data such as accounts, blocks, and transactions.
 type MeshService struct {
 	cdb            *datastore.CachedDB
@@ -41,7 +112,16 @@ func (s MeshService) RegisterService(server *grpc.Server) {
 }
 
 func (s MeshService) RegisterHandlerService(mux *runtime.ServeMux) error {
-	return pb.RegisterMeshServiceHandlerServer(context.Background(), mux, s)
+	if err := pb.RegisterMeshServiceHandlerServer(context.Background(), mux, s); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, MeshReorgsStreamPath, s.handleReorgs); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, MalfeasanceProofPath, s.handleMalfeasanceProof); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, MalfeasanceStreamPath, s.handleMalfeasanceStream)
 }
 
 // String returns the name of this service.
@@ -150,16 +230,20 @@ func (s MeshService) AccountMeshDataQuery(
 	}
 
 	if startLayer.After(s.mesh.LatestLayer()) {
-		return nil, status.Errorf(codes.InvalidArgument, "`LatestLayer` must be less than or equal to latest layer")
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`LatestLayer` must be less than or equal to latest layer", "field", "LatestLayer")
 	}
 	if in.Filter == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter` must be provided", "field", "Filter")
 	}
 	if in.Filter.AccountId == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter.AccountId` must be provided", "field", "Filter.AccountId")
 	}
 	if in.Filter.AccountMeshDataFlags == uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_UNSPECIFIED) {
-		return nil, status.Errorf(codes.InvalidArgument, "`Filter.AccountMeshDataFlags` must set at least one bitfield")
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`Filter.AccountMeshDataFlags` must set at least one bitfield", "field", "Filter.AccountMeshDataFlags")
 	}
 
 	// Read the filter flags
@@ -168,7 +252,9 @@ func (s MeshService) AccountMeshDataQuery(
 	// Gather transaction data
 	addr, err := types.StringToAddress(in.Filter.AccountId.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Filter.AccountId.Address `%s`: %w", in.Filter.AccountId.Address, err)
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse Filter.AccountId.Address `%s`: %v", in.Filter.AccountId.Address, err),
+			"field", "Filter.AccountId.Address")
 	}
 	res := &pb.AccountMeshDataQueryResponse{}
 	if filterTx {
@@ -295,7 +381,7 @@ func (s MeshService) readLayer(
 	if len(missing) != 0 {
 		ctxzap.Error(ctx, "could not find transactions from layer",
 			zap.String("missing", fmt.Sprint(missing)), layerID.Field().Zap())
-		return pbLayer, status.Errorf(codes.Internal, "error retrieving tx data")
+		return pbLayer, apiError(codes.Internal, ReasonInternal, "error retrieving tx data")
 	}
 
 	pbTxs := make([]*pb.Transaction, 0, len(mtxs))
@@ -366,7 +452,7 @@ func (s MeshService) LayersQuery(ctx context.Context, in *pb.LayersQueryRequest)
 		// internal errors.
 		if layer == nil || err != nil {
 			ctxzap.Error(ctx, "error retrieving layer data", zap.Error(err))
-			return nil, status.Errorf(codes.Internal, "error retrieving layer data")
+			return nil, apiError(codes.Internal, ReasonInternal, "error retrieving layer data")
 		}
 
 		pbLayer, err := s.readLayer(ctx, l, layerStatus)
@@ -386,17 +472,21 @@ func (s MeshService) AccountMeshDataStream(
 	stream pb.MeshService_AccountMeshDataStreamServer,
 ) error {
 	if in.Filter == nil {
-		return status.Errorf(codes.InvalidArgument, "`Filter` must be provided")
+		return apiError(codes.InvalidArgument, ReasonMissingArgument, "`Filter` must be provided", "field", "Filter")
 	}
 	if in.Filter.AccountId == nil {
-		return status.Errorf(codes.InvalidArgument, "`Filter.AccountId` must be provided")
+		return apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Filter.AccountId` must be provided", "field", "Filter.AccountId")
 	}
 	if in.Filter.AccountMeshDataFlags == uint32(pb.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_UNSPECIFIED) {
-		return status.Errorf(codes.InvalidArgument, "`Filter.AccountMeshDataFlags` must set at least one bitfield")
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`Filter.AccountMeshDataFlags` must set at least one bitfield", "field", "Filter.AccountMeshDataFlags")
 	}
 	addr, err := types.StringToAddress(in.Filter.AccountId.Address)
 	if err != nil {
-		return fmt.Errorf("invalid in.Filter.AccountId.Address `%s`: %w", in.Filter.AccountId.Address, err)
+		return apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("invalid in.Filter.AccountId.Address `%s`: %v", in.Filter.AccountId.Address, err),
+			"field", "Filter.AccountId.Address")
 	}
 
 	// Read the filter flags
@@ -623,3 +713,209 @@ func (s MeshService) MalfeasanceStream(
 		}
 	}
 }
+
+func toMeshReorg(reorg events.Reorg) MeshReorg {
+	rst := MeshReorg{
+		Revert:       reorg.Revert.Uint32(),
+		Depth:        reorg.Depth,
+		Layers:       make([]MeshReorgLayer, 0, len(reorg.Layers)),
+		Transactions: make([]MeshReorgTransaction, 0, len(reorg.Transactions)),
+	}
+	encodeBlock := func(bid types.BlockID) string {
+		if bid.IsEmpty() {
+			return ""
+		}
+		return hex.EncodeToString(bid[:])
+	}
+	for _, layer := range reorg.Layers {
+		rst.Layers = append(rst.Layers, MeshReorgLayer{
+			Layer:   layer.Layer.Uint32(),
+			Old:     encodeBlock(layer.Old),
+			New:     encodeBlock(layer.New),
+			Applied: layer.Applied,
+		})
+	}
+	for _, tx := range reorg.Transactions {
+		encoded := MeshReorgTransaction{
+			ID:    hex.EncodeToString(tx.ID[:]),
+			Layer: tx.Layer.Uint32(),
+		}
+		switch tx.State {
+		case types.APPLIED:
+			encoded.State = "applied"
+		case types.MEMPOOL:
+			encoded.State = "mempool"
+		default:
+			encoded.State = "pending"
+		}
+		rst.Transactions = append(rst.Transactions, encoded)
+	}
+	return rst
+}
+
+func (s MeshService) handleReorgs(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	sub, err := events.Subscribe[events.Reorg]()
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.Internal, ReasonInternal, err.Error()))
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Full():
+			return
+		case reorg := <-sub.Out():
+			if err := enc.Encode(toMeshReorg(reorg)); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// MalfeasanceEvidence returns the decoded malfeasance proof of the smesher.
+func (s MeshService) MalfeasanceEvidence(id types.NodeID) (*MalfeasanceEvidence, error) {
+	proof, err := s.cdb.GetMalfeasanceProof(id)
+	if errors.Is(err, sql.ErrNotFound) {
+		return nil, apiError(codes.NotFound, ReasonNotFound, fmt.Sprintf("no malfeasance proof for %s", id))
+	} else if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return toMalfeasanceEvidence(id, proof), nil
+}
+
+func (s MeshService) handleMalfeasanceProof(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	parsed, err := hex.DecodeString(r.URL.Query().Get("smesher"))
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("parse smesher: %s", err)))
+		return
+	}
+	if l := len(parsed); l != types.NodeIDSize {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("invalid smesher id length (%d), expected (%d)", l, types.NodeIDSize)))
+		return
+	}
+	rst, err := s.MalfeasanceEvidence(types.BytesToNodeID(parsed))
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (s MeshService) handleMalfeasanceStream(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	sub, err := events.Subscribe[events.EventMalfeasance]()
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.Internal, ReasonInternal, err.Error()))
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	flush()
+	// proofs reported while the known ones are streamed may be sent twice
+	if err := s.cdb.IterateMalfeasanceProofs(func(id types.NodeID, proof *types.MalfeasanceProof) error {
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(toMalfeasanceEvidence(id, proof)); err != nil {
+			return err
+		}
+		flush()
+		return nil
+	}); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Full():
+			return
+		case ev := <-sub.Out():
+			if err := enc.Encode(toMalfeasanceEvidence(ev.Smesher, ev.Proof)); err != nil {
+				return
+			}
+			flush()
+		}
+	}
+}
+
+func toMalfeasanceEvidence(id types.NodeID, proof *types.MalfeasanceProof) *MalfeasanceEvidence {
+	rst := &MalfeasanceEvidence{
+		Smesher:  hex.EncodeToString(id.Bytes()),
+		Type:     proof.Proof.Type,
+		Kind:     "unknown",
+		Version:  proof.Proof.Version,
+		Layer:    proof.Layer.Uint32(),
+		Epoch:    proof.Layer.GetEpoch().Uint32(),
+		Received: proof.Received(),
+	}
+	if pt, ok := types.GetMalfeasanceProofType(proof.Proof.Type); ok {
+		rst.Kind = pt.Name
+	}
+	if proof.Proof.Data != nil {
+		rst.Info = proof.Proof.Data.Info()
+	}
+	if encoded, err := codec.Encode(proof); err == nil {
+		rst.Proof = hex.EncodeToString(encoded)
+	}
+	message := func(smesher types.NodeID, signature types.EdSignature, hash types.Hash32) MalfeasanceMessage {
+		return MalfeasanceMessage{
+			Smesher:   hex.EncodeToString(smesher.Bytes()),
+			Signature: hex.EncodeToString(signature.Bytes()),
+			Hash:      hex.EncodeToString(hash.Bytes()),
+		}
+	}
+	switch data := proof.Proof.Data.(type) {
+	case *types.AtxProof:
+		for _, msg := range data.Messages {
+			m := message(msg.SmesherID, msg.Signature, msg.InnerMsg.MsgHash)
+			m.Epoch = msg.InnerMsg.PublishEpoch.Uint32()
+			rst.Messages = append(rst.Messages, m)
+		}
+	case *types.BallotProof:
+		for _, msg := range data.Messages {
+			m := message(msg.SmesherID, msg.Signature, msg.InnerMsg.MsgHash)
+			m.Layer = msg.InnerMsg.Layer.Uint32()
+			m.Epoch = msg.InnerMsg.Layer.GetEpoch().Uint32()
+			rst.Messages = append(rst.Messages, m)
+		}
+	case *types.HareProof:
+		for _, msg := range data.Messages {
+			m := message(msg.SmesherID, msg.Signature, msg.InnerMsg.MsgHash)
+			m.Layer = msg.InnerMsg.Layer.Uint32()
+			m.Epoch = msg.InnerMsg.Layer.GetEpoch().Uint32()
+			m.Round = msg.InnerMsg.Round
+			rst.Messages = append(rst.Messages, m)
+		}
+	case *types.InvalidPostIndexProof:
+		data.Atx.Initialize()
+		m := message(data.Atx.SmesherID, data.Atx.Signature, data.Atx.ID().Hash32())
+		m.Epoch = data.Atx.PublishEpoch.Uint32()
+		rst.Messages = append(rst.Messages, m)
+		idx := data.InvalidIdx
+		rst.InvalidIndex = &idx
+	}
+	return rst
+}
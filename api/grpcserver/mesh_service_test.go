@@ -1,9 +1,13 @@
 package grpcserver
 
 import (
+	"bufio"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"testing"
 	"time"
 
@@ -358,3 +362,189 @@ func TestReadLayer(t *testing.T) {
 	_, err = srv.readLayer(ctx, layer, pb.Layer_LAYER_STATUS_UNSPECIFIED)
 	require.NoError(t, err)
 }
+
+func TestMeshService_ReorgsStream(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	db := sql.InMemory()
+	srv := NewMeshService(
+		datastore.NewCachedDB(db, logtest.New(t)),
+		nil,
+		nil,
+		nil,
+		layersPerEpoch,
+		types.Hash20{},
+		layerDuration,
+		layerAvgSize,
+		txsPerProposal,
+	)
+	cfg, cleanup := launchJsonServer(t, srv)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://%s%s", cfg.JSONListener, MeshReorgsStreamPath), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	old, replaced := types.RandomBlockID(), types.RandomBlockID()
+	applied, dropped := types.RandomTransactionID(), types.RandomTransactionID()
+	events.ReportReorg(events.Reorg{
+		Revert: 9,
+		Depth:  2,
+		Layers: []events.ReorgLayer{
+			{Layer: 10, Old: old, New: replaced, Applied: true},
+			{Layer: 11, Applied: true},
+		},
+		Transactions: []events.ReorgTransaction{
+			{ID: applied, State: types.APPLIED, Layer: 10},
+			{ID: dropped, State: types.MEMPOOL},
+		},
+	})
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan(), scanner.Err())
+	var reorg MeshReorg
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &reorg))
+	require.Equal(t, MeshReorg{
+		Revert: 9,
+		Depth:  2,
+		Layers: []MeshReorgLayer{
+			{Layer: 10, Old: hex.EncodeToString(old[:]), New: hex.EncodeToString(replaced[:]), Applied: true},
+			{Layer: 11, Applied: true},
+		},
+		Transactions: []MeshReorgTransaction{
+			{ID: hex.EncodeToString(applied[:]), State: "applied", Layer: 10},
+			{ID: hex.EncodeToString(dropped[:]), State: "mempool"},
+		},
+	}, reorg)
+}
+
+func TestMeshService_MalfeasanceEvidence(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	db := sql.InMemory()
+	srv := NewMeshService(
+		datastore.NewCachedDB(db, logtest.New(t)),
+		nil,
+		nil,
+		nil,
+		layersPerEpoch,
+		types.Hash20{},
+		layerDuration,
+		layerAvgSize,
+		txsPerProposal,
+	)
+	cfg, cleanup := launchJsonServer(t, srv)
+	t.Cleanup(cleanup)
+
+	get := func(t *testing.T, smesher string) (*http.Response, []byte) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?smesher=%s", cfg.JSONListener, MalfeasanceProofPath, smesher))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	t.Run("invalid smesher", func(t *testing.T) {
+		resp, _ := get(t, "0123456789abcdef")
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("not found", func(t *testing.T) {
+		id := types.RandomNodeID()
+		resp, _ := get(t, hex.EncodeToString(id.Bytes()))
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("hare", func(t *testing.T) {
+		id, proof := HareMalfeasance(t, db)
+		resp, body := get(t, hex.EncodeToString(id.Bytes()))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var evidence MalfeasanceEvidence
+		require.NoError(t, json.Unmarshal(body, &evidence))
+		require.Equal(t, hex.EncodeToString(id.Bytes()), evidence.Smesher)
+		require.Equal(t, types.HareEquivocation, evidence.Type)
+		require.Equal(t, "hare equivocation", evidence.Kind)
+		require.EqualValues(t, layer, evidence.Layer)
+		require.Equal(t, types.LayerID(layer).GetEpoch().Uint32(), evidence.Epoch)
+		require.False(t, evidence.Received.IsZero())
+		require.Equal(t, proof.Proof.Data.Info(), evidence.Info)
+		require.Nil(t, evidence.InvalidIndex)
+		hp := proof.Proof.Data.(*types.HareProof)
+		require.Len(t, evidence.Messages, 2)
+		for i, msg := range evidence.Messages {
+			require.Equal(t, MalfeasanceMessage{
+				Smesher:   hex.EncodeToString(id.Bytes()),
+				Signature: hex.EncodeToString(hp.Messages[i].Signature.Bytes()),
+				Hash:      hex.EncodeToString(hp.Messages[i].InnerMsg.MsgHash.Bytes()),
+				Epoch:     types.LayerID(layer).GetEpoch().Uint32(),
+				Layer:     layer,
+				Round:     3,
+			}, msg)
+		}
+		encoded, err := hex.DecodeString(evidence.Proof)
+		require.NoError(t, err)
+		var got types.MalfeasanceProof
+		require.NoError(t, codec.Decode(encoded, &got))
+		require.Equal(t, *proof, got)
+	})
+}
+
+func TestMeshService_MalfeasanceEvidenceStream(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	db := sql.InMemory()
+	srv := NewMeshService(
+		datastore.NewCachedDB(db, logtest.New(t)),
+		nil,
+		nil,
+		nil,
+		layersPerEpoch,
+		types.Hash20{},
+		layerDuration,
+		layerAvgSize,
+		txsPerProposal,
+	)
+	cfg, cleanup := launchJsonServer(t, srv)
+	t.Cleanup(cleanup)
+
+	known, _ := AtxMalfeasance(t, db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://%s%s", cfg.JSONListener, MalfeasanceStreamPath), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<20)
+	require.True(t, scanner.Scan(), scanner.Err())
+	var evidence MalfeasanceEvidence
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &evidence))
+	require.Equal(t, hex.EncodeToString(known.Bytes()), evidence.Smesher)
+	require.Equal(t, "multiple atxs", evidence.Kind)
+	require.Len(t, evidence.Messages, 2)
+	require.EqualValues(t, epoch, evidence.Messages[0].Epoch)
+
+	id, proof := BallotMalfeasance(t, db)
+	proof.SetReceived(time.Now())
+	events.ReportMalfeasance(id, proof)
+	require.True(t, scanner.Scan(), scanner.Err())
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &evidence))
+	require.Equal(t, hex.EncodeToString(id.Bytes()), evidence.Smesher)
+	require.Equal(t, "multiple ballots", evidence.Kind)
+	require.True(t, proof.Received().Equal(evidence.Received))
+	require.Len(t, evidence.Messages, 2)
+	require.EqualValues(t, layer, evidence.Messages[0].Layer)
+}
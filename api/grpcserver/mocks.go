@@ -17,10 +17,16 @@ import (
 	network "github.com/libp2p/go-libp2p/core/network"
 	multiaddr "github.com/multiformats/go-multiaddr"
 	activation "github.com/spacemeshos/go-spacemesh/activation"
+	beacon "github.com/spacemeshos/go-spacemesh/beacon"
+	checkpoint "github.com/spacemeshos/go-spacemesh/checkpoint"
 	types "github.com/spacemeshos/go-spacemesh/common/types"
+	miner "github.com/spacemeshos/go-spacemesh/miner"
 	p2p "github.com/spacemeshos/go-spacemesh/p2p"
 	signing "github.com/spacemeshos/go-spacemesh/signing"
+	sql "github.com/spacemeshos/go-spacemesh/sql"
+	activecandidates "github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
 	system "github.com/spacemeshos/go-spacemesh/system"
+	tortoise "github.com/spacemeshos/go-spacemesh/tortoise"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -1111,6 +1117,45 @@ func (c *MockpostSupervisorConfigCall) DoAndReturn(f func() activation.PostConfi
 	return c
 }
 
+// PreviewResize mocks base method.
+func (m *MockpostSupervisor) PreviewResize(numUnits uint32) (*activation.ResizePreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewResize", numUnits)
+	ret0, _ := ret[0].(*activation.ResizePreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewResize indicates an expected call of PreviewResize.
+func (mr *MockpostSupervisorMockRecorder) PreviewResize(numUnits any) *MockpostSupervisorPreviewResizeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewResize", reflect.TypeOf((*MockpostSupervisor)(nil).PreviewResize), numUnits)
+	return &MockpostSupervisorPreviewResizeCall{Call: call}
+}
+
+// MockpostSupervisorPreviewResizeCall wrap *gomock.Call
+type MockpostSupervisorPreviewResizeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpostSupervisorPreviewResizeCall) Return(arg0 *activation.ResizePreview, arg1 error) *MockpostSupervisorPreviewResizeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpostSupervisorPreviewResizeCall) Do(f func(uint32) (*activation.ResizePreview, error)) *MockpostSupervisorPreviewResizeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpostSupervisorPreviewResizeCall) DoAndReturn(f func(uint32) (*activation.ResizePreview, error)) *MockpostSupervisorPreviewResizeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Providers mocks base method.
 func (m *MockpostSupervisor) Providers() ([]activation.PostSetupProvider, error) {
 	m.ctrl.T.Helper()
@@ -1150,6 +1195,45 @@ func (c *MockpostSupervisorProvidersCall) DoAndReturn(f func() ([]activation.Pos
 	return c
 }
 
+// Resize mocks base method.
+func (m *MockpostSupervisor) Resize(numUnits uint32) (*activation.ResizePreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resize", numUnits)
+	ret0, _ := ret[0].(*activation.ResizePreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resize indicates an expected call of Resize.
+func (mr *MockpostSupervisorMockRecorder) Resize(numUnits any) *MockpostSupervisorResizeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resize", reflect.TypeOf((*MockpostSupervisor)(nil).Resize), numUnits)
+	return &MockpostSupervisorResizeCall{Call: call}
+}
+
+// MockpostSupervisorResizeCall wrap *gomock.Call
+type MockpostSupervisorResizeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpostSupervisorResizeCall) Return(arg0 *activation.ResizePreview, arg1 error) *MockpostSupervisorResizeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpostSupervisorResizeCall) Do(f func(uint32) (*activation.ResizePreview, error)) *MockpostSupervisorResizeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpostSupervisorResizeCall) DoAndReturn(f func(uint32) (*activation.ResizePreview, error)) *MockpostSupervisorResizeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Start mocks base method.
 func (m *MockpostSupervisor) Start(opts activation.PostSetupOpts, sig *signing.EdSigner) error {
 	m.ctrl.T.Helper()
@@ -1424,6 +1508,145 @@ func (c *MockpeersGetPeersCall) DoAndReturn(f func() []p2p.Peer) *MockpeersGetPe
 	return c
 }
 
+// MockcheckpointScheduler is a mock of checkpointScheduler interface.
+type MockcheckpointScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockcheckpointSchedulerMockRecorder
+}
+
+// MockcheckpointSchedulerMockRecorder is the mock recorder for MockcheckpointScheduler.
+type MockcheckpointSchedulerMockRecorder struct {
+	mock *MockcheckpointScheduler
+}
+
+// NewMockcheckpointScheduler creates a new mock instance.
+func NewMockcheckpointScheduler(ctrl *gomock.Controller) *MockcheckpointScheduler {
+	mock := &MockcheckpointScheduler{ctrl: ctrl}
+	mock.recorder = &MockcheckpointSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcheckpointScheduler) EXPECT() *MockcheckpointSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Generate mocks base method.
+func (m *MockcheckpointScheduler) Generate(snapshot types.LayerID, numAtxs int) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Generate", snapshot, numAtxs)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Generate indicates an expected call of Generate.
+func (mr *MockcheckpointSchedulerMockRecorder) Generate(snapshot, numAtxs any) *MockcheckpointSchedulerGenerateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockcheckpointScheduler)(nil).Generate), snapshot, numAtxs)
+	return &MockcheckpointSchedulerGenerateCall{Call: call}
+}
+
+// MockcheckpointSchedulerGenerateCall wrap *gomock.Call
+type MockcheckpointSchedulerGenerateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockcheckpointSchedulerGenerateCall) Return(arg0 uint64, arg1 error) *MockcheckpointSchedulerGenerateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockcheckpointSchedulerGenerateCall) Do(f func(types.LayerID, int) (uint64, error)) *MockcheckpointSchedulerGenerateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockcheckpointSchedulerGenerateCall) DoAndReturn(f func(types.LayerID, int) (uint64, error)) *MockcheckpointSchedulerGenerateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Jobs mocks base method.
+func (m *MockcheckpointScheduler) Jobs() []checkpoint.Progress {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Jobs")
+	ret0, _ := ret[0].([]checkpoint.Progress)
+	return ret0
+}
+
+// Jobs indicates an expected call of Jobs.
+func (mr *MockcheckpointSchedulerMockRecorder) Jobs() *MockcheckpointSchedulerJobsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Jobs", reflect.TypeOf((*MockcheckpointScheduler)(nil).Jobs))
+	return &MockcheckpointSchedulerJobsCall{Call: call}
+}
+
+// MockcheckpointSchedulerJobsCall wrap *gomock.Call
+type MockcheckpointSchedulerJobsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockcheckpointSchedulerJobsCall) Return(arg0 []checkpoint.Progress) *MockcheckpointSchedulerJobsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockcheckpointSchedulerJobsCall) Do(f func() []checkpoint.Progress) *MockcheckpointSchedulerJobsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockcheckpointSchedulerJobsCall) DoAndReturn(f func() []checkpoint.Progress) *MockcheckpointSchedulerJobsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Progress mocks base method.
+func (m *MockcheckpointScheduler) Progress(id uint64) (checkpoint.Progress, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Progress", id)
+	ret0, _ := ret[0].(checkpoint.Progress)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Progress indicates an expected call of Progress.
+func (mr *MockcheckpointSchedulerMockRecorder) Progress(id any) *MockcheckpointSchedulerProgressCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Progress", reflect.TypeOf((*MockcheckpointScheduler)(nil).Progress), id)
+	return &MockcheckpointSchedulerProgressCall{Call: call}
+}
+
+// MockcheckpointSchedulerProgressCall wrap *gomock.Call
+type MockcheckpointSchedulerProgressCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockcheckpointSchedulerProgressCall) Return(arg0 checkpoint.Progress, arg1 bool) *MockcheckpointSchedulerProgressCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockcheckpointSchedulerProgressCall) Do(f func(uint64) (checkpoint.Progress, bool)) *MockcheckpointSchedulerProgressCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockcheckpointSchedulerProgressCall) DoAndReturn(f func(uint64) (checkpoint.Progress, bool)) *MockcheckpointSchedulerProgressCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockgenesisTimeAPI is a mock of genesisTimeAPI interface.
 type MockgenesisTimeAPI struct {
 	ctrl     *gomock.Controller
@@ -1916,3 +2139,1310 @@ func (c *MockoracleActiveSetCall) DoAndReturn(f func(context.Context, types.Epoc
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// MockclockOffset is a mock of clockOffset interface.
+type MockclockOffset struct {
+	ctrl     *gomock.Controller
+	recorder *MockclockOffsetMockRecorder
+}
+
+// MockclockOffsetMockRecorder is the mock recorder for MockclockOffset.
+type MockclockOffsetMockRecorder struct {
+	mock *MockclockOffset
+}
+
+// NewMockclockOffset creates a new mock instance.
+func NewMockclockOffset(ctrl *gomock.Controller) *MockclockOffset {
+	mock := &MockclockOffset{ctrl: ctrl}
+	mock.recorder = &MockclockOffsetMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockclockOffset) EXPECT() *MockclockOffsetMockRecorder {
+	return m.recorder
+}
+
+// LastOffset mocks base method.
+func (m *MockclockOffset) LastOffset() (time.Duration, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastOffset")
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// LastOffset indicates an expected call of LastOffset.
+func (mr *MockclockOffsetMockRecorder) LastOffset() *MockclockOffsetLastOffsetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastOffset", reflect.TypeOf((*MockclockOffset)(nil).LastOffset))
+	return &MockclockOffsetLastOffsetCall{Call: call}
+}
+
+// MockclockOffsetLastOffsetCall wrap *gomock.Call
+type MockclockOffsetLastOffsetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockclockOffsetLastOffsetCall) Return(arg0 time.Duration, arg1 bool) *MockclockOffsetLastOffsetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockclockOffsetLastOffsetCall) Do(f func() (time.Duration, bool)) *MockclockOffsetLastOffsetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockclockOffsetLastOffsetCall) DoAndReturn(f func() (time.Duration, bool)) *MockclockOffsetLastOffsetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MaxClockOffset mocks base method.
+func (m *MockclockOffset) MaxClockOffset() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxClockOffset")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// MaxClockOffset indicates an expected call of MaxClockOffset.
+func (mr *MockclockOffsetMockRecorder) MaxClockOffset() *MockclockOffsetMaxClockOffsetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxClockOffset", reflect.TypeOf((*MockclockOffset)(nil).MaxClockOffset))
+	return &MockclockOffsetMaxClockOffsetCall{Call: call}
+}
+
+// MockclockOffsetMaxClockOffsetCall wrap *gomock.Call
+type MockclockOffsetMaxClockOffsetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockclockOffsetMaxClockOffsetCall) Return(arg0 time.Duration) *MockclockOffsetMaxClockOffsetCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockclockOffsetMaxClockOffsetCall) Do(f func() time.Duration) *MockclockOffsetMaxClockOffsetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockclockOffsetMaxClockOffsetCall) DoAndReturn(f func() time.Duration) *MockclockOffsetMaxClockOffsetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MocktimeSource is a mock of timeSource interface.
+type MocktimeSource struct {
+	ctrl     *gomock.Controller
+	recorder *MocktimeSourceMockRecorder
+}
+
+// MocktimeSourceMockRecorder is the mock recorder for MocktimeSource.
+type MocktimeSourceMockRecorder struct {
+	mock *MocktimeSource
+}
+
+// NewMocktimeSource creates a new mock instance.
+func NewMocktimeSource(ctrl *gomock.Controller) *MocktimeSource {
+	mock := &MocktimeSource{ctrl: ctrl}
+	mock.recorder = &MocktimeSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktimeSource) EXPECT() *MocktimeSourceMockRecorder {
+	return m.recorder
+}
+
+// Advance mocks base method.
+func (m *MocktimeSource) Advance(arg0 time.Duration) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Advance", arg0)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Advance indicates an expected call of Advance.
+func (mr *MocktimeSourceMockRecorder) Advance(arg0 any) *MocktimeSourceAdvanceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Advance", reflect.TypeOf((*MocktimeSource)(nil).Advance), arg0)
+	return &MocktimeSourceAdvanceCall{Call: call}
+}
+
+// MocktimeSourceAdvanceCall wrap *gomock.Call
+type MocktimeSourceAdvanceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktimeSourceAdvanceCall) Return(arg0 time.Time, arg1 error) *MocktimeSourceAdvanceCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktimeSourceAdvanceCall) Do(f func(time.Duration) (time.Time, error)) *MocktimeSourceAdvanceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktimeSourceAdvanceCall) DoAndReturn(f func(time.Duration) (time.Time, error)) *MocktimeSourceAdvanceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Mode mocks base method.
+func (m *MocktimeSource) Mode() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mode")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Mode indicates an expected call of Mode.
+func (mr *MocktimeSourceMockRecorder) Mode() *MocktimeSourceModeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mode", reflect.TypeOf((*MocktimeSource)(nil).Mode))
+	return &MocktimeSourceModeCall{Call: call}
+}
+
+// MocktimeSourceModeCall wrap *gomock.Call
+type MocktimeSourceModeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktimeSourceModeCall) Return(arg0 string) *MocktimeSourceModeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktimeSourceModeCall) Do(f func() string) *MocktimeSourceModeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktimeSourceModeCall) DoAndReturn(f func() string) *MocktimeSourceModeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Now mocks base method.
+func (m *MocktimeSource) Now() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Now")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Now indicates an expected call of Now.
+func (mr *MocktimeSourceMockRecorder) Now() *MocktimeSourceNowCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MocktimeSource)(nil).Now))
+	return &MocktimeSourceNowCall{Call: call}
+}
+
+// MocktimeSourceNowCall wrap *gomock.Call
+type MocktimeSourceNowCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktimeSourceNowCall) Return(arg0 time.Time) *MocktimeSourceNowCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktimeSourceNowCall) Do(f func() time.Time) *MocktimeSourceNowCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktimeSourceNowCall) DoAndReturn(f func() time.Time) *MocktimeSourceNowCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MocklayerClock is a mock of layerClock interface.
+type MocklayerClock struct {
+	ctrl     *gomock.Controller
+	recorder *MocklayerClockMockRecorder
+}
+
+// MocklayerClockMockRecorder is the mock recorder for MocklayerClock.
+type MocklayerClockMockRecorder struct {
+	mock *MocklayerClock
+}
+
+// NewMocklayerClock creates a new mock instance.
+func NewMocklayerClock(ctrl *gomock.Controller) *MocklayerClock {
+	mock := &MocklayerClock{ctrl: ctrl}
+	mock.recorder = &MocklayerClockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklayerClock) EXPECT() *MocklayerClockMockRecorder {
+	return m.recorder
+}
+
+// CurrentLayer mocks base method.
+func (m *MocklayerClock) CurrentLayer() types.LayerID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentLayer")
+	ret0, _ := ret[0].(types.LayerID)
+	return ret0
+}
+
+// CurrentLayer indicates an expected call of CurrentLayer.
+func (mr *MocklayerClockMockRecorder) CurrentLayer() *MocklayerClockCurrentLayerCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentLayer", reflect.TypeOf((*MocklayerClock)(nil).CurrentLayer))
+	return &MocklayerClockCurrentLayerCall{Call: call}
+}
+
+// MocklayerClockCurrentLayerCall wrap *gomock.Call
+type MocklayerClockCurrentLayerCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocklayerClockCurrentLayerCall) Return(arg0 types.LayerID) *MocklayerClockCurrentLayerCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocklayerClockCurrentLayerCall) Do(f func() types.LayerID) *MocklayerClockCurrentLayerCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocklayerClockCurrentLayerCall) DoAndReturn(f func() types.LayerID) *MocklayerClockCurrentLayerCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// LayerToTime mocks base method.
+func (m *MocklayerClock) LayerToTime(arg0 types.LayerID) time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LayerToTime", arg0)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// LayerToTime indicates an expected call of LayerToTime.
+func (mr *MocklayerClockMockRecorder) LayerToTime(arg0 any) *MocklayerClockLayerToTimeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LayerToTime", reflect.TypeOf((*MocklayerClock)(nil).LayerToTime), arg0)
+	return &MocklayerClockLayerToTimeCall{Call: call}
+}
+
+// MocklayerClockLayerToTimeCall wrap *gomock.Call
+type MocklayerClockLayerToTimeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocklayerClockLayerToTimeCall) Return(arg0 time.Time) *MocklayerClockLayerToTimeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocklayerClockLayerToTimeCall) Do(f func(types.LayerID) time.Time) *MocklayerClockLayerToTimeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocklayerClockLayerToTimeCall) DoAndReturn(f func(types.LayerID) time.Time) *MocklayerClockLayerToTimeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockpostConnections is a mock of postConnections interface.
+type MockpostConnections struct {
+	ctrl     *gomock.Controller
+	recorder *MockpostConnectionsMockRecorder
+}
+
+// MockpostConnectionsMockRecorder is the mock recorder for MockpostConnections.
+type MockpostConnectionsMockRecorder struct {
+	mock *MockpostConnections
+}
+
+// NewMockpostConnections creates a new mock instance.
+func NewMockpostConnections(ctrl *gomock.Controller) *MockpostConnections {
+	mock := &MockpostConnections{ctrl: ctrl}
+	mock.recorder = &MockpostConnectionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpostConnections) EXPECT() *MockpostConnectionsMockRecorder {
+	return m.recorder
+}
+
+// Client mocks base method.
+func (m *MockpostConnections) Client(nodeId types.NodeID) (activation.PostClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Client", nodeId)
+	ret0, _ := ret[0].(activation.PostClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Client indicates an expected call of Client.
+func (mr *MockpostConnectionsMockRecorder) Client(nodeId any) *MockpostConnectionsClientCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Client", reflect.TypeOf((*MockpostConnections)(nil).Client), nodeId)
+	return &MockpostConnectionsClientCall{Call: call}
+}
+
+// MockpostConnectionsClientCall wrap *gomock.Call
+type MockpostConnectionsClientCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpostConnectionsClientCall) Return(arg0 activation.PostClient, arg1 error) *MockpostConnectionsClientCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpostConnectionsClientCall) Do(f func(types.NodeID) (activation.PostClient, error)) *MockpostConnectionsClientCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpostConnectionsClientCall) DoAndReturn(f func(types.NodeID) (activation.PostClient, error)) *MockpostConnectionsClientCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockpoetPinger is a mock of poetPinger interface.
+type MockpoetPinger struct {
+	ctrl     *gomock.Controller
+	recorder *MockpoetPingerMockRecorder
+}
+
+// MockpoetPingerMockRecorder is the mock recorder for MockpoetPinger.
+type MockpoetPingerMockRecorder struct {
+	mock *MockpoetPinger
+}
+
+// NewMockpoetPinger creates a new mock instance.
+func NewMockpoetPinger(ctrl *gomock.Controller) *MockpoetPinger {
+	mock := &MockpoetPinger{ctrl: ctrl}
+	mock.recorder = &MockpoetPingerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpoetPinger) EXPECT() *MockpoetPingerMockRecorder {
+	return m.recorder
+}
+
+// Address mocks base method.
+func (m *MockpoetPinger) Address() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Address")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Address indicates an expected call of Address.
+func (mr *MockpoetPingerMockRecorder) Address() *MockpoetPingerAddressCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockpoetPinger)(nil).Address))
+	return &MockpoetPingerAddressCall{Call: call}
+}
+
+// MockpoetPingerAddressCall wrap *gomock.Call
+type MockpoetPingerAddressCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpoetPingerAddressCall) Return(arg0 string) *MockpoetPingerAddressCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpoetPingerAddressCall) Do(f func() string) *MockpoetPingerAddressCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpoetPingerAddressCall) DoAndReturn(f func() string) *MockpoetPingerAddressCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PowParams mocks base method.
+func (m *MockpoetPinger) PowParams(ctx context.Context) (*activation.PoetPowParams, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PowParams", ctx)
+	ret0, _ := ret[0].(*activation.PoetPowParams)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PowParams indicates an expected call of PowParams.
+func (mr *MockpoetPingerMockRecorder) PowParams(ctx any) *MockpoetPingerPowParamsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PowParams", reflect.TypeOf((*MockpoetPinger)(nil).PowParams), ctx)
+	return &MockpoetPingerPowParamsCall{Call: call}
+}
+
+// MockpoetPingerPowParamsCall wrap *gomock.Call
+type MockpoetPingerPowParamsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpoetPingerPowParamsCall) Return(arg0 *activation.PoetPowParams, arg1 error) *MockpoetPingerPowParamsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpoetPingerPowParamsCall) Do(f func(context.Context) (*activation.PoetPowParams, error)) *MockpoetPingerPowParamsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpoetPingerPowParamsCall) DoAndReturn(f func(context.Context) (*activation.PoetPowParams, error)) *MockpoetPingerPowParamsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockqueryableDatabase is a mock of queryableDatabase interface.
+type MockqueryableDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueryableDatabaseMockRecorder
+}
+
+// MockqueryableDatabaseMockRecorder is the mock recorder for MockqueryableDatabase.
+type MockqueryableDatabaseMockRecorder struct {
+	mock *MockqueryableDatabase
+}
+
+// NewMockqueryableDatabase creates a new mock instance.
+func NewMockqueryableDatabase(ctrl *gomock.Controller) *MockqueryableDatabase {
+	mock := &MockqueryableDatabase{ctrl: ctrl}
+	mock.recorder = &MockqueryableDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueryableDatabase) EXPECT() *MockqueryableDatabaseMockRecorder {
+	return m.recorder
+}
+
+// Tx mocks base method.
+func (m *MockqueryableDatabase) Tx(ctx context.Context) (*sql.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tx", ctx)
+	ret0, _ := ret[0].(*sql.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tx indicates an expected call of Tx.
+func (mr *MockqueryableDatabaseMockRecorder) Tx(ctx any) *MockqueryableDatabaseTxCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tx", reflect.TypeOf((*MockqueryableDatabase)(nil).Tx), ctx)
+	return &MockqueryableDatabaseTxCall{Call: call}
+}
+
+// MockqueryableDatabaseTxCall wrap *gomock.Call
+type MockqueryableDatabaseTxCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockqueryableDatabaseTxCall) Return(arg0 *sql.Tx, arg1 error) *MockqueryableDatabaseTxCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockqueryableDatabaseTxCall) Do(f func(context.Context) (*sql.Tx, error)) *MockqueryableDatabaseTxCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockqueryableDatabaseTxCall) DoAndReturn(f func(context.Context) (*sql.Tx, error)) *MockqueryableDatabaseTxCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockfallbackBeacon is a mock of fallbackBeacon interface.
+type MockfallbackBeacon struct {
+	ctrl     *gomock.Controller
+	recorder *MockfallbackBeaconMockRecorder
+}
+
+// MockfallbackBeaconMockRecorder is the mock recorder for MockfallbackBeacon.
+type MockfallbackBeaconMockRecorder struct {
+	mock *MockfallbackBeacon
+}
+
+// NewMockfallbackBeacon creates a new mock instance.
+func NewMockfallbackBeacon(ctrl *gomock.Controller) *MockfallbackBeacon {
+	mock := &MockfallbackBeacon{ctrl: ctrl}
+	mock.recorder = &MockfallbackBeaconMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockfallbackBeacon) EXPECT() *MockfallbackBeaconMockRecorder {
+	return m.recorder
+}
+
+// SubmitFallbackBeacon mocks base method.
+func (m *MockfallbackBeacon) SubmitFallbackBeacon(arg0 context.Context, arg1 *beacon.FallbackBeacon) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitFallbackBeacon", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubmitFallbackBeacon indicates an expected call of SubmitFallbackBeacon.
+func (mr *MockfallbackBeaconMockRecorder) SubmitFallbackBeacon(arg0, arg1 any) *MockfallbackBeaconSubmitFallbackBeaconCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitFallbackBeacon", reflect.TypeOf((*MockfallbackBeacon)(nil).SubmitFallbackBeacon), arg0, arg1)
+	return &MockfallbackBeaconSubmitFallbackBeaconCall{Call: call}
+}
+
+// MockfallbackBeaconSubmitFallbackBeaconCall wrap *gomock.Call
+type MockfallbackBeaconSubmitFallbackBeaconCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockfallbackBeaconSubmitFallbackBeaconCall) Return(arg0 error) *MockfallbackBeaconSubmitFallbackBeaconCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockfallbackBeaconSubmitFallbackBeaconCall) Do(f func(context.Context, *beacon.FallbackBeacon) error) *MockfallbackBeaconSubmitFallbackBeaconCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockfallbackBeaconSubmitFallbackBeaconCall) DoAndReturn(f func(context.Context, *beacon.FallbackBeacon) error) *MockfallbackBeaconSubmitFallbackBeaconCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockmalfeasanceSubmitter is a mock of malfeasanceSubmitter interface.
+type MockmalfeasanceSubmitter struct {
+	ctrl     *gomock.Controller
+	recorder *MockmalfeasanceSubmitterMockRecorder
+}
+
+// MockmalfeasanceSubmitterMockRecorder is the mock recorder for MockmalfeasanceSubmitter.
+type MockmalfeasanceSubmitterMockRecorder struct {
+	mock *MockmalfeasanceSubmitter
+}
+
+// NewMockmalfeasanceSubmitter creates a new mock instance.
+func NewMockmalfeasanceSubmitter(ctrl *gomock.Controller) *MockmalfeasanceSubmitter {
+	mock := &MockmalfeasanceSubmitter{ctrl: ctrl}
+	mock.recorder = &MockmalfeasanceSubmitterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockmalfeasanceSubmitter) EXPECT() *MockmalfeasanceSubmitterMockRecorder {
+	return m.recorder
+}
+
+// SubmitMalfeasanceProof mocks base method.
+func (m *MockmalfeasanceSubmitter) SubmitMalfeasanceProof(arg0 context.Context, arg1 *types.MalfeasanceProof) (types.NodeID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitMalfeasanceProof", arg0, arg1)
+	ret0, _ := ret[0].(types.NodeID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitMalfeasanceProof indicates an expected call of SubmitMalfeasanceProof.
+func (mr *MockmalfeasanceSubmitterMockRecorder) SubmitMalfeasanceProof(arg0, arg1 any) *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitMalfeasanceProof", reflect.TypeOf((*MockmalfeasanceSubmitter)(nil).SubmitMalfeasanceProof), arg0, arg1)
+	return &MockmalfeasanceSubmitterSubmitMalfeasanceProofCall{Call: call}
+}
+
+// MockmalfeasanceSubmitterSubmitMalfeasanceProofCall wrap *gomock.Call
+type MockmalfeasanceSubmitterSubmitMalfeasanceProofCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall) Return(arg0 types.NodeID, arg1 error) *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall) Do(f func(context.Context, *types.MalfeasanceProof) (types.NodeID, error)) *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall) DoAndReturn(f func(context.Context, *types.MalfeasanceProof) (types.NodeID, error)) *MockmalfeasanceSubmitterSubmitMalfeasanceProofCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockbeaconProtocol is a mock of beaconProtocol interface.
+type MockbeaconProtocol struct {
+	ctrl     *gomock.Controller
+	recorder *MockbeaconProtocolMockRecorder
+}
+
+// MockbeaconProtocolMockRecorder is the mock recorder for MockbeaconProtocol.
+type MockbeaconProtocolMockRecorder struct {
+	mock *MockbeaconProtocol
+}
+
+// NewMockbeaconProtocol creates a new mock instance.
+func NewMockbeaconProtocol(ctrl *gomock.Controller) *MockbeaconProtocol {
+	mock := &MockbeaconProtocol{ctrl: ctrl}
+	mock.recorder = &MockbeaconProtocolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbeaconProtocol) EXPECT() *MockbeaconProtocolMockRecorder {
+	return m.recorder
+}
+
+// InjectBeacon mocks base method.
+func (m *MockbeaconProtocol) InjectBeacon(arg0 types.EpochID, arg1 types.Beacon, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InjectBeacon", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InjectBeacon indicates an expected call of InjectBeacon.
+func (mr *MockbeaconProtocolMockRecorder) InjectBeacon(arg0, arg1, arg2 any) *MockbeaconProtocolInjectBeaconCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InjectBeacon", reflect.TypeOf((*MockbeaconProtocol)(nil).InjectBeacon), arg0, arg1, arg2)
+	return &MockbeaconProtocolInjectBeaconCall{Call: call}
+}
+
+// MockbeaconProtocolInjectBeaconCall wrap *gomock.Call
+type MockbeaconProtocolInjectBeaconCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockbeaconProtocolInjectBeaconCall) Return(arg0 error) *MockbeaconProtocolInjectBeaconCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockbeaconProtocolInjectBeaconCall) Do(f func(types.EpochID, types.Beacon, string) error) *MockbeaconProtocolInjectBeaconCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockbeaconProtocolInjectBeaconCall) DoAndReturn(f func(types.EpochID, types.Beacon, string) error) *MockbeaconProtocolInjectBeaconCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ProtocolState mocks base method.
+func (m *MockbeaconProtocol) ProtocolState(arg0 types.EpochID) (*beacon.ProtocolState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProtocolState", arg0)
+	ret0, _ := ret[0].(*beacon.ProtocolState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProtocolState indicates an expected call of ProtocolState.
+func (mr *MockbeaconProtocolMockRecorder) ProtocolState(arg0 any) *MockbeaconProtocolProtocolStateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtocolState", reflect.TypeOf((*MockbeaconProtocol)(nil).ProtocolState), arg0)
+	return &MockbeaconProtocolProtocolStateCall{Call: call}
+}
+
+// MockbeaconProtocolProtocolStateCall wrap *gomock.Call
+type MockbeaconProtocolProtocolStateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockbeaconProtocolProtocolStateCall) Return(arg0 *beacon.ProtocolState, arg1 error) *MockbeaconProtocolProtocolStateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockbeaconProtocolProtocolStateCall) Do(f func(types.EpochID) (*beacon.ProtocolState, error)) *MockbeaconProtocolProtocolStateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockbeaconProtocolProtocolStateCall) DoAndReturn(f func(types.EpochID) (*beacon.ProtocolState, error)) *MockbeaconProtocolProtocolStateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockatxQuarantine is a mock of atxQuarantine interface.
+type MockatxQuarantine struct {
+	ctrl     *gomock.Controller
+	recorder *MockatxQuarantineMockRecorder
+}
+
+// MockatxQuarantineMockRecorder is the mock recorder for MockatxQuarantine.
+type MockatxQuarantineMockRecorder struct {
+	mock *MockatxQuarantine
+}
+
+// NewMockatxQuarantine creates a new mock instance.
+func NewMockatxQuarantine(ctrl *gomock.Controller) *MockatxQuarantine {
+	mock := &MockatxQuarantine{ctrl: ctrl}
+	mock.recorder = &MockatxQuarantineMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockatxQuarantine) EXPECT() *MockatxQuarantineMockRecorder {
+	return m.recorder
+}
+
+// Quarantined mocks base method.
+func (m *MockatxQuarantine) Quarantined() []activation.QuarantinedAtx {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quarantined")
+	ret0, _ := ret[0].([]activation.QuarantinedAtx)
+	return ret0
+}
+
+// Quarantined indicates an expected call of Quarantined.
+func (mr *MockatxQuarantineMockRecorder) Quarantined() *MockatxQuarantineQuarantinedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quarantined", reflect.TypeOf((*MockatxQuarantine)(nil).Quarantined))
+	return &MockatxQuarantineQuarantinedCall{Call: call}
+}
+
+// MockatxQuarantineQuarantinedCall wrap *gomock.Call
+type MockatxQuarantineQuarantinedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockatxQuarantineQuarantinedCall) Return(arg0 []activation.QuarantinedAtx) *MockatxQuarantineQuarantinedCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockatxQuarantineQuarantinedCall) Do(f func() []activation.QuarantinedAtx) *MockatxQuarantineQuarantinedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockatxQuarantineQuarantinedCall) DoAndReturn(f func() []activation.QuarantinedAtx) *MockatxQuarantineQuarantinedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockactiveSetProjection is a mock of activeSetProjection interface.
+type MockactiveSetProjection struct {
+	ctrl     *gomock.Controller
+	recorder *MockactiveSetProjectionMockRecorder
+}
+
+// MockactiveSetProjectionMockRecorder is the mock recorder for MockactiveSetProjection.
+type MockactiveSetProjectionMockRecorder struct {
+	mock *MockactiveSetProjection
+}
+
+// NewMockactiveSetProjection creates a new mock instance.
+func NewMockactiveSetProjection(ctrl *gomock.Controller) *MockactiveSetProjection {
+	mock := &MockactiveSetProjection{ctrl: ctrl}
+	mock.recorder = &MockactiveSetProjectionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockactiveSetProjection) EXPECT() *MockactiveSetProjectionMockRecorder {
+	return m.recorder
+}
+
+// Candidate mocks base method.
+func (m *MockactiveSetProjection) Candidate(arg0 types.EpochID, arg1 types.NodeID) (*activecandidates.Candidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Candidate", arg0, arg1)
+	ret0, _ := ret[0].(*activecandidates.Candidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Candidate indicates an expected call of Candidate.
+func (mr *MockactiveSetProjectionMockRecorder) Candidate(arg0, arg1 any) *MockactiveSetProjectionCandidateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Candidate", reflect.TypeOf((*MockactiveSetProjection)(nil).Candidate), arg0, arg1)
+	return &MockactiveSetProjectionCandidateCall{Call: call}
+}
+
+// MockactiveSetProjectionCandidateCall wrap *gomock.Call
+type MockactiveSetProjectionCandidateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockactiveSetProjectionCandidateCall) Return(arg0 *activecandidates.Candidate, arg1 error) *MockactiveSetProjectionCandidateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockactiveSetProjectionCandidateCall) Do(f func(types.EpochID, types.NodeID) (*activecandidates.Candidate, error)) *MockactiveSetProjectionCandidateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockactiveSetProjectionCandidateCall) DoAndReturn(f func(types.EpochID, types.NodeID) (*activecandidates.Candidate, error)) *MockactiveSetProjectionCandidateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Deadline mocks base method.
+func (m *MockactiveSetProjection) Deadline(arg0 types.EpochID) time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deadline", arg0)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Deadline indicates an expected call of Deadline.
+func (mr *MockactiveSetProjectionMockRecorder) Deadline(arg0 any) *MockactiveSetProjectionDeadlineCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deadline", reflect.TypeOf((*MockactiveSetProjection)(nil).Deadline), arg0)
+	return &MockactiveSetProjectionDeadlineCall{Call: call}
+}
+
+// MockactiveSetProjectionDeadlineCall wrap *gomock.Call
+type MockactiveSetProjectionDeadlineCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockactiveSetProjectionDeadlineCall) Return(arg0 time.Time) *MockactiveSetProjectionDeadlineCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockactiveSetProjectionDeadlineCall) Do(f func(types.EpochID) time.Time) *MockactiveSetProjectionDeadlineCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockactiveSetProjectionDeadlineCall) DoAndReturn(f func(types.EpochID) time.Time) *MockactiveSetProjectionDeadlineCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Projection mocks base method.
+func (m *MockactiveSetProjection) Projection(arg0 types.EpochID) (activecandidates.Summary, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Projection", arg0)
+	ret0, _ := ret[0].(activecandidates.Summary)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Projection indicates an expected call of Projection.
+func (mr *MockactiveSetProjectionMockRecorder) Projection(arg0 any) *MockactiveSetProjectionProjectionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Projection", reflect.TypeOf((*MockactiveSetProjection)(nil).Projection), arg0)
+	return &MockactiveSetProjectionProjectionCall{Call: call}
+}
+
+// MockactiveSetProjectionProjectionCall wrap *gomock.Call
+type MockactiveSetProjectionProjectionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockactiveSetProjectionProjectionCall) Return(arg0 activecandidates.Summary, arg1 bool) *MockactiveSetProjectionProjectionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockactiveSetProjectionProjectionCall) Do(f func(types.EpochID) (activecandidates.Summary, bool)) *MockactiveSetProjectionProjectionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockactiveSetProjectionProjectionCall) DoAndReturn(f func(types.EpochID) (activecandidates.Summary, bool)) *MockactiveSetProjectionProjectionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Ready mocks base method.
+func (m *MockactiveSetProjection) Ready() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockactiveSetProjectionMockRecorder) Ready() *MockactiveSetProjectionReadyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockactiveSetProjection)(nil).Ready))
+	return &MockactiveSetProjectionReadyCall{Call: call}
+}
+
+// MockactiveSetProjectionReadyCall wrap *gomock.Call
+type MockactiveSetProjectionReadyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockactiveSetProjectionReadyCall) Return(arg0 bool) *MockactiveSetProjectionReadyCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockactiveSetProjectionReadyCall) Do(f func() bool) *MockactiveSetProjectionReadyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockactiveSetProjectionReadyCall) DoAndReturn(f func() bool) *MockactiveSetProjectionReadyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockeligibilityEstimator is a mock of eligibilityEstimator interface.
+type MockeligibilityEstimator struct {
+	ctrl     *gomock.Controller
+	recorder *MockeligibilityEstimatorMockRecorder
+}
+
+// MockeligibilityEstimatorMockRecorder is the mock recorder for MockeligibilityEstimator.
+type MockeligibilityEstimatorMockRecorder struct {
+	mock *MockeligibilityEstimator
+}
+
+// NewMockeligibilityEstimator creates a new mock instance.
+func NewMockeligibilityEstimator(ctrl *gomock.Controller) *MockeligibilityEstimator {
+	mock := &MockeligibilityEstimator{ctrl: ctrl}
+	mock.recorder = &MockeligibilityEstimatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockeligibilityEstimator) EXPECT() *MockeligibilityEstimatorMockRecorder {
+	return m.recorder
+}
+
+// Estimate mocks base method.
+func (m *MockeligibilityEstimator) Estimate(arg0 types.EpochID, arg1 []types.NodeID) (*miner.EpochEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Estimate", arg0, arg1)
+	ret0, _ := ret[0].(*miner.EpochEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Estimate indicates an expected call of Estimate.
+func (mr *MockeligibilityEstimatorMockRecorder) Estimate(arg0, arg1 any) *MockeligibilityEstimatorEstimateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Estimate", reflect.TypeOf((*MockeligibilityEstimator)(nil).Estimate), arg0, arg1)
+	return &MockeligibilityEstimatorEstimateCall{Call: call}
+}
+
+// MockeligibilityEstimatorEstimateCall wrap *gomock.Call
+type MockeligibilityEstimatorEstimateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockeligibilityEstimatorEstimateCall) Return(arg0 *miner.EpochEstimate, arg1 error) *MockeligibilityEstimatorEstimateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockeligibilityEstimatorEstimateCall) Do(f func(types.EpochID, []types.NodeID) (*miner.EpochEstimate, error)) *MockeligibilityEstimatorEstimateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockeligibilityEstimatorEstimateCall) DoAndReturn(f func(types.EpochID, []types.NodeID) (*miner.EpochEstimate, error)) *MockeligibilityEstimatorEstimateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockpublishForecaster is a mock of publishForecaster interface.
+type MockpublishForecaster struct {
+	ctrl     *gomock.Controller
+	recorder *MockpublishForecasterMockRecorder
+}
+
+// MockpublishForecasterMockRecorder is the mock recorder for MockpublishForecaster.
+type MockpublishForecasterMockRecorder struct {
+	mock *MockpublishForecaster
+}
+
+// NewMockpublishForecaster creates a new mock instance.
+func NewMockpublishForecaster(ctrl *gomock.Controller) *MockpublishForecaster {
+	mock := &MockpublishForecaster{ctrl: ctrl}
+	mock.recorder = &MockpublishForecasterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpublishForecaster) EXPECT() *MockpublishForecasterMockRecorder {
+	return m.recorder
+}
+
+// PublishForecast mocks base method.
+func (m *MockpublishForecaster) PublishForecast(arg0 types.NodeID) (*activation.PublishForecast, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishForecast", arg0)
+	ret0, _ := ret[0].(*activation.PublishForecast)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishForecast indicates an expected call of PublishForecast.
+func (mr *MockpublishForecasterMockRecorder) PublishForecast(arg0 any) *MockpublishForecasterPublishForecastCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishForecast", reflect.TypeOf((*MockpublishForecaster)(nil).PublishForecast), arg0)
+	return &MockpublishForecasterPublishForecastCall{Call: call}
+}
+
+// MockpublishForecasterPublishForecastCall wrap *gomock.Call
+type MockpublishForecasterPublishForecastCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockpublishForecasterPublishForecastCall) Return(arg0 *activation.PublishForecast, arg1 error) *MockpublishForecasterPublishForecastCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockpublishForecasterPublishForecastCall) Do(f func(types.NodeID) (*activation.PublishForecast, error)) *MockpublishForecasterPublishForecastCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockpublishForecasterPublishForecastCall) DoAndReturn(f func(types.NodeID) (*activation.PublishForecast, error)) *MockpublishForecasterPublishForecastCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockatxWatchdog is a mock of atxWatchdog interface.
+type MockatxWatchdog struct {
+	ctrl     *gomock.Controller
+	recorder *MockatxWatchdogMockRecorder
+}
+
+// MockatxWatchdogMockRecorder is the mock recorder for MockatxWatchdog.
+type MockatxWatchdogMockRecorder struct {
+	mock *MockatxWatchdog
+}
+
+// NewMockatxWatchdog creates a new mock instance.
+func NewMockatxWatchdog(ctrl *gomock.Controller) *MockatxWatchdog {
+	mock := &MockatxWatchdog{ctrl: ctrl}
+	mock.recorder = &MockatxWatchdogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockatxWatchdog) EXPECT() *MockatxWatchdogMockRecorder {
+	return m.recorder
+}
+
+// Status mocks base method.
+func (m *MockatxWatchdog) Status() []activation.WatchdogStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].([]activation.WatchdogStatus)
+	return ret0
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockatxWatchdogMockRecorder) Status() *MockatxWatchdogStatusCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockatxWatchdog)(nil).Status))
+	return &MockatxWatchdogStatusCall{Call: call}
+}
+
+// MockatxWatchdogStatusCall wrap *gomock.Call
+type MockatxWatchdogStatusCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockatxWatchdogStatusCall) Return(arg0 []activation.WatchdogStatus) *MockatxWatchdogStatusCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockatxWatchdogStatusCall) Do(f func() []activation.WatchdogStatus) *MockatxWatchdogStatusCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockatxWatchdogStatusCall) DoAndReturn(f func() []activation.WatchdogStatus) *MockatxWatchdogStatusCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MocktortoiseIntrospection is a mock of tortoiseIntrospection interface.
+type MocktortoiseIntrospection struct {
+	ctrl     *gomock.Controller
+	recorder *MocktortoiseIntrospectionMockRecorder
+}
+
+// MocktortoiseIntrospectionMockRecorder is the mock recorder for MocktortoiseIntrospection.
+type MocktortoiseIntrospectionMockRecorder struct {
+	mock *MocktortoiseIntrospection
+}
+
+// NewMocktortoiseIntrospection creates a new mock instance.
+func NewMocktortoiseIntrospection(ctrl *gomock.Controller) *MocktortoiseIntrospection {
+	mock := &MocktortoiseIntrospection{ctrl: ctrl}
+	mock.recorder = &MocktortoiseIntrospectionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktortoiseIntrospection) EXPECT() *MocktortoiseIntrospectionMockRecorder {
+	return m.recorder
+}
+
+// BlockOpinion mocks base method.
+func (m *MocktortoiseIntrospection) BlockOpinion(arg0 types.LayerID, arg1 types.BlockID) (tortoise.BlockOpinion, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockOpinion", arg0, arg1)
+	ret0, _ := ret[0].(tortoise.BlockOpinion)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// BlockOpinion indicates an expected call of BlockOpinion.
+func (mr *MocktortoiseIntrospectionMockRecorder) BlockOpinion(arg0, arg1 any) *MocktortoiseIntrospectionBlockOpinionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockOpinion", reflect.TypeOf((*MocktortoiseIntrospection)(nil).BlockOpinion), arg0, arg1)
+	return &MocktortoiseIntrospectionBlockOpinionCall{Call: call}
+}
+
+// MocktortoiseIntrospectionBlockOpinionCall wrap *gomock.Call
+type MocktortoiseIntrospectionBlockOpinionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktortoiseIntrospectionBlockOpinionCall) Return(arg0 tortoise.BlockOpinion, arg1 bool) *MocktortoiseIntrospectionBlockOpinionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktortoiseIntrospectionBlockOpinionCall) Do(f func(types.LayerID, types.BlockID) (tortoise.BlockOpinion, bool)) *MocktortoiseIntrospectionBlockOpinionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktortoiseIntrospectionBlockOpinionCall) DoAndReturn(f func(types.LayerID, types.BlockID) (tortoise.BlockOpinion, bool)) *MocktortoiseIntrospectionBlockOpinionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Progress mocks base method.
+func (m *MocktortoiseIntrospection) Progress(limit int) tortoise.Progress {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Progress", limit)
+	ret0, _ := ret[0].(tortoise.Progress)
+	return ret0
+}
+
+// Progress indicates an expected call of Progress.
+func (mr *MocktortoiseIntrospectionMockRecorder) Progress(limit any) *MocktortoiseIntrospectionProgressCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Progress", reflect.TypeOf((*MocktortoiseIntrospection)(nil).Progress), limit)
+	return &MocktortoiseIntrospectionProgressCall{Call: call}
+}
+
+// MocktortoiseIntrospectionProgressCall wrap *gomock.Call
+type MocktortoiseIntrospectionProgressCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocktortoiseIntrospectionProgressCall) Return(arg0 tortoise.Progress) *MocktortoiseIntrospectionProgressCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocktortoiseIntrospectionProgressCall) Do(f func(int) tortoise.Progress) *MocktortoiseIntrospectionProgressCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocktortoiseIntrospectionProgressCall) DoAndReturn(f func(int) tortoise.Progress) *MocktortoiseIntrospectionProgressCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
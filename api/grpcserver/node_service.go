@@ -2,7 +2,10 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -18,6 +21,39 @@ import (
 	"github.com/spacemeshos/go-spacemesh/events"
 )
 
+// NodeClockPath serves the offsets of the local clock from peers and ntp servers.
+const NodeClockPath = "/v1/node/clock"
+
+// NodeClockOffset is the last measured offset of the local clock from the reference clocks of the source.
+// Offset is positive if the local clock is behind.
+type NodeClockOffset struct {
+	Source      string `json:"source"`
+	Measured    bool   `json:"measured"`
+	OffsetMs    int64  `json:"offset_ms"`
+	MaxOffsetMs int64  `json:"max_offset_ms"`
+}
+
+// NodeClock is the status of the local clock.
+type NodeClock struct {
+	Time    time.Time         `json:"time"`
+	Offsets []NodeClockOffset `json:"offsets"`
+}
+
+// NodeServiceOpt modifies NodeService.
+type NodeServiceOpt func(*NodeService)
+
+// WithClockOffset adds the source of the local clock offset that is served by the NodeClockPath route.
+func WithClockOffset(source string, c clockOffset) NodeServiceOpt {
+	return func(s *NodeService) {
+		s.clocks = append(s.clocks, namedClockOffset{source: source, clockOffset: c})
+	}
+}
+
+type namedClockOffset struct {
+	source string
+	clockOffset
+}
+
 // NodeService is a grpc server that provides the NodeService, which exposes node-related
 // data such as node status, software version, errors, etc. It can also be used to start
 // the sync process, or to shut down the node.
@@ -28,6 +64,7 @@ type NodeService struct {
 	syncer      syncer
 	appVersion  string
 	appCommit   string
+	clocks      []namedClockOffset
 }
 
 // RegisterService registers this service with a grpc server instance.
@@ -36,7 +73,10 @@ func (s NodeService) RegisterService(server *grpc.Server) {
 }
 
 func (s NodeService) RegisterHandlerService(mux *runtime.ServeMux) error {
-	return pb.RegisterNodeServiceHandlerServer(context.Background(), mux, s)
+	if err := pb.RegisterNodeServiceHandlerServer(context.Background(), mux, s); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, NodeClockPath, s.handleClock)
 }
 
 // String returns the name of this service.
@@ -52,8 +92,9 @@ func NewNodeService(
 	syncer syncer,
 	appVersion string,
 	appCommit string,
+	opts ...NodeServiceOpt,
 ) *NodeService {
-	return &NodeService{
+	s := &NodeService{
 		mesh:        msh,
 		genTime:     genTime,
 		peerCounter: peers,
@@ -61,6 +102,10 @@ func NewNodeService(
 		appVersion:  appVersion,
 		appCommit:   appCommit,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Echo returns the response for an echo api request. It's used for E2E tests.
@@ -109,6 +154,29 @@ func (s NodeService) NodeInfo(context.Context, *emptypb.Empty) (*pb.NodeInfoResp
 	}, nil
 }
 
+// Clock returns the offsets of the local clock from all sources.
+func (s NodeService) Clock() *NodeClock {
+	rst := &NodeClock{
+		Time:    time.Now(),
+		Offsets: make([]NodeClockOffset, 0, len(s.clocks)),
+	}
+	for _, clock := range s.clocks {
+		offset, measured := clock.LastOffset()
+		rst.Offsets = append(rst.Offsets, NodeClockOffset{
+			Source:      clock.source,
+			Measured:    measured,
+			OffsetMs:    offset.Milliseconds(),
+			MaxOffsetMs: clock.MaxClockOffset().Milliseconds(),
+		})
+	}
+	return rst
+}
+
+func (s NodeService) handleClock(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Clock())
+}
+
 func (s NodeService) getLayers() (curLayer, latestLayer, verifiedLayer uint32) {
 	// We cannot get meaningful data from the mesh during the genesis epochs since there are no blocks in these
 	// epochs, so just return the current layer instead
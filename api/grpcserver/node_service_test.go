@@ -2,6 +2,9 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -151,3 +154,32 @@ func TestNodeService(t *testing.T) {
 	})
 	// NOTE: ErrorStream and StatusStream have comprehensive, E2E tests in cmd/node/node_test.go.
 }
+
+func TestNodeService_Clock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	peers := NewMockclockOffset(ctrl)
+	peers.EXPECT().LastOffset().Return(-1500*time.Millisecond, true)
+	peers.EXPECT().MaxClockOffset().Return(10 * time.Second)
+	ntp := NewMockclockOffset(ctrl)
+	ntp.EXPECT().LastOffset().Return(time.Duration(0), false)
+	ntp.EXPECT().MaxClockOffset().Return(3 * time.Second)
+
+	svc := NewNodeService(nil, nil, nil, nil, "v0.0.0", "cafebabe",
+		WithClockOffset("peers", peers),
+		WithClockOffset("ntp", ntp),
+	)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, NodeClockPath))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var clock NodeClock
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&clock))
+	require.WithinDuration(t, time.Now(), clock.Time, time.Minute)
+	require.Equal(t, []NodeClockOffset{
+		{Source: "peers", Measured: true, OffsetMs: -1500, MaxOffsetMs: 10000},
+		{Source: "ntp", MaxOffsetMs: 3000},
+	}, clock.Offsets)
+}
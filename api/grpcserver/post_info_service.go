@@ -11,9 +11,15 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 )
 
+// PostStateMalicious is the state of the identity that was proven malicious and doesn't need post-service anymore.
+// The api doesn't define it yet, so it is reported as the next unused value rather than PostState_IDLE,
+// clients that don't know it receive it as an unrecognized enum value.
+const PostStateMalicious pb.PostState_State = pb.PostState_PROVING + 1
+
 var statusMap map[types.PostState]pb.PostState_State = map[types.PostState]pb.PostState_State{
-	types.PostStateIdle:    pb.PostState_IDLE,
-	types.PostStateProving: pb.PostState_PROVING,
+	types.PostStateIdle:      pb.PostState_IDLE,
+	types.PostStateProving:   pb.PostState_PROVING,
+	types.PostStateMalicious: PostStateMalicious,
 }
 
 // PostInfoService provides information about connected PostServices.
@@ -45,8 +45,9 @@ func TestPostInfoService(t *testing.T) {
 	client := pb.NewPostInfoServiceClient(conn)
 
 	existingStates := map[types.IdentityDescriptor]types.PostState{
-		newIdMock("idle.key"):    types.PostStateIdle,
-		newIdMock("proving.key"): types.PostStateProving,
+		newIdMock("idle.key"):      types.PostStateIdle,
+		newIdMock("proving.key"):   types.PostStateProving,
+		newIdMock("malicious.key"): types.PostStateMalicious,
 	}
 	mpostStates.EXPECT().PostStates().Return(existingStates)
 
@@ -61,4 +62,10 @@ func TestPostInfoService(t *testing.T) {
 			State: statusMap[state],
 		})
 	}
+	for _, state := range resp.States {
+		if state.Name == "malicious.key" {
+			require.Equal(t, PostStateMalicious, state.State)
+			require.NotEqual(t, pb.PostState_IDLE, state.State)
+		}
+	}
 }
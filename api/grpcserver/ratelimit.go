@@ -0,0 +1,174 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+)
+
+// APIKeyHeader is the request header (grpc metadata key) with the api key of the caller.
+const APIKeyHeader = "x-api-key"
+
+// RateLimiter enforces the quotas from RateLimitConfig on the api callers.
+// Callers that present a known api key are limited by the quota of the key,
+// other callers are limited by the quota of their ip address.
+type RateLimiter struct {
+	rate  rate.Limit
+	burst int
+	keys  map[string]*rate.Limiter
+
+	mu      sync.Mutex
+	clients *lru.Cache[string, *rate.Limiter]
+}
+
+// NewRateLimiter creates a RateLimiter from the config.
+func NewRateLimiter(cfg RateLimitConfig) (*RateLimiter, error) {
+	if err := cfg.Quota.validate(); err != nil {
+		return nil, err
+	}
+	clients, err := lru.New[string, *rate.Limiter](cfg.MaxClients)
+	if err != nil {
+		return nil, fmt.Errorf("max clients %d: %w", cfg.MaxClients, err)
+	}
+	l := &RateLimiter{
+		rate:    rate.Limit(cfg.Rate),
+		burst:   cfg.Burst,
+		keys:    make(map[string]*rate.Limiter, len(cfg.Keys)),
+		clients: clients,
+	}
+	for key, quota := range cfg.Keys {
+		if len(key) == 0 {
+			return nil, errors.New("empty api key")
+		}
+		if err := quota.validate(); err != nil {
+			return nil, fmt.Errorf("api key: %w", err)
+		}
+		l.keys[key] = rate.NewLimiter(rate.Limit(quota.Rate), quota.Burst)
+	}
+	return l, nil
+}
+
+func (q Quota) validate() error {
+	if q.Rate <= 0 {
+		return fmt.Errorf("rate must be positive, got %v", q.Rate)
+	}
+	if q.Burst <= 0 {
+		return fmt.Errorf("burst must be positive, got %d", q.Burst)
+	}
+	return nil
+}
+
+// lookupKey compares the key against every known key in constant time.
+func (l *RateLimiter) lookupKey(key string) *rate.Limiter {
+	var limiter *rate.Limiter
+	for known, granted := range l.keys {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(key)) == 1 {
+			limiter = granted
+		}
+	}
+	return limiter
+}
+
+func (l *RateLimiter) client(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, exists := l.clients.Get(ip)
+	if !exists {
+		limiter = rate.NewLimiter(l.rate, l.burst)
+		l.clients.Add(ip, limiter)
+	}
+	return limiter
+}
+
+// allow consumes a request from the quota of the api key, or of the ip address if the key is empty.
+func (l *RateLimiter) allow(key, ip string) error {
+	var limiter *rate.Limiter
+	if key == "" {
+		limiter = l.client(ip)
+	} else if limiter = l.lookupKey(key); limiter == nil {
+		return apiError(codes.Unauthenticated, ReasonInvalidAPIKey, "invalid api key")
+	}
+	if !limiter.Allow() {
+		return apiError(codes.ResourceExhausted, ReasonRateLimited, "rate limit exceeded")
+	}
+	return nil
+}
+
+func (l *RateLimiter) allowCall(ctx context.Context) error {
+	var key, ip string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(APIKeyHeader); len(values) > 0 {
+			key = values[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = hostOf(p.Addr.String())
+	}
+	return l.allow(key, ip)
+}
+
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func (l *RateLimiter) unaryInterceptor(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if err := l.allowCall(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *RateLimiter) streamInterceptor(
+	srv any,
+	stream grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := l.allowCall(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// ServerOptions returns grpc server options that enforce the quotas on every call.
+// Streams are limited only when they are opened.
+func (l *RateLimiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.unaryInterceptor),
+		grpc.ChainStreamInterceptor(l.streamInterceptor),
+	}
+}
+
+// Handler returns an http handler that enforces the quotas before calling next.
+// Requests over the quota are rejected with 429 Too Many Requests.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := l.allow(r.Header.Get(APIKeyHeader), hostOf(r.RemoteAddr)); err != nil {
+			rpc.WriteError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
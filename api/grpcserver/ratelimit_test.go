@@ -0,0 +1,137 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter_InvalidConfig(t *testing.T) {
+	valid := RateLimitConfig{Quota: Quota{Rate: 1, Burst: 1}, MaxClients: 1}
+	_, err := NewRateLimiter(valid)
+	require.NoError(t, err)
+
+	cfg := valid
+	cfg.Rate = 0
+	_, err = NewRateLimiter(cfg)
+	require.ErrorContains(t, err, "rate must be positive")
+
+	cfg = valid
+	cfg.Burst = 0
+	_, err = NewRateLimiter(cfg)
+	require.ErrorContains(t, err, "burst must be positive")
+
+	cfg = valid
+	cfg.MaxClients = 0
+	_, err = NewRateLimiter(cfg)
+	require.ErrorContains(t, err, "max clients")
+
+	cfg = valid
+	cfg.Keys = map[string]Quota{"": {Rate: 1, Burst: 1}}
+	_, err = NewRateLimiter(cfg)
+	require.ErrorContains(t, err, "empty api key")
+
+	cfg = valid
+	cfg.Keys = map[string]Quota{"key": {Rate: 1}}
+	_, err = NewRateLimiter(cfg)
+	require.ErrorContains(t, err, "burst must be positive")
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	l, err := NewRateLimiter(RateLimitConfig{
+		Quota:      Quota{Rate: 0.001, Burst: 2},
+		MaxClients: 10,
+		Keys:       map[string]Quota{"key": {Rate: 0.001, Burst: 3}},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, l.allow("", "10.0.0.1"))
+	}
+	require.Equal(t, codes.ResourceExhausted, status.Code(l.allow("", "10.0.0.1")))
+	reason, _, ok := ErrorReasonOf(l.allow("", "10.0.0.1"))
+	require.True(t, ok)
+	require.Equal(t, ReasonRateLimited, reason)
+
+	// quotas of other ip addresses and api keys are separate
+	require.NoError(t, l.allow("", "10.0.0.2"))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.allow("key", "10.0.0.1"))
+	}
+	require.Equal(t, codes.ResourceExhausted, status.Code(l.allow("key", "10.0.0.2")))
+
+	reason, _, ok = ErrorReasonOf(l.allow("unknown", "10.0.0.3"))
+	require.True(t, ok)
+	require.Equal(t, ReasonInvalidAPIKey, reason)
+}
+
+func TestRateLimiter_ServerOptions(t *testing.T) {
+	l, err := NewRateLimiter(RateLimitConfig{
+		Quota:      Quota{Rate: 0.001, Burst: 1},
+		MaxClients: 10,
+		Keys:       map[string]Quota{"key": {Rate: 0.001, Burst: 1}},
+	})
+	require.NoError(t, err)
+
+	cfg := DefaultTestConfig()
+	svc := NewNodeService(nil, nil, nil, nil, "v0.0.0", "cafebabe")
+	server, err := NewWithServices(cfg.PublicListener, zaptest.NewLogger(t), cfg, []ServiceAPI{svc}, l.ServerOptions()...)
+	require.NoError(t, err)
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { require.NoError(t, server.Close()) })
+	cfg.PublicListener = server.BoundAddress
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := pb.NewNodeServiceClient(dialGrpc(ctx, t, cfg))
+
+	req := &pb.EchoRequest{Msg: &pb.SimpleString{Value: "hello"}}
+	_, err = client.Echo(ctx, req)
+	require.NoError(t, err)
+	_, err = client.Echo(ctx, req)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	keyCtx := metadata.AppendToOutgoingContext(ctx, APIKeyHeader, "key")
+	_, err = client.Echo(keyCtx, req)
+	require.NoError(t, err)
+	_, err = client.Echo(keyCtx, req)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestRateLimiter_Handler(t *testing.T) {
+	l, err := NewRateLimiter(RateLimitConfig{Quota: Quota{Rate: 0.001, Burst: 1}, MaxClients: 10})
+	require.NoError(t, err)
+
+	jsonService := NewJSONHTTPServer("127.0.0.1:0", zaptest.NewLogger(t), WithRateLimiter(l))
+	require.NoError(t, jsonService.StartService(context.Background(), NewClockService(nil, nil)))
+	t.Cleanup(func() { require.NoError(t, jsonService.Shutdown(context.Background())) })
+
+	// the quota is consumed before the request is routed
+	url := fmt.Sprintf("http://%s/v1/unknown", jsonService.BoundAddress)
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, "unknown")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,131 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/miner"
+)
+
+// SmesherEstimatePath serves the expected proposal eligibilities and rewards of the smeshers in the epoch,
+// from the projected weight of the active set. Defaults to the next epoch. Repeated smesher parameters
+// are hex encoded node ids, defaults to the identities of the node.
+const SmesherEstimatePath = "/v1/smesher/estimate"
+
+// EligibilityEstimateRequest selects the epoch and the smeshers of the EligibilityEstimate method.
+// Epoch defaults to the next epoch. Smeshers are hex encoded node ids, default to the identities of the node.
+type EligibilityEstimateRequest struct {
+	Epoch    *uint32  `json:"epoch,omitempty"`
+	Smeshers []string `json:"smeshers,omitempty"`
+}
+
+// EligibilityEstimate is the estimate of the proposal eligibilities and rewards of the smeshers in the epoch.
+// Rewards are the expected share of the subsidy, without fees.
+type EligibilityEstimate struct {
+	Epoch     uint32 `json:"epoch"`
+	Weight    uint64 `json:"weight"`
+	MinWeight uint64 `json:"min_weight"`
+	Subsidy   uint64 `json:"subsidy"`
+	// Smeshers are listed in the order of the request.
+	Smeshers []SmesherEligibility `json:"smeshers"`
+}
+
+// SmesherEligibility is the expected number of eligibilities of the smesher and the reward for them.
+// ATX is empty if the node didn't receive the atx of the smesher that targets the epoch.
+type SmesherEligibility struct {
+	Smesher       string `json:"smesher"`
+	ATX           string `json:"atx,omitempty"`
+	Weight        uint64 `json:"weight"`
+	Late          bool   `json:"late"`
+	Eligibilities uint32 `json:"eligibilities"`
+	Reward        uint64 `json:"reward"`
+}
+
+// EligibilityEstimate returns the expected eligibilities and rewards of the smeshers in the epoch.
+func (s SmesherService) EligibilityEstimate(
+	ctx context.Context,
+	epoch types.EpochID,
+	smeshers []types.NodeID,
+) (*EligibilityEstimate, error) {
+	if s.estimator == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "eligibility estimates are not enabled")
+	}
+	estimate, err := s.estimator.Estimate(epoch, smeshers)
+	switch {
+	case errors.Is(err, miner.ErrNotReady):
+		return nil, apiError(codes.Unavailable, ReasonNotSynced, err.Error())
+	case errors.Is(err, miner.ErrEpochNotTracked):
+		return nil, apiError(codes.NotFound, ReasonNotFound, err.Error())
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := &EligibilityEstimate{
+		Epoch:     estimate.Epoch.Uint32(),
+		Weight:    estimate.Weight,
+		MinWeight: estimate.MinWeight,
+		Subsidy:   estimate.Subsidy,
+		Smeshers:  make([]SmesherEligibility, 0, len(estimate.Smeshers)),
+	}
+	for _, smesher := range estimate.Smeshers {
+		eligibility := SmesherEligibility{
+			Smesher:       hex.EncodeToString(smesher.NodeID.Bytes()),
+			Weight:        smesher.Weight,
+			Late:          smesher.Late,
+			Eligibilities: smesher.Eligibilities,
+			Reward:        smesher.Reward,
+		}
+		if smesher.ATX != types.EmptyATXID {
+			eligibility.ATX = hex.EncodeToString(smesher.ATX.Bytes())
+		}
+		rst.Smeshers = append(rst.Smeshers, eligibility)
+	}
+	return rst, nil
+}
+
+func (s SmesherService) eligibilityEstimate(
+	ctx context.Context,
+	req *EligibilityEstimateRequest,
+) (*EligibilityEstimate, error) {
+	smeshers, err := parseSmeshers("smesher", req.Smeshers)
+	if err != nil {
+		return nil, err
+	}
+	var epoch types.EpochID
+	switch {
+	case req.Epoch != nil:
+		epoch = types.EpochID(*req.Epoch)
+	case s.clock != nil:
+		epoch = s.clock.CurrentLayer().GetEpoch() + 1
+	}
+	if len(smeshers) == 0 {
+		smeshers = s.smeshingProvider.SmesherIDs()
+	}
+	return s.EligibilityEstimate(ctx, epoch, smeshers)
+}
+
+func (s SmesherService) handleEstimate(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	epoch, exists, err := epochParam(r, "epoch")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	req := &EligibilityEstimateRequest{Smeshers: r.URL.Query()["smesher"]}
+	if exists {
+		value := epoch.Uint32()
+		req.Epoch = &value
+	}
+	rst, err := s.eligibilityEstimate(r.Context(), req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
@@ -0,0 +1,137 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/miner"
+)
+
+func TestSmesherService_EligibilityEstimate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	smeshing := activation.NewMockSmeshingProvider(ctrl)
+	estimator := NewMockeligibilityEstimator(ctrl)
+	clock := NewMocklayerClock(ctrl)
+	svc := NewSmesherService(
+		smeshing,
+		NewMockpostSupervisor(ctrl),
+		time.Second,
+		nil,
+		activation.DefaultPostSetupOpts(),
+		WithEligibilityEstimator(estimator, clock),
+	)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	smesher, missing := types.NodeID{1}, types.NodeID{2}
+	estimate := &miner.EpochEstimate{
+		Epoch:     5,
+		Weight:    400,
+		MinWeight: 100,
+		Subsidy:   1000,
+		Smeshers: []miner.SmesherEstimate{
+			{NodeID: smesher, ATX: types.ATXID{1}, Weight: 100, Eligibilities: 10, Reward: 250},
+			{NodeID: missing},
+		},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		svc := NewSmesherService(smeshing, nil, time.Second, nil, activation.DefaultPostSetupOpts())
+		_, err := svc.EligibilityEstimate(context.Background(), 5, nil)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInternal, reason)
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			err    error
+			reason ErrorReason
+		}{
+			{miner.ErrNotReady, ReasonNotSynced},
+			{fmt.Errorf("%w: 7", miner.ErrEpochNotTracked), ReasonNotFound},
+			{errors.New("test"), ReasonInternal},
+		} {
+			estimator.EXPECT().Estimate(types.EpochID(7), nil).Return(nil, tc.err)
+			_, err := svc.EligibilityEstimate(context.Background(), 7, nil)
+			reason, _, _ := ErrorReasonOf(err)
+			require.Equal(t, tc.reason, reason)
+		}
+	})
+	t.Run("estimate", func(t *testing.T) {
+		estimator.EXPECT().Estimate(types.EpochID(5), []types.NodeID{smesher, missing}).Return(estimate, nil)
+		rst, err := svc.EligibilityEstimate(context.Background(), 5, []types.NodeID{smesher, missing})
+		require.NoError(t, err)
+		require.Equal(t, &EligibilityEstimate{
+			Epoch:     5,
+			Weight:    400,
+			MinWeight: 100,
+			Subsidy:   1000,
+			Smeshers: []SmesherEligibility{
+				{
+					Smesher:       hex.EncodeToString(smesher[:]),
+					ATX:           hex.EncodeToString(types.ATXID{1}.Bytes()),
+					Weight:        100,
+					Eligibilities: 10,
+					Reward:        250,
+				},
+				{Smesher: hex.EncodeToString(missing[:])},
+			},
+		}, rst)
+	})
+	t.Run("json", func(t *testing.T) {
+		// identities of the node are estimated by default
+		clock.EXPECT().CurrentLayer().Return(types.EpochID(4).FirstLayer())
+		smeshing.EXPECT().SmesherIDs().Return([]types.NodeID{smesher, missing})
+		estimator.EXPECT().Estimate(types.EpochID(5), []types.NodeID{smesher, missing}).Return(estimate, nil)
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, SmesherEstimatePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst EligibilityEstimate
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.EqualValues(t, 5, rst.Epoch)
+		require.Len(t, rst.Smeshers, 2)
+		require.EqualValues(t, 250, rst.Smeshers[0].Reward)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?epoch=5&smesher=0102", cfg.JSONListener, SmesherEstimatePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		epoch := uint32(5)
+		estimator.EXPECT().Estimate(types.EpochID(5), []types.NodeID{missing}).Return(estimate, nil)
+		rst, err := rpc.Invoke[EligibilityEstimateRequest, EligibilityEstimate](
+			ctx, conn, SmesherGrpcService, "EligibilityEstimate", rpc.JSON,
+			&EligibilityEstimateRequest{Epoch: &epoch, Smeshers: []string{hex.EncodeToString(missing[:])}},
+		)
+		require.NoError(t, err)
+		require.EqualValues(t, 5, rst.Epoch)
+		require.Len(t, rst.Smeshers, 2)
+
+		_, err = rpc.Invoke[EligibilityEstimateRequest, EligibilityEstimate](
+			ctx, conn, SmesherGrpcService, "EligibilityEstimate", rpc.JSON,
+			&EligibilityEstimateRequest{Smeshers: []string{"0102"}},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,275 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	localnipost "github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+// SmesherIdentityPath serves the state of the local identities assembled from the state and the local databases:
+// post, last atx, forecast of the next publication, pending challenge with poet registrations, malfeasance
+// and rewards. Repeated smesher parameters are hex encoded node ids, defaults to all identities of the node.
+const SmesherIdentityPath = "/v1/smesher/identity"
+
+// IdentityRequest selects the identities of the Identity method. Smeshers are hex encoded node ids,
+// default to all identities of the node.
+type IdentityRequest struct {
+	Smeshers []string `json:"smeshers,omitempty"`
+}
+
+// IdentityResponse is the state of the identities in the order of the request.
+type IdentityResponse struct {
+	Identities []*IdentityState `json:"identities"`
+}
+
+// IdentityState is the state of the local identity.
+type IdentityState struct {
+	Smesher   string             `json:"smesher"`
+	Malicious bool               `json:"malicious"`
+	Post      *IdentityPost      `json:"post,omitempty"`
+	LastATX   *IdentityATX       `json:"last_atx,omitempty"`
+	Forecast  *IdentityForecast  `json:"forecast,omitempty"`
+	Challenge *IdentityChallenge `json:"challenge,omitempty"`
+	Rewards   IdentityRewards    `json:"rewards"`
+}
+
+// IdentityPost is the post of the identity. Setup state is known only for the supervised identity,
+// initial post is known until the first atx of the identity is published.
+type IdentityPost struct {
+	SetupState       string `json:"setup_state,omitempty"`
+	NumLabelsWritten uint64 `json:"num_labels_written,omitempty"`
+	NumUnits         uint32 `json:"num_units,omitempty"`
+	CommitmentATX    string `json:"commitment_atx,omitempty"`
+}
+
+// IdentityATX is the latest atx of the identity.
+type IdentityATX struct {
+	ID       string `json:"id"`
+	Publish  uint32 `json:"publish"`
+	Sequence uint64 `json:"sequence"`
+	NumUnits uint32 `json:"num_units"`
+	Weight   uint64 `json:"weight"`
+}
+
+// IdentityForecast is the forecast of the next atx publication of the identity.
+type IdentityForecast struct {
+	Phase           string    `json:"phase"`
+	Publish         uint32    `json:"publish"`
+	PoetRoundStart  time.Time `json:"poet_round_start"`
+	PoetRoundEnd    time.Time `json:"poet_round_end"`
+	ProvingStart    time.Time `json:"proving_start"`
+	PublishDeadline time.Time `json:"publish_deadline"`
+}
+
+// IdentityChallenge is the challenge of the atx that is being built, with the poets it is registered in.
+type IdentityChallenge struct {
+	Publish        uint32                     `json:"publish"`
+	Sequence       uint64                     `json:"sequence"`
+	PrevATX        string                     `json:"prev_atx,omitempty"`
+	PositioningATX string                     `json:"positioning_atx"`
+	Registrations  []IdentityPoetRegistration `json:"registrations,omitempty"`
+}
+
+// IdentityPoetRegistration is the registration of the challenge in the poet round.
+type IdentityPoetRegistration struct {
+	Address  string    `json:"address"`
+	RoundID  string    `json:"round_id"`
+	RoundEnd time.Time `json:"round_end"`
+}
+
+// IdentityRewards is the summary of the rewards received by the identity.
+type IdentityRewards struct {
+	Count         uint32 `json:"count"`
+	Total         uint64 `json:"total"`
+	LayerReward   uint64 `json:"layer_reward"`
+	CurrentEpoch  uint64 `json:"current_epoch"`
+	PreviousEpoch uint64 `json:"previous_epoch"`
+}
+
+// Identity returns the state of the local identity.
+func (s SmesherService) Identity(ctx context.Context, id types.NodeID) (*IdentityState, error) {
+	if s.db == nil {
+		return nil, apiError(codes.Unavailable, ReasonInternal, "identity state is not enabled")
+	}
+	if !slices.Contains(s.smeshingProvider.SmesherIDs(), id) {
+		return nil, apiError(codes.NotFound, ReasonNotFound,
+			fmt.Sprintf("%s is not an identity of the node", id.ShortString()))
+	}
+	state, err := s.identity(id)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return state, nil
+}
+
+func (s SmesherService) identity(id types.NodeID) (*IdentityState, error) {
+	state := &IdentityState{Smesher: hex.EncodeToString(id.Bytes())}
+	malicious, err := identities.IsMalicious(s.db, id)
+	if err != nil {
+		return nil, fmt.Errorf("is malicious: %w", err)
+	}
+	state.Malicious = malicious
+
+	if state.Post, err = s.identityPost(id); err != nil {
+		return nil, err
+	}
+	atxID, err := atxs.GetLastIDByNodeID(s.db, id)
+	switch {
+	case err == nil:
+		atx, err := atxs.Get(s.db, atxID)
+		if err != nil {
+			return nil, fmt.Errorf("get atx %s: %w", atxID, err)
+		}
+		state.LastATX = &IdentityATX{
+			ID:       hex.EncodeToString(atxID.Bytes()),
+			Publish:  atx.PublishEpoch.Uint32(),
+			Sequence: atx.Sequence,
+			NumUnits: atx.NumUnits,
+			Weight:   atx.GetWeight(),
+		}
+	case !errors.Is(err, sql.ErrNotFound):
+		return nil, fmt.Errorf("get last atx: %w", err)
+	}
+
+	if s.forecaster != nil {
+		forecast, err := s.forecaster.PublishForecast(id)
+		if err != nil {
+			return nil, fmt.Errorf("forecast: %w", err)
+		}
+		state.Forecast = &IdentityForecast{
+			Phase:           forecast.Phase.String(),
+			Publish:         forecast.PublishEpoch.Uint32(),
+			PoetRoundStart:  forecast.PoetRoundStart,
+			PoetRoundEnd:    forecast.PoetRoundEnd,
+			ProvingStart:    forecast.ProvingStart,
+			PublishDeadline: forecast.PublishDeadline,
+		}
+	}
+
+	if state.Challenge, err = s.identityChallenge(id); err != nil {
+		return nil, err
+	}
+	if state.Rewards, err = s.identityRewards(id); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s SmesherService) identityPost(id types.NodeID) (*IdentityPost, error) {
+	var post *IdentityPost
+	if s.sig != nil && s.sig.NodeID() == id && s.postSupervisor != nil {
+		status := s.postSupervisor.Status()
+		post = &IdentityPost{
+			SetupState:       pb.PostSetupStatus_State(status.State).String(),
+			NumLabelsWritten: status.NumLabelsWritten,
+		}
+	}
+	initial, err := localnipost.InitialPost(s.localdb, id)
+	switch {
+	case err == nil:
+		if post == nil {
+			post = &IdentityPost{}
+		}
+		post.NumUnits = initial.NumUnits
+		post.CommitmentATX = hex.EncodeToString(initial.CommitmentATX.Bytes())
+	case !errors.Is(err, sql.ErrNotFound):
+		return nil, fmt.Errorf("get initial post: %w", err)
+	}
+	return post, nil
+}
+
+func (s SmesherService) identityChallenge(id types.NodeID) (*IdentityChallenge, error) {
+	challenge, err := localnipost.Challenge(s.localdb, id)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("get challenge: %w", err)
+	}
+	rst := &IdentityChallenge{
+		Publish:        challenge.PublishEpoch.Uint32(),
+		Sequence:       challenge.Sequence,
+		PositioningATX: hex.EncodeToString(challenge.PositioningATX.Bytes()),
+	}
+	if challenge.PrevATXID != types.EmptyATXID {
+		rst.PrevATX = hex.EncodeToString(challenge.PrevATXID.Bytes())
+	}
+	registrations, err := localnipost.PoetRegistrations(s.localdb, id)
+	if err != nil {
+		return nil, fmt.Errorf("get poet registrations: %w", err)
+	}
+	for _, registration := range registrations {
+		rst.Registrations = append(rst.Registrations, IdentityPoetRegistration{
+			Address:  registration.Address,
+			RoundID:  registration.RoundID,
+			RoundEnd: registration.RoundEnd,
+		})
+	}
+	return rst, nil
+}
+
+func (s SmesherService) identityRewards(id types.NodeID) (IdentityRewards, error) {
+	var rst IdentityRewards
+	current := s.clock.CurrentLayer().GetEpoch()
+	summaries, err := rewards.SummarizeByEpoch(s.db, nil, &id, 0, current)
+	if err != nil {
+		return rst, err
+	}
+	for _, summary := range summaries {
+		rst.Count += summary.Count
+		rst.Total += summary.TotalReward
+		rst.LayerReward += summary.LayerReward
+		switch summary.Epoch {
+		case current:
+			rst.CurrentEpoch = summary.TotalReward
+		case current - 1:
+			rst.PreviousEpoch = summary.TotalReward
+		}
+	}
+	return rst, nil
+}
+
+func (s SmesherService) identities(ctx context.Context, req *IdentityRequest) (*IdentityResponse, error) {
+	smeshers, err := parseSmeshers("smesher", req.Smeshers)
+	if err != nil {
+		return nil, err
+	}
+	if len(smeshers) == 0 {
+		smeshers = s.smeshingProvider.SmesherIDs()
+	}
+	rst := &IdentityResponse{Identities: make([]*IdentityState, 0, len(smeshers))}
+	for _, id := range smeshers {
+		state, err := s.Identity(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		rst.Identities = append(rst.Identities, state)
+	}
+	return rst, nil
+}
+
+func (s SmesherService) handleIdentity(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	rst, err := s.identities(r.Context(), &IdentityRequest{Smeshers: r.URL.Query()["smesher"]})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst.Identities)
+}
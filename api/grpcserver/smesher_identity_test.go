@@ -0,0 +1,206 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	localnipost "github.com/spacemeshos/go-spacemesh/sql/localsql/nipost"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+func TestSmesherService_Identity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	smeshing := activation.NewMockSmeshingProvider(ctrl)
+	supervisor := NewMockpostSupervisor(ctrl)
+	forecaster := NewMockpublishForecaster(ctrl)
+	clock := NewMocklayerClock(ctrl)
+	db := sql.InMemory()
+	localdb := localsql.InMemory()
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	svc := NewSmesherService(
+		smeshing,
+		supervisor,
+		time.Second,
+		sig,
+		activation.DefaultPostSetupOpts(),
+		WithIdentityState(db, localdb, forecaster, clock),
+	)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	id, other := sig.NodeID(), types.NodeID{2}
+	smeshing.EXPECT().SmesherIDs().Return([]types.NodeID{id, other}).AnyTimes()
+	current := types.EpochID(3)
+	clock.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	supervisor.EXPECT().Status().Return(&activation.PostSetupStatus{
+		State:            activation.PostSetupStateComplete,
+		NumLabelsWritten: 100,
+	}).AnyTimes()
+	forecast := &activation.PublishForecast{
+		Phase:           localnipost.PhaseRegistered,
+		PublishEpoch:    current + 1,
+		PoetRoundStart:  time.Unix(100, 0).UTC(),
+		PoetRoundEnd:    time.Unix(200, 0).UTC(),
+		ProvingStart:    time.Unix(200, 0).UTC(),
+		PublishDeadline: time.Unix(300, 0).UTC(),
+	}
+	forecaster.EXPECT().PublishForecast(gomock.Any()).Return(forecast, nil).AnyTimes()
+
+	t.Run("disabled", func(t *testing.T) {
+		svc := NewSmesherService(smeshing, nil, time.Second, nil, activation.DefaultPostSetupOpts())
+		_, err := svc.Identity(context.Background(), id)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInternal, reason)
+	})
+	t.Run("not local", func(t *testing.T) {
+		_, err := svc.Identity(context.Background(), types.NodeID{3})
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonNotFound, reason)
+	})
+	t.Run("empty", func(t *testing.T) {
+		state, err := svc.Identity(context.Background(), other)
+		require.NoError(t, err)
+		require.Equal(t, &IdentityState{
+			Smesher: hex.EncodeToString(other.Bytes()),
+			Forecast: &IdentityForecast{
+				Phase:           forecast.Phase.String(),
+				Publish:         forecast.PublishEpoch.Uint32(),
+				PoetRoundStart:  forecast.PoetRoundStart,
+				PoetRoundEnd:    forecast.PoetRoundEnd,
+				ProvingStart:    forecast.ProvingStart,
+				PublishDeadline: forecast.PublishDeadline,
+			},
+		}, state)
+	})
+	t.Run("full", func(t *testing.T) {
+		atx := &types.ActivationTx{InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{PublishEpoch: current - 1, Sequence: 3},
+			NumUnits:        2,
+		}}
+		atx.SetID(types.ATXID{1})
+		atx.SmesherID = id
+		atx.SetEffectiveNumUnits(atx.NumUnits)
+		atx.SetReceived(time.Now())
+		vatx, err := atx.Verify(0, 10)
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, vatx))
+		require.NoError(t, identities.SetMalicious(db, id, []byte("proof"), time.Now()))
+		for _, lid := range []types.LayerID{current.FirstLayer() - 1, current.FirstLayer(), current.FirstLayer() + 1} {
+			require.NoError(t, rewards.Add(db, &types.Reward{
+				Layer:       lid,
+				SmesherID:   id,
+				Coinbase:    types.Address{byte(lid)},
+				TotalReward: 10,
+				LayerReward: 7,
+			}))
+		}
+		require.NoError(t, localnipost.AddInitialPost(localdb, id, localnipost.Post{
+			Indices:       []byte{1},
+			NumUnits:      4,
+			CommitmentATX: types.ATXID{2},
+		}))
+		require.NoError(t, localnipost.AddChallenge(localdb, id, &types.NIPostChallenge{
+			PublishEpoch:   current + 1,
+			Sequence:       4,
+			PrevATXID:      types.ATXID{1},
+			PositioningATX: types.ATXID{3},
+		}))
+		require.NoError(t, localnipost.AddPoetRegistration(localdb, id, localnipost.PoETRegistration{
+			ChallengeHash: types.Hash32{1},
+			Address:       "http://poet",
+			RoundID:       "7",
+			RoundEnd:      time.Unix(200, 0),
+		}))
+
+		state, err := svc.Identity(context.Background(), id)
+		require.NoError(t, err)
+		require.True(t, state.Malicious)
+		require.Equal(t, &IdentityPost{
+			SetupState:       "STATE_COMPLETE",
+			NumLabelsWritten: 100,
+			NumUnits:         4,
+			CommitmentATX:    hex.EncodeToString(types.ATXID{2}.Bytes()),
+		}, state.Post)
+		require.Equal(t, &IdentityATX{
+			ID:       hex.EncodeToString(types.ATXID{1}.Bytes()),
+			Publish:  (current - 1).Uint32(),
+			Sequence: 3,
+			NumUnits: 2,
+			Weight:   vatx.GetWeight(),
+		}, state.LastATX)
+		require.Equal(t, &IdentityChallenge{
+			Publish:        (current + 1).Uint32(),
+			Sequence:       4,
+			PrevATX:        hex.EncodeToString(types.ATXID{1}.Bytes()),
+			PositioningATX: hex.EncodeToString(types.ATXID{3}.Bytes()),
+			Registrations: []IdentityPoetRegistration{
+				{Address: "http://poet", RoundID: "7", RoundEnd: time.Unix(200, 0)},
+			},
+		}, state.Challenge)
+		require.Equal(t, IdentityRewards{
+			Count:         3,
+			Total:         30,
+			LayerReward:   21,
+			CurrentEpoch:  20,
+			PreviousEpoch: 10,
+		}, state.Rewards)
+	})
+	t.Run("json", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, SmesherIdentityPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst []IdentityState
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Len(t, rst, 2)
+		require.Equal(t, hex.EncodeToString(id.Bytes()), rst[0].Smesher)
+		require.EqualValues(t, 30, rst[0].Rewards.Total)
+		require.Equal(t, hex.EncodeToString(other.Bytes()), rst[1].Smesher)
+
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?smesher=%s",
+			cfg.JSONListener, SmesherIdentityPath, hex.EncodeToString(types.NodeID{3}.Bytes())))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[IdentityRequest, IdentityResponse](
+			ctx, conn, SmesherGrpcService, "Identity", rpc.JSON,
+			&IdentityRequest{Smeshers: []string{hex.EncodeToString(other.Bytes())}},
+		)
+		require.NoError(t, err)
+		require.Len(t, rst.Identities, 1)
+		require.Equal(t, hex.EncodeToString(other.Bytes()), rst.Identities[0].Smesher)
+
+		_, err = rpc.Invoke[IdentityRequest, IdentityResponse](
+			ctx, conn, SmesherGrpcService, "Identity", rpc.JSON,
+			&IdentityRequest{Smeshers: []string{hex.EncodeToString(types.NodeID{3}.Bytes())}},
+		)
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,116 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+)
+
+// SmesherResizePath validates the change of the number of space units of the supervised identity
+// with GET, and schedules it with POST. The resize is applied after the atx that is being built
+// is published. The proposed size is set with the num_units parameter.
+const SmesherResizePath = "/v1/smesher/resize"
+
+// ResizeRequest is the proposed number of space units of the PreviewResize and Resize methods.
+type ResizeRequest struct {
+	NumUnits uint32 `json:"num_units"`
+}
+
+// ResizePreview is the validation of the change of the number of space units of the supervised identity.
+type ResizePreview struct {
+	Smesher            string `json:"smesher"`
+	CurrentUnits       uint32 `json:"current_units"`
+	ProposedUnits      uint32 `json:"proposed_units"`
+	LabelsToInitialize uint64 `json:"labels_to_initialize"`
+	// ExpectedProvingTime is empty if the node didn't measure the proving of the identity yet.
+	ExpectedProvingTime string `json:"expected_proving_time,omitempty"`
+	ProvingWindow       string `json:"proving_window"`
+	// PublishEpoch is the publish epoch of the first atx proven with the proposed size.
+	PublishEpoch uint32 `json:"publish_epoch"`
+	// WeightEpoch is the first epoch in which the weight of the identity is based on the proposed size.
+	WeightEpoch uint32   `json:"weight_epoch"`
+	Warnings    []string `json:"warnings"`
+}
+
+// Resize validates the change of the number of space units of the supervised identity.
+// If apply is true the resize is scheduled with the atx builder.
+func (s SmesherService) Resize(numUnits uint32, apply bool) (*ResizePreview, error) {
+	if s.sig == nil {
+		return nil, apiError(codes.FailedPrecondition, ReasonSmeshingNotConfigured,
+			"node is not configured for supervised smeshing")
+	}
+	var (
+		preview *activation.ResizePreview
+		err     error
+	)
+	if apply {
+		preview, err = s.postSupervisor.Resize(numUnits)
+	} else {
+		preview, err = s.postSupervisor.PreviewResize(numUnits)
+	}
+	switch {
+	case errors.Is(err, activation.ErrInvalidResize):
+		return nil, apiError(codes.FailedPrecondition, ReasonInvalidResize, err.Error())
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonPostSupervisorFailed, err.Error())
+	}
+	rst := &ResizePreview{
+		Smesher:            hex.EncodeToString(preview.NodeID.Bytes()),
+		CurrentUnits:       preview.CurrentUnits,
+		ProposedUnits:      preview.ProposedUnits,
+		LabelsToInitialize: preview.LabelsToInitialize,
+		ProvingWindow:      preview.ProvingWindow.String(),
+		PublishEpoch:       preview.PublishEpoch.Uint32(),
+		WeightEpoch:        preview.WeightEpoch.Uint32(),
+		Warnings:           preview.Warnings,
+	}
+	if preview.ExpectedProvingTime > 0 {
+		rst.ExpectedProvingTime = preview.ExpectedProvingTime.String()
+	}
+	return rst, nil
+}
+
+func (s SmesherService) previewResize(_ context.Context, req *ResizeRequest) (*ResizePreview, error) {
+	return s.Resize(req.NumUnits, false)
+}
+
+func (s SmesherService) resize(_ context.Context, req *ResizeRequest) (*ResizePreview, error) {
+	return s.Resize(req.NumUnits, true)
+}
+
+func (s SmesherService) writeResize(w http.ResponseWriter, r *http.Request, apply bool) {
+	numUnits, err := strconv.ParseUint(r.URL.Query().Get("num_units"), 10, 32)
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("parse num_units: %s", err)))
+		return
+	}
+	call := s.previewResize
+	if apply {
+		call = s.resize
+	}
+	rst, err := call(r.Context(), &ResizeRequest{NumUnits: uint32(numUnits)})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (s SmesherService) handleResizePreview(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	s.writeResize(w, r, false)
+}
+
+func (s SmesherService) handleResize(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	s.writeResize(w, r, true)
+}
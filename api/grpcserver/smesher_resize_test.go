@@ -0,0 +1,136 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+func TestSmesherService_Resize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	supervisor := NewMockpostSupervisor(ctrl)
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	svc := NewSmesherService(
+		activation.NewMockSmeshingProvider(ctrl),
+		supervisor,
+		time.Second,
+		sig,
+		activation.DefaultPostSetupOpts(),
+	)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	preview := &activation.ResizePreview{
+		NodeID:             sig.NodeID(),
+		CurrentUnits:       4,
+		ProposedUnits:      8,
+		LabelsToInitialize: 100,
+		ProvingWindow:      time.Hour,
+		PublishEpoch:       6,
+		WeightEpoch:        8,
+		Warnings:           []string{"warning"},
+	}
+	expected := &ResizePreview{
+		Smesher:            hex.EncodeToString(sig.NodeID().Bytes()),
+		CurrentUnits:       4,
+		ProposedUnits:      8,
+		LabelsToInitialize: 100,
+		ProvingWindow:      "1h0m0s",
+		PublishEpoch:       6,
+		WeightEpoch:        8,
+		Warnings:           []string{"warning"},
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		svc := NewSmesherService(nil, supervisor, time.Second, nil, activation.DefaultPostSetupOpts())
+		_, err := svc.Resize(8, false)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonSmeshingNotConfigured, reason)
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			err    error
+			reason ErrorReason
+		}{
+			{fmt.Errorf("%w: test", activation.ErrInvalidResize), ReasonInvalidResize},
+			{errors.New("test"), ReasonPostSupervisorFailed},
+		} {
+			supervisor.EXPECT().PreviewResize(uint32(8)).Return(nil, tc.err)
+			_, err := svc.Resize(8, false)
+			reason, _, _ := ErrorReasonOf(err)
+			require.Equal(t, tc.reason, reason)
+		}
+	})
+	t.Run("preview", func(t *testing.T) {
+		supervisor.EXPECT().PreviewResize(uint32(8)).Return(preview, nil)
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?num_units=8", cfg.JSONListener, SmesherResizePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst ResizePreview
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, expected, &rst)
+	})
+	t.Run("apply", func(t *testing.T) {
+		supervisor.EXPECT().Resize(uint32(8)).Return(preview, nil)
+		url := fmt.Sprintf("http://%s%s?num_units=8", cfg.JSONListener, SmesherResizePath)
+		resp, err := http.Post(url, "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?num_units=-1", cfg.JSONListener, SmesherResizePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		supervisor.EXPECT().PreviewResize(uint32(4)).Return(nil, activation.ErrInvalidResize)
+		resp, err = http.Get(fmt.Sprintf("http://%s%s?num_units=4", cfg.JSONListener, SmesherResizePath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		supervisor.EXPECT().PreviewResize(uint32(8)).Return(preview, nil)
+		rst, err := rpc.Invoke[ResizeRequest, ResizePreview](
+			ctx, conn, SmesherGrpcService, "PreviewResize", rpc.JSON, &ResizeRequest{NumUnits: 8},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst)
+
+		supervisor.EXPECT().Resize(uint32(8)).Return(preview, nil)
+		rst, err = rpc.Invoke[ResizeRequest, ResizePreview](
+			ctx, conn, SmesherGrpcService, "Resize", rpc.JSON, &ResizeRequest{NumUnits: 8},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst)
+
+		supervisor.EXPECT().Resize(uint32(4)).Return(nil, activation.ErrInvalidResize)
+		_, err = rpc.Invoke[ResizeRequest, ResizePreview](
+			ctx, conn, SmesherGrpcService, "Resize", rpc.JSON, &ResizeRequest{NumUnits: 4},
+		)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
@@ -3,6 +3,7 @@ package grpcserver
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
@@ -18,8 +19,10 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
 // SmesherService exposes endpoints to manage smeshing.
@@ -30,15 +33,86 @@ type SmesherService struct {
 	streamInterval time.Duration
 	sig            *signing.EdSigner
 	postOpts       activation.PostSetupOpts
+
+	estimator eligibilityEstimator
+	clock     layerClock
+
+	db         sql.Executor
+	localdb    sql.Executor
+	forecaster publishForecaster
+}
+
+// SmesherServiceOpt modifies SmesherService.
+type SmesherServiceOpt func(*SmesherService)
+
+// WithEligibilityEstimator enables the estimates of the eligibilities and rewards of the smeshers
+// in the next epoch on the json gateway.
+func WithEligibilityEstimator(estimator eligibilityEstimator, clock layerClock) SmesherServiceOpt {
+	return func(s *SmesherService) {
+		s.estimator = estimator
+		s.clock = clock
+	}
 }
 
+// WithIdentityState enables the state of the local identities.
+func WithIdentityState(
+	db, localdb sql.Executor,
+	forecaster publishForecaster,
+	clock layerClock,
+) SmesherServiceOpt {
+	return func(s *SmesherService) {
+		s.db = db
+		s.localdb = localdb
+		s.forecaster = forecaster
+		s.clock = clock
+	}
+}
+
+// SmesherGrpcService is the name of the grpc service that serves the smesher methods that are not defined
+// in the SmesherService protobuf. Messages of the service are encoded in json (see rpc.JSON).
+const SmesherGrpcService = "spacemesh.node.v1.SmesherService"
+
 // RegisterService registers this service with a grpc server instance.
 func (s SmesherService) RegisterService(server *grpc.Server) {
 	pb.RegisterSmesherServiceServer(server, s)
+	server.RegisterService(&smesherDesc, s)
 }
 
+type smesherServer interface {
+	eligibilityEstimate(context.Context, *EligibilityEstimateRequest) (*EligibilityEstimate, error)
+	identities(context.Context, *IdentityRequest) (*IdentityResponse, error)
+	previewResize(context.Context, *ResizeRequest) (*ResizePreview, error)
+	resize(context.Context, *ResizeRequest) (*ResizePreview, error)
+}
+
+var smesherDesc = grpc.ServiceDesc{
+	ServiceName: SmesherGrpcService,
+	HandlerType: (*smesherServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(SmesherGrpcService, "EligibilityEstimate", smesherServer.eligibilityEstimate),
+		rpc.UnaryMethod(SmesherGrpcService, "Identity", smesherServer.identities),
+		rpc.UnaryMethod(SmesherGrpcService, "PreviewResize", smesherServer.previewResize),
+		rpc.UnaryMethod(SmesherGrpcService, "Resize", smesherServer.resize),
+	},
+	Metadata: "api/grpcserver/smesher_service.go",
+}
+
+// RegisterHandlerService registers the smesher routes with the json gateway.
+// Routes of the eligibility estimate, the identity and the resize serve the methods of SmesherGrpcService.
 func (s SmesherService) RegisterHandlerService(mux *runtime.ServeMux) error {
-	return pb.RegisterSmesherServiceHandlerServer(context.Background(), mux, s)
+	if err := pb.RegisterSmesherServiceHandlerServer(context.Background(), mux, s); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, SmesherEstimatePath, s.handleEstimate); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, SmesherIdentityPath, s.handleIdentity); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, SmesherResizePath, s.handleResizePreview); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodPost, SmesherResizePath, s.handleResize)
 }
 
 // String returns the name of this service.
@@ -53,14 +127,19 @@ func NewSmesherService(
 	streamInterval time.Duration,
 	sig *signing.EdSigner,
 	postOpts activation.PostSetupOpts,
+	opts ...SmesherServiceOpt,
 ) *SmesherService {
-	return &SmesherService{
+	s := &SmesherService{
 		smeshingProvider: smeshing,
 		postSupervisor:   postSupervisor,
 		streamInterval:   streamInterval,
 		sig:              sig,
 		postOpts:         postOpts,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // IsSmeshing reports whether the node is smeshing.
@@ -74,27 +153,32 @@ func (s SmesherService) StartSmeshing(
 	in *pb.StartSmeshingRequest,
 ) (*pb.StartSmeshingResponse, error) {
 	if in.Coinbase == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Coinbase` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument,
+			"`Coinbase` must be provided", "field", "Coinbase")
 	}
 	coinbaseAddr, err := types.StringToAddress(in.Coinbase.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse in.Coinbase.Address `%s`: %w", in.Coinbase.Address, err)
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse in.Coinbase.Address `%s`: %v", in.Coinbase.Address, err),
+			"field", "Coinbase.Address")
 	}
 
 	opts, err := s.postSetupOpts(in.Opts)
 	if err != nil {
-		status.Error(codes.InvalidArgument, err.Error())
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, err.Error())
 	}
 	if s.sig == nil {
-		return nil, status.Errorf(codes.FailedPrecondition, "node is not configured for supervised smeshing")
+		return nil, apiError(codes.FailedPrecondition, ReasonSmeshingNotConfigured,
+			"node is not configured for supervised smeshing")
 	}
 	if err := s.postSupervisor.Start(opts, s.sig); err != nil {
 		ctxzap.Error(ctx, "failed to start post supervisor", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to start post supervisor: %v", err))
+		return nil, apiError(codes.Internal, ReasonPostSupervisorFailed,
+			fmt.Sprintf("failed to start post supervisor: %v", err))
 	}
 	if err := s.smeshingProvider.StartSmeshing(coinbaseAddr); err != nil {
 		ctxzap.Error(ctx, "failed to start smeshing", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to start smeshing: %v", err))
+		return nil, apiError(codes.Internal, ReasonSmeshingFailed, fmt.Sprintf("failed to start smeshing: %v", err))
 	}
 	return &pb.StartSmeshingResponse{
 		Status: &rpcstatus.Status{Code: int32(code.Code_OK)},
@@ -134,11 +218,12 @@ func (s SmesherService) StopSmeshing(
 ) (*pb.StopSmeshingResponse, error) {
 	if err := s.smeshingProvider.StopSmeshing(in.DeleteFiles); err != nil {
 		ctxzap.Error(ctx, "failed to stop smeshing", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to stop smeshing: %v", err))
+		return nil, apiError(codes.Internal, ReasonSmeshingFailed, fmt.Sprintf("failed to stop smeshing: %v", err))
 	}
 	if err := s.postSupervisor.Stop(in.DeleteFiles); err != nil {
 		ctxzap.Error(ctx, "failed to stop post supervisor", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to stop post supervisor: %v", err))
+		return nil, apiError(codes.Internal, ReasonPostSupervisorFailed,
+			fmt.Sprintf("failed to stop post supervisor: %v", err))
 	}
 	return &pb.StopSmeshingResponse{
 		Status: &rpcstatus.Status{Code: int32(code.Code_OK)},
@@ -167,12 +252,13 @@ func (s SmesherService) Coinbase(context.Context, *emptypb.Empty) (*pb.CoinbaseR
 // SetCoinbase sets the current coinbase setting of this node.
 func (s SmesherService) SetCoinbase(_ context.Context, in *pb.SetCoinbaseRequest) (*pb.SetCoinbaseResponse, error) {
 	if in.Id == nil {
-		return nil, status.Errorf(codes.InvalidArgument, "`Id` must be provided")
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, "`Id` must be provided", "field", "Id")
 	}
 
 	addr, err := types.StringToAddress(in.Id.Address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse in.Id.Address `%s`: %w", in.Id.Address, err)
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+			fmt.Sprintf("failed to parse in.Id.Address `%s`: %v", in.Id.Address, err), "field", "Id.Address")
 	}
 	s.smeshingProvider.SetCoinbase(addr)
 
@@ -233,7 +319,7 @@ func (s SmesherService) PostSetupProviders(
 ) (*pb.PostSetupProvidersResponse, error) {
 	providers, err := s.postSupervisor.Providers()
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get OpenCL providers: %v", err)
+		return nil, apiError(codes.Internal, ReasonInternal, fmt.Sprintf("failed to get OpenCL providers: %v", err))
 	}
 
 	res := &pb.PostSetupProvidersResponse{}
@@ -245,7 +331,7 @@ func (s SmesherService) PostSetupProviders(
 			hashesPerSec, err = s.postSupervisor.Benchmark(p)
 			if err != nil {
 				ctxzap.Error(ctx, "failed to benchmark provider", zap.Error(err))
-				return nil, status.Error(codes.Internal, "failed to benchmark provider")
+				return nil, apiError(codes.Internal, ReasonInternal, "failed to benchmark provider")
 			}
 		}
 
@@ -0,0 +1,343 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+const (
+	// StateRootsPath serves state roots of the applied layers in the range [start_layer, end_layer]
+	// on the json gateway.
+	StateRootsPath = "/v1/state/roots"
+	// StateRootsStreamPath streams state roots of the applied layers as newline delimited json.
+	// If start_layer is set stored roots starting from that layer are sent first.
+	StateRootsStreamPath = "/v1/state/roots/stream"
+	// StateProofPath serves the proof for the state of the account after the layer was applied
+	// against the state root of the layer. The layer defaults to the last applied layer.
+	StateProofPath = "/v1/state/proof"
+	// StateGrpcService is the name of the grpc service that serves Roots, RootsStream and Proof methods.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	StateGrpcService = "spacemesh.node.v1.StateService"
+)
+
+// StateRootsRequest selects the range of layers [start_layer, end_layer], end_layer defaults to start_layer.
+type StateRootsRequest struct {
+	StartLayer uint32 `json:"start_layer"`
+	EndLayer   uint32 `json:"end_layer"`
+}
+
+// StateRootsResponse is the response of the Roots method.
+type StateRootsResponse struct {
+	Roots []StateRoot `json:"roots"`
+}
+
+// StateRootsStreamRequest is the request of the RootsStream method.
+// If start_layer is set stored roots starting from that layer are sent first.
+type StateRootsStreamRequest struct {
+	StartLayer *uint32 `json:"start_layer,omitempty"`
+}
+
+// StateProofRequest is the request of the Proof method, the layer defaults to the last applied layer.
+type StateProofRequest struct {
+	Address string  `json:"address"`
+	Layer   *uint32 `json:"layer,omitempty"`
+}
+
+// StateRoot is the hex encoded root of the state of all accounts after the layer was applied.
+// Nodes that applied the same layers have equal state roots.
+type StateRoot struct {
+	Layer uint32 `json:"layer"`
+	Root  string `json:"root"`
+}
+
+// StateProof is the merkle proof for the state of the account in the layer.
+// It can be verified with the stateproof package against the state root of the layer
+// obtained from several nodes.
+type StateProof struct {
+	Layer     uint32   `json:"layer"`
+	Address   string   `json:"address"`
+	Balance   uint64   `json:"balance"`
+	NextNonce uint64   `json:"next_nonce"`
+	Template  string   `json:"template,omitempty"`
+	State     string   `json:"state,omitempty"`
+	Root      string   `json:"root"`
+	Index     uint64   `json:"index"`
+	Proof     []string `json:"proof"`
+}
+
+// StateService exposes state roots so that nodes can cheaply compare their state
+// and light clients can anchor account proofs. It is served on the json gateway and as StateGrpcService.
+// Roots are persisted only if the vm is configured to compute them (vm.Config.StateRoot).
+type StateService struct {
+	db sql.Executor
+}
+
+// NewStateService creates a new state service.
+func NewStateService(db sql.Executor) *StateService {
+	return &StateService{db: db}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *StateService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&stateDesc, s)
+}
+
+type stateServer interface {
+	roots(context.Context, *StateRootsRequest) (*StateRootsResponse, error)
+	rootsStream(*StateRootsStreamRequest, rpc.ServerStream[StateRoot]) error
+	proof(context.Context, *StateProofRequest) (*StateProof, error)
+}
+
+var stateDesc = grpc.ServiceDesc{
+	ServiceName: StateGrpcService,
+	HandlerType: (*stateServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(StateGrpcService, "Roots", stateServer.roots),
+		rpc.UnaryMethod(StateGrpcService, "Proof", stateServer.proof),
+	},
+	Streams: []grpc.StreamDesc{
+		rpc.ServerStreamMethod("RootsStream", stateServer.rootsStream),
+	},
+	Metadata: "api/grpcserver/state_service.go",
+}
+
+// RegisterHandlerService registers the state routes with the json gateway.
+func (s *StateService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, StateRootsPath, s.handleRoots); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, StateRootsStreamPath, s.handleStream); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, StateProofPath, s.handleProof)
+}
+
+// String returns the name of this service.
+func (s *StateService) String() string {
+	return "StateService"
+}
+
+// Roots returns state roots of the applied layers in the range [start, end].
+func (s *StateService) Roots(ctx context.Context, start, end types.LayerID) ([]StateRoot, error) {
+	if err := checkLayerRange(start, end); err != nil {
+		return nil, err
+	}
+	stored, err := layers.StateRoots(s.db, start, end)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := make([]StateRoot, 0, len(stored))
+	for _, root := range stored {
+		rst = append(rst, toStateRoot(root))
+	}
+	return rst, nil
+}
+
+// Proof returns the proof for the state of the account after the layer was applied.
+func (s *StateService) Proof(ctx context.Context, address types.Address, layer types.LayerID) (*StateProof, error) {
+	root, err := layers.GetStateRoot(s.db, layer)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		return nil, apiError(codes.NotFound, ReasonNotFound, fmt.Sprintf("layer %s is not applied", layer))
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	proof, err := vm.AccountStateProof(s.db, address, layer)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		return nil, apiError(codes.NotFound, ReasonNotFound, "account doesn't exist")
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	if proof.Root != root {
+		return nil, apiError(codes.Internal, ReasonInternal,
+			fmt.Sprintf("computed state root %s doesn't match stored %s", proof.Root, root))
+	}
+	account, err := accounts.Get(s.db, address, layer)
+	if err != nil {
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	rst := &StateProof{
+		Layer:     layer.Uint32(),
+		Address:   address.String(),
+		Balance:   account.Balance,
+		NextNonce: account.NextNonce,
+		Root:      hex.EncodeToString(proof.Root[:]),
+		Index:     proof.Index,
+		Proof:     make([]string, 0, len(proof.Nodes)),
+	}
+	if account.TemplateAddress != nil {
+		rst.Template = account.TemplateAddress.String()
+		rst.State = hex.EncodeToString(account.State)
+	}
+	for _, node := range proof.Nodes {
+		rst.Proof = append(rst.Proof, hex.EncodeToString(node[:]))
+	}
+	return rst, nil
+}
+
+func toStateRoot(root layers.StateRoot) StateRoot {
+	return StateRoot{Layer: root.Layer.Uint32(), Root: hex.EncodeToString(root.Root[:])}
+}
+
+func (s *StateService) roots(ctx context.Context, req *StateRootsRequest) (*StateRootsResponse, error) {
+	end := req.EndLayer
+	if end == 0 {
+		end = req.StartLayer
+	}
+	roots, err := s.Roots(ctx, types.LayerID(req.StartLayer), types.LayerID(end))
+	if err != nil {
+		return nil, err
+	}
+	return &StateRootsResponse{Roots: roots}, nil
+}
+
+func (s *StateService) handleRoots(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	start, end, err := layerRange(r)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.roots(r.Context(), &StateRootsRequest{StartLayer: start.Uint32(), EndLayer: end.Uint32()})
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (s *StateService) proof(ctx context.Context, req *StateProofRequest) (*StateProof, error) {
+	if req.Address == "" {
+		return nil, apiError(codes.InvalidArgument, ReasonMissingArgument, "address is required")
+	}
+	address, err := types.StringToAddress(req.Address)
+	if err != nil {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidAddress, err.Error())
+	}
+	if req.Layer != nil {
+		return s.Proof(ctx, address, types.LayerID(*req.Layer))
+	}
+	last, err := layers.LastStateRoot(s.db)
+	switch {
+	case errors.Is(err, sql.ErrNotFound):
+		return nil, apiError(codes.NotFound, ReasonNotFound, "no layers were applied")
+	case err != nil:
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	return s.Proof(ctx, address, last.Layer)
+}
+
+func (s *StateService) handleProof(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	req := StateProofRequest{Address: r.URL.Query().Get("address")}
+	layer, exists, err := layerParam(r, "layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if exists {
+		value := layer.Uint32()
+		req.Layer = &value
+	}
+	proof, err := s.proof(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proof)
+}
+
+// rootsStream sends state roots of the layers as they are applied. If the start layer is set
+// stored roots starting from that layer are sent first.
+func (s *StateService) rootsStream(req *StateRootsStreamRequest, stream rpc.ServerStream[StateRoot]) error {
+	sub, err := events.Subscribe[layers.StateRoot]()
+	if err != nil {
+		return apiError(codes.Internal, ReasonInternal, err.Error())
+	}
+	defer sub.Close()
+
+	send := func(root layers.StateRoot) error {
+		rst := toStateRoot(root)
+		return stream.Send(&rst)
+	}
+	var (
+		start  types.LayerID
+		stored []layers.StateRoot
+	)
+	if req.StartLayer != nil {
+		start = types.LayerID(*req.StartLayer)
+		stored, err = layers.StateRoots(s.db, start, math.MaxUint32)
+		if err != nil {
+			return apiError(codes.Internal, ReasonInternal, err.Error())
+		}
+	}
+	// header is sent after subscribing, so that the client can wait until the stream is initialized
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	// roots that were already replayed from the database are skipped,
+	// unless the layer was reverted and applied again
+	replayed := map[types.LayerID]types.Hash32{}
+	for _, root := range stored {
+		if err := send(root); err != nil {
+			return err
+		}
+		replayed[root.Layer] = root.Root
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.Full():
+			return apiError(codes.Unavailable, ReasonInternal, "subscriber is too slow")
+		case root := <-sub.Out():
+			if root.Layer < start {
+				continue
+			}
+			if prev, exists := replayed[root.Layer]; exists {
+				delete(replayed, root.Layer)
+				if prev == root.Root {
+					continue
+				}
+			}
+			if err := send(root); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *StateService) handleStream(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req StateRootsStreamRequest
+	start, replay, err := layerParam(r, "start_layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if replay {
+		value := start.Uint32()
+		req.StartLayer = &value
+	}
+	rpc.ServeNDJSON(w, r, func(stream rpc.ServerStream[StateRoot]) error {
+		return s.rootsStream(&req, stream)
+	})
+}
@@ -0,0 +1,211 @@
+package grpcserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+	vm "github.com/spacemeshos/go-spacemesh/genvm"
+	"github.com/spacemeshos/go-spacemesh/genvm/stateproof"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+func TestStateService(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	db := sql.InMemory()
+	var roots []layers.StateRoot
+	for i := byte(1); i <= 3; i++ {
+		layer := types.LayerID(i)
+		require.NoError(t, accounts.Update(db, &types.Account{
+			Layer:     layer,
+			Address:   types.GenerateAddress([]byte{i}),
+			Balance:   uint64(i) * 100,
+			NextNonce: uint64(i),
+		}))
+		root, err := vm.StateRootAt(db, layer)
+		require.NoError(t, err)
+		require.NoError(t, layers.SetStateRoot(db, layer, root))
+		roots = append(roots, layers.StateRoot{Layer: layer, Root: root})
+	}
+
+	svc := NewStateService(db)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	t.Run("roots", func(t *testing.T) {
+		rst, err := svc.Roots(context.Background(), 2, 5)
+		require.NoError(t, err)
+		require.Equal(t, []StateRoot{toStateRoot(roots[1]), toStateRoot(roots[2])}, rst)
+
+		_, err = svc.Roots(context.Background(), 5, 1)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonInvalidArgument, reason)
+	})
+	t.Run("json", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s?start_layer=1&end_layer=1", cfg.JSONListener, StateRootsPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst struct {
+			Roots []StateRoot `json:"roots"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, []StateRoot{toStateRoot(roots[0])}, rst.Roots)
+	})
+	t.Run("proof", func(t *testing.T) {
+		get := func(address types.Address, query string) (*http.Response, *StateProof) {
+			resp, err := http.Get(fmt.Sprintf("http://%s%s?address=%s%s",
+				cfg.JSONListener, StateProofPath, address.String(), query))
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			if resp.StatusCode != http.StatusOK {
+				return resp, nil
+			}
+			var rst StateProof
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+			return resp, &rst
+		}
+		verify := func(rst *StateProof, root layers.StateRoot) {
+			require.Equal(t, root.Layer.Uint32(), rst.Layer)
+			require.Equal(t, hex.EncodeToString(root.Root[:]), rst.Root)
+			address, err := types.StringToAddress(rst.Address)
+			require.NoError(t, err)
+			proof := &stateproof.Proof{Layer: root.Layer, Root: root.Root, Index: rst.Index}
+			for _, node := range rst.Proof {
+				decoded, err := hex.DecodeString(node)
+				require.NoError(t, err)
+				proof.Nodes = append(proof.Nodes, types.BytesToHash(decoded))
+			}
+			require.NoError(t, stateproof.Verify(&types.Account{
+				Address:   address,
+				Balance:   rst.Balance,
+				NextNonce: rst.NextNonce,
+			}, proof))
+		}
+
+		address := types.GenerateAddress([]byte{2})
+		resp, rst := get(address, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, 200, rst.Balance)
+		verify(rst, roots[2])
+
+		address = types.GenerateAddress([]byte{1})
+		resp, rst = get(address, "&layer=1")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.EqualValues(t, 100, rst.Balance)
+		verify(rst, roots[0])
+
+		// account is created in the later layer
+		resp, _ = get(types.GenerateAddress([]byte{3}), "&layer=2")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		// layer is not applied
+		resp, _ = get(address, "&layer=9")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = get(types.GenerateAddress([]byte{9}), "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("stream", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			fmt.Sprintf("http://%s%s?start_layer=2", cfg.JSONListener, StateRootsStreamPath), nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		scanner := bufio.NewScanner(resp.Body)
+		next := func() StateRoot {
+			require.True(t, scanner.Scan(), scanner.Err())
+			var rst StateRoot
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &rst))
+			return rst
+		}
+		require.Equal(t, toStateRoot(roots[1]), next())
+		require.Equal(t, toStateRoot(roots[2]), next())
+
+		// already replayed
+		events.ReportStateRoot(roots[2])
+		// applied again after revert
+		reapplied := layers.StateRoot{Layer: 3, Root: types.RandomHash()}
+		events.ReportStateRoot(reapplied)
+		require.Equal(t, toStateRoot(reapplied), next())
+		applied := layers.StateRoot{Layer: 4, Root: types.RandomHash()}
+		events.ReportStateRoot(applied)
+		require.Equal(t, toStateRoot(applied), next())
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[StateRootsRequest, StateRootsResponse](
+			ctx, conn, StateGrpcService, "Roots", rpc.JSON, &StateRootsRequest{StartLayer: 1, EndLayer: 2},
+		)
+		require.NoError(t, err)
+		require.Equal(t, []StateRoot{toStateRoot(roots[0]), toStateRoot(roots[1])}, rst.Roots)
+		_, err = rpc.Invoke[StateRootsRequest, StateRootsResponse](
+			ctx, conn, StateGrpcService, "Roots", rpc.JSON, &StateRootsRequest{StartLayer: 5, EndLayer: 1},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		layer := uint32(1)
+		proof, err := rpc.Invoke[StateProofRequest, StateProof](
+			ctx, conn, StateGrpcService, "Proof", rpc.JSON,
+			&StateProofRequest{Address: types.GenerateAddress([]byte{1}).String(), Layer: &layer},
+		)
+		require.NoError(t, err)
+		require.EqualValues(t, 100, proof.Balance)
+		require.Equal(t, hex.EncodeToString(roots[0].Root[:]), proof.Root)
+		proof, err = rpc.Invoke[StateProofRequest, StateProof](
+			ctx, conn, StateGrpcService, "Proof", rpc.JSON,
+			&StateProofRequest{Address: types.GenerateAddress([]byte{3}).String()},
+		)
+		require.NoError(t, err)
+		require.Equal(t, roots[2].Layer.Uint32(), proof.Layer)
+		_, err = rpc.Invoke[StateProofRequest, StateProof](
+			ctx, conn, StateGrpcService, "Proof", rpc.JSON, &StateProofRequest{},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = rpc.Invoke[StateProofRequest, StateProof](
+			ctx, conn, StateGrpcService, "Proof", rpc.JSON,
+			&StateProofRequest{Address: types.GenerateAddress([]byte{9}).String()},
+		)
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		start := uint32(3)
+		stream, err := rpc.Stream[StateRootsStreamRequest, StateRoot](
+			ctx, conn, StateGrpcService, "RootsStream", rpc.JSON, &StateRootsStreamRequest{StartLayer: &start},
+		)
+		require.NoError(t, err)
+		_, err = stream.Header()
+		require.NoError(t, err)
+		root, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, toStateRoot(roots[2]), *root)
+		applied := layers.StateRoot{Layer: 5, Root: types.RandomHash()}
+		events.ReportStateRoot(applied)
+		root, err = stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, toStateRoot(applied), *root)
+	})
+}
@@ -0,0 +1,263 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+)
+
+const (
+	// TortoiseProgressPath serves the state of verification for layers after the verified layer.
+	// The number of returned layers can be limited with the limit parameter.
+	TortoiseProgressPath = "/v1/tortoise/progress"
+	// TortoiseOpinionPath serves the local opinion on the block, selected by layer and hex encoded block id.
+	TortoiseOpinionPath = "/v1/tortoise/opinion"
+	// TortoiseGrpcService is the name of the grpc service that serves Progress and Opinion methods.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	TortoiseGrpcService = "spacemesh.node.v1.TortoiseService"
+
+	// MaxTortoiseLayers is the largest number of undecided layers that can be requested at once.
+	MaxTortoiseLayers = 1000
+	// DefaultTortoiseLayers is the number of undecided layers that are returned if limit is not set.
+	DefaultTortoiseLayers = 100
+)
+
+// TortoiseProgressRequest is the request of the Progress method, zero limit is replaced with DefaultTortoiseLayers.
+type TortoiseProgressRequest struct {
+	Limit int `json:"limit"`
+}
+
+// TortoiseOpinionRequest is the request of the Opinion method, block is the hex encoded block id.
+type TortoiseOpinionRequest struct {
+	Layer uint32 `json:"layer"`
+	Block string `json:"block"`
+}
+
+// TortoiseProgress explains why layers after the verified layer are not verified yet.
+type TortoiseProgress struct {
+	Mode      string `json:"mode"`
+	Last      uint32 `json:"last"`
+	Processed uint32 `json:"processed"`
+	Verified  uint32 `json:"verified"`
+	Evicted   uint32 `json:"evicted"`
+	// Pending is the lowest layer where opinion changed and wasn't applied yet. Zero if there are no changes.
+	Pending   uint32                  `json:"pending"`
+	Undecided []TortoiseLayerProgress `json:"undecided"`
+}
+
+// TortoiseLayerProgress compares the weight counted for the layer with the threshold required to verify it.
+// Margin is counted by the verifying mode, margins of the blocks and empty weight by the full mode.
+type TortoiseLayerProgress struct {
+	Layer          uint32                 `json:"layer"`
+	HareTerminated bool                   `json:"hare_terminated"`
+	Opinion        string                 `json:"opinion"`
+	Threshold      float64                `json:"threshold"`
+	Margin         float64                `json:"margin"`
+	Uncounted      float64                `json:"uncounted"`
+	Empty          float64                `json:"empty"`
+	Blocks         []TortoiseBlockOpinion `json:"blocks"`
+}
+
+// TortoiseBlockOpinion is the local opinion on the block.
+type TortoiseBlockOpinion struct {
+	Block    string  `json:"block"`
+	Layer    uint32  `json:"layer"`
+	Height   uint64  `json:"height"`
+	Data     bool    `json:"data"`
+	Hare     string  `json:"hare"`
+	Validity string  `json:"validity"`
+	Margin   float64 `json:"margin"`
+	// Vote is the vote on the block in the next ballot, Reason explains how it was chosen.
+	Vote   string `json:"vote"`
+	Reason string `json:"reason"`
+}
+
+// TortoiseService exposes the state of the tortoise, so that operators can find
+// why verification doesn't make progress. It is served on the json gateway and as TortoiseGrpcService.
+type TortoiseService struct {
+	tortoise tortoiseIntrospection
+}
+
+// NewTortoiseService creates a new tortoise service.
+func NewTortoiseService(tortoise tortoiseIntrospection) *TortoiseService {
+	return &TortoiseService{tortoise: tortoise}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *TortoiseService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&tortoiseDesc, s)
+}
+
+type tortoiseServer interface {
+	progress(context.Context, *TortoiseProgressRequest) (*TortoiseProgress, error)
+	opinion(context.Context, *TortoiseOpinionRequest) (*TortoiseBlockOpinion, error)
+}
+
+var tortoiseDesc = grpc.ServiceDesc{
+	ServiceName: TortoiseGrpcService,
+	HandlerType: (*tortoiseServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(TortoiseGrpcService, "Progress", tortoiseServer.progress),
+		rpc.UnaryMethod(TortoiseGrpcService, "Opinion", tortoiseServer.opinion),
+	},
+	Metadata: "api/grpcserver/tortoise_service.go",
+}
+
+// RegisterHandlerService registers the tortoise routes with the json gateway.
+func (s *TortoiseService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodGet, TortoiseProgressPath, s.handleProgress); err != nil {
+		return err
+	}
+	return mux.HandlePath(http.MethodGet, TortoiseOpinionPath, s.handleOpinion)
+}
+
+// String returns the name of this service.
+func (s *TortoiseService) String() string {
+	return "TortoiseService"
+}
+
+// Progress returns the state of verification for at most limit layers after the verified layer.
+func (s *TortoiseService) Progress(ctx context.Context, limit int) (*TortoiseProgress, error) {
+	if limit <= 0 || limit > MaxTortoiseLayers {
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("limit should be in range [1, %d]", MaxTortoiseLayers))
+	}
+	progress := s.tortoise.Progress(limit)
+	rst := &TortoiseProgress{
+		Mode:      progress.Mode.String(),
+		Last:      progress.Last.Uint32(),
+		Processed: progress.Processed.Uint32(),
+		Verified:  progress.Verified.Uint32(),
+		Evicted:   progress.Evicted.Uint32(),
+		Pending:   progress.Pending.Uint32(),
+		Undecided: make([]TortoiseLayerProgress, 0, len(progress.Undecided)),
+	}
+	for _, layer := range progress.Undecided {
+		lp := TortoiseLayerProgress{
+			Layer:          layer.Layer.Uint32(),
+			HareTerminated: layer.HareTerminated,
+			Opinion:        hex.EncodeToString(layer.Opinion[:]),
+			Threshold:      layer.Threshold,
+			Margin:         layer.Margin,
+			Uncounted:      layer.Uncounted,
+			Empty:          layer.Empty,
+			Blocks:         make([]TortoiseBlockOpinion, 0, len(layer.Blocks)),
+		}
+		for _, block := range layer.Blocks {
+			lp.Blocks = append(lp.Blocks, toBlockOpinion(block))
+		}
+		rst.Undecided = append(rst.Undecided, lp)
+	}
+	return rst, nil
+}
+
+// Opinion returns the local opinion on the block in the layer.
+func (s *TortoiseService) Opinion(
+	ctx context.Context,
+	layer types.LayerID,
+	id types.BlockID,
+) (*TortoiseBlockOpinion, error) {
+	opinion, exists := s.tortoise.BlockOpinion(layer, id)
+	if !exists {
+		return nil, apiError(codes.NotFound, ReasonNotFound,
+			fmt.Sprintf("block %s in layer %d is not in the tortoise state", id, layer))
+	}
+	rst := toBlockOpinion(opinion)
+	return &rst, nil
+}
+
+func (s *TortoiseService) progress(ctx context.Context, req *TortoiseProgressRequest) (*TortoiseProgress, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultTortoiseLayers
+	}
+	return s.Progress(ctx, limit)
+}
+
+func (s *TortoiseService) opinion(ctx context.Context, req *TortoiseOpinionRequest) (*TortoiseBlockOpinion, error) {
+	id, err := parseBlockID(req.Block)
+	if err != nil {
+		return nil, err
+	}
+	return s.Opinion(ctx, types.LayerID(req.Layer), id)
+}
+
+func parseBlockID(value string) (types.BlockID, error) {
+	var id types.BlockID
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != len(id) {
+		return id, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			fmt.Sprintf("block must be %d hex encoded bytes", len(id)))
+	}
+	copy(id[:], decoded)
+	return id, nil
+}
+
+func toBlockOpinion(opinion tortoise.BlockOpinion) TortoiseBlockOpinion {
+	return TortoiseBlockOpinion{
+		Block:    hex.EncodeToString(opinion.Header.ID[:]),
+		Layer:    opinion.Header.LayerID.Uint32(),
+		Height:   opinion.Header.Height,
+		Data:     opinion.Data,
+		Hare:     opinion.Hare,
+		Validity: opinion.Validity,
+		Margin:   opinion.Margin,
+		Vote:     opinion.Vote,
+		Reason:   opinion.Reason,
+	}
+}
+
+func (s *TortoiseService) handleProgress(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	limit := DefaultTortoiseLayers
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+				fmt.Sprintf("parse limit: %s", err)))
+			return
+		}
+		limit = parsed
+	}
+	rst, err := s.Progress(r.Context(), limit)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
+
+func (s *TortoiseService) handleOpinion(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	layer, exists, err := layerParam(r, "layer")
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	if !exists {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonMissingArgument, "layer must be set"))
+		return
+	}
+	id, err := parseBlockID(r.URL.Query().Get("block"))
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	rst, err := s.Opinion(r.Context(), layer, id)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
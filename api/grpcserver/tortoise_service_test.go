@@ -0,0 +1,176 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+)
+
+func TestTortoiseService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	trtl := NewMocktortoiseIntrospection(ctrl)
+	svc := NewTortoiseService(trtl)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	block := types.BlockID{1, 2, 3}
+	opinion := tortoise.BlockOpinion{
+		Header:   types.Vote{ID: block, LayerID: 11, Height: 100},
+		Data:     true,
+		Hare:     "abstain",
+		Validity: "abstain",
+		Margin:   -10,
+		Vote:     "abstain",
+		Reason:   "hare",
+	}
+	progress := tortoise.Progress{
+		Mode:      tortoise.Full,
+		Last:      12,
+		Processed: 12,
+		Verified:  10,
+		Evicted:   2,
+		Pending:   11,
+		Undecided: []tortoise.LayerProgress{{
+			Layer:     11,
+			Opinion:   types.Hash32{1},
+			Threshold: 100,
+			Margin:    50,
+			Uncounted: 20,
+			Blocks:    []tortoise.BlockOpinion{opinion},
+		}},
+	}
+	expected := TortoiseBlockOpinion{
+		Block:    hex.EncodeToString(block[:]),
+		Layer:    11,
+		Height:   100,
+		Data:     true,
+		Hare:     "abstain",
+		Validity: "abstain",
+		Margin:   -10,
+		Vote:     "abstain",
+		Reason:   "hare",
+	}
+
+	get := func(path string) ([]byte, int) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, path))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return buf, resp.StatusCode
+	}
+
+	t.Run("progress", func(t *testing.T) {
+		trtl.EXPECT().Progress(5).Return(progress)
+		rst, err := svc.Progress(context.Background(), 5)
+		require.NoError(t, err)
+		require.Equal(t, &TortoiseProgress{
+			Mode:      "full",
+			Last:      12,
+			Processed: 12,
+			Verified:  10,
+			Evicted:   2,
+			Pending:   11,
+			Undecided: []TortoiseLayerProgress{{
+				Layer:     11,
+				Opinion:   hex.EncodeToString(progress.Undecided[0].Opinion[:]),
+				Threshold: 100,
+				Margin:    50,
+				Uncounted: 20,
+				Blocks:    []TortoiseBlockOpinion{expected},
+			}},
+		}, rst)
+
+		for _, limit := range []int{0, MaxTortoiseLayers + 1} {
+			_, err = svc.Progress(context.Background(), limit)
+			reason, _, _ := ErrorReasonOf(err)
+			require.Equal(t, ReasonInvalidArgument, reason)
+		}
+	})
+	t.Run("progress json", func(t *testing.T) {
+		trtl.EXPECT().Progress(DefaultTortoiseLayers).Return(progress)
+		buf, code := get(TortoiseProgressPath)
+		require.Equal(t, http.StatusOK, code)
+		var rst TortoiseProgress
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.EqualValues(t, 10, rst.Verified)
+		require.Len(t, rst.Undecided, 1)
+
+		trtl.EXPECT().Progress(1).Return(progress)
+		_, code = get(TortoiseProgressPath + "?limit=1")
+		require.Equal(t, http.StatusOK, code)
+
+		_, code = get(TortoiseProgressPath + "?limit=all")
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+	t.Run("opinion", func(t *testing.T) {
+		trtl.EXPECT().BlockOpinion(types.LayerID(11), block).Return(opinion, true)
+		rst, err := svc.Opinion(context.Background(), 11, block)
+		require.NoError(t, err)
+		require.Equal(t, &expected, rst)
+
+		trtl.EXPECT().BlockOpinion(types.LayerID(12), block).Return(tortoise.BlockOpinion{}, false)
+		_, err = svc.Opinion(context.Background(), 12, block)
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonNotFound, reason)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		trtl.EXPECT().Progress(DefaultTortoiseLayers).Return(progress)
+		rst, err := rpc.Invoke[TortoiseProgressRequest, TortoiseProgress](
+			ctx, conn, TortoiseGrpcService, "Progress", rpc.JSON, &TortoiseProgressRequest{},
+		)
+		require.NoError(t, err)
+		require.EqualValues(t, 10, rst.Verified)
+		require.Len(t, rst.Undecided, 1)
+
+		trtl.EXPECT().BlockOpinion(types.LayerID(11), block).Return(opinion, true)
+		bo, err := rpc.Invoke[TortoiseOpinionRequest, TortoiseBlockOpinion](
+			ctx, conn, TortoiseGrpcService, "Opinion", rpc.JSON,
+			&TortoiseOpinionRequest{Layer: 11, Block: hex.EncodeToString(block[:])},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, *bo)
+
+		_, err = rpc.Invoke[TortoiseOpinionRequest, TortoiseBlockOpinion](
+			ctx, conn, TortoiseGrpcService, "Opinion", rpc.JSON, &TortoiseOpinionRequest{Layer: 11, Block: "0102"},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("opinion json", func(t *testing.T) {
+		trtl.EXPECT().BlockOpinion(types.LayerID(11), block).Return(opinion, true)
+		buf, code := get(fmt.Sprintf("%s?layer=11&block=%s", TortoiseOpinionPath, hex.EncodeToString(block[:])))
+		require.Equal(t, http.StatusOK, code)
+		var rst TortoiseBlockOpinion
+		require.NoError(t, json.Unmarshal(buf, &rst))
+		require.Equal(t, expected, rst)
+
+		trtl.EXPECT().BlockOpinion(types.LayerID(12), block).Return(tortoise.BlockOpinion{}, false)
+		_, code = get(fmt.Sprintf("%s?layer=12&block=%s", TortoiseOpinionPath, hex.EncodeToString(block[:])))
+		require.Equal(t, http.StatusNotFound, code)
+
+		_, code = get(fmt.Sprintf("%s?block=%s", TortoiseOpinionPath, hex.EncodeToString(block[:])))
+		require.Equal(t, http.StatusBadRequest, code)
+		_, code = get(TortoiseOpinionPath + "?layer=11&block=0102")
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+}
@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
+)
+
+// MempoolStreamPath streams admission, rejection, replacement and eviction of transactions in the mempool
+// of the node as newline delimited json on the json gateway. Events are filtered by the principal
+// if the principal parameter is set. The stream is closed if the client doesn't keep up with events.
+const MempoolStreamPath = "/v1/transaction/mempool/stream"
+
+// MempoolEvent is the json encoding of the change of the transaction in the mempool.
+// Kind is one of admitted, rejected, replaced or evicted.
+type MempoolEvent struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`
+	Principal string `json:"principal,omitempty"`
+	Nonce     uint64 `json:"nonce"`
+	// Reason is set for rejected and evicted transactions.
+	Reason string `json:"reason,omitempty"`
+	// ReplacedBy is the hex encoded id of the transaction that replaced the transaction with the same nonce.
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+func toMempoolEvent(ev events.MempoolEvent) MempoolEvent {
+	rst := MempoolEvent{
+		Kind:   ev.Kind.String(),
+		ID:     hex.EncodeToString(ev.ID[:]),
+		Nonce:  ev.Nonce,
+		Reason: ev.Reason,
+	}
+	if ev.Principal != (types.Address{}) {
+		rst.Principal = ev.Principal.String()
+	}
+	if ev.ReplacedBy != (types.TransactionID{}) {
+		rst.ReplacedBy = hex.EncodeToString(ev.ReplacedBy[:])
+	}
+	return rst
+}
+
+func (s TransactionService) handleMempoolStream(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var matcher func(*events.MempoolEvent) bool
+	if param := r.URL.Query().Get("principal"); param != "" {
+		principal, err := types.StringToAddress(param)
+		if err != nil {
+			rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidAddress,
+				fmt.Sprintf("parse principal: %s", err)))
+			return
+		}
+		matcher = func(ev *events.MempoolEvent) bool {
+			return ev.Principal == principal
+		}
+	}
+	sub, err := events.SubscribeMatched(matcher)
+	if err != nil {
+		rpc.WriteError(w, apiError(codes.Internal, ReasonInternal, err.Error()))
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Full():
+			return
+		case ev := <-sub.Out():
+			if err := enc.Encode(toMempoolEvent(ev)); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
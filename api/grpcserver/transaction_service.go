@@ -3,9 +3,12 @@ package grpcserver
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -17,7 +20,9 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
@@ -26,6 +31,19 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/transactions"
 )
 
+const (
+	// MaxTransactionBatch is the max number of transactions accepted by ParseAndSubmitBatch.
+	MaxTransactionBatch = 100
+	// SubmitBatchPath is the json gateway path that serves ParseAndSubmitBatch.
+	SubmitBatchPath = "/v1/transaction/submitbatch"
+	// TransactionBatchService is the name of the grpc service that serves ParseAndSubmitBatch
+	// with the SubmitBatch method. Messages of the service are encoded in json (see rpc.JSON).
+	TransactionBatchService = "spacemesh.node.v1.TransactionService"
+
+	// maxSubmitBatchBody limits the size of the json body accepted on SubmitBatchPath.
+	maxSubmitBatchBody = 16 << 20
+)
+
 // TransactionService exposes transaction data, and a submit tx endpoint.
 type TransactionService struct {
 	db        *sql.Database
@@ -39,9 +57,29 @@ type TransactionService struct {
 // RegisterService registers this service with a grpc server instance.
 func (s TransactionService) RegisterService(server *grpc.Server) {
 	pb.RegisterTransactionServiceServer(server, s)
+	server.RegisterService(&transactionBatchDesc, s)
+}
+
+type transactionBatchServer interface {
+	SubmitBatch(context.Context, *SubmitBatchRequest) (*SubmitBatchResponse, error)
+}
+
+var transactionBatchDesc = grpc.ServiceDesc{
+	ServiceName: TransactionBatchService,
+	HandlerType: (*transactionBatchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(TransactionBatchService, "SubmitBatch", transactionBatchServer.SubmitBatch),
+	},
+	Metadata: "api/grpcserver/transaction_service.go",
 }
 
 func (s TransactionService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodPost, SubmitBatchPath, s.handleSubmitBatch); err != nil {
+		return err
+	}
+	if err := mux.HandlePath(http.MethodGet, MempoolStreamPath, s.handleMempoolStream); err != nil {
+		return err
+	}
 	return pb.RegisterTransactionServiceHandlerServer(context.Background(), mux, s)
 }
 
@@ -74,20 +112,20 @@ func (s TransactionService) ParseTransaction(
 	in *pb.ParseTransactionRequest,
 ) (*pb.ParseTransactionResponse, error) {
 	if len(in.Transaction) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "empty transaction")
+		return nil, apiError(codes.InvalidArgument, ReasonTxEmpty, "empty transaction")
 	}
 	raw := types.NewRawTx(in.Transaction)
 	req := s.conState.Validation(raw)
 	header, err := req.Parse()
 	if errors.Is(err, core.ErrNotSpawned) {
-		return nil, status.Error(codes.NotFound, "account is not spawned")
+		return nil, apiError(codes.NotFound, ReasonAccountNotSpawned, "account is not spawned")
 	} else if errors.Is(err, core.ErrMalformed) {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, apiError(codes.InvalidArgument, ReasonTxMalformed, err.Error())
 	} else if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, apiError(codes.Internal, ReasonInternal, err.Error())
 	}
 	if in.Verify && !req.Verify() {
-		return nil, status.Error(codes.InvalidArgument, "signature is invalid")
+		return nil, apiError(codes.InvalidArgument, ReasonTxInvalidSignature, "signature is invalid")
 	}
 	tx := types.Transaction{RawTx: raw, TxHeader: header}
 	return &pb.ParseTransactionResponse{Tx: castTransaction(&tx)}, nil
@@ -99,22 +137,19 @@ func (s TransactionService) SubmitTransaction(
 	in *pb.SubmitTransactionRequest,
 ) (*pb.SubmitTransactionResponse, error) {
 	if len(in.Transaction) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "`Transaction` payload empty")
+		return nil, apiError(codes.InvalidArgument, ReasonTxEmpty, "`Transaction` payload empty")
 	}
 
 	if !s.syncer.IsSynced(ctx) {
-		return nil, status.Error(
+		return nil, apiError(
 			codes.FailedPrecondition,
+			ReasonNotSynced,
 			"Cannot submit transaction, node is not in sync yet, try again later",
 		)
 	}
 
-	if err := s.txHandler.VerifyAndCacheTx(ctx, in.Transaction); err != nil {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Failed to verify transaction: %s", err.Error()))
-	}
-
-	if err := s.publisher.Publish(ctx, pubsub.TxProtocol, in.Transaction); err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to publish transaction: %s", err.Error()))
+	if err := s.submit(ctx, in.Transaction); err != nil {
+		return nil, err
 	}
 
 	raw := types.NewRawTx(in.Transaction)
@@ -127,6 +162,128 @@ func (s TransactionService) SubmitTransaction(
 	}, nil
 }
 
+// submit verifies the transaction and publishes it to the network.
+func (s TransactionService) submit(ctx context.Context, tx []byte) error {
+	if err := s.txHandler.VerifyAndCacheTx(ctx, tx); err != nil {
+		return txError(err)
+	}
+
+	if err := s.publisher.Publish(ctx, pubsub.TxProtocol, tx); err != nil {
+		return apiError(codes.Internal, ReasonTxPublishFailed, fmt.Sprintf("Failed to publish transaction: %s", err.Error()))
+	}
+	return nil
+}
+
+// ParseAndSubmitBatch verifies and publishes every transaction independently. Result for each
+// transaction is returned at the same index, a failure of one transaction doesn't affect the others.
+// The whole batch is rejected only if it is empty, exceeds MaxTransactionBatch or the node is not synced.
+func (s TransactionService) ParseAndSubmitBatch(
+	ctx context.Context,
+	batch [][]byte,
+) ([]*pb.SubmitTransactionResponse, error) {
+	switch {
+	case len(batch) == 0:
+		return nil, apiError(codes.InvalidArgument, ReasonTxEmpty, "batch is empty")
+	case len(batch) > MaxTransactionBatch:
+		return nil, apiError(codes.InvalidArgument, ReasonBatchTooLarge,
+			fmt.Sprintf("batch is limited to %d transactions", MaxTransactionBatch),
+			"limit", strconv.Itoa(MaxTransactionBatch))
+	}
+	if !s.syncer.IsSynced(ctx) {
+		return nil, apiError(
+			codes.FailedPrecondition,
+			ReasonNotSynced,
+			"Cannot submit transactions, node is not in sync yet, try again later",
+		)
+	}
+
+	results := make([]*pb.SubmitTransactionResponse, len(batch))
+	for i, tx := range batch {
+		result := &pb.SubmitTransactionResponse{Status: &rpcstatus.Status{Code: int32(code.Code_OK)}}
+		results[i] = result
+		if len(tx) == 0 {
+			result.Status = apiStatus(codes.InvalidArgument, ReasonTxEmpty, "`Transaction` payload empty").Proto()
+			continue
+		}
+		raw := types.NewRawTx(tx)
+		result.Txstate = &pb.TransactionState{Id: &pb.TransactionId{Id: raw.ID[:]}}
+		if err := s.submit(ctx, tx); err != nil {
+			result.Status = status.Convert(err).Proto()
+			continue
+		}
+		result.Txstate.State = pb.TransactionState_TRANSACTION_STATE_MEMPOOL
+	}
+	return results, nil
+}
+
+// SubmitBatchRequest is the request of SubmitBatch and the json body accepted on SubmitBatchPath.
+// Transactions are encoded in base64, the same way as bytes in the rest of the json api.
+type SubmitBatchRequest struct {
+	Transactions [][]byte `json:"transactions"`
+}
+
+// SubmitBatchResponse is the response of SubmitBatch. Results are encoded in json
+// as a list of SubmitTransactionResponse under the "results" key.
+type SubmitBatchResponse struct {
+	Results []*pb.SubmitTransactionResponse
+}
+
+type submitBatchResults struct {
+	Results []json.RawMessage `json:"results"`
+}
+
+func (r *SubmitBatchResponse) MarshalJSON() ([]byte, error) {
+	encoded := submitBatchResults{Results: make([]json.RawMessage, len(r.Results))}
+	for i, result := range r.Results {
+		var err error
+		encoded.Results[i], err = protojson.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+func (r *SubmitBatchResponse) UnmarshalJSON(data []byte) error {
+	var encoded submitBatchResults
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	r.Results = make([]*pb.SubmitTransactionResponse, len(encoded.Results))
+	for i, result := range encoded.Results {
+		r.Results[i] = &pb.SubmitTransactionResponse{}
+		if err := protojson.Unmarshal(result, r.Results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubmitBatch serves ParseAndSubmitBatch on the grpc listeners.
+func (s TransactionService) SubmitBatch(ctx context.Context, req *SubmitBatchRequest) (*SubmitBatchResponse, error) {
+	results, err := s.ParseAndSubmitBatch(ctx, req.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	return &SubmitBatchResponse{Results: results}, nil
+}
+
+// handleSubmitBatch serves SubmitBatch on the json gateway.
+func (s TransactionService) handleSubmitBatch(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req SubmitBatchRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSubmitBatchBody)).Decode(&req); err != nil {
+		rpc.WriteError(w, apiError(codes.InvalidArgument, ReasonInvalidArgument, fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	resp, err := s.SubmitBatch(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // Get transaction and status for a given txid. It's not an error if we cannot find the tx,
 // we just return all nils.
 func (s TransactionService) getTransactionAndStatus(
@@ -154,7 +311,8 @@ func (s TransactionService) TransactionsState(
 	in *pb.TransactionsStateRequest,
 ) (*pb.TransactionsStateResponse, error) {
 	if in.TransactionId == nil || len(in.TransactionId) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "`TransactionId` must include one or more transaction IDs")
+		return nil, apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`TransactionId` must include one or more transaction IDs", "field", "TransactionId")
 	}
 
 	res := &pb.TransactionsStateResponse{}
@@ -191,7 +349,8 @@ func (s TransactionService) TransactionsStateStream(
 	stream pb.TransactionService_TransactionsStateStreamServer,
 ) error {
 	if in.TransactionId == nil || len(in.TransactionId) == 0 {
-		return status.Error(codes.InvalidArgument, "`TransactionId` must include one or more transaction IDs")
+		return apiError(codes.InvalidArgument, ReasonInvalidArgument,
+			"`TransactionId` must include one or more transaction IDs", "field", "TransactionId")
 	}
 
 	// The tx channel tells us about newly received and newly created transactions
@@ -268,7 +427,7 @@ func (s TransactionService) TransactionsStateStream(
 					layer.LayerID.Field().Zap(),
 					zap.Error(err),
 				)
-				return status.Error(codes.Internal, "error reading layer data")
+				return apiError(codes.Internal, ReasonInternal, "error reading layer data")
 			}
 
 			// Filter for any matching transactions in the reported layer
@@ -317,7 +476,7 @@ func (s TransactionService) TransactionsStateStream(
 									layer.Field().Zap(),
 									zap.Error(err),
 								)
-								return status.Error(codes.Internal, "error retrieving tx data")
+								return apiError(codes.Internal, ReasonInternal, "error retrieving tx data")
 							}
 
 							res.Transaction = castTransaction(&tx.Transaction)
@@ -351,7 +510,8 @@ func (s TransactionService) StreamResults(
 	if len(in.Address) > 0 {
 		addr, err := types.StringToAddress(in.Address)
 		if err != nil {
-			return fmt.Errorf("failed to parse in.Address `%s`: %w", in.Address, err)
+			return apiError(codes.InvalidArgument, ReasonInvalidAddress,
+				fmt.Sprintf("failed to parse in.Address `%s`: %v", in.Address, err), "field", "Address")
 		}
 		filter.Address = &addr
 	}
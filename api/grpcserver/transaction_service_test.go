@@ -1,11 +1,17 @@
 package grpcserver
 
 import (
+	"bufio"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
 	"runtime"
+	"strconv"
 	"testing"
 	"time"
 
@@ -13,14 +19,19 @@ import (
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
 	"github.com/spacemeshos/go-spacemesh/common/fixture"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/transactions"
@@ -132,6 +143,27 @@ func TestTransactionService_StreamResults(t *testing.T) {
 			})
 		}
 	})
+	t.Run("Skipped", func(t *testing.T) {
+		principal := types.GenerateAddress([]byte{1})
+		skipped := types.Transaction{
+			RawTx:    types.NewRawTx([]byte{1, 2, 3}),
+			TxHeader: &types.TxHeader{Principal: principal},
+		}
+		require.NoError(t, db.WithTx(ctx, func(dtx *sql.Tx) error {
+			require.NoError(t, transactions.Add(dtx, &skipped, time.Time{}))
+			return transactions.AddSkipped(dtx, skipped.ID, 7, types.BlockID{1}, types.SkippedNonceTooLow)
+		}))
+		stream, err := client.StreamResults(ctx, &pb.TransactionResultsRequest{Address: principal.String()})
+		require.NoError(t, err)
+		received, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, skipped.ID[:], received.Tx.Id)
+		require.Equal(t, pb.TransactionResult_INVALID, received.Status)
+		require.Equal(t, types.SkippedNonceTooLow.String(), received.Message)
+		require.Equal(t, uint32(7), received.Layer)
+		_, err = stream.Recv()
+		require.ErrorIs(t, err, io.EOF)
+	})
 }
 
 func BenchmarkStreamResults(b *testing.B) {
@@ -296,3 +328,157 @@ func TestParseTransactions(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionService_ParseAndSubmitBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	syncer := NewMocksyncer(ctrl)
+	publisher := pubsubmocks.NewMockPublisher(ctrl)
+	txHandler := NewMocktxValidator(ctrl)
+	svc := NewTransactionService(sql.InMemory(), publisher, nil, nil, syncer, txHandler)
+
+	valid := []byte{1, 2, 3}
+	invalid := []byte{4, 5, 6}
+	txHandler.EXPECT().VerifyAndCacheTx(gomock.Any(), valid).Return(nil).AnyTimes()
+	txHandler.EXPECT().VerifyAndCacheTx(gomock.Any(), invalid).Return(fmt.Errorf("add: %w", txs.ErrBadNonce)).AnyTimes()
+	publisher.EXPECT().Publish(gomock.Any(), pubsub.TxProtocol, valid).Return(nil).AnyTimes()
+
+	t.Run("per tx results", func(t *testing.T) {
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(true)
+		results, err := svc.ParseAndSubmitBatch(context.Background(), [][]byte{valid, invalid, nil})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		require.Equal(t, int32(codes.OK), results[0].Status.Code)
+		require.Equal(t, types.NewRawTx(valid).ID.Bytes(), results[0].Txstate.Id.Id)
+		require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MEMPOOL, results[0].Txstate.State)
+
+		require.Equal(t, int32(codes.InvalidArgument), results[1].Status.Code)
+		require.Contains(t, results[1].Status.Message, "Failed to verify transaction")
+		reason, _, ok := ErrorReasonOf(status.ErrorProto(results[1].Status))
+		require.True(t, ok)
+		require.Equal(t, ReasonTxNonceTooLow, reason)
+		require.Equal(t, types.NewRawTx(invalid).ID.Bytes(), results[1].Txstate.Id.Id)
+		require.Equal(t, pb.TransactionState_TRANSACTION_STATE_UNSPECIFIED, results[1].Txstate.State)
+
+		require.Equal(t, int32(codes.InvalidArgument), results[2].Status.Code)
+		require.Nil(t, results[2].Txstate)
+	})
+	t.Run("not synced", func(t *testing.T) {
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(false)
+		_, err := svc.ParseAndSubmitBatch(context.Background(), [][]byte{valid})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonNotSynced, reason)
+	})
+	t.Run("batch limits", func(t *testing.T) {
+		_, err := svc.ParseAndSubmitBatch(context.Background(), nil)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = svc.ParseAndSubmitBatch(context.Background(), make([][]byte, MaxTransactionBatch+1))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		reason, md, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonBatchTooLarge, reason)
+		require.Equal(t, strconv.Itoa(MaxTransactionBatch), md["limit"])
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(true)
+		resp, err := rpc.Invoke[SubmitBatchRequest, SubmitBatchResponse](
+			ctx, conn, TransactionBatchService, "SubmitBatch", rpc.JSON,
+			&SubmitBatchRequest{Transactions: [][]byte{valid, invalid}},
+		)
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 2)
+		require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MEMPOOL, resp.Results[0].Txstate.State)
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Status.Code)
+
+		_, err = rpc.Invoke[SubmitBatchRequest, SubmitBatchResponse](
+			ctx, conn, TransactionBatchService, "SubmitBatch", rpc.JSON, &SubmitBatchRequest{},
+		)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		reason, _, _ := ErrorReasonOf(err)
+		require.Equal(t, ReasonTxEmpty, reason)
+	})
+	t.Run("json", func(t *testing.T) {
+		cfg, cleanup := launchJsonServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		syncer.EXPECT().IsSynced(gomock.Any()).Return(true)
+		body, err := json.Marshal(map[string][][]byte{"transactions": {valid, invalid}})
+		require.NoError(t, err)
+		buf, code := callEndpoint(ctx, t, fmt.Sprintf("http://%s%s", cfg.JSONListener, SubmitBatchPath), body)
+		require.Equal(t, http.StatusOK, code)
+		var res struct {
+			Results []json.RawMessage `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(buf, &res))
+		require.Len(t, res.Results, 2)
+		var first, second pb.SubmitTransactionResponse
+		require.NoError(t, protojson.Unmarshal(res.Results[0], &first))
+		require.NoError(t, protojson.Unmarshal(res.Results[1], &second))
+		require.Equal(t, pb.TransactionState_TRANSACTION_STATE_MEMPOOL, first.Txstate.State)
+		require.Equal(t, int32(codes.InvalidArgument), second.Status.Code)
+
+		buf, code = callEndpoint(ctx, t, fmt.Sprintf("http://%s%s", cfg.JSONListener, SubmitBatchPath), []byte("{}"))
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Contains(t, string(buf), "batch is empty")
+		require.Contains(t, string(buf), string(ReasonTxEmpty))
+	})
+}
+
+func TestTransactionService_MempoolStream(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	svc := NewTransactionService(sql.InMemory(), nil, nil, nil, nil, nil)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	principal := types.GenerateAddress([]byte("principal"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://%s%s?principal=%s", cfg.JSONListener, MempoolStreamPath, principal.String()), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	replaced, better := types.RandomTransactionID(), types.RandomTransactionID()
+	events.ReportMempool(events.MempoolEvent{
+		Kind:      events.MempoolAdmitted,
+		ID:        types.RandomTransactionID(),
+		Principal: types.GenerateAddress([]byte("other")),
+	})
+	events.ReportMempool(events.MempoolEvent{
+		Kind:       events.MempoolReplaced,
+		ID:         replaced,
+		Principal:  principal,
+		Nonce:      3,
+		ReplacedBy: better,
+	})
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan(), scanner.Err())
+	var ev MempoolEvent
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+	require.Equal(t, MempoolEvent{
+		Kind:       "replaced",
+		ID:         hex.EncodeToString(replaced[:]),
+		Principal:  principal.String(),
+		Nonce:      3,
+		ReplacedBy: hex.EncodeToString(better[:]),
+	}, ev)
+
+	resp, err = http.Get(fmt.Sprintf("http://%s%s?principal=invalid", cfg.JSONListener, MempoolStreamPath))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -25,6 +25,11 @@ import (
 const (
 	Activation       = "activation_v2alpha1"
 	ActivationStream = "activation_stream_v2alpha1"
+
+	// NodeIDsHeader is the request metadata key that restricts the activation stream to atxs
+	// published by any of the listed identities. The key can be repeated, every value is a raw node id.
+	// Identities are combined with the NodeId from the request.
+	NodeIDsHeader = "node-id-bin"
 )
 
 func NewActivationStreamService(db sql.Executor) *ActivationStreamService {
@@ -52,9 +57,17 @@ func (s *ActivationStreamService) Stream(
 	stream spacemeshv2alpha1.ActivationStreamService_StreamServer,
 ) error {
 	ctx := stream.Context()
+	nodeIDs, err := requestNodeIDs(ctx, request.NodeId)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if nodeIDs != nil {
+		// node id from the request is already included in the set
+		request.NodeId = nil
+	}
 	var sub *events.BufferedSubscription[events.ActivationTx]
 	if request.Watch {
-		matcher := atxsMatcher{request, ctx}
+		matcher := atxsMatcher{request, nodeIDs, ctx}
 		var err error
 		sub, err = events.SubscribeMatched(matcher.match)
 		if err != nil {
@@ -73,6 +86,9 @@ func (s *ActivationStreamService) Stream(
 	if err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
+	if len(nodeIDs) > 0 {
+		ops.Filter = append(ops.Filter, nodeIDsFilter(nodeIDs))
+	}
 
 	// send db data to chan to avoid buffer overflow
 	go func() {
@@ -281,6 +297,39 @@ func (s *ActivationService) ActivationsCount(
 	return &spacemeshv2alpha1.ActivationsCountResponse{Count: count}, nil
 }
 
+// requestNodeIDs returns identities listed in NodeIDsHeader together with the id from the request.
+// It returns nil if the header is not set, the id from the request is handled as a regular filter in that case.
+func requestNodeIDs(ctx context.Context, nodeID []byte) (map[types.NodeID]struct{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(NodeIDsHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(nodeID) > 0 {
+		values = append(values, string(nodeID))
+	}
+	ids := make(map[types.NodeID]struct{}, len(values))
+	for _, value := range values {
+		if len(value) != len(types.NodeID{}) {
+			return nil, fmt.Errorf("invalid node id length %d", len(value))
+		}
+		ids[types.BytesToNodeID([]byte(value))] = struct{}{}
+	}
+	return ids, nil
+}
+
+func nodeIDsFilter(ids map[types.NodeID]struct{}) builder.Op {
+	values := make([][]byte, 0, len(ids))
+	for id := range ids {
+		values = append(values, id.Bytes())
+	}
+	return builder.Op{
+		Field: builder.Smesher,
+		Token: builder.In,
+		Value: values,
+	}
+}
+
 func toAtxRequest(filter *spacemeshv2alpha1.ActivationStreamRequest) *spacemeshv2alpha1.ActivationRequest {
 	return &spacemeshv2alpha1.ActivationRequest{
 		NodeId:     filter.NodeId,
@@ -359,10 +408,17 @@ func toAtxOperations(filter *spacemeshv2alpha1.ActivationRequest) (builder.Opera
 
 type atxsMatcher struct {
 	*spacemeshv2alpha1.ActivationStreamRequest
-	ctx context.Context
+	nodeIDs map[types.NodeID]struct{}
+	ctx     context.Context
 }
 
 func (m *atxsMatcher) match(t *events.ActivationTx) bool {
+	if m.nodeIDs != nil {
+		if _, exists := m.nodeIDs[t.SmesherID]; !exists {
+			return false
+		}
+	}
+
 	if len(m.NodeId) > 0 {
 		var nodeId types.NodeID
 		copy(nodeId[:], m.NodeId)
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/spacemeshos/go-spacemesh/common/fixture"
@@ -138,6 +139,57 @@ func TestActivationStreamService_Stream(t *testing.T) {
 		require.Len(t, activations, i)
 	})
 
+	t.Run("node ids", func(t *testing.T) {
+		events.InitializeReporter()
+		t.Cleanup(events.CloseEventReporter)
+
+		selected := []types.NodeID{activations[3].SmesherID, activations[7].SmesherID, activations[42].SmesherID}
+		md := metadata.Pairs(
+			NodeIDsHeader, string(selected[0].Bytes()),
+			NodeIDsHeader, string(selected[1].Bytes()),
+		)
+		stream, err := client.Stream(metadata.NewOutgoingContext(ctx, md), &spacemeshv2alpha1.ActivationStreamRequest{
+			NodeId: selected[2].Bytes(),
+			Watch:  true,
+		})
+		require.NoError(t, err)
+		_, err = stream.Header()
+		require.NoError(t, err)
+
+		expected := map[types.ATXID]*types.VerifiedActivationTx{}
+		for _, i := range []int{3, 7, 42} {
+			expected[activations[i].ID()] = &activations[i]
+		}
+		for range expected {
+			received, err := stream.Recv()
+			require.NoError(t, err)
+			atx, exists := expected[types.ATXID(types.BytesToHash(received.GetV1().Id))]
+			require.True(t, exists)
+			require.Equal(t, atx.PrevATXID.Bytes(), received.GetV1().PreviousAtx)
+			require.Equal(t, uint32(atx.TickCount()), received.GetV1().Ticks)
+		}
+
+		published := fixture.NewAtxsGenerator().WithEpochs(200, 10)
+		for i := 0; i < 3; i++ {
+			atx := published.Next()
+			if i == 1 {
+				atx.SmesherID = selected[1]
+			}
+			events.ReportNewActivation(atx)
+		}
+		received, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, selected[1].Bytes(), received.GetV1().NodeId)
+
+		stream, err = client.Stream(
+			metadata.AppendToOutgoingContext(ctx, NodeIDsHeader, "short"),
+			&spacemeshv2alpha1.ActivationStreamRequest{},
+		)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
 	t.Run("watch", func(t *testing.T) {
 		events.InitializeReporter()
 		t.Cleanup(events.CloseEventReporter)
@@ -192,7 +244,7 @@ func TestActivationStreamService_Stream(t *testing.T) {
 				var expect []*types.VerifiedActivationTx
 				for _, rst := range streamed {
 					events.ReportNewActivation(rst.VerifiedActivationTx)
-					matcher := atxsMatcher{tc.request, ctx}
+					matcher := atxsMatcher{tc.request, nil, ctx}
 					if matcher.match(rst) {
 						expect = append(expect, rst.VerifiedActivationTx)
 					}
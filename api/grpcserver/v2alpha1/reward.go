@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -147,12 +149,13 @@ func (s *RewardStreamService) String() string {
 	return "RewardStreamService"
 }
 
-func NewRewardService(db sql.Executor) *RewardService {
-	return &RewardService{db: db}
+func NewRewardService(db sql.Executor, layerDuration time.Duration) *RewardService {
+	return &RewardService{db: db, layerDuration: layerDuration}
 }
 
 type RewardService struct {
-	db sql.Executor
+	db            sql.Executor
+	layerDuration time.Duration
 }
 
 func (s *RewardService) RegisterService(server *grpc.Server) {
@@ -160,6 +163,9 @@ func (s *RewardService) RegisterService(server *grpc.Server) {
 }
 
 func (s *RewardService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	if err := mux.HandlePath(http.MethodPost, RewardAnalyticsPath, s.handleAnalytics); err != nil {
+		return err
+	}
 	return spacemeshv2alpha1.RegisterRewardServiceHandlerServer(context.Background(), mux, s)
 }
 
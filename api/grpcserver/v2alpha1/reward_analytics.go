@@ -0,0 +1,208 @@
+package v2alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+const (
+	// RewardAnalyticsPath serves RewardService.Analytics on the json gateway.
+	RewardAnalyticsPath = "/v2alpha1/reward/analytics"
+
+	// MaxAnalyticsEpochs is the largest epoch range that can be requested at once.
+	MaxAnalyticsEpochs = 100
+
+	maxAnalyticsBody = 1 << 10
+	year             = 365 * 24 * time.Hour
+)
+
+// RewardAnalyticsRequest selects rewards of a smesher and/or all smeshers that use a coinbase
+// in the range of epochs [StartEpoch, EndEpoch].
+type RewardAnalyticsRequest struct {
+	Smesher    []byte `json:"smesher,omitempty"`
+	Coinbase   string `json:"coinbase,omitempty"`
+	StartEpoch uint32 `json:"start_epoch"`
+	EndEpoch   uint32 `json:"end_epoch"`
+}
+
+// EpochRewards compares rewards received by a smesher in an epoch with the rewards
+// it was expected to receive based on its share of the total weight in that epoch.
+type EpochRewards struct {
+	Epoch       uint32 `json:"epoch"`
+	Count       uint32 `json:"count"`
+	Total       uint64 `json:"total"`
+	LayerReward uint64 `json:"layer_reward"`
+	Weight      uint64 `json:"weight"`
+	Expected    uint64 `json:"expected"`
+	// Performance is the ratio of received to expected rewards. It is zero if no rewards were expected.
+	Performance float64 `json:"performance"`
+}
+
+// SmesherRewards aggregates rewards of a single smesher over the requested epochs.
+// Annualized is the average reward per eligible epoch projected over a year.
+type SmesherRewards struct {
+	Smesher     []byte         `json:"smesher"`
+	Count       uint32         `json:"count"`
+	Total       uint64         `json:"total"`
+	Expected    uint64         `json:"expected"`
+	Performance float64        `json:"performance"`
+	Annualized  uint64         `json:"annualized"`
+	Epochs      []EpochRewards `json:"epochs"`
+}
+
+// RewardAnalytics is the response of RewardService.Analytics.
+type RewardAnalytics struct {
+	Smeshers []SmesherRewards `json:"smeshers"`
+}
+
+// Analytics aggregates rewards of the requested smeshers per epoch and compares them with
+// the rewards expected from their weight, so that underperforming identities can be detected.
+func (s *RewardService) Analytics(ctx context.Context, req *RewardAnalyticsRequest) (*RewardAnalytics, error) {
+	var (
+		smesherID *types.NodeID
+		coinbase  *types.Address
+	)
+	if len(req.Smesher) > 0 {
+		if len(req.Smesher) != types.NodeIDSize {
+			return nil, status.Errorf(codes.InvalidArgument, "smesher must be %d bytes", types.NodeIDSize)
+		}
+		id := types.BytesToNodeID(req.Smesher)
+		smesherID = &id
+	}
+	if req.Coinbase != "" {
+		addr, err := types.StringToAddress(req.Coinbase)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		coinbase = &addr
+	}
+	switch {
+	case smesherID == nil && coinbase == nil:
+		return nil, status.Error(codes.InvalidArgument, "smesher and/or coinbase must be set")
+	case req.EndEpoch < req.StartEpoch:
+		return nil, status.Error(codes.InvalidArgument, "end epoch is before start epoch")
+	case req.EndEpoch-req.StartEpoch >= MaxAnalyticsEpochs:
+		return nil, status.Errorf(codes.InvalidArgument, "epoch range is capped at %d", MaxAnalyticsEpochs)
+	}
+	from, to := types.EpochID(req.StartEpoch), types.EpochID(req.EndEpoch)
+
+	summaries, err := rewards.SummarizeByEpoch(s.db, coinbase, smesherID, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	weights, err := atxs.WeightsByTargetEpoch(s.db, coinbase, smesherID, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	issued, err := rewards.TotalByEpoch(s.db, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	totalWeights, err := atxs.TotalWeightByTargetEpoch(s.db, from, to)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	bySmesher := map[types.NodeID]map[types.EpochID]*EpochRewards{}
+	get := func(id types.NodeID, epoch types.EpochID) *EpochRewards {
+		if _, exists := bySmesher[id]; !exists {
+			bySmesher[id] = map[types.EpochID]*EpochRewards{}
+		}
+		if _, exists := bySmesher[id][epoch]; !exists {
+			bySmesher[id][epoch] = &EpochRewards{Epoch: epoch.Uint32()}
+		}
+		return bySmesher[id][epoch]
+	}
+	for _, summary := range summaries {
+		epoch := get(summary.SmesherID, summary.Epoch)
+		epoch.Count = summary.Count
+		epoch.Total = summary.TotalReward
+		epoch.LayerReward = summary.LayerReward
+	}
+	for _, weight := range weights {
+		epoch := get(weight.SmesherID, weight.Epoch)
+		epoch.Weight += weight.Weight
+	}
+
+	rst := &RewardAnalytics{Smeshers: make([]SmesherRewards, 0, len(bySmesher))}
+	for id, epochs := range bySmesher {
+		smesher := SmesherRewards{Smesher: id.Bytes(), Epochs: make([]EpochRewards, 0, len(epochs))}
+		eligible := 0
+		for epochID, epoch := range epochs {
+			epoch.Expected = expectedReward(epoch.Weight, totalWeights[epochID], issued[epochID])
+			epoch.Performance = performance(epoch.Total, epoch.Expected)
+			smesher.Count += epoch.Count
+			smesher.Total += epoch.Total
+			smesher.Expected += epoch.Expected
+			if epoch.Weight > 0 {
+				eligible++
+			}
+			smesher.Epochs = append(smesher.Epochs, *epoch)
+		}
+		sort.Slice(smesher.Epochs, func(i, j int) bool {
+			return smesher.Epochs[i].Epoch < smesher.Epochs[j].Epoch
+		})
+		smesher.Performance = performance(smesher.Total, smesher.Expected)
+		epochDuration := s.layerDuration * time.Duration(types.GetLayersPerEpoch())
+		if eligible > 0 && epochDuration > 0 {
+			perEpoch := float64(smesher.Total) / float64(eligible)
+			smesher.Annualized = uint64(perEpoch * float64(year) / float64(epochDuration))
+		}
+		rst.Smeshers = append(rst.Smeshers, smesher)
+	}
+	sort.Slice(rst.Smeshers, func(i, j int) bool {
+		return bytes.Compare(rst.Smeshers[i].Smesher, rst.Smeshers[j].Smesher) < 0
+	})
+	return rst, nil
+}
+
+// expectedReward is a share of rewards issued in the epoch proportional to the weight.
+func expectedReward(weight, totalWeight, issued uint64) uint64 {
+	if totalWeight == 0 {
+		return 0
+	}
+	expected := new(big.Int).Mul(new(big.Int).SetUint64(weight), new(big.Int).SetUint64(issued))
+	expected.Quo(expected, new(big.Int).SetUint64(totalWeight))
+	if !expected.IsUint64() {
+		return issued
+	}
+	return expected.Uint64()
+}
+
+func performance(actual, expected uint64) float64 {
+	if expected == 0 {
+		return 0
+	}
+	return float64(actual) / float64(expected)
+}
+
+// handleAnalytics serves Analytics on the json gateway.
+func (s *RewardService) handleAnalytics(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+	var req RewardAnalyticsRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxAnalyticsBody)).Decode(&req); err != nil {
+		rpc.WriteError(w, status.Error(codes.InvalidArgument, fmt.Sprintf("decode request: %s", err)))
+		return
+	}
+	rst, err := s.Analytics(r.Context(), &req)
+	if err != nil {
+		rpc.WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rst)
+}
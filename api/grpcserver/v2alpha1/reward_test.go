@@ -1,10 +1,16 @@
 package v2alpha1
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	spacemeshv2alpha1 "github.com/spacemeshos/api/release/go/spacemesh/v2alpha1"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +22,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/rewards"
 )
 
@@ -31,7 +38,7 @@ func TestRewardService_List(t *testing.T) {
 		rwds[i] = *rwd
 	}
 
-	svc := NewRewardService(db)
+	svc := NewRewardService(db, time.Minute)
 	cfg, cleanup := launchServer(t, svc)
 	t.Cleanup(cleanup)
 
@@ -207,3 +214,87 @@ func TestRewardStreamService_Stream(t *testing.T) {
 		}
 	})
 }
+
+func TestRewardService_Analytics(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+	ctx := context.Background()
+
+	coinbase := types.GenerateAddress([]byte{1})
+	smesherA := types.NodeID{1}
+	smesherB := types.NodeID{2}
+	for id, units := range map[types.NodeID]uint32{smesherA: 1, smesherB: 3} {
+		atx := &types.ActivationTx{
+			InnerActivationTx: types.InnerActivationTx{
+				NIPostChallenge: types.NIPostChallenge{PublishEpoch: 1},
+				Coinbase:        coinbase,
+				NumUnits:        units,
+			},
+			SmesherID: id,
+		}
+		atx.SetID(types.RandomATXID())
+		atx.SetEffectiveNumUnits(units)
+		atx.SetReceived(time.Now())
+		vatx, err := atx.Verify(0, 1)
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, vatx))
+	}
+	for _, rwd := range []types.Reward{
+		{Layer: 8, SmesherID: smesherA, Coinbase: coinbase, TotalReward: 10, LayerReward: 8},
+		{Layer: 9, SmesherID: smesherB, Coinbase: coinbase, TotalReward: 30, LayerReward: 25},
+		{Layer: 10, SmesherID: smesherB, Coinbase: coinbase, TotalReward: 40, LayerReward: 35},
+	} {
+		require.NoError(t, rewards.Add(db, &rwd))
+	}
+	svc := NewRewardService(db, time.Minute)
+
+	rst, err := svc.Analytics(ctx, &RewardAnalyticsRequest{Coinbase: coinbase.String(), StartEpoch: 2, EndEpoch: 3})
+	require.NoError(t, err)
+	require.Len(t, rst.Smeshers, 2)
+
+	a := rst.Smeshers[0]
+	require.Equal(t, smesherA.Bytes(), a.Smesher)
+	require.Equal(t, []EpochRewards{
+		{Epoch: 2, Count: 1, Total: 10, LayerReward: 8, Weight: 1, Expected: 20, Performance: 0.5},
+	}, a.Epochs)
+	require.Equal(t, 0.5, a.Performance)
+	// one epoch takes 4 minutes
+	require.Equal(t, uint64(10*365*24*60/4), a.Annualized)
+
+	b := rst.Smeshers[1]
+	require.Equal(t, smesherB.Bytes(), b.Smesher)
+	require.EqualValues(t, 2, b.Count)
+	require.EqualValues(t, 70, b.Total)
+	require.EqualValues(t, 60, b.Expected)
+
+	rst, err = svc.Analytics(ctx, &RewardAnalyticsRequest{Smesher: smesherA.Bytes(), StartEpoch: 2, EndEpoch: 2})
+	require.NoError(t, err)
+	require.Len(t, rst.Smeshers, 1)
+	require.Equal(t, a.Epochs, rst.Smeshers[0].Epochs)
+
+	for _, req := range []*RewardAnalyticsRequest{
+		{StartEpoch: 2, EndEpoch: 3},
+		{Smesher: []byte{1}, EndEpoch: 3},
+		{Coinbase: "invalid", EndEpoch: 3},
+		{Coinbase: coinbase.String(), StartEpoch: 3, EndEpoch: 2},
+		{Coinbase: coinbase.String(), EndEpoch: MaxAnalyticsEpochs},
+	} {
+		_, err := svc.Analytics(ctx, req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "%+v", req)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		body, err := json.Marshal(RewardAnalyticsRequest{Smesher: smesherA.Bytes(), StartEpoch: 2, EndEpoch: 2})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		svc.handleAnalytics(rec, httptest.NewRequest(http.MethodPost, RewardAnalyticsPath, bytes.NewReader(body)), nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var decoded RewardAnalytics
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+		require.Equal(t, rst, &decoded)
+
+		rec = httptest.NewRecorder()
+		svc.handleAnalytics(rec, httptest.NewRequest(http.MethodPost, RewardAnalyticsPath, strings.NewReader("{}")), nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
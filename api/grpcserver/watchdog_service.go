@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+const (
+	// WatchdogIdentitiesPath serves the state of the identities watched by the node.
+	WatchdogIdentitiesPath = "/v1/watchdog/identities"
+	// WatchdogGrpcService is the name of the grpc service that serves Identities method.
+	// Messages of the service are encoded in json (see rpc.JSON).
+	WatchdogGrpcService = "spacemesh.node.v1.WatchdogService"
+)
+
+// WatchdogIdentitiesRequest is the request of the Identities method.
+type WatchdogIdentitiesRequest struct{}
+
+// WatchdogIdentitiesResponse is the response of the Identities method.
+type WatchdogIdentitiesResponse struct {
+	Identities []WatchedIdentity `json:"identities"`
+}
+
+// WatchedIdentity is the state of the identity watched by the node. LastATX is empty
+// if the node didn't receive any atx of the identity since it started.
+type WatchedIdentity struct {
+	Smesher     string          `json:"smesher"`
+	LastATX     string          `json:"last_atx,omitempty"`
+	LastPublish uint32          `json:"last_publish,omitempty"`
+	Missing     []uint32        `json:"missing,omitempty"`
+	Unexpected  []UnexpectedATX `json:"unexpected,omitempty"`
+}
+
+// UnexpectedATX is an atx of the watched identity that wasn't expected to be published.
+type UnexpectedATX struct {
+	ATX     string `json:"atx"`
+	Publish uint32 `json:"publish"`
+	Reason  string `json:"reason"`
+}
+
+// WatchdogService exposes the alerts about the atxs of the watched identities.
+// It is served on the json gateway and as WatchdogGrpcService.
+type WatchdogService struct {
+	watchdog atxWatchdog
+}
+
+// NewWatchdogService creates a new watchdog service.
+func NewWatchdogService(watchdog atxWatchdog) *WatchdogService {
+	return &WatchdogService{watchdog: watchdog}
+}
+
+// RegisterService registers this service with a grpc server instance.
+func (s *WatchdogService) RegisterService(server *grpc.Server) {
+	server.RegisterService(&watchdogDesc, s)
+}
+
+type watchdogServer interface {
+	identities(context.Context, *WatchdogIdentitiesRequest) (*WatchdogIdentitiesResponse, error)
+}
+
+var watchdogDesc = grpc.ServiceDesc{
+	ServiceName: WatchdogGrpcService,
+	HandlerType: (*watchdogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(WatchdogGrpcService, "Identities", watchdogServer.identities),
+	},
+	Metadata: "api/grpcserver/watchdog_service.go",
+}
+
+// RegisterHandlerService registers the watchdog routes with the json gateway.
+func (s *WatchdogService) RegisterHandlerService(mux *runtime.ServeMux) error {
+	return mux.HandlePath(http.MethodGet, WatchdogIdentitiesPath, s.handleIdentities)
+}
+
+// String returns the name of this service.
+func (s *WatchdogService) String() string {
+	return "WatchdogService"
+}
+
+// Identities returns the state of the watched identities in the order of the configuration.
+func (s *WatchdogService) Identities() []WatchedIdentity {
+	statuses := s.watchdog.Status()
+	rst := make([]WatchedIdentity, 0, len(statuses))
+	for _, status := range statuses {
+		identity := WatchedIdentity{Smesher: hex.EncodeToString(status.ID.Bytes())}
+		if status.LastAtx != types.EmptyATXID {
+			identity.LastATX = hex.EncodeToString(status.LastAtx.Bytes())
+			identity.LastPublish = status.LastPublish.Uint32()
+		}
+		for _, epoch := range status.Missing {
+			identity.Missing = append(identity.Missing, epoch.Uint32())
+		}
+		for _, atx := range status.Unexpected {
+			identity.Unexpected = append(identity.Unexpected, UnexpectedATX{
+				ATX:     hex.EncodeToString(atx.ID.Bytes()),
+				Publish: atx.Publish.Uint32(),
+				Reason:  atx.Reason,
+			})
+		}
+		rst = append(rst, identity)
+	}
+	return rst
+}
+
+func (s *WatchdogService) identities(
+	context.Context,
+	*WatchdogIdentitiesRequest,
+) (*WatchdogIdentitiesResponse, error) {
+	return &WatchdogIdentitiesResponse{Identities: s.Identities()}, nil
+}
+
+func (s *WatchdogService) handleIdentities(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Identities())
+}
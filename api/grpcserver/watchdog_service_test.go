@@ -0,0 +1,74 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestWatchdogService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	watchdog := NewMockatxWatchdog(ctrl)
+	svc := NewWatchdogService(watchdog)
+	cfg, cleanup := launchJsonServer(t, svc)
+	t.Cleanup(cleanup)
+
+	healthy, silent := types.NodeID{1}, types.NodeID{2}
+	watchdog.EXPECT().Status().Return([]activation.WatchdogStatus{
+		{
+			ID:          healthy,
+			LastAtx:     types.ATXID{1},
+			LastPublish: 5,
+			Unexpected:  []activation.UnexpectedAtx{{ID: types.ATXID{2}, Publish: 5, Reason: "coinbase"}},
+		},
+		{ID: silent, Missing: []types.EpochID{4, 5}},
+	}).AnyTimes()
+	expected := []WatchedIdentity{
+		{
+			Smesher:     hex.EncodeToString(healthy.Bytes()),
+			LastATX:     hex.EncodeToString(types.ATXID{1}.Bytes()),
+			LastPublish: 5,
+			Unexpected: []UnexpectedATX{
+				{ATX: hex.EncodeToString(types.ATXID{2}.Bytes()), Publish: 5, Reason: "coinbase"},
+			},
+		},
+		{Smesher: hex.EncodeToString(silent.Bytes()), Missing: []uint32{4, 5}},
+	}
+
+	t.Run("identities", func(t *testing.T) {
+		require.Equal(t, expected, svc.Identities())
+	})
+	t.Run("json", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", cfg.JSONListener, WatchdogIdentitiesPath))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var rst []WatchedIdentity
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&rst))
+		require.Equal(t, expected, rst)
+	})
+	t.Run("grpc", func(t *testing.T) {
+		cfg, cleanup := launchServer(t, svc)
+		t.Cleanup(cleanup)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		conn := dialGrpc(ctx, t, cfg)
+
+		rst, err := rpc.Invoke[WatchdogIdentitiesRequest, WatchdogIdentitiesResponse](
+			ctx, conn, WatchdogGrpcService, "Identities", rpc.JSON, &WatchdogIdentitiesRequest{},
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, rst.Identities)
+	})
+}
@@ -0,0 +1,64 @@
+// Package rpc describes grpc services whose messages are not defined with protobuf.
+// Messages of such services are encoded with one of the codecs registered by this package,
+// clients select the codec with grpc.CallContentSubtype.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+)
+
+const (
+	// JSON is the content subtype of the calls with messages encoded in json.
+	JSON = "json"
+	// Scale is the content subtype of the calls with messages encoded in scale.
+	Scale = "scale"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+	encoding.RegisterCodec(scaleCodec{})
+}
+
+// jsonCodec encodes grpc messages with encoding/json, so that the messages of the services
+// have the same format on the grpc listeners and on the json gateway.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return JSON
+}
+
+// scaleCodec encodes grpc messages with scale, messages must implement codec.Encodable and codec.Decodable.
+type scaleCodec struct{}
+
+func (scaleCodec) Marshal(v any) ([]byte, error) {
+	value, ok := v.(codec.Encodable)
+	if !ok {
+		return nil, fmt.Errorf("%T is not scale encodable", v)
+	}
+	return codec.Encode(value)
+}
+
+func (scaleCodec) Unmarshal(data []byte, v any) error {
+	value, ok := v.(codec.Decodable)
+	if !ok {
+		return fmt.Errorf("%T is not scale decodable", v)
+	}
+	return codec.Decode(data, value)
+}
+
+func (scaleCodec) Name() string {
+	return Scale
+}
@@ -0,0 +1,22 @@
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// WriteError writes the grpc status of the error in the same format as the json gateway.
+func WriteError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	encoded, merr := protojson.Marshal(st.Proto())
+	if merr != nil {
+		http.Error(w, st.Message(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(st.Code()))
+	w.Write(encoded)
+}
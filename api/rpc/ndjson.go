@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// NDJSONStream sends the responses of a method that streams them on the json gateway,
+// every response is written as a line of json.
+type NDJSONStream[Resp any] struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	started bool
+}
+
+// ServeNDJSON calls the streaming method with the responses written to w.
+// The error of the method is written only if nothing was sent yet.
+func ServeNDJSON[Resp any](w http.ResponseWriter, r *http.Request, call func(ServerStream[Resp]) error) {
+	stream := &NDJSONStream[Resp]{ctx: r.Context(), w: w, enc: json.NewEncoder(w)}
+	if err := call(stream); err != nil && !stream.started {
+		WriteError(w, err)
+	}
+}
+
+// Context returns the context of the request.
+func (s *NDJSONStream[Resp]) Context() context.Context {
+	return s.ctx
+}
+
+// SendHeader writes the response header, metadata is ignored.
+func (s *NDJSONStream[Resp]) SendHeader(metadata.MD) error {
+	s.start()
+	s.flush()
+	return nil
+}
+
+// Send writes the response as a line of json.
+func (s *NDJSONStream[Resp]) Send(resp *Resp) error {
+	s.start()
+	if err := s.enc.Encode(resp); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *NDJSONStream[Resp]) start() {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.started = true
+	}
+}
+
+func (s *NDJSONStream[Resp]) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// FullMethod returns the name of the method in the format used by grpc interceptors.
+func FullMethod(service, method string) string {
+	return "/" + service + "/" + method
+}
+
+// UnaryMethod describes a unary method of the service implemented by Srv.
+func UnaryMethod[Srv, Req, Resp any](
+	service, name string,
+	call func(Srv, context.Context, *Req) (*Resp, error),
+) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(
+			srv any,
+			ctx context.Context,
+			dec func(any) error,
+			interceptor grpc.UnaryServerInterceptor,
+		) (any, error) {
+			in := new(Req)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(Srv), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: FullMethod(service, name)}
+			return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+				return call(srv.(Srv), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// Invoke calls a unary method of the service with messages encoded by the codec
+// with the given content subtype.
+func Invoke[Req, Resp any](
+	ctx context.Context,
+	conn grpc.ClientConnInterface,
+	service, method, subtype string,
+	req *Req,
+) (*Resp, error) {
+	resp := new(Resp)
+	if err := conn.Invoke(ctx, FullMethod(service, method), req, resp, grpc.CallContentSubtype(subtype)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ServerStream sends the responses of a method that streams them to the client.
+// Header can be sent before the first response to let the client know that the stream is ready.
+type ServerStream[Resp any] interface {
+	Context() context.Context
+	SendHeader(metadata.MD) error
+	Send(*Resp) error
+}
+
+type serverStream[Resp any] struct {
+	grpc.ServerStream
+}
+
+func (s *serverStream[Resp]) Send(resp *Resp) error {
+	return s.SendMsg(resp)
+}
+
+// ServerStreamMethod describes a method of the service implemented by Srv that streams responses to the client.
+func ServerStreamMethod[Srv, Req, Resp any](
+	name string,
+	call func(Srv, *Req, ServerStream[Resp]) error,
+) grpc.StreamDesc {
+	return grpc.StreamDesc{
+		StreamName:    name,
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			in := new(Req)
+			if err := stream.RecvMsg(in); err != nil {
+				return err
+			}
+			return call(srv.(Srv), in, &serverStream[Resp]{stream})
+		},
+	}
+}
+
+// ClientStream receives the responses of a method that streams them to the client.
+type ClientStream[Resp any] struct {
+	grpc.ClientStream
+}
+
+// Recv returns the next response, io.EOF is returned after the last response.
+func (s *ClientStream[Resp]) Recv() (*Resp, error) {
+	resp := new(Resp)
+	if err := s.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Stream calls a method of the service that streams responses, messages are encoded by the codec
+// with the given content subtype.
+func Stream[Req, Resp any](
+	ctx context.Context,
+	conn grpc.ClientConnInterface,
+	service, method, subtype string,
+	req *Req,
+) (*ClientStream[Resp], error) {
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, FullMethod(service, method), grpc.CallContentSubtype(subtype))
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &ClientStream[Resp]{stream}, nil
+}
@@ -19,6 +19,8 @@ type ATX struct {
 	Weight             uint64
 	BaseHeight, Height uint64
 	Nonce              types.VRFPostIndex
+	// VRFKey verifies the vrf proofs of the identity in the epoch, it is the node id if the key is not rotated.
+	VRFKey types.NodeID
 	// unexported to avoid accidental unsynchronized access
 	// (this field is mutated by the Data under a lock and
 	// might only be safely read under the same lock)
@@ -103,9 +105,15 @@ func (d *Data) OnEpoch(applied types.EpochID) {
 	}
 }
 
-// AddFromVerified extracts relevant fields from verified atx and adds them together with nonce and malicious flag.
+// AddFromVerified extracts relevant fields from verified atx and adds them together with nonce, vrf key
+// and malicious flag.
 // Returns the ATX that was added to the store (if any) or `nil` if it wasn't.
-func (d *Data) AddFromHeader(atx *types.ActivationTxHeader, nonce types.VRFPostIndex, malicious bool) *ATX {
+func (d *Data) AddFromHeader(
+	atx *types.ActivationTxHeader,
+	nonce types.VRFPostIndex,
+	vrfKey types.NodeID,
+	malicious bool,
+) *ATX {
 	return d.Add(
 		atx.TargetEpoch(),
 		atx.NodeID,
@@ -115,6 +123,7 @@ func (d *Data) AddFromHeader(atx *types.ActivationTxHeader, nonce types.VRFPostI
 		atx.BaseTickHeight,
 		atx.TickHeight(),
 		nonce,
+		vrfKey,
 		malicious,
 	)
 }
@@ -157,6 +166,7 @@ func (d *Data) Add(
 	atxid types.ATXID,
 	weight, baseHeight, height uint64,
 	nonce types.VRFPostIndex,
+	vrfKey types.NodeID,
 	malicious bool,
 ) *ATX {
 	atx := &ATX{
@@ -166,6 +176,7 @@ func (d *Data) Add(
 		BaseHeight: baseHeight,
 		Height:     height,
 		Nonce:      nonce,
+		VRFKey:     vrfKey,
 		malicious:  malicious,
 	}
 	if d.AddAtx(epoch, atxid, atx) {
@@ -42,6 +42,7 @@ func TestData(t *testing.T) {
 						d.BaseHeight,
 						d.Height,
 						d.Nonce,
+						d.VRFKey,
 						d.malicious,
 					)
 				}
@@ -67,6 +68,7 @@ func TestData(t *testing.T) {
 				0,
 				0,
 				0,
+				node,
 				false,
 			)
 			data := c.Get(types.EpochID(epoch), types.ATXID{byte(epoch)})
@@ -89,7 +91,7 @@ func TestData(t *testing.T) {
 		c := New(WithCapacity(capacity))
 		node := types.NodeID{1}
 		for epoch := 1; epoch <= epochs; epoch++ {
-			c.Add(types.EpochID(epoch), node, types.Address{}, types.ATXID{}, 2, 0, 0, 0, false)
+			c.Add(types.EpochID(epoch), node, types.Address{}, types.ATXID{}, 2, 0, 0, 0, node, false)
 			data := c.Get(types.EpochID(epoch), types.ATXID{})
 			require.NotNil(t, data)
 		}
@@ -105,20 +107,20 @@ func TestData(t *testing.T) {
 		require.Nil(t, c.Get(0, types.ATXID{}))
 		require.Nil(t, c.Get(1, types.ATXID{}))
 
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 2, 0, 0, 0, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 2, 0, 0, 0, types.NodeID{1}, false)
 		require.Nil(t, c.Get(1, types.ATXID{}))
 	})
 	t.Run("multiple atxs", func(t *testing.T) {
 		c := New()
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 1, 0, 0, 0, false)
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{2}, 2, 0, 0, 0, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 1, 0, 0, 0, types.NodeID{1}, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{2}, 2, 0, 0, 0, types.NodeID{1}, false)
 		require.NotNil(t, c.Get(1, types.ATXID{1}))
 		require.NotNil(t, c.Get(1, types.ATXID{2}))
 	})
 	t.Run("weight for set", func(t *testing.T) {
 		c := New()
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 1, 0, 0, 0, false)
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{2}, 2, 0, 0, 0, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 1, 0, 0, 0, types.NodeID{1}, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{2}, 2, 0, 0, 0, types.NodeID{1}, false)
 
 		weight, used := c.WeightForSet(1, []types.ATXID{{1}, {2}, {3}})
 		require.Equal(t, []bool{true, true, false}, used)
@@ -132,7 +134,7 @@ func TestData(t *testing.T) {
 		c := New()
 		c.OnEpoch(0)
 		c.OnEpoch(3)
-		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 500, 100, 0, 0, false)
+		c.Add(1, types.NodeID{1}, types.Address{}, types.ATXID{1}, 500, 100, 0, 0, types.NodeID{1}, false)
 		require.Nil(t, c.Get(3, types.ATXID{1}))
 		c.OnEpoch(3)
 	})
@@ -159,7 +161,7 @@ func TestMemory(t *testing.T) {
 			)
 			binary.PutUvarint(node[:], uint64(i))
 			binary.PutUvarint(atx[:], uint64(i))
-			c.Add(1, node, types.Address{}, atx, 500, 100, 0, 0, false)
+			c.Add(1, node, types.Address{}, atx, 500, 100, 0, 0, node, false)
 		}
 		runtime.GC()
 		var after runtime.MemStats
@@ -189,7 +191,7 @@ func BenchmarkConcurrentReadWrite(b *testing.B) {
 		)
 		binary.PutUvarint(node[:], uint64(i))
 		binary.PutUvarint(atx[:], uint64(i))
-		c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, false)
+		c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, node, false)
 	}
 	b.ResetTimer()
 
@@ -206,7 +208,7 @@ func BenchmarkConcurrentReadWrite(b *testing.B) {
 			if i%writeFraction == 0 {
 				binary.PutUvarint(node[:], size+i*core)
 				binary.PutUvarint(atx[:], size+i*core)
-				c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, false)
+				c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, node, false)
 			} else {
 				binary.PutUvarint(node[:], i%size)
 				binary.PutUvarint(atx[:], i%size)
@@ -230,7 +232,7 @@ func benchmarkkWeightForSet(b *testing.B, size, setSize int) {
 		binary.PutUvarint(node[:], uint64(i))
 		binary.PutUvarint(atx[:], uint64(i))
 		atxs = append(atxs, atx)
-		c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, false)
+		c.Add(epoch, node, types.Address{}, atx, 500, 100, 0, 0, node, false)
 	}
 	rng.Shuffle(size, func(i, j int) {
 		atxs[i], atxs[j] = atxs[j], atxs[i]
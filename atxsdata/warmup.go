@@ -52,6 +52,11 @@ func Warmup(db sql.Executor, cache *Data) error {
 				ierr = fmt.Errorf("missing nonce %w", err)
 				return false
 			}
+			key, err := atxs.VRFKey(db, node, target)
+			if err != nil {
+				ierr = fmt.Errorf("vrf key %w", err)
+				return false
+			}
 			malicious, err := identities.IsMalicious(db, node)
 			if err != nil {
 				ierr = err
@@ -66,6 +71,7 @@ func Warmup(db sql.Executor, cache *Data) error {
 				base,
 				height,
 				nonce,
+				key,
 				malicious,
 			)
 			return true
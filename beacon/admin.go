@@ -0,0 +1,119 @@
+package beacon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+)
+
+// ErrInjectionDisabled is returned if the beacon is injected without UnsafeInjection in the config.
+var ErrInjectionDisabled = errors.New("beacon injection is disabled")
+
+// ProtocolState is the state of the beacon protocol in the epoch, to troubleshoot epochs
+// in which the beacon wasn't computed.
+type ProtocolState struct {
+	Epoch types.EpochID
+	// Beacon is used in the epoch, NextBeacon is computed in the epoch for the next one.
+	// Beacons are empty if they are not known yet.
+	Beacon     types.Beacon
+	NextBeacon types.Beacon
+	// InProtocol is true while the protocol for the current epoch is running.
+	InProtocol bool
+	// Running is true if the node keeps the state of the protocol run in the epoch.
+	// Fields below are set only if it is true.
+	Running                   bool
+	Round                     types.RoundID
+	EpochWeight               uint64
+	Participants              int
+	Miners                    int
+	ValidProposals            int
+	PotentiallyValidProposals int
+	Proposers                 int
+	Voters                    int
+	// BallotBeacons are the beacons of the epoch reported in ballots, sorted by weight.
+	BallotBeacons []BallotBeacon
+}
+
+// BallotBeacon is the beacon reported in ballots with the weight of the ballots.
+type BallotBeacon struct {
+	Beacon        types.Beacon
+	Ballots       int
+	Eligibilities int
+	Weight        float64
+}
+
+// ProtocolState returns the state of the beacon protocol in the epoch.
+func (pd *ProtocolDriver) ProtocolState(epoch types.EpochID) (*ProtocolState, error) {
+	rst := &ProtocolState{Epoch: epoch, InProtocol: pd.isInProtocol()}
+	for _, target := range []struct {
+		epoch types.EpochID
+		dst   *types.Beacon
+	}{{epoch, &rst.Beacon}, {epoch + 1, &rst.NextBeacon}} {
+		beacon, err := pd.GetBeacon(target.epoch)
+		switch {
+		case err == nil:
+			*target.dst = beacon
+		case !errors.Is(err, errBeaconNotCalculated):
+			return nil, err
+		}
+	}
+
+	pd.mu.RLock()
+	defer pd.mu.RUnlock()
+	if st, exists := pd.states[epoch]; exists {
+		rst.Running = true
+		if epoch == pd.clock.CurrentLayer().GetEpoch() {
+			rst.Round = pd.roundInProgress
+		}
+		rst.EpochWeight = st.epochWeight
+		rst.Participants = len(st.active)
+		rst.Miners = len(st.minerAtxs)
+		rst.ValidProposals = len(st.incomingProposals.valid)
+		rst.PotentiallyValidProposals = len(st.incomingProposals.potentiallyValid)
+		rst.Proposers = len(st.hasProposed)
+		rst.Voters = len(st.hasVoted)
+	}
+	for beacon, weight := range pd.ballotsBeacons[epoch] {
+		rst.BallotBeacons = append(rst.BallotBeacons, BallotBeacon{
+			Beacon:        beacon,
+			Ballots:       len(weight.ballots),
+			Eligibilities: weight.numEligibility,
+			Weight:        weight.totalWeight.Float(),
+		})
+	}
+	sort.Slice(rst.BallotBeacons, func(i, j int) bool {
+		return rst.BallotBeacons[i].Weight > rst.BallotBeacons[j].Weight
+	})
+	return rst, nil
+}
+
+// InjectBeacon sets the beacon of the epoch, replacing the computed one, if UnsafeInjection is enabled.
+// The beacon is not published, it must be injected on every node of the network.
+func (pd *ProtocolDriver) InjectBeacon(epoch types.EpochID, beacon types.Beacon, reason string) error {
+	if !pd.config.UnsafeInjection {
+		pd.logger.With().Warning("refused beacon injection", epoch, beacon, log.String("reason", reason))
+		return ErrInjectionDisabled
+	}
+	if beacon == types.EmptyBeacon {
+		return errors.New("empty beacon can't be injected")
+	}
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	previous := pd.beacons[epoch]
+	if err := beacons.Set(pd.cdb, epoch, beacon); err != nil {
+		return fmt.Errorf("persist injected beacon epoch %v: %w", epoch, err)
+	}
+	pd.beacons[epoch] = beacon
+	pd.logger.With().Warning("UNSAFE: using injected beacon",
+		epoch,
+		beacon,
+		log.Stringer("previous", previous),
+		log.String("reason", reason),
+	)
+	pd.onResult(epoch, beacon)
+	return nil
+}
@@ -0,0 +1,84 @@
+package beacon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/fixed"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/types/result"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+)
+
+func TestProtocolState(t *testing.T) {
+	const epoch = types.EpochID(5)
+	tpd := setUpProtocolDriver(t)
+	tpd.mClock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
+	tpd.mClock.EXPECT().LayerToTime(gomock.Any()).Return(time.Now()).AnyTimes()
+
+	st, err := tpd.ProtocolState(epoch)
+	require.NoError(t, err)
+	require.Equal(t, &ProtocolState{Epoch: epoch}, st)
+
+	createRandomATXs(t, tpd.cdb, (epoch - 1).FirstLayer(), 4)
+	_, err = tpd.setupEpoch(tpd.logger, epoch)
+	require.NoError(t, err)
+	require.NoError(t, tpd.setBeacon(&beacons.Provenance{Epoch: epoch, Beacon: types.Beacon{1}}))
+
+	major, minor := types.Beacon{2}, types.Beacon{3}
+	for _, reported := range []struct {
+		beacon types.Beacon
+		weight fixed.Fixed
+	}{{major, fixed.New64(2)}, {minor, fixed.New64(1)}, {major, fixed.New64(1)}} {
+		ballot := types.NewExistingBallot(types.RandomBallotID(), types.EmptyEdSignature, types.EmptyNodeID,
+			epoch.FirstLayer())
+		ballot.EligibilityProofs = []types.VotingEligibility{{J: 1}}
+		tpd.recordBeacon(epoch, &ballot, reported.beacon, reported.weight)
+	}
+
+	st, err = tpd.ProtocolState(epoch)
+	require.NoError(t, err)
+	require.Equal(t, &ProtocolState{
+		Epoch:       epoch,
+		Beacon:      types.Beacon{1},
+		Running:     true,
+		Round:       types.FirstRound,
+		EpochWeight: 4,
+		Miners:      4,
+		BallotBeacons: []BallotBeacon{
+			{Beacon: major, Ballots: 2, Eligibilities: 2, Weight: 3},
+			{Beacon: minor, Ballots: 1, Eligibilities: 1, Weight: 1},
+		},
+	}, st)
+}
+
+func TestInjectBeacon(t *testing.T) {
+	const epoch = types.EpochID(5)
+	t.Run("disabled", func(t *testing.T) {
+		tpd := setUpProtocolDriver(t)
+		require.ErrorIs(t, tpd.InjectBeacon(epoch, types.Beacon{1}, "test"), ErrInjectionDisabled)
+		_, err := tpd.GetBeacon(epoch)
+		require.ErrorIs(t, err, errBeaconNotCalculated)
+	})
+	t.Run("replaces computed beacon", func(t *testing.T) {
+		cfg := UnitTestConfig()
+		cfg.UnsafeInjection = true
+		tpd := newTestDriver(t, cfg, newPublisher(t), 3, "")
+		tpd.mClock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
+		require.NoError(t, tpd.setBeacon(&beacons.Provenance{Epoch: epoch, Beacon: types.Beacon{1}}))
+		<-tpd.Results()
+
+		require.Error(t, tpd.InjectBeacon(epoch, types.EmptyBeacon, "test"))
+		require.NoError(t, tpd.InjectBeacon(epoch, types.Beacon{2}, "test"))
+		require.Equal(t, result.Beacon{Epoch: epoch, Beacon: types.Beacon{2}}, <-tpd.Results())
+		got, err := tpd.GetBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, types.Beacon{2}, got)
+		persisted, err := tpd.getPersistedBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, types.Beacon{2}, persisted)
+	})
+}
@@ -102,7 +102,7 @@ func New(
 		nonceFetcher:   cdb,
 		cdb:            cdb,
 		clock:          clock,
-		signers:        make(map[types.NodeID]*signing.EdSigner),
+		signers:        make(map[types.NodeID]signing.Signer),
 		beacons:        make(map[types.EpochID]types.Beacon),
 		ballotsBeacons: make(map[types.EpochID]map[types.Beacon]*beaconWeight),
 		states:         make(map[types.EpochID]*state),
@@ -135,7 +135,7 @@ func New(
 	return pd
 }
 
-func (pd *ProtocolDriver) Register(sig *signing.EdSigner) {
+func (pd *ProtocolDriver) Register(sig signing.Signer) {
 	pd.mu.Lock()
 	defer pd.mu.Unlock()
 	if _, exists := pd.signers[sig.NodeID()]; exists {
@@ -148,7 +148,7 @@ func (pd *ProtocolDriver) Register(sig *signing.EdSigner) {
 }
 
 type participant struct {
-	signer *signing.EdSigner
+	signer signing.Signer
 	nonce  types.VRFPostIndex
 }
 
@@ -175,7 +175,7 @@ type ProtocolDriver struct {
 	sync      system.SyncStateProvider
 	publisher pubsub.Publisher
 
-	signers  map[types.NodeID]*signing.EdSigner
+	signers  map[types.NodeID]signing.Signer
 	weakCoin coin
 
 	edVerifier   *signing.EdVerifier
@@ -248,7 +248,16 @@ func (pd *ProtocolDriver) Start(ctx context.Context) {
 func (pd *ProtocolDriver) UpdateBeacon(epoch types.EpochID, beacon types.Beacon) error {
 	pd.mu.Lock()
 	defer pd.mu.Unlock()
-	if err := beacons.Set(pd.cdb, epoch, beacon); err != nil {
+	if err := pd.cdb.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if err := beacons.Set(tx, epoch, beacon); err != nil {
+			return err
+		}
+		return beacons.SetProvenance(tx, &beacons.Provenance{
+			Epoch:  epoch,
+			Beacon: beacon,
+			Source: beacons.SourceBootstrap,
+		})
+	}); err != nil {
 		return fmt.Errorf("persist fallback beacon epoch %v, beacon %v: %w", epoch, beacon, err)
 	}
 	pd.beacons[epoch] = beacon
@@ -367,7 +376,14 @@ func (pd *ProtocolDriver) ReportBeaconFromBallot(
 	}
 
 	if eBeacon := pd.findMajorityBeacon(epoch); eBeacon != types.EmptyBeacon {
-		if err := pd.setBeacon(epoch, eBeacon); err != nil {
+		support, weight := pd.ballotsWeight(epoch, eBeacon)
+		if err := pd.setBeacon(&beacons.Provenance{
+			Epoch:   epoch,
+			Beacon:  eBeacon,
+			Source:  beacons.SourceBallots,
+			Support: support,
+			Weight:  weight,
+		}); err != nil {
 			pd.logger.With().Error("beacon sync: failed to set beacon", log.Err(err))
 		}
 	}
@@ -470,6 +486,21 @@ func (pd *ProtocolDriver) findMajorityBeacon(epoch types.EpochID) types.Beacon {
 	return bPlurality
 }
 
+// ballotsWeight returns the weight of the ballots with the beacon and the weight of all ballots in the epoch.
+func (pd *ProtocolDriver) ballotsWeight(epoch types.EpochID, beacon types.Beacon) (uint64, uint64) {
+	pd.mu.RLock()
+	defer pd.mu.RUnlock()
+	total := fixed.New64(0)
+	for _, bw := range pd.ballotsBeacons[epoch] {
+		total = total.Add(bw.totalWeight)
+	}
+	var support fixed.Fixed
+	if bw, exists := pd.ballotsBeacons[epoch][beacon]; exists {
+		support = bw.totalWeight
+	}
+	return uint64(support.Floor()), uint64(total.Floor())
+}
+
 // GetBeacon returns the beacon for the specified epoch or an error if it doesn't exist.
 func (pd *ProtocolDriver) GetBeacon(targetEpoch types.EpochID) (types.Beacon, error) {
 	beacon := pd.getBeacon(targetEpoch)
@@ -498,7 +529,8 @@ func (pd *ProtocolDriver) getBeacon(epoch types.EpochID) types.Beacon {
 	return types.EmptyBeacon
 }
 
-func (pd *ProtocolDriver) setBeacon(targetEpoch types.EpochID, beacon types.Beacon) error {
+func (pd *ProtocolDriver) setBeacon(provenance *beacons.Provenance) error {
+	targetEpoch, beacon := provenance.Epoch, provenance.Beacon
 	if beacon == types.EmptyBeacon {
 		pd.logger.Fatal("invalid beacon")
 	}
@@ -513,7 +545,17 @@ func (pd *ProtocolDriver) setBeacon(targetEpoch types.EpochID, beacon types.Beac
 		return nil
 	}
 
-	if err := beacons.Add(pd.cdb, targetEpoch, beacon); err != nil && !errors.Is(err, sql.ErrObjectExists) {
+	if err := pd.cdb.WithTx(context.Background(), func(tx *sql.Tx) error {
+		err := beacons.Add(tx, targetEpoch, beacon)
+		switch {
+		case errors.Is(err, sql.ErrObjectExists):
+			// provenance was recorded with the beacon that was persisted first
+			return nil
+		case err != nil:
+			return err
+		}
+		return beacons.SetProvenance(tx, provenance)
+	}); err != nil {
 		pd.logger.With().Error("failed to persist beacon", targetEpoch, beacon, log.Err(err))
 		return fmt.Errorf("persist beacon: %w", err)
 	}
@@ -564,7 +606,7 @@ func (pd *ProtocolDriver) initEpochStateIfNotPresent(logger log.Log, epoch types
 	var (
 		epochWeight       uint64
 		miners            = make(map[types.NodeID]*minerInfo)
-		potentiallyActive = make(map[types.NodeID]*signing.EdSigner)
+		potentiallyActive = make(map[types.NodeID]signing.Signer)
 		// w1 is the weight units at δ before the end of the previous epoch, used to calculate `thresholdStrict`
 		// w2 is the weight units at the end of the previous epoch, used to calculate `threshold`
 		w1, w2 int
@@ -634,8 +676,12 @@ func (pd *ProtocolDriver) initEpochStateIfNotPresent(logger log.Log, epoch types
 	)
 
 	checker := createProposalChecker(logger, pd.config, w1, w1+w2)
-	pd.states[epoch] = newState(logger, pd.config, active, epochWeight, miners, checker)
-	return pd.states[epoch], nil
+	st := newState(logger, pd.config, active, epochWeight, miners, checker)
+	if pd.config.Observer {
+		st.observation = newObservation(epoch, epochWeight)
+	}
+	pd.states[epoch] = st
+	return st, nil
 }
 
 func (pd *ProtocolDriver) setProposalTimeForNextEpoch() {
@@ -770,6 +816,7 @@ func (pd *ProtocolDriver) runProtocol(ctx context.Context, epoch types.EpochID,
 
 	pd.setBeginProtocol(ctx)
 	defer pd.setEndProtocol(ctx)
+	defer pd.persistObservation(logger, st)
 
 	pd.weakCoin.StartEpoch(ctx, epoch)
 	defer pd.weakCoin.FinishEpoch(ctx, epoch)
@@ -791,8 +838,18 @@ func (pd *ProtocolDriver) runProtocol(ctx context.Context, epoch types.EpochID,
 	// K rounds passed
 	// After K rounds had passed, tally up votes for proposals using simple tortoise vote counting
 	beacon := calcBeacon(logger, lastRoundOwnVotes.support)
+	pd.mu.Lock()
+	st.recordBeacon(beacon)
+	provenance := &beacons.Provenance{
+		Epoch:   targetEpoch,
+		Beacon:  beacon,
+		Source:  beacons.SourceProtocol,
+		Support: st.voteWeight[pd.config.RoundsNumber-1],
+		Weight:  st.epochWeight,
+	}
+	pd.mu.Unlock()
 
-	if err = pd.setBeacon(targetEpoch, beacon); err != nil {
+	if err = pd.setBeacon(provenance); err != nil {
 		logger.With().Error("failed to set beacon", log.Err(err))
 		return
 	}
@@ -857,7 +914,11 @@ func (pd *ProtocolDriver) sendProposal(
 	}
 
 	logger := pd.logger.WithContext(ctx).WithFields(epoch)
-	vrfSig := buildSignedProposal(ctx, pd.logger, s.signer.VRFSigner(), epoch, s.nonce)
+	vrfSig, err := buildSignedProposal(ctx, pd.logger, s.signer, epoch, s.nonce)
+	if err != nil {
+		pd.logger.WithContext(ctx).With().Warning("failed to sign proposal", epoch, s.Id(), log.Err(err))
+		return
+	}
 	proposal := ProposalFromVrf(vrfSig)
 	m := ProposalMessage{
 		EpochID:      epoch,
@@ -920,7 +981,7 @@ func (pd *ProtocolDriver) runConsensusPhase(ctx context.Context, epoch types.Epo
 	case <-ctx.Done():
 		return allVotes{}, fmt.Errorf("context done: %w", ctx.Err())
 	}
-	ownVotes, _ = pd.calcVotesBeforeWeakCoin(logger, st)
+	ownVotes, _ = pd.calcVotesBeforeWeakCoin(logger, st, round)
 
 	// Subsequent rounds
 	for round := types.FirstRound + 1; round < pd.config.RoundsNumber; round++ {
@@ -949,7 +1010,7 @@ func (pd *ProtocolDriver) runConsensusPhase(ctx context.Context, epoch types.Epo
 		// note that votes after this call will _not_ be counted towards our votes
 		// for this round, as the late votes can be cast after the weak coin is revealed. we
 		// count them towards our votes in the next round.
-		ownVotes, undecided = pd.calcVotesBeforeWeakCoin(rLogger, st)
+		ownVotes, undecided = pd.calcVotesBeforeWeakCoin(rLogger, st, round)
 
 		timer.Reset(pd.config.WeakCoinRoundDuration)
 
@@ -957,7 +1018,7 @@ func (pd *ProtocolDriver) runConsensusPhase(ctx context.Context, epoch types.Epo
 			participants := make([]weakcoin.Participant, 0, len(st.active))
 			for _, session := range st.active {
 				participants = append(participants, weakcoin.Participant{
-					Signer: session.signer.VRFSigner(),
+					Signer: session.signer,
 					Nonce:  session.nonce,
 				})
 			}
@@ -980,6 +1041,9 @@ func (pd *ProtocolDriver) runConsensusPhase(ctx context.Context, epoch types.Epo
 		}
 
 		tallyUndecided(&ownVotes, undecided, flip)
+		pd.mu.Lock()
+		st.recordCoin(round, flip)
+		pd.mu.Unlock()
 	}
 
 	logger.Info("consensus phase finished")
@@ -992,21 +1056,37 @@ func (pd *ProtocolDriver) markProposalPhaseFinished(st *state, finishedAt time.T
 	st.proposalPhaseFinishedTime = finishedAt
 }
 
-func (pd *ProtocolDriver) calcVotesBeforeWeakCoin(logger log.Log, st *state) (allVotes, proposalList) {
+func (pd *ProtocolDriver) calcVotesBeforeWeakCoin(
+	logger log.Log,
+	st *state,
+	round types.RoundID,
+) (allVotes, proposalList) {
 	pd.mu.RLock()
-	defer pd.mu.RUnlock()
-	return calcVotes(logger, pd.theta, st)
+	votes, undecided := calcVotes(logger, pd.theta, st)
+	pd.mu.RUnlock()
+	// observation is set before the state is shared, so it can be checked without the lock.
+	// tally is recorded with the write lock only in observer mode.
+	if st.observation != nil {
+		pd.mu.Lock()
+		st.recordTally(round, votes, undecided)
+		pd.mu.Unlock()
+	}
+	return votes, undecided
 }
 
 func (pd *ProtocolDriver) sendFirstRoundVote(
 	ctx context.Context,
 	msg FirstVotingMessageBody,
-	signer *signing.EdSigner,
+	signer signing.Signer,
 ) error {
+	sig, err := signer.SignContext(ctx, signing.BEACON_FIRST_MSG, codec.MustEncode(&msg))
+	if err != nil {
+		return fmt.Errorf("sign first round vote: %w", err)
+	}
 	m := FirstVotingMessage{
 		FirstVotingMessageBody: msg,
 		SmesherID:              signer.NodeID(),
-		Signature:              signer.Sign(signing.BEACON_FIRST_MSG, codec.MustEncode(&msg)),
+		Signature:              sig,
 	}
 
 	pd.logger.WithContext(ctx).
@@ -1032,7 +1112,7 @@ func (pd *ProtocolDriver) sendFollowingVote(
 	epoch types.EpochID,
 	round types.RoundID,
 	ownCurrentRoundVotes allVotes,
-	signer *signing.EdSigner,
+	signer signing.Signer,
 ) error {
 	firstRoundVotes, err := pd.getFirstRoundVote(epoch, signer.NodeID())
 	if err != nil {
@@ -1046,10 +1126,14 @@ func (pd *ProtocolDriver) sendFollowingVote(
 		VotesBitVector: bitVector,
 	}
 
+	sig, err := signer.SignContext(ctx, signing.BEACON_FOLLOWUP_MSG, codec.MustEncode(&mb))
+	if err != nil {
+		return fmt.Errorf("sign following round vote: %w", err)
+	}
 	m := FollowingVotingMessage{
 		FollowingVotingMessageBody: mb,
 		SmesherID:                  signer.NodeID(),
-		Signature:                  signer.Sign(signing.BEACON_FOLLOWUP_MSG, codec.MustEncode(&mb)),
+		Signature:                  sig,
 	}
 
 	pd.logger.WithContext(ctx).
@@ -1148,14 +1232,17 @@ func buildSignedProposal(
 	signer vrfSigner,
 	epoch types.EpochID,
 	nonce types.VRFPostIndex,
-) types.VrfSignature {
+) (types.VrfSignature, error) {
 	p := buildProposal(logger, epoch, nonce)
-	vrfSig := signer.Sign(p)
+	vrfSig, err := signer.SignVRFContext(ctx, epoch, p)
+	if err != nil {
+		return types.EmptyVrfSignature, err
+	}
 	proposal := ProposalFromVrf(vrfSig)
 	logger.WithContext(ctx).
 		With().
 		Debug("calculated beacon proposal", epoch, nonce, log.Inline(proposal), log.ShortStringer("id", signer.NodeID()))
-	return vrfSig
+	return vrfSig, nil
 }
 
 func buildProposal(logger log.Log, epoch types.EpochID, nonce types.VRFPostIndex) []byte {
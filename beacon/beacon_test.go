@@ -28,6 +28,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/system/mocks"
 )
@@ -199,7 +200,7 @@ func TestBeacon_MultipleNodes(t *testing.T) {
 
 		for _, db := range dbs {
 			for _, s := range node.signers {
-				createATX(t, db, atxPublishLid, s, 1, time.Now().Add(-1*time.Second))
+				createATX(t, db, atxPublishLid, s.(*signing.EdSigner), 1, time.Now().Add(-1*time.Second))
 			}
 		}
 	}
@@ -266,7 +267,7 @@ func TestBeacon_MultipleNodes_OnlyOneHonest(t *testing.T) {
 	for i, node := range testNodes {
 		for _, db := range dbs {
 			for _, s := range node.signers {
-				createATX(t, db, atxPublishLid, s, 1, time.Now().Add(-1*time.Second))
+				createATX(t, db, atxPublishLid, s.(*signing.EdSigner), 1, time.Now().Add(-1*time.Second))
 				if i != 0 {
 					require.NoError(t, identities.SetMalicious(db, s.NodeID(), []byte("bad"), time.Now()))
 				}
@@ -322,7 +323,7 @@ func TestBeacon_NoProposals(t *testing.T) {
 	for _, node := range testNodes {
 		for _, db := range dbs {
 			for _, s := range node.signers {
-				createATX(t, db, atxPublishLid, s, 1, time.Now().Add(-1*time.Second))
+				createATX(t, db, atxPublishLid, s.(*signing.EdSigner), 1, time.Now().Add(-1*time.Second))
 			}
 		}
 	}
@@ -421,7 +422,7 @@ func TestBeaconWithMetrics(t *testing.T) {
 	for i := types.EpochID(2); i < epoch; i++ {
 		lid := i.FirstLayer().Sub(1)
 		for _, s := range tpd.signers {
-			createATX(t, tpd.cdb, lid, s, 199, time.Now())
+			createATX(t, tpd.cdb, lid, s.(*signing.EdSigner), 199, time.Now())
 		}
 		createRandomATXs(t, tpd.cdb, lid, 9)
 	}
@@ -512,9 +513,9 @@ func TestBeacon_BeaconsWithDatabase(t *testing.T) {
 	beacon4 := types.RandomBeacon()
 
 	mclock.EXPECT().CurrentLayer().Return(epoch5.FirstLayer()).AnyTimes()
-	err := pd.setBeacon(epoch3, beacon2)
+	err := pd.setBeacon(&beacons.Provenance{Epoch: epoch3, Beacon: beacon2})
 	require.NoError(t, err)
-	err = pd.setBeacon(epoch5, beacon4)
+	err = pd.setBeacon(&beacons.Provenance{Epoch: epoch5, Beacon: beacon4})
 	require.NoError(t, err)
 
 	got, err := pd.GetBeacon(epoch3)
@@ -582,7 +583,7 @@ func TestBeacon_BeaconsCleanupOldEpoch(t *testing.T) {
 	mclock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
 	for i := 0; i < numEpochsToKeep; i++ {
 		e := epoch + types.EpochID(i)
-		err := pd.setBeacon(e, types.RandomBeacon())
+		err := pd.setBeacon(&beacons.Provenance{Epoch: e, Beacon: types.RandomBeacon()})
 		require.NoError(t, err)
 		b := types.NewExistingBallot(types.RandomBallotID(), types.EmptyEdSignature, types.EmptyNodeID, e.FirstLayer())
 		b.EligibilityProofs = []types.VotingEligibility{{J: 1}}
@@ -595,7 +596,7 @@ func TestBeacon_BeaconsCleanupOldEpoch(t *testing.T) {
 	require.Equal(t, numEpochsToKeep, len(pd.ballotsBeacons))
 
 	epoch = epoch + numEpochsToKeep
-	err := pd.setBeacon(epoch, types.RandomBeacon())
+	err := pd.setBeacon(&beacons.Provenance{Epoch: epoch, Beacon: types.RandomBeacon()})
 	require.NoError(t, err)
 	b := types.NewExistingBallot(types.RandomBallotID(), types.EmptyEdSignature, types.EmptyNodeID, epoch.FirstLayer())
 	b.EligibilityProofs = []types.VotingEligibility{{J: 1}}
@@ -808,6 +809,9 @@ func TestBeacon_findMajorityBeacon(t *testing.T) {
 	pd.config.BeaconSyncWeightUnits = 4
 	got := pd.findMajorityBeacon(epoch)
 	require.Equal(t, beacon2, got)
+	support, weight := pd.ballotsWeight(epoch, got)
+	require.EqualValues(t, 3, support)
+	require.EqualValues(t, 5, weight)
 }
 
 func TestBeacon_findMajorityBeacon_plurality(t *testing.T) {
@@ -902,13 +906,22 @@ func TestBeacon_setBeacon(t *testing.T) {
 	tpd := setUpProtocolDriver(t)
 	epoch := types.EpochID(5)
 	tpd.mClock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
-	beacon := types.RandomBeacon()
-	require.NoError(t, tpd.setBeacon(epoch, beacon))
+	provenance := &beacons.Provenance{
+		Epoch:   epoch,
+		Beacon:  types.RandomBeacon(),
+		Source:  beacons.SourceProtocol,
+		Support: 10,
+		Weight:  20,
+	}
+	require.NoError(t, tpd.setBeacon(provenance))
+	stored, err := beacons.Provenances(tpd.cdb, epoch, epoch)
+	require.NoError(t, err)
+	require.Equal(t, []*beacons.Provenance{provenance}, stored)
 
 	// saving it again won't cause error
-	require.NoError(t, tpd.setBeacon(epoch, beacon))
+	require.NoError(t, tpd.setBeacon(provenance))
 	// but saving a different one will
-	require.ErrorIs(t, tpd.setBeacon(epoch, types.RandomBeacon()), errDifferentBeacon)
+	require.ErrorIs(t, tpd.setBeacon(&beacons.Provenance{Epoch: epoch, Beacon: types.RandomBeacon()}), errDifferentBeacon)
 }
 
 func TestBeacon_atxThresholdFraction(t *testing.T) {
@@ -1028,13 +1041,14 @@ func TestBeacon_proposalPassesEligibilityThreshold(t *testing.T) {
 			for i := 0; i < tc.wEarly; i++ {
 				signer, err := signing.NewEdSigner()
 				require.NoError(t, err)
-				proposal := buildSignedProposal(
+				proposal, err := buildSignedProposal(
 					context.Background(),
 					logtest.New(t),
 					signer.VRFSigner(),
 					3,
 					types.VRFPostIndex(1),
 				)
+				require.NoError(t, err)
 				if checker.PassThreshold(proposal) {
 					numEligible++
 				}
@@ -1103,13 +1117,14 @@ func TestBeacon_getSignedProposal(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			result := buildSignedProposal(
+			result, err := buildSignedProposal(
 				context.Background(),
 				logtest.New(t),
 				edSgn.VRFSigner(),
 				tc.epoch,
 				types.VRFPostIndex(1),
 			)
+			require.NoError(t, err)
 			require.Equal(t, tc.result, result)
 		})
 	}
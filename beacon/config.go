@@ -1,6 +1,7 @@
 package beacon
 
 import (
+	"fmt"
 	"math/big"
 	"time"
 
@@ -32,6 +33,28 @@ type Config struct {
 	// Numbers of layers to wait before determining beacon values from ballots when the node didn't participate
 	// in previous epoch.
 	BeaconSyncWeightUnits int `mapstructure:"beacon-sync-weight-units"`
+	// Observer records statistics of every protocol run in the database, so that the health of the beacon
+	// can be assessed without running a custom build.
+	Observer bool `mapstructure:"beacon-observer"`
+	// Authorities that can sign the fallback beacon, used when participation in the beacon protocol is too low.
+	FallbackAuthorities []types.NodeID `mapstructure:"beacon-fallback-authorities"`
+	// Number of distinct authorities that must sign the fallback beacon. Zero disables the fallback.
+	FallbackQuorum int `mapstructure:"beacon-fallback-quorum"`
+	// UnsafeInjection allows to set the beacon of the epoch with the admin api, so that a private network
+	// in which the beacon wasn't computed can proceed. Must never be enabled on public networks.
+	UnsafeInjection bool `mapstructure:"beacon-unsafe-injection"`
+}
+
+// Validate checks that the fallback beacon can be signed by the configured authorities.
+func (c *Config) Validate() error {
+	if c.FallbackQuorum == 0 && len(c.FallbackAuthorities) == 0 {
+		return nil
+	}
+	if c.FallbackQuorum <= 0 || c.FallbackQuorum > len(c.FallbackAuthorities) {
+		return fmt.Errorf("fallback quorum (%d) must be in range [1, %d]",
+			c.FallbackQuorum, len(c.FallbackAuthorities))
+	}
+	return nil
 }
 
 // DefaultConfig returns the default configuration for the beacon.
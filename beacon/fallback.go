@@ -0,0 +1,153 @@
+package beacon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+)
+
+var (
+	errFallbackDisabled = errors.New("fallback beacon is not configured")
+	errFallbackQuorum   = fmt.Errorf("%w: fallback beacon is not signed by quorum", pubsub.ErrValidationReject)
+	errFallbackConflict = errors.New("different fallback beacon is already recorded")
+	errFallbackEpoch    = errors.New("fallback beacon is accepted only for the current or the next epoch")
+	errFallbackComputed = errors.New("beacon for the epoch is already known")
+)
+
+// HandleFallbackBeacon handles fallback beacon from gossip.
+func (pd *ProtocolDriver) HandleFallbackBeacon(ctx context.Context, peer p2p.Peer, msg []byte) error {
+	var fallback FallbackBeacon
+	if err := codec.Decode(msg, &fallback); err != nil {
+		pd.logger.WithContext(ctx).With().Warning("received malformed fallback beacon",
+			log.Stringer("sender", peer),
+			log.Err(err),
+		)
+		return errMalformedMessage
+	}
+	return pd.OnFallbackBeacon(&fallback)
+}
+
+// SubmitFallbackBeacon uses the fallback beacon and publishes it, so that it is used by other nodes.
+func (pd *ProtocolDriver) SubmitFallbackBeacon(ctx context.Context, fallback *FallbackBeacon) error {
+	if err := pd.OnFallbackBeacon(fallback); err != nil {
+		return err
+	}
+	return pd.sendToGossip(ctx, pubsub.BeaconFallbackProtocol, codec.MustEncode(fallback))
+}
+
+// OnFallbackBeacon uses the beacon if it is signed by the quorum of configured authorities.
+// Fallback is accepted only for the current or the next epoch, and only if the beacon for that epoch
+// wasn't produced otherwise (e.g. by the protocol).
+// Fallback is recorded separately from the beacons computed by the protocol, so that its use can be audited.
+func (pd *ProtocolDriver) OnFallbackBeacon(fallback *FallbackBeacon) error {
+	current := pd.clock.CurrentLayer().GetEpoch()
+	if fallback.Epoch != current && fallback.Epoch != current+1 {
+		return fmt.Errorf("%w: fallback epoch %d, current epoch %d", errFallbackEpoch, fallback.Epoch, current)
+	}
+	if err := pd.verifyFallback(fallback); err != nil {
+		return err
+	}
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	recorded, err := beacons.GetFallback(pd.cdb, fallback.Epoch)
+	switch {
+	case err == nil && recorded.Beacon == fallback.Beacon:
+		return nil
+	case err == nil:
+		pd.logger.With().Error("authorities signed different fallback beacons",
+			fallback.Epoch,
+			log.Stringer("recorded", recorded.Beacon),
+			log.Stringer("received", fallback.Beacon),
+		)
+		return errFallbackConflict
+	case !errors.Is(err, sql.ErrNotFound):
+		return err
+	}
+	if _, exists := pd.beacons[fallback.Epoch]; exists {
+		return fmt.Errorf("%w: epoch %d", errFallbackComputed, fallback.Epoch)
+	}
+	if err := pd.cdb.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if err := beacons.SetFallback(tx, &beacons.Fallback{
+			Epoch:       fallback.Epoch,
+			Beacon:      fallback.Beacon,
+			Certificate: codec.MustEncode(fallback),
+			Received:    time.Now(),
+		}); err != nil {
+			return err
+		}
+		if err := beacons.Add(tx, fallback.Epoch, fallback.Beacon); errors.Is(err, sql.ErrObjectExists) {
+			return fmt.Errorf("%w: epoch %d", errFallbackComputed, fallback.Epoch)
+		} else if err != nil {
+			return err
+		}
+		return beacons.SetProvenance(tx, &beacons.Provenance{
+			Epoch:  fallback.Epoch,
+			Beacon: fallback.Beacon,
+			Source: beacons.SourceFallback,
+		})
+	}); err != nil {
+		return fmt.Errorf("persist fallback beacon epoch %v: %w", fallback.Epoch, err)
+	}
+	pd.beacons[fallback.Epoch] = fallback.Beacon
+	pd.logger.With().Info("using fallback beacon signed by authorities",
+		fallback.Epoch,
+		fallback.Beacon,
+		log.Int("signatures", len(fallback.Signatures)),
+	)
+	pd.onResult(fallback.Epoch, fallback.Beacon)
+	return nil
+}
+
+// SetFallbackAuthorities replaces the authorities that sign the fallback beacon.
+// The quorum doesn't change, therefore there must be enough authorities to reach it.
+func (pd *ProtocolDriver) SetFallbackAuthorities(authorities []types.NodeID) error {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	if len(authorities) < pd.config.FallbackQuorum {
+		return fmt.Errorf("%d fallback authorities can't reach quorum %d", len(authorities), pd.config.FallbackQuorum)
+	}
+	pd.config.FallbackAuthorities = slices.Clone(authorities)
+	pd.logger.With().Info("fallback beacon authorities updated", log.Int("authorities", len(authorities)))
+	return nil
+}
+
+func (pd *ProtocolDriver) verifyFallback(fallback *FallbackBeacon) error {
+	if pd.config.FallbackQuorum == 0 {
+		return errFallbackDisabled
+	}
+	pd.mu.RLock()
+	authorities := pd.config.FallbackAuthorities
+	pd.mu.RUnlock()
+	if fallback.Beacon == types.EmptyBeacon {
+		return fmt.Errorf("%w: empty fallback beacon", pubsub.ErrValidationReject)
+	}
+	msg := codec.MustEncode(&fallback.FallbackBeaconBody)
+	signed := map[types.NodeID]struct{}{}
+	for _, sig := range fallback.Signatures {
+		if !slices.Contains(authorities, sig.Authority) {
+			continue
+		}
+		if _, exists := signed[sig.Authority]; exists {
+			continue
+		}
+		if !pd.edVerifier.Verify(signing.BEACON_FALLBACK, sig.Authority, msg, sig.Signature) {
+			return fmt.Errorf("%w: invalid fallback signature from %s", pubsub.ErrValidationReject, sig.Authority)
+		}
+		signed[sig.Authority] = struct{}{}
+	}
+	if len(signed) < pd.config.FallbackQuorum {
+		return fmt.Errorf("%w: %d out of %d", errFallbackQuorum, len(signed), pd.config.FallbackQuorum)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package beacon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+)
+
+func signFallback(
+	tb testing.TB,
+	epoch types.EpochID,
+	beacon types.Beacon,
+	signers ...*signing.EdSigner,
+) *FallbackBeacon {
+	fallback := &FallbackBeacon{FallbackBeaconBody: FallbackBeaconBody{Epoch: epoch, Beacon: beacon}}
+	msg := codec.MustEncode(&fallback.FallbackBeaconBody)
+	for _, signer := range signers {
+		fallback.Signatures = append(fallback.Signatures, FallbackSignature{
+			Authority: signer.NodeID(),
+			Signature: signer.Sign(signing.BEACON_FALLBACK, msg),
+		})
+	}
+	return fallback
+}
+
+func TestBeacon_FallbackBeacon(t *testing.T) {
+	authorities := make([]*signing.EdSigner, 3)
+	for i := range authorities {
+		signer, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		authorities[i] = signer
+	}
+	outsider, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	const epoch = types.EpochID(5)
+
+	newDriver := func(tb testing.TB, quorum int) *testProtocolDriver {
+		cfg := UnitTestConfig()
+		cfg.FallbackQuorum = quorum
+		for _, signer := range authorities {
+			cfg.FallbackAuthorities = append(cfg.FallbackAuthorities, signer.NodeID())
+		}
+		tpd := newTestDriver(tb, cfg, newPublisher(tb), 1, "")
+		tpd.mClock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
+		return tpd
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		tpd := newDriver(t, 0)
+		fallback := signFallback(t, epoch, types.RandomBeacon(), authorities...)
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackDisabled)
+	})
+	t.Run("quorum", func(t *testing.T) {
+		tpd := newDriver(t, 2)
+		beacon := types.RandomBeacon()
+		fallback := signFallback(t, epoch, beacon, authorities[0], authorities[1])
+		require.NoError(t, tpd.OnFallbackBeacon(fallback))
+
+		got, err := tpd.GetBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, beacon, got)
+		persisted, err := beacons.Get(tpd.cdb, epoch)
+		require.NoError(t, err)
+		require.Equal(t, beacon, persisted)
+		recorded, err := beacons.GetFallback(tpd.cdb, epoch)
+		require.NoError(t, err)
+		require.Equal(t, beacon, recorded.Beacon)
+		var certificate FallbackBeacon
+		require.NoError(t, codec.Decode(recorded.Certificate, &certificate))
+		require.Equal(t, *fallback, certificate)
+		rst := getNoWait(t, tpd.Results())
+		require.Equal(t, epoch, rst.Epoch)
+		require.Equal(t, beacon, rst.Beacon)
+
+		// the same beacon signed by another set of authorities is ignored
+		require.NoError(t, tpd.OnFallbackBeacon(signFallback(t, epoch, beacon, authorities[1], authorities[2])))
+		again, err := beacons.GetFallback(tpd.cdb, epoch)
+		require.NoError(t, err)
+		require.Equal(t, recorded, again)
+
+		other := signFallback(t, epoch, types.RandomBeacon(), authorities...)
+		require.ErrorIs(t, tpd.OnFallbackBeacon(other), errFallbackConflict)
+		got, err = tpd.GetBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, beacon, got)
+	})
+	t.Run("computed beacon", func(t *testing.T) {
+		tpd := newDriver(t, 1)
+		computed := types.RandomBeacon()
+		require.NoError(t, tpd.setBeacon(&beacons.Provenance{
+			Epoch:  epoch,
+			Beacon: computed,
+			Source: beacons.SourceProtocol,
+		}))
+		fallback := signFallback(t, epoch, types.RandomBeacon(), authorities[2])
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackComputed)
+		got, err := tpd.GetBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, computed, got)
+		_, err = beacons.GetFallback(tpd.cdb, epoch)
+		require.ErrorIs(t, err, sql.ErrNotFound)
+
+		// beacon that is persisted but not loaded into memory
+		require.NoError(t, beacons.Add(tpd.cdb, epoch+1, types.RandomBeacon()))
+		fallback = signFallback(t, epoch+1, types.RandomBeacon(), authorities[2])
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackComputed)
+	})
+	t.Run("epoch", func(t *testing.T) {
+		tpd := newDriver(t, 1)
+		for _, target := range []types.EpochID{epoch - 1, epoch + 2} {
+			fallback := signFallback(t, target, types.RandomBeacon(), authorities[0])
+			require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackEpoch)
+			_, err := beacons.GetFallback(tpd.cdb, target)
+			require.ErrorIs(t, err, sql.ErrNotFound)
+		}
+		next := signFallback(t, epoch+1, types.RandomBeacon(), authorities[0])
+		require.NoError(t, tpd.OnFallbackBeacon(next))
+	})
+	t.Run("not enough signatures", func(t *testing.T) {
+		tpd := newDriver(t, 2)
+		for _, fallback := range []*FallbackBeacon{
+			signFallback(t, epoch, types.RandomBeacon(), authorities[0]),
+			signFallback(t, epoch, types.RandomBeacon(), authorities[0], authorities[0]),
+			signFallback(t, epoch, types.RandomBeacon(), authorities[0], outsider),
+		} {
+			require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackQuorum)
+		}
+		_, err := beacons.GetFallback(tpd.cdb, epoch)
+		require.ErrorIs(t, err, sql.ErrNotFound)
+		_, err = tpd.GetBeacon(epoch)
+		require.ErrorIs(t, err, errBeaconNotCalculated)
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		tpd := newDriver(t, 2)
+		fallback := signFallback(t, epoch, types.RandomBeacon(), authorities[0], authorities[1])
+		fallback.Signatures[1].Signature = types.RandomEdSignature()
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), pubsub.ErrValidationReject)
+	})
+	t.Run("empty beacon", func(t *testing.T) {
+		tpd := newDriver(t, 2)
+		fallback := signFallback(t, epoch, types.EmptyBeacon, authorities...)
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), pubsub.ErrValidationReject)
+	})
+	t.Run("gossip", func(t *testing.T) {
+		tpd := newDriver(t, 2)
+		require.ErrorIs(t, tpd.HandleFallbackBeacon(context.Background(), p2p.NoPeer, []byte{1}), errMalformedMessage)
+
+		beacon := types.RandomBeacon()
+		fallback := signFallback(t, epoch, beacon, authorities[0], authorities[2])
+		require.NoError(t, tpd.HandleFallbackBeacon(context.Background(), p2p.NoPeer, codec.MustEncode(fallback)))
+		got, err := tpd.GetBeacon(epoch)
+		require.NoError(t, err)
+		require.Equal(t, beacon, got)
+	})
+	t.Run("submit", func(t *testing.T) {
+		cfg := UnitTestConfig()
+		cfg.FallbackQuorum = 1
+		cfg.FallbackAuthorities = []types.NodeID{authorities[0].NodeID()}
+		publisher := pubsubmocks.NewMockPublisher(gomock.NewController(t))
+		tpd := newTestDriver(t, cfg, publisher, 1, "")
+		tpd.mClock.EXPECT().CurrentLayer().Return(epoch.FirstLayer()).AnyTimes()
+
+		fallback := signFallback(t, epoch, types.RandomBeacon(), authorities[0])
+		publisher.EXPECT().Publish(gomock.Any(), pubsub.BeaconFallbackProtocol, codec.MustEncode(fallback))
+		require.NoError(t, tpd.SubmitFallbackBeacon(context.Background(), fallback))
+
+		invalid := signFallback(t, epoch+1, types.RandomBeacon(), outsider)
+		require.ErrorIs(t, tpd.SubmitFallbackBeacon(context.Background(), invalid), errFallbackQuorum)
+	})
+	t.Run("set authorities", func(t *testing.T) {
+		tpd := newDriver(t, 1)
+		require.Error(t, tpd.SetFallbackAuthorities(nil))
+
+		fallback := signFallback(t, epoch, types.RandomBeacon(), outsider)
+		require.ErrorIs(t, tpd.OnFallbackBeacon(fallback), errFallbackQuorum)
+		require.NoError(t, tpd.SetFallbackAuthorities([]types.NodeID{outsider.NodeID()}))
+		require.NoError(t, tpd.OnFallbackBeacon(fallback))
+
+		replaced := signFallback(t, epoch+1, types.RandomBeacon(), authorities[0])
+		require.ErrorIs(t, tpd.OnFallbackBeacon(replaced), errFallbackQuorum)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.Validate())
+
+	cfg.FallbackAuthorities = []types.NodeID{types.RandomNodeID(), types.RandomNodeID()}
+	require.Error(t, cfg.Validate())
+	cfg.FallbackQuorum = 3
+	require.Error(t, cfg.Validate())
+	cfg.FallbackQuorum = -1
+	require.Error(t, cfg.Validate())
+	cfg.FallbackQuorum = 2
+	require.NoError(t, cfg.Validate())
+
+	cfg.FallbackAuthorities = nil
+	require.Error(t, cfg.Validate())
+}
@@ -183,6 +183,7 @@ func (pd *ProtocolDriver) addProposal(m ProposalMessage, cat category) error {
 	if _, ok := pd.states[m.EpochID]; !ok {
 		return errEpochNotActive
 	}
+	pd.states[m.EpochID].recordProposal(cat)
 	switch cat {
 	case valid:
 		pd.states[m.EpochID].addValidProposal(p)
@@ -198,8 +199,13 @@ func (pd *ProtocolDriver) verifyProposalMessage(logger log.Log, m ProposalMessag
 		logger.With().Warning("[proposal] failed to get VRF nonce", log.Err(err))
 		return fmt.Errorf("[proposal] get VRF nonce (miner ID %s): %w", m.NodeID, err)
 	}
+	key, err := pd.nonceFetcher.VRFKey(m.NodeID, m.EpochID)
+	if err != nil {
+		logger.With().Warning("[proposal] failed to get VRF key", log.Err(err))
+		return fmt.Errorf("[proposal] get VRF key (miner ID %s): %w", m.NodeID, err)
+	}
 	currentEpochProposal := buildProposal(logger, m.EpochID, nonce)
-	if !pd.vrfVerifier.Verify(m.NodeID, currentEpochProposal, m.VRFSignature) {
+	if !pd.vrfVerifier.Verify(key, signing.VRFMessage(m.NodeID, key, currentEpochProposal), m.VRFSignature) {
 		// TODO(nkryuchkov): attach telemetry
 		logger.With().Warning("[proposal] failed to verify VRF signature")
 		return fmt.Errorf("[proposal] verify VRF (miner ID %s): %w", m.NodeID, errVRFNotVerified)
@@ -322,6 +328,7 @@ func (pd *ProtocolDriver) storeFirstVotes(m FirstVotingMessage, nodeID types.Nod
 	}
 
 	pd.states[m.EpochID].setMinerFirstRoundVote(nodeID, voteList)
+	pd.states[m.EpochID].recordVote(types.FirstRound, voteWeight)
 	return nil
 }
 
@@ -409,7 +416,7 @@ func (pd *ProtocolDriver) storeFollowingVotes(m FollowingVotingMessage, nodeID t
 	}
 
 	thisRoundVotes := decodeVotes(m.VotesBitVector, firstRoundVotes)
-	return pd.addToVoteMargin(m.EpochID, thisRoundVotes, voteWeight)
+	return pd.addToVoteMargin(m.EpochID, m.RoundID, thisRoundVotes, voteWeight)
 }
 
 func (pd *ProtocolDriver) getProposalPhaseFinishedTime(epoch types.EpochID) time.Time {
@@ -423,7 +430,12 @@ func (pd *ProtocolDriver) getProposalPhaseFinishedTime(epoch types.EpochID) time
 	return time.Time{}.Add(time.Second)
 }
 
-func (pd *ProtocolDriver) addToVoteMargin(epoch types.EpochID, thisRoundVotes allVotes, voteWeight *big.Int) error {
+func (pd *ProtocolDriver) addToVoteMargin(
+	epoch types.EpochID,
+	round types.RoundID,
+	thisRoundVotes allVotes,
+	voteWeight *big.Int,
+) error {
 	pd.mu.Lock()
 	defer pd.mu.Unlock()
 
@@ -437,6 +449,7 @@ func (pd *ProtocolDriver) addToVoteMargin(epoch types.EpochID, thisRoundVotes al
 	for proposal := range thisRoundVotes.against {
 		pd.states[epoch].addVote(proposal, down, voteWeight)
 	}
+	pd.states[epoch].recordVote(round, voteWeight)
 
 	return nil
 }
@@ -84,13 +84,14 @@ func createProposal(
 	epoch types.EpochID,
 	corruptSignature bool,
 ) *ProposalMessage {
-	sig := buildSignedProposal(
+	sig, err := buildSignedProposal(
 		context.Background(),
 		logtest.New(t),
 		vrfSigner,
 		epoch,
 		types.VRFPostIndex(rand.Uint64()),
 	)
+	require.NoError(t, err)
 	msg := &ProposalMessage{
 		NodeID:       vrfSigner.NodeID(),
 		EpochID:      epoch,
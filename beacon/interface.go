@@ -32,7 +32,7 @@ type layerClock interface {
 }
 
 type vrfSigner interface {
-	Sign(msg []byte) types.VrfSignature
+	SignVRFContext(ctx context.Context, epoch types.EpochID, msg []byte) (types.VrfSignature, error)
 	NodeID() types.NodeID
 }
 
@@ -42,4 +42,5 @@ type vrfVerifier interface {
 
 type nonceFetcher interface {
 	VRFNonce(types.NodeID, types.EpochID) (types.VRFPostIndex, error)
+	VRFKey(types.NodeID, types.EpochID) (types.NodeID, error)
 }
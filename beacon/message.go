@@ -81,3 +81,24 @@ type FollowingVotingMessage struct {
 	SmesherID types.NodeID
 	Signature types.EdSignature
 }
+
+// FallbackBeaconBody is FallbackBeacon without signatures.
+type FallbackBeaconBody struct {
+	Epoch  types.EpochID
+	Beacon types.Beacon
+}
+
+// FallbackBeacon is the beacon for the epoch signed by the authorities that are trusted
+// in the node configuration. It is used when the beacon protocol doesn't have enough participants.
+type FallbackBeacon struct {
+	FallbackBeaconBody
+
+	// number of authorities is expected to be under 10
+	Signatures []FallbackSignature `scale:"max=100"`
+}
+
+// FallbackSignature is the signature of the authority on FallbackBeaconBody.
+type FallbackSignature struct {
+	Authority types.NodeID
+	Signature types.EdSignature
+}
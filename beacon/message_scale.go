@@ -317,3 +317,113 @@ func (t *FollowingVotingMessage) DecodeScale(dec *scale.Decoder) (total int, err
 	}
 	return total, nil
 }
+
+func (t *FallbackBeaconBody) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Epoch))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Beacon[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *FallbackBeaconBody) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Epoch = types.EpochID(field)
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Beacon[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *FallbackBeacon) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := t.FallbackBeaconBody.EncodeScale(enc)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Signatures, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *FallbackBeacon) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := t.FallbackBeaconBody.DecodeScale(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[FallbackSignature](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Signatures = field
+	}
+	return total, nil
+}
+
+func (t *FallbackSignature) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeByteArray(enc, t.Authority[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Signature[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *FallbackSignature) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := scale.DecodeByteArray(dec, t.Authority[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Signature[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
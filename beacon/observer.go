@@ -0,0 +1,116 @@
+package beacon
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/beaconstats"
+)
+
+// observation collects statistics of the protocol run in observer mode.
+// like state, it relies on ProtocolDriver's thread-safety mechanism.
+type observation struct {
+	stats  beaconstats.Epoch
+	rounds map[types.RoundID]*beaconstats.Round
+}
+
+func newObservation(epoch types.EpochID, weight uint64) *observation {
+	return &observation{
+		stats:  beaconstats.Epoch{Epoch: epoch, Weight: weight},
+		rounds: map[types.RoundID]*beaconstats.Round{},
+	}
+}
+
+func (o *observation) round(round types.RoundID) *beaconstats.Round {
+	stats, exists := o.rounds[round]
+	if !exists {
+		stats = &beaconstats.Round{Round: round}
+		o.rounds[round] = stats
+	}
+	return stats
+}
+
+// encode returns a copy of the statistics with rounds in ascending order.
+func (o *observation) encode() *beaconstats.Epoch {
+	stats := o.stats
+	stats.Rounds = make([]beaconstats.Round, 0, len(o.rounds))
+	for _, round := range o.rounds {
+		stats.Rounds = append(stats.Rounds, *round)
+	}
+	sort.Slice(stats.Rounds, func(i, j int) bool {
+		return stats.Rounds[i].Round < stats.Rounds[j].Round
+	})
+	return &stats
+}
+
+func (s *state) recordProposal(cat category) {
+	if s.observation == nil {
+		return
+	}
+	switch cat {
+	case valid:
+		s.observation.stats.Valid++
+	case potentiallyValid:
+		s.observation.stats.PotentiallyValid++
+	default:
+		s.observation.stats.Invalid++
+	}
+}
+
+func (s *state) recordVote(round types.RoundID, weight *big.Int) {
+	s.voteWeight[round] += weight.Uint64()
+	if s.observation == nil {
+		return
+	}
+	stats := s.observation.round(round)
+	stats.Votes++
+	stats.Weight += weight.Uint64()
+}
+
+func (s *state) recordTally(round types.RoundID, votes allVotes, undecided proposalList) {
+	if s.observation == nil {
+		return
+	}
+	stats := s.observation.round(round)
+	stats.Support = uint32(len(votes.support))
+	stats.Against = uint32(len(votes.against))
+	stats.Undecided = uint32(len(undecided))
+}
+
+func (s *state) recordCoin(round types.RoundID, flip bool) {
+	if s.observation == nil {
+		return
+	}
+	s.observation.round(round).Coin = &flip
+}
+
+func (s *state) recordBeacon(beacon types.Beacon) {
+	if s.observation == nil {
+		return
+	}
+	s.observation.stats.Beacon = beacon
+}
+
+// persistObservation stores statistics of the protocol run in the epoch if the node is in observer mode.
+func (pd *ProtocolDriver) persistObservation(logger log.Log, st *state) {
+	pd.mu.RLock()
+	if st.observation == nil {
+		pd.mu.RUnlock()
+		return
+	}
+	stats := st.observation.encode()
+	pd.mu.RUnlock()
+	if err := beaconstats.Add(pd.cdb, stats); err != nil {
+		logger.With().Error("failed to persist beacon protocol statistics", log.Err(err))
+		return
+	}
+	logger.With().Info("recorded beacon protocol statistics",
+		log.Uint32("valid", stats.Valid),
+		log.Uint32("potentially_valid", stats.PotentiallyValid),
+		log.Uint32("invalid", stats.Invalid),
+		log.Int("rounds", len(stats.Rounds)),
+		log.Stringer("beacon", stats.Beacon),
+	)
+}
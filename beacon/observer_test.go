@@ -0,0 +1,107 @@
+package beacon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/beaconstats"
+)
+
+func TestBeacon_Observer(t *testing.T) {
+	const (
+		numNodes         = 3
+		numMinersPerNode = 4
+	)
+	testNodes := make([]*testProtocolDriver, 0, numNodes)
+	publisher := pubsubmocks.NewMockPublisher(gomock.NewController(t))
+	publisher.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, protocol string, data []byte) error {
+			for i, node := range testNodes {
+				peer := p2p.Peer(fmt.Sprint(i))
+				switch protocol {
+				case pubsub.BeaconProposalProtocol:
+					require.NoError(t, node.HandleProposal(ctx, peer, data))
+				case pubsub.BeaconFirstVotesProtocol:
+					require.NoError(t, node.HandleFirstVotes(ctx, peer, data))
+				case pubsub.BeaconFollowingVotesProtocol:
+					require.NoError(t, node.HandleFollowingVotes(ctx, peer, data))
+				}
+			}
+			return nil
+		}).AnyTimes()
+
+	atxPublishLid := types.LayerID(types.GetLayersPerEpoch()*2 - 1)
+	current := atxPublishLid.Add(1)
+	dbs := make([]*datastore.CachedDB, 0, numNodes)
+	now := time.Now()
+	for i := 0; i < numNodes; i++ {
+		cfg := NodeSimUnitTestConfig()
+		miners := numMinersPerNode
+		if i == 0 {
+			// the first node only observes the protocol
+			cfg.Observer = true
+			miners = 0
+		}
+		node := newTestDriver(t, cfg, publisher, miners, fmt.Sprintf("node-%d", i))
+		node.mSync.EXPECT().IsSynced(gomock.Any()).Return(true).AnyTimes()
+		node.mClock.EXPECT().CurrentLayer().Return(current).AnyTimes()
+		node.mClock.EXPECT().LayerToTime(current).Return(now).AnyTimes()
+		testNodes = append(testNodes, node)
+		dbs = append(dbs, node.cdb)
+	}
+	for _, node := range testNodes {
+		for _, db := range dbs {
+			for _, s := range node.signers {
+				createATX(t, db, atxPublishLid, s.(*signing.EdSigner), 1, time.Now().Add(-1*time.Second))
+			}
+		}
+	}
+	var wg sync.WaitGroup
+	for _, node := range testNodes {
+		wg.Add(1)
+		go func(testNode *testProtocolDriver) {
+			require.NoError(t, testNode.onNewEpoch(context.Background(), types.EpochID(2)))
+			wg.Done()
+		}(node)
+	}
+	wg.Wait()
+
+	beacon, err := testNodes[0].GetBeacon(types.EpochID(3))
+	require.NoError(t, err)
+	stats, err := beaconstats.Get(testNodes[0].cdb, types.EpochID(2))
+	require.NoError(t, err)
+	require.Equal(t, beacon, stats.Beacon)
+	require.NotZero(t, stats.Weight)
+	require.NotZero(t, stats.Valid)
+	require.LessOrEqual(t, int(stats.Valid+stats.PotentiallyValid+stats.Invalid), (numNodes-1)*numMinersPerNode)
+	require.Len(t, stats.Rounds, int(NodeSimUnitTestConfig().RoundsNumber))
+	for i, round := range stats.Rounds {
+		require.EqualValues(t, i, round.Round)
+		require.EqualValues(t, (numNodes-1)*numMinersPerNode, round.Votes)
+		require.NotZero(t, round.Weight)
+		require.EqualValues(t, stats.Valid+stats.PotentiallyValid,
+			round.Support+round.Against+round.Undecided, "round %d", i)
+		if round.Round == types.FirstRound {
+			require.Nil(t, round.Coin)
+		} else {
+			require.NotNil(t, round.Coin)
+		}
+	}
+
+	// statistics are not recorded if the observer mode is disabled
+	_, err = beaconstats.Get(testNodes[1].cdb, types.EpochID(2))
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
@@ -33,6 +33,10 @@ type state struct {
 	proposalPhaseFinishedTime time.Time
 	proposalChecker           eligibilityChecker
 	minerAtxs                 map[types.NodeID]*minerInfo
+	// voteWeight is the weight of the voters in each round.
+	voteWeight map[types.RoundID]uint64
+	// observation is not nil in observer mode.
+	observation *observation
 }
 
 func newState(
@@ -53,6 +57,7 @@ func newState(
 		votesMargin:             map[Proposal]*big.Int{},
 		hasProposed:             make(map[types.NodeID]struct{}),
 		hasVoted:                make(map[types.NodeID]*votesTracker),
+		voteWeight:              make(map[types.RoundID]uint64),
 		proposalChecker:         checker,
 	}
 }
@@ -1,13 +1,15 @@
 package weakcoin
 
 import (
+	"context"
+
 	"github.com/spacemeshos/go-spacemesh/common/types"
 )
 
 //go:generate mockgen -typed -package=weakcoin -destination=./mocks.go -source=./interface.go
 
 type vrfSigner interface {
-	Sign(msg []byte) types.VrfSignature
+	SignVRFContext(ctx context.Context, epoch types.EpochID, msg []byte) (types.VrfSignature, error)
 	NodeID() types.NodeID
 }
 
@@ -17,6 +19,7 @@ type vrfVerifier interface {
 
 type nonceFetcher interface {
 	VRFNonce(types.NodeID, types.EpochID) (types.VRFPostIndex, error)
+	VRFKey(types.NodeID, types.EpochID) (types.NodeID, error)
 }
 
 type allowance interface {
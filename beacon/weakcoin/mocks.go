@@ -10,6 +10,7 @@
 package weakcoin
 
 import (
+	context "context"
 	reflect "reflect"
 
 	types "github.com/spacemeshos/go-spacemesh/common/types"
@@ -77,40 +78,41 @@ func (c *MockvrfSignerNodeIDCall) DoAndReturn(f func() types.NodeID) *MockvrfSig
 	return c
 }
 
-// Sign mocks base method.
-func (m *MockvrfSigner) Sign(msg []byte) types.VrfSignature {
+// SignVRFContext mocks base method.
+func (m *MockvrfSigner) SignVRFContext(ctx context.Context, epoch types.EpochID, msg []byte) (types.VrfSignature, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Sign", msg)
+	ret := m.ctrl.Call(m, "SignVRFContext", ctx, epoch, msg)
 	ret0, _ := ret[0].(types.VrfSignature)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Sign indicates an expected call of Sign.
-func (mr *MockvrfSignerMockRecorder) Sign(msg any) *MockvrfSignerSignCall {
+// SignVRFContext indicates an expected call of SignVRFContext.
+func (mr *MockvrfSignerMockRecorder) SignVRFContext(ctx, epoch, msg any) *MockvrfSignerSignVRFContextCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockvrfSigner)(nil).Sign), msg)
-	return &MockvrfSignerSignCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignVRFContext", reflect.TypeOf((*MockvrfSigner)(nil).SignVRFContext), ctx, epoch, msg)
+	return &MockvrfSignerSignVRFContextCall{Call: call}
 }
 
-// MockvrfSignerSignCall wrap *gomock.Call
-type MockvrfSignerSignCall struct {
+// MockvrfSignerSignVRFContextCall wrap *gomock.Call
+type MockvrfSignerSignVRFContextCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockvrfSignerSignCall) Return(arg0 types.VrfSignature) *MockvrfSignerSignCall {
-	c.Call = c.Call.Return(arg0)
+func (c *MockvrfSignerSignVRFContextCall) Return(arg0 types.VrfSignature, arg1 error) *MockvrfSignerSignVRFContextCall {
+	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockvrfSignerSignCall) Do(f func([]byte) types.VrfSignature) *MockvrfSignerSignCall {
+func (c *MockvrfSignerSignVRFContextCall) Do(f func(context.Context, types.EpochID, []byte) (types.VrfSignature, error)) *MockvrfSignerSignVRFContextCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockvrfSignerSignCall) DoAndReturn(f func([]byte) types.VrfSignature) *MockvrfSignerSignCall {
+func (c *MockvrfSignerSignVRFContextCall) DoAndReturn(f func(context.Context, types.EpochID, []byte) (types.VrfSignature, error)) *MockvrfSignerSignVRFContextCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -199,6 +201,45 @@ func (m *MocknonceFetcher) EXPECT() *MocknonceFetcherMockRecorder {
 	return m.recorder
 }
 
+// VRFKey mocks base method.
+func (m *MocknonceFetcher) VRFKey(arg0 types.NodeID, arg1 types.EpochID) (types.NodeID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VRFKey", arg0, arg1)
+	ret0, _ := ret[0].(types.NodeID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VRFKey indicates an expected call of VRFKey.
+func (mr *MocknonceFetcherMockRecorder) VRFKey(arg0, arg1 any) *MocknonceFetcherVRFKeyCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VRFKey", reflect.TypeOf((*MocknonceFetcher)(nil).VRFKey), arg0, arg1)
+	return &MocknonceFetcherVRFKeyCall{Call: call}
+}
+
+// MocknonceFetcherVRFKeyCall wrap *gomock.Call
+type MocknonceFetcherVRFKeyCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MocknonceFetcherVRFKeyCall) Return(arg0 types.NodeID, arg1 error) *MocknonceFetcherVRFKeyCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MocknonceFetcherVRFKeyCall) Do(f func(types.NodeID, types.EpochID) (types.NodeID, error)) *MocknonceFetcherVRFKeyCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MocknonceFetcherVRFKeyCall) DoAndReturn(f func(types.NodeID, types.EpochID) (types.NodeID, error)) *MocknonceFetcherVRFKeyCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // VRFNonce mocks base method.
 func (m *MocknonceFetcher) VRFNonce(arg0 types.NodeID, arg1 types.EpochID) (types.VRFPostIndex, error) {
 	m.ctrl.T.Helper()
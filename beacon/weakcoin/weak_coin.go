@@ -13,6 +13,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/signing"
 )
 
 var (
@@ -242,8 +243,13 @@ func (wc *WeakCoin) updateProposal(ctx context.Context, message Message) error {
 		wc.logger.With().Error("failed to get vrf nonce", log.Err(err))
 		return fmt.Errorf("failed to get vrf nonce for node %s: %w", message.NodeID, err)
 	}
+	key, err := wc.nonceFetcher.VRFKey(message.NodeID, message.Epoch)
+	if err != nil {
+		wc.logger.With().Error("failed to get vrf key", log.Err(err))
+		return fmt.Errorf("failed to get vrf key for node %s: %w", message.NodeID, err)
+	}
 	buf := wc.encodeProposal(message.Epoch, nonce, message.Round, message.Unit)
-	if !wc.verifier.Verify(message.NodeID, buf, message.VRFSignature) {
+	if !wc.verifier.Verify(key, signing.VRFMessage(message.NodeID, key, buf), message.VRFSignature) {
 		return fmt.Errorf("signature is invalid signature %x", message.VRFSignature)
 	}
 
@@ -261,6 +267,7 @@ func (wc *WeakCoin) updateProposal(ctx context.Context, message Message) error {
 }
 
 func (wc *WeakCoin) prepareProposal(
+	ctx context.Context,
 	epoch types.EpochID,
 	signer vrfSigner,
 	nonce types.VRFPostIndex,
@@ -274,7 +281,11 @@ func (wc *WeakCoin) prepareProposal(
 	var smallest *types.VrfSignature
 	for unit := uint32(0); unit < minerAllowance; unit++ {
 		proposal := wc.encodeProposal(epoch, nonce, round, unit)
-		signature := signer.Sign(proposal)
+		signature, err := signer.SignVRFContext(ctx, epoch, proposal)
+		if err != nil {
+			wc.logger.With().Warning("failed to sign weak coin proposal", epoch, round, log.Err(err))
+			return nil, types.EmptyVrfSignature
+		}
 		if wc.aboveThreshold(signature) {
 			continue
 		}
@@ -306,7 +317,7 @@ func (wc *WeakCoin) publishProposal(
 	nonce types.VRFPostIndex,
 	round types.RoundID,
 ) {
-	msg, proposal := wc.prepareProposal(epoch, signer, nonce, round)
+	msg, proposal := wc.prepareProposal(ctx, epoch, signer, nonce, round)
 	if msg == nil {
 		return
 	}
@@ -44,7 +44,7 @@ func staticSigner(
 ) *weakcoin.MockvrfSigner {
 	tb.Helper()
 	signer := weakcoin.NewMockvrfSigner(ctrl)
-	signer.EXPECT().Sign(gomock.Any()).Return(sig).AnyTimes()
+	signer.EXPECT().SignVRFContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(sig, nil).AnyTimes()
 	signer.EXPECT().NodeID().Return(nodeId).AnyTimes()
 	return signer
 }
@@ -60,6 +60,9 @@ func nonceFetcher(tb testing.TB, ctrl *gomock.Controller) *weakcoin.MocknonceFet
 	tb.Helper()
 	fetcher := weakcoin.NewMocknonceFetcher(ctrl)
 	fetcher.EXPECT().VRFNonce(gomock.Any(), gomock.Any()).Return(types.VRFPostIndex(1), nil).AnyTimes()
+	fetcher.EXPECT().VRFKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id types.NodeID, _ types.EpochID) (types.NodeID, error) { return id, nil },
+	).AnyTimes()
 	return fetcher
 }
 
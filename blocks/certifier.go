@@ -82,7 +82,7 @@ type Certifier struct {
 
 	db         *sql.Database
 	oracle     eligibility.Rolacle
-	signers    map[types.NodeID]*signing.EdSigner
+	signers    map[types.NodeID]signing.Signer
 	edVerifier *signing.EdVerifier
 	publisher  pubsub.Publisher
 	layerClock layerClock
@@ -113,7 +113,7 @@ func NewCertifier(
 		cfg:         defaultCertConfig(),
 		db:          db,
 		oracle:      o,
-		signers:     make(map[types.NodeID]*signing.EdSigner),
+		signers:     make(map[types.NodeID]signing.Signer),
 		edVerifier:  v,
 		publisher:   p,
 		layerClock:  lc,
@@ -130,7 +130,7 @@ func NewCertifier(
 	return c
 }
 
-func (c *Certifier) Register(sig *signing.EdSigner) {
+func (c *Certifier) Register(sig signing.Signer) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if _, exists := c.signers[sig.NodeID()]; exists {
@@ -243,12 +243,15 @@ func (c *Certifier) CertifyIfEligible(ctx context.Context, lid types.LayerID, bi
 
 func (c *Certifier) certifySingleSigner(
 	ctx context.Context,
-	s *signing.EdSigner,
+	s signing.Signer,
 	lid types.LayerID,
 	bid types.BlockID,
 	beacon types.Beacon,
 ) error {
-	proof := eligibility.GenVRF(context.Background(), s.VRFSigner(), beacon, lid, eligibility.CertifyRound)
+	proof, err := eligibility.GenVRF(ctx, s, beacon, lid, eligibility.CertifyRound)
+	if err != nil {
+		return fmt.Errorf("generating eligibility proof: %w", err)
+	}
 	eligibilityCount, err := c.oracle.CalcEligibility(
 		ctx,
 		lid,
@@ -264,7 +267,10 @@ func (c *Certifier) certifySingleSigner(
 		return nil
 	}
 
-	msg := newCertifyMsg(s, lid, bid, proof, eligibilityCount)
+	msg, err := newCertifyMsg(ctx, s, lid, bid, proof, eligibilityCount)
+	if err != nil {
+		return fmt.Errorf("signing block certification message: %w", err)
+	}
 	if err = c.publisher.Publish(ctx, pubsub.BlockCertify, codec.MustEncode(msg)); err != nil {
 		return fmt.Errorf("publishing block certification message: %w", err)
 	}
@@ -272,12 +278,13 @@ func (c *Certifier) certifySingleSigner(
 }
 
 func newCertifyMsg(
-	s *signing.EdSigner,
+	ctx context.Context,
+	s signing.Signer,
 	lid types.LayerID,
 	bid types.BlockID,
 	proof types.VrfSignature,
 	eligibility uint16,
-) *types.CertifyMessage {
+) (*types.CertifyMessage, error) {
 	msg := &types.CertifyMessage{
 		CertifyContent: types.CertifyContent{
 			LayerID:        lid,
@@ -287,8 +294,12 @@ func newCertifyMsg(
 		},
 		SmesherID: s.NodeID(),
 	}
-	msg.Signature = s.Sign(signing.HARE, msg.Bytes())
-	return msg
+	sig, err := s.SignContext(ctx, signing.HARE, msg.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	msg.Signature = sig
+	return msg, nil
 }
 
 // NumCached returns the number of layers being cached in memory.
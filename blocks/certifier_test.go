@@ -732,7 +732,8 @@ func TestNewCertifyMsg(t *testing.T) {
 	proof := types.RandomVrfSignature()
 	blockID := types.RandomBlockID()
 
-	msg := newCertifyMsg(signer, types.LayerID(1), blockID, proof, 77)
+	msg, err := newCertifyMsg(context.Background(), signer, types.LayerID(1), blockID, proof, 77)
+	require.NoError(t, err)
 
 	require.Equal(t, types.LayerID(1), msg.LayerID)
 	require.Equal(t, blockID, msg.BlockID)
@@ -167,7 +167,7 @@ func createModifiedATXs(
 		vAtx, err := onAtx(atx)
 		require.NoError(tb, err)
 
-		data.AddFromHeader(vAtx.ToHeader(), 0, false)
+		data.AddFromHeader(vAtx.ToHeader(), 0, vAtx.SmesherID, false)
 		atxes = append(atxes, atx)
 	}
 	return signers, atxes
@@ -0,0 +1,311 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const (
+	// ParamsUpdateName is the name of the runtime parameters update relative to the bootstrap url.
+	ParamsUpdateName = "params-update"
+
+	paramsDirName = "params"
+	auditFile     = "audit.log"
+
+	// criticalQuorum is the least number of authorities that must sign the update of the golden atxs
+	// or the fallback beacon authorities, regardless of the configured quorum.
+	criticalQuorum = 2
+)
+
+var (
+	ErrUnknownAuthority = errors.New("params update is not signed by a configured authority")
+	ErrInvalidSignature = errors.New("invalid params update signature")
+	ErrInvalidParams    = errors.New("invalid params")
+	ErrQuorum           = errors.New("params update is not signed by quorum")
+)
+
+// ParamsUpdate is the update of the runtime parameters signed by the configured authorities.
+// The signatures are over the raw bytes of the params, so that they are verified before they are decoded.
+type ParamsUpdate struct {
+	Params     json.RawMessage   `json:"params"`
+	Signatures []ParamsSignature `json:"signatures"`
+}
+
+// ParamsSignature is the hex encoded signature of the params by the authority.
+type ParamsSignature struct {
+	Authority types.NodeID `json:"authority"`
+	Signature string       `json:"signature"`
+}
+
+// Params are the runtime parameters that are changed by the update without restarting the node.
+// Parameters that are not set in the update remain unchanged.
+type Params struct {
+	// Sequence orders the updates. The update is applied only if its sequence is higher
+	// than the sequence of the last applied update, which prevents replays and rollbacks.
+	Sequence            uint64             `json:"sequence"`
+	PoetServers         []types.PoetServer `json:"poet-servers,omitempty"`
+	GoldenATXs          map[string]string  `json:"golden-atxs,omitempty"`
+	FallbackAuthorities []types.NodeID     `json:"beacon-fallback-authorities,omitempty"`
+}
+
+// VerifiedParams are the params from the update with the valid signatures of the authorities.
+type VerifiedParams struct {
+	Sequence            uint64
+	Authorities         []types.NodeID
+	PoetServers         []types.PoetServer
+	GoldenATXs          map[types.EpochID]types.ATXID
+	FallbackAuthorities []types.NodeID
+	Persisted           string
+}
+
+// Changed returns the names of the parameters that are changed by the update.
+func (vp *VerifiedParams) Changed() []string {
+	var changed []string
+	if len(vp.PoetServers) > 0 {
+		changed = append(changed, "poet-servers")
+	}
+	if len(vp.GoldenATXs) > 0 {
+		changed = append(changed, "golden-atxs")
+	}
+	if len(vp.FallbackAuthorities) > 0 {
+		changed = append(changed, "beacon-fallback-authorities")
+	}
+	return changed
+}
+
+func (vp *VerifiedParams) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddString("persisted", vp.Persisted)
+	encoder.AddUint64("sequence", vp.Sequence)
+	encoder.AddInt("authorities", len(vp.Authorities))
+	encoder.AddInt("poet_servers", len(vp.PoetServers))
+	encoder.AddInt("golden_atxs", len(vp.GoldenATXs))
+	encoder.AddInt("fallback_authorities", len(vp.FallbackAuthorities))
+	return nil
+}
+
+// AuditEntry is the record of the runtime parameters update in the audit log.
+// Error is set if the update was verified but failed to apply.
+type AuditEntry struct {
+	Time        time.Time      `json:"time"`
+	Sequence    uint64         `json:"sequence"`
+	Authorities []types.NodeID `json:"authorities"`
+	Source      string         `json:"source"`
+	Changed     []string       `json:"changed"`
+	Error       string         `json:"error,omitempty"`
+}
+
+func validateParams(cfg Config, verifier *signing.EdVerifier, data []byte) (*VerifiedParams, error) {
+	update := &ParamsUpdate{}
+	if err := json.Unmarshal(data, update); err != nil {
+		return nil, fmt.Errorf("unmarshal params update: %w", err)
+	}
+	var signed []types.NodeID
+	for _, sig := range update.Signatures {
+		if !slices.Contains(cfg.Authorities, sig.Authority) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAuthority, sig.Authority.ShortString())
+		}
+		if slices.Contains(signed, sig.Authority) {
+			continue
+		}
+		raw, err := hex.DecodeString(sig.Signature)
+		if err != nil || len(raw) != types.EdSignatureSize {
+			return nil, fmt.Errorf("%w: malformed %v", ErrInvalidSignature, sig.Signature)
+		}
+		if !verifier.Verify(signing.BOOTSTRAP_PARAMS, sig.Authority, update.Params, types.EdSignature(raw)) {
+			return nil, fmt.Errorf("%w: from %s", ErrInvalidSignature, sig.Authority.ShortString())
+		}
+		signed = append(signed, sig.Authority)
+	}
+	if len(signed) == 0 || len(signed) < cfg.AuthorityQuorum {
+		return nil, fmt.Errorf("%w: %d out of %d", ErrQuorum, len(signed), cfg.AuthorityQuorum)
+	}
+
+	params := &Params{}
+	if err := json.Unmarshal(update.Params, params); err != nil {
+		return nil, fmt.Errorf("%w: unmarshal: %w", ErrInvalidParams, err)
+	}
+	if params.Sequence == 0 {
+		return nil, fmt.Errorf("%w: sequence must be positive", ErrInvalidParams)
+	}
+	if (len(params.GoldenATXs) > 0 || len(params.FallbackAuthorities) > 0) && len(signed) < criticalQuorum {
+		return nil, fmt.Errorf("%w: golden atxs and fallback authorities require %d signatures, got %d",
+			ErrQuorum, criticalQuorum, len(signed))
+	}
+	verified := &VerifiedParams{
+		Sequence:            params.Sequence,
+		Authorities:         signed,
+		PoetServers:         params.PoetServers,
+		FallbackAuthorities: params.FallbackAuthorities,
+	}
+	for _, server := range params.PoetServers {
+		u, err := url.Parse(server.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: poet address %v", ErrInvalidParams, server.Address)
+		}
+	}
+	if len(params.GoldenATXs) > 0 {
+		verified.GoldenATXs = make(map[types.EpochID]types.ATXID, len(params.GoldenATXs))
+		for epoch, id := range params.GoldenATXs {
+			parsed, err := strconv.ParseUint(epoch, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%w: golden atx epoch %v", ErrInvalidParams, epoch)
+			}
+			golden, err := hex.DecodeString(id)
+			if err != nil || len(golden) != types.ATXIDSize {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidGolden, id)
+			}
+			verified.GoldenATXs[types.EpochID(parsed)] = types.ATXID(golden)
+		}
+	}
+	return verified, nil
+}
+
+func paramsDir(dataDir string) string {
+	return filepath.Join(bootstrapDir(dataDir), paramsDirName)
+}
+
+// ParamsFilename returns the file where the last applied params update is persisted.
+func ParamsFilename(dataDir string) string {
+	return filepath.Join(paramsDir(dataDir), ParamsUpdateName)
+}
+
+// AuditFilename returns the file with the audit log of the params updates, one json entry per line.
+func AuditFilename(dataDir string) string {
+	return filepath.Join(paramsDir(dataDir), auditFile)
+}
+
+func (u *Updater) appliedSequence() uint64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.paramsSequence
+}
+
+func (u *Updater) loadParams(ctx context.Context) error {
+	persisted := ParamsFilename(u.cfg.DataDir)
+	data, err := afero.ReadFile(u.fs, persisted)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read params update %v: %w", persisted, err)
+	}
+	verified, err := validateParams(u.cfg, u.verifier, data)
+	if err != nil {
+		// authorities may have been changed in the config since the update was applied
+		u.logger.WithContext(ctx).With().Warning("ignoring persisted params update",
+			log.String("persisted", persisted),
+			log.Err(err),
+		)
+		return nil
+	}
+	verified.Persisted = persisted
+	u.logger.WithContext(ctx).With().Info("loaded params update", log.Inline(verified))
+	return u.applyParams(ctx, verified, persisted, nil)
+}
+
+func (u *Updater) checkParamsUpdate(ctx context.Context) error {
+	uri := fmt.Sprintf("%s/%s", u.cfg.URL, ParamsUpdateName)
+	resource, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("parse params uri: %w", err)
+	}
+	data, err := query(ctx, u.client, resource)
+	if err != nil {
+		queryFailureCount.Add(1)
+		return err
+	}
+	queryOkCount.Add(1)
+	if len(data) == 0 {
+		return nil
+	}
+	verified, err := validateParams(u.cfg, u.verifier, data)
+	if err != nil {
+		return err
+	}
+	if verified.Sequence <= u.appliedSequence() {
+		// the same update is served until the next one is published
+		return nil
+	}
+	verified.Persisted = ParamsFilename(u.cfg.DataDir)
+	u.logger.WithContext(ctx).With().Info("new params update", log.Inline(verified))
+	return u.applyParams(ctx, verified, uri, data)
+}
+
+// applyParams applies the verified params with the handler and records the outcome in the audit log.
+// The update that was received from the source is persisted only if it was applied, so that it is
+// applied again after restart.
+func (u *Updater) applyParams(ctx context.Context, verified *VerifiedParams, source string, data []byte) error {
+	u.mu.Lock()
+	u.paramsSequence = verified.Sequence
+	u.mu.Unlock()
+
+	var err error
+	if u.onParams != nil {
+		err = u.onParams(ctx, verified)
+	}
+	if err == nil && data != nil {
+		err = u.persistParams(verified.Persisted, data)
+	}
+	entry := AuditEntry{
+		Time:        time.Now().UTC(),
+		Sequence:    verified.Sequence,
+		Authorities: verified.Authorities,
+		Source:      source,
+		Changed:     verified.Changed(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		u.logger.WithContext(ctx).With().Error("failed to apply params update",
+			log.Inline(verified),
+			log.Err(err),
+		)
+	}
+	if aerr := u.audit(&entry); aerr != nil {
+		return errors.Join(err, aerr)
+	}
+	return err
+}
+
+func (u *Updater) persistParams(filename string, data []byte) error {
+	if err := u.fs.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("create params dir %s: %w", filename, err)
+	}
+	if err := afero.WriteFile(u.fs, filename, data, 0o600); err != nil {
+		return fmt.Errorf("persist params update %s: %w", filename, err)
+	}
+	return nil
+}
+
+func (u *Updater) audit(entry *AuditEntry) error {
+	filename := AuditFilename(u.cfg.DataDir)
+	if err := u.fs.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("create params dir %s: %w", filename, err)
+	}
+	f, err := u.fs.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log %s: %w", filename, err)
+	}
+	defer f.Close()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit log %s: %w", filename, err)
+	}
+	return nil
+}
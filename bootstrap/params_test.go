@@ -0,0 +1,248 @@
+package bootstrap_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/bootstrap"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const (
+	params1 = `{
+  "sequence": 1,
+  "poet-servers": [{"address": "https://poet.example.com", "pubkey": "cW5ZTT5QNxJcr2nWDI6IUiwVbxAhoRm7e9Vje8tcjG8="}],
+  "golden-atxs": {"5": "a7aac3edcef469b2ad9e6465af4350d28f3d953c6c6660e37954698839125fbd"}
+}`
+	params2 = `{"sequence": 2, "beacon-fallback-authorities": ["cW5ZTT5QNxJcr2nWDI6IUiwVbxAhoRm7e9Vje8tcjG8="]}`
+	params3 = `{"sequence": 3, "poet-servers": [{"address": "https://poet2.example.com", "pubkey": ""}]}`
+)
+
+func signParams(tb testing.TB, params string, signers ...*signing.EdSigner) string {
+	tb.Helper()
+	sigs := make([]string, 0, len(signers))
+	for _, signer := range signers {
+		id := signer.NodeID()
+		authority, err := id.MarshalText()
+		require.NoError(tb, err)
+		sig := signer.Sign(signing.BOOTSTRAP_PARAMS, []byte(params))
+		sigs = append(sigs, fmt.Sprintf(`{"authority": %q, "signature": %q}`, authority, hex.EncodeToString(sig[:])))
+	}
+	// params are embedded as is, as the signatures are over the raw bytes
+	return fmt.Sprintf(`{"params": %s, "signatures": [%s]}`, params, strings.Join(sigs, ","))
+}
+
+func readAudit(tb testing.TB, fs afero.Fs, dataDir string) []bootstrap.AuditEntry {
+	tb.Helper()
+	f, err := fs.Open(bootstrap.AuditFilename(dataDir))
+	require.NoError(tb, err)
+	defer f.Close()
+	var entries []bootstrap.AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry bootstrap.AuditEntry
+		require.NoError(tb, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(tb, scanner.Err())
+	return entries
+}
+
+func TestParamsUpdate(t *testing.T) {
+	authority, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	second, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	outsider, err := signing.NewEdSigner()
+	require.NoError(t, err)
+
+	var served atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+bootstrap.ParamsUpdateName {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(served.Load().(string)))
+	}))
+	t.Cleanup(ts.Close)
+
+	cfg := bootstrap.DefaultConfig()
+	cfg.URL = ts.URL
+	cfg.Authorities = []types.NodeID{authority.NodeID(), second.NodeID()}
+	cfg.AuthorityQuorum = 1
+	fs := afero.NewMemMapFs()
+	mc := bootstrap.NewMocklayerClock(gomock.NewController(t))
+	mc.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+
+	var (
+		applied  []*bootstrap.VerifiedParams
+		applyErr error
+	)
+	newUpdater := func() *bootstrap.Updater {
+		return bootstrap.New(
+			mc,
+			bootstrap.WithConfig(cfg),
+			bootstrap.WithLogger(logtest.New(t)),
+			bootstrap.WithFilesystem(fs),
+			bootstrap.WithHttpClient(ts.Client()),
+			bootstrap.WithParamsHandler(func(_ context.Context, params *bootstrap.VerifiedParams) error {
+				applied = append(applied, params)
+				return applyErr
+			}),
+		)
+	}
+	updater := newUpdater()
+
+	// golden atxs can't be changed by a single authority
+	served.Store(signParams(t, params1, authority, authority))
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrQuorum)
+	require.Empty(t, applied)
+
+	served.Store(signParams(t, params1, authority, second))
+	require.NoError(t, updater.DoIt(context.Background()))
+	require.Len(t, applied, 1)
+	require.EqualValues(t, 1, applied[0].Sequence)
+	require.Equal(t, []types.NodeID{authority.NodeID(), second.NodeID()}, applied[0].Authorities)
+	require.Len(t, applied[0].PoetServers, 1)
+	require.Equal(t, "https://poet.example.com", applied[0].PoetServers[0].Address)
+	require.Equal(t, map[types.EpochID]types.ATXID{
+		5: types.ATXID(types.HexToHash32("a7aac3edcef469b2ad9e6465af4350d28f3d953c6c6660e37954698839125fbd")),
+	}, applied[0].GoldenATXs)
+	require.Empty(t, applied[0].FallbackAuthorities)
+
+	// the same update is not applied again
+	require.NoError(t, updater.DoIt(context.Background()))
+	require.Len(t, applied, 1)
+
+	served.Store(signParams(t, params2, outsider, authority))
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrUnknownAuthority)
+	served.Store(signParams(t, params2, authority))
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrQuorum)
+	tampered := signParams(t, params2, authority, second)
+	served.Store(strings.Replace(tampered, `"sequence": 2`, `"sequence": 3`, 1))
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrInvalidSignature)
+	served.Store(`{"params": {"sequence": 2}, "signatures": []}`)
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrQuorum)
+	require.Len(t, applied, 1)
+
+	applyErr = errors.New("apply failed")
+	served.Store(signParams(t, params2, authority, second))
+	require.ErrorIs(t, updater.DoIt(context.Background()), applyErr)
+	require.Len(t, applied, 2)
+	require.Len(t, applied[1].FallbackAuthorities, 1)
+
+	entries := readAudit(t, fs, cfg.DataDir)
+	require.Len(t, entries, 2)
+	require.EqualValues(t, 1, entries[0].Sequence)
+	require.Equal(t, []types.NodeID{authority.NodeID(), second.NodeID()}, entries[0].Authorities)
+	require.Equal(t, ts.URL+"/"+bootstrap.ParamsUpdateName, entries[0].Source)
+	require.Equal(t, []string{"poet-servers", "golden-atxs"}, entries[0].Changed)
+	require.Empty(t, entries[0].Error)
+	require.EqualValues(t, 2, entries[1].Sequence)
+	require.Equal(t, []string{"beacon-fallback-authorities"}, entries[1].Changed)
+	require.Equal(t, applyErr.Error(), entries[1].Error)
+
+	// the last update that was applied successfully is applied again after restart
+	applied = nil
+	applyErr = nil
+	require.NoError(t, newUpdater().Load(context.Background()))
+	require.Len(t, applied, 1)
+	require.EqualValues(t, 1, applied[0].Sequence)
+	require.Equal(t, bootstrap.ParamsFilename(cfg.DataDir), applied[0].Persisted)
+	require.Len(t, readAudit(t, fs, cfg.DataDir), 3)
+}
+
+func TestParamsUpdateQuorum(t *testing.T) {
+	var signers []*signing.EdSigner
+	cfg := bootstrap.DefaultConfig()
+	for i := 0; i < 3; i++ {
+		signer, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		signers = append(signers, signer)
+		cfg.Authorities = append(cfg.Authorities, signer.NodeID())
+	}
+	cfg.AuthorityQuorum = 2
+
+	var served atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+bootstrap.ParamsUpdateName {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(served.Load().(string)))
+	}))
+	t.Cleanup(ts.Close)
+	cfg.URL = ts.URL
+	mc := bootstrap.NewMocklayerClock(gomock.NewController(t))
+	mc.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	var applied []*bootstrap.VerifiedParams
+	updater := bootstrap.New(
+		mc,
+		bootstrap.WithConfig(cfg),
+		bootstrap.WithLogger(logtest.New(t)),
+		bootstrap.WithFilesystem(afero.NewMemMapFs()),
+		bootstrap.WithHttpClient(ts.Client()),
+		bootstrap.WithParamsHandler(func(_ context.Context, params *bootstrap.VerifiedParams) error {
+			applied = append(applied, params)
+			return nil
+		}),
+	)
+
+	served.Store(signParams(t, params3, signers[0]))
+	require.ErrorIs(t, updater.DoIt(context.Background()), bootstrap.ErrQuorum)
+	served.Store(signParams(t, params3, signers[0], signers[2]))
+	require.NoError(t, updater.DoIt(context.Background()))
+	require.Len(t, applied, 1)
+	require.Equal(t, []types.NodeID{signers[0].NodeID(), signers[2].NodeID()}, applied[0].Authorities)
+}
+
+func TestParamsConfigValidate(t *testing.T) {
+	cfg := bootstrap.DefaultConfig()
+	require.NoError(t, cfg.Validate())
+	cfg.Authorities = []types.NodeID{{1}, {2}}
+	require.Error(t, cfg.Validate())
+	cfg.AuthorityQuorum = 2
+	require.NoError(t, cfg.Validate())
+	cfg.AuthorityQuorum = 3
+	require.Error(t, cfg.Validate())
+}
+
+func TestParamsUpdateDisabled(t *testing.T) {
+	var queried atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+bootstrap.ParamsUpdateName {
+			queried.Store(true)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(ts.Close)
+
+	cfg := bootstrap.DefaultConfig()
+	cfg.URL = ts.URL
+	mc := bootstrap.NewMocklayerClock(gomock.NewController(t))
+	mc.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	updater := bootstrap.New(
+		mc,
+		bootstrap.WithConfig(cfg),
+		bootstrap.WithLogger(logtest.New(t)),
+		bootstrap.WithFilesystem(afero.NewMemMapFs()),
+		bootstrap.WithHttpClient(ts.Client()),
+	)
+	require.NoError(t, updater.DoIt(context.Background()))
+	require.False(t, queried.Load())
+}
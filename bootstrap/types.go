@@ -18,6 +18,7 @@ type EpochData struct {
 	ID        uint32   `json:"number"`
 	Beacon    string   `json:"beacon"`
 	ActiveSet []string `json:"activeSet"`
+	GoldenAtx string   `json:"goldenAtx,omitempty"`
 }
 
 type VerifiedUpdate struct {
@@ -29,6 +30,8 @@ type EpochOverride struct {
 	Epoch     types.EpochID
 	Beacon    types.Beacon
 	ActiveSet []types.ATXID
+	// GoldenAtx is set if the network upgrade resets the atx chain starting from the epoch.
+	GoldenAtx types.ATXID
 }
 
 func (vd *VerifiedUpdate) MarshalLogObject(encoder log.ObjectEncoder) error {
@@ -36,5 +39,8 @@ func (vd *VerifiedUpdate) MarshalLogObject(encoder log.ObjectEncoder) error {
 	encoder.AddString("epoch", vd.Data.Epoch.String())
 	encoder.AddString("beacon", vd.Data.Beacon.String())
 	encoder.AddInt("activeset_size", len(vd.Data.ActiveSet))
+	if vd.Data.GoldenAtx != types.EmptyATXID {
+		encoder.AddString("golden_atx", vd.Data.GoldenAtx.String())
+	}
 	return nil
 }
@@ -10,6 +10,11 @@
 //
 // Subscribers register by calling `Subscribe()` to receive a channel for
 // the latest update.
+//
+// If authorities are configured, the updater also checks for the update of
+// the runtime parameters (poet servers, golden atxs and fallback beacon
+// authorities) signed by one of them. Verified updates are applied by the
+// handler set with `WithParamsHandler()` and recorded in the audit log.
 package bootstrap
 
 import (
@@ -33,6 +38,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
 )
 
 const (
@@ -50,16 +56,33 @@ const (
 var (
 	ErrWrongVersion  = errors.New("wrong schema version")
 	ErrInvalidBeacon = errors.New("invalid beacon")
+	ErrInvalidGolden = errors.New("invalid golden atx")
 )
 
 type Config struct {
 	URL     string `mapstructure:"bootstrap-url"`
 	Version string `mapstructure:"bootstrap-version"`
+	// Authorities sign the updates of the runtime parameters. Updates are not checked if empty.
+	Authorities []types.NodeID `mapstructure:"bootstrap-authorities"`
+	// Number of distinct authorities that must sign the update of the runtime parameters.
+	// Updates of the golden atxs and the fallback beacon authorities require at least two signatures.
+	AuthorityQuorum int `mapstructure:"bootstrap-authority-quorum"`
 
 	DataDir  string
 	Interval time.Duration
 }
 
+// Validate checks that the updates of the runtime parameters can be signed by the configured authorities.
+func (c *Config) Validate() error {
+	if c.AuthorityQuorum == 0 && len(c.Authorities) == 0 {
+		return nil
+	}
+	if c.AuthorityQuorum <= 0 || c.AuthorityQuorum > len(c.Authorities) {
+		return fmt.Errorf("authority quorum (%d) must be in range [1, %d]", c.AuthorityQuorum, len(c.Authorities))
+	}
+	return nil
+}
+
 func DefaultConfig() Config {
 	return Config{
 		URL:      DefaultURL,
@@ -79,11 +102,20 @@ type Updater struct {
 	stop   chan struct{}
 	eg     errgroup.Group
 
+	// verifier of the params updates signatures.
+	verifier *signing.EdVerifier
+	onParams ParamsHandler
+
 	mu          sync.Mutex
 	subscribers []chan *VerifiedUpdate
 	updates     map[types.EpochID]map[string]struct{}
+	// sequence of the last params update that was applied or failed to apply.
+	paramsSequence uint64
 }
 
+// ParamsHandler applies the verified runtime parameters without restarting the node.
+type ParamsHandler func(context.Context, *VerifiedParams) error
+
 type Opt func(*Updater)
 
 func WithConfig(cfg Config) Opt {
@@ -110,15 +142,30 @@ func WithHttpClient(c *http.Client) Opt {
 	}
 }
 
+// WithVerifier sets the verifier for the signatures of the params updates.
+func WithVerifier(verifier *signing.EdVerifier) Opt {
+	return func(u *Updater) {
+		u.verifier = verifier
+	}
+}
+
+// WithParamsHandler sets the handler that applies the params updates.
+func WithParamsHandler(handler ParamsHandler) Opt {
+	return func(u *Updater) {
+		u.onParams = handler
+	}
+}
+
 func New(clock layerClock, opts ...Opt) *Updater {
 	u := &Updater{
-		cfg:     DefaultConfig(),
-		logger:  log.NewNop(),
-		clock:   clock,
-		fs:      afero.NewOsFs(),
-		client:  &http.Client{},
-		stop:    make(chan struct{}),
-		updates: map[types.EpochID]map[string]struct{}{},
+		cfg:      DefaultConfig(),
+		logger:   log.NewNop(),
+		clock:    clock,
+		fs:       afero.NewOsFs(),
+		client:   &http.Client{},
+		verifier: signing.NewEdVerifier(),
+		stop:     make(chan struct{}),
+		updates:  map[types.EpochID]map[string]struct{}{},
 	}
 	for _, opt := range opts {
 		opt(u)
@@ -152,6 +199,9 @@ func (u *Updater) Load(ctx context.Context) error {
 		u.logger.With().Info("loaded bootstrap file", log.Inline(verified))
 		u.addUpdate(verified.Data.Epoch, verified.Persisted[len(verified.Persisted)-suffixLen:])
 	}
+	if len(u.cfg.Authorities) > 0 {
+		return u.loadParams(ctx)
+	}
 	return nil
 }
 
@@ -240,6 +290,11 @@ func (u *Updater) DoIt(ctx context.Context) error {
 			)
 		}
 	}()
+	if len(u.cfg.Authorities) > 0 {
+		if err := u.checkParamsUpdate(ctx); err != nil {
+			return err
+		}
+	}
 	for _, epoch := range requiredEpochs(current) {
 		verified, cached, err := u.checkEpochUpdate(ctx, epoch, SuffixBootstrap)
 		if err != nil {
@@ -417,6 +472,13 @@ func validateData(cfg Config, update *Update) (*VerifiedUpdate, error) {
 		}
 		verified.Data.ActiveSet = activeSet
 	}
+	if update.Data.Epoch.GoldenAtx != "" {
+		golden, err := hex.DecodeString(update.Data.Epoch.GoldenAtx)
+		if err != nil || len(golden) != types.ATXIDSize {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGolden, update.Data.Epoch.GoldenAtx)
+		}
+		verified.Data.GoldenAtx = types.ATXID(types.BytesToHash(golden))
+	}
 	return verified, nil
 }
 
@@ -483,7 +545,7 @@ func (u *Updater) prune(current types.EpochID) error {
 	}
 	for _, f := range files {
 		if f.IsDir() {
-			if _, ok := toKeep[f.Name()]; ok {
+			if _, ok := toKeep[f.Name()]; ok || f.Name() == paramsDirName {
 				continue
 			}
 		}
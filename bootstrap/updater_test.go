@@ -76,7 +76,8 @@ const (
       "activeSet": [
         "65af4350d28f3d953c6c6660e37954698839125fbda7aac3edcef469b2ad9e64",
         "e46b23d64140357b16d18eace600b28ab767bfd7b51c8e9977a342b71c3a23dd",
-        "85de8823d6a0cd251aa62ce9315459302ea31ce9701531d3677ac8ba548a4210"]
+        "85de8823d6a0cd251aa62ce9315459302ea31ce9701531d3677ac8ba548a4210"],
+      "goldenAtx": "a7aac3edcef469b2ad9e6465af4350d28f3d953c6c6660e37954698839125fbd"
     }
   }
 }
@@ -146,6 +147,11 @@ func checkUpdate4(t *testing.T, got *bootstrap.VerifiedUpdate) {
 		types.HexToHash32("85de8823d6a0cd251aa62ce9315459302ea31ce9701531d3677ac8ba548a4210"),
 		got.Data.ActiveSet[2].Hash32(),
 	)
+	require.Equal(
+		t,
+		types.HexToHash32("a7aac3edcef469b2ad9e6465af4350d28f3d953c6c6660e37954698839125fbd"),
+		got.Data.GoldenAtx.Hash32(),
+	)
 }
 
 type checkFunc func(*testing.T, *bootstrap.VerifiedUpdate)
@@ -467,6 +473,22 @@ func TestGetInvalidUpdate(t *testing.T) {
     }
   }
 }
+`,
+		},
+		{
+			desc: "invalid golden atx",
+			err:  bootstrap.ErrInvalidGolden,
+			update: `
+{
+  "version": "https://spacemesh.io/bootstrap.schema.json.1.0",
+  "data": {
+    "epoch": {
+	  "number": 2,
+      "beacon": "f70cf90b",
+      "goldenAtx": "not a hex encoded atx id that is long enough to pass the schema check"
+    }
+  }
+}
 `,
 		},
 	}
@@ -0,0 +1,178 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/afero"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// IncrementalSchemaVersion is the version of checkpoints that contain only changes since the base checkpoint.
+// It is different from SchemaVersion, so that nodes that can't apply incremental checkpoints reject them.
+const IncrementalSchemaVersion = "https://spacemesh.io/checkpoint.schema.json.1.1"
+
+// maxChainLength limits the number of incremental checkpoints applied on top of a full checkpoint.
+const maxChainLength = 1000
+
+var ErrBaseMismatch = errors.New("base checkpoint mismatch")
+
+// readCheckpoint reads and validates the checkpoint file. It returns the checkpoint and the hash of the file.
+func readCheckpoint(fs afero.Fs, file string) (*types.Checkpoint, types.Hash32, error) {
+	data, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return nil, types.Hash32{}, fmt.Errorf("%w: read recovery file %v", err, file)
+	}
+	if err := ValidateSchema(data); err != nil {
+		return nil, types.Hash32{}, err
+	}
+	var checkpoint types.Checkpoint
+	if err = json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, types.Hash32{}, fmt.Errorf("%w: unmarshal checkpoint from %v", err, file)
+	}
+	switch {
+	case checkpoint.Data.Base == nil && checkpoint.Version != SchemaVersion:
+		return nil, types.Hash32{}, fmt.Errorf("expected version %v, got %v", SchemaVersion, checkpoint.Version)
+	case checkpoint.Data.Base != nil && checkpoint.Version != IncrementalSchemaVersion:
+		return nil, types.Hash32{}, fmt.Errorf("expected version %v, got %v",
+			IncrementalSchemaVersion, checkpoint.Version)
+	}
+	return &checkpoint, hash.Sum(data), nil
+}
+
+// resolveCheckpoint returns full checkpoint data from the checkpoint file.
+// If checkpoint is incremental, it is applied on top of the base checkpoint, which is located with baseFile.
+// Hash of every base in the chain is checked against the hash recorded in the checkpoint based on it.
+func resolveCheckpoint(
+	fs afero.Fs,
+	file string,
+	baseFile func(id string) string,
+) (*types.InnerData, types.Hash32, error) {
+	checkpoint, fhash, err := readCheckpoint(fs, file)
+	if err != nil {
+		return nil, types.Hash32{}, err
+	}
+	chain := []*types.InnerData{&checkpoint.Data}
+	for base := checkpoint.Data.Base; base != nil; base = chain[len(chain)-1].Base {
+		if len(chain) > maxChainLength {
+			return nil, types.Hash32{}, fmt.Errorf("incremental checkpoints chain exceeds %d", maxChainLength)
+		}
+		checkpoint, bhash, err := readCheckpoint(fs, baseFile(base.ID))
+		if err != nil {
+			return nil, types.Hash32{}, fmt.Errorf("read base checkpoint %s: %w", base.ID, err)
+		}
+		if checkpoint.Data.CheckpointId != base.ID {
+			return nil, types.Hash32{}, fmt.Errorf("%w: expected id %s, got %s",
+				ErrBaseMismatch, base.ID, checkpoint.Data.CheckpointId)
+		}
+		if !bytes.Equal(bhash[:], base.Hash) {
+			return nil, types.Hash32{}, fmt.Errorf("%w: hash of %s is %s", ErrBaseMismatch, base.ID, bhash)
+		}
+		chain = append(chain, &checkpoint.Data)
+	}
+	data := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		data = applyIncremental(data, chain[i])
+	}
+	return data, fhash, nil
+}
+
+// applyIncremental returns full checkpoint data after applying incremental checkpoint on top of the base.
+func applyIncremental(base, incremental *types.InnerData) *types.InnerData {
+	rst := &types.InnerData{CheckpointId: incremental.CheckpointId}
+	removed := make(map[string]struct{}, len(incremental.RemovedAtxs))
+	for _, id := range incremental.RemovedAtxs {
+		removed[string(id)] = struct{}{}
+	}
+	for _, atx := range base.Atxs {
+		if _, ok := removed[string(atx.ID)]; !ok {
+			rst.Atxs = append(rst.Atxs, atx)
+		}
+	}
+	rst.Atxs = append(rst.Atxs, incremental.Atxs...)
+
+	updated := make(map[string]struct{}, len(incremental.Accounts))
+	for _, acct := range incremental.Accounts {
+		updated[string(acct.Address)] = struct{}{}
+	}
+	for _, acct := range base.Accounts {
+		if _, ok := updated[string(acct.Address)]; !ok {
+			rst.Accounts = append(rst.Accounts, acct)
+		}
+	}
+	rst.Accounts = append(rst.Accounts, incremental.Accounts...)
+	slices.SortFunc(rst.Accounts, func(a, b types.AccountSnapshot) int {
+		return bytes.Compare(a.Address, b.Address)
+	})
+	return rst
+}
+
+// diffCheckpoints sets atxs and accounts in data to changes since the base,
+// so that applying it on top of the base results in the same data.
+func diffCheckpoints(base, data *types.InnerData) {
+	atxs := make(map[string]struct{}, len(data.Atxs))
+	for _, atx := range data.Atxs {
+		atxs[string(atx.ID)] = struct{}{}
+	}
+	baseAtxs := make(map[string]struct{}, len(base.Atxs))
+	for _, atx := range base.Atxs {
+		baseAtxs[string(atx.ID)] = struct{}{}
+		if _, ok := atxs[string(atx.ID)]; !ok {
+			data.RemovedAtxs = append(data.RemovedAtxs, atx.ID)
+		}
+	}
+	data.Atxs = slices.DeleteFunc(data.Atxs, func(atx types.AtxSnapshot) bool {
+		_, ok := baseAtxs[string(atx.ID)]
+		return ok
+	})
+
+	baseAccounts := make(map[string]types.AccountSnapshot, len(base.Accounts))
+	for _, acct := range base.Accounts {
+		baseAccounts[string(acct.Address)] = acct
+	}
+	data.Accounts = slices.DeleteFunc(data.Accounts, func(acct types.AccountSnapshot) bool {
+		prev, ok := baseAccounts[string(acct.Address)]
+		return ok && prev.Balance == acct.Balance && prev.Nonce == acct.Nonce &&
+			bytes.Equal(prev.Template, acct.Template) && bytes.Equal(prev.State, acct.State)
+	})
+}
+
+// GenerateIncremental generates a checkpoint that contains only changes since the base checkpoint.
+// Base checkpoint must have been generated by this node before, and it can be incremental as well.
+func GenerateIncremental(
+	ctx context.Context,
+	fs afero.Fs,
+	db *sql.Database,
+	dataDir string,
+	base, snapshot types.LayerID,
+	numAtxs int,
+) error {
+	if !base.Before(snapshot) {
+		return fmt.Errorf("base %s is not before snapshot %s", base, snapshot)
+	}
+	baseData, baseHash, err := resolveCheckpoint(fs, SelfCheckpointFilename(dataDir, base), func(id string) string {
+		return filepath.Join(dataDir, checkpointDir, filepath.Base(id))
+	})
+	if err != nil {
+		return fmt.Errorf("base checkpoint: %w", err)
+	}
+	checkpoint, err := checkpointDB(ctx, db, snapshot, numAtxs, noProgress)
+	if err != nil {
+		return err
+	}
+	checkpoint.Version = IncrementalSchemaVersion
+	diffCheckpoints(baseData, &checkpoint.Data)
+	checkpoint.Data.Base = &types.CheckpointBase{
+		ID:   baseData.CheckpointId,
+		Hash: baseHash.Bytes(),
+	}
+	return writeCheckpoint(fs, SelfCheckpointFilename(dataDir, snapshot), checkpoint)
+}
@@ -0,0 +1,382 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/spf13/afero"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/server"
+)
+
+const (
+	// P2PScheme is the scheme of the recovery uri to download the checkpoint from peers.
+	// The host part of such uri is the hex encoded hash of the checkpoint file, e.g. p2p://0x1234...
+	P2PScheme = "p2p"
+
+	listProtocol  = "cl/1"
+	chunkProtocol = "cc/1"
+
+	// chunkSize is the maximal size of the checkpoint data served in response to a single request.
+	chunkSize = 1 << 20
+	// maxAdvertised is the maximal number of checkpoints advertised to peers.
+	maxAdvertised = 100
+	// p2pRetryInterval is the interval between attempts to find peers that serve the checkpoint.
+	p2pRetryInterval = 10 * time.Second
+
+	// DefaultMaxP2PSize is the default limit on the size of the checkpoint downloaded from peers.
+	DefaultMaxP2PSize = 4 << 30
+)
+
+var (
+	ErrHashMismatch = errors.New("checkpoint hash mismatch")
+	// ErrCheckpointTooLarge is returned if the checkpoint served by the peer exceeds the configured limit.
+	ErrCheckpointTooLarge = errors.New("checkpoint is too large")
+)
+
+//go:generate scalegen -types CheckpointInfo,CheckpointList,ChunkRequest
+
+// CheckpointInfo describes the checkpoint that is available for download from the peer.
+type CheckpointInfo struct {
+	Layer types.LayerID
+	Hash  types.Hash32
+	Size  uint64
+}
+
+// CheckpointList is the list of checkpoints served by the peer.
+type CheckpointList struct {
+	Checkpoints []CheckpointInfo `scale:"max=100"` // max maxAdvertised checkpoints
+}
+
+// ChunkRequest requests the part of the checkpoint file with the hash, starting at the offset.
+type ChunkRequest struct {
+	Hash   types.Hash32
+	Offset uint64
+}
+
+type servedCheckpoint struct {
+	info    CheckpointInfo
+	file    string
+	modTime time.Time
+}
+
+// Server serves checkpoints generated by this node to peers.
+type Server struct {
+	logger  log.Log
+	fs      afero.Fs
+	dataDir string
+	list    *server.Server
+	chunk   *server.Server
+
+	mu     sync.Mutex
+	served map[string]servedCheckpoint // by file name
+}
+
+// NewServer creates a server for checkpoints generated by this node in the data directory.
+func NewServer(logger log.Log, fs afero.Fs, dataDir string, h server.Host) *Server {
+	s := &Server{
+		logger:  logger,
+		fs:      fs,
+		dataDir: dataDir,
+		served:  map[string]servedCheckpoint{},
+	}
+	s.list = server.New(h, listProtocol, s.handleList,
+		server.WithLog(logger),
+		server.WithQueueSize(100),
+		server.WithRequestsPerInterval(10, time.Second),
+	)
+	s.chunk = server.New(h, chunkProtocol, s.handleChunk,
+		server.WithLog(logger),
+		server.WithQueueSize(100),
+		server.WithRequestsPerInterval(20, time.Second),
+	)
+	return s
+}
+
+// Run serves requests until the context is canceled.
+func (s *Server) Run(ctx context.Context) error {
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return s.list.Run(ctx)
+	})
+	eg.Go(func() error {
+		return s.chunk.Run(ctx)
+	})
+	return eg.Wait()
+}
+
+// available returns checkpoints in the data directory, the latest first.
+// Files are hashed once and rehashed only if they were modified.
+func (s *Server) available() ([]servedCheckpoint, error) {
+	dir := filepath.Join(s.dataDir, checkpointDir)
+	files, err := afero.ReadDir(s.fs, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read checkpoints dir %s: %w", dir, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rst []servedCheckpoint
+	for _, fi := range files {
+		name, ok := strings.CutPrefix(fi.Name(), "snapshot-")
+		if fi.IsDir() || !ok {
+			continue
+		}
+		layer, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		if cp, ok := s.served[fi.Name()]; ok && cp.modTime.Equal(fi.ModTime()) && cp.info.Size == uint64(fi.Size()) {
+			rst = append(rst, cp)
+			continue
+		}
+		file := filepath.Join(dir, fi.Name())
+		// temporary files of the checkpoints that are being written have the same prefix.
+		// only complete checkpoint with matching id is served
+		checkpoint, fhash, err := readCheckpoint(s.fs, file)
+		if err != nil || checkpoint.Data.CheckpointId != fi.Name() {
+			continue
+		}
+		cp := servedCheckpoint{
+			info:    CheckpointInfo{Layer: types.LayerID(layer), Hash: fhash, Size: uint64(fi.Size())},
+			file:    file,
+			modTime: fi.ModTime(),
+		}
+		s.served[fi.Name()] = cp
+		rst = append(rst, cp)
+	}
+	slices.SortFunc(rst, func(a, b servedCheckpoint) int {
+		return int(b.info.Layer) - int(a.info.Layer)
+	})
+	if len(rst) > maxAdvertised {
+		rst = rst[:maxAdvertised]
+	}
+	return rst, nil
+}
+
+func (s *Server) handleList(_ context.Context, _ []byte) ([]byte, error) {
+	served, err := s.available()
+	if err != nil {
+		return nil, err
+	}
+	var list CheckpointList
+	for _, cp := range served {
+		list.Checkpoints = append(list.Checkpoints, cp.info)
+	}
+	return codec.Encode(&list)
+}
+
+func (s *Server) handleChunk(_ context.Context, msg []byte) ([]byte, error) {
+	var req ChunkRequest
+	if err := codec.Decode(msg, &req); err != nil {
+		return nil, err
+	}
+	served, err := s.available()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(served, func(cp servedCheckpoint) bool {
+		return cp.info.Hash == req.Hash
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, req.Hash)
+	}
+	cp := served[idx]
+	if req.Offset >= cp.info.Size {
+		return nil, fmt.Errorf("offset %d exceeds size %d", req.Offset, cp.info.Size)
+	}
+	f, err := s.fs.Open(cp.file)
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoint: %w", err)
+	}
+	defer f.Close()
+	buf := make([]byte, min(chunkSize, cp.info.Size-req.Offset))
+	if _, err := f.ReadAt(buf, int64(req.Offset)); err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	return buf, nil
+}
+
+// parseP2PHash returns the checkpoint hash from the p2p recovery uri.
+func parseP2PHash(host string) (types.Hash32, error) {
+	var h types.Hash32
+	if err := h.UnmarshalText([]byte(host)); err != nil {
+		return types.Hash32{}, fmt.Errorf("parse checkpoint hash %s: %w", host, err)
+	}
+	return h, nil
+}
+
+// p2pToLocalFile downloads the checkpoint with the hash from any peer that serves it.
+// Connected peers are asked for the checkpoint until it is downloaded or the context is canceled.
+// Peers that advertise checkpoints larger than maxSize are skipped.
+func p2pToLocalFile(
+	ctx context.Context,
+	logger log.Log,
+	h server.Host,
+	want types.Hash32,
+	maxSize uint64,
+	fs afero.Fs,
+	dst string,
+) error {
+	if maxSize == 0 {
+		maxSize = DefaultMaxP2PSize
+	}
+	list := server.New(h, listProtocol, nil, server.WithLog(logger))
+	chunk := server.New(h, chunkProtocol, nil, server.WithLog(logger))
+	for {
+		for _, pid := range h.Network().Peers() {
+			size, err := checkpointSize(ctx, list, pid, want)
+			if err != nil {
+				logger.With().Debug("failed to list checkpoints",
+					log.Context(ctx),
+					log.Stringer("peer", pid),
+					log.Err(err),
+				)
+				continue
+			} else if size == 0 {
+				continue
+			} else if size > maxSize {
+				logger.With().Warning("peer serves checkpoint larger than the limit",
+					log.Context(ctx),
+					log.Stringer("peer", pid),
+					log.Stringer("hash", want),
+					log.Uint64("size", size),
+					log.Uint64("limit", maxSize),
+				)
+				continue
+			}
+			err = downloadFromPeer(ctx, chunk, pid, want, size, fs, dst)
+			if err == nil {
+				logger.With().Info("checkpoint downloaded from peer",
+					log.Context(ctx),
+					log.Stringer("peer", pid),
+					log.Stringer("hash", want),
+				)
+				return nil
+			}
+			logger.With().Warning("failed to download checkpoint",
+				log.Context(ctx),
+				log.Stringer("peer", pid),
+				log.Stringer("hash", want),
+				log.Err(err),
+			)
+		}
+		logger.With().Info("waiting for peers that serve checkpoint",
+			log.Context(ctx),
+			log.Stringer("hash", want),
+			log.Int("peers", len(h.Network().Peers())),
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("download checkpoint %s: %w", want, ctx.Err())
+		case <-time.After(p2pRetryInterval):
+		}
+	}
+}
+
+// checkpointSize returns the size of the checkpoint with the hash if the peer serves it, and zero otherwise.
+func checkpointSize(ctx context.Context, list *server.Server, pid peer.ID, want types.Hash32) (uint64, error) {
+	resp, err := list.Request(ctx, pid, []byte{})
+	if err != nil {
+		return 0, err
+	}
+	var served CheckpointList
+	if err := codec.Decode(resp, &served); err != nil {
+		return 0, fmt.Errorf("decode checkpoints list: %w", err)
+	}
+	for _, info := range served.Checkpoints {
+		if info.Hash == want {
+			return info.Size, nil
+		}
+	}
+	return 0, nil
+}
+
+// chunkReader reads the checkpoint from the peer chunk by chunk.
+// Every chunk is checked to have the size that the server must respond with, so that the peer
+// can't send more data than it advertised. Chunks can't be verified against the hash individually,
+// the hash of the whole checkpoint is verified when it is downloaded.
+type chunkReader struct {
+	ctx    context.Context
+	chunk  *server.Server
+	pid    peer.ID
+	hash   types.Hash32
+	size   uint64
+	offset uint64
+	buf    []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.offset == r.size {
+			return 0, io.EOF
+		}
+		req, err := codec.Encode(&ChunkRequest{Hash: r.hash, Offset: r.offset})
+		if err != nil {
+			return 0, err
+		}
+		r.buf, err = r.chunk.Request(r.ctx, r.pid, req)
+		if err != nil {
+			return 0, fmt.Errorf("request chunk at %d: %w", r.offset, err)
+		}
+		if expected := min(chunkSize, r.size-r.offset); uint64(len(r.buf)) != expected {
+			received := len(r.buf)
+			r.buf = nil
+			return 0, fmt.Errorf("invalid chunk at %d with size %d, expected %d", r.offset, received, expected)
+		}
+		r.offset += uint64(len(r.buf))
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func downloadFromPeer(
+	ctx context.Context,
+	chunk *server.Server,
+	pid peer.ID,
+	want types.Hash32,
+	size uint64,
+	fs afero.Fs,
+	dst string,
+) error {
+	rf, err := NewRecoveryFile(fs, dst)
+	if err != nil {
+		return fmt.Errorf("new recovery file %w", err)
+	}
+	hh := hash.New()
+	_, err = io.Copy(io.MultiWriter(rf.fwriter, hh), &chunkReader{
+		ctx:   ctx,
+		chunk: chunk,
+		pid:   pid,
+		hash:  want,
+		size:  size,
+	})
+	var got types.Hash32
+	hh.Sum(got[:0])
+	if err == nil && got != want {
+		err = fmt.Errorf("%w: expected %s, got %s", ErrHashMismatch, want, got)
+	}
+	if err != nil {
+		rf.file.Close()
+		fs.Remove(rf.file.Name())
+		return err
+	}
+	return rf.Save(fs)
+}
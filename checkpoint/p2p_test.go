@@ -0,0 +1,139 @@
+package checkpoint_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestRecover_P2P(t *testing.T) {
+	baseData, incrementalData := incrementalCheckpoint(t)
+	// whitespace padding makes the checkpoint larger than a single chunk
+	full := []byte(checkpointData + strings.Repeat(" ", 5<<19))
+
+	tt := []struct {
+		name    string
+		served  map[string][]byte
+		want    types.Hash32
+		maxSize uint64
+		expErr  error
+	}{
+		{
+			name:   "full",
+			served: map[string][]byte{"snapshot-15": full},
+			want:   hash.Sum(full),
+		},
+		{
+			name:   "incremental",
+			served: map[string][]byte{"snapshot-10": baseData, "snapshot-15": incrementalData},
+			want:   hash.Sum(incrementalData),
+		},
+		{
+			name:    "too large",
+			served:  map[string][]byte{"snapshot-15": full},
+			want:    hash.Sum(full),
+			maxSize: uint64(len(full) - 1),
+			expErr:  context.DeadlineExceeded,
+		},
+		{
+			name:   "not served",
+			served: map[string][]byte{"snapshot-15": full},
+			want:   types.RandomHash(),
+			expErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mesh, err := mocknet.FullMeshConnected(2)
+			require.NoError(t, err)
+
+			sfs := afero.NewMemMapFs()
+			for name, data := range tc.served {
+				require.NoError(t, afero.WriteFile(sfs, filepath.Join("/server", "checkpoint", name), data, 0o600))
+			}
+			// temporary file of the checkpoint that is being generated is not served
+			require.NoError(t, afero.WriteFile(sfs, "/server/checkpoint/snapshot-201234", full[:100], 0o600))
+			srv := checkpoint.NewServer(logtest.New(t), sfs, "/server", mesh.Hosts()[0])
+			ctx, cancel := context.WithCancel(context.Background())
+			var eg errgroup.Group
+			t.Cleanup(func() {
+				cancel()
+				require.NoError(t, eg.Wait())
+			})
+			eg.Go(func() error {
+				return srv.Run(ctx)
+			})
+			require.Eventually(t, func() bool {
+				protocols := mesh.Hosts()[0].Mux().Protocols()
+				return slices.Contains(protocols, protocol.ID("cl/1")) && slices.Contains(protocols, protocol.ID("cc/1"))
+			}, time.Second, 10*time.Millisecond)
+
+			timeout := 5 * time.Second
+			if tc.expErr != nil {
+				timeout = time.Second
+			}
+			rctx, rcancel := context.WithTimeout(context.Background(), timeout)
+			defer rcancel()
+			cfg := &checkpoint.RecoverConfig{
+				GoldenAtx:   goldenAtx,
+				DataDir:     t.TempDir(),
+				DbFile:      "test.sql",
+				LocalDbFile: "local.sql",
+				Uri:         "p2p://" + tc.want.Hex(),
+				Restore:     types.LayerID(recoverLayer),
+				Host:        mesh.Hosts()[1],
+				MaxP2PSize:  tc.maxSize,
+			}
+			_, err = checkpoint.RecoverWithDb(
+				rctx, logtest.New(t), sql.InMemory(), localsql.InMemory(), afero.NewMemMapFs(), cfg)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			newDB, err := sql.Open("file:" + filepath.Join(cfg.DataDir, cfg.DbFile))
+			require.NoError(t, err)
+			defer newDB.Close()
+			verifyDbContent(t, newDB)
+		})
+	}
+}
+
+func TestRecover_P2PNoHost(t *testing.T) {
+	cfg := &checkpoint.RecoverConfig{
+		GoldenAtx:   goldenAtx,
+		DataDir:     t.TempDir(),
+		DbFile:      "test.sql",
+		LocalDbFile: "local.sql",
+		Uri:         "p2p://" + types.RandomHash().Hex(),
+		Restore:     types.LayerID(recoverLayer),
+	}
+	_, err := checkpoint.RecoverWithDb(
+		context.Background(), logtest.New(t), sql.InMemory(), localsql.InMemory(), afero.NewMemMapFs(), cfg)
+	require.ErrorContains(t, err, "p2p host is required")
+
+	mesh, err := mocknet.WithNPeers(1)
+	require.NoError(t, err)
+	cfg.Host = mesh.Hosts()[0]
+	cfg.Uri = "p2p://invalid"
+	_, err = checkpoint.RecoverWithDb(
+		context.Background(), logtest.New(t), sql.InMemory(), localsql.InMemory(), afero.NewMemMapFs(), cfg)
+	require.ErrorContains(t, err, "parse checkpoint hash")
+}
@@ -3,7 +3,6 @@ package checkpoint
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -17,6 +16,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
@@ -35,11 +35,16 @@ type Config struct {
 
 	// set to false if atxs are not compatible before and after the checkpoint recovery.
 	PreserveOwnAtx bool `mapstructure:"preserve-own-atx"`
+
+	// MaxP2PSize is the largest checkpoint in bytes that is downloaded from peers.
+	// Peers that advertise larger checkpoints are skipped.
+	MaxP2PSize uint64 `mapstructure:"recovery-max-p2p-size"`
 }
 
 func DefaultConfig() Config {
 	return Config{
 		PreserveOwnAtx: true,
+		MaxP2PSize:     DefaultMaxP2PSize,
 	}
 }
 
@@ -52,6 +57,10 @@ type RecoverConfig struct {
 	NodeIDs        []types.NodeID
 	Uri            string
 	Restore        types.LayerID
+	// Host is used to download the checkpoint from peers if Uri has P2PScheme.
+	Host server.Host
+	// MaxP2PSize limits the size of the checkpoint downloaded from peers, zero is replaced with DefaultMaxP2PSize.
+	MaxP2PSize uint64
 }
 
 func RecoveryDir(dataDir string) string {
@@ -66,6 +75,8 @@ func copyToLocalFile(
 	ctx context.Context,
 	logger log.Log,
 	fs afero.Fs,
+	host server.Host,
+	maxP2PSize uint64,
 	dataDir, uri string,
 	restore types.LayerID,
 ) (string, error) {
@@ -73,7 +84,33 @@ func copyToLocalFile(
 	if err != nil {
 		return "", fmt.Errorf("%w: parse recovery URI %v", err, uri)
 	}
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+	// download fetches the checkpoint to the local file, or its base if base is not nil
+	var download func(dst string, base *types.CheckpointBase) error
+	switch parsed.Scheme {
+	case "http", "https":
+		download = func(dst string, base *types.CheckpointBase) error {
+			resource := parsed
+			if base != nil {
+				resource = parsed.ResolveReference(&url.URL{Path: filepath.Base(base.ID)})
+			}
+			return httpToLocalFile(ctx, resource, fs, dst)
+		}
+	case P2PScheme:
+		if host == nil {
+			return "", fmt.Errorf("p2p host is required to recover from %s", uri)
+		}
+		want, err := parseP2PHash(parsed.Host)
+		if err != nil {
+			return "", err
+		}
+		download = func(dst string, base *types.CheckpointBase) error {
+			hash := want
+			if base != nil {
+				hash = types.BytesToHash(base.Hash)
+			}
+			return p2pToLocalFile(ctx, logger, host, hash, maxP2PSize, fs, dst)
+		}
+	default:
 		return "", fmt.Errorf("%w: %s", ErrUrlSchemeNotSupported, uri)
 	}
 	if bdir, err := backupRecovery(fs, RecoveryDir(dataDir)); err != nil {
@@ -85,19 +122,50 @@ func copyToLocalFile(
 		)
 	}
 	dst := RecoveryFilename(dataDir, filepath.Base(parsed.String()), restore)
-	if err = httpToLocalFile(ctx, parsed, fs, dst); err != nil {
+	if err = download(dst, nil); err != nil {
 		return "", err
 	}
 	logger.With().Info("checkpoint data persisted",
 		log.Context(ctx),
 		log.String("file", dst),
 	)
+	// incremental checkpoint is published together with its bases, they are validated when data is resolved
+	for file, chain := dst, 0; ; chain++ {
+		checkpoint, _, err := readCheckpoint(fs, file)
+		if err != nil {
+			return "", err
+		}
+		base := checkpoint.Data.Base
+		if base == nil {
+			break
+		}
+		if chain >= maxChainLength {
+			return "", fmt.Errorf("incremental checkpoints chain exceeds %d", maxChainLength)
+		}
+		file = recoveryBaseFilename(dst, base.ID)
+		if err := download(file, base); err != nil {
+			// don't wrap the error, missing base is not the same as missing checkpoint
+			return "", fmt.Errorf("download base checkpoint %s: %v", base.ID, err)
+		}
+		logger.With().Info("base checkpoint data persisted",
+			log.Context(ctx),
+			log.String("id", base.ID),
+			log.String("file", file),
+		)
+	}
 	return dst, nil
 }
 
+// recoveryBaseFilename is the path of the base checkpoint with the id, that is stored next to the checkpoint file.
+func recoveryBaseFilename(file, id string) string {
+	return filepath.Join(filepath.Dir(file), filepath.Base(id))
+}
+
 type PreservedData struct {
 	Deps   []*types.VerifiedActivationTx
 	Proofs []*types.PoetProofMessage
+	// PendingProofs are poet proofs referenced by the nipost state of atxs that were being built before recovery.
+	PendingProofs []*types.PoetProofMessage
 }
 
 func Recover(
@@ -155,7 +223,7 @@ func RecoverWithDb(
 		return nil, fmt.Errorf("remove old bootstrap data: %w", err)
 	}
 	logger.With().Info("recover from uri", log.String("uri", cfg.Uri))
-	cpFile, err := copyToLocalFile(ctx, logger, fs, cfg.DataDir, cfg.Uri, cfg.Restore)
+	cpFile, err := copyToLocalFile(ctx, logger, fs, cfg.Host, cfg.MaxP2PSize, cfg.DataDir, cfg.Uri, cfg.Restore)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +268,12 @@ func recoverFromLocalFile(
 					nodeID,
 					log.Err(err),
 				)
-				// continue to recover from checkpoint despite failure to preserve own atx
+				// continue to recover from checkpoint despite failure to preserve own atx.
+				// the atx that is being built refers to atxs that won't be known after recovery,
+				// the builder has to start with a new challenge.
+				if err := discardNIPostState(ctx, localDB, nodeID); err != nil {
+					return nil, err
+				}
 				continue
 			}
 			logger.With().Info("collected own atx deps",
@@ -225,12 +298,21 @@ func recoverFromLocalFile(
 	})
 	allProofs := make([]*types.PoetProofMessage, 0, len(proofs))
 	for _, dep := range allDeps {
-		proof, ok := proofs[types.PoetProofRef(dep.GetPoetProofRef())]
+		ref := types.PoetProofRef(dep.GetPoetProofRef())
+		proof, ok := proofs[ref]
 		if !ok {
 			return nil, fmt.Errorf("missing poet proof for atx %v", dep.ID())
 		}
 		allProofs = append(allProofs, proof)
 	}
+	var pendingProofs []*types.PoetProofMessage
+	for ref, proof := range proofs {
+		if !slices.ContainsFunc(allDeps, func(dep *types.VerifiedActivationTx) bool {
+			return types.PoetProofRef(dep.GetPoetProofRef()) == ref
+		}) {
+			pendingProofs = append(pendingProofs, proof)
+		}
+	}
 
 	// all is ready. backup the old data and create new.
 	backupDir, err := backupOldDb(fs, cfg.DataDir, cfg.DbFile)
@@ -290,30 +372,23 @@ func recoverFromLocalFile(
 		types.GetEffectiveGenesis(),
 	)
 	var preserve *PreservedData
-	if len(allDeps) > 0 {
-		preserve = &PreservedData{Deps: allDeps, Proofs: allProofs}
+	if len(allDeps) > 0 || len(pendingProofs) > 0 {
+		preserve = &PreservedData{Deps: allDeps, Proofs: allProofs, PendingProofs: pendingProofs}
 	}
 	return preserve, nil
 }
 
 func checkpointData(fs afero.Fs, file string, newGenesis types.LayerID) (*recoveryData, error) {
-	data, err := afero.ReadFile(fs, file)
+	// bases of the incremental checkpoint are downloaded next to it
+	checkpoint, _, err := resolveCheckpoint(fs, file, func(id string) string {
+		return recoveryBaseFilename(file, id)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: read recovery file %v", err, file)
-	}
-	if err := ValidateSchema(data); err != nil {
 		return nil, err
 	}
-	var checkpoint types.Checkpoint
-	if err = json.Unmarshal(data, &checkpoint); err != nil {
-		return nil, fmt.Errorf("%w: unmarshal checkpoint from %v", err, file)
-	}
-	if checkpoint.Version != SchemaVersion {
-		return nil, fmt.Errorf("expected version %v, got %v", SchemaVersion, checkpoint.Version)
-	}
 
-	allAccts := make([]*types.Account, 0, len(checkpoint.Data.Accounts))
-	for _, acct := range checkpoint.Data.Accounts {
+	allAccts := make([]*types.Account, 0, len(checkpoint.Accounts))
+	for _, acct := range checkpoint.Accounts {
 		a := types.Account{
 			Layer:     newGenesis,
 			NextNonce: acct.Nonce,
@@ -328,8 +403,8 @@ func checkpointData(fs afero.Fs, file string, newGenesis types.LayerID) (*recove
 		}
 		allAccts = append(allAccts, &a)
 	}
-	allAtxs := make([]*atxs.CheckpointAtx, 0, len(checkpoint.Data.Atxs))
-	for _, atx := range checkpoint.Data.Atxs {
+	allAtxs := make([]*atxs.CheckpointAtx, 0, len(checkpoint.Atxs))
+	for _, atx := range checkpoint.Atxs {
 		var cAtx atxs.CheckpointAtx
 		cAtx.ID = types.ATXID(types.BytesToHash(atx.ID))
 		cAtx.Epoch = types.EpochID(atx.Epoch)
@@ -337,6 +412,9 @@ func checkpointData(fs afero.Fs, file string, newGenesis types.LayerID) (*recove
 		cAtx.SmesherID = types.BytesToNodeID(atx.PublicKey)
 		cAtx.NumUnits = atx.NumUnits
 		cAtx.VRFNonce = types.VRFPostIndex(atx.VrfNonce)
+		if len(atx.VrfKey) > 0 {
+			cAtx.VRFKey = types.BytesToNodeID(atx.VrfKey)
+		}
 		cAtx.BaseTickHeight = atx.BaseTickHeight
 		cAtx.TickCount = atx.TickCount
 		cAtx.Sequence = atx.Sequence
@@ -410,12 +488,68 @@ func collectOwnAtxDeps(
 		if err != nil {
 			return nil, nil, fmt.Errorf("deps from nipost positioning atx (%v): %w", nipostCh.PositioningATX, err)
 		}
-		maps.Copy(deps, deps2)
-		maps.Copy(proofs, proofs2)
+		if deps == nil {
+			deps, proofs = deps2, proofs2
+		} else {
+			maps.Copy(deps, deps2)
+			maps.Copy(proofs, proofs2)
+		}
+		// poet proof of the pending atx is stored in the state database, that is replaced by recovery
+		proof, err := pendingPoetProof(db, localDB, nodeID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if proof != nil {
+			ref, err := proof.Ref()
+			if err != nil {
+				return nil, nil, err
+			}
+			logger.With().Info("collected poet proof of pending atx",
+				log.String("poet proof ref", types.Hash32(ref).ShortString()),
+			)
+			proofs[ref] = proof
+		}
 	}
 	return deps, proofs, nil
 }
 
+// pendingPoetProof returns the poet proof that was selected for the atx that is being built,
+// or nil if the proof wasn't received yet.
+func pendingPoetProof(
+	db *sql.Database,
+	localDB *localsql.Database,
+	nodeID types.NodeID,
+) (*types.PoetProofMessage, error) {
+	ref, _, err := nipost.PoetProofRef(localDB, nodeID)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return nil, fmt.Errorf("get pending poet proof ref: %w", err)
+	}
+	if ref == types.EmptyPoetProofRef {
+		return nil, nil
+	}
+	proof, err := poets.Get(db, ref)
+	if errors.Is(err, sql.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get pending poet proof (%s): %w", types.Hash32(ref).ShortString(), err)
+	}
+	var msg types.PoetProofMessage
+	if err := codec.Decode(proof, &msg); err != nil {
+		return nil, fmt.Errorf("decode pending poet proof (%s): %w", types.Hash32(ref).ShortString(), err)
+	}
+	return &msg, nil
+}
+
+// discardNIPostState removes the state of the atx that is being built by the identity.
+func discardNIPostState(ctx context.Context, localDB *localsql.Database, nodeID types.NodeID) error {
+	if err := localDB.WithTx(ctx, func(tx *sql.Tx) error {
+		return nipost.ResetPhase(tx, nodeID, nipost.PhaseNone)
+	}); err != nil {
+		return fmt.Errorf("discard nipost state of %s: %w", nodeID.ShortString(), err)
+	}
+	return nil
+}
+
 func collectDeps(
 	db *sql.Database,
 	ref types.ATXID,
@@ -25,6 +25,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/hash"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -188,6 +189,122 @@ func TestRecover(t *testing.T) {
 	}
 }
 
+// incrementalCheckpoint returns base and incremental checkpoints, that together are equal to checkpointData.
+func incrementalCheckpoint(tb testing.TB) ([]byte, []byte) {
+	var full types.Checkpoint
+	require.NoError(tb, json.Unmarshal([]byte(checkpointData), &full))
+	require.Greater(tb, len(full.Data.Atxs), 1)
+
+	// base misses the last atx, has an outdated first account and an atx that was removed since.
+	removed := full.Data.Atxs[0]
+	removed.ID = types.RandomATXID().Bytes()
+	outdated := full.Data.Accounts[0]
+	outdated.Balance++
+	base := types.Checkpoint{
+		Command: full.Command,
+		Version: checkpoint.SchemaVersion,
+		Data: types.InnerData{
+			CheckpointId: "snapshot-10",
+			Atxs:         append(append([]types.AtxSnapshot{}, full.Data.Atxs[:len(full.Data.Atxs)-1]...), removed),
+			Accounts:     append([]types.AccountSnapshot{outdated}, full.Data.Accounts[1:]...),
+		},
+	}
+	baseData, err := json.Marshal(base)
+	require.NoError(tb, err)
+	bhash := hash.Sum(baseData)
+	incremental := types.Checkpoint{
+		Command: full.Command,
+		Version: checkpoint.IncrementalSchemaVersion,
+		Data: types.InnerData{
+			CheckpointId: full.Data.CheckpointId,
+			Base:         &types.CheckpointBase{ID: base.Data.CheckpointId, Hash: bhash[:]},
+			RemovedAtxs:  [][]byte{removed.ID},
+			Atxs:         full.Data.Atxs[len(full.Data.Atxs)-1:],
+			Accounts:     full.Data.Accounts[:1],
+		},
+	}
+	incrementalData, err := json.Marshal(incremental)
+	require.NoError(tb, err)
+	return baseData, incrementalData
+}
+
+func TestRecover_Incremental(t *testing.T) {
+	baseData, incrementalData := incrementalCheckpoint(t)
+
+	tt := []struct {
+		name   string
+		base   []byte
+		expErr error
+	}{
+		{
+			name: "valid chain",
+			base: baseData,
+		},
+		{
+			name:   "modified base",
+			base:   append(append([]byte{}, baseData...), ' '),
+			expErr: checkpoint.ErrBaseMismatch,
+		},
+		{
+			name: "missing base",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				var data []byte
+				switch r.URL.Path {
+				case "/snapshot-15":
+					data = incrementalData
+				case "/snapshot-10":
+					data = tc.base
+				}
+				if data == nil {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, err := w.Write(data)
+				require.NoError(t, err)
+			}))
+			defer ts.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			fs := afero.NewMemMapFs()
+			cfg := &checkpoint.RecoverConfig{
+				GoldenAtx:   goldenAtx,
+				DataDir:     t.TempDir(),
+				DbFile:      "test.sql",
+				LocalDbFile: "local.sql",
+				Uri:         fmt.Sprintf("%s/snapshot-15", ts.URL),
+				Restore:     types.LayerID(recoverLayer),
+			}
+			db := sql.InMemory()
+			localDB := localsql.InMemory()
+			_, err := checkpoint.RecoverWithDb(ctx, logtest.New(t), db, localDB, fs, cfg)
+			switch {
+			case tc.expErr != nil:
+				require.ErrorIs(t, err, tc.expErr)
+				return
+			case tc.base == nil:
+				require.ErrorContains(t, err, "download base checkpoint")
+				require.NotErrorIs(t, err, checkpoint.ErrCheckpointNotFound)
+				return
+			}
+			require.NoError(t, err)
+			newDB, err := sql.Open("file:" + filepath.Join(cfg.DataDir, cfg.DbFile))
+			require.NoError(t, err)
+			defer newDB.Close()
+			verifyDbContent(t, newDB)
+		})
+	}
+}
+
 func TestRecover_SameRecoveryInfo(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		require.Equal(t, http.MethodGet, r.Method)
@@ -607,7 +724,29 @@ func TestRecover_OwnAtxNotInCheckpoint_Preserve_IncludePending(t *testing.T) {
 		PositioningATX: posAtx2.ID(),
 	})
 	require.NoError(t, err)
+	// poet proof for the challenge of the first identity was already received
+	pending := &types.PoetProofMessage{
+		PoetProof: types.PoetProof{
+			MerkleProof: shared.MerkleProof{
+				Root:         []byte{4, 5, 6},
+				ProvenLeaves: [][]byte{{1}, {2}},
+				ProofNodes:   [][]byte{{1}, {2}},
+			},
+			LeafCount: 1234,
+		},
+		PoetServiceID: []byte("poet_id_123456"),
+		RoundID:       "1338",
+	}
+	pendingRef, err := pending.Ref()
+	require.NoError(t, err)
+	require.NoError(t, nipost.UpdatePoetProofRef(localDB, sig1.NodeID(), pendingRef, &types.MerkleProof{}))
 	require.NoError(t, localDB.Close())
+	oldDB, err = sql.Open("file:" + filepath.Join(cfg.DataDir, cfg.DbFile))
+	require.NoError(t, err)
+	encoded, err := codec.Encode(pending)
+	require.NoError(t, err)
+	require.NoError(t, poets.Add(oldDB, pendingRef, encoded, pending.PoetServiceID, pending.RoundID))
+	require.NoError(t, oldDB.Close())
 
 	preserve, err := checkpoint.Recover(ctx, logtest.New(t), afero.NewOsFs(), cfg)
 	require.NoError(t, err)
@@ -618,6 +757,7 @@ func TestRecover_OwnAtxNotInCheckpoint_Preserve_IncludePending(t *testing.T) {
 	proofRef := proofRefs(append(proofs1, proofs2...))
 	require.ElementsMatch(t, atxRef, atxIDs(preserve.Deps))
 	require.ElementsMatch(t, proofRef, proofRefs(preserve.Proofs))
+	require.Equal(t, []*types.PoetProofMessage{pending}, preserve.PendingProofs)
 
 	newDB, err := sql.Open("file:" + filepath.Join(cfg.DataDir, cfg.DbFile))
 	require.NoError(t, err)
@@ -799,10 +939,38 @@ func TestRecover_OwnAtxNotInCheckpoint_Preserve_DepIsGolden(t *testing.T) {
 	}
 	require.NoError(t, oldDB.Close())
 
+	// the atx that is being built refers to the own atx that can't be preserved
+	localDB, err := localsql.Open("file:" + filepath.Join(cfg.DataDir, cfg.LocalDbFile))
+	require.NoError(t, err)
+	last := vAtxs[len(vAtxs)-1]
+	require.NoError(t, nipost.AddChallenge(localDB, sig.NodeID(), &types.NIPostChallenge{
+		PublishEpoch:   last.PublishEpoch + 1,
+		Sequence:       last.Sequence + 1,
+		PrevATXID:      last.ID(),
+		PositioningATX: last.ID(),
+	}))
+	require.NoError(t, nipost.AddPoetRegistration(localDB, sig.NodeID(), nipost.PoETRegistration{
+		ChallengeHash: types.RandomHash(),
+		Address:       "http://poet.test",
+		RoundID:       "1",
+		RoundEnd:      time.Now(),
+	}))
+	require.NoError(t, localDB.Close())
+
 	preserve, err := checkpoint.Recover(ctx, logtest.New(t), afero.NewOsFs(), cfg)
 	require.NoError(t, err)
 	require.Nil(t, preserve)
 
+	// builder starts with a new challenge after recovery
+	localDB, err = localsql.Open("file:" + filepath.Join(cfg.DataDir, cfg.LocalDbFile))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, localDB.Close()) })
+	_, err = nipost.Challenge(localDB, sig.NodeID())
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	count, err := nipost.PoetRegistrationCount(localDB, sig.NodeID())
+	require.NoError(t, err)
+	require.Zero(t, count)
+
 	newDB, err := sql.Open("file:" + filepath.Join(cfg.DataDir, cfg.DbFile))
 	require.NoError(t, err)
 	require.NotNil(t, newDB)
@@ -26,11 +26,17 @@ const (
 	dirPerm       = 0o700
 )
 
+// progressFunc is notified about the number of items processed in the stage of the checkpoint generation.
+type progressFunc func(stage Stage, done, total int)
+
+func noProgress(Stage, int, int) {}
+
 func checkpointDB(
 	ctx context.Context,
 	db *sql.Database,
 	snapshot types.LayerID,
 	numAtxs int,
+	progress progressFunc,
 ) (*types.Checkpoint, error) {
 	request, err := json.Marshal(&pb.CheckpointStreamRequest{
 		SnapshotLayer: uint32(snapshot),
@@ -60,6 +66,7 @@ func checkpointDB(
 	}
 	malicious := map[types.NodeID]bool{}
 	for i, catx := range atxSnapshot {
+		progress(StageAtxs, i, len(atxSnapshot))
 		if _, ok := malicious[catx.SmesherID]; !ok {
 			mal, err := identities.IsMalicious(tx, catx.SmesherID)
 			if err != nil {
@@ -75,18 +82,28 @@ func checkpointDB(
 		if err != nil {
 			return nil, fmt.Errorf("atxs snapshot nonce: %w", err)
 		}
+		vrfKey, err := atxs.VRFKey(tx, catx.SmesherID, catx.Epoch+1)
+		if err != nil {
+			return nil, fmt.Errorf("atxs snapshot vrf key: %w", err)
+		}
 		copy(atxSnapshot[i].CommitmentATX[:], commitmentAtx[:])
 		atxSnapshot[i].VRFNonce = vrfNonce
+		atxSnapshot[i].VRFKey = vrfKey
 	}
 	for _, catx := range atxSnapshot {
 		if mal, ok := malicious[catx.SmesherID]; ok && mal {
 			continue
 		}
+		var vrfKey []byte
+		if catx.VRFKey != catx.SmesherID {
+			vrfKey = catx.VRFKey.Bytes()
+		}
 		checkpoint.Data.Atxs = append(checkpoint.Data.Atxs, types.AtxSnapshot{
 			ID:             catx.ID.Bytes(),
 			Epoch:          catx.Epoch.Uint32(),
 			CommitmentAtx:  catx.CommitmentATX.Bytes(),
 			VrfNonce:       uint64(catx.VRFNonce),
+			VrfKey:         vrfKey,
 			NumUnits:       catx.NumUnits,
 			BaseTickHeight: catx.BaseTickHeight,
 			TickCount:      catx.TickCount,
@@ -100,7 +117,8 @@ func checkpointDB(
 	if err != nil {
 		return nil, fmt.Errorf("accounts snapshot: %w", err)
 	}
-	for _, acct := range acctSnapshot {
+	for i, acct := range acctSnapshot {
+		progress(StageAccounts, i, len(acctSnapshot))
 		a := types.AccountSnapshot{
 			Address: acct.Address.Bytes(),
 			Balance: acct.Balance,
@@ -125,11 +143,28 @@ func Generate(
 	snapshot types.LayerID,
 	numAtxs int,
 ) error {
-	checkpoint, err := checkpointDB(ctx, db, snapshot, numAtxs)
+	return generate(ctx, fs, db, dataDir, snapshot, numAtxs, noProgress)
+}
+
+func generate(
+	ctx context.Context,
+	fs afero.Fs,
+	db *sql.Database,
+	dataDir string,
+	snapshot types.LayerID,
+	numAtxs int,
+	progress progressFunc,
+) error {
+	checkpoint, err := checkpointDB(ctx, db, snapshot, numAtxs, progress)
 	if err != nil {
 		return err
 	}
-	rf, err := NewRecoveryFile(fs, SelfCheckpointFilename(dataDir, snapshot))
+	progress(StageWriting, 0, 1)
+	return writeCheckpoint(fs, SelfCheckpointFilename(dataDir, snapshot), checkpoint)
+}
+
+func writeCheckpoint(fs afero.Fs, file string, checkpoint *types.Checkpoint) error {
+	rf, err := NewRecoveryFile(fs, file)
 	if err != nil {
 		return fmt.Errorf("new recovery file: %w", err)
 	}
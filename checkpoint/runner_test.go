@@ -17,6 +17,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
@@ -336,6 +337,55 @@ func TestRunner_Generate(t *testing.T) {
 	}
 }
 
+func TestRunner_GenerateIncremental(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db, allAtxs, allAccounts)
+
+	fs := afero.NewMemMapFs()
+	dir, err := afero.TempDir(fs, "", "Generate")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	base, snapshot := types.LayerID(5), types.LayerID(7)
+	require.ErrorContains(t, checkpoint.GenerateIncremental(ctx, fs, db, dir, base, snapshot, 2), "base checkpoint")
+	require.NoError(t, checkpoint.Generate(ctx, fs, db, dir, base, 2))
+	require.ErrorContains(t, checkpoint.GenerateIncremental(ctx, fs, db, dir, snapshot, base, 2), "not before")
+	require.NoError(t, checkpoint.GenerateIncremental(ctx, fs, db, dir, base, snapshot, 2))
+
+	full, err := afero.ReadFile(fs, checkpoint.SelfCheckpointFilename(dir, base))
+	require.NoError(t, err)
+	persisted, err := afero.ReadFile(fs, checkpoint.SelfCheckpointFilename(dir, snapshot))
+	require.NoError(t, err)
+	require.NoError(t, checkpoint.ValidateSchema(persisted))
+	var got types.Checkpoint
+	require.NoError(t, json.Unmarshal(persisted, &got))
+
+	require.Equal(t, checkpoint.IncrementalSchemaVersion, got.Version)
+	require.Equal(t, "snapshot-7", got.Data.CheckpointId)
+	fhash := hash.Sum(full)
+	require.Equal(t, &types.CheckpointBase{ID: "snapshot-5", Hash: fhash[:]}, got.Data.Base)
+	// atxs didn't change since the base, only accounts updated in layers 6 and 7 are included
+	require.Empty(t, got.Data.Atxs)
+	require.Empty(t, got.Data.RemovedAtxs)
+	require.ElementsMatch(t, []types.AccountSnapshot{
+		{
+			Address:  types.Address{2, 2}.Bytes(),
+			Balance:  111,
+			Nonce:    15,
+			Template: types.Address{2}.Bytes(),
+			State:    []byte("state26"),
+		},
+		{
+			Address:  types.Address{4, 4}.Bytes(),
+			Balance:  31,
+			Nonce:    1,
+			Template: types.Address{3}.Bytes(),
+			State:    []byte("state47"),
+		},
+	}, got.Data.Accounts)
+}
+
 func TestRunner_Generate_Error(t *testing.T) {
 	const numEpochs = 2
 
@@ -0,0 +1,331 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Stage of the checkpoint generation.
+type Stage string
+
+const (
+	StagePending  Stage = "pending"
+	StageAtxs     Stage = "atxs"
+	StageAccounts Stage = "accounts"
+	StageWriting  Stage = "writing"
+	StageDone     Stage = "done"
+	StageFailed   Stage = "failed"
+)
+
+// maxJobs is the number of the most recent generation jobs that are kept for progress reporting.
+const maxJobs = 100
+
+var ErrAlreadyScheduled = errors.New("checkpoint generation already scheduled")
+
+// ScheduleConfig configures recurring generation of checkpoints.
+type ScheduleConfig struct {
+	// Interval is the number of epochs between generated checkpoints. Checkpoints are not scheduled if set to 0.
+	// Checkpoint is generated at the last layer of every epoch that precedes the epoch divisible by Interval.
+	Interval uint32 `mapstructure:"interval"`
+	// Retain is the number of the most recent checkpoints that are kept on disk. All are kept if set to 0.
+	Retain int `mapstructure:"retain"`
+	// NumAtxs is the number of atxs of every identity that are included in scheduled checkpoints.
+	NumAtxs int `mapstructure:"num-atxs"`
+}
+
+func DefaultScheduleConfig() ScheduleConfig {
+	return ScheduleConfig{
+		NumAtxs: 4,
+	}
+}
+
+// Progress of the checkpoint generation job.
+type Progress struct {
+	ID       uint64
+	Snapshot types.LayerID
+	Stage    Stage
+	// Done and Total are the number of processed items in the current stage.
+	Done     int
+	Total    int
+	Started  time.Time
+	Finished time.Time
+	Err      error
+}
+
+type layersState interface {
+	LatestLayerInState() types.LayerID
+}
+
+// Scheduler generates checkpoints in the background, one at a time, and keeps track of their progress.
+type Scheduler struct {
+	logger  log.Log
+	fs      afero.Fs
+	db      *sql.Database
+	dataDir string
+	cfg     ScheduleConfig
+
+	// lock is held while checkpoint is generated.
+	lock chan struct{}
+	wg   sync.WaitGroup
+
+	shutdownCtx context.Context
+	cancel      context.CancelFunc
+
+	mu     sync.Mutex
+	lastID uint64
+	jobs   []*Progress
+}
+
+// NewScheduler creates a scheduler for checkpoints stored in the data directory.
+func NewScheduler(logger log.Log, fs afero.Fs, db *sql.Database, dataDir string, cfg ScheduleConfig) *Scheduler {
+	s := &Scheduler{
+		logger:  logger,
+		fs:      fs,
+		db:      db,
+		dataDir: dataDir,
+		cfg:     cfg,
+		lock:    make(chan struct{}, 1),
+	}
+	s.shutdownCtx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Generate starts generation of the checkpoint at the snapshot layer in the background.
+// It returns the id of the job that can be used to query its progress.
+func (s *Scheduler) Generate(snapshot types.LayerID, numAtxs int) (uint64, error) {
+	job, err := s.schedule(snapshot)
+	if err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(s.shutdownCtx, job, numAtxs)
+	}()
+	return job.ID, nil
+}
+
+// Stop cancels checkpoints that are being generated and waits for them to exit.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) schedule(snapshot types.LayerID) (*Progress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdownCtx.Err() != nil {
+		return nil, s.shutdownCtx.Err()
+	}
+	for _, job := range s.jobs {
+		if job.Snapshot == snapshot && job.Finished.IsZero() {
+			return nil, fmt.Errorf("%w: layer %s in job %d", ErrAlreadyScheduled, snapshot, job.ID)
+		}
+	}
+	s.lastID++
+	job := &Progress{ID: s.lastID, Snapshot: snapshot, Stage: StagePending}
+	s.jobs = append(s.jobs, job)
+	if len(s.jobs) > maxJobs {
+		s.jobs = slices.Delete(s.jobs, 0, len(s.jobs)-maxJobs)
+	}
+	return job, nil
+}
+
+func (s *Scheduler) run(ctx context.Context, job *Progress, numAtxs int) {
+	select {
+	case <-ctx.Done():
+		s.finish(job, ctx.Err())
+		return
+	case s.lock <- struct{}{}:
+	}
+	defer func() { <-s.lock }()
+	s.mu.Lock()
+	job.Started = time.Now()
+	s.mu.Unlock()
+	s.logger.With().Info("generating checkpoint",
+		log.Context(ctx),
+		log.Uint64("job", job.ID),
+		log.Stringer("snapshot", job.Snapshot),
+	)
+	err := generate(ctx, s.fs, s.db, s.dataDir, job.Snapshot, numAtxs, func(stage Stage, done, total int) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.Stage = stage
+		job.Done = done
+		job.Total = total
+	})
+	s.finish(job, err)
+	if err != nil {
+		s.logger.With().Warning("failed to generate checkpoint",
+			log.Context(ctx),
+			log.Uint64("job", job.ID),
+			log.Stringer("snapshot", job.Snapshot),
+			log.Err(err),
+		)
+		return
+	}
+	s.logger.With().Info("checkpoint generated",
+		log.Context(ctx),
+		log.Uint64("job", job.ID),
+		log.Stringer("snapshot", job.Snapshot),
+	)
+	if err := s.retain(); err != nil {
+		s.logger.With().Warning("failed to remove old checkpoints", log.Context(ctx), log.Err(err))
+	}
+}
+
+func (s *Scheduler) finish(job *Progress, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.Finished = time.Now()
+	job.Err = err
+	job.Done = job.Total
+	if err != nil {
+		job.Stage = StageFailed
+	} else {
+		job.Stage = StageDone
+	}
+}
+
+// Progress returns the progress of the job with the id.
+func (s *Scheduler) Progress(id uint64) (Progress, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return Progress{}, false
+}
+
+// Jobs returns the progress of the most recent jobs, ordered by id.
+func (s *Scheduler) Jobs() []Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rst := make([]Progress, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		rst = append(rst, *job)
+	}
+	return rst
+}
+
+// retain removes checkpoints that are older than the configured number of the most recent checkpoints,
+// unless they are a base of the retained incremental checkpoint.
+func (s *Scheduler) retain() error {
+	if s.cfg.Retain == 0 {
+		return nil
+	}
+	dir := filepath.Join(s.dataDir, checkpointDir)
+	files, err := afero.ReadDir(s.fs, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("read checkpoints dir %s: %w", dir, err)
+	}
+	var layers []types.LayerID
+	for _, fi := range files {
+		name, ok := strings.CutPrefix(fi.Name(), "snapshot-")
+		if fi.IsDir() || !ok {
+			continue
+		}
+		layer, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		// temporary files of the checkpoints that are being written have the same prefix
+		checkpoint, _, err := readCheckpoint(s.fs, filepath.Join(dir, fi.Name()))
+		if err != nil || checkpoint.Data.CheckpointId != fi.Name() {
+			continue
+		}
+		layers = append(layers, types.LayerID(layer))
+	}
+	if len(layers) <= s.cfg.Retain {
+		return nil
+	}
+	slices.Sort(layers)
+	retained := map[string]struct{}{}
+	for _, layer := range layers[len(layers)-s.cfg.Retain:] {
+		file := SelfCheckpointFilename(s.dataDir, layer)
+		retained[file] = struct{}{}
+		for {
+			checkpoint, _, err := readCheckpoint(s.fs, file)
+			if err != nil || checkpoint.Data.Base == nil {
+				break
+			}
+			file = filepath.Join(dir, filepath.Base(checkpoint.Data.Base.ID))
+			if _, ok := retained[file]; ok {
+				break
+			}
+			retained[file] = struct{}{}
+		}
+	}
+	for _, layer := range layers[:len(layers)-s.cfg.Retain] {
+		file := SelfCheckpointFilename(s.dataDir, layer)
+		if _, ok := retained[file]; ok {
+			continue
+		}
+		if err := s.fs.Remove(file); err != nil {
+			return fmt.Errorf("remove checkpoint %s: %w", file, err)
+		}
+		s.logger.With().Info("removed old checkpoint", log.Stringer("snapshot", layer))
+	}
+	return nil
+}
+
+// nextScheduled returns the first layer at or after the given layer, at which checkpoint is scheduled.
+func (s *Scheduler) nextScheduled(layer types.LayerID) types.LayerID {
+	period := s.cfg.Interval * types.GetLayersPerEpoch()
+	return types.LayerID((layer.Uint32()/period+1)*period - 1)
+}
+
+// Run generates checkpoints every configured number of epochs, once the snapshot layer is applied.
+// The applied layer is checked every interval.
+func (s *Scheduler) Run(ctx context.Context, state layersState, interval time.Duration) error {
+	if s.cfg.Interval == 0 {
+		return nil
+	}
+	next := s.nextScheduled(state.LatestLayerInState())
+	s.logger.With().Info("checkpoint generation scheduled",
+		log.Context(ctx),
+		log.Uint32("interval", s.cfg.Interval),
+		log.Int("retain", s.cfg.Retain),
+		log.Stringer("next", next),
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		if state.LatestLayerInState() < next {
+			continue
+		}
+		if exists, _ := afero.Exists(s.fs, SelfCheckpointFilename(s.dataDir, next)); exists {
+			next = s.nextScheduled(next + 1)
+			continue
+		}
+		if _, err := s.Generate(next, s.cfg.NumAtxs); err != nil {
+			s.logger.With().Warning("failed to schedule checkpoint",
+				log.Context(ctx),
+				log.Stringer("snapshot", next),
+				log.Err(err),
+			)
+		}
+		next = s.nextScheduled(next + 1)
+	}
+}
@@ -0,0 +1,178 @@
+package checkpoint_test
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/checkpoint"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// blockingFs blocks writing checkpoints until unblock is closed.
+type blockingFs struct {
+	afero.Fs
+	unblock chan struct{}
+}
+
+func (fs *blockingFs) MkdirAll(path string, perm os.FileMode) error {
+	<-fs.unblock
+	return fs.Fs.MkdirAll(path, perm)
+}
+
+type appliedLayer struct {
+	atomic.Uint32
+	reads atomic.Int32
+}
+
+func (l *appliedLayer) LatestLayerInState() types.LayerID {
+	l.reads.Add(1)
+	return types.LayerID(l.Load())
+}
+
+func TestScheduler_Generate(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db, allAtxs, allAccounts)
+	fs := &blockingFs{Fs: afero.NewMemMapFs(), unblock: make(chan struct{})}
+	s := checkpoint.NewScheduler(logtest.New(t), fs, db, "/data", checkpoint.DefaultScheduleConfig())
+	t.Cleanup(s.Stop)
+
+	first, err := s.Generate(5, 2)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		progress, exists := s.Progress(first)
+		return exists && progress.Stage == checkpoint.StageWriting
+	}, time.Second, 10*time.Millisecond)
+	_, err = s.Generate(5, 2)
+	require.ErrorIs(t, err, checkpoint.ErrAlreadyScheduled)
+
+	second, err := s.Generate(7, 2)
+	require.NoError(t, err)
+	progress, exists := s.Progress(second)
+	require.True(t, exists)
+	require.Equal(t, checkpoint.StagePending, progress.Stage)
+	require.Equal(t, types.LayerID(7), progress.Snapshot)
+
+	close(fs.unblock)
+	require.Eventually(t, func() bool {
+		progress, _ := s.Progress(second)
+		return progress.Stage == checkpoint.StageDone
+	}, time.Second, 10*time.Millisecond)
+	jobs := s.Jobs()
+	require.Len(t, jobs, 2)
+	for i, snapshot := range []types.LayerID{5, 7} {
+		require.Equal(t, snapshot, jobs[i].Snapshot)
+		require.Equal(t, checkpoint.StageDone, jobs[i].Stage)
+		require.NoError(t, jobs[i].Err)
+		require.False(t, jobs[i].Finished.Before(jobs[i].Started))
+		exists, err := afero.Exists(fs, checkpoint.SelfCheckpointFilename("/data", snapshot))
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+
+	_, exists = s.Progress(second + 1)
+	require.False(t, exists)
+}
+
+func TestScheduler_GenerateFailed(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db, allAtxs, nil)
+	s := checkpoint.NewScheduler(
+		logtest.New(t), afero.NewMemMapFs(), db, "/data", checkpoint.DefaultScheduleConfig())
+	t.Cleanup(s.Stop)
+
+	id, err := s.Generate(5, 2)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		progress, _ := s.Progress(id)
+		return progress.Stage == checkpoint.StageFailed
+	}, time.Second, 10*time.Millisecond)
+	progress, _ := s.Progress(id)
+	require.Error(t, progress.Err)
+
+	s.Stop()
+	_, err = s.Generate(7, 2)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestScheduler_Retain(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db, allAtxs, allAccounts)
+	fs := afero.NewMemMapFs()
+	ctx := context.Background()
+	require.NoError(t, checkpoint.Generate(ctx, fs, db, "/data", 3, 2))
+	require.NoError(t, checkpoint.Generate(ctx, fs, db, "/data", 5, 2))
+	require.NoError(t, checkpoint.GenerateIncremental(ctx, fs, db, "/data", 3, 6, 2))
+
+	cfg := checkpoint.DefaultScheduleConfig()
+	cfg.Retain = 2
+	s := checkpoint.NewScheduler(logtest.New(t), fs, db, "/data", cfg)
+	t.Cleanup(s.Stop)
+	id, err := s.Generate(7, 2)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		progress, _ := s.Progress(id)
+		return progress.Stage == checkpoint.StageDone
+	}, time.Second, 10*time.Millisecond)
+	s.Stop()
+
+	// base of the retained incremental checkpoint is kept
+	for layer, retained := range map[types.LayerID]bool{3: true, 5: false, 6: true, 7: true} {
+		exists, err := afero.Exists(fs, checkpoint.SelfCheckpointFilename("/data", layer))
+		require.NoError(t, err)
+		require.Equal(t, retained, exists, "layer %s", layer)
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	db := sql.InMemory()
+	createMesh(t, db, allAtxs, allAccounts)
+	fs := afero.NewMemMapFs()
+
+	t.Run("disabled", func(t *testing.T) {
+		s := checkpoint.NewScheduler(logtest.New(t), fs, db, "/data", checkpoint.DefaultScheduleConfig())
+		t.Cleanup(s.Stop)
+		require.NoError(t, s.Run(context.Background(), &appliedLayer{}, time.Millisecond))
+	})
+
+	cfg := checkpoint.DefaultScheduleConfig()
+	cfg.Interval = 1
+	cfg.NumAtxs = 2
+	s := checkpoint.NewScheduler(logtest.New(t), fs, db, "/data", cfg)
+	t.Cleanup(s.Stop)
+	var state appliedLayer
+	state.Store(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, eg.Wait())
+	})
+	eg.Go(func() error {
+		return s.Run(ctx, &state, 10*time.Millisecond)
+	})
+
+	// the first checkpoint is scheduled after the layer applied on start
+	require.Eventually(t, func() bool {
+		return state.reads.Load() > 0
+	}, time.Second, time.Millisecond)
+	// with 2 layers per epoch checkpoints are scheduled at layers 3, 5, 7
+	state.Store(6)
+	require.Eventually(t, func() bool {
+		jobs := s.Jobs()
+		return len(jobs) == 2 && jobs[0].Stage == checkpoint.StageDone && jobs[1].Stage == checkpoint.StageDone
+	}, time.Second, 10*time.Millisecond)
+	for layer, generated := range map[types.LayerID]bool{1: false, 3: true, 5: true, 7: false} {
+		exists, err := afero.Exists(fs, checkpoint.SelfCheckpointFilename("/data", layer))
+		require.NoError(t, err)
+		require.Equal(t, generated, exists, "layer %s", layer)
+	}
+}
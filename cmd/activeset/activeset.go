@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -33,15 +32,14 @@ Example:
 	db, err := sql.Open("file:" + dbpath)
 	must(err, "can't open db at dbpath=%v. err=%s\n", dbpath, err)
 
-	ids, err := atxs.GetIDsByEpoch(context.Background(), db, types.EpochID(publish))
-	must(err, "get ids by epoch %d. dbpath=%v. err=%s\n", publish, dbpath, err)
-	var weight uint64
-	for _, id := range ids {
-		atx, err := atxs.Get(db, id)
-		must(err, "get id %v: %s\n", id, err)
-		weight += atx.GetWeight()
-	}
-	fmt.Printf("count = %d\nweight = %d\n", len(ids), weight)
+	var count, weight uint64
+	err = atxs.IterateHeadersByEpoch(db, types.EpochID(publish), func(header *types.ActivationTxHeader) bool {
+		count++
+		weight += header.GetWeight()
+		return true
+	})
+	must(err, "iterate atxs in epoch %d. dbpath=%v. err=%s\n", publish, dbpath, err)
+	fmt.Printf("count = %d\nweight = %d\n", count, weight)
 }
 
 func must(err error, msg string, vars ...any) {
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/haretrace"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Println(`Usage:
+	> haretrace <local db path> [layer]
+Example:
+	list layers with recorded hare traces in local.sql file.
+	> haretrace local.sql
+	export messages of the hare session in layer 1000 as json lines.
+	> haretrace local.sql 1000`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dbpath := flag.Arg(0)
+	if len(dbpath) == 0 {
+		must(errors.New("dbpath is empty"), "dbpath is empty\n")
+	}
+	db, err := localsql.Open(fmt.Sprintf("file:%s?mode=ro", dbpath), sql.WithConnections(1))
+	must(err, "can't open db at dbpath=%v. err=%s\n", dbpath, err)
+	defer db.Close()
+
+	if flag.NArg() < 2 {
+		layers, err := haretrace.Layers(db)
+		must(err, "list traced layers. dbpath=%v. err=%s\n", dbpath, err)
+		for _, layer := range layers {
+			fmt.Println(layer)
+		}
+		return
+	}
+	layer, err := strconv.ParseUint(flag.Arg(1), 10, 32)
+	must(err, "layer %v is not a valid integer: %s", flag.Arg(1), err)
+	trace, err := haretrace.Get(db, types.LayerID(layer))
+	must(err, "get trace for layer %d. dbpath=%v. err=%s\n", layer, dbpath, err)
+	records, err := hare3.DecodeTrace(trace)
+	must(err, "decode trace for layer %d: %s\n", layer, err)
+	enc := json.NewEncoder(os.Stdout)
+	for i := range records {
+		must(enc.Encode(&records[i]), "write record\n")
+	}
+}
+
+func must(err error, msg string, vars ...any) {
+	if err != nil {
+		fmt.Printf(msg, vars...)
+		fmt.Println("")
+		flag.Usage()
+		os.Exit(1)
+	}
+}
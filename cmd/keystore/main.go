@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
+)
+
+var (
+	path           = flag.String("path", filepath.Join("identities", keystore.FileName), "path to the keystore file")
+	passphraseFile = flag.String("passphrase-file", "",
+		"file with the keystore passphrase. if not set passphrase is read from "+keystore.PassphraseEnv)
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Println(`Usage:
+	> keystore [flags] list
+	> keystore [flags] generate <label>
+	> keystore [flags] import <label> <key file>
+	> keystore [flags] label <id> <label>
+	> keystore [flags] export <id> <key file>
+	> keystore [flags] remove <id>
+Identities are referenced by the hex encoded node id. Key files contain the hex encoded private key.
+Example:
+	add the existing identity to the keystore in the node data directory.
+	> keystore -path ~/spacemesh/identities/keystore.json import remote-1 ~/post-1/identity.key`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// only identities can be added to the new keystore
+	if cmd := flag.Arg(0); cmd != "generate" && cmd != "import" {
+		_, err := os.Stat(*path)
+		must(err, "keystore at %s: %s\n", *path, err)
+	}
+	passphrase, err := keystore.Config{PassphraseFile: *passphraseFile}.Passphrase()
+	must(err, "read passphrase: %s\n", err)
+	ks, err := keystore.Open(*path, passphrase)
+	must(err, "open keystore at %s: %s\n", *path, err)
+
+	switch cmd := flag.Arg(0); cmd {
+	case "list":
+		for _, id := range ks.Identities() {
+			fmt.Printf("%s\t%s\t%s\n", hex.EncodeToString(id.NodeID.Bytes()), id.Created.Format(time.RFC3339), id.Label)
+		}
+	case "generate":
+		requireArgs(2)
+		id, err := ks.Generate(flag.Arg(1))
+		must(err, "generate identity: %s\n", err)
+		fmt.Println(hex.EncodeToString(id.NodeID.Bytes()))
+	case "import":
+		requireArgs(3)
+		signer, err := signing.NewEdSigner(signing.FromFile(flag.Arg(2)))
+		must(err, "load key file: %s\n", err)
+		id, err := ks.Add(flag.Arg(1), signer.PrivateKey())
+		must(err, "import identity: %s\n", err)
+		fmt.Println(hex.EncodeToString(id.NodeID.Bytes()))
+	case "label":
+		requireArgs(3)
+		err := ks.SetLabel(parseID(flag.Arg(1)), flag.Arg(2))
+		must(err, "set label of %s: %s\n", flag.Arg(1), err)
+	case "export":
+		requireArgs(3)
+		key, err := ks.Export(parseID(flag.Arg(1)))
+		must(err, "export identity: %s\n", err)
+		_, err = os.Stat(flag.Arg(2))
+		if err == nil {
+			err = fmt.Errorf("file %s already exists", flag.Arg(2))
+		} else if errors.Is(err, os.ErrNotExist) {
+			err = os.WriteFile(flag.Arg(2), []byte(hex.EncodeToString(key)), 0o600)
+		}
+		must(err, "write key file: %s\n", err)
+	case "remove":
+		requireArgs(2)
+		err := ks.Remove(parseID(flag.Arg(1)))
+		must(err, "remove identity %s: %s\n", flag.Arg(1), err)
+	default:
+		must(fmt.Errorf("unknown command %q", cmd), "unknown command %q\n", cmd)
+	}
+}
+
+func parseID(arg string) types.NodeID {
+	data, err := hex.DecodeString(arg)
+	must(err, "id %s is not valid hex: %s\n", arg, err)
+	if len(data) != types.NodeIDSize {
+		must(errors.New("invalid id length"), "invalid id length (%d), expected (%d)\n", len(data), types.NodeIDSize)
+	}
+	return types.BytesToNodeID(data)
+}
+
+func requireArgs(n int) {
+	if flag.NArg() != n {
+		must(errors.New("wrong number of arguments"), "%s expects %d arguments\n", flag.Arg(0), n-1)
+	}
+}
+
+func must(err error, msg string, vars ...any) {
+	if err != nil {
+		fmt.Printf(msg, vars...)
+		fmt.Println("")
+		flag.Usage()
+		os.Exit(1)
+	}
+}
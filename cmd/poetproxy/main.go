@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+type poetsFlag []types.PoetServer
+
+func (p *poetsFlag) String() string {
+	addresses := make([]string, 0, len(*p))
+	for _, server := range *p {
+		addresses = append(addresses, server.Address)
+	}
+	return strings.Join(addresses, ",")
+}
+
+func (p *poetsFlag) Set(address string) error {
+	*p = append(*p, types.PoetServer{Address: address})
+	return nil
+}
+
+func main() {
+	var (
+		cfg     = poetproxy.DefaultConfig()
+		poetCfg = activation.DefaultPoetConfig()
+		poets   poetsFlag
+	)
+	flag.Usage = func() {
+		fmt.Println(`Usage:
+	> poetproxy -listen <address> -poet <address> [-poet <address>...]
+Example:
+	serve the proxy of two poets for the nodes on the local network.
+	nodes point to the proxy with poet-proxy.endpoint and keep the same poet-servers.
+	> poetproxy -listen 0.0.0.0:9095 -poet https://poet-110.spacemesh.network -poet https://poet-111.spacemesh.network`)
+		flag.PrintDefaults()
+	}
+	flag.StringVar(&cfg.Listen, "listen", "127.0.0.1:9095", "address to serve the proxy on")
+	flag.Var(&poets, "poet", "address of the poet, can be repeated")
+	flag.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "largest number of registrations sent to the poet together")
+	flag.DurationVar(&cfg.BatchDelay, "batch-delay", cfg.BatchDelay,
+		"time the first registration of the batch waits for the other ones")
+	flag.DurationVar(&cfg.PowParamsTTL, "pow-params-ttl", cfg.PowParamsTTL, "time pow params of the poet are cached")
+	flag.IntVar(&cfg.ProofCacheSize, "proof-cache-size", cfg.ProofCacheSize, "number of proofs kept in memory")
+	flag.IntVar(&poetCfg.MaxRequestRetries, "retry-max", poetCfg.MaxRequestRetries, "retries of a request to the poet")
+	flag.DurationVar(&poetCfg.RequestRetryDelay, "retry-delay", poetCfg.RequestRetryDelay,
+		"delay between retries of a request to the poet")
+	flag.Parse()
+
+	if len(poets) == 0 {
+		fmt.Println("no poets configured")
+		flag.Usage()
+		os.Exit(1)
+	}
+	logger, err := zap.NewProduction()
+	must(err, "create logger: %s\n", err)
+	srv, err := poetproxy.NewHTTPServer(logger, cfg, poets, poetCfg)
+	must(err, "create proxy: %s\n", err)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	err = srv.Run(ctx)
+	must(err, "serve proxy: %s\n", err)
+}
+
+func must(err error, msg string, vars ...any) {
+	if err != nil {
+		fmt.Printf(msg, vars...)
+		os.Exit(1)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/config"
 	"github.com/spacemeshos/go-spacemesh/config/presets"
+	"github.com/spacemeshos/go-spacemesh/config/profiles"
 	"github.com/spacemeshos/go-spacemesh/node/flags"
 )
 
@@ -20,12 +21,30 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 	configPath = flagSet.StringP("config", "c", "", "load configuration from file")
 	flagSet.StringVarP(&cfg.Preset, "preset", "p", "",
 		fmt.Sprintf("preset overwrites default values of the config. options %s", presets.Options()))
+	flagSet.StringVar(&cfg.Profile, "profile", "",
+		fmt.Sprintf("profile adjusts pruning, caching, served protocols and api services of the preset. options %s",
+			profiles.Options()))
 
 	/** ======================== Checkpoint Flags ========================== **/
 	flagSet.StringVar(&cfg.Recovery.Uri,
-		"recovery-uri", cfg.Recovery.Uri, "reset the node state based on the supplied checkpoint file")
+		"recovery-uri", cfg.Recovery.Uri,
+		"reset the node state based on the supplied checkpoint file (http(s) url, or p2p://<hash> to download from peers)")
 	flagSet.Uint32Var(&cfg.Recovery.Restore,
 		"recovery-layer", cfg.Recovery.Restore, "restart the mesh with the checkpoint file at this layer")
+	flagSet.Uint32Var(&cfg.Checkpoint.Interval,
+		"checkpoint-interval", cfg.Checkpoint.Interval, "generate checkpoint every this number of epochs (0 to disable)")
+	flagSet.IntVar(&cfg.Checkpoint.Retain,
+		"checkpoint-retain", cfg.Checkpoint.Retain, "keep this number of the most recent checkpoints (0 to keep all)")
+
+	/** ======================== Keystore Flags ========================== **/
+	flagSet.BoolVar(&cfg.Keystore.Enabled,
+		"keystore", cfg.Keystore.Enabled, "load identities from the encrypted keystore, key files are imported into it")
+	flagSet.StringVar(&cfg.Keystore.PassphraseFile,
+		"keystore-passphrase-file", cfg.Keystore.PassphraseFile,
+		"file with the keystore passphrase (SPACEMESH_KEYSTORE_PASSPHRASE environment variable is used if not set)")
+	flagSet.BoolVar(&cfg.Keystore.KeepImported,
+		"keystore-keep-imported", cfg.Keystore.KeepImported,
+		"keep key files imported into the keystore as plain text backups instead of deleting them")
 
 	/** ======================== BaseConfig Flags ========================== **/
 	flagSet.StringVarP(&cfg.BaseConfig.DataDirParent, "data-folder", "d",
@@ -62,6 +81,44 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.ProfilerURL, "send profiler data to certain url, if no url no profiling will be sent, format: http://<IP>:<PORT>")
 	flagSet.StringVar(&cfg.ProfilerName, "profiler-name",
 		cfg.ProfilerName, "the name to use when sending profiles")
+	flagSet.BoolVar(&cfg.Tracing.Enable, "tracing",
+		cfg.Tracing.Enable, "export traces of gossip, validation, fetch and database writes to the otlp collector")
+	flagSet.StringVar(&cfg.Tracing.Endpoint, "tracing-endpoint",
+		cfg.Tracing.Endpoint, "address of the otlp grpc collector, format: <IP>:<PORT>")
+	flagSet.Float64Var(&cfg.Tracing.SampleRatio, "tracing-sample-ratio",
+		cfg.Tracing.SampleRatio, "fraction of traces started on this node that are exported")
+	flagSet.BoolVar(&cfg.DiskSpace.Disable, "disable-diskspace-monitor",
+		cfg.DiskSpace.Disable, "disable forecasting of disk usage and the protective mode on low disk space")
+	flagSet.Uint64Var(&cfg.DiskSpace.MinFree, "diskspace-min-free",
+		cfg.DiskSpace.MinFree, "free disk space in bytes below which the node enters the protective mode")
+	flagSet.BoolVar(&cfg.Explorer.Enabled, "explorer-index",
+		cfg.Explorer.Enabled, "maintain the index of applied layers for the explorer api service")
+	flagSet.Float64Var(&cfg.AtxValidation.CPUShare, "atx-validation-cpu-share",
+		cfg.AtxValidation.CPUShare, "fraction of cpus used to validate received atxs concurrently")
+	flagSet.IntVar(&cfg.AtxValidation.DependencyDepth, "atx-validation-dependency-depth",
+		cfg.AtxValidation.DependencyDepth, "number of referenced atxs in a chain fetched while handling a received atx")
+	flagSet.IntVar(&cfg.AtxValidation.QuarantineSize, "atx-validation-quarantine-size",
+		cfg.AtxValidation.QuarantineSize, "number of atxs with missing references kept until the references arrive")
+	flagSet.StringSliceVar(&cfg.PostVerifier.Endpoints, "post-verifier-endpoints",
+		cfg.PostVerifier.Endpoints, "addresses of the trusted remote verifiers of post proofs, format: <IP>:<PORT>")
+	flagSet.IntVar(&cfg.EventsJournalSize, "events-journal-size",
+		cfg.EventsJournalSize, "number of recent user events persisted for replay, 0 disables the journal")
+	flagSet.DurationVar(&cfg.SignatureBatchWindow, "signature-batch-window",
+		cfg.SignatureBatchWindow, "time to collect signatures for batch verification, 0 verifies them one by one")
+	flagSet.IntVar(&cfg.SignatureBatchSize, "signature-batch-size",
+		cfg.SignatureBatchSize, "max number of signatures verified in a single batch")
+	flagSet.Uint32Var(&cfg.AccountsRetention, "accounts-retention",
+		cfg.AccountsRetention, "number of layers for which historical account states are kept, 0 keeps all")
+	flagSet.BoolVar(&cfg.CertificateArchive, "certificate-archive",
+		cfg.CertificateArchive, "keep block certificates for all layers to serve them to light clients")
+	flagSet.BoolVar(&cfg.FETCH.ServeLight, "serve-light",
+		cfg.FETCH.ServeLight, "serve layer certificates, aggregated hashes and atx headers to light clients")
+	flagSet.BoolVar(&cfg.SigningGuard, "signing-guard",
+		cfg.SigningGuard, "refuse to sign ballots for identities that are used by another instance")
+	flagSet.StringVar(&cfg.SigningLeaseDir, "signing-lease-dir",
+		cfg.SigningLeaseDir, "directory with leases of identities, shared with standby instances")
+	flagSet.DurationVar(&cfg.SigningLockout, "signing-lockout",
+		cfg.SigningLockout, "time to refuse signing after a ballot of the identity from another instance is received")
 
 	flagSet.IntVar(&cfg.TxsPerProposal, "txs-per-proposal",
 		cfg.TxsPerProposal, "the number of transactions to select per proposal")
@@ -69,6 +126,12 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.BlockGasLimit, "max gas allowed per block")
 	flagSet.IntVar(&cfg.OptFilterThreshold, "optimistic-filtering-threshold",
 		cfg.OptFilterThreshold, "threshold for optimistic filtering in percentage")
+	flagSet.StringSliceVar(&cfg.PriorityAddresses, "priority-addresses",
+		cfg.PriorityAddresses, "addresses whose transactions are packed first into the node's own proposals")
+	flagSet.StringSliceVar(&cfg.PriorityTXs, "priority-txs",
+		cfg.PriorityTXs, "hex encoded ids of transactions that are packed first into the node's own proposals")
+	flagSet.Uint8Var(&cfg.PriorityGasShare, "priority-gas-share",
+		cfg.PriorityGasShare, "percentage of the block gas limit that can be used by prioritized transactions")
 
 	flagSet.IntVar(&cfg.DatabaseConnections, "db-connections",
 		cfg.DatabaseConnections, "configure number of active connections to enable parallel read requests")
@@ -76,6 +139,16 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.DatabaseLatencyMetering, "if enabled collect latency histogram for every database query")
 	flagSet.DurationVar(&cfg.DatabasePruneInterval, "db-prune-interval",
 		cfg.DatabasePruneInterval, "configure interval for database pruning")
+	flagSet.StringVar(&cfg.DatabasePragmas.JournalMode, "db-journal-mode",
+		cfg.DatabasePragmas.JournalMode, "journal mode of the databases: wal, delete, truncate or persist")
+	flagSet.StringVar(&cfg.DatabasePragmas.Synchronous, "db-synchronous",
+		cfg.DatabasePragmas.Synchronous, "synchronous level of the databases: off, normal, full or extra")
+	flagSet.IntVar(&cfg.DatabasePragmas.WALAutocheckpoint, "db-wal-autocheckpoint",
+		cfg.DatabasePragmas.WALAutocheckpoint, "number of pages in the write-ahead log after which it is checkpointed")
+	flagSet.DurationVar(&cfg.DatabasePragmas.BusyTimeout, "db-busy-timeout",
+		cfg.DatabasePragmas.BusyTimeout, "time to wait for the database lock before failing the query")
+	flagSet.IntVar(&cfg.DatabasePragmas.CacheSize, "db-cache-size",
+		cfg.DatabasePragmas.CacheSize, "size of the page cache of every database connection in KiB")
 
 	flagSet.BoolVar(&cfg.NoMainOverride, "no-main-override",
 		cfg.NoMainOverride, "force 'nomain' builds to run on the mainnet")
@@ -133,6 +206,10 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		"enable routing discovery")
 	flagSet.BoolVar(&cfg.P2P.RoutingDiscoveryAdvertise, "routing-discovery-advertise",
 		cfg.P2P.RoutingDiscoveryAdvertise, "advertise for routing discovery")
+	flagSet.BoolVar(&cfg.P2P.DialRanker.Enable, "p2p-dial-ranker", cfg.P2P.DialRanker.Enable,
+		"dial addresses that connected recently first, and addresses or ip families that keep failing last")
+	flagSet.DurationVar(&cfg.P2P.DialRanker.FailedDelay, "p2p-dial-failed-delay", cfg.P2P.DialRanker.FailedDelay,
+		"delay of the dials to the addresses that failed recently")
 
 	/** ======================== TIME Flags ========================== **/
 
@@ -150,6 +227,18 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.TIME.Peersync.MaxOffsetErrors, "the node will exit when max number of consecutive offset errors will be reached")
 	flagSet.IntVar(&cfg.TIME.Peersync.RequiredResponses, "peersync-required-responses",
 		cfg.TIME.Peersync.RequiredResponses, "min number of clock samples fetched from others to verify time")
+	flagSet.BoolVar(&cfg.TIME.NTP.Disable, "ntp-disable", cfg.TIME.NTP.Disable,
+		"disable monitoring of the local clock offset from ntp servers")
+	flagSet.StringSliceVar(&cfg.TIME.NTP.Servers, "ntp-servers", cfg.TIME.NTP.Servers,
+		"ntp servers used to monitor the local clock offset")
+	flagSet.Float64Var(&cfg.TIME.NTP.MaxOffsetRatio, "ntp-max-offset-ratio", cfg.TIME.NTP.MaxOffsetRatio,
+		"warn if the local clock offset from ntp servers exceeds this fraction of the layer duration")
+	flagSet.StringVar(&cfg.TIME.Source.Mode, "clock-source", cfg.TIME.Source.Mode,
+		"source of time for the layer clock: real, accelerated or stepped (for integration environments only)")
+	flagSet.Float64Var(&cfg.TIME.Source.Speed, "clock-speed", cfg.TIME.Source.Speed,
+		"how many times the accelerated clock is faster than the system clock")
+	flagSet.StringVar(&cfg.TIME.Source.Start, "clock-start", cfg.TIME.Source.Start,
+		"time (RFC3339) at which the accelerated or stepped clock starts, current time if empty")
 
 	/** ======================== API Flags ========================== **/
 
@@ -175,6 +264,22 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.API.GrpcSendMsgSize, "GRPC api send message size")
 	flagSet.StringVar(&cfg.API.JSONListener, "grpc-json-listener",
 		cfg.API.JSONListener, "(Optional) endpoint to expose public grpc services via HTTP/JSON.")
+	flagSet.StringSliceVar(&cfg.API.JSONServices, "grpc-json-services",
+		cfg.API.JSONServices, "List of services to be exposed via HTTP/JSON, public services if empty.")
+	flagSet.BoolVar(&cfg.API.Auth.Enabled, "grpc-auth-enabled",
+		cfg.API.Auth.Enabled, "Enforce role based authorization on the public, private and TLS grpc listeners.")
+	flagSet.StringVar((*string)(&cfg.API.Auth.DefaultRole), "grpc-auth-default-role",
+		string(cfg.API.Auth.DefaultRole), "Role granted to grpc callers without credentials (none, read, wallet or admin).")
+	flagSet.BoolVar(&cfg.API.RateLimit.Enabled, "grpc-rate-limit-enabled",
+		cfg.API.RateLimit.Enabled, "Enforce quotas of requests on the public grpc listener and the json gateway.")
+	flagSet.Float64Var(&cfg.API.RateLimit.Rate, "grpc-rate-limit-rate",
+		cfg.API.RateLimit.Rate, "Number of requests per second allowed for a single ip address.")
+	flagSet.IntVar(&cfg.API.RateLimit.Burst, "grpc-rate-limit-burst",
+		cfg.API.RateLimit.Burst, "Number of requests that a single ip address can make at once.")
+	flagSet.IntVar(&cfg.API.Health.MinPeers, "grpc-health-min-peers",
+		cfg.API.Health.MinPeers, "Number of peers below which the health service reports the node as degraded.")
+	flagSet.DurationVar(&cfg.API.Health.CheckTimeout, "grpc-health-check-timeout",
+		cfg.API.Health.CheckTimeout, "Time limit for checking the health of all node subsystems.")
 
 	/**======================== Hare Eligibility Oracle Flags ========================== **/
 
@@ -206,6 +311,8 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.Beacon.VotesLimit, "Maximum allowed number of votes to be sent")
 	flagSet.IntVar(&cfg.Beacon.BeaconSyncWeightUnits, "beacon-sync-weight-units",
 		cfg.Beacon.BeaconSyncWeightUnits, "Numbers of weight units to wait before determining beacon values from them.")
+	flagSet.BoolVar(&cfg.Beacon.UnsafeInjection, "beacon-unsafe-injection",
+		cfg.Beacon.UnsafeInjection, "allow to inject beacon values with the admin api. only for private networks")
 
 	/**======================== Tortoise Flags ========================== **/
 	flagSet.Uint32Var(&cfg.Tortoise.Hdist, "tortoise-hdist",
@@ -255,6 +362,13 @@ func AddFlags(flagSet *pflag.FlagSet, cfg *config.Config) (configPath *string) {
 		cfg.SMESHING.Start, "")
 	flagSet.StringVar(&cfg.SMESHING.CoinbaseAccount, "smeshing-coinbase",
 		cfg.SMESHING.CoinbaseAccount, "coinbase account to accumulate rewards")
+	flagSet.StringVar((*string)(&cfg.SMESHING.LatePublish), "smeshing-late-publish",
+		string(cfg.SMESHING.LatePublish),
+		"what to do with the nipost challenge that expired before the atx was published: "+
+			"skip (build a new challenge) or target-next (retarget the challenge to the next epoch)")
+	flagSet.Uint32Var(&cfg.SMESHING.VRFKey, "smeshing-vrf-key",
+		cfg.SMESHING.VRFKey,
+		"index of the vrf key derived from the identity key, increase it to rotate the vrf keys of the identities")
 	flagSet.StringVar(&cfg.SMESHING.Opts.DataDir, "smeshing-opts-datadir",
 		cfg.SMESHING.Opts.DataDir, "")
 	flagSet.Uint32Var(&cfg.SMESHING.Opts.NumUnits, "smeshing-opts-numunits",
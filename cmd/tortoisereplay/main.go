@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spacemeshos/go-spacemesh/atxsdata"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/config"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+)
+
+var (
+	level            = zap.LevelFlag("level", zapcore.ErrorLevel, "set verbosity level for execution")
+	from             = flag.Uint("from", 0, "replay from the epoch of this layer. zero replays all layers")
+	effectiveGenesis = flag.Uint("effective-genesis", 0, "effective genesis after checkpoint recovery, if any")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Println(`Usage:
+	> tortoisereplay [flags] <state db path>
+Example:
+	replay tortoise with mainnet parameters from the data in state.sql file and compare results with stored validity.
+	> tortoisereplay -from 20000 state.sql`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dbpath := flag.Arg(0)
+	if len(dbpath) == 0 {
+		must(errors.New("dbpath is empty"), "dbpath is empty\n")
+	}
+	cfg := config.MainnetConfig()
+	types.SetLayersPerEpoch(cfg.LayersPerEpoch)
+	if *effectiveGenesis != 0 {
+		types.SetEffectiveGenesis(uint32(*effectiveGenesis))
+	}
+	db, err := sql.Open(fmt.Sprintf("file:%s?mode=ro", dbpath))
+	must(err, "can't open db at dbpath=%v. err=%s\n", dbpath, err)
+	defer db.Close()
+
+	start := types.LayerID(*from)
+	applied, err := layers.GetLastApplied(db)
+	must(err, "get last applied layer. dbpath=%v. err=%s\n", dbpath, err)
+	capacity := types.EpochID(0)
+	if applied.GetEpoch() > start.GetEpoch() {
+		capacity = applied.GetEpoch() - start.GetEpoch()
+	}
+	// capacity is extended by an epoch, so that atxs targeting the first replayed epoch are not evicted
+	atxdata, err := atxsdata.Warm(db, atxsdata.WithCapacity(capacity+1))
+	must(err, "load atxs. dbpath=%v. err=%s\n", dbpath, err)
+
+	trtlCfg := cfg.Tortoise
+	trtlCfg.LayerSize = cfg.LayerAvgSize
+	logger := log.NewWithLevel("replay", zap.NewAtomicLevelAt(*level))
+	rst, err := tortoise.Replay(context.Background(), db, atxdata, start,
+		tortoise.WithConfig(trtlCfg),
+		tortoise.WithLogger(logger),
+	)
+	must(err, "replay. dbpath=%v. err=%s\n", dbpath, err)
+	for _, mismatch := range rst.Mismatches {
+		fmt.Println(mismatch)
+	}
+	fmt.Printf("replayed = %d-%d\nverified = %d\nstored verified = %d\nmismatches = %d\n",
+		rst.First, rst.Last, rst.Verified, rst.StoredVerified, len(rst.Mismatches))
+	if !rst.Consistent() {
+		os.Exit(1)
+	}
+}
+
+func must(err error, msg string, vars ...any) {
+	if err != nil {
+		fmt.Printf(msg, vars...)
+		fmt.Println("")
+		flag.Usage()
+		os.Exit(1)
+	}
+}
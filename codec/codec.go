@@ -10,7 +10,11 @@ import (
 	"github.com/spacemeshos/go-scale"
 )
 
-var ErrShortRead = errors.New("decode from buffer: not all bytes were consumed")
+var (
+	ErrShortRead = errors.New("decode from buffer: not all bytes were consumed")
+	// ErrTooLarge is returned if the buffer is larger than the limit of the decoded value.
+	ErrTooLarge = errors.New("decode from buffer: buffer exceeds the limit")
+)
 
 // Encodable is an interface that must be implemented by a struct to be encoded.
 type Encodable = scale.Encodable
@@ -96,6 +100,15 @@ func Decode(buf []byte, value Decodable) error {
 	return nil
 }
 
+// DecodeWithLimit decodes value from a byte buffer that is not larger than limit.
+// Buffers received from the network that can't hold a valid value are rejected before decoding.
+func DecodeWithLimit(buf []byte, value Decodable, limit int) error {
+	if len(buf) > limit {
+		return fmt.Errorf("%w: %d > %d", ErrTooLarge, len(buf), limit)
+	}
+	return Decode(buf, value)
+}
+
 // EncodeSlice encodes slice to a buffer.
 func EncodeSlice[V any, H scale.EncodablePtr[V]](value []V) ([]byte, error) {
 	var b bytes.Buffer
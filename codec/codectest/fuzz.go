@@ -0,0 +1,66 @@
+// Package codectest provides fuzz targets for decoders of the values received from the network.
+package codectest
+
+import (
+	"bytes"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/spacemeshos/go-scale"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+)
+
+// Seeds encodes n values of T filled by the fuzzer with the seed. Seeds are the same in every run,
+// so that the corpus of the fuzz target is mutated from the same valid encodings.
+// Funcs are the custom fuzz functions for the types that the fuzzer can't fill, such as interfaces.
+func Seeds[T any, H scale.TypePtr[T]](tb testing.TB, n int, seed int64, funcs ...any) [][]byte {
+	tb.Helper()
+	fuzzer := fuzz.NewWithSeed(seed).NilChance(0.2).Funcs(funcs...)
+	rst := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		var object T
+		fuzzer.Fuzz(&object)
+		buf, err := codec.Encode(H(&object))
+		require.NoError(tb, err)
+		rst = append(rst, buf)
+	}
+	return rst
+}
+
+// FuzzDecode decodes the data with the decode function and checks that the decoded value is encoded canonically:
+// encoding and decoding it again must produce the same value and the same bytes.
+// Decode is expected to be the function that is used for the data received from the network.
+func FuzzDecode[T any, H scale.TypePtr[T]](f *testing.F, decode func([]byte, *T) error, seeds ...[]byte) {
+	f.Add([]byte{})
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded T
+		if err := decode(data, &decoded); err != nil {
+			return
+		}
+		encoded, err := codec.Encode(H(&decoded))
+		require.NoError(t, err, "decoded value must be encodable")
+
+		var again T
+		require.NoError(t, codec.Decode(encoded, H(&again)), "encoded value must be decodable")
+		reencoded, err := codec.Encode(H(&again))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(encoded, reencoded), "encoding must be stable")
+	})
+}
+
+// Decode is the default decode function for FuzzDecode.
+func Decode[T any, H scale.TypePtr[T]](data []byte, value *T) error {
+	return codec.Decode(data, H(value))
+}
+
+// DecodeWithLimit returns the decode function for FuzzDecode that rejects data larger than limit.
+func DecodeWithLimit[T any, H scale.TypePtr[T]](limit int) func([]byte, *T) error {
+	return func(data []byte, value *T) error {
+		return codec.DecodeWithLimit(data, H(value), limit)
+	}
+}
@@ -193,7 +193,9 @@ type ActivationTx struct {
 	SmesherID NodeID
 	Signature EdSignature
 
-	golden bool
+	golden  bool
+	vrfKey  *NodeID
+	version AtxVersion
 }
 
 // NewActivationTx returns a new activation transaction. The ATXID is calculated and cached.
@@ -241,7 +243,11 @@ func (atx *ActivationTx) SignedBytes() []byte {
 }
 
 // HashInnerBytes returns a byte slice of the serialization of the inner ATX (excluding the signature field).
+// The inner ATX is serialized in the wire version of the ATX.
 func (atx *ActivationTx) HashInnerBytes() []byte {
+	if atx.Version() == AtxV2 {
+		return atx.hashInnerV2()
+	}
 	h := hash.New()
 	_, err := codec.EncodeTo(h, &atx.InnerActivationTx)
 	if err != nil {
@@ -263,6 +269,9 @@ func (atx *ActivationTx) MarshalLogObject(encoder log.ObjectEncoder) error {
 	if atx.VRFNonce != nil {
 		encoder.AddUint64("vrf_nonce", uint64(*atx.VRFNonce))
 	}
+	if atx.vrfKey != nil {
+		encoder.AddString("vrf_key", atx.vrfKey.String())
+	}
 	encoder.AddString("coinbase", atx.Coinbase.String())
 	encoder.AddUint32("epoch", atx.PublishEpoch.Uint32())
 	encoder.AddUint64("num_units", uint64(atx.NumUnits))
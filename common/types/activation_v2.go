@@ -0,0 +1,230 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+//go:generate scalegen
+
+// AtxVersion is the wire version of the activation transaction.
+type AtxVersion uint
+
+const (
+	// AtxV1 is the original version of the activation transaction.
+	AtxV1 AtxVersion = 1
+	// AtxV2 drops the node id from the initial atx, the smesher id is used instead.
+	AtxV2 AtxVersion = 2
+	// AtxVMax is the latest supported version.
+	AtxVMax = AtxV2
+)
+
+// AtxVersions maps the first publish epoch of the version to the version.
+// Atxs published before the first configured epoch are of the first version.
+type AtxVersions map[EpochID]AtxVersion
+
+// Validate checks that the versions are supported and don't decrease with epochs.
+func (v AtxVersions) Validate() error {
+	epochs := maps.Keys(v)
+	slices.Sort(epochs)
+	last := AtxV1
+	for _, epoch := range epochs {
+		version := v[epoch]
+		if version < AtxV1 || version > AtxVMax {
+			return fmt.Errorf("atx version %d in epoch %d is not supported", version, epoch)
+		}
+		if version < last {
+			return fmt.Errorf("atx version %d in epoch %d is lower than %d", version, epoch, last)
+		}
+		last = version
+	}
+	return nil
+}
+
+// Version returns the version of the atxs published in the epoch.
+func (v AtxVersions) Version(publish EpochID) AtxVersion {
+	version, first := AtxV1, EpochID(0)
+	for epoch, candidate := range v {
+		if epoch <= publish && epoch >= first {
+			version, first = candidate, epoch
+		}
+	}
+	return version
+}
+
+// InnerActivationTxV2 is the signed part of the second version of the activation transaction.
+// Publish epoch is the first field in all versions, the version of the encoded atx is selected by it.
+type InnerActivationTxV2 struct {
+	PublishEpoch   EpochID
+	Sequence       uint64
+	PrevATXID      ATXID
+	PositioningATX ATXID
+
+	CommitmentATX *ATXID
+	InitialPost   *Post
+
+	Coinbase Address
+	NumUnits uint32
+	NIPost   *NIPost
+	VRFNonce *VRFPostIndex
+	// VRFKey is the rotated vrf key of the identity, it replaces the previous key from the target epoch.
+	// Empty if the atx doesn't rotate the key. The key is not optional, so that the encoding of the second
+	// version is never a valid encoding of the first version.
+	VRFKey NodeID
+}
+
+// ActivationTxV2 is the wire format of the second version of the activation transaction.
+type ActivationTxV2 struct {
+	InnerActivationTxV2
+
+	SmesherID NodeID
+	Signature EdSignature
+}
+
+// ActivationTx converts the atx into the version independent representation.
+func (atx *ActivationTxV2) ActivationTx() *ActivationTx {
+	var vrfKey *NodeID
+	if atx.VRFKey != EmptyNodeID {
+		vrfKey = &atx.VRFKey
+	}
+	return &ActivationTx{
+		InnerActivationTx: InnerActivationTx{
+			NIPostChallenge: NIPostChallenge{
+				PublishEpoch:   atx.PublishEpoch,
+				Sequence:       atx.Sequence,
+				PrevATXID:      atx.PrevATXID,
+				PositioningATX: atx.PositioningATX,
+				CommitmentATX:  atx.CommitmentATX,
+				InitialPost:    atx.InitialPost,
+			},
+			Coinbase: atx.Coinbase,
+			NumUnits: atx.NumUnits,
+			NIPost:   atx.NIPost,
+			VRFNonce: atx.VRFNonce,
+		},
+		SmesherID: atx.SmesherID,
+		Signature: atx.Signature,
+		vrfKey:    vrfKey,
+		version:   AtxV2,
+	}
+}
+
+// Version returns the wire version of the atx.
+func (atx *ActivationTx) Version() AtxVersion {
+	if atx.version == 0 {
+		return AtxV1
+	}
+	return atx.version
+}
+
+// SetVersion sets the wire version of the atx, it must be set before the atx is signed.
+func (atx *ActivationTx) SetVersion(version AtxVersion) {
+	atx.version = version
+}
+
+// VRFKey returns the rotated vrf key declared by the atx, nil if the atx doesn't rotate the key.
+func (atx *ActivationTx) VRFKey() *NodeID {
+	return atx.vrfKey
+}
+
+// SetVRFKey sets the rotated vrf key, it must be set before the atx is signed.
+// Only atxs of the second version can rotate the key.
+func (atx *ActivationTx) SetVRFKey(key *NodeID) {
+	atx.vrfKey = key
+}
+
+// ToV2 converts the atx into the wire format of the second version.
+// The node id of the first version is not included.
+func (atx *ActivationTx) ToV2() *ActivationTxV2 {
+	return &ActivationTxV2{
+		InnerActivationTxV2: atx.innerV2(),
+		SmesherID:           atx.SmesherID,
+		Signature:           atx.Signature,
+	}
+}
+
+func (atx *ActivationTx) innerV2() InnerActivationTxV2 {
+	var vrfKey NodeID
+	if atx.vrfKey != nil {
+		vrfKey = *atx.vrfKey
+	}
+	return InnerActivationTxV2{
+		PublishEpoch:   atx.PublishEpoch,
+		Sequence:       atx.Sequence,
+		PrevATXID:      atx.PrevATXID,
+		PositioningATX: atx.PositioningATX,
+		CommitmentATX:  atx.CommitmentATX,
+		InitialPost:    atx.InitialPost,
+		Coinbase:       atx.Coinbase,
+		NumUnits:       atx.NumUnits,
+		NIPost:         atx.NIPost,
+		VRFNonce:       atx.VRFNonce,
+		VRFKey:         vrfKey,
+	}
+}
+
+// hashInnerV2 returns the hash of the signed part of the second version.
+func (atx *ActivationTx) hashInnerV2() []byte {
+	h := hash.New()
+	inner := atx.innerV2()
+	if _, err := codec.EncodeTo(h, &inner); err != nil {
+		panic(fmt.Sprintf("failed to encode InnerActivationTxV2 for hashing: %v", err))
+	}
+	return h.Sum(nil)
+}
+
+// Blob returns the encoding of the atx in its wire version.
+func (atx *ActivationTx) Blob() ([]byte, error) {
+	switch atx.Version() {
+	case AtxV1:
+		if atx.vrfKey != nil {
+			return nil, errors.New("atx of the first version can't rotate the vrf key")
+		}
+		return codec.Encode(atx)
+	case AtxV2:
+		return codec.Encode(atx.ToV2())
+	default:
+		return nil, fmt.Errorf("atx version %d is not supported", atx.Version())
+	}
+}
+
+// MaxAtxSize is the upper bound for the size of the encoded atx of any version.
+// The size is dominated by the limits of the variable size fields: indices of the initial post and the post
+// in nipost (8000 bytes each) and the nodes of the membership proof (32 hashes).
+const MaxAtxSize = 64 << 10
+
+// AtxPublishEpoch decodes the publish epoch of the encoded atx of any version.
+func AtxPublishEpoch(data []byte) (EpochID, error) {
+	var publish EpochID
+	if _, err := codec.DecodeFrom(bytes.NewReader(data), &publish); err != nil {
+		return 0, fmt.Errorf("decode publish epoch: %w", err)
+	}
+	return publish, nil
+}
+
+// DecodeAtx decodes the atx encoded in the version. Data larger than MaxAtxSize is rejected without decoding.
+func DecodeAtx(version AtxVersion, data []byte) (*ActivationTx, error) {
+	switch version {
+	case AtxV1:
+		var atx ActivationTx
+		if err := codec.DecodeWithLimit(data, &atx, MaxAtxSize); err != nil {
+			return nil, err
+		}
+		return &atx, nil
+	case AtxV2:
+		var atx ActivationTxV2
+		if err := codec.DecodeWithLimit(data, &atx, MaxAtxSize); err != nil {
+			return nil, err
+		}
+		return atx.ActivationTx(), nil
+	default:
+		return nil, errors.New("unsupported atx version")
+	}
+}
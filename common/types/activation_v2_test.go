@@ -0,0 +1,126 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-scale/tester"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/codec/codectest"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestAtxVersions(t *testing.T) {
+	versions := types.AtxVersions{4: types.AtxV2}
+	require.NoError(t, versions.Validate())
+	require.Equal(t, types.AtxV1, versions.Version(0))
+	require.Equal(t, types.AtxV1, versions.Version(3))
+	require.Equal(t, types.AtxV2, versions.Version(4))
+	require.Equal(t, types.AtxV2, versions.Version(10))
+	require.Equal(t, types.AtxV1, types.AtxVersions(nil).Version(10))
+
+	require.Error(t, types.AtxVersions{4: types.AtxVMax + 1}.Validate())
+	require.Error(t, types.AtxVersions{4: 0}.Validate())
+	require.Error(t, types.AtxVersions{2: types.AtxV2, 4: types.AtxV1}.Validate())
+}
+
+func TestActivationTxV2(t *testing.T) {
+	nodeID := types.RandomNodeID()
+	atx := types.NewActivationTx(types.NIPostChallenge{
+		PublishEpoch:   3,
+		PositioningATX: types.RandomATXID(),
+		CommitmentATX:  &types.ATXID{1},
+		InitialPost:    &types.Post{Nonce: 1},
+	}, types.Address{1}, &types.NIPost{}, 2, nil)
+	atx.InnerActivationTx.NodeID = &nodeID
+	atx.SmesherID = nodeID
+	atx.Signature = types.RandomEdSignature()
+	v1Bytes := atx.SignedBytes()
+	require.NoError(t, atx.Initialize())
+	v1ID := atx.ID()
+
+	atx.SetVersion(types.AtxV2)
+	atx.SetID(types.EmptyATXID)
+	require.NotEqual(t, v1Bytes, atx.SignedBytes())
+	require.NoError(t, atx.Initialize())
+	require.NotEqual(t, v1ID, atx.ID())
+
+	data, err := atx.Blob()
+	require.NoError(t, err)
+	publish, err := types.AtxPublishEpoch(data)
+	require.NoError(t, err)
+	require.Equal(t, atx.PublishEpoch, publish)
+
+	decoded, err := types.DecodeAtx(types.AtxV2, data)
+	require.NoError(t, err)
+	require.Equal(t, types.AtxV2, decoded.Version())
+	require.Nil(t, decoded.InnerActivationTx.NodeID)
+	require.NoError(t, decoded.Initialize())
+	require.Equal(t, atx.ID(), decoded.ID())
+	require.Equal(t, atx.SignedBytes(), decoded.SignedBytes())
+
+	_, err = types.DecodeAtx(types.AtxV1, data)
+	require.Error(t, err)
+}
+
+func TestActivationTxV2VRFKey(t *testing.T) {
+	atx := types.NewActivationTx(types.NIPostChallenge{
+		PublishEpoch:   3,
+		PositioningATX: types.RandomATXID(),
+	}, types.Address{1}, &types.NIPost{}, 2, nil)
+	atx.SmesherID = types.RandomNodeID()
+	key := types.RandomNodeID()
+	atx.SetVRFKey(&key)
+
+	_, err := atx.Blob()
+	require.Error(t, err)
+
+	atx.SetVersion(types.AtxV2)
+	require.NoError(t, atx.Initialize())
+	data, err := atx.Blob()
+	require.NoError(t, err)
+	decoded, err := types.DecodeAtx(types.AtxV2, data)
+	require.NoError(t, err)
+	require.Equal(t, &key, decoded.VRFKey())
+	require.NoError(t, decoded.Initialize())
+	require.Equal(t, atx.ID(), decoded.ID())
+
+	atx.SetVRFKey(nil)
+	atx.SetID(types.EmptyATXID)
+	require.NoError(t, atx.Initialize())
+	require.NotEqual(t, decoded.ID(), atx.ID())
+}
+
+func FuzzActivationTxV2Consistency(f *testing.F) {
+	tester.FuzzConsistency[types.ActivationTxV2](f)
+}
+
+func FuzzActivationTxV2StateSafety(f *testing.F) {
+	tester.FuzzSafety[types.ActivationTxV2](f)
+}
+
+func FuzzActivationTxDecode(f *testing.F) {
+	codectest.FuzzDecode(f, func(data []byte, atx *types.ActivationTx) error {
+		decoded, err := types.DecodeAtx(types.AtxV1, data)
+		if err != nil {
+			return err
+		}
+		*atx = *decoded
+		return nil
+	}, codectest.Seeds[types.ActivationTx](f, 20, 1001)...)
+}
+
+func FuzzActivationTxV2Decode(f *testing.F) {
+	codectest.FuzzDecode(f, func(data []byte, atx *types.ActivationTxV2) error {
+		return codec.DecodeWithLimit(data, atx, types.MaxAtxSize)
+	}, codectest.Seeds[types.ActivationTxV2](f, 20, 1001)...)
+}
+
+func TestDecodeAtxLimit(t *testing.T) {
+	data := make([]byte, types.MaxAtxSize+1)
+	for _, version := range []types.AtxVersion{types.AtxV1, types.AtxV2} {
+		_, err := types.DecodeAtx(version, data)
+		require.ErrorIs(t, err, codec.ErrTooLarge)
+	}
+}
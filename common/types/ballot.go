@@ -46,6 +46,12 @@ func (id *BallotID) UnmarshalText(buf []byte) error {
 	return util.Base64Decode(id[:], buf)
 }
 
+// MaxBallotSize is the upper bound for the size of the encoded ballot received from peers.
+// Votes and eligibility proofs take less than 3 MiB at their limits, the remainder leaves room
+// for the active sets that are still embedded in the ballots served by peers that didn't move them
+// into the activesets table.
+const MaxBallotSize = 16 << 20
+
 // Ballot contains the smeshers signed vote on the mesh history.
 type Ballot struct {
 	InnerBallot
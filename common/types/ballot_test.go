@@ -6,6 +6,7 @@ import (
 	"github.com/spacemeshos/go-scale/tester"
 	"github.com/stretchr/testify/require"
 
+	"github.com/spacemeshos/go-spacemesh/codec/codectest"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/signing"
 )
@@ -100,6 +101,11 @@ func FuzzVotingEligibilityProofSafety(f *testing.F) {
 	tester.FuzzSafety[types.VotingEligibility](f)
 }
 
+func FuzzBallotDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.DecodeWithLimit[types.Ballot](types.MaxBallotSize),
+		codectest.Seeds[types.Ballot](f, 20, 1001)...)
+}
+
 func TestBallotEncoding(t *testing.T) {
 	types.CheckLayerFirstEncoding(t, func(object types.Ballot) types.LayerID { return object.Layer })
 }
@@ -7,9 +7,21 @@ type Checkpoint struct {
 }
 
 type InnerData struct {
-	CheckpointId string            `json:"id"`
-	Atxs         []AtxSnapshot     `json:"atxs"`
-	Accounts     []AccountSnapshot `json:"accounts"`
+	CheckpointId string `json:"id"`
+	// Base is set for incremental checkpoints, that contain only changes since the base checkpoint.
+	Base *CheckpointBase `json:"base,omitempty"`
+	Atxs []AtxSnapshot   `json:"atxs"`
+	// RemovedAtxs are ids of atxs from the base checkpoint that are not part of this checkpoint.
+	RemovedAtxs [][]byte          `json:"removedAtxs,omitempty"`
+	Accounts    []AccountSnapshot `json:"accounts"`
+}
+
+// CheckpointBase references the checkpoint that incremental checkpoint is based on.
+type CheckpointBase struct {
+	ID string `json:"id"`
+	// Hash of the base checkpoint file. Base can be incremental as well,
+	// so that hashes bind the whole chain of checkpoints.
+	Hash []byte `json:"hash"`
 }
 
 type AtxSnapshot struct {
@@ -17,6 +29,7 @@ type AtxSnapshot struct {
 	Epoch          uint32 `json:"epoch"`
 	CommitmentAtx  []byte `json:"commitmentAtx"`
 	VrfNonce       uint64 `json:"vrfNonce"`
+	VrfKey         []byte `json:"vrfKey,omitempty"`
 	NumUnits       uint32 `json:"numUnits"`
 	BaseTickHeight uint64 `json:"baseTickHeight"`
 	TickCount      uint64 `json:"tickCount"`
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// GoldenATX is the reference point of the atx chain for the atxs published starting from the epoch.
+type GoldenATX struct {
+	Epoch EpochID
+	ID    ATXID
+}
+
+// GoldenATXs is the set of golden atxs. The golden atx derived from the genesis is used from the first epoch,
+// network upgrades that reset the atx chain add golden atxs that replace it starting from the upgrade epoch.
+// The set is safe for concurrent use, golden atxs of the upcoming upgrades may be added while the node runs.
+type GoldenATXs struct {
+	mu     sync.RWMutex
+	golden []GoldenATX // sorted by epoch
+}
+
+// NewGoldenATXs creates the set with the golden atx derived from the genesis.
+func NewGoldenATXs(genesis ATXID) *GoldenATXs {
+	return &GoldenATXs{golden: []GoldenATX{{ID: genesis}}}
+}
+
+// Add adds the golden atx used from the epoch. Adding the same golden atx again is a no-op,
+// replacing the golden atx of the epoch with a different one is an error.
+func (g *GoldenATXs) Add(epoch EpochID, id ATXID) error {
+	if id == EmptyATXID {
+		return fmt.Errorf("empty golden atx for epoch %d", epoch)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := sort.Search(len(g.golden), func(i int) bool { return g.golden[i].Epoch >= epoch })
+	if i < len(g.golden) && g.golden[i].Epoch == epoch {
+		if g.golden[i].ID != id {
+			return fmt.Errorf("golden atx for epoch %d is already set to %s", epoch, g.golden[i].ID.ShortString())
+		}
+		return nil
+	}
+	g.golden = append(g.golden, GoldenATX{})
+	copy(g.golden[i+1:], g.golden[i:])
+	g.golden[i] = GoldenATX{Epoch: epoch, ID: id}
+	return nil
+}
+
+// ForEpoch returns the golden atx for the atxs published in the epoch.
+func (g *GoldenATXs) ForEpoch(epoch EpochID) GoldenATX {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	i := sort.Search(len(g.golden), func(i int) bool { return g.golden[i].Epoch > epoch })
+	return g.golden[max(i-1, 0)]
+}
+
+// Valid returns true if the id is the golden atx used in the epoch or in any of the previous epochs.
+// Commitment atxs remain valid after the upgrade, as they are bound to the initialized post data.
+// It is safe to call on the nil set.
+func (g *GoldenATXs) Valid(id ATXID, epoch EpochID) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, golden := range g.golden {
+		if golden.Epoch > epoch {
+			return false
+		}
+		if golden.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains returns true if the id is any of the golden atxs. Golden atxs are never stored or fetched.
+func (g *GoldenATXs) Contains(id ATXID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, golden := range g.golden {
+		if golden.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns the golden atxs sorted by epoch.
+func (g *GoldenATXs) All() []GoldenATX {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]GoldenATX(nil), g.golden...)
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenATXs(t *testing.T) {
+	genesis := ATXID{1}
+	first := ATXID{2}
+	second := ATXID{3}
+	golden := NewGoldenATXs(genesis)
+	require.NoError(t, golden.Add(20, second))
+	require.NoError(t, golden.Add(10, first))
+	require.NoError(t, golden.Add(10, first))
+	require.ErrorContains(t, golden.Add(10, second), "already set")
+	require.ErrorContains(t, golden.Add(0, first), "already set")
+	require.Error(t, golden.Add(30, EmptyATXID))
+
+	require.Equal(t, []GoldenATX{{0, genesis}, {10, first}, {20, second}}, golden.All())
+	require.Equal(t, GoldenATX{0, genesis}, golden.ForEpoch(9))
+	require.Equal(t, GoldenATX{10, first}, golden.ForEpoch(10))
+	require.Equal(t, GoldenATX{10, first}, golden.ForEpoch(19))
+	require.Equal(t, GoldenATX{20, second}, golden.ForEpoch(100))
+
+	require.True(t, golden.Valid(genesis, 25))
+	require.True(t, golden.Valid(first, 10))
+	require.False(t, golden.Valid(first, 9))
+	require.False(t, golden.Valid(ATXID{4}, 25))
+	require.True(t, golden.Contains(second))
+	require.False(t, golden.Contains(ATXID{4}))
+
+	var empty *GoldenATXs
+	require.False(t, empty.Valid(genesis, 0))
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spacemeshos/go-scale"
@@ -22,6 +24,105 @@ const (
 	InvalidPostIndex
 )
 
+// MalfeasanceProofData is the evidence of the misbehavior of the single type.
+type MalfeasanceProofData interface {
+	scale.Type
+	log.ObjectMarshaller
+
+	// Info returns human readable explanation of the misbehavior.
+	Info() string
+}
+
+// MalfeasanceProofType describes the encoding of the malfeasance proofs of the single type.
+type MalfeasanceProofType struct {
+	Type uint8
+	// Name of the misbehavior, e.g. "multiple atxs".
+	Name string
+	// Legacy proofs were introduced before the encoding was versioned.
+	// Their encoding doesn't include the version, and the version is always 0.
+	Legacy bool
+	// New returns an empty proof of the encoding version. It fails if the version is not supported.
+	New func(version uint8) (MalfeasanceProofData, error)
+}
+
+var malfeasanceProofTypes = struct {
+	sync.RWMutex
+	types map[uint8]MalfeasanceProofType
+}{types: map[uint8]MalfeasanceProofType{}}
+
+func init() {
+	RegisterMalfeasanceProof(MalfeasanceProofType{
+		Type:   MultipleATXs,
+		Name:   "multiple atxs",
+		Legacy: true,
+		New:    newLegacyProof[AtxProof],
+	})
+	RegisterMalfeasanceProof(MalfeasanceProofType{
+		Type:   MultipleBallots,
+		Name:   "multiple ballots",
+		Legacy: true,
+		New:    newLegacyProof[BallotProof],
+	})
+	RegisterMalfeasanceProof(MalfeasanceProofType{
+		Type:   HareEquivocation,
+		Name:   "hare equivocation",
+		Legacy: true,
+		New:    newLegacyProof[HareProof],
+	})
+	RegisterMalfeasanceProof(MalfeasanceProofType{
+		Type:   InvalidPostIndex,
+		Name:   "invalid post index",
+		Legacy: true,
+		New:    newLegacyProof[InvalidPostIndexProof],
+	})
+}
+
+func newLegacyProof[T any, P interface {
+	*T
+	MalfeasanceProofData
+}](version uint8) (MalfeasanceProofData, error) {
+	if version != 0 {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+	return P(new(T)), nil
+}
+
+// RegisterMalfeasanceProof registers the type of the malfeasance proof, so that it can be encoded and decoded.
+// It panics if the type is already registered, types are expected to be registered in init.
+func RegisterMalfeasanceProof(pt MalfeasanceProofType) {
+	if pt.Type == 0 || pt.New == nil {
+		panic(fmt.Sprintf("invalid malfeasance proof type %d (%s)", pt.Type, pt.Name))
+	}
+	malfeasanceProofTypes.Lock()
+	defer malfeasanceProofTypes.Unlock()
+	if registered, exists := malfeasanceProofTypes.types[pt.Type]; exists {
+		panic(fmt.Sprintf("malfeasance proof type %d already registered as %s", pt.Type, registered.Name))
+	}
+	malfeasanceProofTypes.types[pt.Type] = pt
+}
+
+// GetMalfeasanceProofType returns the registered type of the malfeasance proof.
+func GetMalfeasanceProofType(typ uint8) (MalfeasanceProofType, bool) {
+	malfeasanceProofTypes.RLock()
+	defer malfeasanceProofTypes.RUnlock()
+	pt, exists := malfeasanceProofTypes.types[typ]
+	return pt, exists
+}
+
+// MalfeasanceProofTypes returns all registered types of malfeasance proofs, ordered by type.
+func MalfeasanceProofTypes() []MalfeasanceProofType {
+	malfeasanceProofTypes.RLock()
+	defer malfeasanceProofTypes.RUnlock()
+	rst := make([]MalfeasanceProofType, 0, len(malfeasanceProofTypes.types))
+	for _, pt := range malfeasanceProofTypes.types {
+		rst = append(rst, pt)
+	}
+	slices.SortFunc(rst, func(a, b MalfeasanceProofType) int {
+		return int(a.Type) - int(b.Type)
+	})
+	return rst
+}
+
 type MalfeasanceProof struct {
 	// for network upgrade
 	Layer LayerID
@@ -40,55 +141,34 @@ func (mp *MalfeasanceProof) SetReceived(received time.Time) {
 
 func (mp *MalfeasanceProof) MarshalLogObject(encoder log.ObjectEncoder) error {
 	encoder.AddUint32("generated_layer", mp.Layer.Uint32())
-	switch mp.Proof.Type {
-	case MultipleATXs:
-		encoder.AddString("type", "multiple atxs")
-		p, ok := mp.Proof.Data.(*AtxProof)
-		if !ok {
-			encoder.AddString("msgs", "n/a")
-		} else {
-			encoder.AddObject("msgs", p)
-		}
-	case MultipleBallots:
-		encoder.AddString("type", "multiple ballots")
-		p, ok := mp.Proof.Data.(*BallotProof)
-		if !ok {
-			encoder.AddString("msgs", "n/a")
-		} else {
-			encoder.AddObject("msgs", p)
-		}
-	case HareEquivocation:
-		encoder.AddString("type", "hare equivocation")
-		p, ok := mp.Proof.Data.(*HareProof)
-		if !ok {
-			encoder.AddString("msgs", "n/a")
-		} else {
-			encoder.AddObject("msgs", p)
-		}
-	case InvalidPostIndex:
-		encoder.AddString("type", "invalid post index")
-		p, ok := mp.Proof.Data.(*InvalidPostIndexProof)
-		if ok {
-			p.Atx.Initialize()
-			encoder.AddString("atx_id", p.Atx.ID().String())
-			encoder.AddString("smesher", p.Atx.SmesherID.String())
-			encoder.AddUint32("invalid index", p.InvalidIdx)
-		}
-	default:
+	if pt, ok := GetMalfeasanceProofType(mp.Proof.Type); ok {
+		encoder.AddString("type", pt.Name)
+	} else {
 		encoder.AddString("type", "unknown")
 	}
+	if mp.Proof.Data != nil {
+		encoder.AddObject("msgs", mp.Proof.Data)
+	} else {
+		encoder.AddString("msgs", "n/a")
+	}
 	encoder.AddTime("received", mp.received)
 	return nil
 }
 
 type Proof struct {
-	// MultipleATXs | MultipleBallots | HareEquivocation | InvalidPostIndex
+	// one of the registered MalfeasanceProofType
 	Type uint8
-	// AtxProof | BallotProof | HareProof | InvalidPostIndexProof
-	Data scale.Type
+	// Version of the encoding of Data. Always 0 for legacy types.
+	Version uint8
+	// AtxProof | BallotProof | HareProof | InvalidPostIndexProof | proof of other registered type
+	Data MalfeasanceProofData
 }
 
 func (e *Proof) EncodeScale(enc *scale.Encoder) (int, error) {
+	pt, ok := GetMalfeasanceProofType(e.Type)
+	if !ok {
+		return 0, fmt.Errorf("unknown malfeasance proof type %d", e.Type)
+	}
 	var total int
 	{
 		// not compact, as scale spec uses "full" uint8 for enums
@@ -98,6 +178,13 @@ func (e *Proof) EncodeScale(enc *scale.Encoder) (int, error) {
 		}
 		total += n
 	}
+	if !pt.Legacy {
+		n, err := scale.EncodeByte(enc, e.Version)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
 	{
 		n, err := e.Data.EncodeScale(enc)
 		if err != nil {
@@ -118,45 +205,35 @@ func (e *Proof) DecodeScale(dec *scale.Decoder) (int, error) {
 		e.Type = typ
 		total += n
 	}
-	switch e.Type {
-	case MultipleATXs:
-		var proof AtxProof
-		n, err := proof.DecodeScale(dec)
-		if err != nil {
-			return total, err
-		}
-		e.Data = &proof
-		total += n
-	case MultipleBallots:
-		var proof BallotProof
-		n, err := proof.DecodeScale(dec)
-		if err != nil {
-			return total, err
-		}
-		e.Data = &proof
-		total += n
-	case HareEquivocation:
-		var proof HareProof
-		n, err := proof.DecodeScale(dec)
-		if err != nil {
-			return total, err
-		}
-		e.Data = &proof
-		total += n
-	case InvalidPostIndex:
-		var proof InvalidPostIndexProof
-		n, err := proof.DecodeScale(dec)
+	pt, ok := GetMalfeasanceProofType(e.Type)
+	if !ok {
+		return total, errors.New("unknown malfeasance proof type")
+	}
+	if !pt.Legacy {
+		version, n, err := scale.DecodeByte(dec)
 		if err != nil {
 			return total, err
 		}
-		e.Data = &proof
+		e.Version = version
 		total += n
-	default:
-		return total, errors.New("unknown malfeasance proof type")
 	}
+	proof, err := pt.New(e.Version)
+	if err != nil {
+		return total, fmt.Errorf("malfeasance proof %s: %w", pt.Name, err)
+	}
+	n, err := proof.DecodeScale(dec)
+	if err != nil {
+		return total, err
+	}
+	e.Data = proof
+	total += n
 	return total, nil
 }
 
+// MaxMalfeasanceProofSize is the upper bound for the size of the encoded malfeasance proof or gossip.
+// The largest proofs embed an atx, the other proofs are a few hundred bytes.
+const MaxMalfeasanceProofSize = 2 * MaxAtxSize
+
 type MalfeasanceGossip struct {
 	MalfeasanceProof
 	Eligibility *HareEligibilityGossip // optional, only useful in live hare rounds
@@ -180,6 +257,14 @@ func (ap *AtxProof) MarshalLogObject(encoder log.ObjectEncoder) error {
 	return nil
 }
 
+func (ap *AtxProof) Info() string {
+	return messagesInfo(
+		fmt.Sprintf("smesher published multiple ATXs in epoch %d", ap.Messages[0].InnerMsg.PublishEpoch),
+		[2]Hash32{ap.Messages[0].InnerMsg.MsgHash, ap.Messages[1].InnerMsg.MsgHash},
+		[2]EdSignature{ap.Messages[0].Signature, ap.Messages[1].Signature},
+	)
+}
+
 type BallotProof struct {
 	Messages [2]BallotProofMsg
 }
@@ -190,6 +275,14 @@ func (bp *BallotProof) MarshalLogObject(encoder log.ObjectEncoder) error {
 	return nil
 }
 
+func (bp *BallotProof) Info() string {
+	return messagesInfo(
+		fmt.Sprintf("smesher published multiple ballots in layer %d", bp.Messages[0].InnerMsg.Layer),
+		[2]Hash32{bp.Messages[0].InnerMsg.MsgHash, bp.Messages[1].InnerMsg.MsgHash},
+		[2]EdSignature{bp.Messages[0].Signature, bp.Messages[1].Signature},
+	)
+}
+
 type HareProof struct {
 	Messages [2]HareProofMsg
 }
@@ -200,6 +293,26 @@ func (hp *HareProof) MarshalLogObject(encoder log.ObjectEncoder) error {
 	return nil
 }
 
+func (hp *HareProof) Info() string {
+	return messagesInfo(
+		fmt.Sprintf("smesher published multiple hare messages in layer %d round %d",
+			hp.Messages[0].InnerMsg.Layer, hp.Messages[0].InnerMsg.Round),
+		[2]Hash32{hp.Messages[0].InnerMsg.MsgHash, hp.Messages[1].InnerMsg.MsgHash},
+		[2]EdSignature{hp.Messages[0].Signature, hp.Messages[1].Signature},
+	)
+}
+
+// messagesInfo explains the proof that consists of two conflicting messages.
+func messagesInfo(cause string, hashes [2]Hash32, signatures [2]EdSignature) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("cause: %s\n", cause))
+	for i, ord := range []string{"1st", "2nd"} {
+		b.WriteString(fmt.Sprintf("%s message hash: %s\n", ord, hex.EncodeToString(hashes[i].Bytes())))
+		b.WriteString(fmt.Sprintf("%s message signature: %s\n", ord, hex.EncodeToString(signatures[i].Bytes())))
+	}
+	return b.String()
+}
+
 func (hp *HareProof) ToMalfeasanceProof() *MalfeasanceProof {
 	return &MalfeasanceProof{
 		Layer: hp.Messages[0].InnerMsg.Layer,
@@ -233,6 +346,24 @@ type InvalidPostIndexProof struct {
 	InvalidIdx uint32
 }
 
+func (p *InvalidPostIndexProof) MarshalLogObject(encoder log.ObjectEncoder) error {
+	p.Atx.Initialize()
+	encoder.AddString("atx_id", p.Atx.ID().String())
+	encoder.AddString("smesher", p.Atx.SmesherID.String())
+	encoder.AddUint32("invalid index", p.InvalidIdx)
+	return nil
+}
+
+func (p *InvalidPostIndexProof) Info() string {
+	p.Atx.Initialize()
+	return fmt.Sprintf(
+		"cause: smesher published ATX %s with invalid post index %d in epoch %d\n",
+		p.Atx.ID().ShortString(),
+		p.InvalidIdx,
+		p.Atx.PublishEpoch,
+	)
+}
+
 type BallotProofMsg struct {
 	InnerMsg BallotMetadata
 
@@ -294,78 +425,8 @@ func MalfeasanceInfo(smesher NodeID, mp *MalfeasanceProof) string {
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf("generate layer: %v\n", mp.Layer))
 	b.WriteString(fmt.Sprintf("smesher id: %s\n", smesher.String()))
-	switch mp.Proof.Type {
-	case MultipleATXs:
-		p, ok := mp.Proof.Data.(*AtxProof)
-		if ok {
-			b.WriteString(
-				fmt.Sprintf(
-					"cause: smesher published multiple ATXs in epoch %d\n",
-					p.Messages[0].InnerMsg.PublishEpoch,
-				),
-			)
-			b.WriteString(
-				fmt.Sprintf("1st message hash: %s\n", hex.EncodeToString(p.Messages[0].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("1st message signature: %s\n", hex.EncodeToString(p.Messages[0].Signature.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message hash: %s\n", hex.EncodeToString(p.Messages[1].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message signature: %s\n", hex.EncodeToString(p.Messages[1].Signature.Bytes())),
-			)
-		}
-	case MultipleBallots:
-		p, ok := mp.Proof.Data.(*BallotProof)
-		if ok {
-			b.WriteString(
-				fmt.Sprintf("cause: smesher published multiple ballots in layer %d\n", p.Messages[0].InnerMsg.Layer),
-			)
-			b.WriteString(
-				fmt.Sprintf("1st message hash: %s\n", hex.EncodeToString(p.Messages[0].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("1st message signature: %s\n", hex.EncodeToString(p.Messages[0].Signature.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message hash: %s\n", hex.EncodeToString(p.Messages[1].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message signature: %s\n", hex.EncodeToString(p.Messages[1].Signature.Bytes())),
-			)
-		}
-	case HareEquivocation:
-		p, ok := mp.Proof.Data.(*HareProof)
-		if ok {
-			b.WriteString(fmt.Sprintf("cause: smesher published multiple hare messages in layer %d round %d\n",
-				p.Messages[0].InnerMsg.Layer, p.Messages[0].InnerMsg.Round))
-			b.WriteString(
-				fmt.Sprintf("1st message hash: %s\n", hex.EncodeToString(p.Messages[0].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("1st message signature: %s\n", hex.EncodeToString(p.Messages[0].Signature.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message hash: %s\n", hex.EncodeToString(p.Messages[1].InnerMsg.MsgHash.Bytes())),
-			)
-			b.WriteString(
-				fmt.Sprintf("2nd message signature: %s\n", hex.EncodeToString(p.Messages[1].Signature.Bytes())),
-			)
-		}
-	case InvalidPostIndex:
-		p, ok := mp.Proof.Data.(*InvalidPostIndexProof)
-		if ok {
-			p.Atx.Initialize()
-			b.WriteString(
-				fmt.Sprintf(
-					"cause: smesher published ATX %s with invalid post index %d in epoch %d\n",
-					p.Atx.ID().ShortString(),
-					p.InvalidIdx,
-					p.Atx.PublishEpoch,
-				))
-		}
+	if mp.Proof.Data != nil {
+		b.WriteString(mp.Proof.Data.Info())
 	}
 	return b.String()
 }
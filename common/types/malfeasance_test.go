@@ -1,15 +1,19 @@
 package types_test
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
 	fuzz "github.com/google/gofuzz"
+	"github.com/spacemeshos/go-scale"
 	"github.com/spacemeshos/go-scale/tester"
 	"github.com/stretchr/testify/require"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/codec/codectest"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
 )
 
 func TestMain(m *testing.M) {
@@ -181,28 +185,178 @@ func Test_HareMetadata_Equivocation(t *testing.T) {
 	require.False(t, hm1.Equivocation(&hm2))
 }
 
+func fuzzProof(p *types.Proof, c fuzz.Continue) {
+	switch c.Intn(4) {
+	case 0:
+		p.Type = types.MultipleATXs
+		data := types.AtxProof{}
+		c.Fuzz(&data)
+		p.Data = &data
+	case 1:
+		p.Type = types.MultipleBallots
+		data := types.BallotProof{}
+		c.Fuzz(&data)
+		p.Data = &data
+	case 2:
+		p.Type = types.HareEquivocation
+		data := types.HareProof{}
+		c.Fuzz(&data)
+		p.Data = &data
+	case 3:
+		p.Type = types.InvalidPostIndex
+		data := types.InvalidPostIndexProof{}
+		c.Fuzz(&data)
+		p.Data = &data
+	}
+}
+
 func FuzzProofConsistency(f *testing.F) {
-	tester.FuzzConsistency[types.Proof](f, func(p *types.Proof, c fuzz.Continue) {
-		switch c.Intn(3) {
-		case 0:
-			p.Type = types.MultipleATXs
-			data := types.AtxProof{}
-			c.Fuzz(&data)
-			p.Data = &data
-		case 1:
-			p.Type = types.MultipleBallots
-			data := types.BallotProof{}
-			c.Fuzz(&data)
-			p.Data = &data
-		case 2:
-			p.Type = types.HareEquivocation
-			data := types.HareProof{}
-			c.Fuzz(&data)
-			p.Data = &data
-		}
-	})
+	tester.FuzzConsistency[types.Proof](f, fuzzProof)
 }
 
 func FuzzProofSafety(f *testing.F) {
 	tester.FuzzSafety[types.Proof](f)
 }
+
+func FuzzMalfeasanceGossipDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.DecodeWithLimit[types.MalfeasanceGossip](types.MaxMalfeasanceProofSize),
+		codectest.Seeds[types.MalfeasanceGossip](f, 20, 1001, fuzzProof)...)
+}
+
+const testProofType uint8 = 200
+
+// testProof is encoded with a counter in version 2, and without it in version 1.
+type testProof struct {
+	version uint8
+	Layer   types.LayerID
+	Counter uint32
+}
+
+func (p *testProof) EncodeScale(enc *scale.Encoder) (int, error) {
+	total, err := scale.EncodeCompact32(enc, p.Layer.Uint32())
+	if err != nil || p.version < 2 {
+		return total, err
+	}
+	n, err := scale.EncodeCompact32(enc, p.Counter)
+	return total + n, err
+}
+
+func (p *testProof) DecodeScale(dec *scale.Decoder) (int, error) {
+	layer, total, err := scale.DecodeCompact32(dec)
+	if err != nil {
+		return total, err
+	}
+	p.Layer = types.LayerID(layer)
+	if p.version < 2 {
+		return total, nil
+	}
+	counter, n, err := scale.DecodeCompact32(dec)
+	p.Counter = counter
+	return total + n, err
+}
+
+func (p *testProof) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddUint32("layer", p.Layer.Uint32())
+	return nil
+}
+
+func (p *testProof) Info() string {
+	return fmt.Sprintf("cause: test misbehavior in layer %d\n", p.Layer)
+}
+
+func init() {
+	types.RegisterMalfeasanceProof(types.MalfeasanceProofType{
+		Type: testProofType,
+		Name: "test",
+		New: func(version uint8) (types.MalfeasanceProofData, error) {
+			if version == 0 || version > 2 {
+				return nil, fmt.Errorf("unsupported version %d", version)
+			}
+			return &testProof{version: version}, nil
+		},
+	})
+}
+
+func TestMalfeasanceProofTypes(t *testing.T) {
+	registered := types.MalfeasanceProofTypes()
+	require.Len(t, registered, 5)
+	for i, typ := range []uint8{
+		types.MultipleATXs,
+		types.MultipleBallots,
+		types.HareEquivocation,
+		types.InvalidPostIndex,
+		testProofType,
+	} {
+		require.Equal(t, typ, registered[i].Type)
+		require.Equal(t, typ != testProofType, registered[i].Legacy)
+	}
+	require.Panics(t, func() {
+		types.RegisterMalfeasanceProof(types.MalfeasanceProofType{
+			Type: types.MultipleATXs,
+			Name: "duplicate",
+			New:  registered[0].New,
+		})
+	})
+	_, exists := types.GetMalfeasanceProofType(testProofType + 1)
+	require.False(t, exists)
+}
+
+func TestCodec_VersionedProof(t *testing.T) {
+	for version, counter := range map[uint8]uint32{1: 0, 2: 7} {
+		t.Run(fmt.Sprintf("version %d", version), func(t *testing.T) {
+			proof := &types.MalfeasanceProof{
+				Layer: 11,
+				Proof: types.Proof{
+					Type:    testProofType,
+					Version: version,
+					Data:    &testProof{version: version, Layer: 10, Counter: counter},
+				},
+			}
+			encoded, err := codec.Encode(proof)
+			require.NoError(t, err)
+			// type and version follow the compact encoded layer
+			require.Equal(t, []byte{testProofType, version}, encoded[1:3])
+
+			var decoded types.MalfeasanceProof
+			require.NoError(t, codec.Decode(encoded, &decoded))
+			require.Equal(t, proof, &decoded)
+			require.Equal(t,
+				"generate layer: 11\nsmesher id: "+types.EmptyNodeID.String()+"\ncause: test misbehavior in layer 10\n",
+				types.MalfeasanceInfo(types.EmptyNodeID, &decoded),
+			)
+		})
+	}
+	t.Run("unsupported version", func(t *testing.T) {
+		proof := &types.MalfeasanceProof{
+			Layer: 11,
+			Proof: types.Proof{
+				Type:    testProofType,
+				Version: 3,
+				Data:    &testProof{version: 3, Layer: 10, Counter: 7},
+			},
+		}
+		encoded, err := codec.Encode(proof)
+		require.NoError(t, err)
+		var decoded types.MalfeasanceProof
+		require.ErrorContains(t, codec.Decode(encoded, &decoded), "unsupported version 3")
+	})
+	t.Run("unknown type", func(t *testing.T) {
+		proof := &types.MalfeasanceProof{
+			Layer: 11,
+			Proof: types.Proof{
+				Type: testProofType + 1,
+				Data: &testProof{Layer: 10},
+			},
+		}
+		_, err := codec.Encode(proof)
+		require.ErrorContains(t, err, "unknown malfeasance proof type")
+
+		encoded := codec.MustEncode(&types.MalfeasanceProof{
+			Layer: 11,
+			Proof: types.Proof{Type: testProofType, Version: 1, Data: &testProof{version: 1, Layer: 10}},
+		})
+		encoded[1] = testProofType + 1
+		var decoded types.MalfeasanceProof
+		require.ErrorContains(t, codec.Decode(encoded, &decoded), "unknown malfeasance proof type")
+	})
+}
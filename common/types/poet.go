@@ -12,7 +12,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/log"
 )
 
-//go:generate scalegen -types PoetChallenge,PoetProof,PoetProofMessage,PoetRound,ProcessingError
+//go:generate scalegen -types PoetChallenge,PoetProof,PoetProofMessage,PoetFraudReport,PoetRound,ProcessingError
 
 type PoetServer struct {
 	Address string    `mapstructure:"address" json:"address"`
@@ -126,6 +126,21 @@ func (p *PoetProofMessage) Ref() (PoetProofRef, error) {
 	return (PoetProofRef)(h), nil
 }
 
+// SignedBytes returns the bytes signed by the poet service: the reference of the proof followed by the statement.
+func (p *PoetProofMessage) SignedBytes() ([]byte, error) {
+	ref, err := p.Ref()
+	if err != nil {
+		return nil, err
+	}
+	return append(ref[:], p.Statement[:]...), nil
+}
+
+// PoetFraudReport proves that the poet service released an invalid proof. The report is valid if the proof
+// is signed by the poet service and doesn't pass the validation.
+type PoetFraudReport struct {
+	Proof PoetProofMessage
+}
+
 type RoundEnd time.Time
 
 func (re RoundEnd) Equal(other RoundEnd) bool {
@@ -192,6 +192,28 @@ func (t *PoetProofMessage) DecodeScale(dec *scale.Decoder) (total int, err error
 	return total, nil
 }
 
+func (t *PoetFraudReport) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := t.Proof.EncodeScale(enc)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *PoetFraudReport) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := t.Proof.DecodeScale(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
 func (t *PoetRound) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
 		n, err := scale.EncodeStringWithLimit(enc, string(t.ID), 32)
@@ -19,6 +19,8 @@ const (
 	PostStateIdle PostState = iota
 	// PostStateProving is the state of a PoST service that is currently proving.
 	PostStateProving
+	// PostStateMalicious is the state of an identity that was proven malicious and doesn't smesh anymore.
+	PostStateMalicious
 )
 
 func (s PostState) String() string {
@@ -27,6 +29,8 @@ func (s PostState) String() string {
 		return "idle"
 	case PostStateProving:
 		return "proving"
+	case PostStateMalicious:
+		return "malicious"
 	default:
 		panic(fmt.Sprintf("unknown post state %d", s))
 	}
@@ -38,6 +38,10 @@ func (id *ProposalID) DecodeScale(d *scale.Decoder) (int, error) {
 	return scale.DecodeByteArray(d, id[:])
 }
 
+// MaxProposalSize is the upper bound for the size of the encoded proposal received from peers.
+// It is the size of the ballot and the transaction ids at their limit (3.2 MiB).
+const MaxProposalSize = MaxBallotSize + 4<<20
+
 // Proposal contains the smesher's signed content proposal for a given layer and vote on the mesh history.
 // Proposal is ephemeral and will be discarded after the unified content block is created. the Ballot within
 // the Proposal will remain in the mesh.
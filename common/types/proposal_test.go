@@ -6,6 +6,7 @@ import (
 	"github.com/spacemeshos/go-scale/tester"
 	"github.com/stretchr/testify/require"
 
+	"github.com/spacemeshos/go-spacemesh/codec/codectest"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/signing"
 )
@@ -57,6 +58,11 @@ func FuzzInnerProposalSafety(f *testing.F) {
 	tester.FuzzSafety[types.InnerProposal](f)
 }
 
+func FuzzProposalDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.DecodeWithLimit[types.Proposal](types.MaxProposalSize),
+		codectest.Seeds[types.Proposal](f, 20, 1001)...)
+}
+
 func TestProposalEncoding(t *testing.T) {
 	types.CheckLayerFirstEncoding(t, func(object types.Proposal) types.LayerID { return object.Layer })
 }
@@ -14,8 +14,9 @@ const (
 	TransactionSuccess TransactionStatus = iota
 	// TransactionFailure is a status for failed but consumed transaction.
 	TransactionFailure
-	// TODO(dshulyak) what about TransactionSkipped? we shouldn't store such state
-	// but it might be useful to stream it.
+	// TransactionSkipped is a status for transaction that was included into the applied block
+	// but not executed. SkipReason is recorded in the message of the result.
+	TransactionSkipped
 )
 
 // String implements human readable representation of the status.
@@ -25,10 +26,55 @@ func (t TransactionStatus) String() string {
 		return "success"
 	case 1:
 		return "failure"
+	case 2:
+		return "skipped"
 	}
 	panic("unknown status")
 }
 
+// SkipReason is the reason why the transaction included into the applied block was not executed.
+type SkipReason uint8
+
+const (
+	// SkippedMalformed is a reason for transaction that can't be parsed.
+	SkippedMalformed SkipReason = iota + 1
+	// SkippedZeroGasPrice is a reason for transaction with zero gas price.
+	SkippedZeroGasPrice
+	// SkippedInsufficientFunds is a reason for transaction whose principal can't cover the intrinsic gas.
+	SkippedInsufficientFunds
+	// SkippedBlockGasLimit is a reason for transaction that doesn't fit into the gas limit of the block.
+	SkippedBlockGasLimit
+	// SkippedInvalidSignature is a reason for transaction that failed verification.
+	SkippedInvalidSignature
+	// SkippedNonceTooLow is a reason for transaction with the nonce that was already used by the principal.
+	SkippedNonceTooLow
+)
+
+// String returns the code of the reason that is used in the message of the result.
+func (r SkipReason) String() string {
+	switch r {
+	case SkippedMalformed:
+		return "malformed"
+	case SkippedZeroGasPrice:
+		return "zero_gas_price"
+	case SkippedInsufficientFunds:
+		return "insufficient_funds"
+	case SkippedBlockGasLimit:
+		return "block_gas_limit"
+	case SkippedInvalidSignature:
+		return "invalid_signature"
+	case SkippedNonceTooLow:
+		return "nonce_too_low"
+	}
+	return "unknown"
+}
+
+// SkippedTransaction is the transaction that was included into the applied block but not executed.
+type SkippedTransaction struct {
+	ID     TransactionID
+	Reason SkipReason
+}
+
 // TransactionResult is created after consuming transaction.
 type TransactionResult struct {
 	Status  TransactionStatus
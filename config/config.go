@@ -2,15 +2,22 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/activation/remote"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
 	"github.com/spacemeshos/go-spacemesh/beacon"
 	"github.com/spacemeshos/go-spacemesh/blocks"
@@ -18,14 +25,22 @@ import (
 	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/diskspace"
+	"github.com/spacemeshos/go-spacemesh/explorer"
 	"github.com/spacemeshos/go-spacemesh/fetch"
 	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/hare3"
 	"github.com/spacemeshos/go-spacemesh/hare3/eligibility"
+	"github.com/spacemeshos/go-spacemesh/malfeasance"
+	"github.com/spacemeshos/go-spacemesh/node/shutdown"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
+	remotesigner "github.com/spacemeshos/go-spacemesh/signing/remote"
+	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/syncer"
 	timeConfig "github.com/spacemeshos/go-spacemesh/timesync/config"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 const (
@@ -47,6 +62,7 @@ func init() {
 type Config struct {
 	BaseConfig      `mapstructure:"main"`
 	Preset          string                `mapstructure:"preset"`
+	Profile         string                `mapstructure:"profile"`
 	Genesis         GenesisConfig         `mapstructure:"genesis"`
 	PublicMetrics   PublicMetrics         `mapstructure:"public-metrics"`
 	Tortoise        tortoise.Config       `mapstructure:"tortoise"`
@@ -60,14 +76,27 @@ type Config struct {
 	VM              vm.Config             `mapstructure:"vm"`
 	POST            activation.PostConfig `mapstructure:"post"`
 	POSTService     activation.PostSupervisorConfig
-	POET            activation.PoetConfig `mapstructure:"poet"`
-	SMESHING        SmeshingConfig        `mapstructure:"smeshing"`
-	LOGGING         LoggerConfig          `mapstructure:"logging"`
-	FETCH           fetch.Config          `mapstructure:"fetch"`
-	Bootstrap       bootstrap.Config      `mapstructure:"bootstrap"`
-	Sync            syncer.Config         `mapstructure:"syncer"`
-	Recovery        checkpoint.Config     `mapstructure:"recovery"`
-	Cache           datastore.Config      `mapstructure:"cache"`
+	POET            activation.PoetConfig     `mapstructure:"poet"`
+	SMESHING        SmeshingConfig            `mapstructure:"smeshing"`
+	LOGGING         LoggerConfig              `mapstructure:"logging"`
+	FETCH           fetch.Config              `mapstructure:"fetch"`
+	Bootstrap       bootstrap.Config          `mapstructure:"bootstrap"`
+	Sync            syncer.Config             `mapstructure:"syncer"`
+	Recovery        checkpoint.Config         `mapstructure:"recovery"`
+	Checkpoint      checkpoint.ScheduleConfig `mapstructure:"checkpoint"`
+	Keystore        keystore.Config           `mapstructure:"keystore"`
+	RemoteSigners   []remotesigner.Config     `mapstructure:"remote-signers"`
+	Cache           datastore.Config          `mapstructure:"cache"`
+	Tracing         tracing.Config            `mapstructure:"tracing"`
+	DiskSpace       diskspace.Config          `mapstructure:"diskspace"`
+	Explorer        explorer.Config           `mapstructure:"explorer"`
+
+	AtxValidation activation.AtxValidationConfig `mapstructure:"atx-validation"`
+	PostVerifier  remote.Config                  `mapstructure:"post-verifier"`
+	Watchdog      activation.WatchdogConfig      `mapstructure:"watchdog"`
+	Watchtower    malfeasance.WatchtowerConfig   `mapstructure:"watchtower"`
+	PoetProxy     poetproxy.Config               `mapstructure:"poet-proxy"`
+	Shutdown      shutdown.Config                `mapstructure:"shutdown"`
 }
 
 // DataDir returns the absolute path to use for the node's data. This is the tilde-expanded path given in the config
@@ -110,6 +139,17 @@ type BaseConfig struct {
 	OptFilterThreshold int    `mapstructure:"optimistic-filtering-threshold"`
 	TickSize           uint64 `mapstructure:"tick-size"`
 
+	// transactions from PriorityAddresses and PriorityTXs (hex encoded) are packed into the node's own
+	// proposals before other transactions, using up to PriorityGasShare percent of the block gas limit.
+	PriorityAddresses []string `mapstructure:"priority-addresses"`
+	PriorityTXs       []string `mapstructure:"priority-txs"`
+	PriorityGasShare  uint8    `mapstructure:"priority-gas-share"`
+
+	// GoldenATXs (hex encoded) reset the atx chain in network upgrades, keyed by the first epoch (decimal)
+	// in which they are used. The golden atx derived from the genesis is used before the first upgrade.
+	// Golden atxs of the upgrades may also be delivered with the bootstrap updates.
+	GoldenATXs map[string]string `mapstructure:"golden-atxs"`
+
 	DatabaseConnections          int                     `mapstructure:"db-connections"`
 	DatabaseLatencyMetering      bool                    `mapstructure:"db-latency-metering"`
 	DatabaseSizeMeteringInterval time.Duration           `mapstructure:"db-size-metering-interval"`
@@ -118,16 +158,62 @@ type BaseConfig struct {
 	DatabaseSkipMigrations       []int                   `mapstructure:"db-skip-migrations"`
 	DatabaseQueryCache           bool                    `mapstructure:"db-query-cache"`
 	DatabaseQueryCacheSizes      DatabaseQueryCacheSizes `mapstructure:"db-query-cache-sizes"`
+	// DatabasePragmas are applied to the state and the local databases.
+	DatabasePragmas sql.Pragmas `mapstructure:"db-pragmas"`
 
 	PruneActivesetsFrom types.EpochID `mapstructure:"prune-activesets-from"`
 
+	// AccountsRetention is the number of layers for which historical account states are kept
+	// and can be queried from the api. History is never pruned if set to 0.
+	AccountsRetention uint32 `mapstructure:"accounts-retention"`
+
+	// CertificateArchive keeps block certificates for all layers instead of pruning them outside
+	// of the hdist window, so that the node can serve historical certificates to light clients.
+	CertificateArchive bool `mapstructure:"certificate-archive"`
+
+	// EventsJournalSize is the number of the most recent user events persisted in the local database,
+	// so that api clients can replay them after reconnecting. Journal is disabled if set to 0.
+	EventsJournalSize int `mapstructure:"events-journal-size"`
+
+	// SigningGuard refuses to sign ballots for the identities that appear to be used by another instance
+	// of the node, such as the primary node that is still alive after failover to the standby.
+	SigningGuard bool `mapstructure:"signing-guard"`
+	// SigningLeaseDir enables lease files of the identities in the directory. It is expected to be shared
+	// between the instances that may use the same identities.
+	SigningLeaseDir string `mapstructure:"signing-lease-dir"`
+	// SigningLockout is the time during which the identity doesn't sign ballots after its ballot
+	// signed by another instance was received. Default is used if set to 0.
+	SigningLockout time.Duration `mapstructure:"signing-lockout"`
+
 	NetworkHRP string `mapstructure:"network-hrp"`
 
+	// SignatureBatchWindow is the time during which signatures of messages received from peers are collected
+	// to be verified in a single batch. Signatures are verified one by one if set to 0.
+	// Only the validators of gossip messages batch signatures, other components verify them synchronously.
+	SignatureBatchWindow time.Duration `mapstructure:"signature-batch-window"`
+	// SignatureBatchSize is the max number of signatures verified in a single batch.
+	SignatureBatchSize int `mapstructure:"signature-batch-size"`
+
 	// MinerGoodAtxsPercent is a threshold to decide if tortoise activeset should be
 	// picked from first block instead of synced data.
 	MinerGoodAtxsPercent int `mapstructure:"miner-good-atxs-percent"`
 
 	RegossipAtxInterval time.Duration `mapstructure:"regossip-atx-interval"`
+	// RegossipAtxProbePeers is the number of peers that are asked which atxs of the node they already have
+	// before they are re-gossiped. Zero disables probing, all atxs are re-gossiped.
+	RegossipAtxProbePeers int `mapstructure:"regossip-atx-probe-peers"`
+	// RegossipAtxRate is the largest number of atxs re-gossiped per second by all identities of the node.
+	RegossipAtxRate float64 `mapstructure:"regossip-atx-rate"`
+
+	// PoetValidationQueue is the max number of poet proofs waiting to be validated.
+	// Zero disables the queue, proofs are validated synchronously.
+	PoetValidationQueue int `mapstructure:"poet-validation-queue"`
+	// PoetValidationWorkers is the number of poet proofs validated concurrently.
+	PoetValidationWorkers int `mapstructure:"poet-validation-workers"`
+
+	// AtxVersions maps the first publish epoch of the atx wire version to the version.
+	// Atxs of the first version are published and accepted in all epochs before the first configured one.
+	AtxVersions types.AtxVersions `mapstructure:"atx-versions"`
 
 	// ATXGradeDelay is used to grade ATXs for selection in tortoise active set.
 	// See grading function in miner/proposals_builder.go
@@ -172,6 +258,10 @@ type SmeshingConfig struct {
 	Opts            activation.PostSetupOpts          `mapstructure:"smeshing-opts"`
 	ProvingOpts     activation.PostProvingOpts        `mapstructure:"smeshing-proving-opts"`
 	VerifyingOpts   activation.PostProofVerifyingOpts `mapstructure:"smeshing-verifying-opts"`
+	// LatePublish is the strategy for the nipost challenge that expired before the atx was published.
+	LatePublish activation.LatePublishStrategy `mapstructure:"smeshing-late-publish"`
+	// VRFKey is the index of the vrf key derived from the identity key, increase it to rotate the vrf keys.
+	VRFKey uint32 `mapstructure:"smeshing-vrf-key"`
 }
 
 // DefaultConfig returns the default configuration for a spacemesh node.
@@ -196,7 +286,17 @@ func DefaultConfig() Config {
 		Bootstrap:       bootstrap.DefaultConfig(),
 		Sync:            syncer.DefaultConfig(),
 		Recovery:        checkpoint.DefaultConfig(),
+		Checkpoint:      checkpoint.DefaultScheduleConfig(),
+		Keystore:        keystore.DefaultConfig(),
 		Cache:           datastore.DefaultConfig(),
+		Tracing:         tracing.DefaultConfig(),
+		DiskSpace:       diskspace.DefaultConfig(),
+		Explorer:        explorer.DefaultConfig(),
+		AtxValidation:   activation.DefaultAtxValidationConfig(),
+		PostVerifier:    remote.DefaultConfig(),
+		Watchtower:      malfeasance.DefaultWatchtowerConfig(),
+		PoetProxy:       poetproxy.DefaultConfig(),
+		Shutdown:        shutdown.DefaultConfig(),
 	}
 }
 
@@ -210,6 +310,7 @@ func DefaultTestConfig() Config {
 	conf.POSTService = activation.DefaultTestPostServiceConfig()
 	conf.HARE3.PreroundDelay = 1 * time.Second
 	conf.HARE3.RoundDuration = 1 * time.Second
+	conf.TIME.NTP.Disable = true
 	return conf
 }
 
@@ -235,9 +336,16 @@ func defaultBaseConfig() BaseConfig {
 			ATXBlob:       10000,
 			ActiveSetBlob: 200,
 		},
-		NetworkHRP:     "sm",
-		ATXGradeDelay:  10 * time.Second,
-		PostValidDelay: 12 * time.Hour,
+		NetworkHRP:           "sm",
+		SignatureBatchWindow: 2 * time.Millisecond,
+		SignatureBatchSize:   64,
+		ATXGradeDelay:        10 * time.Second,
+		PostValidDelay:       12 * time.Hour,
+
+		RegossipAtxProbePeers: 20,
+		RegossipAtxRate:       10,
+		PoetValidationQueue:   100,
+		PoetValidationWorkers: 2,
 	}
 }
 
@@ -249,6 +357,7 @@ func DefaultSmeshingConfig() SmeshingConfig {
 		Opts:            activation.DefaultPostSetupOpts(),
 		ProvingOpts:     activation.DefaultPostProvingOpts(),
 		VerifyingOpts:   activation.DefaultPostVerifyingOpts(),
+		LatePublish:     activation.LatePublishSkip,
 	}
 }
 
@@ -260,14 +369,53 @@ func defaultTestConfig() BaseConfig {
 	return conf
 }
 
-// LoadConfig load the config file.
+// remoteConfigTimeout is the timeout to download the config from the remote url.
+const remoteConfigTimeout = 30 * time.Second
+
+// IsRemoteConfig returns true if the config is loaded from the http(s) url.
+func IsRemoteConfig(config string) bool {
+	u, err := url.Parse(config)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// LoadConfig load the config file. The config is downloaded if it is the http(s) url.
 func LoadConfig(config string, vip *viper.Viper) error {
 	if config == "" {
 		return nil
 	}
+	if IsRemoteConfig(config) {
+		return loadRemoteConfig(config, vip)
+	}
 	vip.SetConfigFile(config)
 	if err := vip.ReadInConfig(); err != nil {
 		return fmt.Errorf("can't load config at %s: %w", config, err)
 	}
 	return nil
 }
+
+func loadRemoteConfig(config string, vip *viper.Viper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config, nil)
+	if err != nil {
+		return fmt.Errorf("can't load config at %s: %w", config, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("can't load config at %s: %w", config, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("can't load config at %s: unexpected status %s", config, resp.Status)
+	}
+	// the format of the config is detected by the extension like for the config file
+	format := strings.TrimPrefix(path.Ext(req.URL.Path), ".")
+	if format == "" {
+		format = "json"
+	}
+	vip.SetConfigType(format)
+	if err := vip.ReadConfig(resp.Body); err != nil {
+		return fmt.Errorf("can't load config at %s: %w", config, err)
+	}
+	return nil
+}
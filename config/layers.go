@@ -0,0 +1,185 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the config.
+//
+// The name of the variable after the prefix is the key of the config, where sections are separated
+// by double underscores and dashes are replaced by underscores, e.g. SPACEMESH_P2P__MIN_PEERS overrides
+// the min-peers key in the p2p section. Lists and maps are set as json, e.g.
+// SPACEMESH_MAIN__POET_SERVERS='[{"address": "https://poet.example.com", "pubkey": "..."}]'.
+const EnvPrefix = "SPACEMESH_"
+
+// EnvKey returns the key of the config that is overridden by the environment variable,
+// or an empty string if the variable doesn't override the config.
+func EnvKey(name string) string {
+	name, ok := strings.CutPrefix(name, EnvPrefix)
+	if !ok || name == "" {
+		return ""
+	}
+	sections := strings.Split(name, "__")
+	for i, section := range sections {
+		sections[i] = strings.ReplaceAll(strings.ToLower(section), "_", "-")
+	}
+	return strings.Join(sections, ".")
+}
+
+// LoadEnv sets the keys of the config overridden by the environment variables to the viper.
+// The environment is in the format of os.Environ.
+func LoadEnv(environ []string, vip *viper.Viper) error {
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		key := EnvKey(name)
+		if key == "" {
+			continue
+		}
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			var decoded any
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				return fmt.Errorf("can't decode %s as json for %s: %w", name, key, err)
+			}
+			vip.Set(key, decoded)
+			continue
+		}
+		vip.Set(key, value)
+	}
+	return nil
+}
+
+// Sources tracks the layer of the configuration that set the effective value of every key.
+// Layers are applied in the order defaults < preset < profile < file < environment < flags.
+type Sources struct {
+	values  map[string]string
+	sources map[string]string
+}
+
+// NewSources creates Sources that are empty until the first layer is recorded.
+func NewSources() *Sources {
+	return &Sources{
+		values:  make(map[string]string),
+		sources: make(map[string]string),
+	}
+}
+
+// Record the values of the config after the layer was applied. Keys that changed their value
+// are attributed to the layer. The layer that sets the same value as the previous layers is not recorded.
+func (s *Sources) Record(layer string, cfg *Config) {
+	for key, value := range Flatten(cfg) {
+		if prev, exists := s.values[key]; exists && prev == value {
+			continue
+		}
+		s.values[key] = value
+		s.sources[key] = layer
+	}
+}
+
+// Source returns the layer that set the effective value of the key.
+func (s *Sources) Source(key string) string {
+	return s.sources[key]
+}
+
+// Write the effective config sorted by key, one key per line with the layer that set its value.
+func (s *Sources) Write(w io.Writer) error {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s = %s # %s\n", key, s.values[key], s.sources[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flatten returns the values of the config by their keys, e.g. "main.layer-duration".
+func Flatten(cfg *Config) map[string]string {
+	rst := make(map[string]string)
+	flatten("", reflect.ValueOf(cfg).Elem(), rst)
+	return rst
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+func flatten(prefix string, v reflect.Value, rst map[string]string) {
+	if v.Kind() == reflect.Pointer && !v.IsNil() && !isFormatted(v) {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || isFormatted(v) || !hasTaggedFields(v.Type()) {
+		rst[prefix] = format(v)
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		switch {
+		case strings.Contains(opts, "squash"):
+			flatten(prefix, v.Field(i), rst)
+			continue
+		case name == "" || name == "-":
+			// untagged fields are not loaded from the config
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		flatten(key, v.Field(i), rst)
+	}
+}
+
+func hasTaggedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, exists := t.Field(i).Tag.Lookup("mapstructure"); exists {
+			return true
+		}
+	}
+	return false
+}
+
+func isFormatted(v reflect.Value) bool {
+	t := v.Type()
+	if v.CanAddr() {
+		t = reflect.PointerTo(t)
+	}
+	return t.Implements(textMarshalerType) || t.Implements(stringerType)
+}
+
+func format(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return "null"
+	}
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	switch value := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		if text, err := value.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return value.String()
+	}
+	if encoded, err := json.Marshal(v.Interface()); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestEnvKey(t *testing.T) {
+	for _, tc := range []struct {
+		name, key string
+	}{
+		{"SPACEMESH_PRESET", "preset"},
+		{"SPACEMESH_P2P__MIN_PEERS", "p2p.min-peers"},
+		{"SPACEMESH_API__GRPC_RATE_LIMIT__KEYS", "api.grpc-rate-limit.keys"},
+		{"SPACEMESH_", ""},
+		{"HOME", ""},
+	} {
+		require.Equal(t, tc.key, EnvKey(tc.name), tc.name)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	vip := viper.New()
+	require.NoError(t, LoadEnv([]string{
+		"HOME=/root",
+		"SPACEMESH_MAIN__LAYER_DURATION=30s",
+		`SPACEMESH_MAIN__POET_SERVERS=[{"address": "https://poet.example.com"}]`,
+	}, vip))
+	require.Equal(t, map[string]any{
+		"main": map[string]any{
+			"layer-duration": "30s",
+			"poet-servers":   []any{map[string]any{"address": "https://poet.example.com"}},
+		},
+	}, vip.AllSettings())
+
+	require.ErrorContains(t, LoadEnv([]string{"SPACEMESH_MAIN__POET_SERVERS=[invalid"}, viper.New()),
+		"SPACEMESH_MAIN__POET_SERVERS")
+}
+
+func TestSources(t *testing.T) {
+	cfg := MainnetConfig()
+	sources := NewSources()
+	sources.Record("default", &cfg)
+
+	cfg.LayerDuration = time.Minute
+	cfg.PoetServers = []types.PoetServer{{Address: "https://poet.example.com"}}
+	sources.Record("file", &cfg)
+	cfg.LayerDuration = 2 * time.Minute
+	cfg.P2P.MinPeers = MainnetConfig().P2P.MinPeers
+	sources.Record("env", &cfg)
+
+	require.Equal(t, "env", sources.Source("main.layer-duration"))
+	require.Equal(t, "file", sources.Source("main.poet-servers"))
+	require.Equal(t, "default", sources.Source("p2p.min-peers"))
+	require.Equal(t, "default", sources.Source("api.grpc-rate-limit.rate"), "squashed fields")
+	require.Empty(t, sources.Source("main.unknown"))
+
+	var buf bytes.Buffer
+	require.NoError(t, sources.Write(&buf))
+	require.Contains(t, buf.String(), "main.layer-duration = 2m0s # env\n")
+	require.Contains(t, buf.String(),
+		`main.poet-servers = [{"address":"https://poet.example.com","pubkey":null}] # file`+"\n")
+}
+
+func TestLoadRemoteConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			w.Write([]byte(`{"main": {"layer-duration": "30s"}}`))
+		case "/config.yaml":
+			w.Write([]byte("main:\n  layer-duration: 1m\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	vip := viper.New()
+	require.NoError(t, LoadConfig(srv.URL+"/config.json", vip))
+	require.Equal(t, "30s", vip.GetString("main.layer-duration"))
+
+	vip = viper.New()
+	require.NoError(t, LoadConfig(srv.URL+"/config.yaml", vip))
+	require.Equal(t, "1m", vip.GetString("main.layer-duration"))
+
+	require.ErrorContains(t, LoadConfig(srv.URL+"/missing.json", viper.New()), "404")
+	require.False(t, IsRemoteConfig("config.json"))
+}
+
+func TestValidate(t *testing.T) {
+	cfg := MainnetConfig()
+	require.NoError(t, cfg.Validate())
+
+	cfg.LayersPerEpoch = 0
+	cfg.PriorityGasShare = 101
+	cfg.PoetServers = append(cfg.PoetServers,
+		types.PoetServer{Address: "poet.example.com"},
+		cfg.PoetServers[0],
+	)
+	cfg.API.JSONListener = "9093"
+	err := cfg.Validate()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	for _, msg := range []string{
+		"main.layers-per-epoch = 0: must be positive",
+		"main.priority-gas-share = 101",
+		"main.poet-servers[5].address = poet.example.com: must be an absolute http or https url",
+		"main.poet-servers[6].address = https://mainnet-poet-0.spacemesh.network: " +
+			"is a duplicate of main.poet-servers[0].address",
+		"api.grpc-json-listener = 9093: must be in the host:port format",
+	} {
+		require.ErrorContains(t, err, msg)
+	}
+}
@@ -16,6 +16,9 @@ const (
 // LoggerConfig holds the logging level for each module.
 type LoggerConfig struct {
 	Encoder LogEncoder `mapstructure:"log-encoder"`
+	// Encoders overrides the encoder for the modules by the name of the module.
+	// Modules that are not listed use Encoder.
+	Encoders map[string]LogEncoder `mapstructure:"encoders"`
 
 	AppLoggerLevel             string `mapstructure:"app"`
 	ClockLoggerLevel           string `mapstructure:"clock"`
@@ -50,6 +53,8 @@ type LoggerConfig struct {
 	ExecutorLoggerLevel        string `mapstructure:"executor"`
 	MalfeasanceLoggerLevel     string `mapstructure:"malfeasance"`
 	BootstrapLoggerLevel       string `mapstructure:"bootstrap"`
+	CheckpointLoggerLevel      string `mapstructure:"checkpoint"`
+	DiskSpaceLoggerLevel       string `mapstructure:"diskspace"`
 }
 
 func DefaultLoggingConfig() LoggerConfig {
@@ -87,5 +92,7 @@ func DefaultLoggingConfig() LoggerConfig {
 		ConStateLoggerLevel:        defaultLoggingLevel.String(),
 		MalfeasanceLoggerLevel:     defaultLoggingLevel.String(),
 		BootstrapLoggerLevel:       defaultLoggingLevel.String(),
+		CheckpointLoggerLevel:      defaultLoggingLevel.String(),
+		DiskSpaceLoggerLevel:       defaultLoggingLevel.String(),
 	}
 }
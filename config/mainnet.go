@@ -11,6 +11,8 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/activation/remote"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
 	"github.com/spacemeshos/go-spacemesh/beacon"
 	"github.com/spacemeshos/go-spacemesh/blocks"
@@ -18,14 +20,20 @@ import (
 	"github.com/spacemeshos/go-spacemesh/checkpoint"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/diskspace"
+	"github.com/spacemeshos/go-spacemesh/explorer"
 	"github.com/spacemeshos/go-spacemesh/fetch"
 	"github.com/spacemeshos/go-spacemesh/hare3"
 	"github.com/spacemeshos/go-spacemesh/hare3/eligibility"
+	"github.com/spacemeshos/go-spacemesh/malfeasance"
+	"github.com/spacemeshos/go-spacemesh/node/shutdown"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
 	"github.com/spacemeshos/go-spacemesh/syncer"
 	"github.com/spacemeshos/go-spacemesh/syncer/atxsync"
 	timeConfig "github.com/spacemeshos/go-spacemesh/timesync/config"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 func MainnetConfig() Config {
@@ -106,9 +114,15 @@ func MainnetConfig() Config {
 					Pubkey:  types.MustBase64FromString("5p/mPvmqhwdvf8U0GVrNq/9IN/HmZj5hCkFLAN04g1E="),
 				},
 			},
-			RegossipAtxInterval: 2 * time.Hour,
-			ATXGradeDelay:       30 * time.Minute,
-			PostValidDelay:      time.Duration(math.MaxInt64),
+			RegossipAtxInterval:   2 * time.Hour,
+			RegossipAtxProbePeers: 20,
+			RegossipAtxRate:       10,
+			PoetValidationQueue:   100,
+			PoetValidationWorkers: 2,
+			ATXGradeDelay:         30 * time.Minute,
+			PostValidDelay:        time.Duration(math.MaxInt64),
+			SignatureBatchWindow:  2 * time.Millisecond,
+			SignatureBatchSize:    64,
 		},
 		Genesis: GenesisConfig{
 			GenesisTime: "2023-07-14T08:00:00Z",
@@ -187,8 +201,19 @@ func MainnetConfig() Config {
 			OutOfSyncThresholdLayers: 36, // 3h
 			DisableMeshAgreement:     true,
 			AtxSync:                  atxsync.DefaultConfig(),
+			Backfill:                 syncer.DefaultBackfillConfig(),
 		},
-		Recovery: checkpoint.DefaultConfig(),
-		Cache:    datastore.DefaultConfig(),
+		Recovery:      checkpoint.DefaultConfig(),
+		Checkpoint:    checkpoint.DefaultScheduleConfig(),
+		Keystore:      keystore.DefaultConfig(),
+		Cache:         datastore.DefaultConfig(),
+		Tracing:       tracing.DefaultConfig(),
+		DiskSpace:     diskspace.DefaultConfig(),
+		Explorer:      explorer.DefaultConfig(),
+		AtxValidation: activation.DefaultAtxValidationConfig(),
+		PostVerifier:  remote.DefaultConfig(),
+		Watchtower:    malfeasance.DefaultWatchtowerConfig(),
+		PoetProxy:     poetproxy.DefaultConfig(),
+		Shutdown:      shutdown.DefaultConfig(),
 	}
 }
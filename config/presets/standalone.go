@@ -23,6 +23,7 @@ func standalone() config.Config {
 	conf.NetworkHRP = "standalone"
 
 	conf.TIME.Peersync.Disable = true
+	conf.TIME.NTP.Disable = true
 	conf.Standalone = true
 	conf.DataDirParent = filepath.Join(os.TempDir(), "spacemesh")
 	conf.FileLock = filepath.Join(conf.DataDirParent, "LOCK")
@@ -11,6 +11,8 @@ import (
 	"github.com/spacemeshos/post/initialization"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/activation/remote"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
 	"github.com/spacemeshos/go-spacemesh/beacon"
 	"github.com/spacemeshos/go-spacemesh/blocks"
@@ -19,14 +21,20 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/config"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/diskspace"
+	"github.com/spacemeshos/go-spacemesh/explorer"
 	"github.com/spacemeshos/go-spacemesh/fetch"
 	"github.com/spacemeshos/go-spacemesh/hare3"
 	"github.com/spacemeshos/go-spacemesh/hare3/eligibility"
+	"github.com/spacemeshos/go-spacemesh/malfeasance"
+	"github.com/spacemeshos/go-spacemesh/node/shutdown"
 	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
 	"github.com/spacemeshos/go-spacemesh/syncer"
 	"github.com/spacemeshos/go-spacemesh/syncer/atxsync"
 	timeConfig "github.com/spacemeshos/go-spacemesh/timesync/config"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 func init() {
@@ -73,9 +81,16 @@ func testnet() config.Config {
 
 			OptFilterThreshold: 90,
 
-			TickSize:            666514,
-			RegossipAtxInterval: time.Hour,
-			ATXGradeDelay:       30 * time.Minute,
+			TickSize:              666514,
+			RegossipAtxInterval:   time.Hour,
+			RegossipAtxProbePeers: 20,
+			RegossipAtxRate:       10,
+			PoetValidationQueue:   100,
+			PoetValidationWorkers: 2,
+			ATXGradeDelay:         30 * time.Minute,
+
+			SignatureBatchWindow: 2 * time.Millisecond,
+			SignatureBatchSize:   64,
 		},
 		Genesis: config.GenesisConfig{
 			GenesisTime: "2023-09-13T18:00:00Z",
@@ -143,9 +158,20 @@ func testnet() config.Config {
 			GossipDuration:           50 * time.Second,
 			OutOfSyncThresholdLayers: 10,
 			AtxSync:                  atxsync.DefaultConfig(),
+			Backfill:                 syncer.DefaultBackfillConfig(),
 		},
-		Recovery: checkpoint.DefaultConfig(),
-		Cache:    datastore.DefaultConfig(),
+		Recovery:      checkpoint.DefaultConfig(),
+		Checkpoint:    checkpoint.DefaultScheduleConfig(),
+		Keystore:      keystore.DefaultConfig(),
+		Cache:         datastore.DefaultConfig(),
+		Tracing:       tracing.DefaultConfig(),
+		DiskSpace:     diskspace.DefaultConfig(),
+		Explorer:      explorer.DefaultConfig(),
+		AtxValidation: activation.DefaultAtxValidationConfig(),
+		PostVerifier:  remote.DefaultConfig(),
+		Watchtower:    malfeasance.DefaultWatchtowerConfig(),
+		PoetProxy:     poetproxy.DefaultConfig(),
+		Shutdown:      shutdown.DefaultConfig(),
 		Certificate: blocks.CertConfig{
 			// NOTE(dshulyak) this is intentional. we increased committee size with hare3 upgrade
 			// but certifier continues to use 200 committee size.
@@ -0,0 +1,100 @@
+// Package profiles provides node profiles that adjust pruning, caching, served protocols
+// and api services of the config consistently for the intended use of the node.
+package profiles
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
+	"github.com/spacemeshos/go-spacemesh/config"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+const (
+	// Archive keeps the full history of the mesh and the accounts, serves light clients
+	// and caches more data to serve api queries.
+	Archive = "archive"
+	// Default keeps the config of the preset unchanged.
+	Default = "default"
+	// LightSmesher prunes the history that isn't needed for smeshing and serves only the essential
+	// api services, for nodes on constrained devices.
+	LightSmesher = "light-smesher"
+)
+
+var profiles = map[string]func(*config.Config){
+	Archive:      archive,
+	Default:      func(*config.Config) {},
+	LightSmesher: lightSmesher,
+}
+
+// Options returns list of available profiles.
+func Options() []string {
+	var options []string
+	for name := range profiles {
+		options = append(options, name)
+	}
+	sort.Strings(options)
+	return options
+}
+
+// Apply overwrites values of the config with the values of the profile.
+func Apply(name string, cfg *config.Config) error {
+	profile, exists := profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %s is not available. select one from the options %s", name, Options())
+	}
+	profile(cfg)
+	cfg.Profile = name
+	return nil
+}
+
+func archive(cfg *config.Config) {
+	cfg.AccountsRetention = 0
+	cfg.CertificateArchive = true
+	cfg.PruneActivesetsFrom = math.MaxUint32
+	cfg.DatabaseQueryCache = true
+	cfg.DatabasePragmas = sql.Pragmas{
+		Synchronous:       "normal",
+		WALAutocheckpoint: 10000,
+		CacheSize:         32 << 10,
+	}
+	cfg.EventsJournalSize = max(cfg.EventsJournalSize, 10000)
+	cfg.FETCH.ServeLight = true
+	cfg.Explorer.Enabled = true
+	cfg.API.PublicServices = withServices(cfg.API.PublicServices,
+		grpcserver.GlobalState, grpcserver.Mesh, grpcserver.Transaction, grpcserver.Activation,
+		grpcserver.ActivationV2Alpha1, grpcserver.RewardV2Alpha1, grpcserver.Explorer,
+	)
+}
+
+func lightSmesher(cfg *config.Config) {
+	// history of two epochs is enough to build and validate the atxs of the node
+	cfg.AccountsRetention = 2 * cfg.LayersPerEpoch
+	cfg.CertificateArchive = false
+	cfg.PruneActivesetsFrom = 0
+	cfg.DatabasePruneInterval = 10 * time.Minute
+	cfg.DatabaseConnections = 4
+	cfg.DatabaseQueryCache = false
+	cfg.DatabasePragmas = sql.Pragmas{
+		Synchronous: "normal",
+		CacheSize:   2 << 10,
+	}
+	cfg.EventsJournalSize = min(cfg.EventsJournalSize, 100)
+	cfg.FETCH.ServeLight = false
+	cfg.Explorer.Enabled = false
+	cfg.API.PublicServices = []grpcserver.Service{grpcserver.Node, grpcserver.Health}
+}
+
+func withServices(services []grpcserver.Service, add ...grpcserver.Service) []grpcserver.Service {
+	rst := slices.Clone(services)
+	for _, service := range add {
+		if !slices.Contains(rst, service) {
+			rst = append(rst, service)
+		}
+	}
+	return rst
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// ErrInvalidConfig is returned if the config doesn't pass validation.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// KeyError is the error in the value of the config key, with a hint on how to fix it.
+type KeyError struct {
+	Key   string
+	Value any
+	Hint  string
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s = %v: %s", e.Key, e.Value, e.Hint)
+}
+
+func (e *KeyError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Validate checks the values of the config that can be checked without starting the node,
+// and returns all errors found, each naming the key and how to fix it.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, value any, hint string, args ...any) {
+		if !ok {
+			errs = append(errs, &KeyError{Key: key, Value: value, Hint: fmt.Sprintf(hint, args...)})
+		}
+	}
+	check(cfg.LayerDuration > 0, "main.layer-duration", cfg.LayerDuration,
+		"must be positive, e.g. 5m")
+	check(cfg.LayersPerEpoch > 0, "main.layers-per-epoch", cfg.LayersPerEpoch,
+		"must be positive")
+	check(cfg.DataDirParent != "", "main.data-folder", cfg.DataDirParent,
+		"must be set to the directory where the node stores its data")
+	check(cfg.PriorityGasShare <= 100, "main.priority-gas-share", cfg.PriorityGasShare,
+		"is a percentage of the block gas limit and must not exceed 100")
+	check(len(cfg.Genesis.ExtraData) <= 255, "genesis.genesis-extra-data", cfg.Genesis.ExtraData,
+		"must not be longer than 255 symbols")
+
+	seen := make(map[string]int, len(cfg.PoetServers))
+	for i, server := range cfg.PoetServers {
+		key := fmt.Sprintf("main.poet-servers[%d].address", i)
+		u, err := url.Parse(server.Address)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", key, server.Address,
+			"must be an absolute http or https url, e.g. https://poet.example.com")
+		if j, exists := seen[server.Address]; exists {
+			check(false, key, server.Address, "is a duplicate of main.poet-servers[%d].address", j)
+		}
+		seen[server.Address] = i
+	}
+
+	for _, listener := range []struct{ key, addr string }{
+		{"api.grpc-public-listener", cfg.API.PublicListener},
+		{"api.grpc-private-listener", cfg.API.PrivateListener},
+		{"api.grpc-post-listener", cfg.API.PostListener},
+		{"api.grpc-tls-listener", cfg.API.TLSListener},
+		{"api.grpc-json-listener", cfg.API.JSONListener},
+	} {
+		if listener.addr == "" {
+			continue
+		}
+		_, _, err := net.SplitHostPort(listener.addr)
+		check(err == nil, listener.key, listener.addr, "must be in the host:port format, e.g. 0.0.0.0:9092")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+//go:build !windows
+
+package datastore
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// initialMmapSize is the size of the mapping when the first header of the epoch is cached.
+const initialMmapSize = 1 << 20
+
+// mmapArena keeps encoded headers in the memory mapped file, so that the OS can page them out
+// under memory pressure. The file is removed right after it is created and is freed when the
+// arena is closed or the node exits.
+type mmapArena struct {
+	file *os.File
+	data []byte
+	size int
+}
+
+func newMmapArena(dir string) (arena, error) {
+	file, err := os.CreateTemp(dir, "atx-headers-*")
+	if err != nil {
+		return nil, fmt.Errorf("create mmap file: %w", err)
+	}
+	if err := os.Remove(file.Name()); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("remove mmap file: %w", err)
+	}
+	return &mmapArena{file: file}, nil
+}
+
+func (a *mmapArena) grow(size int) error {
+	capacity := max(len(a.data), initialMmapSize)
+	for capacity < size {
+		capacity *= 2
+	}
+	if err := a.file.Truncate(int64(capacity)); err != nil {
+		return fmt.Errorf("truncate mmap file: %w", err)
+	}
+	if a.data != nil {
+		if err := unix.Munmap(a.data); err != nil {
+			return fmt.Errorf("munmap: %w", err)
+		}
+		a.data = nil
+	}
+	data, err := unix.Mmap(int(a.file.Fd()), 0, capacity, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("mmap: %w", err)
+	}
+	a.data = data
+	return nil
+}
+
+func (a *mmapArena) Append(data []byte) (int, error) {
+	if a.size+len(data) > len(a.data) {
+		if err := a.grow(a.size + len(data)); err != nil {
+			return 0, err
+		}
+	}
+	offset := a.size
+	a.size += copy(a.data[offset:], data)
+	return offset, nil
+}
+
+func (a *mmapArena) Slice(offset, n int) []byte {
+	return a.data[offset : offset+n]
+}
+
+func (a *mmapArena) Size() int {
+	return a.size
+}
+
+func (a *mmapArena) Close() error {
+	if a.data != nil {
+		if err := unix.Munmap(a.data); err != nil {
+			return fmt.Errorf("munmap: %w", err)
+		}
+		a.data = nil
+	}
+	return a.file.Close()
+}
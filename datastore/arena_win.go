@@ -0,0 +1,9 @@
+//go:build windows
+
+package datastore
+
+import "errors"
+
+func newMmapArena(string) (arena, error) {
+	return nil, errors.New("memory mapped atx header cache is not supported on windows")
+}
@@ -0,0 +1,304 @@
+package datastore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// headerOverhead is the approximate memory used by the index entry of a single cached header.
+const headerOverhead = 64
+
+const (
+	flagGolden = 1 << iota
+	flagCommitment
+	flagVRFNonce
+	flagInitialPost
+	flagReceived
+)
+
+// arena stores encoded headers of a single epoch.
+type arena interface {
+	// Append copies data to the end of the arena and returns its offset.
+	Append(data []byte) (int, error)
+	// Slice returns the data at the offset. It is valid until the next call to Append or Close.
+	Slice(offset, n int) []byte
+	Size() int
+	Close() error
+}
+
+// heapArena keeps encoded headers in a single byte slice on the heap.
+type heapArena struct {
+	data []byte
+}
+
+func (a *heapArena) Append(data []byte) (int, error) {
+	offset := len(a.data)
+	a.data = append(a.data, data...)
+	return offset, nil
+}
+
+func (a *heapArena) Slice(offset, n int) []byte {
+	return a.data[offset : offset+n]
+}
+
+func (a *heapArena) Size() int {
+	return len(a.data)
+}
+
+func (a *heapArena) Close() error {
+	a.data = nil
+	return nil
+}
+
+type span struct {
+	offset, length uint32
+}
+
+type epochHeaders struct {
+	arena arena
+	index map[types.ATXID]span
+}
+
+func (e *epochHeaders) size() int {
+	return e.arena.Size() + len(e.index)*headerOverhead
+}
+
+// atxHeaderCache keeps compactly encoded atx headers, grouped by the publish epoch, within the memory budget.
+// When the budget is exceeded the headers of the oldest epoch are evicted together. Headers of the epochs
+// older than all cached epochs are not cached when the budget is exhausted.
+type atxHeaderCache struct {
+	budget   int
+	newArena func() (arena, error)
+
+	mu     sync.Mutex
+	used   int
+	epochs map[types.EpochID]*epochHeaders
+}
+
+func newAtxHeaderCache(budget int, newArena func() (arena, error)) *atxHeaderCache {
+	return &atxHeaderCache{
+		budget:   budget,
+		newArena: newArena,
+		epochs:   map[types.EpochID]*epochHeaders{},
+	}
+}
+
+// Get returns the cached header. The returned header is decoded on every call and is not shared.
+func (c *atxHeaderCache) Get(id types.ATXID) (*types.ActivationTxHeader, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, epoch := range c.epochs {
+		s, ok := epoch.index[id]
+		if !ok {
+			continue
+		}
+		header, err := decodeHeader(id, epoch.arena.Slice(int(s.offset), int(s.length)))
+		if err != nil {
+			break
+		}
+		atxHeaderCacheHits.Inc()
+		return header, true
+	}
+	atxHeaderCacheMisses.Inc()
+	return nil, false
+}
+
+// Add caches the header if it fits into the budget. Headers are immutable, the header that
+// is already cached is not replaced.
+func (c *atxHeaderCache) Add(header *types.ActivationTxHeader) error {
+	data, err := encodeHeader(header)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	epoch, ok := c.epochs[header.PublishEpoch]
+	if ok {
+		if _, exists := epoch.index[header.ID]; exists {
+			return nil
+		}
+	}
+	for c.used+len(data)+headerOverhead > c.budget {
+		if !c.evictOlderThan(header.PublishEpoch) {
+			return nil
+		}
+	}
+	if !ok {
+		a, err := c.newArena()
+		if err != nil {
+			return err
+		}
+		epoch = &epochHeaders{arena: a, index: map[types.ATXID]span{}}
+		c.epochs[header.PublishEpoch] = epoch
+	}
+	offset, err := epoch.arena.Append(data)
+	if err != nil {
+		return err
+	}
+	epoch.index[header.ID] = span{offset: uint32(offset), length: uint32(len(data))}
+	c.used += len(data) + headerOverhead
+	c.updateMetrics()
+	return nil
+}
+
+// evictOlderThan evicts the oldest cached epoch if it is older than the epoch.
+func (c *atxHeaderCache) evictOlderThan(epoch types.EpochID) bool {
+	oldest, found := epoch, false
+	for e := range c.epochs {
+		if e < oldest {
+			oldest, found = e, true
+		}
+	}
+	if !found {
+		return false
+	}
+	evicted := c.epochs[oldest]
+	delete(c.epochs, oldest)
+	c.used -= evicted.size()
+	evicted.arena.Close()
+	atxHeaderCacheEvictions.Add(float64(len(evicted.index)))
+	c.updateMetrics()
+	return true
+}
+
+func (c *atxHeaderCache) updateMetrics() {
+	entries := 0
+	for _, epoch := range c.epochs {
+		entries += len(epoch.index)
+	}
+	atxHeaderCacheBytes.Set(float64(c.used))
+	atxHeaderCacheEntries.Set(float64(entries))
+}
+
+// Len returns the number of cached headers.
+func (c *atxHeaderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, epoch := range c.epochs {
+		n += len(epoch.index)
+	}
+	return n
+}
+
+// encodeHeader encodes all fields of the header except the id, which is the key in the cache.
+func encodeHeader(h *types.ActivationTxHeader) ([]byte, error) {
+	buf := make([]byte, 0, 256)
+	var flags byte
+	if h.Golden {
+		flags |= flagGolden
+	}
+	if h.CommitmentATX != nil {
+		flags |= flagCommitment
+	}
+	if h.VRFNonce != nil {
+		flags |= flagVRFNonce
+	}
+	if h.InitialPost != nil {
+		flags |= flagInitialPost
+	}
+	if !h.Received.IsZero() {
+		flags |= flagReceived
+	}
+	buf = append(buf, flags)
+	buf = binary.LittleEndian.AppendUint32(buf, h.PublishEpoch.Uint32())
+	buf = binary.LittleEndian.AppendUint64(buf, h.Sequence)
+	buf = append(buf, h.PrevATXID[:]...)
+	buf = append(buf, h.PositioningATX[:]...)
+	buf = append(buf, h.Coinbase[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, h.NumUnits)
+	buf = binary.LittleEndian.AppendUint32(buf, h.EffectiveNumUnits)
+	buf = append(buf, h.NodeID[:]...)
+	buf = binary.LittleEndian.AppendUint64(buf, h.BaseTickHeight)
+	buf = binary.LittleEndian.AppendUint64(buf, h.TickCount)
+	if flags&flagReceived != 0 {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(h.Received.UnixNano()))
+	}
+	if flags&flagCommitment != 0 {
+		buf = append(buf, h.CommitmentATX[:]...)
+	}
+	if flags&flagVRFNonce != 0 {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(*h.VRFNonce))
+	}
+	if flags&flagInitialPost != 0 {
+		post, err := codec.Encode(h.InitialPost)
+		if err != nil {
+			return nil, fmt.Errorf("encode initial post: %w", err)
+		}
+		buf = append(buf, post...)
+	}
+	return buf, nil
+}
+
+type headerReader struct {
+	data []byte
+	err  error
+}
+
+func (r *headerReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = fmt.Errorf("encoded header is too short: %d < %d", len(r.data), n)
+		return make([]byte, n)
+	}
+	rst := r.data[:n]
+	r.data = r.data[n:]
+	return rst
+}
+
+func (r *headerReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *headerReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+func decodeHeader(id types.ATXID, data []byte) (*types.ActivationTxHeader, error) {
+	r := headerReader{data: data}
+	h := &types.ActivationTxHeader{ID: id}
+	flags := r.next(1)[0]
+	h.Golden = flags&flagGolden != 0
+	h.PublishEpoch = types.EpochID(r.uint32())
+	h.Sequence = r.uint64()
+	copy(h.PrevATXID[:], r.next(len(h.PrevATXID)))
+	copy(h.PositioningATX[:], r.next(len(h.PositioningATX)))
+	copy(h.Coinbase[:], r.next(len(h.Coinbase)))
+	h.NumUnits = r.uint32()
+	h.EffectiveNumUnits = r.uint32()
+	copy(h.NodeID[:], r.next(len(h.NodeID)))
+	h.BaseTickHeight = r.uint64()
+	h.TickCount = r.uint64()
+	if flags&flagReceived != 0 {
+		h.Received = time.Unix(0, int64(r.uint64())).Local()
+	}
+	if flags&flagCommitment != 0 {
+		var commitment types.ATXID
+		copy(commitment[:], r.next(len(commitment)))
+		h.CommitmentATX = &commitment
+	}
+	if flags&flagVRFNonce != 0 {
+		nonce := types.VRFPostIndex(r.uint64())
+		h.VRFNonce = &nonce
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if flags&flagInitialPost != 0 {
+		var post types.Post
+		if err := codec.Decode(r.data, &post); err != nil {
+			return nil, fmt.Errorf("decode initial post: %w", err)
+		}
+		h.InitialPost = &post
+	} else if len(r.data) != 0 {
+		return nil, fmt.Errorf("encoded header has %d trailing bytes", len(r.data))
+	}
+	return h, nil
+}
@@ -0,0 +1,106 @@
+package datastore
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func randomHeader(epoch types.EpochID) *types.ActivationTxHeader {
+	return &types.ActivationTxHeader{
+		NIPostChallenge: types.NIPostChallenge{
+			PublishEpoch:   epoch,
+			Sequence:       3,
+			PrevATXID:      types.RandomATXID(),
+			PositioningATX: types.RandomATXID(),
+		},
+		Coinbase:          types.Address{1, 2, 3},
+		NumUnits:          4,
+		EffectiveNumUnits: 3,
+		ID:                types.RandomATXID(),
+		NodeID:            types.RandomNodeID(),
+		BaseTickHeight:    100,
+		TickCount:         10,
+		Received:          time.Unix(0, time.Now().UnixNano()).Local(),
+	}
+}
+
+func TestAtxHeaderCache_Encoding(t *testing.T) {
+	header := randomHeader(5)
+	data, err := encodeHeader(header)
+	require.NoError(t, err)
+	decoded, err := decodeHeader(header.ID, data)
+	require.NoError(t, err)
+	require.Equal(t, header, decoded)
+
+	commitment := types.RandomATXID()
+	nonce := types.VRFPostIndex(7)
+	header.CommitmentATX = &commitment
+	header.VRFNonce = &nonce
+	header.InitialPost = &types.Post{Nonce: 1, Indices: []byte{1, 2, 3}, Pow: 5}
+	header.Golden = true
+	header.Received = time.Time{}
+	data, err = encodeHeader(header)
+	require.NoError(t, err)
+	decoded, err = decodeHeader(header.ID, data)
+	require.NoError(t, err)
+	require.Equal(t, header, decoded)
+
+	_, err = decodeHeader(header.ID, data[:50])
+	require.Error(t, err)
+}
+
+func TestAtxHeaderCache_Budget(t *testing.T) {
+	arenas := []func() (arena, error){
+		func() (arena, error) { return &heapArena{}, nil },
+	}
+	if runtime.GOOS != "windows" {
+		dir := t.TempDir()
+		arenas = append(arenas, func() (arena, error) { return newMmapArena(dir) })
+	}
+	for _, newArena := range arenas {
+		header := randomHeader(1)
+		data, err := encodeHeader(header)
+		require.NoError(t, err)
+		entry := len(data) + headerOverhead
+		// fits 3 headers
+		cache := newAtxHeaderCache(3*entry+entry/2, newArena)
+
+		e1 := []*types.ActivationTxHeader{randomHeader(1), randomHeader(1)}
+		for _, h := range e1 {
+			require.NoError(t, cache.Add(h))
+		}
+		e2 := randomHeader(2)
+		require.NoError(t, cache.Add(e2))
+		require.Equal(t, 3, cache.Len())
+		got, ok := cache.Get(e1[0].ID)
+		require.True(t, ok)
+		require.Equal(t, e1[0], got)
+
+		// headers of the oldest epoch are evicted together
+		e3 := randomHeader(3)
+		require.NoError(t, cache.Add(e3))
+		require.Equal(t, 2, cache.Len())
+		_, ok = cache.Get(e1[0].ID)
+		require.False(t, ok)
+		_, ok = cache.Get(e1[1].ID)
+		require.False(t, ok)
+		for _, h := range []*types.ActivationTxHeader{e2, e3} {
+			got, ok := cache.Get(h.ID)
+			require.True(t, ok)
+			require.Equal(t, h, got)
+		}
+
+		// older epochs are not cached when the budget is exhausted
+		require.NoError(t, cache.Add(randomHeader(3)))
+		old := randomHeader(1)
+		require.NoError(t, cache.Add(old))
+		_, ok = cache.Get(old.ID)
+		require.False(t, ok)
+		require.Equal(t, 3, cache.Len())
+	}
+}
@@ -0,0 +1,37 @@
+package datastore
+
+import "github.com/spacemeshos/go-spacemesh/metrics"
+
+const subsystem = "datastore"
+
+var (
+	atxHeaderCacheLookups = metrics.NewCounter(
+		"atx_header_cache_lookups",
+		subsystem,
+		"lookups in the atx header cache",
+		[]string{"result"},
+	)
+	atxHeaderCacheHits   = atxHeaderCacheLookups.WithLabelValues("hit")
+	atxHeaderCacheMisses = atxHeaderCacheLookups.WithLabelValues("miss")
+
+	atxHeaderCacheEvictions = metrics.NewCounter(
+		"atx_header_cache_evictions",
+		subsystem,
+		"atx headers evicted from the cache",
+		[]string{},
+	).WithLabelValues()
+
+	atxHeaderCacheBytes = metrics.NewGauge(
+		"atx_header_cache_bytes",
+		subsystem,
+		"approximate memory used by the atx header cache",
+		[]string{},
+	).WithLabelValues()
+
+	atxHeaderCacheEntries = metrics.NewGauge(
+		"atx_header_cache_entries",
+		subsystem,
+		"atx headers in the cache",
+		[]string{},
+	).WithLabelValues()
+)
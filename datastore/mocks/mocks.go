@@ -117,6 +117,44 @@ func (c *MockExecutorQueryCacheCall) DoAndReturn(f func() sql.QueryCache) *MockE
 	return c
 }
 
+// WithSavepoint mocks base method.
+func (m *MockExecutor) WithSavepoint(exec func(sql.Executor) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithSavepoint", exec)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithSavepoint indicates an expected call of WithSavepoint.
+func (mr *MockExecutorMockRecorder) WithSavepoint(exec any) *MockExecutorWithSavepointCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithSavepoint", reflect.TypeOf((*MockExecutor)(nil).WithSavepoint), exec)
+	return &MockExecutorWithSavepointCall{Call: call}
+}
+
+// MockExecutorWithSavepointCall wrap *gomock.Call
+type MockExecutorWithSavepointCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockExecutorWithSavepointCall) Return(arg0 error) *MockExecutorWithSavepointCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockExecutorWithSavepointCall) Do(f func(func(sql.Executor) error) error) *MockExecutorWithSavepointCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockExecutorWithSavepointCall) DoAndReturn(f func(func(sql.Executor) error) error) *MockExecutorWithSavepointCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // WithTx mocks base method.
 func (m *MockExecutor) WithTx(arg0 context.Context, arg1 func(*sql.Tx) error) error {
 	m.ctrl.T.Helper()
@@ -45,7 +45,7 @@ type CachedDB struct {
 	// cache is optional
 	atxsdata *atxsdata.Data
 
-	atxHdrCache   *lru.Cache[types.ATXID, *types.ActivationTxHeader]
+	atxHdrCache   *atxHeaderCache
 	vrfNonceCache *lru.Cache[VrfNonceKey, *types.VRFPostIndex]
 
 	// used to coordinate db update and cache
@@ -54,15 +54,23 @@ type CachedDB struct {
 }
 
 type Config struct {
-	// ATXSize must be larger than the sum of all ATXs in last 2 epochs to be effective
+	// ATXSize must be larger than the sum of all ATXs in last 2 epochs for the vrf nonce cache to be effective
 	ATXSize         int `mapstructure:"atx-size"`
 	MalfeasanceSize int `mapstructure:"malfeasance-size"`
+
+	// ATXHeadersBudget is the memory in bytes used by the atx header cache. It should fit the headers
+	// of the atxs in last 2 epochs (about 280 bytes per atx), headers of the oldest epochs are evicted first.
+	ATXHeadersBudget int `mapstructure:"atx-headers-budget"`
+	// ATXHeadersMmapDir is the directory for the memory mapped files with the cached atx headers.
+	// If empty, the headers are kept on the heap.
+	ATXHeadersMmapDir string `mapstructure:"atx-headers-mmap-dir"`
 }
 
 func DefaultConfig() Config {
 	return Config{
-		ATXSize:         4_400_000, // to be in line with 2*`EpochData` size (see fetch/wire_types.go) - see comment above
-		MalfeasanceSize: 1_000,
+		ATXSize:          4_400_000, // to be in line with 2*`EpochData` size (see fetch/wire_types.go) - see comment above
+		MalfeasanceSize:  1_000,
+		ATXHeadersBudget: 4_400_000 * 280, // fits as many headers as ATXSize
 	}
 }
 
@@ -93,10 +101,11 @@ func NewCachedDB(db Executor, lg log.Log, opts ...Opt) *CachedDB {
 	}
 	lg.With().Info("initialized datastore", log.Any("config", o.cfg))
 
-	atxHdrCache, err := lru.New[types.ATXID, *types.ActivationTxHeader](o.cfg.ATXSize)
-	if err != nil {
-		lg.Fatal("failed to create atx cache", err)
+	newArena := func() (arena, error) { return &heapArena{}, nil }
+	if dir := o.cfg.ATXHeadersMmapDir; dir != "" {
+		newArena = func() (arena, error) { return newMmapArena(dir) }
 	}
+	atxHdrCache := newAtxHeaderCache(o.cfg.ATXHeadersBudget, newArena)
 
 	malfeasanceCache, err := lru.New[types.NodeID, *types.MalfeasanceProof](o.cfg.MalfeasanceSize)
 	if err != nil {
@@ -201,6 +210,12 @@ func (db *CachedDB) VRFNonce(id types.NodeID, epoch types.EpochID) (types.VRFPos
 	return nonce, nil
 }
 
+// VRFKey returns the vrf key of the given node in the given epoch, it is the node id if the node didn't rotate the key.
+// Rotations are rare and indexed separately, the key is not cached.
+func (db *CachedDB) VRFKey(id types.NodeID, epoch types.EpochID) (types.NodeID, error) {
+	return atxs.VRFKey(db, id, epoch)
+}
+
 // GetAtxHeader returns the ATX header by the given ID. This function is thread safe and will return an error if the ID
 // is not found in the ATX DB.
 func (db *CachedDB) GetAtxHeader(id types.ATXID) (*types.ActivationTxHeader, error) {
@@ -227,23 +242,23 @@ func (db *CachedDB) GetFullAtx(id types.ATXID) (*types.VerifiedActivationTx, err
 		return nil, fmt.Errorf("get ATXs from DB: %w", err)
 	}
 
-	db.atxHdrCache.Add(id, atx.ToHeader())
+	db.cacheHeader(atx.ToHeader())
 	return atx, nil
 }
 
-// getAndCacheHeader fetches the full atx struct from the database, caches it and returns the cached header.
+func (db *CachedDB) cacheHeader(header *types.ActivationTxHeader) {
+	if err := db.atxHdrCache.Add(header); err != nil {
+		db.logger.With().Warning("failed to cache atx header", header.ID, log.Err(err))
+	}
+}
+
+// getAndCacheHeader fetches the full atx struct from the database, caches and returns its header.
 func (db *CachedDB) getAndCacheHeader(id types.ATXID) (*types.ActivationTxHeader, error) {
-	_, err := db.GetFullAtx(id)
+	atx, err := db.GetFullAtx(id)
 	if err != nil {
 		return nil, err
 	}
-
-	atxHeader, gotIt := db.atxHdrCache.Get(id)
-	if !gotIt {
-		return nil, fmt.Errorf("inconsistent state: failed to get atx header: %w", err)
-	}
-
-	return atxHeader, nil
+	return atx.ToHeader(), nil
 }
 
 // GetEpochWeight returns the total weight of ATXs targeting the given epochID.
@@ -323,7 +338,7 @@ func (db *CachedDB) GetEpochAtx(
 		return nil, fmt.Errorf("no epoch atx found: %w", err)
 	}
 	header := vatx.ToHeader()
-	db.atxHdrCache.Add(vatx.ID(), header)
+	db.cacheHeader(header)
 	return header, nil
 }
 
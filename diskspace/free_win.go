@@ -0,0 +1,20 @@
+//go:build windows
+
+package diskspace
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the number of bytes available to the current user on the volume with the path.
+func freeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
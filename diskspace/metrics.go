@@ -0,0 +1,38 @@
+package diskspace
+
+import "github.com/spacemeshos/go-spacemesh/metrics"
+
+const subsystem = "disk"
+
+var (
+	usedBytes = metrics.NewGauge(
+		"used_bytes",
+		subsystem,
+		"size of the monitored directory in bytes",
+		[]string{"path"},
+	)
+	freeBytes = metrics.NewGauge(
+		"free_bytes",
+		subsystem,
+		"free space on the volume with the monitored directory in bytes",
+		[]string{"path"},
+	)
+	growthRate = metrics.NewGauge(
+		"growth_bytes_per_second",
+		subsystem,
+		"growth rate of the monitored directory",
+		[]string{"path"},
+	)
+	timeToFull = metrics.NewGauge(
+		"time_to_full_seconds",
+		subsystem,
+		"forecasted time until the volume is full, 0 if the directory doesn't grow",
+		[]string{"path"},
+	)
+	protectiveMode = metrics.NewGauge(
+		"protective_mode",
+		subsystem,
+		"1 if the node is in the protective mode because of low disk space",
+		[]string{},
+	).WithLabelValues()
+)
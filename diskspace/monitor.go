@@ -0,0 +1,325 @@
+// Package diskspace forecasts when the disk with the node data will be full.
+//
+// Sqlite database may be corrupted if the disk fills up in the middle of a write. Monitor tracks
+// the growth of the database and post directories, warns the operator ahead of time and switches
+// the node into the protective mode, in which the node stops accepting non-essential data and
+// pauses post initialization, before the disk is actually full.
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+// ErrProtectiveMode is returned for data that is not accepted in the protective mode.
+var ErrProtectiveMode = errors.New("diskspace: node is in the protective mode")
+
+// Config for Monitor.
+type Config struct {
+	Disable bool `mapstructure:"disable"`
+	// Interval between measurements.
+	Interval time.Duration `mapstructure:"interval"`
+	// Window over which the growth rate is computed.
+	Window time.Duration `mapstructure:"window"`
+	// WarnTimeToFull is the forecasted time until the disk is full when the operator is warned.
+	WarnTimeToFull time.Duration `mapstructure:"warn-time-to-full"`
+	// ProtectTimeToFull is the forecasted time until the disk is full when the node enters the protective mode.
+	ProtectTimeToFull time.Duration `mapstructure:"protect-time-to-full"`
+	// MinFree is the free space in bytes below which the node enters the protective mode regardless
+	// of the forecast. The operator is warned when free space drops below twice this value.
+	MinFree uint64 `mapstructure:"min-free"`
+}
+
+// DefaultConfig for Monitor.
+func DefaultConfig() Config {
+	return Config{
+		Interval:          10 * time.Minute,
+		Window:            24 * time.Hour,
+		WarnTimeToFull:    7 * 24 * time.Hour,
+		ProtectTimeToFull: 24 * time.Hour,
+		MinFree:           2 << 30, // 2 GiB
+	}
+}
+
+// Forecast for a single monitored directory.
+type Forecast struct {
+	Name string
+	Dir  string
+	// Used is the size of the directory in bytes.
+	Used uint64
+	// Free is the free space on the volume with the directory in bytes.
+	Free uint64
+	// GrowthRate of the directory in bytes per second over the configured window.
+	GrowthRate float64
+	// TimeToFull is the forecasted time until the volume is full, 0 if the directory doesn't grow.
+	TimeToFull time.Duration
+}
+
+type sample struct {
+	at   time.Time
+	used uint64
+}
+
+type path struct {
+	name, dir string
+	samples   []sample
+}
+
+// Opt modifies Monitor.
+type Opt func(*Monitor)
+
+// WithConfig modifies config used by Monitor.
+func WithConfig(cfg Config) Opt {
+	return func(m *Monitor) {
+		m.cfg = cfg
+	}
+}
+
+// WithLogger modifies logger used by Monitor.
+func WithLogger(logger *zap.Logger) Opt {
+	return func(m *Monitor) {
+		m.logger = logger
+	}
+}
+
+// WithPath adds the directory to the monitored directories.
+func WithPath(name, dir string) Opt {
+	return func(m *Monitor) {
+		m.paths = append(m.paths, &path{name: name, dir: dir})
+	}
+}
+
+// Monitor periodically measures monitored directories and free space on their volumes.
+type Monitor struct {
+	logger *zap.Logger
+	cfg    Config
+
+	// used, free and now are replaced in tests.
+	used func(string) (uint64, error)
+	free func(string) (uint64, error)
+	now  func() time.Time
+
+	mu        sync.Mutex
+	paths     []*path
+	forecasts []Forecast
+	warned    bool
+	callbacks []func(protected bool)
+
+	protected atomic.Bool
+}
+
+// New creates Monitor.
+func New(opts ...Opt) *Monitor {
+	m := &Monitor{
+		logger: zap.NewNop(),
+		cfg:    DefaultConfig(),
+		used:   dirSize,
+		free:   freeSpace,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// OnProtectiveMode registers the callback that is called when the node enters (with true)
+// or leaves (with false) the protective mode.
+func (m *Monitor) OnProtectiveMode(cb func(protected bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.callbacks = append(m.callbacks, cb)
+}
+
+// Protected returns true if the node is in the protective mode.
+func (m *Monitor) Protected() bool {
+	return m.protected.Load()
+}
+
+// Forecasts returns the forecasts computed by the most recent check.
+func (m *Monitor) Forecasts() []Forecast {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Forecast(nil), m.forecasts...)
+}
+
+// NonEssentialGossip is a gossip handler that ignores messages in the protective mode.
+// It is chained before handlers of the data that the node can live without.
+func (m *Monitor) NonEssentialGossip(context.Context, p2p.Peer, []byte) error {
+	if m.Protected() {
+		return ErrProtectiveMode
+	}
+	return nil
+}
+
+// Run checks disk space every interval until the context is canceled.
+func (m *Monitor) Run(ctx context.Context) error {
+	m.logger.Info("started disk space monitor", zap.Duration("interval", m.cfg.Interval))
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Check(); err != nil {
+			m.logger.Warn("failed to check disk space", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check measures all monitored directories, updates forecasts and switches the protective mode.
+func (m *Monitor) Check() ([]Forecast, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	forecasts := make([]Forecast, 0, len(m.paths))
+	for _, p := range m.paths {
+		forecast, err := m.measure(now, p)
+		if err != nil {
+			return nil, err
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	m.forecasts = forecasts
+
+	var warn, protect bool
+	for _, f := range forecasts {
+		switch {
+		case f.Free < m.cfg.MinFree,
+			f.TimeToFull > 0 && f.TimeToFull < m.cfg.ProtectTimeToFull:
+			protect = true
+		case f.Free < 2*m.cfg.MinFree,
+			f.TimeToFull > 0 && f.TimeToFull < m.cfg.WarnTimeToFull:
+			warn = true
+		}
+	}
+	m.transition(forecasts, warn, protect)
+	return forecasts, nil
+}
+
+func (m *Monitor) measure(now time.Time, p *path) (Forecast, error) {
+	used, err := m.used(p.dir)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("size of %s: %w", p.dir, err)
+	}
+	free, err := m.free(p.dir)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("free space for %s: %w", p.dir, err)
+	}
+	p.samples = append(p.samples, sample{at: now, used: used})
+	// keep the newest sample that is older than the window, so that the rate
+	// is computed over the whole window
+	for len(p.samples) > 2 && now.Sub(p.samples[1].at) >= m.cfg.Window {
+		p.samples = p.samples[1:]
+	}
+	forecast := Forecast{Name: p.name, Dir: p.dir, Used: used, Free: free}
+	first := p.samples[0]
+	if elapsed := now.Sub(first.at); elapsed > 0 && used > first.used {
+		forecast.GrowthRate = float64(used-first.used) / elapsed.Seconds()
+		forecast.TimeToFull = time.Duration(float64(free) / forecast.GrowthRate * float64(time.Second))
+	}
+
+	usedBytes.WithLabelValues(p.name).Set(float64(used))
+	freeBytes.WithLabelValues(p.name).Set(float64(free))
+	growthRate.WithLabelValues(p.name).Set(forecast.GrowthRate)
+	timeToFull.WithLabelValues(p.name).Set(forecast.TimeToFull.Seconds())
+	return forecast, nil
+}
+
+func (m *Monitor) transition(forecasts []Forecast, warn, protect bool) {
+	fields := make([]zap.Field, 0, len(forecasts))
+	for _, f := range forecasts {
+		fields = append(fields, zap.Object(f.Name, f))
+	}
+	switch {
+	case protect && !m.protected.Load():
+		m.logger.Error("disk is almost full, entering protective mode", fields...)
+		m.protected.Store(true)
+		protectiveMode.Set(1)
+		events.ReportError(events.NodeError{
+			Msg: "disk is almost full. node stopped accepting non-essential data and paused post initialization " +
+				"until disk space is freed",
+			Level: zapcore.ErrorLevel,
+		})
+		m.notify(true)
+	case !protect && m.protected.Load():
+		m.logger.Info("disk space is sufficient, leaving protective mode", fields...)
+		m.protected.Store(false)
+		protectiveMode.Set(0)
+		events.ReportError(events.NodeError{
+			Msg:   "disk space is sufficient, node left the protective mode",
+			Level: zapcore.InfoLevel,
+		})
+		m.notify(false)
+	}
+	switch {
+	case warn && !protect && !m.warned:
+		m.logger.Warn("disk will be full soon", fields...)
+		events.ReportError(events.NodeError{
+			Msg: fmt.Sprintf("disk will be full soon, node will enter the protective mode when less than %s "+
+				"remain until the disk is full or less than %d MiB are free",
+				m.cfg.ProtectTimeToFull, m.cfg.MinFree>>20),
+			Level: zapcore.WarnLevel,
+		})
+	case !warn && !protect && m.warned:
+		m.logger.Info("disk space is sufficient", fields...)
+	}
+	// warning is not reported after the node leaves the protective mode until disk space is sufficient
+	m.warned = warn || protect
+}
+
+func (m *Monitor) notify(protected bool) {
+	for _, cb := range m.callbacks {
+		cb(protected)
+	}
+}
+
+// MarshalLogObject implements logging interface.
+func (f Forecast) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("dir", f.Dir)
+	encoder.AddUint64("used", f.Used)
+	encoder.AddUint64("free", f.Free)
+	encoder.AddFloat64("growth_rate", f.GrowthRate)
+	encoder.AddDuration("time_to_full", f.TimeToFull)
+	return nil
+}
+
+// dirSize returns the total size of regular files in the directory.
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// file was removed while walking the directory
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
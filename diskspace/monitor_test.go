@@ -0,0 +1,155 @@
+package diskspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+)
+
+type fakeDisk struct {
+	now  time.Time
+	used uint64
+	free uint64
+}
+
+func (d *fakeDisk) grow(elapsed time.Duration, size uint64) {
+	d.now = d.now.Add(elapsed)
+	d.used += size
+	d.free -= size
+}
+
+func newTestMonitor(tb testing.TB, cfg Config, disk *fakeDisk) *Monitor {
+	m := New(WithConfig(cfg), WithLogger(zaptest.NewLogger(tb)), WithPath("database", tb.TempDir()))
+	m.used = func(string) (uint64, error) { return disk.used, nil }
+	m.free = func(string) (uint64, error) { return disk.free, nil }
+	m.now = func() time.Time { return disk.now }
+	return m
+}
+
+func nextError(tb testing.TB, sub events.Subscription) events.NodeError {
+	tb.Helper()
+	select {
+	case ev := <-sub.Out():
+		return ev.(events.NodeError)
+	case <-time.After(time.Second):
+		require.FailNow(tb, "timed out waiting for event")
+	}
+	return events.NodeError{}
+}
+
+func TestMonitor_Forecast(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	sub := events.SubscribeErrors()
+
+	cfg := DefaultConfig()
+	cfg.MinFree = 1 << 20
+	disk := &fakeDisk{now: time.Now(), used: 1 << 20, free: 100 << 20}
+	m := newTestMonitor(t, cfg, disk)
+	var transitions []bool
+	m.OnProtectiveMode(func(protected bool) {
+		transitions = append(transitions, protected)
+	})
+
+	forecasts, err := m.Check()
+	require.NoError(t, err)
+	require.Len(t, forecasts, 1)
+	require.Zero(t, forecasts[0].GrowthRate)
+	require.Zero(t, forecasts[0].TimeToFull)
+
+	// 1 MiB per hour, 98 MiB are free
+	disk.grow(time.Hour, 1<<20)
+	forecasts, err = m.Check()
+	require.NoError(t, err)
+	require.Equal(t, float64(1<<20)/3600, forecasts[0].GrowthRate)
+	require.Equal(t, 99*time.Hour, forecasts[0].TimeToFull)
+	require.Equal(t, forecasts, m.Forecasts())
+
+	ev := nextError(t, sub)
+	require.Equal(t, zapcore.WarnLevel, ev.Level)
+	require.Contains(t, ev.Msg, "disk will be full soon")
+	require.False(t, m.Protected())
+	require.NoError(t, m.NonEssentialGossip(context.Background(), "", nil))
+
+	// growth accelerated, less than a day until the disk is full
+	disk.grow(time.Hour, 50<<20)
+	_, err = m.Check()
+	require.NoError(t, err)
+	ev = nextError(t, sub)
+	require.Equal(t, zapcore.ErrorLevel, ev.Level)
+	require.True(t, m.Protected())
+	require.ErrorIs(t, m.NonEssentialGossip(context.Background(), "", nil), ErrProtectiveMode)
+	require.Equal(t, []bool{true}, transitions)
+
+	// operator freed disk space
+	disk.free += 1 << 40
+	disk.now = disk.now.Add(time.Hour)
+	_, err = m.Check()
+	require.NoError(t, err)
+	ev = nextError(t, sub)
+	require.Equal(t, zapcore.InfoLevel, ev.Level)
+	require.False(t, m.Protected())
+	require.Equal(t, []bool{true, false}, transitions)
+
+	select {
+	case ev := <-sub.Out():
+		require.FailNow(t, "unexpected event", "%v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMonitor_MinFree(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinFree = 10 << 20
+	disk := &fakeDisk{now: time.Now(), used: 1 << 20, free: 5 << 20}
+	m := newTestMonitor(t, cfg, disk)
+	_, err := m.Check()
+	require.NoError(t, err)
+	require.True(t, m.Protected())
+}
+
+func TestMonitor_Window(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Window = 3 * time.Hour
+	disk := &fakeDisk{now: time.Now(), free: 1 << 40}
+	m := newTestMonitor(t, cfg, disk)
+
+	// fast growth in the first hour is outside the window after 4 hours
+	_, err := m.Check()
+	require.NoError(t, err)
+	disk.grow(time.Hour, 50<<20)
+	for i := 0; i < 3; i++ {
+		_, err := m.Check()
+		require.NoError(t, err)
+		disk.grow(time.Hour, 1<<20)
+	}
+	forecasts, err := m.Check()
+	require.NoError(t, err)
+	require.Equal(t, float64(1<<20)/3600, forecasts[0].GrowthRate)
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 50), 0o600))
+	size, err := dirSize(dir)
+	require.NoError(t, err)
+	require.EqualValues(t, 150, size)
+
+	size, err = dirSize(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	free, err := freeSpace(dir)
+	require.NoError(t, err)
+	require.NotZero(t, free)
+}
@@ -1,6 +1,8 @@
 package events
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
@@ -13,7 +15,13 @@ import (
 )
 
 type UserEvent struct {
+	// Seq is a sequence number of the event. Events emitted by the node are numbered consecutively,
+	// numbering is preserved across restarts if the events journal is enabled.
+	Seq   uint64
 	Event *pb.Event
+	// Severity and Cause are not part of the api event, they are used to filter events for alerting.
+	Severity Severity
+	Cause    Cause
 }
 
 func EmitBeacon(epoch types.EpochID, beacon types.Beacon) {
@@ -46,9 +54,10 @@ func EmitInitStart(smesher types.NodeID, commitment types.ATXID) {
 
 func EmitInitFailure(smesher types.NodeID, commitment types.ATXID, err error) {
 	const help = "Node failed PoST data initialization."
-	emitUserEvent(
+	emitAlert(
 		help,
-		true,
+		SeverityCritical,
+		CauseInitFailed,
 		&pb.Event_InitFailed{
 			InitFailed: &pb.EventInitFailed{
 				Smesher:    smesher[:],
@@ -157,9 +166,10 @@ func EmitPostComplete(nodeID types.NodeID, challenge []byte) {
 
 func EmitPostFailure(nodeID types.NodeID) {
 	const help = "Node failed PoST execution."
-	emitUserEvent(
+	emitAlert(
 		help,
-		true,
+		SeverityCritical,
+		CausePostFailed,
 		&pb.Event_PostComplete{
 			PostComplete: &pb.EventPostComplete{
 				Smesher: nodeID.Bytes(),
@@ -170,9 +180,10 @@ func EmitPostFailure(nodeID types.NodeID) {
 
 func EmitInvalidPostProof() {
 	const help = "Node generated invalid POST proof. Please verify your POST data."
-	emitUserEvent(
+	emitAlert(
 		help,
-		true,
+		SeverityCritical,
+		CauseInvalidPost,
 		&pb.Event_PostComplete{PostComplete: &pb.EventPostComplete{}},
 	)
 }
@@ -199,6 +210,95 @@ func EmitAtxPublished(
 	)
 }
 
+func EmitAtxMismatch(current, target types.EpochID, id types.ATXID, mismatched []string) {
+	help := "Activation stored after publication doesn't match the activation built by the node. " +
+		"Mismatched fields: " + strings.Join(mismatched, ", ") + ". " +
+		"Please verify your PoST service and report the issue."
+	emitAlert(
+		help,
+		SeverityCritical,
+		CauseAtxMismatch,
+		&pb.Event_AtxPublished{
+			AtxPublished: &pb.EventAtxPubished{
+				Current: current.Uint32(),
+				Target:  target.Uint32(),
+				Id:      id[:],
+			},
+		},
+	)
+}
+
+// EmitAtxRetargeted is emitted when the atx couldn't be published in the publish epoch of its challenge
+// and the challenge was retargeted to a later publish epoch instead of being discarded.
+func EmitAtxRetargeted(expired, publish types.EpochID, roundStart time.Time) {
+	help := fmt.Sprintf("Activation for publish epoch %d couldn't be completed in time. "+
+		"The same challenge was retargeted to publish epoch %d and will be submitted to PoET again.",
+		expired, publish)
+	emitUserEvent(
+		help,
+		false,
+		&pb.Event_PoetWaitRound{PoetWaitRound: &pb.EventPoetWaitRound{
+			Current: (publish - 1).Uint32(),
+			Publish: publish.Uint32(),
+			Wait:    durationpb.New(time.Until(roundStart)),
+			Until:   timestamppb.New(roundStart),
+		}},
+	)
+}
+
+// EmitAtxChallengeDiscarded is emitted when the atx couldn't be published in the publish epoch of its challenge
+// and the challenge was discarded. A new challenge is built for the next poet round.
+func EmitAtxChallengeDiscarded(expired, current types.EpochID, reason string) {
+	help := fmt.Sprintf("Activation for publish epoch %d couldn't be completed in time "+
+		"and its challenge was discarded (%s). A new challenge will be built for the next PoET round.",
+		expired, reason)
+	emitAlert(
+		help,
+		SeverityWarning,
+		CauseAtxChallengeDiscarded,
+		&pb.Event_PoetWaitRound{PoetWaitRound: &pb.EventPoetWaitRound{
+			Current: current.Uint32(),
+			Publish: expired.Uint32(),
+		}},
+	)
+}
+
+// EmitWatchedAtxMissing is emitted when the watched identity didn't publish an atx in the epoch.
+func EmitWatchedAtxMissing(id types.NodeID, publish types.EpochID) {
+	help := fmt.Sprintf("Watched identity %s didn't publish an activation in epoch %d "+
+		"and will not be eligible for rewards in epoch %d.", id.ShortString(), publish, publish+1)
+	emitAlert(
+		help,
+		SeverityWarning,
+		CauseWatchedAtxMissing,
+		&pb.Event_AtxPublished{
+			AtxPublished: &pb.EventAtxPubished{
+				Current: publish.Uint32(),
+				Target:  (publish + 1).Uint32(),
+			},
+		},
+	)
+}
+
+// EmitWatchedAtxUnexpected is emitted when an activation of the watched identity appears
+// that wasn't expected to be published.
+func EmitWatchedAtxUnexpected(id types.NodeID, publish types.EpochID, atx types.ATXID, reason string) {
+	help := fmt.Sprintf("Unexpected activation of watched identity %s in epoch %d (%s). "+
+		"The key of the identity might be compromised.", id.ShortString(), publish, reason)
+	emitAlert(
+		help,
+		SeverityCritical,
+		CauseWatchedAtxUnexpected,
+		&pb.Event_AtxPublished{
+			AtxPublished: &pb.EventAtxPubished{
+				Current: publish.Uint32(),
+				Target:  (publish + 1).Uint32(),
+				Id:      atx[:],
+			},
+		},
+	)
+}
+
 func EmitEligibilities(
 	epoch types.EpochID,
 	beacon types.Beacon,
@@ -251,9 +351,10 @@ func EmitProposal(layer types.LayerID, proposal types.ProposalID) {
 
 func EmitOwnMalfeasanceProof(id types.NodeID, mp *types.MalfeasanceProof) {
 	const help = "Node committed malicious behavior. Identity will be canceled."
-	emitUserEvent(
+	emitAlert(
 		help,
-		false,
+		SeverityCritical,
+		CauseMalfeasance,
 		&pb.Event_Malfeasance{
 			Malfeasance: &pb.EventMalfeasance{
 				Proof: ToMalfeasancePB(id, mp, false),
@@ -262,16 +363,78 @@ func EmitOwnMalfeasanceProof(id types.NodeID, mp *types.MalfeasanceProof) {
 	)
 }
 
+func EmitSmeshingCanceled(id types.NodeID, mp *types.MalfeasanceProof) {
+	const help = "Identity was proven malicious. Smeshing with this identity is canceled, " +
+		"it will not be eligible for rewards anymore."
+	emitAlert(
+		help,
+		SeverityCritical,
+		CauseSmeshingCanceled,
+		&pb.Event_Malfeasance{
+			Malfeasance: &pb.EventMalfeasance{
+				Proof: ToMalfeasancePB(id, mp, false),
+			},
+		},
+	)
+}
+
+// EmitPoetUnreachable is emitted when the challenge of the identity couldn't be submitted to any poet
+// before the poet round started.
+func EmitPoetUnreachable(id types.NodeID, publish types.EpochID, err error) {
+	help := fmt.Sprintf("Node failed to submit the challenge of identity %s to any PoET for publish epoch %d (%s). "+
+		"Please verify that the configured PoET services are reachable.", id.ShortString(), publish, err)
+	emitAlert(
+		help,
+		SeverityWarning,
+		CausePoetUnreachable,
+		&pb.Event_PoetWaitRound{PoetWaitRound: &pb.EventPoetWaitRound{
+			Current: (publish - 1).Uint32(),
+			Publish: publish.Uint32(),
+		}},
+	)
+}
+
+// EmitClockSkew is emitted when the offset between the local clock and the clocks of peers is larger
+// than allowed. If the offset persists, the node exits and the event is critical.
+func EmitClockSkew(offset, maxOffset time.Duration, critical bool) {
+	help := fmt.Sprintf("Local clock differs from the clocks of peers by %s, more than allowed %s. "+
+		"Please make sure that the system clock is synchronized.", offset, maxOffset)
+	severity := SeverityWarning
+	if critical {
+		severity = SeverityCritical
+	}
+	emitAlert(help, severity, CauseClockSkew, nil)
+}
+
+// emitUserEvent emits the event without a cause, failures have warning severity.
 func emitUserEvent(help string, failure bool, details pb.IsEventDetails) {
+	severity := SeverityInfo
+	if failure {
+		severity = SeverityWarning
+	}
+	emit(help, failure, severity, "", details)
+}
+
+// emitAlert emits the failure event with the given severity and cause.
+func emitAlert(help string, severity Severity, cause Cause, details pb.IsEventDetails) {
+	emit(help, true, severity, cause, details)
+}
+
+func emit(help string, failure bool, severity Severity, cause Cause, details pb.IsEventDetails) {
 	mu.RLock()
 	defer mu.RUnlock()
 	if reporter != nil {
-		if err := reporter.emitUserEvent(UserEvent{Event: &pb.Event{
-			Timestamp: timestamppb.New(time.Now()),
-			Help:      help,
-			Failure:   failure,
-			Details:   details,
-		}}); err != nil {
+		ev := UserEvent{
+			Event: &pb.Event{
+				Timestamp: timestamppb.New(time.Now()),
+				Help:      help,
+				Failure:   failure,
+				Details:   details,
+			},
+			Severity: severity,
+			Cause:    cause,
+		}
+		if err := reporter.emitUserEvent(ev); err != nil {
 			log.With().Error("failed to emit event", log.Err(err))
 		}
 	}
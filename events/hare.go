@@ -0,0 +1,18 @@
+package events
+
+import (
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
+)
+
+// ReportHareResult reports the outcome of the hare session.
+// Results can be consumed with Subscribe[hareresults.Result].
+func ReportHareResult(result hareresults.Result) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		if err := reporter.hareEmitter.Emit(result); err != nil {
+			log.With().Error("failed to emit hare result", log.Err(err))
+		}
+	}
+}
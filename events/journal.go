@@ -0,0 +1,63 @@
+package events
+
+import (
+	"fmt"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/journal"
+)
+
+// eventsJournal persists user events in the local database.
+type eventsJournal struct {
+	db   sql.Executor
+	size uint64
+}
+
+func (j *eventsJournal) add(ev UserEvent) error {
+	data, err := proto.Marshal(ev.Event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	entry := journal.Entry{
+		Seq:       ev.Seq,
+		Timestamp: ev.Event.Timestamp.AsTime(),
+		Severity:  uint8(ev.Severity),
+		Cause:     string(ev.Cause),
+		Event:     data,
+	}
+	if err := journal.Add(j.db, entry); err != nil {
+		return err
+	}
+	if ev.Seq > j.size {
+		return journal.Prune(j.db, ev.Seq-j.size+1)
+	}
+	return nil
+}
+
+func (j *eventsJournal) after(seq uint64) ([]UserEvent, error) {
+	var (
+		rst  []UserEvent
+		derr error
+	)
+	err := journal.IterateAfter(j.db, seq, func(entry journal.Entry) bool {
+		ev := &pb.Event{}
+		if derr = proto.Unmarshal(entry.Event, ev); derr != nil {
+			derr = fmt.Errorf("decode event %d: %w", entry.Seq, derr)
+			return false
+		}
+		rst = append(rst, UserEvent{
+			Seq:      entry.Seq,
+			Event:    ev,
+			Severity: Severity(entry.Severity),
+			Cause:    Cause(entry.Cause),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rst, derr
+}
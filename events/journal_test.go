@@ -0,0 +1,73 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestJournal(t *testing.T) {
+	db := localsql.InMemory()
+
+	InitializeReporter()
+	EmitBeacon(1, types.RandomBeacon())
+	EmitBeacon(2, types.RandomBeacon())
+	require.NoError(t, EnableJournal(db, 10))
+	EmitBeacon(3, types.RandomBeacon())
+
+	sub, replay, next, err := SubscribeUserEventsAfter(1)
+	require.NoError(t, err)
+	sub.Close()
+	require.EqualValues(t, 2, next)
+	require.Len(t, replay, 2)
+	for i, ev := range replay {
+		require.EqualValues(t, i+2, ev.Seq)
+		require.EqualValues(t, i+2, ev.Event.GetBeacon().Epoch)
+	}
+	CloseEventReporter()
+
+	// numbering continues after restart
+	InitializeReporter()
+	t.Cleanup(CloseEventReporter)
+	EmitBeacon(4, types.RandomBeacon())
+	require.NoError(t, EnableJournal(db, 3))
+	EmitBeacon(5, types.RandomBeacon())
+
+	sub, replay, next, err = SubscribeUserEventsAfter(0)
+	require.NoError(t, err)
+	defer sub.Close()
+	require.EqualValues(t, 3, next)
+	require.Len(t, replay, 3)
+	for i, ev := range replay {
+		require.EqualValues(t, i+3, ev.Seq)
+		require.EqualValues(t, i+3, ev.Event.GetBeacon().Epoch)
+	}
+
+	EmitBeacon(6, types.RandomBeacon())
+	ev := <-sub.Out()
+	require.EqualValues(t, 6, ev.Seq)
+}
+
+func TestSubscribeUserEventsAfterWithoutJournal(t *testing.T) {
+	InitializeReporter()
+	t.Cleanup(CloseEventReporter)
+
+	sub, replay, next, err := SubscribeUserEventsAfter(0)
+	require.NoError(t, err)
+	sub.Close()
+	require.Empty(t, replay)
+	require.EqualValues(t, 1, next)
+
+	EmitBeacon(1, types.RandomBeacon())
+	EmitBeacon(2, types.RandomBeacon())
+
+	sub, replay, next, err = SubscribeUserEventsAfter(1)
+	require.NoError(t, err)
+	sub.Close()
+	require.Len(t, replay, 1)
+	require.EqualValues(t, 2, next)
+	require.EqualValues(t, 2, replay[0].Event.GetBeacon().Epoch)
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// MempoolEventKind is the change of the transaction in the mempool of the node.
+type MempoolEventKind uint8
+
+const (
+	// MempoolAdmitted is reported when the transaction becomes eligible for proposals,
+	// either when it is received or later when a layer is applied.
+	MempoolAdmitted MempoolEventKind = iota + 1
+	// MempoolRejected is reported when the received transaction is not admitted to the mempool.
+	// Transactions rejected for insufficient balance or too many pending nonces are kept in the database
+	// and can be admitted once a layer is applied.
+	MempoolRejected
+	// MempoolReplaced is reported when the transaction is replaced by a better transaction with the same nonce.
+	MempoolReplaced
+	// MempoolEvicted is reported when the transaction is removed from the mempool before it was applied,
+	// because it can't be paid for anymore.
+	MempoolEvicted
+)
+
+func (k MempoolEventKind) String() string {
+	switch k {
+	case MempoolAdmitted:
+		return "admitted"
+	case MempoolRejected:
+		return "rejected"
+	case MempoolReplaced:
+		return "replaced"
+	case MempoolEvicted:
+		return "evicted"
+	}
+	return "unknown"
+}
+
+// MempoolEvent is the change of the transaction in the mempool of the node.
+type MempoolEvent struct {
+	Kind      MempoolEventKind
+	ID        types.TransactionID
+	Principal types.Address
+	Nonce     uint64
+	// Reason is set for rejected and evicted transactions.
+	Reason string
+	// ReplacedBy is the transaction that replaced the transaction with the same nonce.
+	ReplacedBy types.TransactionID
+}
+
+// ReportMempool reports the change of the transaction in the mempool.
+// Events can be consumed with Subscribe[MempoolEvent].
+func ReportMempool(ev MempoolEvent) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		if err := reporter.mempoolEmitter.Emit(ev); err != nil {
+			log.With().Error("failed to emit mempool event", ev.ID, log.Err(err))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// Reorg is reported when tortoise changes the block in consensus for the layer that was already applied,
+// usually overriding the hare output. State is reverted to the layer before the change and re-applied.
+type Reorg struct {
+	// Revert is the last layer that was kept in the state, Depth is the number of layers reverted after it.
+	Revert types.LayerID
+	Depth  uint32
+	Layers []ReorgLayer
+	// Transactions from the blocks that were replaced, with the status after new blocks were applied.
+	Transactions []ReorgTransaction
+}
+
+// ReorgLayer is the layer that was reverted.
+type ReorgLayer struct {
+	Layer types.LayerID
+	// Old is the block that was applied before the reorg, and New is the block that was applied instead.
+	// Applied is false if the layer was not applied again yet, for example if the new block is missing.
+	Old     types.BlockID
+	New     types.BlockID
+	Applied bool
+}
+
+// ReorgTransaction is the transaction affected by the reorg.
+type ReorgTransaction struct {
+	ID    types.TransactionID
+	State types.TXState
+	// Layer is the layer where transaction is applied now. Zero if it is not applied.
+	Layer types.LayerID
+}
+
+// ReportReorg reports the reorg of the applied layers.
+// Reorgs can be consumed with Subscribe[Reorg].
+func ReportReorg(reorg Reorg) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		if err := reporter.reorgEmitter.Emit(reorg); err != nil {
+			log.With().Error("failed to emit reorg", log.Err(err))
+		}
+	}
+}
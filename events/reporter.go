@@ -1,6 +1,7 @@
 package events
 
 import (
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sync"
@@ -11,6 +12,10 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/journal"
 )
 
 // Subscription is a subscription to events.
@@ -342,6 +347,44 @@ func SubscribeUserEvents(opts ...SubOpt) (*BufferedSubscription[UserEvent], *Rin
 	return reporter.subUserEvents(opts...)
 }
 
+// RecentUserEvents returns the most recent user events kept in memory, oldest first.
+func RecentUserEvents() []UserEvent {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter == nil {
+		return nil
+	}
+	return reporter.recentUserEvents()
+}
+
+// SubscribeUserEventsAfter subscribes to user events and returns all events with a sequence number
+// greater than seq that are still retained by the node. Events are retained in the journal if it is enabled,
+// otherwise only the most recent events kept in memory are returned.
+// Returned sequence number is the one of the first event that will be delivered to the subscriber,
+// either from the replayed events or from the subscription, all following events are numbered consecutively.
+func SubscribeUserEventsAfter(
+	seq uint64,
+	opts ...SubOpt,
+) (*BufferedSubscription[UserEvent], []UserEvent, uint64, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter == nil {
+		return nil, nil, 0, nil
+	}
+	return reporter.subUserEventsAfter(seq, opts...)
+}
+
+// EnableJournal persists user events in the database, keeping at most size most recent events.
+// Persisted events can be replayed with SubscribeUserEventsAfter after the node is restarted.
+func EnableJournal(db sql.Executor, size int) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter == nil {
+		return nil
+	}
+	return reporter.enableJournal(db, size)
+}
+
 // The status of a layer
 // TODO: this list is woefully inadequate and does not map to reality.
 // See https://github.com/spacemeshos/api/issues/144.
@@ -414,9 +457,15 @@ type EventReporter struct {
 	resultsEmitter     event.Emitter
 	proposalsEmitter   event.Emitter
 	malfeasanceEmitter event.Emitter
+	hareEmitter        event.Emitter
+	stateRootEmitter   event.Emitter
+	reorgEmitter       event.Emitter
+	mempoolEmitter     event.Emitter
 	events             struct {
 		sync.Mutex
+		seq     uint64
 		buf     *Ring[UserEvent]
+		journal *eventsJournal
 		emitter event.Emitter
 	}
 	stopChan chan struct{}
@@ -425,7 +474,14 @@ type EventReporter struct {
 func (r *EventReporter) emitUserEvent(ev UserEvent) error {
 	r.events.Lock()
 	defer r.events.Unlock()
+	r.events.seq++
+	ev.Seq = r.events.seq
 	r.events.buf.insert(ev)
+	if r.events.journal != nil {
+		if err := r.events.journal.add(ev); err != nil {
+			log.With().Error("failed to persist event", log.Uint64("seq", ev.Seq), log.Err(err))
+		}
+	}
 	return r.events.emitter.Emit(ev)
 }
 
@@ -440,6 +496,78 @@ func (r *EventReporter) subUserEvents(opts ...SubOpt) (*BufferedSubscription[Use
 	return sub, buf, nil
 }
 
+func (r *EventReporter) recentUserEvents() []UserEvent {
+	r.events.Lock()
+	defer r.events.Unlock()
+	rst := make([]UserEvent, 0, r.events.buf.Len())
+	r.events.buf.Iterate(func(ev UserEvent) bool {
+		rst = append(rst, ev)
+		return true
+	})
+	return rst
+}
+
+func (r *EventReporter) subUserEventsAfter(
+	seq uint64,
+	opts ...SubOpt,
+) (*BufferedSubscription[UserEvent], []UserEvent, uint64, error) {
+	r.events.Lock()
+	defer r.events.Unlock()
+	var (
+		replay []UserEvent
+		err    error
+	)
+	if r.events.journal != nil {
+		replay, err = r.events.journal.after(seq)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+	} else {
+		r.events.buf.Iterate(func(ev UserEvent) bool {
+			if ev.Seq > seq {
+				replay = append(replay, ev)
+			}
+			return true
+		})
+	}
+	sub, err := Subscribe[UserEvent](opts...)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	next := r.events.seq + 1
+	if len(replay) > 0 {
+		next = replay[0].Seq
+	}
+	return sub, replay, next, nil
+}
+
+func (r *EventReporter) enableJournal(db sql.Executor, size int) error {
+	r.events.Lock()
+	defer r.events.Unlock()
+	j := &eventsJournal{db: db, size: uint64(size)}
+	last, err := journal.Last(db)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return err
+	}
+	// events emitted before the journal was enabled are renumbered to follow the persisted ones
+	buf := newRing[UserEvent](len(r.events.buf.data))
+	var aerr error
+	r.events.buf.Iterate(func(ev UserEvent) bool {
+		last++
+		ev.Seq = last
+		buf.insert(ev)
+		aerr = j.add(ev)
+		return aerr == nil
+	})
+	if aerr != nil {
+		return aerr
+	}
+	r.events.seq = last
+	r.events.buf = buf
+	r.events.journal = j
+	return nil
+}
+
 func newEventReporter() *EventReporter {
 	bus := eventbus.NewBus()
 	transactionEmitter, err := bus.Emitter(new(Transaction))
@@ -486,6 +614,22 @@ func newEventReporter() *EventReporter {
 	if err != nil {
 		log.With().Panic("failed to create malfeasance emitter", log.Err(err))
 	}
+	hareEmitter, err := bus.Emitter(new(hareresults.Result))
+	if err != nil {
+		log.With().Panic("failed to create hare emitter", log.Err(err))
+	}
+	stateRootEmitter, err := bus.Emitter(new(layers.StateRoot))
+	if err != nil {
+		log.With().Panic("failed to create state root emitter", log.Err(err))
+	}
+	reorgEmitter, err := bus.Emitter(new(Reorg))
+	if err != nil {
+		log.With().Panic("failed to create reorg emitter", log.Err(err))
+	}
+	mempoolEmitter, err := bus.Emitter(new(MempoolEvent))
+	if err != nil {
+		log.With().Panic("failed to create mempool emitter", log.Err(err))
+	}
 
 	reporter := &EventReporter{
 		bus:                bus,
@@ -499,6 +643,10 @@ func newEventReporter() *EventReporter {
 		errorEmitter:       errorEmitter,
 		proposalsEmitter:   proposalsEmitter,
 		malfeasanceEmitter: malfeasanceEmitter,
+		hareEmitter:        hareEmitter,
+		stateRootEmitter:   stateRootEmitter,
+		reorgEmitter:       reorgEmitter,
+		mempoolEmitter:     mempoolEmitter,
 		stopChan:           make(chan struct{}),
 	}
 	reporter.events.buf = newRing[UserEvent](100)
@@ -541,6 +689,18 @@ func CloseEventReporter() {
 		if err := reporter.malfeasanceEmitter.Close(); err != nil {
 			log.With().Panic("failed to close malfeasanceEmitter", log.Err(err))
 		}
+		if err := reporter.hareEmitter.Close(); err != nil {
+			log.With().Panic("failed to close hareEmitter", log.Err(err))
+		}
+		if err := reporter.stateRootEmitter.Close(); err != nil {
+			log.With().Panic("failed to close stateRootEmitter", log.Err(err))
+		}
+		if err := reporter.reorgEmitter.Close(); err != nil {
+			log.With().Panic("failed to close reorgEmitter", log.Err(err))
+		}
+		if err := reporter.mempoolEmitter.Close(); err != nil {
+			log.With().Panic("failed to close mempoolEmitter", log.Err(err))
+		}
 
 		close(reporter.stopChan)
 		reporter = nil
@@ -595,7 +755,7 @@ func (r *Ring[T]) Iterate(iter func(val T) bool) {
 	if r.last == -1 {
 		return
 	}
-	if r.last > r.first {
+	if r.last >= r.first {
 		for i := r.first; i <= r.last; i++ {
 			if !iter(r.data[i]) {
 				return
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Severity of the user event. Alerting can select events by severity instead of matching the help text.
+type Severity uint8
+
+const (
+	// SeverityInfo events report progress of the node, they don't require any action.
+	SeverityInfo Severity = iota
+	// SeverityWarning events report failures that the node is expected to recover from,
+	// e.g. a missed poet round. They require attention if they repeat.
+	SeverityWarning
+	// SeverityCritical events report failures that the node can't recover from without the operator,
+	// e.g. invalid post data or a canceled identity.
+	SeverityCritical
+)
+
+var severityNames = [...]string{
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityCritical: "critical",
+}
+
+func (s Severity) String() string {
+	if int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("severity(%d)", s)
+}
+
+// MarshalText encodes severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes severity from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity parses the name of the severity.
+func ParseSeverity(name string) (Severity, error) {
+	if i := slices.Index(severityNames[:], name); i >= 0 {
+		return Severity(i), nil
+	}
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+// Cause is a machine-readable code of the reason of the event. Causes are stable across releases,
+// unlike the help text of the event. Events that don't report a failure don't have a cause.
+type Cause string
+
+const (
+	CauseInitFailed            Cause = "init_failed"
+	CausePostFailed            Cause = "post_failed"
+	CauseInvalidPost           Cause = "invalid_post"
+	CauseAtxMismatch           Cause = "atx_mismatch"
+	CauseAtxChallengeDiscarded Cause = "atx_challenge_discarded"
+	CausePoetUnreachable       Cause = "poet_unreachable"
+	CauseClockSkew             Cause = "clock_skew"
+	CauseWatchedAtxMissing     Cause = "watched_atx_missing"
+	CauseWatchedAtxUnexpected  Cause = "watched_atx_unexpected"
+	CauseMalfeasance           Cause = "malfeasance"
+	CauseSmeshingCanceled      Cause = "smeshing_canceled"
+)
+
+// UserEventFilter selects user events by severity and cause.
+type UserEventFilter struct {
+	// MinSeverity is the lowest severity of the selected events.
+	MinSeverity Severity
+	// Causes of the selected events, events with any cause are selected if empty.
+	Causes []Cause
+}
+
+// Match returns true if the event is selected by the filter.
+func (f UserEventFilter) Match(ev *UserEvent) bool {
+	if ev.Severity < f.MinSeverity {
+		return false
+	}
+	return len(f.Causes) == 0 || slices.Contains(f.Causes, ev.Cause)
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestSeverity(t *testing.T) {
+	for _, severity := range []Severity{SeverityInfo, SeverityWarning, SeverityCritical} {
+		parsed, err := ParseSeverity(severity.String())
+		require.NoError(t, err)
+		require.Equal(t, severity, parsed)
+
+		encoded, err := json.Marshal(severity)
+		require.NoError(t, err)
+		var decoded Severity
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		require.Equal(t, severity, decoded)
+	}
+	_, err := ParseSeverity("fatal")
+	require.Error(t, err)
+}
+
+func TestUserEventFilter(t *testing.T) {
+	info := &UserEvent{Severity: SeverityInfo}
+	skew := &UserEvent{Severity: SeverityWarning, Cause: CauseClockSkew}
+	post := &UserEvent{Severity: SeverityCritical, Cause: CausePostFailed}
+
+	require.True(t, UserEventFilter{}.Match(info))
+	warnings := UserEventFilter{MinSeverity: SeverityWarning}
+	require.False(t, warnings.Match(info))
+	require.True(t, warnings.Match(skew))
+	require.True(t, warnings.Match(post))
+	causes := UserEventFilter{Causes: []Cause{CausePostFailed, CauseInvalidPost}}
+	require.False(t, causes.Match(skew))
+	require.True(t, causes.Match(post))
+}
+
+func TestEventSeverities(t *testing.T) {
+	InitializeReporter()
+	t.Cleanup(CloseEventReporter)
+	require.NoError(t, EnableJournal(localsql.InMemory(), 10))
+
+	EmitBeacon(1, types.RandomBeacon())
+	EmitAtxRetargeted(2, 3, time.Now())
+	EmitClockSkew(time.Minute, 10*time.Second, false)
+	EmitPoetUnreachable(types.RandomNodeID(), 3, errors.New("connection refused"))
+	EmitClockSkew(time.Minute, 10*time.Second, true)
+	EmitPostFailure(types.RandomNodeID())
+
+	expected := []struct {
+		failure  bool
+		severity Severity
+		cause    Cause
+	}{
+		{false, SeverityInfo, ""},
+		{false, SeverityInfo, ""},
+		{true, SeverityWarning, CauseClockSkew},
+		{true, SeverityWarning, CausePoetUnreachable},
+		{true, SeverityCritical, CauseClockSkew},
+		{true, SeverityCritical, CausePostFailed},
+	}
+	recent := RecentUserEvents()
+	// severity and cause are persisted in the journal
+	sub, replay, _, err := SubscribeUserEventsAfter(0)
+	require.NoError(t, err)
+	sub.Close()
+	for _, events := range [][]UserEvent{recent, replay} {
+		require.Len(t, events, len(expected))
+		for i, ev := range events {
+			require.Equal(t, expected[i].failure, ev.Event.Failure, i)
+			require.Equal(t, expected[i].severity, ev.Severity, i)
+			require.Equal(t, expected[i].cause, ev.Cause, i)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+// ReportStateRoot reports the state root after the layer was applied.
+// The root is reported again for the same layer if the layer was reverted and applied again.
+// State roots can be consumed with Subscribe[layers.StateRoot].
+func ReportStateRoot(root layers.StateRoot) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if reporter != nil {
+		if err := reporter.stateRootEmitter.Emit(root); err != nil {
+			log.With().Error("failed to emit state root", log.Err(err))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package explorer maintains denormalized tables for explorer-grade queries on the api,
+// such as transactions by address, activations by smesher and rewards by epoch.
+//
+// Indexer follows the layers applied to the state. Layers are indexed after they are applied,
+// and if the applied block of an indexed layer changes, the index is rebuilt starting
+// from the first layer of the epoch with that layer.
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/explorer"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+// Config for Indexer.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval between checks for newly applied layers.
+	Interval time.Duration `mapstructure:"interval"`
+	// ReorgDepth is the number of the latest indexed layers that are checked for changes of the applied block.
+	ReorgDepth uint32 `mapstructure:"reorg-depth"`
+	// BatchSize is the number of layers indexed in a single database transaction.
+	BatchSize uint32 `mapstructure:"batch-size"`
+}
+
+// DefaultConfig for Indexer.
+func DefaultConfig() Config {
+	return Config{
+		Interval:   10 * time.Second,
+		ReorgDepth: 1000,
+		BatchSize:  100,
+	}
+}
+
+// Indexer maintains the explorer index for the layers applied to the state.
+type Indexer struct {
+	logger *zap.Logger
+	db     *sql.Database
+	cfg    Config
+}
+
+// New creates an Indexer.
+func New(db *sql.Database, logger *zap.Logger, cfg Config) *Indexer {
+	return &Indexer{logger: logger, db: db, cfg: cfg}
+}
+
+// Run indexes applied layers until the context is canceled.
+func (i *Indexer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(i.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := i.Index(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Error("failed to update explorer index", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Index reverts the index of the layers whose applied block changed,
+// and indexes all layers that were applied since the last indexed layer.
+func (i *Indexer) Index(ctx context.Context) error {
+	last, err := explorer.LastIndexed(i.db)
+	if err != nil {
+		return err
+	}
+	var from types.LayerID
+	if last > types.LayerID(i.cfg.ReorgDepth) {
+		from = last - types.LayerID(i.cfg.ReorgDepth)
+	}
+	diverged, err := explorer.FirstDiverged(i.db, from)
+	switch {
+	case err == nil:
+		i.logger.Info("reverting explorer index",
+			zap.Uint32("diverged", diverged.Uint32()),
+			zap.Uint32("epoch", diverged.GetEpoch().Uint32()),
+		)
+		if err := i.db.WithTx(ctx, func(tx *sql.Tx) error {
+			return explorer.Revert(tx, diverged)
+		}); err != nil {
+			return err
+		}
+		last, err = explorer.LastIndexed(i.db)
+		if err != nil {
+			return err
+		}
+	case !errors.Is(err, sql.ErrNotFound):
+		return err
+	}
+
+	applied, err := layers.GetLastApplied(i.db)
+	if err != nil {
+		return fmt.Errorf("last applied layer: %w", err)
+	}
+	next := last + 1
+	if last == 0 {
+		next = types.GetEffectiveGenesis() + 1
+	}
+	for next <= applied {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(applied, next+types.LayerID(i.cfg.BatchSize)-1)
+		if err := i.db.WithTx(ctx, func(tx *sql.Tx) error {
+			for lid := next; lid <= end; lid++ {
+				block, err := layers.GetApplied(tx, lid)
+				if err != nil {
+					return fmt.Errorf("applied block of layer %v: %w", lid, err)
+				}
+				if err := explorer.IndexLayer(tx, lid, block); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		i.logger.Debug("indexed layers",
+			zap.Uint32("from", next.Uint32()),
+			zap.Uint32("to", end.Uint32()),
+		)
+		next = end + 1
+	}
+	return nil
+}
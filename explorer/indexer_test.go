@@ -0,0 +1,63 @@
+package explorer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/explorer"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+)
+
+func TestIndexer(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+	cfg := DefaultConfig()
+	cfg.BatchSize = 3
+	indexer := New(db, zaptest.NewLogger(t), cfg)
+
+	smesher := types.RandomNodeID()
+	apply := func(lid types.LayerID, reward uint64) {
+		require.NoError(t, rewards.Add(db, &types.Reward{
+			Layer:       lid,
+			Coinbase:    types.GenerateAddress(smesher[:]),
+			SmesherID:   smesher,
+			TotalReward: reward,
+			LayerReward: reward,
+		}))
+		require.NoError(t, layers.SetApplied(db, lid, types.RandomBlockID()))
+	}
+	total := func(epoch types.EpochID) uint64 {
+		rst, err := explorer.RewardsByEpoch(db, epoch, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, rst, 1)
+		return rst[0].TotalReward
+	}
+
+	genesis := types.GetEffectiveGenesis()
+	for lid := genesis + 1; lid <= genesis+8; lid++ {
+		apply(lid, 1)
+	}
+	require.NoError(t, indexer.Index(context.Background()))
+	last, err := explorer.LastIndexed(db)
+	require.NoError(t, err)
+	require.Equal(t, genesis+8, last)
+	require.Equal(t, uint64(4), total(genesis.GetEpoch()+1))
+	require.Equal(t, uint64(4), total(genesis.GetEpoch()+2))
+
+	// layer is applied again with a different block and a different reward
+	require.NoError(t, rewards.Revert(db, genesis+6))
+	apply(genesis+7, 10)
+	apply(genesis+8, 10)
+	require.NoError(t, indexer.Index(context.Background()))
+	last, err = explorer.LastIndexed(db)
+	require.NoError(t, err)
+	require.Equal(t, genesis+8, last)
+	require.Equal(t, uint64(4), total(genesis.GetEpoch()+1))
+	require.Equal(t, uint64(22), total(genesis.GetEpoch()+2))
+}
@@ -0,0 +1,223 @@
+// Package export dumps the mesh data of a layer range into files that can be loaded into analytics tooling.
+package export
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Format is the format of the exported files.
+type Format string
+
+const (
+	// NDJSON writes one json object per line.
+	NDJSON Format = "ndjson"
+	// Parquet writes snappy compressed parquet files.
+	Parquet Format = "parquet"
+
+	// ManifestFile is the name of the manifest in the export directory.
+	ManifestFile = "manifest.json"
+
+	// parquetBatch is the number of rows buffered before they are written to the parquet file.
+	parquetBatch = 1024
+)
+
+var (
+	// ErrUnknownFormat is returned if the format is neither ndjson nor parquet.
+	ErrUnknownFormat = errors.New("unknown export format")
+	// ErrInvalidRange is returned if the first layer of the range is after the last one.
+	ErrInvalidRange = errors.New("invalid layer range")
+)
+
+// ParseFormat parses the name of the format.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case NDJSON, Parquet:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+	}
+}
+
+// Manifest describes the files of the export. It is written into the export directory last,
+// so an export without a manifest is incomplete.
+type Manifest struct {
+	From    uint32    `json:"from"`
+	To      uint32    `json:"to"`
+	Format  Format    `json:"format"`
+	Created time.Time `json:"created"`
+	Tables  []Table   `json:"tables"`
+}
+
+// Table is the exported file of the table.
+type Table struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int    `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Export dumps layers, blocks, transactions and rewards in the layer range [from, to] into the directory.
+// The directory is created if it doesn't exist. Every table is written into a separate file in the format,
+// followed by the manifest.
+func Export(
+	ctx context.Context,
+	db sql.Executor,
+	dir string,
+	from, to types.LayerID,
+	format Format,
+) (*Manifest, error) {
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("%w: %d > %d", ErrInvalidRange, from, to)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+	manifest := &Manifest{
+		From:    from.Uint32(),
+		To:      to.Uint32(),
+		Format:  format,
+		Created: time.Now().UTC(),
+	}
+	for _, write := range []func() (Table, error){
+		func() (Table, error) {
+			return writeTable(dir, "layers", format, func(emit func(Layer) error) error {
+				return exportLayers(ctx, db, from, to, emit)
+			})
+		},
+		func() (Table, error) {
+			return writeTable(dir, "blocks", format, func(emit func(Block) error) error {
+				return exportBlocks(ctx, db, from, to, emit)
+			})
+		},
+		func() (Table, error) {
+			return writeTable(dir, "transactions", format, func(emit func(Transaction) error) error {
+				return exportTransactions(ctx, db, from, to, emit)
+			})
+		},
+		func() (Table, error) {
+			return writeTable(dir, "rewards", format, func(emit func(Reward) error) error {
+				return exportRewards(ctx, db, from, to, emit)
+			})
+		},
+	} {
+		table, err := write()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Tables = append(manifest.Tables, table)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// ReadManifest reads the manifest of the export in the directory.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// countingWriter counts and hashes the bytes written to the file.
+type countingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func (c *countingWriter) Write(buf []byte) (int, error) {
+	n, err := c.w.Write(buf)
+	c.hash.Write(buf[:n])
+	c.size += int64(n)
+	return n, err
+}
+
+// writeTable writes the rows produced by the iterate function into the file of the table.
+func writeTable[T any](
+	dir, name string,
+	format Format,
+	iterate func(emit func(T) error) error,
+) (Table, error) {
+	table := Table{Name: name, File: fmt.Sprintf("%s.%s", name, format)}
+	f, err := os.Create(filepath.Join(dir, table.File))
+	if err != nil {
+		return table, fmt.Errorf("create %s: %w", table.File, err)
+	}
+	defer f.Close()
+	buffered := bufio.NewWriter(f)
+	out := &countingWriter{w: buffered, hash: sha256.New()}
+
+	switch format {
+	case NDJSON:
+		enc := json.NewEncoder(out)
+		err = iterate(func(row T) error {
+			table.Rows++
+			return enc.Encode(row)
+		})
+	case Parquet:
+		writer := parquet.NewGenericWriter[T](out, parquet.Compression(&parquet.Snappy))
+		rows := make([]T, 0, parquetBatch)
+		flush := func() error {
+			_, err := writer.Write(rows)
+			table.Rows += len(rows)
+			rows = rows[:0]
+			return err
+		}
+		err = iterate(func(row T) error {
+			rows = append(rows, row)
+			if len(rows) == cap(rows) {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+	}
+	if err != nil {
+		return table, fmt.Errorf("export %s: %w", name, err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return table, fmt.Errorf("write %s: %w", table.File, err)
+	}
+	if err := f.Close(); err != nil {
+		return table, fmt.Errorf("close %s: %w", table.File, err)
+	}
+	table.Size = out.size
+	table.SHA256 = hex.EncodeToString(out.hash.Sum(nil))
+	return table, nil
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+func TestMain(m *testing.M) {
+	types.SetLayersPerEpoch(4)
+
+	res := m.Run()
+	os.Exit(res)
+}
+
+func populate(tb testing.TB, db *sql.Database, last types.LayerID) {
+	tb.Helper()
+	for lid := types.LayerID(1); lid <= last; lid++ {
+		block := types.NewExistingBlock(types.BlockID{byte(lid)}, types.InnerBlock{
+			LayerIndex: lid,
+			TickHeight: uint64(lid) * 10,
+			Rewards:    []types.AnyReward{{AtxID: types.ATXID{1}}},
+		})
+		require.NoError(tb, blocks.Add(db, block))
+		require.NoError(tb, blocks.SetValid(db, block.ID()))
+		require.NoError(tb, layers.SetApplied(db, lid, block.ID()))
+		require.NoError(tb, layers.UpdateStateHash(db, lid, types.Hash32{byte(lid)}))
+		require.NoError(tb, rewards.Add(db, &types.Reward{
+			Layer:       lid,
+			SmesherID:   types.NodeID{byte(lid)},
+			Coinbase:    types.Address{byte(lid)},
+			TotalReward: 100,
+			LayerReward: 90,
+		}))
+		tx := types.Transaction{
+			RawTx:    types.NewRawTx([]byte{byte(lid)}),
+			TxHeader: &types.TxHeader{Principal: types.Address{1}, Nonce: uint64(lid), MaxGas: 10, GasPrice: 1},
+		}
+		require.NoError(tb, transactions.Add(db, &tx, time.Time{}))
+		require.NoError(tb, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+			return transactions.AddResult(dtx, tx.ID, &types.TransactionResult{
+				Layer: lid, Block: block.ID(), Gas: 5, Fee: 5,
+			})
+		}))
+	}
+}
+
+func readNDJSON[T any](tb testing.TB, path string) []T {
+	tb.Helper()
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer f.Close()
+	var rows []T
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var row T
+		require.NoError(tb, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+	require.NoError(tb, scanner.Err())
+	return rows
+}
+
+func readParquet[T any](tb testing.TB, path string) []T {
+	tb.Helper()
+	f, err := os.Open(path)
+	require.NoError(tb, err)
+	defer f.Close()
+	stat, err := f.Stat()
+	require.NoError(tb, err)
+	rows, err := parquet.Read[T](f, stat.Size())
+	require.NoError(tb, err)
+	return rows
+}
+
+func TestExport(t *testing.T) {
+	db := sql.InMemory()
+	populate(t, db, 5)
+
+	for _, format := range []Format{NDJSON, Parquet} {
+		t.Run(string(format), func(t *testing.T) {
+			dir := t.TempDir()
+			manifest, err := Export(context.Background(), db, dir, 2, 4, format)
+			require.NoError(t, err)
+			require.EqualValues(t, 2, manifest.From)
+			require.EqualValues(t, 4, manifest.To)
+			require.Len(t, manifest.Tables, 4)
+			for _, table := range manifest.Tables {
+				require.Equal(t, 3, table.Rows, table.Name)
+				stat, err := os.Stat(filepath.Join(dir, table.File))
+				require.NoError(t, err)
+				require.Equal(t, stat.Size(), table.Size)
+			}
+			stored, err := ReadManifest(dir)
+			require.NoError(t, err)
+			require.Equal(t, manifest.Tables, stored.Tables)
+
+			var (
+				layerRows  []Layer
+				blockRows  []Block
+				txRows     []Transaction
+				rewardRows []Reward
+			)
+			if format == NDJSON {
+				layerRows = readNDJSON[Layer](t, filepath.Join(dir, "layers.ndjson"))
+				blockRows = readNDJSON[Block](t, filepath.Join(dir, "blocks.ndjson"))
+				txRows = readNDJSON[Transaction](t, filepath.Join(dir, "transactions.ndjson"))
+				rewardRows = readNDJSON[Reward](t, filepath.Join(dir, "rewards.ndjson"))
+			} else {
+				layerRows = readParquet[Layer](t, filepath.Join(dir, "layers.parquet"))
+				blockRows = readParquet[Block](t, filepath.Join(dir, "blocks.parquet"))
+				txRows = readParquet[Transaction](t, filepath.Join(dir, "transactions.parquet"))
+				rewardRows = readParquet[Reward](t, filepath.Join(dir, "rewards.parquet"))
+			}
+			require.Equal(t, Layer{
+				Layer:        2,
+				Epoch:        types.LayerID(2).GetEpoch().Uint32(),
+				AppliedBlock: hex.EncodeToString(types.BlockID{2}.Bytes()),
+				StateHash:    hex.EncodeToString(types.Hash32{2}.Bytes()),
+			}, layerRows[0])
+			require.Equal(t, Block{
+				ID:         hex.EncodeToString(types.BlockID{3}.Bytes()),
+				Layer:      3,
+				TickHeight: 30,
+				Valid:      true,
+				Rewards:    1,
+			}, blockRows[1])
+			require.EqualValues(t, 4, txRows[2].Layer)
+			require.EqualValues(t, 4, txRows[2].Nonce)
+			require.Equal(t, types.Address{1}.String(), txRows[2].Principal)
+			require.Equal(t, "success", txRows[2].Status)
+			require.Equal(t, Reward{
+				Layer:       2,
+				Smesher:     hex.EncodeToString(types.NodeID{2}.Bytes()),
+				Coinbase:    types.Address{2}.String(),
+				TotalReward: 100,
+				LayerReward: 90,
+			}, rewardRows[0])
+		})
+	}
+}
+
+func TestExport_Invalid(t *testing.T) {
+	db := sql.InMemory()
+	_, err := Export(context.Background(), db, t.TempDir(), 1, 2, "csv")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = Export(context.Background(), db, t.TempDir(), 2, 1, NDJSON)
+	require.ErrorIs(t, err, ErrInvalidRange)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dir := t.TempDir()
+	_, err = Export(ctx, db, dir, 1, 2, NDJSON)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NoFileExists(t, filepath.Join(dir, ManifestFile))
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/builder"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+// Layer is a row of the layers table. Hashes and the applied block are empty
+// if the layer wasn't applied yet.
+type Layer struct {
+	Layer          uint32 `json:"layer"                     parquet:"layer"`
+	Epoch          uint32 `json:"epoch"                     parquet:"epoch"`
+	AppliedBlock   string `json:"applied_block,omitempty"   parquet:"applied_block,optional"`
+	StateHash      string `json:"state_hash,omitempty"      parquet:"state_hash,optional"`
+	AggregatedHash string `json:"aggregated_hash,omitempty" parquet:"aggregated_hash,optional"`
+}
+
+// Block is a row of the blocks table.
+type Block struct {
+	ID           string `json:"id"           parquet:"id"`
+	Layer        uint32 `json:"layer"        parquet:"layer"`
+	TickHeight   uint64 `json:"tick_height"  parquet:"tick_height"`
+	Valid        bool   `json:"valid"        parquet:"valid"`
+	Rewards      int    `json:"rewards"      parquet:"rewards"`
+	Transactions int    `json:"transactions" parquet:"transactions"`
+}
+
+// Transaction is a row of the transactions table, only transactions applied in the exported layers are included.
+type Transaction struct {
+	ID        string `json:"id"                  parquet:"id"`
+	Layer     uint32 `json:"layer"               parquet:"layer"`
+	Block     string `json:"block"               parquet:"block"`
+	Principal string `json:"principal,omitempty" parquet:"principal,optional"`
+	Template  string `json:"template,omitempty"  parquet:"template,optional"`
+	Method    uint32 `json:"method"              parquet:"method"`
+	Nonce     uint64 `json:"nonce"               parquet:"nonce"`
+	MaxGas    uint64 `json:"max_gas"             parquet:"max_gas"`
+	GasPrice  uint64 `json:"gas_price"           parquet:"gas_price"`
+	Status    string `json:"status"              parquet:"status"`
+	Message   string `json:"message,omitempty"   parquet:"message,optional"`
+	Gas       uint64 `json:"gas"                 parquet:"gas"`
+	Fee       uint64 `json:"fee"                 parquet:"fee"`
+}
+
+// Reward is a row of the rewards table.
+type Reward struct {
+	Layer       uint32 `json:"layer"        parquet:"layer"`
+	Smesher     string `json:"smesher"      parquet:"smesher"`
+	Coinbase    string `json:"coinbase"     parquet:"coinbase"`
+	TotalReward uint64 `json:"total_reward" parquet:"total_reward"`
+	LayerReward uint64 `json:"layer_reward" parquet:"layer_reward"`
+}
+
+func exportLayers(ctx context.Context, db sql.Executor, from, to types.LayerID, emit func(Layer) error) error {
+	for lid := from; lid <= to; lid++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row := Layer{Layer: lid.Uint32(), Epoch: lid.GetEpoch().Uint32()}
+		applied, err := layers.GetApplied(db, lid)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			return err
+		default:
+			row.AppliedBlock = hex.EncodeToString(applied.Bytes())
+		}
+		state, err := layers.GetStateHash(db, lid)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			return err
+		default:
+			row.StateHash = hex.EncodeToString(state.Bytes())
+		}
+		aggregated, err := layers.GetAggregatedHash(db, lid)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			return err
+		default:
+			if aggregated != (types.Hash32{}) {
+				row.AggregatedHash = hex.EncodeToString(aggregated.Bytes())
+			}
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportBlocks(ctx context.Context, db sql.Executor, from, to types.LayerID, emit func(Block) error) error {
+	for lid := from; lid <= to; lid++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		validity, err := blocks.ContextualValidity(db, lid)
+		if err != nil {
+			return err
+		}
+		valid := make(map[types.BlockID]bool, len(validity))
+		for _, v := range validity {
+			valid[v.ID] = v.Validity
+		}
+		layer, err := blocks.Layer(db, lid)
+		if err != nil {
+			return err
+		}
+		for _, block := range layer {
+			if err := emit(Block{
+				ID:           hex.EncodeToString(block.ID().Bytes()),
+				Layer:        block.LayerIndex.Uint32(),
+				TickHeight:   block.TickHeight,
+				Valid:        valid[block.ID()],
+				Rewards:      len(block.Rewards),
+				Transactions: len(block.TxIDs),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func exportTransactions(
+	ctx context.Context,
+	db sql.Executor,
+	from, to types.LayerID,
+	emit func(Transaction) error,
+) error {
+	var ierr error
+	err := transactions.IterateResults(db, transactions.ResultsFilter{Start: &from, End: &to},
+		func(tx *types.TransactionWithResult) bool {
+			if ierr = ctx.Err(); ierr != nil {
+				return false
+			}
+			row := Transaction{
+				ID:      hex.EncodeToString(tx.ID.Bytes()),
+				Layer:   tx.Layer.Uint32(),
+				Block:   hex.EncodeToString(tx.Block.Bytes()),
+				Status:  tx.Status.String(),
+				Message: tx.Message,
+				Gas:     tx.Gas,
+				Fee:     tx.Fee,
+			}
+			if tx.TxHeader != nil {
+				row.Principal = tx.Principal.String()
+				row.Template = tx.TemplateAddress.String()
+				row.Method = uint32(tx.Method)
+				row.Nonce = tx.Nonce
+				row.MaxGas = tx.MaxGas
+				row.GasPrice = tx.GasPrice
+			}
+			ierr = emit(row)
+			return ierr == nil
+		})
+	if err != nil {
+		return err
+	}
+	return ierr
+}
+
+func exportRewards(ctx context.Context, db sql.Executor, from, to types.LayerID, emit func(Reward) error) error {
+	var ierr error
+	err := rewards.IterateRewardsOps(db, builder.Operations{
+		Filter: []builder.Op{
+			{Field: builder.Layer, Token: builder.Gte, Value: int64(from)},
+			{Field: builder.Layer, Token: builder.Lte, Value: int64(to)},
+		},
+		Modifiers: []builder.Modifier{{Key: builder.OrderBy, Value: "layer asc"}},
+	}, func(reward *types.Reward) bool {
+		// the iteration can't be stopped early, the rest of the rewards are skipped after the first error
+		if ierr != nil {
+			return false
+		}
+		if ierr = ctx.Err(); ierr != nil {
+			return false
+		}
+		ierr = emit(Reward{
+			Layer:       reward.Layer.Uint32(),
+			Smesher:     hex.EncodeToString(reward.SmesherID.Bytes()),
+			Coinbase:    reward.Coinbase.String(),
+			TotalReward: reward.TotalReward,
+			LayerReward: reward.LayerReward,
+		})
+		return ierr == nil
+	})
+	if err != nil {
+		return fmt.Errorf("iterate rewards: %w", err)
+	}
+	return ierr
+}
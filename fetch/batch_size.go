@@ -0,0 +1,68 @@
+package fetch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+// batchSizes adapts the size of hash batches to every peer with additive increase and multiplicative decrease.
+// The size of the peer is increased by one after a batch that is served within the latency target,
+// and halved after a slow or failed batch.
+type batchSizes struct {
+	initial, limit int
+	target         time.Duration
+
+	mu    sync.Mutex
+	peers map[p2p.Peer]float64
+}
+
+func newBatchSizes(initial, limit int, target time.Duration) *batchSizes {
+	return &batchSizes{
+		initial: max(min(initial, limit), 1),
+		limit:   limit,
+		target:  target,
+		peers:   map[p2p.Peer]float64{},
+	}
+}
+
+// size returns the current batch size of the peer.
+func (b *batchSizes) size(peer p2p.Peer) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int(b.get(peer))
+}
+
+// onResponse updates the batch size of the peer after it served a batch.
+func (b *batchSizes) onResponse(peer p2p.Peer, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.target > 0 && latency > b.target {
+		b.peers[peer] = max(b.get(peer)/2, 1)
+		return
+	}
+	b.peers[peer] = min(b.get(peer)+1, float64(b.limit))
+}
+
+// onFailure halves the batch size of the peer after it failed to serve a batch.
+func (b *batchSizes) onFailure(peer p2p.Peer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.peers[peer] = max(b.get(peer)/2, 1)
+}
+
+func (b *batchSizes) get(peer p2p.Peer) float64 {
+	size, exists := b.peers[peer]
+	if !exists {
+		return float64(b.initial)
+	}
+	return size
+}
+
+// delete forgets the batch size of the disconnected peer.
+func (b *batchSizes) delete(peer p2p.Peer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.peers, peer)
+}
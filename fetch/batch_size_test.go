@@ -0,0 +1,37 @@
+package fetch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+func TestBatchSizes(t *testing.T) {
+	sizes := newBatchSizes(10, 12, time.Second)
+	fast, slow := p2p.Peer("fast"), p2p.Peer("slow")
+	require.Equal(t, 10, sizes.size(fast))
+
+	for i := 0; i < 5; i++ {
+		sizes.onResponse(fast, time.Millisecond)
+	}
+	require.Equal(t, 12, sizes.size(fast), "increased up to the limit")
+
+	sizes.onResponse(slow, 2*time.Second)
+	require.Equal(t, 5, sizes.size(slow))
+	sizes.onFailure(slow)
+	require.Equal(t, 2, sizes.size(slow))
+	for i := 0; i < 5; i++ {
+		sizes.onFailure(slow)
+	}
+	require.Equal(t, 1, sizes.size(slow), "never below one")
+	sizes.onResponse(slow, time.Millisecond)
+	require.Equal(t, 2, sizes.size(slow))
+
+	sizes.delete(fast)
+	require.Equal(t, 10, sizes.size(fast))
+
+	require.Equal(t, 5, newBatchSizes(10, 5, time.Second).size(fast), "initial size is capped by the limit")
+}
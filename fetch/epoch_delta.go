@@ -0,0 +1,242 @@
+package fetch
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+const (
+	// maxDeltaEpochs is the number of the most recent epochs for which the sets of atx ids are tracked.
+	maxDeltaEpochs = 2
+	// maxDeltaVersions is the number of previous versions of the set of the epoch that deltas are served against.
+	maxDeltaVersions = 16
+)
+
+func compareATXIDs(a, b types.ATXID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// sortedATXIDs returns the ids sorted, ids are copied only if they are not sorted already.
+func sortedATXIDs(ids []types.ATXID) []types.ATXID {
+	if slices.IsSortedFunc(ids, compareATXIDs) {
+		return ids
+	}
+	ids = slices.Clone(ids)
+	slices.SortFunc(ids, compareATXIDs)
+	return ids
+}
+
+// atxSetHash returns the hash of the set of sorted ids.
+func atxSetHash(ids []types.ATXID) types.Hash32 {
+	hh := hash.New()
+	for _, id := range ids {
+		hh.Write(id[:])
+	}
+	var rst types.Hash32
+	hh.Sum(rst[:0])
+	return rst
+}
+
+// diffATXIDs returns the ids that are added and removed in the sorted set next compared to the sorted set prev.
+func diffATXIDs(prev, next []types.ATXID) (added, removed []types.ATXID) {
+	i, j := 0, 0
+	for i < len(prev) || j < len(next) {
+		switch {
+		case j == len(next) || (i < len(prev) && compareATXIDs(prev[i], next[j]) < 0):
+			removed = append(removed, prev[i])
+			i++
+		case i == len(prev) || compareATXIDs(prev[i], next[j]) > 0:
+			added = append(added, next[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
+
+// applyATXIDs returns the sorted set base with the ids added and removed.
+func applyATXIDs(base, added, removed []types.ATXID) []types.ATXID {
+	added, removed = sortedATXIDs(added), sortedATXIDs(removed)
+	rst := make([]types.ATXID, 0, len(base)+len(added))
+	i, j := 0, 0
+	for i < len(base) || j < len(added) {
+		var id types.ATXID
+		switch {
+		case j == len(added) || (i < len(base) && compareATXIDs(base[i], added[j]) < 0):
+			id = base[i]
+			i++
+		case i == len(base) || compareATXIDs(base[i], added[j]) > 0:
+			id = added[j]
+			j++
+		default:
+			id = base[i]
+			i++
+			j++
+		}
+		if _, found := slices.BinarySearchFunc(removed, id, compareATXIDs); !found {
+			rst = append(rst, id)
+		}
+	}
+	return rst
+}
+
+// atxSetVersion is the previous version of the set of ids with the changes to the next version.
+type atxSetVersion struct {
+	hash           types.Hash32
+	added, removed []types.ATXID
+}
+
+type atxSetHistory struct {
+	// raw ids are used to skip the update if the set wasn't changed since the last request.
+	raw      []types.ATXID
+	ids      []types.ATXID
+	hash     types.Hash32
+	versions []atxSetVersion
+}
+
+// atxSetVersions tracks the changes of the sets of ids served for the recent epochs,
+// so that they can be served as deltas against the previous versions.
+type atxSetVersions struct {
+	mu     sync.Mutex
+	epochs map[types.EpochID]*atxSetHistory
+}
+
+func newATXSetVersions() *atxSetVersions {
+	return &atxSetVersions{epochs: map[types.EpochID]*atxSetHistory{}}
+}
+
+// delta returns the ids of the epoch as a delta against the version of the set with the base hash.
+// All ids are returned if the base version is not known.
+func (v *atxSetVersions) delta(epoch types.EpochID, ids []types.ATXID, base types.Hash32) *EpochDelta {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	history := v.update(epoch, ids)
+	if base == history.hash {
+		return &EpochDelta{Hash: history.hash}
+	}
+	full := &EpochDelta{Full: true, Hash: history.hash, Added: history.ids}
+	start := slices.IndexFunc(history.versions, func(version atxSetVersion) bool {
+		return version.hash == base
+	})
+	if base == (types.Hash32{}) || start < 0 {
+		return full
+	}
+	added := map[types.ATXID]struct{}{}
+	removed := map[types.ATXID]struct{}{}
+	for _, version := range history.versions[start:] {
+		for _, id := range version.added {
+			if _, exists := removed[id]; exists {
+				delete(removed, id)
+			} else {
+				added[id] = struct{}{}
+			}
+		}
+		for _, id := range version.removed {
+			if _, exists := added[id]; exists {
+				delete(added, id)
+			} else {
+				removed[id] = struct{}{}
+			}
+		}
+	}
+	if len(added)+len(removed) >= len(history.ids) {
+		return full
+	}
+	delta := &EpochDelta{Hash: history.hash}
+	for id := range added {
+		delta.Added = append(delta.Added, id)
+	}
+	for id := range removed {
+		delta.Removed = append(delta.Removed, id)
+	}
+	slices.SortFunc(delta.Added, compareATXIDs)
+	slices.SortFunc(delta.Removed, compareATXIDs)
+	return delta
+}
+
+// update records the current ids of the epoch as the latest version of the set.
+func (v *atxSetVersions) update(epoch types.EpochID, raw []types.ATXID) *atxSetHistory {
+	history, exists := v.epochs[epoch]
+	if exists && sameSlice(history.raw, raw) {
+		// the ids are shared by the query cache while the set doesn't change
+		return history
+	}
+	ids := sortedATXIDs(raw)
+	if !exists {
+		history = &atxSetHistory{raw: raw, ids: ids, hash: atxSetHash(ids)}
+		v.epochs[epoch] = history
+		if len(v.epochs) > maxDeltaEpochs {
+			delete(v.epochs, minEpoch(v.epochs))
+		}
+		return history
+	}
+	current := atxSetHash(ids)
+	if current != history.hash {
+		added, removed := diffATXIDs(history.ids, ids)
+		history.versions = append(history.versions, atxSetVersion{
+			hash:    history.hash,
+			added:   added,
+			removed: removed,
+		})
+		if len(history.versions) > maxDeltaVersions {
+			history.versions = slices.Delete(history.versions, 0, len(history.versions)-maxDeltaVersions)
+		}
+	}
+	history.raw = raw
+	history.ids = ids
+	history.hash = current
+	return history
+}
+
+// knownATXSets keeps the latest set of ids received for the recent epochs,
+// so that the sets can be requested from other peers as deltas against them.
+type knownATXSets struct {
+	mu   sync.Mutex
+	sets map[types.EpochID]knownATXSet
+}
+
+type knownATXSet struct {
+	hash types.Hash32
+	ids  []types.ATXID
+}
+
+func newKnownATXSets() *knownATXSets {
+	return &knownATXSets{sets: map[types.EpochID]knownATXSet{}}
+}
+
+func (k *knownATXSets) get(epoch types.EpochID) knownATXSet {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.sets[epoch]
+}
+
+func (k *knownATXSets) set(epoch types.EpochID, set knownATXSet) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sets[epoch] = set
+	if len(k.sets) > maxDeltaEpochs {
+		delete(k.sets, minEpoch(k.sets))
+	}
+}
+
+func minEpoch[V any](epochs map[types.EpochID]V) types.EpochID {
+	first := true
+	var rst types.EpochID
+	for epoch := range epochs {
+		if first || epoch < rst {
+			rst = epoch
+			first = false
+		}
+	}
+	return rst
+}
+
+func sameSlice(a, b []types.ATXID) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -4,11 +4,17 @@ package fetch
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math/rand"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
@@ -20,18 +26,39 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 const (
 	atxProtocol      = "ax/1"
 	lyrDataProtocol  = "ld/1"
 	hashProtocol     = "hs/1"
+	hashProtocolV2   = "hs/2"
+	hashProtocolV3   = "hs/3" // same as hashProtocolV2, but the response is streamed in chunks
 	meshHashProtocol = "mh/1"
 	malProtocol      = "ml/1"
 	OpnProtocol      = "lp/2"
+	certProtocol     = "ct/1"
+	// serves only the presence of the hashes, used to decide if the data needs to be gossiped again.
+	hashProbeProtocol = "hp/1"
+	// serves the atx ids of the epoch as a delta against the set known to the requester.
+	atxDeltaProtocol = "ad/1"
+
+	// light client protocols, served only if enabled in the config.
+	lightLayersProtocol = "lh/1"
+	atxHeadersProtocol  = "ah/1"
 
 	cacheSize = 1000
 
+	// ledgerCapacity is the number of identities for which the usage of the servers is tracked.
+	ledgerCapacity = 10000
+	// ledgerTop is the number of identities with the largest usage that are logged with peer stats.
+	ledgerTop = 10
+
+	// streamChunkSize is the size of the data above which the response on hashProtocolV3 is split in chunks.
+	streamChunkSize = 4 << 20
+
 	RedundantPeers = 5
 )
 
@@ -43,6 +70,17 @@ var (
 	errValidatorsNotSet = errors.New("validators not set")
 )
 
+// HintMismatchError is returned when the peer found the requested hash under a different hint.
+type HintMismatchError struct {
+	Hash      types.Hash32
+	Requested datastore.Hint
+	Actual    datastore.Hint
+}
+
+func (e *HintMismatchError) Error() string {
+	return fmt.Sprintf("hash %s requested as %s is %s", e.Hash.ShortString(), e.Requested, e.Actual)
+}
+
 // request contains all relevant Data for a single request for a specified hash.
 type request struct {
 	ctx       context.Context
@@ -61,6 +99,11 @@ type promise struct {
 type batchInfo struct {
 	RequestBatch
 	peer p2p.Peer
+	// hinted is true if the batch is requested with hashProtocolV2 or hashProtocolV3.
+	hinted bool
+	// links to the spans of the requesters, the batch is sent in the background
+	// and can't be a child of any of them.
+	links []trace.Link
 }
 
 // setID calculates the hash of all requests and sets it as this batches ID.
@@ -84,6 +127,9 @@ type ServerConfig struct {
 	Queue    int           `mapstructure:"queue"`
 	Requests int           `mapstructure:"requests"`
 	Interval time.Duration `mapstructure:"interval"`
+	// Compress enables zstd compression of the responses that are not shorter than CompressionThreshold,
+	// if the peer requests it.
+	Compress bool `mapstructure:"compress"`
 }
 
 func (s ServerConfig) toOpts() []server.Opt {
@@ -111,6 +157,37 @@ type Config struct {
 	GetAtxsConcurrency   int64                  `mapstructure:"getatxsconcurrency"`
 	DecayingTag          server.DecayingTagSpec `mapstructure:"decaying-tag"`
 	LogPeerStatsInterval time.Duration          `mapstructure:"log-peer-stats-interval"`
+	// CompressionThreshold is the minimal size of the response data that is compressed
+	// by the servers with compression enabled.
+	CompressionThreshold int `mapstructure:"compression-threshold"`
+
+	// MaxBatchSize enables adaptive batching if not zero. The batch size of every peer starts at BatchSize,
+	// grows up to MaxBatchSize while the peer serves batches within BatchLatencyTarget,
+	// and is halved after a slow or failed batch.
+	MaxBatchSize       int           `mapstructure:"max-batchsize"`
+	BatchLatencyTarget time.Duration `mapstructure:"batch-latency-target"`
+	// SplitFailedBatches requests the halves of a failed batch from the same peer again,
+	// until the hashes that the peer fails to serve are isolated.
+	SplitFailedBatches bool `mapstructure:"split-failed-batches"`
+
+	// ServeLight enables the protocols for light clients that serve layer certificates,
+	// aggregated hashes and atx headers.
+	ServeLight bool `mapstructure:"serve-light"`
+
+	// SignRequests signs requests with the identity of the node if the peer serves the authenticated protocol,
+	// so that the peer can attribute requests to the identity across reconnects.
+	SignRequests bool `mapstructure:"sign-requests"`
+	// AuthenticatedOnly are the protocols that are served only for signed requests, e.g. "ax/1" for epoch info.
+	AuthenticatedOnly []string `mapstructure:"authenticated-only"`
+}
+
+// authenticatedOnly returns true if the protocol is served only for signed requests.
+// The deltas of the epoch atx ids serve the same data as the epoch info, so they share the restriction.
+func (c Config) authenticatedOnly(protocol string) bool {
+	if protocol == atxDeltaProtocol {
+		protocol = atxProtocol
+	}
+	return slices.Contains(c.AuthenticatedOnly, protocol)
 }
 
 func (c Config) getServerConfig(protocol string) ServerConfig {
@@ -131,25 +208,43 @@ func DefaultConfig() Config {
 		BatchTimeout:         50 * time.Millisecond,
 		QueueSize:            20,
 		BatchSize:            10,
+		MaxBatchSize:         MaxHashesInBatch,
+		BatchLatencyTarget:   2 * time.Second,
+		SplitFailedBatches:   true,
 		RequestTimeout:       25 * time.Second,
 		RequestHardTimeout:   5 * time.Minute,
 		MaxRetriesForRequest: 100,
 		ServersConfig: map[string]ServerConfig{
 			// serves 1 MB of data
 			atxProtocol: {Queue: 10, Requests: 1, Interval: time.Second},
+			// same as atxProtocol, usually much cheaper to serve
+			atxDeltaProtocol: {Queue: 10, Requests: 1, Interval: time.Second},
 			// serves 1 KB of data
 			lyrDataProtocol: {Queue: 1000, Requests: 100, Interval: time.Second},
 			// serves atxs, ballots, active sets
 			// atx - 1 KB
 			// ballots > 300 bytes
 			// often queried after receiving gossip message
-			hashProtocol: {Queue: 2000, Requests: 200, Interval: time.Second},
+			// ballots and active sets are highly compressible
+			hashProtocol: {Queue: 2000, Requests: 200, Interval: time.Second, Compress: true},
+			// same as hashProtocol, but responds with the actual hint of the hash
+			hashProtocolV2: {Queue: 2000, Requests: 200, Interval: time.Second, Compress: true},
+			// same as hashProtocolV2, streams batches that don't fit into a single response
+			hashProtocolV3: {Queue: 2000, Requests: 200, Interval: time.Second},
 			// serves at most 100 hashes - 3KB
 			meshHashProtocol: {Queue: 1000, Requests: 100, Interval: time.Second},
 			// serves all malicious ids (id - 32 byte) - 10KB
 			malProtocol: {Queue: 100, Requests: 10, Interval: time.Second},
 			// 64 bytes
 			OpnProtocol: {Queue: 10000, Requests: 1000, Interval: time.Second},
+			// serves at most 100 certificates - 1 MB
+			certProtocol: {Queue: 100, Requests: 10, Interval: time.Second},
+			// serves presence of at most 100 hashes - 3KB
+			hashProbeProtocol: {Queue: 1000, Requests: 100, Interval: time.Second},
+			// serves at most 100 layers - 100 KB
+			lightLayersProtocol: {Queue: 100, Requests: 10, Interval: time.Second},
+			// serves at most 10000 atx headers - 1 MB
+			atxHeadersProtocol: {Queue: 10, Requests: 1, Interval: time.Second},
 		},
 		GetAtxsConcurrency: 100,
 		DecayingTag: server.DecayingTagSpec{
@@ -159,7 +254,19 @@ func DefaultConfig() Config {
 			Cap:      10000,
 		},
 		LogPeerStatsInterval: 20 * time.Minute,
+		CompressionThreshold: 1024,
+	}
+}
+
+type usageList []server.Usage
+
+func (l usageList) MarshalLogArray(enc log.ArrayEncoder) error {
+	for i := range l {
+		if err := enc.AppendObject(&l[i]); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // randomPeer returns a random peer from current peer list.
@@ -191,6 +298,20 @@ func WithLogger(log log.Log) Option {
 	}
 }
 
+// WithSigner configures the identity that signs requests if SignRequests is enabled.
+func WithSigner(signer *signing.EdSigner) Option {
+	return func(f *Fetch) {
+		f.signer = signer
+	}
+}
+
+// WithVerifier enables serving authenticated requests, the usage is attributed to the identities of the clients.
+func WithVerifier(verifier *signing.EdVerifier) Option {
+	return func(f *Fetch) {
+		f.verifier = verifier
+	}
+}
+
 func withServers(s map[string]requester) Option {
 	return func(f *Fetch) {
 		f.servers = s
@@ -210,10 +331,17 @@ type Fetch struct {
 	bs     *datastore.BlobStore
 	host   host
 	peers  *peers.Peers
+	// peerProtocols returns the protocols supported by the peer, nil if the host is not available.
+	peerProtocols func(p2p.Peer) ([]protocol.ID, error)
 
 	servers    map[string]requester
 	validators *dataValidators
 
+	signer   *signing.EdSigner
+	verifier *signing.EdVerifier
+	// ledger is nil if authenticated requests are not served.
+	ledger *server.Ledger
+
 	// unprocessed contains requests that are not processed
 	unprocessed map[types.Hash32]*request
 	// ongoing contains requests that have been processed and are waiting for responses
@@ -228,6 +356,10 @@ type Fetch struct {
 	eg          errgroup.Group
 
 	getAtxsLimiter limiter
+	// knownATXSets are the latest sets of atx ids received for the recent epochs.
+	knownATXSets *knownATXSets
+	// batchSizes is nil if batch size is not adapted to peers.
+	batchSizes *batchSizes
 }
 
 // NewFetch creates a new Fetch struct.
@@ -240,23 +372,35 @@ func NewFetch(
 	bs := datastore.NewBlobStore(cdb, proposals)
 
 	f := &Fetch{
-		cfg:         DefaultConfig(),
-		logger:      log.NewNop(),
-		bs:          bs,
-		host:        host,
-		servers:     map[string]requester{},
-		unprocessed: make(map[types.Hash32]*request),
-		ongoing:     make(map[types.Hash32]*request),
-		hashToPeers: NewHashPeersCache(cacheSize),
+		cfg:          DefaultConfig(),
+		logger:       log.NewNop(),
+		bs:           bs,
+		host:         host,
+		servers:      map[string]requester{},
+		unprocessed:  make(map[types.Hash32]*request),
+		ongoing:      make(map[types.Hash32]*request),
+		hashToPeers:  NewHashPeersCache(cacheSize),
+		knownATXSets: newKnownATXSets(),
 	}
 	for _, opt := range opts {
 		opt(f)
 	}
 	f.getAtxsLimiter = semaphore.NewWeighted(f.cfg.GetAtxsConcurrency)
+	if f.cfg.MaxBatchSize > 0 {
+		f.batchSizes = newBatchSizes(
+			f.cfg.BatchSize,
+			min(f.cfg.MaxBatchSize, MaxHashesInBatch),
+			f.cfg.BatchLatencyTarget,
+		)
+	}
 	f.peers = peers.New()
+	if f.verifier != nil {
+		f.ledger = server.NewLedger(ledgerCapacity)
+	}
 	// NOTE(dshulyak) this is to avoid tests refactoring.
 	// there is one test that covers this part.
 	if host != nil {
+		f.peerProtocols = host.PeerProtocols
 		connectedf := func(peer p2p.Peer) {
 			if f.peers.Add(peer) {
 				f.logger.With().Debug("add peer", log.Stringer("id", peer))
@@ -272,6 +416,9 @@ func NewFetch(
 				if !c.Stat().Transient && !host.Connected(c.RemotePeer()) {
 					f.logger.With().Debug("remove peer", log.Stringer("id", c.RemotePeer()))
 					f.peers.Delete(c.RemotePeer())
+					if f.batchSizes != nil {
+						f.batchSizes.delete(c.RemotePeer())
+					}
 				}
 			},
 		})
@@ -288,9 +435,18 @@ func NewFetch(
 		f.registerServer(host, atxProtocol, h.handleEpochInfoReq)
 		f.registerServer(host, lyrDataProtocol, h.handleLayerDataReq)
 		f.registerServer(host, hashProtocol, h.handleHashReq)
+		f.registerServer(host, hashProtocolV2, h.handleHintedHashReq)
+		f.registerServer(host, hashProtocolV3, nil, server.WithStreamHandler(h.handleStreamedHashReq))
 		f.registerServer(host, meshHashProtocol, h.handleMeshHashReq)
 		f.registerServer(host, malProtocol, h.handleMaliciousIDsReq)
 		f.registerServer(host, OpnProtocol, h.handleLayerOpinionsReq2)
+		f.registerServer(host, certProtocol, h.handleCertificatesReq)
+		f.registerServer(host, hashProbeProtocol, h.handleHashProbeReq)
+		f.registerServer(host, atxDeltaProtocol, h.handleEpochDeltaReq)
+		if f.cfg.ServeLight {
+			f.registerServer(host, lightLayersProtocol, h.handleLightLayersReq)
+			f.registerServer(host, atxHeadersProtocol, h.handleAtxHeadersReq)
+		}
 	}
 	return f
 }
@@ -299,6 +455,7 @@ func (f *Fetch) registerServer(
 	host *p2p.Host,
 	protocol string,
 	handler server.Handler,
+	extra ...server.Opt,
 ) {
 	opts := []server.Opt{
 		server.WithTimeout(f.cfg.RequestTimeout),
@@ -309,7 +466,21 @@ func (f *Fetch) registerServer(
 	if f.cfg.EnableServerMetrics {
 		opts = append(opts, server.WithMetrics())
 	}
-	opts = append(opts, f.cfg.getServerConfig(protocol).toOpts()...)
+	if f.cfg.SignRequests && f.signer != nil {
+		opts = append(opts, server.WithSigner(f.signer))
+	}
+	if f.verifier != nil {
+		opts = append(opts, server.WithVerifier(f.verifier), server.WithAccountant(f.ledger))
+		if f.cfg.authenticatedOnly(protocol) {
+			opts = append(opts, server.WithRequireAuthentication())
+		}
+	}
+	cfg := f.cfg.getServerConfig(protocol)
+	if cfg.Compress {
+		opts = append(opts, server.WithCompression(f.cfg.CompressionThreshold))
+	}
+	opts = append(opts, cfg.toOpts()...)
+	opts = append(opts, extra...)
 	f.servers[protocol] = server.New(host, protocol, handler, opts...)
 }
 
@@ -374,6 +545,10 @@ func (f *Fetch) Start() error {
 				case <-time.After(f.cfg.LogPeerStatsInterval):
 					stats := f.peers.Stats()
 					f.logger.With().Info("peer stats", log.Inline(&stats))
+					if f.ledger != nil {
+						usage := usageList(f.ledger.Top(ledgerTop))
+						f.logger.With().Info("authenticated requests", log.Array("top", usage))
+					}
 				}
 			}
 		})
@@ -443,17 +618,11 @@ func (f *Fetch) meteredRequest(
 }
 
 // receive Data from message server and call response handlers accordingly.
-func (f *Fetch) receiveResponse(data []byte, batch *batchInfo) {
+func (f *Fetch) receiveResponse(response *HintedResponseBatch, batch *batchInfo) {
 	if f.stopped() {
 		return
 	}
 
-	var response ResponseBatch
-	if err := codec.Decode(data, &response); err != nil {
-		f.logger.With().Warning("failed to decode batch response", log.Err(err))
-		return
-	}
-
 	f.logger.With().Debug("received batch response",
 		log.Stringer("batch_hash", response.ID),
 		log.Int("num_hashes", len(response.Responses)),
@@ -481,6 +650,11 @@ func (f *Fetch) receiveResponse(data []byte, batch *batchInfo) {
 				log.Stringer("hash", resp.Hash))
 			continue
 		}
+		if requested, ok := batchMap[resp.Hash]; ok && requested.Hint != resp.Hint {
+			f.hintMismatch(resp.Hash, requested.Hint, resp.Hint, batch.peer)
+			delete(batchMap, resp.Hash)
+			continue
+		}
 
 		rsp := resp
 		f.eg.Go(func() error {
@@ -503,6 +677,58 @@ func (f *Fetch) receiveResponse(data []byte, batch *batchInfo) {
 	}
 }
 
+// decodeResponse decodes the response to the batch. Responses on hashProtocol have the requested hint.
+func decodeResponse(data []byte, batch *batchInfo) (*HintedResponseBatch, error) {
+	var response HintedResponseBatch
+	if batch.hinted {
+		if err := codec.Decode(data, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}
+	var legacy ResponseBatch
+	if err := codec.Decode(data, &legacy); err != nil {
+		return nil, err
+	}
+	hints := make(map[types.Hash32]datastore.Hint, len(batch.Requests))
+	for _, r := range batch.Requests {
+		hints[r.Hash] = r.Hint
+	}
+	response.ID = legacy.ID
+	response.Responses = make([]HintedResponseMessage, 0, len(legacy.Responses))
+	for _, r := range legacy.Responses {
+		response.Responses = append(response.Responses, HintedResponseMessage{
+			Hash: r.Hash,
+			Hint: hints[r.Hash],
+			Data: r.Data,
+		})
+	}
+	return &response, nil
+}
+
+// hintMismatch fails the request for the hash that the peer found under a different hint.
+// The request is not retried, data under the requested hint doesn't exist.
+func (f *Fetch) hintMismatch(hash types.Hash32, requested, actual datastore.Hint, peer p2p.Peer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req, ok := f.ongoing[hash]
+	if !ok {
+		f.logger.With().Error("hash missing from ongoing requests", log.Stringer("hash", hash))
+		return
+	}
+	f.logger.WithContext(req.ctx).With().Debug("hash requested with wrong hint",
+		log.Stringer("hash", hash),
+		log.String("hint", string(requested)),
+		log.String("actual", string(actual)),
+		log.Stringer("peer", peer),
+	)
+	hashHintMismatch.WithLabelValues(string(requested), string(actual)).Inc()
+	req.promise.err = &HintMismatchError{Hash: hash, Requested: requested, Actual: actual}
+	close(req.promise.completed)
+	delete(f.ongoing, hash)
+}
+
 func (f *Fetch) hashValidationDone(hash types.Hash32, err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -593,27 +819,80 @@ func (f *Fetch) send(requests []RequestMessage) {
 				RequestBatch: RequestBatch{
 					Requests: reqs,
 				},
-				peer: peer,
+				peer:  peer,
+				links: f.requestLinks(reqs),
 			}
 			batch.setID()
-			go func() {
-				data, err := f.sendBatch(peer, batch)
-				if err != nil {
-					f.logger.With().Debug(
-						"failed to send batch request",
-						log.Stringer("batch", batch.ID),
-						log.Stringer("peer", peer),
-						log.Err(err),
-					)
-					f.handleHashError(batch, err)
-				} else {
-					f.receiveResponse(data, batch)
-				}
-			}()
+			go f.fetchBatch(peer, batch)
 		}
 	}
 }
 
+// fetchBatch requests the batch from the peer and handles the response.
+// If enabled, a failed batch is split in halves that are requested from the peer one after another.
+func (f *Fetch) fetchBatch(peer p2p.Peer, batch *batchInfo) {
+	ctx, span := tracing.StartSpan(f.shutdownCtx, "fetch.batch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(batch.links...),
+		trace.WithAttributes(
+			attribute.Stringer("peer", peer),
+			attribute.Int("requests", len(batch.Requests)),
+		),
+	)
+	start := time.Now()
+	response, err := f.sendBatch(ctx, peer, batch)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		f.logger.With().Debug(
+			"failed to send batch request",
+			log.Stringer("batch", batch.ID),
+			log.Stringer("peer", peer),
+			log.Err(err),
+		)
+		if f.batchSizes != nil {
+			f.batchSizes.onFailure(peer)
+		}
+		if f.canSplit(batch, err) {
+			splitBatches.Inc()
+			for _, half := range splitBatch(batch) {
+				f.fetchBatch(peer, half)
+			}
+			return
+		}
+		f.handleHashError(batch, err)
+		return
+	}
+	if f.batchSizes != nil {
+		f.batchSizes.onResponse(peer, time.Since(start))
+	}
+	f.receiveResponse(response, batch)
+}
+
+// canSplit returns true if the failed batch should be split to isolate the hashes that fail.
+// Batches are not split if the peer is gone or the fetcher is stopped.
+func (f *Fetch) canSplit(batch *batchInfo, err error) bool {
+	return f.cfg.SplitFailedBatches &&
+		len(batch.Requests) > 1 &&
+		!f.stopped() &&
+		!errors.Is(err, server.ErrNotConnected) &&
+		!errors.Is(err, context.Canceled)
+}
+
+func splitBatch(batch *batchInfo) []*batchInfo {
+	mid := len(batch.Requests) / 2
+	halves := make([]*batchInfo, 0, 2)
+	for _, reqs := range [][]RequestMessage{batch.Requests[:mid], batch.Requests[mid:]} {
+		half := &batchInfo{
+			RequestBatch: RequestBatch{Requests: reqs},
+			peer:         batch.peer,
+			links:        batch.links,
+		}
+		half.setID()
+		halves = append(halves, half)
+	}
+	return halves
+}
+
 func (f *Fetch) organizeRequests(requests []RequestMessage) map[p2p.Peer][][]RequestMessage {
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	peer2requests := make(map[p2p.Peer][]RequestMessage)
@@ -652,17 +931,18 @@ func (f *Fetch) organizeRequests(requests []RequestMessage) map[p2p.Peer][][]Req
 		}
 	}
 
-	// split every peer's requests into batches of f.cfg.BatchSize each
+	// split every peer's requests into batches of the peer's batch size each
 	result := make(map[p2p.Peer][][]RequestMessage)
 	for peer, reqs := range peer2requests {
-		if len(reqs) < f.cfg.BatchSize {
+		size := f.batchSize(peer)
+		if len(reqs) < size {
 			result[peer] = [][]RequestMessage{
 				reqs,
 			}
 			continue
 		}
-		for i := 0; i < len(reqs); i += f.cfg.BatchSize {
-			j := i + f.cfg.BatchSize
+		for i := 0; i < len(reqs); i += size {
+			j := i + size
 			if j > len(reqs) {
 				j = len(reqs)
 			}
@@ -672,8 +952,33 @@ func (f *Fetch) organizeRequests(requests []RequestMessage) map[p2p.Peer][][]Req
 	return result
 }
 
+// batchSize returns the number of requests batched for the peer.
+func (f *Fetch) batchSize(peer p2p.Peer) int {
+	if f.batchSizes == nil {
+		return f.cfg.BatchSize
+	}
+	return f.batchSizes.size(peer)
+}
+
+// requestLinks returns links to the spans of the ongoing requests.
+func (f *Fetch) requestLinks(reqs []RequestMessage) []trace.Link {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var links []trace.Link
+	for _, msg := range reqs {
+		req, ok := f.ongoing[msg.Hash]
+		if !ok {
+			continue
+		}
+		if link, ok := tracing.Link(req.ctx); ok {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
 // sendBatch dispatches batched request messages to provided peer.
-func (f *Fetch) sendBatch(peer p2p.Peer, batch *batchInfo) ([]byte, error) {
+func (f *Fetch) sendBatch(ctx context.Context, peer p2p.Peer, batch *batchInfo) (*HintedResponseBatch, error) {
 	if f.stopped() {
 		return nil, f.shutdownCtx.Err()
 	}
@@ -686,7 +991,76 @@ func (f *Fetch) sendBatch(peer p2p.Peer, batch *batchInfo) ([]byte, error) {
 	// it will return errors only if size of the bytes buffer is large
 	// or target peer is not connected
 	req := codec.MustEncode(&batch.RequestBatch)
-	return f.meteredRequest(f.shutdownCtx, hashProtocol, peer, req)
+	if f.supports(peer, hashProtocolV3) {
+		batch.hinted = true
+		return f.streamBatch(ctx, peer, batch, req)
+	}
+	proto := hashProtocol
+	if batch.hinted = f.supportsHints(peer); batch.hinted {
+		proto = hashProtocolV2
+	}
+	data, err := f.meteredRequest(ctx, proto, peer, req)
+	if err != nil {
+		return nil, err
+	}
+	response, err := decodeResponse(data, batch)
+	if err != nil {
+		return nil, fmt.Errorf("decode batch response: %w", err)
+	}
+	return response, nil
+}
+
+// streamBatch requests the batch on hashProtocolV3 and collects the chunks of the response.
+func (f *Fetch) streamBatch(
+	ctx context.Context,
+	peer p2p.Peer,
+	batch *batchInfo,
+	req []byte,
+) (*HintedResponseBatch, error) {
+	response := &HintedResponseBatch{ID: batch.ID}
+	start := time.Now()
+	size := 0
+	err := f.servers[hashProtocolV3].StreamRequest(ctx, peer, req, func(rd io.Reader) error {
+		for {
+			var chunk ResponseChunk
+			n, err := codec.DecodeFrom(rd, &chunk)
+			if err != nil {
+				return fmt.Errorf("decode response chunk: %w", err)
+			}
+			size += n
+			response.Responses = append(response.Responses, chunk.Responses...)
+			if len(response.Responses) > len(batch.Requests) {
+				return fmt.Errorf("too many responses (%d) to %d requests",
+					len(response.Responses), len(batch.Requests))
+			}
+			if chunk.Last {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		f.peers.OnFailure(peer)
+		return nil, err
+	}
+	f.peers.OnLatency(peer, size, time.Since(start))
+	return response, nil
+}
+
+// supportsHints returns true if the peer serves hashProtocolV2.
+func (f *Fetch) supportsHints(peer p2p.Peer) bool {
+	return f.supports(peer, hashProtocolV2)
+}
+
+// supports returns true if the peer advertises the protocol.
+func (f *Fetch) supports(peer p2p.Peer, proto protocol.ID) bool {
+	if f.peerProtocols == nil {
+		return false
+	}
+	protocols, err := f.peerProtocols(peer)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(protocols, proto)
 }
 
 // handleHashError is called when an error occurred processing batches of the following hashes.
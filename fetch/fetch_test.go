@@ -1,12 +1,18 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -21,6 +27,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
+	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
@@ -33,6 +40,7 @@ type testFetch struct {
 	mHashS  *mocks.Mockrequester
 	mMHashS *mocks.Mockrequester
 	mOpn2S  *mocks.Mockrequester
+	mCertS  *mocks.Mockrequester
 
 	mMalH        *mocks.MockSyncValidator
 	mAtxH        *mocks.MockSyncValidator
@@ -56,6 +64,7 @@ func createFetch(tb testing.TB) *testFetch {
 		mHashS:       mocks.NewMockrequester(ctrl),
 		mMHashS:      mocks.NewMockrequester(ctrl),
 		mOpn2S:       mocks.NewMockrequester(ctrl),
+		mCertS:       mocks.NewMockrequester(ctrl),
 		mMalH:        mocks.NewMockSyncValidator(ctrl),
 		mAtxH:        mocks.NewMockSyncValidator(ctrl),
 		mBallotH:     mocks.NewMockSyncValidator(ctrl),
@@ -66,7 +75,7 @@ func createFetch(tb testing.TB) *testFetch {
 		mTxProposalH: mocks.NewMockSyncValidator(ctrl),
 		mPoetH:       mocks.NewMockSyncValidator(ctrl),
 	}
-	for _, srv := range []*mocks.Mockrequester{tf.mMalS, tf.mAtxS, tf.mLyrS, tf.mHashS, tf.mMHashS, tf.mOpn2S} {
+	for _, srv := range []*mocks.Mockrequester{tf.mMalS, tf.mAtxS, tf.mLyrS, tf.mHashS, tf.mMHashS, tf.mOpn2S, tf.mCertS} {
 		srv.EXPECT().Run(gomock.Any()).AnyTimes()
 	}
 	cfg := Config{
@@ -91,6 +100,7 @@ func createFetch(tb testing.TB) *testFetch {
 			hashProtocol:     tf.mHashS,
 			meshHashProtocol: tf.mMHashS,
 			OpnProtocol:      tf.mOpn2S,
+			certProtocol:     tf.mCertS,
 		}),
 		withHost(tf.mh))
 	tf.Fetch.SetValidators(
@@ -292,6 +302,146 @@ func TestFetch_Loop_BatchRequestMax(t *testing.T) {
 	}
 }
 
+func TestFetch_SplitFailedBatch(t *testing.T) {
+	f := createFetch(t)
+	f.cfg.QueueSize = 4
+	f.cfg.BatchSize = 4
+	f.cfg.SplitFailedBatches = true
+	f.cfg.MaxRetriesForRequest = 0
+	peer := p2p.Peer("buddy")
+	f.peers.Add(peer)
+
+	hashes := []types.Hash32{types.RandomHash(), types.RandomHash(), types.RandomHash(), types.RandomHash()}
+	bad := hashes[2]
+	var (
+		mu      sync.Mutex
+		batches []int
+	)
+	f.mHashS.EXPECT().
+		Request(gomock.Any(), peer, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ p2p.Peer, req []byte) ([]byte, error) {
+			var rb RequestBatch
+			require.NoError(t, codec.Decode(req, &rb))
+			mu.Lock()
+			batches = append(batches, len(rb.Requests))
+			mu.Unlock()
+			resBatch := ResponseBatch{ID: rb.ID}
+			for _, r := range rb.Requests {
+				if r.Hash == bad {
+					return nil, errors.New("peer error: failed to serve")
+				}
+				resBatch.Responses = append(resBatch.Responses, ResponseMessage{Hash: r.Hash, Data: []byte("a")})
+			}
+			return codec.MustEncode(&resBatch), nil
+		}).
+		Times(5)
+	// batch of 4 fails -> halves of 2, one of them fails -> halves of 1
+
+	defer f.Stop()
+	require.NoError(t, f.Start())
+	var promises []*promise
+	for _, h := range hashes {
+		p, err := f.getHash(context.Background(), h, datastore.POETDB, goodReceiver)
+		require.NoError(t, err)
+		promises = append(promises, p)
+	}
+	for i, p := range promises {
+		<-p.completed
+		if hashes[i] == bad {
+			require.Error(t, p.err)
+		} else {
+			require.NoError(t, p.err)
+		}
+	}
+	require.ElementsMatch(t, []int{4, 2, 2, 1, 1}, batches)
+}
+
+func TestFetch_HintMismatch(t *testing.T) {
+	f := createFetch(t)
+	peer := p2p.Peer("buddy")
+	f.peers.Add(peer)
+	hinted := mocks.NewMockrequester(gomock.NewController(t))
+	f.servers[hashProtocolV2] = hinted
+	f.peerProtocols = func(p2p.Peer) ([]protocol.ID, error) {
+		return []protocol.ID{hashProtocol, hashProtocolV2}, nil
+	}
+
+	ballot := types.RandomHash()
+	poet := types.RandomHash()
+	hinted.EXPECT().
+		Request(gomock.Any(), peer, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ p2p.Peer, req []byte) ([]byte, error) {
+			var rb RequestBatch
+			require.NoError(t, codec.Decode(req, &rb))
+			require.Len(t, rb.Requests, 2)
+			return codec.MustEncode(&HintedResponseBatch{
+				ID: rb.ID,
+				Responses: []HintedResponseMessage{
+					{Hash: ballot, Hint: datastore.ATXDB, Data: []byte("a")},
+					{Hash: poet, Hint: datastore.POETDB, Data: []byte("b")},
+				},
+			}), nil
+		})
+	f.mPoetH.EXPECT().HandleMessage(gomock.Any(), poet, peer, []byte("b"))
+
+	pb, err := f.getHash(context.Background(), ballot, datastore.BallotDB, f.validators.ballot.HandleMessage)
+	require.NoError(t, err)
+	pp, err := f.getHash(context.Background(), poet, datastore.POETDB, f.validators.poet.HandleMessage)
+	require.NoError(t, err)
+	f.requestHashBatchFromPeers()
+
+	<-pb.completed
+	var mismatch *HintMismatchError
+	require.ErrorAs(t, pb.err, &mismatch)
+	require.Equal(t, datastore.BallotDB, mismatch.Requested)
+	require.Equal(t, datastore.ATXDB, mismatch.Actual)
+	<-pp.completed
+	require.NoError(t, pp.err)
+}
+
+func TestFetch_StreamedBatch(t *testing.T) {
+	f := createFetch(t)
+	peer := p2p.Peer("buddy")
+	f.peers.Add(peer)
+	streamed := mocks.NewMockrequester(gomock.NewController(t))
+	f.servers[hashProtocolV3] = streamed
+	f.peerProtocols = func(p2p.Peer) ([]protocol.ID, error) {
+		return []protocol.ID{hashProtocol, hashProtocolV2, hashProtocolV3}, nil
+	}
+
+	set := types.RandomHash()
+	poet := types.RandomHash()
+	streamed.EXPECT().
+		StreamRequest(gomock.Any(), peer, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ p2p.Peer, req []byte, read func(io.Reader) error) error {
+			var rb RequestBatch
+			require.NoError(t, codec.Decode(req, &rb))
+			require.Len(t, rb.Requests, 2)
+			var buf bytes.Buffer
+			codec.EncodeTo(&buf, &ResponseChunk{
+				Responses: []HintedResponseMessage{{Hash: set, Hint: datastore.ActiveSet, Data: []byte("a")}},
+			})
+			codec.EncodeTo(&buf, &ResponseChunk{
+				Responses: []HintedResponseMessage{{Hash: poet, Hint: datastore.POETDB, Data: []byte("b")}},
+				Last:      true,
+			})
+			return read(&buf)
+		})
+	f.mActiveSetH.EXPECT().HandleMessage(gomock.Any(), set, peer, []byte("a"))
+	f.mPoetH.EXPECT().HandleMessage(gomock.Any(), poet, peer, []byte("b"))
+
+	ps, err := f.getHash(context.Background(), set, datastore.ActiveSet, f.validators.activeset.HandleMessage)
+	require.NoError(t, err)
+	pp, err := f.getHash(context.Background(), poet, datastore.POETDB, f.validators.poet.HandleMessage)
+	require.NoError(t, err)
+	f.requestHashBatchFromPeers()
+
+	<-ps.completed
+	require.NoError(t, ps.err)
+	<-pp.completed
+	require.NoError(t, pp.err)
+}
+
 func TestFetch_GetRandomPeer(t *testing.T) {
 	myPeers := make([]p2p.Peer, 1000)
 	for i := 0; i < len(myPeers); i++ {
@@ -444,3 +594,85 @@ func TestFetch_PeerDroppedWhenMessageResultsInValidationReject(t *testing.T) {
 	}, time.Second*15, time.Millisecond*200)
 	require.Equal(t, 0, len(h.GetPeers()))
 }
+
+func TestFetch_AuthenticatedOnly(t *testing.T) {
+	mesh, err := mocknet.FullMeshConnected(3)
+	require.NoError(t, err)
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	verifier := signing.NewEdVerifier()
+
+	cfg := DefaultConfig()
+	cfg.SignRequests = true
+	cfg.AuthenticatedOnly = []string{atxProtocol}
+	start := func(i int, opts ...Option) *Fetch {
+		host, err := p2p.Upgrade(mesh.Hosts()[i])
+		require.NoError(t, err)
+		f := NewFetch(datastore.NewCachedDB(sql.InMemory(), logtest.New(t)), store.New(), host,
+			append([]Option{
+				WithContext(context.Background()),
+				WithConfig(cfg),
+				WithLogger(logtest.New(t)),
+			}, opts...)...,
+		)
+		f.SetValidators(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, f.Start())
+		t.Cleanup(f.Stop)
+		return f
+	}
+	srv := start(0, WithVerifier(verifier))
+	signed := start(1, WithSigner(signer))
+	unsigned := start(2)
+	require.Eventually(t, func() bool {
+		return slices.Contains(mesh.Hosts()[0].Mux().Protocols(), protocol.ID(server.AuthProtocol(atxProtocol)))
+	}, time.Second, 10*time.Millisecond)
+	require.NotContains(t, mesh.Hosts()[0].Mux().Protocols(), protocol.ID(atxProtocol))
+	require.NotContains(t, mesh.Hosts()[0].Mux().Protocols(), protocol.ID(atxDeltaProtocol))
+
+	_, err = signed.PeerEpochInfo(context.Background(), mesh.Hosts()[0].ID(), 1)
+	require.NoError(t, err)
+	_, err = unsigned.PeerEpochInfo(context.Background(), mesh.Hosts()[0].ID(), 1)
+	require.Error(t, err)
+	// other protocols are served without authentication
+	_, err = unsigned.GetMaliciousIDs(context.Background(), mesh.Hosts()[0].ID())
+	require.NoError(t, err)
+
+	usage, exists := srv.ledger.Get(signer.NodeID())
+	require.True(t, exists)
+	require.Equal(t, 1, usage.Requests)
+	require.Equal(t, mesh.Hosts()[1].ID(), usage.Peer)
+}
+
+func TestFetch_Compression(t *testing.T) {
+	mesh, err := mocknet.FullMeshConnected(2)
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	cfg.ServersConfig[malProtocol] = ServerConfig{Compress: true}
+	start := func(i int) *Fetch {
+		host, err := p2p.Upgrade(mesh.Hosts()[i])
+		require.NoError(t, err)
+		f := NewFetch(datastore.NewCachedDB(sql.InMemory(), logtest.New(t)), store.New(), host,
+			WithContext(context.Background()),
+			WithConfig(cfg),
+			WithLogger(logtest.New(t)),
+		)
+		f.SetValidators(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, f.Start())
+		t.Cleanup(f.Stop)
+		return f
+	}
+	start(0)
+	client := start(1)
+	require.Eventually(t, func() bool {
+		return slices.Contains(mesh.Hosts()[0].Mux().Protocols(), protocol.ID(server.CompressedProtocol(malProtocol)))
+	}, time.Second, 10*time.Millisecond)
+	protocols := mesh.Hosts()[0].Mux().Protocols()
+	require.Contains(t, protocols, protocol.ID(server.CompressedProtocol(hashProtocol)))
+	require.NotContains(t, protocols, protocol.ID(server.CompressedProtocol(atxProtocol)))
+	// uncompressed protocols are served for peers without compression
+	require.Contains(t, protocols, protocol.ID(malProtocol))
+
+	_, err = client.GetMaliciousIDs(context.Background(), mesh.Hosts()[0].ID())
+	require.NoError(t, err)
+}
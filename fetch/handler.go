@@ -1,8 +1,14 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spacemeshos/go-scale"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
@@ -18,12 +24,18 @@ import (
 
 const (
 	fetchSubKey sql.QueryCacheSubKey = "epoch-info-req"
+
+	// maxEpochDataAtxs is the limit of EpochData.AtxIDs.
+	maxEpochDataAtxs = 2_200_000
+	// maxCompactLen is the largest size of the length of the slice in the scale encoding.
+	maxCompactLen = 5
 )
 
 type handler struct {
 	logger log.Log
 	cdb    *datastore.CachedDB
 	bs     *datastore.BlobStore
+	deltas *atxSetVersions
 }
 
 func newHandler(
@@ -35,6 +47,7 @@ func newHandler(
 		logger: lg,
 		cdb:    cdb,
 		bs:     bs,
+		deltas: newATXSetVersions(),
 	}
 }
 
@@ -62,10 +75,23 @@ func (h *handler) handleMaliciousIDsReq(ctx context.Context, _ []byte) ([]byte,
 // handleEpochInfoReq returns the ATXs published in the specified epoch.
 func (h *handler) handleEpochInfoReq(ctx context.Context, msg []byte) ([]byte, error) {
 	var epoch types.EpochID
-	if err := codec.Decode(msg, &epoch); err != nil {
+	if err := codec.DecodeWithLimit(msg, &epoch, MaxRequestSize); err != nil {
 		return nil, err
 	}
 
+	if !sql.IsCached(h.cdb) {
+		// without the query cache the ids are not kept in memory, they are streamed into the response
+		bts, count, err := encodeEpochData(h.cdb, epoch)
+		if err != nil {
+			h.logger.With().Warning("serve: failed to get epoch atx IDs",
+				epoch, log.Err(err), log.Context(ctx))
+			return nil, err
+		}
+		h.logger.With().Debug("serve: responded to epoch info request",
+			epoch, log.Context(ctx), log.Int("atx_count", count))
+		return bts, nil
+	}
+
 	cacheKey := sql.QueryCacheKey(atxs.CacheKindEpochATXs, epoch.String())
 	return sql.WithCachedSubKey(ctx, h.cdb, cacheKey, fetchSubKey, func(ctx context.Context) ([]byte, error) {
 		atxids, err := atxs.GetIDsByEpoch(ctx, h.cdb, epoch)
@@ -88,6 +114,58 @@ func (h *handler) handleEpochInfoReq(ctx context.Context, msg []byte) ([]byte, e
 	})
 }
 
+// handleEpochDeltaReq returns the ATXs published in the specified epoch as a delta against the set
+// with the hash known to the requester, or all of them if the set is not known.
+func (h *handler) handleEpochDeltaReq(ctx context.Context, msg []byte) ([]byte, error) {
+	var req EpochDeltaRequest
+	if err := codec.DecodeWithLimit(msg, &req, MaxRequestSize); err != nil {
+		return nil, err
+	}
+	// ids are shared with the query cache and must not be modified
+	atxids, err := atxs.GetIDsByEpoch(ctx, h.cdb, req.Epoch)
+	if err != nil {
+		h.logger.With().Warning("serve: failed to get epoch atx IDs",
+			req.Epoch, log.Err(err), log.Context(ctx))
+		return nil, err
+	}
+	delta := h.deltas.delta(req.Epoch, atxids, req.Base)
+	h.logger.With().Debug("serve: responded to epoch delta request",
+		req.Epoch,
+		log.Context(ctx),
+		log.Bool("full", delta.Full),
+		log.Int("added", len(delta.Added)),
+		log.Int("removed", len(delta.Removed)),
+	)
+	bts, err := codec.Encode(delta)
+	if err != nil {
+		h.logger.With().Fatal("serve: failed to serialize epoch delta",
+			req.Epoch, log.Context(ctx), log.Err(err))
+	}
+	return bts, nil
+}
+
+// encodeEpochData encodes the ids of the atxs published in the epoch the same way as EpochData.
+// Ids are streamed from the database into the encoded response without loading them as a slice.
+func encodeEpochData(db sql.Executor, epoch types.EpochID) ([]byte, int, error) {
+	// the space for the length of the ids is reserved in front of them, the length is known at the end
+	buf := bytes.NewBuffer(make([]byte, maxCompactLen))
+	count := 0
+	if err := atxs.IterateIDsByEpoch(db, epoch, func(id types.ATXID) bool {
+		buf.Write(id.Bytes())
+		count++
+		return true
+	}); err != nil {
+		return nil, 0, err
+	}
+	var length bytes.Buffer
+	if _, err := scale.EncodeLen(scale.NewEncoder(&length), uint32(count), maxEpochDataAtxs); err != nil {
+		return nil, 0, fmt.Errorf("encode epoch %v data: %w", epoch, err)
+	}
+	data := buf.Bytes()[maxCompactLen-length.Len():]
+	copy(data, length.Bytes())
+	return data, count, nil
+}
+
 // handleLayerDataReq returns all data in a layer, described in LayerData.
 func (h *handler) handleLayerDataReq(ctx context.Context, req []byte) ([]byte, error) {
 	var (
@@ -95,7 +173,7 @@ func (h *handler) handleLayerDataReq(ctx context.Context, req []byte) ([]byte, e
 		ld  LayerData
 		err error
 	)
-	if err := codec.Decode(req, &lid); err != nil {
+	if err := codec.DecodeWithLimit(req, &lid, MaxRequestSize); err != nil {
 		return nil, err
 	}
 	ld.Ballots, err = ballots.IDsInLayer(h.cdb, lid)
@@ -116,7 +194,7 @@ func (h *handler) handleLayerDataReq(ctx context.Context, req []byte) ([]byte, e
 
 func (h *handler) handleLayerOpinionsReq2(ctx context.Context, data []byte) ([]byte, error) {
 	var req OpinionRequest
-	if err := codec.Decode(data, &req); err != nil {
+	if err := codec.DecodeWithLimit(data, &req, MaxRequestSize); err != nil {
 		return nil, err
 	}
 	if req.Block != nil {
@@ -175,65 +253,214 @@ func (h *handler) handleCertReq(ctx context.Context, lid types.LayerID, bid type
 	return nil, err
 }
 
+// inferableHints are checked, in order, for the hash that isn't found under the requested hint.
+var inferableHints = []datastore.Hint{
+	datastore.ATXDB,
+	datastore.BallotDB,
+	datastore.ProposalDB,
+	datastore.BlockDB,
+	datastore.ActiveSet,
+	datastore.TXDB,
+	datastore.POETDB,
+}
+
 func (h *handler) handleHashReq(ctx context.Context, data []byte) ([]byte, error) {
+	return h.handleHashReqWithHints(ctx, data, false)
+}
+
+// handleHintedHashReq serves hashProtocolV2. Unlike handleHashReq, the hashes that are requested
+// with a wrong hint are served from the database in which they are found, with the actual hint
+// in the response.
+func (h *handler) handleHintedHashReq(ctx context.Context, data []byte) ([]byte, error) {
+	return h.handleHashReqWithHints(ctx, data, true)
+}
+
+func (h *handler) handleHashReqWithHints(ctx context.Context, data []byte, hinted bool) ([]byte, error) {
 	var requestBatch RequestBatch
-	if err := codec.Decode(data, &requestBatch); err != nil {
+	if err := codec.DecodeWithLimit(data, &requestBatch, MaxRequestSize); err != nil {
 		h.logger.With().Warning("serve: failed to parse request", log.Context(ctx), log.Err(err))
 		return nil, errBadRequest
 	}
-	resBatch := ResponseBatch{
-		ID:        requestBatch.ID,
-		Responses: make([]ResponseMessage, 0, len(requestBatch.Requests)),
-	}
+	responses := make([]HintedResponseMessage, 0, len(requestBatch.Requests))
 	// this will iterate all requests and populate appropriate Responses, if there are any missing items they will not
 	// be included in the response at all
 	for _, r := range requestBatch.Requests {
-		totalHashReqs.WithLabelValues(string(r.Hint)).Add(1)
-		res, err := h.bs.Get(ctx, r.Hint, r.Hash.Bytes())
-		if err != nil {
-			h.logger.With().Debug("serve: remote peer requested nonexistent hash",
-				log.Context(ctx),
-				log.String("hash", r.Hash.ShortString()),
-				log.String("hint", string(r.Hint)),
-				log.Err(err))
-			hashMissing.WithLabelValues(string(r.Hint)).Add(1)
-			continue
-		} else if res == nil {
-			h.logger.With().Debug("serve: remote peer requested golden",
-				log.Context(ctx),
-				log.String("hash", r.Hash.ShortString()),
-				log.Int("dataSize", len(res)))
-			hashEmptyData.WithLabelValues(string(r.Hint)).Add(1)
-			continue
-		} else {
-			h.logger.With().Debug("serve: responded to hash request",
-				log.Context(ctx),
-				log.String("hash", r.Hash.ShortString()),
-				log.Int("dataSize", len(res)))
+		if res, ok := h.lookupHash(ctx, r, hinted); ok {
+			responses = append(responses, res)
 		}
-		// add response to batch
-		m := ResponseMessage{
-			Hash: r.Hash,
-			Data: res,
-		}
-		resBatch.Responses = append(resBatch.Responses, m)
 	}
 
-	bts, err := codec.Encode(&resBatch)
+	var (
+		bts []byte
+		err error
+	)
+	if hinted {
+		bts, err = codec.Encode(&HintedResponseBatch{ID: requestBatch.ID, Responses: responses})
+	} else {
+		resBatch := ResponseBatch{
+			ID:        requestBatch.ID,
+			Responses: make([]ResponseMessage, 0, len(responses)),
+		}
+		for _, r := range responses {
+			resBatch.Responses = append(resBatch.Responses, ResponseMessage{Hash: r.Hash, Data: r.Data})
+		}
+		bts, err = codec.Encode(&resBatch)
+	}
 	if err != nil {
 		h.logger.With().Fatal("serve: failed to encode batch id",
 			log.Context(ctx),
 			log.Err(err),
-			log.String("batch_hash", resBatch.ID.ShortString()))
+			log.String("batch_hash", requestBatch.ID.ShortString()))
 	}
 	h.logger.With().Debug("serve: returning response for batch",
 		log.Context(ctx),
-		log.String("batch_hash", resBatch.ID.ShortString()),
-		log.Int("count_responses", len(resBatch.Responses)),
+		log.String("batch_hash", requestBatch.ID.ShortString()),
+		log.Int("count_responses", len(responses)),
 		log.Int("data_size", len(bts)))
 	return bts, nil
 }
 
+// handleStreamedHashReq serves hashProtocolV3. Responses are the same as on hashProtocolV2,
+// but they are written to the stream in chunks of at most streamChunkSize bytes of data,
+// so that batches of large blobs can be served.
+func (h *handler) handleStreamedHashReq(ctx context.Context, data []byte, w io.Writer) error {
+	var requestBatch RequestBatch
+	if err := codec.DecodeWithLimit(data, &requestBatch, MaxRequestSize); err != nil {
+		h.logger.With().Warning("serve: failed to parse request", log.Context(ctx), log.Err(err))
+		return errBadRequest
+	}
+	var (
+		chunk  ResponseChunk
+		size   int
+		total  int
+		chunks int
+	)
+	write := func() error {
+		if _, err := codec.EncodeTo(w, &chunk); err != nil {
+			return fmt.Errorf("write chunk: %w", err)
+		}
+		chunks++
+		chunk.Responses = chunk.Responses[:0]
+		size = 0
+		return nil
+	}
+	for _, r := range requestBatch.Requests {
+		res, ok := h.lookupHash(ctx, r, true)
+		if !ok {
+			continue
+		}
+		if len(chunk.Responses) > 0 && size+len(res.Data) > streamChunkSize {
+			if err := write(); err != nil {
+				return err
+			}
+		}
+		chunk.Responses = append(chunk.Responses, res)
+		size += len(res.Data)
+		total += len(res.Data)
+	}
+	chunk.Last = true
+	if err := write(); err != nil {
+		return err
+	}
+	h.logger.With().Debug("serve: streamed response for batch",
+		log.Context(ctx),
+		log.String("batch_hash", requestBatch.ID.ShortString()),
+		log.Int("chunks", chunks),
+		log.Int("data_size", total))
+	return nil
+}
+
+// lookupHash returns the response to the request, false if the requested data is not served.
+// If hinted is true the hashes requested with a wrong hint are served with the actual hint.
+func (h *handler) lookupHash(ctx context.Context, r RequestMessage, hinted bool) (HintedResponseMessage, bool) {
+	totalHashReqs.WithLabelValues(string(r.Hint)).Add(1)
+	hint := r.Hint
+	res, err := h.bs.Get(ctx, r.Hint, r.Hash.Bytes())
+	if err != nil {
+		h.logger.With().Debug("serve: remote peer requested nonexistent hash",
+			log.Context(ctx),
+			log.String("hash", r.Hash.ShortString()),
+			log.String("hint", string(r.Hint)),
+			log.Err(err))
+		hashMissing.WithLabelValues(string(r.Hint)).Add(1)
+		var ok bool
+		hint, ok = h.inferHint(r)
+		if !ok {
+			return HintedResponseMessage{}, false
+		}
+		h.logger.With().Debug("serve: remote peer requested hash with wrong hint",
+			log.Context(ctx),
+			log.String("hash", r.Hash.ShortString()),
+			log.String("hint", string(r.Hint)),
+			log.String("actual", string(hint)))
+		hashHintInferred.WithLabelValues(string(r.Hint), string(hint)).Add(1)
+		if !hinted {
+			return HintedResponseMessage{}, false
+		}
+		res, err = h.bs.Get(ctx, hint, r.Hash.Bytes())
+		if err != nil {
+			return HintedResponseMessage{}, false
+		}
+	}
+	if res == nil {
+		h.logger.With().Debug("serve: remote peer requested golden",
+			log.Context(ctx),
+			log.String("hash", r.Hash.ShortString()),
+			log.Int("dataSize", len(res)))
+		hashEmptyData.WithLabelValues(string(hint)).Add(1)
+		return HintedResponseMessage{}, false
+	}
+	h.logger.With().Debug("serve: responded to hash request",
+		log.Context(ctx),
+		log.String("hash", r.Hash.ShortString()),
+		log.Int("dataSize", len(res)))
+	return HintedResponseMessage{
+		Hash: r.Hash,
+		Hint: hint,
+		Data: res,
+	}, true
+}
+
+// inferHint returns the hint of the database in which the hash requested with a wrong hint is found.
+func (h *handler) inferHint(r RequestMessage) (datastore.Hint, bool) {
+	for _, hint := range inferableHints {
+		if hint == r.Hint {
+			continue
+		}
+		if has, err := h.bs.Has(hint, r.Hash.Bytes()); err == nil && has {
+			return hint, true
+		}
+	}
+	return "", false
+}
+
+// handleHashProbeReq returns the requested hashes that are stored locally. Hashes that are missing or
+// can't be checked are omitted from the response.
+func (h *handler) handleHashProbeReq(ctx context.Context, data []byte) ([]byte, error) {
+	var req HashProbeRequest
+	if err := codec.DecodeWithLimit(data, &req, MaxRequestSize); err != nil {
+		h.logger.With().Warning("serve: failed to parse hash probe request", log.Context(ctx), log.Err(err))
+		return nil, errBadRequest
+	}
+	hashProbeReq.Inc()
+	var rst HashProbeResponse
+	for _, r := range req.Requests {
+		has, err := h.bs.Has(r.Hint, r.Hash.Bytes())
+		if err != nil {
+			h.logger.With().Debug("serve: failed to check hash",
+				log.Context(ctx),
+				log.String("hash", r.Hash.ShortString()),
+				log.String("hint", string(r.Hint)),
+				log.Err(err))
+			continue
+		}
+		if has {
+			rst.Hashes = append(rst.Hashes, r.Hash)
+		}
+	}
+	return codec.MustEncode(&rst), nil
+}
+
 func (h *handler) handleMeshHashReq(ctx context.Context, reqData []byte) ([]byte, error) {
 	var (
 		req    MeshHashRequest
@@ -241,7 +468,7 @@ func (h *handler) handleMeshHashReq(ctx context.Context, reqData []byte) ([]byte
 		data   []byte
 		err    error
 	)
-	if err = codec.Decode(reqData, &req); err != nil {
+	if err = codec.DecodeWithLimit(reqData, &req, MaxRequestSize); err != nil {
 		h.logger.With().Warning("serve: failed to parse mesh hash request",
 			log.Context(ctx), log.Err(err))
 		return nil, errBadRequest
@@ -271,3 +498,144 @@ func (h *handler) handleMeshHashReq(ctx context.Context, reqData []byte) ([]byte
 	)
 	return data, nil
 }
+
+// handleCertificatesReq returns certificates for the range of layers.
+func (h *handler) handleCertificatesReq(ctx context.Context, reqData []byte) ([]byte, error) {
+	var req CertificatesRequest
+	if err := codec.DecodeWithLimit(reqData, &req, MaxRequestSize); err != nil {
+		h.logger.With().Warning("serve: failed to parse certificates request",
+			log.Context(ctx), log.Err(err))
+		return nil, errBadRequest
+	}
+	if err := req.Validate(); err != nil {
+		h.logger.With().Debug("failed to validate certificates request",
+			log.Context(ctx), log.Err(err))
+		return nil, err
+	}
+	certRangeReq.Inc()
+	certs, err := certificates.CertifiedInRange(h.cdb, req.From, req.To)
+	if err != nil {
+		h.logger.With().Warning("serve: failed to get certificates",
+			log.Context(ctx), log.Err(err))
+		return nil, err
+	}
+	var rst LayerCertificates
+	for _, cert := range certs {
+		rst.Certificates = append(rst.Certificates, LayerCertificate{Layer: cert.Layer, Certificate: *cert.Cert})
+	}
+	data, err := codec.Encode(&rst)
+	if err != nil {
+		h.logger.With().Fatal("serve: failed to encode certificates",
+			log.Context(ctx), log.Err(err))
+	}
+	h.logger.With().Debug("serve: returning response for certificates",
+		log.Context(ctx),
+		log.Object("req", &req),
+		log.Int("count_certs", len(rst.Certificates)),
+	)
+	return data, nil
+}
+
+// handleLightLayersReq returns aggregated hashes and certificates for the range of layers.
+func (h *handler) handleLightLayersReq(ctx context.Context, reqData []byte) ([]byte, error) {
+	var req LightLayersRequest
+	if err := codec.DecodeWithLimit(reqData, &req, MaxRequestSize); err != nil {
+		h.logger.With().Warning("serve: failed to parse light layers request",
+			log.Context(ctx), log.Err(err))
+		return nil, errBadRequest
+	}
+	if err := req.Validate(); err != nil {
+		h.logger.With().Debug("failed to validate light layers request",
+			log.Context(ctx), log.Err(err))
+		return nil, err
+	}
+	lightLayersReq.Inc()
+	certs, err := certificates.CertifiedInRange(h.cdb, req.From, req.To)
+	if err != nil {
+		h.logger.With().Warning("serve: failed to get certificates",
+			log.Context(ctx), log.Err(err))
+		return nil, err
+	}
+	certified := make(map[types.LayerID]*types.Certificate, len(certs))
+	for _, cert := range certs {
+		certified[cert.Layer] = cert.Cert
+	}
+	var rst LightLayers
+	for lid := req.From; !req.To.Before(lid); lid = lid.Add(1) {
+		hash, err := layers.GetAggregatedHash(h.cdb, lid)
+		if errors.Is(err, sql.ErrNotFound) {
+			break
+		} else if err != nil {
+			h.logger.With().Warning("serve: failed to get aggregated hash",
+				log.Context(ctx), lid, log.Err(err))
+			return nil, err
+		}
+		rst.Layers = append(rst.Layers, LightLayer{Layer: lid, AggHash: hash, Certificate: certified[lid]})
+	}
+	data, err := codec.Encode(&rst)
+	if err != nil {
+		h.logger.With().Fatal("serve: failed to encode light layers",
+			log.Context(ctx), log.Err(err))
+	}
+	h.logger.With().Debug("serve: returning response for light layers",
+		log.Context(ctx),
+		log.Object("req", &req),
+		log.Int("count_layers", len(rst.Layers)),
+	)
+	return data, nil
+}
+
+// handleAtxHeadersReq returns headers of the atxs published in the epoch, without the atx bodies.
+func (h *handler) handleAtxHeadersReq(ctx context.Context, reqData []byte) ([]byte, error) {
+	var req AtxHeadersRequest
+	if err := codec.DecodeWithLimit(reqData, &req, MaxRequestSize); err != nil {
+		h.logger.With().Warning("serve: failed to parse atx headers request",
+			log.Context(ctx), log.Err(err))
+		return nil, errBadRequest
+	}
+	atxHeadersReq.Inc()
+	var headers []AtxHeader
+	if err := atxs.IterateAtxsData(h.cdb, req.Epoch, req.Epoch,
+		func(
+			id types.ATXID,
+			node types.NodeID,
+			_ types.EpochID,
+			coinbase types.Address,
+			weight, base, height uint64,
+		) bool {
+			headers = append(headers, AtxHeader{
+				ID:         id,
+				SmesherID:  node,
+				Coinbase:   coinbase,
+				Weight:     weight,
+				BaseHeight: base,
+				Height:     height,
+			})
+			return true
+		},
+	); err != nil {
+		h.logger.With().Warning("serve: failed to get atx headers",
+			log.Context(ctx), req.Epoch, log.Err(err))
+		return nil, err
+	}
+	slices.SortFunc(headers, func(a, b AtxHeader) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	rst := AtxHeaders{Total: uint32(len(headers))}
+	if int(req.Offset) < len(headers) {
+		headers = headers[req.Offset:]
+		rst.Headers = headers[:min(len(headers), MaxAtxHeadersInResp)]
+	}
+	data, err := codec.Encode(&rst)
+	if err != nil {
+		h.logger.With().Fatal("serve: failed to encode atx headers",
+			log.Context(ctx), log.Err(err))
+	}
+	h.logger.With().Debug("serve: returning response for atx headers",
+		log.Context(ctx),
+		req.Epoch,
+		log.Uint32("offset", req.Offset),
+		log.Int("count_headers", len(rst.Headers)),
+	)
+	return data, nil
+}
@@ -1,7 +1,10 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/activesets"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/ballots"
 	"github.com/spacemeshos/go-spacemesh/sql/blocks"
@@ -193,6 +197,212 @@ func TestHandleCertReq(t *testing.T) {
 	require.Equal(t, *cert, got)
 }
 
+func TestHandleCertificatesReq(t *testing.T) {
+	th := createTestHandler(t)
+	certs := map[types.LayerID]*types.Certificate{
+		11: {BlockID: types.RandomBlockID()},
+		13: {BlockID: types.RandomBlockID()},
+	}
+	for lid, cert := range certs {
+		require.NoError(t, certificates.Add(th.cdb, lid, cert))
+	}
+	require.NoError(t, certificates.SetHareOutput(th.cdb, 12, types.RandomBlockID()))
+
+	resp, err := th.handleCertificatesReq(context.Background(),
+		codec.MustEncode(&CertificatesRequest{From: 10, To: 20}))
+	require.NoError(t, err)
+	var got LayerCertificates
+	require.NoError(t, codec.Decode(resp, &got))
+	require.Equal(t, []LayerCertificate{
+		{Layer: 11, Certificate: *certs[11]},
+		{Layer: 13, Certificate: *certs[13]},
+	}, got.Certificates)
+
+	_, err = th.handleCertificatesReq(context.Background(),
+		codec.MustEncode(&CertificatesRequest{From: 20, To: 10}))
+	require.ErrorIs(t, err, errBadRequest)
+	_, err = th.handleCertificatesReq(context.Background(),
+		codec.MustEncode(&CertificatesRequest{From: 0, To: MaxCertificatesInReq}))
+	require.ErrorIs(t, err, errBadRequest)
+}
+
+func TestHandleLightLayersReq(t *testing.T) {
+	th := createTestHandler(t)
+	hashes := map[types.LayerID]types.Hash32{}
+	for lid := types.LayerID(10); lid <= 13; lid++ {
+		hashes[lid] = types.RandomHash()
+		require.NoError(t, layers.SetMeshHash(th.cdb, lid, hashes[lid]))
+	}
+	cert := &types.Certificate{BlockID: types.RandomBlockID()}
+	require.NoError(t, certificates.Add(th.cdb, 11, cert))
+
+	resp, err := th.handleLightLayersReq(context.Background(),
+		codec.MustEncode(&LightLayersRequest{From: 10, To: 20}))
+	require.NoError(t, err)
+	var got LightLayers
+	require.NoError(t, codec.Decode(resp, &got))
+	require.Equal(t, []LightLayer{
+		{Layer: 10, AggHash: hashes[10]},
+		{Layer: 11, AggHash: hashes[11], Certificate: cert},
+		{Layer: 12, AggHash: hashes[12]},
+		{Layer: 13, AggHash: hashes[13]},
+	}, got.Layers)
+
+	_, err = th.handleLightLayersReq(context.Background(),
+		codec.MustEncode(&LightLayersRequest{From: 20, To: 10}))
+	require.ErrorIs(t, err, errBadRequest)
+	_, err = th.handleLightLayersReq(context.Background(),
+		codec.MustEncode(&LightLayersRequest{From: 0, To: MaxLightLayersInReq}))
+	require.ErrorIs(t, err, errBadRequest)
+}
+
+func TestHandleAtxHeadersReq(t *testing.T) {
+	th := createTestHandler(t)
+	epoch := types.EpochID(11)
+	var ids []types.ATXID
+	for i := 0; i < 10; i++ {
+		vatx := newAtx(t, epoch)
+		require.NoError(t, atxs.Add(th.cdb, vatx))
+		ids = append(ids, vatx.ID())
+	}
+	require.NoError(t, atxs.Add(th.cdb, newAtx(t, epoch+1)))
+	slices.SortFunc(ids, func(a, b types.ATXID) int { return bytes.Compare(a[:], b[:]) })
+
+	resp, err := th.handleAtxHeadersReq(context.Background(),
+		codec.MustEncode(&AtxHeadersRequest{Epoch: epoch, Offset: 4}))
+	require.NoError(t, err)
+	var got AtxHeaders
+	require.NoError(t, codec.Decode(resp, &got))
+	require.EqualValues(t, 10, got.Total)
+	require.Len(t, got.Headers, 6)
+	for i, header := range got.Headers {
+		require.Equal(t, ids[4+i], header.ID)
+		vatx, err := atxs.Get(th.cdb, header.ID)
+		require.NoError(t, err)
+		require.Equal(t, vatx.SmesherID, header.SmesherID)
+		require.Equal(t, vatx.GetWeight(), header.Weight)
+		require.Equal(t, vatx.TickHeight(), header.Height)
+	}
+
+	resp, err = th.handleAtxHeadersReq(context.Background(),
+		codec.MustEncode(&AtxHeadersRequest{Epoch: epoch, Offset: 10}))
+	require.NoError(t, err)
+	require.NoError(t, codec.Decode(resp, &got))
+	require.EqualValues(t, 10, got.Total)
+	require.Empty(t, got.Headers)
+}
+
+func TestHandleHashProbeReq(t *testing.T) {
+	th := createTestHandler(t)
+	vatx := newAtx(t, 11)
+	require.NoError(t, atxs.Add(th.cdb, vatx))
+	missing := types.RandomHash()
+
+	resp, err := th.handleHashProbeReq(context.Background(), codec.MustEncode(&HashProbeRequest{
+		Requests: []RequestMessage{
+			{Hint: datastore.ATXDB, Hash: vatx.ID().Hash32()},
+			{Hint: datastore.ATXDB, Hash: missing},
+			{Hint: datastore.BallotDB, Hash: vatx.ID().Hash32()},
+		},
+	}))
+	require.NoError(t, err)
+	var got HashProbeResponse
+	require.NoError(t, codec.Decode(resp, &got))
+	require.Equal(t, []types.Hash32{vatx.ID().Hash32()}, got.Hashes)
+
+	_, err = th.handleHashProbeReq(context.Background(), []byte("garbage"))
+	require.ErrorIs(t, err, errBadRequest)
+}
+
+func TestHandleHashReq_Hints(t *testing.T) {
+	th := createTestHandler(t)
+	blts, blks := createLayer(t, th.cdb, types.LayerID(11))
+	ballot := blts[0].AsHash32()
+	block := blks[0].AsHash32()
+	req := codec.MustEncode(&RequestBatch{
+		ID: types.RandomHash(),
+		Requests: []RequestMessage{
+			{Hint: datastore.BlockDB, Hash: ballot},
+			{Hint: datastore.BlockDB, Hash: block},
+			{Hint: datastore.BlockDB, Hash: types.RandomHash()},
+		},
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		out, err := th.handleHashReq(context.Background(), req)
+		require.NoError(t, err)
+		var resp ResponseBatch
+		require.NoError(t, codec.Decode(out, &resp))
+		require.Len(t, resp.Responses, 1)
+		require.Equal(t, block, resp.Responses[0].Hash)
+	})
+	t.Run("hinted", func(t *testing.T) {
+		out, err := th.handleHintedHashReq(context.Background(), req)
+		require.NoError(t, err)
+		var resp HintedResponseBatch
+		require.NoError(t, codec.Decode(out, &resp))
+		require.Len(t, resp.Responses, 2)
+		require.Equal(t, ballot, resp.Responses[0].Hash)
+		require.Equal(t, datastore.BallotDB, resp.Responses[0].Hint)
+		blt, err := ballots.Get(th.cdb, blts[0])
+		require.NoError(t, err)
+		require.Equal(t, codec.MustEncode(blt), resp.Responses[0].Data)
+		require.Equal(t, block, resp.Responses[1].Hash)
+		require.Equal(t, datastore.BlockDB, resp.Responses[1].Hint)
+	})
+}
+
+func TestHandleStreamedHashReq(t *testing.T) {
+	th := createTestHandler(t)
+	// every active set is larger than half of the chunk
+	sets := make([]types.Hash32, 3)
+	for i := range sets {
+		set := &types.EpochActiveSet{Epoch: 2, Set: make([]types.ATXID, streamChunkSize/types.ATXIDSize*3/4)}
+		for j := range set.Set {
+			set.Set[j] = types.RandomATXID()
+		}
+		sets[i] = types.RandomHash()
+		require.NoError(t, activesets.Add(th.cdb, sets[i], set))
+	}
+	blts, _ := createLayer(t, th.cdb, types.LayerID(11))
+	ballot := blts[0].AsHash32()
+
+	requests := []RequestMessage{{Hint: datastore.BlockDB, Hash: ballot}}
+	for _, set := range sets {
+		requests = append(requests, RequestMessage{Hint: datastore.ActiveSet, Hash: set})
+	}
+	requests = append(requests, RequestMessage{Hint: datastore.ActiveSet, Hash: types.RandomHash()})
+	req := codec.MustEncode(&RequestBatch{ID: types.RandomHash(), Requests: requests})
+
+	var out bytes.Buffer
+	require.NoError(t, th.handleStreamedHashReq(context.Background(), req, &out))
+	var (
+		chunks    []ResponseChunk
+		responses []HintedResponseMessage
+	)
+	for {
+		var chunk ResponseChunk
+		_, err := codec.DecodeFrom(&out, &chunk)
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+		responses = append(responses, chunk.Responses...)
+		if chunk.Last {
+			break
+		}
+	}
+	require.Zero(t, out.Len())
+	require.Len(t, chunks, 3)
+	require.Len(t, responses, 4)
+	require.Equal(t, ballot, responses[0].Hash)
+	require.Equal(t, datastore.BallotDB, responses[0].Hint)
+	for i, set := range sets {
+		require.Equal(t, set, responses[i+1].Hash)
+		require.Equal(t, datastore.ActiveSet, responses[i+1].Hint)
+	}
+
+	require.ErrorIs(t, th.handleStreamedHashReq(context.Background(), []byte("bad"), &out), errBadRequest)
+}
+
 func TestHandleMeshHashReq(t *testing.T) {
 	tt := []struct {
 		name        string
@@ -323,6 +533,28 @@ func TestHandleEpochInfoReq(t *testing.T) {
 	}
 }
 
+func TestEncodeEpochData(t *testing.T) {
+	// the sizes cover all forms of the compact length prefix
+	for _, n := range []int{0, 1, 63, 64, 16383, 16384} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			db := sql.InMemory()
+			epoch := types.EpochID(3)
+			for i := 0; i < n; i++ {
+				require.NoError(t, atxs.Add(db, newAtx(t, epoch)))
+			}
+			ids, err := atxs.GetIDsByEpoch(context.Background(), db, epoch)
+			require.NoError(t, err)
+			encoded, err := codec.Encode(&EpochData{AtxIDs: ids})
+			require.NoError(t, err)
+
+			data, count, err := encodeEpochData(db, epoch)
+			require.NoError(t, err)
+			require.Equal(t, n, count)
+			require.Equal(t, encoded, data)
+		})
+	}
+}
+
 func TestHandleEpochInfoReqWithQueryCache(t *testing.T) {
 	th := createTestHandler(t, sql.WithQueryCache(true))
 	epoch := types.EpochID(11)
@@ -335,7 +567,7 @@ func TestHandleEpochInfoReqWithQueryCache(t *testing.T) {
 	}
 
 	qc := th.cdb.Executor.(interface{ QueryCount() int })
-	require.Equal(t, 10, qc.QueryCount())
+	require.Equal(t, 20, qc.QueryCount())
 	epochBytes, err := codec.Encode(epoch)
 	require.NoError(t, err)
 
@@ -345,14 +577,14 @@ func TestHandleEpochInfoReqWithQueryCache(t *testing.T) {
 		require.NoError(t, err)
 		require.NoError(t, codec.Decode(out, &got))
 		require.ElementsMatch(t, expected.AtxIDs, got.AtxIDs)
-		require.Equal(t, 11, qc.QueryCount())
+		require.Equal(t, 21, qc.QueryCount())
 	}
 
 	// Add another ATX which should be appended to the cached slice
 	vatx := newAtx(t, epoch)
 	require.NoError(t, atxs.Add(th.cdb, vatx))
 	expected.AtxIDs = append(expected.AtxIDs, vatx.ID())
-	require.Equal(t, 12, qc.QueryCount())
+	require.Equal(t, 23, qc.QueryCount())
 
 	out, err := th.handleEpochInfoReq(context.Background(), epochBytes)
 	require.NoError(t, err)
@@ -361,7 +593,49 @@ func TestHandleEpochInfoReqWithQueryCache(t *testing.T) {
 	// The query count is not incremented as the slice is still
 	// cached and the new atx is just appended to it, even though
 	// the response is re-serialized.
-	require.Equal(t, 12, qc.QueryCount())
+	require.Equal(t, 23, qc.QueryCount())
+}
+
+func TestHandleEpochDeltaReq(t *testing.T) {
+	th := createTestHandler(t, sql.WithQueryCache(true))
+	epoch := types.EpochID(11)
+	request := func(base types.Hash32) *EpochDelta {
+		t.Helper()
+		out, err := th.handleEpochDeltaReq(context.Background(), codec.MustEncode(&EpochDeltaRequest{
+			Epoch: epoch,
+			Base:  base,
+		}))
+		require.NoError(t, err)
+		var delta EpochDelta
+		require.NoError(t, codec.Decode(out, &delta))
+		return &delta
+	}
+
+	var ids []types.ATXID
+	for i := 0; i < 10; i++ {
+		vatx := newAtx(t, epoch)
+		require.NoError(t, atxs.Add(th.cdb, vatx))
+		ids = append(ids, vatx.ID())
+	}
+	first := request(types.Hash32{})
+	require.True(t, first.Full)
+	require.ElementsMatch(t, ids, first.Added)
+	require.Equal(t, atxSetHash(sortedATXIDs(ids)), first.Hash)
+
+	require.Equal(t, &EpochDelta{Hash: first.Hash}, request(first.Hash))
+
+	vatx := newAtx(t, epoch)
+	require.NoError(t, atxs.Add(th.cdb, vatx))
+	ids = append(ids, vatx.ID())
+	second := request(first.Hash)
+	require.False(t, second.Full)
+	require.Equal(t, []types.ATXID{vatx.ID()}, second.Added)
+	require.Empty(t, second.Removed)
+	require.Equal(t, atxSetHash(sortedATXIDs(ids)), second.Hash)
+
+	unknown := request(types.RandomHash())
+	require.True(t, unknown.Full)
+	require.ElementsMatch(t, ids, unknown.Added)
 }
 
 func TestHandleMaliciousIDsReq(t *testing.T) {
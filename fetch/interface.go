@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"context"
+	"io"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/p2p"
@@ -13,6 +14,7 @@ import (
 type requester interface {
 	Run(context.Context) error
 	Request(context.Context, p2p.Peer, []byte) ([]byte, error)
+	StreamRequest(context.Context, p2p.Peer, []byte, func(io.Reader) error) error
 }
 
 // The ValidatorFunc type is an adapter to allow the use of functions as
@@ -245,6 +245,9 @@ func (f *Fetch) PeerEpochInfo(ctx context.Context, peer p2p.Peer, epoch types.Ep
 	f.logger.WithContext(ctx).With().Debug("requesting epoch info from peer",
 		log.Stringer("peer", peer),
 		log.Stringer("epoch", epoch))
+	if f.supports(peer, atxDeltaProtocol) {
+		return f.peerEpochDelta(ctx, peer, epoch)
+	}
 	epochBytes := codec.MustEncode(epoch)
 	data, err := f.meteredRequest(ctx, atxProtocol, peer, epochBytes)
 	if err != nil {
@@ -254,10 +257,43 @@ func (f *Fetch) PeerEpochInfo(ctx context.Context, peer p2p.Peer, epoch types.Ep
 	if err := codec.Decode(data, &ed); err != nil {
 		return nil, fmt.Errorf("decoding epoch data: %w", err)
 	}
+	ids := sortedATXIDs(ed.AtxIDs)
+	f.knownATXSets.set(epoch, knownATXSet{hash: atxSetHash(ids), ids: ids})
 	f.RegisterPeerHashes(peer, types.ATXIDsToHashes(ed.AtxIDs))
 	return &ed, nil
 }
 
+// peerEpochDelta requests the atx ids of the epoch from the peer as a delta against the latest set
+// received for the epoch. The set built from the delta must match the hash of the set of the peer.
+func (f *Fetch) peerEpochDelta(ctx context.Context, peer p2p.Peer, epoch types.EpochID) (*EpochData, error) {
+	known := f.knownATXSets.get(epoch)
+	req := codec.MustEncode(&EpochDeltaRequest{Epoch: epoch, Base: known.hash})
+	data, err := f.meteredRequest(ctx, atxDeltaProtocol, peer, req)
+	if err != nil {
+		return nil, err
+	}
+	var delta EpochDelta
+	if err := codec.Decode(data, &delta); err != nil {
+		return nil, fmt.Errorf("decoding epoch delta: %w", err)
+	}
+	var ids []types.ATXID
+	switch {
+	case delta.Full:
+		ids = sortedATXIDs(delta.Added)
+	case delta.Hash == known.hash:
+		ids = known.ids
+	default:
+		ids = applyATXIDs(known.ids, delta.Added, delta.Removed)
+	}
+	if got := atxSetHash(ids); got != delta.Hash {
+		return nil, fmt.Errorf("epoch %v delta from peer %s: hash mismatch %s != %s",
+			epoch, peer, got.ShortString(), delta.Hash.ShortString())
+	}
+	f.knownATXSets.set(epoch, knownATXSet{hash: delta.Hash, ids: ids})
+	f.RegisterPeerHashes(peer, types.ATXIDsToHashes(ids))
+	return &EpochData{AtxIDs: ids}, nil
+}
+
 func (f *Fetch) PeerMeshHashes(ctx context.Context, peer p2p.Peer, req *MeshHashRequest) (*MeshHashes, error) {
 	f.logger.WithContext(ctx).With().Debug("requesting mesh hashes from peer",
 		log.Stringer("peer", peer),
@@ -278,6 +314,123 @@ func (f *Fetch) PeerMeshHashes(ctx context.Context, peer p2p.Peer, req *MeshHash
 	}, nil
 }
 
+// PeerCertificates requests certificates for the layers [from, to] from the peer,
+// in batches of at most MaxCertificatesInReq layers. Layers for which the peer
+// doesn't have a certificate are skipped. Certificates are not validated.
+func (f *Fetch) PeerCertificates(
+	ctx context.Context,
+	peer p2p.Peer,
+	from, to types.LayerID,
+) ([]LayerCertificate, error) {
+	var rst []LayerCertificate
+	for start := from; start <= to; start = start.Add(MaxCertificatesInReq) {
+		req := &CertificatesRequest{
+			From: start,
+			To:   min(to, start.Add(MaxCertificatesInReq-1)),
+		}
+		f.logger.WithContext(ctx).With().Debug("requesting certificates from peer",
+			log.Stringer("peer", peer),
+			log.Object("req", req),
+		)
+		data, err := f.meteredRequest(ctx, certProtocol, peer, codec.MustEncode(req))
+		if err != nil {
+			return nil, err
+		}
+		var batch LayerCertificates
+		if err := codec.Decode(data, &batch); err != nil {
+			return nil, fmt.Errorf("decoding certificates response: %w", err)
+		}
+		for _, cert := range batch.Certificates {
+			if cert.Layer.Before(req.From) || req.To.Before(cert.Layer) {
+				return nil, fmt.Errorf("certificate for layer %s outside of requested range [%s, %s]",
+					cert.Layer, req.From, req.To)
+			}
+		}
+		rst = append(rst, batch.Certificates...)
+		if req.To == to {
+			break
+		}
+	}
+	return rst, nil
+}
+
+// ProbeHashes asks up to n of the best peers that serve hashProbeProtocol which of the hashes they have,
+// without fetching the data. It returns the number of peers that have each of the hashes and the number
+// of peers that responded. Peers that failed to respond are not counted.
+func (f *Fetch) ProbeHashes(
+	ctx context.Context,
+	hint datastore.Hint,
+	hashes []types.Hash32,
+	n int,
+) (map[types.Hash32]int, int, error) {
+	var peers []p2p.Peer
+	for _, peer := range f.SelectBestShuffled(2 * n) {
+		if len(peers) == n {
+			break
+		}
+		if f.supports(peer, hashProbeProtocol) {
+			peers = append(peers, peer)
+		}
+	}
+	if len(peers) == 0 {
+		return nil, 0, errors.New("no peers serve hash probes")
+	}
+	requested := make(map[types.Hash32]struct{}, len(hashes))
+	for _, hash := range hashes {
+		requested[hash] = struct{}{}
+	}
+	var reqs [][]byte
+	for start := 0; start < len(hashes); start += MaxHashesInProbe {
+		req := HashProbeRequest{}
+		for _, hash := range hashes[start:min(len(hashes), start+MaxHashesInProbe)] {
+			req.Requests = append(req.Requests, RequestMessage{Hint: hint, Hash: hash})
+		}
+		reqs = append(reqs, codec.MustEncode(&req))
+	}
+
+	var (
+		eg        errgroup.Group
+		mu        sync.Mutex
+		have      = make(map[types.Hash32]int, len(hashes))
+		responded int
+	)
+	for _, peer := range peers {
+		peer := peer
+		eg.Go(func() error {
+			// peer may respond with hashes that were not requested or with duplicates
+			got := map[types.Hash32]struct{}{}
+			for _, req := range reqs {
+				data, err := f.meteredRequest(ctx, hashProbeProtocol, peer, req)
+				if err != nil {
+					f.logger.WithContext(ctx).With().Debug("failed to probe hashes",
+						log.Stringer("peer", peer), log.Err(err))
+					return nil
+				}
+				var rst HashProbeResponse
+				if err := codec.Decode(data, &rst); err != nil {
+					f.logger.WithContext(ctx).With().Debug("failed to decode hash probe response",
+						log.Stringer("peer", peer), log.Err(err))
+					return nil
+				}
+				for _, hash := range rst.Hashes {
+					if _, exists := requested[hash]; exists {
+						got[hash] = struct{}{}
+					}
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			responded++
+			for hash := range got {
+				have[hash]++
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return have, responded, ctx.Err()
+}
+
 func (f *Fetch) GetCert(
 	ctx context.Context,
 	lid types.LayerID,
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/libp2p/go-libp2p/core/protocol"
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,6 +25,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
 
@@ -585,6 +587,55 @@ func Test_PeerEpochInfo(t *testing.T) {
 	}
 }
 
+func TestFetch_PeerEpochDelta(t *testing.T) {
+	peer := p2p.Peer("p0")
+	epoch := types.EpochID(11)
+	th := createTestHandler(t, sql.WithQueryCache(true))
+	f := createFetch(t)
+	f.mh.EXPECT().ID().Return("self").AnyTimes()
+	f.peerProtocols = func(p2p.Peer) ([]protocol.ID, error) {
+		return []protocol.ID{atxProtocol, atxDeltaProtocol}, nil
+	}
+	delta := mocks.NewMockrequester(gomock.NewController(t))
+	f.servers[atxDeltaProtocol] = delta
+	var responses []*EpochDelta
+	delta.EXPECT().Request(gomock.Any(), peer, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ p2p.Peer, req []byte) ([]byte, error) {
+			data, err := th.handleEpochDeltaReq(ctx, req)
+			require.NoError(t, err)
+			var rst EpochDelta
+			require.NoError(t, codec.Decode(data, &rst))
+			responses = append(responses, &rst)
+			return data, nil
+		}).AnyTimes()
+
+	var ids []types.ATXID
+	add := func(n int) {
+		for i := 0; i < n; i++ {
+			vatx := newAtx(t, epoch)
+			require.NoError(t, atxs.Add(th.cdb, vatx))
+			ids = append(ids, vatx.ID())
+		}
+	}
+	add(10)
+	for _, added := range []int{0, 3, 0} {
+		add(added)
+		ed, err := f.PeerEpochInfo(context.Background(), peer, epoch)
+		require.NoError(t, err)
+		require.ElementsMatch(t, ids, ed.AtxIDs)
+	}
+	require.Len(t, responses, 3)
+	require.True(t, responses[0].Full)
+	require.False(t, responses[1].Full)
+	require.Len(t, responses[1].Added, 3)
+	require.Equal(t, &EpochDelta{Hash: responses[1].Hash}, responses[2])
+
+	// the set built from the delta doesn't match the set of the peer
+	f.knownATXSets.set(epoch, knownATXSet{hash: responses[0].Hash, ids: ids[:5]})
+	_, err := f.PeerEpochInfo(context.Background(), peer, epoch)
+	require.ErrorContains(t, err, "hash mismatch")
+}
+
 func TestFetch_GetMeshHashes(t *testing.T) {
 	peer := p2p.Peer("p0")
 	errUnknown := errors.New("unknown")
@@ -713,6 +764,90 @@ func TestFetch_GetCert(t *testing.T) {
 	}
 }
 
+func TestFetch_PeerCertificates(t *testing.T) {
+	peer := p2p.Peer("p0")
+	f := createFetch(t)
+	from, to := types.LayerID(10), types.LayerID(10+MaxCertificatesInReq+20)
+
+	var expected []LayerCertificate
+	for lid := from; lid <= to; lid += 7 {
+		expected = append(expected, LayerCertificate{
+			Layer:       lid,
+			Certificate: types.Certificate{BlockID: types.RandomBlockID()},
+		})
+	}
+	serve := func(_ context.Context, _ p2p.Peer, data []byte) ([]byte, error) {
+		var req CertificatesRequest
+		require.NoError(t, codec.Decode(data, &req))
+		require.NoError(t, req.Validate())
+		var rst LayerCertificates
+		for _, cert := range expected {
+			if !cert.Layer.Before(req.From) && !req.To.Before(cert.Layer) {
+				rst.Certificates = append(rst.Certificates, cert)
+			}
+		}
+		return codec.MustEncode(&rst), nil
+	}
+	f.mCertS.EXPECT().Request(gomock.Any(), peer, gomock.Any()).DoAndReturn(serve).Times(2)
+	got, err := f.PeerCertificates(context.Background(), peer, from, to)
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+
+	f.mCertS.EXPECT().Request(gomock.Any(), peer, gomock.Any()).Return(
+		codec.MustEncode(&LayerCertificates{Certificates: []LayerCertificate{{Layer: to}}}), nil)
+	_, err = f.PeerCertificates(context.Background(), peer, from, from)
+	require.ErrorContains(t, err, "outside of requested range")
+}
+
+func TestFetch_ProbeHashes(t *testing.T) {
+	f := createFetch(t)
+	probe := mocks.NewMockrequester(gomock.NewController(t))
+	f.servers[hashProbeProtocol] = probe
+	legacy := p2p.Peer("legacy")
+	peers := []p2p.Peer{"p0", "p1", "p2", legacy}
+	for _, peer := range peers {
+		f.peers.Add(peer)
+	}
+	f.peerProtocols = func(peer p2p.Peer) ([]protocol.ID, error) {
+		if peer == legacy {
+			return []protocol.ID{hashProtocol}, nil
+		}
+		return []protocol.ID{hashProtocol, hashProbeProtocol}, nil
+	}
+
+	hashes := make([]types.Hash32, MaxHashesInProbe+1)
+	for i := range hashes {
+		hashes[i] = types.RandomHash()
+	}
+	served := map[p2p.Peer][]types.Hash32{
+		"p0": {hashes[0], hashes[MaxHashesInProbe]},
+		// duplicates and hashes that were not requested are not counted
+		"p1": {hashes[0], hashes[0], types.RandomHash()},
+	}
+	probe.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, peer p2p.Peer, data []byte) ([]byte, error) {
+			require.NotEqual(t, legacy, peer)
+			if peer == "p2" {
+				return nil, errors.New("unavailable")
+			}
+			var req HashProbeRequest
+			require.NoError(t, codec.Decode(data, &req))
+			require.NotEmpty(t, req.Requests)
+			return codec.MustEncode(&HashProbeResponse{Hashes: served[peer]}), nil
+		}).Times(5)
+
+	have, responded, err := f.ProbeHashes(context.Background(), datastore.ATXDB, hashes, len(peers))
+	require.NoError(t, err)
+	require.Equal(t, 2, responded)
+	require.Equal(t, map[types.Hash32]int{hashes[0]: 2, hashes[MaxHashesInProbe]: 1}, have)
+
+	f.peerProtocols = func(p2p.Peer) ([]protocol.ID, error) {
+		return []protocol.ID{hashProtocol}, nil
+	}
+	_, _, err = f.ProbeHashes(context.Background(), datastore.ATXDB, hashes, len(peers))
+	require.Error(t, err)
+}
+
 // Test if GetAtxs() limits the number of concurrent requests to `cfg.GetAtxsConcurrency`.
 func Test_GetAtxsLimiting(t *testing.T) {
 	mesh, err := mocknet.FullMeshConnected(2)
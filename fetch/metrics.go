@@ -9,6 +9,7 @@ const (
 	// subsystem shared by all metrics exposed by this package.
 	subsystem = "fetch"
 	hint      = "hint"
+	actual    = "actual"
 )
 
 var (
@@ -48,18 +49,60 @@ var (
 		"total request that hash has no data",
 		[]string{hint})
 
+	hashHintInferred = metrics.NewCounter(
+		"hash_hint_inferred",
+		subsystem,
+		"total requests that hash is not present under the requested hint but found under the actual hint",
+		[]string{hint, actual})
+
+	hashHintMismatch = metrics.NewCounter(
+		"hash_hint_mismatch",
+		subsystem,
+		"total responses from peers with the hash found under a different hint than requested",
+		[]string{hint, actual})
+
 	peerErrors = metrics.NewCounter(
 		"hash_peer_err",
 		subsystem,
 		"total error from sending peers hash requests",
 		[]string{hint})
 
+	splitBatches = metrics.NewCounter(
+		"split_batches",
+		subsystem,
+		"total failed hash batches that were split to isolate failing hashes",
+		[]string{}).WithLabelValues()
+
 	certReq = metrics.NewCounter(
 		"certs",
 		subsystem,
 		"total requests for block certificate received",
 		[]string{}).WithLabelValues()
 
+	certRangeReq = metrics.NewCounter(
+		"cert_ranges",
+		subsystem,
+		"total requests for range of block certificates received",
+		[]string{}).WithLabelValues()
+
+	lightLayersReq = metrics.NewCounter(
+		"light_layers",
+		subsystem,
+		"total requests for range of light layer headers received",
+		[]string{}).WithLabelValues()
+
+	atxHeadersReq = metrics.NewCounter(
+		"atx_headers",
+		subsystem,
+		"total requests for atx headers received",
+		[]string{}).WithLabelValues()
+
+	hashProbeReq = metrics.NewCounter(
+		"hash_probe",
+		subsystem,
+		"total requests for presence of hashes received",
+		[]string{}).WithLabelValues()
+
 	opnReqV2 = metrics.NewCounter(
 		"opn_reqs",
 		subsystem,
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	types "github.com/spacemeshos/go-spacemesh/common/types"
@@ -118,6 +119,44 @@ func (c *MockrequesterRunCall) DoAndReturn(f func(context.Context) error) *Mockr
 	return c
 }
 
+// StreamRequest mocks base method.
+func (m *Mockrequester) StreamRequest(arg0 context.Context, arg1 p2p.Peer, arg2 []byte, arg3 func(io.Reader) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamRequest", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamRequest indicates an expected call of StreamRequest.
+func (mr *MockrequesterMockRecorder) StreamRequest(arg0, arg1, arg2, arg3 any) *MockrequesterStreamRequestCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamRequest", reflect.TypeOf((*Mockrequester)(nil).StreamRequest), arg0, arg1, arg2, arg3)
+	return &MockrequesterStreamRequestCall{Call: call}
+}
+
+// MockrequesterStreamRequestCall wrap *gomock.Call
+type MockrequesterStreamRequestCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockrequesterStreamRequestCall) Return(arg0 error) *MockrequesterStreamRequestCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockrequesterStreamRequestCall) Do(f func(context.Context, p2p.Peer, []byte, func(io.Reader) error) error) *MockrequesterStreamRequestCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockrequesterStreamRequestCall) DoAndReturn(f func(context.Context, p2p.Peer, []byte, func(io.Reader) error) error) *MockrequesterStreamRequestCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockSyncValidator is a mock of SyncValidator interface.
 type MockSyncValidator struct {
 	ctrl     *gomock.Controller
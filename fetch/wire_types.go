@@ -13,6 +13,22 @@ import (
 
 const MaxHashesInReq = 100
 
+// MaxCertificatesInReq is the largest range of layers that can be requested with CertificatesRequest.
+const MaxCertificatesInReq = 100
+
+// MaxLightLayersInReq is the largest range of layers that can be requested with LightLayersRequest.
+const MaxLightLayersInReq = 100
+
+// MaxAtxHeadersInResp is the largest number of atx headers in a single AtxHeaders response.
+const MaxAtxHeadersInResp = 10_000
+
+// MaxHashesInProbe is the largest number of hashes in a single HashProbeRequest.
+const MaxHashesInProbe = 100
+
+// MaxRequestSize is the upper bound for the size of the encoded request of any fetch protocol.
+// The largest requests are batches of MaxHashesInBatch hashes with their hints (under 30 KiB).
+const MaxRequestSize = 64 << 10
+
 // RequestMessage is sent to the peer for hash query.
 type RequestMessage struct {
 	Hint datastore.Hint `scale:"max=256"` // TODO(mafa): covert to an enum
@@ -25,10 +41,22 @@ type ResponseMessage struct {
 	Data []byte `scale:"max=89128960"` // limit to 85 MiB - keep in line with Response.Data in `p2p/server/server.go`
 }
 
+// HintedResponseMessage is sent to the node as a response on the hashProtocolV2.
+// Hint is the database in which the hash was found. It differs from the requested hint
+// if the hash was requested with a wrong hint.
+type HintedResponseMessage struct {
+	Hash types.Hash32
+	Hint datastore.Hint `scale:"max=256"`
+	Data []byte         `scale:"max=89128960"` // keep in line with ResponseMessage.Data
+}
+
+// MaxHashesInBatch is the max number of hashes requested in a single batch.
+const MaxHashesInBatch = 100
+
 // RequestBatch is a batch of requests and a hash of all requests as ID.
 type RequestBatch struct {
 	ID types.Hash32
-	// depends on fetch config `BatchSize` which defaults to 10, more than 100 seems unlikely
+	// depends on fetch config `BatchSize` which defaults to 10, keep in line with MaxHashesInBatch
 	Requests []RequestMessage `scale:"max=100"`
 }
 
@@ -40,6 +68,20 @@ type ResponseBatch struct {
 	Responses []ResponseMessage `scale:"max=100"`
 }
 
+// HintedResponseBatch is the response for a RequestBatch on the hashProtocolV2.
+type HintedResponseBatch struct {
+	ID        types.Hash32
+	Responses []HintedResponseMessage `scale:"max=100"`
+}
+
+// ResponseChunk is a part of the response streamed on the hashProtocolV3. The responses to a RequestBatch
+// are written in chunks of limited size, so that large blobs don't exceed the limit of a single message.
+// The last chunk of the response is marked.
+type ResponseChunk struct {
+	Responses []HintedResponseMessage `scale:"max=100"`
+	Last      bool
+}
+
 // MeshHashRequest is used by ForkFinder to request the hashes of layers from
 // a peer to find the layer at which a divergence occurred in the local mesh of
 // the node.
@@ -100,6 +142,110 @@ type MeshHashes struct {
 	Hashes []types.Hash32 `scale:"max=1000"`
 }
 
+// CertificatesRequest is used to request certificates of the certified blocks
+// in the range of layers [From, To]. Nodes serve historical certificates only
+// if they keep the certificate archive.
+type CertificatesRequest struct {
+	From, To types.LayerID
+}
+
+func (r *CertificatesRequest) Validate() error {
+	if r.To.Before(r.From) {
+		return fmt.Errorf("%w: To before From", errBadRequest)
+	}
+	if r.To.Difference(r.From) >= MaxCertificatesInReq {
+		return fmt.Errorf("%w: number of layers requested exceeds maximum for one request", errBadRequest)
+	}
+	return nil
+}
+
+func (r *CertificatesRequest) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddUint32("from", r.From.Uint32())
+	encoder.AddUint32("to", r.To.Uint32())
+	return nil
+}
+
+// LayerCertificate is the certificate of the block certified in the layer.
+type LayerCertificate struct {
+	Layer       types.LayerID
+	Certificate types.Certificate
+}
+
+// LayerCertificates is the response for CertificatesRequest. Layers without a certificate are skipped.
+type LayerCertificates struct {
+	Certificates []LayerCertificate `scale:"max=100"` // keep in line with MaxCertificatesInReq
+}
+
+// LightLayersRequest is used by light clients to request the headers of the layers
+// in the range [From, To].
+type LightLayersRequest struct {
+	From, To types.LayerID
+}
+
+func (r *LightLayersRequest) Validate() error {
+	if r.To.Before(r.From) {
+		return fmt.Errorf("%w: To before From", errBadRequest)
+	}
+	if r.To.Difference(r.From) >= MaxLightLayersInReq {
+		return fmt.Errorf("%w: number of layers requested exceeds maximum for one request", errBadRequest)
+	}
+	return nil
+}
+
+func (r *LightLayersRequest) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddUint32("from", r.From.Uint32())
+	encoder.AddUint32("to", r.To.Uint32())
+	return nil
+}
+
+// LightLayer is the header of the layer served to light clients.
+type LightLayer struct {
+	Layer   types.LayerID
+	AggHash types.Hash32
+	// Certificate of the block certified in the layer, nil if the layer isn't certified.
+	Certificate *types.Certificate
+}
+
+// LightLayers is the response for LightLayersRequest. It ends with the last layer
+// that is processed by the serving node.
+type LightLayers struct {
+	Layers []LightLayer `scale:"max=100"` // keep in line with MaxLightLayersInReq
+}
+
+// AtxHeadersRequest is used by light clients to request the headers of the atxs published in the epoch.
+// Headers are ordered by atx id, the response starts with the header at Offset.
+type AtxHeadersRequest struct {
+	Epoch  types.EpochID
+	Offset uint32
+}
+
+// AtxHeader is the part of the atx that light clients need to follow the weight of the identities.
+type AtxHeader struct {
+	ID         types.ATXID
+	SmesherID  types.NodeID
+	Coinbase   types.Address
+	Weight     uint64
+	BaseHeight uint64
+	Height     uint64
+}
+
+// AtxHeaders is the response for AtxHeadersRequest.
+type AtxHeaders struct {
+	// Total is the number of atxs published in the epoch.
+	Total   uint32
+	Headers []AtxHeader `scale:"max=10000"` // keep in line with MaxAtxHeadersInResp
+}
+
+// HashProbeRequest asks the peer which of the hashes it has, without requesting the data.
+type HashProbeRequest struct {
+	Requests []RequestMessage `scale:"max=100"` // keep in line with MaxHashesInProbe
+}
+
+// HashProbeResponse lists the requested hashes that the peer has.
+type HashProbeResponse struct {
+	Hashes []types.Hash32 `scale:"max=100"` // keep in line with MaxHashesInProbe
+}
+
 type MaliciousIDs struct {
 	NodeIDs []types.NodeID `scale:"max=100000"` // max. expected number of ATXs per epoch is 100_000
 }
@@ -135,6 +281,23 @@ type LayerData struct {
 	Ballots []types.BallotID `scale:"max=800"`
 }
 
+// EpochDeltaRequest requests the ids of the atxs published in the epoch as changes against the set of ids
+// that the requester already has. Base is the hash of the sorted ids of that set, empty if there is none.
+type EpochDeltaRequest struct {
+	Epoch types.EpochID
+	Base  types.Hash32
+}
+
+// EpochDelta is the response on EpochDeltaRequest. If Full is true Added are all ids of the epoch,
+// otherwise Added and Removed are the changes against the base set of the request. Ids are sorted.
+// Hash is the hash of the set of ids with the changes applied.
+type EpochDelta struct {
+	Full    bool
+	Hash    types.Hash32
+	Added   []types.ATXID `scale:"max=2200000"` // same limit as EpochData.AtxIDs
+	Removed []types.ATXID `scale:"max=2200000"`
+}
+
 type OpinionRequest struct {
 	Layer types.LayerID
 	Block *types.BlockID
@@ -83,6 +83,58 @@ func (t *ResponseMessage) DecodeScale(dec *scale.Decoder) (total int, err error)
 	return total, nil
 }
 
+func (t *HintedResponseMessage) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeByteArray(enc, t.Hash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStringWithLimit(enc, string(t.Hint), 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteSliceWithLimit(enc, t.Data, 89128960)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *HintedResponseMessage) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := scale.DecodeByteArray(dec, t.Hash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeStringWithLimit(dec, 256)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Hint = datastore.Hint(field)
+	}
+	{
+		field, n, err := scale.DecodeByteSliceWithLimit(dec, 89128960)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Data = field
+	}
+	return total, nil
+}
+
 func (t *RequestBatch) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
 		n, err := scale.EncodeByteArray(enc, t.ID[:])
@@ -157,6 +209,81 @@ func (t *ResponseBatch) DecodeScale(dec *scale.Decoder) (total int, err error) {
 	return total, nil
 }
 
+func (t *HintedResponseBatch) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeByteArray(enc, t.ID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Responses, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *HintedResponseBatch) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := scale.DecodeByteArray(dec, t.ID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[HintedResponseMessage](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Responses = field
+	}
+	return total, nil
+}
+
+func (t *ResponseChunk) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Responses, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeBool(enc, t.Last)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *ResponseChunk) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[HintedResponseMessage](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Responses = field
+	}
+	{
+		field, n, err := scale.DecodeBool(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Last = field
+	}
+	return total, nil
+}
+
 func (t *MeshHashRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
 		n, err := scale.EncodeCompact32(enc, uint32(t.From))
@@ -233,9 +360,16 @@ func (t *MeshHashes) DecodeScale(dec *scale.Decoder) (total int, err error) {
 	return total, nil
 }
 
-func (t *MaliciousIDs) EncodeScale(enc *scale.Encoder) (total int, err error) {
+func (t *CertificatesRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
-		n, err := scale.EncodeStructSliceWithLimit(enc, t.NodeIDs, 100000)
+		n, err := scale.EncodeCompact32(enc, uint32(t.From))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.To))
 		if err != nil {
 			return total, err
 		}
@@ -244,21 +378,36 @@ func (t *MaliciousIDs) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	return total, nil
 }
 
-func (t *MaliciousIDs) DecodeScale(dec *scale.Decoder) (total int, err error) {
+func (t *CertificatesRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
 	{
-		field, n, err := scale.DecodeStructSliceWithLimit[types.NodeID](dec, 100000)
+		field, n, err := scale.DecodeCompact32(dec)
 		if err != nil {
 			return total, err
 		}
 		total += n
-		t.NodeIDs = field
+		t.From = types.LayerID(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.To = types.LayerID(field)
 	}
 	return total, nil
 }
 
-func (t *EpochData) EncodeScale(enc *scale.Encoder) (total int, err error) {
+func (t *LayerCertificate) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
-		n, err := scale.EncodeStructSliceWithLimit(enc, t.AtxIDs, 2200000)
+		n, err := scale.EncodeCompact32(enc, uint32(t.Layer))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := t.Certificate.EncodeScale(enc)
 		if err != nil {
 			return total, err
 		}
@@ -267,21 +416,28 @@ func (t *EpochData) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	return total, nil
 }
 
-func (t *EpochData) DecodeScale(dec *scale.Decoder) (total int, err error) {
+func (t *LayerCertificate) DecodeScale(dec *scale.Decoder) (total int, err error) {
 	{
-		field, n, err := scale.DecodeStructSliceWithLimit[types.ATXID](dec, 2200000)
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Layer = types.LayerID(field)
+	}
+	{
+		n, err := t.Certificate.DecodeScale(dec)
 		if err != nil {
 			return total, err
 		}
 		total += n
-		t.AtxIDs = field
 	}
 	return total, nil
 }
 
-func (t *LayerData) EncodeScale(enc *scale.Encoder) (total int, err error) {
+func (t *LayerCertificates) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	{
-		n, err := scale.EncodeStructSliceWithLimit(enc, t.Ballots, 800)
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Certificates, 100)
 		if err != nil {
 			return total, err
 		}
@@ -290,14 +446,517 @@ func (t *LayerData) EncodeScale(enc *scale.Encoder) (total int, err error) {
 	return total, nil
 }
 
-func (t *LayerData) DecodeScale(dec *scale.Decoder) (total int, err error) {
+func (t *LayerCertificates) DecodeScale(dec *scale.Decoder) (total int, err error) {
 	{
-		field, n, err := scale.DecodeStructSliceWithLimit[types.BallotID](dec, 800)
+		field, n, err := scale.DecodeStructSliceWithLimit[LayerCertificate](dec, 100)
 		if err != nil {
 			return total, err
 		}
 		total += n
-		t.Ballots = field
+		t.Certificates = field
+	}
+	return total, nil
+}
+
+func (t *LightLayersRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.From))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.To))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *LightLayersRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.From = types.LayerID(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.To = types.LayerID(field)
+	}
+	return total, nil
+}
+
+func (t *LightLayer) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Layer))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.AggHash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeOption(enc, t.Certificate)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *LightLayer) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Layer = types.LayerID(field)
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.AggHash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeOption[types.Certificate](dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Certificate = field
+	}
+	return total, nil
+}
+
+func (t *LightLayers) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Layers, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *LightLayers) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[LightLayer](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Layers = field
+	}
+	return total, nil
+}
+
+func (t *AtxHeadersRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Epoch))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Offset))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *AtxHeadersRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Epoch = types.EpochID(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Offset = uint32(field)
+	}
+	return total, nil
+}
+
+func (t *AtxHeader) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeByteArray(enc, t.ID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.SmesherID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Coinbase[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.Weight))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.BaseHeight))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeCompact64(enc, uint64(t.Height))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *AtxHeader) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		n, err := scale.DecodeByteArray(dec, t.ID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.SmesherID[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Coinbase[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Weight = uint64(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.BaseHeight = uint64(field)
+	}
+	{
+		field, n, err := scale.DecodeCompact64(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Height = uint64(field)
+	}
+	return total, nil
+}
+
+func (t *AtxHeaders) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Total))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Headers, 10000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *AtxHeaders) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Total = uint32(field)
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[AtxHeader](dec, 10000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Headers = field
+	}
+	return total, nil
+}
+
+func (t *HashProbeRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Requests, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *HashProbeRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[RequestMessage](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Requests = field
+	}
+	return total, nil
+}
+
+func (t *HashProbeResponse) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Hashes, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *HashProbeResponse) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.Hash32](dec, 100)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Hashes = field
+	}
+	return total, nil
+}
+
+func (t *MaliciousIDs) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.NodeIDs, 100000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *MaliciousIDs) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.NodeID](dec, 100000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.NodeIDs = field
+	}
+	return total, nil
+}
+
+func (t *EpochData) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.AtxIDs, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *EpochData) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.ATXID](dec, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.AtxIDs = field
+	}
+	return total, nil
+}
+
+func (t *LayerData) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Ballots, 800)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *LayerData) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.BallotID](dec, 800)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Ballots = field
+	}
+	return total, nil
+}
+
+func (t *EpochDeltaRequest) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeCompact32(enc, uint32(t.Epoch))
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Base[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *EpochDeltaRequest) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeCompact32(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Epoch = types.EpochID(field)
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Base[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *EpochDelta) EncodeScale(enc *scale.Encoder) (total int, err error) {
+	{
+		n, err := scale.EncodeBool(enc, t.Full)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeByteArray(enc, t.Hash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Added, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		n, err := scale.EncodeStructSliceWithLimit(enc, t.Removed, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (t *EpochDelta) DecodeScale(dec *scale.Decoder) (total int, err error) {
+	{
+		field, n, err := scale.DecodeBool(dec)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Full = field
+	}
+	{
+		n, err := scale.DecodeByteArray(dec, t.Hash[:])
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.ATXID](dec, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Added = field
+	}
+	{
+		field, n, err := scale.DecodeStructSliceWithLimit[types.ATXID](dec, 2200000)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		t.Removed = field
 	}
 	return total, nil
 }
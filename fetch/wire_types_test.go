@@ -1,11 +1,15 @@
 package fetch
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/codec/codectest"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
 )
 
 func Fuzz_NewMeshHashRequest(f *testing.F) {
@@ -19,3 +23,36 @@ func Fuzz_NewMeshHashRequest(f *testing.F) {
 		require.NoError(t, req.Validate())
 	})
 }
+
+func FuzzRequestBatchDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.DecodeWithLimit[RequestBatch](MaxRequestSize),
+		codectest.Seeds[RequestBatch](f, 20, 1001)...)
+}
+
+func TestMaxRequestSize(t *testing.T) {
+	batch := RequestBatch{ID: types.RandomHash()}
+	for i := 0; i < MaxHashesInBatch; i++ {
+		batch.Requests = append(batch.Requests, RequestMessage{
+			Hint: datastore.Hint(strings.Repeat("h", 256)),
+			Hash: types.RandomHash(),
+		})
+	}
+	encoded := codec.MustEncode(&batch)
+	require.LessOrEqual(t, len(encoded), MaxRequestSize)
+	var decoded RequestBatch
+	require.NoError(t, codec.DecodeWithLimit(encoded, &decoded, MaxRequestSize))
+	require.ErrorIs(t, codec.DecodeWithLimit(encoded, &decoded, len(encoded)-1), codec.ErrTooLarge)
+}
+
+func FuzzResponseBatchDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.Decode[ResponseBatch], codectest.Seeds[ResponseBatch](f, 20, 1001)...)
+}
+
+func FuzzHintedResponseBatchDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.Decode[HintedResponseBatch],
+		codectest.Seeds[HintedResponseBatch](f, 20, 1001)...)
+}
+
+func FuzzResponseChunkDecode(f *testing.F) {
+	codectest.FuzzDecode(f, codectest.Decode[ResponseChunk], codectest.Seeds[ResponseChunk](f, 20, 1001)...)
+}
@@ -191,7 +191,7 @@ func (c *Context) Consume(gas uint64) (err error) {
 
 // Apply is executed if transaction was consumed.
 func (c *Context) Apply(updater AccountUpdater) error {
-	c.PrincipalAccount.NextNonce = c.Header.Nonce + 1
+	UseNonce(c.PrincipalHandler, &c.PrincipalAccount, c.Header.Nonce)
 	if err := updater.Update(c.PrincipalAccount); err != nil {
 		return fmt.Errorf("%w: %w", ErrInternal, err)
 	}
@@ -0,0 +1,42 @@
+package core
+
+import "fmt"
+
+// NonceScheme is an optional interface for template handlers that define their own replay protection,
+// e.g. independent sequences of nonces for channel-like use, instead of the sequential nonce of the account.
+//
+// The scheme is consulted by the vm when the transaction is executed, therefore both methods must be deterministic.
+// The mempool orders transactions of the account by sequential nonces, it doesn't admit transactions of
+// the accounts whose templates define their own scheme.
+type NonceScheme interface {
+	// CheckNonce returns an error if the transaction with the nonce can't be executed by the account.
+	CheckNonce(account *Account, nonce Nonce) error
+	// UseNonce updates the account after the transaction with the nonce was executed,
+	// so that the nonce can't be executed again.
+	UseNonce(account *Account, nonce Nonce)
+}
+
+// CheckNonce returns an error if the account can't execute the transaction with the nonce.
+// By default the nonce must not be lower than the next nonce of the account.
+func CheckNonce(handler Handler, account *Account, nonce Nonce) error {
+	if scheme, ok := handler.(NonceScheme); ok {
+		if err := scheme.CheckNonce(account, nonce); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidNonce, err)
+		}
+		return nil
+	}
+	if nonce < account.NextNonce {
+		return fmt.Errorf("%w: nonce %d is lower than next nonce %d", ErrInvalidNonce, nonce, account.NextNonce)
+	}
+	return nil
+}
+
+// UseNonce updates the account after the transaction with the nonce was executed.
+// By default the next nonce of the account follows the nonce of the transaction.
+func UseNonce(handler Handler, account *Account, nonce Nonce) {
+	if scheme, ok := handler.(NonceScheme); ok {
+		scheme.UseNonce(account, nonce)
+		return
+	}
+	account.NextNonce = nonce + 1
+}
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/genvm/core"
@@ -9,23 +10,106 @@ import (
 
 // New creates Registry instance.
 func New() *Registry {
-	return &Registry{templates: map[types.Address]core.Handler{}}
+	return &Registry{templates: map[types.Address]*template{}}
+}
+
+type activation struct {
+	epoch   types.EpochID
+	version uint32
+}
+
+// template keeps all code versions of the template and the epochs in which they are activated.
+// Versions stay resident after the activation of the next version, so that the layers before
+// the activation epoch are executed by the same code that executed them before the upgrade.
+type template struct {
+	versions    map[uint32]core.Handler
+	activations []activation // sorted by epoch
+}
+
+func (t *template) at(epoch types.EpochID) core.Handler {
+	i := sort.Search(len(t.activations), func(i int) bool {
+		return t.activations[i].epoch > epoch
+	})
+	return t.versions[t.activations[i-1].version]
 }
 
 // Registry stores mapping from address to template handler.
 type Registry struct {
-	templates map[core.Address]core.Handler
+	templates map[core.Address]*template
+	upgraded  bool
 }
 
-// Get template handler for the address if it exists.
+// Get template handler for the address that is active since genesis.
 func (r *Registry) Get(address core.Address) core.Handler {
-	return r.templates[address]
+	return r.At(0).Get(address)
 }
 
-// Register handler for the address. Panics if address is already taken.
+// At returns the view of the registry with the handlers that are active in the epoch.
+func (r *Registry) At(epoch types.EpochID) *Epoch {
+	return &Epoch{registry: r, epoch: epoch}
+}
+
+// Upgraded returns true if any template has versions activated after genesis.
+func (r *Registry) Upgraded() bool {
+	return r.upgraded
+}
+
+// Register handler for the address as the version 0 that is active since genesis.
+// Panics if address is already taken.
 func (r *Registry) Register(address core.Address, handler core.Handler) {
 	if _, exist := r.templates[address]; exist {
 		panic(fmt.Sprintf("%x already register", address))
 	}
-	r.templates[address] = handler
+	r.templates[address] = &template{
+		versions:    map[uint32]core.Handler{0: handler},
+		activations: []activation{{epoch: 0, version: 0}},
+	}
+}
+
+// RegisterVersion adds the version of the code for the template that was registered before.
+// The version is not used until it is activated. Panics if the template is not registered
+// or if the version already exists.
+func (r *Registry) RegisterVersion(address core.Address, version uint32, handler core.Handler) {
+	tpl, exist := r.templates[address]
+	if !exist {
+		panic(fmt.Sprintf("%x is not registered", address))
+	}
+	if _, exist := tpl.versions[version]; exist {
+		panic(fmt.Sprintf("version %d of %x already registered", version, address))
+	}
+	tpl.versions[version] = handler
+}
+
+// Activate the version of the template starting from the epoch.
+// Activations of the template must be ordered by epoch.
+func (r *Registry) Activate(address core.Address, version uint32, epoch types.EpochID) error {
+	tpl, exist := r.templates[address]
+	if !exist {
+		return fmt.Errorf("template %s is not registered", address)
+	}
+	if _, exist := tpl.versions[version]; !exist {
+		return fmt.Errorf("version %d of template %s is not registered", version, address)
+	}
+	if last := tpl.activations[len(tpl.activations)-1]; epoch <= last.epoch {
+		return fmt.Errorf("version %d of template %s activated in epoch %d after version %d in epoch %d",
+			version, address, epoch, last.version, last.epoch)
+	}
+	tpl.activations = append(tpl.activations, activation{epoch: epoch, version: version})
+	r.upgraded = true
+	return nil
+}
+
+// Epoch is a view of the registry with the handlers that are active in the epoch.
+type Epoch struct {
+	registry *Registry
+	epoch    types.EpochID
+}
+
+// Get template handler for the address that is active in the epoch if it exists.
+func (e *Epoch) Get(address core.Address) core.Handler {
+	tpl, exist := e.registry.templates[address]
+	if !exist {
+		return nil
+	}
+	return tpl.at(e.epoch)
 }
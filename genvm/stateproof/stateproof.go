@@ -0,0 +1,72 @@
+// Package stateproof verifies that the state of the account is included in the state root of the layer.
+// It doesn't depend on the database, so that light clients can verify proofs served by untrusted nodes
+// against the state root agreed by several nodes.
+package stateproof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-scale"
+	"github.com/spacemeshos/merkle-tree"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+// ErrInvalid is returned if the account is not proven by the proof.
+var ErrInvalid = errors.New("invalid state proof")
+
+// Proof proves that the account is the leaf of the state tree with the root after the layer was applied.
+type Proof struct {
+	Layer types.LayerID
+	Root  types.Hash32
+	// Index of the leaf, leaves are ordered by the address of the account.
+	Index uint64
+	Nodes []types.Hash32
+}
+
+// Parent computes the parent node from the children, it is used as the hash function of the state tree.
+func Parent(buf, lChild, rChild []byte) []byte {
+	hh := hash.New()
+	hh.Write(lChild)
+	hh.Write(rChild)
+	return hh.Sum(buf)
+}
+
+// Leaf returns the leaf of the state tree for the account. The layer of the last update
+// is not included, so that the state root doesn't depend on how the state was reached.
+func Leaf(account *types.Account) types.Hash32 {
+	leaf := *account
+	leaf.Layer = 0
+	hh := hash.New()
+	// encoding to the hasher never fails
+	_, _ = leaf.EncodeScale(scale.NewEncoder(hh))
+	var rst types.Hash32
+	hh.Sum(rst[:0])
+	return rst
+}
+
+// Verify checks that the account is proven by the proof.
+// The caller is expected to check that the root of the proof is the state root of the layer.
+func Verify(account *types.Account, proof *Proof) error {
+	leaf := Leaf(account)
+	nodes := make([][]byte, 0, len(proof.Nodes))
+	for _, node := range proof.Nodes {
+		nodes = append(nodes, node.Bytes())
+	}
+	valid, err := merkle.ValidatePartialTree(
+		[]uint64{proof.Index},
+		[][]byte{leaf.Bytes()},
+		nodes,
+		proof.Root.Bytes(),
+		Parent,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if !valid {
+		return fmt.Errorf("%w: account %s in layer %s", ErrInvalid, account.Address, proof.Layer)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package stateproof
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/merkle-tree"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func prove(tb testing.TB, accounts []types.Account, index uint64) *Proof {
+	tree, err := merkle.NewTreeBuilder().
+		WithHashFunc(Parent).
+		WithLeavesToProve(map[uint64]bool{index: true}).
+		Build()
+	require.NoError(tb, err)
+	for i := range accounts {
+		leaf := Leaf(&accounts[i])
+		require.NoError(tb, tree.AddLeaf(leaf[:]))
+	}
+	root, nodes := tree.RootAndProof()
+	proof := &Proof{Layer: 7, Root: types.BytesToHash(root), Index: index}
+	for _, node := range nodes {
+		proof.Nodes = append(proof.Nodes, types.BytesToHash(node))
+	}
+	return proof
+}
+
+func TestLeaf(t *testing.T) {
+	account := types.Account{Address: types.Address{1}, Balance: 100, NextNonce: 2, Layer: 5}
+	moved := account
+	moved.Layer = 9
+	require.Equal(t, Leaf(&account), Leaf(&moved))
+
+	spent := account
+	spent.Balance--
+	require.NotEqual(t, Leaf(&account), Leaf(&spent))
+}
+
+func TestVerify(t *testing.T) {
+	template := types.Address{9}
+	accounts := []types.Account{
+		{Address: types.Address{1}, Balance: 100},
+		{Address: types.Address{2}, Balance: 200, NextNonce: 1, TemplateAddress: &template, State: []byte{1, 2}},
+		{Address: types.Address{3}, Balance: 300, NextNonce: 3},
+		{Address: types.Address{4}, Balance: 400},
+		{Address: types.Address{5}, Balance: 500},
+	}
+	for i := range accounts {
+		proof := prove(t, accounts, uint64(i))
+		require.NoError(t, Verify(&accounts[i], proof))
+
+		other := accounts[(i+1)%len(accounts)]
+		require.ErrorIs(t, Verify(&other, proof), ErrInvalid)
+
+		tampered := *proof
+		tampered.Root = types.Hash32{1}
+		require.ErrorIs(t, Verify(&accounts[i], &tampered), ErrInvalid)
+
+		tampered = *proof
+		tampered.Index = uint64(len(accounts))
+		require.ErrorIs(t, Verify(&accounts[i], &tampered), ErrInvalid)
+	}
+}
@@ -0,0 +1,102 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/merkle-tree"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/stateproof"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+)
+
+// StateRoot computes the root of the merkle tree with the leaves for all accounts in ascending order
+// of addresses. Unbalanced tree is padded with empty nodes, the root of the empty state is the zero hash.
+// Leaves and nodes are computed as defined in the stateproof package.
+func StateRoot(db sql.Executor) (types.Hash32, error) {
+	root, _, err := stateTree(latest(db), nil)
+	return root, err
+}
+
+// StateRootAt computes the state root for the state of the accounts after the layer was applied.
+func StateRootAt(db sql.Executor, layer types.LayerID) (types.Hash32, error) {
+	root, _, err := stateTree(snapshot(db, layer), nil)
+	return root, err
+}
+
+// AccountStateProof returns the proof for the state of the account after the layer was applied.
+func AccountStateProof(db sql.Executor, address types.Address, layer types.LayerID) (*stateproof.Proof, error) {
+	var (
+		index uint64
+		found bool
+	)
+	if err := snapshot(db, layer)(func(account *types.Account) bool {
+		if account.Address == address {
+			found = true
+			return false
+		}
+		index++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("account %s in layer %s: %w", address, layer, sql.ErrNotFound)
+	}
+	root, nodes, err := stateTree(snapshot(db, layer), map[uint64]bool{index: true})
+	if err != nil {
+		return nil, err
+	}
+	rst := &stateproof.Proof{Layer: layer, Root: root, Index: index, Nodes: make([]types.Hash32, 0, len(nodes))}
+	for _, node := range nodes {
+		rst.Nodes = append(rst.Nodes, types.BytesToHash(node))
+	}
+	return rst, nil
+}
+
+type iterator func(func(*types.Account) bool) error
+
+func latest(db sql.Executor) iterator {
+	return func(fn func(*types.Account) bool) error {
+		return accounts.IterateLatest(db, fn)
+	}
+}
+
+func snapshot(db sql.Executor, layer types.LayerID) iterator {
+	return func(fn func(*types.Account) bool) error {
+		return accounts.IterateSnapshot(db, layer, fn)
+	}
+}
+
+func stateTree(iterate iterator, prove map[uint64]bool) (types.Hash32, [][]byte, error) {
+	tree, err := merkle.NewTreeBuilder().
+		WithHashFunc(stateproof.Parent).
+		WithLeavesToProve(prove).
+		Build()
+	if err != nil {
+		return types.Hash32{}, nil, fmt.Errorf("create state tree: %w", err)
+	}
+	var (
+		leaves int
+		aerr   error
+	)
+	if err := iterate(func(account *types.Account) bool {
+		leaf := stateproof.Leaf(account)
+		if aerr = tree.AddLeaf(leaf[:]); aerr != nil {
+			return false
+		}
+		leaves++
+		return true
+	}); err != nil {
+		return types.Hash32{}, nil, err
+	}
+	if aerr != nil {
+		return types.Hash32{}, nil, fmt.Errorf("add state leaf: %w", aerr)
+	}
+	if leaves == 0 {
+		return types.Hash32{}, nil, nil
+	}
+	root, nodes := tree.RootAndProof()
+	return types.BytesToHash(root), nodes, nil
+}
@@ -0,0 +1,123 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/genvm/stateproof"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+func (t *tester) withStateRoot() *tester {
+	t.cfg.StateRoot = true
+	return t
+}
+
+func TestStateRoot(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tt := newTester(t).addSingleSig(2).applyGenesis()
+		lid := types.GetEffectiveGenesis()
+		_, _, err := tt.Apply(testContext(lid), notVerified(tt.spawnAll()...), nil)
+		require.NoError(t, err)
+		_, err = layers.GetStateRoot(tt.db, lid)
+		require.ErrorIs(t, err, sql.ErrNotFound)
+	})
+	t.Run("empty", func(t *testing.T) {
+		root, err := StateRoot(sql.InMemory())
+		require.NoError(t, err)
+		require.Equal(t, types.Hash32{}, root)
+	})
+	t.Run("deterministic", func(t *testing.T) {
+		first := newTester(t).withStateRoot().withSeed(101).addSingleSig(5).applyGenesis()
+		second := newTester(t).withStateRoot().withSeed(101).addSingleSig(5).applyGenesis()
+		genesis, err := StateRoot(first.db)
+		require.NoError(t, err)
+		require.NotEqual(t, types.Hash32{}, genesis)
+
+		lid := types.GetEffectiveGenesis()
+		txs := notVerified(first.spawnAll()...)
+		for _, tt := range []*tester{first, second} {
+			skipped, _, err := tt.Apply(testContext(lid), txs, nil)
+			require.NoError(t, err)
+			require.Empty(t, skipped)
+		}
+		expected, err := layers.GetStateRoot(first.db, lid)
+		require.NoError(t, err)
+		require.NotEqual(t, genesis, expected)
+		root, err := layers.GetStateRoot(second.db, lid)
+		require.NoError(t, err)
+		require.Equal(t, expected, root)
+	})
+	t.Run("unchanged state", func(t *testing.T) {
+		tt := newTester(t).withStateRoot().addSingleSig(2).applyGenesis()
+		lid := types.GetEffectiveGenesis()
+		_, _, err := tt.Apply(testContext(lid), notVerified(tt.spawnAll()...), nil)
+		require.NoError(t, err)
+		_, _, err = tt.Apply(testContext(lid.Add(1)), nil, nil)
+		require.NoError(t, err)
+
+		roots, err := layers.StateRoots(tt.db, lid, lid.Add(1))
+		require.NoError(t, err)
+		require.Len(t, roots, 2)
+		require.Equal(t, roots[0].Root, roots[1].Root)
+	})
+	t.Run("revert", func(t *testing.T) {
+		tt := newTester(t).withStateRoot().addSingleSig(2).applyGenesis()
+		lid := types.GetEffectiveGenesis()
+		_, _, err := tt.Apply(testContext(lid), notVerified(tt.spawnAll()...), nil)
+		require.NoError(t, err)
+		_, _, err = tt.Apply(testContext(lid.Add(1)), notVerified(tt.spend(0, 1, 100)), nil)
+		require.NoError(t, err)
+
+		expected, err := layers.GetStateRoot(tt.db, lid)
+		require.NoError(t, err)
+		require.NoError(t, tt.Revert(lid))
+		root, err := StateRoot(tt.db)
+		require.NoError(t, err)
+		require.Equal(t, expected, root)
+	})
+}
+
+func TestStateProof(t *testing.T) {
+	tt := newTester(t).withStateRoot().addSingleSig(5).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	_, _, err := tt.Apply(testContext(lid), notVerified(tt.spawnAll()...), nil)
+	require.NoError(t, err)
+	_, _, err = tt.Apply(testContext(lid.Add(1)), notVerified(tt.spend(0, 1, 100)), nil)
+	require.NoError(t, err)
+
+	for _, layer := range []types.LayerID{lid, lid.Add(1)} {
+		root, err := layers.GetStateRoot(tt.db, layer)
+		require.NoError(t, err)
+		computed, err := StateRootAt(tt.db, layer)
+		require.NoError(t, err)
+		require.Equal(t, root, computed)
+
+		snapshot, err := accounts.Snapshot(tt.db, layer)
+		require.NoError(t, err)
+		for _, account := range snapshot {
+			proof, err := AccountStateProof(tt.db, account.Address, layer)
+			require.NoError(t, err)
+			require.Equal(t, layer, proof.Layer)
+			require.Equal(t, root, proof.Root)
+			require.NoError(t, stateproof.Verify(account, proof))
+
+			account.Balance++
+			require.ErrorIs(t, stateproof.Verify(account, proof), stateproof.ErrInvalid)
+		}
+	}
+
+	// proof for the state of the account before the spend is not valid for the state after it
+	before, err := accounts.Get(tt.db, tt.accounts[0].getAddress(), lid)
+	require.NoError(t, err)
+	proof, err := AccountStateProof(tt.db, before.Address, lid.Add(1))
+	require.NoError(t, err)
+	require.ErrorIs(t, stateproof.Verify(&before, proof), stateproof.ErrInvalid)
+
+	_, err = AccountStateProof(tt.db, types.Address{1}, lid)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
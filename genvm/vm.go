@@ -37,10 +37,23 @@ func WithLogger(logger log.Log) Opt {
 	}
 }
 
+// TemplateUpgrade activates the version of the template code starting from the epoch.
+type TemplateUpgrade struct {
+	Template types.Address `mapstructure:"template"`
+	Version  uint32        `mapstructure:"version"`
+	Epoch    types.EpochID `mapstructure:"epoch"`
+}
+
 // Config defines the configuration options for vm.
 type Config struct {
 	GasLimit  uint64
 	GenesisID types.Hash20
+	// TemplateUpgrades are the activations of the template code versions, ordered by epoch
+	// for every template. Layers before the activation epoch are executed by the previous version.
+	TemplateUpgrades []TemplateUpgrade `mapstructure:"template-upgrades"`
+	// StateRoot persists the root of the state of all accounts for every applied layer (see StateRoot).
+	// The root is rebuilt from all accounts when a layer is applied, so it is disabled by default.
+	StateRoot bool `mapstructure:"state-root"`
 }
 
 // DefaultConfig returns the default RewardConfig.
@@ -72,6 +85,11 @@ func New(db *sql.Database, opts ...Opt) *VM {
 	for _, opt := range opts {
 		opt(vm)
 	}
+	for _, upgrade := range vm.cfg.TemplateUpgrades {
+		if err := vm.registry.Activate(upgrade.Template, upgrade.Version, upgrade.Epoch); err != nil {
+			panic(fmt.Sprintf("invalid template upgrade: %v", err))
+		}
+	}
 	return vm
 }
 
@@ -90,9 +108,23 @@ func (v *VM) Validation(raw types.RawTx) system.ValidationRequest {
 		cache:   core.NewStagedCache(core.DBLoader{Executor: v.db}),
 		decoder: scale.NewDecoder(bytes.NewReader(raw.Raw)),
 		raw:     raw,
+		epoch:   v.validationEpoch(),
 	}
 }
 
+// validationEpoch returns the epoch of the template code that validates transactions
+// before they are applied, which is the epoch of the next layer to be applied.
+func (v *VM) validationEpoch() types.EpochID {
+	if !v.registry.Upgraded() {
+		return 0
+	}
+	lid, err := layers.GetLastApplied(v.db)
+	if err != nil {
+		return 0
+	}
+	return lid.Add(1).GetEpoch()
+}
+
 // GetLayerStateRoot returns the state root at a given layer.
 func (v *VM) GetLayerStateRoot(lid types.LayerID) (types.Hash32, error) {
 	return layers.GetStateHash(v.db, lid)
@@ -161,6 +193,20 @@ func (v *VM) GetNonce(address core.Address) (core.Nonce, error) {
 	return account.NextNonce, nil
 }
 
+// HasNonceScheme returns true if the template of the account defines its own replay protection
+// (see core.NonceScheme) instead of the sequential nonce.
+func (v *VM) HasNonceScheme(address core.Address) (bool, error) {
+	account, err := accounts.Latest(v.db, address)
+	if err != nil {
+		return false, err
+	}
+	if account.TemplateAddress == nil {
+		return false, nil
+	}
+	_, ok := v.registry.At(v.validationEpoch()).Get(*account.TemplateAddress).(core.NonceScheme)
+	return ok, nil
+}
+
 // GetBalance returns balance for an address.
 func (v *VM) GetBalance(address types.Address) (uint64, error) {
 	account, err := accounts.Latest(v.db, address)
@@ -202,7 +248,7 @@ func (v *VM) Apply(
 	blockDurationWait.Observe(float64(time.Since(t1)))
 
 	ss := core.NewStagedCache(core.DBLoader{Executor: v.db})
-	results, skipped, fees, err := v.execute(lctx, ss, txs)
+	results, skipped, reasons, fees, err := v.execute(lctx, ss, txs)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -254,6 +300,26 @@ func (v *VM) Apply(
 	if err := layers.UpdateStateHash(tx, lctx.Layer, hash); err != nil {
 		return nil, nil, err
 	}
+	var root types.Hash32
+	if v.cfg.StateRoot {
+		root, err = StateRoot(tx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", core.ErrInternal, err)
+		}
+		if err := layers.SetStateRoot(tx, lctx.Layer, root); err != nil {
+			return nil, nil, err
+		}
+	}
+	if lctx.Persist != nil {
+		if err := lctx.Persist(tx, results); err != nil {
+			return nil, nil, err
+		}
+	}
+	if lctx.PersistSkipped != nil && len(reasons) > 0 {
+		if err := lctx.PersistSkipped(tx, reasons); err != nil {
+			return nil, nil, err
+		}
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, nil, fmt.Errorf("%w: %w", core.ErrInternal, err)
 	}
@@ -264,18 +330,25 @@ func (v *VM) Apply(
 	for _, reward := range rewardsResult {
 		events.ReportRewardReceived(reward)
 	}
+	if v.cfg.StateRoot {
+		events.ReportStateRoot(layers.StateRoot{Layer: lctx.Layer, Root: root})
+	}
 
 	blockDurationPersist.Observe(float64(time.Since(t3)))
 	blockDuration.Observe(float64(time.Since(t1)))
 	transactionsPerBlock.Observe(float64(len(txs)))
 	appliedLayer.Set(float64(lctx.Layer))
 
-	v.logger.With().Debug("applied layer",
+	fields := []log.LoggableField{
 		log.Uint32("layer", lctx.Layer.Uint32()),
 		log.Int("count", len(txs)-len(skipped)),
 		log.Duration("duration", time.Since(t1)),
 		log.Stringer("state_hash", hash),
-	)
+	}
+	if v.cfg.StateRoot {
+		fields = append(fields, log.Stringer("state_root", root))
+	}
+	v.logger.With().Debug("applied layer", fields...)
 	return skipped, results, nil
 }
 
@@ -283,15 +356,21 @@ func (v *VM) execute(
 	lctx ApplyContext,
 	ss *core.StagedCache,
 	txs []types.Transaction,
-) ([]types.TransactionWithResult, []types.Transaction, uint64, error) {
+) ([]types.TransactionWithResult, []types.Transaction, []types.SkippedTransaction, uint64, error) {
 	var (
 		rd          bytes.Reader
 		decoder     = scale.NewDecoder(&rd)
 		fees        uint64
 		ineffective []types.Transaction
+		reasons     []types.SkippedTransaction
 		executed    []types.TransactionWithResult
 		limit       = v.cfg.GasLimit
 	)
+	skip := func(tx types.Transaction, reason types.SkipReason) {
+		ineffective = append(ineffective, tx)
+		reasons = append(reasons, types.SkippedTransaction{ID: tx.ID, Reason: reason})
+		invalidTxCount.Inc()
+	}
 	for i := range txs {
 		logger := v.logger.WithFields(log.Int("ith", i))
 		txCount.Inc()
@@ -304,6 +383,7 @@ func (v *VM) execute(
 			vm:      v,
 			cache:   ss,
 			lid:     lctx.Layer,
+			epoch:   lctx.Layer.GetEpoch(),
 			raw:     txs[i].GetRaw(),
 			decoder: decoder,
 		}
@@ -314,8 +394,7 @@ func (v *VM) execute(
 				tx.GetRaw().ID,
 				log.Err(err),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw()}, types.SkippedMalformed)
 			continue
 		}
 		ctx := req.ctx
@@ -326,8 +405,7 @@ func (v *VM) execute(
 				log.Object("header", header),
 				log.Object("account", &ctx.PrincipalAccount),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw()}, types.SkippedZeroGasPrice)
 			continue
 		}
 		if intrinsic := core.IntrinsicGas(ctx.Gas.BaseGas, tx.GetRaw().Raw); ctx.PrincipalAccount.Balance < intrinsic {
@@ -336,8 +414,7 @@ func (v *VM) execute(
 				log.Object("account", &ctx.PrincipalAccount),
 				log.Uint64("intrinsic gas", intrinsic),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw()}, types.SkippedInsufficientFunds)
 			continue
 		}
 		if limit < ctx.Header.MaxGas {
@@ -347,8 +424,7 @@ func (v *VM) execute(
 				log.Object("header", header),
 				log.Object("account", &ctx.PrincipalAccount),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw()}, types.SkippedBlockGasLimit)
 			continue
 		}
 
@@ -359,18 +435,17 @@ func (v *VM) execute(
 				log.Object("header", header),
 				log.Object("account", &ctx.PrincipalAccount),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw()})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw()}, types.SkippedInvalidSignature)
 			continue
 		}
 
-		if ctx.PrincipalAccount.NextNonce > ctx.Header.Nonce {
+		if err := core.CheckNonce(ctx.PrincipalHandler, &ctx.PrincipalAccount, ctx.Header.Nonce); err != nil {
 			logger.With().Warning("ineffective transaction. nonce too low",
 				log.Object("header", header),
 				log.Object("account", &ctx.PrincipalAccount),
+				log.Err(err),
 			)
-			ineffective = append(ineffective, types.Transaction{RawTx: tx.GetRaw(), TxHeader: header})
-			invalidTxCount.Inc()
+			skip(types.Transaction{RawTx: tx.GetRaw(), TxHeader: header}, types.SkippedNonceTooLow)
 			continue
 		}
 
@@ -394,7 +469,7 @@ func (v *VM) execute(
 				log.Err(err),
 			)
 			if errors.Is(err, core.ErrInternal) {
-				return nil, nil, 0, err
+				return nil, nil, nil, 0, err
 			}
 		}
 		transactionDurationExecute.Observe(float64(time.Since(t2)))
@@ -412,7 +487,7 @@ func (v *VM) execute(
 
 		err = ctx.Apply(ss)
 		if err != nil {
-			return nil, nil, 0, fmt.Errorf("%w: %w", core.ErrInternal, err)
+			return nil, nil, nil, 0, fmt.Errorf("%w: %w", core.ErrInternal, err)
 		}
 		fees += ctx.Fee()
 		limit -= ctx.Consumed()
@@ -420,7 +495,7 @@ func (v *VM) execute(
 		executed = append(executed, rst)
 		transactionDuration.Observe(float64(time.Since(t1)))
 	}
-	return executed, ineffective, fees, nil
+	return executed, ineffective, reasons, fees, nil
 }
 
 // Request used to implement 2-step validation flow.
@@ -431,6 +506,7 @@ type Request struct {
 	cache *core.StagedCache
 
 	lid     types.LayerID
+	epoch   types.EpochID // selects versions of the template code
 	raw     types.RawTx
 	decoder *scale.Decoder
 
@@ -445,7 +521,8 @@ func (r *Request) Parse() (*core.Header, error) {
 	if len(r.raw.Raw) > core.TxSizeLimit {
 		return nil, fmt.Errorf("%w: tx size (%d) > limit (%d)", core.ErrTxLimit, len(r.raw.Raw), core.TxSizeLimit)
 	}
-	header, ctx, args, err := parse(r.vm.logger, r.lid, r.vm.registry, r.cache, r.vm.cfg, r.raw.Raw, r.decoder)
+	reg := r.vm.registry.At(r.epoch)
+	header, ctx, args, err := parse(r.vm.logger, r.lid, reg, r.cache, r.vm.cfg, r.raw.Raw, r.decoder)
 	if err != nil {
 		return nil, err
 	}
@@ -469,7 +546,7 @@ func (r *Request) Verify() bool {
 func parse(
 	logger log.Log,
 	lid types.LayerID,
-	reg *registry.Registry,
+	reg core.HandlerRegistry,
 	loader core.AccountLoader,
 	cfg Config,
 	raw []byte,
@@ -608,4 +685,11 @@ func verify(ctx *core.Context, raw []byte, dec *scale.Decoder) bool {
 // ApplyContext has information on layer and block id.
 type ApplyContext struct {
 	Layer types.LayerID
+	// Persist is called with execution results before the updated state is committed,
+	// in the same database transaction. It allows the caller to persist data that must
+	// be consistent with the state, e.g. transaction results.
+	Persist func(*sql.Tx, []types.TransactionWithResult) error
+	// PersistSkipped is called after Persist with the transactions that were not executed,
+	// in the same database transaction.
+	PersistSkipped func(*sql.Tx, []types.SkippedTransaction) error
 }
@@ -2311,6 +2311,170 @@ func TestStateHashFromUpdatedAccounts(t *testing.T) {
 	require.Equal(t, expected, root)
 }
 
+func TestApplyPersist(t *testing.T) {
+	tt := newTester(t).addSingleSig(2).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	txs := notVerified(tt.selfSpawn(0), tt.spend(0, 1, 100))
+
+	errPersist := errors.New("persist")
+	lctx := testContext(lid)
+	lctx.Persist = func(tx *sql.Tx, results []types.TransactionWithResult) error {
+		require.Len(t, results, len(txs))
+		return errPersist
+	}
+	_, _, err := tt.Apply(lctx, txs, nil)
+	require.ErrorIs(t, err, errPersist)
+	_, err = layers.GetStateHash(tt.db, lid)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	account, err := accounts.Latest(tt.db, tt.accounts[0].getAddress())
+	require.NoError(t, err)
+	require.Zero(t, account.NextNonce)
+
+	var persisted []types.TransactionWithResult
+	lctx.Persist = func(tx *sql.Tx, results []types.TransactionWithResult) error {
+		persisted = results
+		return nil
+	}
+	_, results, err := tt.Apply(lctx, txs, nil)
+	require.NoError(t, err)
+	require.Equal(t, results, persisted)
+	_, err = layers.GetStateHash(tt.db, lid)
+	require.NoError(t, err)
+}
+
+var errUpgraded = errors.New("upgraded")
+
+type upgradedHandler struct {
+	core.Handler
+}
+
+func (upgradedHandler) Exec(core.Host, uint8, scale.Encodable) error {
+	return errUpgraded
+}
+
+func TestTemplateUpgrade(t *testing.T) {
+	tt := newTester(t).addSingleSig(2).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	epoch := lid.GetEpoch() + 1
+	original := tt.registry.Get(wallet.TemplateAddress)
+	tt.registry.RegisterVersion(wallet.TemplateAddress, 1, upgradedHandler{Handler: original})
+	require.False(t, tt.registry.Upgraded())
+	require.Error(t, tt.registry.Activate(wallet.TemplateAddress, 2, epoch))
+	require.Error(t, tt.registry.Activate(types.Address{1}, 1, epoch))
+	require.NoError(t, tt.registry.Activate(wallet.TemplateAddress, 1, epoch))
+	require.Error(t, tt.registry.Activate(wallet.TemplateAddress, 1, epoch))
+	require.True(t, tt.registry.Upgraded())
+
+	require.Equal(t, original, tt.registry.At(epoch-1).Get(wallet.TemplateAddress))
+	require.Equal(t, upgradedHandler{Handler: original}, tt.registry.At(epoch).Get(wallet.TemplateAddress))
+	require.Equal(t, upgradedHandler{Handler: original}, tt.registry.At(epoch+1).Get(wallet.TemplateAddress))
+	require.Equal(t, original, tt.registry.Get(wallet.TemplateAddress))
+	require.Nil(t, tt.registry.At(epoch).Get(types.Address{1}))
+
+	_, results, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0), tt.spend(0, 1, 100)), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, rst := range results {
+		require.Equal(t, types.TransactionSuccess, rst.Status)
+	}
+
+	_, results, err = tt.Apply(testContext(epoch.FirstLayer()), notVerified(tt.spend(0, 1, 100)), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, types.TransactionFailure, results[0].Status)
+	require.Equal(t, errUpgraded.Error(), results[0].Message)
+}
+
+func TestTemplateUpgradeConfig(t *testing.T) {
+	require.Panics(t, func() {
+		New(sql.InMemory(), WithConfig(Config{
+			GasLimit:         math.MaxUint64,
+			TemplateUpgrades: []TemplateUpgrade{{Template: wallet.TemplateAddress, Version: 1, Epoch: 2}},
+		}))
+	})
+}
+
+// bitmapNonceHandler allows to use nonces below 64 in any order,
+// the next nonce of the account is a bitmap of the used nonces.
+type bitmapNonceHandler struct {
+	core.Handler
+}
+
+func (bitmapNonceHandler) CheckNonce(account *core.Account, nonce core.Nonce) error {
+	if nonce >= 64 {
+		return fmt.Errorf("nonce %d out of range", nonce)
+	}
+	if account.NextNonce&(1<<nonce) != 0 {
+		return fmt.Errorf("nonce %d used", nonce)
+	}
+	return nil
+}
+
+func (bitmapNonceHandler) UseNonce(account *core.Account, nonce core.Nonce) {
+	account.NextNonce |= 1 << nonce
+}
+
+func TestNonceScheme(t *testing.T) {
+	tt := newTester(t).addSingleSig(2).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	epoch := lid.GetEpoch() + 1
+	tt.registry.RegisterVersion(wallet.TemplateAddress, 1,
+		bitmapNonceHandler{Handler: tt.registry.Get(wallet.TemplateAddress)})
+	require.NoError(t, tt.registry.Activate(wallet.TemplateAddress, 1, epoch))
+
+	skipped, _, err := tt.Apply(testContext(lid), notVerified(tt.selfSpawn(0)), nil)
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	scheme, err := tt.HasNonceScheme(tt.accounts[0].getAddress())
+	require.NoError(t, err)
+	require.False(t, scheme, "scheme is not active yet")
+
+	replayed := tt.spendWithNonce(0, 1, 100, 3)
+	skipped, results, err := tt.Apply(testContext(epoch.FirstLayer()), notVerified(
+		tt.spendWithNonce(0, 1, 100, 3),
+		tt.spendWithNonce(0, 1, 100, 2),
+		replayed,
+	), nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, []types.Transaction{{RawTx: replayed, TxHeader: results[0].TxHeader}}, skipped)
+
+	account, err := accounts.Latest(tt.db, tt.accounts[0].getAddress())
+	require.NoError(t, err)
+	require.Equal(t, core.Nonce(0b1101), account.NextNonce)
+
+	require.NoError(t, layers.SetApplied(tt.db, epoch.FirstLayer(), types.RandomBlockID()))
+	scheme, err = tt.HasNonceScheme(tt.accounts[0].getAddress())
+	require.NoError(t, err)
+	require.True(t, scheme)
+	scheme, err = tt.HasNonceScheme(tt.accounts[1].getAddress())
+	require.NoError(t, err)
+	require.False(t, scheme, "account is not spawned")
+}
+
+func TestApplyPersistSkipped(t *testing.T) {
+	tt := newTester(t).addSingleSig(1).applyGenesis()
+	lid := types.GetEffectiveGenesis()
+	spawn := tt.selfSpawn(0)
+	_, _, err := tt.Apply(testContext(lid), notVerified(spawn), nil)
+	require.NoError(t, err)
+
+	malformed := types.NewRawTx([]byte{1, 2, 3})
+	var persisted []types.SkippedTransaction
+	lctx := testContext(lid.Add(1))
+	lctx.PersistSkipped = func(tx *sql.Tx, skipped []types.SkippedTransaction) error {
+		persisted = skipped
+		return nil
+	}
+	ineffective, _, err := tt.Apply(lctx, notVerified(spawn, malformed), nil)
+	require.NoError(t, err)
+	require.Len(t, ineffective, 2)
+	require.Equal(t, []types.SkippedTransaction{
+		{ID: spawn.ID, Reason: types.SkippedNonceTooLow},
+		{ID: malformed.ID, Reason: types.SkippedMalformed},
+	}, persisted)
+}
+
 func BenchmarkWallet(b *testing.B) {
 	b.Run("Accounts100k/Txs100k", func(b *testing.B) {
 		benchmarkWallet(b, 100_000, 100_000)
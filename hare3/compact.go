@@ -0,0 +1,296 @@
+package hare3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/spacemeshos/go-scale"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+//go:generate scalegen
+
+const (
+	// compactSuffix is appended to the protocol name to get the topic for compact preround messages.
+	compactSuffix = "/compact"
+	// fullProtocol serves full preround messages to the peers that can't restore compact messages.
+	fullProtocol = "hfm/1"
+
+	// maxFullRequests is the number of full messages that are requested at the same time.
+	maxFullRequests = 64
+	// fullRequestsRate and fullRequestsBurst limit full message requests to a single peer.
+	fullRequestsRate  = 10
+	fullRequestsBurst = 20
+	// fullRequestsPeers is the number of peers whose rate limits are tracked.
+	fullRequestsPeers = 1000
+)
+
+// errNotRestored is returned if the compact message can't be restored from the known proposals.
+var errNotRestored = errors.New("compact message not restored")
+
+// CompactProposalID is the prefix of the proposal id, used instead of the full id in compact preround messages.
+type CompactProposalID [4]byte
+
+// EncodeScale implements scale codec interface.
+func (id *CompactProposalID) EncodeScale(e *scale.Encoder) (int, error) {
+	return scale.EncodeByteArray(e, id[:])
+}
+
+// DecodeScale implements scale codec interface.
+func (id *CompactProposalID) DecodeScale(d *scale.Decoder) (int, error) {
+	return scale.DecodeByteArray(d, id[:])
+}
+
+func compactProposalID(id types.ProposalID) CompactProposalID {
+	var rst CompactProposalID
+	copy(rst[:], id[:])
+	return rst
+}
+
+// CompactMessage is the preround message with prefixes of the proposal ids instead of full ids.
+//
+// Compact encoding is only a transport encoding. The message carries the signature of the full message,
+// the receiver restores the full message from the proposals it knows and verifies the signature against it.
+// If the message can't be restored, the full message is requested from the peer that relayed it.
+type CompactMessage struct {
+	Layer       types.LayerID
+	Proposals   []CompactProposalID `scale:"max=800"`
+	Eligibility types.HareEligibility
+	Sender      types.NodeID
+	Signature   types.EdSignature
+}
+
+func compactMessage(msg *Message) *CompactMessage {
+	compact := &CompactMessage{
+		Layer:       msg.Layer,
+		Proposals:   make([]CompactProposalID, 0, len(msg.Value.Proposals)),
+		Eligibility: msg.Eligibility,
+		Sender:      msg.Sender,
+		Signature:   msg.Signature,
+	}
+	for _, id := range msg.Value.Proposals {
+		compact.Proposals = append(compact.Proposals, compactProposalID(id))
+	}
+	return compact
+}
+
+// FullRequest requests the full preround message of the sender in the layer.
+type FullRequest struct {
+	Layer  types.LayerID
+	Sender types.NodeID
+}
+
+// exchange requests full preround messages from peers.
+type exchange interface {
+	Request(context.Context, p2p.Peer, []byte) ([]byte, error)
+}
+
+// fullRequests bounds the number of outstanding full message requests, and the rate of requests to every peer.
+type fullRequests struct {
+	mu      sync.Mutex
+	pending map[FullRequest]struct{}
+	peers   *lru.Cache[p2p.Peer, *rate.Limiter]
+	wg      sync.WaitGroup
+}
+
+func newFullRequests() *fullRequests {
+	peers, err := lru.New[p2p.Peer, *rate.Limiter](fullRequestsPeers)
+	if err != nil {
+		panic(err) // the size is positive
+	}
+	return &fullRequests{
+		pending: map[FullRequest]struct{}{},
+		peers:   peers,
+	}
+}
+
+// start reserves the request to the peer. It returns false if too many requests are outstanding,
+// the same message is requested already or the peer is over its rate limit.
+func (r *fullRequests) start(peer p2p.Peer, req FullRequest) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) >= maxFullRequests {
+		return false
+	}
+	if _, exists := r.pending[req]; exists {
+		return false
+	}
+	limiter, exists := r.peers.Get(peer)
+	if !exists {
+		limiter = rate.NewLimiter(fullRequestsRate, fullRequestsBurst)
+		r.peers.Add(peer, limiter)
+	}
+	if !limiter.Allow() {
+		return false
+	}
+	r.pending[req] = struct{}{}
+	r.wg.Add(1)
+	return true
+}
+
+// done releases the request reserved with start.
+func (r *fullRequests) done(req FullRequest) {
+	r.mu.Lock()
+	delete(r.pending, req)
+	r.mu.Unlock()
+	r.wg.Done()
+}
+
+// wait blocks until all outstanding requests are done.
+func (r *fullRequests) wait() {
+	r.wg.Wait()
+}
+
+// CompactHandler handles compact preround messages. The message is passed to the protocol
+// the same as the full message after it is restored.
+//
+// The handler runs as an inline validator, therefore if the message can't be restored the full message
+// is requested in the background, and the compact message is not relayed by this node.
+func (h *Hare) CompactHandler(ctx context.Context, peer p2p.Peer, buf []byte) error {
+	compact := &CompactMessage{}
+	if err := codec.Decode(buf, compact); err != nil {
+		malformedError.Inc()
+		return fmt.Errorf("%w: decoding error %s", pubsub.ErrValidationReject, err.Error())
+	}
+	msg, err := h.restore(compact)
+	if err == nil && !h.verifier.Verify(signing.HARE, msg.Sender, msg.ToMetadata().ToBytes(), msg.Signature) {
+		// the sender included a proposal that is not known locally, but shares the prefix with a known one
+		err = fmt.Errorf("%w: signature doesn't match restored message", errNotRestored)
+	}
+	if err == nil {
+		compactRestored.Inc()
+		return h.handle(ctx, msg)
+	}
+	h.log.Debug("requesting full message",
+		zap.Uint32("lid", compact.Layer.Uint32()),
+		log.ZShortStringer("sender", compact.Sender),
+		zap.Stringer("peer", peer),
+		zap.Error(err),
+	)
+	if h.exchange == nil {
+		compactFailed.Inc()
+		return fmt.Errorf("%w: exchange of full messages is not enabled", errNotRestored)
+	}
+	req := FullRequest{Layer: compact.Layer, Sender: compact.Sender}
+	if !h.full.start(peer, req) {
+		compactFailed.Inc()
+		return fmt.Errorf("%w: full message request dropped", errNotRestored)
+	}
+	h.eg.Go(func() error {
+		defer h.full.done(req)
+		msg, err := h.requestFull(h.ctx, peer, compact, buf)
+		if err != nil {
+			compactFailed.Inc()
+			// the peer might not be able to serve the message, it is not a reason to reject it
+			h.log.Debug("failed to request full message",
+				zap.Uint32("lid", compact.Layer.Uint32()),
+				log.ZShortStringer("sender", compact.Sender),
+				zap.Stringer("peer", peer),
+				zap.Error(err),
+			)
+			return nil
+		}
+		compactFetched.Inc()
+		if err := h.handle(h.ctx, msg); err != nil {
+			h.log.Debug("full message not accepted",
+				zap.Uint32("lid", msg.Layer.Uint32()),
+				log.ZShortStringer("sender", msg.Sender),
+				zap.Error(err),
+			)
+		}
+		return nil
+	})
+	return fmt.Errorf("%w: full message requested", errNotRestored)
+}
+
+// restore returns the full message if every prefix matches exactly one proposal known in the layer.
+func (h *Hare) restore(compact *CompactMessage) (*Message, error) {
+	known := map[CompactProposalID][]types.ProposalID{}
+	for _, p := range h.proposals.GetForLayer(compact.Layer) {
+		id := compactProposalID(p.ID())
+		known[id] = append(known[id], p.ID())
+	}
+	msg := &Message{
+		Body: Body{
+			Layer:       compact.Layer,
+			IterRound:   IterRound{Round: preround},
+			Value:       Value{Proposals: make([]types.ProposalID, 0, len(compact.Proposals))},
+			Eligibility: compact.Eligibility,
+		},
+		Sender:    compact.Sender,
+		Signature: compact.Signature,
+	}
+	for _, id := range compact.Proposals {
+		matches := known[id]
+		if len(matches) != 1 {
+			return nil, fmt.Errorf("%w: %d proposals with prefix %x", errNotRestored, len(matches), id[:])
+		}
+		msg.Value.Proposals = append(msg.Value.Proposals, matches[0])
+	}
+	return msg, nil
+}
+
+// requestFull requests the full message from the peer. The full message has to match the compact message
+// that was received, its signature is verified the same as for the messages received with explicit sets.
+func (h *Hare) requestFull(ctx context.Context, peer p2p.Peer, compact *CompactMessage, buf []byte) (*Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.config.RoundDuration)
+	defer cancel()
+	req := codec.MustEncode(&FullRequest{Layer: compact.Layer, Sender: compact.Sender})
+	rst, err := h.exchange.Request(ctx, peer, req)
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{}
+	if err := codec.Decode(rst, msg); err != nil {
+		return nil, fmt.Errorf("decode full message: %w", err)
+	}
+	if msg.IterRound != (IterRound{Round: preround}) || !bytes.Equal(codec.MustEncode(compactMessage(msg)), buf) {
+		return nil, errors.New("full message doesn't match compact message")
+	}
+	return msg, nil
+}
+
+// handleFullRequest serves full preround messages that were sent or received in the running sessions.
+func (h *Hare) handleFullRequest(_ context.Context, buf []byte) ([]byte, error) {
+	var req FullRequest
+	if err := codec.Decode(buf, &req); err != nil {
+		return nil, fmt.Errorf("decode request: %w", err)
+	}
+	h.mu.Lock()
+	msg := h.preround[req.Layer][req.Sender]
+	h.mu.Unlock()
+	if msg == nil {
+		return nil, fmt.Errorf("preround message of %s in layer %d is not known",
+			req.Sender.ShortString(), req.Layer)
+	}
+	return msg.ToBytes(), nil
+}
+
+// remember keeps the preround message, so that it can be served to the peers that can't restore
+// the compact message. Messages are kept until the session terminates.
+func (h *Hare) remember(msg *Message) {
+	if msg.IterRound != (IterRound{Round: preround}) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, registered := h.sessions[msg.Layer]; !registered {
+		return
+	}
+	if _, exists := h.preround[msg.Layer]; !exists {
+		h.preround[msg.Layer] = map[types.NodeID]*Message{}
+	}
+	h.preround[msg.Layer][msg.Sender] = msg
+}
@@ -0,0 +1,128 @@
+package hare3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/proposals/store"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+type exchangeFunc func(context.Context, p2p.Peer, []byte) ([]byte, error)
+
+func (f exchangeFunc) Request(ctx context.Context, peer p2p.Peer, req []byte) ([]byte, error) {
+	return f(ctx, peer, req)
+}
+
+func TestCompactMessage(t *testing.T) {
+	const layer = types.LayerID(10)
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	proposals := store.New()
+	for _, id := range []types.ProposalID{{1, 1}, {2, 0, 0, 0, 1}, {2, 0, 0, 0, 2}} {
+		proposal := &types.Proposal{}
+		proposal.Layer = layer
+		proposal.SetID(id)
+		require.NoError(t, proposals.Add(proposal))
+	}
+	cfg := DefaultConfig()
+	cfg.CompactPreround = true
+	h := New(nil, nil, nil, nil, proposals, signing.NewEdVerifier(), nil, nil, nil, WithConfig(cfg))
+	h.sessions[layer] = nil
+
+	sign := func(ids ...types.ProposalID) *Message {
+		msg := &Message{}
+		msg.Layer = layer
+		msg.Value.Proposals = ids
+		msg.Sender = signer.NodeID()
+		msg.Signature = signer.Sign(signing.HARE, msg.ToMetadata().ToBytes())
+		return msg
+	}
+
+	t.Run("restored", func(t *testing.T) {
+		msg := sign(types.ProposalID{1, 1})
+		restored, err := h.restore(compactMessage(msg))
+		require.NoError(t, err)
+		require.Equal(t, msg.ToBytes(), restored.ToBytes())
+	})
+	t.Run("unknown and ambiguous prefixes", func(t *testing.T) {
+		_, err := h.restore(compactMessage(sign(types.ProposalID{3})))
+		require.ErrorIs(t, err, errNotRestored)
+		_, err = h.restore(compactMessage(sign(types.ProposalID{2, 0, 0, 0, 1})))
+		require.ErrorIs(t, err, errNotRestored)
+	})
+	t.Run("exchange", func(t *testing.T) {
+		msg := sign(types.ProposalID{2, 0, 0, 0, 1})
+		buf := codec.MustEncode(compactMessage(msg))
+		compact := compactMessage(msg)
+
+		err := h.CompactHandler(context.Background(), "peer", buf)
+		require.ErrorIs(t, err, errNotRestored)
+		require.ErrorContains(t, err, "not enabled")
+
+		h.exchange = exchangeFunc(func(ctx context.Context, _ p2p.Peer, req []byte) ([]byte, error) {
+			return h.handleFullRequest(ctx, req)
+		})
+		_, err = h.requestFull(context.Background(), "peer", compact, buf)
+		require.ErrorContains(t, err, "is not known")
+
+		h.remember(msg)
+		full, err := h.requestFull(context.Background(), "peer", compact, buf)
+		require.NoError(t, err)
+		require.Equal(t, msg.ToBytes(), full.ToBytes())
+
+		// full message with a different set of proposals is not accepted
+		h.exchange = exchangeFunc(func(context.Context, p2p.Peer, []byte) ([]byte, error) {
+			return sign(types.ProposalID{1, 1}).ToBytes(), nil
+		})
+		_, err = h.requestFull(context.Background(), "peer", compact, buf)
+		require.ErrorContains(t, err, "doesn't match")
+
+		// full message is requested in the background, the compact message is not relayed
+		requested := make(chan struct{})
+		h.exchange = exchangeFunc(func(context.Context, p2p.Peer, []byte) ([]byte, error) {
+			close(requested)
+			return nil, errors.New("test")
+		})
+		err = h.CompactHandler(context.Background(), "peer", buf)
+		require.ErrorIs(t, err, errNotRestored)
+		require.ErrorContains(t, err, "full message requested")
+		<-requested
+		h.full.wait()
+	})
+}
+
+func TestFullRequests(t *testing.T) {
+	r := newFullRequests()
+	req := func(i int) FullRequest {
+		return FullRequest{Layer: types.LayerID(i)}
+	}
+
+	require.True(t, r.start("peer", req(0)))
+	require.False(t, r.start("other", req(0)), "same message is requested already")
+	r.done(req(0))
+	require.True(t, r.start("other", req(0)))
+	r.done(req(0))
+
+	for i := 1; i < fullRequestsBurst; i++ {
+		require.True(t, r.start("peer", req(i)))
+	}
+	require.False(t, r.start("peer", req(fullRequestsBurst)), "peer is over the rate limit")
+	for i := 1; i < fullRequestsBurst; i++ {
+		r.done(req(i))
+	}
+
+	for i := 0; i < maxFullRequests; i++ {
+		require.True(t, r.start(p2p.Peer(fmt.Sprint(i)), req(i)))
+	}
+	require.False(t, r.start("new", req(maxFullRequests)), "too many outstanding requests")
+	r.done(req(0))
+	require.True(t, r.start("new", req(maxFullRequests)))
+}
@@ -5,12 +5,11 @@ import (
 
 	"go.uber.org/zap"
 
-	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/hare3"
 )
 
 type weakCoin interface {
-	Set(types.LayerID, bool) error
+	Set(hare3.WeakCoinOutput) error
 }
 
 func ReportWeakcoin(ctx context.Context, logger *zap.Logger, from <-chan hare3.WeakCoinOutput, to weakCoin) {
@@ -23,7 +22,7 @@ func ReportWeakcoin(ctx context.Context, logger *zap.Logger, from <-chan hare3.W
 			if !open {
 				return
 			}
-			if err := to.Set(out.Layer, out.Coin); err != nil {
+			if err := to.Set(out); err != nil {
 				logger.Error("failed to update weakcoin",
 					zap.Uint32("lid", out.Layer.Uint32()),
 					zap.Error(err),
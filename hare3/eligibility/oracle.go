@@ -19,6 +19,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/activesets"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/ballots"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
@@ -243,8 +244,13 @@ func (o *Oracle) prepareEligibilityCheck(
 		return 0, fixed.Fixed{}, fixed.Fixed{}, true, err
 	}
 
+	key, err := atxs.VRFKey(o.db, id, layer.GetEpoch())
+	if err != nil {
+		return 0, fixed.Fixed{}, fixed.Fixed{}, true, err
+	}
+
 	// validate message
-	if !o.vrfVerifier.Verify(id, msg, vrfSig) {
+	if !o.vrfVerifier.Verify(key, signing.VRFMessage(id, key, msg), vrfSig) {
 		logger.Debug("eligibility: a node did not pass vrf signature verification")
 		return 0, fixed.Fixed{}, fixed.Fixed{}, true, nil
 	}
@@ -399,20 +405,22 @@ func (o *Oracle) Proof(
 	if err != nil {
 		return types.EmptyVrfSignature, fmt.Errorf("get beacon: %w", err)
 	}
-	return GenVRF(ctx, signer, beacon, layer, round), nil
+	return signer.Sign(vrfMessage(beacon, layer, round)), nil
 }
 
-// GenVRF generates vrf for hare eligibility.
+// GenVRF generates vrf for hare eligibility. Signing fails only if the signer is remote.
 func GenVRF(
 	ctx context.Context,
-	signer *signing.VRFSigner,
+	signer signing.Signer,
 	beacon types.Beacon,
 	layer types.LayerID,
 	round uint32,
-) types.VrfSignature {
-	return signer.Sign(
-		codec.MustEncode(&VrfMessage{Type: types.EligibilityHare, Beacon: beacon, Round: round, Layer: layer}),
-	)
+) (types.VrfSignature, error) {
+	return signer.SignVRFContext(ctx, layer.GetEpoch(), vrfMessage(beacon, layer, round))
+}
+
+func vrfMessage(beacon types.Beacon, layer types.LayerID, round uint32) []byte {
+	return codec.MustEncode(&VrfMessage{Type: types.EligibilityHare, Beacon: beacon, Round: round, Layer: layer})
 }
 
 // Returns a map of all active node IDs in the specified layer id.
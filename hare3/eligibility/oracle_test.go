@@ -163,7 +163,7 @@ func (t *testOracle) createActiveSet(
 func (t *testOracle) addAtx(atx *types.VerifiedActivationTx) {
 	t.tb.Helper()
 	require.NoError(t.tb, atxs.Add(t.db, atx))
-	t.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, false)
+	t.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, atx.SmesherID, false)
 }
 
 // create n identities with weights and identifiers 1,2,3,...,n.
@@ -933,7 +933,7 @@ func TestActiveSetMatrix(t *testing.T) {
 			}
 			for _, atx := range tc.atxs {
 				require.NoError(t, atxs.Add(oracle.db, atx))
-				oracle.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, false)
+				oracle.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, atx.SmesherID, false)
 			}
 			if tc.beacon != types.EmptyBeacon {
 				oracle.mBeacon.EXPECT().GetBeacon(target).Return(tc.beacon, nil)
@@ -17,17 +17,20 @@ import (
 	"github.com/spacemeshos/go-spacemesh/atxsdata"
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/layerpatrol"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/metrics"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/p2p/server"
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/beacons"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
 
@@ -44,6 +47,17 @@ type Config struct {
 	// This requires additional computation and should be used for debugging only.
 	LogStats     bool   `mapstructure:"log-stats"`
 	ProtocolName string `mapstructure:"protocolname"`
+	// RecordLayers if positive will persist all messages sent and received in the last RecordLayers layers
+	// to the local database. Traces can be exported with cmd/haretrace.
+	RecordLayers uint32 `mapstructure:"record-layers"`
+	// CompactPreround if true will publish preround messages starting from CompactPreroundLayer
+	// with prefixes of proposal ids instead of full ids, on the protocol with the "/compact" suffix.
+	// Receivers restore the full set from the known proposals, and request the explicit set from
+	// the peer that relayed the message if it can't be restored.
+	// Compact messages are received and explicit sets are served to the peers regardless of this setting,
+	// as well as messages with explicit sets.
+	CompactPreround      bool          `mapstructure:"compact-preround"`
+	CompactPreroundLayer types.LayerID `mapstructure:"compact-preround-layer"`
 }
 
 func (cfg *Config) Validate(zdist time.Duration) error {
@@ -69,9 +83,22 @@ func (cfg *Config) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
 	encoder.AddDuration("round duration", cfg.RoundDuration)
 	encoder.AddBool("log stats", cfg.LogStats)
 	encoder.AddString("p2p protocol", cfg.ProtocolName)
+	encoder.AddUint32("record layers", cfg.RecordLayers)
+	encoder.AddBool("compact preround", cfg.CompactPreround)
+	encoder.AddUint32("compact preround layer", cfg.CompactPreroundLayer.Uint32())
 	return nil
 }
 
+// compactPreround returns true if preround messages in the layer are published in compact encoding.
+func (cfg *Config) compactPreround(layer types.LayerID) bool {
+	return cfg.CompactPreround && layer >= cfg.CompactPreroundLayer
+}
+
+// compactProtocol is the topic for compact preround messages.
+func (cfg *Config) compactProtocol() string {
+	return cfg.ProtocolName + compactSuffix
+}
+
 // roundStart returns expected time for iter/round relative to
 // layer start.
 func (cfg *Config) roundStart(round IterRound) time.Duration {
@@ -107,6 +134,10 @@ type ConsensusOutput struct {
 type WeakCoinOutput struct {
 	Layer types.LayerID
 	Coin  bool
+	// Vrf is the smallest vrf from preround messages, coin is its least significant bit.
+	Vrf types.VrfSignature
+	// Smesher sent the preround message with the smallest vrf.
+	Smesher types.NodeID
 }
 
 type Opt func(*Hare)
@@ -137,6 +168,32 @@ func WithTracer(tracer Tracer) Opt {
 	}
 }
 
+// WithExchange serves full preround messages to the peers that can't restore compact messages,
+// and requests full messages from the peers that relayed compact messages that can't be restored.
+func WithExchange(host server.Host) Opt {
+	return func(hr *Hare) {
+		srv := server.New(host, fullProtocol, hr.handleFullRequest,
+			server.WithQueueSize(1000),
+			server.WithRequestsPerInterval(500, time.Second),
+		)
+		hr.server = srv
+		hr.exchange = srv
+	}
+}
+
+// WithLocalDB persists results of the sessions observed by the node in the local database.
+func WithLocalDB(db sql.Executor) Opt {
+	return func(hr *Hare) {
+		hr.localdb = db
+	}
+}
+
+func WithRecorder(recorder *Recorder) Opt {
+	return func(hr *Hare) {
+		hr.recorder = recorder
+	}
+}
+
 type nodeclock interface {
 	AwaitLayer(types.LayerID) <-chan struct{}
 	CurrentLayer() types.LayerID
@@ -161,8 +218,10 @@ func New(
 		cancel:   cancel,
 		results:  make(chan ConsensusOutput, 32),
 		coins:    make(chan WeakCoinOutput, 32),
-		signers:  map[string]*signing.EdSigner{},
+		signers:  map[string]signing.Signer{},
 		sessions: map[types.LayerID]*protocol{},
+		preround: map[types.LayerID]map[types.NodeID]*Message{},
+		full:     newFullRequests(),
 
 		config:    DefaultConfig(),
 		log:       zap.NewNop(),
@@ -197,8 +256,10 @@ type Hare struct {
 	results  chan ConsensusOutput
 	coins    chan WeakCoinOutput
 	mu       sync.Mutex
-	signers  map[string]*signing.EdSigner
+	signers  map[string]signing.Signer
 	sessions map[types.LayerID]*protocol
+	// preround messages of the running sessions, served to the peers that can't restore compact messages
+	preround map[types.LayerID]map[types.NodeID]*Message
 
 	// options
 	config    Config
@@ -209,6 +270,7 @@ type Hare struct {
 	nodeclock nodeclock
 	pubsub    pubsub.PublishSubsciber
 	db        *sql.Database
+	localdb   sql.Executor
 	atxsdata  *atxsdata.Data
 	proposals *store.Store
 	verifier  *signing.EdVerifier
@@ -216,9 +278,13 @@ type Hare struct {
 	sync      system.SyncStateProvider
 	patrol    *layerpatrol.LayerPatrol
 	tracer    Tracer
+	recorder  *Recorder
+	server    *server.Server
+	exchange  exchange
+	full      *fullRequests
 }
 
-func (h *Hare) Register(sig *signing.EdSigner) {
+func (h *Hare) Register(sig signing.Signer) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.log.Info("registered signing key", log.ZShortStringer("id", sig.NodeID()))
@@ -235,6 +301,12 @@ func (h *Hare) Coins() <-chan WeakCoinOutput {
 
 func (h *Hare) Start() {
 	h.pubsub.Register(h.config.ProtocolName, h.Handler, pubsub.WithValidatorInline(true))
+	h.pubsub.Register(h.config.compactProtocol(), h.CompactHandler, pubsub.WithValidatorInline(true))
+	if h.server != nil {
+		h.eg.Go(func() error {
+			return h.server.Run(h.ctx)
+		})
+	}
 	current := h.nodeclock.CurrentLayer() + 1
 	enabled := max(current, h.config.EnableLayer, types.GetEffectiveGenesis()+1)
 	disabled := types.LayerID(math.MaxUint32)
@@ -272,11 +344,16 @@ func (h *Hare) Handler(ctx context.Context, peer p2p.Peer, buf []byte) error {
 		malformedError.Inc()
 		return fmt.Errorf("%w: decoding error %s", pubsub.ErrValidationReject, err.Error())
 	}
+	return h.handle(ctx, msg)
+}
+
+func (h *Hare) handle(ctx context.Context, msg *Message) error {
 	if err := msg.Validate(); err != nil {
 		malformedError.Inc()
 		return fmt.Errorf("%w: validation %s", pubsub.ErrValidationReject, err.Error())
 	}
 	h.tracer.OnMessageReceived(msg)
+	h.recorder.received(msg)
 	h.mu.Lock()
 	session, registered := h.sessions[msg.Layer]
 	h.mu.Unlock()
@@ -309,25 +386,42 @@ func (h *Hare) Handler(ctx context.Context, peer p2p.Peer, buf []byte) error {
 	h.log.Debug("after on message", log.ZShortStringer("hash", input.msgHash), zap.Bool("gossip", gossip))
 	submitLatency.Observe(time.Since(start).Seconds())
 	if equivocation != nil && !malicious {
-		h.log.Debug("registered equivocation",
-			zap.Uint32("lid", msg.Layer.Uint32()),
-			zap.Stringer("sender", equivocation.Messages[0].SmesherID))
-		proof := equivocation.ToMalfeasanceProof()
-		if err := identities.SetMalicious(
-			h.db, equivocation.Messages[0].SmesherID, codec.MustEncode(proof), time.Now()); err != nil {
-			h.log.Error("failed to save malicious identity", zap.Error(err))
-		}
-		h.atxsdata.SetMalicious(equivocation.Messages[0].SmesherID)
+		h.onEquivocation(ctx, msg.Layer, equivocation)
 	}
 	if !gossip {
 		droppedMessages.Inc()
 		return fmt.Errorf("dropped by graded gossip")
 	}
+	h.remember(msg)
 	expected := h.nodeclock.LayerToTime(msg.Layer).Add(h.config.roundStart(msg.IterRound))
 	metrics.ReportMessageLatency(h.config.ProtocolName, msg.Round.String(), time.Since(expected))
 	return nil
 }
 
+// onEquivocation persists the proof that the identity signed conflicting messages in the same round
+// and gossips it, so that the rest of the network doesn't need to observe the equivocation independently.
+func (h *Hare) onEquivocation(ctx context.Context, layer types.LayerID, equivocation *types.HareProof) {
+	smesher := equivocation.Messages[0].SmesherID
+	h.log.Debug("registered equivocation",
+		zap.Uint32("lid", layer.Uint32()),
+		zap.Stringer("sender", smesher))
+	equivocations.Inc()
+	proof := equivocation.ToMalfeasanceProof()
+	if err := identities.SetMalicious(h.db, smesher, codec.MustEncode(proof), time.Now()); err != nil {
+		h.log.Error("failed to save malicious identity", zap.Error(err))
+	}
+	h.atxsdata.SetMalicious(smesher)
+	gossip := types.MalfeasanceGossip{MalfeasanceProof: *proof}
+	if err := h.pubsub.Publish(ctx, pubsub.MalfeasanceProof, codec.MustEncode(&gossip)); err != nil {
+		equivocationPublishErrors.Inc()
+		h.log.Error("failed to broadcast malfeasance proof",
+			zap.Uint32("lid", layer.Uint32()),
+			zap.Stringer("sender", smesher),
+			zap.Error(err),
+		)
+	}
+}
+
 func (h *Hare) onLayer(layer types.LayerID) {
 	h.proposals.OnLayer(layer)
 	if !h.sync.IsSynced(h.ctx) {
@@ -359,9 +453,12 @@ func (h *Hare) onLayer(layer types.LayerID) {
 
 	sessionStart.Inc()
 	h.tracer.OnStart(layer)
+	h.recorder.start(layer)
 	h.log.Debug("registered layer", zap.Uint32("lid", layer.Uint32()))
 	h.eg.Go(func() error {
-		if err := h.run(s); err != nil {
+		err := h.run(s)
+		h.recorder.stop(layer, err)
+		if err != nil {
 			h.log.Warn("failed",
 				zap.Uint32("lid", layer.Uint32()),
 				zap.Error(err),
@@ -375,8 +472,13 @@ func (h *Hare) onLayer(layer types.LayerID) {
 				zap.Uint32("lid", layer.Uint32()),
 			)
 		}
+		if h.ctx.Err() == nil {
+			h.observeParticipation(s.proto)
+			h.saveResult(s, err == nil)
+		}
 		h.mu.Lock()
 		delete(h.sessions, layer)
+		delete(h.preround, layer)
 		h.mu.Unlock()
 		sessionTerminated.Inc()
 		h.tracer.OnStop(layer)
@@ -384,6 +486,24 @@ func (h *Hare) onLayer(layer types.LayerID) {
 	})
 }
 
+// saveResult persists the outcome of the session if local database is set
+// and reports it to the api subscribers.
+func (h *Hare) saveResult(session *session, terminated bool) {
+	result := hareresults.Result{
+		Layer:         session.lid,
+		Terminated:    terminated,
+		Iterations:    session.proto.iterations(),
+		Participation: session.proto.preroundWeight(),
+		Threshold:     session.proto.threshold(),
+	}
+	if h.localdb != nil {
+		if err := hareresults.Add(h.localdb, result); err != nil {
+			h.log.Error("failed to save hare result", zap.Uint32("lid", session.lid.Uint32()), zap.Error(err))
+		}
+	}
+	events.ReportHareResult(result)
+}
+
 func (h *Hare) run(session *session) error {
 	// oracle may load non-negligible amount of data from disk
 	// we do it before preround starts, so that load can have some slack time
@@ -476,8 +596,19 @@ func (h *Hare) onOutput(session *session, ir IterRound, out output) error {
 		msg.Layer = session.lid
 		msg.Eligibility = *vrf
 		msg.Sender = session.signers[i].NodeID()
-		msg.Signature = session.signers[i].Sign(signing.HARE, msg.ToMetadata().ToBytes())
-		if err := h.pubsub.Publish(h.ctx, h.config.ProtocolName, msg.ToBytes()); err != nil {
+		sig, err := session.signers[i].SignContext(h.ctx, signing.HARE, msg.ToMetadata().ToBytes())
+		if err != nil {
+			h.log.Warn("failed to sign message", zap.Inline(&msg), zap.Error(err))
+			continue
+		}
+		msg.Signature = sig
+		h.recorder.sent(&msg)
+		h.remember(&msg)
+		topic, buf := h.config.ProtocolName, msg.ToBytes()
+		if msg.Round == preround && h.config.compactPreround(msg.Layer) {
+			topic, buf = h.config.compactProtocol(), codec.MustEncode(compactMessage(&msg))
+		}
+		if err := h.pubsub.Publish(h.ctx, topic, buf); err != nil {
 			h.log.Error("failed to publish", zap.Inline(&msg), zap.Error(err))
 		}
 	}
@@ -488,10 +619,11 @@ func (h *Hare) onOutput(session *session, ir IterRound, out output) error {
 		zap.Inline(&out),
 	)
 	if out.coin != nil {
+		vrf, smesher := session.proto.coinSource()
 		select {
 		case <-h.ctx.Done():
 			return h.ctx.Err()
-		case h.coins <- WeakCoinOutput{Layer: session.lid, Coin: *out.coin}:
+		case h.coins <- WeakCoinOutput{Layer: session.lid, Coin: *out.coin, Vrf: vrf, Smesher: smesher}:
 		}
 		sessionCoin.Inc()
 	}
@@ -610,6 +742,6 @@ type session struct {
 	proto   *protocol
 	lid     types.LayerID
 	beacon  types.Beacon
-	signers []*signing.EdSigner
+	signers []signing.Signer
 	vrfs    []*types.HareEligibility
 }
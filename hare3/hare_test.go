@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/hare3/eligibility"
 	"github.com/spacemeshos/go-spacemesh/layerpatrol"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
 	pmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
@@ -29,6 +31,8 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/ballots"
 	"github.com/spacemeshos/go-spacemesh/sql/beacons"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/hareresults"
 	smocks "github.com/spacemeshos/go-spacemesh/system/mocks"
 )
 
@@ -115,6 +119,7 @@ type node struct {
 	atx        *types.VerifiedActivationTx
 	oracle     *eligibility.Oracle
 	db         *sql.Database
+	localdb    *localsql.Database
 	atxsdata   *atxsdata.Data
 	proposals  *store.Store
 
@@ -147,6 +152,7 @@ func (n *node) reuseSigner(signer *signing.EdSigner) *node {
 
 func (n *node) withDb() *node {
 	n.db = sql.InMemory()
+	n.localdb = localsql.InMemory()
 	n.atxsdata = atxsdata.New()
 	n.proposals = store.New()
 	return n
@@ -230,6 +236,7 @@ func (n *node) withHare() *node {
 		WithLogger(logger.Zap()),
 		WithWallclock(n.clock),
 		WithTracer(tracer),
+		WithLocalDB(n.localdb),
 	)
 	n.register(n.signer)
 	return n
@@ -252,7 +259,7 @@ func (n *node) storeAtx(atx *types.VerifiedActivationTx) error {
 	if err := atxs.Add(n.db, atx); err != nil {
 		return err
 	}
-	n.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, false)
+	n.atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, atx.SmesherID, false)
 	return nil
 }
 
@@ -280,6 +287,14 @@ func withSigners(n int) clusterOpt {
 	}
 }
 
+// withCompact publishes preround messages in compact encoding. Nodes that can't restore them request
+// full messages from the node that published them.
+func withCompact() clusterOpt {
+	return func(cluster *lockstepCluster) {
+		cluster.t.cfg.CompactPreround = true
+	}
+}
+
 func newLockstepCluster(t *tester, opts ...clusterOpt) *lockstepCluster {
 	cluster := &lockstepCluster{t: t}
 	cluster.units.min = 10
@@ -442,11 +457,21 @@ func (cl *lockstepCluster) setup() {
 			require.NoError(cl.t, n.storeAtx(other.atx))
 		}
 		n.oracle.UpdateActiveSet(cl.t.genesis.GetEpoch()+1, active)
+		n.hare.exchange = cl
+		peer := p2p.Peer(strconv.Itoa(n.i))
 		n.mpublisher.EXPECT().
 			Publish(gomock.Any(), gomock.Any(), gomock.Any()).
-			Do(func(ctx context.Context, _ string, msg []byte) error {
+			Do(func(ctx context.Context, topic string, msg []byte) error {
 				for _, other := range cl.nodes {
-					other.hare.Handler(ctx, "self", msg)
+					if topic == cl.t.cfg.compactProtocol() {
+						other.hare.CompactHandler(ctx, peer, msg)
+					} else {
+						other.hare.Handler(ctx, peer, msg)
+					}
+				}
+				// full messages are requested in the background, delivery completes when they are fetched
+				for _, other := range cl.nodes {
+					other.hare.full.wait()
 				}
 				return nil
 			}).
@@ -454,6 +479,15 @@ func (cl *lockstepCluster) setup() {
 	}
 }
 
+// Request serves requests for full messages by the node with the index of the peer.
+func (cl *lockstepCluster) Request(ctx context.Context, peer p2p.Peer, req []byte) ([]byte, error) {
+	i, err := strconv.Atoi(string(peer))
+	if err != nil {
+		return nil, err
+	}
+	return cl.nodes[i].hare.handleFullRequest(ctx, req)
+}
+
 func (cl *lockstepCluster) movePreround(layer types.LayerID) {
 	cl.timestamp = cl.t.start.
 		Add(cl.t.layerDuration * time.Duration(layer)).
@@ -618,6 +652,11 @@ func testHare(t *testing.T, active, inactive, equivocators int, opts ...clusterO
 			require.FailNow(t, "no result")
 		}
 		require.Empty(t, n.hare.Running())
+		result, err := hareresults.Get(n.localdb, layer)
+		require.NoError(t, err)
+		require.True(t, result.Terminated)
+		require.EqualValues(t, 2, result.Iterations)
+		require.NotZero(t, result.Participation)
 	}
 }
 
@@ -632,6 +671,10 @@ func TestHare(t *testing.T) {
 	t.Run("equivocators", func(t *testing.T) { testHare(t, 4, 0, 1, withProposals(0.75)) })
 	t.Run("one active multi signers", func(t *testing.T) { testHare(t, 1, 0, 0, withSigners(2)) })
 	t.Run("three active multi signers", func(t *testing.T) { testHare(t, 3, 0, 0, withSigners(10)) })
+	t.Run("compact", func(t *testing.T) { testHare(t, 5, 0, 0, withCompact()) })
+	t.Run("compact with proposals subsets", func(t *testing.T) {
+		testHare(t, 5, 0, 0, withCompact(), withProposals(0.5))
+	})
 }
 
 func TestIterationLimit(t *testing.T) {
@@ -658,6 +701,10 @@ func TestIterationLimit(t *testing.T) {
 	cluster.waitStopped()
 	require.Empty(t, cluster.nodes[0].hare.Running())
 	require.False(t, cluster.nodes[0].patrol.IsHareInCharge(layer))
+	result, err := hareresults.Get(cluster.nodes[0].localdb, layer)
+	require.NoError(t, err)
+	require.False(t, result.Terminated)
+	require.Equal(t, tst.cfg.IterationsLimit, result.Iterations)
 }
 
 func TestConfigMarshal(t *testing.T) {
@@ -683,7 +730,21 @@ func TestHandler(t *testing.T) {
 	require.NoError(t, beacons.Add(n.db, tst.genesis.GetEpoch()+1, tst.beacon))
 	require.NoError(t, n.storeAtx(n.atx))
 	n.oracle.UpdateActiveSet(tst.genesis.GetEpoch()+1, []types.ATXID{n.atx.ID()})
-	n.mpublisher.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	var (
+		proofsMu sync.Mutex
+		proofs   []types.MalfeasanceGossip
+	)
+	n.mpublisher.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, topic string, buf []byte) error {
+			if topic == pubsub.MalfeasanceProof {
+				var proof types.MalfeasanceGossip
+				require.NoError(t, codec.Decode(buf, &proof))
+				proofsMu.Lock()
+				proofs = append(proofs, proof)
+				proofsMu.Unlock()
+			}
+			return nil
+		}).AnyTimes()
 	layer := tst.genesis + 1
 	n.nclock.StartLayer(layer)
 	n.clock.Advance((tst.start.
@@ -755,6 +816,21 @@ func TestHandler(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, malicious)
 
+		proofsMu.Lock()
+		require.Len(t, proofs, 1)
+		proof := proofs[0]
+		proofsMu.Unlock()
+		require.Nil(t, proof.Eligibility)
+		require.Equal(t, types.HareEquivocation, proof.Proof.Type)
+		require.Equal(t, layer, proof.Layer)
+		hp, ok := proof.Proof.Data.(*types.HareProof)
+		require.True(t, ok)
+		require.Equal(t, msg1.ToMalfeasanceProof(), hp.Messages[0])
+		require.Equal(t, msg2.ToMalfeasanceProof(), hp.Messages[1])
+		stored, err := identities.GetMalfeasanceProof(n.db, n.signer.NodeID())
+		require.NoError(t, err)
+		require.Equal(t, proof.MalfeasanceProof.Proof, stored.Proof)
+
 		require.ErrorContains(t,
 			n.hare.Handler(context.Background(), "", codec.MustEncode(msg2)),
 			"dropped by graded",
@@ -909,7 +985,7 @@ func TestProposals(t *testing.T) {
 			hare := New(nil, nil, db, atxsdata, proposals, nil, nil, nil, layerpatrol.New(), WithLogger(logtest.New(t).Zap()))
 			for _, atx := range tc.atxs {
 				require.NoError(t, atxs.Add(db, &atx))
-				atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, false)
+				atxsdata.AddFromHeader(atx.ToHeader(), *atx.VRFNonce, atx.SmesherID, false)
 			}
 			for _, proposal := range tc.proposals {
 				proposals.Add(proposal)
@@ -921,7 +997,7 @@ func TestProposals(t *testing.T) {
 			require.ElementsMatch(t, tc.expect, hare.selectProposals(&session{
 				lid:     tc.layer,
 				beacon:  tc.beacon,
-				signers: []*signing.EdSigner{signer},
+				signers: []signing.Signer{signer},
 			}))
 		})
 	}
@@ -44,12 +44,16 @@ func (lg *legacyOracle) validate(msg *Message) grade {
 }
 
 func (lg *legacyOracle) active(
-	signer *signing.EdSigner,
+	signer signing.Signer,
 	beacon types.Beacon,
 	layer types.LayerID,
 	ir IterRound,
 ) *types.HareEligibility {
-	vrf := eligibility.GenVRF(context.Background(), signer.VRFSigner(), beacon, layer, ir.Absolute())
+	vrf, err := eligibility.GenVRF(context.Background(), signer, beacon, layer, ir.Absolute())
+	if err != nil {
+		lg.log.Warn("failed to generate eligibility proof", zap.Error(err))
+		return nil
+	}
 	committee := int(lg.config.Committee)
 	if ir.Round == propose {
 		committee = int(lg.config.Leaders)
@@ -44,6 +44,29 @@ var (
 		[]string{},
 	).WithLabelValues()
 
+	compactMessages = metrics.NewCounter(
+		"compact_msgs",
+		namespace,
+		"number of compact preround messages by how they were restored",
+		[]string{"restored"},
+	)
+	compactRestored = compactMessages.WithLabelValues("local")
+	compactFetched  = compactMessages.WithLabelValues("fetched")
+	compactFailed   = compactMessages.WithLabelValues("failed")
+
+	equivocations = metrics.NewCounter(
+		"equivocations",
+		namespace,
+		"number of identities that were detected signing conflicting messages",
+		[]string{},
+	).WithLabelValues()
+	equivocationPublishErrors = metrics.NewCounter(
+		"equivocation_publish_errors",
+		namespace,
+		"number of malfeasance proofs for equivocations that failed to be broadcasted",
+		[]string{},
+	).WithLabelValues()
+
 	validationLatency = metrics.NewHistogramWithBuckets(
 		"validation_seconds",
 		namespace,
@@ -63,4 +86,11 @@ var (
 	)
 	proposalsLatency = protocolLatency.WithLabelValues("proposals")
 	activeLatency    = protocolLatency.WithLabelValues("active")
+
+	participationRate = metrics.NewGauge(
+		"participation",
+		namespace,
+		"share of the committee that participated in the preround of the last layer",
+		[]string{},
+	).WithLabelValues()
 )
@@ -0,0 +1,17 @@
+package hare3
+
+import (
+	"go.uber.org/zap"
+)
+
+// observeParticipation reports the share of the expected committee that participated in the preround.
+// Participation is observed locally and differs between nodes, so it is reported for monitoring only
+// and never changes the threshold: honest nodes must agree on the size of the quorum.
+func (h *Hare) observeParticipation(proto *protocol) {
+	if h.config.Committee == 0 {
+		return
+	}
+	rate := float64(proto.preroundWeight()) / float64(h.config.Committee)
+	participationRate.Set(rate)
+	h.log.Debug("observed participation", zap.Float64("rate", rate))
+}
@@ -0,0 +1,26 @@
+package hare3
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestObserveParticipation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Committee = 100
+	hr := New(nil, nil, nil, nil, nil, nil, nil, nil, nil, WithConfig(cfg))
+
+	proto := newProtocol(cfg.Committee/2 + 1)
+	for _, weight := range []uint16{10, 20} {
+		proto.OnInput(&input{Message: &Message{
+			Body:   Body{IterRound: IterRound{Round: preround}, Eligibility: types.HareEligibility{Count: weight}},
+			Sender: types.RandomNodeID(),
+		}})
+	}
+	hr.observeParticipation(proto)
+	require.InDelta(t, 0.3, testutil.ToFloat64(participationRate), 1e-9)
+}
@@ -101,6 +101,7 @@ type protocol struct {
 	IterRound
 	coinout        bool
 	coin           *types.VrfSignature // smallest vrf from preround messages. not a part of paper
+	coinSender     types.NodeID        // sender of the preround message with the smallest vrf
 	initial        []types.ProposalID  // Si
 	result         *types.Hash32       // set after waiting for notify messages. Case 1
 	locked         *types.Hash32       // Li
@@ -126,10 +127,21 @@ func (p *protocol) OnInput(msg *input) (bool, *types.HareProof) {
 	if msg.Round == preround &&
 		(p.coin == nil || (p.coin != nil && msg.Eligibility.Proof.Cmp(p.coin) == -1)) {
 		p.coin = &msg.Eligibility.Proof
+		p.coinSender = msg.Sender
 	}
 	return gossip, equivocation
 }
 
+// coinSource returns the smallest vrf from preround messages and its sender.
+func (p *protocol) coinSource() (types.VrfSignature, types.NodeID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.coin == nil {
+		return types.VrfSignature{}, types.NodeID{}
+	}
+	return *p.coin, p.coinSender
+}
+
 func (p *protocol) thresholdProposals(ir IterRound, grade grade) (*types.Hash32, []types.ProposalID) {
 	for _, ref := range p.gossip.thresholdGossipRef(ir, grade) {
 		valid, exist := p.validProposals[ref]
@@ -287,6 +299,34 @@ func (p *protocol) Next() output {
 	return out
 }
 
+// iterations returns the number of iterations completed by the protocol.
+// Protocol advances to the next iteration after notify round, therefore if it terminated
+// in the iteration N it returns N+1.
+func (p *protocol) iterations() uint8 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Iter
+}
+
+func (p *protocol) threshold() uint16 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.gossip.threshold
+}
+
+// preroundWeight returns the sum of eligibilities of all identities that participated in the preround.
+func (p *protocol) preroundWeight() uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var weight uint32
+	for key, value := range p.gossip.state {
+		if key.IterRound == (IterRound{Round: preround}) {
+			weight += uint32(value.Eligibility.Count)
+		}
+	}
+	return weight
+}
+
 func (p *protocol) Stats() *stats {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -0,0 +1,226 @@
+package hare3
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/haretrace"
+)
+
+// Types of records.
+const (
+	RecordStart    = "start"
+	RecordSent     = "sent"
+	RecordReceived = "received"
+	RecordStop     = "stop"
+)
+
+// Record is a single event of the hare session stored by the Recorder.
+type Record struct {
+	Time          time.Time `json:"time"`
+	Type          string    `json:"type"`
+	Iter          uint8     `json:"iter,omitempty"`
+	Round         string    `json:"round,omitempty"`
+	Sender        string    `json:"sender,omitempty"`
+	Eligibilities uint16    `json:"eligibilities,omitempty"`
+	Proposals     []string  `json:"proposals,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	// Message is the scale encoded message, it can be decoded to replay the session.
+	Message []byte `json:"message,omitempty"`
+	// Error is set on stop if the session failed to reach agreement.
+	Error string `json:"error,omitempty"`
+}
+
+func newMessageRecord(typ string, msg *Message) Record {
+	record := Record{
+		Time:          time.Now(),
+		Type:          typ,
+		Iter:          msg.Iter,
+		Round:         msg.Round.String(),
+		Sender:        msg.Sender.String(),
+		Eligibilities: msg.Eligibility.Count,
+		Message:       codec.MustEncode(msg),
+	}
+	for _, id := range msg.Value.Proposals {
+		record.Proposals = append(record.Proposals, id.String())
+	}
+	if msg.Value.Reference != nil {
+		record.Reference = msg.Value.Reference.String()
+	}
+	return record
+}
+
+// Recorder keeps all messages sent and received by hare in the sessions that are running,
+// and persists them compressed when the session stops. Only the last N layers are retained.
+// Nil Recorder doesn't record anything.
+type Recorder struct {
+	db     sql.Executor
+	layers uint32
+	log    *zap.Logger
+
+	mu       sync.Mutex
+	sessions map[types.LayerID][]Record
+}
+
+// NewRecorder creates a recorder that retains traces for the given number of layers.
+func NewRecorder(db sql.Executor, layers uint32, logger *zap.Logger) *Recorder {
+	return &Recorder{
+		db:       db,
+		layers:   layers,
+		log:      logger,
+		sessions: map[types.LayerID][]Record{},
+	}
+}
+
+func (r *Recorder) start(layer types.LayerID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[layer] = []Record{{Time: time.Now(), Type: RecordStart}}
+}
+
+func (r *Recorder) record(typ string, msg *Message) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, exists := r.sessions[msg.Layer]
+	if !exists {
+		return
+	}
+	r.sessions[msg.Layer] = append(records, newMessageRecord(typ, msg))
+}
+
+func (r *Recorder) sent(msg *Message) {
+	r.record(RecordSent, msg)
+}
+
+func (r *Recorder) received(msg *Message) {
+	r.record(RecordReceived, msg)
+}
+
+func (r *Recorder) stop(layer types.LayerID, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	records, exists := r.sessions[layer]
+	delete(r.sessions, layer)
+	r.mu.Unlock()
+	if !exists {
+		return
+	}
+	last := Record{Time: time.Now(), Type: RecordStop}
+	if err != nil {
+		last.Error = err.Error()
+	}
+	records = append(records, last)
+	trace, err := EncodeTrace(records)
+	if err != nil {
+		r.log.Error("failed to encode hare trace", zap.Uint32("lid", layer.Uint32()), zap.Error(err))
+		return
+	}
+	if err := haretrace.Add(r.db, layer, trace); err != nil {
+		r.log.Error("failed to save hare trace", zap.Uint32("lid", layer.Uint32()), zap.Error(err))
+		return
+	}
+	if layer.Uint32() >= r.layers {
+		if err := haretrace.Prune(r.db, layer-types.LayerID(r.layers)+1); err != nil {
+			r.log.Error("failed to prune hare traces", zap.Uint32("lid", layer.Uint32()), zap.Error(err))
+		}
+	}
+}
+
+// EncodeTrace encodes records as gzip compressed json lines.
+func EncodeTrace(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(w)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeTrace decodes records encoded with EncodeTrace.
+func DecodeTrace(trace []byte) ([]Record, error) {
+	r, err := gzip.NewReader(bytes.NewReader(trace))
+	if err != nil {
+		return nil, fmt.Errorf("open trace: %w", err)
+	}
+	defer r.Close()
+	var (
+		records []Record
+		dec     = json.NewDecoder(bufio.NewReader(r))
+	)
+	for {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("decode trace: %w", err)
+		}
+		records = append(records, record)
+	}
+}
+
+// TraceEquivocations returns proofs of the equivocations among the messages received in a single session.
+// Messages are recorded before they are validated, so messages with invalid signatures are ignored.
+// At most one proof is returned for every identity.
+func TraceEquivocations(verifier *signing.EdVerifier, records []Record) []*types.HareProof {
+	var (
+		first  = map[messageKey]*Message{}
+		proven = map[types.NodeID]struct{}{}
+		proofs []*types.HareProof
+	)
+	for _, record := range records {
+		if record.Type != RecordReceived {
+			continue
+		}
+		msg := &Message{}
+		if err := codec.Decode(record.Message, msg); err != nil {
+			continue
+		}
+		if _, exists := proven[msg.Sender]; exists {
+			continue
+		}
+		if !verifier.Verify(signing.HARE, msg.Sender, msg.ToMetadata().ToBytes(), msg.Signature) {
+			continue
+		}
+		prev, exists := first[msg.key()]
+		if !exists {
+			first[msg.key()] = msg
+			continue
+		}
+		if prev.ToHash() == msg.ToHash() {
+			continue
+		}
+		proven[msg.Sender] = struct{}{}
+		proofs = append(proofs, &types.HareProof{
+			Messages: [2]types.HareProofMsg{prev.ToMalfeasanceProof(), msg.ToMalfeasanceProof()},
+		})
+	}
+	return proofs
+}
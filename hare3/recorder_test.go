@@ -0,0 +1,124 @@
+package hare3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/haretrace"
+)
+
+func TestRecorder(t *testing.T) {
+	db := localsql.InMemory()
+	recorder := NewRecorder(db, 2, zaptest.NewLogger(t))
+
+	ref := types.RandomHash()
+	received := &Message{
+		Body: Body{
+			Layer:       1,
+			IterRound:   IterRound{Round: preround},
+			Value:       Value{Proposals: []types.ProposalID{{1}, {2}}},
+			Eligibility: types.HareEligibility{Count: 3},
+		},
+		Sender: types.RandomNodeID(),
+	}
+	sent := &Message{
+		Body: Body{
+			Layer:     1,
+			IterRound: IterRound{Iter: 1, Round: commit},
+			Value:     Value{Reference: &ref},
+		},
+		Sender: types.RandomNodeID(),
+	}
+	recorder.received(received) // not started yet
+	recorder.start(1)
+	recorder.received(received)
+	recorder.sent(sent)
+	recorder.stop(1, errors.New("hare failed to reach consensus"))
+
+	trace, err := haretrace.Get(db, 1)
+	require.NoError(t, err)
+	records, err := DecodeTrace(trace)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	require.Equal(t, RecordStart, records[0].Type)
+
+	require.Equal(t, RecordReceived, records[1].Type)
+	require.Equal(t, "preround", records[1].Round)
+	require.Equal(t, received.Sender.String(), records[1].Sender)
+	require.EqualValues(t, 3, records[1].Eligibilities)
+	require.Len(t, records[1].Proposals, 2)
+	var decoded Message
+	require.NoError(t, codec.Decode(records[1].Message, &decoded))
+	require.Equal(t, *received, decoded)
+
+	require.Equal(t, RecordSent, records[2].Type)
+	require.EqualValues(t, 1, records[2].Iter)
+	require.Equal(t, "commit", records[2].Round)
+	require.Equal(t, ref.String(), records[2].Reference)
+
+	require.Equal(t, RecordStop, records[3].Type)
+	require.Equal(t, "hare failed to reach consensus", records[3].Error)
+
+	for layer := types.LayerID(2); layer <= 3; layer++ {
+		recorder.start(layer)
+		recorder.stop(layer, nil)
+	}
+	layers, err := haretrace.Layers(db)
+	require.NoError(t, err)
+	require.Equal(t, []types.LayerID{2, 3}, layers)
+}
+
+func TestRecorderNil(t *testing.T) {
+	var recorder *Recorder
+	recorder.start(1)
+	recorder.received(&Message{})
+	recorder.stop(1, nil)
+}
+
+func TestTraceEquivocations(t *testing.T) {
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	other, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	message := func(signer *signing.EdSigner, round Round, proposal types.ProposalID) *Message {
+		msg := &Message{
+			Body: Body{
+				Layer:     1,
+				IterRound: IterRound{Round: round},
+				Value:     Value{Proposals: []types.ProposalID{proposal}},
+			},
+			Sender: signer.NodeID(),
+		}
+		msg.Signature = signer.Sign(signing.HARE, msg.ToMetadata().ToBytes())
+		return msg
+	}
+	forged := message(other, preround, types.ProposalID{3})
+	forged.Signature = types.RandomEdSignature()
+
+	first := message(signer, preround, types.ProposalID{1})
+	second := message(signer, preround, types.ProposalID{2})
+	records := []Record{
+		{Type: RecordStart},
+		newMessageRecord(RecordReceived, forged),
+		newMessageRecord(RecordReceived, message(other, preround, types.ProposalID{1})),
+		newMessageRecord(RecordReceived, first),
+		newMessageRecord(RecordReceived, first),
+		newMessageRecord(RecordReceived, message(signer, propose, types.ProposalID{2})),
+		newMessageRecord(RecordReceived, second),
+		newMessageRecord(RecordReceived, message(signer, preround, types.ProposalID{3})),
+		newMessageRecord(RecordSent, message(other, preround, types.ProposalID{2})),
+		{Type: RecordReceived, Message: []byte{1, 2, 3}},
+		{Type: RecordStop},
+	}
+	proofs := TraceEquivocations(signing.NewEdVerifier(), records)
+	require.Len(t, proofs, 1)
+	require.Equal(t, first.ToMalfeasanceProof(), proofs[0].Messages[0])
+	require.Equal(t, second.ToMalfeasanceProof(), proofs[0].Messages[1])
+}
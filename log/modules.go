@@ -0,0 +1,325 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ConsoleEncoder writes log entries as plain text.
+	ConsoleEncoder = "console"
+	// JSONEncoder writes log entries as json objects.
+	JSONEncoder = "json"
+)
+
+var (
+	// ErrUnknownModule is returned if the module has no loggers.
+	ErrUnknownModule = errors.New("unknown module")
+	// ErrInvalidModuleConfig is returned if the level or the encoder of the module is not valid.
+	ErrInvalidModuleConfig = errors.New("invalid module config")
+)
+
+// ModuleConfig is the level and the encoder of the loggers of the module.
+// If the level is empty the default level is used, if the encoder is empty entries are written
+// by the logger from which the logger of the module was derived.
+type ModuleConfig struct {
+	Level   string `json:"level,omitempty"`
+	Encoder string `json:"encoder,omitempty"`
+}
+
+// ModulesConfig configures the loggers of the modules.
+type ModulesConfig struct {
+	// Modules are configured levels and encoders by the name of the module.
+	Modules map[string]ModuleConfig
+	// File keeps the settings that were changed at runtime, they override configured ones after restart.
+	// Settings are not persisted if File is empty.
+	File string
+}
+
+// ModuleState is the current level and encoder of the module.
+// Encoder is empty if the module uses the encoder of the parent logger. Overridden is true if the settings
+// were changed at runtime.
+type ModuleState struct {
+	Name       string `json:"name"`
+	Level      string `json:"level"`
+	Encoder    string `json:"encoder,omitempty"`
+	Overridden bool   `json:"overridden"`
+}
+
+// Module holds the level and the encoder that are shared by all loggers of the module.
+type Module struct {
+	name    string
+	level   zap.AtomicLevel
+	encoder atomic.Pointer[string]
+}
+
+// Name returns the name of the module.
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Level returns the level of the module, changes to it apply to all loggers of the module.
+func (m *Module) Level() *zap.AtomicLevel {
+	return &m.level
+}
+
+// Encoder returns the name of the encoder used by the module, it is empty if the module
+// uses the encoder of the parent logger.
+func (m *Module) Encoder() string {
+	return *m.encoder.Load()
+}
+
+func (m *Module) apply(cfg ModuleConfig) {
+	level, _ := zapcore.ParseLevel(cfg.Level)
+	m.level.SetLevel(level)
+	m.encoder.Store(&cfg.Encoder)
+}
+
+func (m *Module) state() ModuleState {
+	return ModuleState{Name: m.name, Level: m.level.String(), Encoder: m.Encoder()}
+}
+
+// Modules keeps the loggers of the modules of the node. Level and encoder of each module can be changed
+// at runtime, the change applies to all loggers created for the module.
+type Modules struct {
+	cfg   ModulesConfig
+	hooks []func(zapcore.Entry) error
+
+	mu        sync.Mutex
+	modules   map[string]*Module
+	overrides map[string]ModuleConfig
+}
+
+// NewModules loads the settings changed at runtime from cfg.File and creates the registry of modules.
+// Hooks are called with every entry written by the loggers of modules.
+func NewModules(cfg ModulesConfig, hooks ...func(zapcore.Entry) error) (*Modules, error) {
+	for name, module := range cfg.Modules {
+		if err := validate(module); err != nil {
+			return nil, fmt.Errorf("module %s: %w", name, err)
+		}
+	}
+	ms := &Modules{
+		cfg:       cfg,
+		hooks:     hooks,
+		modules:   map[string]*Module{},
+		overrides: map[string]ModuleConfig{},
+	}
+	if cfg.File == "" {
+		return ms, nil
+	}
+	data, err := os.ReadFile(cfg.File)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ms, nil
+	case err != nil:
+		return nil, fmt.Errorf("read %s: %w", cfg.File, err)
+	}
+	if err := json.Unmarshal(data, &ms.overrides); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", cfg.File, err)
+	}
+	for name, override := range ms.overrides {
+		if err := validate(override); err != nil {
+			return nil, fmt.Errorf("override of module %s: %w", name, err)
+		}
+	}
+	return ms, nil
+}
+
+// Logger returns the logger of the module derived from the logger. Entries are filtered by the level
+// of the module. If the module sets the encoder, entries are written with it to the output of the node,
+// otherwise they are written by the parent logger.
+func (ms *Modules) Logger(logger Log, name string) Log {
+	module := ms.Get(name)
+	lgr := logger.logger.WithOptions(zap.WrapCore(func(parent zapcore.Core) zapcore.Core {
+		return &moduleCore{
+			module:  module,
+			parent:  parent,
+			json:    ms.core(zapcore.NewJSONEncoder(defaultEncoder), module),
+			console: ms.core(zapcore.NewConsoleEncoder(defaultEncoder), module),
+		}
+	}))
+	return Log{logger: lgr, name: logger.name}.WithName(name)
+}
+
+func (ms *Modules) core(enc zapcore.Encoder, module *Module) zapcore.Core {
+	return zapcore.RegisterHooks(zapcore.NewCore(enc, zapcore.AddSync(logwriter), module.level), ms.hooks...)
+}
+
+// Get returns the module with the name, the module is created with the configured settings if it doesn't exist.
+func (ms *Modules) Get(name string) *Module {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if module, exists := ms.modules[name]; exists {
+		return module
+	}
+	module := &Module{name: name, level: zap.NewAtomicLevel()}
+	module.apply(ms.config(name))
+	ms.modules[name] = module
+	return module
+}
+
+// config returns the effective settings of the module. Must be called with the lock held.
+func (ms *Modules) config(name string) ModuleConfig {
+	cfg := ms.cfg.Modules[name]
+	if override, exists := ms.overrides[name]; exists {
+		if override.Level != "" {
+			cfg.Level = override.Level
+		}
+		if override.Encoder != "" {
+			cfg.Encoder = override.Encoder
+		}
+	}
+	if cfg.Level == "" {
+		cfg.Level = DefaultLevel().String()
+	}
+	return cfg
+}
+
+// List returns the state of the modules ordered by name.
+func (ms *Modules) List() []ModuleState {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	rst := make([]ModuleState, 0, len(ms.modules))
+	for name, module := range ms.modules {
+		state := module.state()
+		_, state.Overridden = ms.overrides[name]
+		rst = append(rst, state)
+	}
+	sort.Slice(rst, func(i, j int) bool {
+		return rst[i].Name < rst[j].Name
+	})
+	return rst
+}
+
+// Set changes the level and the encoder of the module, empty values are not changed.
+// The change is persisted if the file is configured.
+func (ms *Modules) Set(name string, cfg ModuleConfig) error {
+	if err := validate(cfg); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	module, exists := ms.modules[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownModule, name)
+	}
+	override := ms.overrides[name]
+	if cfg.Level != "" {
+		override.Level = cfg.Level
+	}
+	if cfg.Encoder != "" {
+		override.Encoder = cfg.Encoder
+	}
+	ms.overrides[name] = override
+	module.apply(ms.config(name))
+	return ms.save()
+}
+
+// Reset restores the configured level and encoder of the module and removes the persisted change.
+func (ms *Modules) Reset(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	module, exists := ms.modules[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownModule, name)
+	}
+	delete(ms.overrides, name)
+	module.apply(ms.config(name))
+	return ms.save()
+}
+
+// save writes the overrides to the file. Must be called with the lock held.
+func (ms *Modules) save() error {
+	if ms.cfg.File == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ms.overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode overrides: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ms.cfg.File), filepath.Base(ms.cfg.File)+".tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), ms.cfg.File); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp.Name(), err)
+	}
+	return nil
+}
+
+func validate(cfg ModuleConfig) error {
+	if cfg.Level != "" {
+		if _, err := zapcore.ParseLevel(cfg.Level); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidModuleConfig, err)
+		}
+	}
+	if cfg.Encoder != "" && cfg.Encoder != ConsoleEncoder && cfg.Encoder != JSONEncoder {
+		return fmt.Errorf("%w: unknown encoder %q, expected %s or %s",
+			ErrInvalidModuleConfig, cfg.Encoder, ConsoleEncoder, JSONEncoder)
+	}
+	return nil
+}
+
+// moduleCore writes entries with the encoder selected for the module.
+type moduleCore struct {
+	module  *Module
+	parent  zapcore.Core
+	json    zapcore.Core
+	console zapcore.Core
+}
+
+func (c *moduleCore) current() zapcore.Core {
+	switch c.module.Encoder() {
+	case JSONEncoder:
+		return c.json
+	case ConsoleEncoder:
+		return c.console
+	default:
+		return c.parent
+	}
+}
+
+func (c *moduleCore) Enabled(level zapcore.Level) bool {
+	return c.module.level.Enabled(level)
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &moduleCore{
+		module:  c.module,
+		parent:  c.parent.With(fields),
+		json:    c.json.With(fields),
+		console: c.console.With(fields),
+	}
+}
+
+func (c *moduleCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return c.current().Check(entry, checked)
+}
+
+func (c *moduleCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(entry, fields)
+}
+
+func (c *moduleCore) Sync() error {
+	return c.current().Sync()
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func captureLogs(tb testing.TB) *bytes.Buffer {
+	var buf bytes.Buffer
+	writer := logwriter
+	logwriter = &buf
+	tb.Cleanup(func() { logwriter = writer })
+	return &buf
+}
+
+func TestModules(t *testing.T) {
+	buf := captureLogs(t)
+	hooked := 0
+	file := filepath.Join(t.TempDir(), "logging.json")
+	hook := func(zapcore.Entry) error {
+		hooked++
+		return nil
+	}
+	cfg := ModulesConfig{
+		Modules: map[string]ModuleConfig{
+			"hare": {Level: "warn"},
+			"sync": {Encoder: JSONEncoder},
+		},
+		File: file,
+	}
+	modules, err := NewModules(cfg, hook)
+	require.NoError(t, err)
+
+	// the parent writes with the console encoder and allows all levels
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(defaultEncoder), zapcore.AddSync(buf), zapcore.DebugLevel)
+	parent := NewFromLog(zap.New(zapcore.RegisterHooks(core, hook))).Named("node")
+	hare := modules.Logger(parent, "hare").WithFields(String("key", "value"))
+	other := modules.Logger(parent, "hare")
+	sync := modules.Logger(parent, "sync")
+
+	hare.Info("dropped")
+	require.Zero(t, buf.Len())
+	hare.Warning("console")
+	require.Contains(t, buf.String(), "node.hare\tconsole\t{\"key\": \"value\"}")
+	require.Equal(t, 1, hooked)
+	buf.Reset()
+
+	sync.Info("json")
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "json", entry["M"])
+	require.Equal(t, "node.sync", entry["N"])
+	require.Equal(t, 2, hooked)
+	buf.Reset()
+
+	// the change applies to all loggers of the module, fields are preserved
+	require.NoError(t, modules.Set("hare", ModuleConfig{Level: "debug", Encoder: JSONEncoder}))
+	hare.Debug("changed")
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "changed", entry["M"])
+	require.Equal(t, "value", entry["key"])
+	buf.Reset()
+	other.Debug("changed")
+	require.NotZero(t, buf.Len())
+	buf.Reset()
+
+	require.Equal(t, []ModuleState{
+		{Name: "hare", Level: "debug", Encoder: JSONEncoder, Overridden: true},
+		{Name: "sync", Level: "info", Encoder: JSONEncoder},
+	}, modules.List())
+
+	require.ErrorIs(t, modules.Set("unknown", ModuleConfig{Level: "debug"}), ErrUnknownModule)
+	require.ErrorIs(t, modules.Set("hare", ModuleConfig{Level: "loud"}), ErrInvalidModuleConfig)
+	require.ErrorIs(t, modules.Set("hare", ModuleConfig{Encoder: "xml"}), ErrInvalidModuleConfig)
+
+	t.Run("persisted", func(t *testing.T) {
+		restarted, err := NewModules(cfg)
+		require.NoError(t, err)
+		module := restarted.Get("hare")
+		require.Equal(t, zapcore.DebugLevel, module.Level().Level())
+		require.Equal(t, JSONEncoder, module.Encoder())
+	})
+
+	require.NoError(t, modules.Reset("hare"))
+	hare.Info("dropped")
+	require.Zero(t, buf.Len())
+	hare.Warning("reset")
+	require.True(t, strings.HasSuffix(buf.String(), "node.hare\treset\t{\"key\": \"value\"}\n"))
+
+	restarted, err := NewModules(cfg)
+	require.NoError(t, err)
+	require.Equal(t, zapcore.WarnLevel, restarted.Get("hare").Level().Level())
+	require.Empty(t, restarted.Get("hare").Encoder())
+}
+
+func TestModulesInvalidConfig(t *testing.T) {
+	_, err := NewModules(ModulesConfig{Modules: map[string]ModuleConfig{"hare": {Encoder: "xml"}}})
+	require.ErrorIs(t, err, ErrInvalidModuleConfig)
+	_, err = NewModules(ModulesConfig{Modules: map[string]ModuleConfig{"hare": {Level: "loud"}}})
+	require.ErrorIs(t, err, ErrInvalidModuleConfig)
+}
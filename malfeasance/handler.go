@@ -7,6 +7,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spacemeshos/post/shared"
 	"github.com/spacemeshos/post/verifying"
 
@@ -29,6 +30,27 @@ var (
 	errWrongHash     = fmt.Errorf("%w: incorrect hash", pubsub.ErrValidationReject)
 )
 
+// ProofValidator validates malfeasance proofs of a single type.
+type ProofValidator interface {
+	// Validate returns the id of the malicious identity if the proof is valid.
+	Validate(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error)
+}
+
+// ProofValidatorFunc is an adapter to use a function as ProofValidator.
+type ProofValidatorFunc func(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error)
+
+func (f ProofValidatorFunc) Validate(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+	return f(ctx, proof)
+}
+
+type proofValidator struct {
+	ProofValidator
+	// label of the type in metrics
+	label string
+	// numProofs counts valid proofs of the type
+	numProofs prometheus.Counter
+}
+
 // Handler processes MalfeasanceProof from gossip and, if deems it valid, propagates it to peers.
 type Handler struct {
 	logger       log.Log
@@ -38,6 +60,8 @@ type Handler struct {
 	edVerifier   SigVerifier
 	tortoise     tortoise
 	postVerifier postVerifier
+
+	validators map[uint8]proofValidator
 }
 
 func NewHandler(
@@ -49,7 +73,7 @@ func NewHandler(
 	tortoise tortoise,
 	postVerifier postVerifier,
 ) *Handler {
-	return &Handler{
+	h := &Handler{
 		logger:       lg,
 		cdb:          cdb,
 		self:         self,
@@ -57,6 +81,52 @@ func NewHandler(
 		edVerifier:   edVerifier,
 		tortoise:     tortoise,
 		postVerifier: postVerifier,
+		validators:   map[uint8]proofValidator{},
+	}
+	h.mustRegister(types.MultipleATXs, multiATXs,
+		func(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			return validateMultipleATXs(ctx, h.logger, h.cdb, h.edVerifier, proof)
+		})
+	h.mustRegister(types.MultipleBallots, multiBallots,
+		func(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			return validateMultipleBallots(ctx, h.logger, h.cdb, h.edVerifier, proof)
+		})
+	h.mustRegister(types.HareEquivocation, hareEquivocate,
+		func(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			return validateHareEquivocation(ctx, h.logger, h.cdb, h.edVerifier, proof)
+		})
+	h.mustRegister(types.InvalidPostIndex, invalidPostIndex,
+		func(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			data, ok := proof.Proof.Data.(*types.InvalidPostIndexProof)
+			if !ok {
+				return types.EmptyNodeID, errors.New("wrong message type for invalid post index")
+			}
+			return validateInvalidPostIndex(ctx, h.logger, h.cdb, h.edVerifier, h.postVerifier, data)
+		})
+	return h
+}
+
+// RegisterValidator registers the validator for proofs of the type, that is registered with
+// types.RegisterMalfeasanceProof. The label is used in metrics.
+// Proofs of the types without validator are rejected.
+func (h *Handler) RegisterValidator(typ uint8, label string, validator ProofValidator) error {
+	if _, exists := types.GetMalfeasanceProofType(typ); !exists {
+		return fmt.Errorf("malfeasance proof type %d is not registered", typ)
+	}
+	if _, exists := h.validators[typ]; exists {
+		return fmt.Errorf("validator for malfeasance proof type %d already registered", typ)
+	}
+	h.validators[typ] = proofValidator{
+		ProofValidator: validator,
+		label:          label,
+		numProofs:      numProofs.WithLabelValues(label),
+	}
+	return nil
+}
+
+func (h *Handler) mustRegister(typ uint8, label string, validator ProofValidatorFunc) {
+	if err := h.RegisterValidator(typ, label, validator); err != nil {
+		panic(err)
 	}
 }
 
@@ -76,7 +146,7 @@ func (h *Handler) HandleSyncedMalfeasanceProof(
 	data []byte,
 ) error {
 	var p types.MalfeasanceProof
-	if err := codec.Decode(data, &p); err != nil {
+	if err := codec.DecodeWithLimit(data, &p, types.MaxMalfeasanceProofSize); err != nil {
 		numMalformed.Inc()
 		h.logger.With().Error("malformed message (sync)", log.Context(ctx), log.Err(err))
 		return errMalformedData
@@ -96,20 +166,20 @@ func (h *Handler) HandleSyncedMalfeasanceProof(
 // HandleMalfeasanceProof is the gossip receiver for MalfeasanceGossip.
 func (h *Handler) HandleMalfeasanceProof(ctx context.Context, peer p2p.Peer, data []byte) error {
 	var p types.MalfeasanceGossip
-	if err := codec.Decode(data, &p); err != nil {
+	if err := codec.DecodeWithLimit(data, &p, types.MaxMalfeasanceProofSize); err != nil {
 		numMalformed.Inc()
 		h.logger.With().Error("malformed message", log.Context(ctx), log.Err(err))
 		return errMalformedData
 	}
 	if peer == h.self {
-		id, err := Validate(ctx, h.logger, h.cdb, h.edVerifier, h.postVerifier, &p)
+		id, err := h.validate(ctx, &p)
 		if err != nil {
 			return err
 		}
 		h.reportMalfeasance(id, &p.MalfeasanceProof)
 		// node saves malfeasance proof eagerly/atomically with the malicious data.
 		// it has validated the proof before saving to db.
-		updateMetrics(p.Proof)
+		h.updateMetrics(p.Proof)
 		return nil
 	}
 	_, err := h.validateAndSave(ctx, &p)
@@ -123,10 +193,47 @@ func (h *Handler) validateAndSave(ctx context.Context, p *types.MalfeasanceGossi
 			pubsub.ErrValidationReject,
 		)
 	}
-	nodeID, err := Validate(ctx, h.logger, h.cdb, h.edVerifier, h.postVerifier, p)
+	nodeID, err := h.validate(ctx, p)
+	if err != nil {
+		return types.EmptyNodeID, err
+	}
+	if err := h.save(ctx, nodeID, &p.MalfeasanceProof); err != nil {
+		if !errors.Is(err, ErrKnownProof) {
+			h.logger.WithContext(ctx).With().Error("failed to save MalfeasanceProof",
+				log.Stringer("smesher", nodeID),
+				log.Inline(p),
+				log.Err(err),
+			)
+		}
+		return types.EmptyNodeID, err
+	}
+	h.reportMalfeasance(nodeID, &p.MalfeasanceProof)
+	h.updateMetrics(p.Proof)
+	h.logger.WithContext(ctx).With().Info("new malfeasance proof",
+		log.Stringer("smesher", nodeID),
+		log.Inline(p),
+	)
+	return nodeID, nil
+}
+
+// SubmitMalfeasanceProof validates and saves the proof that was observed outside of the network,
+// e.g. by a watcher that submitted it through the api. The proof is reported when the node receives
+// its own gossip, so the caller must publish it after it was saved.
+// ErrKnownProof is returned with the id of the identity if it is already known to be malicious.
+func (h *Handler) SubmitMalfeasanceProof(ctx context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+	nodeID, err := h.validate(ctx, &types.MalfeasanceGossip{MalfeasanceProof: *proof})
 	if err != nil {
 		return types.EmptyNodeID, err
 	}
+	if err := h.save(ctx, nodeID, proof); err != nil {
+		return nodeID, err
+	}
+	h.logger.WithContext(ctx).With().Info("submitted malfeasance proof", log.Stringer("smesher", nodeID))
+	return nodeID, nil
+}
+
+// save stores the proof unless the identity is already known to be malicious.
+func (h *Handler) save(ctx context.Context, nodeID types.NodeID, proof *types.MalfeasanceProof) error {
 	if err := h.cdb.WithTx(ctx, func(dbtx *sql.Tx) error {
 		malicious, err := identities.IsMalicious(dbtx, nodeID)
 		if err != nil {
@@ -135,34 +242,25 @@ func (h *Handler) validateAndSave(ctx context.Context, p *types.MalfeasanceGossi
 			h.logger.WithContext(ctx).With().Debug("known malicious identity", log.Stringer("smesher", nodeID))
 			return ErrKnownProof
 		}
-		encoded, err := codec.Encode(&p.MalfeasanceProof)
+		encoded, err := codec.Encode(proof)
 		if err != nil {
 			h.logger.With().Panic("failed to encode MalfeasanceProof", log.Err(err))
 		}
-		if err := identities.SetMalicious(dbtx, nodeID, encoded, time.Now()); err != nil {
+		received := time.Now()
+		if err := identities.SetMalicious(dbtx, nodeID, encoded, received); err != nil {
 			return fmt.Errorf("add malfeasance proof: %w", err)
 		}
+		proof.SetReceived(received)
 		return nil
 	}); err != nil {
-		if !errors.Is(err, ErrKnownProof) {
-			h.logger.WithContext(ctx).With().Error("failed to save MalfeasanceProof",
-				log.Stringer("smesher", nodeID),
-				log.Inline(p),
-				log.Err(err),
-			)
-		}
-		return types.EmptyNodeID, err
+		return err
 	}
-	h.reportMalfeasance(nodeID, &p.MalfeasanceProof)
-	h.cdb.CacheMalfeasanceProof(nodeID, &p.MalfeasanceProof)
-	updateMetrics(p.Proof)
-	h.logger.WithContext(ctx).With().Info("new malfeasance proof",
-		log.Stringer("smesher", nodeID),
-		log.Inline(p),
-	)
-	return nodeID, nil
+	h.cdb.CacheMalfeasanceProof(nodeID, proof)
+	return nil
 }
 
+// Validate validates the malfeasance proof of one of the built-in types
+// and returns the id of the malicious identity.
 func Validate(
 	ctx context.Context,
 	logger log.Log,
@@ -171,27 +269,18 @@ func Validate(
 	postVerifier postVerifier,
 	p *types.MalfeasanceGossip,
 ) (types.NodeID, error) {
-	var (
-		nodeID types.NodeID
-		err    error
-	)
-	switch p.Proof.Type {
-	case types.HareEquivocation:
-		nodeID, err = validateHareEquivocation(ctx, logger, cdb, edVerifier, &p.MalfeasanceProof)
-	case types.MultipleATXs:
-		nodeID, err = validateMultipleATXs(ctx, logger, cdb, edVerifier, &p.MalfeasanceProof)
-	case types.MultipleBallots:
-		nodeID, err = validateMultipleBallots(ctx, logger, cdb, edVerifier, &p.MalfeasanceProof)
-	case types.InvalidPostIndex:
-		proof := p.MalfeasanceProof.Proof.Data.(*types.InvalidPostIndexProof) // guaranteed to work by scale func
-		nodeID, err = validateInvalidPostIndex(ctx, logger, cdb, edVerifier, postVerifier, proof)
-	default:
-		return nodeID, errors.New("unknown malfeasance type")
-	}
+	return NewHandler(cdb, logger, "", nil, edVerifier, nil, postVerifier).validate(ctx, p)
+}
 
+func (h *Handler) validate(ctx context.Context, p *types.MalfeasanceGossip) (types.NodeID, error) {
+	validator, exists := h.validators[p.Proof.Type]
+	if !exists {
+		return types.EmptyNodeID, errors.New("unknown malfeasance type")
+	}
+	nodeID, err := validator.Validate(ctx, &p.MalfeasanceProof)
 	if err != nil {
 		if !errors.Is(err, ErrKnownProof) {
-			logger.WithContext(ctx).With().Warning("failed to validate malfeasance proof",
+			h.logger.WithContext(ctx).With().Warning("failed to validate malfeasance proof",
 				log.Inline(p),
 				log.Err(err),
 			)
@@ -201,16 +290,9 @@ func Validate(
 	return nodeID, nil
 }
 
-func updateMetrics(tp types.Proof) {
-	switch tp.Type {
-	case types.HareEquivocation:
-		numProofsHare.Inc()
-	case types.MultipleATXs:
-		numProofsATX.Inc()
-	case types.MultipleBallots:
-		numProofsBallot.Inc()
-	case types.InvalidPostIndex:
-		numProofsPostIndex.Inc()
+func (h *Handler) updateMetrics(tp types.Proof) {
+	if validator, exists := h.validators[tp.Type]; exists {
+		validator.numProofs.Inc()
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/spacemeshos/go-scale"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/malfeasance"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
@@ -1226,3 +1228,145 @@ func TestHandler_HandleMalfeasanceProof_InvalidPostIndex(t *testing.T) {
 		require.False(t, malicious)
 	})
 }
+
+const (
+	validatedProofType   uint8 = 200
+	unvalidatedProofType uint8 = 201
+)
+
+// claimProof claims that the identity misbehaved, it is valid if claimed by the validator.
+type claimProof struct {
+	Smesher types.NodeID
+}
+
+func (p *claimProof) EncodeScale(enc *scale.Encoder) (int, error) {
+	return p.Smesher.EncodeScale(enc)
+}
+
+func (p *claimProof) DecodeScale(dec *scale.Decoder) (int, error) {
+	return p.Smesher.DecodeScale(dec)
+}
+
+func (p *claimProof) MarshalLogObject(encoder log.ObjectEncoder) error {
+	encoder.AddString("smesher", p.Smesher.String())
+	return nil
+}
+
+func (p *claimProof) Info() string {
+	return "cause: claimed\n"
+}
+
+func init() {
+	for _, typ := range []uint8{validatedProofType, unvalidatedProofType} {
+		types.RegisterMalfeasanceProof(types.MalfeasanceProofType{
+			Type: typ,
+			Name: "claim",
+			New: func(uint8) (types.MalfeasanceProofData, error) {
+				return &claimProof{}, nil
+			},
+		})
+	}
+}
+
+func TestHandler_RegisterValidator(t *testing.T) {
+	db := sql.InMemory()
+	lg := logtest.New(t)
+	ctrl := gomock.NewController(t)
+	trt := malfeasance.NewMocktortoise(ctrl)
+	h := malfeasance.NewHandler(
+		datastore.NewCachedDB(db, lg),
+		lg,
+		"self",
+		[]types.NodeID{types.RandomNodeID()},
+		signing.NewEdVerifier(),
+		trt,
+		malfeasance.NewMockpostVerifier(ctrl),
+	)
+	claimed := types.RandomNodeID()
+	validator := malfeasance.ProofValidatorFunc(
+		func(_ context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			p := proof.Proof.Data.(*claimProof)
+			if p.Smesher != claimed {
+				return types.EmptyNodeID, errors.New("not claimed")
+			}
+			return p.Smesher, nil
+		},
+	)
+	require.ErrorContains(t, h.RegisterValidator(validatedProofType+10, "claim", validator), "not registered")
+	require.NoError(t, h.RegisterValidator(validatedProofType, "claim", validator))
+	require.ErrorContains(t, h.RegisterValidator(validatedProofType, "claim", validator), "already registered")
+	require.ErrorContains(t, h.RegisterValidator(types.MultipleATXs, "atx", validator), "already registered")
+
+	encode := func(typ uint8, smesher types.NodeID) []byte {
+		return codec.MustEncode(&types.MalfeasanceProof{
+			Layer: 11,
+			Proof: types.Proof{Type: typ, Version: 1, Data: &claimProof{Smesher: smesher}},
+		})
+	}
+	other := types.RandomNodeID()
+	err := h.HandleSyncedMalfeasanceProof(
+		context.Background(), types.Hash32(other), "peer", encode(validatedProofType, other))
+	require.ErrorContains(t, err, "not claimed")
+	err = h.HandleSyncedMalfeasanceProof(
+		context.Background(), types.Hash32(claimed), "peer", encode(unvalidatedProofType, claimed))
+	require.ErrorContains(t, err, "unknown malfeasance type")
+
+	trt.EXPECT().OnMalfeasance(claimed)
+	err = h.HandleSyncedMalfeasanceProof(
+		context.Background(), types.Hash32(claimed), "peer", encode(validatedProofType, claimed))
+	require.NoError(t, err)
+	malicious, err := identities.IsMalicious(db, claimed)
+	require.NoError(t, err)
+	require.True(t, malicious)
+}
+
+func TestHandler_SubmitMalfeasanceProof(t *testing.T) {
+	db := sql.InMemory()
+	lg := logtest.New(t)
+	ctrl := gomock.NewController(t)
+	trt := malfeasance.NewMocktortoise(ctrl)
+	h := malfeasance.NewHandler(
+		datastore.NewCachedDB(db, lg),
+		lg,
+		"self",
+		[]types.NodeID{types.RandomNodeID()},
+		signing.NewEdVerifier(),
+		trt,
+		malfeasance.NewMockpostVerifier(ctrl),
+	)
+	claimed := types.RandomNodeID()
+	require.NoError(t, h.RegisterValidator(validatedProofType, "claim",
+		malfeasance.ProofValidatorFunc(func(_ context.Context, proof *types.MalfeasanceProof) (types.NodeID, error) {
+			p := proof.Proof.Data.(*claimProof)
+			if p.Smesher != claimed {
+				return types.EmptyNodeID, errors.New("not claimed")
+			}
+			return p.Smesher, nil
+		}),
+	))
+	proof := func(smesher types.NodeID) *types.MalfeasanceProof {
+		return &types.MalfeasanceProof{
+			Layer: 11,
+			Proof: types.Proof{Type: validatedProofType, Version: 1, Data: &claimProof{Smesher: smesher}},
+		}
+	}
+
+	_, err := h.SubmitMalfeasanceProof(context.Background(), proof(types.RandomNodeID()))
+	require.ErrorContains(t, err, "not claimed")
+
+	// the proof is reported when the node receives its own gossip
+	id, err := h.SubmitMalfeasanceProof(context.Background(), proof(claimed))
+	require.NoError(t, err)
+	require.Equal(t, claimed, id)
+	malicious, err := identities.IsMalicious(db, claimed)
+	require.NoError(t, err)
+	require.True(t, malicious)
+
+	id, err = h.SubmitMalfeasanceProof(context.Background(), proof(claimed))
+	require.ErrorIs(t, err, malfeasance.ErrKnownProof)
+	require.Equal(t, claimed, id)
+
+	trt.EXPECT().OnMalfeasance(claimed)
+	gossip := codec.MustEncode(&types.MalfeasanceGossip{MalfeasanceProof: *proof(claimed)})
+	require.NoError(t, h.HandleMalfeasanceProof(context.Background(), "self", gossip))
+}
@@ -20,6 +20,10 @@ type tortoise interface {
 	OnMalfeasance(types.NodeID)
 }
 
+type layerClock interface {
+	CurrentLayer() types.LayerID
+}
+
 type postVerifier interface {
 	Verify(ctx context.Context, p *shared.Proof, m *shared.ProofMetadata, opts ...verifying.OptionFunc) error
 }
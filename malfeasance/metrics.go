@@ -25,11 +25,6 @@ var (
 		},
 	)
 
-	numProofsATX       = numProofs.WithLabelValues(multiATXs)
-	numProofsBallot    = numProofs.WithLabelValues(multiBallots)
-	numProofsHare      = numProofs.WithLabelValues(hareEquivocate)
-	numProofsPostIndex = numProofs.WithLabelValues(invalidPostIndex)
-
 	numInvalidProofs = metrics.NewCounter(
 		"num_invalid_proofs",
 		namespace,
@@ -39,6 +34,15 @@ var (
 		},
 	)
 
+	numWatchtowerProofs = metrics.NewCounter(
+		"watchtower_proofs",
+		namespace,
+		"number of malfeasance proofs generated by the watchtower",
+		[]string{
+			typeLabel,
+		},
+	)
+
 	numInvalidProofsATX       = numInvalidProofs.WithLabelValues(multiATXs)
 	numInvalidProofsBallot    = numInvalidProofs.WithLabelValues(multiBallots)
 	numInvalidProofsHare      = numInvalidProofs.WithLabelValues(hareEquivocate)
@@ -0,0 +1,294 @@
+package malfeasance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/haretrace"
+)
+
+// WatchtowerConfig configures the watchtower, that cross-checks the ballots, atxs and hare messages stored
+// by the node for equivocations and publishes proofs of them. It doesn't need any identities registered
+// on the node, so it can run on a node that doesn't smesh.
+type WatchtowerConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Interval between the scans of the layers that passed since the previous scan.
+	Interval time.Duration `mapstructure:"interval"`
+	// Window is the number of the most recent layers checked by the first scan after the node starts.
+	Window uint32 `mapstructure:"window"`
+	// LayersPerSecond and Burst bound the rate at which layers are checked.
+	LayersPerSecond float64 `mapstructure:"layers-per-second"`
+	Burst           int     `mapstructure:"burst"`
+	// MaxProofs limits the number of proofs published by a single scan,
+	// the rest of the layers are checked by the next scan.
+	MaxProofs int `mapstructure:"max-proofs"`
+}
+
+// DefaultWatchtowerConfig for the watchtower, it is disabled by default.
+func DefaultWatchtowerConfig() WatchtowerConfig {
+	return WatchtowerConfig{
+		Interval:        time.Minute,
+		Window:          1000,
+		LayersPerSecond: 10,
+		Burst:           10,
+		MaxProofs:       100,
+	}
+}
+
+// Watchtower periodically scans the layers for identities that signed more than one ballot in a layer,
+// more than one atx in an epoch or conflicting hare messages in a round, and publishes proofs of them.
+// Hare messages are checked only if the node records hare traces (see hare3.WithRecorder),
+// since they are not stored otherwise.
+type Watchtower struct {
+	logger    *zap.Logger
+	cfg       WatchtowerConfig
+	db        sql.Executor
+	localDB   sql.Executor
+	clock     layerClock
+	handler   *Handler
+	publisher pubsub.Publisher
+	verifier  *signing.EdVerifier
+	limiter   *rate.Limiter
+
+	// next is the first layer that wasn't checked yet, 0 before the first scan.
+	next types.LayerID
+}
+
+// NewWatchtower creates a watchtower. Proofs are validated and saved by the handler before they are published.
+// Hare traces are read from the localDB, they are not checked if it is nil.
+func NewWatchtower(
+	logger *zap.Logger,
+	cfg WatchtowerConfig,
+	db, localDB sql.Executor,
+	clock layerClock,
+	handler *Handler,
+	publisher pubsub.Publisher,
+	verifier *signing.EdVerifier,
+) *Watchtower {
+	return &Watchtower{
+		logger:    logger,
+		cfg:       cfg,
+		db:        db,
+		localDB:   localDB,
+		clock:     clock,
+		handler:   handler,
+		publisher: publisher,
+		verifier:  verifier,
+		limiter:   rate.NewLimiter(rate.Limit(cfg.LayersPerSecond), cfg.Burst),
+	}
+}
+
+// Run scans the layers every interval until the context is canceled.
+func (w *Watchtower) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.scan(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			w.logger.Warn("watchtower scan failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan checks the layers that weren't checked yet, up to the layer before the previous one,
+// so that hare had a chance to terminate and ballots of the layer to arrive.
+// The atxs of the epoch are checked together with its last layer.
+func (w *Watchtower) scan(ctx context.Context) error {
+	current := w.clock.CurrentLayer()
+	if current.Uint32() < 2 {
+		return nil
+	}
+	last := current.Sub(2)
+	if w.next == 0 {
+		w.next = types.GetEffectiveGenesis().Add(1)
+		if last.Uint32() >= w.cfg.Window && last.Sub(w.cfg.Window).After(w.next) {
+			w.next = last.Sub(w.cfg.Window).Add(1)
+		}
+	}
+	published := 0
+	for ; !w.next.After(last); w.next = w.next.Add(1) {
+		if published >= w.cfg.MaxProofs {
+			w.logger.Info("watchtower published max proofs in a scan, deferring the rest of layers",
+				zap.Uint32("lid", w.next.Uint32()),
+				zap.Int("proofs", published),
+			)
+			return nil
+		}
+		if err := w.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		proofs, err := w.layerProofs(w.next)
+		if err != nil {
+			return err
+		}
+		for _, proof := range proofs {
+			if w.submit(ctx, proof) {
+				published++
+			}
+		}
+	}
+	return nil
+}
+
+// layerProofs returns the proofs of all equivocations that were found in the layer.
+func (w *Watchtower) layerProofs(lid types.LayerID) ([]*types.MalfeasanceProof, error) {
+	proofs, err := w.ballotProofs(lid)
+	if err != nil {
+		return nil, err
+	}
+	hare, err := w.hareProofs(lid)
+	if err != nil {
+		return nil, err
+	}
+	proofs = append(proofs, hare...)
+	if lid.Add(1).FirstInEpoch() {
+		atx, err := w.atxProofs(lid.GetEpoch())
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, atx...)
+	}
+	return proofs, nil
+}
+
+func (w *Watchtower) ballotProofs(lid types.LayerID) ([]*types.MalfeasanceProof, error) {
+	var pairs [][2]types.BallotID
+	if err := ballots.IterateEquivocations(w.db, lid, func(first, second types.BallotID) bool {
+		pairs = append(pairs, [2]types.BallotID{first, second})
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	var proofs []*types.MalfeasanceProof
+	for _, pair := range pairs {
+		var ballotProof types.BallotProof
+		for i, id := range pair {
+			b, err := ballots.Get(w.db, id)
+			if err != nil {
+				return nil, fmt.Errorf("get ballot %s: %w", id, err)
+			}
+			ballotProof.Messages[i] = types.BallotProofMsg{
+				InnerMsg: types.BallotMetadata{
+					Layer:   b.Layer,
+					MsgHash: types.BytesToHash(b.HashInnerBytes()),
+				},
+				Signature: b.Signature,
+				SmesherID: b.SmesherID,
+			}
+		}
+		proofs = append(proofs, &types.MalfeasanceProof{
+			Layer: lid,
+			Proof: types.Proof{
+				Type: types.MultipleBallots,
+				Data: &ballotProof,
+			},
+		})
+	}
+	return proofs, nil
+}
+
+func (w *Watchtower) atxProofs(epoch types.EpochID) ([]*types.MalfeasanceProof, error) {
+	var pairs [][2]types.ATXID
+	if err := atxs.IterateEquivocations(w.db, epoch, func(first, second types.ATXID) bool {
+		pairs = append(pairs, [2]types.ATXID{first, second})
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	var proofs []*types.MalfeasanceProof
+	for _, pair := range pairs {
+		var atxProof types.AtxProof
+		for i, id := range pair {
+			a, err := atxs.Get(w.db, id)
+			if err != nil {
+				return nil, fmt.Errorf("get atx %s: %w", id, err)
+			}
+			atxProof.Messages[i] = types.AtxProofMsg{
+				InnerMsg: types.ATXMetadata{
+					PublishEpoch: a.PublishEpoch,
+					MsgHash:      types.BytesToHash(a.HashInnerBytes()),
+				},
+				SmesherID: a.SmesherID,
+				Signature: a.Signature,
+			}
+		}
+		proofs = append(proofs, &types.MalfeasanceProof{
+			Layer: epoch.FirstLayer(),
+			Proof: types.Proof{
+				Type: types.MultipleATXs,
+				Data: &atxProof,
+			},
+		})
+	}
+	return proofs, nil
+}
+
+func (w *Watchtower) hareProofs(lid types.LayerID) ([]*types.MalfeasanceProof, error) {
+	if w.localDB == nil {
+		return nil, nil
+	}
+	trace, err := haretrace.Get(w.localDB, lid)
+	if errors.Is(err, sql.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	records, err := hare3.DecodeTrace(trace)
+	if err != nil {
+		w.logger.Warn("failed to decode hare trace", zap.Uint32("lid", lid.Uint32()), zap.Error(err))
+		return nil, nil
+	}
+	var proofs []*types.MalfeasanceProof
+	for _, equivocation := range hare3.TraceEquivocations(w.verifier, records) {
+		proofs = append(proofs, equivocation.ToMalfeasanceProof())
+	}
+	return proofs, nil
+}
+
+// submit saves and publishes the proof, it returns false if the proof wasn't saved,
+// e.g. if the identity is already known to be malicious.
+func (w *Watchtower) submit(ctx context.Context, proof *types.MalfeasanceProof) bool {
+	id, err := w.handler.SubmitMalfeasanceProof(ctx, proof)
+	switch {
+	case errors.Is(err, ErrKnownProof):
+		return false
+	case err != nil:
+		w.logger.Warn("watchtower proof rejected",
+			zap.Uint32("lid", proof.Layer.Uint32()),
+			zap.Uint8("type", proof.Proof.Type),
+			zap.Error(err),
+		)
+		return false
+	}
+	numWatchtowerProofs.WithLabelValues(w.handler.validators[proof.Proof.Type].label).Inc()
+	w.logger.Info("watchtower detected equivocation",
+		zap.Stringer("smesher", id),
+		zap.Uint32("lid", proof.Layer.Uint32()),
+		zap.Uint8("type", proof.Proof.Type),
+	)
+	gossip := types.MalfeasanceGossip{MalfeasanceProof: *proof}
+	if err := w.publisher.Publish(ctx, pubsub.MalfeasanceProof, codec.MustEncode(&gossip)); err != nil {
+		w.logger.Error("failed to publish malfeasance proof", zap.Stringer("smesher", id), zap.Error(err))
+	}
+	return true
+}
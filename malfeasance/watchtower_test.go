@@ -0,0 +1,136 @@
+package malfeasance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	pubsubmocks "github.com/spacemeshos/go-spacemesh/p2p/pubsub/mocks"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/haretrace"
+)
+
+func TestWatchtower(t *testing.T) {
+	db := sql.InMemory()
+	localDB := localsql.InMemory()
+	lg := logtest.New(t)
+	ctrl := gomock.NewController(t)
+	handler := NewHandler(
+		datastore.NewCachedDB(db, lg),
+		lg,
+		"self",
+		nil,
+		signing.NewEdVerifier(),
+		NewMocktortoise(ctrl),
+		NewMockpostVerifier(ctrl),
+	)
+	clock := NewMocklayerClock(ctrl)
+	publisher := pubsubmocks.NewMockPublisher(ctrl)
+	cfg := DefaultWatchtowerConfig()
+	cfg.LayersPerSecond = 1000
+	cfg.Burst = 1000
+	cfg.MaxProofs = 1
+	watchtower := NewWatchtower(
+		zaptest.NewLogger(t), cfg, db, localDB, clock, handler, publisher, signing.NewEdVerifier())
+
+	addAtx := func(sig *signing.EdSigner, sequence uint64) {
+		challenge := types.NIPostChallenge{PublishEpoch: 2, Sequence: sequence}
+		coinbase := types.GenerateAddress(sig.PublicKey().Bytes())
+		atx := types.NewActivationTx(challenge, coinbase, nil, 1, nil)
+		require.NoError(t, activation.SignAndFinalizeAtx(sig, atx))
+		atx.SetEffectiveNumUnits(atx.NumUnits)
+		atx.SetReceived(time.Now())
+		vAtx, err := atx.Verify(0, 1)
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, vAtx))
+	}
+	newSigner := func() *signing.EdSigner {
+		sig, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		addAtx(sig, 0)
+		return sig
+	}
+
+	// second atx in epoch 2, it is checked with the last layer of the epoch
+	atxSigner := newSigner()
+	addAtx(atxSigner, 1)
+
+	ballotSigner := newSigner()
+	for i := 0; i < 2; i++ {
+		ballot := types.RandomBallot()
+		ballot.Layer = 7
+		ballot.Signature = ballotSigner.Sign(signing.BALLOT, ballot.SignedBytes())
+		ballot.SmesherID = ballotSigner.NodeID()
+		require.NoError(t, ballot.Initialize())
+		require.NoError(t, ballots.Add(db, ballot))
+	}
+
+	hareSigner := newSigner()
+	var records []hare3.Record
+	for _, proposal := range []types.ProposalID{{1}, {2}} {
+		msg := &hare3.Message{
+			Body: hare3.Body{
+				Layer: 9,
+				Value: hare3.Value{Proposals: []types.ProposalID{proposal}},
+			},
+			Sender: hareSigner.NodeID(),
+		}
+		msg.Signature = hareSigner.Sign(signing.HARE, msg.ToMetadata().ToBytes())
+		records = append(records, hare3.Record{Type: hare3.RecordReceived, Message: codec.MustEncode(msg)})
+	}
+	trace, err := hare3.EncodeTrace(records)
+	require.NoError(t, err)
+	require.NoError(t, haretrace.Add(localDB, 9, trace))
+
+	published := map[types.NodeID]uint8{}
+	publisher.EXPECT().Publish(gomock.Any(), pubsub.MalfeasanceProof, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, msg []byte) error {
+			var gossip types.MalfeasanceGossip
+			require.NoError(t, codec.Decode(msg, &gossip))
+			id, err := handler.validate(context.Background(), &gossip)
+			require.NoError(t, err)
+			published[id] = gossip.Proof.Type
+			return nil
+		}).Times(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// a single proof is published in a scan
+	clock.EXPECT().CurrentLayer().Return(types.LayerID(10)).Times(2)
+	require.NoError(t, watchtower.scan(ctx))
+	require.Equal(t, map[types.NodeID]uint8{ballotSigner.NodeID(): types.MultipleBallots}, published)
+	require.NoError(t, watchtower.scan(ctx))
+	require.Equal(t, types.MultipleATXs, published[atxSigner.NodeID()])
+	// the layer before the current one is not checked
+	require.NotContains(t, published, hareSigner.NodeID())
+
+	clock.EXPECT().CurrentLayer().Return(types.LayerID(11))
+	require.NoError(t, watchtower.scan(ctx))
+	require.Equal(t, types.HareEquivocation, published[hareSigner.NodeID()])
+
+	for _, sig := range []*signing.EdSigner{atxSigner, ballotSigner, hareSigner} {
+		malicious, err := identities.IsMalicious(db, sig.NodeID())
+		require.NoError(t, err)
+		require.True(t, malicious)
+	}
+
+	// proven identities are not published again
+	clock.EXPECT().CurrentLayer().Return(types.LayerID(20))
+	require.NoError(t, watchtower.scan(ctx))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/atxsdata"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -92,22 +93,29 @@ func (e *Executor) ExecuteOptimistic(
 	if err != nil {
 		return nil, err
 	}
-	ineffective, executed, err := e.vm.Apply(vm.ApplyContext{Layer: lid}, executable, crewards)
+	// layer is not marked as applied here, it is done after hare output for the layer is saved.
+	// if node crashes before that, state is reverted to the last applied layer on restart.
+	var b *types.Block
+	ineffective, executed, err := e.vm.Apply(vm.ApplyContext{
+		Layer: lid,
+		Persist: func(dbtx *sql.Tx, executed []types.TransactionWithResult) error {
+			b = &types.Block{
+				InnerBlock: types.InnerBlock{
+					LayerIndex: lid,
+					TickHeight: tickHeight,
+					Rewards:    rewards,
+				},
+			}
+			for _, tx := range executed {
+				b.TxIDs = append(b.TxIDs, tx.ID)
+			}
+			b.Initialize()
+			return persistResults(dbtx, b.ID(), executed)
+		},
+	}, executable, crewards)
 	if err != nil {
 		return nil, fmt.Errorf("apply txs optimistically: %w", err)
 	}
-	b := &types.Block{
-		InnerBlock: types.InnerBlock{
-			LayerIndex: lid,
-			TickHeight: tickHeight,
-			Rewards:    rewards,
-		},
-	}
-	for _, tx := range executed {
-		b.TxIDs = append(b.TxIDs, tx.ID)
-	}
-	b.Initialize()
-	updateResults(b.ID(), executed)
 	if err = e.cs.UpdateCache(ctx, lid, b.ID(), executed, ineffective); err != nil {
 		return nil, fmt.Errorf("update cache: %w", err)
 	}
@@ -129,6 +137,7 @@ func (e *Executor) ExecuteOptimistic(
 }
 
 // Execute transactions in the specified block and update the conservative cache.
+// Updated state, transaction results and the applied block are persisted atomically.
 func (e *Executor) Execute(ctx context.Context, lid types.LayerID, block *types.Block) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -148,18 +157,33 @@ func (e *Executor) Execute(ctx context.Context, lid types.LayerID, block *types.
 	if err != nil {
 		return err
 	}
+	var skipped []types.TransactionWithResult
 	ineffective, executed, err := e.vm.Apply(
-		vm.ApplyContext{Layer: block.LayerIndex},
+		vm.ApplyContext{
+			Layer: block.LayerIndex,
+			Persist: func(dbtx *sql.Tx, executed []types.TransactionWithResult) error {
+				if err := persistResults(dbtx, block.ID(), executed); err != nil {
+					return err
+				}
+				return layers.SetApplied(dbtx, lid, block.ID())
+			},
+			PersistSkipped: func(dbtx *sql.Tx, reasons []types.SkippedTransaction) (err error) {
+				skipped, err = persistSkipped(dbtx, block, executable, reasons)
+				return err
+			},
+		},
 		executable,
 		rewards,
 	)
 	if err != nil {
 		return fmt.Errorf("apply block: %w", err)
 	}
-	updateResults(block.ID(), executed)
 	if err = e.cs.UpdateCache(ctx, block.LayerIndex, block.ID(), executed, ineffective); err != nil {
 		return fmt.Errorf("update cache: %w", err)
 	}
+	for _, rst := range skipped {
+		events.ReportResult(rst)
+	}
 	state, err := e.vm.GetStateRoot()
 	if err != nil {
 		return fmt.Errorf("get state hash: %w", err)
@@ -197,7 +221,12 @@ func (e *Executor) convertRewards(lid types.LayerID, rewards []types.AnyReward)
 
 func (e *Executor) executeEmpty(ctx context.Context, lid types.LayerID) error {
 	start := time.Now()
-	if _, _, err := e.vm.Apply(vm.ApplyContext{Layer: lid}, nil, nil); err != nil {
+	if _, _, err := e.vm.Apply(vm.ApplyContext{
+		Layer: lid,
+		Persist: func(dbtx *sql.Tx, _ []types.TransactionWithResult) error {
+			return layers.SetApplied(dbtx, lid, types.EmptyBlockID)
+		},
+	}, nil, nil); err != nil {
 		return fmt.Errorf("apply empty layer: %w", err)
 	}
 	if err := e.cs.UpdateCache(ctx, lid, types.EmptyBlockID, nil, nil); err != nil {
@@ -230,10 +259,51 @@ func (e *Executor) checkOrder(lid types.LayerID) error {
 	return nil
 }
 
-func updateResults(bid types.BlockID, executed []types.TransactionWithResult) {
+// persistResults sets the block for executed transactions and saves their results.
+func persistResults(dbtx *sql.Tx, bid types.BlockID, executed []types.TransactionWithResult) error {
 	for i := range executed {
 		executed[i].Block = bid
+		if err := transactions.AddResult(dbtx, executed[i].ID, &executed[i].TransactionResult); err != nil {
+			return fmt.Errorf("add result tx=%s nonce=%d: %w", executed[i].ID, executed[i].Nonce, err)
+		}
+	}
+	return nil
+}
+
+// persistSkipped records the transactions of the block that were not executed and returns their results.
+func persistSkipped(
+	dbtx *sql.Tx,
+	block *types.Block,
+	executable []types.Transaction,
+	reasons []types.SkippedTransaction,
+) ([]types.TransactionWithResult, error) {
+	byID := make(map[types.TransactionID]*types.Transaction, len(executable))
+	for i := range executable {
+		byID[executable[i].ID] = &executable[i]
+	}
+	results := make([]types.TransactionWithResult, 0, len(reasons))
+	for _, skipped := range reasons {
+		if err := transactions.AddSkipped(dbtx, skipped.ID, block.LayerIndex, block.ID(), skipped.Reason); err != nil {
+			return nil, err
+		}
+		rst := types.TransactionWithResult{
+			TransactionResult: types.TransactionResult{
+				Status:  types.TransactionSkipped,
+				Message: skipped.Reason.String(),
+				Block:   block.ID(),
+				Layer:   block.LayerIndex,
+			},
+		}
+		rst.ID = skipped.ID
+		if tx, exists := byID[skipped.ID]; exists {
+			rst.Transaction = *tx
+			if tx.TxHeader != nil {
+				rst.Addresses = []types.Address{tx.Principal}
+			}
+		}
+		results = append(results, rst)
 	}
+	return results, nil
 }
 
 // getExecutableTxs retrieves a list of txs filtering transaction that were previously executed.
@@ -23,6 +23,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
 )
 
 func TestMain(m *testing.M) {
@@ -54,6 +55,40 @@ func newTestExecutor(t *testing.T) *testExecutor {
 	return te
 }
 
+// applyAt matches vm.ApplyContext for the layer.
+func applyAt(lid types.LayerID) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		lctx, ok := x.(vm.ApplyContext)
+		return ok && lctx.Layer == lid
+	})
+}
+
+// persist calls Persist from the apply context in the database transaction, as it is done by vm.
+func (t *testExecutor) persist(lctx vm.ApplyContext, executed []types.TransactionWithResult) error {
+	return t.db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
+		return lctx.Persist(dbtx, executed)
+	})
+}
+
+// persistApplied can be used as an action for vm.Apply that doesn't execute any transactions.
+func (t *testExecutor) persistApplied(
+	lctx vm.ApplyContext,
+	_ []types.Transaction,
+	_ []types.CoinbaseReward,
+) ([]types.Transaction, []types.TransactionWithResult, error) {
+	return nil, nil, t.persist(lctx, nil)
+}
+
+// revert undoes results and applied blocks persisted after the layer.
+func (t *testExecutor) revert(lid types.LayerID) {
+	require.NoError(t.tb, t.db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
+		if err := transactions.UndoLayers(dbtx, lid.Add(1)); err != nil {
+			return err
+		}
+		return layers.UnsetAppliedFrom(dbtx, lid.Add(1))
+	}))
+}
+
 func makeResults(lid types.LayerID, txs ...types.Transaction) []types.TransactionWithResult {
 	var results []types.TransactionWithResult
 	for _, tx := range txs {
@@ -85,7 +120,7 @@ func (t *testExecutor) createATX(epoch types.EpochID, cb types.Address) (types.A
 	vAtx, err := atx.Verify(0, 1)
 	require.NoError(t.tb, err)
 	require.NoError(t.tb, atxs.Add(t.db, vAtx))
-	t.atxsdata.AddFromHeader(vAtx.ToHeader(), 0, false)
+	t.atxsdata.AddFromHeader(vAtx.ToHeader(), 0, vAtx.SmesherID, false)
 	return vAtx.ID(), sig.NodeID()
 }
 
@@ -113,11 +148,13 @@ func TestExecutor_Execute(t *testing.T) {
 	})
 
 	t.Run("empty layer", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, nil, nil)
+		te.mvm.EXPECT().Apply(applyAt(lid), nil, nil).DoAndReturn(te.persistApplied)
 		te.mcs.EXPECT().UpdateCache(gomock.Any(), lid, types.EmptyBlockID, nil, nil)
 		te.mvm.EXPECT().GetStateRoot()
 		require.NoError(t, te.exec.Execute(context.Background(), lid, nil))
-		require.NoError(t, layers.SetApplied(te.db, lid, types.EmptyBlockID))
+		applied, err := layers.GetApplied(te.db, lid)
+		require.NoError(t, err)
+		require.Equal(t, types.EmptyBlockID, applied)
 	})
 
 	lid = lid.Add(1)
@@ -125,11 +162,14 @@ func TestExecutor_Execute(t *testing.T) {
 		LayerIndex: lid,
 	})
 	t.Run("empty block", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, []types.Transaction{}, []types.CoinbaseReward{})
+		te.mvm.EXPECT().Apply(applyAt(lid), []types.Transaction{}, []types.CoinbaseReward{}).
+			DoAndReturn(te.persistApplied)
 		te.mcs.EXPECT().UpdateCache(gomock.Any(), lid, block.ID(), nil, nil)
 		te.mvm.EXPECT().GetStateRoot()
 		require.NoError(t, te.exec.Execute(context.Background(), block.LayerIndex, block))
-		require.NoError(t, layers.SetApplied(te.db, lid, block.ID()))
+		applied, err := layers.GetApplied(te.db, lid)
+		require.NoError(t, err)
+		require.Equal(t, block.ID(), applied)
 	})
 
 	lid = lid.Add(1)
@@ -169,7 +209,7 @@ func TestExecutor_Execute(t *testing.T) {
 	})
 	errTest := errors.New("test")
 	t.Run("vm failure", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: block.LayerIndex}, gomock.Any(), expRewards).
+		te.mvm.EXPECT().Apply(applyAt(block.LayerIndex), gomock.Any(), expRewards).
 			DoAndReturn(func(
 				_ vm.ApplyContext,
 				gotTxs []types.Transaction,
@@ -188,9 +228,9 @@ func TestExecutor_Execute(t *testing.T) {
 	var executed []types.TransactionWithResult
 	var ineffective []types.Transaction
 	t.Run("conservative cache failure", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: block.LayerIndex}, gomock.Any(), expRewards).DoAndReturn(
+		te.mvm.EXPECT().Apply(applyAt(block.LayerIndex), gomock.Any(), expRewards).DoAndReturn(
 			func(
-				_ vm.ApplyContext,
+				lctx vm.ApplyContext,
 				gotTxs []types.Transaction,
 				_ []types.CoinbaseReward,
 			) ([]types.Transaction, []types.TransactionWithResult, error) {
@@ -202,7 +242,7 @@ func TestExecutor_Execute(t *testing.T) {
 				// make first tx ineffective
 				ineffective = gotTxs[:1]
 				executed = makeResults(block.LayerIndex, gotTxs[1:]...)
-				return ineffective, executed, nil
+				return ineffective, executed, te.persist(lctx, executed)
 			})
 		te.mcs.EXPECT().UpdateCache(gomock.Any(), block.LayerIndex, block.ID(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(
@@ -220,12 +260,14 @@ func TestExecutor_Execute(t *testing.T) {
 				return errTest
 			})
 		require.ErrorIs(t, te.exec.Execute(context.Background(), block.LayerIndex, block), errTest)
+		// results and applied block are persisted with the state, revert them before executing again
+		te.revert(block.LayerIndex.Sub(1))
 	})
 
 	t.Run("applied block", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: block.LayerIndex}, gomock.Any(), expRewards).DoAndReturn(
+		te.mvm.EXPECT().Apply(applyAt(block.LayerIndex), gomock.Any(), expRewards).DoAndReturn(
 			func(
-				_ vm.ApplyContext,
+				lctx vm.ApplyContext,
 				gotTxs []types.Transaction,
 				_ []types.CoinbaseReward,
 			) ([]types.Transaction, []types.TransactionWithResult, error) {
@@ -237,7 +279,7 @@ func TestExecutor_Execute(t *testing.T) {
 				// make first tx ineffective
 				ineffective = gotTxs[:1]
 				executed = makeResults(block.LayerIndex, gotTxs[1:]...)
-				return ineffective, executed, nil
+				return ineffective, executed, te.persist(lctx, executed)
 			})
 		te.mcs.EXPECT().UpdateCache(gomock.Any(), block.LayerIndex, block.ID(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(
@@ -255,7 +297,15 @@ func TestExecutor_Execute(t *testing.T) {
 			})
 		te.mvm.EXPECT().GetStateRoot()
 		require.NoError(t, te.exec.Execute(context.Background(), block.LayerIndex, block))
-		require.NoError(t, layers.SetApplied(te.db, lid, block.ID()))
+		applied, err := layers.GetApplied(te.db, lid)
+		require.NoError(t, err)
+		require.Equal(t, block.ID(), applied)
+		for _, tr := range executed {
+			mtx, err := transactions.Get(te.db, tr.ID)
+			require.NoError(t, err)
+			require.Equal(t, types.APPLIED, mtx.State)
+			require.Equal(t, block.ID(), mtx.BlockID)
+		}
 	})
 }
 
@@ -316,7 +366,7 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 
 	errTest := errors.New("test")
 	t.Run("vm failure", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, gomock.Any(), expRewards).DoAndReturn(
+		te.mvm.EXPECT().Apply(applyAt(lid), gomock.Any(), expRewards).DoAndReturn(
 			func(
 				_ vm.ApplyContext,
 				gotTxs []types.Transaction,
@@ -337,9 +387,9 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 	var executed []types.TransactionWithResult
 	var ineffective []types.Transaction
 	t.Run("conservative cache failure", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, gomock.Any(), expRewards).DoAndReturn(
+		te.mvm.EXPECT().Apply(applyAt(lid), gomock.Any(), expRewards).DoAndReturn(
 			func(
-				_ vm.ApplyContext,
+				lctx vm.ApplyContext,
 				gotTxs []types.Transaction,
 				_ []types.CoinbaseReward,
 			) ([]types.Transaction, []types.TransactionWithResult, error) {
@@ -351,7 +401,7 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 				// make first tx ineffective
 				ineffective = gotTxs[:1]
 				executed = makeResults(lid, gotTxs[1:]...)
-				return ineffective, executed, nil
+				return ineffective, executed, te.persist(lctx, executed)
 			})
 		expBlock := &types.Block{
 			InnerBlock: types.InnerBlock{
@@ -380,12 +430,13 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 		block, err := te.exec.ExecuteOptimistic(context.Background(), lid, tickHeight, rewards, tids)
 		require.ErrorIs(t, err, errTest)
 		require.Nil(t, block)
+		te.revert(lid.Sub(1))
 	})
 
 	t.Run("executed in situ", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, gomock.Any(), expRewards).DoAndReturn(
+		te.mvm.EXPECT().Apply(applyAt(lid), gomock.Any(), expRewards).DoAndReturn(
 			func(
-				_ vm.ApplyContext,
+				lctx vm.ApplyContext,
 				gotTxs []types.Transaction,
 				_ []types.CoinbaseReward,
 			) ([]types.Transaction, []types.TransactionWithResult, error) {
@@ -397,7 +448,7 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 				// make first tx ineffective
 				ineffective = gotTxs[:1]
 				executed = makeResults(lid, gotTxs[1:]...)
-				return ineffective, executed, nil
+				return ineffective, executed, te.persist(lctx, executed)
 			})
 		expBlock := &types.Block{
 			InnerBlock: types.InnerBlock{
@@ -429,12 +480,21 @@ func TestExecutor_ExecuteOptimistic(t *testing.T) {
 		block, err := te.exec.ExecuteOptimistic(context.Background(), lid, tickHeight, rewards, tids)
 		require.NoError(t, err)
 		require.Equal(t, expBlock, block)
+		for _, tr := range executed {
+			mtx, err := transactions.Get(te.db, tr.ID)
+			require.NoError(t, err)
+			require.Equal(t, types.APPLIED, mtx.State)
+			require.Equal(t, block.ID(), mtx.BlockID)
+		}
+		// block is marked as applied after hare output is saved
+		_, err = layers.GetApplied(te.db, lid)
+		require.ErrorIs(t, err, sql.ErrNotFound)
 		require.NoError(t, layers.SetApplied(te.db, lid, block.ID()))
 	})
 
 	lid = lid.Add(1)
 	t.Run("no txs in block", func(t *testing.T) {
-		te.mvm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, gomock.Len(0), expRewards)
+		te.mvm.EXPECT().Apply(applyAt(lid), gomock.Len(0), expRewards).DoAndReturn(te.persistApplied)
 		expBlock := &types.Block{
 			InnerBlock: types.InnerBlock{
 				LayerIndex: lid,
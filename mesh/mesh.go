@@ -27,6 +27,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
 	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
 
@@ -128,11 +129,15 @@ func (msh *Mesh) recoverFromDB(latest types.LayerID) {
 	}
 	msh.setLatestLayerInState(applied)
 
-	if applied.After(types.GetEffectiveGenesis()) {
-		if err = msh.executor.Revert(context.Background(), applied); err != nil {
-			msh.logger.With().
-				Fatal("failed to load state for layer", msh.LatestLayerInState(), log.Err(err))
-		}
+	// state is persisted together with the applied block, but optimistically executed layers
+	// are marked as applied only after hare output is saved, and reverted layers are unmarked
+	// before state is reverted. if node crashed in between, state is ahead of the last applied layer.
+	if err := layers.UnsetAppliedFrom(msh.cdb, applied.Add(1)); err != nil {
+		msh.logger.With().Fatal("failed to unset layers after the latest applied", applied, log.Err(err))
+	}
+	if err = msh.executor.Revert(context.Background(), applied); err != nil {
+		msh.logger.With().
+			Fatal("failed to load state for layer", msh.LatestLayerInState(), log.Err(err))
 	}
 	msh.logger.With().Info("recovered mesh from disk",
 		log.Stringer("latest", msh.LatestLayer()),
@@ -245,8 +250,8 @@ func (msh *Mesh) setProcessedLayer(layerID types.LayerID) error {
 
 // ensureStateConsistent finds first layer where applied doesn't match
 // the block in consensus, and reverts state before that layer
-// if such layer is found.
-func (msh *Mesh) ensureStateConsistent(ctx context.Context, results []result.Layer) error {
+// if such layer is found. Reverted layers are returned as a reorg.
+func (msh *Mesh) ensureStateConsistent(ctx context.Context, results []result.Layer) (*events.Reorg, error) {
 	changed := types.LayerID(math.MaxUint32)
 	for _, layer := range results {
 		applied, err := layers.GetApplied(msh.cdb, layer.Layer)
@@ -254,7 +259,7 @@ func (msh *Mesh) ensureStateConsistent(ctx context.Context, results []result.Lay
 			continue
 		}
 		if err != nil {
-			return fmt.Errorf("get applied %v: %w", layer.Layer, err)
+			return nil, fmt.Errorf("get applied %v: %w", layer.Layer, err)
 		}
 
 		if bid := layer.FirstValid(); bid != applied {
@@ -268,17 +273,87 @@ func (msh *Mesh) ensureStateConsistent(ctx context.Context, results []result.Lay
 		}
 	}
 	if changed == math.MaxUint32 {
-		return nil
+		return nil, nil
 	}
 	revert := changed.Sub(1)
-	if err := msh.executor.Revert(ctx, revert); err != nil {
-		return fmt.Errorf("revert state to layer %v: %w", revert, err)
+	reorg := &events.Reorg{Revert: revert}
+	for lid := changed; lid <= msh.LatestLayerInState(); lid++ {
+		applied, err := layers.GetApplied(msh.cdb, lid)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return nil, fmt.Errorf("get applied %v: %w", lid, err)
+		}
+		reorg.Layers = append(reorg.Layers, events.ReorgLayer{Layer: lid, Old: applied})
 	}
+	reorg.Depth = uint32(len(reorg.Layers))
+	// layers are unset before reverting the state, so that if node crashes in between
+	// state will be reverted to the last applied layer on restart.
 	if err := layers.UnsetAppliedFrom(msh.cdb, revert.Add(1)); err != nil {
-		return fmt.Errorf("unset applied layer %v: %w", revert.Add(1), err)
+		return nil, fmt.Errorf("unset applied layer %v: %w", revert.Add(1), err)
+	}
+	if err := msh.executor.Revert(ctx, revert); err != nil {
+		return nil, fmt.Errorf("revert state to layer %v: %w", revert, err)
 	}
 	msh.setLatestLayerInState(revert)
-	return nil
+	return reorg, nil
+}
+
+// reportReorg completes the reorg with blocks that were applied instead of reverted blocks,
+// and with the status of transactions from the replaced blocks.
+func (msh *Mesh) reportReorg(ctx context.Context, reorg *events.Reorg) {
+	var (
+		tids []types.TransactionID
+		seen = map[types.TransactionID]struct{}{}
+	)
+	for i := range reorg.Layers {
+		layer := &reorg.Layers[i]
+		applied, err := layers.GetApplied(msh.cdb, layer.Layer)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+		case err != nil:
+			msh.logger.With().Error("failed to get applied block", log.Context(ctx), layer.Layer, log.Err(err))
+		default:
+			layer.New = applied
+			layer.Applied = true
+		}
+		if layer.Applied && layer.New == layer.Old {
+			continue
+		}
+		for _, bid := range []types.BlockID{layer.Old, layer.New} {
+			if bid.IsEmpty() {
+				continue
+			}
+			block, err := blocks.Get(msh.cdb, bid)
+			if err != nil {
+				msh.logger.With().Error("failed to get block", log.Context(ctx), bid, log.Err(err))
+				continue
+			}
+			for _, tid := range block.TxIDs {
+				if _, exists := seen[tid]; !exists {
+					seen[tid] = struct{}{}
+					tids = append(tids, tid)
+				}
+			}
+		}
+	}
+	for _, tid := range tids {
+		mtx, err := transactions.Get(msh.cdb, tid)
+		if err != nil {
+			msh.logger.With().Error("failed to get transaction", log.Context(ctx), tid, log.Err(err))
+			continue
+		}
+		affected := events.ReorgTransaction{ID: tid, State: mtx.State}
+		if mtx.State == types.APPLIED {
+			affected.Layer = mtx.LayerID
+		}
+		reorg.Transactions = append(reorg.Transactions, affected)
+	}
+	msh.logger.With().Info("reverted applied layers",
+		log.Context(ctx),
+		log.Stringer("revert", reorg.Revert),
+		log.Uint32("depth", reorg.Depth),
+		log.Int("affected_txs", len(reorg.Transactions)),
+	)
+	events.ReportReorg(*reorg)
 }
 
 // ProcessLayer reads latest consensus results and ensures that vm state
@@ -319,13 +394,15 @@ func (msh *Mesh) ProcessLayer(ctx context.Context, lid types.LayerID) error {
 		}
 	}
 
-	if err := msh.ensureStateConsistent(ctx, applicable); err != nil {
+	reorg, err := msh.ensureStateConsistent(ctx, applicable)
+	if err != nil {
 		return err
 	}
-	if err := msh.applyResults(ctx, applicable); err != nil {
-		return err
+	err = msh.applyResults(ctx, applicable)
+	if reorg != nil {
+		msh.reportReorg(ctx, reorg)
 	}
-	return nil
+	return err
 }
 
 func filterMissing(results []result.Layer, next types.LayerID) ([]result.Layer, []types.BlockID) {
@@ -14,6 +14,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/common/types/result"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
 	"github.com/spacemeshos/go-spacemesh/hash"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
@@ -269,6 +270,38 @@ func TestMesh_WakeUp(t *testing.T) {
 	require.Equal(t, latestState, gotLS)
 }
 
+func TestMesh_WakeUpRevertsUnappliedState(t *testing.T) {
+	tm := createTestMesh(t)
+	genesis := types.GetEffectiveGenesis()
+	latest := genesis.Add(2)
+	b := types.NewExistingBallot(
+		types.BallotID{1, 2, 3},
+		types.EmptyEdSignature,
+		types.EmptyNodeID,
+		latest,
+	)
+	require.NoError(t, ballots.Add(tm.cdb, &b))
+	// layer was executed optimistically, but node crashed before it was marked as applied
+	require.NoError(t, layers.UpdateStateHash(tm.cdb, genesis.Add(1), types.RandomHash()))
+
+	tm.mockVM.EXPECT().Revert(genesis)
+	tm.mockState.EXPECT().RevertCache(genesis)
+	tm.mockVM.EXPECT().GetStateRoot()
+	msh, err := NewMesh(
+		tm.db,
+		tm.atxsdata,
+		tm.mockClock,
+		tm.mockTortoise,
+		tm.executor,
+		tm.mockState,
+		logtest.New(t),
+	)
+	require.NoError(t, err)
+	require.Equal(t, genesis, msh.LatestLayerInState())
+	_, err = layers.GetStateHash(tm.cdb, genesis.Add(1))
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
+
 func TestMesh_GetLayerVerified(t *testing.T) {
 	tm := createTestMesh(t)
 	id := types.GetEffectiveGenesis().Add(1)
@@ -815,6 +848,68 @@ func TestProcessLayer(t *testing.T) {
 	}
 }
 
+func TestProcessLayer_Reorg(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	sub, err := events.Subscribe[events.Reorg]()
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	tm := createTestMesh(t)
+	tm.mockTortoise.EXPECT().TallyVotes(gomock.Any(), gomock.Any()).AnyTimes()
+	tm.mockTortoise.EXPECT().OnApplied(gomock.Any(), gomock.Any()).AnyTimes()
+	tm.mockVM.EXPECT().GetStateRoot().AnyTimes()
+	tm.mockVM.EXPECT().Apply(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+	tm.mockState.EXPECT().UpdateCache(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+	start := types.GetEffectiveGenesis().Add(1)
+	hare := types.NewExistingBlock(idg("1"), types.InnerBlock{
+		LayerIndex: start,
+		TxIDs:      CreateAndSaveTxs(t, tm.cdb, 2),
+	})
+	valid := types.NewExistingBlock(idg("2"), types.InnerBlock{
+		LayerIndex: start,
+		TxIDs:      CreateAndSaveTxs(t, tm.cdb, 1),
+	})
+	require.NoError(t, blocks.Add(tm.cdb, hare))
+	require.NoError(t, blocks.Add(tm.cdb, valid))
+
+	tm.mockTortoise.EXPECT().Updates().Return(rlayers(
+		fixture.RLayerNonFinal(start, rblock(hare.ID(), fixture.Hare(), fixture.Data())),
+	))
+	require.NoError(t, tm.ProcessLayer(context.Background(), start))
+	select {
+	case <-sub.Out():
+		require.FailNow(t, "reorg reported without reverting state")
+	default:
+	}
+
+	tm.mockVM.EXPECT().Revert(start - 1)
+	tm.mockState.EXPECT().RevertCache(start - 1)
+	tm.mockTortoise.EXPECT().Updates().Return(rlayers(
+		rlayer(start,
+			rblock(hare.ID(), fixture.Hare(), fixture.Data(), fixture.Invalid()),
+			rblock(valid.ID(), fixture.Valid(), fixture.Data())),
+	))
+	require.NoError(t, tm.ProcessLayer(context.Background(), start+1))
+
+	var reorg events.Reorg
+	select {
+	case reorg = <-sub.Out():
+	case <-time.After(time.Second):
+		require.FailNow(t, "reorg wasn't reported")
+	}
+	require.Equal(t, start-1, reorg.Revert)
+	require.EqualValues(t, 1, reorg.Depth)
+	require.Equal(t, []events.ReorgLayer{
+		{Layer: start, Old: hare.ID(), New: valid.ID(), Applied: true},
+	}, reorg.Layers)
+	require.Len(t, reorg.Transactions, len(hare.TxIDs)+len(valid.TxIDs))
+	for i, tid := range append(hare.TxIDs, valid.TxIDs...) {
+		require.Equal(t, tid, reorg.Transactions[i].ID)
+	}
+}
+
 func ensuresDatabaseConsistent(t *testing.T, db sql.Executor, results []result.Layer) {
 	for _, layer := range results {
 		for _, rst := range layer.Blocks {
@@ -0,0 +1,215 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
+)
+
+// ActiveSetTracker maintains the candidates for the active sets of the current and the next epoch
+// as atxs are received, together with their weight totals. Candidates are persisted in the local database,
+// so that the active set is generated from them instead of loading all atx headers at the start of the epoch.
+type ActiveSetTracker struct {
+	logger       log.Log
+	cdb          *datastore.CachedDB
+	localdb      sql.Executor
+	clock        layerClock
+	networkDelay time.Duration
+
+	ready atomic.Bool
+
+	mu        sync.Mutex
+	summaries map[types.EpochID]activecandidates.Summary
+}
+
+// NewActiveSetTracker creates a tracker. Atxs received before the epoch start minus 4 network delays
+// are counted in the projected weight of the epoch, the same as when the active set is generated.
+func NewActiveSetTracker(
+	logger log.Log,
+	cdb *datastore.CachedDB,
+	localdb sql.Executor,
+	clock layerClock,
+	networkDelay time.Duration,
+) *ActiveSetTracker {
+	return &ActiveSetTracker{
+		logger:       logger,
+		cdb:          cdb,
+		localdb:      localdb,
+		clock:        clock,
+		networkDelay: networkDelay,
+		summaries:    map[types.EpochID]activecandidates.Summary{},
+	}
+}
+
+// Deadline returns the time before which the atx has to be received to be a good candidate
+// for the active set of the epoch.
+func (t *ActiveSetTracker) Deadline(epoch types.EpochID) time.Time {
+	return t.clock.LayerToTime(epoch.FirstLayer()).Add(-4 * t.networkDelay)
+}
+
+// OnAtx adds the atx to the candidates of its target epoch.
+func (t *ActiveSetTracker) OnAtx(header *types.ActivationTxHeader) {
+	target := header.TargetEpoch()
+	if target < t.clock.CurrentLayer().GetEpoch() {
+		return
+	}
+	if err := t.add(target, &activecandidates.Candidate{
+		ID:       header.ID,
+		Node:     header.NodeID,
+		Weight:   header.GetWeight(),
+		Received: header.Received,
+	}); err != nil {
+		t.logger.With().Warning("failed to track active set candidate", header.ID, log.Err(err))
+	}
+}
+
+func (t *ActiveSetTracker) add(epoch types.EpochID, candidate *activecandidates.Candidate) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	added, err := activecandidates.Add(t.localdb, epoch, candidate)
+	if err != nil || !added {
+		return err
+	}
+	summary := t.summaries[epoch]
+	if candidate.Received.Before(t.Deadline(epoch)) {
+		summary.ATXs++
+		summary.Weight += candidate.Weight
+	} else {
+		summary.LateATXs++
+		summary.LateWeight += candidate.Weight
+	}
+	t.summaries[epoch] = summary
+	activeSetProjectedWeight.WithLabelValues(epoch.String()).Set(float64(summary.Weight))
+	return nil
+}
+
+// Ready returns true when the candidates include all atxs stored before the tracker was started.
+func (t *ActiveSetTracker) Ready() bool {
+	return t.ready.Load()
+}
+
+// Projection returns the totals of the candidates for the active set of the epoch.
+func (t *ActiveSetTracker) Projection(epoch types.EpochID) (activecandidates.Summary, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	summary, exists := t.summaries[epoch]
+	return summary, exists
+}
+
+// Candidate returns the candidate of the node for the active set of the epoch.
+func (t *ActiveSetTracker) Candidate(epoch types.EpochID, node types.NodeID) (*activecandidates.Candidate, error) {
+	return activecandidates.GetByNode(t.localdb, epoch, node)
+}
+
+// Iterate calls fn for every candidate for the active set of the epoch until it returns an error.
+func (t *ActiveSetTracker) Iterate(epoch types.EpochID, fn func(*activecandidates.Candidate) error) error {
+	var ierr error
+	if err := activecandidates.IterateEpoch(t.localdb, epoch, func(candidate *activecandidates.Candidate) bool {
+		ierr = fn(candidate)
+		return ierr == nil
+	}); err != nil {
+		return err
+	}
+	return ierr
+}
+
+// Run adds atxs that were stored while the node was down to the candidates of the current and the next epoch,
+// and deletes the candidates of the past epochs at the start of every epoch.
+func (t *ActiveSetTracker) Run(ctx context.Context) error {
+	current := t.clock.CurrentLayer().GetEpoch()
+	for _, epoch := range []types.EpochID{current, current + 1} {
+		if err := t.warmup(ctx, epoch); err != nil {
+			return err
+		}
+	}
+	t.ready.Store(true)
+	if err := t.prune(current); err != nil {
+		return err
+	}
+	for epoch := current + 1; ; epoch++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.clock.AwaitLayer(epoch.FirstLayer()):
+		}
+		if err := t.prune(epoch); err != nil {
+			t.logger.With().Warning("failed to prune active set candidates", epoch, log.Err(err))
+		}
+	}
+}
+
+func (t *ActiveSetTracker) warmup(ctx context.Context, epoch types.EpochID) error {
+	if epoch == 0 {
+		return nil
+	}
+	known := map[types.ATXID]struct{}{}
+	if err := activecandidates.IterateEpoch(t.localdb, epoch, func(candidate *activecandidates.Candidate) bool {
+		known[candidate.ID] = struct{}{}
+		return true
+	}); err != nil {
+		return err
+	}
+	added := 0
+	var ierr error
+	if err := atxs.IterateHeadersByEpoch(t.cdb, epoch-1, func(header *types.ActivationTxHeader) bool {
+		if _, exists := known[header.ID]; exists {
+			return true
+		}
+		if ierr = ctx.Err(); ierr != nil {
+			return false
+		}
+		if ierr = t.add(epoch, &activecandidates.Candidate{
+			ID:       header.ID,
+			Node:     header.NodeID,
+			Weight:   header.GetWeight(),
+			Received: header.Received,
+		}); ierr != nil {
+			return false
+		}
+		added++
+		return true
+	}); err != nil {
+		return fmt.Errorf("atxs targeting epoch %d: %w", epoch, err)
+	}
+	if ierr != nil {
+		return ierr
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	summary, err := activecandidates.Summarize(t.localdb, epoch, t.Deadline(epoch))
+	if err != nil {
+		return err
+	}
+	t.summaries[epoch] = summary
+	activeSetProjectedWeight.WithLabelValues(epoch.String()).Set(float64(summary.Weight))
+	t.logger.With().Info("active set candidates loaded",
+		epoch,
+		log.Int("atxs", summary.ATXs),
+		log.Uint64("weight", summary.Weight),
+		log.Int("late atxs", summary.LateATXs),
+		log.Int("added", added),
+	)
+	return nil
+}
+
+func (t *ActiveSetTracker) prune(epoch types.EpochID) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for e := range t.summaries {
+		if e < epoch {
+			delete(t.summaries, e)
+			activeSetProjectedWeight.DeleteLabelValues(e.String())
+		}
+	}
+	return activecandidates.DeleteBefore(t.localdb, epoch)
+}
@@ -0,0 +1,95 @@
+package miner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/miner/mocks"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
+)
+
+func TestActiveSetTracker(t *testing.T) {
+	var (
+		ctrl    = gomock.NewController(t)
+		clock   = mocks.NewMocklayerClock(ctrl)
+		cdb     = datastore.NewCachedDB(sql.InMemory(), logtest.New(t))
+		localdb = localsql.InMemory()
+		start   = time.Unix(1000, 0)
+		delay   = time.Second
+		current = types.EpochID(3)
+	)
+	clock.EXPECT().LayerToTime(gomock.Any()).Return(start).AnyTimes()
+	clock.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	awaited := make(chan struct{})
+	clock.EXPECT().AwaitLayer((current + 1).FirstLayer()).Return(awaited)
+	clock.EXPECT().AwaitLayer((current + 2).FirstLayer()).Return(make(chan struct{}))
+
+	stored := gatx(types.ATXID{1}, current, types.NodeID{1}, 2, genAtxWithReceived(start.Add(-time.Hour)))
+	require.NoError(t, atxs.Add(cdb, stored))
+	late := gatx(types.ATXID{2}, current, types.NodeID{2}, 3, genAtxWithReceived(start.Add(-delay)))
+	require.NoError(t, atxs.Add(cdb, late))
+	// candidates of the past epochs are deleted
+	_, err := activecandidates.Add(localdb, current-1, &activecandidates.Candidate{ID: types.ATXID{9}})
+	require.NoError(t, err)
+
+	tracker := NewActiveSetTracker(logtest.New(t), cdb, localdb, clock, delay)
+	require.False(t, tracker.Ready())
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- tracker.Run(ctx) }()
+	require.Eventually(t, tracker.Ready, time.Second, 10*time.Millisecond)
+
+	projection, exists := tracker.Projection(current + 1)
+	require.True(t, exists)
+	require.Equal(t, activecandidates.Summary{
+		ATXs:       1,
+		Weight:     stored.GetWeight(),
+		LateATXs:   1,
+		LateWeight: late.GetWeight(),
+	}, projection)
+	_, err = activecandidates.GetByNode(localdb, current-1, types.EmptyNodeID)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	received := gatx(types.ATXID{3}, current, types.NodeID{3}, 4, genAtxWithReceived(start.Add(-time.Minute)))
+	tracker.OnAtx(received.ToHeader())
+	tracker.OnAtx(received.ToHeader())
+	// atxs that target past epochs are ignored
+	tracker.OnAtx(gatx(types.ATXID{4}, current-2, types.NodeID{4}, 1).ToHeader())
+	projection, _ = tracker.Projection(current + 1)
+	require.Equal(t, 2, projection.ATXs)
+	require.Equal(t, stored.GetWeight()+received.GetWeight(), projection.Weight)
+	_, exists = tracker.Projection(current - 1)
+	require.False(t, exists)
+
+	candidate, err := tracker.Candidate(current+1, types.NodeID{3})
+	require.NoError(t, err)
+	require.Equal(t, received.ID(), candidate.ID)
+	require.Equal(t, received.GetWeight(), candidate.Weight)
+
+	var ids []types.ATXID
+	require.NoError(t, tracker.Iterate(current+1, func(candidate *activecandidates.Candidate) error {
+		ids = append(ids, candidate.ID)
+		return nil
+	}))
+	require.ElementsMatch(t, []types.ATXID{{1}, {2}, {3}}, ids)
+
+	close(awaited)
+	require.Eventually(t, func() bool {
+		_, exists := tracker.Projection(current)
+		return !exists
+	}, time.Second, 10*time.Millisecond)
+	_, exists = tracker.Projection(current + 1)
+	require.True(t, exists)
+	cancel()
+	require.NoError(t, <-errc)
+}
@@ -0,0 +1,155 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/spacemeshos/economics/rewards"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/miner/minweight"
+	"github.com/spacemeshos/go-spacemesh/proposals/util"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+var (
+	// ErrNotReady is returned if the candidates for the active set are not loaded yet.
+	ErrNotReady = errors.New("active set candidates are not loaded yet")
+	// ErrEpochNotTracked is returned if the active set of the epoch is not tracked.
+	ErrEpochNotTracked = errors.New("epoch is not tracked")
+)
+
+// EpochEstimate is the estimate of the proposal eligibilities and rewards of the smeshers in the epoch,
+// based on the projected weight of the active set.
+type EpochEstimate struct {
+	Epoch types.EpochID
+	// Weight is the projected weight of the active set, from the atxs received before the deadline.
+	Weight uint64
+	// MinWeight is the minimal active set weight used to compute eligibilities in the epoch.
+	MinWeight uint64
+	// Subsidy is the total subsidy issued in the layers of the epoch.
+	Subsidy uint64
+	// Smeshers are listed in the order of the request.
+	Smeshers []SmesherEstimate
+}
+
+// SmesherEstimate is the expected number of proposal eligibilities of the smesher in the epoch and the expected
+// reward for them. Fees are not included into the reward, as they are not known in advance.
+//
+// ATX is empty if the node didn't receive the atx of the smesher that targets the epoch, the smesher is not
+// eligible in that case. The atx received after the deadline is not included into the projected weight,
+// its eligibilities are estimated as if the smesher generated the active set that includes its own atx.
+type SmesherEstimate struct {
+	NodeID        types.NodeID
+	ATX           types.ATXID
+	Weight        uint64
+	Late          bool
+	Eligibilities uint32
+	Reward        uint64
+}
+
+// EligibilityEstimator estimates the proposal eligibilities and rewards of the smeshers from the projection
+// of the active set maintained by the tracker. It uses the same formula as the proposal builder,
+// so that the estimates don't diverge from the eligibilities the smeshers get.
+type EligibilityEstimator struct {
+	tracker            *ActiveSetTracker
+	layerSize          uint32
+	layersPerEpoch     uint32
+	minActiveSetWeight []types.EpochMinimalActiveWeight
+}
+
+// NewEligibilityEstimator creates an estimator for the network with the layer size and the minimal
+// active set weights.
+func NewEligibilityEstimator(
+	tracker *ActiveSetTracker,
+	layerSize, layersPerEpoch uint32,
+	minActiveSetWeight []types.EpochMinimalActiveWeight,
+) *EligibilityEstimator {
+	return &EligibilityEstimator{
+		tracker:            tracker,
+		layerSize:          layerSize,
+		layersPerEpoch:     layersPerEpoch,
+		minActiveSetWeight: minActiveSetWeight,
+	}
+}
+
+// Estimate returns the expected eligibilities and rewards of the smeshers in the epoch.
+func (e *EligibilityEstimator) Estimate(epoch types.EpochID, smeshers []types.NodeID) (*EpochEstimate, error) {
+	if !e.tracker.Ready() {
+		return nil, ErrNotReady
+	}
+	summary, exists := e.tracker.Projection(epoch)
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrEpochNotTracked, epoch)
+	}
+	deadline := e.tracker.Deadline(epoch)
+	rst := &EpochEstimate{
+		Epoch:     epoch,
+		Weight:    summary.Weight,
+		MinWeight: minweight.Select(epoch, e.minActiveSetWeight),
+		Subsidy:   e.subsidy(epoch),
+		Smeshers:  make([]SmesherEstimate, 0, len(smeshers)),
+	}
+	for _, smesher := range smeshers {
+		estimate := SmesherEstimate{NodeID: smesher}
+		candidate, err := e.tracker.Candidate(epoch, smesher)
+		switch {
+		case errors.Is(err, sql.ErrNotFound):
+			rst.Smeshers = append(rst.Smeshers, estimate)
+			continue
+		case err != nil:
+			return nil, err
+		}
+		estimate.ATX = candidate.ID
+		estimate.Weight = candidate.Weight
+		estimate.Late = !candidate.Received.Before(deadline)
+		total := summary.Weight
+		if estimate.Late {
+			total += candidate.Weight
+		}
+		estimate.Eligibilities, err = util.GetNumEligibleSlots(
+			candidate.Weight, rst.MinWeight, total, e.layerSize, e.layersPerEpoch,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("eligible slots for %s: %w", smesher.ShortString(), err)
+		}
+		estimate.Reward = e.reward(estimate.Eligibilities, rst.Subsidy, rst.MinWeight, total)
+		rst.Smeshers = append(rst.Smeshers, estimate)
+	}
+	return rst, nil
+}
+
+// reward is the share of the subsidy for the eligibilities. The subsidy of the layer is split between
+// the eligibilities included into the block, there are layer size eligibilities per layer on average,
+// or less if the total weight is below the minimal active set weight.
+func (e *EligibilityEstimator) reward(eligibilities uint32, subsidy, minWeight, total uint64) uint64 {
+	if total == 0 || e.layerSize == 0 || e.layersPerEpoch == 0 {
+		return 0
+	}
+	reward := new(big.Int).SetUint64(uint64(eligibilities))
+	reward.Mul(reward, new(big.Int).SetUint64(subsidy))
+	reward.Mul(reward, new(big.Int).SetUint64(max(minWeight, total)))
+	div := new(big.Int).SetUint64(uint64(e.layerSize) * uint64(e.layersPerEpoch))
+	div.Mul(div, new(big.Int).SetUint64(total))
+	reward.Quo(reward, div)
+	if !reward.IsUint64() {
+		return subsidy
+	}
+	return reward.Uint64()
+}
+
+// subsidy is the total subsidy issued in the layers of the epoch.
+func (e *EligibilityEstimator) subsidy(epoch types.EpochID) uint64 {
+	var (
+		first = types.FirstEffectiveGenesis()
+		total uint64
+	)
+	for lid := epoch.FirstLayer(); lid < (epoch + 1).FirstLayer(); lid++ {
+		if lid < first {
+			continue
+		}
+		total += rewards.TotalSubsidyAtLayer(lid.Difference(first))
+	}
+	return total
+}
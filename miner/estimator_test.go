@@ -0,0 +1,90 @@
+package miner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/miner/mocks"
+	"github.com/spacemeshos/go-spacemesh/proposals/util"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestEligibilityEstimator(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		clock     = mocks.NewMocklayerClock(ctrl)
+		cdb       = datastore.NewCachedDB(sql.InMemory(), logtest.New(t))
+		start     = time.Unix(1000, 0)
+		delay     = time.Second
+		current   = types.EpochID(3)
+		layerSize = uint32(10)
+	)
+	clock.EXPECT().LayerToTime(gomock.Any()).Return(start).AnyTimes()
+	clock.EXPECT().CurrentLayer().Return(current.FirstLayer()).AnyTimes()
+	clock.EXPECT().AwaitLayer(gomock.Any()).Return(make(chan struct{})).AnyTimes()
+
+	onTime := gatx(types.ATXID{1}, current, types.NodeID{1}, 2, genAtxWithReceived(start.Add(-time.Hour)))
+	require.NoError(t, atxs.Add(cdb, onTime))
+	late := gatx(types.ATXID{2}, current, types.NodeID{2}, 3, genAtxWithReceived(start.Add(-delay)))
+	require.NoError(t, atxs.Add(cdb, late))
+
+	tracker := NewActiveSetTracker(logtest.New(t), cdb, localsql.InMemory(), clock, delay)
+	minWeights := []types.EpochMinimalActiveWeight{{Epoch: 0, Weight: 10 * onTime.GetWeight()}}
+	estimator := NewEligibilityEstimator(tracker, layerSize, layersPerEpoch, minWeights)
+	_, err := estimator.Estimate(current+1, nil)
+	require.ErrorIs(t, err, ErrNotReady)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- tracker.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errc)
+	})
+	require.Eventually(t, tracker.Ready, time.Second, 10*time.Millisecond)
+
+	_, err = estimator.Estimate(current+3, nil)
+	require.ErrorIs(t, err, ErrEpochNotTracked)
+
+	estimate, err := estimator.Estimate(current+1, []types.NodeID{{3}, onTime.SmesherID, late.SmesherID})
+	require.NoError(t, err)
+	require.Equal(t, current+1, estimate.Epoch)
+	require.Equal(t, onTime.GetWeight(), estimate.Weight)
+	require.Equal(t, minWeights[0].Weight, estimate.MinWeight)
+	require.Positive(t, estimate.Subsidy)
+	require.Len(t, estimate.Smeshers, 3)
+
+	require.Equal(t, SmesherEstimate{NodeID: types.NodeID{3}}, estimate.Smeshers[0])
+
+	slots, err := util.GetNumEligibleSlots(
+		onTime.GetWeight(), estimate.MinWeight, onTime.GetWeight(), layerSize, layersPerEpoch,
+	)
+	require.NoError(t, err)
+	require.Equal(t, SmesherEstimate{
+		NodeID:        onTime.SmesherID,
+		ATX:           onTime.ID(),
+		Weight:        onTime.GetWeight(),
+		Eligibilities: slots,
+		// the only smesher in the active set receives all the subsidy
+		Reward: estimate.Subsidy,
+	}, estimate.Smeshers[1])
+
+	slots, err = util.GetNumEligibleSlots(
+		late.GetWeight(), estimate.MinWeight, onTime.GetWeight()+late.GetWeight(), layerSize, layersPerEpoch,
+	)
+	require.NoError(t, err)
+	require.Equal(t, late.ID(), estimate.Smeshers[2].ATX)
+	require.True(t, estimate.Smeshers[2].Late)
+	require.Equal(t, slots, estimate.Smeshers[2].Eligibilities)
+	require.Less(t, estimate.Smeshers[2].Reward, estimate.Subsidy)
+	require.Positive(t, estimate.Smeshers[2].Reward)
+}
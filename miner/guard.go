@@ -0,0 +1,277 @@
+package miner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/landing"
+)
+
+// ErrIdentityInUse is returned if the identity appears to be used by another instance of the node.
+var ErrIdentityInUse = errors.New("identity is used by another instance")
+
+const (
+	defaultLeaseTTL = 15 * time.Minute
+	defaultLockout  = time.Hour
+)
+
+// lease is the content of the lease file of the identity.
+type lease struct {
+	Instance string    `json:"instance"`
+	Host     string    `json:"host"`
+	Updated  time.Time `json:"updated"`
+}
+
+// GuardOpt for configuring SigningGuard.
+type GuardOpt func(*SigningGuard)
+
+// WithLeaseDir enables lease files in the directory. The directory is expected to be shared
+// between the instances that may use the same identities, such as the primary and the standby node.
+func WithLeaseDir(dir string) GuardOpt {
+	return func(g *SigningGuard) {
+		g.leaseDir = dir
+	}
+}
+
+// WithLeaseTTL sets the time after which the lease that is not renewed by its holder expires.
+// Leases are renewed every layer, ttl should be longer than a few layers.
+func WithLeaseTTL(ttl time.Duration) GuardOpt {
+	return func(g *SigningGuard) {
+		g.leaseTTL = ttl
+	}
+}
+
+// WithLockout sets the time during which the identity is not used for signing
+// after a ballot of the identity signed by another instance was received.
+func WithLockout(lockout time.Duration) GuardOpt {
+	return func(g *SigningGuard) {
+		g.lockout = lockout
+	}
+}
+
+// WithGuardLogger sets the logger.
+func WithGuardLogger(logger log.Log) GuardOpt {
+	return func(g *SigningGuard) {
+		g.logger = logger
+	}
+}
+
+// SigningGuard prevents the identities of the node from signing conflicting ballots when the same key
+// is used by more than one running instance, for example after failover to the standby node
+// while the primary node is still alive. The builder doesn't sign a ballot for the identity if:
+//   - the identity already landed a ballot in the layer, landings are persisted in the local database;
+//   - a ballot of the identity that wasn't landed by this node was received in the current layer
+//     or the layer before, in this case the identity is locked out for a while;
+//   - the lease file of the identity is held by another instance and is not expired.
+type SigningGuard struct {
+	logger   log.Log
+	localdb  sql.Executor
+	clock    layerClock
+	instance string
+	host     string
+	leaseDir string
+	leaseTTL time.Duration
+	lockout  time.Duration
+
+	mu sync.Mutex
+	// identities maps registered identities to the last time their ballot from another instance was received.
+	identities map[types.NodeID]time.Time
+	now        func() time.Time
+}
+
+// NewSigningGuard creates the guard. Instance id is loaded from the local database.
+func NewSigningGuard(localdb sql.Executor, clock layerClock, opts ...GuardOpt) (*SigningGuard, error) {
+	instance, err := landing.Instance(localdb)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	g := &SigningGuard{
+		logger:     log.NewNop(),
+		localdb:    localdb,
+		clock:      clock,
+		instance:   instance,
+		host:       host,
+		leaseTTL:   defaultLeaseTTL,
+		lockout:    defaultLockout,
+		identities: map[types.NodeID]time.Time{},
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.leaseDir != "" {
+		if err := os.MkdirAll(g.leaseDir, 0o700); err != nil {
+			return nil, fmt.Errorf("create lease dir: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// Register adds the identity to the guarded identities.
+func (g *SigningGuard) Register(id types.NodeID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.identities[id]; !exists {
+		g.identities[id] = time.Time{}
+	}
+}
+
+// OnBallot locks out the identity if the ballot is signed by it, but wasn't landed by this node.
+func (g *SigningGuard) OnBallot(ballot *types.Ballot) {
+	g.mu.Lock()
+	_, registered := g.identities[ballot.SmesherID]
+	g.mu.Unlock()
+	// ballots of the older layers are received during sync and don't mean that the other instance is alive
+	if !registered || ballot.Layer+1 < g.clock.CurrentLayer() {
+		return
+	}
+	record, err := landing.Get(g.localdb, ballot.SmesherID, ballot.Layer)
+	switch {
+	case err == nil && record.Ballot == ballot.ID():
+		return
+	case err != nil && !errors.Is(err, sql.ErrNotFound):
+		g.logger.With().Warning("failed to load landing", ballot.SmesherID, ballot.Layer, log.Err(err))
+		return
+	}
+	g.logger.With().Error("received ballot signed by another instance, identity is locked out",
+		ballot.SmesherID,
+		ballot.Layer,
+		ballot.ID(),
+		log.Duration("lockout", g.lockout),
+	)
+	g.mu.Lock()
+	g.identities[ballot.SmesherID] = g.now()
+	g.mu.Unlock()
+}
+
+// Acquire checks that the identity is not used by another instance and renews its lease.
+// It is expected to be called every layer for every identity, so that the lease doesn't expire.
+func (g *SigningGuard) Acquire(id types.NodeID) error {
+	now := g.now()
+	g.mu.Lock()
+	seen := g.identities[id]
+	g.mu.Unlock()
+	if !seen.IsZero() && now.Sub(seen) < g.lockout {
+		return fmt.Errorf("%w: ballot of %s from another instance received at %s",
+			ErrIdentityInUse, id.ShortString(), seen.Format(time.RFC3339))
+	}
+	if g.leaseDir == "" {
+		return nil
+	}
+	path := g.leasePath(id)
+	current, err := readLease(path)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Instance != g.instance && now.Sub(current.Updated) < g.leaseTTL {
+		return fmt.Errorf("%w: %s is leased by instance %s on host %s until %s", ErrIdentityInUse,
+			id.ShortString(), current.Instance, current.Host, current.Updated.Add(g.leaseTTL).Format(time.RFC3339))
+	}
+	if err := writeLease(path, &lease{Instance: g.instance, Host: g.host, Updated: now}); err != nil {
+		return err
+	}
+	// another instance may have renewed the lease concurrently, the last writer holds the lease
+	current, err = readLease(path)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Instance != g.instance {
+		return fmt.Errorf("%w: lease of %s was taken concurrently", ErrIdentityInUse, id.ShortString())
+	}
+	return nil
+}
+
+// Land persists that the identity signed the ballot in the layer. Returns ErrIdentityInUse if
+// the identity already landed a different ballot in the layer.
+func (g *SigningGuard) Land(ballot *types.Ballot) error {
+	added, err := landing.Add(g.localdb, &landing.Record{
+		Node:      ballot.SmesherID,
+		Layer:     ballot.Layer,
+		Ballot:    ballot.ID(),
+		Signature: ballot.Signature,
+		Instance:  g.instance,
+		Created:   g.now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !added {
+		record, err := landing.Get(g.localdb, ballot.SmesherID, ballot.Layer)
+		if err != nil {
+			return err
+		}
+		if record.Ballot != ballot.ID() {
+			return fmt.Errorf("%w: %s already signed ballot %s in layer %d",
+				ErrIdentityInUse, ballot.SmesherID.ShortString(), record.Ballot, ballot.Layer)
+		}
+	}
+	// records are kept for the previous epoch to recognize ballots received during the epoch transition
+	if epoch := ballot.Layer.GetEpoch(); epoch > 0 {
+		return landing.PruneBefore(g.localdb, (epoch - 1).FirstLayer())
+	}
+	return nil
+}
+
+// Release removes the leases held by this instance, so that the standby instance
+// can take over the identities without waiting for the leases to expire.
+func (g *SigningGuard) Release() {
+	if g.leaseDir == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id := range g.identities {
+		path := g.leasePath(id)
+		current, err := readLease(path)
+		if err != nil || current == nil || current.Instance != g.instance {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			g.logger.With().Warning("failed to release lease", id, log.Err(err))
+		}
+	}
+}
+
+func (g *SigningGuard) leasePath(id types.NodeID) string {
+	return filepath.Join(g.leaseDir, id.String()+".lease")
+}
+
+func readLease(path string) (*lease, error) {
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read lease: %w", err)
+	}
+	var rst lease
+	if err := json.Unmarshal(buf, &rst); err != nil {
+		// partially written lease is overwritten by the next holder
+		return nil, nil
+	}
+	return &rst, nil
+}
+
+func writeLease(path string, l *lease) error {
+	buf, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("encode lease: %w", err)
+	}
+	tmp := path + "." + l.Instance + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o600); err != nil {
+		return fmt.Errorf("write lease: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package miner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/miner/mocks"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func signedBallot(tb testing.TB, signer *signing.EdSigner, lid types.LayerID, atx types.ATXID) *types.Ballot {
+	ballot := &types.Ballot{InnerBallot: types.InnerBallot{Layer: lid, AtxID: atx}}
+	ballot.Signature = signer.Sign(signing.BALLOT, ballot.SignedBytes())
+	ballot.SmesherID = signer.NodeID()
+	require.NoError(tb, ballot.Initialize())
+	return ballot
+}
+
+func TestSigningGuard(t *testing.T) {
+	const current = types.LayerID(100)
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	clock := mocks.NewMocklayerClock(gomock.NewController(t))
+	clock.EXPECT().CurrentLayer().Return(current).AnyTimes()
+
+	now := time.Unix(1000, 0)
+	newGuard := func(tb testing.TB, opts ...GuardOpt) *SigningGuard {
+		guard, err := NewSigningGuard(localsql.InMemory(), clock, append(opts, WithGuardLogger(logtest.New(tb)))...)
+		require.NoError(tb, err)
+		guard.now = func() time.Time { return now }
+		guard.Register(signer.NodeID())
+		return guard
+	}
+
+	t.Run("landing", func(t *testing.T) {
+		guard := newGuard(t)
+		ballot := signedBallot(t, signer, current, types.ATXID{1})
+		require.NoError(t, guard.Land(ballot))
+		require.NoError(t, guard.Land(ballot))
+		require.ErrorIs(t, guard.Land(signedBallot(t, signer, current, types.ATXID{2})), ErrIdentityInUse)
+
+		// landed ballot received from gossip is not foreign
+		guard.OnBallot(ballot)
+		require.NoError(t, guard.Acquire(signer.NodeID()))
+	})
+	t.Run("foreign ballot", func(t *testing.T) {
+		guard := newGuard(t, WithLockout(time.Minute))
+		// ballots from sync are ignored
+		guard.OnBallot(signedBallot(t, signer, current-2, types.ATXID{1}))
+		require.NoError(t, guard.Acquire(signer.NodeID()))
+
+		// ballots of other identities are ignored
+		other, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		guard.OnBallot(signedBallot(t, other, current, types.ATXID{1}))
+		require.NoError(t, guard.Acquire(signer.NodeID()))
+
+		guard.OnBallot(signedBallot(t, signer, current-1, types.ATXID{1}))
+		require.ErrorIs(t, guard.Acquire(signer.NodeID()), ErrIdentityInUse)
+		require.NoError(t, guard.Acquire(other.NodeID()))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, guard.Acquire(signer.NodeID()))
+	})
+	t.Run("lease", func(t *testing.T) {
+		dir := t.TempDir()
+		primary := newGuard(t, WithLeaseDir(dir), WithLeaseTTL(time.Minute))
+		standby := newGuard(t, WithLeaseDir(dir), WithLeaseTTL(time.Minute))
+		require.NotEqual(t, primary.instance, standby.instance)
+
+		require.NoError(t, primary.Acquire(signer.NodeID()))
+		require.ErrorIs(t, standby.Acquire(signer.NodeID()), ErrIdentityInUse)
+
+		// renewed lease is still held by the primary
+		now = now.Add(time.Minute / 2)
+		require.NoError(t, primary.Acquire(signer.NodeID()))
+		now = now.Add(time.Minute / 2)
+		require.ErrorIs(t, standby.Acquire(signer.NodeID()), ErrIdentityInUse)
+
+		// expired lease is taken over
+		now = now.Add(time.Minute)
+		require.NoError(t, standby.Acquire(signer.NodeID()))
+		require.ErrorIs(t, primary.Acquire(signer.NodeID()), ErrIdentityInUse)
+
+		// released lease is available immediately, lease of the other instance is not removed
+		primary.Release()
+		require.FileExists(t, filepath.Join(dir, signer.NodeID().String()+".lease"))
+		standby.Release()
+		require.NoError(t, primary.Acquire(signer.NodeID()))
+
+		// corrupted lease is overwritten
+		require.NoError(t, os.WriteFile(filepath.Join(dir, signer.NodeID().String()+".lease"), []byte("{"), 0o600))
+		require.NoError(t, standby.Acquire(signer.NodeID()))
+	})
+}
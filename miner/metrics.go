@@ -17,6 +17,13 @@ var proposalBuild = metrics.NewHistogramWithBuckets(
 	prometheus.ExponentialBuckets(0.1, 2, 10),
 ).WithLabelValues()
 
+var activeSetProjectedWeight = metrics.NewGauge(
+	"active_set_projected_weight",
+	"miner",
+	"weight of the atxs received in time for the active set of the epoch",
+	[]string{"epoch"},
+)
+
 type latencyTracker struct {
 	start    time.Time
 	data     time.Time
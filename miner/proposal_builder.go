@@ -32,6 +32,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/blocks"
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/activecandidates"
 	"github.com/spacemeshos/go-spacemesh/system"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
 )
@@ -82,13 +83,18 @@ type ProposalBuilder struct {
 		mu   sync.Mutex
 		data map[types.EpochID][]types.ATXID
 	}
+
+	tracker *ActiveSetTracker
+	guard   *SigningGuard
 }
 
 type signerSession struct {
-	signer  *signing.EdSigner
+	signer  signing.Signer
 	log     log.Log
 	session session
 	latency latencyTracker
+	// refused is set if the guard refused to sign ballots in the layer.
+	refused error
 }
 
 // shared data for all signers in the epoch.
@@ -227,6 +233,22 @@ func WithMinGoodAtxPercent(percent int) Opt {
 	}
 }
 
+// WithActiveSetTracker generates the active set from the candidates maintained by the tracker,
+// once the tracker is ready.
+func WithActiveSetTracker(tracker *ActiveSetTracker) Opt {
+	return func(pb *ProposalBuilder) {
+		pb.tracker = tracker
+	}
+}
+
+// WithSigningGuard refuses to sign ballots for the identities that are used by another instance.
+// Should be before signers.
+func WithSigningGuard(guard *SigningGuard) Opt {
+	return func(pb *ProposalBuilder) {
+		pb.guard = guard
+	}
+}
+
 // WithSigners guarantees that builder will start execution with provided list of signers.
 // Should be after logging.
 func WithSigners(signers ...*signing.EdSigner) Opt {
@@ -277,12 +299,15 @@ func New(
 	return pb
 }
 
-func (pb *ProposalBuilder) Register(sig *signing.EdSigner) {
+func (pb *ProposalBuilder) Register(sig signing.Signer) {
 	pb.signers.mu.Lock()
 	defer pb.signers.mu.Unlock()
 	_, exist := pb.signers.signers[sig.NodeID()]
 	if !exist {
 		pb.logger.With().Info("registered signing key", log.ShortStringer("id", sig.NodeID()))
+		if pb.guard != nil {
+			pb.guard.Register(sig.NodeID())
+		}
 		pb.signers.signers[sig.NodeID()] = &signerSession{
 			signer: sig,
 			log:    pb.logger.WithFields(log.String("signer", sig.NodeID().ShortString())),
@@ -294,6 +319,9 @@ func (pb *ProposalBuilder) Register(sig *signing.EdSigner) {
 func (pb *ProposalBuilder) Run(ctx context.Context) error {
 	next := pb.clock.CurrentLayer().Add(1)
 	pb.logger.With().Info("started", log.Inline(&pb.cfg), log.Uint32("next", next.Uint32()))
+	if pb.guard != nil {
+		defer pb.guard.Release()
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -429,9 +457,26 @@ func (pb *ProposalBuilder) initSharedData(ctx context.Context, lid types.LayerID
 		return nil
 	}
 
+	epoch := pb.shared.epoch
+	iterate := func(fn func(*activecandidates.Candidate) error) error {
+		return pb.cdb.IterateEpochATXHeaders(epoch, func(header *types.ActivationTxHeader) error {
+			return fn(&activecandidates.Candidate{
+				ID:       header.ID,
+				Node:     header.NodeID,
+				Weight:   header.GetWeight(),
+				Received: header.Received,
+			})
+		})
+	}
+	if pb.tracker != nil && pb.tracker.Ready() {
+		iterate = func(fn func(*activecandidates.Candidate) error) error {
+			return pb.tracker.Iterate(epoch, fn)
+		}
+	}
 	weight, set, err := generateActiveSet(
 		pb.logger,
 		pb.cdb,
+		iterate,
 		pb.shared.epoch,
 		pb.clock.LayerToTime(pb.shared.epoch.FirstLayer()),
 		pb.cfg.goodAtxPercent,
@@ -504,14 +549,19 @@ func (pb *ProposalBuilder) initSignerData(
 		}
 	}
 	if ss.session.eligibilities.proofs == nil {
-		ss.session.eligibilities.proofs = calcEligibilityProofs(
-			ss.signer.VRFSigner(),
+		proofs, err := calcEligibilityProofs(
+			ctx,
+			ss.signer,
 			ss.session.epoch,
 			ss.session.beacon,
 			ss.session.nonce,
 			ss.session.eligibilities.slots,
 			pb.cfg.layersPerEpoch,
 		)
+		if err != nil {
+			return fmt.Errorf("calculate eligibility proofs: %w", err)
+		}
+		ss.session.eligibilities.proofs = proofs
 		ss.log.With().Info("proposal eligibilities for an epoch", log.Inline(&ss.session))
 		events.EmitEligibilities(
 			ss.session.epoch,
@@ -558,6 +608,11 @@ func (pb *ProposalBuilder) build(ctx context.Context, lid types.LayerID) error {
 				)
 			}
 			ss.session.prev = lid
+			ss.refused = nil
+			if pb.guard != nil {
+				// leases are renewed every layer, not only in the layers where the signer is eligible
+				ss.refused = pb.guard.Acquire(ss.signer.NodeID())
+			}
 			ss.latency.data = time.Now()
 			return nil
 		})
@@ -613,6 +668,10 @@ func (pb *ProposalBuilder) build(ctx context.Context, lid types.LayerID) error {
 			log.Context(ctx),
 			lid.Field(), log.Int("num proposals", len(proofs)),
 		)
+		if ss.refused != nil {
+			ss.log.With().Error("refused to build proposal", log.Context(ctx), lid.Field(), log.Err(ss.refused))
+			continue
+		}
 
 		txs := pb.conState.SelectProposalTXs(lid, len(proofs))
 		ss.latency.txs = time.Now()
@@ -629,7 +688,8 @@ func (pb *ProposalBuilder) build(ctx context.Context, lid types.LayerID) error {
 
 		ss := ss
 		eg.Go(func() error {
-			proposal := createProposal(
+			proposal, err := createProposal(
+				ctx,
 				&ss.session,
 				pb.shared.beacon,
 				pb.shared.active.set,
@@ -640,6 +700,26 @@ func (pb *ProposalBuilder) build(ctx context.Context, lid types.LayerID) error {
 				proofs,
 				meshHash,
 			)
+			if err != nil {
+				ss.log.Error("failed to sign proposal",
+					log.Context(ctx),
+					log.Uint32("lid", lid.Uint32()),
+					log.Err(err),
+				)
+				return nil
+			}
+			if pb.guard != nil {
+				// landing is persisted before publishing, so that the proposal received from gossip is recognized
+				if err := pb.guard.Land(&proposal.Ballot); err != nil {
+					ss.log.Error("refused to publish proposal",
+						log.Context(ctx),
+						log.Uint32("lid", proposal.Layer.Uint32()),
+						log.Stringer("id", proposal.ID()),
+						log.Err(err),
+					)
+					return nil
+				}
+			}
 			if err := pb.publisher.Publish(ctx, pubsub.ProposalProtocol, codec.MustEncode(proposal)); err != nil {
 				ss.log.Error("failed to publish proposal",
 					log.Context(ctx),
@@ -661,16 +741,17 @@ func (pb *ProposalBuilder) build(ctx context.Context, lid types.LayerID) error {
 }
 
 func createProposal(
+	ctx context.Context,
 	session *session,
 	beacon types.Beacon,
 	activeset types.ATXIDList,
-	signer *signing.EdSigner,
+	signer signing.Signer,
 	lid types.LayerID,
 	txs []types.TransactionID,
 	opinion *types.Opinion,
 	eligibility []types.VotingEligibility,
 	meshHash types.Hash32,
-) *types.Proposal {
+) (*types.Proposal, error) {
 	p := &types.Proposal{
 		InnerProposal: types.InnerProposal{
 			Ballot: types.Ballot{
@@ -696,11 +777,17 @@ func createProposal(
 	} else {
 		p.Ballot.RefBallot = session.ref
 	}
-	p.Ballot.Signature = signer.Sign(signing.BALLOT, p.Ballot.SignedBytes())
+	sig, err := signer.SignContext(ctx, signing.BALLOT, p.Ballot.SignedBytes())
+	if err != nil {
+		return nil, fmt.Errorf("sign ballot: %w", err)
+	}
+	p.Ballot.Signature = sig
 	p.SmesherID = signer.NodeID()
-	p.Signature = signer.Sign(signing.PROPOSAL, p.SignedBytes())
+	if p.Signature, err = signer.SignContext(ctx, signing.PROPOSAL, p.SignedBytes()); err != nil {
+		return nil, fmt.Errorf("sign proposal: %w", err)
+	}
 	p.MustInitialize()
-	return p
+	return p, nil
 }
 
 func ActiveSetFromEpochFirstBlock(db sql.Executor, epoch types.EpochID) ([]types.ATXID, error) {
@@ -777,9 +864,11 @@ func (pb *ProposalBuilder) fallbackActiveSet(targetEpoch types.EpochID) (uint64,
 	return totalWeight, set, nil
 }
 
+// generateActiveSet selects the good atxs among the candidates returned by iterate.
 func generateActiveSet(
 	logger log.Log,
 	cdb *datastore.CachedDB,
+	iterate func(func(*activecandidates.Candidate) error) error,
 	target types.EpochID,
 	epochStart time.Time,
 	goodAtxPercent int,
@@ -790,24 +879,24 @@ func generateActiveSet(
 		set         []types.ATXID
 		numOmitted  = 0
 	)
-	if err := cdb.IterateEpochATXHeaders(target, func(header *types.ActivationTxHeader) error {
-		grade, err := gradeAtx(cdb, header.NodeID, header.Received, epochStart, networkDelay)
+	if err := iterate(func(candidate *activecandidates.Candidate) error {
+		grade, err := gradeAtx(cdb, candidate.Node, candidate.Received, epochStart, networkDelay)
 		if err != nil {
 			return err
 		}
 		if grade != good {
 			logger.With().Debug("atx omitted from active set",
-				header.ID,
+				candidate.ID,
 				log.Int("grade", int(grade)),
-				log.Stringer("smesher", header.NodeID),
-				log.Time("received", header.Received),
+				log.Stringer("smesher", candidate.Node),
+				log.Time("received", candidate.Received),
 				log.Time("epoch_start", epochStart),
 			)
 			numOmitted++
 			return nil
 		}
-		totalWeight += header.GetWeight()
-		set = append(set, header.ID)
+		totalWeight += candidate.Weight
+		set = append(set, candidate.ID)
 		return nil
 	}); err != nil {
 		return 0, nil, err
@@ -845,23 +934,27 @@ func generateActiveSet(
 // calcEligibilityProofs calculates the eligibility proofs of proposals for the miner in the given epoch
 // and returns the proofs along with the epoch's active set.
 func calcEligibilityProofs(
-	signer *signing.VRFSigner,
+	ctx context.Context,
+	signer signing.Signer,
 	epoch types.EpochID,
 	beacon types.Beacon,
 	nonce types.VRFPostIndex,
 	slots uint32,
 	layersPerEpoch uint32,
-) map[types.LayerID][]types.VotingEligibility {
+) (map[types.LayerID][]types.VotingEligibility, error) {
 	proofs := map[types.LayerID][]types.VotingEligibility{}
 	for counter := uint32(0); counter < slots; counter++ {
-		vrf := signer.Sign(proposals.MustSerializeVRFMessage(beacon, epoch, nonce, counter))
+		vrf, err := signer.SignVRFContext(ctx, epoch, proposals.MustSerializeVRFMessage(beacon, epoch, nonce, counter))
+		if err != nil {
+			return nil, err
+		}
 		layer := proposals.CalcEligibleLayer(epoch, layersPerEpoch, vrf)
 		proofs[layer] = append(proofs[layer], types.VotingEligibility{
 			J:   counter,
 			Sig: vrf,
 		})
 	}
-	return proofs
+	return proofs, nil
 }
 
 // atxGrade describes the grade of an ATX as described in
@@ -33,6 +33,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
 	smocks "github.com/spacemeshos/go-spacemesh/system/mocks"
 )
 
@@ -243,6 +244,14 @@ func TestBuild(t *testing.T) {
 		WithLayerPerEpoch(types.GetLayersPerEpoch()),
 		WithLayerSize(10),
 	}
+	newGuard := func(dir string) *SigningGuard {
+		guard, err := NewSigningGuard(localsql.InMemory(), nil, WithLeaseDir(dir))
+		require.NoError(t, err)
+		return guard
+	}
+	// the lease of the signer is held by another instance
+	leased := t.TempDir()
+	require.NoError(t, newGuard(leased).Acquire(signer.NodeID()))
 	for _, tc := range []struct {
 		desc  string
 		opts  []Opt
@@ -732,6 +741,44 @@ func TestBuild(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "signing guard",
+			opts: []Opt{WithSigningGuard(newGuard(t.TempDir()))},
+			steps: []step{
+				{
+					lid:    15,
+					beacon: types.Beacon{1},
+					atxs: []*types.VerifiedActivationTx{
+						gatx(types.ATXID{1}, 2, signer.NodeID(), 1, genAtxWithNonce(777)),
+						gatx(types.ATXID{2}, 2, types.NodeID{2}, 1),
+					},
+					opinion:        &types.Opinion{Hash: types.Hash32{1}},
+					txs:            []types.TransactionID{{1}, {2}},
+					latestComplete: 14,
+					expectProposal: expectProposal(
+						signer, 15, types.ATXID{1}, types.Opinion{Hash: types.Hash32{1}},
+						expectEpochData(gactiveset(types.ATXID{1}, types.ATXID{2}), 25, types.Beacon{1}),
+						expectTxs([]types.TransactionID{{1}, {2}}),
+						expectCounters(signers[0], 3, types.Beacon{1}, 777, 0, 6, 9, 12, 16, 18, 20, 23),
+					),
+				},
+			},
+		},
+		{
+			desc: "refused by signing guard",
+			opts: []Opt{WithSigningGuard(newGuard(leased))},
+			steps: []step{
+				{
+					lid:    15,
+					beacon: types.Beacon{1},
+					atxs: []*types.VerifiedActivationTx{
+						gatx(types.ATXID{1}, 2, signer.NodeID(), 1, genAtxWithNonce(777)),
+					},
+					opinion:        &types.Opinion{Hash: types.Hash32{1}},
+					latestComplete: 14,
+				},
+			},
+		},
 	} {
 		tc := tc
 		t.Run(tc.desc, func(t *testing.T) {
@@ -4,8 +4,11 @@ package node
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"net"
 	"net/http"
@@ -15,6 +18,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -28,12 +33,16 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
 	"github.com/spacemeshos/go-spacemesh/activation"
+	"github.com/spacemeshos/go-spacemesh/activation/poetproxy"
+	"github.com/spacemeshos/go-spacemesh/activation/remote"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver"
 	"github.com/spacemeshos/go-spacemesh/api/grpcserver/v2alpha1"
 	"github.com/spacemeshos/go-spacemesh/atxsdata"
@@ -46,8 +55,11 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/config"
 	"github.com/spacemeshos/go-spacemesh/config/presets"
+	"github.com/spacemeshos/go-spacemesh/config/profiles"
 	"github.com/spacemeshos/go-spacemesh/datastore"
+	"github.com/spacemeshos/go-spacemesh/diskspace"
 	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/explorer"
 	"github.com/spacemeshos/go-spacemesh/fetch"
 	vm "github.com/spacemeshos/go-spacemesh/genvm"
 	"github.com/spacemeshos/go-spacemesh/hare3"
@@ -62,6 +74,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/metrics/public"
 	"github.com/spacemeshos/go-spacemesh/miner"
 	"github.com/spacemeshos/go-spacemesh/node/mapstructureutil"
+	"github.com/spacemeshos/go-spacemesh/node/shutdown"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/handshake"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
@@ -69,6 +82,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/proposals/store"
 	"github.com/spacemeshos/go-spacemesh/prune"
 	"github.com/spacemeshos/go-spacemesh/signing"
+	remotesigner "github.com/spacemeshos/go-spacemesh/signing/remote"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/activesets"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
@@ -82,15 +96,21 @@ import (
 	"github.com/spacemeshos/go-spacemesh/system"
 	"github.com/spacemeshos/go-spacemesh/timesync"
 	timeCfg "github.com/spacemeshos/go-spacemesh/timesync/config"
+	"github.com/spacemeshos/go-spacemesh/timesync/ntp"
 	"github.com/spacemeshos/go-spacemesh/timesync/peersync"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 	"github.com/spacemeshos/go-spacemesh/txs"
 )
 
 const (
 	genesisFileName = "genesis.json"
+	loggingFileName = "logging.json"
 	dbFile          = "state.sql"
 
+	// shutdownReportFile keeps the outcome of the last shutdown of the node.
+	shutdownReportFile = "shutdown.json"
+
 	oldLocalDbFile = "node_state.sql"
 	localDbFile    = "local.sql"
 )
@@ -130,23 +150,40 @@ const (
 	ExecutorLogger         = "executor"
 	MalfeasanceLogger      = "malfeasance"
 	BootstrapLogger        = "bootstrap"
+	CheckpointLogger       = "checkpoint"
+	DiskSpaceLogger        = "diskspace"
 )
 
 func GetCommand() *cobra.Command {
 	conf := config.MainnetConfig()
-	var configPath *string
+	var (
+		configPath  *string
+		printConfig bool
+	)
 	c := &cobra.Command{
 		Use:   "node",
 		Short: "start node",
 		RunE: func(c *cobra.Command, args []string) error {
-			preset := conf.Preset // might be set via CLI flag
-			if err := loadConfig(&conf, preset, *configPath); err != nil {
+			// might be set via CLI flags
+			preset, profile := conf.Preset, conf.Profile
+			if err := loadConfig(&conf, preset, profile, *configPath); err != nil {
 				return fmt.Errorf("loading config: %w", err)
 			}
 			// apply CLI args to config
 			if err := c.ParseFlags(os.Args[1:]); err != nil {
 				return fmt.Errorf("parsing flags: %w", err)
 			}
+			if printConfig {
+				if err := printEffectiveConfig(c.OutOrStdout(), &conf, preset, profile, *configPath); err != nil {
+					return fmt.Errorf("printing config: %w", err)
+				}
+			}
+			if err := conf.Validate(); err != nil {
+				return fmt.Errorf("validating config:\n%w", err)
+			}
+			if printConfig {
+				return nil
+			}
 
 			if conf.LOGGING.Encoder == config.JSONLogEncoder {
 				log.JSONLog(true)
@@ -195,6 +232,8 @@ func GetCommand() *cobra.Command {
 			if app.signers == nil {
 				err := app.LoadIdentities()
 				switch {
+				case errors.Is(err, fs.ErrNotExist) && len(app.Config.RemoteSigners) > 0:
+					app.log.Info("Identity file not found. Using identities of the remote signers only.")
 				case errors.Is(err, fs.ErrNotExist):
 					app.log.Info("Identity file not found. Creating new identity...")
 					if err := app.NewIdentity(); err != nil {
@@ -205,6 +244,10 @@ func GetCommand() *cobra.Command {
 				}
 			}
 
+			if err := app.DialRemoteSigners(ctx); err != nil {
+				return fmt.Errorf("dialing remote signers: %w", err)
+			}
+
 			// Don't print usage on error from this point forward
 			c.SilenceUsage = true
 
@@ -236,6 +279,8 @@ func GetCommand() *cobra.Command {
 	}
 
 	configPath = cmd.AddFlags(c.PersistentFlags(), &conf)
+	c.Flags().BoolVar(&printConfig, "print-effective-config", false,
+		"print the effective config with the layer that set every value and exit")
 
 	// versionCmd returns the current version of spacemesh.
 	versionCmd := &cobra.Command{
@@ -264,18 +309,45 @@ func init() {
 	grpclog = grpc_logsettable.ReplaceGrpcLoggerV2()
 }
 
-// loadConfig loads config and preset (if provided) into the provided config.
-// It first loads the preset and then overrides it with values from the config file.
-func loadConfig(cfg *config.Config, preset, path string) error {
+// loadConfig loads config, preset and profile (if provided) into the provided config.
+// It first loads the preset, adjusts it with the profile and then overrides it with values from the config file
+// and the environment.
+func loadConfig(cfg *config.Config, preset, profile, path string) error {
+	return loadLayeredConfig(cfg, preset, profile, path, os.Environ(), nil)
+}
+
+// loadLayeredConfig loads the layers of the config in the order preset < profile < file < environment.
+// If sources are not nil, they record the layer that set every value of the config.
+func loadLayeredConfig(
+	cfg *config.Config,
+	preset, profile, path string,
+	environ []string,
+	sources *config.Sources,
+) error {
+	record := func(layer string) {
+		if sources != nil {
+			sources.Record(layer, cfg)
+		}
+	}
+
 	v := viper.New()
 	// read in config from file
 	if err := config.LoadConfig(path, v); err != nil {
 		return err
 	}
+	env := viper.New()
+	if err := config.LoadEnv(environ, env); err != nil {
+		return err
+	}
 
 	// override default config with preset if provided
-	if len(preset) == 0 && v.IsSet("preset") {
-		preset = v.GetString("preset")
+	for _, vip := range []*viper.Viper{env, v} {
+		if len(preset) == 0 && vip.IsSet("preset") {
+			preset = vip.GetString("preset")
+		}
+		if len(profile) == 0 && vip.IsSet("profile") {
+			profile = vip.GetString("profile")
+		}
 	}
 	if len(preset) > 0 {
 		p, err := presets.Get(preset)
@@ -283,6 +355,13 @@ func loadConfig(cfg *config.Config, preset, path string) error {
 			return err
 		}
 		*cfg = p
+		record("preset " + preset)
+	}
+	if len(profile) > 0 {
+		if err := profiles.Apply(profile, cfg); err != nil {
+			return err
+		}
+		record("profile " + profile)
 	}
 
 	// Unmarshall config file into config struct
@@ -307,9 +386,31 @@ func loadConfig(cfg *config.Config, preset, path string) error {
 	if err := v.Unmarshal(cfg, opts...); err != nil {
 		return fmt.Errorf("unmarshal config: %w", err)
 	}
+	if config.IsRemoteConfig(path) {
+		record("remote " + path)
+	} else if path != "" {
+		record("file " + path)
+	}
+	if err := env.Unmarshal(cfg, opts...); err != nil {
+		return fmt.Errorf("unmarshal environment (%s*): %w", config.EnvPrefix, err)
+	}
+	record("env")
 	return nil
 }
 
+// printEffectiveConfig prints the effective config with the layer that set every value.
+// The layers are loaded again on top of the defaults, so that the values set by flags are attributed to them.
+func printEffectiveConfig(w io.Writer, effective *config.Config, preset, profile, path string) error {
+	sources := config.NewSources()
+	layered := config.MainnetConfig()
+	sources.Record("default", &layered)
+	if err := loadLayeredConfig(&layered, preset, profile, path, os.Environ(), sources); err != nil {
+		return err
+	}
+	sources.Record("flags", effective)
+	return sources.Write(w)
+}
+
 func WithZeroFields() viper.DecoderConfigOption {
 	return func(cfg *mapstructure.DecoderConfig) {
 		cfg.ZeroFields = true
@@ -374,6 +475,7 @@ type App struct {
 	*cobra.Command
 	fileLock          *flock.Flock
 	signers           []*signing.EdSigner
+	remoteSigners     []*remotesigner.Signer
 	Config            *config.Config
 	db                *sql.Database
 	cachedDB          *datastore.CachedDB
@@ -387,12 +489,19 @@ type App struct {
 	grpcServices      map[grpcserver.Service]grpcserver.ServiceAPI
 	pprofService      *http.Server
 	profilerService   *pyroscope.Profiler
+	tracingProvider   *tracing.Provider
 	syncer            *syncer.Syncer
 	proposalListener  *proposals.Handler
 	proposalBuilder   *miner.ProposalBuilder
+	activeSetTracker  *miner.ActiveSetTracker
+	estimator         *miner.EligibilityEstimator
+	signingGuard      *miner.SigningGuard
+	watchdog          *activation.Watchdog
+	poetProxy         *poetproxy.Server
 	mesh              *mesh.Mesh
 	atxsdata          *atxsdata.Data
 	clock             *timesync.NodeClock
+	timeSource        *timesync.TimeSource
 	hare3             *hare3.Hare
 	hOracle           *eligibility.Oracle
 	blockGen          *blocks.Generator
@@ -400,9 +509,11 @@ type App struct {
 	atxBuilder        *activation.Builder
 	nipostBuilder     *activation.NIPostBuilder
 	atxHandler        *activation.Handler
+	malHandler        *malfeasance.Handler
 	txHandler         *txs.TxHandler
 	validator         *activation.Validator
 	edVerifier        *signing.EdVerifier
+	gossipVerifier    *signing.EdVerifier
 	beaconProtocol    *beacon.ProtocolDriver
 	log               log.Log
 	syncLogger        log.Log
@@ -410,19 +521,25 @@ type App struct {
 	conState          *txs.ConservativeState
 	fetcher           *fetch.Fetch
 	ptimesync         *peersync.Sync
+	ntpMonitor        *ntp.Monitor
+	diskMonitor       *diskspace.Monitor
 	tortoise          *tortoise.Tortoise
 	updater           *bootstrap.Updater
 	poetDb            *activation.PoetDb
 	postVerifier      activation.PostVerifier
+	golden            *types.GoldenATXs
 	postSupervisor    *activation.PostSupervisor
 	preserve          *checkpoint.PreservedData
+	checkpointServer  *checkpoint.Server
+	checkpoints       *checkpoint.Scheduler
 	errCh             chan error
 
 	host *p2p.Host
 
-	loggers map[string]*zap.AtomicLevel
-	started chan struct{} // this channel is closed once the app has finished starting
-	eg      *errgroup.Group
+	loggers    map[string]*zap.AtomicLevel
+	logModules *log.Modules
+	started    chan struct{} // this channel is closed once the app has finished starting
+	eg         *errgroup.Group
 }
 
 func (app *App) LoadCheckpoint(ctx context.Context) (*checkpoint.PreservedData, error) {
@@ -434,9 +551,9 @@ func (app *App) LoadCheckpoint(ctx context.Context) (*checkpoint.PreservedData,
 	if restore == 0 {
 		return nil, fmt.Errorf("restore layer not set")
 	}
-	nodeIDs := make([]types.NodeID, len(app.signers))
-	for i, sig := range app.signers {
-		nodeIDs[i] = sig.NodeID()
+	nodeIDs := make([]types.NodeID, 0, len(app.signers)+len(app.remoteSigners))
+	for _, sig := range app.identities() {
+		nodeIDs = append(nodeIDs, sig.NodeID())
 	}
 	cfg := &checkpoint.RecoverConfig{
 		GoldenAtx:      types.ATXID(app.Config.Genesis.GoldenATX()),
@@ -444,6 +561,7 @@ func (app *App) LoadCheckpoint(ctx context.Context) (*checkpoint.PreservedData,
 		DbFile:         dbFile,
 		LocalDbFile:    localDbFile,
 		PreserveOwnAtx: app.Config.Recovery.PreserveOwnAtx,
+		MaxP2PSize:     app.Config.Recovery.MaxP2PSize,
 		NodeIDs:        nodeIDs,
 		Uri:            checkpointFile,
 		Restore:        restore,
@@ -452,6 +570,18 @@ func (app *App) LoadCheckpoint(ctx context.Context) (*checkpoint.PreservedData,
 		log.String("url", checkpointFile),
 		log.Stringer("restore", restore),
 	)
+	if strings.HasPrefix(checkpointFile, checkpoint.P2PScheme+"://") {
+		// peers that already recovered from the checkpoint use effective genesis set by recovery
+		host, err := app.newHost(ctx, app.log, restore-1)
+		if err != nil {
+			return nil, err
+		}
+		defer host.Stop()
+		if err := host.Start(); err != nil {
+			return nil, fmt.Errorf("start p2p host for recovery: %w", err)
+		}
+		cfg.Host = host
+	}
 	return checkpoint.Recover(ctx, app.log, afero.NewOsFs(), cfg)
 }
 
@@ -519,6 +649,9 @@ func (app *App) Initialize() error {
 	// override default config in timesync since timesync is using TimeConfigValues
 	timeCfg.TimeConfigValues = app.Config.TIME
 
+	if err := app.setupLogModules(filepath.Join(app.Config.DataDir(), loggingFileName)); err != nil {
+		return fmt.Errorf("setup module loggers: %w", err)
+	}
 	app.setupLogging()
 	app.log.Info("Welcome to Spacemesh. Spacemesh full node is starting...")
 
@@ -555,32 +688,39 @@ func (app *App) Cleanup(ctx context.Context) {
 	app.log.Info("app cleanup completed")
 }
 
-// Wrap the top-level logger to add context info and set the level for a
-// specific module. Calling this method and will create a new logger every time
-// and not re-use an existing logger with the same name.
-//
-// This method is not safe to be called concurrently.
-func (app *App) addLogger(name string, logger log.Log) log.Log {
-	lvl := zap.NewAtomicLevel()
-	loggers, err := decodeLoggers(app.Config.LOGGING)
+// setupLogModules creates the loggers of the modules with the levels and the encoders from the config.
+// Levels and encoders changed at runtime are persisted in the file and override the config.
+func (app *App) setupLogModules(file string) error {
+	levels, err := decodeLoggers(app.Config.LOGGING)
 	if err != nil {
-		app.log.With().Panic("unable to decode loggers into map[string]string", log.Err(err))
+		return err
 	}
-	level, ok := loggers[name]
-	if ok {
-		if err := lvl.UnmarshalText([]byte(level)); err != nil {
-			app.log.Error("cannot parse logging for %v error %v", name, err)
-			lvl.SetLevel(log.DefaultLevel())
-		}
-	} else {
-		lvl.SetLevel(log.DefaultLevel())
+	modules := make(map[string]log.ModuleConfig, len(levels))
+	for name, level := range levels {
+		modules[name] = log.ModuleConfig{Level: level}
+	}
+	for name, encoder := range app.Config.LOGGING.Encoders {
+		module := modules[name]
+		module.Encoder = encoder
+		modules[name] = module
 	}
+	app.logModules, err = log.NewModules(log.ModulesConfig{Modules: modules, File: file}, events.EventHook())
+	return err
+}
 
-	if logger.Check(lvl.Level()) {
-		app.loggers[name] = &lvl
-		logger = logger.SetLevel(&lvl)
+// Wrap the top-level logger to add context info and set the level and the encoder of a
+// specific module. All loggers created for the module share the level and the encoder,
+// so that both can be changed at runtime.
+//
+// This method is not safe to be called concurrently.
+func (app *App) addLogger(name string, logger log.Log) log.Log {
+	if app.logModules == nil {
+		if err := app.setupLogModules(""); err != nil {
+			app.log.With().Panic("unable to configure module loggers", log.Err(err))
+		}
 	}
-	return logger.WithName(name)
+	app.loggers[name] = app.logModules.Get(name).Level()
+	return app.logModules.Logger(logger, name)
 }
 
 func (app *App) getLevel(name string) log.Level {
@@ -605,18 +745,104 @@ func (app *App) SetLogLevel(name, loglevel string) error {
 	return nil
 }
 
+// replayTortoise recomputes tortoise state for the sliding window from the ballots stored in the database
+// and checks that it matches validity of the blocks that was stored by the node.
+func (app *App) replayTortoise(ctx context.Context, window uint32, opts ...tortoise.Opt) error {
+	applied, err := layers.GetLastApplied(app.db)
+	if err != nil {
+		return fmt.Errorf("get last applied: %w", err)
+	}
+	var from types.LayerID
+	if applied > types.LayerID(window) {
+		from = applied - types.LayerID(window)
+	}
+	app.log.With().Info("replaying tortoise", log.Uint32("from", from.Uint32()))
+	start := time.Now()
+	rst, err := tortoise.Replay(ctx, app.db, app.atxsdata, from, opts...)
+	if err != nil {
+		return fmt.Errorf("replay tortoise: %w", err)
+	}
+	for _, mismatch := range rst.Mismatches {
+		app.log.With().Error("replayed tortoise state doesn't match stored", log.Stringer("mismatch", mismatch))
+	}
+	if !rst.Consistent() {
+		return fmt.Errorf("replayed tortoise verified %d with %d mismatches, stored verified %d",
+			rst.Verified, len(rst.Mismatches), rst.StoredVerified)
+	}
+	app.log.With().Info("tortoise replay matches stored state",
+		log.Uint32("first", rst.First.Uint32()),
+		log.Uint32("last", rst.Last.Uint32()),
+		log.Uint32("verified", rst.Verified.Uint32()),
+		log.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+// parsePriorityConfig decodes local transactions that are prioritized in the node's own proposals.
+func parsePriorityConfig(cfg config.BaseConfig) (txs.PriorityConfig, error) {
+	if cfg.PriorityGasShare > 100 {
+		return txs.PriorityConfig{}, fmt.Errorf("priority gas share must not exceed 100, got %d", cfg.PriorityGasShare)
+	}
+	priority := txs.PriorityConfig{GasShare: cfg.PriorityGasShare}
+	for _, encoded := range cfg.PriorityAddresses {
+		addr, err := types.StringToAddress(encoded)
+		if err != nil {
+			return txs.PriorityConfig{}, fmt.Errorf("parse priority address %s: %w", encoded, err)
+		}
+		priority.Addresses = append(priority.Addresses, addr)
+	}
+	for _, encoded := range cfg.PriorityTXs {
+		var tid types.TransactionID
+		decoded, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+		if err != nil || len(decoded) != len(tid) {
+			return txs.PriorityConfig{}, fmt.Errorf("priority tx %s must be %d hex encoded bytes", encoded, len(tid))
+		}
+		copy(tid[:], decoded)
+		priority.TXs = append(priority.TXs, tid)
+	}
+	return priority, nil
+}
+
+// goldenATXs returns the golden atx derived from the genesis and the golden atxs of the network upgrades.
+func (app *App) goldenATXs() (*types.GoldenATXs, error) {
+	golden := types.NewGoldenATXs(types.ATXID(app.Config.Genesis.GoldenATX()))
+	for key, value := range app.Config.GoldenATXs {
+		epoch, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse epoch of golden atx %s: %w", key, err)
+		}
+		id, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil || len(id) != types.ATXIDSize {
+			return nil, fmt.Errorf("invalid golden atx for epoch %d: %s", epoch, value)
+		}
+		if err := golden.Add(types.EpochID(epoch), types.ATXID(types.BytesToHash(id))); err != nil {
+			return nil, err
+		}
+	}
+	return golden, nil
+}
+
 func (app *App) initServices(ctx context.Context) error {
 	layerSize := app.Config.LayerAvgSize
 	layersPerEpoch := types.GetLayersPerEpoch()
 	lg := app.log
 
-	poetDb := activation.NewPoetDb(app.db, app.addLogger(PoetDbLogger, lg))
+	poetDb := activation.NewPoetDb(app.db, app.addLogger(PoetDbLogger, lg),
+		activation.WithPoetValidationQueue(app.Config.PoetValidationQueue, app.Config.PoetValidationWorkers),
+		activation.WithPoetFraudPublisher(app.host),
+	)
+
+	golden, err := app.goldenATXs()
+	if err != nil {
+		return err
+	}
+	app.golden = golden
 
 	opts := []activation.PostVerifierOpt{
 		activation.WithVerifyingOpts(app.Config.SMESHING.VerifyingOpts),
 		activation.WithAutoscaling(),
 	}
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		opts = append(opts, activation.WithPrioritizedID(sig.NodeID()))
 	}
 
@@ -628,6 +854,16 @@ func (app *App) initServices(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("creating post verifier: %w", err)
 	}
+	if len(app.Config.PostVerifier.Endpoints) > 0 {
+		verifier, err = remote.NewVerifier(
+			app.addLogger(NipostValidatorLogger, lg).Zap().Named("remote"),
+			app.Config.PostVerifier,
+			verifier,
+		)
+		if err != nil {
+			return fmt.Errorf("creating remote post verifier: %w", err)
+		}
+	}
 	app.postVerifier = verifier
 
 	validator := activation.NewValidator(
@@ -636,19 +872,27 @@ func (app *App) initServices(ctx context.Context) error {
 		app.Config.POST,
 		app.Config.SMESHING.Opts.Scrypt,
 		app.postVerifier,
+		activation.WithValidatorGoldenATXs(app.golden),
 	)
 	app.validator = validator
 
 	cfg := vm.DefaultConfig()
 	cfg.GasLimit = app.Config.BlockGasLimit
 	cfg.GenesisID = app.Config.Genesis.GenesisID()
+	cfg.TemplateUpgrades = app.Config.VM.TemplateUpgrades
+	cfg.StateRoot = app.Config.VM.StateRoot
 	state := vm.New(app.db,
 		vm.WithConfig(cfg),
 		vm.WithLogger(app.addLogger(VMLogger, lg)))
+	priority, err := parsePriorityConfig(app.Config.BaseConfig)
+	if err != nil {
+		return err
+	}
 	app.conState = txs.NewConservativeState(state, app.db,
 		txs.WithCSConfig(txs.CSConfig{
 			BlockGasLimit:     app.Config.BlockGasLimit,
 			NumTXsPerProposal: app.Config.TxsPerProposal,
+			Priority:          priority,
 		}),
 		txs.WithLogger(app.addLogger(ConStateLogger, lg)))
 
@@ -677,11 +921,23 @@ func (app *App) initServices(ctx context.Context) error {
 	app.edVerifier = signing.NewEdVerifier(
 		signing.WithVerifierPrefix(app.Config.Genesis.GenesisID().Bytes()),
 	)
+	// gossip validators verify many signatures concurrently and can wait for the batch,
+	// other components keep verifying synchronously
+	app.gossipVerifier = signing.NewEdVerifier(
+		signing.WithVerifierPrefix(app.Config.Genesis.GenesisID().Bytes()),
+		signing.WithVerifierBatch(app.Config.SignatureBatchWindow, app.Config.SignatureBatchSize),
+	)
 
+	if app.Config.Beacon.UnsafeInjection && onMainNet(app.Config) {
+		return errors.New("unsafe beacon injection can't be enabled on mainnet")
+	}
+	if err := app.Config.Beacon.Validate(); err != nil {
+		return fmt.Errorf("invalid beacon config: %w", err)
+	}
 	vrfVerifier := signing.NewVRFVerifier()
 	beaconProtocol := beacon.New(
 		app.host,
-		app.edVerifier,
+		app.gossipVerifier,
 		vrfVerifier,
 		app.cachedDB,
 		app.clock,
@@ -689,7 +945,7 @@ func (app *App) initServices(ctx context.Context) error {
 		beacon.WithConfig(app.Config.Beacon),
 		beacon.WithLogger(app.addLogger(BeaconLogger, lg)),
 	)
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		beaconProtocol.Register(sig)
 	}
 
@@ -702,6 +958,17 @@ func (app *App) initServices(ctx context.Context) error {
 		tortoise.WithLogger(app.addLogger(TrtlLogger, lg)),
 		tortoise.WithConfig(trtlCfg),
 	}
+	if trtlCfg.ReplayCheck {
+		if err := app.replayTortoise(ctx, trtlCfg.WindowSize, trtlopts...); err != nil {
+			return err
+		}
+	}
+	if trtlCfg.SpillMemory > 0 {
+		trtlopts = append(trtlopts, tortoise.WithSpillStore(app.localDB.Database))
+	}
+	if trtlCfg.SnapshotInterval > 0 {
+		trtlopts = append(trtlopts, tortoise.WithSnapshotStore(app.localDB.Database))
+	}
 	if trtlCfg.EnableTracer {
 		app.log.With().Info("tortoise will trace execution")
 		trtlopts = append(trtlopts, tortoise.WithTracer())
@@ -740,7 +1007,19 @@ func (app *App) initServices(ctx context.Context) error {
 		return fmt.Errorf("create mesh: %w", err)
 	}
 
-	pruner := prune.New(app.db, app.Config.Tortoise.Hdist, app.Config.PruneActivesetsFrom, prune.WithLogger(mlog.Zap()))
+	pruneOpts := []prune.Opt{
+		prune.WithLogger(mlog.Zap()),
+		prune.WithAccountsRetention(app.Config.AccountsRetention),
+	}
+	if app.Config.CertificateArchive {
+		pruneOpts = append(pruneOpts, prune.WithCertificateArchive())
+	}
+	pruner := prune.New(
+		app.db,
+		app.Config.Tortoise.Hdist,
+		app.Config.PruneActivesetsFrom,
+		pruneOpts...,
+	)
 	if err := pruner.Prune(app.clock.CurrentLayer()); err != nil {
 		return fmt.Errorf("pruner %w", err)
 	}
@@ -749,12 +1028,61 @@ func (app *App) initServices(ctx context.Context) error {
 		return nil
 	})
 
+	builderLog := app.addLogger(ProposalBuilderLogger, lg)
+	app.activeSetTracker = miner.NewActiveSetTracker(
+		builderLog.WithName("activeset"),
+		app.cachedDB,
+		app.localDB,
+		app.clock,
+		app.Config.ATXGradeDelay,
+	)
+	app.estimator = miner.NewEligibilityEstimator(
+		app.activeSetTracker,
+		layerSize,
+		layersPerEpoch,
+		app.Config.Tortoise.MinimalActiveSetWeight,
+	)
+	if app.Config.SigningGuard {
+		guardOpts := []miner.GuardOpt{
+			miner.WithGuardLogger(builderLog.WithName("guard")),
+			miner.WithLeaseDir(app.Config.SigningLeaseDir),
+			// lease is renewed every layer
+			miner.WithLeaseTTL(3 * app.Config.LayerDuration),
+		}
+		if app.Config.SigningLockout > 0 {
+			guardOpts = append(guardOpts, miner.WithLockout(app.Config.SigningLockout))
+		}
+		app.signingGuard, err = miner.NewSigningGuard(app.localDB, app.clock, guardOpts...)
+		if err != nil {
+			return fmt.Errorf("create signing guard: %w", err)
+		}
+	}
+
+	var watchedCoinbase *types.Address
+	if app.Config.Watchdog.Coinbase != "" {
+		coinbase, err := types.StringToAddress(app.Config.Watchdog.Coinbase)
+		if err != nil {
+			return fmt.Errorf("parse watchdog coinbase %s: %w", app.Config.Watchdog.Coinbase, err)
+		}
+		watchedCoinbase = &coinbase
+	}
+	app.watchdog = activation.NewWatchdog(
+		app.addLogger(ATXHandlerLogger, lg).Zap().Named("watchdog"),
+		app.cachedDB,
+		app.clock,
+		app.Config.Watchdog.Identities,
+		watchedCoinbase,
+	)
+
+	if err := app.Config.AtxVersions.Validate(); err != nil {
+		return fmt.Errorf("atx versions: %w", err)
+	}
 	fetcherWrapped := &layerFetcher{}
 	atxHandler := activation.NewHandler(
 		app.host.ID(),
 		app.cachedDB,
 		app.atxsdata,
-		app.edVerifier,
+		app.gossipVerifier,
 		app.clock,
 		app.host,
 		fetcherWrapped,
@@ -764,8 +1092,13 @@ func (app *App) initServices(ctx context.Context) error {
 		beaconProtocol,
 		trtl,
 		app.addLogger(ATXHandlerLogger, lg),
+		activation.WithAtxValidationConfig(app.Config.AtxValidation),
+		activation.WithHandlerGoldenATXs(app.golden),
+		activation.WithHandlerAtxVersions(app.Config.AtxVersions),
+		activation.WithAtxReceiver(app.activeSetTracker),
+		activation.WithAtxReceiver(app.watchdog),
 	)
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		atxHandler.Register(sig)
 	}
 
@@ -800,12 +1133,17 @@ func (app *App) initServices(ctx context.Context) error {
 	// TODO: genesisMinerWeight is set to app.Config.SpaceToCommit, because PoET ticks are currently hardcoded to 1
 
 	bscfg := app.Config.Bootstrap
+	if err := bscfg.Validate(); err != nil {
+		return fmt.Errorf("invalid bootstrap config: %w", err)
+	}
 	bscfg.DataDir = app.Config.DataDir()
 	bscfg.Interval = app.Config.LayerDuration / 5
 	app.updater = bootstrap.New(
 		app.clock,
 		bootstrap.WithConfig(bscfg),
 		bootstrap.WithLogger(app.addLogger(BootstrapLogger, lg)),
+		bootstrap.WithVerifier(app.edVerifier),
+		bootstrap.WithParamsHandler(app.applyParams),
 	)
 	if app.Config.Certificate.CommitteeSize == 0 {
 		app.log.With().Warning("certificate committee size is not set, defaulting to hare committee size",
@@ -818,7 +1156,7 @@ func (app *App) initServices(ctx context.Context) error {
 	app.certifier = blocks.NewCertifier(
 		app.db,
 		app.hOracle,
-		app.edVerifier,
+		app.gossipVerifier,
 		app.host,
 		app.clock,
 		beaconProtocol,
@@ -826,7 +1164,7 @@ func (app *App) initServices(ctx context.Context) error {
 		blocks.WithCertConfig(app.Config.Certificate),
 		blocks.WithCertifierLogger(app.addLogger(BlockCertLogger, lg)),
 	)
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		app.certifier.Register(sig)
 	}
 
@@ -837,11 +1175,18 @@ func (app *App) initServices(ctx context.Context) error {
 	)
 
 	flog := app.addLogger(Fetcher, lg)
-	fetcher := fetch.NewFetch(app.cachedDB, proposalsStore, app.host,
+	fetchOpts := []fetch.Option{
 		fetch.WithContext(ctx),
 		fetch.WithConfig(app.Config.FETCH),
 		fetch.WithLogger(flog),
-	)
+		fetch.WithVerifier(app.edVerifier),
+	}
+	if len(app.signers) > 0 {
+		// requests are attributed to the first identity if the node runs several,
+		// remote signers are not asked to sign requests as that would add a round trip to every request
+		fetchOpts = append(fetchOpts, fetch.WithSigner(app.signers[0]))
+	}
+	fetcher := fetch.NewFetch(app.cachedDB, proposalsStore, app.host, fetchOpts...)
 	fetcherWrapped.Fetcher = fetcher
 	app.eg.Go(func() error {
 		return blockssync.Sync(ctx, flog.Zap(), msh.MissingBlocks(), fetcher)
@@ -879,20 +1224,32 @@ func (app *App) initServices(ctx context.Context) error {
 	}
 	logger := app.addLogger(HareLogger, lg).Zap()
 
+	hareOpts := []hare3.Opt{
+		hare3.WithLogger(logger),
+		hare3.WithConfig(app.Config.HARE3),
+		hare3.WithWallclock(app.timeSource),
+		hare3.WithLocalDB(app.localDB),
+		hare3.WithExchange(app.host),
+	}
+	if app.Config.HARE3.RecordLayers > 0 {
+		logger.Info("hare will record messages", zap.Uint32("layers", app.Config.HARE3.RecordLayers))
+		hareOpts = append(hareOpts, hare3.WithRecorder(
+			hare3.NewRecorder(app.localDB, app.Config.HARE3.RecordLayers, logger.Named("recorder")),
+		))
+	}
 	app.hare3 = hare3.New(
 		app.clock,
 		app.host,
 		app.db,
 		app.atxsdata,
 		proposalsStore,
-		app.edVerifier,
+		app.gossipVerifier,
 		app.hOracle,
 		newSyncer,
 		patrol,
-		hare3.WithLogger(logger),
-		hare3.WithConfig(app.Config.HARE3),
+		hareOpts...,
 	)
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		app.hare3.Register(sig)
 	}
 	app.hare3.Start()
@@ -906,11 +1263,25 @@ func (app *App) initServices(ctx context.Context) error {
 		return nil
 	})
 
+	proposalOpts := []proposals.Opt{
+		proposals.WithLogger(app.addLogger(ProposalListenerLogger, lg)),
+		proposals.WithConfig(proposals.Config{
+			LayerSize:              layerSize,
+			LayersPerEpoch:         layersPerEpoch,
+			GoldenATXID:            goldenATXID,
+			MaxExceptions:          trtlCfg.MaxExceptions,
+			Hdist:                  trtlCfg.Hdist,
+			MinimalActiveSetWeight: trtlCfg.MinimalActiveSetWeight,
+		}),
+	}
+	if app.signingGuard != nil {
+		proposalOpts = append(proposalOpts, proposals.WithBallotReceiver(app.signingGuard))
+	}
 	proposalListener := proposals.NewHandler(
 		app.db,
 		app.atxsdata,
 		app.hare3,
-		app.edVerifier,
+		app.gossipVerifier,
 		app.host,
 		fetcherWrapped,
 		beaconProtocol,
@@ -918,15 +1289,7 @@ func (app *App) initServices(ctx context.Context) error {
 		trtl,
 		vrfVerifier,
 		app.clock,
-		proposals.WithLogger(app.addLogger(ProposalListenerLogger, lg)),
-		proposals.WithConfig(proposals.Config{
-			LayerSize:              layerSize,
-			LayersPerEpoch:         layersPerEpoch,
-			GoldenATXID:            goldenATXID,
-			MaxExceptions:          trtlCfg.MaxExceptions,
-			Hdist:                  trtlCfg.Hdist,
-			MinimalActiveSetWeight: trtlCfg.MinimalActiveSetWeight,
-		}),
+		proposalOpts...,
 	)
 
 	app.blockGen = blocks.NewGenerator(
@@ -964,9 +1327,11 @@ func (app *App) initServices(ctx context.Context) error {
 		miner.WithHdist(app.Config.Tortoise.Hdist),
 		miner.WithNetworkDelay(app.Config.ATXGradeDelay),
 		miner.WithMinGoodAtxPercent(minerGoodAtxPct),
-		miner.WithLogger(app.addLogger(ProposalBuilderLogger, lg)),
+		miner.WithActiveSetTracker(app.activeSetTracker),
+		miner.WithSigningGuard(app.signingGuard),
+		miner.WithLogger(builderLog),
 	)
-	for _, sig := range app.signers {
+	for _, sig := range app.identities() {
 		proposalBuilder.Register(sig)
 	}
 
@@ -998,24 +1363,59 @@ func (app *App) initServices(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("init post grpc service: %w", err)
 	}
+	nipostLogger := app.addLogger(NipostBuilderLogger, lg).Zap()
+	nipostOpts := []activation.NIPostBuilderOption{activation.NipostbuilderWithPostStates(postStates)}
+	if endpoint := app.Config.PoetProxy.Endpoint; endpoint != "" {
+		poets := make([]activation.PoetClient, 0, len(app.Config.PoetServers))
+		for _, server := range app.Config.PoetServers {
+			client, err := poetproxy.NewClient(nipostLogger.Named("poet"), endpoint, server, app.Config.POET)
+			if err != nil {
+				return fmt.Errorf("create poet proxy client: %w", err)
+			}
+			poets = append(poets, client)
+		}
+		nipostOpts = append(nipostOpts, activation.WithPoetClients(poets...))
+	}
+	if app.Config.PoetProxy.Listen != "" {
+		app.poetProxy, err = poetproxy.NewHTTPServer(
+			nipostLogger.Named("poet_proxy"),
+			app.Config.PoetProxy,
+			app.Config.PoetServers,
+			app.Config.POET,
+		)
+		if err != nil {
+			return fmt.Errorf("create poet proxy: %w", err)
+		}
+	}
 	nipostBuilder, err := activation.NewNIPostBuilder(
 		app.localDB,
 		poetDb,
 		grpcPostService.(*grpcserver.PostService),
 		app.Config.PoetServers,
-		app.addLogger(NipostBuilderLogger, lg).Zap(),
+		nipostLogger,
 		app.Config.POET,
 		app.clock,
-		activation.NipostbuilderWithPostStates(postStates),
+		nipostOpts...,
 	)
 	if err != nil {
 		return fmt.Errorf("create nipost builder: %w", err)
 	}
 
+	if err := app.Config.SMESHING.LatePublish.Validate(); err != nil {
+		return err
+	}
 	builderConfig := activation.Config{
-		GoldenATXID:      goldenATXID,
-		LabelsPerUnit:    app.Config.POST.LabelsPerUnit,
-		RegossipInterval: app.Config.RegossipAtxInterval,
+		GoldenATXID:        goldenATXID,
+		LabelsPerUnit:      app.Config.POST.LabelsPerUnit,
+		RegossipInterval:   app.Config.RegossipAtxInterval,
+		RegossipProbePeers: app.Config.RegossipAtxProbePeers,
+		RegossipRate:       app.Config.RegossipAtxRate,
+		LatePublish:        app.Config.SMESHING.LatePublish,
+		VRFKey:             app.Config.SMESHING.VRFKey,
+	}
+	poetAddresses := make([]string, 0, len(app.Config.PoetServers))
+	for _, server := range app.Config.PoetServers {
+		poetAddresses = append(poetAddresses, server.Address)
 	}
 	atxBuilder := activation.NewBuilder(
 		builderConfig,
@@ -1028,13 +1428,17 @@ func (app *App) initServices(ctx context.Context) error {
 		app.addLogger(ATXBuilderLogger, lg).Zap(),
 		activation.WithContext(ctx),
 		activation.WithPoetConfig(app.Config.POET),
+		activation.WithPoetAddresses(poetAddresses...),
 		// TODO(dshulyak) makes no sense. how we ended using it?
 		activation.WithPoetRetryInterval(app.Config.HARE3.PreroundDelay),
 		activation.WithValidator(app.validator),
 		activation.WithPostValidityDelay(app.Config.PostValidDelay),
 		activation.WithPostStates(postStates),
+		activation.WithBuilderGoldenATXs(app.golden),
+		activation.WithBuilderAtxVersions(app.Config.AtxVersions),
+		activation.WithHashProber(fetcher),
 	)
-	if len(app.signers) > 1 || app.signers[0].Name() != supervisedIDKeyFileName {
+	if !app.supervised() {
 		// in a remote setup we register eagerly so the atxBuilder can warn about missing connections asap.
 		// Any setup with more than one signer is considered a remote setup. If there is only one signer it
 		// is considered a remote setup if the key for the signer has not been sourced from `supervisedIDKeyFileName`.
@@ -1042,7 +1446,7 @@ func (app *App) initServices(ctx context.Context) error {
 		// In a supervised setup the postSetupManager will register at the atxBuilder when
 		// it finished initializing, to avoid warning about a missing connection when the supervised post
 		// service isn't ready yet.
-		for _, sig := range app.signers {
+		for _, sig := range app.identities() {
 			atxBuilder.Register(sig)
 		}
 	}
@@ -1058,8 +1462,8 @@ func (app *App) initServices(ctx context.Context) error {
 		return fmt.Errorf("init post service: %w", err)
 	}
 
-	nodeIDs := make([]types.NodeID, 0, len(app.signers))
-	for _, s := range app.signers {
+	nodeIDs := make([]types.NodeID, 0, len(app.signers)+len(app.remoteSigners))
+	for _, s := range app.identities() {
 		nodeIDs = append(nodeIDs, s.NodeID())
 	}
 	malfeasanceHandler := malfeasance.NewHandler(
@@ -1067,7 +1471,7 @@ func (app *App) initServices(ctx context.Context) error {
 		app.addLogger(MalfeasanceLogger, lg),
 		app.host.ID(),
 		nodeIDs,
-		app.edVerifier,
+		app.gossipVerifier,
 		trtl,
 		app.postVerifier,
 	)
@@ -1127,6 +1531,7 @@ func (app *App) initServices(ctx context.Context) error {
 		}
 		return errors.New("not synced for gossip")
 	}
+
 	atxSyncHandler := func(_ context.Context, _ p2p.Peer, _ []byte) error {
 		if newSyncer.ListenToATXGossip() {
 			return nil
@@ -1134,6 +1539,21 @@ func (app *App) initServices(ctx context.Context) error {
 		return errors.New("not synced for gossip")
 	}
 
+	txHandler := pubsub.ChainGossipHandler(syncHandler, app.txHandler.HandleGossipTransaction)
+	if !app.Config.DiskSpace.Disable {
+		paths := []diskspace.Opt{diskspace.WithPath("database", app.Config.DataDir())}
+		if app.Config.SMESHING.Start {
+			paths = append(paths, diskspace.WithPath("post", app.Config.SMESHING.Opts.DataDir))
+		}
+		app.diskMonitor = diskspace.New(append(paths,
+			diskspace.WithLogger(app.addLogger(DiskSpaceLogger, lg).Zap()),
+			diskspace.WithConfig(app.Config.DiskSpace),
+		)...)
+		// transactions are not essential for the node to follow consensus, they are dropped
+		// first to slow down the growth of the database
+		txHandler = pubsub.ChainGossipHandler(app.diskMonitor.NonEssentialGossip, txHandler)
+	}
+
 	if app.Config.Beacon.RoundsNumber > 0 {
 		app.host.Register(
 			pubsub.BeaconWeakCoinProtocol,
@@ -1156,6 +1576,10 @@ func (app *App) initServices(ctx context.Context) error {
 			pubsub.WithValidatorInline(true),
 		)
 	}
+	if app.Config.Beacon.FallbackQuorum > 0 {
+		// fallback beacon is accepted without waiting for sync, as it is needed when the protocol fails
+		app.host.Register(pubsub.BeaconFallbackProtocol, beaconProtocol.HandleFallbackBeacon)
+	}
 	app.host.Register(
 		pubsub.ProposalProtocol,
 		pubsub.ChainGossipHandler(syncHandler, proposalListener.HandleProposal),
@@ -1167,7 +1591,7 @@ func (app *App) initServices(ctx context.Context) error {
 	)
 	app.host.Register(
 		pubsub.TxProtocol,
-		pubsub.ChainGossipHandler(syncHandler, app.txHandler.HandleGossipTransaction),
+		txHandler,
 	)
 	app.host.Register(
 		pubsub.BlockCertify,
@@ -1177,6 +1601,10 @@ func (app *App) initServices(ctx context.Context) error {
 		pubsub.MalfeasanceProof,
 		pubsub.ChainGossipHandler(atxSyncHandler, malfeasanceHandler.HandleMalfeasanceProof),
 	)
+	app.host.Register(
+		pubsub.PoetFraudProtocol,
+		pubsub.ChainGossipHandler(atxSyncHandler, poetDb.HandleFraudReport),
+	)
 
 	app.proposalBuilder = proposalBuilder
 	app.proposalListener = proposalListener
@@ -1186,6 +1614,7 @@ func (app *App) initServices(ctx context.Context) error {
 	app.atxBuilder = atxBuilder
 	app.nipostBuilder = nipostBuilder
 	app.atxHandler = atxHandler
+	app.malHandler = malfeasanceHandler
 	app.poetDb = poetDb
 	app.fetcher = fetcher
 	app.beaconProtocol = beaconProtocol
@@ -1198,6 +1627,26 @@ func (app *App) initServices(ctx context.Context) error {
 			peersync.WithConfig(app.Config.TIME.Peersync),
 		)
 	}
+	if !app.Config.TIME.NTP.Disable {
+		app.ntpMonitor = ntp.New(
+			app.Config.LayerDuration,
+			ntp.WithLogger(app.addLogger(TimeSyncLogger, lg).Zap()),
+			ntp.WithConfig(app.Config.TIME.NTP),
+		)
+	}
+	app.checkpointServer = checkpoint.NewServer(
+		app.addLogger(CheckpointLogger, lg),
+		afero.NewOsFs(),
+		app.Config.DataDir(),
+		app.host,
+	)
+	app.checkpoints = checkpoint.NewScheduler(
+		app.addLogger(CheckpointLogger, lg),
+		afero.NewOsFs(),
+		app.db,
+		app.Config.DataDir(),
+		app.Config.Checkpoint,
+	)
 	if err := app.host.Start(); err != nil {
 		return err
 	}
@@ -1285,6 +1734,16 @@ func (app *App) listenToUpdates(ctx context.Context) {
 						return nil
 					}
 				}
+				if update.Data.GoldenAtx != types.EmptyATXID {
+					if err := app.golden.Add(update.Data.Epoch, update.Data.GoldenAtx); err != nil {
+						app.log.With().Error("failed to add golden atx from bootstrap update", log.Err(err))
+					} else {
+						app.log.With().Info("golden atx for network upgrade",
+							update.Data.Epoch,
+							log.Stringer("golden_atx", update.Data.GoldenAtx),
+						)
+					}
+				}
 				if len(update.Data.ActiveSet) > 0 {
 					epoch := update.Data.Epoch
 					set := update.Data.ActiveSet
@@ -1325,15 +1784,89 @@ func (app *App) listenToUpdates(ctx context.Context) {
 	})
 }
 
+// applyParams applies the runtime parameters from the update signed by the quorum of the bootstrap authorities.
+// Parameters are applied independently, the error is returned for every parameter that failed to apply.
+func (app *App) applyParams(ctx context.Context, params *bootstrap.VerifiedParams) error {
+	var errs []error
+	if len(params.PoetServers) > 0 {
+		if err := app.nipostBuilder.SetPoetServers(params.PoetServers); err != nil {
+			errs = append(errs, fmt.Errorf("set poet servers: %w", err))
+		} else {
+			addresses := make([]string, 0, len(params.PoetServers))
+			for _, server := range params.PoetServers {
+				addresses = append(addresses, server.Address)
+			}
+			app.atxBuilder.SetPoetAddresses(addresses...)
+		}
+	}
+	for epoch, id := range params.GoldenATXs {
+		if err := app.golden.Add(epoch, id); err != nil {
+			errs = append(errs, fmt.Errorf("add golden atx: %w", err))
+			continue
+		}
+		app.log.WithContext(ctx).With().Info("golden atx for network upgrade from params update",
+			epoch,
+			log.Stringer("golden_atx", id),
+		)
+	}
+	if len(params.FallbackAuthorities) > 0 {
+		if err := app.beaconProtocol.SetFallbackAuthorities(params.FallbackAuthorities); err != nil {
+			errs = append(errs, fmt.Errorf("set fallback beacon authorities: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (app *App) startServices(ctx context.Context) error {
+	app.eg.Go(func() error {
+		return app.poetDb.Run(ctx)
+	})
 	if err := app.fetcher.Start(); err != nil {
 		return fmt.Errorf("start fetcher: %w", err)
 	}
 	app.syncer.Start()
 	app.beaconProtocol.Start(ctx)
+	app.eg.Go(func() error {
+		return app.checkpointServer.Run(ctx)
+	})
+	app.eg.Go(func() error {
+		return app.checkpoints.Run(ctx, app.mesh, app.Config.LayerDuration)
+	})
 
 	app.blockGen.Start(ctx)
 	app.certifier.Start(ctx)
+	app.eg.Go(func() error {
+		return app.activeSetTracker.Run(ctx)
+	})
+	app.eg.Go(func() error {
+		return app.watchdog.Run(ctx)
+	})
+	if app.Config.Watchtower.Enable {
+		watchtower := malfeasance.NewWatchtower(
+			app.log.Zap().Named("watchtower"),
+			app.Config.Watchtower,
+			app.db,
+			app.localDB,
+			app.clock,
+			app.malHandler,
+			app.host,
+			app.edVerifier,
+		)
+		app.eg.Go(func() error {
+			return watchtower.Run(ctx)
+		})
+	}
+	if app.Config.Explorer.Enabled {
+		indexer := explorer.New(app.db, app.log.Zap().Named("explorer"), app.Config.Explorer)
+		app.eg.Go(func() error {
+			return indexer.Run(ctx)
+		})
+	}
+	if app.poetProxy != nil {
+		app.eg.Go(func() error {
+			return app.poetProxy.Run(ctx)
+		})
+	}
 	app.eg.Go(func() error {
 		return app.proposalBuilder.Run(ctx)
 	})
@@ -1356,7 +1889,7 @@ func (app *App) startServices(ctx context.Context) error {
 		if app.Config.SMESHING.CoinbaseAccount == "" {
 			return fmt.Errorf("smeshing enabled but no coinbase account provided")
 		}
-		if len(app.signers) > 1 {
+		if len(app.signers) != 1 || len(app.remoteSigners) > 0 {
 			return fmt.Errorf("supervised smeshing cannot be started in a multi-smeshing setup")
 		}
 		if err := app.postSupervisor.Start(
@@ -1372,6 +1905,27 @@ func (app *App) startServices(ctx context.Context) error {
 	if app.ptimesync != nil {
 		app.ptimesync.Start()
 	}
+	if app.ntpMonitor != nil {
+		app.eg.Go(func() error {
+			return app.ntpMonitor.Run(ctx)
+		})
+	}
+	if app.diskMonitor != nil {
+		app.diskMonitor.OnProtectiveMode(func(protected bool) {
+			if protected {
+				if _, err := app.postSupervisor.Pause(); err != nil {
+					app.log.With().Error("failed to pause post initialization", log.Err(err))
+				}
+				return
+			}
+			if err := app.postSupervisor.Resume(); err != nil {
+				app.log.With().Error("failed to resume post initialization", log.Err(err))
+			}
+		})
+		app.eg.Go(func() error {
+			return app.diskMonitor.Run(ctx)
+		})
+	}
 
 	if app.updater != nil {
 		app.listenToUpdates(ctx)
@@ -1390,7 +1944,13 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 		app.grpcServices[svc] = service
 		return service, nil
 	case grpcserver.GlobalState:
-		service := grpcserver.NewGlobalStateService(app.mesh, app.conState)
+		service := grpcserver.NewGlobalStateService(
+			app.db,
+			app.mesh,
+			app.conState,
+			app.clock,
+			app.Config.AccountsRetention,
+		)
 		app.grpcServices[svc] = service
 		return service, nil
 	case grpcserver.Mesh:
@@ -1408,6 +1968,13 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 		app.grpcServices[svc] = service
 		return service, nil
 	case grpcserver.Node:
+		var opts []grpcserver.NodeServiceOpt
+		if app.ptimesync != nil {
+			opts = append(opts, grpcserver.WithClockOffset("peers", app.ptimesync))
+		}
+		if app.ntpMonitor != nil {
+			opts = append(opts, grpcserver.WithClockOffset("ntp", app.ntpMonitor))
+		}
 		service := grpcserver.NewNodeService(
 			app.host,
 			app.mesh,
@@ -1415,16 +1982,80 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 			app.syncer,
 			cmd.Version,
 			cmd.Commit,
+			opts...,
 		)
 		app.grpcServices[svc] = service
 		return service, nil
+	case grpcserver.Health:
+		checks, err := app.healthChecks(lg)
+		if err != nil {
+			return nil, err
+		}
+		service := grpcserver.NewHealthService(app.Config.API.Health, checks...)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Hare:
+		service := grpcserver.NewHareService(app.db, app.localDB)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.State:
+		service := grpcserver.NewStateService(app.db)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Tortoise:
+		service := grpcserver.NewTortoiseService(app.tortoise)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Clock:
+		service := grpcserver.NewClockService(app.timeSource, app.clock)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Explorer:
+		service := grpcserver.NewExplorerService(app.db)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.PostVerifier:
+		service := remote.NewService(
+			app.addLogger(NipostValidatorLogger, app.log).Zap().Named("service"),
+			app.postVerifier,
+			app.Config.POST,
+			app.Config.SMESHING.Opts.Scrypt,
+		)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Beacon:
+		service := grpcserver.NewBeaconService(app.db, app.beaconProtocol)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Malfeasance:
+		service := grpcserver.NewMalfeasanceService(app.malHandler, app.host)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.ActiveSet:
+		service := grpcserver.NewActiveSetService(app.activeSetTracker, app.clock)
+		app.grpcServices[svc] = service
+		return service, nil
+	case grpcserver.Watchdog:
+		service := grpcserver.NewWatchdogService(app.watchdog)
+		app.grpcServices[svc] = service
+		return service, nil
 	case grpcserver.Admin:
-		service := grpcserver.NewAdminService(app.db, app.Config.DataDir(), app.host)
+		service := grpcserver.NewAdminService(
+			app.db,
+			app.Config.DataDir(),
+			app.host,
+			app.checkpoints,
+			grpcserver.WithBundleConfig(app.Config),
+			grpcserver.WithBundleVersion(cmd.Version, cmd.Commit),
+			grpcserver.WithLogModules(app.logModules),
+			grpcserver.WithBeaconProtocol(app.beaconProtocol, app.clock),
+			grpcserver.WithAtxQuarantine(app.atxHandler),
+		)
 		app.grpcServices[svc] = service
 		return service, nil
 	case grpcserver.Smesher:
 		var sig *signing.EdSigner
-		if len(app.signers) == 1 && app.signers[0].Name() == supervisedIDKeyFileName {
+		if app.supervised() {
 			// StartSmeshing is only supported in a supervised setup (single signer)
 			sig = app.signers[0]
 		}
@@ -1434,6 +2065,8 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 			app.Config.API.SmesherStreamInterval,
 			sig,
 			app.Config.SMESHING.Opts,
+			grpcserver.WithEligibilityEstimator(app.estimator, app.clock),
+			grpcserver.WithIdentityState(app.db, app.localDB, app.atxBuilder, app.clock),
 		)
 		app.grpcServices[svc] = service
 		return service, nil
@@ -1469,7 +2102,7 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 		app.grpcServices[svc] = service
 		return service, nil
 	case v2alpha1.Reward:
-		service := v2alpha1.NewRewardService(app.db)
+		service := v2alpha1.NewRewardService(app.db, app.Config.LayerDuration)
 		app.grpcServices[svc] = service
 		return service, nil
 	case v2alpha1.RewardStream:
@@ -1480,6 +2113,53 @@ func (app *App) grpcService(svc grpcserver.Service, lg log.Log) (grpcserver.Serv
 	return nil, fmt.Errorf("unknown service %s", svc)
 }
 
+// healthChecks returns the checks of the subsystems that are enabled in this node.
+func (app *App) healthChecks(lg log.Log) ([]grpcserver.HealthCheck, error) {
+	checks := []grpcserver.HealthCheck{
+		grpcserver.SyncHealthCheck(app.syncer),
+		grpcserver.PeersHealthCheck(app.host, app.Config.API.Health.MinPeers),
+		grpcserver.DatabaseHealthCheck("state_db", app.db),
+		grpcserver.DatabaseHealthCheck("local_db", app.localDB),
+		grpcserver.DiskHealthCheck(
+			app.Config.DataDir(),
+			app.Config.API.Health.WarnFreeDisk,
+			app.Config.API.Health.FailFreeDisk,
+		),
+	}
+	if app.ptimesync != nil {
+		checks = append(checks, grpcserver.ClockHealthCheck(app.ptimesync))
+	}
+	if app.ntpMonitor != nil {
+		checks = append(checks, grpcserver.NTPHealthCheck(app.ntpMonitor))
+	}
+	if app.Config.SMESHING.Start || !app.supervised() {
+		postService, err := app.grpcService(grpcserver.Post, lg)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]types.NodeID, 0, len(app.signers)+len(app.remoteSigners))
+		for _, sig := range app.identities() {
+			ids = append(ids, sig.NodeID())
+		}
+		checks = append(checks, grpcserver.PostHealthCheck(postService.(*grpcserver.PostService), ids))
+	}
+	if len(app.Config.PoetServers) > 0 {
+		// probes are expected to respond quickly, failed requests are not retried
+		cfg := app.Config.POET
+		cfg.MaxRequestRetries = 0
+		poets := make([]*activation.HTTPPoetClient, 0, len(app.Config.PoetServers))
+		for _, server := range app.Config.PoetServers {
+			client, err := activation.NewHTTPPoetClient(server, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("create poet client: %w", err)
+			}
+			poets = append(poets, client)
+		}
+		checks = append(checks, grpcserver.PoetHealthCheck(poets))
+	}
+	return checks, nil
+}
+
 func (app *App) startAPIServices(ctx context.Context) error {
 	logger := app.addLogger(GRPCLogger, app.log)
 	grpczap.SetGrpcLoggerV2(grpclog, logger.Zap())
@@ -1537,6 +2217,34 @@ func (app *App) startAPIServices(ctx context.Context) error {
 		authenticatedSvcs[svc] = gsvc
 	}
 
+	// authorization is enforced on all listeners except the one used by local post services
+	var (
+		auth     *grpcserver.Authorizer
+		authOpts []grpc.ServerOption
+	)
+	if app.Config.API.Auth.Enabled {
+		var err error
+		auth, err = grpcserver.NewAuthorizer(app.Config.API.Auth)
+		if err != nil {
+			return fmt.Errorf("grpc auth: %w", err)
+		}
+		authOpts = auth.ServerOptions()
+	}
+
+	// quotas are enforced on the listeners that are meant to be exposed publicly
+	var (
+		limiter    *grpcserver.RateLimiter
+		publicOpts = authOpts
+	)
+	if app.Config.API.RateLimit.Enabled {
+		var err error
+		limiter, err = grpcserver.NewRateLimiter(app.Config.API.RateLimit)
+		if err != nil {
+			return fmt.Errorf("grpc rate limit: %w", err)
+		}
+		publicOpts = append(limiter.ServerOptions(), authOpts...)
+	}
+
 	// start servers if at least one endpoint is defined for them
 	if len(publicSvcs) > 0 {
 		var err error
@@ -1545,6 +2253,7 @@ func (app *App) startAPIServices(ctx context.Context) error {
 			logger.Zap(),
 			app.Config.API,
 			maps.Values(publicSvcs),
+			publicOpts...,
 		)
 		if err != nil {
 			return err
@@ -1560,6 +2269,7 @@ func (app *App) startAPIServices(ctx context.Context) error {
 			logger.Zap(),
 			app.Config.API,
 			maps.Values(privateSvcs),
+			authOpts...,
 		)
 		if err != nil {
 			return err
@@ -1586,7 +2296,12 @@ func (app *App) startAPIServices(ctx context.Context) error {
 
 	if len(authenticatedSvcs) > 0 && app.Config.API.TLSListener != "" {
 		var err error
-		app.grpcTLSServer, err = grpcserver.NewTLS(logger.Zap(), app.Config.API, maps.Values(authenticatedSvcs))
+		app.grpcTLSServer, err = grpcserver.NewTLS(
+			logger.Zap(),
+			app.Config.API,
+			maps.Values(authenticatedSvcs),
+			authOpts...,
+		)
 		if err != nil {
 			return err
 		}
@@ -1596,131 +2311,210 @@ func (app *App) startAPIServices(ctx context.Context) error {
 	}
 
 	if len(app.Config.API.JSONListener) > 0 {
-		if len(publicSvcs) == 0 {
-			return fmt.Errorf("start json server without public services")
+		jsonSvcs := publicSvcs
+		if len(app.Config.API.JSONServices) > 0 {
+			jsonSvcs = make(map[grpcserver.Service]grpcserver.ServiceAPI, len(app.Config.API.JSONServices))
+			for _, svc := range app.Config.API.JSONServices {
+				if _, exists := jsonSvcs[svc]; exists {
+					return fmt.Errorf("can't start more than one %s on json endpoint", svc)
+				}
+				gsvc, err := app.grpcService(svc, app.log)
+				if err != nil {
+					return err
+				}
+				logger.Info("registering json service %s", svc)
+				jsonSvcs[svc] = gsvc
+			}
+		}
+		if len(jsonSvcs) == 0 {
+			return fmt.Errorf("start json server without services")
+		}
+		var opts []grpcserver.JSONHTTPServerOpt
+		if limiter != nil {
+			opts = append(opts, grpcserver.WithRateLimiter(limiter))
+		}
+		if auth != nil {
+			opts = append(opts, grpcserver.WithAuthorizer(auth))
 		}
 		app.jsonAPIServer = grpcserver.NewJSONHTTPServer(
 			app.Config.API.JSONListener,
 			logger.Zap().Named("JSON"),
+			opts...,
 		)
-		if err := app.jsonAPIServer.StartService(ctx, maps.Values(publicSvcs)...); err != nil {
+		if err := app.jsonAPIServer.StartService(ctx, maps.Values(jsonSvcs)...); err != nil {
 			return fmt.Errorf("start listen server: %w", err)
 		}
 	}
 	return nil
 }
 
+// stopServices stops the subsystems in dependency order: first the subsystems that accept data from
+// the network and the api, then the protocols, then the databases are flushed and closed.
+// The outcome is logged and written to shutdownReportFile in the data directory.
 func (app *App) stopServices(ctx context.Context) {
-	if app.jsonAPIServer != nil {
-		if err := app.jsonAPIServer.Shutdown(ctx); err != nil {
-			app.log.With().Error("error stopping json gateway server", log.Err(err))
-		}
+	coordinator := shutdown.New(app.log.Zap().Named("shutdown"), app.Config.Shutdown)
+	app.registerShutdown(coordinator)
+	report := coordinator.Run(ctx)
+	if err := app.writeShutdownReport(report); err != nil {
+		app.log.With().Warning("failed to write shutdown report", log.Err(err))
 	}
 
-	if app.grpcPublicServer != nil {
-		app.log.Info("stopping public grpc service")
-		app.grpcPublicServer.Close() // err is always nil
-	}
-	if app.grpcPrivateServer != nil {
-		app.log.Info("stopping private grpc service")
-		app.grpcPrivateServer.Close() // err is always nil
-	}
-	if app.grpcPostServer != nil {
-		app.log.Info("stopping local grpc service")
-		app.grpcPostServer.Close() // err is always nil
-	}
-	if app.grpcTLSServer != nil {
-		app.log.Info("stopping tls grpc service")
-		app.grpcTLSServer.Close() // err is always nil
+	events.CloseEventReporter()
+	// SetGrpcLogger unfortunately is global
+	// this ensures that a test-logger isn't used after the app shuts down
+	// by e.g. a grpc connection to the node that is still open - like in TestSpacemeshApp_NodeService
+	grpczap.SetGrpcLoggerV2(grpclog, log.NewNop().Zap())
+}
+
+// stopper adapts the stop function without result to the shutdown coordinator.
+func stopper(stop func()) func(context.Context) error {
+	return func(context.Context) error {
+		stop()
+		return nil
 	}
+}
 
+func (app *App) registerShutdown(c *shutdown.Coordinator) {
+	if app.jsonAPIServer != nil {
+		c.Add(shutdown.Intake, "json-api", app.jsonAPIServer.Shutdown)
+	}
+	for _, grpc := range []struct {
+		name   string
+		server *grpcserver.Server
+	}{
+		{"grpc-public", app.grpcPublicServer},
+		{"grpc-private", app.grpcPrivateServer},
+		{"grpc-post", app.grpcPostServer},
+		{"grpc-tls", app.grpcTLSServer},
+	} {
+		if grpc.server != nil {
+			server := grpc.server
+			c.Add(shutdown.Intake, grpc.name, func(context.Context) error { return server.Close() })
+		}
+	}
 	if app.updater != nil {
-		app.log.Info("stopping updater")
-		app.updater.Close()
+		c.Add(shutdown.Intake, "updater", func(context.Context) error { return app.updater.Close() })
+	}
+	if app.host != nil {
+		c.Add(shutdown.Intake, "p2p-host", func(context.Context) error { return app.host.Stop() })
 	}
 
 	if app.clock != nil {
-		app.clock.Close()
+		c.Add(shutdown.Consensus, "clock", stopper(app.clock.Close))
 	}
-
 	if app.beaconProtocol != nil {
-		app.beaconProtocol.Close()
+		c.Add(shutdown.Consensus, "beacon", stopper(app.beaconProtocol.Close))
+	}
+	if app.checkpoints != nil {
+		c.Add(shutdown.Consensus, "checkpoints", stopper(app.checkpoints.Stop))
 	}
-
 	if app.atxBuilder != nil {
-		app.atxBuilder.StopSmeshing(false)
+		c.Add(shutdown.Consensus, "atx-builder", func(context.Context) error {
+			if !app.atxBuilder.Smeshing() {
+				return nil
+			}
+			return app.atxBuilder.StopSmeshing(false)
+		})
 	}
-
 	if app.postVerifier != nil {
-		app.postVerifier.Close()
+		c.Add(shutdown.Consensus, "post-verifier", func(context.Context) error { return app.postVerifier.Close() })
 	}
-
 	if app.hare3 != nil {
-		app.hare3.Stop()
+		c.Add(shutdown.Consensus, "hare", stopper(app.hare3.Stop))
 	}
-
 	if app.blockGen != nil {
-		app.blockGen.Stop()
+		c.Add(shutdown.Consensus, "block-generator", stopper(app.blockGen.Stop))
 	}
-
 	if app.certifier != nil {
-		app.certifier.Stop()
+		c.Add(shutdown.Consensus, "certifier", stopper(app.certifier.Stop))
 	}
-
 	if app.fetcher != nil {
-		app.fetcher.Stop()
+		c.Add(shutdown.Consensus, "fetcher", stopper(app.fetcher.Stop))
 	}
-
 	if app.syncer != nil {
-		app.syncer.Close()
+		c.Add(shutdown.Consensus, "syncer", stopper(app.syncer.Close))
 	}
-
 	if app.postSupervisor != nil {
-		if err := app.postSupervisor.Stop(false); err != nil {
-			app.log.With().Error("error stopping local post service", log.Err(err))
-		}
+		c.Add(shutdown.Consensus, "post-supervisor", func(context.Context) error {
+			return app.postSupervisor.Stop(false)
+		})
 	}
-
 	if app.ptimesync != nil {
-		app.ptimesync.Stop()
-		app.log.Debug("peer timesync stopped")
+		c.Add(shutdown.Consensus, "peer-timesync", stopper(app.ptimesync.Stop))
 	}
-
-	if app.host != nil {
-		if err := app.host.Stop(); err != nil {
-			app.log.With().Warning("p2p host exited with error", log.Err(err))
-		}
+	if app.tortoise != nil {
+		c.Add(shutdown.Consensus, "tortoise-snapshot", func(context.Context) error { return app.tortoise.Snapshot() })
 	}
+
 	if app.db != nil {
-		if err := app.db.Close(); err != nil {
-			app.log.With().Warning("db exited with error", log.Err(err))
-		}
+		c.Add(shutdown.Storage, "state-db", func(context.Context) error {
+			return closeDB(app.db)
+		})
+		c.Check("state-db-wal", walCheck(filepath.Join(app.Config.DataDir(), dbFile)))
 	}
 	if app.dbMetrics != nil {
-		app.dbMetrics.Close()
+		c.Add(shutdown.Storage, "db-metrics", stopper(app.dbMetrics.Close))
 	}
 	if app.localDB != nil {
-		if err := app.localDB.Close(); err != nil {
-			app.log.With().Warning("local db exited with error", log.Err(err))
-		}
+		c.Add(shutdown.Storage, "local-db", func(context.Context) error {
+			return closeDB(app.localDB.Database)
+		})
+		c.Check("local-db-wal", walCheck(filepath.Join(app.Config.DataDir(), localDbFile)))
 	}
 
+	if len(app.remoteSigners) > 0 {
+		c.Add(shutdown.Final, "remote-signers", stopper(app.closeRemoteSigners))
+	}
 	if app.pprofService != nil {
-		if err := app.pprofService.Close(); err != nil {
-			app.log.With().Warning("pprof service exited with error", log.Err(err))
-		}
+		c.Add(shutdown.Final, "pprof", func(context.Context) error { return app.pprofService.Close() })
 	}
 	if app.profilerService != nil {
-		if err := app.profilerService.Stop(); err != nil {
-			app.log.With().Warning("profiler service exited with error", log.Err(err))
+		c.Add(shutdown.Final, "profiler", func(context.Context) error { return app.profilerService.Stop() })
+	}
+	if app.tracingProvider != nil {
+		c.Add(shutdown.Final, "tracing", app.tracingProvider.Shutdown)
+	}
+}
+
+// closeDB flushes the write-ahead log into the database before closing it.
+func closeDB(db *sql.Database) error {
+	var errs []error
+	if err := sql.Checkpoint(db); err != nil {
+		errs = append(errs, err)
+	}
+	if err := db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// walCheck verifies that the write-ahead log of the closed database is empty,
+// i.e. all changes are in the database file.
+func walCheck(dbPath string) func(context.Context) error {
+	return func(context.Context) error {
+		info, err := os.Stat(dbPath + "-wal")
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil
+		case err != nil:
+			return err
+		case info.Size() != 0:
+			return fmt.Errorf("%s has %d bytes that are not checkpointed", dbPath+"-wal", info.Size())
 		}
+		return nil
 	}
+}
 
-	events.CloseEventReporter()
-	// SetGrpcLogger unfortunately is global
-	// this ensures that a test-logger isn't used after the app shuts down
-	// by e.g. a grpc connection to the node that is still open - like in TestSpacemeshApp_NodeService
-	grpczap.SetGrpcLoggerV2(grpclog, log.NewNop().Zap())
+func (app *App) writeShutdownReport(report *shutdown.Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	path := filepath.Join(app.Config.DataDir(), shutdownReportFile)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
 }
 
 func (app *App) setupDBs(ctx context.Context, lg log.Log) error {
@@ -1728,6 +2522,9 @@ func (app *App) setupDBs(ctx context.Context, lg log.Log) error {
 	if err := os.MkdirAll(dbPath, os.ModePerm); err != nil {
 		return fmt.Errorf("failed to create %s: %w", dbPath, err)
 	}
+	if err := app.Config.DatabasePragmas.Validate(); err != nil {
+		return fmt.Errorf("invalid database pragmas: %w", err)
+	}
 	migrations, err := sql.StateMigrations()
 	if err != nil {
 		return fmt.Errorf("failed to load migrations: %w", err)
@@ -1740,6 +2537,7 @@ func (app *App) setupDBs(ctx context.Context, lg log.Log) error {
 		sql.WithLatencyMetering(app.Config.DatabaseLatencyMetering),
 		sql.WithVacuumState(app.Config.DatabaseVacuumState),
 		sql.WithQueryCache(app.Config.DatabaseQueryCache),
+		sql.WithPragmas(app.Config.DatabasePragmas),
 		sql.WithQueryCacheSizes(map[sql.QueryCacheKind]int{
 			atxs.CacheKindEpochATXs:           app.Config.DatabaseQueryCacheSizes.EpochATXs,
 			atxs.CacheKindATXBlob:             app.Config.DatabaseQueryCacheSizes.ATXBlob,
@@ -1801,11 +2599,17 @@ func (app *App) setupDBs(ctx context.Context, lg log.Log) error {
 		sql.WithMigration(localsql.New0002Migration(app.Config.SMESHING.Opts.DataDir)),
 		sql.WithMigration(localsql.New0003Migration(dbLog.Zap(), app.Config.SMESHING.Opts.DataDir, clients)),
 		sql.WithConnections(app.Config.DatabaseConnections),
+		sql.WithPragmas(app.Config.DatabasePragmas),
 	)
 	if err != nil {
 		return fmt.Errorf("open sqlite db %w", err)
 	}
 	app.localDB = localDB
+	if app.Config.EventsJournalSize > 0 {
+		if err := events.EnableJournal(app.localDB, app.Config.EventsJournalSize); err != nil {
+			return fmt.Errorf("enable events journal: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -1993,6 +2797,15 @@ func (app *App) startSynchronous(ctx context.Context) (err error) {
 		}
 	}
 
+	if app.Config.Tracing.Enable {
+		app.tracingProvider, err = tracing.New(ctx, app.Config.Tracing, "go-spacemesh",
+			attribute.String("service.version", cmd.Version),
+		)
+		if err != nil {
+			return fmt.Errorf("cannot start tracing: %w", err)
+		}
+	}
+
 	lg := logger
 
 	/* Initialize all protocol services */
@@ -2001,7 +2814,18 @@ func (app *App) startSynchronous(ctx context.Context) (err error) {
 	if err != nil {
 		return fmt.Errorf("cannot parse genesis time %s: %w", app.Config.Genesis.GenesisTime, err)
 	}
+	app.timeSource, err = timesync.NewTimeSource(app.Config.TIME.Source)
+	if err != nil {
+		return fmt.Errorf("cannot create time source: %w", err)
+	}
+	if app.timeSource.Mode() != timesync.SourceReal {
+		lg.With().Warning("layer clock is not driven by the system clock",
+			log.String("mode", app.timeSource.Mode()),
+			log.Time("now", app.timeSource.Now()),
+		)
+	}
 	app.clock, err = timesync.NewClock(
+		timesync.WithClock(app.timeSource),
 		timesync.WithLayerDuration(app.Config.LayerDuration),
 		timesync.WithTickInterval(1*time.Second),
 		timesync.WithGenesisTime(gTime),
@@ -2013,27 +2837,9 @@ func (app *App) startSynchronous(ctx context.Context) (err error) {
 
 	lg.Info("initializing p2p services")
 
-	cfg := app.Config.P2P
-	cfg.DataDir = filepath.Join(app.Config.DataDir(), "p2p")
-	p2plog := app.addLogger(P2PLogger, lg)
-	// if addLogger won't add a level we will use a default 0 (info).
-	cfg.LogLevel = app.getLevel(P2PLogger)
-	prologue := fmt.Sprintf("%x-%v",
-		app.Config.Genesis.GenesisID(),
-		types.GetEffectiveGenesis(),
-	)
-	// Prevent testnet nodes from working on the mainnet, but
-	// don't use the network cookie on mainnet as this technique
-	// may be replaced later
-	nc := handshake.NoNetworkCookie
-	if !onMainNet(app.Config) {
-		nc = handshake.NetworkCookie(prologue)
-	}
-	app.host, err = p2p.New(ctx, p2plog, cfg, []byte(prologue), nc,
-		p2p.WithNodeReporter(events.ReportNodeStatusUpdate),
-	)
+	app.host, err = app.newHost(ctx, lg, types.GetEffectiveGenesis())
 	if err != nil {
-		return fmt.Errorf("initialize p2p host: %w", err)
+		return err
 	}
 
 	if err := app.setupDBs(ctx, lg); err != nil {
@@ -2079,6 +2885,33 @@ func (app *App) startSynchronous(ctx context.Context) (err error) {
 	return nil
 }
 
+// newHost creates p2p host that can connect to peers with the given effective genesis.
+func (app *App) newHost(ctx context.Context, lg log.Log, genesis types.LayerID) (*p2p.Host, error) {
+	cfg := app.Config.P2P
+	cfg.DataDir = filepath.Join(app.Config.DataDir(), "p2p")
+	p2plog := app.addLogger(P2PLogger, lg)
+	// if addLogger won't add a level we will use a default 0 (info).
+	cfg.LogLevel = app.getLevel(P2PLogger)
+	prologue := fmt.Sprintf("%x-%v",
+		app.Config.Genesis.GenesisID(),
+		genesis,
+	)
+	// Prevent testnet nodes from working on the mainnet, but
+	// don't use the network cookie on mainnet as this technique
+	// may be replaced later
+	nc := handshake.NoNetworkCookie
+	if !onMainNet(app.Config) {
+		nc = handshake.NetworkCookie(prologue)
+	}
+	host, err := p2p.New(ctx, p2plog, cfg, []byte(prologue), nc,
+		p2p.WithNodeReporter(events.ReportNodeStatusUpdate),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("initialize p2p host: %w", err)
+	}
+	return host, nil
+}
+
 func (app *App) preserveAfterRecovery(ctx context.Context) {
 	if app.preserve == nil {
 		return
@@ -2107,6 +2940,35 @@ func (app *App) preserveAfterRecovery(ctx context.Context) {
 			log.String("poet proof ref", hash.ShortString()),
 		)
 	}
+	for _, poetProof := range app.preserve.PendingProofs {
+		ref, err := poetProof.Ref()
+		if err != nil {
+			app.log.With().Error("failed to compute pending poet proof ref after checkpoint",
+				log.Object("poet proof", poetProof),
+				log.Err(err),
+			)
+			continue
+		}
+		encoded, err := codec.Encode(poetProof)
+		if err != nil {
+			app.log.With().Error("failed to encode pending poet proof after checkpoint",
+				log.Object("poet proof", poetProof),
+				log.Err(err),
+			)
+			continue
+		}
+		hash := types.Hash32(ref)
+		if err := app.poetDb.ValidateAndStoreMsg(ctx, hash, p2p.NoPeer, encoded); err != nil {
+			app.log.With().Error("failed to preserve pending poet proof after checkpoint",
+				log.String("poet proof ref", hash.ShortString()),
+				log.Err(err),
+			)
+			continue
+		}
+		app.log.With().Info("preserved pending poet proof after checkpoint",
+			log.String("poet proof ref", hash.ShortString()),
+		)
+	}
 	for _, vatx := range app.preserve.Deps {
 		encoded, err := codec.Encode(vatx)
 		if err != nil {
@@ -2137,11 +2999,19 @@ type layerFetcher struct {
 	system.Fetcher
 }
 
+// decodeLoggers returns the configured levels by the name of the module.
 func decodeLoggers(cfg config.LoggerConfig) (map[string]string, error) {
-	rst := map[string]string{}
-	if err := mapstructure.Decode(cfg, &rst); err != nil {
+	decoded := map[string]any{}
+	if err := mapstructure.Decode(cfg, &decoded); err != nil {
 		return nil, fmt.Errorf("mapstructure decode: %w", err)
 	}
+	rst := make(map[string]string, len(decoded))
+	for name, value := range decoded {
+		if level, ok := value.(string); ok {
+			rst[name] = level
+		}
+	}
+	delete(rst, "log-encoder")
 	return rst, nil
 }
 
@@ -2150,11 +3020,16 @@ type tortoiseWeakCoin struct {
 	tortoise system.Tortoise
 }
 
-func (w tortoiseWeakCoin) Set(lid types.LayerID, value bool) error {
-	if err := layers.SetWeakCoin(w.db, lid, value); err != nil {
+func (w tortoiseWeakCoin) Set(out hare3.WeakCoinOutput) error {
+	if err := layers.SetWeakCoinProvenance(w.db, &layers.WeakCoin{
+		Layer:   out.Layer,
+		Value:   out.Coin,
+		Vrf:     out.Vrf,
+		Smesher: out.Smesher,
+	}); err != nil {
 		return err
 	}
-	w.tortoise.OnWeakCoin(lid, value)
+	w.tortoise.OnWeakCoin(out.Layer, out.Coin)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,8 +12,11 @@ import (
 
 	"github.com/natefinch/atomic"
 
+	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
+	remotesigner "github.com/spacemeshos/go-spacemesh/signing/remote"
 )
 
 const (
@@ -92,7 +96,12 @@ func (app *App) MigrateExistingIdentity() error {
 
 // NewIdentity creates a new identity, saves it to `keyDir/supervisedIDKeyFileName` in the config directory and
 // initializes app.signers with that identity.
+//
+// If the keystore is enabled the identity is added to the keystore with `supervisedIDKeyFileName` label instead.
 func (app *App) NewIdentity() error {
+	if app.Config.Keystore.Enabled {
+		return app.newKeystoreIdentity()
+	}
 	dir := filepath.Join(app.Config.DataDir(), keyDir)
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return fmt.Errorf("failed to create directory for identity file: %w", err)
@@ -126,7 +135,12 @@ func (app *App) NewIdentity() error {
 }
 
 // LoadIdentities loads all existing identities from the config directory.
+//
+// If the keystore is enabled identities are loaded from the keystore, after importing key files into it.
 func (app *App) LoadIdentities() error {
+	if app.Config.Keystore.Enabled {
+		return app.loadKeystoreIdentities()
+	}
 	signers := make([]*signing.EdSigner, 0)
 
 	dir := filepath.Join(app.Config.DataDir(), keyDir)
@@ -186,6 +200,15 @@ func (app *App) LoadIdentities() error {
 		return fmt.Errorf("duplicate key found in identity files")
 	}
 
+	if err := app.checkSupervisedKey(signers); err != nil {
+		return err
+	}
+	app.signers = signers
+	return nil
+}
+
+// checkSupervisedKey makes sure that the key for supervised smeshing is not used together with other keys.
+func (app *App) checkSupervisedKey(signers []*signing.EdSigner) error {
 	if len(signers) > 1 {
 		app.log.Info("Loaded %d identities from disk", len(signers))
 		for _, sig := range signers {
@@ -202,7 +225,200 @@ func (app *App) LoadIdentities() error {
 			}
 		}
 	}
+	return nil
+}
+
+// DialRemoteSigners connects to the remote signers from the config. Identities of the remote signers
+// are used in addition to the identities loaded from disk, which makes the setup a remote smeshing setup.
+func (app *App) DialRemoteSigners(ctx context.Context) error {
+	if len(app.Config.RemoteSigners) == 0 {
+		return nil
+	}
+	seen := make(map[types.NodeID]string, len(app.signers)+len(app.Config.RemoteSigners))
+	for _, sig := range app.signers {
+		if sig.Name() == supervisedIDKeyFileName {
+			return fmt.Errorf("supervised key %s can't be used with remote signers", supervisedIDKeyFileName)
+		}
+		seen[sig.NodeID()] = sig.Name()
+	}
+	for _, cfg := range app.Config.RemoteSigners {
+		signer, err := remotesigner.Dial(ctx, app.log.Zap().Named("remote-signer"), cfg,
+			app.Config.Genesis.GenesisID().Bytes(),
+		)
+		if err != nil {
+			app.closeRemoteSigners()
+			return err
+		}
+		if name, ok := seen[signer.NodeID()]; ok {
+			signer.Close()
+			app.closeRemoteSigners()
+			return fmt.Errorf("identity of remote signer %s is already used by %s", cfg.Address, name)
+		}
+		seen[signer.NodeID()] = cfg.Address
+		app.remoteSigners = append(app.remoteSigners, signer)
+	}
+	app.log.Info("Connected to %d remote signers", len(app.remoteSigners))
+	return nil
+}
+
+func (app *App) closeRemoteSigners() {
+	for _, signer := range app.remoteSigners {
+		if err := signer.Close(); err != nil {
+			app.log.With().Warning("failed to close remote signer", log.String("address", signer.Name()), log.Err(err))
+		}
+	}
+	app.remoteSigners = nil
+}
+
+// identities returns the identities loaded from disk followed by the identities of the remote signers.
+func (app *App) identities() []signing.Signer {
+	ids := make([]signing.Signer, 0, len(app.signers)+len(app.remoteSigners))
+	for _, sig := range app.signers {
+		ids = append(ids, sig)
+	}
+	for _, sig := range app.remoteSigners {
+		ids = append(ids, sig)
+	}
+	return ids
+}
+
+// supervised is true if the node smeshes with the single identity that is managed by the post supervisor.
+func (app *App) supervised() bool {
+	return len(app.remoteSigners) == 0 && len(app.signers) == 1 && app.signers[0].Name() == supervisedIDKeyFileName
+}
+
+// openKeystore opens the keystore in the identities directory with the configured passphrase.
+func (app *App) openKeystore() (*keystore.Keystore, error) {
+	passphrase, err := app.Config.Keystore.Passphrase()
+	if err != nil {
+		return nil, fmt.Errorf("keystore passphrase: %w", err)
+	}
+	ks, err := keystore.Open(filepath.Join(app.Config.DataDir(), keyDir, keystore.FileName), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("open keystore: %w", err)
+	}
+	return ks, nil
+}
+
+func (app *App) newKeystoreIdentity() error {
+	ks, err := app.openKeystore()
+	if err != nil {
+		return err
+	}
+	if len(ks.Identities()) > 0 {
+		return fmt.Errorf("keystore already has identities: %w", fs.ErrExist)
+	}
+	id, err := ks.Generate(supervisedIDKeyFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	app.log.With().Info("Created new identity in keystore",
+		log.String("label", id.Label),
+		log.Stringer("id", id.NodeID),
+	)
+	signers, err := ks.Signers(signing.WithPrefix(app.Config.Genesis.GenesisID().Bytes()))
+	if err != nil {
+		return err
+	}
+	app.signers = signers
+	return nil
+}
+
+// importKeyFiles adds identities from the key files in the identities directory to the keystore.
+// Imported files are overwritten and removed, so that keys are not stored in plain text next to the keystore.
+// If Keystore.KeepImported is set they are renamed to `<name>.key.bak` instead.
+func (app *App) importKeyFiles(ks *keystore.Keystore) error {
+	dir := filepath.Join(app.Config.DataDir(), keyDir)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read identities directory: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".key" {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		signer, err := signing.NewEdSigner(signing.FromFile(path))
+		if err != nil {
+			return fmt.Errorf("failed to construct identity %s: %w", file.Name(), err)
+		}
+		_, err = ks.Add(file.Name(), signer.PrivateKey())
+		switch {
+		case errors.Is(err, keystore.ErrExists):
+			app.log.With().Warning("Identity from key file is already in keystore",
+				log.String("filename", file.Name()),
+				signer.PublicKey(),
+			)
+		case err != nil:
+			return fmt.Errorf("import identity %s: %w", file.Name(), err)
+		default:
+			app.log.With().Info("Imported identity into keystore",
+				log.String("filename", file.Name()),
+				signer.PublicKey(),
+			)
+		}
+		if app.Config.Keystore.KeepImported {
+			if err := atomic.ReplaceFile(path, path+".bak"); err != nil {
+				return fmt.Errorf("failed to rename imported identity file: %w", err)
+			}
+			continue
+		}
+		if err := wipeFile(path); err != nil {
+			return fmt.Errorf("failed to delete imported identity file: %w", err)
+		}
+	}
+	return nil
+}
 
+// wipeFile overwrites the file with zeros, syncs it to disk and removes it.
+func wipeFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(make([]byte, info.Size())); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (app *App) loadKeystoreIdentities() error {
+	ks, err := app.openKeystore()
+	if err != nil {
+		return err
+	}
+	if err := app.importKeyFiles(ks); err != nil {
+		return err
+	}
+	signers, err := ks.Signers(signing.WithPrefix(app.Config.Genesis.GenesisID().Bytes()))
+	if err != nil {
+		return err
+	}
+	if len(signers) == 0 {
+		return fmt.Errorf("no identities in keystore: %w", fs.ErrNotExist)
+	}
+	for _, sig := range signers {
+		app.log.With().Info("Loaded existing identity from keystore",
+			log.String("label", sig.Name()),
+			sig.PublicKey(),
+		)
+	}
+	if err := app.checkSupervisedKey(signers); err != nil {
+		return err
+	}
 	app.signers = signers
 	return nil
 }
@@ -2,6 +2,7 @@ package node
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io/fs"
@@ -14,9 +15,12 @@ import (
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
+	remotesigner "github.com/spacemeshos/go-spacemesh/signing/remote"
 )
 
 func setupAppWithKeys(tb testing.TB, data ...[]byte) (*App, *observer.ObservedLogs) {
@@ -213,3 +217,117 @@ func TestSpacemeshApp_LoadIdentities(t *testing.T) {
 		require.Len(t, app.signers, 3)
 	})
 }
+
+func TestSpacemeshApp_Keystore(t *testing.T) {
+	t.Setenv(keystore.PassphraseEnv, "passphrase")
+
+	t.Run("new identity", func(t *testing.T) {
+		app := New(WithLog(logtest.New(t)))
+		app.Config.DataDirParent = t.TempDir()
+		app.Config.Keystore.Enabled = true
+		err := app.LoadIdentities()
+		require.ErrorIs(t, err, fs.ErrNotExist)
+
+		require.NoError(t, app.NewIdentity())
+		require.Len(t, app.signers, 1)
+		require.Equal(t, supervisedIDKeyFileName, app.signers[0].Name())
+		created := app.signers[0].NodeID()
+		require.ErrorIs(t, app.NewIdentity(), fs.ErrExist)
+
+		app.signers = nil
+		require.NoError(t, app.LoadIdentities())
+		require.Len(t, app.signers, 1)
+		require.Equal(t, created, app.signers[0].NodeID())
+		require.Equal(t, supervisedIDKeyFileName, app.signers[0].Name())
+	})
+
+	t.Run("import key files", func(t *testing.T) {
+		key1, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		key2, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		app, _ := setupAppWithKeys(t,
+			[]byte(hex.EncodeToString(key1.PrivateKey())),
+			[]byte(hex.EncodeToString(key2.PrivateKey())),
+		)
+		app.Config.Keystore.Enabled = true
+		require.NoError(t, app.LoadIdentities())
+		require.Len(t, app.signers, 2)
+		names := map[types.NodeID]string{}
+		for _, sig := range app.signers {
+			names[sig.NodeID()] = sig.Name()
+		}
+		require.Equal(t, map[types.NodeID]string{
+			key1.NodeID(): "identity_0.key",
+			key2.NodeID(): "identity_1.key",
+		}, names)
+
+		// key files are deleted after import
+		dir := filepath.Join(app.Config.DataDirParent, keyDir)
+		for i := 0; i < 2; i++ {
+			_, err := os.Stat(filepath.Join(dir, fmt.Sprintf("identity_%d.key", i)))
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = os.Stat(filepath.Join(dir, fmt.Sprintf("identity_%d.key.bak", i)))
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		}
+
+		app.signers = nil
+		require.NoError(t, app.LoadIdentities())
+		require.Len(t, app.signers, 2)
+	})
+
+	t.Run("keep imported key files", func(t *testing.T) {
+		key, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		app, _ := setupAppWithKeys(t, []byte(hex.EncodeToString(key.PrivateKey())))
+		app.Config.Keystore.Enabled = true
+		app.Config.Keystore.KeepImported = true
+		require.NoError(t, app.LoadIdentities())
+		require.Len(t, app.signers, 1)
+
+		dir := filepath.Join(app.Config.DataDirParent, keyDir)
+		_, err = os.Stat(filepath.Join(dir, "identity_0.key"))
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = os.Stat(filepath.Join(dir, "identity_0.key.bak"))
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		app := New(WithLog(logtest.New(t)))
+		app.Config.DataDirParent = t.TempDir()
+		app.Config.Keystore.Enabled = true
+		require.NoError(t, app.NewIdentity())
+
+		t.Setenv(keystore.PassphraseEnv, "wrong")
+		require.ErrorIs(t, app.LoadIdentities(), keystore.ErrWrongPassphrase)
+	})
+}
+
+func TestSpacemeshApp_DialRemoteSigners(t *testing.T) {
+	t.Run("no remote signers", func(t *testing.T) {
+		key, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		app, _ := setupAppWithKeys(t, []byte(hex.EncodeToString(key.PrivateKey())))
+		require.NoError(t, app.LoadIdentities())
+		require.NoError(t, app.DialRemoteSigners(context.Background()))
+		require.Len(t, app.identities(), 1)
+		require.True(t, app.supervised())
+	})
+
+	t.Run("supervised key is rejected", func(t *testing.T) {
+		key, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		app, _ := setupAppWithKeys(t, []byte(hex.EncodeToString(key.PrivateKey())))
+		require.NoError(t, app.LoadIdentities())
+		app.Config.RemoteSigners = []remotesigner.Config{{Address: "127.0.0.1:0"}}
+		require.ErrorContains(t, app.DialRemoteSigners(context.Background()), supervisedIDKeyFileName)
+		require.Empty(t, app.remoteSigners)
+	})
+
+	t.Run("missing certificates", func(t *testing.T) {
+		app := New(WithLog(logtest.New(t)))
+		app.Config.RemoteSigners = []remotesigner.Config{{Address: "127.0.0.1:0"}}
+		require.ErrorContains(t, app.DialRemoteSigners(context.Background()), "required")
+		require.Empty(t, app.remoteSigners)
+	})
+}
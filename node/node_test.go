@@ -44,6 +44,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/config"
 	"github.com/spacemeshos/go-spacemesh/config/presets"
+	"github.com/spacemeshos/go-spacemesh/config/profiles"
 	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk"
 	"github.com/spacemeshos/go-spacemesh/genvm/sdk/wallet"
@@ -601,7 +602,7 @@ func TestConfig_Preset(t *testing.T) {
 		require.NoError(t, err)
 
 		conf := config.Config{}
-		require.NoError(t, loadConfig(&conf, name, ""))
+		require.NoError(t, loadConfig(&conf, name, "", ""))
 		require.Equal(t, preset, conf)
 	})
 
@@ -614,7 +615,7 @@ func TestConfig_Preset(t *testing.T) {
 		cmd.AddFlags(&flags, &conf)
 
 		const lowPeers = 1234
-		require.NoError(t, loadConfig(&conf, name, ""))
+		require.NoError(t, loadConfig(&conf, name, "", ""))
 		require.NoError(t, flags.Parse([]string{"--low-peers=" + strconv.Itoa(lowPeers)}))
 		preset.P2P.LowPeers = lowPeers
 		require.Equal(t, preset, conf)
@@ -630,7 +631,7 @@ func TestConfig_Preset(t *testing.T) {
 		path := filepath.Join(t.TempDir(), "config.json")
 		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
 
-		require.NoError(t, loadConfig(&conf, name, path))
+		require.NoError(t, loadConfig(&conf, name, "", path))
 		preset.P2P.LowPeers = lowPeers
 		require.Equal(t, preset, conf)
 	})
@@ -644,12 +645,104 @@ func TestConfig_Preset(t *testing.T) {
 		path := filepath.Join(t.TempDir(), "config.json")
 		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
 
-		require.NoError(t, loadConfig(&conf, name, path))
+		require.NoError(t, loadConfig(&conf, name, "", path))
 		require.NoError(t, err)
 		require.Equal(t, preset, conf)
 	})
 }
 
+func TestConfig_Layers(t *testing.T) {
+	const name = "testnet"
+
+	t.Run("ConfigFileOverwrittenByEnvironment", func(t *testing.T) {
+		preset, err := presets.Get(name)
+		require.NoError(t, err)
+
+		conf := config.Config{}
+		content := `{"p2p": {"low-peers": 1234, "high-peers": 2345}}`
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		environ := []string{
+			"SPACEMESH_PRESET=" + name,
+			"SPACEMESH_P2P__LOW_PEERS=4321",
+			"SPACEMESH_MAIN__LAYER_DURATION=1m",
+		}
+
+		sources := config.NewSources()
+		require.NoError(t, loadLayeredConfig(&conf, "", "", path, environ, sources))
+		preset.P2P.LowPeers = 4321
+		preset.P2P.HighPeers = 2345
+		preset.LayerDuration = time.Minute
+		require.Equal(t, preset, conf)
+		require.Equal(t, "env", sources.Source("p2p.low-peers"))
+		require.Equal(t, "file "+path, sources.Source("p2p.high-peers"))
+		require.Equal(t, "env", sources.Source("main.layer-duration"))
+		require.Equal(t, "preset "+name, sources.Source("p2p.min-peers"))
+	})
+
+	t.Run("UnknownEnvironmentKey", func(t *testing.T) {
+		conf := config.Config{}
+		err := loadLayeredConfig(&conf, name, "", "", []string{"SPACEMESH_P2P__UNKNOWN=1"}, nil)
+		require.ErrorContains(t, err, "'p2p' has invalid keys: unknown")
+	})
+
+	t.Run("PresetsValid", func(t *testing.T) {
+		for _, name := range presets.Options() {
+			preset, err := presets.Get(name)
+			require.NoError(t, err)
+			require.NoError(t, preset.Validate(), name)
+		}
+	})
+}
+
+func TestConfig_Profile(t *testing.T) {
+	const name = "testnet"
+
+	t.Run("ProfileApplied", func(t *testing.T) {
+		preset, err := presets.Get(name)
+		require.NoError(t, err)
+
+		conf := config.Config{}
+		require.NoError(t, loadConfig(&conf, name, profiles.Archive, ""))
+		require.NoError(t, profiles.Apply(profiles.Archive, &preset))
+		require.Equal(t, preset, conf)
+		require.True(t, conf.CertificateArchive)
+		require.True(t, conf.FETCH.ServeLight)
+		require.True(t, conf.Explorer.Enabled)
+	})
+
+	t.Run("DefaultProfile", func(t *testing.T) {
+		preset, err := presets.Get(name)
+		require.NoError(t, err)
+
+		conf := config.Config{}
+		require.NoError(t, loadConfig(&conf, name, profiles.Default, ""))
+		preset.Profile = profiles.Default
+		require.Equal(t, preset, conf)
+	})
+
+	t.Run("ProfileOverwrittenByConfigFile", func(t *testing.T) {
+		preset, err := presets.Get(name)
+		require.NoError(t, err)
+
+		conf := config.Config{}
+		content := fmt.Sprintf(`{"profile": "%s", "main": {"db-connections": 8}}`, profiles.LightSmesher)
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		require.NoError(t, loadConfig(&conf, name, "", path))
+		require.NoError(t, profiles.Apply(profiles.LightSmesher, &preset))
+		preset.DatabaseConnections = 8
+		require.Equal(t, preset, conf)
+		require.Equal(t, []string{grpcserver.Node, grpcserver.Health}, conf.API.PublicServices)
+	})
+
+	t.Run("UnknownProfile", func(t *testing.T) {
+		conf := config.Config{}
+		require.ErrorContains(t, loadConfig(&conf, name, "full", ""), "profile full is not available")
+	})
+}
+
 func TestConfig_CustomTypes(t *testing.T) {
 	const name = "testnet"
 
@@ -716,7 +809,7 @@ func TestConfig_CustomTypes(t *testing.T) {
 			var flags pflag.FlagSet
 			cmd.AddFlags(&flags, &conf)
 
-			require.NoError(t, loadConfig(&conf, "", ""))
+			require.NoError(t, loadConfig(&conf, "", "", ""))
 			require.NoError(t, flags.Parse(strings.Fields(tc.cli)))
 			tc.updatePreset(t, &mainnet)
 			require.Equal(t, mainnet, conf)
@@ -730,7 +823,7 @@ func TestConfig_CustomTypes(t *testing.T) {
 			path := filepath.Join(t.TempDir(), "config.json")
 			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0o600))
 
-			require.NoError(t, loadConfig(&conf, "", path))
+			require.NoError(t, loadConfig(&conf, "", "", path))
 			tc.updatePreset(t, &mainnet)
 			require.Equal(t, mainnet, conf)
 		})
@@ -743,7 +836,7 @@ func TestConfig_CustomTypes(t *testing.T) {
 			var flags pflag.FlagSet
 			cmd.AddFlags(&flags, &conf)
 
-			require.NoError(t, loadConfig(&conf, name, ""))
+			require.NoError(t, loadConfig(&conf, name, "", ""))
 			require.NoError(t, flags.Parse(strings.Fields(tc.cli)))
 			tc.updatePreset(t, &preset)
 			require.Equal(t, preset, conf)
@@ -757,7 +850,7 @@ func TestConfig_CustomTypes(t *testing.T) {
 			path := filepath.Join(t.TempDir(), "config.json")
 			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0o600))
 
-			require.NoError(t, loadConfig(&conf, name, path))
+			require.NoError(t, loadConfig(&conf, name, "", path))
 			tc.updatePreset(t, &preset)
 			require.Equal(t, preset, conf)
 		})
@@ -803,7 +896,7 @@ func TestConfig_PostProviderID_InvalidValues(t *testing.T) {
 			path := filepath.Join(t.TempDir(), "config.json")
 			cfg := fmt.Sprintf(`{"smeshing": {"smeshing-opts": {"smeshing-opts-provider": %s}}}`, tc.configValue)
 			require.NoError(t, os.WriteFile(path, []byte(cfg), 0o600))
-			err := loadConfig(&conf, "", path)
+			err := loadConfig(&conf, "", "", path)
 			require.ErrorContains(t, err, "invalid provider ID value")
 		})
 	}
@@ -816,7 +909,7 @@ func TestConfig_Load(t *testing.T) {
 		path := filepath.Join(t.TempDir(), "config.json")
 		require.NoError(t, os.WriteFile(path, []byte("}"), 0o600))
 
-		err := loadConfig(&conf, "", path)
+		err := loadConfig(&conf, "", "", path)
 		require.ErrorContains(t, err, path)
 	})
 	t.Run("missing default doesn't fail", func(t *testing.T) {
@@ -824,7 +917,7 @@ func TestConfig_Load(t *testing.T) {
 		var flags pflag.FlagSet
 		cmd.AddFlags(&flags, &conf)
 
-		require.NoError(t, loadConfig(&conf, "", ""))
+		require.NoError(t, loadConfig(&conf, "", "", ""))
 		require.NoError(t, flags.Parse([]string{}))
 	})
 }
@@ -845,7 +938,7 @@ func TestConfig_GenesisAccounts(t *testing.T) {
 		args = append(args, fmt.Sprintf("-a %s=%d", key, value))
 	}
 
-	require.NoError(t, loadConfig(&conf, "", ""))
+	require.NoError(t, loadConfig(&conf, "", "", ""))
 	require.NoError(t, flags.Parse(args))
 	for _, key := range keys {
 		require.EqualValues(t, value, conf.Genesis.Accounts[key])
@@ -857,7 +950,7 @@ func TestHRP(t *testing.T) {
 	data := `{"main": {"network-hrp": "TEST"}}`
 	cfg := filepath.Join(t.TempDir(), "config.json")
 	require.NoError(t, os.WriteFile(cfg, []byte(data), 0o600))
-	require.NoError(t, loadConfig(&conf, "", cfg))
+	require.NoError(t, loadConfig(&conf, "", "", cfg))
 	app := New(WithConfig(&conf))
 	require.NotNil(t, app)
 	require.Equal(t, "TEST", types.NetworkHRP())
@@ -0,0 +1,213 @@
+// Package shutdown stops the subsystems of the node in dependency order with a deadline for each subsystem.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Stage groups subsystems that are stopped together. Stages are stopped in the order of their values.
+type Stage int
+
+const (
+	// Intake stops the subsystems that accept data from the outside: api servers, p2p host and updater.
+	Intake Stage = iota
+	// Consensus stops the protocols that produce and verify data.
+	Consensus
+	// Storage flushes and closes the databases.
+	Storage
+	// Final stops the auxiliary services, such as profiling and tracing.
+	Final
+)
+
+func (s Stage) String() string {
+	switch s {
+	case Intake:
+		return "intake"
+	case Consensus:
+		return "consensus"
+	case Storage:
+		return "storage"
+	case Final:
+		return "final"
+	}
+	return fmt.Sprintf("stage(%d)", int(s))
+}
+
+// Status is the outcome of stopping a subsystem.
+type Status string
+
+const (
+	// StatusOK is reported if the subsystem stopped without error.
+	StatusOK Status = "ok"
+	// StatusError is reported if the subsystem returned an error.
+	StatusError Status = "error"
+	// StatusTimeout is reported if the subsystem didn't stop before the deadline.
+	StatusTimeout Status = "timeout"
+)
+
+// Config configures the deadlines of the subsystems.
+type Config struct {
+	// Timeout is the deadline for stopping a subsystem that has no deadline in Timeouts.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Timeouts are deadlines by the name of the subsystem.
+	Timeouts map[string]time.Duration `mapstructure:"timeouts"`
+}
+
+// DefaultConfig returns the default config.
+func DefaultConfig() Config {
+	return Config{
+		Timeout: 10 * time.Second,
+		Timeouts: map[string]time.Duration{
+			"post-supervisor": 20 * time.Second,
+		},
+	}
+}
+
+func (c Config) timeout(name string) time.Duration {
+	if timeout, exists := c.Timeouts[name]; exists {
+		return timeout
+	}
+	return c.Timeout
+}
+
+// StepReport is the outcome of stopping one subsystem.
+type StepReport struct {
+	Name     string        `json:"name"`
+	Stage    string        `json:"stage"`
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (r *StepReport) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", r.Name)
+	enc.AddString("stage", r.Stage)
+	enc.AddString("status", string(r.Status))
+	enc.AddDuration("duration", r.Duration)
+	if r.Error != "" {
+		enc.AddString("error", r.Error)
+	}
+	return nil
+}
+
+// Report is the outcome of the shutdown. The node stopped cleanly if all steps and checks reported StatusOK.
+type Report struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Steps    []StepReport  `json:"steps"`
+	// Checks are verified after all subsystems were stopped.
+	Checks []StepReport `json:"checks"`
+}
+
+// Clean returns true if all subsystems stopped without errors in time and all checks passed.
+func (r *Report) Clean() bool {
+	for _, step := range r.Steps {
+		if step.Status != StatusOK {
+			return false
+		}
+	}
+	for _, check := range r.Checks {
+		if check.Status != StatusOK {
+			return false
+		}
+	}
+	return true
+}
+
+type step struct {
+	stage Stage
+	name  string
+	fn    func(context.Context) error
+}
+
+// Coordinator stops the registered subsystems.
+type Coordinator struct {
+	logger *zap.Logger
+	cfg    Config
+	steps  []step
+	checks []step
+}
+
+// New creates a coordinator without subsystems.
+func New(logger *zap.Logger, cfg Config) *Coordinator {
+	return &Coordinator{logger: logger, cfg: cfg}
+}
+
+// Add registers the function that stops the subsystem. Subsystems of the same stage are stopped sequentially
+// in the order of registration.
+func (c *Coordinator) Add(stage Stage, name string, stop func(context.Context) error) {
+	c.steps = append(c.steps, step{stage: stage, name: name, fn: stop})
+}
+
+// Check registers the function that verifies the state after all subsystems were stopped.
+func (c *Coordinator) Check(name string, check func(context.Context) error) {
+	c.checks = append(c.checks, step{stage: Final, name: name, fn: check})
+}
+
+// Run stops the subsystems and runs the checks. Every subsystem is stopped with the deadline
+// from the config. If the subsystem doesn't stop in time it is reported and the shutdown proceeds
+// with the next one. Cancellation of ctx ends the wait for each subsystem that isn't stopped yet.
+func (c *Coordinator) Run(ctx context.Context) *Report {
+	rst := &Report{Start: time.Now()}
+	sort.SliceStable(c.steps, func(i, j int) bool {
+		return c.steps[i].stage < c.steps[j].stage
+	})
+	for _, s := range c.steps {
+		report := c.run(ctx, s)
+		rst.Steps = append(rst.Steps, report)
+	}
+	for _, s := range c.checks {
+		report := c.run(ctx, s)
+		rst.Checks = append(rst.Checks, report)
+	}
+	rst.Duration = time.Since(rst.Start)
+	c.logger.Info("shutdown completed",
+		zap.Bool("clean", rst.Clean()),
+		zap.Duration("duration", rst.Duration),
+		zap.Objects("steps", reports(rst.Steps)),
+		zap.Objects("checks", reports(rst.Checks)),
+	)
+	return rst
+}
+
+func (c *Coordinator) run(ctx context.Context, s step) StepReport {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.timeout(s.name))
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.fn(ctx)
+	}()
+	report := StepReport{Name: s.name, Stage: s.stage.String(), Status: StatusOK}
+	select {
+	case err := <-done:
+		if err != nil {
+			report.Status = StatusError
+			report.Error = err.Error()
+		}
+	case <-ctx.Done():
+		report.Status = StatusTimeout
+		report.Error = ctx.Err().Error()
+	}
+	report.Duration = time.Since(start)
+	if report.Status == StatusOK {
+		c.logger.Debug("subsystem stopped", zap.Object("step", &report))
+	} else {
+		c.logger.Warn("subsystem failed to stop", zap.Object("step", &report))
+	}
+	return report
+}
+
+func reports(steps []StepReport) []*StepReport {
+	rst := make([]*StepReport, 0, len(steps))
+	for i := range steps {
+		rst = append(rst, &steps[i])
+	}
+	return rst
+}
@@ -0,0 +1,75 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCoordinator(t *testing.T) {
+	cfg := Config{
+		Timeout:  time.Second,
+		Timeouts: map[string]time.Duration{"stuck": 10 * time.Millisecond},
+	}
+	c := New(zaptest.NewLogger(t), cfg)
+
+	var order []string
+	stop := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	unblock := make(chan struct{})
+	t.Cleanup(func() { close(unblock) })
+	c.Add(Storage, "db", stop("db", nil))
+	c.Add(Consensus, "hare", stop("hare", nil))
+	c.Add(Consensus, "stuck", func(context.Context) error {
+		<-unblock
+		return nil
+	})
+	c.Add(Consensus, "syncer", stop("syncer", errors.New("failed")))
+	c.Add(Intake, "p2p", stop("p2p", nil))
+	c.Add(Final, "tracing", stop("tracing", nil))
+	c.Check("wal", func(context.Context) error {
+		order = append(order, "wal")
+		return nil
+	})
+
+	report := c.Run(context.Background())
+	require.Equal(t, []string{"p2p", "hare", "syncer", "db", "tracing", "wal"}, order)
+	require.False(t, report.Clean())
+	require.Len(t, report.Steps, 6)
+	require.Len(t, report.Checks, 1)
+
+	statuses := map[string]Status{}
+	for _, step := range report.Steps {
+		statuses[step.Name] = step.Status
+	}
+	require.Equal(t, map[string]Status{
+		"p2p":     StatusOK,
+		"hare":    StatusOK,
+		"stuck":   StatusTimeout,
+		"syncer":  StatusError,
+		"db":      StatusOK,
+		"tracing": StatusOK,
+	}, statuses)
+	require.Equal(t, "consensus", report.Steps[2].Stage)
+	require.Equal(t, "failed", report.Steps[3].Error)
+	require.Equal(t, StatusOK, report.Checks[0].Status)
+}
+
+func TestCoordinatorClean(t *testing.T) {
+	c := New(zaptest.NewLogger(t), DefaultConfig())
+	c.Add(Intake, "api", func(context.Context) error { return nil })
+	c.Check("wal", func(context.Context) error { return nil })
+	require.True(t, c.Run(context.Background()).Clean())
+
+	c = New(zaptest.NewLogger(t), DefaultConfig())
+	c.Check("wal", func(context.Context) error { return errors.New("not flushed") })
+	require.False(t, c.Run(context.Background()).Clean())
+}
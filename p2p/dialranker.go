@@ -0,0 +1,243 @@
+package p2p
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// DialRankerConfig configures the order in which the addresses of the peer are dialed.
+type DialRankerConfig struct {
+	// Enable ranks the addresses by the outcomes of the previous dials.
+	// If disabled, the addresses are ranked by the default libp2p ranker.
+	Enable bool `mapstructure:"enable"`
+	// FailedDelay is the delay of the dials to the addresses that failed recently,
+	// counted from the last dial to the rest of the addresses of the peer.
+	FailedDelay time.Duration `mapstructure:"failed-delay"`
+	// FamilyFailures is the number of consecutive failed dials to the addresses of the ip family
+	// after which all addresses of the family are dialed as failed, e.g. if the ipv6 route is broken.
+	FamilyFailures int `mapstructure:"family-failures"`
+	// Expiry is the time after which the outcome of the dial is forgotten.
+	Expiry time.Duration `mapstructure:"expiry"`
+	// Size is the number of addresses for which the outcomes of the dials are tracked.
+	Size int `mapstructure:"size"`
+}
+
+// dialOutcome is the outcome of the dials to the address or to the ip family.
+type dialOutcome struct {
+	failures int // consecutive failures
+	success  time.Time
+	failure  time.Time
+
+	// pending is true if the address was ranked for the dial that isn't concluded yet,
+	// dialAt is when the dial to the address is scheduled.
+	pending bool
+	dialAt  time.Time
+}
+
+func (o *dialOutcome) succeed(now time.Time) {
+	o.failures = 0
+	o.success = now
+	o.pending = false
+}
+
+func (o *dialOutcome) fail(now time.Time) {
+	o.failures++
+	o.failure = now
+	o.pending = false
+}
+
+// dialRanker races the addresses of the peer in the happy eyeballs style of the default libp2p ranker,
+// taking into account the outcomes of the previous dials. Addresses that connected recently are dialed
+// first, and addresses that failed recently, or that belong to the ip family that keeps failing,
+// are dialed after the rest of the addresses.
+//
+// Outcomes are learned from the outbound connections: the address of the connection succeeded,
+// and other addresses of the peer that were dialed before the connection was opened lost the race.
+// Dials to the address that weren't concluded by the time the address is ranked again failed.
+type dialRanker struct {
+	cfg       DialRankerConfig
+	peerstore peerstore.Peerstore
+	now       func() time.Time
+
+	mu       sync.Mutex
+	addrs    *lru.Cache[string, *dialOutcome]
+	families map[int]*dialOutcome
+}
+
+func newDialRanker(cfg DialRankerConfig, ps peerstore.Peerstore) *dialRanker {
+	addrs, err := lru.New[string, *dialOutcome](cfg.Size)
+	if err != nil {
+		panic(err) // only on a non-positive size
+	}
+	return &dialRanker{
+		cfg:       cfg,
+		peerstore: ps,
+		now:       time.Now,
+		addrs:     addrs,
+		families: map[int]*dialOutcome{
+			multiaddr.P_IP4: {},
+			multiaddr.P_IP6: {},
+		},
+	}
+}
+
+// Rank implements network.DialRanker.
+func (r *dialRanker) Rank(addrs []multiaddr.Multiaddr) []network.AddrDelay {
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var preferred, rest, failed []multiaddr.Multiaddr
+	tracked := make(map[string]*dialOutcome, len(addrs))
+	for _, addr := range addrs {
+		family := ipFamily(addr)
+		if family == 0 || isRelayAddr(addr) {
+			rest = append(rest, addr)
+			continue
+		}
+		outcome := r.outcome(addr)
+		if outcome.pending && outcome.dialAt.Before(now) {
+			r.fail(outcome, family, now)
+		}
+		tracked[string(addr.Bytes())] = outcome
+		switch {
+		case r.recent(outcome.success, now) && outcome.success.After(outcome.failure):
+			preferred = append(preferred, addr)
+		case r.recent(outcome.failure, now) && outcome.failure.After(outcome.success):
+			failed = append(failed, addr)
+		case r.familyFailing(family, now):
+			failed = append(failed, addr)
+		default:
+			rest = append(rest, addr)
+		}
+	}
+	sort.SliceStable(preferred, func(i, j int) bool {
+		return tracked[string(preferred[i].Bytes())].success.After(tracked[string(preferred[j].Bytes())].success)
+	})
+
+	res := make([]network.AddrDelay, 0, len(addrs))
+	res = append(res, swarm.DefaultDialRanker(preferred)...)
+	var offset time.Duration
+	if len(res) > 0 {
+		offset = maxDelay(res) + swarm.PublicTCPDelay
+	}
+	res = append(res, withOffset(swarm.DefaultDialRanker(rest), offset)...)
+	offset = 0
+	if len(res) > 0 {
+		offset = maxDelay(res) + r.cfg.FailedDelay
+	}
+	res = append(res, withOffset(swarm.DefaultDialRanker(failed), offset)...)
+	for _, ranked := range res {
+		if outcome, exists := tracked[string(ranked.Addr.Bytes())]; exists {
+			outcome.pending = true
+			outcome.dialAt = now.Add(ranked.Delay)
+		}
+	}
+	return res
+}
+
+// Connected implements network.Notifiee.
+func (r *dialRanker) Connected(_ network.Network, conn network.Conn) {
+	stat := conn.Stat()
+	if stat.Direction != network.DirOutbound {
+		return
+	}
+	now := r.now()
+	winner := conn.RemoteMultiaddr()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if family := ipFamily(winner); family != 0 {
+		r.outcome(winner).succeed(now)
+		r.families[family].succeed(now)
+	}
+	for _, addr := range r.peerstore.Addrs(conn.RemotePeer()) {
+		outcome, exists := r.addrs.Peek(string(addr.Bytes()))
+		if !exists || !outcome.pending || addr.Equal(winner) {
+			continue
+		}
+		if outcome.dialAt.Before(stat.Opened) {
+			r.fail(outcome, ipFamily(addr), now)
+		} else {
+			// dial to the address wasn't started before the peer connected
+			outcome.pending = false
+		}
+	}
+}
+
+// Disconnected implements network.Notifiee.
+func (r *dialRanker) Disconnected(network.Network, network.Conn) {}
+
+// Listen implements network.Notifiee.
+func (r *dialRanker) Listen(network.Network, multiaddr.Multiaddr) {}
+
+// ListenClose implements network.Notifiee.
+func (r *dialRanker) ListenClose(network.Network, multiaddr.Multiaddr) {}
+
+func (r *dialRanker) outcome(addr multiaddr.Multiaddr) *dialOutcome {
+	key := string(addr.Bytes())
+	outcome, exists := r.addrs.Get(key)
+	if !exists {
+		outcome = &dialOutcome{}
+		r.addrs.Add(key, outcome)
+	}
+	return outcome
+}
+
+func (r *dialRanker) fail(outcome *dialOutcome, family int, now time.Time) {
+	outcome.fail(now)
+	if family != 0 {
+		r.families[family].fail(now)
+	}
+}
+
+func (r *dialRanker) recent(t, now time.Time) bool {
+	return !t.IsZero() && now.Sub(t) < r.cfg.Expiry
+}
+
+func (r *dialRanker) familyFailing(family int, now time.Time) bool {
+	outcome := r.families[family]
+	return r.cfg.FamilyFailures > 0 && outcome.failures >= r.cfg.FamilyFailures && r.recent(outcome.failure, now)
+}
+
+// ipFamily returns multiaddr.P_IP4 or multiaddr.P_IP6 for the ip address, and 0 for other addresses.
+func ipFamily(addr multiaddr.Multiaddr) int {
+	if addr == nil {
+		return 0
+	}
+	protocols := addr.Protocols()
+	if len(protocols) == 0 {
+		return 0
+	}
+	switch code := protocols[0].Code; code {
+	case multiaddr.P_IP4, multiaddr.P_IP6:
+		return code
+	}
+	return 0
+}
+
+func isRelayAddr(addr multiaddr.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT)
+	return err == nil
+}
+
+func maxDelay(ranked []network.AddrDelay) time.Duration {
+	var rst time.Duration
+	for _, addr := range ranked {
+		rst = max(rst, addr.Delay)
+	}
+	return rst
+}
+
+func withOffset(ranked []network.AddrDelay, offset time.Duration) []network.AddrDelay {
+	for i := range ranked {
+		ranked[i].Delay += offset
+	}
+	return ranked
+}
@@ -0,0 +1,120 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+type testConn struct {
+	network.Conn
+	peer   peer.ID
+	remote multiaddr.Multiaddr
+	stat   network.ConnStats
+}
+
+func (c *testConn) RemotePeer() peer.ID                  { return c.peer }
+func (c *testConn) RemoteMultiaddr() multiaddr.Multiaddr { return c.remote }
+func (c *testConn) Stat() network.ConnStats              { return c.stat }
+
+func testDialRanker(tb testing.TB) (*dialRanker, peerstore.Peerstore, *time.Time) {
+	tb.Helper()
+	ps, err := pstoremem.NewPeerstore()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { ps.Close() })
+	cfg := DefaultConfig().DialRanker
+	cfg.FamilyFailures = 2
+	ranker := newDialRanker(cfg, ps)
+	now := time.Now()
+	ranker.now = func() time.Time { return now }
+	return ranker, ps, &now
+}
+
+func rankedDelays(ranked []network.AddrDelay) map[string]time.Duration {
+	rst := make(map[string]time.Duration, len(ranked))
+	for _, addr := range ranked {
+		rst[addr.Addr.String()] = addr.Delay
+	}
+	return rst
+}
+
+func connect(ranker *dialRanker, id peer.ID, remote multiaddr.Multiaddr, opened time.Time) {
+	ranker.Connected(nil, &testConn{
+		peer:   id,
+		remote: remote,
+		stat:   network.ConnStats{Stats: network.Stats{Direction: network.DirOutbound, Opened: opened}},
+	})
+}
+
+func TestDialRanker(t *testing.T) {
+	ip6 := multiaddr.StringCast("/ip6/2001:db8::1/tcp/7513")
+	ip4 := multiaddr.StringCast("/ip4/1.1.1.1/tcp/7513")
+	other := multiaddr.StringCast("/ip4/1.1.1.2/tcp/7513")
+	addrs := func() []multiaddr.Multiaddr { return []multiaddr.Multiaddr{ip4, ip6, other} }
+
+	t.Run("happy eyeballs without outcomes", func(t *testing.T) {
+		ranker, _, _ := testDialRanker(t)
+		require.Equal(t, swarm.DefaultDialRanker(addrs()), ranker.Rank(addrs()))
+	})
+	t.Run("succeeded address first", func(t *testing.T) {
+		ranker, ps, now := testDialRanker(t)
+		id := peer.ID("peer")
+		ps.AddAddrs(id, addrs(), time.Hour)
+
+		delays := rankedDelays(ranker.Rank(addrs()))
+		require.Zero(t, delays[ip6.String()])
+		require.Equal(t, swarm.PublicTCPDelay, delays[ip4.String()])
+
+		// ipv6 dial was started, but the connection over ipv4 won
+		connect(ranker, id, ip4, now.Add(swarm.PublicTCPDelay+time.Millisecond))
+		*now = now.Add(time.Minute)
+		delays = rankedDelays(ranker.Rank(addrs()))
+		require.Zero(t, delays[ip4.String()])
+		require.Equal(t, swarm.PublicTCPDelay, delays[other.String()])
+		require.Equal(t, swarm.PublicTCPDelay+ranker.cfg.FailedDelay, delays[ip6.String()])
+	})
+	t.Run("dial not started before connection", func(t *testing.T) {
+		ranker, ps, now := testDialRanker(t)
+		id := peer.ID("peer")
+		ps.AddAddrs(id, addrs(), time.Hour)
+
+		ranker.Rank(addrs())
+		connect(ranker, id, ip6, now.Add(time.Millisecond))
+		delays := rankedDelays(ranker.Rank(addrs()))
+		require.Zero(t, delays[ip6.String()])
+		require.Less(t, delays[ip4.String()], ranker.cfg.FailedDelay)
+		require.Less(t, delays[other.String()], ranker.cfg.FailedDelay)
+	})
+	t.Run("failing family", func(t *testing.T) {
+		ranker, _, now := testDialRanker(t)
+		for i := 0; i < ranker.cfg.FamilyFailures; i++ {
+			ranker.Rank([]multiaddr.Multiaddr{multiaddr.StringCast("/ip6/2001:db8::2/tcp/7513")})
+			*now = now.Add(time.Second)
+		}
+		// the pending dial to the address failed when it is ranked again
+		ranker.Rank([]multiaddr.Multiaddr{multiaddr.StringCast("/ip6/2001:db8::2/tcp/7513")})
+
+		delays := rankedDelays(ranker.Rank(addrs()))
+		require.Zero(t, delays[ip4.String()])
+		require.Greater(t, delays[ip6.String()], delays[other.String()]+ranker.cfg.FailedDelay/2)
+
+		*now = now.Add(ranker.cfg.Expiry)
+		require.Equal(t, swarm.DefaultDialRanker(addrs()), ranker.Rank(addrs()))
+	})
+	t.Run("relay and dns addresses", func(t *testing.T) {
+		ranker, _, _ := testDialRanker(t)
+		addrs := []multiaddr.Multiaddr{
+			multiaddr.StringCast("/dns4/example.com/tcp/7513"),
+			multiaddr.StringCast("/ip4/1.1.1.1/tcp/7513/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC/p2p-circuit"),
+		}
+		require.Len(t, ranker.Rank(addrs), 2)
+		require.Zero(t, ranker.addrs.Len())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	lp2plog "github.com/ipfs/go-log/v2"
@@ -86,9 +87,14 @@ func DefaultConfig() Config {
 		GossipQueueSize:             50000,
 		GossipValidationThrottle:    50000,
 		GossipAtxValidationThrottle: 50000,
+		GossipValidationCacheSize:   10000,
+		GossipValidationCacheTTL:    time.Minute,
 		PingInterval:                time.Second,
 		EnableTCPTransport:          true,
 		EnableQUICTransport:         false,
+		Proxy: ProxyConfig{
+			DNSServer: "1.1.1.1:53",
+		},
 		AutoNATServer: AutoNATServer{
 			// Defaults taken from libp2p
 			GlobalMax:   30,
@@ -101,6 +107,13 @@ func DefaultConfig() Config {
 			AdvertiseRetryDelay: time.Minute,
 			FindPeersRetryDelay: time.Minute,
 		},
+		DialRanker: DialRankerConfig{
+			Enable:         true,
+			FailedDelay:    time.Second,
+			FamilyFailures: 10,
+			Expiry:         time.Hour,
+			Size:           10000,
+		},
 	}
 }
 
@@ -117,45 +130,51 @@ type Config struct {
 	MaxMessageSize     int           `mapstructure:"maxmessagesize"`
 
 	// see https://lwn.net/Articles/542629/ for reuseport explanation
-	DisableReusePort            bool             `mapstructure:"disable-reuseport"`
-	DisableNatPort              bool             `mapstructure:"disable-natport"`
-	DisableConnectionManager    bool             `mapstructure:"disable-connection-manager"`
-	DisableResourceManager      bool             `mapstructure:"disable-resource-manager"`
-	DisableDHT                  bool             `mapstructure:"disable-dht"`
-	Flood                       bool             `mapstructure:"flood"`
-	Listen                      AddressList      `mapstructure:"listen"`
-	Bootnodes                   []string         `mapstructure:"bootnodes"`
-	Direct                      []string         `mapstructure:"direct"`
-	MinPeers                    int              `mapstructure:"min-peers"`
-	LowPeers                    int              `mapstructure:"low-peers"`
-	HighPeers                   int              `mapstructure:"high-peers"`
-	InboundFraction             float64          `mapstructure:"inbound-fraction"`
-	OutboundFraction            float64          `mapstructure:"outbound-fraction"`
-	AutoscalePeers              bool             `mapstructure:"autoscale-peers"`
-	AdvertiseAddress            AddressList      `mapstructure:"advertise-address"`
-	AcceptQueue                 int              `mapstructure:"p2p-accept-queue"`
-	Metrics                     bool             `mapstructure:"p2p-metrics"`
-	Bootnode                    bool             `mapstructure:"p2p-bootnode"`
-	ForceReachability           string           `mapstructure:"p2p-reachability"`
-	ForceDHTServer              bool             `mapstructure:"force-dht-server"`
-	EnableHolepunching          bool             `mapstructure:"p2p-holepunching"`
-	PrivateNetwork              bool             `mapstructure:"p2p-private-network"`
-	RelayServer                 RelayServer      `mapstructure:"relay-server"`
-	IP4Blocklist                []string         `mapstructure:"ip4-blocklist"`
-	IP6Blocklist                []string         `mapstructure:"ip6-blocklist"`
-	GossipQueueSize             int              `mapstructure:"gossip-queue-size"`
-	GossipValidationThrottle    int              `mapstructure:"gossip-validation-throttle"`
-	GossipAtxValidationThrottle int              `mapstructure:"gossip-atx-validation-throttle"`
-	PingPeers                   []string         `mapstructure:"ping-peers"`
-	PingInterval                time.Duration    `mapstructure:"ping-interval"`
-	Relay                       bool             `mapstructure:"relay"`
-	StaticRelays                []string         `mapstructure:"static-relays"`
-	EnableTCPTransport          bool             `mapstructure:"enable-tcp-transport"`
-	EnableQUICTransport         bool             `mapstructure:"enable-quic-transport"`
-	EnableRoutingDiscovery      bool             `mapstructure:"enable-routing-discovery"`
-	RoutingDiscoveryAdvertise   bool             `mapstructure:"routing-discovery-advertise"`
-	DiscoveryTimings            DiscoveryTimings `mapstructure:"discovery-timings"`
-	AutoNATServer               AutoNATServer    `mapstructure:"auto-nat-server"`
+	DisableReusePort            bool        `mapstructure:"disable-reuseport"`
+	DisableNatPort              bool        `mapstructure:"disable-natport"`
+	DisableConnectionManager    bool        `mapstructure:"disable-connection-manager"`
+	DisableResourceManager      bool        `mapstructure:"disable-resource-manager"`
+	DisableDHT                  bool        `mapstructure:"disable-dht"`
+	Flood                       bool        `mapstructure:"flood"`
+	Listen                      AddressList `mapstructure:"listen"`
+	Bootnodes                   []string    `mapstructure:"bootnodes"`
+	Direct                      []string    `mapstructure:"direct"`
+	MinPeers                    int         `mapstructure:"min-peers"`
+	LowPeers                    int         `mapstructure:"low-peers"`
+	HighPeers                   int         `mapstructure:"high-peers"`
+	InboundFraction             float64     `mapstructure:"inbound-fraction"`
+	OutboundFraction            float64     `mapstructure:"outbound-fraction"`
+	AutoscalePeers              bool        `mapstructure:"autoscale-peers"`
+	AdvertiseAddress            AddressList `mapstructure:"advertise-address"`
+	AcceptQueue                 int         `mapstructure:"p2p-accept-queue"`
+	Metrics                     bool        `mapstructure:"p2p-metrics"`
+	Bootnode                    bool        `mapstructure:"p2p-bootnode"`
+	ForceReachability           string      `mapstructure:"p2p-reachability"`
+	ForceDHTServer              bool        `mapstructure:"force-dht-server"`
+	EnableHolepunching          bool        `mapstructure:"p2p-holepunching"`
+	PrivateNetwork              bool        `mapstructure:"p2p-private-network"`
+	RelayServer                 RelayServer `mapstructure:"relay-server"`
+	IP4Blocklist                []string    `mapstructure:"ip4-blocklist"`
+	IP6Blocklist                []string    `mapstructure:"ip6-blocklist"`
+	GossipQueueSize             int         `mapstructure:"gossip-queue-size"`
+	GossipValidationThrottle    int         `mapstructure:"gossip-validation-throttle"`
+	GossipAtxValidationThrottle int         `mapstructure:"gossip-atx-validation-throttle"`
+	// GossipValidationCacheSize is the number of gossip messages for which the results of the validation
+	// are kept for GossipValidationCacheTTL, so that a message received from several peers is validated once.
+	GossipValidationCacheSize int              `mapstructure:"gossip-validation-cache-size"`
+	GossipValidationCacheTTL  time.Duration    `mapstructure:"gossip-validation-cache-ttl"`
+	PingPeers                 []string         `mapstructure:"ping-peers"`
+	PingInterval              time.Duration    `mapstructure:"ping-interval"`
+	Relay                     bool             `mapstructure:"relay"`
+	StaticRelays              []string         `mapstructure:"static-relays"`
+	EnableTCPTransport        bool             `mapstructure:"enable-tcp-transport"`
+	EnableQUICTransport       bool             `mapstructure:"enable-quic-transport"`
+	EnableRoutingDiscovery    bool             `mapstructure:"enable-routing-discovery"`
+	RoutingDiscoveryAdvertise bool             `mapstructure:"routing-discovery-advertise"`
+	DiscoveryTimings          DiscoveryTimings `mapstructure:"discovery-timings"`
+	AutoNATServer             AutoNATServer    `mapstructure:"auto-nat-server"`
+	DialRanker                DialRankerConfig `mapstructure:"dial-ranker"`
+	Proxy                     ProxyConfig      `mapstructure:"proxy"`
 }
 
 type DiscoveryTimings struct {
@@ -198,6 +217,14 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.DialRanker.Enable && cfg.DialRanker.Size <= 0 {
+		return errors.New("dial-ranker size must be positive")
+	}
+
+	if err := cfg.Proxy.validate(cfg); err != nil {
+		return err
+	}
+
 	if len(cfg.ForceReachability) > 0 {
 		if cfg.ForceReachability != PublicReachability &&
 			cfg.ForceReachability != PrivateReachability {
@@ -267,20 +294,38 @@ func New(
 			cfg.AutoNATServer.ResetPeriod),
 		libp2p.ConnectionGater(g),
 	}
-	if cfg.EnableTCPTransport {
+	if cfg.Proxy.Enabled() {
+		dialer, err := cfg.Proxy.dialer()
+		if err != nil {
+			return nil, err
+		}
+		resolver, err := cfg.Proxy.resolver(dialer)
+		if err != nil {
+			return nil, fmt.Errorf("proxy dns resolver: %w", err)
+		}
 		lopts = append(lopts,
 			libp2p.Transport(
 				func(upgrader transport.Upgrader, rcmgr network.ResourceManager) (transport.Transport, error) {
-					opts := []tcp.Option{}
-					if cfg.DisableReusePort {
-						opts = append(opts, tcp.DisableReuseport())
-					}
-					if cfg.Metrics {
-						opts = append(opts, tcp.WithMetrics())
-					}
-					return tcp.NewTCPTransport(upgrader, rcmgr, opts...)
+					return newProxyTransport(dialer, upgrader, rcmgr, tcpOptions(cfg)...)
+				},
+			),
+			libp2p.MultiaddrResolver(resolver),
+		)
+		logger.Zap().Info("outbound connections are routed through the proxy",
+			zap.String("proxy", cfg.Proxy.Address),
+			zap.String("dns_server", cfg.Proxy.DNSServer),
+		)
+	} else if cfg.EnableTCPTransport {
+		lopts = append(lopts,
+			libp2p.Transport(
+				func(upgrader transport.Upgrader, rcmgr network.ResourceManager) (transport.Transport, error) {
+					return tcp.NewTCPTransport(upgrader, rcmgr, tcpOptions(cfg)...)
 				},
 			),
+		)
+	}
+	if cfg.EnableTCPTransport {
+		lopts = append(lopts,
 			libp2p.Security(
 				noise.ID,
 				func(id protocol.ID, privkey crypto.PrivKey, muxers []tptu.StreamMuxer) (*noise.SessionTransport, error) {
@@ -322,7 +367,24 @@ func New(
 	} else {
 		lopts = append(lopts, libp2p.ConnectionManager(&ccmgr.NullConnMgr{}))
 	}
-	if len(cfg.AdvertiseAddress) > 0 {
+	if cfg.Proxy.Enabled() {
+		// addresses of the listeners and the addresses observed by the peers would reveal the node,
+		// therefore only the configured addresses are advertised
+		advertise := slices.Clone(cfg.AdvertiseAddress)
+		if cfg.Proxy.OnionAddress != "" {
+			onion, err := cfg.Proxy.onion()
+			if err != nil {
+				return nil, err
+			}
+			advertise = append(advertise, onion)
+		}
+		lopts = append(
+			lopts,
+			libp2p.AddrsFactory(func([]multiaddr.Multiaddr) []multiaddr.Multiaddr {
+				return advertise
+			}),
+		)
+	} else if len(cfg.AdvertiseAddress) > 0 {
 		lopts = append(
 			lopts,
 			libp2p.AddrsFactory(func([]multiaddr.Multiaddr) []multiaddr.Multiaddr {
@@ -330,7 +392,7 @@ func New(
 			}),
 		)
 	}
-	if cfg.EnableHolepunching {
+	if cfg.EnableHolepunching && !cfg.Proxy.Enabled() {
 		lopts = append(lopts, libp2p.EnableHolePunching())
 	}
 	if cfg.Relay {
@@ -371,18 +433,26 @@ func New(
 		lopts = append(lopts, libp2p.ForceReachabilityPrivate())
 	}
 	lopts = append(lopts, setupResourcesManager(cfg))
-	if !cfg.DisableNatPort {
+	if !cfg.DisableNatPort && !cfg.Proxy.Enabled() {
 		lopts = append(lopts, libp2p.NATPortMap())
 	}
 	if cfg.AcceptQueue != 0 {
 		tptu.AcceptQueueLength = cfg.AcceptQueue
 	}
+	var ranker *dialRanker
+	if cfg.DialRanker.Enable {
+		ranker = newDialRanker(cfg.DialRanker, ps)
+		lopts = append(lopts, libp2p.DialRanker(ranker.Rank))
+	}
 	h, err := libp2p.New(lopts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize libp2p host: %w", err)
 	}
 	g.updateHost(h)
 	h.Network().Notify(p2pmetrics.NewConnectionsMeeter())
+	if ranker != nil {
+		h.Network().Notify(ranker)
+	}
 
 	logger.Zap().Info("local node identity", zap.Stringer("identity", h.ID()))
 	// TODO(dshulyak) this is small mess. refactor to avoid this patching
@@ -397,6 +467,17 @@ func New(
 	return Upgrade(h, opts...)
 }
 
+func tcpOptions(cfg Config) []tcp.Option {
+	opts := []tcp.Option{}
+	if cfg.DisableReusePort {
+		opts = append(opts, tcp.DisableReuseport())
+	}
+	if cfg.Metrics {
+		opts = append(opts, tcp.WithMetrics())
+	}
+	return opts
+}
+
 func setupResourcesManager(hostcfg Config) func(cfg *libp2p.Config) error {
 	return func(cfg *libp2p.Config) error {
 		rcmgr.MustRegisterWith(prometheus.DefaultRegisterer)
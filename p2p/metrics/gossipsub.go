@@ -30,6 +30,15 @@ var (
 		[]string{"protocol", "result"},
 		prometheus.ExponentialBuckets(1_000_000, 4, 10),
 	)
+	// GossipValidationCache counts messages by the source of the validation result. Labeled by protocol
+	// and source: miss if the message is validated, hit if the cached result is used and shared
+	// if the result of the validation of the same message in progress is used.
+	GossipValidationCache = metrics.NewCounter(
+		"gossip_validation_cache",
+		subsystem,
+		"Number of gossip messages by the source of the validation result",
+		[]string{"protocol", "source"},
+	)
 	deliveredMessagesBytes = metrics.NewCounter(
 		"delivered_messages_bytes",
 		subsystem,
@@ -0,0 +1,232 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/multiformats/go-multiaddr"
+	madns "github.com/multiformats/go-multiaddr-dns"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"golang.org/x/net/proxy"
+)
+
+// ProxyConfig routes all outbound connections through the SOCKS5 proxy, e.g. the local Tor client.
+// Holepunching and NAT port mapping are disabled with the proxy, as they require direct connections.
+type ProxyConfig struct {
+	// Address of the SOCKS5 proxy in the host:port format. Connections are not proxied if it is empty.
+	Address  string `mapstructure:"address"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// DNSServer is queried over tcp through the proxy, so that the addresses of the peers
+	// are not resolved by the local resolver.
+	DNSServer string `mapstructure:"dns-server"`
+	// OnionAddress of the onion service that forwards to the tcp listener, e.g. /onion3/<service-id>:7513.
+	// It is advertised to the peers that can dial it through their proxy.
+	OnionAddress string `mapstructure:"onion-address"`
+}
+
+// Enabled returns true if the outbound connections are routed through the proxy.
+func (c *ProxyConfig) Enabled() bool {
+	return c.Address != ""
+}
+
+func (c *ProxyConfig) validate(cfg *Config) error {
+	if !c.Enabled() {
+		if c.OnionAddress != "" {
+			return errors.New("onion-address requires the proxy to be configured")
+		}
+		return nil
+	}
+	if !cfg.EnableTCPTransport {
+		return errors.New("proxy requires tcp transport")
+	}
+	if cfg.EnableQUICTransport {
+		return errors.New("quic transport can't be used with the proxy, as socks5 doesn't proxy udp")
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("proxy address %s: %w", c.Address, err)
+	}
+	if _, _, err := net.SplitHostPort(c.DNSServer); err != nil {
+		return fmt.Errorf("proxy dns-server %s: %w", c.DNSServer, err)
+	}
+	if c.OnionAddress != "" {
+		if _, err := c.onion(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ProxyConfig) onion() (multiaddr.Multiaddr, error) {
+	addr, err := multiaddr.NewMultiaddr(c.OnionAddress)
+	if err != nil {
+		return nil, fmt.Errorf("onion-address %s: %w", c.OnionAddress, err)
+	}
+	if first, _ := multiaddr.SplitFirst(addr); first == nil || first.Protocol().Code != multiaddr.P_ONION3 {
+		return nil, fmt.Errorf("onion-address %s must be an onion3 address", c.OnionAddress)
+	}
+	return addr, nil
+}
+
+func (c *ProxyConfig) dialer() (proxy.ContextDialer, error) {
+	var auth *proxy.Auth
+	if c.Username != "" {
+		auth = &proxy.Auth{User: c.Username, Password: c.Password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", c.Address, auth, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("socks5 proxy %s: %w", c.Address, err)
+	}
+	return dialer.(proxy.ContextDialer), nil
+}
+
+// resolver returns the resolver that queries the dns server over tcp through the proxy.
+func (c *ProxyConfig) resolver(dialer proxy.ContextDialer) (*madns.Resolver, error) {
+	return madns.NewResolver(madns.WithDefaultResolver(&net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			// the stream connection makes the resolver use dns over tcp, as socks5 doesn't proxy udp
+			return dialer.DialContext(ctx, "tcp", c.DNSServer)
+		},
+	}))
+}
+
+// proxyTarget returns the host:port of the tcp or onion address that is dialed through the proxy.
+func proxyTarget(addr multiaddr.Multiaddr) (string, error) {
+	first, rest := multiaddr.SplitFirst(addr)
+	if first == nil {
+		return "", fmt.Errorf("empty address")
+	}
+	switch first.Protocol().Code {
+	case multiaddr.P_ONION3:
+		if rest != nil {
+			return "", fmt.Errorf("unsupported onion address %s", addr)
+		}
+		service, port, _ := strings.Cut(first.Value(), ":")
+		return net.JoinHostPort(service+".onion", port), nil
+	case multiaddr.P_IP4, multiaddr.P_IP6, multiaddr.P_DNS, multiaddr.P_DNS4, multiaddr.P_DNS6:
+		second, rest := multiaddr.SplitFirst(rest)
+		if second == nil || second.Protocol().Code != multiaddr.P_TCP || rest != nil {
+			return "", fmt.Errorf("unsupported address %s", addr)
+		}
+		return net.JoinHostPort(first.Value(), second.Value()), nil
+	}
+	return "", fmt.Errorf("unsupported address %s", addr)
+}
+
+// proxyTransport dials tcp and onion addresses through the SOCKS5 proxy.
+// Listening is not proxied, inbound connections are accepted by the tcp transport.
+type proxyTransport struct {
+	*tcp.TcpTransport
+	upgrader transport.Upgrader
+	rcmgr    network.ResourceManager
+	dialer   proxy.ContextDialer
+}
+
+func newProxyTransport(
+	dialer proxy.ContextDialer,
+	upgrader transport.Upgrader,
+	rcmgr network.ResourceManager,
+	opts ...tcp.Option,
+) (*proxyTransport, error) {
+	tr, err := tcp.NewTCPTransport(upgrader, rcmgr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if rcmgr == nil {
+		rcmgr = &network.NullResourceManager{}
+	}
+	return &proxyTransport{TcpTransport: tr, upgrader: upgrader, rcmgr: rcmgr, dialer: dialer}, nil
+}
+
+func (t *proxyTransport) CanDial(addr multiaddr.Multiaddr) bool {
+	_, err := proxyTarget(addr)
+	return err == nil
+}
+
+func (t *proxyTransport) Dial(
+	ctx context.Context,
+	raddr multiaddr.Multiaddr,
+	p peer.ID,
+) (transport.CapableConn, error) {
+	return t.DialWithUpdates(ctx, raddr, p, nil)
+}
+
+// DialWithUpdates overrides the method of the tcp transport that is preferred by the swarm for dialing.
+func (t *proxyTransport) DialWithUpdates(
+	ctx context.Context,
+	raddr multiaddr.Multiaddr,
+	p peer.ID,
+	_ chan<- transport.DialUpdate,
+) (transport.CapableConn, error) {
+	target, err := proxyTarget(raddr)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := t.rcmgr.OpenConnection(network.DirOutbound, true, raddr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := t.dial(ctx, target, raddr, p, scope)
+	if err != nil {
+		scope.Done()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (t *proxyTransport) dial(
+	ctx context.Context,
+	target string,
+	raddr multiaddr.Multiaddr,
+	p peer.ID,
+	scope network.ConnManagementScope,
+) (transport.CapableConn, error) {
+	if err := scope.SetPeer(p); err != nil {
+		return nil, err
+	}
+	conn, err := t.dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s through proxy: %w", raddr, err)
+	}
+	laddr, err := manet.FromNetAddr(conn.LocalAddr())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	direction := network.DirOutbound
+	if ok, isClient, _ := network.GetSimultaneousConnect(ctx); ok && !isClient {
+		direction = network.DirInbound
+	}
+	// the remote address of the connection is the proxy, the peer is identified by the dialed address
+	return t.upgrader.Upgrade(ctx, t, &proxyConn{Conn: conn, laddr: laddr, raddr: raddr}, direction, p, scope)
+}
+
+// Proxy returns true, as the connections are not direct.
+func (t *proxyTransport) Proxy() bool {
+	return true
+}
+
+func (t *proxyTransport) String() string {
+	return "TCP over SOCKS5"
+}
+
+type proxyConn struct {
+	net.Conn
+	laddr, raddr multiaddr.Multiaddr
+}
+
+func (c *proxyConn) LocalMultiaddr() multiaddr.Multiaddr {
+	return c.laddr
+}
+
+func (c *proxyConn) RemoteMultiaddr() multiaddr.Multiaddr {
+	return c.raddr
+}
@@ -0,0 +1,180 @@
+package p2p
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+)
+
+// socks5Server is the minimal SOCKS5 proxy without authentication that records the dialed targets.
+type socks5Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func startSocks5(tb testing.TB) *socks5Server {
+	tb.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	srv := &socks5Server{listener: listener}
+	tb.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv
+}
+
+func (s *socks5Server) dialed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.targets...)
+}
+
+func (s *socks5Server) serve(conn net.Conn) {
+	defer conn.Close()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return
+		}
+		host = string(domain)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	s.mu.Lock()
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestProxyTarget(t *testing.T) {
+	for _, tc := range []struct {
+		addr, target string
+	}{
+		{"/ip4/10.0.0.1/tcp/7513", "10.0.0.1:7513"},
+		{"/ip6/::1/tcp/7513", "[::1]:7513"},
+		{"/dns4/bootnode.example.com/tcp/7513", "bootnode.example.com:7513"},
+		{"/onion3/vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd:7513",
+			"vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion:7513"},
+		{"/ip4/10.0.0.1/udp/7513/quic-v1", ""},
+		{"/ip4/10.0.0.1/tcp/7513/ws", ""},
+	} {
+		target, err := proxyTarget(multiaddr.StringCast(tc.addr))
+		if tc.target == "" {
+			require.Error(t, err, tc.addr)
+			continue
+		}
+		require.NoError(t, err, tc.addr)
+		require.Equal(t, tc.target, target)
+	}
+}
+
+func TestProxyConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.Validate())
+
+	cfg.Proxy.OnionAddress = "/onion3/vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd:7513"
+	require.ErrorContains(t, cfg.Validate(), "requires the proxy")
+	cfg.Proxy.Address = "127.0.0.1:9050"
+	require.NoError(t, cfg.Validate())
+
+	cfg.EnableQUICTransport = true
+	require.ErrorContains(t, cfg.Validate(), "quic")
+	cfg.EnableQUICTransport = false
+
+	cfg.Proxy.OnionAddress = "/ip4/127.0.0.1/tcp/7513"
+	require.ErrorContains(t, cfg.Validate(), "onion3")
+	cfg.Proxy.OnionAddress = ""
+	cfg.Proxy.Address = "9050"
+	require.ErrorContains(t, cfg.Validate(), "proxy address")
+}
+
+func TestProxy(t *testing.T) {
+	socks := startSocks5(t)
+
+	cfg1 := DefaultConfig()
+	cfg1.DataDir = t.TempDir()
+	cfg1.Listen = MustParseAddresses("/ip4/127.0.0.1/tcp/0")
+	cfg1.IP4Blocklist = nil
+	h1, err := New(context.Background(), logtest.New(t), cfg1, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { h1.Stop() })
+
+	onion := "/onion3/vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd:7513"
+	cfg2 := DefaultConfig()
+	cfg2.DataDir = t.TempDir()
+	cfg2.Listen = MustParseAddresses("/ip4/127.0.0.1/tcp/0")
+	cfg2.IP4Blocklist = nil
+	cfg2.Proxy.Address = socks.listener.Addr().String()
+	cfg2.Proxy.OnionAddress = onion
+	h2, err := New(context.Background(), logtest.New(t), cfg2, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { h2.Stop() })
+	require.Equal(t, []multiaddr.Multiaddr{multiaddr.StringCast(onion)}, h2.Addrs(),
+		"only the onion address is advertised")
+
+	require.NoError(t, h2.Connect(context.Background(), peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()}))
+	target, err := proxyTarget(h1.Addrs()[0])
+	require.NoError(t, err)
+	require.Contains(t, socks.dialed(), target)
+}
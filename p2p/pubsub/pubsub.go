@@ -73,13 +73,24 @@ const (
 	BeaconFirstVotesProtocol = "bf1"
 	// BeaconFollowingVotesProtocol is the protocol id for beacon following votes.
 	BeaconFollowingVotesProtocol = "bo1"
+	// BeaconFallbackProtocol is the protocol id for beacons signed by the fallback authorities.
+	BeaconFallbackProtocol = "bfb1"
 
 	MalfeasanceProof = "mp1"
+
+	// PoetFraudProtocol is the protocol id for reports of invalid proofs signed by poet services.
+	PoetFraudProtocol = "pf1"
 )
 
 // DefaultConfig for PubSub.
 func DefaultConfig() Config {
-	return Config{Flood: true, QueueSize: 10000, Throttle: 10000}
+	return Config{
+		Flood:               true,
+		QueueSize:           10000,
+		Throttle:            10000,
+		ValidationCacheSize: 10000,
+		ValidationCacheTTL:  time.Minute,
+	}
 }
 
 // Config for PubSub.
@@ -92,6 +103,10 @@ type Config struct {
 	MaxMessageSize int
 	QueueSize      int
 	Throttle       int
+	// ValidationCacheSize is the number of messages for which the results of the validation are cached.
+	// Messages are validated once within ValidationCacheTTL. The cache is disabled if either is zero.
+	ValidationCacheSize int
+	ValidationCacheTTL  time.Duration
 }
 
 // New creates PubSub instance.
@@ -102,12 +117,16 @@ func New(ctx context.Context, logger log.Log, h host.Host, cfg Config) (*PubSub,
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize gossipsub instance: %w", err)
 	}
-	return &PubSub{
+	rst := &PubSub{
 		logger: logger,
 		pubsub: ps,
 		topics: map[string]*pubsub.Topic{},
 		host:   h,
-	}, nil
+	}
+	if cfg.ValidationCacheSize > 0 && cfg.ValidationCacheTTL > 0 {
+		rst.validations = newValidationCache(cfg.ValidationCacheSize, cfg.ValidationCacheTTL)
+	}
+	return rst, nil
 }
 
 //go:generate mockgen -typed -package=mocks -destination=./mocks/publisher.go -source=./pubsub.go
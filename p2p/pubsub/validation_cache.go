@@ -0,0 +1,102 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
+)
+
+const (
+	cacheMiss   = "miss"
+	cacheHit    = "hit"
+	cacheShared = "shared"
+)
+
+type validationKey [32]byte
+
+type validationResult struct {
+	err     error
+	expires time.Time
+}
+
+// validation is the validation of the message that is in progress.
+type validation struct {
+	done chan struct{}
+	err  error
+}
+
+// validationCache keeps the results of the validation of gossip messages for a short time,
+// so that a message received from several peers before it is deduplicated by gossipsub
+// is validated only once. Messages received while the same message is validated
+// wait for the result of that validation.
+//
+// Only accepted and rejected messages are cached, other results may change on the retry,
+// e.g. once the dependencies of the message are available.
+type validationCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	results  *lru.Cache[validationKey, validationResult]
+	inflight map[validationKey]*validation
+}
+
+func newValidationCache(size int, ttl time.Duration) *validationCache {
+	results, err := lru.New[validationKey, validationResult](size)
+	if err != nil {
+		panic(err) // only on a non-positive size
+	}
+	return &validationCache{
+		ttl:      ttl,
+		now:      time.Now,
+		results:  results,
+		inflight: map[validationKey]*validation{},
+	}
+}
+
+// wrap returns a handler that validates each message of the topic once within the ttl of the cache.
+func (c *validationCache) wrap(topic string, handler GossipHandler) GossipHandler {
+	return func(ctx context.Context, pid peer.ID, msg []byte) error {
+		key := validationKey(hash.Sum([]byte(topic), msg))
+		c.mu.Lock()
+		if result, exists := c.results.Get(key); exists {
+			if c.now().Before(result.expires) {
+				c.mu.Unlock()
+				metrics.GossipValidationCache.WithLabelValues(topic, cacheHit).Inc()
+				return result.err
+			}
+			c.results.Remove(key)
+		}
+		if ongoing, exists := c.inflight[key]; exists {
+			c.mu.Unlock()
+			metrics.GossipValidationCache.WithLabelValues(topic, cacheShared).Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ongoing.done:
+				return ongoing.err
+			}
+		}
+		ongoing := &validation{done: make(chan struct{})}
+		c.inflight[key] = ongoing
+		c.mu.Unlock()
+		metrics.GossipValidationCache.WithLabelValues(topic, cacheMiss).Inc()
+
+		ongoing.err = handler(ctx, pid, msg)
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if ongoing.err == nil || errors.Is(ongoing.err, ErrValidationReject) {
+			c.results.Add(key, validationResult{err: ongoing.err, expires: c.now().Add(c.ttl)})
+		}
+		c.mu.Unlock()
+		close(ongoing.done)
+		return ongoing.err
+	}
+}
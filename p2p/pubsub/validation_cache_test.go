@@ -0,0 +1,92 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
+)
+
+func TestValidationCache(t *testing.T) {
+	t.Run("cached results", func(t *testing.T) {
+		cache := newValidationCache(10, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		results := map[string]error{
+			"valid":   nil,
+			"invalid": fmt.Errorf("bad signature: %w", ErrValidationReject),
+			"ignored": errors.New("missing dependency"),
+		}
+		calls := map[string]int{}
+		handler := cache.wrap(t.Name(), func(_ context.Context, _ peer.ID, msg []byte) error {
+			calls[string(msg)]++
+			return results[string(msg)]
+		})
+		for i := 0; i < 3; i++ {
+			for msg, expected := range results {
+				require.Equal(t, expected, handler(context.Background(), "p1", []byte(msg)))
+			}
+		}
+		require.Equal(t, map[string]int{"valid": 1, "invalid": 1, "ignored": 3}, calls)
+		require.Equal(t, 4.0, testutil.ToFloat64(metrics.GossipValidationCache.WithLabelValues(t.Name(), cacheHit)))
+
+		now = now.Add(time.Minute)
+		require.NoError(t, handler(context.Background(), "p1", []byte("valid")))
+		require.Equal(t, 2, calls["valid"])
+	})
+	t.Run("topics are cached separately", func(t *testing.T) {
+		cache := newValidationCache(10, time.Minute)
+		var calls atomic.Int32
+		handler := func(context.Context, peer.ID, []byte) error {
+			calls.Add(1)
+			return nil
+		}
+		for _, topic := range []string{"t1", "t2"} {
+			require.NoError(t, cache.wrap(topic, handler)(context.Background(), "p1", []byte("msg")))
+		}
+		require.Equal(t, int32(2), calls.Load())
+	})
+	t.Run("concurrent validation is shared", func(t *testing.T) {
+		cache := newValidationCache(10, time.Minute)
+		var calls atomic.Int32
+		started := make(chan struct{})
+		release := make(chan struct{})
+		handler := cache.wrap(t.Name(), func(context.Context, peer.ID, []byte) error {
+			calls.Add(1)
+			close(started)
+			<-release
+			return ErrValidationReject
+		})
+		var eg errgroup.Group
+		eg.Go(func() error {
+			return handler(context.Background(), "p1", []byte("msg"))
+		})
+		<-started
+		const waiting = 3
+		for i := 0; i < waiting; i++ {
+			eg.Go(func() error {
+				return handler(context.Background(), "p2", []byte("msg"))
+			})
+		}
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(metrics.GossipValidationCache.WithLabelValues(t.Name(), cacheShared)) == waiting
+		}, time.Second, 10*time.Millisecond)
+		close(release)
+		require.ErrorIs(t, eg.Wait(), ErrValidationReject)
+		require.Equal(t, int32(1), calls.Load())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		// the cached result is returned even if the context is canceled
+		require.ErrorIs(t, handler(ctx, "p3", []byte("msg")), ErrValidationReject)
+	})
+}
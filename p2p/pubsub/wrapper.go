@@ -10,9 +10,12 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p/metrics"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 // PubSub is a spacemesh-specific wrapper around gossip protocol.
@@ -20,6 +23,8 @@ type PubSub struct {
 	logger log.Log
 	pubsub *pubsub.PubSub
 	host   host.Host
+	// validations is nil if the results of the validation are not cached.
+	validations *validationCache
 
 	mu     sync.RWMutex
 	topics map[string]*pubsub.Topic
@@ -32,13 +37,25 @@ func (ps *PubSub) Register(topic string, handler GossipHandler, opts ...Validato
 	if _, exist := ps.topics[topic]; exist {
 		ps.logger.Panic("already registered a topic %s", topic)
 	}
+	if ps.validations != nil {
+		handler = ps.validations.wrap(topic, handler)
+	}
 	// Drop peers on ValidationRejectErr
 	handler = DropPeerOnValidationReject(handler, ps.host, ps.logger)
 	ps.pubsub.RegisterTopicValidator(
 		topic,
 		func(ctx context.Context, pid peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
 			start := time.Now()
-			err := handler(log.WithNewRequestID(ctx), pid, msg.Data)
+			ctx, span := tracing.StartSpan(log.WithNewRequestID(ctx), "gossip."+topic,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.Stringer("peer", pid),
+					attribute.Int("size", len(msg.Data)),
+				),
+			)
+			err := handler(ctx, pid, msg.Data)
+			span.SetAttributes(attribute.String("result", castResult(err)))
+			tracing.EndSpan(span, err)
 			metrics.ProcessedMessagesDuration.WithLabelValues(topic, castResult(err)).
 				Observe(float64(time.Since(start)))
 			if err != nil {
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const (
+	// authSuffix is appended to the protocol of the server for requests signed by the identity of the client.
+	authSuffix = "/auth"
+	// authOverhead is the upper bound for the size of AuthenticatedRequest without the data.
+	authOverhead = 128
+	// maxAuthSkew is the largest difference between the timestamp of the request and the local time.
+	maxAuthSkew = time.Minute
+)
+
+// ErrUnauthenticated is returned if the authenticated request is not valid.
+var ErrUnauthenticated = errors.New("request is not authenticated")
+
+// AuthProtocol returns the protocol that serves requests signed by the identity of the client.
+func AuthProtocol(proto string) string {
+	return proto + authSuffix
+}
+
+//go:generate scalegen -types AuthenticatedRequest
+
+// AuthenticatedRequest is sent on the authenticated protocol. The request is signed by the identity
+// of the client, so that the server can attribute requests to the identity across reconnects.
+//
+// The signature covers the protocol and the peer id of the server, so that the request can't be
+// replayed to other servers, and the timestamp limits the time when it can be replayed to the same server.
+type AuthenticatedRequest struct {
+	NodeID    types.NodeID
+	Timestamp uint64 // unix seconds
+	Data      []byte `scale:"max=89128960"` // limited by the request size limit of the server
+	Signature types.EdSignature
+}
+
+func authSignedBytes(proto string, server peer.ID, timestamp uint64, data []byte) []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], timestamp)
+	h := hash.Sum([]byte(proto), []byte(server), ts[:], data)
+	return h[:]
+}
+
+func signRequest(signer *signing.EdSigner, proto string, server peer.ID, now time.Time, data []byte) ([]byte, error) {
+	req := AuthenticatedRequest{
+		NodeID:    signer.NodeID(),
+		Timestamp: uint64(now.Unix()),
+		Data:      data,
+	}
+	req.Signature = signer.Sign(signing.REQUEST, authSignedBytes(proto, server, req.Timestamp, data))
+	return codec.Encode(&req)
+}
+
+func verifyRequest(
+	verifier *signing.EdVerifier,
+	proto string,
+	server peer.ID,
+	now time.Time,
+	buf []byte,
+) (*AuthenticatedRequest, error) {
+	var req AuthenticatedRequest
+	if err := codec.Decode(buf, &req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	skew := now.Sub(time.Unix(int64(req.Timestamp), 0))
+	if skew > maxAuthSkew || skew < -maxAuthSkew {
+		return nil, fmt.Errorf("%w: timestamp is off by %v", ErrUnauthenticated, skew)
+	}
+	msg := authSignedBytes(proto, server, req.Timestamp, req.Data)
+	if !verifier.Verify(signing.REQUEST, req.NodeID, msg, req.Signature) {
+		return nil, fmt.Errorf("%w: invalid signature from %s", ErrUnauthenticated, req.NodeID.ShortString())
+	}
+	return &req, nil
+}
+
+type nodeIDKey struct{}
+
+func withNodeID(ctx context.Context, id types.NodeID) context.Context {
+	return context.WithValue(ctx, nodeIDKey{}, id)
+}
+
+// NodeIDFromContext returns the identity of the client if the request that is being handled is authenticated.
+func NodeIDFromContext(ctx context.Context) (types.NodeID, bool) {
+	id, ok := ctx.Value(nodeIDKey{}).(types.NodeID)
+	return id, ok
+}
+
+// Accountant attributes requests served on the authenticated protocol to the identities of the clients.
+type Accountant interface {
+	OnRequest(proto string, id types.NodeID, pid peer.ID, took time.Duration, served bool)
+}
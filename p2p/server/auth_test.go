@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/spacemeshos/go-scale/tester"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+func TestAuthenticatedServer(t *testing.T) {
+	mesh, err := mocknet.FullMeshConnected(5)
+	require.NoError(t, err)
+	proto := "test"
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	verifier := signing.NewEdVerifier()
+
+	// handler responds with the identity of the client, empty if the request is not authenticated
+	handler := func(ctx context.Context, _ []byte) ([]byte, error) {
+		id, ok := NodeIDFromContext(ctx)
+		if !ok {
+			return []byte{}, nil
+		}
+		return id.Bytes(), nil
+	}
+	ledger := NewLedger(10)
+	opts := []Opt{WithTimeout(time.Second), WithLog(logtest.New(t))}
+	signed := New(mesh.Hosts()[0], proto, nil, append(opts, WithSigner(signer))...)
+	unsigned := New(mesh.Hosts()[1], proto, nil, opts...)
+	plain := New(mesh.Hosts()[2], proto, handler, opts...)
+	optional := New(mesh.Hosts()[3], proto, handler,
+		append(opts, WithVerifier(verifier), WithAccountant(ledger))...)
+	required := New(mesh.Hosts()[4], proto, handler,
+		append(opts, WithVerifier(verifier), WithRequireAuthentication(), WithAccountant(ledger))...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	for _, srv := range []*Server{plain, optional, required} {
+		srv := srv
+		eg.Go(func() error {
+			return srv.Run(ctx)
+		})
+	}
+	require.Eventually(t, func() bool {
+		for _, h := range mesh.Hosts()[2:] {
+			if len(h.Mux().Protocols()) == 0 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		cancel()
+		eg.Wait()
+	})
+
+	t.Run("signed to plain", func(t *testing.T) {
+		resp, err := signed.Request(ctx, mesh.Hosts()[2].ID(), []byte("test"))
+		require.NoError(t, err)
+		require.Empty(t, resp)
+	})
+	t.Run("signed to optional", func(t *testing.T) {
+		resp, err := signed.Request(ctx, mesh.Hosts()[3].ID(), []byte("test"))
+		require.NoError(t, err)
+		require.Equal(t, signer.NodeID().Bytes(), resp)
+	})
+	t.Run("unsigned to optional", func(t *testing.T) {
+		resp, err := unsigned.Request(ctx, mesh.Hosts()[3].ID(), []byte("test"))
+		require.NoError(t, err)
+		require.Empty(t, resp)
+	})
+	t.Run("signed to required", func(t *testing.T) {
+		resp, err := signed.Request(ctx, mesh.Hosts()[4].ID(), []byte("test"))
+		require.NoError(t, err)
+		require.Equal(t, signer.NodeID().Bytes(), resp)
+	})
+	t.Run("unsigned to required", func(t *testing.T) {
+		_, err := unsigned.Request(ctx, mesh.Hosts()[4].ID(), []byte("test"))
+		require.Error(t, err)
+	})
+	t.Run("attributed", func(t *testing.T) {
+		usage, exists := ledger.Get(signer.NodeID())
+		require.True(t, exists)
+		require.Equal(t, 2, usage.Requests)
+		require.Zero(t, usage.Failed)
+		require.Equal(t, 1, usage.Peers)
+		require.Equal(t, mesh.Hosts()[0].ID(), usage.Peer)
+	})
+}
+
+func TestVerifyRequest(t *testing.T) {
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	verifier := signing.NewEdVerifier()
+	now := time.Unix(1000, 0)
+	data := []byte("request")
+
+	buf, err := signRequest(signer, "test", "server", now, data)
+	require.NoError(t, err)
+	req, err := verifyRequest(verifier, "test", "server", now.Add(maxAuthSkew), buf)
+	require.NoError(t, err)
+	require.Equal(t, signer.NodeID(), req.NodeID)
+	require.Equal(t, data, req.Data)
+
+	for _, tc := range []struct {
+		desc   string
+		proto  string
+		server string
+		now    time.Time
+		buf    []byte
+	}{
+		{desc: "other protocol", proto: "other", server: "server", now: now, buf: buf},
+		{desc: "other server", proto: "test", server: "other", now: now, buf: buf},
+		{desc: "expired", proto: "test", server: "server", now: now.Add(maxAuthSkew + time.Second), buf: buf},
+		{desc: "future", proto: "test", server: "server", now: now.Add(-maxAuthSkew - time.Second), buf: buf},
+		{desc: "malformed", proto: "test", server: "server", now: now, buf: buf[:len(buf)-1]},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := verifyRequest(verifier, tc.proto, peer.ID(tc.server), tc.now, tc.buf)
+			require.ErrorIs(t, err, ErrUnauthenticated)
+		})
+	}
+}
+
+func TestLedger(t *testing.T) {
+	ledger := NewLedger(2)
+	ids := []types.NodeID{types.RandomNodeID(), types.RandomNodeID(), types.RandomNodeID()}
+	ledger.OnRequest("a", ids[0], "peer-1", 3*time.Second, true)
+	ledger.OnRequest("b", ids[0], "peer-2", time.Second, false)
+	ledger.OnRequest("a", ids[1], "peer-3", time.Second, true)
+
+	top := ledger.Top(1)
+	require.Len(t, top, 1)
+	require.Equal(t, ids[0], top[0].NodeID)
+	require.Equal(t, 2, top[0].Requests)
+	require.Equal(t, 1, top[0].Failed)
+	require.Equal(t, 2, top[0].Peers)
+	require.Equal(t, peer.ID("peer-2"), top[0].Peer)
+	require.Equal(t, 4*time.Second, top[0].Duration)
+
+	// identity with the least usage is evicted
+	ledger.OnRequest("a", ids[2], "peer-4", 2*time.Second, true)
+	_, exists := ledger.Get(ids[1])
+	require.False(t, exists)
+	require.Len(t, ledger.Top(10), 2)
+}
+
+func FuzzAuthenticatedRequestConsistency(f *testing.F) {
+	tester.FuzzConsistency[AuthenticatedRequest](f)
+}
+
+func FuzzAuthenticatedRequestSafety(f *testing.F) {
+	tester.FuzzSafety[AuthenticatedRequest](f)
+}
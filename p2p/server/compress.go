@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	// compressSuffix is appended to the protocol of the server for requests that accept compressed responses.
+	compressSuffix = "/zstd"
+	// maxResponseSize is the limit for the decompressed data, it matches the limit of the Response.
+	maxResponseSize = 89128960
+)
+
+// CompressedProtocol returns the protocol that responds with data compressed by zstd.
+// It is negotiated in addition to the authenticated protocol, e.g. proto/auth/zstd.
+func CompressedProtocol(proto string) string {
+	return proto + compressSuffix
+}
+
+// WithCompression makes the server serve the compressed protocols and the client prefer them,
+// if the peer serves them. Response data shorter than the threshold is sent uncompressed.
+func WithCompression(threshold int) Opt {
+	return func(s *Server) {
+		s.compress = true
+		s.compressThreshold = threshold
+	}
+}
+
+//go:generate scalegen -types CompressedResponse
+
+// CompressedResponse is sent on the compressed protocol. Data is compressed with zstd if Compressed is true.
+type CompressedResponse struct {
+	Compressed bool
+	Data       []byte `scale:"max=89128960"` // 85 MiB
+	Error      string `scale:"max=1024"`
+}
+
+// splitProtocol returns the protocol without the compression suffix and whether it was set.
+func splitProtocol(proto protocol.ID) (protocol.ID, bool) {
+	base, compressed := strings.CutSuffix(string(proto), compressSuffix)
+	return protocol.ID(base), compressed
+}
+
+var (
+	codecOnce sync.Once
+	encoder   *zstd.Encoder
+	decoder   *zstd.Decoder
+)
+
+// zstdCodec returns the encoder and decoder shared by all servers, both are safe for concurrent use
+// of EncodeAll and DecodeAll.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	codecOnce.Do(func() {
+		var err error
+		encoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			panic(fmt.Sprintf("zstd encoder: %v", err))
+		}
+		decoder, err = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(0),
+			zstd.WithDecoderMaxMemory(maxResponseSize),
+		)
+		if err != nil {
+			panic(fmt.Sprintf("zstd decoder: %v", err))
+		}
+	})
+	return encoder, decoder
+}
+
+// compressResponse compresses the data of the response if it is not shorter than the threshold.
+// Data is sent uncompressed if compression doesn't reduce its size.
+func compressResponse(resp *Response, threshold int) *CompressedResponse {
+	rst := &CompressedResponse{Data: resp.Data, Error: resp.Error}
+	if len(resp.Data) < threshold {
+		return rst
+	}
+	enc, _ := zstdCodec()
+	compressed := enc.EncodeAll(resp.Data, make([]byte, 0, len(resp.Data)/2))
+	if len(compressed) < len(resp.Data) {
+		rst.Compressed = true
+		rst.Data = compressed
+	}
+	return rst
+}
+
+// decompressResponse returns the response with decompressed data.
+func decompressResponse(resp *CompressedResponse) (*Response, error) {
+	rst := &Response{Data: resp.Data, Error: resp.Error}
+	if !resp.Compressed {
+		return rst, nil
+	}
+	_, dec := zstdCodec()
+	data, err := dec.DecodeAll(resp.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompress response: %w", err)
+	}
+	rst.Data = data
+	return rst, nil
+}
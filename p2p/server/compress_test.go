@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spacemeshos/go-scale/tester"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+func TestCompressedServer(t *testing.T) {
+	const threshold = 1024
+	mesh, err := mocknet.FullMeshConnected(5)
+	require.NoError(t, err)
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+
+	// handler repeats the request, so that the response is highly compressible
+	handler := func(_ context.Context, msg []byte) ([]byte, error) {
+		return bytes.Repeat(msg, 1000), nil
+	}
+	opts := []Opt{WithTimeout(time.Second), WithLog(logtest.New(t))}
+	compressing := New(mesh.Hosts()[0], "compressing", nil, append(opts, WithCompression(threshold))...)
+	signed := New(mesh.Hosts()[1], "compressing", nil,
+		append(opts, WithCompression(threshold), WithSigner(signer))...)
+	plain := New(mesh.Hosts()[1], "plain", nil, opts...)
+	compressingServer := New(mesh.Hosts()[2], "compressing", handler,
+		append(opts, WithCompression(threshold), WithMetrics())...)
+	authServer := New(mesh.Hosts()[3], "compressing", handler,
+		append(opts, WithCompression(threshold), WithVerifier(signing.NewEdVerifier()), WithRequireAuthentication())...)
+	plainServer := New(mesh.Hosts()[4], "compressing", handler, opts...)
+	plainProtoServer := New(mesh.Hosts()[2], "plain", handler, opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	for _, srv := range []*Server{compressingServer, authServer, plainServer, plainProtoServer} {
+		srv := srv
+		eg.Go(func() error {
+			return srv.Run(ctx)
+		})
+	}
+	require.Eventually(t, func() bool {
+		for _, h := range mesh.Hosts()[2:] {
+			if len(h.Mux().Protocols()) == 0 {
+				return false
+			}
+		}
+		protos := mesh.Hosts()[2].Mux().Protocols()
+		return slices.Contains(protos, "plain") && slices.Contains(protos, protocol.ID(CompressedProtocol("compressing")))
+	}, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		cancel()
+		eg.Wait()
+	})
+
+	request := []byte("compressible")
+	expected := bytes.Repeat(request, 1000)
+	uncompressedBytes := func() float64 {
+		return testutil.ToFloat64(compressingServer.metrics.uncompressedBytes)
+	}
+	t.Run("compressed", func(t *testing.T) {
+		uncompressed := uncompressedBytes()
+		compressed := testutil.ToFloat64(compressingServer.metrics.compressedBytes)
+		resp, err := compressing.Request(ctx, mesh.Hosts()[2].ID(), request)
+		require.NoError(t, err)
+		require.Equal(t, expected, resp)
+		require.Equal(t, float64(len(expected)), uncompressedBytes()-uncompressed)
+		require.Less(t, testutil.ToFloat64(compressingServer.metrics.compressedBytes)-compressed,
+			float64(len(expected))/10)
+	})
+	t.Run("below threshold", func(t *testing.T) {
+		uncompressed := uncompressedBytes()
+		resp, err := compressing.Request(ctx, mesh.Hosts()[2].ID(), []byte{1})
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{1}, 1000), resp)
+		require.Equal(t, uncompressed, uncompressedBytes())
+	})
+	t.Run("authenticated and compressed", func(t *testing.T) {
+		resp, err := signed.Request(ctx, mesh.Hosts()[3].ID(), request)
+		require.NoError(t, err)
+		require.Equal(t, expected, resp)
+	})
+	t.Run("server without compression", func(t *testing.T) {
+		resp, err := compressing.Request(ctx, mesh.Hosts()[4].ID(), request)
+		require.NoError(t, err)
+		require.Equal(t, expected, resp)
+	})
+	t.Run("client without compression", func(t *testing.T) {
+		resp, err := plain.Request(ctx, mesh.Hosts()[2].ID(), request)
+		require.NoError(t, err)
+		require.Equal(t, expected, resp)
+	})
+}
+
+func TestCompressResponse(t *testing.T) {
+	data := bytes.Repeat([]byte("data"), 1000)
+	compressed := compressResponse(&Response{Data: data}, 100)
+	require.True(t, compressed.Compressed)
+	require.Less(t, len(compressed.Data), len(data))
+	resp, err := decompressResponse(compressed)
+	require.NoError(t, err)
+	require.Equal(t, data, resp.Data)
+
+	// incompressible data is sent as is
+	random := make([]byte, 200)
+	_, err = rand.Read(random)
+	require.NoError(t, err)
+	require.False(t, compressResponse(&Response{Data: random}, 100).Compressed)
+	// as well as data below the threshold
+	require.False(t, compressResponse(&Response{Data: data[:99]}, 100).Compressed)
+
+	resp, err = decompressResponse(&CompressedResponse{Error: "failed"})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Error)
+
+	_, err = decompressResponse(&CompressedResponse{Compressed: true, Data: []byte("not zstd")})
+	require.Error(t, err)
+}
+
+func FuzzCompressedResponseConsistency(f *testing.F) {
+	tester.FuzzConsistency[CompressedResponse](f)
+}
+
+func FuzzCompressedResponseSafety(f *testing.F) {
+	tester.FuzzSafety[CompressedResponse](f)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Usage is the usage of the servers by the identity of the client.
+type Usage struct {
+	NodeID types.NodeID
+	// Peer is the last peer that sent requests signed by the identity.
+	Peer peer.ID
+	// Peers is the number of distinct peers that sent requests signed by the identity.
+	Peers    int
+	Requests int
+	Failed   int
+	// Duration is the total time spent serving requests of the identity.
+	Duration time.Duration
+	LastSeen time.Time
+
+	protocols map[string]int
+	peers     map[peer.ID]struct{}
+}
+
+func (u *Usage) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", u.NodeID.ShortString())
+	enc.AddString("peer", u.Peer.String())
+	enc.AddInt("peers", u.Peers)
+	enc.AddInt("requests", u.Requests)
+	enc.AddInt("failed", u.Failed)
+	enc.AddDuration("duration", u.Duration)
+	enc.AddTime("last seen", u.LastSeen)
+	return enc.AddObject("protocols", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for proto, requests := range u.protocols {
+			enc.AddInt(proto, requests)
+		}
+		return nil
+	}))
+}
+
+// Ledger accumulates the usage of the servers by identities of the clients that sent authenticated requests.
+// Identities are not bound to the stake, so the number of tracked identities is limited by capacity.
+// When the capacity is reached the identity with the least usage is evicted.
+type Ledger struct {
+	capacity int
+	now      func() time.Time
+
+	mu    sync.Mutex
+	usage map[types.NodeID]*Usage
+}
+
+// NewLedger creates the ledger that tracks at most capacity identities.
+func NewLedger(capacity int) *Ledger {
+	return &Ledger{
+		capacity: capacity,
+		now:      time.Now,
+		usage:    map[types.NodeID]*Usage{},
+	}
+}
+
+// OnRequest records the request of the identity.
+func (l *Ledger) OnRequest(proto string, id types.NodeID, pid peer.ID, took time.Duration, served bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usage, exists := l.usage[id]
+	if !exists {
+		if len(l.usage) >= l.capacity {
+			l.evict()
+		}
+		usage = &Usage{NodeID: id, protocols: map[string]int{}, peers: map[peer.ID]struct{}{}}
+		l.usage[id] = usage
+	}
+	usage.Peer = pid
+	usage.peers[pid] = struct{}{}
+	usage.Peers = len(usage.peers)
+	usage.Requests++
+	if !served {
+		usage.Failed++
+	}
+	usage.Duration += took
+	usage.LastSeen = l.now()
+	usage.protocols[proto]++
+}
+
+func (l *Ledger) evict() {
+	var least *Usage
+	for _, usage := range l.usage {
+		if least == nil || usage.Duration < least.Duration {
+			least = usage
+		}
+	}
+	if least != nil {
+		delete(l.usage, least.NodeID)
+	}
+}
+
+// Get returns the usage of the identity.
+func (l *Ledger) Get(id types.NodeID) (Usage, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	usage, exists := l.usage[id]
+	if !exists {
+		return Usage{}, false
+	}
+	return usage.copy(), true
+}
+
+// Top returns at most n identities that took the most time to serve.
+func (l *Ledger) Top(n int) []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rst := make([]Usage, 0, len(l.usage))
+	for _, usage := range l.usage {
+		rst = append(rst, usage.copy())
+	}
+	sort.Slice(rst, func(i, j int) bool {
+		return rst[i].Duration > rst[j].Duration
+	})
+	if len(rst) > n {
+		rst = rst[:n]
+	}
+	return rst
+}
+
+func (u *Usage) copy() Usage {
+	rst := *u
+	rst.protocols = make(map[string]int, len(u.protocols))
+	for proto, requests := range u.protocols {
+		rst.protocols[proto] = requests
+	}
+	rst.peers = nil
+	return rst
+}
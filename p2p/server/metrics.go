@@ -55,6 +55,19 @@ var (
 		[]string{protoLabel},
 		prometheus.ExponentialBuckets(0.01, 2, 20),
 	)
+	compressionRatio = metrics.NewHistogramWithBuckets(
+		"compression_ratio",
+		namespace,
+		"ratio of the uncompressed to the compressed size of the response data",
+		[]string{protoLabel},
+		prometheus.LinearBuckets(1, 1, 20),
+	)
+	compressionBytes = metrics.NewCounter(
+		"compression_bytes",
+		namespace,
+		"size of the compressed response data before and after compression",
+		[]string{protoLabel, "state"},
+	)
 	inQueueLatency = metrics.NewHistogramWithBuckets(
 		"in_queue_latency_seconds",
 		namespace,
@@ -80,6 +93,9 @@ func newTracker(protocol string) *tracker {
 		serverLatency:        serverLatency.WithLabelValues(protocol),
 		clientLatency:        clientLatency.WithLabelValues(protocol, "success"),
 		clientLatencyFailure: clientLatency.WithLabelValues(protocol, "failure"),
+		compressionRatio:     compressionRatio.WithLabelValues(protocol),
+		uncompressedBytes:    compressionBytes.WithLabelValues(protocol, "uncompressed"),
+		compressedBytes:      compressionBytes.WithLabelValues(protocol, "compressed"),
 	}
 }
 
@@ -97,4 +113,6 @@ type tracker struct {
 	inQueueLatency                      prometheus.Observer
 	serverLatency                       prometheus.Observer
 	clientLatency, clientLatencyFailure prometheus.Observer
+	compressionRatio                    prometheus.Observer
+	uncompressedBytes, compressedBytes  prometheus.Counter
 }
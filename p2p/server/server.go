@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/connmgr"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
 )
 
 type DecayingTagSpec struct {
@@ -106,9 +108,49 @@ func WithDecayingTag(tag DecayingTagSpec) Opt {
 	}
 }
 
+// WithStreamHandler makes the server respond with the stream handler instead of the handler.
+func WithStreamHandler(handler StreamHandler) Opt {
+	return func(s *Server) {
+		s.streamHandler = handler
+	}
+}
+
+// WithSigner makes the client sign requests with the identity, if the server serves the authenticated protocol.
+func WithSigner(signer *signing.EdSigner) Opt {
+	return func(s *Server) {
+		s.signer = signer
+	}
+}
+
+// WithVerifier makes the server serve authenticated requests on AuthProtocol in addition to the protocol.
+func WithVerifier(verifier *signing.EdVerifier) Opt {
+	return func(s *Server) {
+		s.verifier = verifier
+	}
+}
+
+// WithRequireAuthentication makes the server serve only authenticated requests.
+// It requires the verifier, otherwise the server doesn't serve any requests.
+func WithRequireAuthentication() Opt {
+	return func(s *Server) {
+		s.requireAuth = true
+	}
+}
+
+// WithAccountant reports authenticated requests to the accountant.
+func WithAccountant(accountant Accountant) Opt {
+	return func(s *Server) {
+		s.accountant = accountant
+	}
+}
+
 // Handler is the handler to be defined by the application.
 type Handler func(context.Context, []byte) ([]byte, error)
 
+// StreamHandler writes the response to the request directly to the stream, the response is not limited
+// by the size of a single Response. The stream is reset if the handler fails.
+type StreamHandler func(context.Context, []byte, io.Writer) error
+
 //go:generate scalegen -types Response
 
 // Response is a server response.
@@ -122,6 +164,7 @@ type Server struct {
 	logger              log.Log
 	protocol            string
 	handler             Handler
+	streamHandler       StreamHandler
 	timeout             time.Duration
 	hardTimeout         time.Duration
 	requestLimit        int
@@ -131,6 +174,14 @@ type Server struct {
 	decayingTagSpec     *DecayingTagSpec
 	decayingTag         connmgr.DecayingTag
 
+	signer      *signing.EdSigner
+	verifier    *signing.EdVerifier
+	requireAuth bool
+	accountant  Accountant
+
+	compress          bool
+	compressThreshold int
+
 	metrics *tracker // metrics can be nil
 
 	h Host
@@ -185,7 +236,7 @@ func (s *Server) Run(ctx context.Context) error {
 		s.metrics.targetQueue.Set(float64(s.queueSize))
 		s.metrics.targetRps.Set(float64(limit.Limit()))
 	}
-	s.h.SetStreamHandler(protocol.ID(s.protocol), func(stream network.Stream) {
+	enqueue := func(stream network.Stream) {
 		select {
 		case queue <- request{stream: stream, received: time.Now()}:
 			if s.metrics != nil {
@@ -198,7 +249,10 @@ func (s *Server) Run(ctx context.Context) error {
 			}
 			stream.Close()
 		}
-	})
+	}
+	for _, proto := range s.servedProtocols() {
+		s.h.SetStreamHandler(proto, enqueue)
+	}
 
 	var eg errgroup.Group
 	eg.SetLimit(s.queueSize)
@@ -234,7 +288,7 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 }
 
-func (s *Server) queueHandler(ctx context.Context, stream network.Stream) bool {
+func (s *Server) queueHandler(ctx context.Context, stream network.Stream) (served bool) {
 	defer stream.Close()
 	defer stream.SetDeadline(time.Time{})
 	dadj := newDeadlineAdjuster(stream, s.timeout, s.hardTimeout)
@@ -249,12 +303,18 @@ func (s *Server) queueHandler(ctx context.Context, stream network.Stream) bool {
 		)
 		return false
 	}
-	if size > uint64(s.requestLimit) {
+	proto, compressed := splitProtocol(stream.Protocol())
+	authenticated := proto == protocol.ID(AuthProtocol(s.protocol))
+	limit := s.requestLimit
+	if authenticated {
+		limit += authOverhead
+	}
+	if size > uint64(limit) {
 		s.logger.With().Warning("request limit overflow",
 			log.String("protocol", s.protocol),
 			log.Stringer("remotePeer", stream.Conn().RemotePeer()),
 			log.Stringer("remoteMultiaddr", stream.Conn().RemoteMultiaddr()),
-			log.Int("limit", s.requestLimit),
+			log.Int("limit", limit),
 			log.Uint64("request", size),
 		)
 		stream.Conn().Close()
@@ -271,6 +331,30 @@ func (s *Server) queueHandler(ctx context.Context, stream network.Stream) bool {
 		)
 		return false
 	}
+	if authenticated {
+		req, err := verifyRequest(s.verifier, s.protocol, stream.Conn().LocalPeer(), time.Now(), buf)
+		if err != nil {
+			s.logger.With().Debug("failed to authenticate request",
+				log.String("protocol", s.protocol),
+				log.Stringer("remotePeer", stream.Conn().RemotePeer()),
+				log.Stringer("remoteMultiaddr", stream.Conn().RemoteMultiaddr()),
+				log.Err(err),
+			)
+			stream.Reset()
+			return false
+		}
+		ctx = withNodeID(ctx, req.NodeID)
+		buf = req.Data
+		if s.accountant != nil {
+			start := time.Now()
+			defer func() {
+				s.accountant.OnRequest(s.protocol, req.NodeID, stream.Conn().RemotePeer(), time.Since(start), served)
+			}()
+		}
+	}
+	if s.streamHandler != nil {
+		return s.serveStream(ctx, stream, dadj, buf)
+	}
 	start := time.Now()
 	buf, err = s.handler(log.WithNewRequestID(ctx), buf)
 	s.logger.With().Debug("protocol handler execution time",
@@ -292,8 +376,18 @@ func (s *Server) queueHandler(ctx context.Context, stream network.Stream) bool {
 		resp.Data = buf
 	}
 
+	var encodable codec.Encodable = &resp
+	if compressed {
+		cresp := compressResponse(&resp, s.compressThreshold)
+		if s.metrics != nil && cresp.Compressed {
+			s.metrics.uncompressedBytes.Add(float64(len(resp.Data)))
+			s.metrics.compressedBytes.Add(float64(len(cresp.Data)))
+			s.metrics.compressionRatio.Observe(float64(len(resp.Data)) / float64(len(cresp.Data)))
+		}
+		encodable = cresp
+	}
 	wr := bufio.NewWriter(dadj)
-	if _, err := codec.EncodeTo(wr, &resp); err != nil {
+	if _, err := codec.EncodeTo(wr, encodable); err != nil {
 		s.logger.With().Warning(
 			"failed to write response",
 			log.String("protocol", s.protocol),
@@ -317,23 +411,89 @@ func (s *Server) queueHandler(ctx context.Context, stream network.Stream) bool {
 	return true
 }
 
-// Request sends a binary request to the peer. Request is executed in the background, one of the callbacks
-// is guaranteed to be called on success/error.
-func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte) ([]byte, error) {
+func (s *Server) serveStream(ctx context.Context, stream network.Stream, dadj *deadlineAdjuster, req []byte) bool {
 	start := time.Now()
-	if len(req) > s.requestLimit {
-		return nil, fmt.Errorf("request length (%d) is longer than limit %d", len(req), s.requestLimit)
-	}
-	if s.h.Network().Connectedness(pid) != network.Connected {
-		return nil, fmt.Errorf("%w: %s", ErrNotConnected, pid)
+	wr := bufio.NewWriter(dadj)
+	err := s.streamHandler(log.WithNewRequestID(ctx), req, wr)
+	if err == nil {
+		err = wr.Flush()
 	}
-	data, err := s.request(ctx, pid, req)
-	s.logger.WithContext(ctx).With().Debug("request execution time",
+	s.logger.With().Debug("protocol stream handler execution time",
 		log.String("protocol", s.protocol),
+		log.Stringer("remotePeer", stream.Conn().RemotePeer()),
 		log.Duration("duration", time.Since(start)),
 		log.Err(err),
 	)
+	if err != nil {
+		stream.Reset()
+		return false
+	}
+	return true
+}
 
+// servedProtocols returns the protocols with the handler of the server.
+func (s *Server) servedProtocols() []protocol.ID {
+	var protocols []protocol.ID
+	if !s.requireAuth {
+		protocols = append(protocols, protocol.ID(s.protocol))
+	}
+	if s.verifier != nil {
+		protocols = append(protocols, protocol.ID(AuthProtocol(s.protocol)))
+	}
+	if s.compressed() {
+		for _, proto := range slices.Clone(protocols) {
+			protocols = append(protocols, protocol.ID(CompressedProtocol(string(proto))))
+		}
+	}
+	return protocols
+}
+
+// requestedProtocols returns the protocols in the order of preference, the first one that the peer serves
+// is negotiated. Authenticated and compressed protocols are preferred if they are enabled.
+func (s *Server) requestedProtocols() []protocol.ID {
+	protocols := []protocol.ID{protocol.ID(s.protocol)}
+	if s.signer != nil {
+		// authenticated protocol is negotiated if the peer serves it
+		protocols = append([]protocol.ID{protocol.ID(AuthProtocol(s.protocol))}, protocols...)
+	}
+	if s.compressed() {
+		rst := make([]protocol.ID, 0, 2*len(protocols))
+		for _, proto := range protocols {
+			rst = append(rst, protocol.ID(CompressedProtocol(string(proto))), proto)
+		}
+		protocols = rst
+	}
+	return protocols
+}
+
+// compressed returns true if the responses are compressed.
+// Responses of the stream handler are written directly to the stream and are never compressed.
+func (s *Server) compressed() bool {
+	return s.compress && s.streamHandler == nil
+}
+
+// Request sends a binary request to the peer. Request is executed in the background, one of the callbacks
+// is guaranteed to be called on success/error.
+func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte) ([]byte, error) {
+	if err := s.canRequest(pid, req); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	var data *Response
+	err := s.send(ctx, pid, req, func(rd io.Reader, compressed bool) error {
+		if !compressed {
+			data = &Response{}
+			_, err := codec.DecodeFrom(rd, data)
+			return err
+		}
+		var cresp CompressedResponse
+		if _, err := codec.DecodeFrom(rd, &cresp); err != nil {
+			return err
+		}
+		var err error
+		data, err = decompressResponse(&cresp)
+		return err
+	})
 	took := time.Since(start).Seconds()
 	switch {
 	case err != nil:
@@ -355,7 +515,53 @@ func (s *Server) Request(ctx context.Context, pid peer.ID, req []byte) ([]byte,
 	return data.Data, nil
 }
 
-func (s *Server) request(ctx context.Context, pid peer.ID, req []byte) (*Response, error) {
+// StreamRequest sends a binary request to the peer that serves the protocol with a StreamHandler.
+// The response is consumed by the read function, the stream is closed when it returns.
+func (s *Server) StreamRequest(ctx context.Context, pid peer.ID, req []byte, read func(io.Reader) error) error {
+	if err := s.canRequest(pid, req); err != nil {
+		return err
+	}
+	start := time.Now()
+	err := s.send(ctx, pid, req, func(rd io.Reader, _ bool) error {
+		return read(rd)
+	})
+	took := time.Since(start).Seconds()
+	switch {
+	case err != nil:
+		if s.metrics != nil {
+			s.metrics.clientFailed.Inc()
+			s.metrics.clientLatencyFailure.Observe(took)
+		}
+		return err
+	case s.metrics != nil:
+		s.metrics.clientSucceeded.Inc()
+		s.metrics.clientLatency.Observe(took)
+	}
+	return nil
+}
+
+func (s *Server) canRequest(pid peer.ID, req []byte) error {
+	if len(req) > s.requestLimit {
+		return fmt.Errorf("request length (%d) is longer than limit %d", len(req), s.requestLimit)
+	}
+	if s.h.Network().Connectedness(pid) != network.Connected {
+		return fmt.Errorf("%w: %s", ErrNotConnected, pid)
+	}
+	return nil
+}
+
+func (s *Server) send(ctx context.Context, pid peer.ID, req []byte, read func(io.Reader, bool) error) error {
+	start := time.Now()
+	err := s.request(ctx, pid, req, read)
+	s.logger.WithContext(ctx).With().Debug("request execution time",
+		log.String("protocol", s.protocol),
+		log.Duration("duration", time.Since(start)),
+		log.Err(err),
+	)
+	return err
+}
+
+func (s *Server) request(ctx context.Context, pid peer.ID, req []byte, read func(io.Reader, bool) error) error {
 	ctx, cancel := context.WithTimeout(ctx, s.hardTimeout)
 	defer cancel()
 
@@ -363,10 +569,18 @@ func (s *Server) request(ctx context.Context, pid peer.ID, req []byte) (*Respons
 	stream, err := s.h.NewStream(
 		network.WithNoDial(ctx, "existing connection"),
 		pid,
-		protocol.ID(s.protocol),
+		s.requestedProtocols()...,
 	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	proto, compressed := splitProtocol(stream.Protocol())
+	if proto == protocol.ID(AuthProtocol(s.protocol)) {
+		req, err = signRequest(s.signer, s.protocol, pid, time.Now(), req)
+		if err != nil {
+			stream.Reset()
+			return fmt.Errorf("sign request: %w", err)
+		}
 	}
 	defer stream.Close()
 	defer stream.SetDeadline(time.Time{})
@@ -376,23 +590,21 @@ func (s *Server) request(ctx context.Context, pid peer.ID, req []byte) (*Respons
 	sz := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(sz, uint64(len(req)))
 	if _, err := wr.Write(sz[:n]); err != nil {
-		return nil, fmt.Errorf("peer %s address %s: %w",
+		return fmt.Errorf("peer %s address %s: %w",
 			pid, stream.Conn().RemoteMultiaddr(), err)
 	}
 	if _, err := wr.Write(req); err != nil {
-		return nil, fmt.Errorf("peer %s address %s: %w",
+		return fmt.Errorf("peer %s address %s: %w",
 			pid, stream.Conn().RemoteMultiaddr(), err)
 	}
 	if err := wr.Flush(); err != nil {
-		return nil, fmt.Errorf("peer %s address %s: %w",
+		return fmt.Errorf("peer %s address %s: %w",
 			pid, stream.Conn().RemoteMultiaddr(), err)
 	}
 
-	rd := bufio.NewReader(dadj)
-	var r Response
-	if _, err = codec.DecodeFrom(rd, &r); err != nil {
-		return nil, fmt.Errorf("peer %s address %s: %w",
+	if err := read(bufio.NewReader(dadj), compressed); err != nil {
+		return fmt.Errorf("peer %s address %s: %w",
 			pid, stream.Conn().RemoteMultiaddr(), err)
 	}
-	return &r, nil
+	return nil
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"io"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -140,6 +141,72 @@ func TestQueued(t *testing.T) {
 	t.Log(success.Load())
 }
 
+func TestStreamServer(t *testing.T) {
+	mesh, err := mocknet.FullMeshConnected(3)
+	require.NoError(t, err)
+	proto := "test"
+	// the response is larger than the limit of a single Response
+	chunk := make([]byte, 1<<20)
+	const chunks = 100
+
+	handler := func(_ context.Context, msg []byte, w io.Writer) error {
+		for i := 0; i < chunks; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	errhandler := func(_ context.Context, _ []byte, w io.Writer) error {
+		w.Write(chunk[:10])
+		return errors.New("test error")
+	}
+	client := New(mesh.Hosts()[0], proto, nil, WithLog(logtest.New(t)))
+	srv1 := New(mesh.Hosts()[1], proto, nil, WithStreamHandler(handler), WithLog(logtest.New(t)))
+	srv2 := New(mesh.Hosts()[2], proto, nil, WithStreamHandler(errhandler), WithLog(logtest.New(t)))
+	ctx, cancel := context.WithCancel(context.Background())
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return srv1.Run(ctx)
+	})
+	eg.Go(func() error {
+		return srv2.Run(ctx)
+	})
+	require.Eventually(t, func() bool {
+		for _, h := range mesh.Hosts()[1:] {
+			if len(h.Mux().Protocols()) == 0 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		cancel()
+		eg.Wait()
+	})
+
+	t.Run("streamed response", func(t *testing.T) {
+		var received int
+		require.NoError(t, client.StreamRequest(ctx, mesh.Hosts()[1].ID(), []byte("test"), func(rd io.Reader) error {
+			n, err := io.Copy(io.Discard, rd)
+			received = int(n)
+			return err
+		}))
+		require.Equal(t, chunks*len(chunk), received)
+	})
+	t.Run("handler failed", func(t *testing.T) {
+		err := client.StreamRequest(ctx, mesh.Hosts()[2].ID(), []byte("test"), func(rd io.Reader) error {
+			_, err := io.ReadAll(rd)
+			return err
+		})
+		require.Error(t, err)
+	})
+	t.Run("not connected", func(t *testing.T) {
+		err := client.StreamRequest(ctx, "unknown", []byte("test"), func(io.Reader) error { return nil })
+		require.ErrorIs(t, err, ErrNotConnected)
+	})
+}
+
 func FuzzResponseConsistency(f *testing.F) {
 	tester.FuzzConsistency[Response](f)
 }
@@ -148,6 +148,9 @@ func Upgrade(h host.Host, opts ...Opt) (*Host, error) {
 		MaxMessageSize: cfg.MaxMessageSize,
 		QueueSize:      cfg.GossipQueueSize,
 		Throttle:       cfg.GossipValidationThrottle,
+
+		ValidationCacheSize: cfg.GossipValidationCacheSize,
+		ValidationCacheTTL:  cfg.GossipValidationCacheTTL,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to initialize pubsub: %w", err)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/miner/minweight"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/system"
 )
 
@@ -110,8 +111,8 @@ func (v *Validator) CheckEligibility(ctx context.Context, ballot *types.Ballot,
 				ballot.EligibilityProofs[i-1].J,
 			)
 		}
-		if !v.vrfVerifier.Verify(ballot.SmesherID,
-			MustSerializeVRFMessage(data.Beacon, ballot.Layer.GetEpoch(), atx.Nonce, proof.J), proof.Sig) {
+		msg := MustSerializeVRFMessage(data.Beacon, ballot.Layer.GetEpoch(), atx.Nonce, proof.J)
+		if !v.vrfVerifier.Verify(atx.VRFKey, signing.VRFMessage(ballot.SmesherID, atx.VRFKey, msg), proof.Sig) {
 			return fmt.Errorf(
 				"%w: proof contains incorrect VRF signature. beacon: %v, epoch: %v, counter: %v, vrfSig: %s",
 				pubsub.ErrValidationReject,
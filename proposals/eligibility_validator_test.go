@@ -574,6 +574,7 @@ func TestEligibilityValidator(t *testing.T) {
 				c.AddFromHeader(
 					atx.ToHeader(),
 					0,
+					atx.SmesherID,
 					false,
 				)
 			}
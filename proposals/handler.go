@@ -9,6 +9,8 @@ import (
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 
 	"github.com/spacemeshos/go-spacemesh/atxsdata"
@@ -25,6 +27,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/sql/ballots"
 	"github.com/spacemeshos/go-spacemesh/system"
 	"github.com/spacemeshos/go-spacemesh/tortoise"
+	"github.com/spacemeshos/go-spacemesh/tracing"
 )
 
 var (
@@ -58,6 +61,7 @@ type Handler struct {
 	clock      layerClock
 
 	proposals proposalsConsumer
+	receivers []BallotReceiver
 }
 
 // Config defines configuration for the handler.
@@ -101,6 +105,13 @@ func WithConfig(cfg Config) Opt {
 	}
 }
 
+// WithBallotReceiver adds the receiver that is notified about every stored ballot.
+func WithBallotReceiver(receiver BallotReceiver) Opt {
+	return func(h *Handler) {
+		h.receivers = append(h.receivers, receiver)
+	}
+}
+
 // NewHandler creates new Handler.
 func NewHandler(
 	db *sql.Database,
@@ -159,7 +170,7 @@ func (h *Handler) HandleSyncedBallot(ctx context.Context, expHash types.Hash32,
 
 	var b types.Ballot
 	t0 := time.Now()
-	if err := codec.Decode(data, &b); err != nil {
+	if err := codec.DecodeWithLimit(data, &b, types.MaxBallotSize); err != nil {
 		malformed.Inc()
 		return errMalformedData
 	}
@@ -284,12 +295,19 @@ func (h *Handler) HandleProposal(ctx context.Context, peer p2p.Peer, data []byte
 }
 
 // HandleProposal is the gossip receiver for Proposal.
-func (h *Handler) handleProposal(ctx context.Context, expHash types.Hash32, peer p2p.Peer, data []byte) error {
+func (h *Handler) handleProposal(
+	ctx context.Context,
+	expHash types.Hash32,
+	peer p2p.Peer,
+	data []byte,
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "proposal.process", trace.WithAttributes(attribute.Stringer("peer", peer)))
+	defer func() { tracing.EndSpan(span, err) }()
 	logger := h.logger.WithContext(ctx)
 
 	t0 := time.Now()
 	var p types.Proposal
-	if err := codec.Decode(data, &p); err != nil {
+	if err := codec.DecodeWithLimit(data, &p, types.MaxProposalSize); err != nil {
 		malformed.Inc()
 		return errMalformedData
 	}
@@ -318,6 +336,7 @@ func (h *Handler) handleProposal(ctx context.Context, expHash types.Hash32, peer
 		failedInit.Inc()
 		return errInitialize
 	}
+	span.SetAttributes(attribute.Stringer("proposal", p.ID()), attribute.Int64("layer", int64(p.Layer)))
 	if expHash != (types.Hash32{}) && p.ID().AsHash32() != expHash {
 		return fmt.Errorf(
 			"%w: proposal want %s, got %s",
@@ -353,7 +372,10 @@ func (h *Handler) handleProposal(ctx context.Context, expHash types.Hash32, peer
 
 	// FIXME: how to handle proposals from malicious identity?
 	t4 := time.Now()
-	if err := h.checkTransactions(ctx, &p); err != nil {
+	fetchCtx, fetchSpan := tracing.StartSpan(ctx, "proposal.fetch_references")
+	err = h.checkTransactions(fetchCtx, &p)
+	tracing.EndSpan(fetchSpan, err)
+	if err != nil {
 		unavailRef.Inc()
 		return err
 	}
@@ -363,6 +385,8 @@ func (h *Handler) handleProposal(ctx context.Context, expHash types.Hash32, peer
 	}
 	logger.With().Debug("proposal is syntactically valid")
 
+	storeCtx, storeSpan := tracing.StartSpan(ctx, "proposal.store")
+	defer func() { tracing.EndSpan(storeSpan, err) }()
 	err = h.proposals.OnProposal(&p)
 	switch {
 	case errors.Is(err, store.ErrProposalExists):
@@ -374,7 +398,7 @@ func (h *Handler) handleProposal(ctx context.Context, expHash types.Hash32, peer
 	logger.With().Debug("stored proposal")
 
 	t6 := time.Now()
-	if err = h.mesh.AddTXsFromProposal(ctx, p.Layer, p.ID(), p.TxIDs); err != nil {
+	if err = h.mesh.AddTXsFromProposal(storeCtx, p.Layer, p.ID(), p.TxIDs); err != nil {
 		logger.With().Error("failed to link txs to proposal", log.Err(err))
 		return fmt.Errorf("proposal add TXs: %w", err)
 	}
@@ -426,7 +450,16 @@ func (h *Handler) setProposalBeacon(p *types.Proposal) error {
 	return nil
 }
 
-func (h *Handler) processBallot(ctx context.Context, logger log.Log, b *types.Ballot) (*types.MalfeasanceProof, error) {
+func (h *Handler) processBallot(
+	ctx context.Context,
+	logger log.Log,
+	b *types.Ballot,
+) (proof *types.MalfeasanceProof, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ballot.process", trace.WithAttributes(
+		attribute.Stringer("ballot", b.ID()),
+		attribute.Int64("layer", int64(b.Layer)),
+	))
+	defer func() { tracing.EndSpan(span, err) }()
 	if data := h.tortoise.GetBallot(b.ID()); data != nil {
 		known.Inc()
 		return nil, fmt.Errorf("%w: ballot %s", errKnownBallot, b.ID())
@@ -441,7 +474,9 @@ func (h *Handler) processBallot(ctx context.Context, logger log.Log, b *types.Ba
 	b.ActiveSet = nil
 
 	t1 := time.Now()
-	proof, err := h.mesh.AddBallot(ctx, b)
+	storeCtx, storeSpan := tracing.StartSpan(ctx, "ballot.store")
+	defer func() { tracing.EndSpan(storeSpan, err) }()
+	proof, err = h.mesh.AddBallot(storeCtx, b)
 	if err != nil {
 		if errors.Is(err, sql.ErrObjectExists) {
 			known.Inc()
@@ -456,6 +491,9 @@ func (h *Handler) processBallot(ctx context.Context, logger log.Log, b *types.Ba
 		}
 		return nil, fmt.Errorf("store decoded ballot %s: %w", decoded.ID, err)
 	}
+	for _, receiver := range h.receivers {
+		receiver.OnBallot(b)
+	}
 	reportVotesMetrics(b)
 	return proof, nil
 }
@@ -466,7 +504,9 @@ func (h *Handler) checkBallotSyntacticValidity(
 	b *types.Ballot,
 ) (*tortoise.DecodedBallot, error) {
 	t0 := time.Now()
-	ref, err := h.checkBallotDataIntegrity(ctx, b)
+	validateCtx, span := tracing.StartSpan(ctx, "ballot.syntactic_validation")
+	ref, err := h.checkBallotDataIntegrity(validateCtx, b)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		badData.Inc()
 		return nil, err
@@ -474,7 +514,10 @@ func (h *Handler) checkBallotSyntacticValidity(
 	ballotDuration.WithLabelValues(dataCheck).Observe(float64(time.Since(t0)))
 
 	t1 := time.Now()
-	if err := h.checkBallotDataAvailability(ctx, b); err != nil {
+	fetchCtx, span := tracing.StartSpan(ctx, "ballot.fetch_references")
+	err = h.checkBallotDataAvailability(fetchCtx, b)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		unavailRef.Inc()
 		return nil, err
 	}
@@ -715,6 +715,9 @@ func TestBallot_Success(t *testing.T) {
 	decoded := &tortoise.DecodedBallot{BallotTortoiseData: b.ToTortoiseData()}
 	th.md.EXPECT().DecodeBallot(decoded.BallotTortoiseData).Return(decoded, nil)
 	th.md.EXPECT().StoreBallot(decoded).Return(nil)
+	receiver := NewMockBallotReceiver(gomock.NewController(t))
+	WithBallotReceiver(receiver)(th.Handler)
+	receiver.EXPECT().OnBallot(b)
 	require.NoError(t, th.HandleSyncedBallot(context.Background(), b.ID().AsHash32(), peer, data))
 }
 
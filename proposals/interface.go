@@ -10,6 +10,11 @@ import (
 
 //go:generate mockgen -typed -package=proposals -destination=./mocks.go -source=./interface.go
 
+// BallotReceiver is notified about every new ballot after it is stored.
+type BallotReceiver interface {
+	OnBallot(*types.Ballot)
+}
+
 type meshProvider interface {
 	ProcessedLayer() types.LayerID
 	AddBallot(context.Context, *types.Ballot) (*types.MalfeasanceProof, error)
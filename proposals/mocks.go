@@ -19,6 +19,65 @@ import (
 	gomock "go.uber.org/mock/gomock"
 )
 
+// MockBallotReceiver is a mock of BallotReceiver interface.
+type MockBallotReceiver struct {
+	ctrl     *gomock.Controller
+	recorder *MockBallotReceiverMockRecorder
+}
+
+// MockBallotReceiverMockRecorder is the mock recorder for MockBallotReceiver.
+type MockBallotReceiverMockRecorder struct {
+	mock *MockBallotReceiver
+}
+
+// NewMockBallotReceiver creates a new mock instance.
+func NewMockBallotReceiver(ctrl *gomock.Controller) *MockBallotReceiver {
+	mock := &MockBallotReceiver{ctrl: ctrl}
+	mock.recorder = &MockBallotReceiverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBallotReceiver) EXPECT() *MockBallotReceiverMockRecorder {
+	return m.recorder
+}
+
+// OnBallot mocks base method.
+func (m *MockBallotReceiver) OnBallot(arg0 *types.Ballot) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnBallot", arg0)
+}
+
+// OnBallot indicates an expected call of OnBallot.
+func (mr *MockBallotReceiverMockRecorder) OnBallot(arg0 any) *MockBallotReceiverOnBallotCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnBallot", reflect.TypeOf((*MockBallotReceiver)(nil).OnBallot), arg0)
+	return &MockBallotReceiverOnBallotCall{Call: call}
+}
+
+// MockBallotReceiverOnBallotCall wrap *gomock.Call
+type MockBallotReceiverOnBallotCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockBallotReceiverOnBallotCall) Return() *MockBallotReceiverOnBallotCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockBallotReceiverOnBallotCall) Do(f func(*types.Ballot)) *MockBallotReceiverOnBallotCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockBallotReceiverOnBallotCall) DoAndReturn(f func(*types.Ballot)) *MockBallotReceiverOnBallotCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockmeshProvider is a mock of meshProvider interface.
 type MockmeshProvider struct {
 	ctrl     *gomock.Controller
@@ -20,4 +20,5 @@ var (
 	certLatency      = pruneLatency.WithLabelValues("cert")
 	propTxLatency    = pruneLatency.WithLabelValues("proptxs")
 	activeSetLatency = pruneLatency.WithLabelValues("activeset")
+	accountsLatency  = pruneLatency.WithLabelValues("accounts")
 )
@@ -8,7 +8,9 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/activesets"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
 	"github.com/spacemeshos/go-spacemesh/sql/transactions"
 	"github.com/spacemeshos/go-spacemesh/timesync"
@@ -22,6 +24,22 @@ func WithLogger(logger *zap.Logger) Opt {
 	}
 }
 
+// WithAccountsRetention keeps historical account states only for the specified number of layers.
+// States that are needed to load accounts at the oldest retained layer are never deleted.
+// Zero keeps the whole history.
+func WithAccountsRetention(layers uint32) Opt {
+	return func(p *Pruner) {
+		p.accountsRetention = layers
+	}
+}
+
+// WithCertificateArchive keeps certificates for all layers, so that they can be served to light clients.
+func WithCertificateArchive() Opt {
+	return func(p *Pruner) {
+		p.certificateArchive = true
+	}
+}
+
 func New(db *sql.Database, safeDist uint32, activesetEpoch types.EpochID, opts ...Opt) *Pruner {
 	p := &Pruner{
 		logger:         zap.NewNop(),
@@ -36,10 +54,12 @@ func New(db *sql.Database, safeDist uint32, activesetEpoch types.EpochID, opts .
 }
 
 type Pruner struct {
-	logger         *zap.Logger
-	db             *sql.Database
-	safeDist       uint32
-	activesetEpoch types.EpochID
+	logger             *zap.Logger
+	db                 *sql.Database
+	safeDist           uint32
+	activesetEpoch     types.EpochID
+	accountsRetention  uint32
+	certificateArchive bool
 }
 
 func Run(ctx context.Context, p *Pruner, clock *timesync.NodeClock, interval time.Duration) {
@@ -70,11 +90,13 @@ func (p *Pruner) Prune(current types.LayerID) error {
 	start := time.Now()
 
 	proposalLatency.Observe(time.Since(start).Seconds())
-	start = time.Now()
-	if err := certificates.DeleteCertBefore(p.db, oldest); err != nil {
-		return err
+	if !p.certificateArchive {
+		start = time.Now()
+		if err := certificates.DeleteCertBefore(p.db, oldest); err != nil {
+			return err
+		}
+		certLatency.Observe(time.Since(start).Seconds())
 	}
-	certLatency.Observe(time.Since(start).Seconds())
 	start = time.Now()
 	if err := transactions.DeleteProposalTxsBefore(p.db, oldest); err != nil {
 		return err
@@ -92,7 +114,21 @@ func (p *Pruner) Prune(current types.LayerID) error {
 		if err := activesets.DeleteBeforeEpoch(p.db, epoch); err != nil {
 			return err
 		}
+		if _, err := blobs.GC(p.db); err != nil {
+			return err
+		}
 		activeSetLatency.Observe(time.Since(start).Seconds())
 	}
+	if p.accountsRetention > 0 {
+		// states within safe distance may still be reverted and must be kept
+		retention := max(p.accountsRetention, p.safeDist)
+		if current > types.LayerID(retention) {
+			start = time.Now()
+			if err := accounts.Prune(p.db, current-types.LayerID(retention)); err != nil {
+				return err
+			}
+			accountsLatency.Observe(time.Since(start).Seconds())
+		}
+	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/accounts"
 	"github.com/spacemeshos/go-spacemesh/sql/activesets"
 	"github.com/spacemeshos/go-spacemesh/sql/ballots"
 	"github.com/spacemeshos/go-spacemesh/sql/certificates"
@@ -84,3 +85,41 @@ func TestPrune(t *testing.T) {
 		}
 	}
 }
+
+func TestPruneCertificateArchive(t *testing.T) {
+	db := sql.InMemory()
+	current := types.LayerID(10)
+	for lid := types.LayerID(0); lid < current; lid++ {
+		require.NoError(t, certificates.Add(db, lid, &types.Certificate{BlockID: types.RandomBlockID()}))
+	}
+	pruner := New(db, 3, 0, WithCertificateArchive(), WithLogger(logtest.New(t).Zap()))
+	require.NoError(t, pruner.Prune(current))
+
+	certs, err := certificates.CertifiedInRange(db, 0, current)
+	require.NoError(t, err)
+	require.Len(t, certs, int(current))
+}
+
+func TestPruneAccounts(t *testing.T) {
+	db := sql.InMemory()
+	current := types.LayerID(20)
+	address := types.Address{1}
+	for lid := types.LayerID(1); lid <= current; lid++ {
+		require.NoError(t, accounts.Update(db, &types.Account{Address: address, Layer: lid, Balance: uint64(lid)}))
+	}
+
+	// retention is extended to the safe distance, as those layers can be reverted
+	pruner := New(db, 10, 0, WithLogger(logtest.New(t).Zap()), WithAccountsRetention(5))
+	require.NoError(t, pruner.Prune(current))
+
+	oldest := current - 10
+	for lid := types.LayerID(1); lid <= current; lid++ {
+		account, err := accounts.Get(db, address, lid)
+		require.NoError(t, err)
+		if lid < oldest {
+			require.Zero(t, account.Balance)
+		} else {
+			require.Equal(t, uint64(lid), account.Balance)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package signing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/spacemeshos/go-spacemesh/metrics"
+)
+
+var batchSize = metrics.NewHistogramWithBuckets(
+	"batch_size",
+	"signing",
+	"number of signatures verified in a single batch",
+	[]string{},
+	prometheus.ExponentialBuckets(1, 2, 10),
+).WithLabelValues()
+
+type batch struct {
+	verifier *ed25519.BatchVerifier
+	timer    *time.Timer
+	size     int
+	results  []bool
+	done     chan struct{}
+}
+
+func (b *batch) verify() {
+	batchSize.Observe(float64(b.size))
+	_, b.results = b.verifier.Verify(nil)
+	close(b.done)
+}
+
+// batcher collects signatures from concurrent callers and verifies them together.
+// Batch is verified once it is full or when the window since the first signature in the batch has passed.
+//
+// Signatures in a batch are verified with the same (cofactored) equation as ed25519.Verify,
+// and every signature in the batch is verified individually if the batch fails,
+// so the result for every signature is the same as with individual verification.
+type batcher struct {
+	window time.Duration
+	size   int
+
+	mu      sync.Mutex
+	current *batch
+}
+
+func (b *batcher) verify(pub, msg, sig []byte) bool {
+	b.mu.Lock()
+	current := b.current
+	if current == nil {
+		current = &batch{
+			verifier: ed25519.NewBatchVerifierWithCapacity(b.size),
+			done:     make(chan struct{}),
+		}
+		current.timer = time.AfterFunc(b.window, func() { b.flush(current) })
+		b.current = current
+	}
+	idx := current.size
+	current.verifier.Add(pub, msg, sig)
+	current.size++
+	full := current.size == b.size
+	if full {
+		b.current = nil
+	}
+	b.mu.Unlock()
+
+	if full {
+		current.timer.Stop()
+		current.verify()
+	}
+	<-current.done
+	return current.results[idx]
+}
+
+func (b *batcher) flush(current *batch) {
+	b.mu.Lock()
+	if b.current != current {
+		// batch was filled and verified by the last caller
+		b.mu.Unlock()
+		return
+	}
+	b.current = nil
+	b.mu.Unlock()
+	current.verify()
+}
@@ -0,0 +1,427 @@
+// Package keystore stores ed25519 identities of the node in a single file,
+// encrypted with the key derived from the passphrase.
+//
+// The file is json encoded. Every private key is sealed with XChaCha20-Poly1305,
+// using the key derived with scrypt from the passphrase and the random salt of the keystore.
+// Public key of the identity is authenticated as additional data. The passphrase is verified
+// with the sealed empty message, so that it is checked even if the keystore has no identities.
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/natefinch/atomic"
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const (
+	// FileName is the name of the keystore file in the identities directory.
+	FileName = "keystore.json"
+	// PassphraseEnv is the environment variable with the passphrase, used if passphrase file is not configured.
+	PassphraseEnv = "SPACEMESH_KEYSTORE_PASSPHRASE"
+
+	version = 1
+	kdf     = "scrypt"
+	saltLen = 32
+)
+
+var (
+	// ErrWrongPassphrase is returned if keys in the keystore can't be decrypted with the passphrase.
+	ErrWrongPassphrase = errors.New("keystore: wrong passphrase")
+	// ErrNotFound is returned if identity is not in the keystore.
+	ErrNotFound = errors.New("keystore: identity not found")
+	// ErrExists is returned when adding identity that is already in the keystore.
+	ErrExists = errors.New("keystore: identity already exists")
+)
+
+// Config of the keystore.
+type Config struct {
+	// Enabled loads identities from the keystore instead of raw key files.
+	// Existing key files are imported into the keystore on startup.
+	Enabled bool `mapstructure:"enabled"`
+	// PassphraseFile is the path to the file with the passphrase.
+	// Passphrase is read from SPACEMESH_KEYSTORE_PASSPHRASE environment variable if not set.
+	PassphraseFile string `mapstructure:"passphrase-file"`
+	// KeepImported keeps imported key files as plain text `<name>.key.bak` backups.
+	// By default key files are overwritten and removed once they are imported.
+	KeepImported bool `mapstructure:"keep-imported"`
+}
+
+func DefaultConfig() Config {
+	return Config{}
+}
+
+// Passphrase reads the configured passphrase.
+func (c Config) Passphrase() ([]byte, error) {
+	if c.PassphraseFile == "" {
+		passphrase, ok := os.LookupEnv(PassphraseEnv)
+		if !ok || passphrase == "" {
+			return nil, fmt.Errorf("passphrase is not set in %s and passphrase file is not configured", PassphraseEnv)
+		}
+		return []byte(passphrase), nil
+	}
+	data, err := os.ReadFile(c.PassphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase file: %w", err)
+	}
+	// editors often add the trailing newline
+	for len(data) > 0 && (data[len(data)-1] == '\n' || data[len(data)-1] == '\r') {
+		data = data[:len(data)-1]
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("passphrase file %s is empty", c.PassphraseFile)
+	}
+	return data, nil
+}
+
+// ScryptParams are the parameters of the key derivation.
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// DefaultScryptParams are the parameters recommended for interactive logins.
+func DefaultScryptParams() ScryptParams {
+	return ScryptParams{N: 1 << 15, R: 8, P: 1}
+}
+
+// Identity is the metadata of the identity in the keystore.
+type Identity struct {
+	NodeID  types.NodeID
+	Label   string
+	Created time.Time
+}
+
+type file struct {
+	Version    int            `json:"version"`
+	Kdf        string         `json:"kdf"`
+	Params     ScryptParams   `json:"params"`
+	Salt       string         `json:"salt"`
+	Check      string         `json:"check"`
+	Identities []fileIdentity `json:"identities"`
+}
+
+type fileIdentity struct {
+	ID      string    `json:"id"`
+	Label   string    `json:"label"`
+	Created time.Time `json:"created"`
+	// Sealed is the hex encoded nonce followed by the encrypted private key.
+	Sealed string `json:"sealed"`
+}
+
+// Opt modifies Keystore.
+type Opt func(*Keystore)
+
+// WithScryptParams sets parameters of the key derivation for the new keystore.
+// Parameters of the existing keystore are loaded from the file.
+func WithScryptParams(params ScryptParams) Opt {
+	return func(k *Keystore) {
+		k.params = params
+	}
+}
+
+// Keystore is the encrypted storage of identities.
+// Every modification is written to the file atomically.
+type Keystore struct {
+	path   string
+	params ScryptParams
+
+	mu    sync.Mutex
+	key   []byte
+	store file
+}
+
+// Open opens the keystore at path, or creates an empty one if the file doesn't exist.
+// ErrWrongPassphrase is returned if the passphrase doesn't decrypt existing identities.
+func Open(path string, passphrase []byte, opts ...Opt) (*Keystore, error) {
+	k := &Keystore{path: path, params: DefaultScryptParams()}
+	for _, opt := range opts {
+		opt(k)
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		salt := make([]byte, saltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+		k.store = file{
+			Version: version,
+			Kdf:     kdf,
+			Params:  k.params,
+			Salt:    hex.EncodeToString(salt),
+		}
+		if k.key, err = deriveKey(passphrase, salt, k.params); err != nil {
+			return nil, err
+		}
+		if k.store.Check, err = k.seal(salt, nil); err != nil {
+			return nil, err
+		}
+		if err := k.write(); err != nil {
+			return nil, err
+		}
+		return k, nil
+	case err != nil:
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	if err := json.Unmarshal(data, &k.store); err != nil {
+		return nil, fmt.Errorf("decode keystore %s: %w", path, err)
+	}
+	if k.store.Version != version || k.store.Kdf != kdf {
+		return nil, fmt.Errorf("unsupported keystore version %d with kdf %q", k.store.Version, k.store.Kdf)
+	}
+	salt, err := hex.DecodeString(k.store.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt: %w", err)
+	}
+	k.params = k.store.Params
+	if k.key, err = deriveKey(passphrase, salt, k.params); err != nil {
+		return nil, err
+	}
+	if _, err := k.open(k.store.Check, salt); err != nil {
+		return nil, err
+	}
+	// decrypting every key makes sure that keystore is not corrupted
+	for _, id := range k.store.Identities {
+		if _, err := k.decrypt(id); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func deriveKey(passphrase, salt []byte, params ScryptParams) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is empty")
+	}
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	return key, nil
+}
+
+// Identities returns metadata of the identities in the order they were added.
+func (k *Keystore) Identities() []Identity {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	rst := make([]Identity, 0, len(k.store.Identities))
+	for _, id := range k.store.Identities {
+		rst = append(rst, Identity{
+			NodeID:  mustNodeID(id.ID),
+			Label:   id.Label,
+			Created: id.Created,
+		})
+	}
+	return rst
+}
+
+// Generate adds the new random identity with the label.
+func (k *Keystore) Generate(label string) (Identity, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Identity{}, fmt.Errorf("generate key: %w", err)
+	}
+	return k.Add(label, key)
+}
+
+// Add adds the existing private key with the label.
+func (k *Keystore) Add(label string, key signing.PrivateKey) (Identity, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return Identity{}, fmt.Errorf("invalid key size %d/%d", len(key), ed25519.PrivateKeySize)
+	}
+	id := types.BytesToNodeID(key.Public().(ed25519.PublicKey))
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.index(id) >= 0 {
+		return Identity{}, fmt.Errorf("%w: %s", ErrExists, id.ShortString())
+	}
+	sealed, err := k.seal(id.Bytes(), key)
+	if err != nil {
+		return Identity{}, err
+	}
+	created := time.Now().UTC().Truncate(time.Second)
+	k.store.Identities = append(k.store.Identities, fileIdentity{
+		ID:      hex.EncodeToString(id.Bytes()),
+		Label:   label,
+		Created: created,
+		Sealed:  sealed,
+	})
+	if err := k.write(); err != nil {
+		k.store.Identities = k.store.Identities[:len(k.store.Identities)-1]
+		return Identity{}, err
+	}
+	return Identity{NodeID: id, Label: label, Created: created}, nil
+}
+
+// SetLabel changes the label of the identity.
+func (k *Keystore) SetLabel(id types.NodeID, label string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := k.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id.ShortString())
+	}
+	prev := k.store.Identities[i].Label
+	k.store.Identities[i].Label = label
+	if err := k.write(); err != nil {
+		k.store.Identities[i].Label = prev
+		return err
+	}
+	return nil
+}
+
+// Export returns the decrypted private key of the identity.
+func (k *Keystore) Export(id types.NodeID) (signing.PrivateKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := k.index(id)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id.ShortString())
+	}
+	return k.decrypt(k.store.Identities[i])
+}
+
+// Remove removes the identity from the keystore.
+func (k *Keystore) Remove(id types.NodeID) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	i := k.index(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id.ShortString())
+	}
+	prev := slices.Clone(k.store.Identities)
+	k.store.Identities = slices.Delete(k.store.Identities, i, i+1)
+	if err := k.write(); err != nil {
+		k.store.Identities = prev
+		return err
+	}
+	return nil
+}
+
+// Signers returns signers for all identities in the keystore. Name of the signer is the label of the identity.
+func (k *Keystore) Signers(opts ...signing.EdSignerOptionFunc) ([]*signing.EdSigner, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	signers := make([]*signing.EdSigner, 0, len(k.store.Identities))
+	for _, id := range k.store.Identities {
+		key, err := k.decrypt(id)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := signing.NewEdSigner(
+			append([]signing.EdSignerOptionFunc{signing.WithPrivateKey(key), signing.WithName(id.Label)}, opts...)...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("construct identity %s: %w", id.Label, err)
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+func (k *Keystore) index(id types.NodeID) int {
+	encoded := hex.EncodeToString(id.Bytes())
+	return slices.IndexFunc(k.store.Identities, func(fi fileIdentity) bool {
+		return fi.ID == encoded
+	})
+}
+
+func (k *Keystore) decrypt(id fileIdentity) (signing.PrivateKey, error) {
+	pub, err := hex.DecodeString(id.ID)
+	if err != nil {
+		return nil, fmt.Errorf("decode id of %s: %w", id.Label, err)
+	}
+	key, err := k.open(id.Sealed, pub)
+	if err != nil {
+		return nil, fmt.Errorf("identity %s: %w", id.Label, err)
+	}
+	return key, nil
+}
+
+// seal encrypts plaintext with a random nonce and returns them hex encoded.
+func (k *Keystore) seal(additional, plaintext []byte) (string, error) {
+	aead, err := chacha20poly1305.NewX(k.key)
+	if err != nil {
+		return "", fmt.Errorf("create cipher: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(aead.Seal(nonce, nonce, plaintext, additional)), nil
+}
+
+func (k *Keystore) open(sealed string, additional []byte) ([]byte, error) {
+	data, err := hex.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decode sealed data: %w", err)
+	}
+	aead, err := chacha20poly1305.NewX(k.key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data is too short: %d", len(data))
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func (k *Keystore) write() error {
+	data, err := json.MarshalIndent(&k.store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode keystore: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return fmt.Errorf("create keystore directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), FileName+".*")
+	if err != nil {
+		return fmt.Errorf("create temporary keystore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write keystore: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync keystore: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close keystore: %w", err)
+	}
+	if err := atomic.ReplaceFile(tmp.Name(), k.path); err != nil {
+		return fmt.Errorf("replace keystore: %w", err)
+	}
+	return nil
+}
+
+func mustNodeID(encoded string) types.NodeID {
+	// ids are validated when keystore is opened
+	data, err := hex.DecodeString(encoded)
+	if err != nil {
+		panic(fmt.Sprintf("invalid id %s: %v", encoded, err))
+	}
+	return types.BytesToNodeID(data)
+}
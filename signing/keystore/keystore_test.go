@@ -0,0 +1,101 @@
+package keystore_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/signing/keystore"
+)
+
+// weak parameters keep tests fast.
+var testParams = keystore.ScryptParams{N: 1 << 4, R: 8, P: 1}
+
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identities", keystore.FileName)
+	passphrase := []byte("passphrase")
+	ks, err := keystore.Open(path, passphrase, keystore.WithScryptParams(testParams))
+	require.NoError(t, err)
+	require.Empty(t, ks.Identities())
+
+	generated, err := ks.Generate("first")
+	require.NoError(t, err)
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	imported, err := ks.Add("second", signer.PrivateKey())
+	require.NoError(t, err)
+	require.Equal(t, signer.NodeID(), imported.NodeID)
+	_, err = ks.Add("again", signer.PrivateKey())
+	require.ErrorIs(t, err, keystore.ErrExists)
+
+	require.NoError(t, ks.SetLabel(generated.NodeID, "renamed"))
+	generated.Label = "renamed"
+	require.Equal(t, []keystore.Identity{generated, imported}, ks.Identities())
+
+	key, err := ks.Export(imported.NodeID)
+	require.NoError(t, err)
+	require.Equal(t, signer.PrivateKey(), key)
+
+	// private keys are not stored in plain text
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), hex.EncodeToString(signer.PrivateKey()))
+
+	_, err = keystore.Open(path, []byte("wrong"))
+	require.ErrorIs(t, err, keystore.ErrWrongPassphrase)
+
+	reopened, err := keystore.Open(path, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, ks.Identities(), reopened.Identities())
+	prefix := []byte("prefix")
+	signers, err := reopened.Signers(signing.WithPrefix(prefix))
+	require.NoError(t, err)
+	require.Len(t, signers, 2)
+	require.Equal(t, generated.NodeID, signers[0].NodeID())
+	require.Equal(t, "renamed", signers[0].Name())
+	require.Equal(t, prefix, signers[0].Prefix())
+	require.Equal(t, signer.PrivateKey(), signers[1].PrivateKey())
+	require.Equal(t, "second", signers[1].Name())
+
+	require.NoError(t, reopened.Remove(generated.NodeID))
+	require.ErrorIs(t, reopened.Remove(generated.NodeID), keystore.ErrNotFound)
+	_, err = reopened.Export(generated.NodeID)
+	require.ErrorIs(t, err, keystore.ErrNotFound)
+	require.ErrorIs(t, reopened.SetLabel(generated.NodeID, "removed"), keystore.ErrNotFound)
+
+	reopened, err = keystore.Open(path, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, []keystore.Identity{imported}, reopened.Identities())
+}
+
+func TestKeystore_WrongPassphraseEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), keystore.FileName)
+	_, err := keystore.Open(path, []byte("passphrase"), keystore.WithScryptParams(testParams))
+	require.NoError(t, err)
+	_, err = keystore.Open(path, []byte("wrong"))
+	require.ErrorIs(t, err, keystore.ErrWrongPassphrase)
+	_, err = keystore.Open(filepath.Join(t.TempDir(), keystore.FileName), nil)
+	require.ErrorContains(t, err, "passphrase is empty")
+}
+
+func TestConfig_Passphrase(t *testing.T) {
+	t.Setenv(keystore.PassphraseEnv, "")
+	cfg := keystore.DefaultConfig()
+	_, err := cfg.Passphrase()
+	require.Error(t, err)
+
+	t.Setenv(keystore.PassphraseEnv, "from env")
+	passphrase, err := cfg.Passphrase()
+	require.NoError(t, err)
+	require.Equal(t, []byte("from env"), passphrase)
+
+	cfg.PassphraseFile = filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(cfg.PassphraseFile, []byte("from file\n"), 0o600))
+	passphrase, err = cfg.Passphrase()
+	require.NoError(t, err)
+	require.Equal(t, []byte("from file"), passphrase)
+}
@@ -0,0 +1,197 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+// Config of the connection to the remote signer.
+type Config struct {
+	// Address of the remote signer.
+	Address string `mapstructure:"address"`
+	// CACert is the certificate of the authority that signed the certificate of the remote signer.
+	CACert string `mapstructure:"ca-cert"`
+	// Cert and Key are used to authenticate the node to the remote signer.
+	Cert string `mapstructure:"cert"`
+	Key  string `mapstructure:"key"`
+
+	// Timeout of a single request, the default timeout is used if 0.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// RateLimit is the number of requests per second that are sent to the remote signer, not limited if 0.
+	// Requests over the limit wait until they can be sent.
+	RateLimit float64 `mapstructure:"rate-limit"`
+	// Burst of requests over the rate limit, the default burst is used if 0.
+	Burst int `mapstructure:"burst"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout: 5 * time.Second,
+		Burst:   10,
+	}
+}
+
+// Signer forwards signing requests to the remote signer over mTLS. It implements signing.Signer.
+// Signatures are verified before they are returned, so that misconfigured signer is noticed
+// before signed messages are published.
+type Signer struct {
+	logger   *zap.Logger
+	conn     *grpc.ClientConn
+	timeout  time.Duration
+	limiter  *rate.Limiter
+	verifier *signing.EdVerifier
+	address  string
+	prefix   []byte
+	nodeID   types.NodeID
+}
+
+var _ signing.Signer = (*Signer)(nil)
+
+// Dial connects to the remote signer and requests its identity.
+// Prefix is the network prefix that is applied by the remote signer.
+func Dial(ctx context.Context, logger *zap.Logger, cfg Config, prefix []byte) (*Signer, error) {
+	creds, err := clientCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.DialContext(ctx, cfg.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(rpc.Scale)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dial remote signer %s: %w", cfg.Address, err)
+	}
+	// signers in the node config are listed without defaults
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultConfig().Timeout
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = DefaultConfig().Burst
+	}
+	limit := rate.Inf
+	if cfg.RateLimit > 0 {
+		limit = rate.Limit(cfg.RateLimit)
+	}
+	s := &Signer{
+		logger:   logger,
+		conn:     conn,
+		timeout:  cfg.Timeout,
+		limiter:  rate.NewLimiter(limit, cfg.Burst),
+		verifier: signing.NewEdVerifier(signing.WithVerifierPrefix(prefix)),
+		address:  cfg.Address,
+		prefix:   prefix,
+	}
+	var resp PublicKeyResponse
+	if err := s.invoke(ctx, "PublicKey", &PublicKeyRequest{}, &resp); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("request identity of remote signer %s: %w", cfg.Address, err)
+	}
+	s.nodeID = resp.NodeID
+	s.logger = logger.With(log.ZShortStringer("smesherID", s.nodeID), zap.String("address", cfg.Address))
+	s.logger.Info("connected to remote signer")
+	return s, nil
+}
+
+func clientCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	if cfg.CACert == "" || cfg.Cert == "" || cfg.Key == "" {
+		return nil, errors.New("ca certificate, certificate and key are required for remote signer")
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	caCert, err := os.ReadFile(cfg.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("load ca certificate: %w", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("setup ca certificate")
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      certPool,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+func (s *Signer) invoke(ctx context.Context, method string, req, resp any) error {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.conn.Invoke(ctx, rpc.FullMethod(serviceName, method), req, resp)
+}
+
+// NodeID returns the identity of the remote signer.
+func (s *Signer) NodeID() types.NodeID {
+	return s.nodeID
+}
+
+// Name returns the address of the remote signer.
+func (s *Signer) Name() string {
+	return s.address
+}
+
+// Prefix returns the network prefix that is applied by the remote signer.
+func (s *Signer) Prefix() []byte {
+	return s.prefix
+}
+
+// SignContext requests the signature of the message in the domain from the remote signer.
+func (s *Signer) SignContext(ctx context.Context, d signing.Domain, m []byte) (types.EdSignature, error) {
+	start := time.Now()
+	var resp SignResponse
+	if err := s.invoke(ctx, "Sign", &SignRequest{Domain: uint8(d), Message: m}, &resp); err != nil {
+		s.logger.Warn("remote signing failed", zap.Stringer("domain", d), zap.Error(err))
+		return types.EmptyEdSignature, fmt.Errorf("remote sign %s: %w", d, err)
+	}
+	if !s.verifier.Verify(d, s.nodeID, m, resp.Signature) {
+		s.logger.Error("remote signer returned invalid signature", zap.Stringer("domain", d))
+		return types.EmptyEdSignature, fmt.Errorf("remote sign %s: invalid signature", d)
+	}
+	s.logger.Debug("signed remotely",
+		zap.Stringer("domain", d),
+		zap.Int("size", len(m)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return resp.Signature, nil
+}
+
+// SignVRFContext requests the vrf signature of the message from the remote signer.
+// The remote signer proves with the identity key in all epochs, identities of remote signers don't rotate vrf keys.
+func (s *Signer) SignVRFContext(ctx context.Context, _ types.EpochID, m []byte) (types.VrfSignature, error) {
+	start := time.Now()
+	var resp SignVRFResponse
+	if err := s.invoke(ctx, "SignVRF", &SignVRFRequest{Message: m}, &resp); err != nil {
+		s.logger.Warn("remote vrf signing failed", zap.Error(err))
+		return types.EmptyVrfSignature, fmt.Errorf("remote vrf sign: %w", err)
+	}
+	if !signing.VRFVerify(s.nodeID, m, resp.Signature) {
+		s.logger.Error("remote signer returned invalid vrf signature")
+		return types.EmptyVrfSignature, errors.New("remote vrf sign: invalid signature")
+	}
+	s.logger.Debug("signed vrf remotely", zap.Int("size", len(m)), zap.Duration("duration", time.Since(start)))
+	return resp.Signature, nil
+}
+
+// Close closes the connection to the remote signer.
+func (s *Signer) Close() error {
+	return s.conn.Close()
+}
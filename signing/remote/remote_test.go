@@ -0,0 +1,191 @@
+package remote_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/signing/remote"
+)
+
+type certs struct {
+	ca, serverCert, serverKey, clientCert, clientKey string
+}
+
+func writePEM(tb testing.TB, path, typ string, data []byte) {
+	require.NoError(tb, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: data}), 0o600))
+}
+
+func genCert(
+	tb testing.TB,
+	dir, name string,
+	template, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(tb, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(tb, err)
+	writePEM(tb, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+	encoded, err := x509.MarshalECPrivateKey(key)
+	require.NoError(tb, err)
+	writePEM(tb, filepath.Join(dir, name+".key"), "EC PRIVATE KEY", encoded)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(tb, err)
+	return cert, key
+}
+
+func genCerts(tb testing.TB) certs {
+	dir := tb.TempDir()
+	template := func(serial int64, name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+	}
+	caTemplate := template(1, "ca")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign
+	ca, caKey := genCert(tb, dir, "ca", caTemplate, nil, nil)
+	genCert(tb, dir, "server", template(2, "server"), ca, caKey)
+	genCert(tb, dir, "client", template(3, "client"), ca, caKey)
+	return certs{
+		ca:         filepath.Join(dir, "ca.crt"),
+		serverCert: filepath.Join(dir, "server.crt"),
+		serverKey:  filepath.Join(dir, "server.key"),
+		clientCert: filepath.Join(dir, "client.crt"),
+		clientKey:  filepath.Join(dir, "client.key"),
+	}
+}
+
+func launchSigner(tb testing.TB, c certs, svc *remote.Service) string {
+	cert, err := tls.LoadX509KeyPair(c.serverCert, c.serverKey)
+	require.NoError(tb, err)
+	ca, err := os.ReadFile(c.ca)
+	require.NoError(tb, err)
+	pool := x509.NewCertPool()
+	require.True(tb, pool.AppendCertsFromPEM(ca))
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})))
+	svc.RegisterService(server)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	var eg errgroup.Group
+	eg.Go(func() error {
+		return server.Serve(lis)
+	})
+	tb.Cleanup(func() {
+		server.Stop()
+		require.NoError(tb, eg.Wait())
+	})
+	return lis.Addr().String()
+}
+
+func TestRemoteSigner(t *testing.T) {
+	prefix := []byte("prefix")
+	local, err := signing.NewEdSigner(signing.WithPrefix(prefix))
+	require.NoError(t, err)
+	c := genCerts(t)
+	addr := launchSigner(t, c, remote.NewService(zaptest.NewLogger(t), local,
+		remote.WithDomains(signing.ATX, signing.HARE),
+	))
+
+	cfg := remote.DefaultConfig()
+	cfg.Address = addr
+	cfg.CACert = c.ca
+	cfg.Cert = c.clientCert
+	cfg.Key = c.clientKey
+	ctx := context.Background()
+	signer, err := remote.Dial(ctx, zaptest.NewLogger(t), cfg, prefix)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, signer.Close()) })
+	require.Equal(t, local.NodeID(), signer.NodeID())
+
+	msg := []byte("message")
+	sig, err := signer.SignContext(ctx, signing.HARE, msg)
+	require.NoError(t, err)
+	require.Equal(t, local.Sign(signing.HARE, msg), sig)
+
+	_, err = signer.SignContext(ctx, signing.BALLOT, msg)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	vrf, err := signer.SignVRFContext(ctx, 1, msg)
+	require.NoError(t, err)
+	require.True(t, signing.VRFVerify(local.NodeID(), msg, vrf))
+
+	t.Run("wrong prefix", func(t *testing.T) {
+		signer, err := remote.Dial(ctx, zaptest.NewLogger(t), cfg, []byte("other"))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, signer.Close()) })
+		_, err = signer.SignContext(ctx, signing.ATX, msg)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+	t.Run("invalid client certificate", func(t *testing.T) {
+		cfg := cfg
+		cfg.Cert = ""
+		_, err := remote.Dial(ctx, zaptest.NewLogger(t), cfg, prefix)
+		require.ErrorContains(t, err, "required")
+
+		// client certificate is signed by the unknown authority
+		other := genCerts(t)
+		cfg.Cert = other.clientCert
+		cfg.Key = other.clientKey
+		_, err = remote.Dial(ctx, zaptest.NewLogger(t), cfg, prefix)
+		require.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestRemoteSigner_RateLimit(t *testing.T) {
+	local, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	c := genCerts(t)
+	addr := launchSigner(t, c, remote.NewService(zaptest.NewLogger(t), local, remote.WithRateLimit(0.001, 2)))
+
+	cfg := remote.DefaultConfig()
+	cfg.Address = addr
+	cfg.CACert = c.ca
+	cfg.Cert = c.clientCert
+	cfg.Key = c.clientKey
+	ctx := context.Background()
+	signer, err := remote.Dial(ctx, zaptest.NewLogger(t), cfg, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, signer.Close()) })
+
+	for i := 0; i < 2; i++ {
+		_, err := signer.SignContext(ctx, signing.BEACON_FIRST_MSG, []byte{byte(i)})
+		require.NoError(t, err)
+	}
+	_, err = signer.SignVRFContext(ctx, 1, []byte{3})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
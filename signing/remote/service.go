@@ -0,0 +1,127 @@
+package remote
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/go-spacemesh/api/rpc"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/signing"
+)
+
+const serviceName = "spacemesh.signer.v1.SignerService"
+
+type signerServer interface {
+	PublicKey(context.Context, *PublicKeyRequest) (*PublicKeyResponse, error)
+	Sign(context.Context, *SignRequest) (*SignResponse, error)
+	SignVRF(context.Context, *SignVRFRequest) (*SignVRFResponse, error)
+}
+
+// serviceDesc describes the service without protobuf definitions, messages are encoded with scale.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*signerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		rpc.UnaryMethod(serviceName, "PublicKey", signerServer.PublicKey),
+		rpc.UnaryMethod(serviceName, "Sign", signerServer.Sign),
+		rpc.UnaryMethod(serviceName, "SignVRF", signerServer.SignVRF),
+	},
+	Metadata: "signing/remote/types.go",
+}
+
+// ServiceOpt modifies Service.
+type ServiceOpt func(*Service)
+
+// WithRateLimit limits the number of signing requests per second. Requests over the limit are rejected.
+func WithRateLimit(limit rate.Limit, burst int) ServiceOpt {
+	return func(s *Service) {
+		s.limiter = rate.NewLimiter(limit, burst)
+	}
+}
+
+// WithDomains restricts the domains of the messages that are signed. All domains are signed by default.
+func WithDomains(domains ...signing.Domain) ServiceOpt {
+	return func(s *Service) {
+		s.domains = make(map[signing.Domain]struct{}, len(domains))
+		for _, d := range domains {
+			s.domains[d] = struct{}{}
+		}
+	}
+}
+
+// Service signs messages with the local key on behalf of the remote node.
+// It is meant to run on the hardened host, with the server that requires client certificates.
+type Service struct {
+	logger  *zap.Logger
+	signer  *signing.EdSigner
+	limiter *rate.Limiter
+	domains map[signing.Domain]struct{}
+}
+
+// NewService creates the service for the signer. The signer must be created with the network prefix.
+func NewService(logger *zap.Logger, signer *signing.EdSigner, opts ...ServiceOpt) *Service {
+	s := &Service{
+		logger:  logger,
+		signer:  signer,
+		limiter: rate.NewLimiter(rate.Inf, 0),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RegisterService registers the service with the grpc server.
+func (s *Service) RegisterService(server *grpc.Server) {
+	server.RegisterService(&serviceDesc, s)
+}
+
+// String returns the name of this service.
+func (s *Service) String() string {
+	return "SignerService"
+}
+
+func (s *Service) PublicKey(context.Context, *PublicKeyRequest) (*PublicKeyResponse, error) {
+	return &PublicKeyResponse{NodeID: s.signer.NodeID()}, nil
+}
+
+func (s *Service) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	domain := signing.Domain(req.Domain)
+	logger := s.requestLogger(ctx).With(zap.Stringer("domain", domain), zap.Int("size", len(req.Message)))
+	if s.domains != nil {
+		if _, ok := s.domains[domain]; !ok {
+			logger.Warn("rejected signing request for not allowed domain")
+			return nil, status.Errorf(codes.PermissionDenied, "domain %s is not allowed", domain)
+		}
+	}
+	if !s.limiter.Allow() {
+		logger.Warn("rejected signing request over rate limit")
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	logger.Info("signing request")
+	return &SignResponse{Signature: s.signer.Sign(domain, req.Message)}, nil
+}
+
+func (s *Service) SignVRF(ctx context.Context, req *SignVRFRequest) (*SignVRFResponse, error) {
+	logger := s.requestLogger(ctx).With(zap.Int("size", len(req.Message)))
+	if !s.limiter.Allow() {
+		logger.Warn("rejected vrf signing request over rate limit")
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	logger.Info("vrf signing request")
+	return &SignVRFResponse{Signature: s.signer.VRFSigner().Sign(req.Message)}, nil
+}
+
+func (s *Service) requestLogger(ctx context.Context) *zap.Logger {
+	logger := s.logger.With(log.ZShortStringer("smesherID", s.signer.NodeID()))
+	if p, ok := peer.FromContext(ctx); ok {
+		logger = logger.With(zap.Stringer("peer", p.Addr))
+	}
+	return logger
+}
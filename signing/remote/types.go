@@ -0,0 +1,40 @@
+package remote
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+//go:generate scalegen
+
+// PublicKeyRequest requests the identity of the remote signer.
+type PublicKeyRequest struct {
+	// Unused is reserved, message can't be empty.
+	Unused uint8
+}
+
+// PublicKeyResponse is the identity of the remote signer.
+type PublicKeyResponse struct {
+	NodeID types.NodeID
+}
+
+// SignRequest requests signature for the message in the domain.
+// Remote signer applies the network prefix and the domain before signing the message.
+type SignRequest struct {
+	Domain  uint8
+	Message []byte `scale:"max=1048576"` // largest message that can be signed remotely
+}
+
+// SignResponse is the signature of the message.
+type SignResponse struct {
+	Signature types.EdSignature
+}
+
+// SignVRFRequest requests vrf signature for the message.
+type SignVRFRequest struct {
+	Message []byte `scale:"max=1048576"` // largest message that can be signed remotely
+}
+
+// SignVRFResponse is the vrf signature of the message.
+type SignVRFResponse struct {
+	Signature types.VrfSignature
+}
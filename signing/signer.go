@@ -2,6 +2,7 @@ package signing
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -23,9 +24,13 @@ const (
 	BALLOT   = 2
 	HARE     = 3
 	POET     = 4
+	REQUEST  = 5
 
 	BEACON_FIRST_MSG    = 10
 	BEACON_FOLLOWUP_MSG = 11
+	BEACON_FALLBACK     = 12
+
+	BOOTSTRAP_PARAMS = 13
 )
 
 // String returns the string representation of a domain.
@@ -41,10 +46,16 @@ func (d Domain) String() string {
 		return "HARE"
 	case POET:
 		return "POET"
+	case REQUEST:
+		return "REQUEST"
 	case BEACON_FIRST_MSG:
 		return "BEACON_FIRST_MSG"
 	case BEACON_FOLLOWUP_MSG:
 		return "BEACON_FOLLOWUP_MSG"
+	case BEACON_FALLBACK:
+		return "BEACON_FALLBACK"
+	case BOOTSTRAP_PARAMS:
+		return "BOOTSTRAP_PARAMS"
 	default:
 		return "UNKNOWN"
 	}
@@ -123,6 +134,14 @@ func WithPrivateKey(priv PrivateKey) EdSignerOptionFunc {
 	}
 }
 
+// WithName sets the name of the signer, used instead of the file name for keys that are not loaded from files.
+func WithName(name string) EdSignerOptionFunc {
+	return func(opt *edSignerOption) error {
+		opt.file = name
+		return nil
+	}
+}
+
 // WithKeyFromRand sets the private key used by EdSigner using predictable randomness source.
 func WithKeyFromRand(rand io.Reader) EdSignerOptionFunc {
 	return func(opt *edSignerOption) error {
@@ -136,10 +155,23 @@ func WithKeyFromRand(rand io.Reader) EdSignerOptionFunc {
 	}
 }
 
+// Signer signs messages of the identity with domain separation.
+// It is implemented by EdSigner and by signers that forward requests to an external service,
+// therefore signing can fail.
+type Signer interface {
+	types.IdentityDescriptor
+	// Prefix is the network prefix that is applied to the signed messages.
+	Prefix() []byte
+	SignContext(ctx context.Context, d Domain, m []byte) (types.EdSignature, error)
+	// SignVRFContext proves the message with the vrf key that is used by the identity in the epoch.
+	SignVRFContext(ctx context.Context, epoch types.EpochID, m []byte) (types.VrfSignature, error)
+}
+
 // EdSigner represents an ED25519 signer.
 type EdSigner struct {
 	priv PrivateKey
 	file string
+	vrf  *vrfKeys
 
 	prefix []byte
 }
@@ -165,6 +197,7 @@ func NewEdSigner(opts ...EdSignerOptionFunc) (*EdSigner, error) {
 		priv:   cfg.priv,
 		prefix: cfg.prefix,
 		file:   cfg.file,
+		vrf:    &vrfKeys{},
 	}
 	return sig, nil
 }
@@ -179,6 +212,11 @@ func (es *EdSigner) Sign(d Domain, m []byte) types.EdSignature {
 	return *(*[types.EdSignatureSize]byte)(ed25519.Sign(es.priv, msg))
 }
 
+// SignContext signs the provided message. It never fails and implements Signer.
+func (es *EdSigner) SignContext(_ context.Context, d Domain, m []byte) (types.EdSignature, error) {
+	return es.Sign(d, m), nil
+}
+
 // NodeID returns the node ID of the signer.
 func (es *EdSigner) NodeID() types.NodeID {
 	return types.BytesToNodeID(es.PublicKey().Bytes())
@@ -199,7 +237,7 @@ func (es *EdSigner) Name() string {
 	return es.file
 }
 
-// VRFSigner wraps same ed25519 key to provide ecvrf.
+// VRFSigner wraps same ed25519 key to provide ecvrf. It always uses the identity key, see UseVRFKey.
 func (es *EdSigner) VRFSigner() *VRFSigner {
 	return &VRFSigner{
 		privateKey: ed25519.PrivateKey(es.priv),
@@ -207,6 +245,7 @@ func (es *EdSigner) VRFSigner() *VRFSigner {
 	}
 }
 
+// Prefix returns the network prefix that is applied to the signed messages.
 func (es *EdSigner) Prefix() []byte {
 	return es.prefix
 }
@@ -1,13 +1,17 @@
 package signing
 
 import (
+	"time"
+
 	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 )
 
 type edVerifierOption struct {
-	prefix []byte
+	prefix      []byte
+	batchWindow time.Duration
+	batchSize   int
 }
 
 // VerifierOptionFunc to modify verifier.
@@ -20,9 +24,20 @@ func WithVerifierPrefix(prefix []byte) VerifierOptionFunc {
 	}
 }
 
+// WithVerifierBatch enables batch verification of signatures. Concurrent calls to Verify are collected
+// for up to window (or until size signatures are collected) and verified together, which raises
+// verification throughput at the cost of latency of a single call.
+func WithVerifierBatch(window time.Duration, size int) VerifierOptionFunc {
+	return func(opts *edVerifierOption) {
+		opts.batchWindow = window
+		opts.batchSize = size
+	}
+}
+
 // EdVerifier extracts public keys from signatures.
 type EdVerifier struct {
-	prefix []byte
+	prefix  []byte
+	batcher *batcher
 }
 
 func NewEdVerifier(opts ...VerifierOptionFunc) *EdVerifier {
@@ -30,9 +45,13 @@ func NewEdVerifier(opts ...VerifierOptionFunc) *EdVerifier {
 	for _, opt := range opts {
 		opt(cfg)
 	}
-	return &EdVerifier{
+	verifier := &EdVerifier{
 		prefix: cfg.prefix,
 	}
+	if cfg.batchWindow > 0 && cfg.batchSize > 1 {
+		verifier.batcher = &batcher{window: cfg.batchWindow, size: cfg.batchSize}
+	}
+	return verifier
 }
 
 // Verify verifies that a signature matches public key and message.
@@ -41,5 +60,8 @@ func (es *EdVerifier) Verify(d Domain, nodeID types.NodeID, m []byte, sig types.
 	msg = append(msg, es.prefix...)
 	msg = append(msg, byte(d))
 	msg = append(msg, m...)
+	if es.batcher != nil {
+		return es.batcher.verify(nodeID[:], msg, sig[:])
+	}
 	return ed25519.Verify(nodeID[:], msg, sig[:])
 }
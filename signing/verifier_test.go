@@ -2,10 +2,13 @@ package signing_test
 
 import (
 	"crypto/rand"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/signing"
@@ -66,6 +69,51 @@ func TestVerifier_WithPrefix(t *testing.T) {
 	})
 }
 
+func TestVerifier_Batch(t *testing.T) {
+	verifier := signing.NewEdVerifier(
+		signing.WithVerifierPrefix([]byte("one")),
+		signing.WithVerifierBatch(time.Second, 8),
+	)
+	signer, err := signing.NewEdSigner(signing.WithPrefix([]byte("one")))
+	require.NoError(t, err)
+
+	t.Run("full batch", func(t *testing.T) {
+		var eg errgroup.Group
+		for i := 0; i < 16; i++ {
+			msg := []byte{byte(i)}
+			sig := signer.Sign(signing.ATX, msg)
+			valid := i%3 != 0
+			if !valid {
+				sig[0]++
+			}
+			eg.Go(func() error {
+				if verifier.Verify(signing.ATX, signer.NodeID(), msg, sig) != valid {
+					return fmt.Errorf("unexpected result for message %d", msg[0])
+				}
+				return nil
+			})
+		}
+		// batches are full, so verification doesn't wait for the window
+		done := make(chan error)
+		go func() { done <- eg.Wait() }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(500 * time.Millisecond):
+			require.FailNow(t, "timed out waiting for full batch")
+		}
+	})
+	t.Run("window", func(t *testing.T) {
+		verifier := signing.NewEdVerifier(
+			signing.WithVerifierPrefix([]byte("one")),
+			signing.WithVerifierBatch(10*time.Millisecond, 8),
+		)
+		msg := []byte("test")
+		require.True(t, verifier.Verify(signing.ATX, signer.NodeID(), msg, signer.Sign(signing.ATX, msg)))
+		require.False(t, verifier.Verify(signing.HARE, signer.NodeID(), msg, signer.Sign(signing.ATX, msg)))
+	})
+}
+
 func Fuzz_EdVerifier(f *testing.F) {
 	f.Fuzz(func(t *testing.T, msg, prefix []byte) {
 		signer, err := signing.NewEdSigner(signing.WithPrefix(prefix))
@@ -1,6 +1,8 @@
 package signing
 
 import (
+	"context"
+
 	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
 	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519/extra/ecvrf"
 
@@ -18,11 +20,20 @@ func (s VRFSigner) Sign(msg []byte) types.VrfSignature {
 	return *(*[types.VrfSignatureSize]byte)(ecvrf.Prove(s.privateKey, msg))
 }
 
+// SignVRFContext signs a message for VRF purposes with the same key in all epochs. It never fails.
+func (s VRFSigner) SignVRFContext(_ context.Context, _ types.EpochID, msg []byte) (types.VrfSignature, error) {
+	return s.Sign(msg), nil
+}
+
 // NodeID of the signer.
 func (s VRFSigner) NodeID() types.NodeID {
 	return s.nodeID
 }
 
+func (s VRFSigner) publicKey() []byte {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
 // PublicKey of the signer.
 func (s VRFSigner) PublicKey() *PublicKey {
 	return NewPublicKey(s.nodeID.Bytes())
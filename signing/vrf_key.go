@@ -0,0 +1,107 @@
+package signing
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"slices"
+	"sync"
+
+	"github.com/oasisprotocol/curve25519-voi/primitives/ed25519"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+var vrfKeyDomain = []byte("vrf key")
+
+// VRFMessage returns the message that is proven with the vrf key of the identity.
+// Proofs of a rotated key are made over the message prefixed with the node id. Any identity can declare
+// any key, the prefix makes sure that proofs of one identity are not valid for another identity with the same key.
+func VRFMessage(nodeID, key types.NodeID, msg []byte) []byte {
+	if key == nodeID {
+		return msg
+	}
+	buf := make([]byte, 0, len(nodeID)+len(msg))
+	buf = append(buf, nodeID.Bytes()...)
+	return append(buf, msg...)
+}
+
+// vrfKey is a rotated vrf key that proves the messages of the epochs starting from the first one.
+type vrfKey struct {
+	first  types.EpochID
+	public types.NodeID
+	signer *VRFSigner
+}
+
+// vrfKeys are the rotated vrf keys of the identity ordered by the first epoch.
+type vrfKeys struct {
+	mu   sync.RWMutex
+	keys []vrfKey
+}
+
+func (v *vrfKeys) use(key vrfKey) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	i, found := slices.BinarySearchFunc(v.keys, key.first, func(k vrfKey, first types.EpochID) int {
+		return cmp.Compare(k.first, first)
+	})
+	if found {
+		v.keys[i] = key
+		return
+	}
+	v.keys = slices.Insert(v.keys, i, key)
+}
+
+func (v *vrfKeys) get(epoch types.EpochID) (vrfKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for i := len(v.keys) - 1; i >= 0; i-- {
+		if v.keys[i].first <= epoch {
+			return v.keys[i], true
+		}
+	}
+	return vrfKey{}, false
+}
+
+func (es *EdSigner) rotatedVRFSigner(n uint32) *VRFSigner {
+	index := make([]byte, 4)
+	binary.LittleEndian.PutUint32(index, n)
+	seed := hash.Sum(vrfKeyDomain, es.priv.Seed(), index)
+	return &VRFSigner{
+		privateKey: ed25519.NewKeyFromSeed(seed[:]),
+		nodeID:     es.NodeID(),
+	}
+}
+
+// VRFKey returns the public vrf key with the index. Index zero is the identity key, the other keys are derived
+// from the identity key, so a rotated key can be recovered from the identity key and the exposure
+// of a rotated key doesn't expose the identity key or the other rotated keys.
+func (es *EdSigner) VRFKey(n uint32) types.NodeID {
+	if n == 0 {
+		return es.NodeID()
+	}
+	return types.BytesToNodeID(es.rotatedVRFSigner(n).publicKey())
+}
+
+// UseVRFKey makes the vrf key with the index prove the messages of the epochs starting from the first one.
+func (es *EdSigner) UseVRFKey(first types.EpochID, n uint32) {
+	signer := es.VRFSigner()
+	if n != 0 {
+		signer = es.rotatedVRFSigner(n)
+	}
+	es.vrf.use(vrfKey{
+		first:  first,
+		public: types.BytesToNodeID(signer.publicKey()),
+		signer: signer,
+	})
+}
+
+// SignVRFContext signs the provided message for VRF purposes with the vrf key that is used in the epoch.
+// It never fails and implements Signer.
+func (es *EdSigner) SignVRFContext(_ context.Context, epoch types.EpochID, m []byte) (types.VrfSignature, error) {
+	if key, ok := es.vrf.get(epoch); ok {
+		return key.signer.Sign(VRFMessage(es.NodeID(), key.public, m)), nil
+	}
+	return es.VRFSigner().Sign(m), nil
+}
@@ -0,0 +1,46 @@
+package signing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func Test_VRFKeyRotation(t *testing.T) {
+	signer, err := NewEdSigner()
+	require.NoError(t, err)
+	other, err := NewEdSigner(WithPrivateKey(signer.PrivateKey()))
+	require.NoError(t, err)
+
+	require.Equal(t, signer.NodeID(), signer.VRFKey(0))
+	key := signer.VRFKey(1)
+	require.NotEqual(t, signer.NodeID(), key)
+	require.NotEqual(t, key, signer.VRFKey(2))
+	require.Equal(t, key, other.VRFKey(1), "keys are derived from the identity key")
+
+	msg := []byte("hello world")
+	signer.UseVRFKey(5, 1)
+	for epoch := types.EpochID(0); epoch < 5; epoch++ {
+		sig, err := signer.SignVRFContext(context.Background(), epoch, msg)
+		require.NoError(t, err)
+		require.True(t, VRFVerify(signer.NodeID(), msg, sig))
+	}
+	sig, err := signer.SignVRFContext(context.Background(), 5, msg)
+	require.NoError(t, err)
+	require.False(t, VRFVerify(signer.NodeID(), msg, sig))
+	require.False(t, VRFVerify(key, msg, sig))
+	require.True(t, VRFVerify(key, VRFMessage(signer.NodeID(), key, msg), sig))
+	require.False(t, VRFVerify(key, VRFMessage(types.RandomNodeID(), key, msg), sig),
+		"proof is bound to the identity")
+
+	signer.UseVRFKey(7, 2)
+	sig, err = signer.SignVRFContext(context.Background(), 6, msg)
+	require.NoError(t, err)
+	require.True(t, VRFVerify(key, VRFMessage(signer.NodeID(), key, msg), sig))
+	sig, err = signer.SignVRFContext(context.Background(), 8, msg)
+	require.NoError(t, err)
+	require.True(t, VRFVerify(signer.VRFKey(2), VRFMessage(signer.NodeID(), signer.VRFKey(2), msg), sig))
+}
@@ -0,0 +1,89 @@
+package simulation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Clock is the clock shared by all nodes of the simulation. Layers start only when StartLayer is called,
+// the wall time is advanced explicitly with the methods of the embedded fake clock.
+type Clock struct {
+	clockwork.FakeClock
+
+	genesis       time.Time
+	layerDuration time.Duration
+
+	mu      sync.Mutex
+	current types.LayerID
+	waiters []layerWaiter
+}
+
+type layerWaiter struct {
+	lid types.LayerID
+	ch  chan struct{}
+}
+
+// NewClock creates the clock that starts at genesis with the current layer set to the layer.
+func NewClock(genesis time.Time, layerDuration time.Duration, current types.LayerID) *Clock {
+	c := &Clock{
+		FakeClock:     clockwork.NewFakeClockAt(genesis),
+		genesis:       genesis,
+		layerDuration: layerDuration,
+		current:       current,
+	}
+	c.AdvanceTo(c.LayerToTime(current))
+	return c
+}
+
+// CurrentLayer returns the last started layer.
+func (c *Clock) CurrentLayer() types.LayerID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// LayerToTime returns the time when the layer starts.
+func (c *Clock) LayerToTime(lid types.LayerID) time.Time {
+	return c.genesis.Add(time.Duration(lid) * c.layerDuration)
+}
+
+// AwaitLayer returns the channel that is closed when the layer is started.
+func (c *Clock) AwaitLayer(lid types.LayerID) <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan struct{})
+	if lid <= c.current {
+		close(ch)
+		return ch
+	}
+	c.waiters = append(c.waiters, layerWaiter{lid: lid, ch: ch})
+	return ch
+}
+
+// StartLayer moves the wall time to the start of the layer and notifies subscribers of the layer.
+func (c *Clock) StartLayer(lid types.LayerID) {
+	c.AdvanceTo(c.LayerToTime(lid))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = lid
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.lid <= lid {
+			close(w.ch)
+		} else {
+			waiters = append(waiters, w)
+		}
+	}
+	c.waiters = waiters
+}
+
+// AdvanceTo moves the wall time forward to t. It is a noop if t is not after the current time.
+func (c *Clock) AdvanceTo(t time.Time) {
+	if d := t.Sub(c.Now()); d > 0 {
+		c.Advance(d)
+	}
+}
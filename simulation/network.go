@@ -0,0 +1,191 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+)
+
+// Network delivers gossip between peers in the same process. Messages are delivered synchronously
+// to the handlers of all peers in the partition of the sender, including the sender itself.
+//
+// Messages on synced topics are recorded, peers that missed them because of a partition receive them
+// in the original order when the partition is healed, similarly to the data that node downloads with sync.
+//
+// Messages on delayed topics are queued and delivered only when Flush is called, this emulates
+// the network delay for protocols that expect messages to arrive in the next round.
+type Network struct {
+	mu      sync.Mutex
+	peers   []*peerState
+	synced  map[string]struct{}
+	delayed map[string]struct{}
+	history []record
+	queue   []delivery
+}
+
+type peerState struct {
+	id        p2p.Peer
+	partition int
+	handlers  map[string]pubsub.GossipHandler
+}
+
+type delivery struct {
+	from, to int
+	topic    string
+	msg      []byte
+}
+
+type record struct {
+	from      int
+	topic     string
+	msg       []byte
+	delivered []bool
+}
+
+// NewNetwork creates the network in which all peers are connected.
+func NewNetwork(synced ...string) *Network {
+	n := &Network{synced: map[string]struct{}{}, delayed: map[string]struct{}{}}
+	for _, topic := range synced {
+		n.synced[topic] = struct{}{}
+	}
+	return n
+}
+
+// Delay queues messages on the topics until Flush is called.
+func (n *Network) Delay(topics ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, topic := range topics {
+		n.delayed[topic] = struct{}{}
+	}
+}
+
+// Flush delivers queued messages to the peers that were in the partition of the sender when it published them.
+func (n *Network) Flush(ctx context.Context) {
+	n.mu.Lock()
+	queue := n.queue
+	n.queue = nil
+	n.mu.Unlock()
+	for _, d := range queue {
+		n.deliver(ctx, d.from, d.to, d.topic, d.msg)
+	}
+}
+
+// Join adds the peer to the network. The peer is connected to all peers that are not partitioned.
+func (n *Network) Join() *PubSub {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	index := len(n.peers)
+	n.peers = append(n.peers, &peerState{
+		id:       p2p.Peer(fmt.Sprintf("peer-%d", index)),
+		handlers: map[string]pubsub.GossipHandler{},
+	})
+	return &PubSub{network: n, index: index}
+}
+
+// Partition splits the peers into groups by their indexes, peers in different groups don't receive messages
+// from each other. Peers that are not in any group are isolated.
+func (n *Network) Partition(groups ...[]int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, peer := range n.peers {
+		// isolated peers are in their own partitions after the groups
+		peer.partition = len(groups) + i
+	}
+	for i, group := range groups {
+		for _, index := range group {
+			n.peers[index].partition = i
+		}
+	}
+}
+
+// Heal connects all peers and delivers recorded messages that peers missed during the partition.
+func (n *Network) Heal(ctx context.Context) {
+	n.mu.Lock()
+	var missed []delivery
+	for _, peer := range n.peers {
+		peer.partition = 0
+	}
+	for i := range n.history {
+		r := &n.history[i]
+		for to := range n.peers {
+			if to < len(r.delivered) && !r.delivered[to] {
+				r.delivered[to] = true
+				missed = append(missed, delivery{from: r.from, to: to, topic: r.topic, msg: r.msg})
+			}
+		}
+	}
+	n.mu.Unlock()
+	for _, d := range missed {
+		n.deliver(ctx, d.from, d.to, d.topic, d.msg)
+	}
+}
+
+// Connected returns true if the peers are in the same partition.
+func (n *Network) Connected(a, b int) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.peers[a].partition == n.peers[b].partition
+}
+
+func (n *Network) publish(ctx context.Context, from int, topic string, msg []byte) {
+	n.mu.Lock()
+	var recipients []int
+	delivered := make([]bool, len(n.peers))
+	for i, peer := range n.peers {
+		if peer.partition == n.peers[from].partition {
+			recipients = append(recipients, i)
+			delivered[i] = true
+		}
+	}
+	if _, exists := n.synced[topic]; exists {
+		n.history = append(n.history, record{from: from, topic: topic, msg: msg, delivered: delivered})
+	}
+	if _, exists := n.delayed[topic]; exists {
+		for _, to := range recipients {
+			n.queue = append(n.queue, delivery{from: from, to: to, topic: topic, msg: msg})
+		}
+		n.mu.Unlock()
+		return
+	}
+	n.mu.Unlock()
+	for _, to := range recipients {
+		n.deliver(ctx, from, to, topic, msg)
+	}
+}
+
+func (n *Network) deliver(ctx context.Context, from, to int, topic string, msg []byte) {
+	n.mu.Lock()
+	handler := n.peers[to].handlers[topic]
+	sender := n.peers[from].id
+	n.mu.Unlock()
+	if handler != nil {
+		// errors are returned by handlers for messages that are not valid for the peer,
+		// they are not propagated to the sender, same as in the p2p network
+		_ = handler(ctx, sender, msg)
+	}
+}
+
+// PubSub is the pubsub of the peer in the network.
+type PubSub struct {
+	network *Network
+	index   int
+}
+
+var _ pubsub.PublishSubsciber = (*PubSub)(nil)
+
+// Register the handler for the topic. Options are ignored, handlers are always called inline.
+func (ps *PubSub) Register(topic string, handler pubsub.GossipHandler, _ ...pubsub.ValidatorOpt) {
+	ps.network.mu.Lock()
+	defer ps.network.mu.Unlock()
+	ps.network.peers[ps.index].handlers[topic] = handler
+}
+
+// Publish delivers the message to the peers in the partition of this peer, or queues it if the topic is delayed.
+func (ps *PubSub) Publish(ctx context.Context, topic string, msg []byte) error {
+	ps.network.publish(ctx, ps.index, topic, msg)
+	return nil
+}
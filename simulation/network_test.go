@@ -0,0 +1,59 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/p2p"
+)
+
+func TestNetwork(t *testing.T) {
+	const (
+		synced  = "synced"
+		gossip  = "gossip"
+		delayed = "delayed"
+	)
+	ctx := context.Background()
+	network := NewNetwork(synced)
+	network.Delay(delayed)
+
+	received := make([][]string, 3)
+	pubsubs := make([]*PubSub, 3)
+	for i := range pubsubs {
+		i := i
+		pubsubs[i] = network.Join()
+		for _, topic := range []string{synced, gossip, delayed} {
+			topic := topic
+			pubsubs[i].Register(topic, func(_ context.Context, _ p2p.Peer, msg []byte) error {
+				received[i] = append(received[i], topic+":"+string(msg))
+				return nil
+			})
+		}
+	}
+
+	require.NoError(t, pubsubs[0].Publish(ctx, gossip, []byte("a")))
+	require.Equal(t, [][]string{{"gossip:a"}, {"gossip:a"}, {"gossip:a"}}, received)
+
+	network.Partition([]int{0, 1})
+	require.True(t, network.Connected(0, 1))
+	require.False(t, network.Connected(0, 2))
+	require.NoError(t, pubsubs[0].Publish(ctx, synced, []byte("b")))
+	require.NoError(t, pubsubs[0].Publish(ctx, gossip, []byte("c")))
+	require.NoError(t, pubsubs[2].Publish(ctx, synced, []byte("d")))
+	require.NoError(t, pubsubs[1].Publish(ctx, delayed, []byte("e")))
+	require.Equal(t, []string{"gossip:a", "synced:b", "gossip:c"}, received[0])
+	require.Equal(t, []string{"gossip:a", "synced:d"}, received[2])
+
+	network.Flush(ctx)
+	require.Equal(t, []string{"gossip:a", "synced:b", "gossip:c", "delayed:e"}, received[0])
+	require.Equal(t, []string{"gossip:a", "synced:b", "gossip:c", "delayed:e"}, received[1])
+	require.Equal(t, []string{"gossip:a", "synced:d"}, received[2])
+
+	network.Heal(ctx)
+	require.True(t, network.Connected(0, 2))
+	require.Equal(t, []string{"gossip:a", "synced:b", "gossip:c", "delayed:e", "synced:d"}, received[0])
+	require.Equal(t, []string{"gossip:a", "synced:b", "gossip:c", "delayed:e", "synced:d"}, received[1])
+	require.Equal(t, []string{"gossip:a", "synced:d", "synced:b"}, received[2])
+}
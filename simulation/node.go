@@ -0,0 +1,375 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/atxsdata"
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+	"github.com/spacemeshos/go-spacemesh/hare3/eligibility"
+	"github.com/spacemeshos/go-spacemesh/layerpatrol"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/proposals/store"
+	"github.com/spacemeshos/go-spacemesh/proposals/util"
+	"github.com/spacemeshos/go-spacemesh/signing"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+)
+
+// blockProtocol distributes blocks built from the hare output. Nodes in the network build blocks
+// independently, the simulation shares them so that nodes that didn't reach consensus learn about them.
+const blockProtocol = "simulation/block"
+
+// Node runs hare and tortoise of a single identity on top of the in-memory database.
+type Node struct {
+	index  int
+	cfg    Config
+	logger *zap.Logger
+	clock  *Clock
+
+	signer    *signing.EdSigner
+	pubsub    *PubSub
+	db        *sql.Database
+	atxsdata  *atxsdata.Data
+	proposals *store.Store
+	oracle    *eligibility.Oracle
+	hare      *hare3.Hare
+	tortoise  *tortoise.Tortoise
+	tracer    *tracer
+
+	// hare is started for layers after this one
+	hareStart types.LayerID
+
+	mu     sync.Mutex
+	hareOf map[types.LayerID]types.BlockID
+}
+
+func newNode(index int, cfg Config, logger *zap.Logger, clock *Clock, ps *PubSub) (*Node, error) {
+	signer, err := signing.NewEdSigner()
+	if err != nil {
+		return nil, fmt.Errorf("create signer: %w", err)
+	}
+	n := &Node{
+		index:     index,
+		cfg:       cfg,
+		logger:    logger,
+		clock:     clock,
+		signer:    signer,
+		pubsub:    ps,
+		db:        sql.InMemory(),
+		atxsdata:  atxsdata.New(),
+		proposals: store.New(store.WithLogger(logger)),
+		tracer:    newTracer(),
+		hareStart: clock.CurrentLayer(),
+		hareOf:    map[types.LayerID]types.BlockID{},
+	}
+	n.oracle = eligibility.New(
+		beaconGetter{db: n.db},
+		n.db,
+		n.atxsdata,
+		signing.NewVRFVerifier(),
+		cfg.LayersPerEpoch,
+		eligibility.WithLogger(log.NewFromLog(logger)),
+	)
+	n.tortoise, err = tortoise.New(n.atxsdata,
+		tortoise.WithConfig(cfg.Tortoise),
+		tortoise.WithLogger(log.NewFromLog(logger.Named("tortoise"))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create tortoise: %w", err)
+	}
+	n.hare = hare3.New(
+		clock,
+		ps,
+		n.db,
+		n.atxsdata,
+		n.proposals,
+		signing.NewEdVerifier(),
+		n.oracle,
+		synced{},
+		layerpatrol.New(),
+		hare3.WithConfig(cfg.Hare),
+		hare3.WithLogger(logger.Named("hare")),
+		hare3.WithWallclock(clock),
+		hare3.WithTracer(n.tracer),
+	)
+	n.hare.Register(signer)
+	ps.Register(pubsub.AtxProtocol, n.handleAtx)
+	ps.Register(pubsub.ProposalProtocol, n.handleProposal)
+	ps.Register(blockProtocol, n.handleBlock)
+	return n, nil
+}
+
+// Index returns the index of the node in the simulation.
+func (n *Node) Index() int {
+	return n.index
+}
+
+// NodeID returns the identity of the node.
+func (n *Node) NodeID() types.NodeID {
+	return n.signer.NodeID()
+}
+
+// DB returns the database of the node.
+func (n *Node) DB() *sql.Database {
+	return n.db
+}
+
+// Tortoise returns the tortoise of the node.
+func (n *Node) Tortoise() *tortoise.Tortoise {
+	return n.tortoise
+}
+
+// HareOutput returns the block that the node built from the hare output in the layer.
+// It returns false if hare didn't terminate with a result.
+func (n *Node) HareOutput(lid types.LayerID) (types.BlockID, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	bid, exists := n.hareOf[lid]
+	return bid, exists
+}
+
+// Opinion returns the hash of the opinion of the node on all layers before the layer.
+func (n *Node) Opinion(ctx context.Context, lid types.LayerID) (types.Hash32, error) {
+	opinion, err := n.tortoise.EncodeVotes(ctx, tortoise.EncodeVotesWithCurrent(lid))
+	if err != nil {
+		return types.Hash32{}, err
+	}
+	return opinion.Hash, nil
+}
+
+func (n *Node) start() {
+	n.hare.Start()
+}
+
+func (n *Node) stop() {
+	n.hare.Stop()
+}
+
+// expectsSession returns true if hare starts a session in the layer.
+func (n *Node) expectsSession(lid types.LayerID) bool {
+	return lid > n.hareStart && lid > types.GetEffectiveGenesis() && lid >= n.cfg.Hare.EnableLayer
+}
+
+// publishAtx publishes the atx targeting the next epoch. Post and poet are not executed, the atx
+// is valid as if the identity proved the configured space and received the configured number of ticks.
+func (n *Node) publishAtx(ctx context.Context, publish types.EpochID, nonce types.VRFPostIndex) error {
+	atx := types.NewActivationTx(
+		types.NIPostChallenge{PublishEpoch: publish, Sequence: uint64(publish)},
+		types.GenerateAddress(n.signer.PublicKey().Bytes()),
+		nil,
+		n.cfg.Units,
+		&nonce,
+	)
+	atx.Signature = n.signer.Sign(signing.ATX, atx.SignedBytes())
+	atx.SmesherID = n.signer.NodeID()
+	return n.pubsub.Publish(ctx, pubsub.AtxProtocol, codec.MustEncode(atx))
+}
+
+func (n *Node) handleAtx(_ context.Context, _ p2p.Peer, msg []byte) error {
+	var atx types.ActivationTx
+	if err := codec.Decode(msg, &atx); err != nil {
+		return err
+	}
+	if err := atx.Initialize(); err != nil {
+		return err
+	}
+	atx.SetEffectiveNumUnits(atx.NumUnits)
+	atx.SetReceived(n.clock.Now())
+	// heights are derived from the publish epoch, as if every identity used the same poet
+	verified, err := atx.Verify(uint64(atx.PublishEpoch)*n.cfg.Ticks, n.cfg.Ticks)
+	if err != nil {
+		return err
+	}
+	if err := atxs.Add(n.db, verified); err != nil && !errors.Is(err, sql.ErrObjectExists) {
+		return fmt.Errorf("add atx %s: %w", verified.ID(), err)
+	}
+	header := verified.ToHeader()
+	if added := n.atxsdata.AddFromHeader(header, *verified.VRFNonce, verified.SmesherID, false); added != nil {
+		n.tortoise.OnAtx(verified.TargetEpoch(), verified.ID(), added)
+	}
+	return nil
+}
+
+// onEpoch updates the beacon and the active set that are used in the epoch.
+func (n *Node) onEpoch(epoch types.EpochID, beacon types.Beacon) error {
+	if err := beacons.Add(n.db, epoch, beacon); err != nil && !errors.Is(err, sql.ErrObjectExists) {
+		return fmt.Errorf("add beacon: %w", err)
+	}
+	n.tortoise.OnBeacon(epoch, beacon)
+	var active []types.ATXID
+	n.atxsdata.IterateInEpoch(epoch, func(id types.ATXID, _ *atxsdata.ATX) {
+		active = append(active, id)
+	})
+	sort.Slice(active, func(i, j int) bool {
+		return bytes.Compare(active[i][:], active[j][:]) < 0
+	})
+	n.oracle.UpdateActiveSet(epoch, active)
+	return nil
+}
+
+// propose publishes the ballot with the votes of the tortoise and the proposal without transactions.
+func (n *Node) propose(ctx context.Context, lid types.LayerID, beacon types.Beacon) error {
+	epoch := lid.GetEpoch()
+	atxid, err := atxs.GetIDByEpochAndNodeID(n.db, epoch-1, n.signer.NodeID())
+	if errors.Is(err, sql.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("get own atx: %w", err)
+	}
+	own := n.atxsdata.Get(epoch, atxid)
+	if own == nil {
+		return fmt.Errorf("atx %s is not loaded", atxid)
+	}
+	var total uint64
+	n.atxsdata.IterateInEpoch(epoch, func(_ types.ATXID, atx *atxsdata.ATX) {
+		total += atx.Weight
+	})
+	slots, err := util.GetNumEligibleSlots(own.Weight, 0, total, n.cfg.LayerSize, n.cfg.LayersPerEpoch)
+	if err != nil {
+		return err
+	}
+	// slots of the epoch are spread evenly over its layers
+	offset := lid.Difference(epoch.FirstLayer())
+	eligibilities := slots / n.cfg.LayersPerEpoch
+	if offset < slots%n.cfg.LayersPerEpoch {
+		eligibilities++
+	}
+	if eligibilities == 0 {
+		return nil
+	}
+	opinion, err := n.tortoise.EncodeVotes(ctx, tortoise.EncodeVotesWithCurrent(lid))
+	if err != nil {
+		return fmt.Errorf("encode votes: %w", err)
+	}
+	proposal := &types.Proposal{}
+	proposal.Layer = lid
+	proposal.AtxID = atxid
+	proposal.OpinionHash = opinion.Hash
+	proposal.EpochData = &types.EpochData{Beacon: beacon, EligibilityCount: slots}
+	proposal.Votes = opinion.Votes
+	for j := uint32(0); j < eligibilities; j++ {
+		proposal.EligibilityProofs = append(proposal.EligibilityProofs, types.VotingEligibility{J: j})
+	}
+	proposal.Ballot.Signature = n.signer.Sign(signing.BALLOT, proposal.Ballot.SignedBytes())
+	proposal.SmesherID = n.signer.NodeID()
+	proposal.Signature = n.signer.Sign(signing.PROPOSAL, proposal.SignedBytes())
+	return n.pubsub.Publish(ctx, pubsub.ProposalProtocol, codec.MustEncode(proposal))
+}
+
+func (n *Node) handleProposal(_ context.Context, _ p2p.Peer, msg []byte) error {
+	var proposal types.Proposal
+	if err := codec.Decode(msg, &proposal); err != nil {
+		return err
+	}
+	if err := proposal.Initialize(); err != nil {
+		return err
+	}
+	proposal.SetBeacon(proposal.EpochData.Beacon)
+	decoded, err := n.tortoise.DecodeBallot(proposal.Ballot.ToTortoiseData())
+	if err != nil {
+		return fmt.Errorf("decode ballot %s: %w", proposal.Ballot.ID(), err)
+	}
+	if err := n.tortoise.StoreBallot(decoded); err != nil {
+		return fmt.Errorf("store ballot %s: %w", proposal.Ballot.ID(), err)
+	}
+	return n.hare.OnProposal(&proposal)
+}
+
+// onHareOutput builds the block from the proposals selected by hare and votes for it.
+func (n *Node) onHareOutput(ctx context.Context, out hare3.ConsensusOutput) error {
+	bid := types.EmptyBlockID
+	if len(out.Proposals) > 0 {
+		block := &types.Block{}
+		block.LayerIndex = out.Layer
+		for _, id := range out.Proposals {
+			proposal := n.proposals.Get(out.Layer, id)
+			if proposal == nil {
+				return fmt.Errorf("proposal %s is not known", id)
+			}
+			atx := n.atxsdata.Get(out.Layer.GetEpoch(), proposal.AtxID)
+			if atx == nil {
+				return fmt.Errorf("atx %s is not known", proposal.AtxID)
+			}
+			if block.TickHeight == 0 || atx.Height < block.TickHeight {
+				block.TickHeight = atx.Height
+			}
+			block.Rewards = append(block.Rewards, types.AnyReward{
+				AtxID:  proposal.AtxID,
+				Weight: types.RatNum{Num: 1, Denom: 1},
+			})
+		}
+		sort.Slice(block.Rewards, func(i, j int) bool {
+			return bytes.Compare(block.Rewards[i].AtxID[:], block.Rewards[j].AtxID[:]) < 0
+		})
+		block.Initialize()
+		bid = block.ID()
+		if err := n.pubsub.Publish(ctx, blockProtocol, codec.MustEncode(block)); err != nil {
+			return err
+		}
+	}
+	n.tortoise.OnHareOutput(out.Layer, bid)
+	n.mu.Lock()
+	n.hareOf[out.Layer] = bid
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *Node) handleBlock(_ context.Context, _ p2p.Peer, msg []byte) error {
+	var block types.Block
+	if err := codec.Decode(msg, &block); err != nil {
+		return err
+	}
+	block.Initialize()
+	if err := blocks.Add(n.db, &block); errors.Is(err, sql.ErrObjectExists) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("add block %s: %w", block.ID(), err)
+	}
+	n.tortoise.OnBlock(block.ToVote())
+	return nil
+}
+
+// onLayerEnd consumes the outputs of hare sessions that stopped in the layer and tallies votes.
+func (n *Node) onLayerEnd(ctx context.Context, lid types.LayerID) error {
+	for {
+		select {
+		case coin := <-n.hare.Coins():
+			n.tortoise.OnWeakCoin(coin.Layer, coin.Coin)
+		case out := <-n.hare.Results():
+			if err := n.onHareOutput(ctx, out); err != nil {
+				return fmt.Errorf("hare output in layer %s: %w", out.Layer, err)
+			}
+		default:
+			n.tortoise.TallyVotes(ctx, lid)
+			return nil
+		}
+	}
+}
+
+type beaconGetter struct {
+	db sql.Executor
+}
+
+func (b beaconGetter) GetBeacon(epoch types.EpochID) (types.Beacon, error) {
+	return beacons.Get(b.db, epoch)
+}
+
+type synced struct{}
+
+func (synced) IsSynced(context.Context) bool { return true }
+
+func (synced) IsBeaconSynced(types.EpochID) bool { return true }
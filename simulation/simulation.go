@@ -0,0 +1,261 @@
+// Package simulation runs networks of nodes in a single process, so that changes to the protocols
+// can be validated in scenarios that would otherwise require a cluster.
+//
+// Every node runs hare and tortoise of a single identity. Post and poet are not executed: at the start of
+// every epoch each identity publishes an atx with the configured space units and number of ticks.
+// Ballots carry the votes encoded by the tortoise of the node and hare outputs are turned into blocks.
+// Layers and hare rounds are advanced explicitly by the simulation, and the network can be partitioned
+// and healed between layers.
+//
+// Simulation modifies the global number of layers per epoch.
+package simulation
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+	"github.com/spacemeshos/go-spacemesh/hash"
+	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
+	"github.com/spacemeshos/go-spacemesh/tortoise"
+)
+
+// roundTimeout is the time that the simulation waits for every node to complete the hare round.
+const roundTimeout = 10 * time.Second
+
+// Config of the simulation.
+type Config struct {
+	Nodes          int
+	LayersPerEpoch uint32
+	LayerSize      uint32
+	LayerDuration  time.Duration
+	// Units are the space units of every identity.
+	Units uint32
+	// Ticks is the number of ticks that every identity receives from poet in an epoch.
+	Ticks uint64
+
+	Hare     hare3.Config
+	Tortoise tortoise.Config
+}
+
+// DefaultConfig returns the config of the small network with short epochs.
+func DefaultConfig() Config {
+	hare := hare3.DefaultConfig()
+	hare.Committee = 50
+	trtl := tortoise.DefaultConfig()
+	trtl.LayerSize = 10
+	trtl.Hdist = 4
+	trtl.Zdist = 2
+	trtl.WindowSize = 100
+	return Config{
+		Nodes:          5,
+		LayersPerEpoch: 4,
+		LayerSize:      trtl.LayerSize,
+		LayerDuration:  5 * time.Minute,
+		Units:          4,
+		Ticks:          100,
+		Hare:           hare,
+		Tortoise:       trtl,
+	}
+}
+
+// Opt configures the simulation.
+type Opt func(*Simulation)
+
+// WithConfig overwrites the default config.
+func WithConfig(cfg Config) Opt {
+	return func(s *Simulation) {
+		s.cfg = cfg
+	}
+}
+
+// WithLogger sets the logger of the nodes.
+func WithLogger(logger *zap.Logger) Opt {
+	return func(s *Simulation) {
+		s.logger = logger
+	}
+}
+
+// WithSeed sets the seed of the random values used by the simulation.
+func WithSeed(seed int64) Opt {
+	return func(s *Simulation) {
+		s.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// Simulation is the network of nodes that advances layer by layer.
+type Simulation struct {
+	tb     testing.TB
+	cfg    Config
+	logger *zap.Logger
+	rng    *rand.Rand
+
+	clock   *Clock
+	network *Network
+	nodes   []*Node
+}
+
+// New creates the network of nodes and publishes atxs targeting the first epoch after genesis.
+// Nodes are stopped in the cleanup of tb.
+func New(tb testing.TB, opts ...Opt) *Simulation {
+	s := &Simulation{
+		tb:     tb,
+		cfg:    DefaultConfig(),
+		logger: zap.NewNop(),
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	require.Positive(tb, s.cfg.Nodes, "simulation requires at least one node")
+	types.SetLayersPerEpoch(s.cfg.LayersPerEpoch)
+	s.cfg.Tortoise.LayerSize = s.cfg.LayerSize
+
+	genesis := types.GetEffectiveGenesis()
+	s.clock = NewClock(time.Now(), s.cfg.LayerDuration, genesis)
+	s.network = NewNetwork(pubsub.AtxProtocol, pubsub.ProposalProtocol, blockProtocol)
+	s.network.Delay(s.cfg.Hare.ProtocolName)
+	for i := 0; i < s.cfg.Nodes; i++ {
+		node, err := newNode(i, s.cfg, s.logger.Named(fmt.Sprintf("node-%d", i)), s.clock, s.network.Join())
+		require.NoError(tb, err)
+		s.nodes = append(s.nodes, node)
+	}
+	s.publishAtxs(genesis.GetEpoch())
+	for _, node := range s.nodes {
+		node.start()
+	}
+	tb.Cleanup(func() {
+		for _, node := range s.nodes {
+			node.stop()
+		}
+	})
+	return s
+}
+
+// Nodes returns all nodes of the simulation.
+func (s *Simulation) Nodes() []*Node {
+	return s.nodes
+}
+
+// Node returns the node with the index.
+func (s *Simulation) Node(i int) *Node {
+	return s.nodes[i]
+}
+
+// Clock returns the clock shared by the nodes.
+func (s *Simulation) Clock() *Clock {
+	return s.clock
+}
+
+// Network returns the network that connects the nodes.
+func (s *Simulation) Network() *Network {
+	return s.network
+}
+
+// Partition splits the nodes into groups by their indexes, nodes that are not in any group are isolated.
+// Hare messages, ballots, blocks and atxs are delivered only within the group.
+func (s *Simulation) Partition(groups ...[]int) {
+	s.network.Partition(groups...)
+}
+
+// Heal connects all nodes and delivers atxs, ballots and blocks that nodes missed during the partition.
+func (s *Simulation) Heal() {
+	s.network.Heal(context.Background())
+}
+
+// Beacon returns the beacon that all nodes use in the epoch.
+func Beacon(epoch types.EpochID) types.Beacon {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], epoch.Uint32())
+	var beacon types.Beacon
+	h := hash.Sum(buf[:])
+	copy(beacon[:], h[:])
+	return beacon
+}
+
+// Next runs the next layer: nodes publish ballots, hare runs in lockstep until every session stops,
+// blocks are built from hare outputs and every tortoise tallies votes.
+func (s *Simulation) Next() types.LayerID {
+	ctx := context.Background()
+	lid := s.clock.CurrentLayer() + 1
+	epoch := lid.GetEpoch()
+	if lid == epoch.FirstLayer() {
+		for _, node := range s.nodes {
+			require.NoError(s.tb, node.onEpoch(epoch, Beacon(epoch)))
+		}
+		s.publishAtxs(epoch)
+	}
+	for _, node := range s.nodes {
+		require.NoError(s.tb, node.propose(ctx, lid, Beacon(epoch)))
+	}
+	s.clock.StartLayer(lid)
+	s.runHare(lid)
+	for _, node := range s.nodes {
+		require.NoError(s.tb, node.onLayerEnd(ctx, lid))
+	}
+	return lid
+}
+
+// Run runs n layers and returns the last one.
+func (s *Simulation) Run(n int) types.LayerID {
+	var last types.LayerID
+	for i := 0; i < n; i++ {
+		last = s.Next()
+	}
+	return last
+}
+
+func (s *Simulation) publishAtxs(publish types.EpochID) {
+	for _, node := range s.nodes {
+		require.NoError(s.tb, node.publishAtx(context.Background(), publish, types.VRFPostIndex(s.rng.Uint64())))
+	}
+}
+
+// runHare advances the clock round by round once every session of the layer completed the previous round.
+// Hare messages sent in the round are delivered before the clock is advanced, so that every node receives them
+// with the delay of one round.
+func (s *Simulation) runHare(lid types.LayerID) {
+	var running []*Node
+	for _, node := range s.nodes {
+		if node.expectsSession(lid) {
+			running = append(running, node)
+		}
+	}
+	running = s.awaitRound(lid, running)
+	walltime := s.clock.LayerToTime(lid).Add(s.cfg.Hare.PreroundDelay)
+	for len(running) > 0 {
+		s.network.Flush(context.Background())
+		s.clock.AdvanceTo(walltime)
+		running = s.awaitRound(lid, running)
+		walltime = walltime.Add(s.cfg.Hare.RoundDuration)
+	}
+	// messages from the last round are dropped by nodes that stopped the session
+	s.network.Flush(context.Background())
+}
+
+// awaitRound waits until every session either waits for the next round or stops,
+// and returns nodes with sessions that didn't stop.
+func (s *Simulation) awaitRound(lid types.LayerID, running []*Node) []*Node {
+	timeout := time.NewTimer(roundTimeout)
+	defer timeout.Stop()
+	rst := running[:0]
+	for _, node := range running {
+		select {
+		case stopped := <-node.tracer.events:
+			if !stopped {
+				rst = append(rst, node)
+			}
+		case <-timeout.C:
+			require.FailNow(s.tb, "hare round timed out", "node %d in layer %d", node.index, lid)
+		}
+	}
+	return rst
+}
@@ -0,0 +1,67 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+)
+
+func requireConsistent(tb testing.TB, nodes []*Node, lid types.LayerID) {
+	tb.Helper()
+	expected, err := nodes[0].Opinion(context.Background(), lid)
+	require.NoError(tb, err)
+	for _, node := range nodes[1:] {
+		opinion, err := node.Opinion(context.Background(), lid)
+		require.NoError(tb, err)
+		require.Equal(tb, expected, opinion, "node %d in layer %s", node.Index(), lid)
+	}
+}
+
+func TestSimulation(t *testing.T) {
+	sim := New(t, WithLogger(logtest.New(t).Zap()), WithSeed(1001))
+	first := sim.Next()
+	last := sim.Run(3 * int(sim.cfg.LayersPerEpoch))
+
+	for lid := first; lid <= last; lid++ {
+		expected, exists := sim.Node(0).HareOutput(lid)
+		require.True(t, exists, "layer %s", lid)
+		require.NotEqual(t, types.EmptyBlockID, expected, "layer %s", lid)
+		for _, node := range sim.Nodes()[1:] {
+			bid, exists := node.HareOutput(lid)
+			require.True(t, exists, "node %d in layer %s", node.Index(), lid)
+			require.Equal(t, expected, bid, "node %d in layer %s", node.Index(), lid)
+		}
+	}
+	for _, node := range sim.Nodes() {
+		require.Equal(t, last-1, node.Tortoise().LatestComplete(), "node %d", node.Index())
+	}
+	requireConsistent(t, sim.Nodes(), last+1)
+}
+
+func TestSimulationPartition(t *testing.T) {
+	sim := New(t, WithLogger(logtest.New(t).Zap()), WithSeed(1001))
+	sim.Run(int(sim.cfg.LayersPerEpoch))
+
+	// partition is healed before the epoch ends, so that atxs published during the partition
+	// are known to all nodes in the next epoch
+	sim.Partition([]int{0, 1, 2, 3}, []int{4})
+	start := sim.Next()
+	last := sim.Run(int(sim.cfg.LayersPerEpoch) - 1)
+	for lid := start; lid <= last; lid++ {
+		_, exists := sim.Node(0).HareOutput(lid)
+		require.True(t, exists, "majority partition terminates in layer %s", lid)
+		_, exists = sim.Node(4).HareOutput(lid)
+		require.False(t, exists, "minority partition can't terminate in layer %s", lid)
+	}
+
+	sim.Heal()
+	last = sim.Run(3 * int(sim.cfg.LayersPerEpoch))
+	for _, node := range sim.Nodes() {
+		require.Equal(t, last-1, node.Tortoise().LatestComplete(), "node %d", node.Index())
+	}
+	requireConsistent(t, sim.Nodes(), last+1)
+}
@@ -0,0 +1,33 @@
+package simulation
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hare3"
+)
+
+// tracer notifies the simulation each time the hare session waits for the next round and when it stops,
+// so that the clock is advanced only after all nodes completed the round.
+type tracer struct {
+	// events receives true when the session stopped and false when it waits for the next round
+	events chan bool
+}
+
+var _ hare3.Tracer = (*tracer)(nil)
+
+func newTracer() *tracer {
+	return &tracer{events: make(chan bool, 64)}
+}
+
+func (*tracer) OnStart(types.LayerID) {}
+
+func (t *tracer) OnStop(types.LayerID) {
+	t.events <- true
+}
+
+func (t *tracer) OnActive([]*types.HareEligibility) {
+	t.events <- false
+}
+
+func (*tracer) OnMessageSent(*hare3.Message) {}
+
+func (*tracer) OnMessageReceived(*hare3.Message) {}
@@ -2,6 +2,7 @@ package accounts
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -103,6 +104,42 @@ func All(db sql.Executor) ([]*types.Account, error) {
 	return rst, nil
 }
 
+// IterateLatest iterates over the latest state of all accounts in ascending order of addresses.
+func IterateLatest(db sql.Executor, fn func(*types.Account) bool) error {
+	return IterateSnapshot(db, types.LayerID(math.MaxUint32), fn)
+}
+
+// IterateSnapshot iterates over the state of all accounts at the layer in ascending order of addresses.
+func IterateSnapshot(db sql.Executor, layer types.LayerID, fn func(*types.Account) bool) error {
+	_, err := db.Exec(`
+			select address, balance, next_nonce, max(layer_updated), template, state from accounts
+			where layer_updated <= ?1
+			group by address order by address asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		},
+		func(stmt *sql.Statement) bool {
+			var account types.Account
+			stmt.ColumnBytes(0, account.Address[:])
+			account.Balance = uint64(stmt.ColumnInt64(1))
+			account.NextNonce = uint64(stmt.ColumnInt64(2))
+			account.Layer = types.LayerID(uint32(stmt.ColumnInt64(3)))
+			if stmt.ColumnLen(4) > 0 {
+				var template types.Address
+				stmt.ColumnBytes(4, template[:])
+				account.TemplateAddress = &template
+				account.State = make([]byte, stmt.ColumnLen(5))
+				stmt.ColumnBytes(5, account.State)
+			}
+			return fn(&account)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("iterate accounts at %s: %w", layer, err)
+	}
+	return nil
+}
+
 func Snapshot(db sql.Executor, layer types.LayerID) ([]*types.Account, error) {
 	var rst []*types.Account
 	if rows, err := db.Exec(`
@@ -169,3 +206,19 @@ func Revert(db sql.Executor, after types.LayerID) error {
 	}
 	return nil
 }
+
+// Prune deletes account states that are not needed to load the state at the layer or any later layer.
+// For every address the latest state that was valid at the layer is kept, together with all later states.
+func Prune(db sql.Executor, layer types.LayerID) error {
+	_, err := db.Exec(`delete from accounts where layer_updated < ?1 and exists (
+			select 1 from accounts newer where newer.address = accounts.address
+			and newer.layer_updated > accounts.layer_updated and newer.layer_updated <= ?1
+		);`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to prune accounts before %v: %w", layer, err)
+	}
+	return nil
+}
@@ -104,3 +104,63 @@ func TestSnapshot(t *testing.T) {
 		}
 	}
 }
+
+func TestIterateSnapshot(t *testing.T) {
+	db := sql.InMemory()
+	addresses := []types.Address{{3, 3}, {1, 1}, {2, 2}}
+	n := []int{10, 7, 20}
+	for i, address := range addresses {
+		for _, update := range genSeq(address, n[i]) {
+			require.NoError(t, Update(db, update))
+		}
+	}
+	for _, lid := range []types.LayerID{5, 8, 20} {
+		expected, err := Snapshot(db, lid)
+		require.NoError(t, err)
+		var got []*types.Account
+		require.NoError(t, IterateSnapshot(db, lid, func(account *types.Account) bool {
+			got = append(got, account)
+			return true
+		}))
+		require.Equal(t, expected, got)
+	}
+	expected, err := Snapshot(db, 20)
+	require.NoError(t, err)
+	var latest []*types.Account
+	require.NoError(t, IterateLatest(db, func(account *types.Account) bool {
+		latest = append(latest, account)
+		return len(latest) < 2
+	}))
+	require.Equal(t, expected[:2], latest)
+}
+
+func TestPrune(t *testing.T) {
+	db := sql.InMemory()
+	// first address is updated in every layer, second only in layers 2 and 8
+	first := genSeq(types.Address{1}, 10)
+	second := []*types.Account{
+		{Address: types.Address{2}, Layer: 2, Balance: 2},
+		{Address: types.Address{2}, Layer: 8, Balance: 8},
+	}
+	for _, update := range append(first, second...) {
+		require.NoError(t, Update(db, update))
+	}
+
+	require.NoError(t, Prune(db, 5))
+	for lid := types.LayerID(5); lid <= 10; lid++ {
+		account, err := Get(db, first[0].Address, lid)
+		require.NoError(t, err)
+		require.Equal(t, first[lid-1], &account)
+	}
+	// states before the layer are no longer available
+	account, err := Get(db, first[0].Address, 4)
+	require.NoError(t, err)
+	require.Equal(t, types.Account{Address: first[0].Address}, account)
+
+	account, err = Get(db, second[0].Address, 5)
+	require.NoError(t, err)
+	require.Equal(t, second[0], &account)
+	account, err = Get(db, second[0].Address, 8)
+	require.NoError(t, err)
+	require.Equal(t, second[1], &account)
+}
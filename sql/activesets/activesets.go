@@ -7,25 +7,34 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
 )
 
 const (
 	CacheKindActiveSetBlob sql.QueryCacheKind = "activeset-blob"
 )
 
+// Add adds the active set. The encoded set is stored in the blobs table once per content.
 func Add(db sql.Executor, id types.Hash32, set *types.EpochActiveSet) error {
-	_, err := db.Exec(`insert into activesets
-		(id, epoch, active_set)
-		values (?1, ?2, ?3);`,
-		func(stmt *sql.Statement) {
-			stmt.BindBytes(1, id[:])
-			stmt.BindInt64(2, int64(set.Epoch))
-			stmt.BindBytes(3, codec.MustEncode(set))
-		}, nil)
-	if err != nil {
-		return fmt.Errorf("add active set %v: %w", id.String(), err)
-	}
-	return nil
+	// the blob is referenced in the same transaction, otherwise it may be removed by GC before the insert
+	return db.WithSavepoint(func(db sql.Executor) error {
+		blob, err := blobs.Add(db, codec.MustEncode(set))
+		if err != nil {
+			return fmt.Errorf("add active set %v: %w", id.String(), err)
+		}
+		_, err = db.Exec(`insert into activesets
+			(id, epoch, blob)
+			values (?1, ?2, ?3);`,
+			func(stmt *sql.Statement) {
+				stmt.BindBytes(1, id[:])
+				stmt.BindInt64(2, int64(set.Epoch))
+				stmt.BindBytes(3, blob[:])
+			}, nil)
+		if err != nil {
+			return fmt.Errorf("add active set %v: %w", id.String(), err)
+		}
+		return nil
+	})
 }
 
 func Get(db sql.Executor, id types.Hash32) (*types.EpochActiveSet, error) {
@@ -33,7 +42,8 @@ func Get(db sql.Executor, id types.Hash32) (*types.EpochActiveSet, error) {
 		rst  types.EpochActiveSet
 		err2 error
 	)
-	rows, err := db.Exec("select active_set from activesets where id = ?1;",
+	rows, err := db.Exec(`select b.data from activesets a join blobs b on b.hash = a.blob
+		where a.id = ?1;`,
 		func(stmt *sql.Statement) {
 			stmt.BindBytes(1, id.Bytes())
 		},
@@ -58,7 +68,8 @@ func GetBlob(ctx context.Context, db sql.Executor, id []byte) ([]byte, error) {
 	cacheKey := sql.QueryCacheKey(CacheKindActiveSetBlob, string(id))
 	return sql.WithCachedValue(ctx, db, cacheKey, func(context.Context) ([]byte, error) {
 		var rst []byte
-		rows, err := db.Exec("select active_set from activesets where id = ?1;",
+		rows, err := db.Exec(`select b.data from activesets a join blobs b on b.hash = a.blob
+		where a.id = ?1;`,
 			func(stmt *sql.Statement) {
 				stmt.BindBytes(1, id)
 			},
@@ -9,6 +9,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
 )
 
 func TestActiveSet(t *testing.T) {
@@ -23,6 +24,11 @@ func TestActiveSet(t *testing.T) {
 
 	require.NoError(t, Add(db, ids[0], set))
 	require.ErrorIs(t, Add(db, ids[0], set), sql.ErrObjectExists)
+	// the blob of the rejected set is rolled back with the failed insert
+	rejected := &types.EpochActiveSet{Epoch: 2, Set: []types.ATXID{{3}}}
+	require.ErrorIs(t, Add(db, ids[0], rejected), sql.ErrObjectExists)
+	_, err := blobs.Get(db, blobs.Hash(codec.MustEncode(rejected)))
+	require.ErrorIs(t, err, sql.ErrNotFound)
 	require.NoError(t, Add(db, ids[1], &types.EpochActiveSet{}))
 
 	set1, err := Get(db, ids[0])
@@ -68,19 +74,20 @@ func TestCachedActiveSet(t *testing.T) {
 
 	require.NoError(t, Add(db, ids[0], set0))
 	require.NoError(t, Add(db, ids[1], set1))
-	require.Equal(t, 2, db.QueryCount())
+	// each set is added to the blobs and activesets tables
+	require.Equal(t, 4, db.QueryCount())
 
 	for i := 0; i < 3; i++ {
 		blob, err := GetBlob(ctx, db, ids[0].Bytes())
 		require.NoError(t, err)
 		require.Equal(t, codec.MustEncode(set0), blob)
-		require.Equal(t, 3, db.QueryCount())
+		require.Equal(t, 5, db.QueryCount())
 	}
 
 	for i := 0; i < 3; i++ {
 		blob, err := GetBlob(ctx, db, ids[1].Bytes())
 		require.NoError(t, err)
 		require.Equal(t, codec.MustEncode(set1), blob)
-		require.Equal(t, 4, db.QueryCount())
+		require.Equal(t, 6, db.QueryCount())
 	}
 }
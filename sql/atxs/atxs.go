@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/spacemeshos/go-spacemesh/codec"
+	sqlite "github.com/go-llsqlite/crawshaw"
+
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/builder"
@@ -16,23 +17,32 @@ const (
 	CacheKindATXBlob   sql.QueryCacheKind = "atx-blob"
 )
 
-const fullQuery = `select id, atx, base_tick_height, tick_count, pubkey,
-	effective_num_units, received, epoch, sequence, coinbase, validity from atxs`
+// fullQuery loads the blob with a subquery, so that filters on the columns of atxs are not ambiguous.
+const fullQuery = `select id, (select atx from atx_blobs b where b.id = atxs.id), base_tick_height, tick_count,
+	pubkey, effective_num_units, received, epoch, sequence, coinbase, validity, version from atxs`
+
+// headerQuery doesn't read atx_blobs, the fields that are stored only in the blob are not loaded.
+const headerQuery = `select id, commitment_atx, nonce, base_tick_height, tick_count, pubkey,
+	effective_num_units, received, epoch, sequence, coinbase from atxs`
 
 type decoderCallback func(*types.VerifiedActivationTx, error) bool
 
 func decoder(fn decoderCallback) sql.Decoder {
 	return func(stmt *sql.Statement) bool {
 		var (
-			a  types.ActivationTx
+			a  = &types.ActivationTx{}
 			id types.ATXID
 		)
 		stmt.ColumnBytes(0, id[:])
 		checkpointed := stmt.ColumnLen(1) == 0
 		if !checkpointed {
-			if _, err := codec.DecodeFrom(stmt.ColumnReader(1), &a); err != nil {
+			blob := make([]byte, stmt.ColumnLen(1))
+			stmt.ColumnBytes(1, blob)
+			decoded, err := types.DecodeAtx(types.AtxVersion(stmt.ColumnInt(11)), blob)
+			if err != nil {
 				return fn(nil, fmt.Errorf("decode %w", err))
 			}
+			a = decoded
 		}
 		a.SetID(id)
 		baseTickHeight := uint64(stmt.ColumnInt64(2))
@@ -59,6 +69,37 @@ func decoder(fn decoderCallback) sql.Decoder {
 	}
 }
 
+// headerDecoder decodes the header from the columns of atxs. Checkpointed atxs are stored with zero received time.
+// NumUnits is set to EffectiveNumUnits, PrevATXID, PositioningATX and InitialPost are not set.
+func headerDecoder(fn func(*types.ActivationTxHeader) bool) sql.Decoder {
+	return func(stmt *sql.Statement) bool {
+		var header types.ActivationTxHeader
+		stmt.ColumnBytes(0, header.ID[:])
+		received := stmt.ColumnInt64(7)
+		header.Golden = received == 0
+		if !header.Golden {
+			header.Received = time.Unix(0, received).Local()
+		}
+		if stmt.ColumnLen(1) > 0 && !header.Golden {
+			header.CommitmentATX = &types.ATXID{}
+			stmt.ColumnBytes(1, header.CommitmentATX[:])
+		}
+		if stmt.ColumnType(2) != sqlite.SQLITE_NULL && !header.Golden {
+			nonce := types.VRFPostIndex(stmt.ColumnInt64(2))
+			header.VRFNonce = &nonce
+		}
+		header.BaseTickHeight = uint64(stmt.ColumnInt64(3))
+		header.TickCount = uint64(stmt.ColumnInt64(4))
+		stmt.ColumnBytes(5, header.NodeID[:])
+		header.EffectiveNumUnits = uint32(stmt.ColumnInt32(6))
+		header.NumUnits = header.EffectiveNumUnits
+		header.PublishEpoch = types.EpochID(uint32(stmt.ColumnInt(8)))
+		header.Sequence = uint64(stmt.ColumnInt64(9))
+		stmt.ColumnBytes(10, header.Coinbase[:])
+		return fn(&header)
+	}
+}
+
 func load(db sql.Executor, query string, enc sql.Encoder) (*types.VerifiedActivationTx, error) {
 	var (
 		v    *types.VerifiedActivationTx
@@ -240,6 +281,66 @@ func GetIDsByEpoch(ctx context.Context, db sql.Executor, epoch types.EpochID) (i
 	})
 }
 
+// IterateIDsByEpoch iterates over the ids of the atxs published in the epoch without loading all of them
+// in memory, iteration stops if fn returns false. fn must not query the database.
+func IterateIDsByEpoch(db sql.Executor, epoch types.EpochID, fn func(types.ATXID) bool) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(epoch))
+	}
+	dec := func(stmt *sql.Statement) bool {
+		var id types.ATXID
+		stmt.ColumnBytes(0, id[:])
+		return fn(id)
+	}
+	if _, err := db.Exec("select id from atxs where epoch = ?1;", enc, dec); err != nil {
+		return fmt.Errorf("iterate ids in epoch %v: %w", epoch, err)
+	}
+	return nil
+}
+
+// IterateEquivocations iterates over the pairs of atxs published in the epoch by the same identity,
+// iteration stops if fn returns false. fn must not query the database.
+// Identities that are already known to be malicious are skipped, as well as atxs without blobs
+// (e.g. checkpointed atxs), since they can't be used in a malfeasance proof.
+func IterateEquivocations(db sql.Executor, epoch types.EpochID, fn func(first, second types.ATXID) bool) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(epoch))
+	}
+	dec := func(stmt *sql.Statement) bool {
+		var first, second types.ATXID
+		stmt.ColumnBytes(0, first[:])
+		stmt.ColumnBytes(1, second[:])
+		return fn(first, second)
+	}
+	if _, err := db.Exec(`select a.id, b.id from atxs a
+		join atxs b on a.epoch = b.epoch and a.pubkey = b.pubkey and a.id < b.id
+		join atx_blobs ab on ab.id = a.id
+		join atx_blobs bb on bb.id = b.id
+		where a.epoch = ?1 and ab.atx is not null and bb.atx is not null
+		and not exists (select 1 from identities i where i.pubkey = a.pubkey);`, enc, dec); err != nil {
+		return fmt.Errorf("iterate equivocations in epoch %v: %w", epoch, err)
+	}
+	return nil
+}
+
+// IterateHeadersByEpoch iterates over the headers of the atxs published in the epoch without loading all
+// of them in memory, iteration stops if fn returns false. fn must not query the database.
+// Blobs are not loaded, see headerDecoder for the fields that are not set.
+func IterateHeadersByEpoch(
+	db sql.Executor,
+	epoch types.EpochID,
+	fn func(*types.ActivationTxHeader) bool,
+) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(epoch))
+	}
+	_, err := db.Exec(fmt.Sprintf("%v where epoch = ?1;", headerQuery), enc, headerDecoder(fn))
+	if err != nil {
+		return fmt.Errorf("iterate headers in epoch %v: %w", epoch, err)
+	}
+	return nil
+}
+
 // VRFNonce gets the VRF nonce of a smesher for a given epoch.
 func VRFNonce(db sql.Executor, id types.NodeID, epoch types.EpochID) (nonce types.VRFPostIndex, err error) {
 	enc := func(stmt *sql.Statement) {
@@ -264,11 +365,60 @@ func VRFNonce(db sql.Executor, id types.NodeID, epoch types.EpochID) (nonce type
 	return nonce, err
 }
 
+// VRFKey returns the vrf key of the identity in the epoch. It is the last key rotated by the atxs published
+// before the epoch, or the node id if the identity didn't rotate the key.
+func VRFKey(db sql.Executor, id types.NodeID, epoch types.EpochID) (key types.NodeID, err error) {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, id.Bytes())
+		stmt.BindInt64(2, int64(epoch))
+	}
+	dec := func(stmt *sql.Statement) bool {
+		stmt.ColumnBytes(0, key[:])
+		return true
+	}
+
+	if rows, err := db.Exec(`
+		select vrf_key from atxs
+		where pubkey = ?1 and epoch < ?2 and vrf_key is not null
+		order by epoch desc
+		limit 1;`, enc, dec); err != nil {
+		return types.EmptyNodeID, fmt.Errorf("exec id %v, epoch %d: %w", id, epoch, err)
+	} else if rows == 0 {
+		return id, nil
+	}
+	return key, nil
+}
+
+// IterateVRFKeys iterates the vrf keys rotated by the atxs of the identity in the order of publish epochs.
+func IterateVRFKeys(
+	db sql.Executor,
+	id types.NodeID,
+	fn func(publish types.EpochID, key types.NodeID) bool,
+) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, id.Bytes())
+	}
+	dec := func(stmt *sql.Statement) bool {
+		var key types.NodeID
+		stmt.ColumnBytes(1, key[:])
+		return fn(types.EpochID(uint32(stmt.ColumnInt64(0))), key)
+	}
+	if _, err := db.Exec(`
+		select epoch, vrf_key from atxs
+		where pubkey = ?1 and vrf_key is not null
+		order by epoch asc;`, enc, dec); err != nil {
+		return fmt.Errorf("vrf keys %v: %w", id, err)
+	}
+	return nil
+}
+
 // GetBlob loads ATX as an encoded blob, ready to be sent over the wire.
 func GetBlob(ctx context.Context, db sql.Executor, id []byte) (buf []byte, err error) {
 	cacheKey := sql.QueryCacheKey(CacheKindATXBlob, string(id))
 	return sql.WithCachedValue(ctx, db, cacheKey, func(context.Context) ([]byte, error) {
-		if rows, err := db.Exec("select atx from atxs where id = ?1",
+		// checkpointed atxs don't have a blob, they are served as empty
+		if rows, err := db.Exec(`select b.atx from atxs a left join atx_blobs b on a.id = b.id
+			where a.id = ?1`,
 			func(stmt *sql.Statement) {
 				stmt.BindBytes(1, id)
 			}, func(stmt *sql.Statement) bool {
@@ -288,7 +438,7 @@ func GetBlob(ctx context.Context, db sql.Executor, id []byte) (buf []byte, err e
 
 // Add adds an ATX for a given ATX ID.
 func Add(db sql.Executor, atx *types.VerifiedActivationTx) error {
-	buf, err := codec.Encode(atx.ActivationTx)
+	buf, err := atx.Blob()
 	if err != nil {
 		return fmt.Errorf("encode: %w", err)
 	}
@@ -308,22 +458,35 @@ func Add(db sql.Executor, atx *types.VerifiedActivationTx) error {
 			stmt.BindNull(5)
 		}
 		stmt.BindBytes(6, atx.SmesherID.Bytes())
-		stmt.BindBytes(7, buf)
-		stmt.BindInt64(8, atx.Received().UnixNano())
-		stmt.BindInt64(9, int64(atx.BaseTickHeight()))
-		stmt.BindInt64(10, int64(atx.TickCount()))
-		stmt.BindInt64(11, int64(atx.Sequence))
-		stmt.BindBytes(12, atx.Coinbase.Bytes())
-		stmt.BindInt64(13, int64(atx.Validity()))
+		stmt.BindInt64(7, atx.Received().UnixNano())
+		stmt.BindInt64(8, int64(atx.BaseTickHeight()))
+		stmt.BindInt64(9, int64(atx.TickCount()))
+		stmt.BindInt64(10, int64(atx.Sequence))
+		stmt.BindBytes(11, atx.Coinbase.Bytes())
+		stmt.BindInt64(12, int64(atx.Validity()))
+		stmt.BindInt64(13, int64(atx.Version()))
+		if key := atx.VRFKey(); key != nil {
+			stmt.BindBytes(14, key.Bytes())
+		} else {
+			stmt.BindNull(14)
+		}
 	}
 
 	_, err = db.Exec(`
 		insert into atxs (id, epoch, effective_num_units, commitment_atx, nonce,
-			 pubkey, atx, received, base_tick_height, tick_count, sequence, coinbase, validity)
-		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13);`, enc, nil)
+			 pubkey, received, base_tick_height, tick_count, sequence, coinbase, validity, version, vrf_key)
+		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14);`, enc, nil)
 	if err != nil {
 		return fmt.Errorf("insert ATX ID %v: %w", atx.ID(), err)
 	}
+	_, err = db.Exec("insert into atx_blobs (id, atx) values (?1, ?2);",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, atx.ID().Bytes())
+			stmt.BindBytes(2, buf)
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert ATX blob %v: %w", atx.ID(), err)
+	}
 	epochCacheKey := sql.QueryCacheKey(CacheKindEpochATXs, atx.PublishEpoch.String())
 	sql.AppendToCachedSlice(db, epochCacheKey, atx.ID())
 	return nil
@@ -390,10 +553,12 @@ func GetIDWithMaxHeight(db sql.Executor, pref types.NodeID, filter Filter) (type
 }
 
 type CheckpointAtx struct {
-	ID             types.ATXID
-	Epoch          types.EpochID
-	CommitmentATX  types.ATXID
-	VRFNonce       types.VRFPostIndex
+	ID            types.ATXID
+	Epoch         types.EpochID
+	CommitmentATX types.ATXID
+	VRFNonce      types.VRFPostIndex
+	// VRFKey is the vrf key of the identity in the target epoch of the atx.
+	VRFKey         types.NodeID
 	NumUnits       uint32
 	BaseTickHeight uint64
 	TickCount      uint64
@@ -448,12 +613,17 @@ func AddCheckpointed(db sql.Executor, catx *CheckpointAtx) error {
 		stmt.BindInt64(8, int64(catx.Sequence))
 		stmt.BindBytes(9, catx.SmesherID.Bytes())
 		stmt.BindBytes(10, catx.Coinbase.Bytes())
+		if catx.VRFKey != types.EmptyNodeID && catx.VRFKey != catx.SmesherID {
+			stmt.BindBytes(11, catx.VRFKey.Bytes())
+		} else {
+			stmt.BindNull(11)
+		}
 	}
 
 	_, err := db.Exec(`
 		insert into atxs (id, epoch, effective_num_units, commitment_atx, nonce,
-			base_tick_height, tick_count, sequence, pubkey, coinbase, received)
-		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 0);`, enc, nil)
+			base_tick_height, tick_count, sequence, pubkey, coinbase, received, vrf_key)
+		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, 0, ?11);`, enc, nil)
 	if err != nil {
 		return fmt.Errorf("insert checkpoint ATX %v: %w", catx.ID, err)
 	}
@@ -576,3 +746,81 @@ func CountAtxsByOps(db sql.Executor, operations builder.Operations) (count uint3
 	)
 	return
 }
+
+// EpochWeight is the weight of a smesher in the epoch that its atx targets.
+type EpochWeight struct {
+	SmesherID types.NodeID
+	Epoch     types.EpochID
+	Weight    uint64
+}
+
+// WeightsByTargetEpoch returns weights of atxs of the smesherID and/or coinbase that target
+// epochs in the range [from, to].
+func WeightsByTargetEpoch(
+	db sql.Executor,
+	coinbase *types.Address,
+	smesherID *types.NodeID,
+	from, to types.EpochID,
+) (rst []EpochWeight, err error) {
+	var (
+		whereClause string
+		key         []byte
+	)
+	switch {
+	case coinbase != nil && smesherID != nil:
+		whereClause = "pubkey = ?3 and coinbase = ?4"
+		key = smesherID[:]
+	case coinbase != nil:
+		whereClause = "coinbase = ?3"
+		key = coinbase[:]
+	case smesherID != nil:
+		whereClause = "pubkey = ?3"
+		key = smesherID[:]
+	default:
+		return nil, fmt.Errorf("must specify coinbase and/or smesherID")
+	}
+	query := fmt.Sprintf(`select pubkey, epoch + 1, effective_num_units, tick_count
+		from atxs where epoch between ?1 and ?2 and %s order by epoch, pubkey;`, whereClause)
+	_, err = db.Exec(query,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from.Uint32())-1)
+			stmt.BindInt64(2, int64(to.Uint32())-1)
+			stmt.BindBytes(3, key)
+			if coinbase != nil && smesherID != nil {
+				stmt.BindBytes(4, coinbase[:])
+			}
+		},
+		func(stmt *sql.Statement) bool {
+			weight := EpochWeight{
+				Epoch:  types.EpochID(uint32(stmt.ColumnInt64(1))),
+				Weight: uint64(stmt.ColumnInt64(2)) * uint64(stmt.ColumnInt64(3)),
+			}
+			stmt.ColumnBytes(0, weight.SmesherID[:])
+			rst = append(rst, weight)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("weights in epochs %v-%v: %w", from, to, err)
+	}
+	return rst, nil
+}
+
+// TotalWeightByTargetEpoch returns the sum of weights of all atxs that target epochs in the range [from, to].
+// Epochs without atxs are omitted.
+func TotalWeightByTargetEpoch(db sql.Executor, from, to types.EpochID) (map[types.EpochID]uint64, error) {
+	rst := map[types.EpochID]uint64{}
+	_, err := db.Exec(`select epoch + 1, sum(effective_num_units * tick_count)
+		from atxs where epoch between ?1 and ?2 group by epoch;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from.Uint32())-1)
+			stmt.BindInt64(2, int64(to.Uint32())-1)
+		},
+		func(stmt *sql.Statement) bool {
+			rst[types.EpochID(uint32(stmt.ColumnInt64(0)))] = uint64(stmt.ColumnInt64(1))
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("total weight in epochs %v-%v: %w", from, to, err)
+	}
+	return rst, nil
+}
@@ -1,9 +1,12 @@
 package atxs_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/builder"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 )
 
@@ -417,6 +421,74 @@ func TestGetIDsByEpoch(t *testing.T) {
 	require.ElementsMatch(t, []types.ATXID{atx4.ID()}, ids3)
 }
 
+func TestIterateByEpoch(t *testing.T) {
+	db := sql.InMemory()
+
+	var expected []*types.VerifiedActivationTx
+	for i := 0; i < 4; i++ {
+		sig, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		atx, err := newAtx(sig, withPublishEpoch(2))
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, atx))
+		expected = append(expected, atx)
+	}
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	other, err := newAtx(sig, withPublishEpoch(3))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, other))
+
+	t.Run("ids", func(t *testing.T) {
+		var ids []types.ATXID
+		require.NoError(t, atxs.IterateIDsByEpoch(db, 2, func(id types.ATXID) bool {
+			ids = append(ids, id)
+			return true
+		}))
+		require.Len(t, ids, len(expected))
+		for _, atx := range expected {
+			require.Contains(t, ids, atx.ID())
+		}
+	})
+	t.Run("headers", func(t *testing.T) {
+		headers := map[types.ATXID]*types.ActivationTxHeader{}
+		require.NoError(t, atxs.IterateHeadersByEpoch(db, 2, func(header *types.ActivationTxHeader) bool {
+			headers[header.ID] = header
+			return true
+		}))
+		require.Len(t, headers, len(expected))
+		for _, atx := range expected {
+			// fields that are stored only in the blob are not loaded
+			header := atx.ToHeader()
+			header.PrevATXID = types.EmptyATXID
+			header.PositioningATX = types.EmptyATXID
+			header.InitialPost = nil
+			header.NumUnits = header.EffectiveNumUnits
+			require.Equal(t, header, headers[atx.ID()])
+		}
+	})
+	t.Run("stop", func(t *testing.T) {
+		calls := 0
+		require.NoError(t, atxs.IterateIDsByEpoch(db, 2, func(types.ATXID) bool {
+			calls++
+			return false
+		}))
+		require.Equal(t, 1, calls)
+		calls = 0
+		require.NoError(t, atxs.IterateHeadersByEpoch(db, 2, func(*types.ActivationTxHeader) bool {
+			calls++
+			return calls < 2
+		}))
+		require.Equal(t, 2, calls)
+	})
+	t.Run("empty epoch", func(t *testing.T) {
+		require.NoError(t, atxs.IterateIDsByEpoch(db, 1, func(types.ATXID) bool {
+			require.Fail(t, "no atxs in the epoch")
+			return true
+		}))
+	})
+}
+
 func TestGetIDsByEpochCached(t *testing.T) {
 	db := sql.InMemory(sql.WithQueryCache(true))
 	ctx := context.Background()
@@ -447,13 +519,13 @@ func TestGetIDsByEpochCached(t *testing.T) {
 		require.NoError(t, atxs.Add(db, atx))
 	}
 
-	require.Equal(t, 4, db.QueryCount())
+	require.Equal(t, 8, db.QueryCount())
 
 	for i := 0; i < 3; i++ {
 		ids1, err := atxs.GetIDsByEpoch(ctx, db, e1)
 		require.NoError(t, err)
 		require.ElementsMatch(t, []types.ATXID{atx1.ID()}, ids1)
-		require.Equal(t, 5, db.QueryCount())
+		require.Equal(t, 9, db.QueryCount())
 	}
 
 	for i := 0; i < 3; i++ {
@@ -461,26 +533,26 @@ func TestGetIDsByEpochCached(t *testing.T) {
 		require.NoError(t, err)
 		require.Contains(t, ids2, atx2.ID())
 		require.Contains(t, ids2, atx3.ID())
-		require.Equal(t, 6, db.QueryCount())
+		require.Equal(t, 10, db.QueryCount())
 	}
 
 	for i := 0; i < 3; i++ {
 		ids3, err := atxs.GetIDsByEpoch(ctx, db, e3)
 		require.NoError(t, err)
 		require.ElementsMatch(t, []types.ATXID{atx4.ID()}, ids3)
-		require.Equal(t, 7, db.QueryCount())
+		require.Equal(t, 11, db.QueryCount())
 	}
 
 	require.NoError(t, db.WithTx(context.Background(), func(tx *sql.Tx) error {
 		atxs.Add(tx, atx5)
 		return nil
 	}))
-	require.Equal(t, 8, db.QueryCount())
+	require.Equal(t, 13, db.QueryCount())
 
 	ids3, err := atxs.GetIDsByEpoch(ctx, db, e3)
 	require.NoError(t, err)
 	require.ElementsMatch(t, []types.ATXID{atx4.ID(), atx5.ID()}, ids3)
-	require.Equal(t, 8, db.QueryCount()) // not incremented after Add
+	require.Equal(t, 13, db.QueryCount()) // not incremented after Add
 
 	require.Error(t, db.WithTx(context.Background(), func(tx *sql.Tx) error {
 		atxs.Add(tx, atx6)
@@ -491,7 +563,7 @@ func TestGetIDsByEpochCached(t *testing.T) {
 	ids4, err := atxs.GetIDsByEpoch(ctx, db, e3)
 	require.NoError(t, err)
 	require.ElementsMatch(t, []types.ATXID{atx4.ID(), atx5.ID()}, ids4)
-	require.Equal(t, 10, db.QueryCount()) // not incremented after Add
+	require.Equal(t, 16, db.QueryCount()) // not incremented after Add
 }
 
 func TestVRFNonce(t *testing.T) {
@@ -543,6 +615,57 @@ func TestVRFNonce(t *testing.T) {
 	require.ErrorIs(t, err, sql.ErrNotFound)
 }
 
+func TestVRFKey(t *testing.T) {
+	db := sql.InMemory()
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+
+	atx1, err := newAtx(sig, withPublishEpoch(20), withVersion(types.AtxV2))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, atx1))
+
+	key1 := sig.VRFKey(1)
+	atx2, err := newAtx(sig, withPublishEpoch(30), withVersion(types.AtxV2), withVRFKey(key1))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, atx2))
+
+	atx3, err := newAtx(sig, withPublishEpoch(40), withVersion(types.AtxV2))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, atx3))
+
+	key2 := sig.VRFKey(2)
+	atx4, err := newAtx(sig, withPublishEpoch(50), withVersion(types.AtxV2), withVRFKey(key2))
+	require.NoError(t, err)
+	require.NoError(t, atxs.Add(db, atx4))
+
+	for _, tc := range []struct {
+		epoch types.EpochID
+		key   types.NodeID
+	}{
+		{epoch: atx1.TargetEpoch(), key: sig.NodeID()},
+		{epoch: atx2.PublishEpoch, key: sig.NodeID()},
+		{epoch: atx2.TargetEpoch(), key: key1},
+		{epoch: atx3.TargetEpoch(), key: key1},
+		{epoch: atx4.TargetEpoch() + 10, key: key2},
+	} {
+		got, err := atxs.VRFKey(db, sig.NodeID(), tc.epoch)
+		require.NoError(t, err)
+		require.Equal(t, tc.key, got, "epoch %d", tc.epoch)
+	}
+
+	stored, err := atxs.Get(db, atx2.ID())
+	require.NoError(t, err)
+	require.Equal(t, &key1, stored.VRFKey())
+
+	var rotated []types.NodeID
+	require.NoError(t, atxs.IterateVRFKeys(db, sig.NodeID(), func(publish types.EpochID, key types.NodeID) bool {
+		rotated = append(rotated, key)
+		return true
+	}))
+	require.Equal(t, []types.NodeID{key1, key2}, rotated)
+}
+
 func TestGetBlob(t *testing.T) {
 	db := sql.InMemory()
 	ctx := context.Background()
@@ -560,6 +683,28 @@ func TestGetBlob(t *testing.T) {
 	require.Equal(t, encoded, buf)
 }
 
+func TestGetV2(t *testing.T) {
+	db := sql.InMemory()
+	ctx := context.Background()
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	atx, err := newAtx(sig, withPublishEpoch(1), withVersion(types.AtxV2))
+	require.NoError(t, err)
+
+	require.NoError(t, atxs.Add(db, atx))
+	got, err := atxs.Get(db, atx.ID())
+	require.NoError(t, err)
+	require.Equal(t, atx, got)
+	require.Equal(t, types.AtxV2, got.Version())
+
+	buf, err := atxs.GetBlob(ctx, db, atx.ID().Bytes())
+	require.NoError(t, err)
+	encoded, err := codec.Encode(atx.ToV2())
+	require.NoError(t, err)
+	require.Equal(t, encoded, buf)
+}
+
 func TestGetBlobCached(t *testing.T) {
 	db := sql.InMemory(sql.WithQueryCache(true))
 	ctx := context.Background()
@@ -572,13 +717,13 @@ func TestGetBlobCached(t *testing.T) {
 	require.NoError(t, atxs.Add(db, atx))
 	encoded, err := codec.Encode(atx.ActivationTx)
 	require.NoError(t, err)
-	require.Equal(t, 1, db.QueryCount())
+	require.Equal(t, 2, db.QueryCount())
 
 	for i := 0; i < 3; i++ {
 		buf, err := atxs.GetBlob(ctx, db, atx.ID().Bytes())
 		require.NoError(t, err)
 		require.Equal(t, encoded, buf)
-		require.Equal(t, 2, db.QueryCount())
+		require.Equal(t, 3, db.QueryCount())
 	}
 }
 
@@ -607,21 +752,21 @@ func TestCachedBlobEviction(t *testing.T) {
 		require.Equal(t, encoded, buf)
 	}
 
-	require.Equal(t, 22, db.QueryCount())
+	require.Equal(t, 33, db.QueryCount())
 
 	// The ATXs except the first one stay in place
 	for n, atx := range addedATXs[1:] {
 		buf, err := atxs.GetBlob(ctx, db, atx.ID().Bytes())
 		require.NoError(t, err)
 		require.Equal(t, blobs[n+1], buf)
-		require.Equal(t, 22, db.QueryCount())
+		require.Equal(t, 33, db.QueryCount())
 	}
 
 	// The first ATX is evicted. We check it after the loop to avoid additional evictions.
 	buf, err := atxs.GetBlob(ctx, db, addedATXs[0].ID().Bytes())
 	require.NoError(t, err)
 	require.Equal(t, blobs[0], buf)
-	require.Equal(t, 23, db.QueryCount())
+	require.Equal(t, 34, db.QueryCount())
 }
 
 func TestCheckpointATX(t *testing.T) {
@@ -669,6 +814,13 @@ func TestCheckpointATX(t *testing.T) {
 	blob, err := atxs.GetBlob(ctx, db, catx.ID.Bytes())
 	require.NoError(t, err)
 	require.Nil(t, blob)
+
+	var header *types.ActivationTxHeader
+	require.NoError(t, atxs.IterateHeadersByEpoch(db, catx.Epoch, func(h *types.ActivationTxHeader) bool {
+		header = h
+		return true
+	}))
+	require.Equal(t, got.ToHeader(), header)
 }
 
 func TestAdd(t *testing.T) {
@@ -691,6 +843,52 @@ func TestAdd(t *testing.T) {
 	require.Equal(t, atx, got)
 }
 
+func TestIterateEquivocations(t *testing.T) {
+	db := sql.InMemory()
+	equivocations := func(epoch types.EpochID) [][2]types.ATXID {
+		var rst [][2]types.ATXID
+		require.NoError(t, atxs.IterateEquivocations(db, epoch, func(first, second types.ATXID) bool {
+			rst = append(rst, [2]types.ATXID{first, second})
+			return true
+		}))
+		return rst
+	}
+	add := func(sig *signing.EdSigner, opts ...createAtxOpt) types.ATXID {
+		atx, err := newAtx(sig, opts...)
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, atx))
+		return atx.ID()
+	}
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	first := add(sig, withPublishEpoch(1), withSequence(1))
+	second := add(sig, withPublishEpoch(1), withSequence(2))
+	add(sig, withPublishEpoch(2), withSequence(3))
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+
+	// checkpointed atxs don't have blobs to prove an equivocation with
+	checkpointed, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	add(checkpointed, withPublishEpoch(1))
+	require.NoError(t, atxs.AddCheckpointed(db, &atxs.CheckpointAtx{
+		ID:        types.RandomATXID(),
+		Epoch:     1,
+		SmesherID: checkpointed.NodeID(),
+	}))
+
+	malicious, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	add(malicious, withPublishEpoch(1), withSequence(1))
+	add(malicious, withPublishEpoch(1), withSequence(2))
+	require.NoError(t, identities.SetMalicious(db, malicious.NodeID(), []byte("proof"), time.Now()))
+
+	require.Equal(t, [][2]types.ATXID{{first, second}}, equivocations(1))
+	require.Empty(t, equivocations(2))
+}
+
 type createAtxOpt func(*types.ActivationTx)
 
 func withPublishEpoch(epoch types.EpochID) createAtxOpt {
@@ -699,6 +897,18 @@ func withPublishEpoch(epoch types.EpochID) createAtxOpt {
 	}
 }
 
+func withVersion(version types.AtxVersion) createAtxOpt {
+	return func(atx *types.ActivationTx) {
+		atx.SetVersion(version)
+	}
+}
+
+func withVRFKey(key types.NodeID) createAtxOpt {
+	return func(atx *types.ActivationTx) {
+		atx.SetVRFKey(&key)
+	}
+}
+
 func withSequence(seq uint64) createAtxOpt {
 	return func(atx *types.ActivationTx) {
 		atx.Sequence = seq
@@ -914,3 +1124,147 @@ func TestLatest(t *testing.T) {
 		})
 	}
 }
+
+func TestWeightsByTargetEpoch(t *testing.T) {
+	db := sql.InMemory()
+	sig1, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	sig2, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	sig3, err := signing.NewEdSigner()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		signer   *signing.EdSigner
+		epoch    types.EpochID
+		coinbase types.Address
+		units    uint32
+	}{
+		{sig1, 1, types.Address{1}, 2},
+		{sig1, 2, types.Address{1}, 3},
+		{sig2, 2, types.Address{1}, 4},
+		{sig3, 2, types.Address{2}, 5},
+		{sig1, 3, types.Address{1}, 6},
+	} {
+		tc := tc
+		atx, err := newAtx(tc.signer, withPublishEpoch(tc.epoch), func(atx *types.ActivationTx) {
+			atx.Coinbase = tc.coinbase
+			atx.NumUnits = tc.units
+		})
+		require.NoError(t, err)
+		require.NoError(t, atxs.Add(db, atx))
+	}
+
+	id := sig1.NodeID()
+	weights, err := atxs.WeightsByTargetEpoch(db, nil, &id, 2, 3)
+	require.NoError(t, err)
+	require.Equal(t, []atxs.EpochWeight{
+		{SmesherID: id, Epoch: 2, Weight: 2},
+		{SmesherID: id, Epoch: 3, Weight: 3},
+	}, weights)
+
+	coinbase := types.Address{1}
+	weights, err = atxs.WeightsByTargetEpoch(db, &coinbase, nil, 3, 3)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []atxs.EpochWeight{
+		{SmesherID: sig1.NodeID(), Epoch: 3, Weight: 3},
+		{SmesherID: sig2.NodeID(), Epoch: 3, Weight: 4},
+	}, weights)
+
+	_, err = atxs.WeightsByTargetEpoch(db, nil, nil, 1, 2)
+	require.Error(t, err)
+
+	total, err := atxs.TotalWeightByTargetEpoch(db, 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, map[types.EpochID]uint64{2: 2, 3: 12, 4: 6}, total)
+}
+
+func TestMigration0026(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "state.sql")
+	migrations, err := sql.StateMigrations()
+	require.NoError(t, err)
+	before := slices.IndexFunc(migrations, func(m sql.Migration) bool { return m.Order() > 25 })
+	db, err := sql.Open("file:"+dbFile, sql.WithMigrations(migrations[:before]))
+	require.NoError(t, err)
+
+	sig, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	atx, err := newAtx(sig, withPublishEpoch(1))
+	require.NoError(t, err)
+	blob, err := atx.Blob()
+	require.NoError(t, err)
+	_, err = db.Exec(`insert into atxs (id, epoch, effective_num_units, pubkey, atx, received,
+		base_tick_height, tick_count, sequence, coinbase, validity, version)
+		values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12);`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, atx.ID().Bytes())
+			stmt.BindInt64(2, int64(atx.PublishEpoch))
+			stmt.BindInt64(3, int64(atx.EffectiveNumUnits()))
+			stmt.BindBytes(4, atx.SmesherID.Bytes())
+			stmt.BindBytes(5, blob)
+			stmt.BindInt64(6, atx.Received().UnixNano())
+			stmt.BindInt64(7, int64(atx.BaseTickHeight()))
+			stmt.BindInt64(8, int64(atx.TickCount()))
+			stmt.BindInt64(9, int64(atx.Sequence))
+			stmt.BindBytes(10, atx.Coinbase.Bytes())
+			stmt.BindInt64(11, int64(atx.Validity()))
+			stmt.BindInt64(12, int64(atx.Version()))
+		}, nil)
+	require.NoError(t, err)
+	// checkpointed atx is inserted as it was stored by the version before the migration
+	catx := &atxs.CheckpointAtx{ID: types.RandomATXID(), Epoch: 1, NumUnits: 3, TickCount: 1}
+	_, err = db.Exec(`insert into atxs (id, epoch, effective_num_units, commitment_atx, nonce,
+		base_tick_height, tick_count, sequence, pubkey, coinbase, received)
+		values (?1, ?2, ?3, ?4, 0, 0, ?5, 0, ?6, ?7, 0);`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, catx.ID.Bytes())
+			stmt.BindInt64(2, int64(catx.Epoch))
+			stmt.BindInt64(3, int64(catx.NumUnits))
+			stmt.BindBytes(4, catx.CommitmentATX.Bytes())
+			stmt.BindInt64(5, int64(catx.TickCount))
+			stmt.BindBytes(6, catx.SmesherID.Bytes())
+			stmt.BindBytes(7, catx.Coinbase.Bytes())
+		}, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = sql.Open("file:" + dbFile)
+	require.NoError(t, err)
+	defer db.Close()
+
+	got, err := atxs.GetBlob(context.Background(), db, atx.ID().Bytes())
+	require.NoError(t, err)
+	require.Equal(t, blob, got)
+	full, err := atxs.Get(db, atx.ID())
+	require.NoError(t, err)
+	require.Equal(t, atx, full)
+	got, err = atxs.GetBlob(context.Background(), db, catx.ID.Bytes())
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func BenchmarkIterateEpoch(b *testing.B) {
+	db := sql.InMemory()
+	for i := 0; i < 1000; i++ {
+		sig, err := signing.NewEdSigner()
+		require.NoError(b, err)
+		atx, err := newAtx(sig, withPublishEpoch(1))
+		require.NoError(b, err)
+		require.NoError(b, atxs.Add(db, atx))
+	}
+	b.Run("headers", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, atxs.IterateHeadersByEpoch(db, 1, func(*types.ActivationTxHeader) bool {
+				return true
+			}))
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		ops := builder.Operations{Filter: []builder.Op{{Field: builder.Epoch, Token: builder.Eq, Value: int64(1)}}}
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, atxs.IterateAtxsOps(db, ops, func(*types.VerifiedActivationTx) bool {
+				return true
+			}))
+		}
+	})
+}
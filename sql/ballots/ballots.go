@@ -9,6 +9,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/activesets"
 )
 
 func decodeBallot(id types.BallotID, pubkey, body *bytes.Reader, malicious bool) (*types.Ballot, error) {
@@ -37,7 +38,17 @@ func decodeBallot(id types.BallotID, pubkey, body *bytes.Reader, malicious bool)
 }
 
 // Add ballot to the database.
+// The active set of the ballot is stored separately in the activesets table, the stored ballot references it
+// with the hash in the epoch data.
 func Add(db sql.Executor, ballot *types.Ballot) error {
+	if ballot.EpochData != nil && len(ballot.ActiveSet) > 0 {
+		if err := addActiveSet(db, ballot); err != nil {
+			return err
+		}
+		compact := *ballot
+		compact.ActiveSet = nil
+		ballot = &compact
+	}
 	bytes, err := codec.Encode(ballot)
 	if err != nil {
 		return fmt.Errorf("encode ballot %s: %w", ballot.ID(), err)
@@ -57,6 +68,21 @@ func Add(db sql.Executor, ballot *types.Ballot) error {
 	return nil
 }
 
+func addActiveSet(db sql.Executor, ballot *types.Ballot) error {
+	id := ballot.EpochData.ActiveSetHash
+	exists, err := activesets.Has(db, id[:])
+	if err != nil || exists {
+		return err
+	}
+	if err := activesets.Add(db, id, &types.EpochActiveSet{
+		Epoch: ballot.Layer.GetEpoch(),
+		Set:   ballot.ActiveSet,
+	}); err != nil {
+		return fmt.Errorf("add active set of ballot %s: %w", ballot.ID(), err)
+	}
+	return nil
+}
+
 // Has a ballot in the database.
 func Has(db sql.Executor, id types.BallotID) (bool, error) {
 	rows, err := db.Exec("select 1 from ballots where id = ?1;",
@@ -169,6 +195,28 @@ func IDsInLayer(db sql.Executor, lid types.LayerID) (rst []types.BallotID, err e
 	return rst, err
 }
 
+// IterateEquivocations iterates over the pairs of ballots in the layer signed by the same identity,
+// iteration stops if fn returns false. fn must not query the database.
+// Identities that are already known to be malicious are skipped.
+func IterateEquivocations(db sql.Executor, lid types.LayerID, fn func(first, second types.BallotID) bool) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(lid))
+	}
+	dec := func(stmt *sql.Statement) bool {
+		var first, second types.BallotID
+		stmt.ColumnBytes(0, first[:])
+		stmt.ColumnBytes(1, second[:])
+		return fn(first, second)
+	}
+	if _, err := db.Exec(`select a.id, b.id from ballots a
+		join ballots b on a.layer = b.layer and a.pubkey = b.pubkey and a.id < b.id
+		where a.layer = ?1
+		and not exists (select 1 from identities i where i.pubkey = a.pubkey);`, enc, dec); err != nil {
+		return fmt.Errorf("iterate equivocations in layer %v: %w", lid, err)
+	}
+	return nil
+}
+
 // LayerBallotByNodeID returns any ballot by the specified NodeID in a given layer.
 func LayerBallotByNodeID(db sql.Executor, lid types.LayerID, nodeID types.NodeID) (*types.Ballot, error) {
 	var (
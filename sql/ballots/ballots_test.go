@@ -11,6 +11,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/activesets"
 	"github.com/spacemeshos/go-spacemesh/sql/atxs"
 	"github.com/spacemeshos/go-spacemesh/sql/identities"
 )
@@ -83,6 +84,28 @@ func TestAdd(t *testing.T) {
 	require.True(t, stored.IsMalicious())
 }
 
+func TestAddActiveSet(t *testing.T) {
+	db := sql.InMemory()
+	set := types.RandomActiveSet(199)
+	hash := types.RandomHash()
+	for i := byte(1); i <= 2; i++ {
+		ballot := types.NewExistingBallot(types.BallotID{i}, types.RandomEdSignature(), types.RandomNodeID(), 8)
+		ballot.EpochData = &types.EpochData{ActiveSetHash: hash}
+		ballot.ActiveSet = set
+		require.NoError(t, Add(db, &ballot))
+		require.Equal(t, set, ballot.ActiveSet)
+
+		got, err := Get(db, ballot.ID())
+		require.NoError(t, err)
+		require.Empty(t, got.ActiveSet)
+		require.Equal(t, hash, got.EpochData.ActiveSetHash)
+	}
+	stored, err := activesets.Get(db, hash)
+	require.NoError(t, err)
+	require.Equal(t, set, stored.Set)
+	require.Equal(t, types.LayerID(8).GetEpoch(), stored.Epoch)
+}
+
 func TestUpdateBlob(t *testing.T) {
 	db := sql.InMemory()
 	nodeID := types.RandomNodeID()
@@ -90,8 +113,9 @@ func TestUpdateBlob(t *testing.T) {
 	ballot.EpochData = &types.EpochData{
 		ActiveSetHash: types.RandomHash(),
 	}
-	ballot.ActiveSet = types.RandomActiveSet(199)
 	require.NoError(t, Add(db, &ballot))
+	ballot.ActiveSet = types.RandomActiveSet(199)
+	require.NoError(t, UpdateBlob(db, types.BallotID{1}, codec.MustEncode(&ballot)))
 	got, err := Get(db, types.BallotID{1})
 	require.NoError(t, err)
 	require.Equal(t, ballot, *got)
@@ -163,6 +187,35 @@ func TestLayerBallotBySmesher(t *testing.T) {
 	require.Equal(t, ballots[1], *prev)
 }
 
+func TestIterateEquivocations(t *testing.T) {
+	db := sql.InMemory()
+	lid := types.LayerID(1)
+	nodeID := types.RandomNodeID()
+	malicious := types.RandomNodeID()
+	for _, ballot := range []types.Ballot{
+		types.NewExistingBallot(types.BallotID{1}, types.EmptyEdSignature, nodeID, lid),
+		types.NewExistingBallot(types.BallotID{2}, types.EmptyEdSignature, nodeID, lid),
+		types.NewExistingBallot(types.BallotID{3}, types.EmptyEdSignature, nodeID, lid.Add(1)),
+		types.NewExistingBallot(types.BallotID{4}, types.EmptyEdSignature, types.RandomNodeID(), lid),
+		types.NewExistingBallot(types.BallotID{5}, types.EmptyEdSignature, malicious, lid),
+		types.NewExistingBallot(types.BallotID{6}, types.EmptyEdSignature, malicious, lid),
+	} {
+		require.NoError(t, Add(db, &ballot))
+	}
+	require.NoError(t, identities.SetMalicious(db, malicious, []byte("proof"), time.Now()))
+
+	equivocations := func(lid types.LayerID) [][2]types.BallotID {
+		var rst [][2]types.BallotID
+		require.NoError(t, IterateEquivocations(db, lid, func(first, second types.BallotID) bool {
+			rst = append(rst, [2]types.BallotID{first, second})
+			return true
+		}))
+		return rst
+	}
+	require.Equal(t, [][2]types.BallotID{{{1}, {2}}}, equivocations(lid))
+	require.Empty(t, equivocations(lid.Add(1)))
+}
+
 func newAtx(signer *signing.EdSigner, layerID types.LayerID) (*types.VerifiedActivationTx, error) {
 	atx := &types.ActivationTx{
 		InnerActivationTx: types.InnerActivationTx{
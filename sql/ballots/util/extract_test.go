@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
 	"github.com/spacemeshos/go-spacemesh/sql/activesets"
@@ -18,6 +19,16 @@ func TestMain(m *testing.M) {
 	os.Exit(res)
 }
 
+// addWithActiveSet stores the ballot with the embedded active set, as ballots were stored before
+// active sets were moved to the activesets table.
+func addWithActiveSet(tb testing.TB, db sql.Executor, ballot *types.Ballot) {
+	tb.Helper()
+	stored := *ballot
+	stored.ActiveSet = nil
+	require.NoError(tb, ballots.Add(db, &stored))
+	require.NoError(tb, ballots.UpdateBlob(db, ballot.ID(), codec.MustEncode(ballot)))
+}
+
 func TestExtractActiveSet(t *testing.T) {
 	db := sql.InMemory()
 	current := types.LayerID(20)
@@ -38,7 +49,7 @@ func TestExtractActiveSet(t *testing.T) {
 			}
 			blt.ActiveSet = actives[1]
 		}
-		require.NoError(t, ballots.Add(db, &blt))
+		addWithActiveSet(t, db, &blt)
 		blts = append(blts, &blt)
 	}
 	require.NoError(t, ExtractActiveSet(db))
@@ -0,0 +1,136 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+// ballotsMigrationBatch is the number of ballots that are decoded and rewritten at once.
+const ballotsMigrationBatch = 1000
+
+// ballotsMigration moves active sets embedded in historical ballots into the activesets table.
+// Ballots are rewritten to reference the sets with the hash in the epoch data. Ballots are decoded
+// to find the sets, therefore the migration is not a plain sql file.
+type ballotsMigration struct{}
+
+func (ballotsMigration) Name() string {
+	return "0021_ballots_activesets"
+}
+
+func (ballotsMigration) Order() int {
+	return 21
+}
+
+func (ballotsMigration) Rollback() error {
+	// handled by the DB itself
+	return nil
+}
+
+func (ballotsMigration) Apply(db Executor) error {
+	last := []byte{} // sorts before all ballot ids
+	for {
+		batch, next, err := ballotsWithActiveSets(db, last)
+		if err != nil {
+			return err
+		}
+		for _, ballot := range batch {
+			if err := extractActiveSet(db, ballot); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		last = next
+	}
+}
+
+// ballotsWithActiveSets decodes the batch of ballots ordered by id after the last one and returns
+// the ballots with embedded active sets and the id of the last ballot in the batch.
+// The returned id is nil if there are no more ballots.
+func ballotsWithActiveSets(db Executor, last []byte) ([]*types.Ballot, []byte, error) {
+	var (
+		rst  []*types.Ballot
+		next []byte
+		ierr error
+		rows int
+	)
+	_, err := db.Exec(`select id, ballot from ballots
+		where layer >= ?1 and id > ?2
+		order by id limit ?3;`,
+		func(stmt *Statement) {
+			stmt.BindInt64(1, int64(types.EpochID(2).FirstLayer()))
+			stmt.BindBytes(2, last)
+			stmt.BindInt64(3, ballotsMigrationBatch)
+		},
+		func(stmt *Statement) bool {
+			rows++
+			next = make([]byte, stmt.ColumnLen(0))
+			stmt.ColumnBytes(0, next)
+			var ballot types.Ballot
+			if _, ierr = codec.DecodeFrom(stmt.ColumnReader(1), &ballot); ierr != nil {
+				ierr = fmt.Errorf("decode ballot %x: %w", next, ierr)
+				return false
+			}
+			if ballot.EpochData == nil || len(ballot.ActiveSet) == 0 {
+				return true
+			}
+			ballot.SetID(types.BallotID(next))
+			rst = append(rst, &ballot)
+			return true
+		},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("select ballots after %x: %w", last, err)
+	}
+	if ierr != nil {
+		return nil, nil, ierr
+	}
+	if rows < ballotsMigrationBatch {
+		next = nil
+	}
+	return rst, next, nil
+}
+
+// extractActiveSet stores the active set of the ballot, unless a set with the same hash is stored already,
+// and rewrites the ballot without the set.
+func extractActiveSet(db Executor, ballot *types.Ballot) error {
+	set := codec.MustEncode(&types.EpochActiveSet{
+		Epoch: ballot.Layer.GetEpoch(),
+		Set:   ballot.ActiveSet,
+	})
+	blob := hash.Sum(set)
+	if _, err := db.Exec(`insert into blobs (hash, data) values (?1, ?2)
+		on conflict (hash) do nothing;`,
+		func(stmt *Statement) {
+			stmt.BindBytes(1, blob[:])
+			stmt.BindBytes(2, set)
+		}, nil,
+	); err != nil {
+		return fmt.Errorf("add active set blob of ballot %s: %w", ballot.ID(), err)
+	}
+	id := ballot.EpochData.ActiveSetHash
+	if _, err := db.Exec(`insert into activesets (id, epoch, blob) values (?1, ?2, ?3)
+		on conflict (id) do nothing;`,
+		func(stmt *Statement) {
+			stmt.BindBytes(1, id[:])
+			stmt.BindInt64(2, int64(ballot.Layer.GetEpoch()))
+			stmt.BindBytes(3, blob[:])
+		}, nil,
+	); err != nil {
+		return fmt.Errorf("add active set %s of ballot %s: %w", id.ShortString(), ballot.ID(), err)
+	}
+	ballot.ActiveSet = nil
+	if _, err := db.Exec("update ballots set ballot = ?2 where id = ?1;",
+		func(stmt *Statement) {
+			stmt.BindBytes(1, ballot.ID().Bytes())
+			stmt.BindBytes(2, codec.MustEncode(ballot))
+		}, nil,
+	); err != nil {
+		return fmt.Errorf("update ballot %s: %w", ballot.ID(), err)
+	}
+	return nil
+}
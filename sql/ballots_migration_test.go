@@ -0,0 +1,115 @@
+package sql
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+func TestBallotsMigration(t *testing.T) {
+	types.SetLayersPerEpoch(10)
+	migrations, err := StateMigrations()
+	require.NoError(t, err)
+	dbFile := filepath.Join(t.TempDir(), "test.sql")
+	before := slices.IndexFunc(migrations, func(m Migration) bool { return m.Order() >= ballotsMigration{}.Order() })
+	db, err := Open("file:"+dbFile, WithMigrations(migrations[:before]))
+	require.NoError(t, err)
+
+	add := func(ballot *types.Ballot) {
+		_, err := db.Exec("insert into ballots (id, atx, layer, pubkey, ballot) values (?1, ?2, ?3, ?4, ?5);",
+			func(stmt *Statement) {
+				stmt.BindBytes(1, ballot.ID().Bytes())
+				stmt.BindBytes(2, ballot.AtxID.Bytes())
+				stmt.BindInt64(3, int64(ballot.Layer))
+				stmt.BindBytes(4, ballot.SmesherID.Bytes())
+				stmt.BindBytes(5, codec.MustEncode(ballot))
+			}, nil)
+		require.NoError(t, err)
+	}
+	get := func(id types.BallotID) *types.Ballot {
+		var ballot types.Ballot
+		rows, err := db.Exec("select ballot from ballots where id = ?1;",
+			func(stmt *Statement) {
+				stmt.BindBytes(1, id.Bytes())
+			}, func(stmt *Statement) bool {
+				_, err := codec.DecodeFrom(stmt.ColumnReader(0), &ballot)
+				require.NoError(t, err)
+				return true
+			})
+		require.NoError(t, err)
+		require.Equal(t, 1, rows)
+		return &ballot
+	}
+
+	set := types.RandomActiveSet(10)
+	setHash := types.RandomHash()
+	// ballots span several batches, every first ballot of the smesher in the epoch embeds the set
+	var withSet, withoutSet []types.BallotID
+	for i := 0; i < 2*ballotsMigrationBatch+1; i++ {
+		lid := types.EpochID(2).FirstLayer() + types.LayerID(i%10)
+		ballot := types.NewExistingBallot(types.RandomBallotID(), types.RandomEdSignature(), types.RandomNodeID(), lid)
+		if i%100 == 0 {
+			ballot.EpochData = &types.EpochData{ActiveSetHash: setHash}
+			ballot.ActiveSet = set
+			withSet = append(withSet, ballot.ID())
+		} else {
+			withoutSet = append(withoutSet, ballot.ID())
+		}
+		add(&ballot)
+	}
+	// ballots before the second epoch are not migrated
+	genesis := types.NewExistingBallot(types.RandomBallotID(), types.RandomEdSignature(), types.RandomNodeID(), 1)
+	genesis.EpochData = &types.EpochData{ActiveSetHash: types.RandomHash()}
+	genesis.ActiveSet = types.RandomActiveSet(3)
+	add(&genesis)
+	require.NoError(t, db.Close())
+
+	db, err = Open("file:"+dbFile, WithMigrations(migrations))
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, id := range withSet {
+		got := get(id)
+		require.Empty(t, got.ActiveSet)
+		require.Equal(t, setHash, got.EpochData.ActiveSetHash)
+	}
+	for _, id := range withoutSet {
+		require.Nil(t, get(id).EpochData)
+	}
+	require.Equal(t, genesis.ActiveSet, get(genesis.ID()).ActiveSet)
+
+	var (
+		epoch int64
+		blob  types.Hash32
+	)
+	rows, err := db.Exec("select epoch, blob from activesets where id = ?1;",
+		func(stmt *Statement) {
+			stmt.BindBytes(1, setHash[:])
+		}, func(stmt *Statement) bool {
+			epoch = stmt.ColumnInt64(0)
+			stmt.ColumnBytes(1, blob[:])
+			return true
+		})
+	require.NoError(t, err)
+	require.Equal(t, 1, rows)
+	require.Equal(t, int64(2), epoch)
+	encoded := codec.MustEncode(&types.EpochActiveSet{Epoch: 2, Set: set})
+	require.Equal(t, hash.Sum(encoded), [32]byte(blob))
+
+	var refs int
+	_, err = db.Exec("select refs from blobs where hash = ?1;",
+		func(stmt *Statement) {
+			stmt.BindBytes(1, blob[:])
+		}, func(stmt *Statement) bool {
+			refs = stmt.ColumnInt(0)
+			return true
+		})
+	require.NoError(t, err)
+	require.Equal(t, 1, refs)
+}
@@ -2,6 +2,7 @@ package beacons
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -55,3 +56,166 @@ func Set(db sql.Executor, epoch types.EpochID, beacon types.Beacon) error {
 
 	return nil
 }
+
+// Fallback is the beacon that was signed by the quorum of trusted authorities, instead of
+// being computed by the beacon protocol.
+type Fallback struct {
+	Epoch  types.EpochID
+	Beacon types.Beacon
+	// Certificate is the encoded fallback beacon with signatures of the authorities.
+	Certificate []byte
+	Received    time.Time
+}
+
+// SetFallback records the fallback beacon for the epoch, replacing previously recorded fallback.
+func SetFallback(db sql.Executor, fallback *Fallback) error {
+	_, err := db.Exec(`insert into fallback_beacons (epoch, beacon, certificate, received)
+		values (?1, ?2, ?3, ?4)
+		on conflict (epoch) do update set beacon = ?2, certificate = ?3, received = ?4;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(fallback.Epoch))
+			stmt.BindBytes(2, fallback.Beacon.Bytes())
+			stmt.BindBytes(3, fallback.Certificate)
+			stmt.BindInt64(4, fallback.Received.UnixNano())
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert fallback beacon epoch %v: %w", fallback.Epoch, err)
+	}
+	return nil
+}
+
+// GetFallback returns the fallback beacon recorded for the epoch.
+func GetFallback(db sql.Executor, epoch types.EpochID) (*Fallback, error) {
+	var fallback *Fallback
+	rows, err := db.Exec(`select epoch, beacon, certificate, received from fallback_beacons where epoch = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+		},
+		func(stmt *sql.Statement) bool {
+			fallback = decodeFallback(stmt)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("fallback beacon epoch %v: %w", epoch, err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("fallback beacon epoch %v: %w", epoch, sql.ErrNotFound)
+	}
+	return fallback, nil
+}
+
+// Fallbacks returns all recorded fallback beacons in ascending order of epochs.
+func Fallbacks(db sql.Executor) ([]*Fallback, error) {
+	var rst []*Fallback
+	_, err := db.Exec(`select epoch, beacon, certificate, received from fallback_beacons order by epoch asc;`,
+		nil,
+		func(stmt *sql.Statement) bool {
+			rst = append(rst, decodeFallback(stmt))
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select fallback beacons: %w", err)
+	}
+	return rst, nil
+}
+
+func decodeFallback(stmt *sql.Statement) *Fallback {
+	fallback := &Fallback{
+		Epoch:       types.EpochID(stmt.ColumnInt64(0)),
+		Certificate: make([]byte, stmt.ColumnLen(2)),
+		Received:    time.Unix(0, stmt.ColumnInt64(3)),
+	}
+	stmt.ColumnBytes(1, fallback.Beacon[:])
+	stmt.ColumnBytes(2, fallback.Certificate)
+	return fallback
+}
+
+// Source is how the beacon for the epoch was obtained.
+type Source int
+
+const (
+	// SourceUnknown is the source of beacons recorded before the provenance was tracked.
+	SourceUnknown Source = iota
+	// SourceProtocol is the beacon computed by the beacon protocol.
+	SourceProtocol
+	// SourceBallots is the beacon adopted from ballots by the node that didn't run the protocol.
+	SourceBallots
+	// SourceFallback is the beacon signed by the quorum of authorities.
+	SourceFallback
+	// SourceBootstrap is the beacon from the bootstrap update.
+	SourceBootstrap
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceProtocol:
+		return "protocol"
+	case SourceBallots:
+		return "ballots"
+	case SourceFallback:
+		return "fallback"
+	case SourceBootstrap:
+		return "bootstrap"
+	default:
+		return "unknown"
+	}
+}
+
+// Provenance is the beacon for the epoch with the record of how it was obtained.
+type Provenance struct {
+	Epoch  types.EpochID
+	Beacon types.Beacon
+	Source Source
+	// Support is the weight that supported the beacon and Weight is the total weight it was selected from.
+	// For the protocol it is the weight of the voters in the last round out of the epoch weight,
+	// for ballots it is the weight of the ballots with the beacon out of the weight of all ballots.
+	// Both are zero for other sources.
+	Support uint64
+	Weight  uint64
+}
+
+// SetProvenance records how the beacon for the epoch was obtained. The beacon must be already stored.
+func SetProvenance(db sql.Executor, provenance *Provenance) error {
+	rows, err := db.Exec(`update beacons set source = ?2, support = ?3, weight = ?4
+		where epoch = ?1 and beacon = ?5 returning epoch;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(provenance.Epoch))
+			stmt.BindInt64(2, int64(provenance.Source))
+			stmt.BindInt64(3, int64(provenance.Support))
+			stmt.BindInt64(4, int64(provenance.Weight))
+			stmt.BindBytes(5, provenance.Beacon.Bytes())
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("set provenance epoch %v: %w", provenance.Epoch, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("set provenance epoch %v, beacon %v: %w", provenance.Epoch, provenance.Beacon, sql.ErrNotFound)
+	}
+	return nil
+}
+
+// Provenances returns beacons with their provenance for epochs in [from, to] in ascending order.
+func Provenances(db sql.Executor, from, to types.EpochID) ([]*Provenance, error) {
+	var rst []*Provenance
+	_, err := db.Exec(`select epoch, beacon, source, support, weight from beacons
+		where epoch between ?1 and ?2 order by epoch asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+			stmt.BindInt64(2, int64(to))
+		},
+		func(stmt *sql.Statement) bool {
+			provenance := &Provenance{
+				Epoch:   types.EpochID(stmt.ColumnInt64(0)),
+				Source:  Source(stmt.ColumnInt64(2)),
+				Support: uint64(stmt.ColumnInt64(3)),
+				Weight:  uint64(stmt.ColumnInt64(4)),
+			}
+			stmt.ColumnBytes(1, provenance.Beacon[:])
+			rst = append(rst, provenance)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select beacons in [%v, %v]: %w", from, to, err)
+	}
+	return rst, nil
+}
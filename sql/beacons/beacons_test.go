@@ -2,6 +2,7 @@ package beacons
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -69,3 +70,84 @@ func TestSet(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, fallbackBeacon, got)
 }
+
+func TestFallback(t *testing.T) {
+	db := sql.InMemory()
+
+	_, err := GetFallback(db, baseEpoch)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	all, err := Fallbacks(db)
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	fallbacks := []*Fallback{
+		{
+			Epoch:       baseEpoch + 1,
+			Beacon:      types.HexToBeacon("0x2"),
+			Certificate: []byte{2, 2},
+			Received:    time.Unix(0, 200),
+		},
+		{
+			Epoch:       baseEpoch,
+			Beacon:      types.HexToBeacon("0x1"),
+			Certificate: []byte{1, 1},
+			Received:    time.Unix(0, 100),
+		},
+	}
+	for _, fallback := range fallbacks {
+		require.NoError(t, SetFallback(db, fallback))
+	}
+	got, err := GetFallback(db, baseEpoch)
+	require.NoError(t, err)
+	require.Equal(t, fallbacks[1], got)
+
+	all, err = Fallbacks(db)
+	require.NoError(t, err)
+	require.Equal(t, []*Fallback{fallbacks[1], fallbacks[0]}, all)
+
+	// fallback is recorded separately from the beacon
+	_, err = Get(db, baseEpoch)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	replaced := &Fallback{
+		Epoch:       baseEpoch,
+		Beacon:      types.HexToBeacon("0x3"),
+		Certificate: []byte{3},
+		Received:    time.Unix(0, 300),
+	}
+	require.NoError(t, SetFallback(db, replaced))
+	got, err = GetFallback(db, baseEpoch)
+	require.NoError(t, err)
+	require.Equal(t, replaced, got)
+}
+
+func TestProvenance(t *testing.T) {
+	db := sql.InMemory()
+
+	provenance := &Provenance{
+		Epoch:   baseEpoch + 1,
+		Beacon:  types.HexToBeacon("0x2"),
+		Source:  SourceBallots,
+		Support: 60,
+		Weight:  100,
+	}
+	require.ErrorIs(t, SetProvenance(db, provenance), sql.ErrNotFound)
+
+	require.NoError(t, Add(db, baseEpoch, types.HexToBeacon("0x1")))
+	require.NoError(t, Add(db, provenance.Epoch, provenance.Beacon))
+	require.NoError(t, SetProvenance(db, provenance))
+	// provenance is not recorded for a different beacon
+	require.ErrorIs(t, SetProvenance(db, &Provenance{
+		Epoch:  provenance.Epoch,
+		Beacon: types.HexToBeacon("0x3"),
+		Source: SourceProtocol,
+	}), sql.ErrNotFound)
+
+	got, err := Provenances(db, baseEpoch, baseEpoch+2)
+	require.NoError(t, err)
+	require.Equal(t, []*Provenance{
+		{Epoch: baseEpoch, Beacon: types.HexToBeacon("0x1"), Source: SourceUnknown},
+		provenance,
+	}, got)
+	require.Equal(t, "ballots", got[1].Source.String())
+}
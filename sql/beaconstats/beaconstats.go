@@ -0,0 +1,163 @@
+// Package beaconstats stores statistics of the beacon protocol runs, recorded by nodes in observer mode.
+package beaconstats
+
+import (
+	"fmt"
+
+	sqlite "github.com/go-llsqlite/crawshaw"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Epoch is the statistics of the beacon protocol that was run in the epoch
+// to compute the beacon for the next epoch.
+type Epoch struct {
+	Epoch types.EpochID
+	// Weight is the total weight of the activations that are eligible to participate.
+	Weight uint64
+	// Proposals that were received, by validity.
+	Valid            uint32
+	PotentiallyValid uint32
+	Invalid          uint32
+	// Beacon is the beacon computed by the protocol. It is empty if the protocol failed.
+	Beacon types.Beacon
+	Rounds []Round
+}
+
+// Round is the statistics of votes in the round of the beacon protocol.
+type Round struct {
+	Round types.RoundID
+	// Votes is the number of voting messages received in the round, Weight is the weight of the voters.
+	Votes  uint32
+	Weight uint64
+	// Proposals by the local opinion after the votes were counted.
+	Support   uint32
+	Against   uint32
+	Undecided uint32
+	// Coin is the weak coin used for undecided proposals, nil if the coin wasn't computed in the round.
+	Coin *bool
+}
+
+// Add stores the statistics for the epoch, replacing previously stored statistics.
+func Add(db sql.Executor, stats *Epoch) error {
+	_, err := db.Exec(`insert into beacon_stats
+		(epoch, weight, valid_proposals, potentially_valid_proposals, invalid_proposals, beacon)
+		values (?1, ?2, ?3, ?4, ?5, ?6)
+		on conflict (epoch) do update set
+			weight = ?2, valid_proposals = ?3, potentially_valid_proposals = ?4,
+			invalid_proposals = ?5, beacon = ?6;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(stats.Epoch))
+			stmt.BindInt64(2, int64(stats.Weight))
+			stmt.BindInt64(3, int64(stats.Valid))
+			stmt.BindInt64(4, int64(stats.PotentiallyValid))
+			stmt.BindInt64(5, int64(stats.Invalid))
+			if stats.Beacon == types.EmptyBeacon {
+				stmt.BindNull(6)
+			} else {
+				stmt.BindBytes(6, stats.Beacon[:])
+			}
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert beacon stats for epoch %d: %w", stats.Epoch, err)
+	}
+	if _, err := db.Exec(`delete from beacon_round_stats where epoch = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(stats.Epoch))
+		}, nil); err != nil {
+		return fmt.Errorf("delete beacon round stats for epoch %d: %w", stats.Epoch, err)
+	}
+	for _, round := range stats.Rounds {
+		if _, err := db.Exec(`insert into beacon_round_stats
+			(epoch, round, votes, weight, support, against, undecided, coin)
+			values (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8);`,
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(stats.Epoch))
+				stmt.BindInt64(2, int64(round.Round))
+				stmt.BindInt64(3, int64(round.Votes))
+				stmt.BindInt64(4, int64(round.Weight))
+				stmt.BindInt64(5, int64(round.Support))
+				stmt.BindInt64(6, int64(round.Against))
+				stmt.BindInt64(7, int64(round.Undecided))
+				if round.Coin == nil {
+					stmt.BindNull(8)
+				} else {
+					stmt.BindBool(8, *round.Coin)
+				}
+			}, nil); err != nil {
+			return fmt.Errorf("insert beacon stats for epoch %d round %d: %w", stats.Epoch, round.Round, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the statistics for the epoch.
+func Get(db sql.Executor, epoch types.EpochID) (*Epoch, error) {
+	rst, err := Range(db, epoch, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if len(rst) == 0 {
+		return nil, fmt.Errorf("beacon stats for epoch %d: %w", epoch, sql.ErrNotFound)
+	}
+	return rst[0], nil
+}
+
+// Range returns the statistics for epochs in [from, to] in ascending order.
+func Range(db sql.Executor, from, to types.EpochID) ([]*Epoch, error) {
+	var (
+		rst    []*Epoch
+		byID   = map[types.EpochID]*Epoch{}
+		bindFn = func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+			stmt.BindInt64(2, int64(to))
+		}
+	)
+	_, err := db.Exec(`select epoch, weight, valid_proposals, potentially_valid_proposals, invalid_proposals, beacon
+		from beacon_stats where epoch between ?1 and ?2 order by epoch asc;`, bindFn,
+		func(stmt *sql.Statement) bool {
+			stats := &Epoch{
+				Epoch:            types.EpochID(stmt.ColumnInt64(0)),
+				Weight:           uint64(stmt.ColumnInt64(1)),
+				Valid:            uint32(stmt.ColumnInt64(2)),
+				PotentiallyValid: uint32(stmt.ColumnInt64(3)),
+				Invalid:          uint32(stmt.ColumnInt64(4)),
+			}
+			if stmt.ColumnLen(5) > 0 {
+				stmt.ColumnBytes(5, stats.Beacon[:])
+			}
+			rst = append(rst, stats)
+			byID[stats.Epoch] = stats
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select beacon stats in [%d, %d]: %w", from, to, err)
+	}
+	_, err = db.Exec(`select epoch, round, votes, weight, support, against, undecided, coin
+		from beacon_round_stats where epoch between ?1 and ?2 order by epoch asc, round asc;`, bindFn,
+		func(stmt *sql.Statement) bool {
+			stats, exists := byID[types.EpochID(stmt.ColumnInt64(0))]
+			if !exists {
+				return true
+			}
+			round := Round{
+				Round:     types.RoundID(stmt.ColumnInt64(1)),
+				Votes:     uint32(stmt.ColumnInt64(2)),
+				Weight:    uint64(stmt.ColumnInt64(3)),
+				Support:   uint32(stmt.ColumnInt64(4)),
+				Against:   uint32(stmt.ColumnInt64(5)),
+				Undecided: uint32(stmt.ColumnInt64(6)),
+			}
+			if stmt.ColumnType(7) != sqlite.SQLITE_NULL {
+				coin := stmt.ColumnInt(7) != 0
+				round.Coin = &coin
+			}
+			stats.Rounds = append(stats.Rounds, round)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select beacon round stats in [%d, %d]: %w", from, to, err)
+	}
+	return rst, nil
+}
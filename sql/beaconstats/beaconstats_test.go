@@ -0,0 +1,53 @@
+package beaconstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+func TestStats(t *testing.T) {
+	db := sql.InMemory()
+
+	_, err := Get(db, 1)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	heads, tails := true, false
+	var all []*Epoch
+	for epoch := types.EpochID(2); epoch <= 4; epoch++ {
+		stats := &Epoch{
+			Epoch:            epoch,
+			Weight:           1 << 40,
+			Valid:            10,
+			PotentiallyValid: 2,
+			Invalid:          uint32(epoch),
+			Rounds: []Round{
+				{Round: 0, Votes: 10, Weight: 1 << 39, Support: 10, Against: 2},
+				{Round: 1, Votes: 8, Weight: 1 << 38, Support: 9, Against: 1, Undecided: 2, Coin: &heads},
+				{Round: 2, Votes: 7, Weight: 1 << 37, Support: 9, Against: 2, Undecided: 1, Coin: &tails},
+			},
+		}
+		if epoch != 3 {
+			stats.Beacon = types.Beacon{byte(epoch), 1, 2, 3}
+		}
+		require.NoError(t, Add(db, stats))
+		all = append(all, stats)
+	}
+
+	got, err := Get(db, 3)
+	require.NoError(t, err)
+	require.Equal(t, all[1], got)
+
+	rst, err := Range(db, 3, 10)
+	require.NoError(t, err)
+	require.Equal(t, all[1:], rst)
+
+	replaced := &Epoch{Epoch: 3, Weight: 10, Beacon: types.Beacon{1}, Rounds: []Round{{Round: 0, Votes: 1}}}
+	require.NoError(t, Add(db, replaced))
+	got, err = Get(db, 3)
+	require.NoError(t, err)
+	require.Equal(t, replaced, got)
+}
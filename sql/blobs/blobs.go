@@ -0,0 +1,86 @@
+// Package blobs stores large values, such as poet proofs and active sets, once per content.
+//
+// Blobs are addressed by the hash of their content. Tables that reference blobs keep the hash
+// and maintain the reference count of the blob with triggers. Blobs that are not referenced
+// anymore are removed by GC.
+package blobs
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Hash returns the content address of the data.
+func Hash(data []byte) types.Hash32 {
+	return types.CalcHash32(data)
+}
+
+// Add stores the data if it is not stored yet and returns its hash.
+// The blob is not referenced until a row with the hash is added to the referencing table.
+func Add(db sql.Executor, data []byte) (types.Hash32, error) {
+	hash := Hash(data)
+	if _, err := db.Exec(`insert into blobs (hash, data) values (?1, ?2)
+		on conflict (hash) do nothing;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, hash[:])
+			stmt.BindBytes(2, data)
+		}, nil,
+	); err != nil {
+		return hash, fmt.Errorf("add blob %s: %w", hash, err)
+	}
+	return hash, nil
+}
+
+// Get returns the data of the blob.
+func Get(db sql.Executor, hash types.Hash32) ([]byte, error) {
+	var data []byte
+	rows, err := db.Exec("select data from blobs where hash = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, hash[:])
+		},
+		func(stmt *sql.Statement) bool {
+			data = make([]byte, stmt.ColumnLen(0))
+			stmt.ColumnBytes(0, data)
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get blob %s: %w", hash, err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("get blob %s: %w", hash, sql.ErrNotFound)
+	}
+	return data, nil
+}
+
+// Refs returns the number of rows that reference the blob.
+func Refs(db sql.Executor, hash types.Hash32) (int, error) {
+	var refs int
+	rows, err := db.Exec("select refs from blobs where hash = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, hash[:])
+		},
+		func(stmt *sql.Statement) bool {
+			refs = stmt.ColumnInt(0)
+			return true
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("refs of blob %s: %w", hash, err)
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("refs of blob %s: %w", hash, sql.ErrNotFound)
+	}
+	return refs, nil
+}
+
+// GC removes blobs that are not referenced and returns the number of removed blobs.
+func GC(db sql.Executor) (int, error) {
+	rows, err := db.Exec("delete from blobs where refs <= 0 returning hash;", nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("gc blobs: %w", err)
+	}
+	return rows, nil
+}
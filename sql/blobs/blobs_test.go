@@ -0,0 +1,55 @@
+package blobs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/activesets"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
+	"github.com/spacemeshos/go-spacemesh/sql/poets"
+)
+
+func TestBlobs_Dedup(t *testing.T) {
+	db := sql.InMemory()
+	set := &types.EpochActiveSet{Epoch: 3, Set: []types.ATXID{{1}, {2}}}
+	require.NoError(t, activesets.Add(db, types.Hash32{1}, set))
+	// the same set under a different id is stored once
+	require.NoError(t, activesets.Add(db, types.Hash32{2}, set))
+	proof := []byte("proof")
+	require.NoError(t, poets.Add(db, types.PoetProofRef{1}, proof, []byte("service"), "1"))
+	require.NoError(t, poets.Add(db, types.PoetProofRef{2}, proof, []byte("service"), "2"))
+
+	setHash := blobs.Hash(codec.MustEncode(set))
+	refs, err := blobs.Refs(db, setHash)
+	require.NoError(t, err)
+	require.Equal(t, 2, refs)
+	refs, err = blobs.Refs(db, blobs.Hash(proof))
+	require.NoError(t, err)
+	require.Equal(t, 2, refs)
+
+	got, err := poets.Get(db, types.PoetProofRef{2})
+	require.NoError(t, err)
+	require.Equal(t, proof, got)
+
+	removed, err := blobs.GC(db)
+	require.NoError(t, err)
+	require.Zero(t, removed)
+
+	require.NoError(t, activesets.DeleteBeforeEpoch(db, set.Epoch+1))
+	refs, err = blobs.Refs(db, setHash)
+	require.NoError(t, err)
+	require.Zero(t, refs)
+	removed, err = blobs.GC(db)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	_, err = blobs.Get(db, setHash)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	data, err := blobs.Get(db, blobs.Hash(proof))
+	require.NoError(t, err)
+	require.Equal(t, proof, data)
+}
@@ -0,0 +1,142 @@
+package sql
+
+import (
+	"fmt"
+
+	sqlite "github.com/go-llsqlite/crawshaw"
+
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+// blobsMigration moves poet proofs and active sets into the content addressed blobs table.
+// Identical values are stored once, the reference count of each blob is maintained by triggers
+// on the referencing tables. Content hashes can't be computed in sqlite, therefore the migration
+// is not a plain sql file.
+type blobsMigration struct{}
+
+func (blobsMigration) Name() string {
+	return "0019_blobs"
+}
+
+func (blobsMigration) Order() int {
+	return 19
+}
+
+func (blobsMigration) Rollback() error {
+	// handled by the DB itself
+	return nil
+}
+
+func (blobsMigration) Apply(db Executor) error {
+	for _, query := range []string{
+		`CREATE TABLE blobs
+		(
+			hash CHAR(32) PRIMARY KEY,
+			data BLOB NOT NULL,
+			refs INT NOT NULL DEFAULT 0
+		);`,
+
+		`DROP INDEX poets_by_service_id_by_round_id;`,
+		`ALTER TABLE poets RENAME TO poets_old;`,
+		`CREATE TABLE poets
+		(
+			ref        VARCHAR PRIMARY KEY,
+			blob       CHAR(32) NOT NULL,
+			service_id VARCHAR,
+			round_id   VARCHAR
+		);`,
+		`CREATE INDEX poets_by_service_id_by_round_id ON poets (service_id, round_id);`,
+		`CREATE TRIGGER poets_ref_blob AFTER INSERT ON poets BEGIN
+			UPDATE blobs SET refs = refs + 1 WHERE hash = NEW.blob;
+		END;`,
+		`CREATE TRIGGER poets_unref_blob AFTER DELETE ON poets BEGIN
+			UPDATE blobs SET refs = refs - 1 WHERE hash = OLD.blob;
+		END;`,
+
+		`DROP INDEX activesets_by_epoch;`,
+		`ALTER TABLE activesets RENAME TO activesets_old;`,
+		`CREATE TABLE activesets
+		(
+			id    CHAR(32) PRIMARY KEY,
+			epoch INT DEFAULT 0 NOT NULL,
+			blob  CHAR(32) NOT NULL
+		) WITHOUT ROWID;`,
+		`CREATE INDEX activesets_by_epoch ON activesets (epoch asc);`,
+		`CREATE TRIGGER activesets_ref_blob AFTER INSERT ON activesets BEGIN
+			UPDATE blobs SET refs = refs + 1 WHERE hash = NEW.blob;
+		END;`,
+		`CREATE TRIGGER activesets_unref_blob AFTER DELETE ON activesets BEGIN
+			UPDATE blobs SET refs = refs - 1 WHERE hash = OLD.blob;
+		END;`,
+	} {
+		if _, err := db.Exec(query, nil, nil); err != nil {
+			return fmt.Errorf("exec %s: %w", query, err)
+		}
+	}
+
+	if err := moveToBlobs(db,
+		"select ref, poet, service_id, round_id from poets_old;",
+		"insert into poets (ref, blob, service_id, round_id) values (?1, ?2, ?3, ?4);",
+		1,
+	); err != nil {
+		return fmt.Errorf("move poets: %w", err)
+	}
+	if err := moveToBlobs(db,
+		"select id, active_set, epoch from activesets_old;",
+		"insert into activesets (id, blob, epoch) values (?1, ?2, ?3);",
+		1,
+	); err != nil {
+		return fmt.Errorf("move activesets: %w", err)
+	}
+	for _, query := range []string{
+		"DROP TABLE poets_old;",
+		"DROP TABLE activesets_old;",
+	} {
+		if _, err := db.Exec(query, nil, nil); err != nil {
+			return fmt.Errorf("exec %s: %w", query, err)
+		}
+	}
+	return nil
+}
+
+// moveToBlobs copies the rows selected by the query into the table with insert statement.
+// The column at the index is stored in the blobs table and replaced by its hash, other columns are copied as is.
+func moveToBlobs(db Executor, query, insert string, column int) error {
+	var ierr error
+	if _, err := db.Exec(query, nil, func(stmt *Statement) bool {
+		data := make([]byte, stmt.ColumnLen(column))
+		stmt.ColumnBytes(column, data)
+		blob := hash.Sum(data)
+		if _, ierr = db.Exec(`insert into blobs (hash, data) values (?1, ?2)
+			on conflict (hash) do nothing;`,
+			func(ins *Statement) {
+				ins.BindBytes(1, blob[:])
+				ins.BindBytes(2, data)
+			}, nil,
+		); ierr != nil {
+			return false
+		}
+		_, ierr = db.Exec(insert, func(ins *Statement) {
+			for i := 0; i < stmt.ColumnCount(); i++ {
+				switch {
+				case i == column:
+					ins.BindBytes(i+1, blob[:])
+				case stmt.ColumnType(i) == sqlite.SQLITE_INTEGER:
+					ins.BindInt64(i+1, stmt.ColumnInt64(i))
+				case stmt.ColumnType(i) == sqlite.SQLITE_TEXT:
+					ins.BindText(i+1, stmt.ColumnText(i))
+				case stmt.ColumnType(i) == sqlite.SQLITE_NULL:
+					ins.BindNull(i + 1)
+				default:
+					value := make([]byte, stmt.ColumnLen(i))
+					stmt.ColumnBytes(i, value)
+					ins.BindBytes(i+1, value)
+				}
+			}
+		}, nil)
+		return ierr == nil
+	}); err != nil {
+		return err
+	}
+	return ierr
+}
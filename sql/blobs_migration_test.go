@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/hash"
+)
+
+func TestBlobsMigration(t *testing.T) {
+	migrations, err := StateMigrations()
+	require.NoError(t, err)
+	dbFile := filepath.Join(t.TempDir(), "test.sql")
+	before := slices.IndexFunc(migrations, func(m Migration) bool { return m.Order() >= blobsMigration{}.Order() })
+	db, err := Open("file:"+dbFile, WithMigrations(migrations[:before]))
+	require.NoError(t, err)
+
+	proof, set := []byte("proof"), []byte("set")
+	for i, ref := range []byte{1, 2} {
+		_, err = db.Exec("insert into poets (ref, poet, service_id, round_id) values (?1, ?2, ?3, ?4);",
+			func(stmt *Statement) {
+				stmt.BindBytes(1, []byte{ref})
+				stmt.BindBytes(2, proof)
+				stmt.BindBytes(3, []byte("service"))
+				stmt.BindText(4, string(rune('0'+i)))
+			}, nil)
+		require.NoError(t, err)
+		_, err = db.Exec("insert into activesets (id, active_set, epoch) values (?1, ?2, ?3);",
+			func(stmt *Statement) {
+				stmt.BindBytes(1, []byte{ref})
+				stmt.BindBytes(2, set)
+				stmt.BindInt64(3, 7)
+			}, nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Open("file:"+dbFile, WithMigrations(migrations))
+	require.NoError(t, err)
+	defer db.Close()
+
+	refs := map[string]int{}
+	_, err = db.Exec("select data, refs from blobs;", nil, func(stmt *Statement) bool {
+		data := make([]byte, stmt.ColumnLen(0))
+		stmt.ColumnBytes(0, data)
+		refs[string(data)] = stmt.ColumnInt(1)
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"proof": 2, "set": 2}, refs)
+
+	var (
+		epoch int64
+		rows  int
+	)
+	setHash := hash.Sum(set)
+	rows, err = db.Exec("select epoch from activesets where blob = ?1;",
+		func(stmt *Statement) { stmt.BindBytes(1, setHash[:]) },
+		func(stmt *Statement) bool {
+			epoch = stmt.ColumnInt64(0)
+			return true
+		})
+	require.NoError(t, err)
+	require.Equal(t, 2, rows)
+	require.EqualValues(t, 7, epoch)
+}
@@ -17,6 +17,8 @@ const (
 	Gte   token = ">="
 	Lt    token = "<"
 	Lte   token = "<="
+	// In matches any of the values, Value is expected to be [][]byte.
+	In token = "in"
 )
 
 type field string
@@ -60,13 +62,25 @@ type Operations struct {
 func FilterFrom(operations Operations) string {
 	var queryBuilder strings.Builder
 
+	param := 1
 	for i, op := range operations.Filter {
 		if i == 0 {
 			queryBuilder.WriteString(" where")
 		} else {
 			queryBuilder.WriteString(" and")
 		}
-		queryBuilder.WriteString(" " + string(op.Field) + " " + string(op.Token) + " ?" + strconv.Itoa(i+1))
+		if values, ok := op.Value.([][]byte); ok {
+			params := make([]string, len(values))
+			for j := range values {
+				params[j] = "?" + strconv.Itoa(param)
+				param++
+			}
+			queryBuilder.WriteString(" " + string(op.Field) + " " + string(op.Token) +
+				" (" + strings.Join(params, ", ") + ")")
+			continue
+		}
+		queryBuilder.WriteString(" " + string(op.Field) + " " + string(op.Token) + " ?" + strconv.Itoa(param))
+		param++
 	}
 
 	for _, m := range operations.Modifiers {
@@ -78,12 +92,20 @@ func FilterFrom(operations Operations) string {
 
 func BindingsFrom(operations Operations) sql.Encoder {
 	return func(stmt *sql.Statement) {
-		for i, op := range operations.Filter {
+		param := 1
+		for _, op := range operations.Filter {
 			switch value := op.Value.(type) {
 			case int64:
-				stmt.BindInt64(i+1, value)
+				stmt.BindInt64(param, value)
+				param++
 			case []byte:
-				stmt.BindBytes(i+1, value)
+				stmt.BindBytes(param, value)
+				param++
+			case [][]byte:
+				for _, v := range value {
+					stmt.BindBytes(param, v)
+					param++
+				}
 			default:
 				panic(fmt.Sprintf("unexpected type %T", value))
 			}
@@ -166,6 +166,42 @@ func SetInvalid(db sql.Executor, lid types.LayerID, bid types.BlockID) error {
 	return nil
 }
 
+// LayerCertificate is the certificate of the block certified in the layer.
+type LayerCertificate struct {
+	Layer types.LayerID
+	Cert  *types.Certificate
+}
+
+// CertifiedInRange returns certificates of the certified blocks in layers [from, to], in ascending order.
+// Layers without a certificate, including layers where it was pruned, are skipped.
+func CertifiedInRange(db sql.Executor, from, to types.LayerID) ([]LayerCertificate, error) {
+	var (
+		result []LayerCertificate
+		derr   error
+	)
+	if _, err := db.Exec(`
+		select layer, cert from certificates where layer between ?1 and ?2 and valid = 1 and cert is not null
+		order by layer asc;`, func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(from))
+		stmt.BindInt64(2, int64(to))
+	}, func(stmt *sql.Statement) bool {
+		var cert types.Certificate
+		data := make([]byte, stmt.ColumnLen(1))
+		stmt.ColumnBytes(1, data)
+		if derr = codec.Decode(data, &cert); derr != nil {
+			return false
+		}
+		result = append(result, LayerCertificate{Layer: types.LayerID(stmt.ColumnInt64(0)), Cert: &cert})
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("certified in range [%s, %s]: %w", from, to, err)
+	}
+	if derr != nil {
+		return nil, fmt.Errorf("decode cert: %w", derr)
+	}
+	return result, nil
+}
+
 func DeleteCertBefore(db sql.Executor, lid types.LayerID) error {
 	if _, err := db.Exec(`update certificates set cert = null where layer < ?1;`,
 		func(stmt *sql.Statement) {
@@ -176,6 +176,28 @@ func TestDeleteCert(t *testing.T) {
 	require.Equal(t, types.BlockID{4}, got)
 }
 
+func TestCertifiedInRange(t *testing.T) {
+	db := sql.InMemory()
+	for lid := types.LayerID(1); lid <= 5; lid++ {
+		require.NoError(t, Add(db, lid, &types.Certificate{BlockID: types.BlockID{byte(lid)}}))
+	}
+	require.NoError(t, SetHareOutput(db, 6, types.BlockID{6}))
+	require.NoError(t, SetInvalid(db, 3, types.BlockID{3}))
+	require.NoError(t, DeleteCertBefore(db, 2))
+
+	got, err := CertifiedInRange(db, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	for i, lid := range []types.LayerID{2, 4, 5} {
+		require.Equal(t, lid, got[i].Layer)
+		require.Equal(t, types.BlockID{byte(lid)}, got[i].Cert.BlockID)
+	}
+
+	got, err = CertifiedInRange(db, 6, 10)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
 func TestFirstInEpoch(t *testing.T) {
 	db := sql.InMemory()
 
@@ -24,6 +24,8 @@ var (
 	ErrNotFound = errors.New("database: not found")
 	// ErrObjectExists is returned if database constraints didn't allow to insert an object.
 	ErrObjectExists = errors.New("database: object exists")
+	// ErrTxDone is returned if the savepoint is created in the transaction that was already committed.
+	ErrTxDone = errors.New("database: transaction is already committed")
 )
 
 const (
@@ -36,6 +38,9 @@ const (
 // Executor is an interface for executing raw statement.
 type Executor interface {
 	Exec(string, Encoder, Decoder) (int, error)
+	// WithSavepoint executes exec in a nested transaction. Changes made by exec are rolled back
+	// if it returns an error, without affecting the enclosing transaction.
+	WithSavepoint(exec func(Executor) error) error
 }
 
 // Statement is an sqlite statement.
@@ -77,6 +82,7 @@ type conf struct {
 	enableLatency bool
 	cache         bool
 	cacheSizes    map[QueryCacheKind]int
+	pragmas       Pragmas
 	logger        *zap.Logger
 }
 
@@ -181,18 +187,25 @@ func InMemory(opts ...Opt) *Database {
 
 // Open database with options.
 //
-// Database is opened in WAL mode and pragma synchronous=normal.
+// Database is opened in WAL mode unless other journal mode is configured with WithPragmas.
 // https://sqlite.org/wal.html
-// https://www.sqlite.org/pragma.html#pragma_synchronous
+// https://www.sqlite.org/pragma.html
 func Open(uri string, opts ...Opt) (*Database, error) {
 	config := defaultConf()
 	for _, opt := range opts {
 		opt(config)
 	}
-	pool, err := sqlitex.Open(uri, config.flags, config.connections)
+	if err := config.pragmas.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pragmas: %w", err)
+	}
+	pool, err := sqlitex.Open(uri, config.pragmas.flags(config.flags), config.connections)
 	if err != nil {
 		return nil, fmt.Errorf("open db %s: %w", uri, err)
 	}
+	if err := config.pragmas.apply(pool, config.connections); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("apply pragmas to db %s: %w", uri, err)
+	}
 	db := &Database{pool: pool}
 	if config.enableLatency {
 		db.latency = newQueryLatency()
@@ -357,6 +370,14 @@ func (db *Database) Exec(query string, encoder Encoder, decoder Decoder) (int, e
 	return exec(conn, query, encoder, decoder)
 }
 
+// WithSavepoint executes exec in a new immediate transaction, as the database is not in a transaction.
+// The transaction is committed only if exec returns nil.
+func (db *Database) WithSavepoint(exec func(Executor) error) error {
+	return db.WithTx(context.Background(), func(tx *Tx) error {
+		return exec(tx)
+	})
+}
+
 // Close closes all pooled connections.
 func (db *Database) Close() error {
 	db.closeMux.Lock()
@@ -426,6 +447,8 @@ type Tx struct {
 	conn      *sqlite.Conn
 	committed bool
 	err       error
+	// savepoints is the number of savepoints created in the transaction, used to name them uniquely.
+	savepoints int
 }
 
 func (tx *Tx) begin(initstmt string) error {
@@ -459,6 +482,45 @@ func (tx *Tx) Release() error {
 	return tx.err
 }
 
+// WithSavepoint executes exec in a nested transaction started with SAVEPOINT.
+// Savepoints can be nested by calling WithSavepoint on the executor passed to exec.
+//
+// If exec returns an error, the changes made by exec are rolled back, the savepoint is released
+// and the error is returned. The enclosing transaction remains usable and can be committed with the changes
+// made outside of the savepoint.
+//
+// https://www.sqlite.org/lang_savepoint.html
+func (tx *Tx) WithSavepoint(exec func(Executor) error) error {
+	if tx.committed {
+		return ErrTxDone
+	}
+	tx.savepoints++
+	name := fmt.Sprintf("sp_%d", tx.savepoints)
+	if err := tx.step("SAVEPOINT " + name + ";"); err != nil {
+		return fmt.Errorf("create savepoint %s: %w", name, err)
+	}
+	if err := exec(tx); err != nil {
+		tx.queryCache.ClearCache()
+		if rerr := tx.step("ROLLBACK TO " + name + ";"); rerr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint %s: %w", name, rerr))
+		}
+		if rerr := tx.step("RELEASE " + name + ";"); rerr != nil {
+			return errors.Join(err, fmt.Errorf("release savepoint %s: %w", name, rerr))
+		}
+		return err
+	}
+	if err := tx.step("RELEASE " + name + ";"); err != nil {
+		return fmt.Errorf("release savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+func (tx *Tx) step(query string) error {
+	stmt := tx.conn.Prep(query)
+	_, err := stmt.Step()
+	return err
+}
+
 // Exec query.
 func (tx *Tx) Exec(query string, encoder Encoder, decoder Decoder) (int, error) {
 	tx.db.queryCount.Add(1)
@@ -57,6 +57,71 @@ func Test_Transaction_Isolation(t *testing.T) {
 	require.Equal(t, rows, 0)
 }
 
+func Test_Savepoint_PartialRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	testMigration := NewMockMigration(ctrl)
+	testMigration.EXPECT().Name().Return("test").AnyTimes()
+	testMigration.EXPECT().Order().Return(1).AnyTimes()
+	testMigration.EXPECT().Apply(gomock.Any()).DoAndReturn(func(e Executor) error {
+		_, err := e.Exec(`create table testing1 (id varchar primary key)`, nil, nil)
+		return err
+	})
+	db := InMemory(WithMigrations([]Migration{testMigration}))
+
+	insert := func(e Executor, key string) error {
+		_, err := e.Exec("insert into testing1(id) values (?1)", func(stmt *Statement) {
+			stmt.BindText(1, key)
+		}, nil)
+		return err
+	}
+	exists := func(key string) bool {
+		rows, err := db.Exec("select 1 from testing1 where id = ?1", func(stmt *Statement) {
+			stmt.BindText(1, key)
+		}, nil)
+		require.NoError(t, err)
+		return rows > 0
+	}
+
+	errFailed := errors.New("failed")
+	require.NoError(t, db.WithTx(context.Background(), func(tx *Tx) error {
+		require.NoError(t, insert(tx, "outer"))
+		require.NoError(t, tx.WithSavepoint(func(e Executor) error {
+			require.NoError(t, insert(e, "released"))
+			require.ErrorIs(t, e.WithSavepoint(func(e Executor) error {
+				require.NoError(t, insert(e, "nested"))
+				return errFailed
+			}), errFailed)
+			return nil
+		}))
+		require.ErrorIs(t, tx.WithSavepoint(func(e Executor) error {
+			require.NoError(t, insert(e, "rolled back"))
+			return insert(e, "outer")
+		}), ErrObjectExists)
+		return nil
+	}))
+	require.True(t, exists("outer"))
+	require.True(t, exists("released"))
+	require.False(t, exists("nested"))
+	require.False(t, exists("rolled back"))
+
+	// without enclosing transaction the savepoint is a transaction
+	require.ErrorIs(t, db.WithSavepoint(func(e Executor) error {
+		require.NoError(t, insert(e, "top"))
+		return errFailed
+	}), errFailed)
+	require.False(t, exists("top"))
+	require.NoError(t, db.WithSavepoint(func(e Executor) error {
+		return insert(e, "top")
+	}))
+	require.True(t, exists("top"))
+
+	tx, err := db.Tx(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.ErrorIs(t, tx.WithSavepoint(func(Executor) error { return nil }), ErrTxDone)
+	require.NoError(t, tx.Release())
+}
+
 func Test_Migration_Rollback(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	migration1 := NewMockMigration(ctrl)
@@ -157,6 +222,26 @@ func TestDatabaseVacuumState(t *testing.T) {
 	require.ErrorIs(t, err, os.ErrNotExist)
 }
 
+func TestCheckpoint(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "test.sql")
+	db, err := Open("file:" + dbFile)
+	require.NoError(t, err)
+	_, err = db.Exec("create table testing (id int)", nil, nil)
+	require.NoError(t, err)
+	_, err = db.Exec("insert into testing values (1)", nil, nil)
+	require.NoError(t, err)
+
+	info, err := os.Stat(dbFile + "-wal")
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+
+	require.NoError(t, Checkpoint(db))
+	info, err = os.Stat(dbFile + "-wal")
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+	require.NoError(t, db.Close())
+}
+
 func TestQueryCount(t *testing.T) {
 	db := InMemory()
 	require.Equal(t, 0, db.QueryCount())
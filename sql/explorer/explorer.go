@@ -0,0 +1,231 @@
+// Package explorer stores denormalized tables that are maintained by the explorer indexer
+// for the layers applied to the state.
+package explorer
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// AddressTransaction is the transaction with a result that touched the address.
+type AddressTransaction struct {
+	Layer types.LayerID
+	ID    types.TransactionID
+}
+
+// SmesherATX is the activation of the smesher targeting the epoch.
+type SmesherATX struct {
+	Epoch    types.EpochID
+	ID       types.ATXID
+	Coinbase types.Address
+	Units    uint32
+	Weight   uint64
+}
+
+// EpochReward is the sum of the rewards received by the smesher in the epoch.
+type EpochReward struct {
+	SmesherID   types.NodeID
+	Coinbase    types.Address
+	TotalReward uint64
+	LayerReward uint64
+	// Count is the number of layers in which the smesher was rewarded.
+	Count uint32
+}
+
+// IndexLayer indexes data of the layer with the block applied to the state.
+// Activations targeting the epoch are indexed with the first layer of the epoch.
+func IndexLayer(db sql.Executor, lid types.LayerID, block types.BlockID) error {
+	if _, err := db.Exec(`insert or ignore into explorer_address_transactions (address, layer, tid)
+		select a.address, t.layer, t.id from transactions t
+		join transactions_results_addresses a on a.tid = t.id
+		where t.layer = ?1 and t.result is not null;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		}, nil); err != nil {
+		return fmt.Errorf("index transactions of layer %v: %w", lid, err)
+	}
+	if _, err := db.Exec(`insert into explorer_epoch_rewards
+		(epoch, pubkey, coinbase, total_reward, layer_reward, count)
+		select ?2, pubkey, coinbase, total_reward, layer_reward, 1 from rewards
+		where layer = ?1 and pubkey is not null
+		on conflict (epoch, pubkey) do update set
+			coinbase = excluded.coinbase,
+			total_reward = total_reward + excluded.total_reward,
+			layer_reward = layer_reward + excluded.layer_reward,
+			count = count + 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+			stmt.BindInt64(2, int64(lid.GetEpoch()))
+		}, nil); err != nil {
+		return fmt.Errorf("index rewards of layer %v: %w", lid, err)
+	}
+	if lid.FirstInEpoch() {
+		if _, err := db.Exec(`insert or ignore into explorer_smesher_atxs
+			(pubkey, epoch, id, coinbase, units, weight)
+			select pubkey, epoch + 1, id, coinbase, effective_num_units, effective_num_units * tick_count
+			from atxs where epoch = ?1;`,
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(lid.GetEpoch()-1))
+			}, nil); err != nil {
+			return fmt.Errorf("index atxs targeting epoch %v: %w", lid.GetEpoch(), err)
+		}
+	}
+	if _, err := db.Exec(`insert into explorer_layers (layer, block) values (?1, ?2)
+		on conflict (layer) do update set block = ?2;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+			stmt.BindBytes(2, block[:])
+		}, nil); err != nil {
+		return fmt.Errorf("set indexed layer %v: %w", lid, err)
+	}
+	return nil
+}
+
+// Revert removes the index of the epoch with the layer and of all later epochs,
+// so that they can be indexed again from the first layer of the epoch.
+func Revert(db sql.Executor, lid types.LayerID) error {
+	epoch := lid.GetEpoch()
+	first := epoch.FirstLayer()
+	bindLayer := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(first))
+	}
+	bindEpoch := func(stmt *sql.Statement) {
+		stmt.BindInt64(1, int64(epoch))
+	}
+	for _, q := range []struct {
+		query string
+		bind  func(*sql.Statement)
+	}{
+		{`delete from explorer_address_transactions where layer >= ?1;`, bindLayer},
+		{`delete from explorer_epoch_rewards where epoch >= ?1;`, bindEpoch},
+		{`delete from explorer_smesher_atxs where epoch >= ?1;`, bindEpoch},
+		{`delete from explorer_layers where layer >= ?1;`, bindLayer},
+	} {
+		if _, err := db.Exec(q.query, q.bind, nil); err != nil {
+			return fmt.Errorf("revert index to epoch %v: %w", epoch, err)
+		}
+	}
+	return nil
+}
+
+// LastIndexed returns the last indexed layer, or 0 if no layer was indexed.
+func LastIndexed(db sql.Executor) (types.LayerID, error) {
+	var lid types.LayerID
+	if _, err := db.Exec(`select max(layer) from explorer_layers;`, nil,
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(stmt.ColumnInt64(0))
+			return true
+		}); err != nil {
+		return 0, fmt.Errorf("last indexed layer: %w", err)
+	}
+	return lid, nil
+}
+
+// FirstDiverged returns the first layer after the from layer whose indexed block is no longer
+// the block applied to the state. It returns sql.ErrNotFound if no such layer exists.
+func FirstDiverged(db sql.Executor, from types.LayerID) (types.LayerID, error) {
+	var (
+		lid   types.LayerID
+		found bool
+	)
+	if _, err := db.Exec(`select e.layer from explorer_layers e
+		left join layers l on l.id = e.layer
+		where e.layer > ?1 and (l.applied_block is null or l.applied_block != e.block)
+		order by e.layer asc limit 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+		},
+		func(stmt *sql.Statement) bool {
+			lid = types.LayerID(stmt.ColumnInt64(0))
+			found = true
+			return false
+		}); err != nil {
+		return 0, fmt.Errorf("first diverged layer after %v: %w", from, err)
+	}
+	if !found {
+		return 0, sql.ErrNotFound
+	}
+	return lid, nil
+}
+
+// TransactionsByAddress returns up to limit transactions that touched the address
+// in the layers [from, to], ordered by layer.
+func TransactionsByAddress(
+	db sql.Executor,
+	address types.Address,
+	from, to types.LayerID,
+	limit int,
+) ([]AddressTransaction, error) {
+	var rst []AddressTransaction
+	if _, err := db.Exec(`select layer, tid from explorer_address_transactions
+		where address = ?1 and layer between ?2 and ?3
+		order by layer asc, tid asc limit ?4;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, address[:])
+			stmt.BindInt64(2, int64(from))
+			stmt.BindInt64(3, int64(to))
+			stmt.BindInt64(4, int64(limit))
+		},
+		func(stmt *sql.Statement) bool {
+			tx := AddressTransaction{Layer: types.LayerID(stmt.ColumnInt64(0))}
+			stmt.ColumnBytes(1, tx.ID[:])
+			rst = append(rst, tx)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("transactions of %s: %w", address, err)
+	}
+	return rst, nil
+}
+
+// ATXsBySmesher returns activations of the smesher, ordered by target epoch.
+func ATXsBySmesher(db sql.Executor, smesherID types.NodeID) ([]SmesherATX, error) {
+	var rst []SmesherATX
+	if _, err := db.Exec(`select epoch, id, coinbase, units, weight from explorer_smesher_atxs
+		where pubkey = ?1 order by epoch asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, smesherID[:])
+		},
+		func(stmt *sql.Statement) bool {
+			atx := SmesherATX{
+				Epoch:  types.EpochID(stmt.ColumnInt64(0)),
+				Units:  uint32(stmt.ColumnInt64(3)),
+				Weight: uint64(stmt.ColumnInt64(4)),
+			}
+			stmt.ColumnBytes(1, atx.ID[:])
+			stmt.ColumnBytes(2, atx.Coinbase[:])
+			rst = append(rst, atx)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("atxs of %s: %w", smesherID.ShortString(), err)
+	}
+	return rst, nil
+}
+
+// RewardsByEpoch returns up to limit rewards received in the epoch, starting from offset.
+// Rewards are ordered by smesher id.
+func RewardsByEpoch(db sql.Executor, epoch types.EpochID, offset, limit int) ([]EpochReward, error) {
+	var rst []EpochReward
+	if _, err := db.Exec(`select pubkey, coinbase, total_reward, layer_reward, count from explorer_epoch_rewards
+		where epoch = ?1 order by pubkey asc limit ?2 offset ?3;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+			stmt.BindInt64(2, int64(limit))
+			stmt.BindInt64(3, int64(offset))
+		},
+		func(stmt *sql.Statement) bool {
+			reward := EpochReward{
+				TotalReward: uint64(stmt.ColumnInt64(2)),
+				LayerReward: uint64(stmt.ColumnInt64(3)),
+				Count:       uint32(stmt.ColumnInt64(4)),
+			}
+			stmt.ColumnBytes(0, reward.SmesherID[:])
+			stmt.ColumnBytes(1, reward.Coinbase[:])
+			rst = append(rst, reward)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("rewards of epoch %v: %w", epoch, err)
+	}
+	return rst, nil
+}
@@ -0,0 +1,160 @@
+package explorer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/rewards"
+	"github.com/spacemeshos/go-spacemesh/sql/transactions"
+)
+
+func addTransaction(
+	tb testing.TB,
+	db *sql.Database,
+	lid types.LayerID,
+	block types.BlockID,
+	addresses ...types.Address,
+) types.TransactionID {
+	tb.Helper()
+	tx := &types.Transaction{
+		RawTx:    types.NewRawTx(types.RandomBytes(32)),
+		TxHeader: &types.TxHeader{Principal: addresses[0]},
+	}
+	require.NoError(tb, transactions.Add(db, tx, time.Now()))
+	require.NoError(tb, db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
+		return transactions.AddResult(dbtx, tx.ID, &types.TransactionResult{
+			Layer:     lid,
+			Block:     block,
+			Addresses: addresses,
+		})
+	}))
+	return tx.ID
+}
+
+func addATX(tb testing.TB, db *sql.Database, epoch types.EpochID, smesherID types.NodeID) types.ATXID {
+	tb.Helper()
+	atx := &types.ActivationTx{
+		InnerActivationTx: types.InnerActivationTx{
+			NIPostChallenge: types.NIPostChallenge{PublishEpoch: epoch},
+			Coinbase:        types.GenerateAddress(smesherID[:]),
+			NumUnits:        4,
+		},
+		SmesherID: smesherID,
+	}
+	atx.SetID(types.RandomATXID())
+	atx.SetEffectiveNumUnits(atx.NumUnits)
+	atx.SetReceived(time.Now())
+	vatx, err := atx.Verify(0, 10)
+	require.NoError(tb, err)
+	require.NoError(tb, atxs.Add(db, vatx))
+	return atx.ID()
+}
+
+func TestIndexLayer(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+
+	alice := types.GenerateAddress([]byte("alice"))
+	bob := types.GenerateAddress([]byte("bob"))
+	smesher := types.RandomNodeID()
+	atx := addATX(t, db, 1, smesher)
+
+	block := types.RandomBlockID()
+	tx1 := addTransaction(t, db, 8, block, alice, bob)
+	tx2 := addTransaction(t, db, 9, block, bob)
+	for _, lid := range []types.LayerID{8, 9} {
+		require.NoError(t, rewards.Add(db, &types.Reward{
+			Layer: lid, Coinbase: alice, SmesherID: smesher, TotalReward: 10, LayerReward: 7,
+		}))
+		require.NoError(t, layers.SetApplied(db, lid, block))
+		require.NoError(t, IndexLayer(db, lid, block))
+	}
+
+	last, err := LastIndexed(db)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(9), last)
+
+	txs, err := TransactionsByAddress(db, bob, 0, 100, 10)
+	require.NoError(t, err)
+	require.Equal(t, []AddressTransaction{{Layer: 8, ID: tx1}, {Layer: 9, ID: tx2}}, txs)
+	txs, err = TransactionsByAddress(db, bob, 9, 9, 10)
+	require.NoError(t, err)
+	require.Equal(t, []AddressTransaction{{Layer: 9, ID: tx2}}, txs)
+	txs, err = TransactionsByAddress(db, alice, 0, 100, 10)
+	require.NoError(t, err)
+	require.Equal(t, []AddressTransaction{{Layer: 8, ID: tx1}}, txs)
+
+	smesherATXs, err := ATXsBySmesher(db, smesher)
+	require.NoError(t, err)
+	require.Equal(t, []SmesherATX{{
+		Epoch:    2,
+		ID:       atx,
+		Coinbase: types.GenerateAddress(smesher[:]),
+		Units:    4,
+		Weight:   40,
+	}}, smesherATXs)
+
+	epochRewards, err := RewardsByEpoch(db, 2, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []EpochReward{{
+		SmesherID:   smesher,
+		Coinbase:    alice,
+		TotalReward: 20,
+		LayerReward: 14,
+		Count:       2,
+	}}, epochRewards)
+	epochRewards, err = RewardsByEpoch(db, 2, 1, 10)
+	require.NoError(t, err)
+	require.Empty(t, epochRewards)
+}
+
+func TestRevert(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+
+	smesher := types.RandomNodeID()
+	addATX(t, db, 1, smesher)
+	addATX(t, db, 2, smesher)
+	for lid := types.LayerID(8); lid <= 13; lid++ {
+		block := types.RandomBlockID()
+		require.NoError(t, rewards.Add(db, &types.Reward{
+			Layer: lid, Coinbase: types.GenerateAddress(smesher[:]), SmesherID: smesher, TotalReward: 1,
+		}))
+		require.NoError(t, layers.SetApplied(db, lid, block))
+		require.NoError(t, IndexLayer(db, lid, block))
+	}
+	_, err := FirstDiverged(db, 0)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	require.NoError(t, layers.SetApplied(db, 13, types.RandomBlockID()))
+	diverged, err := FirstDiverged(db, 0)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(13), diverged)
+	_, err = FirstDiverged(db, 13)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	require.NoError(t, Revert(db, diverged))
+	last, err := LastIndexed(db)
+	require.NoError(t, err)
+	require.Equal(t, types.LayerID(11), last)
+
+	smesherATXs, err := ATXsBySmesher(db, smesher)
+	require.NoError(t, err)
+	require.Len(t, smesherATXs, 1)
+	require.Equal(t, types.EpochID(2), smesherATXs[0].Epoch)
+
+	epochRewards, err := RewardsByEpoch(db, 3, 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, epochRewards)
+	epochRewards, err = RewardsByEpoch(db, 2, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, epochRewards, 1)
+	require.Equal(t, uint32(4), epochRewards[0].Count)
+}
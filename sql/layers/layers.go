@@ -62,7 +62,8 @@ func SetApplied(db sql.Executor, lid types.LayerID, applied types.BlockID) error
 // UnsetAppliedFrom updates the applied block to nil for layer >= `lid`.
 func UnsetAppliedFrom(db sql.Executor, lid types.LayerID) error {
 	if _, err := db.Exec(
-		"update layers set applied_block = null, state_hash = null, aggregated_hash = null where id >= ?1;",
+		`update layers set applied_block = null, state_hash = null, state_root = null, aggregated_hash = null
+		where id >= ?1;`,
 		func(stmt *sql.Statement) {
 			stmt.BindInt64(1, int64(lid))
 		}, nil); err != nil {
@@ -120,6 +121,91 @@ func GetStateHash(db sql.Executor, lid types.LayerID) (rst types.Hash32, err err
 	return rst, err
 }
 
+// StateRoot is the root of the state of all accounts after the layer was applied.
+type StateRoot struct {
+	Layer types.LayerID
+	Root  types.Hash32
+}
+
+// SetStateRoot for the layer.
+func SetStateRoot(db sql.Executor, lid types.LayerID, root types.Hash32) error {
+	if _, err := db.Exec(`insert into layers (id, state_root) values (?1, ?2)
+	on conflict(id) do update set state_root=?2;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+			stmt.BindBytes(2, root[:])
+		}, nil); err != nil {
+		return fmt.Errorf("set state root %s: %w", lid, err)
+	}
+	return nil
+}
+
+// GetStateRoot loads the state root for the layer.
+func GetStateRoot(db sql.Executor, lid types.LayerID) (types.Hash32, error) {
+	var (
+		root types.Hash32
+		set  bool
+	)
+	if _, err := db.Exec("select state_root from layers where id = ?1;",
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(lid))
+		},
+		func(stmt *sql.Statement) bool {
+			set = stmt.ColumnLen(0) > 0
+			stmt.ColumnBytes(0, root[:])
+			return false
+		}); err != nil {
+		return root, fmt.Errorf("state root for %s: %w", lid, err)
+	}
+	if !set {
+		return root, fmt.Errorf("%w: state root for %s is not set", sql.ErrNotFound, lid)
+	}
+	return root, nil
+}
+
+// LastStateRoot returns the state root of the last applied layer.
+func LastStateRoot(db sql.Executor) (StateRoot, error) {
+	var (
+		root StateRoot
+		set  bool
+	)
+	if _, err := db.Exec(`select id, state_root from layers
+		where state_root is not null order by id desc limit 1;`,
+		nil,
+		func(stmt *sql.Statement) bool {
+			set = true
+			root.Layer = types.LayerID(stmt.ColumnInt64(0))
+			stmt.ColumnBytes(1, root.Root[:])
+			return false
+		}); err != nil {
+		return root, fmt.Errorf("last state root: %w", err)
+	}
+	if !set {
+		return root, fmt.Errorf("%w: state root is not set", sql.ErrNotFound)
+	}
+	return root, nil
+}
+
+// StateRoots returns state roots set for layers in [from, to] in ascending order.
+func StateRoots(db sql.Executor, from, to types.LayerID) ([]StateRoot, error) {
+	var rst []StateRoot
+	if _, err := db.Exec(`select id, state_root from layers
+		where id between ?1 and ?2 and state_root is not null order by id asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+			stmt.BindInt64(2, int64(to))
+		},
+		func(stmt *sql.Statement) bool {
+			root := StateRoot{Layer: types.LayerID(stmt.ColumnInt64(0))}
+			stmt.ColumnBytes(1, root.Root[:])
+			rst = append(rst, root)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("state roots in [%s, %s]: %w", from, to, err)
+	}
+	return rst, nil
+}
+
 // GetApplied for the applied block for layer.
 func GetApplied(db sql.Executor, lid types.LayerID) (rst types.BlockID, err error) {
 	if rows, err := db.Exec("select applied_block from layers where id = ?1;",
@@ -268,3 +354,53 @@ func GetAggHashes(db sql.Executor, from, to types.LayerID, by uint32) ([]types.H
 	}
 	return hashes, nil
 }
+
+// WeakCoin is the weak coin of the layer with the preround message it was derived from.
+type WeakCoin struct {
+	Layer types.LayerID
+	Value bool
+	// Vrf is the smallest vrf from preround messages of the hare, the coin is its least significant bit.
+	// Vrf and Smesher are empty if the coin was recorded before the provenance was tracked.
+	Vrf types.VrfSignature
+	// Smesher sent the preround message with the smallest vrf.
+	Smesher types.NodeID
+}
+
+// SetWeakCoinProvenance for the layer, replacing the value set by SetWeakCoin.
+func SetWeakCoinProvenance(db sql.Executor, coin *WeakCoin) error {
+	if _, err := db.Exec(`insert into layers (id, weak_coin, weak_coin_vrf, weak_coin_smesher) values (?1, ?2, ?3, ?4)
+					on conflict(id) do update set weak_coin=?2, weak_coin_vrf=?3, weak_coin_smesher=?4;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(coin.Layer))
+			stmt.BindBool(2, coin.Value)
+			stmt.BindBytes(3, coin.Vrf[:])
+			stmt.BindBytes(4, coin.Smesher[:])
+		}, nil); err != nil {
+		return fmt.Errorf("set weak coin %s: %w", coin.Layer, err)
+	}
+	return nil
+}
+
+// WeakCoins returns weak coins set for layers in [from, to] in ascending order.
+func WeakCoins(db sql.Executor, from, to types.LayerID) ([]*WeakCoin, error) {
+	var rst []*WeakCoin
+	if _, err := db.Exec(`select id, weak_coin, weak_coin_vrf, weak_coin_smesher from layers
+		where id between ?1 and ?2 and weak_coin is not null order by id asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+			stmt.BindInt64(2, int64(to))
+		},
+		func(stmt *sql.Statement) bool {
+			coin := &WeakCoin{
+				Layer: types.LayerID(stmt.ColumnInt64(0)),
+				Value: stmt.ColumnInt(1) == 1,
+			}
+			stmt.ColumnBytes(2, coin.Vrf[:])
+			stmt.ColumnBytes(3, coin.Smesher[:])
+			rst = append(rst, coin)
+			return true
+		}); err != nil {
+		return nil, fmt.Errorf("weak coins in [%s, %s]: %w", from, to, err)
+	}
+	return rst, nil
+}
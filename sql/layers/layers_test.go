@@ -37,6 +37,26 @@ func TestWeakCoin(t *testing.T) {
 	require.False(t, got)
 }
 
+func TestWeakCoins(t *testing.T) {
+	db := sql.InMemory()
+	require.NoError(t, SetWeakCoin(db, 9, true))
+	coin := &WeakCoin{
+		Layer:   10,
+		Value:   false,
+		Vrf:     types.RandomVrfSignature(),
+		Smesher: types.RandomNodeID(),
+	}
+	require.NoError(t, SetWeakCoinProvenance(db, coin))
+	require.NoError(t, SetApplied(db, 11, types.EmptyBlockID))
+
+	got, err := WeakCoins(db, 8, 11)
+	require.NoError(t, err)
+	require.Equal(t, []*WeakCoin{{Layer: 9, Value: true}, coin}, got)
+	value, err := GetWeakCoin(db, coin.Layer)
+	require.NoError(t, err)
+	require.False(t, value)
+}
+
 func TestAppliedBlock(t *testing.T) {
 	db := sql.InMemory()
 	lid := types.LayerID(10)
@@ -146,6 +166,39 @@ func TestStateHash(t *testing.T) {
 	require.Equal(t, hashes[0], latest)
 }
 
+func TestStateRoot(t *testing.T) {
+	db := sql.InMemory()
+	_, err := GetStateRoot(db, 9)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	_, err = LastStateRoot(db)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	require.NoError(t, SetWeakCoin(db, 9, true))
+	_, err = GetStateRoot(db, 9)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	roots := []StateRoot{{Layer: 9, Root: types.Hash32{1}}, {Layer: 10, Root: types.Hash32{2}}}
+	for _, root := range roots {
+		require.NoError(t, SetStateRoot(db, root.Layer, root.Root))
+	}
+	got, err := GetStateRoot(db, 10)
+	require.NoError(t, err)
+	require.Equal(t, roots[1].Root, got)
+	all, err := StateRoots(db, 0, 20)
+	require.NoError(t, err)
+	require.Equal(t, roots, all)
+	last, err := LastStateRoot(db)
+	require.NoError(t, err)
+	require.Equal(t, roots[1], last)
+
+	require.NoError(t, UnsetAppliedFrom(db, 10))
+	all, err = StateRoots(db, 0, 20)
+	require.NoError(t, err)
+	require.Equal(t, roots[:1], all)
+	last, err = LastStateRoot(db)
+	require.NoError(t, err)
+	require.Equal(t, roots[0], last)
+}
+
 func TestSetHashes(t *testing.T) {
 	db := sql.InMemory()
 	_, err := GetAggregatedHash(db, types.LayerID(11))
@@ -0,0 +1,127 @@
+// Package activecandidates stores the atxs that are candidates for the active set of the target epoch,
+// so that the active set is not computed from all atx headers at the start of the epoch.
+package activecandidates
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Candidate is the atx that targets the epoch.
+type Candidate struct {
+	ID       types.ATXID
+	Node     types.NodeID
+	Weight   uint64
+	Received time.Time
+}
+
+// Summary is the number and the weight of the candidates, split by the time they were received.
+type Summary struct {
+	ATXs   int
+	Weight uint64
+	// LateATXs were received after the deadline.
+	LateATXs   int
+	LateWeight uint64
+}
+
+// Add stores the candidate for the target epoch. Returns false if the candidate is already stored.
+func Add(db sql.Executor, epoch types.EpochID, candidate *Candidate) (bool, error) {
+	rows, err := db.Exec(`insert into active_set_candidates (epoch, id, node, weight, received)
+		values (?1, ?2, ?3, ?4, ?5) on conflict do nothing returning 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+			stmt.BindBytes(2, candidate.ID[:])
+			stmt.BindBytes(3, candidate.Node[:])
+			stmt.BindInt64(4, int64(candidate.Weight))
+			stmt.BindInt64(5, candidate.Received.UnixNano())
+		}, nil)
+	if err != nil {
+		return false, fmt.Errorf("insert candidate %s: %w", candidate.ID, err)
+	}
+	return rows > 0, nil
+}
+
+func decodeCandidate(stmt *sql.Statement) *Candidate {
+	var candidate Candidate
+	stmt.ColumnBytes(0, candidate.ID[:])
+	stmt.ColumnBytes(1, candidate.Node[:])
+	candidate.Weight = uint64(stmt.ColumnInt64(2))
+	candidate.Received = time.Unix(0, stmt.ColumnInt64(3)).Local()
+	return &candidate
+}
+
+// IterateEpoch calls fn for every candidate of the target epoch until it returns false.
+func IterateEpoch(db sql.Executor, epoch types.EpochID, fn func(*Candidate) bool) error {
+	_, err := db.Exec(`select id, node, weight, received from active_set_candidates where epoch = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+		},
+		func(stmt *sql.Statement) bool {
+			return fn(decodeCandidate(stmt))
+		})
+	if err != nil {
+		return fmt.Errorf("select candidates in epoch %d: %w", epoch, err)
+	}
+	return nil
+}
+
+// GetByNode returns the candidate of the node in the target epoch.
+func GetByNode(db sql.Executor, epoch types.EpochID, node types.NodeID) (*Candidate, error) {
+	var candidate *Candidate
+	rows, err := db.Exec(`select id, node, weight, received from active_set_candidates
+		where epoch = ?1 and node = ?2 limit 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+			stmt.BindBytes(2, node[:])
+		},
+		func(stmt *sql.Statement) bool {
+			candidate = decodeCandidate(stmt)
+			return false
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select candidate of %s in epoch %d: %w", node, epoch, err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("candidate of %s in epoch %d: %w", node, epoch, sql.ErrNotFound)
+	}
+	return candidate, nil
+}
+
+// Summarize counts the candidates of the target epoch. Candidates received at or after the deadline are late.
+func Summarize(db sql.Executor, epoch types.EpochID, deadline time.Time) (Summary, error) {
+	var summary Summary
+	_, err := db.Exec(`select received < ?2, count(*), ifnull(sum(weight), 0) from active_set_candidates
+		where epoch = ?1 group by 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+			stmt.BindInt64(2, deadline.UnixNano())
+		},
+		func(stmt *sql.Statement) bool {
+			if stmt.ColumnInt(0) == 1 {
+				summary.ATXs = stmt.ColumnInt(1)
+				summary.Weight = uint64(stmt.ColumnInt64(2))
+			} else {
+				summary.LateATXs = stmt.ColumnInt(1)
+				summary.LateWeight = uint64(stmt.ColumnInt64(2))
+			}
+			return true
+		})
+	if err != nil {
+		return Summary{}, fmt.Errorf("summarize candidates in epoch %d: %w", epoch, err)
+	}
+	return summary, nil
+}
+
+// DeleteBefore deletes the candidates of the epochs before the epoch.
+func DeleteBefore(db sql.Executor, epoch types.EpochID) error {
+	if _, err := db.Exec(`delete from active_set_candidates where epoch < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(epoch))
+		}, nil); err != nil {
+		return fmt.Errorf("delete candidates before epoch %d: %w", epoch, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package activecandidates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestCandidates(t *testing.T) {
+	db := localsql.InMemory()
+	deadline := time.Now()
+	candidates := []*Candidate{
+		{ID: types.ATXID{1}, Node: types.NodeID{1}, Weight: 10, Received: deadline.Add(-time.Second)},
+		{ID: types.ATXID{2}, Node: types.NodeID{2}, Weight: 20, Received: deadline.Add(-time.Minute)},
+		{ID: types.ATXID{3}, Node: types.NodeID{3}, Weight: 5, Received: deadline.Add(time.Second)},
+	}
+	for _, candidate := range candidates {
+		added, err := Add(db, 3, candidate)
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	added, err := Add(db, 3, candidates[0])
+	require.NoError(t, err)
+	require.False(t, added)
+	added, err = Add(db, 4, candidates[0])
+	require.NoError(t, err)
+	require.True(t, added)
+
+	summary, err := Summarize(db, 3, deadline)
+	require.NoError(t, err)
+	require.Equal(t, Summary{ATXs: 2, Weight: 30, LateATXs: 1, LateWeight: 5}, summary)
+	summary, err = Summarize(db, 5, deadline)
+	require.NoError(t, err)
+	require.Equal(t, Summary{}, summary)
+
+	got, err := GetByNode(db, 3, types.NodeID{2})
+	require.NoError(t, err)
+	require.Equal(t, candidates[1].ID, got.ID)
+	require.Equal(t, candidates[1].Weight, got.Weight)
+	require.True(t, candidates[1].Received.Equal(got.Received))
+	_, err = GetByNode(db, 4, types.NodeID{2})
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	var ids []types.ATXID
+	require.NoError(t, IterateEpoch(db, 3, func(c *Candidate) bool {
+		ids = append(ids, c.ID)
+		return true
+	}))
+	require.ElementsMatch(t, []types.ATXID{{1}, {2}, {3}}, ids)
+
+	require.NoError(t, DeleteBefore(db, 4))
+	summary, err = Summarize(db, 3, deadline)
+	require.NoError(t, err)
+	require.Equal(t, Summary{}, summary)
+	summary, err = Summarize(db, 4, deadline)
+	require.NoError(t, err)
+	require.Equal(t, Summary{ATXs: 1, Weight: 10}, summary)
+}
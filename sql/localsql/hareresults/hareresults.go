@@ -0,0 +1,90 @@
+// Package hareresults stores the outcome of hare sessions observed by the node.
+// Results depend on the view of the node, therefore they are kept in the local database.
+package hareresults
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Result is the outcome of the hare session in the layer.
+type Result struct {
+	Layer types.LayerID
+	// Terminated is true if the session reached agreement.
+	Terminated bool
+	// Iterations is the number of iterations completed by the session.
+	Iterations uint8
+	// Participation is the sum of eligibilities of the identities that participated in the preround.
+	Participation uint32
+	// Threshold is the number of eligibilities required to cross the threshold in the session.
+	Threshold uint16
+}
+
+// Add stores the result of the hare session, replacing previously stored result for the same layer.
+func Add(db sql.Executor, result Result) error {
+	_, err := db.Exec(`insert into hare_results (layer, terminated, iterations, participation, threshold)
+		values (?1, ?2, ?3, ?4, ?5)
+		on conflict (layer) do update set
+			terminated = ?2, iterations = ?3, participation = ?4, threshold = ?5;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(result.Layer))
+			stmt.BindBool(2, result.Terminated)
+			stmt.BindInt64(3, int64(result.Iterations))
+			stmt.BindInt64(4, int64(result.Participation))
+			stmt.BindInt64(5, int64(result.Threshold))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert hare result for layer %d: %w", result.Layer, err)
+	}
+	return nil
+}
+
+func decodeResult(stmt *sql.Statement) Result {
+	return Result{
+		Layer:         types.LayerID(stmt.ColumnInt64(0)),
+		Terminated:    stmt.ColumnInt(1) != 0,
+		Iterations:    uint8(stmt.ColumnInt64(2)),
+		Participation: uint32(stmt.ColumnInt64(3)),
+		Threshold:     uint16(stmt.ColumnInt64(4)),
+	}
+}
+
+// Get returns the result of the hare session in the layer.
+func Get(db sql.Executor, layer types.LayerID) (Result, error) {
+	var result Result
+	rows, err := db.Exec(`select layer, terminated, iterations, participation, threshold
+		from hare_results where layer = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		},
+		func(stmt *sql.Statement) bool {
+			result = decodeResult(stmt)
+			return true
+		})
+	if err != nil {
+		return Result{}, fmt.Errorf("select hare result for layer %d: %w", layer, err)
+	}
+	if rows == 0 {
+		return Result{}, fmt.Errorf("hare result for layer %d: %w", layer, sql.ErrNotFound)
+	}
+	return result, nil
+}
+
+// IterateRange calls fn for results in layers [from, to] in ascending order, until fn returns false.
+func IterateRange(db sql.Executor, from, to types.LayerID, fn func(Result) bool) error {
+	_, err := db.Exec(`select layer, terminated, iterations, participation, threshold
+		from hare_results where layer between ?1 and ?2 order by layer asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+			stmt.BindInt64(2, int64(to))
+		},
+		func(stmt *sql.Statement) bool {
+			return fn(decodeResult(stmt))
+		})
+	if err != nil {
+		return fmt.Errorf("select hare results in [%d, %d]: %w", from, to, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package hareresults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestResults(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := Get(db, 1)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	var results []Result
+	for layer := types.LayerID(1); layer <= 5; layer++ {
+		result := Result{
+			Layer:         layer,
+			Terminated:    layer%2 == 0,
+			Iterations:    uint8(layer),
+			Participation: 300 + layer.Uint32(),
+			Threshold:     401,
+		}
+		require.NoError(t, Add(db, result))
+		results = append(results, result)
+	}
+	results[4].Terminated = true
+	require.NoError(t, Add(db, results[4]))
+
+	result, err := Get(db, 5)
+	require.NoError(t, err)
+	require.Equal(t, results[4], result)
+
+	var rst []Result
+	require.NoError(t, IterateRange(db, 2, 4, func(result Result) bool {
+		rst = append(rst, result)
+		return true
+	}))
+	require.Equal(t, results[1:4], rst)
+
+	rst = nil
+	require.NoError(t, IterateRange(db, 1, 5, func(result Result) bool {
+		rst = append(rst, result)
+		return len(rst) < 2
+	}))
+	require.Equal(t, results[:2], rst)
+}
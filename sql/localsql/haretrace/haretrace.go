@@ -0,0 +1,70 @@
+// Package haretrace stores compressed traces of hare sessions for postmortem analysis.
+package haretrace
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Add stores the trace of the hare session in the layer, replacing previously stored trace.
+func Add(db sql.Executor, layer types.LayerID, trace []byte) error {
+	_, err := db.Exec(`insert into hare_traces (layer, trace) values (?1, ?2)
+		on conflict (layer) do update set trace = ?2;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+			stmt.BindBytes(2, trace)
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert trace for layer %d: %w", layer, err)
+	}
+	return nil
+}
+
+// Get returns the trace of the hare session in the layer.
+func Get(db sql.Executor, layer types.LayerID) ([]byte, error) {
+	var trace []byte
+	rows, err := db.Exec(`select trace from hare_traces where layer = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		},
+		func(stmt *sql.Statement) bool {
+			trace = make([]byte, stmt.ColumnLen(0))
+			stmt.ColumnBytes(0, trace)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select trace for layer %d: %w", layer, err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("trace for layer %d: %w", layer, sql.ErrNotFound)
+	}
+	return trace, nil
+}
+
+// Layers returns all layers with a stored trace in ascending order.
+func Layers(db sql.Executor) ([]types.LayerID, error) {
+	var layers []types.LayerID
+	_, err := db.Exec(`select layer from hare_traces order by layer asc;`, nil,
+		func(stmt *sql.Statement) bool {
+			layers = append(layers, types.LayerID(stmt.ColumnInt64(0)))
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select traced layers: %w", err)
+	}
+	return layers, nil
+}
+
+// Prune deletes traces of all layers lower than the layer.
+func Prune(db sql.Executor, layer types.LayerID) error {
+	_, err := db.Exec(`delete from hare_traces where layer < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("prune traces before layer %d: %w", layer, err)
+	}
+	return nil
+}
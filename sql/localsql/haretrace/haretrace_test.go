@@ -0,0 +1,31 @@
+package haretrace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestTraces(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := Get(db, 1)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	for layer := types.LayerID(1); layer <= 5; layer++ {
+		require.NoError(t, Add(db, layer, []byte{byte(layer)}))
+	}
+	require.NoError(t, Add(db, 5, []byte{5, 5}))
+	trace, err := Get(db, 5)
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 5}, trace)
+
+	require.NoError(t, Prune(db, 3))
+	layers, err := Layers(db)
+	require.NoError(t, err)
+	require.Equal(t, []types.LayerID{3, 4, 5}, layers)
+}
@@ -0,0 +1,89 @@
+// Package journal stores recent user events so that they can be replayed to api clients after restarts.
+package journal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Entry is an encoded event with the attributes used to filter events without decoding them.
+type Entry struct {
+	Seq       uint64
+	Timestamp time.Time
+	Severity  uint8
+	Cause     string
+	Event     []byte
+}
+
+// Add stores an encoded event.
+func Add(db sql.Executor, entry Entry) error {
+	_, err := db.Exec(`insert into events_journal (seq, timestamp, event, severity, cause)
+		values (?1, ?2, ?3, ?4, ?5);`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(entry.Seq))
+			stmt.BindInt64(2, entry.Timestamp.UnixNano())
+			stmt.BindBytes(3, entry.Event)
+			stmt.BindInt64(4, int64(entry.Severity))
+			stmt.BindText(5, entry.Cause)
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert event %d: %w", entry.Seq, err)
+	}
+	return nil
+}
+
+// Prune deletes all events with a sequence number lower than seq.
+func Prune(db sql.Executor, seq uint64) error {
+	_, err := db.Exec(`delete from events_journal where seq < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(seq))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("prune events before %d: %w", seq, err)
+	}
+	return nil
+}
+
+// Last returns the highest sequence number stored in the journal.
+func Last(db sql.Executor) (uint64, error) {
+	var seq uint64
+	rows, err := db.Exec(`select max(seq) from events_journal;`, nil,
+		func(stmt *sql.Statement) bool {
+			seq = uint64(stmt.ColumnInt64(0))
+			return true
+		})
+	if err != nil {
+		return 0, fmt.Errorf("select last event: %w", err)
+	}
+	if rows == 0 || seq == 0 {
+		return 0, sql.ErrNotFound
+	}
+	return seq, nil
+}
+
+// IterateAfter calls fn for every event with a sequence number greater than seq, in ascending order.
+// Iteration stops when fn returns false.
+func IterateAfter(db sql.Executor, seq uint64, fn func(Entry) bool) error {
+	_, err := db.Exec(`select seq, timestamp, event, severity, cause from events_journal
+		where seq > ?1 order by seq asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(seq))
+		},
+		func(stmt *sql.Statement) bool {
+			entry := Entry{
+				Seq:       uint64(stmt.ColumnInt64(0)),
+				Timestamp: time.Unix(0, stmt.ColumnInt64(1)),
+				Event:     make([]byte, stmt.ColumnLen(2)),
+				Severity:  uint8(stmt.ColumnInt(3)),
+				Cause:     stmt.ColumnText(4),
+			}
+			stmt.ColumnBytes(2, entry.Event)
+			return fn(entry)
+		})
+	if err != nil {
+		return fmt.Errorf("select events after %d: %w", seq, err)
+	}
+	return nil
+}
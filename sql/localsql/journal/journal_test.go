@@ -0,0 +1,54 @@
+package journal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestJournal(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := Last(db)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	now := time.Now()
+	for seq := uint64(1); seq <= 10; seq++ {
+		require.NoError(t, Add(db, Entry{
+			Seq:       seq,
+			Timestamp: now.Add(time.Duration(seq)),
+			Severity:  uint8(seq % 3),
+			Cause:     fmt.Sprintf("cause %d", seq),
+			Event:     []byte{byte(seq)},
+		}))
+	}
+	last, err := Last(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, last)
+
+	var seqs []uint64
+	require.NoError(t, IterateAfter(db, 7, func(entry Entry) bool {
+		require.Equal(t, []byte{byte(entry.Seq)}, entry.Event)
+		require.True(t, now.Add(time.Duration(entry.Seq)).Equal(entry.Timestamp))
+		require.EqualValues(t, entry.Seq%3, entry.Severity)
+		require.Equal(t, fmt.Sprintf("cause %d", entry.Seq), entry.Cause)
+		seqs = append(seqs, entry.Seq)
+		return true
+	}))
+	require.Equal(t, []uint64{8, 9, 10}, seqs)
+
+	require.NoError(t, Prune(db, 5))
+	seqs = seqs[:0]
+	require.NoError(t, IterateAfter(db, 0, func(entry Entry) bool {
+		seqs = append(seqs, entry.Seq)
+		return len(seqs) < 2
+	}))
+	require.Equal(t, []uint64{5, 6}, seqs)
+
+	require.Error(t, Add(db, Entry{Seq: 10, Timestamp: now, Event: []byte{1}}))
+}
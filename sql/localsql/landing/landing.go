@@ -0,0 +1,105 @@
+// Package landing stores the ballots that were signed by the identities of the node, so that the node
+// doesn't sign a second ballot in the same layer after a restart and recognizes ballots of its identities
+// that were signed by another instance.
+package landing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Record is the ballot that was signed by the identity in the layer.
+type Record struct {
+	Node      types.NodeID
+	Layer     types.LayerID
+	Ballot    types.BallotID
+	Signature types.EdSignature
+	// Instance is the instance of the node that signed the ballot.
+	Instance string
+	Created  time.Time
+}
+
+// Add stores the record. Returns false if the identity already landed a ballot in the layer.
+func Add(db sql.Executor, record *Record) (bool, error) {
+	rows, err := db.Exec(`insert into signing_landing (id, layer, ballot, signature, instance, created)
+		values (?1, ?2, ?3, ?4, ?5, ?6) on conflict do nothing returning 1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, record.Node[:])
+			stmt.BindInt64(2, int64(record.Layer))
+			stmt.BindBytes(3, record.Ballot[:])
+			stmt.BindBytes(4, record.Signature[:])
+			stmt.BindText(5, record.Instance)
+			stmt.BindInt64(6, record.Created.UnixNano())
+		}, nil)
+	if err != nil {
+		return false, fmt.Errorf("insert landing of %s in layer %d: %w", record.Node, record.Layer, err)
+	}
+	return rows > 0, nil
+}
+
+// Get returns the record of the identity in the layer.
+func Get(db sql.Executor, node types.NodeID, layer types.LayerID) (*Record, error) {
+	var record *Record
+	rows, err := db.Exec(`select ballot, signature, instance, created from signing_landing
+		where id = ?1 and layer = ?2;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, node[:])
+			stmt.BindInt64(2, int64(layer))
+		},
+		func(stmt *sql.Statement) bool {
+			record = &Record{Node: node, Layer: layer}
+			stmt.ColumnBytes(0, record.Ballot[:])
+			stmt.ColumnBytes(1, record.Signature[:])
+			record.Instance = stmt.ColumnText(2)
+			record.Created = time.Unix(0, stmt.ColumnInt64(3)).Local()
+			return false
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select landing of %s in layer %d: %w", node, layer, err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("landing of %s in layer %d: %w", node, layer, sql.ErrNotFound)
+	}
+	return record, nil
+}
+
+// PruneBefore deletes the records of the layers before the layer.
+func PruneBefore(db sql.Executor, layer types.LayerID) error {
+	if _, err := db.Exec(`delete from signing_landing where layer < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		}, nil); err != nil {
+		return fmt.Errorf("prune landings before %d: %w", layer, err)
+	}
+	return nil
+}
+
+// Instance returns the id of the instance that owns the database. The id is generated when it is requested
+// for the first time, it stays the same after restarts and differs between the copies of the identity
+// that run with separate local databases.
+func Instance(db sql.Executor) (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("generate instance id: %w", err)
+	}
+	if _, err := db.Exec(`insert into signing_instance (id, instance) values (1, ?1) on conflict do nothing;`,
+		func(stmt *sql.Statement) {
+			stmt.BindText(1, hex.EncodeToString(buf[:]))
+		}, nil); err != nil {
+		return "", fmt.Errorf("insert instance: %w", err)
+	}
+	var instance string
+	if _, err := db.Exec(`select instance from signing_instance where id = 1;`, nil,
+		func(stmt *sql.Statement) bool {
+			instance = stmt.ColumnText(0)
+			return false
+		}); err != nil {
+		return "", fmt.Errorf("select instance: %w", err)
+	}
+	return instance, nil
+}
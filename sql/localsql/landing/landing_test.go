@@ -0,0 +1,68 @@
+package landing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestLanding(t *testing.T) {
+	db := localsql.InMemory()
+	node := types.NodeID{1}
+
+	_, err := Get(db, node, 10)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	record := &Record{
+		Node:      node,
+		Layer:     10,
+		Ballot:    types.BallotID{1},
+		Signature: types.EdSignature{2},
+		Instance:  "first",
+		Created:   time.Unix(0, 100).Local(),
+	}
+	added, err := Add(db, record)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	other := *record
+	other.Ballot = types.BallotID{2}
+	added, err = Add(db, &other)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	got, err := Get(db, node, 10)
+	require.NoError(t, err)
+	require.Equal(t, record, got)
+
+	other.Layer = 11
+	added, err = Add(db, &other)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	require.NoError(t, PruneBefore(db, 11))
+	_, err = Get(db, node, 10)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	_, err = Get(db, node, 11)
+	require.NoError(t, err)
+}
+
+func TestInstance(t *testing.T) {
+	db := localsql.InMemory()
+	instance, err := Instance(db)
+	require.NoError(t, err)
+	require.NotEmpty(t, instance)
+
+	again, err := Instance(db)
+	require.NoError(t, err)
+	require.Equal(t, instance, again)
+
+	other, err := Instance(localsql.InMemory())
+	require.NoError(t, err)
+	require.NotEqual(t, instance, other)
+}
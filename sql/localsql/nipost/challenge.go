@@ -158,3 +158,16 @@ func PoetProofRef(db sql.Executor, nodeID types.NodeID) (types.PoetProofRef, *ty
 	}
 	return ref, membership, nil
 }
+
+func clearPoetProofRef(db sql.Executor, nodeID types.NodeID) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, nodeID.Bytes())
+	}
+	if _, err := db.Exec(`
+		update challenge set poet_proof_ref = null, poet_proof_membership = null
+		where id = ?1;`, enc, nil,
+	); err != nil {
+		return fmt.Errorf("clear poet proof ref for node id %s: %w", nodeID.ShortString(), err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package nipost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Phase is the persisted phase of the nipost construction of the identity.
+type Phase int
+
+const (
+	// PhaseNone is the phase of the identity that doesn't build a nipost.
+	PhaseNone Phase = iota
+	// PhaseChallenge is the phase after the challenge is built and stored.
+	PhaseChallenge
+	// PhaseRegistered is the phase after the challenge is registered in at least one poet.
+	PhaseRegistered
+	// PhaseProofAvailable is the phase after the poet proof that includes the challenge is selected.
+	PhaseProofAvailable
+	// PhasePostBuilt is the phase after the nipost is built and stored.
+	PhasePostBuilt
+	// PhasePublished is the phase after the atx with the nipost is published.
+	PhasePublished
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseNone:
+		return "none"
+	case PhaseChallenge:
+		return "challenge"
+	case PhaseRegistered:
+		return "registered"
+	case PhaseProofAvailable:
+		return "proof-available"
+	case PhasePostBuilt:
+		return "post-built"
+	case PhasePublished:
+		return "published"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+	}
+}
+
+// ErrInvalidTransition is returned if the phase can't be changed to the requested one.
+var ErrInvalidTransition = errors.New("invalid nipost phase transition")
+
+// PhaseState is the phase of the identity with the publish epoch of the challenge.
+type PhaseState struct {
+	Phase        Phase
+	PublishEpoch types.EpochID
+	Updated      time.Time
+}
+
+// validTransition returns true if the phase can be changed from one to another during the construction.
+// Staying in the same phase is valid, so that the transition can be repeated after restart.
+func validTransition(from, to Phase) bool {
+	switch {
+	case from == to:
+		return to != PhaseNone
+	case to == PhaseChallenge:
+		return from == PhaseNone || from == PhasePublished
+	default:
+		return from != PhaseNone && to == from+1 && to <= PhasePublished
+	}
+}
+
+// GetPhase returns the phase of the identity. PhaseNone is returned if the identity doesn't build a nipost.
+func GetPhase(db sql.Executor, nodeID types.NodeID) (PhaseState, error) {
+	state := PhaseState{Phase: PhaseNone}
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, nodeID.Bytes())
+	}
+	dec := func(stmt *sql.Statement) bool {
+		state.Phase = Phase(stmt.ColumnInt64(0))
+		state.PublishEpoch = types.EpochID(stmt.ColumnInt64(1))
+		state.Updated = time.Unix(stmt.ColumnInt64(2), 0)
+		return true
+	}
+	if _, err := db.Exec(`select phase, epoch, updated from nipost_phase where id = ?1;`, enc, dec); err != nil {
+		return PhaseState{}, fmt.Errorf("get nipost phase for %s: %w", nodeID.ShortString(), err)
+	}
+	return state, nil
+}
+
+// SetPhase moves the identity to the next phase of the construction of the nipost for the challenge
+// with the publish epoch. ErrInvalidTransition is returned if the phase is not the next one.
+func SetPhase(db sql.Executor, nodeID types.NodeID, phase Phase, publish types.EpochID) error {
+	current, err := GetPhase(db, nodeID)
+	if err != nil {
+		return err
+	}
+	if !validTransition(current.Phase, phase) {
+		return fmt.Errorf("%w: from %s to %s for %s", ErrInvalidTransition, current.Phase, phase, nodeID.ShortString())
+	}
+	return setPhase(db, nodeID, phase, publish)
+}
+
+func setPhase(db sql.Executor, nodeID types.NodeID, phase Phase, publish types.EpochID) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, nodeID.Bytes())
+		stmt.BindInt64(2, int64(phase))
+		stmt.BindInt64(3, int64(publish))
+		stmt.BindInt64(4, time.Now().Unix())
+	}
+	if _, err := db.Exec(`
+		insert into nipost_phase (id, phase, epoch, updated) values (?1, ?2, ?3, ?4)
+		on conflict (id) do update set phase = ?2, epoch = ?3, updated = ?4;`, enc, nil,
+	); err != nil {
+		return fmt.Errorf("set nipost phase %s for %s: %w", phase, nodeID.ShortString(), err)
+	}
+	return nil
+}
+
+// ResetPhase discards the state of the phases after the phase and moves the identity back to it.
+// The state is discarded even if the identity is not past the phase, so that the state that was stored
+// without advancing the phase is discarded as well.
+//
+// Resetting to PhaseNone discards all the state of the identity, including the published phase.
+// Resetting to other phases keeps the published phase, the published atx can't be built again.
+func ResetPhase(db sql.Executor, nodeID types.NodeID, phase Phase) error {
+	if phase >= PhasePublished || phase < PhaseNone {
+		return fmt.Errorf("%w: reset to %s for %s", ErrInvalidTransition, phase, nodeID.ShortString())
+	}
+	current, err := GetPhase(db, nodeID)
+	if err != nil {
+		return err
+	}
+	if phase < PhasePostBuilt {
+		if err := RemoveNIPost(db, nodeID); err != nil {
+			return err
+		}
+	}
+	if phase < PhaseProofAvailable {
+		if err := clearPoetProofRef(db, nodeID); err != nil {
+			return err
+		}
+	}
+	if phase < PhaseRegistered {
+		if err := ClearPoetRegistrations(db, nodeID); err != nil {
+			return err
+		}
+	}
+	if phase < PhaseChallenge {
+		if err := RemoveChallenge(db, nodeID); err != nil {
+			return err
+		}
+		return removePhase(db, nodeID)
+	}
+	if current.Phase > phase && current.Phase != PhasePublished {
+		return setPhase(db, nodeID, phase, current.PublishEpoch)
+	}
+	return nil
+}
+
+func removePhase(db sql.Executor, nodeID types.NodeID) error {
+	enc := func(stmt *sql.Statement) {
+		stmt.BindBytes(1, nodeID.Bytes())
+	}
+	if _, err := db.Exec(`delete from nipost_phase where id = ?1;`, enc, nil); err != nil {
+		return fmt.Errorf("remove nipost phase for %s: %w", nodeID.ShortString(), err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package nipost
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func Test_Phase_Transitions(t *testing.T) {
+	db := localsql.InMemory()
+	nodeID := types.RandomNodeID()
+
+	state, err := GetPhase(db, nodeID)
+	require.NoError(t, err)
+	require.Equal(t, PhaseNone, state.Phase)
+
+	require.ErrorIs(t, SetPhase(db, nodeID, PhaseRegistered, 2), ErrInvalidTransition)
+	require.ErrorIs(t, SetPhase(db, nodeID, PhaseNone, 2), ErrInvalidTransition)
+
+	for phase := PhaseChallenge; phase <= PhasePublished; phase++ {
+		require.NoError(t, SetPhase(db, nodeID, phase, 2))
+		// repeating the transition after restart is valid
+		require.NoError(t, SetPhase(db, nodeID, phase, 2))
+
+		state, err := GetPhase(db, nodeID)
+		require.NoError(t, err)
+		require.Equal(t, phase, state.Phase)
+		require.Equal(t, types.EpochID(2), state.PublishEpoch)
+		require.WithinDuration(t, time.Now(), state.Updated, 2*time.Second)
+	}
+	require.ErrorIs(t, SetPhase(db, nodeID, PhasePostBuilt, 2), ErrInvalidTransition)
+
+	// the next challenge is built after the atx is published
+	require.NoError(t, SetPhase(db, nodeID, PhaseChallenge, 3))
+	require.ErrorIs(t, SetPhase(db, nodeID, PhaseProofAvailable, 3), ErrInvalidTransition)
+	state, err = GetPhase(db, nodeID)
+	require.NoError(t, err)
+	require.Equal(t, PhaseState{Phase: PhaseChallenge, PublishEpoch: 3, Updated: state.Updated}, state)
+}
+
+func Test_ResetPhase(t *testing.T) {
+	setup := func(tb testing.TB, db sql.Executor, nodeID types.NodeID) {
+		challenge := &types.NIPostChallenge{PublishEpoch: 2}
+		require.NoError(tb, AddChallenge(db, nodeID, challenge))
+		require.NoError(tb, SetPhase(db, nodeID, PhaseChallenge, 2))
+		require.NoError(tb, AddPoetRegistration(db, nodeID, PoETRegistration{
+			ChallengeHash: challenge.Hash(),
+			Address:       "address",
+			RoundID:       "round",
+			RoundEnd:      time.Now().Round(time.Second),
+		}))
+		require.NoError(tb, SetPhase(db, nodeID, PhaseRegistered, 2))
+		require.NoError(tb, UpdatePoetProofRef(db, nodeID, types.PoetProofRef{1}, &types.MerkleProof{}))
+		require.NoError(tb, SetPhase(db, nodeID, PhaseProofAvailable, 2))
+		require.NoError(tb, AddNIPost(db, nodeID, &NIPostState{
+			NIPost: &types.NIPost{
+				Post:         &types.Post{Indices: []byte{1, 2, 3}},
+				PostMetadata: &types.PostMetadata{Challenge: types.RandomHash().Bytes()},
+			},
+		}))
+		require.NoError(tb, SetPhase(db, nodeID, PhasePostBuilt, 2))
+	}
+
+	for _, phase := range []Phase{PhaseNone, PhaseChallenge, PhaseRegistered, PhaseProofAvailable, PhasePostBuilt} {
+		phase := phase
+		t.Run(phase.String(), func(t *testing.T) {
+			db := localsql.InMemory()
+			nodeID := types.RandomNodeID()
+			setup(t, db, nodeID)
+
+			require.NoError(t, ResetPhase(db, nodeID, phase))
+			state, err := GetPhase(db, nodeID)
+			require.NoError(t, err)
+			require.Equal(t, phase, state.Phase)
+
+			_, err = Challenge(db, nodeID)
+			require.Equal(t, phase < PhaseChallenge, err != nil)
+			count, err := PoetRegistrationCount(db, nodeID)
+			require.NoError(t, err)
+			require.Equal(t, phase >= PhaseRegistered, count > 0)
+			_, _, err = PoetProofRef(db, nodeID)
+			require.Equal(t, phase < PhaseProofAvailable, err != nil)
+			_, err = NIPost(db, nodeID)
+			require.Equal(t, phase < PhasePostBuilt, err != nil)
+		})
+	}
+	t.Run("published", func(t *testing.T) {
+		db := localsql.InMemory()
+		nodeID := types.RandomNodeID()
+		setup(t, db, nodeID)
+		require.NoError(t, SetPhase(db, nodeID, PhasePublished, 2))
+
+		require.ErrorIs(t, ResetPhase(db, nodeID, PhasePublished), ErrInvalidTransition)
+		require.NoError(t, ResetPhase(db, nodeID, PhaseChallenge))
+		state, err := GetPhase(db, nodeID)
+		require.NoError(t, err)
+		require.Equal(t, PhasePublished, state.Phase)
+		count, err := PoetRegistrationCount(db, nodeID)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		require.NoError(t, ResetPhase(db, nodeID, PhaseNone))
+		state, err = GetPhase(db, nodeID)
+		require.NoError(t, err)
+		require.Equal(t, PhaseNone, state.Phase)
+	})
+}
+
+func Test_PhaseMigration_DerivesPhases(t *testing.T) {
+	migrations, err := sql.LocalMigrations()
+	require.NoError(t, err)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Order() < migrations[j].Order() })
+	require.Equal(t, 11, migrations[10].Order())
+	db := localsql.InMemory(sql.WithMigrations(migrations[:10]))
+
+	expected := map[types.NodeID]PhaseState{}
+	for _, phase := range []Phase{PhaseChallenge, PhaseRegistered, PhaseProofAvailable, PhasePostBuilt} {
+		nodeID := types.RandomNodeID()
+		challenge := &types.NIPostChallenge{PublishEpoch: types.EpochID(phase)}
+		require.NoError(t, AddChallenge(db, nodeID, challenge))
+		if phase >= PhaseRegistered {
+			require.NoError(t, AddPoetRegistration(db, nodeID, PoETRegistration{
+				ChallengeHash: challenge.Hash(),
+				Address:       "address",
+				RoundID:       "round",
+			}))
+		}
+		if phase >= PhaseProofAvailable {
+			require.NoError(t, UpdatePoetProofRef(db, nodeID, types.PoetProofRef{1}, &types.MerkleProof{}))
+		}
+		if phase >= PhasePostBuilt {
+			require.NoError(t, AddNIPost(db, nodeID, &NIPostState{
+				NIPost: &types.NIPost{
+					Post:         &types.Post{Indices: []byte{1, 2, 3}},
+					PostMetadata: &types.PostMetadata{Challenge: types.RandomHash().Bytes()},
+				},
+			}))
+		}
+		expected[nodeID] = PhaseState{Phase: phase, PublishEpoch: challenge.PublishEpoch}
+	}
+
+	require.NoError(t, migrations[10].Apply(db))
+	for nodeID, expect := range expected {
+		state, err := GetPhase(db, nodeID)
+		require.NoError(t, err)
+		require.Equal(t, expect.Phase, state.Phase)
+		require.Equal(t, expect.PublishEpoch, state.PublishEpoch)
+	}
+}
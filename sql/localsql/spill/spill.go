@@ -0,0 +1,158 @@
+// Package spill stores ballots and opinions that the tortoise moved out of memory.
+// The data is only valid for the lifetime of the tortoise instance that wrote it.
+package spill
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// AddBallot stores the ballot.
+func AddBallot(db sql.Executor, ballot *Ballot) error {
+	_, err := db.Exec(`insert into tortoise_spilled_ballots (id, layer, ballot) values (?1, ?2, ?3)
+		on conflict (id) do update set ballot = ?3;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, ballot.ID[:])
+			stmt.BindInt64(2, int64(ballot.Layer))
+			stmt.BindBytes(3, codec.MustEncode(ballot))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert spilled ballot %s: %w", ballot.ID, err)
+	}
+	return nil
+}
+
+func decodeBallot(stmt *sql.Statement, col int) (*Ballot, error) {
+	var ballot Ballot
+	if _, err := codec.DecodeFrom(stmt.ColumnReader(col), &ballot); err != nil {
+		return nil, fmt.Errorf("decode spilled ballot: %w", err)
+	}
+	return &ballot, nil
+}
+
+// GetBallot returns the stored ballot.
+func GetBallot(db sql.Executor, id types.BallotID) (*Ballot, error) {
+	var (
+		ballot *Ballot
+		derr   error
+	)
+	rows, err := db.Exec(`select ballot from tortoise_spilled_ballots where id = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, id[:])
+		},
+		func(stmt *sql.Statement) bool {
+			ballot, derr = decodeBallot(stmt, 0)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select spilled ballot %s: %w", id, err)
+	}
+	if derr != nil {
+		return nil, derr
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("spilled ballot %s: %w", id, sql.ErrNotFound)
+	}
+	return ballot, nil
+}
+
+// LayerBallots returns ballots stored in the layer, in the order they were added.
+func LayerBallots(db sql.Executor, layer types.LayerID) ([]*Ballot, error) {
+	var (
+		ballots []*Ballot
+		derr    error
+	)
+	_, err := db.Exec(`select ballot from tortoise_spilled_ballots where layer = ?1 order by rowid asc;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		},
+		func(stmt *sql.Statement) bool {
+			var ballot *Ballot
+			ballot, derr = decodeBallot(stmt, 0)
+			if derr != nil {
+				return false
+			}
+			ballots = append(ballots, ballot)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select spilled ballots in layer %d: %w", layer, err)
+	}
+	if derr != nil {
+		return nil, derr
+	}
+	return ballots, nil
+}
+
+// AddOpinion stores the opinion. Opinions are identified by hash, therefore
+// the opinion is not updated if it was already stored.
+func AddOpinion(db sql.Executor, opinion *Opinion) error {
+	_, err := db.Exec(`insert into tortoise_spilled_opinions (opinion, layer, votes) values (?1, ?2, ?3)
+		on conflict (opinion) do nothing;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, opinion.Hash[:])
+			stmt.BindInt64(2, int64(opinion.Layer))
+			stmt.BindBytes(3, codec.MustEncode(opinion))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("insert spilled opinion %s: %w", opinion.Hash.ShortString(), err)
+	}
+	return nil
+}
+
+// GetOpinion returns the stored opinion.
+func GetOpinion(db sql.Executor, hash types.Hash32) (*Opinion, error) {
+	var (
+		opinion Opinion
+		derr    error
+	)
+	rows, err := db.Exec(`select votes from tortoise_spilled_opinions where opinion = ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, hash[:])
+		},
+		func(stmt *sql.Statement) bool {
+			_, derr = codec.DecodeFrom(stmt.ColumnReader(0), &opinion)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select spilled opinion %s: %w", hash.ShortString(), err)
+	}
+	if derr != nil {
+		return nil, fmt.Errorf("decode spilled opinion %s: %w", hash.ShortString(), derr)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("spilled opinion %s: %w", hash.ShortString(), sql.ErrNotFound)
+	}
+	return &opinion, nil
+}
+
+// Prune deletes ballots and opinions in layers lower than the layer.
+func Prune(db sql.Executor, layer types.LayerID) error {
+	if _, err := db.Exec(`delete from tortoise_spilled_ballots where layer < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		}, nil); err != nil {
+		return fmt.Errorf("prune spilled ballots before layer %d: %w", layer, err)
+	}
+	if _, err := db.Exec(`delete from tortoise_spilled_opinions where layer < ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(layer))
+		}, nil); err != nil {
+		return fmt.Errorf("prune spilled opinions before layer %d: %w", layer, err)
+	}
+	return nil
+}
+
+// Clear deletes everything that was stored.
+func Clear(db sql.Executor) error {
+	if _, err := db.Exec(`delete from tortoise_spilled_ballots;`, nil, nil); err != nil {
+		return fmt.Errorf("clear spilled ballots: %w", err)
+	}
+	if _, err := db.Exec(`delete from tortoise_spilled_opinions;`, nil, nil); err != nil {
+		return fmt.Errorf("clear spilled opinions: %w", err)
+	}
+	return nil
+}
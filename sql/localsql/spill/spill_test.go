@@ -0,0 +1,91 @@
+package spill
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+)
+
+func TestBallots(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := GetBallot(db, types.RandomBallotID())
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	var stored []*Ballot
+	for layer := types.LayerID(1); layer <= 3; layer++ {
+		for i := 0; i < 3; i++ {
+			ballot := &Ballot{
+				ID:          types.RandomBallotID(),
+				Layer:       layer,
+				BaseID:      types.RandomBallotID(),
+				BaseLayer:   layer - 1,
+				BadBeacon:   i == 1,
+				Smesher:     types.RandomNodeID(),
+				ATXID:       types.RandomATXID(),
+				WeightNum:   10,
+				WeightDenom: 3,
+				Height:      100,
+				Opinion:     types.RandomHash(),
+			}
+			ballot.Weight[0] = byte(i)
+			require.NoError(t, AddBallot(db, ballot))
+			stored = append(stored, ballot)
+		}
+	}
+	got, err := GetBallot(db, stored[4].ID)
+	require.NoError(t, err)
+	require.Equal(t, stored[4], got)
+
+	ballots, err := LayerBallots(db, 2)
+	require.NoError(t, err)
+	require.Equal(t, stored[3:6], ballots)
+
+	require.NoError(t, Prune(db, 3))
+	ballots, err = LayerBallots(db, 2)
+	require.NoError(t, err)
+	require.Empty(t, ballots)
+	ballots, err = LayerBallots(db, 3)
+	require.NoError(t, err)
+	require.Len(t, ballots, 3)
+
+	require.NoError(t, Clear(db))
+	_, err = GetBallot(db, stored[8].ID)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
+
+func TestOpinions(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := GetOpinion(db, types.RandomHash())
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	first := &Opinion{Hash: types.RandomHash(), Layer: 1, Vote: 2}
+	second := &Opinion{
+		Hash:      types.RandomHash(),
+		Layer:     2,
+		Supported: []types.Vote{{ID: types.RandomBlockID(), LayerID: 2, Height: 10}},
+		Prev:      first.Hash,
+	}
+	require.NoError(t, AddOpinion(db, first))
+	require.NoError(t, AddOpinion(db, second))
+	// opinion with the same hash is not overwritten
+	require.NoError(t, AddOpinion(db, &Opinion{Hash: first.Hash, Layer: 1}))
+
+	got, err := GetOpinion(db, first.Hash)
+	require.NoError(t, err)
+	require.Equal(t, first, got)
+	got, err = GetOpinion(db, second.Hash)
+	require.NoError(t, err)
+	require.Equal(t, second, got)
+
+	require.NoError(t, Prune(db, 2))
+	_, err = GetOpinion(db, first.Hash)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	_, err = GetOpinion(db, second.Hash)
+	require.NoError(t, err)
+}
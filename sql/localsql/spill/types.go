@@ -0,0 +1,40 @@
+package spill
+
+import "github.com/spacemeshos/go-spacemesh/common/types"
+
+//go:generate scalegen
+
+// Ballot is the state of the counted ballot.
+type Ballot struct {
+	ID        types.BallotID
+	Layer     types.LayerID
+	BaseID    types.BallotID
+	BaseLayer types.LayerID
+	Malicious bool
+	BadBeacon bool
+	// Weight is the encoded fixed point weight of the ballot.
+	Weight [16]byte
+
+	Smesher         types.NodeID
+	ATXID           types.ATXID
+	ExpectedBallots uint32
+	Beacon          types.Beacon
+	// WeightNum and WeightDenom is a weight of the single eligibility of the smesher.
+	WeightNum   uint64
+	WeightDenom uint64
+	Height      uint64
+
+	// Opinion is the hash of the last layer vote of the ballot. It is empty if the ballot has no votes.
+	Opinion types.Hash32
+}
+
+// Opinion is the vote on a single layer, linked with the vote on the previous layer.
+type Opinion struct {
+	Hash  types.Hash32
+	Layer types.LayerID
+	// Vote is the sign of the vote on the layer, shifted by one to be non-negative.
+	Vote      uint8
+	Supported []types.Vote `scale:"max=10000"`
+	// Prev is empty if there is no vote on the previous layer.
+	Prev types.Hash32
+}
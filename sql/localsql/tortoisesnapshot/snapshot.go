@@ -0,0 +1,87 @@
+// Package tortoisesnapshot stores the latest snapshot of the tortoise state,
+// so that the tortoise can be restored after restart without recounting ballots.
+package tortoisesnapshot
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/sql"
+)
+
+// Write replaces the stored snapshot with the header and layers.
+func Write(db sql.Executor, header *Header, layers []*Layer) error {
+	if err := Clear(db); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`insert into tortoise_snapshot_header (id, header) values (1, ?1);`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, codec.MustEncode(header))
+		}, nil); err != nil {
+		return fmt.Errorf("insert snapshot header: %w", err)
+	}
+	for _, layer := range layers {
+		if _, err := db.Exec(`insert into tortoise_snapshot_layers (layer, state) values (?1, ?2);`,
+			func(stmt *sql.Statement) {
+				stmt.BindInt64(1, int64(layer.Layer))
+				stmt.BindBytes(2, codec.MustEncode(layer))
+			}, nil); err != nil {
+			return fmt.Errorf("insert snapshot layer %d: %w", layer.Layer, err)
+		}
+	}
+	return nil
+}
+
+// GetHeader returns the header of the stored snapshot.
+func GetHeader(db sql.Executor) (*Header, error) {
+	var (
+		header Header
+		derr   error
+	)
+	rows, err := db.Exec(`select header from tortoise_snapshot_header where id = 1;`, nil,
+		func(stmt *sql.Statement) bool {
+			_, derr = codec.DecodeFrom(stmt.ColumnReader(0), &header)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select snapshot header: %w", err)
+	}
+	if derr != nil {
+		return nil, fmt.Errorf("decode snapshot header: %w", derr)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("snapshot header: %w", sql.ErrNotFound)
+	}
+	return &header, nil
+}
+
+// IterateLayers decodes stored layers in ascending order until fn returns false.
+func IterateLayers(db sql.Executor, fn func(*Layer) bool) error {
+	var derr error
+	_, err := db.Exec(`select state from tortoise_snapshot_layers order by layer asc;`, nil,
+		func(stmt *sql.Statement) bool {
+			var layer Layer
+			if _, derr = codec.DecodeFrom(stmt.ColumnReader(0), &layer); derr != nil {
+				return false
+			}
+			return fn(&layer)
+		})
+	if err != nil {
+		return fmt.Errorf("select snapshot layers: %w", err)
+	}
+	if derr != nil {
+		return fmt.Errorf("decode snapshot layer: %w", derr)
+	}
+	return nil
+}
+
+// Clear deletes the stored snapshot.
+func Clear(db sql.Executor) error {
+	if _, err := db.Exec(`delete from tortoise_snapshot_header;`, nil, nil); err != nil {
+		return fmt.Errorf("clear snapshot header: %w", err)
+	}
+	if _, err := db.Exec(`delete from tortoise_snapshot_layers;`, nil, nil); err != nil {
+		return fmt.Errorf("clear snapshot layers: %w", err)
+	}
+	return nil
+}
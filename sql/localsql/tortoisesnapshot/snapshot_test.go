@@ -0,0 +1,79 @@
+package tortoisesnapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/spill"
+)
+
+func TestSnapshot(t *testing.T) {
+	db := localsql.InMemory()
+
+	_, err := GetHeader(db)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+	require.NoError(t, IterateLayers(db, func(*Layer) bool {
+		require.FailNow(t, "no layers expected")
+		return true
+	}))
+
+	header := &Header{
+		Hdist:      10,
+		WindowSize: 100,
+		Last:       12,
+		Verified:   10,
+		Processed:  11,
+		Full:       true,
+		Epochs:     []Epoch{{Epoch: 2, Height: 100, HasBeacon: true, Beacon: types.Beacon{1}}},
+		Delayed:    []Delayed{{Layer: 13, Ballots: []types.BallotID{types.RandomBallotID()}}},
+	}
+	var layers []*Layer
+	for lid := types.LayerID(3); lid <= 5; lid++ {
+		block := types.Vote{ID: types.RandomBlockID(), LayerID: lid, Height: 10}
+		layers = append(layers, &Layer{
+			Layer:          lid,
+			HareTerminated: true,
+			Opinion:        types.RandomHash(),
+			Blocks:         []Block{{Header: block, Hare: 2, Validity: 2, Data: true}},
+			Votes: []spill.Opinion{{
+				Hash:      types.RandomHash(),
+				Layer:     lid,
+				Vote:      1,
+				Supported: []types.Vote{block},
+			}},
+			Ballots: []spill.Ballot{{ID: types.RandomBallotID(), Layer: lid, Opinion: types.RandomHash()}},
+		})
+	}
+	require.NoError(t, Write(db, header, layers))
+
+	got, err := GetHeader(db)
+	require.NoError(t, err)
+	require.Equal(t, header, got)
+
+	var restored []*Layer
+	require.NoError(t, IterateLayers(db, func(layer *Layer) bool {
+		restored = append(restored, layer)
+		return true
+	}))
+	require.Equal(t, layers, restored)
+
+	header.Last = 20
+	require.NoError(t, Write(db, header, layers[1:]))
+	got, err = GetHeader(db)
+	require.NoError(t, err)
+	require.Equal(t, header.Last, got.Last)
+	restored = nil
+	require.NoError(t, IterateLayers(db, func(layer *Layer) bool {
+		restored = append(restored, layer)
+		return len(restored) < 1
+	}))
+	require.Equal(t, layers[1:2], restored)
+
+	require.NoError(t, Clear(db))
+	_, err = GetHeader(db)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+}
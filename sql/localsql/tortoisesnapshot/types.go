@@ -0,0 +1,75 @@
+package tortoisesnapshot
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/spill"
+)
+
+//go:generate scalegen
+
+// Header is the part of the tortoise state that is not specific to a layer.
+type Header struct {
+	// Parameters that the state depends on, snapshot can't be restored if any of them changed.
+	Hdist            uint32
+	Zdist            uint32
+	WindowSize       uint32
+	LayerSize        uint32
+	LayersPerEpoch   uint32
+	EffectiveGenesis types.LayerID
+
+	Last      types.LayerID
+	Verified  types.LayerID
+	Processed types.LayerID
+	Evicted   types.LayerID
+	Pending   types.LayerID
+	// LocalThreshold and TotalGoodWeight are encoded fixed point weights.
+	LocalThreshold  [16]byte
+	TotalGoodWeight [16]byte
+	Full            bool
+	Counted         types.LayerID
+	Epochs          []Epoch   `scale:"max=1000"`
+	Delayed         []Delayed `scale:"max=10000"`
+}
+
+// Epoch is the weight and height of the epoch computed from activations.
+type Epoch struct {
+	Epoch     types.EpochID
+	Weight    [16]byte
+	Height    uint64
+	HasBeacon bool
+	Beacon    types.Beacon
+}
+
+// Delayed are ballots that will be counted by the full mode when the layer is processed.
+type Delayed struct {
+	Layer   types.LayerID
+	Ballots []types.BallotID `scale:"max=1000000"`
+}
+
+// Layer is the state of the layer with blocks, ballots and votes on the layer.
+type Layer struct {
+	Layer          types.LayerID
+	Empty          [16]byte
+	HareTerminated bool
+	// Coinflip is the sign of the weak coin, shifted by one to be non-negative.
+	Coinflip        uint8
+	Opinion         types.Hash32
+	HasPrevOpinion  bool
+	PrevOpinion     types.Hash32
+	GoodUncounted   [16]byte
+	ReferenceHeight uint64
+	Blocks          []Block `scale:"max=10000"`
+	// Votes are unique votes on this layer from the ballots in the following layers.
+	Votes   []spill.Opinion `scale:"max=1000000"`
+	Ballots []spill.Ballot  `scale:"max=1000000"`
+}
+
+// Block is the local opinion on the block.
+type Block struct {
+	Header types.Vote
+	// Hare and Validity are signs shifted by one to be non-negative.
+	Hare     uint8
+	Validity uint8
+	Margin   [16]byte
+	Data     bool
+}
@@ -6,6 +6,7 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -66,7 +67,15 @@ func version(db Executor) (int, error) {
 }
 
 func StateMigrations() ([]Migration, error) {
-	return sqlMigrations("state")
+	migrations, err := sqlMigrations("state")
+	if err != nil {
+		return nil, err
+	}
+	migrations = append(migrations, blobsMigration{}, ballotsMigration{})
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Order() < migrations[j].Order()
+	})
+	return migrations, nil
 }
 
 func LocalMigrations() ([]Migration, error) {
@@ -0,0 +1,6 @@
+CREATE TABLE events_journal
+(
+    seq       INTEGER PRIMARY KEY,
+    timestamp INT NOT NULL,
+    event     BLOB NOT NULL
+);
@@ -0,0 +1,5 @@
+CREATE TABLE hare_traces
+(
+    layer INT PRIMARY KEY,
+    trace BLOB NOT NULL
+);
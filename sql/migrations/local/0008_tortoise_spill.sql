@@ -0,0 +1,15 @@
+CREATE TABLE tortoise_spilled_ballots
+(
+    id     CHAR(20) PRIMARY KEY,
+    layer  INT NOT NULL,
+    ballot BLOB NOT NULL
+);
+CREATE INDEX tortoise_spilled_ballots_by_layer ON tortoise_spilled_ballots (layer);
+
+CREATE TABLE tortoise_spilled_opinions
+(
+    opinion CHAR(32) PRIMARY KEY,
+    layer   INT NOT NULL,
+    votes   BLOB NOT NULL
+);
+CREATE INDEX tortoise_spilled_opinions_by_layer ON tortoise_spilled_opinions (layer);
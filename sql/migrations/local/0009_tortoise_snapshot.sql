@@ -0,0 +1,11 @@
+CREATE TABLE tortoise_snapshot_header
+(
+    id     INT PRIMARY KEY,
+    header BLOB NOT NULL
+);
+
+CREATE TABLE tortoise_snapshot_layers
+(
+    layer INT PRIMARY KEY,
+    state BLOB NOT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE active_set_candidates
+(
+    epoch    INT      NOT NULL,
+    id       CHAR(32) NOT NULL,
+    node     CHAR(32) NOT NULL,
+    weight   INT      NOT NULL,
+    received INT      NOT NULL,
+    PRIMARY KEY (epoch, id)
+) WITHOUT ROWID;
+
+CREATE INDEX active_set_candidates_by_node ON active_set_candidates (epoch, node);
@@ -0,0 +1,20 @@
+CREATE TABLE nipost_phase
+(
+    id      CHAR(32) PRIMARY KEY,
+    phase   INT NOT NULL,
+    epoch   INT NOT NULL,
+    updated INT NOT NULL
+) WITHOUT ROWID;
+
+-- phases of the challenges that were built before the phases were persisted are derived from the stored state
+INSERT INTO nipost_phase (id, phase, epoch, updated)
+SELECT c.id,
+    CASE
+        WHEN EXISTS (SELECT 1 FROM nipost n WHERE n.id = c.id) THEN 4
+        WHEN c.poet_proof_ref IS NOT NULL THEN 3
+        WHEN EXISTS (SELECT 1 FROM poet_registration r WHERE r.id = c.id) THEN 2
+        ELSE 1
+    END,
+    c.epoch,
+    CAST(strftime('%s', 'now') AS INT)
+FROM challenge c;
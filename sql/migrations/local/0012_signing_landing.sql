@@ -0,0 +1,17 @@
+CREATE TABLE signing_landing
+(
+    id        CHAR(32) NOT NULL,
+    layer     INT NOT NULL,
+    ballot    CHAR(20) NOT NULL,
+    signature CHAR(64) NOT NULL,
+    instance  TEXT NOT NULL,
+    created   INT NOT NULL,
+    PRIMARY KEY (id, layer)
+) WITHOUT ROWID;
+
+-- identifies the instance of the node that owns the local database, single row
+CREATE TABLE signing_instance
+(
+    id       INT PRIMARY KEY,
+    instance TEXT NOT NULL
+);
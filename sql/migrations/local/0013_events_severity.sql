@@ -0,0 +1,3 @@
+-- events journaled before the migration have info severity and no cause
+ALTER TABLE events_journal ADD COLUMN severity INT NOT NULL DEFAULT 0;
+ALTER TABLE events_journal ADD COLUMN cause TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE hare_results
+(
+    layer         INT PRIMARY KEY,
+    terminated    BOOL NOT NULL,
+    iterations    INT NOT NULL,
+    participation INT NOT NULL,
+    threshold     INT NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE TABLE fallback_beacons
+(
+    epoch       INT PRIMARY KEY,
+    beacon      CHAR(4) NOT NULL,
+    certificate BLOB NOT NULL,
+    received    INT NOT NULL
+);
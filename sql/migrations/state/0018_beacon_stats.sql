@@ -0,0 +1,22 @@
+CREATE TABLE beacon_stats
+(
+    epoch                       INT PRIMARY KEY,
+    weight                      INT NOT NULL,
+    valid_proposals             INT NOT NULL,
+    potentially_valid_proposals INT NOT NULL,
+    invalid_proposals           INT NOT NULL,
+    beacon                      CHAR(4)
+);
+
+CREATE TABLE beacon_round_stats
+(
+    epoch     INT NOT NULL,
+    round     INT NOT NULL,
+    votes     INT NOT NULL,
+    weight    INT NOT NULL,
+    support   INT NOT NULL,
+    against   INT NOT NULL,
+    undecided INT NOT NULL,
+    coin      INT,
+    PRIMARY KEY (epoch, round)
+) WITHOUT ROWID;
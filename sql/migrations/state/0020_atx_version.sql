@@ -0,0 +1 @@
+ALTER TABLE atxs ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+CREATE TABLE transactions_skipped
+(
+    tid    CHAR(32),
+    layer  INT NOT NULL,
+    block  CHAR(20) NOT NULL,
+    reason INT NOT NULL,
+    PRIMARY KEY (tid, layer)
+) WITHOUT ROWID;
+CREATE INDEX transactions_skipped_by_layer ON transactions_skipped (layer);
@@ -0,0 +1,37 @@
+CREATE TABLE explorer_layers
+(
+    layer INT PRIMARY KEY,
+    block CHAR(20) NOT NULL
+) WITHOUT ROWID;
+
+CREATE TABLE explorer_address_transactions
+(
+    address CHAR(24) NOT NULL,
+    layer   INT NOT NULL,
+    tid     CHAR(32) NOT NULL,
+    PRIMARY KEY (address, layer, tid)
+) WITHOUT ROWID;
+CREATE INDEX explorer_address_transactions_by_layer ON explorer_address_transactions (layer);
+
+CREATE TABLE explorer_smesher_atxs
+(
+    pubkey   CHAR(32) NOT NULL,
+    epoch    INT NOT NULL,
+    id       CHAR(32) NOT NULL,
+    coinbase CHAR(24) NOT NULL,
+    units    INT NOT NULL,
+    weight   INT NOT NULL,
+    PRIMARY KEY (pubkey, epoch)
+) WITHOUT ROWID;
+CREATE INDEX explorer_smesher_atxs_by_epoch ON explorer_smesher_atxs (epoch);
+
+CREATE TABLE explorer_epoch_rewards
+(
+    epoch        INT NOT NULL,
+    pubkey       CHAR(32) NOT NULL,
+    coinbase     CHAR(24) NOT NULL,
+    total_reward INT NOT NULL,
+    layer_reward INT NOT NULL,
+    count        INT NOT NULL,
+    PRIMARY KEY (epoch, pubkey)
+) WITHOUT ROWID;
@@ -0,0 +1,6 @@
+ALTER TABLE layers ADD COLUMN weak_coin_vrf CHAR(80);
+ALTER TABLE layers ADD COLUMN weak_coin_smesher CHAR(32);
+
+ALTER TABLE beacons ADD COLUMN source INT;
+ALTER TABLE beacons ADD COLUMN support INT;
+ALTER TABLE beacons ADD COLUMN weight INT;
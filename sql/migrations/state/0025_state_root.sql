@@ -0,0 +1 @@
+ALTER TABLE layers ADD COLUMN state_root CHAR(32);
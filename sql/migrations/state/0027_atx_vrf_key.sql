@@ -0,0 +1,2 @@
+ALTER TABLE atxs ADD COLUMN vrf_key CHAR(32);
+CREATE INDEX atxs_by_pubkey_by_epoch_vrf_key ON atxs (pubkey, epoch desc, vrf_key) WHERE vrf_key IS NOT NULL;
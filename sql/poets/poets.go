@@ -5,6 +5,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
 )
 
 // Has checks if a PoET exists by the given ref.
@@ -30,7 +31,7 @@ func Get(db sql.Executor, ref types.PoetProofRef) (poet []byte, err error) {
 		return true
 	}
 
-	rows, err := db.Exec("select poet from poets where ref = ?1;", enc, dec)
+	rows, err := db.Exec("select b.data from poets p join blobs b on b.hash = p.blob where p.ref = ?1;", enc, dec)
 	if err != nil {
 		return nil, fmt.Errorf("get value: %w", err)
 	}
@@ -41,22 +42,28 @@ func Get(db sql.Executor, ref types.PoetProofRef) (poet []byte, err error) {
 	return poet, nil
 }
 
-// Add adds a poet for a given ref.
+// Add adds a poet for a given ref. The poet is stored in the blobs table once per content.
+// The blob and the poet are added in one transaction, so that the blob is not removed by GC before it is referenced.
 func Add(db sql.Executor, ref types.PoetProofRef, poet, serviceID []byte, roundID string) error {
-	enc := func(stmt *sql.Statement) {
-		stmt.BindBytes(1, ref[:])
-		stmt.BindBytes(2, poet)
-		stmt.BindBytes(3, serviceID)
-		stmt.BindBytes(4, []byte(roundID))
-	}
-	_, err := db.Exec(`
-		insert into poets (ref, poet, service_id, round_id) 
-		values (?1, ?2, ?3, ?4);`, enc, nil)
-	if err != nil {
-		return fmt.Errorf("exec: %w", err)
-	}
-
-	return nil
+	return db.WithSavepoint(func(db sql.Executor) error {
+		blob, err := blobs.Add(db, poet)
+		if err != nil {
+			return err
+		}
+		enc := func(stmt *sql.Statement) {
+			stmt.BindBytes(1, ref[:])
+			stmt.BindBytes(2, blob[:])
+			stmt.BindBytes(3, serviceID)
+			stmt.BindBytes(4, []byte(roundID))
+		}
+		_, err = db.Exec(`
+			insert into poets (ref, blob, service_id, round_id) 
+			values (?1, ?2, ?3, ?4);`, enc, nil)
+		if err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+		return nil
+	})
 }
 
 // GetRef gets a PoET ref for a given service ID and round ID.
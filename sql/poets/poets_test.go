@@ -8,6 +8,7 @@ import (
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blobs"
 )
 
 func TestHas(t *testing.T) {
@@ -105,6 +106,11 @@ func TestAdd(t *testing.T) {
 	require.NoError(t, Add(db, ref, poet, sid, rid))
 	require.ErrorIs(t, Add(db, ref, poet, sid, rid), sql.ErrObjectExists)
 
+	// the blob of the rejected poet is rolled back with the failed insert
+	require.ErrorIs(t, Add(db, ref, []byte("proof1"), sid, rid), sql.ErrObjectExists)
+	_, err = blobs.Get(db, blobs.Hash([]byte("proof1")))
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
 	got, err := Get(db, ref)
 	require.NoError(t, err)
 	require.Equal(t, poet, got)
@@ -0,0 +1,129 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	sqlite "github.com/go-llsqlite/crawshaw"
+	"github.com/go-llsqlite/crawshaw/sqlitex"
+)
+
+// Pragmas are the sqlite settings applied to every connection when the database is opened.
+// Zero values keep the defaults: wal journal, default synchronous level, checkpoint every 1000 pages,
+// busy handler that waits for the lock and cache of 2000 KiB per connection.
+//
+// https://www.sqlite.org/pragma.html
+type Pragmas struct {
+	// JournalMode is one of wal, delete, truncate or persist.
+	JournalMode string `mapstructure:"journal-mode"`
+	// Synchronous is one of off, normal, full or extra.
+	Synchronous string `mapstructure:"synchronous"`
+	// WALAutocheckpoint is the number of pages in the write-ahead log after which it is checkpointed.
+	// Only valid in wal journal mode.
+	WALAutocheckpoint int `mapstructure:"wal-autocheckpoint"`
+	// BusyTimeout is the time the connection waits for the lock before failing with busy error.
+	BusyTimeout time.Duration `mapstructure:"busy-timeout"`
+	// CacheSize is the size of the page cache of every connection in KiB.
+	CacheSize int `mapstructure:"cache-size"`
+}
+
+var (
+	journalModes = []string{"wal", "delete", "truncate", "persist"}
+	syncLevels   = []string{"off", "normal", "full", "extra"}
+)
+
+// Validate returns an error if the pragmas have unknown or conflicting values.
+func (p Pragmas) Validate() error {
+	if p.JournalMode != "" && !slices.Contains(journalModes, strings.ToLower(p.JournalMode)) {
+		return fmt.Errorf("journal mode %q is not one of %v", p.JournalMode, journalModes)
+	}
+	if p.Synchronous != "" && !slices.Contains(syncLevels, strings.ToLower(p.Synchronous)) {
+		return fmt.Errorf("synchronous level %q is not one of %v", p.Synchronous, syncLevels)
+	}
+	if p.WALAutocheckpoint < 0 {
+		return fmt.Errorf("wal autocheckpoint %d is negative", p.WALAutocheckpoint)
+	}
+	if p.WALAutocheckpoint > 0 && !p.wal() {
+		return fmt.Errorf("wal autocheckpoint is set in %s journal mode", p.JournalMode)
+	}
+	if p.BusyTimeout < 0 {
+		return fmt.Errorf("busy timeout %v is negative", p.BusyTimeout)
+	}
+	if p.CacheSize < 0 {
+		return fmt.Errorf("cache size %d is negative", p.CacheSize)
+	}
+	return nil
+}
+
+func (p Pragmas) wal() bool {
+	return p.JournalMode == "" || strings.EqualFold(p.JournalMode, "wal")
+}
+
+// flags returns the flags to open the connections with. Connections are switched to wal mode when opened
+// unless another journal mode is configured.
+func (p Pragmas) flags(flags sqlite.OpenFlags) sqlite.OpenFlags {
+	if p.wal() {
+		return flags
+	}
+	if flags == 0 {
+		flags = sqlite.SQLITE_OPEN_READWRITE |
+			sqlite.SQLITE_OPEN_CREATE |
+			sqlite.SQLITE_OPEN_URI |
+			sqlite.SQLITE_OPEN_NOMUTEX
+	}
+	return flags &^ sqlite.SQLITE_OPEN_WAL
+}
+
+// apply executes the pragmas on every connection of the pool. Pragmas are executed outside of
+// the transaction as the journal mode can't be changed inside of it.
+func (p Pragmas) apply(pool *sqlitex.Pool, connections int) error {
+	var stmts []string
+	if !p.wal() {
+		stmts = append(stmts, fmt.Sprintf("PRAGMA journal_mode = %s;", strings.ToLower(p.JournalMode)))
+	}
+	if p.Synchronous != "" {
+		stmts = append(stmts, fmt.Sprintf("PRAGMA synchronous = %s;", strings.ToLower(p.Synchronous)))
+	}
+	if p.WALAutocheckpoint > 0 {
+		stmts = append(stmts, fmt.Sprintf("PRAGMA wal_autocheckpoint = %d;", p.WALAutocheckpoint))
+	}
+	if p.CacheSize > 0 {
+		// negative value is the size in KiB instead of the number of pages
+		stmts = append(stmts, fmt.Sprintf("PRAGMA cache_size = -%d;", p.CacheSize))
+	}
+	if len(stmts) == 0 && p.BusyTimeout == 0 {
+		return nil
+	}
+	conns := make([]*sqlite.Conn, 0, connections)
+	defer func() {
+		for _, conn := range conns {
+			pool.Put(conn)
+		}
+	}()
+	for i := 0; i < connections; i++ {
+		conn := pool.Get(context.Background())
+		if conn == nil {
+			return ErrNoConnection
+		}
+		conns = append(conns, conn)
+		if p.BusyTimeout > 0 {
+			conn.SetBusyTimeout(p.BusyTimeout)
+		}
+		for _, stmt := range stmts {
+			if err := sqlitex.ExecTransient(conn, stmt, nil); err != nil {
+				return fmt.Errorf("%s: %w", stmt, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WithPragmas applies the pragmas to every connection.
+func WithPragmas(pragmas Pragmas) Opt {
+	return func(c *conf) {
+		c.pragmas = pragmas
+	}
+}
@@ -0,0 +1,102 @@
+package sql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPragmasValidate(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		pragmas Pragmas
+		err     bool
+	}{
+		{desc: "defaults"},
+		{
+			desc: "valid",
+			pragmas: Pragmas{
+				JournalMode:       "WAL",
+				Synchronous:       "normal",
+				WALAutocheckpoint: 10000,
+				BusyTimeout:       time.Minute,
+				CacheSize:         64 << 10,
+			},
+		},
+		{desc: "rollback journal", pragmas: Pragmas{JournalMode: "truncate", Synchronous: "full"}},
+		{desc: "unknown journal mode", pragmas: Pragmas{JournalMode: "memory"}, err: true},
+		{desc: "unknown synchronous level", pragmas: Pragmas{Synchronous: "fast"}, err: true},
+		{desc: "negative autocheckpoint", pragmas: Pragmas{WALAutocheckpoint: -1}, err: true},
+		{
+			desc:    "autocheckpoint without wal",
+			pragmas: Pragmas{JournalMode: "delete", WALAutocheckpoint: 100},
+			err:     true,
+		},
+		{desc: "negative busy timeout", pragmas: Pragmas{BusyTimeout: -time.Second}, err: true},
+		{desc: "negative cache size", pragmas: Pragmas{CacheSize: -1}, err: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.pragmas.Validate()
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func queryPragma(tb testing.TB, db Executor, pragma string) (rst string) {
+	_, err := db.Exec("PRAGMA "+pragma, nil, func(stmt *Statement) bool {
+		rst = stmt.ColumnText(0)
+		return false
+	})
+	require.NoError(tb, err)
+	return rst
+}
+
+func TestPragmas(t *testing.T) {
+	t.Run("applied to every connection", func(t *testing.T) {
+		dbFile := filepath.Join(t.TempDir(), "test.sql")
+		db, err := Open("file:"+dbFile, WithConnections(2), WithPragmas(Pragmas{
+			Synchronous:       "normal",
+			WALAutocheckpoint: 100,
+			BusyTimeout:       5 * time.Second,
+			CacheSize:         1024,
+		}))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+		// hold one connection in the transaction to query the other one
+		tx, err := db.Tx(context.Background())
+		require.NoError(t, err)
+		defer tx.Release()
+		for _, ex := range []Executor{db, tx} {
+			require.Equal(t, "wal", queryPragma(t, ex, "journal_mode"))
+			require.Equal(t, "1", queryPragma(t, ex, "synchronous"))
+			require.Equal(t, "100", queryPragma(t, ex, "wal_autocheckpoint"))
+			require.Equal(t, "5000", queryPragma(t, ex, "busy_timeout"))
+			require.Equal(t, "-1024", queryPragma(t, ex, "cache_size"))
+		}
+	})
+	t.Run("rollback journal", func(t *testing.T) {
+		dbFile := filepath.Join(t.TempDir(), "test.sql")
+		db, err := Open("file:"+dbFile, WithPragmas(Pragmas{JournalMode: "truncate"}))
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+		require.Equal(t, "truncate", queryPragma(t, db, "journal_mode"))
+		_, err = db.Exec("create table testing (id int)", nil, nil)
+		require.NoError(t, err)
+		_, err = os.Stat(dbFile + "-wal")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Open("file::memory:?mode=memory", WithPragmas(Pragmas{Synchronous: "fast"}))
+		require.Error(t, err)
+	})
+}
@@ -68,6 +68,8 @@ type QueryCache interface {
 	UpdateSlice(key queryCacheKey, update SliceAppender)
 	// ClearCache empties the cache
 	ClearCache()
+	// IsCached returns true if the query cache is enabled.
+	IsCached() bool
 }
 
 // RetrieveFunc retrieves a value to be stored in the cache.
@@ -127,6 +129,12 @@ func AppendToCachedSlice[T any](db any, key queryCacheKey, v T) {
 	}
 }
 
+// IsCached returns true if the database has the query cache enabled.
+func IsCached(db any) bool {
+	cache, ok := db.(QueryCache)
+	return ok && cache.IsCached()
+}
+
 type lruCacheKey struct {
 	key    string
 	subKey QueryCacheSubKey
@@ -264,6 +272,10 @@ func (c *queryCache) UpdateSlice(key queryCacheKey, update SliceAppender) {
 	}
 }
 
+func (c *queryCache) IsCached() bool {
+	return c != nil
+}
+
 func (c *queryCache) ClearCache() {
 	if c == nil {
 		return
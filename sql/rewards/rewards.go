@@ -110,6 +110,32 @@ func ListBySmesherId(db sql.Executor, smesherID types.NodeID) (rst []*types.Rewa
 	return ListByKey(db, nil, &smesherID)
 }
 
+// ListBySmesher lists rewards of the smesherID in the epochs in the range [from, to].
+func ListBySmesher(db sql.Executor, smesherID types.NodeID, from, to types.EpochID) (rst []*types.Reward, err error) {
+	var derr error
+	_, err = db.Exec(fullQuery+" where pubkey = ?1 and layer >= ?2 and layer < ?3 order by layer;",
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, smesherID[:])
+			stmt.BindInt64(2, int64(from.FirstLayer().Uint32()))
+			stmt.BindInt64(3, int64((to + 1).FirstLayer().Uint32()))
+		},
+		decoder(func(reward *types.Reward, err error) bool {
+			if reward != nil {
+				rst = append(rst, reward)
+			}
+			derr = err
+			return derr == nil
+		}),
+	)
+	if err == nil {
+		err = derr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list rewards of %s in epochs %v-%v: %w", smesherID.ShortString(), from, to, err)
+	}
+	return rst, nil
+}
+
 func IterateRewardsOps(
 	db sql.Executor,
 	operations builder.Operations,
@@ -132,3 +158,88 @@ func IterateRewardsOps(
 	}
 	return derr
 }
+
+// EpochSummary aggregates rewards received by a smesher in a single epoch.
+type EpochSummary struct {
+	SmesherID   types.NodeID
+	Epoch       types.EpochID
+	Count       uint32
+	TotalReward uint64
+	LayerReward uint64
+}
+
+// SummarizeByEpoch aggregates rewards of the smesherID and/or coinbase per smesher and epoch,
+// for all epochs in the range [from, to].
+func SummarizeByEpoch(
+	db sql.Executor,
+	coinbase *types.Address,
+	smesherID *types.NodeID,
+	from, to types.EpochID,
+) (rst []EpochSummary, err error) {
+	var (
+		whereClause string
+		key         []byte
+	)
+	switch {
+	case coinbase != nil && smesherID != nil:
+		whereClause = "pubkey = ?4 and coinbase = ?5"
+		key = smesherID[:]
+	case coinbase != nil:
+		whereClause = "coinbase = ?4"
+		key = coinbase[:]
+	case smesherID != nil:
+		whereClause = "pubkey = ?4"
+		key = smesherID[:]
+	default:
+		return nil, fmt.Errorf("must specify coinbase and/or smesherID")
+	}
+	query := fmt.Sprintf(`select pubkey, layer / ?1 as epoch, count(*), sum(total_reward), sum(layer_reward)
+		from rewards where layer >= ?2 and layer < ?3 and %s
+		group by pubkey, epoch order by epoch, pubkey;`, whereClause)
+	_, err = db.Exec(query,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(types.GetLayersPerEpoch()))
+			stmt.BindInt64(2, int64(from.FirstLayer().Uint32()))
+			stmt.BindInt64(3, int64((to + 1).FirstLayer().Uint32()))
+			stmt.BindBytes(4, key)
+			if coinbase != nil && smesherID != nil {
+				stmt.BindBytes(5, coinbase[:])
+			}
+		},
+		func(stmt *sql.Statement) bool {
+			summary := EpochSummary{
+				Epoch:       types.EpochID(uint32(stmt.ColumnInt64(1))),
+				Count:       uint32(stmt.ColumnInt64(2)),
+				TotalReward: uint64(stmt.ColumnInt64(3)),
+				LayerReward: uint64(stmt.ColumnInt64(4)),
+			}
+			stmt.ColumnBytes(0, summary.SmesherID[:])
+			rst = append(rst, summary)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("summarize rewards in epochs %v-%v: %w", from, to, err)
+	}
+	return rst, nil
+}
+
+// TotalByEpoch returns the sum of rewards issued to all smeshers in every epoch in the range [from, to].
+// Epochs without rewards are omitted.
+func TotalByEpoch(db sql.Executor, from, to types.EpochID) (map[types.EpochID]uint64, error) {
+	rst := map[types.EpochID]uint64{}
+	_, err := db.Exec(`select layer / ?1 as epoch, sum(total_reward)
+		from rewards where layer >= ?2 and layer < ?3 group by epoch;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(types.GetLayersPerEpoch()))
+			stmt.BindInt64(2, int64(from.FirstLayer().Uint32()))
+			stmt.BindInt64(3, int64((to + 1).FirstLayer().Uint32()))
+		},
+		func(stmt *sql.Statement) bool {
+			rst[types.EpochID(uint32(stmt.ColumnInt64(0)))] = uint64(stmt.ColumnInt64(1))
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("total rewards in epochs %v-%v: %w", from, to, err)
+	}
+	return rst, nil
+}
@@ -335,3 +335,81 @@ func Test_0008Migration(t *testing.T) {
 	require.Equal(t, reward.Layer, rewards[0].Layer)
 	require.Equal(t, reward.SmesherID, rewards[0].SmesherID)
 }
+
+func TestSummarizeByEpoch(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+
+	coinbase1 := types.Address{1}
+	coinbase2 := types.Address{2}
+	smesherID1 := types.NodeID{1}
+	smesherID2 := types.NodeID{2}
+	for _, reward := range []types.Reward{
+		{Layer: 3, Coinbase: coinbase1, SmesherID: smesherID1, TotalReward: 10, LayerReward: 5},
+		{Layer: 4, Coinbase: coinbase1, SmesherID: smesherID1, TotalReward: 10, LayerReward: 5},
+		{Layer: 5, Coinbase: coinbase1, SmesherID: smesherID1, TotalReward: 20, LayerReward: 15},
+		{Layer: 5, Coinbase: coinbase1, SmesherID: smesherID2, TotalReward: 30, LayerReward: 25},
+		{Layer: 8, Coinbase: coinbase2, SmesherID: smesherID1, TotalReward: 40, LayerReward: 35},
+		{Layer: 12, Coinbase: coinbase2, SmesherID: smesherID2, TotalReward: 50, LayerReward: 45},
+	} {
+		require.NoError(t, Add(db, &reward))
+	}
+
+	got, err := SummarizeByEpoch(db, nil, &smesherID1, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []EpochSummary{
+		{SmesherID: smesherID1, Epoch: 1, Count: 2, TotalReward: 30, LayerReward: 20},
+		{SmesherID: smesherID1, Epoch: 2, Count: 1, TotalReward: 40, LayerReward: 35},
+	}, got)
+
+	got, err = SummarizeByEpoch(db, &coinbase1, nil, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []EpochSummary{
+		{SmesherID: smesherID1, Epoch: 0, Count: 1, TotalReward: 10, LayerReward: 5},
+		{SmesherID: smesherID1, Epoch: 1, Count: 2, TotalReward: 30, LayerReward: 20},
+		{SmesherID: smesherID2, Epoch: 1, Count: 1, TotalReward: 30, LayerReward: 25},
+	}, got)
+
+	got, err = SummarizeByEpoch(db, &coinbase2, &smesherID2, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []EpochSummary{
+		{SmesherID: smesherID2, Epoch: 3, Count: 1, TotalReward: 50, LayerReward: 45},
+	}, got)
+
+	_, err = SummarizeByEpoch(db, nil, nil, 0, 3)
+	require.Error(t, err)
+
+	total, err := TotalByEpoch(db, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, map[types.EpochID]uint64{1: 60, 2: 40}, total)
+}
+
+func TestListBySmesher(t *testing.T) {
+	types.SetLayersPerEpoch(4)
+	db := sql.InMemory()
+
+	smesherID1 := types.NodeID{1}
+	smesherID2 := types.NodeID{2}
+	rewards := []types.Reward{
+		{Layer: 3, Coinbase: types.Address{1}, SmesherID: smesherID1, TotalReward: 10, LayerReward: 5},
+		{Layer: 5, Coinbase: types.Address{1}, SmesherID: smesherID1, TotalReward: 20, LayerReward: 15},
+		{Layer: 5, Coinbase: types.Address{1}, SmesherID: smesherID2, TotalReward: 30, LayerReward: 25},
+		{Layer: 8, Coinbase: types.Address{2}, SmesherID: smesherID1, TotalReward: 40, LayerReward: 35},
+		{Layer: 12, Coinbase: types.Address{2}, SmesherID: smesherID1, TotalReward: 50, LayerReward: 45},
+	}
+	for _, reward := range rewards {
+		require.NoError(t, Add(db, &reward))
+	}
+
+	got, err := ListBySmesher(db, smesherID1, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Reward{&rewards[1], &rewards[3]}, got)
+
+	got, err = ListBySmesher(db, smesherID1, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Reward{&rewards[0], &rewards[1], &rewards[3], &rewards[4]}, got)
+
+	got, err = ListBySmesher(db, smesherID2, 2, 3)
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
@@ -2,9 +2,10 @@ package transactions
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
+	sqlite "github.com/go-llsqlite/crawshaw"
+
 	"github.com/spacemeshos/go-spacemesh/codec"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -17,36 +18,48 @@ type ResultsFilter struct {
 	TID        *types.TransactionID
 }
 
+// query selects results of executed transactions and of the transactions that were skipped in applied blocks.
+// Both parts of the query share the same parameters, result is null for the skipped transactions.
 func (f *ResultsFilter) query() string {
 	var q strings.Builder
 	q.WriteString(`
-		select distinct id, tx, header, result 
-		from transactions
-		left join transactions_results_addresses on id=tid
-		where result is not null
+		select id, tx, header, result, layer, block, reason from (
+		select distinct t.id as id, t.tx as tx, t.header as header, t.result as result,
+			t.layer as layer, t.block as block, null as reason
+		from transactions t
+		left join transactions_results_addresses on t.id=tid
+		where t.result is not null
+	`)
+	f.conditions(&q, "address", "t.layer", "t.id")
+	q.WriteString(`
+		union all
+		select t.id, t.tx, t.header, null, s.layer, s.block, s.reason
+		from transactions_skipped s
+		join transactions t on t.id=s.tid
+		where 1=1
 	`)
+	f.conditions(&q, "t.principal", "s.layer", "t.id")
+	q.WriteString(") order by layer, id;")
+	return q.String()
+}
+
+func (f *ResultsFilter) conditions(q *strings.Builder, address, layer, id string) {
 	i := 1
 	if f.Address != nil {
-		q.WriteString(" and address = ?")
-		q.WriteString(strconv.Itoa(i))
+		fmt.Fprintf(q, " and %s = ?%d", address, i)
 		i++
 	}
 	if f.Start != nil {
-		q.WriteString(" and layer >= ?")
-		q.WriteString(strconv.Itoa(i))
+		fmt.Fprintf(q, " and %s >= ?%d", layer, i)
 		i++
 	}
 	if f.End != nil {
-		q.WriteString(" and layer <= ?")
-		q.WriteString(strconv.Itoa(i))
+		fmt.Fprintf(q, " and %s <= ?%d", layer, i)
 		i++
 	}
 	if f.TID != nil {
-		q.WriteString(" and id = ?")
-		q.WriteString(strconv.Itoa(i))
+		fmt.Fprintf(q, " and %s = ?%d", id, i)
 	}
-	q.WriteString("order by layer, id;")
-	return q.String()
 }
 
 func (f *ResultsFilter) binding(stmt *sql.Statement) {
@@ -84,6 +97,16 @@ func IterateResults(db sql.Executor, filter ResultsFilter, fn func(*types.Transa
 				return false
 			}
 		}
+		if stmt.ColumnType(3) == sqlite.SQLITE_NULL {
+			tx.Status = types.TransactionSkipped
+			tx.Message = types.SkipReason(stmt.ColumnInt(6)).String()
+			tx.Layer = types.LayerID(stmt.ColumnInt64(4))
+			stmt.ColumnBytes(5, tx.Block[:])
+			if tx.TxHeader != nil {
+				tx.Addresses = []types.Address{tx.Principal}
+			}
+			return fn(&tx)
+		}
 		_, ierr = codec.DecodeFrom(stmt.ColumnReader(3), &tx.TransactionResult)
 		if ierr != nil {
 			return false
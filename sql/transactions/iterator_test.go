@@ -141,6 +141,58 @@ func TestIterateResults(t *testing.T) {
 	}
 }
 
+func TestIterateSkipped(t *testing.T) {
+	db := sql.InMemory()
+	executed := fixture.NewTransactionResultGenerator().Next()
+	executed.Layer = 10
+	principal := types.Address{1}
+	skipped := types.Transaction{
+		RawTx:    types.NewRawTx([]byte{1, 2, 3}),
+		TxHeader: &types.TxHeader{Principal: principal},
+	}
+	block := types.BlockID{2}
+	require.NoError(t, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+		require.NoError(t, Add(dtx, &executed.Transaction, time.Time{}))
+		require.NoError(t, AddResult(dtx, executed.ID, &executed.TransactionResult))
+		require.NoError(t, Add(dtx, &skipped, time.Time{}))
+		require.NoError(t, AddSkipped(dtx, skipped.ID, 9, block, types.SkippedBlockGasLimit))
+		require.NoError(t, AddSkipped(dtx, skipped.ID, 11, block, types.SkippedNonceTooLow))
+		return nil
+	}))
+	skippedIn := func(lid types.LayerID, reason types.SkipReason) types.TransactionWithResult {
+		return types.TransactionWithResult{
+			Transaction: skipped,
+			TransactionResult: types.TransactionResult{
+				Status:    types.TransactionSkipped,
+				Message:   reason.String(),
+				Block:     block,
+				Layer:     lid,
+				Addresses: []types.Address{principal},
+			},
+		}
+	}
+	collect := func(filter ResultsFilter) []types.TransactionWithResult {
+		var rst []types.TransactionWithResult
+		require.NoError(t, IterateResults(db, filter, func(tx *types.TransactionWithResult) bool {
+			rst = append(rst, *tx)
+			return true
+		}))
+		return rst
+	}
+
+	first, last := skippedIn(9, types.SkippedBlockGasLimit), skippedIn(11, types.SkippedNonceTooLow)
+	require.Equal(t, []types.TransactionWithResult{first, *executed, last}, collect(ResultsFilter{}))
+	require.Equal(t, []types.TransactionWithResult{first, last}, collect(ResultsFilter{Address: &principal}))
+	start := types.LayerID(10)
+	require.Equal(t, []types.TransactionWithResult{*executed, last}, collect(ResultsFilter{Start: &start}))
+	require.Equal(t, []types.TransactionWithResult{last}, collect(ResultsFilter{Start: &start, TID: &skipped.ID}))
+
+	require.NoError(t, db.WithTx(context.Background(), func(dtx *sql.Tx) error {
+		return UndoLayers(dtx, 11)
+	}))
+	require.Equal(t, []types.TransactionWithResult{first, *executed}, collect(ResultsFilter{}))
+}
+
 func TestIterateSnapshot(t *testing.T) {
 	db, err := sql.Open("file:" + filepath.Join(t.TempDir(), "test.sql"))
 	t.Cleanup(func() { require.NoError(t, db.Close()) })
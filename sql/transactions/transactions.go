@@ -143,6 +143,13 @@ func UndoLayers(db *sql.Tx, from types.LayerID) error {
 	if err != nil {
 		return fmt.Errorf("delete addresses mapping %w", err)
 	}
+	_, err = db.Exec(`delete from transactions_skipped where layer >= ?1;`,
+		func(stmt *sql.Statement) {
+			stmt.BindInt64(1, int64(from))
+		}, nil)
+	if err != nil {
+		return fmt.Errorf("delete skipped %w", err)
+	}
 	_, err = db.Exec(`update transactions 
 		set layer = null, block = null, result = null 
 		where layer >= ?1`,
@@ -361,6 +368,30 @@ func AddResult(db *sql.Tx, id types.TransactionID, rst *types.TransactionResult)
 	return nil
 }
 
+// AddSkipped records that the transaction included into the applied block was skipped in the layer.
+// Transaction may be skipped in several layers, e.g. if it didn't fit into the gas limit of the block,
+// and executed later.
+func AddSkipped(
+	db sql.Executor,
+	id types.TransactionID,
+	lid types.LayerID,
+	bid types.BlockID,
+	reason types.SkipReason,
+) error {
+	if _, err := db.Exec(`insert into transactions_skipped (tid, layer, block, reason)
+		values (?1, ?2, ?3, ?4)
+		on conflict (tid, layer) do update set block = ?3, reason = ?4;`,
+		func(stmt *sql.Statement) {
+			stmt.BindBytes(1, id[:])
+			stmt.BindInt64(2, int64(lid))
+			stmt.BindBytes(3, bid[:])
+			stmt.BindInt64(4, int64(reason))
+		}, nil); err != nil {
+		return fmt.Errorf("add skipped %s in layer %s: %w", id, lid, err)
+	}
+	return nil
+}
+
 // TransactionInProposal returns lowest layer of the proposal where tx is included after the specified layer.
 func TransactionInProposal(db sql.Executor, id types.TransactionID, after types.LayerID) (types.LayerID, error) {
 	var rst types.LayerID
@@ -18,3 +18,22 @@ func Vacuum(db Executor) error {
 	log.Info("db vacuum completed")
 	return nil
 }
+
+// Checkpoint moves the content of the write-ahead log into the database and truncates the log.
+// It fails if the log was not moved completely, e.g. because the database is used by other connections.
+func Checkpoint(db Executor) error {
+	var busy, frames, checkpointed int64
+	if _, err := db.Exec("pragma wal_checkpoint(TRUNCATE)", nil, func(stmt *Statement) bool {
+		busy = stmt.ColumnInt64(0)
+		frames = stmt.ColumnInt64(1)
+		checkpointed = stmt.ColumnInt64(2)
+		return false
+	}); err != nil {
+		return fmt.Errorf("wal checkpoint %w", err)
+	}
+	if busy != 0 || frames != checkpointed {
+		return fmt.Errorf("wal checkpoint incomplete: busy %d, checkpointed %d out of %d frames",
+			busy, checkpointed, frames)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package syncer
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+)
+
+// BackfillConfig configures the lane that downloads layers below the layer the node started syncing from,
+// e.g. history before the checkpoint on the archive node. The lane has its own peers and rate budget
+// and yields to the tip-following lane, which is not rate limited, whenever it is behind the current layer.
+type BackfillConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// From is the first layer to backfill, layers before the effective genesis are never backfilled.
+	From uint32 `mapstructure:"from"`
+	// LayersPerSecond and Burst bound the rate of layer data requests made by the lane.
+	LayersPerSecond float64 `mapstructure:"layers-per-second"`
+	Burst           int     `mapstructure:"burst"`
+	// Peers is the number of peers polled for each layer, they are selected independently from the tip lane.
+	Peers int `mapstructure:"peers"`
+}
+
+// DefaultBackfillConfig for the backfill lane.
+func DefaultBackfillConfig() BackfillConfig {
+	return BackfillConfig{
+		LayersPerSecond: 1,
+		Burst:           1,
+		Peers:           2,
+	}
+}
+
+// backfill downloads layers in the range [from, to] in ascending order.
+// Layers that already have ballots are skipped.
+type backfill struct {
+	logger   log.Log
+	cfg      BackfillConfig
+	db       sql.Executor
+	fetcher  fetchLogic
+	limiter  *rate.Limiter
+	interval time.Duration
+	// tipSynced returns true if the tip lane caught up with the current layer.
+	tipSynced func() bool
+
+	from, to types.LayerID
+}
+
+func newBackfill(
+	logger log.Log,
+	cfg BackfillConfig,
+	db sql.Executor,
+	fetcher fetchLogic,
+	interval time.Duration,
+	tipSynced func() bool,
+	to types.LayerID,
+) *backfill {
+	from := types.LayerID(cfg.From)
+	if genesis := types.GetEffectiveGenesis().Add(1); from.Before(genesis) {
+		from = genesis
+	}
+	return &backfill{
+		logger:    logger,
+		cfg:       cfg,
+		db:        db,
+		fetcher:   fetcher,
+		limiter:   rate.NewLimiter(rate.Limit(cfg.LayersPerSecond), cfg.Burst),
+		interval:  interval,
+		tipSynced: tipSynced,
+		from:      from,
+		to:        to,
+	}
+}
+
+func (b *backfill) run(ctx context.Context) error {
+	if b.to.Before(b.from) {
+		return nil
+	}
+	b.logger.With().Info("starting backfill",
+		log.Stringer("from", b.from),
+		log.Stringer("to", b.to),
+	)
+	for lid := b.from; !lid.After(b.to); {
+		if !b.tipSynced() {
+			if !b.wait(ctx) {
+				return nil
+			}
+			continue
+		}
+		ids, err := ballots.IDsInLayer(b.db, lid)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			lid = lid.Add(1)
+			continue
+		}
+		if err := b.limiter.Wait(ctx); err != nil {
+			return nil
+		}
+		peers := b.fetcher.SelectBestShuffled(b.cfg.Peers)
+		if len(peers) == 0 {
+			if !b.wait(ctx) {
+				return nil
+			}
+			continue
+		}
+		if err := b.fetcher.PollLayerData(ctx, lid, peers...); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			backfillFail.Inc()
+			b.logger.With().Debug("failed to backfill layer", log.Context(ctx), lid, log.Err(err))
+			if !b.wait(ctx) {
+				return nil
+			}
+			continue
+		}
+		backfillLayer.Set(float64(lid))
+		lid = lid.Add(1)
+	}
+	b.logger.With().Info("backfill completed",
+		log.Stringer("from", b.from),
+		log.Stringer("to", b.to),
+	)
+	return nil
+}
+
+func (b *backfill) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(b.interval):
+		return true
+	}
+}
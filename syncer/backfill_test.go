@@ -0,0 +1,94 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/p2p"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/syncer/mocks"
+)
+
+func TestBackfill(t *testing.T) {
+	first := types.GetEffectiveGenesis().Add(1)
+	cfg := BackfillConfig{Enable: true, LayersPerSecond: float64(rate.Inf), Burst: 1, Peers: 3}
+	peers := []p2p.Peer{"a", "b", "c"}
+
+	t.Run("downloads missing layers", func(t *testing.T) {
+		db := sql.InMemory()
+		ballot := types.NewExistingBallot(
+			types.RandomBallotID(), types.RandomEdSignature(), types.RandomNodeID(), first.Add(2),
+		)
+		require.NoError(t, ballots.Add(db, &ballot))
+
+		fetcher := mocks.NewMockfetchLogic(gomock.NewController(t))
+		fetcher.EXPECT().SelectBestShuffled(cfg.Peers).Return(peers).AnyTimes()
+		var polled []types.LayerID
+		fetcher.EXPECT().PollLayerData(gomock.Any(), gomock.Any(), peers[0], peers[1], peers[2]).DoAndReturn(
+			func(_ context.Context, lid types.LayerID, _ ...p2p.Peer) error {
+				polled = append(polled, lid)
+				return nil
+			},
+		).Times(3)
+
+		bf := newBackfill(logtest.New(t), cfg, db, fetcher, time.Millisecond, func() bool { return true }, first.Add(3))
+		require.NoError(t, bf.run(context.Background()))
+		require.Equal(t, []types.LayerID{first, first.Add(1), first.Add(3)}, polled)
+	})
+	t.Run("yields to tip lane", func(t *testing.T) {
+		fetcher := mocks.NewMockfetchLogic(gomock.NewController(t))
+		var synced atomic.Bool
+		bf := newBackfill(logtest.New(t), cfg, sql.InMemory(), fetcher, time.Millisecond, synced.Load, first)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() { done <- bf.run(ctx) }()
+		time.Sleep(20 * time.Millisecond)
+
+		fetcher.EXPECT().SelectBestShuffled(cfg.Peers).Return(peers)
+		fetcher.EXPECT().PollLayerData(gomock.Any(), first, peers[0], peers[1], peers[2])
+		synced.Store(true)
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			require.FailNow(t, "backfill didn't complete")
+		}
+	})
+	t.Run("retries failed layers", func(t *testing.T) {
+		fetcher := mocks.NewMockfetchLogic(gomock.NewController(t))
+		fetcher.EXPECT().SelectBestShuffled(cfg.Peers).Return(nil)
+		fetcher.EXPECT().SelectBestShuffled(cfg.Peers).Return(peers).Times(2)
+		gomock.InOrder(
+			fetcher.EXPECT().PollLayerData(gomock.Any(), first, gomock.Any()).Return(errors.New("test")),
+			fetcher.EXPECT().PollLayerData(gomock.Any(), first, gomock.Any()),
+		)
+		bf := newBackfill(logtest.New(t), cfg, sql.InMemory(), fetcher, time.Millisecond, func() bool { return true }, first)
+		require.NoError(t, bf.run(context.Background()))
+	})
+	t.Run("stops on shutdown", func(t *testing.T) {
+		fetcher := mocks.NewMockfetchLogic(gomock.NewController(t))
+		bf := newBackfill(logtest.New(t), cfg, sql.InMemory(), fetcher, time.Hour, func() bool { return false }, first)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, bf.run(ctx))
+	})
+	t.Run("range starts after effective genesis", func(t *testing.T) {
+		bf := newBackfill(logtest.New(t), BackfillConfig{From: 1}, sql.InMemory(), nil, time.Second, nil, first)
+		require.Equal(t, first, bf.from)
+		bf = newBackfill(logtest.New(t), BackfillConfig{From: first.Add(5).Uint32()}, sql.InMemory(), nil,
+			time.Second, nil, first)
+		require.NoError(t, bf.run(context.Background()))
+	})
+}
@@ -33,8 +33,9 @@ var (
 		"synced layers in different data type",
 		[]string{"data"},
 	)
-	dataLayer    = syncedLayers.WithLabelValues("data")
-	opinionLayer = syncedLayers.WithLabelValues("opinion")
+	dataLayer     = syncedLayers.WithLabelValues("data")
+	opinionLayer  = syncedLayers.WithLabelValues("opinion")
+	backfillLayer = syncedLayers.WithLabelValues("backfill")
 
 	syncedEpochs = metrics.NewGauge(
 		"epochs",
@@ -89,6 +90,13 @@ var (
 	certPeerError  = peerError.WithLabelValues("cert")
 	malPeerError   = peerError.WithLabelValues("mal")
 
+	backfillFail = metrics.NewCounter(
+		"backfill_fail",
+		namespace,
+		"number of layers that failed to backfill",
+		[]string{},
+	).WithLabelValues()
+
 	v2OpnPoll = metrics.NewCounter(
 		"opn_poll",
 		namespace,
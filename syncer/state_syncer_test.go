@@ -35,6 +35,14 @@ func opinions(prevHash types.Hash32) []*fetch.LayerOpinion {
 	}
 }
 
+// applyAt matches vm.ApplyContext for the layer.
+func applyAt(lid types.LayerID) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		lctx, ok := x.(vm.ApplyContext)
+		return ok && lctx.Layer == lid
+	})
+}
+
 func TestProcessLayers_MultiLayers(t *testing.T) {
 	gLid := types.GetEffectiveGenesis()
 	ts := newTestSyncerForState(t)
@@ -193,11 +201,11 @@ func TestProcessLayers_OpinionsNotAdopted(t *testing.T) {
 					certificates.Add(ts.cdb, lid, &types.Certificate{BlockID: tc.localCert}),
 				)
 				require.NoError(t, blocks.SetValid(ts.cdb, tc.localCert))
-				ts.mVm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, gomock.Any(), gomock.Any())
+				ts.mVm.EXPECT().Apply(applyAt(lid), gomock.Any(), gomock.Any())
 				ts.mConState.EXPECT().UpdateCache(gomock.Any(), lid, tc.localCert, nil, nil)
 				ts.mVm.EXPECT().GetStateRoot()
 			} else {
-				ts.mVm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, nil, nil)
+				ts.mVm.EXPECT().Apply(applyAt(lid), nil, nil)
 				ts.mConState.EXPECT().UpdateCache(gomock.Any(), lid, types.EmptyBlockID, nil, nil)
 				ts.mVm.EXPECT().GetStateRoot()
 			}
@@ -308,7 +316,7 @@ func TestProcessLayers_HareTakesTooLong(t *testing.T) {
 		ts.mTortoise.EXPECT().TallyVotes(gomock.Any(), lid)
 		ts.mTortoise.EXPECT().Updates().Return(fixture.RLayers(fixture.RLayer(lid)))
 		ts.mTortoise.EXPECT().OnApplied(lid, gomock.Any())
-		ts.mVm.EXPECT().Apply(vm.ApplyContext{Layer: lid}, nil, nil)
+		ts.mVm.EXPECT().Apply(applyAt(lid), nil, nil)
 		ts.mConState.EXPECT().UpdateCache(gomock.Any(), lid, types.EmptyBlockID, nil, nil)
 		ts.mVm.EXPECT().GetStateRoot()
 	}
@@ -332,7 +340,7 @@ func TestProcessLayers_OpinionsOptional(t *testing.T) {
 	ts.mTortoise.EXPECT().Updates().Return(fixture.RLayers(fixture.RLayer(lastSynced)))
 	ts.mTortoise.EXPECT().OnApplied(lastSynced, gomock.Any())
 	require.False(t, ts.syncer.stateSynced())
-	ts.mVm.EXPECT().Apply(vm.ApplyContext{Layer: lastSynced}, nil, nil)
+	ts.mVm.EXPECT().Apply(applyAt(lastSynced), nil, nil)
 	ts.mConState.EXPECT().UpdateCache(gomock.Any(), lastSynced, types.EmptyBlockID, nil, nil)
 	ts.mVm.EXPECT().GetStateRoot()
 	require.NoError(t, ts.syncer.processLayers(context.Background()))
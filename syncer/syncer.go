@@ -32,6 +32,7 @@ type Config struct {
 	DisableMeshAgreement     bool           `mapstructure:"disable-mesh-agreement"`
 	OutOfSyncThresholdLayers uint32         `mapstructure:"out-of-sync-threshold"`
 	AtxSync                  atxsync.Config `mapstructure:"atx-sync"`
+	Backfill                 BackfillConfig `mapstructure:"backfill"`
 }
 
 // DefaultConfig for the syncer.
@@ -45,6 +46,7 @@ func DefaultConfig() Config {
 		GossipDuration:           15 * time.Second,
 		OutOfSyncThresholdLayers: 3,
 		AtxSync:                  atxsync.DefaultConfig(),
+		Backfill:                 DefaultBackfillConfig(),
 	}
 }
 
@@ -125,6 +127,7 @@ type Syncer struct {
 	dataFetcher  fetchLogic
 	patrol       layerPatrol
 	forkFinder   forkFinder
+	backfill     *backfill
 	syncOnce     sync.Once
 	syncState    atomic.Value
 	atxSyncState atomic.Value
@@ -190,6 +193,18 @@ func NewSyncer(
 	s.isBusy.Store(false)
 	s.lastLayerSynced.Store(s.mesh.LatestLayer().Uint32())
 	s.lastEpochSynced.Store(types.GetEffectiveGenesis().GetEpoch().Uint32() - 1)
+	if s.cfg.Backfill.Enable && !s.cfg.Standalone {
+		// the tip lane syncs layers after the latest layer in the mesh
+		s.backfill = newBackfill(
+			s.logger.WithName("backfill"),
+			s.cfg.Backfill,
+			cdb,
+			s.dataFetcher,
+			s.cfg.Interval,
+			s.dataSynced,
+			s.mesh.LatestLayer(),
+		)
+	}
 	return s
 }
 
@@ -267,6 +282,12 @@ func (s *Syncer) Start() {
 				}
 			}
 		})
+		if s.backfill != nil {
+			s.logger.WithContext(ctx).Info("starting syncer backfill lane")
+			s.eg.Go(func() error {
+				return s.backfill.run(ctx)
+			})
+		}
 	})
 }
 
@@ -200,7 +200,7 @@ func Test_NodeClock_Await_WithClockMovingBackwards(t *testing.T) {
 	mClock := clockwork.NewFakeClockAt(now)
 
 	clock, err := NewClock(
-		withClock(mClock),
+		WithClock(mClock),
 		WithLayerDuration(layerDuration),
 		WithTickInterval(tickInterval),
 		WithGenesisTime(genesis),
@@ -250,7 +250,7 @@ func Test_NodeClock_NonMonotonicTick_Forward(t *testing.T) {
 	mClock := clockwork.NewFakeClockAt(genesis.Add(5 * layerDuration))
 
 	clock, err := NewClock(
-		withClock(mClock),
+		WithClock(mClock),
 		WithLayerDuration(layerDuration),
 		WithTickInterval(tickInterval),
 		WithGenesisTime(genesis),
@@ -289,7 +289,7 @@ func Test_NodeClock_NonMonotonicTick_Backward(t *testing.T) {
 	mClock := clockwork.NewFakeClockAt(genesis.Add(5 * layerDuration))
 
 	clock, err := NewClock(
-		withClock(mClock),
+		WithClock(mClock),
 		WithLayerDuration(layerDuration),
 		WithTickInterval(tickInterval),
 		WithGenesisTime(genesis),
@@ -386,7 +386,7 @@ func Fuzz_NodeClock_CurrentLayer(f *testing.F) {
 		mClock := clockwork.NewFakeClockAt(nowTime)
 
 		clock, err := NewClock(
-			withClock(mClock),
+			WithClock(mClock),
 			WithLayerDuration(layerTime),
 			WithTickInterval(tickInterval),
 			WithGenesisTime(genesisTime),
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"github.com/spacemeshos/go-spacemesh/timesync"
+	"github.com/spacemeshos/go-spacemesh/timesync/ntp"
 	"github.com/spacemeshos/go-spacemesh/timesync/peersync"
 )
 
@@ -12,6 +14,9 @@ var (
 // TimeConfig specifies the timesync params for ntp.
 type TimeConfig struct {
 	Peersync peersync.Config `mapstructure:"peersync"`
+	NTP      ntp.Config      `mapstructure:"ntp"`
+	// Source of time for the layer clock and hare, real unless the node runs in a simulation.
+	Source timesync.SourceConfig `mapstructure:"source"`
 }
 
 // DefaultConfig defines the default tymesync configuration.
@@ -19,6 +24,8 @@ func DefaultConfig() TimeConfig {
 	// TimeConfigValues defines default values for all time and ntp related params.
 	TimeConfigValues := TimeConfig{
 		Peersync: peersync.DefaultConfig(),
+		NTP:      ntp.DefaultConfig(),
+		Source:   timesync.DefaultSourceConfig(),
 	}
 
 	return TimeConfigValues
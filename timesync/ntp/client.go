@@ -0,0 +1,103 @@
+package ntp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	packetSize = 48
+	// ntpEpochOffset is the number of seconds between the ntp epoch (1900) and the unix epoch (1970).
+	ntpEpochOffset = 2208988800
+
+	modeClient = 3
+	modeServer = 4
+	version    = 4
+
+	leapNotSynchronized = 3
+	maxStratum          = 16
+)
+
+var (
+	errInvalidResponse = errors.New("invalid ntp response")
+	errNotSynchronized = errors.New("ntp server is not synchronized")
+)
+
+// Sample is the result of a single query to the ntp server.
+type Sample struct {
+	Server string
+	// Offset of the server clock from the local clock, positive if the local clock is behind.
+	Offset time.Duration
+	// RTT is the round trip time of the query without the processing time on the server.
+	RTT time.Duration
+}
+
+func toNTPTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano()) + ntpEpochOffset*uint64(time.Second)
+	sec := nanos / uint64(time.Second)
+	frac := (nanos - sec*uint64(time.Second)) << 32 / uint64(time.Second)
+	return sec<<32 | frac
+}
+
+func fromNTPTime(ts uint64) time.Time {
+	sec := ts >> 32
+	nanos := (ts & 0xffffffff) * uint64(time.Second) >> 32
+	return time.Unix(int64(sec)-ntpEpochOffset, int64(nanos))
+}
+
+// Query sends a single sntp request (RFC 4330) to the server and estimates the offset of the local clock.
+// Default ntp port is used if the address doesn't include a port.
+func Query(ctx context.Context, server string) (Sample, error) {
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, "123")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return Sample{}, fmt.Errorf("dial %s: %w", server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return Sample{}, fmt.Errorf("set deadline: %w", err)
+		}
+	}
+
+	request := make([]byte, packetSize)
+	request[0] = version<<3 | modeClient
+	sent := time.Now()
+	transmit := toNTPTime(sent)
+	binary.BigEndian.PutUint64(request[40:], transmit)
+	if _, err := conn.Write(request); err != nil {
+		return Sample{}, fmt.Errorf("send request to %s: %w", server, err)
+	}
+	response := make([]byte, packetSize)
+	n, err := conn.Read(response)
+	if err != nil {
+		return Sample{}, fmt.Errorf("read response from %s: %w", server, err)
+	}
+	received := sent.Add(time.Since(sent))
+
+	switch {
+	case n < packetSize:
+		return Sample{}, fmt.Errorf("%w: short packet (%d bytes)", errInvalidResponse, n)
+	case response[0]&0x7 != modeServer:
+		return Sample{}, fmt.Errorf("%w: unexpected mode %d", errInvalidResponse, response[0]&0x7)
+	case binary.BigEndian.Uint64(response[24:]) != transmit:
+		return Sample{}, fmt.Errorf("%w: origin timestamp doesn't match request", errInvalidResponse)
+	case response[0]>>6 == leapNotSynchronized, response[1] == 0, response[1] >= maxStratum:
+		return Sample{}, fmt.Errorf("%w: %s (stratum %d)", errNotSynchronized, server, response[1])
+	}
+	serverReceived := fromNTPTime(binary.BigEndian.Uint64(response[32:]))
+	serverSent := fromNTPTime(binary.BigEndian.Uint64(response[40:]))
+	return Sample{
+		Server: server,
+		Offset: (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2,
+		RTT:    received.Sub(sent) - serverSent.Sub(serverReceived),
+	}, nil
+}
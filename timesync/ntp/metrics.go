@@ -0,0 +1,21 @@
+package ntp
+
+import "github.com/spacemeshos/go-spacemesh/metrics"
+
+var (
+	offsetGauge = metrics.NewGauge(
+		"ntp_offset",
+		"clock",
+		"local clock difference with ntp servers in seconds",
+		[]string{},
+	).WithLabelValues()
+
+	queries = metrics.NewCounter(
+		"ntp_queries",
+		"clock",
+		"number of queries to ntp servers",
+		[]string{"outcome"},
+	)
+	querySucceeded = queries.WithLabelValues("ok")
+	queryFailed    = queries.WithLabelValues("fail")
+)
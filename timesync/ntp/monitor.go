@@ -0,0 +1,197 @@
+// Package ntp monitors the offset of the local clock from ntp servers.
+//
+// Hare and beacon rounds are started by the local clock, so a node with a drifting clock
+// silently misses protocol rounds. Monitor doesn't adjust the clock, it only warns the operator.
+package ntp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+)
+
+// ErrNotEnoughSamples is returned if less than the required number of servers responded.
+var ErrNotEnoughSamples = errors.New("ntp: not enough samples")
+
+// Config for Monitor.
+type Config struct {
+	Disable bool `mapstructure:"disable"`
+	// Servers that are queried in every round.
+	Servers []string `mapstructure:"servers"`
+	// Interval between rounds.
+	Interval time.Duration `mapstructure:"interval"`
+	// Timeout for the query to a single server.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// RequiredResponses is the min number of servers that must respond to estimate the offset.
+	RequiredResponses int `mapstructure:"required-responses"`
+	// MaxOffsetRatio is the max allowed offset as a fraction of the layer duration.
+	MaxOffsetRatio float64 `mapstructure:"max-offset-ratio"`
+}
+
+// DefaultConfig for Monitor.
+func DefaultConfig() Config {
+	return Config{
+		Servers: []string{
+			"time.google.com",
+			"time.cloudflare.com",
+			"0.pool.ntp.org",
+			"1.pool.ntp.org",
+		},
+		Interval:          10 * time.Minute,
+		Timeout:           5 * time.Second,
+		RequiredResponses: 2,
+		MaxOffsetRatio:    0.01,
+	}
+}
+
+// Opt modifies Monitor.
+type Opt func(*Monitor)
+
+// WithConfig modifies config used by Monitor.
+func WithConfig(cfg Config) Opt {
+	return func(m *Monitor) {
+		m.cfg = cfg
+	}
+}
+
+// WithLogger modifies logger used by Monitor.
+func WithLogger(logger *zap.Logger) Opt {
+	return func(m *Monitor) {
+		m.logger = logger
+	}
+}
+
+// Monitor periodically queries ntp servers and estimates the offset of the local clock
+// as the median of offsets reported by servers.
+type Monitor struct {
+	logger        *zap.Logger
+	cfg           Config
+	layerDuration time.Duration
+
+	// offset is the last estimated offset, valid only if measured is set.
+	offset   atomic.Int64
+	measured atomic.Bool
+	drifted  atomic.Bool
+}
+
+// New creates Monitor. Max allowed offset is a fraction of the layer duration.
+func New(layerDuration time.Duration, opts ...Opt) *Monitor {
+	m := &Monitor{
+		logger:        zap.NewNop(),
+		cfg:           DefaultConfig(),
+		layerDuration: layerDuration,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Run estimates the offset every interval until the context is canceled.
+func (m *Monitor) Run(ctx context.Context) error {
+	m.logger.Info("started ntp monitor",
+		zap.Strings("servers", m.cfg.Servers),
+		zap.Duration("max_offset", m.MaxClockOffset()),
+	)
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Measure(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Warn("failed to estimate clock offset with ntp servers", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Measure queries all servers concurrently and updates the estimated offset.
+func (m *Monitor) Measure(ctx context.Context) (time.Duration, error) {
+	var (
+		mu      sync.Mutex
+		offsets []time.Duration
+		wg      sync.WaitGroup
+	)
+	for _, server := range m.cfg.Servers {
+		server := server
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+			defer cancel()
+			sample, err := Query(ctx, server)
+			if err != nil {
+				queryFailed.Inc()
+				m.logger.Debug("ntp query failed", zap.String("server", server), zap.Error(err))
+				return
+			}
+			querySucceeded.Inc()
+			m.logger.Debug("ntp query",
+				zap.String("server", server),
+				zap.Duration("offset", sample.Offset),
+				zap.Duration("rtt", sample.RTT),
+			)
+			mu.Lock()
+			offsets = append(offsets, sample.Offset)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(offsets) < m.cfg.RequiredResponses {
+		return 0, fmt.Errorf("%w: %d servers responded, required %d",
+			ErrNotEnoughSamples, len(offsets), m.cfg.RequiredResponses)
+	}
+	slices.Sort(offsets)
+	offset := offsets[len(offsets)/2]
+	if len(offsets)%2 == 0 {
+		offset = (offsets[len(offsets)/2-1] + offset) / 2
+	}
+	m.offset.Store(int64(offset))
+	m.measured.Store(true)
+	offsetGauge.Set(offset.Seconds())
+	m.check(offset)
+	return offset, nil
+}
+
+func (m *Monitor) check(offset time.Duration) {
+	limit := m.MaxClockOffset()
+	if offset.Abs() <= limit {
+		if m.drifted.CompareAndSwap(true, false) {
+			m.logger.Info("local clock is synchronized with ntp servers", zap.Duration("offset", offset))
+		}
+		return
+	}
+	m.logger.Warn("local clock drifted from ntp servers, synchronize the system clock",
+		zap.Duration("offset", offset),
+		zap.Duration("max_offset", limit),
+	)
+	if m.drifted.CompareAndSwap(false, true) {
+		events.ReportError(events.NodeError{
+			Msg: fmt.Sprintf("local clock is %v away from ntp servers, max allowed offset is %v. "+
+				"node may miss hare and beacon rounds until the system clock is synchronized", offset.Abs(), limit),
+			Level: zapcore.WarnLevel,
+		})
+	}
+}
+
+// LastOffset returns the offset estimated in the most recent successful round.
+// The second return value is false if no round has succeeded yet.
+func (m *Monitor) LastOffset() (time.Duration, bool) {
+	return time.Duration(m.offset.Load()), m.measured.Load()
+}
+
+// MaxClockOffset returns the max allowed offset of the local clock from ntp servers.
+func (m *Monitor) MaxClockOffset() time.Duration {
+	return time.Duration(float64(m.layerDuration) * m.cfg.MaxOffsetRatio)
+}
@@ -0,0 +1,115 @@
+package ntp_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/events"
+	"github.com/spacemeshos/go-spacemesh/timesync/ntp"
+)
+
+func ntpTime(t time.Time) uint64 {
+	nanos := uint64(t.UnixNano()) + 2208988800*uint64(time.Second)
+	sec := nanos / uint64(time.Second)
+	return sec<<32 | (nanos-sec*uint64(time.Second))<<32/uint64(time.Second)
+}
+
+// launchServer starts the ntp server whose clock is ahead of the local clock by offset.
+func launchServer(tb testing.TB, offset time.Duration, stratum byte) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(tb, err)
+	tb.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n != 48 {
+				continue
+			}
+			now := time.Now().Add(offset)
+			resp := make([]byte, 48)
+			resp[0] = 4<<3 | 4
+			resp[1] = stratum
+			copy(resp[24:32], buf[40:48])
+			binary.BigEndian.PutUint64(resp[32:], ntpTime(now))
+			binary.BigEndian.PutUint64(resp[40:], ntpTime(now))
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	offset := 3 * time.Second
+	sample, err := ntp.Query(ctx, launchServer(t, offset, 2))
+	require.NoError(t, err)
+	require.InDelta(t, offset, sample.Offset, float64(100*time.Millisecond))
+	require.Less(t, sample.RTT, 100*time.Millisecond)
+
+	_, err = ntp.Query(ctx, launchServer(t, offset, 0))
+	require.ErrorContains(t, err, "not synchronized")
+}
+
+func TestMonitor(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+	sub := events.SubscribeErrors()
+
+	cfg := ntp.DefaultConfig()
+	cfg.Servers = []string{
+		launchServer(t, -2*time.Second, 1),
+		launchServer(t, 2*time.Second, 1),
+		launchServer(t, 3*time.Second, 1),
+		launchServer(t, 0, 0), // not synchronized
+	}
+	cfg.Timeout = time.Second
+	cfg.RequiredResponses = 3
+	cfg.MaxOffsetRatio = 0.1
+	monitor := ntp.New(10*time.Second, ntp.WithConfig(cfg), ntp.WithLogger(zaptest.NewLogger(t)))
+	require.Equal(t, time.Second, monitor.MaxClockOffset())
+	_, measured := monitor.LastOffset()
+	require.False(t, measured)
+
+	offset, err := monitor.Measure(context.Background())
+	require.NoError(t, err)
+	require.InDelta(t, 2*time.Second, offset, float64(100*time.Millisecond))
+	last, measured := monitor.LastOffset()
+	require.True(t, measured)
+	require.Equal(t, offset, last)
+
+	select {
+	case ev := <-sub.Out():
+		nodeErr := ev.(events.NodeError)
+		require.Equal(t, zapcore.WarnLevel, nodeErr.Level)
+		require.Contains(t, nodeErr.Msg, "ntp servers")
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for warning")
+	}
+
+	// warning is reported once until the clock is synchronized again
+	_, err = monitor.Measure(context.Background())
+	require.NoError(t, err)
+	select {
+	case ev := <-sub.Out():
+		require.FailNow(t, "unexpected event", "%v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cfg.RequiredResponses = 4
+	monitor = ntp.New(10*time.Second, ntp.WithConfig(cfg))
+	_, err = monitor.Measure(context.Background())
+	require.ErrorIs(t, err, ntp.ErrNotEnoughSamples)
+}
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/spacemeshos/go-spacemesh/codec"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 )
@@ -133,6 +134,9 @@ func New(h host.Host, peers getPeers, opts ...Option) *Sync {
 // Sync manages background worker that compares peers time with system time.
 type Sync struct {
 	errCnt uint32
+	// offset is the last offset measured with peers, valid only if measured is set.
+	offset   atomic.Int64
+	measured atomic.Bool
 
 	config Config
 	log    log.Log
@@ -206,13 +210,19 @@ func (s *Sync) run() error {
 			offset, err := s.GetOffset(ctx, round, prs)
 			cancel()
 			if err == nil {
+				s.offset.Store(int64(offset))
+				s.measured.Store(true)
 				if offset > s.config.MaxClockOffset || (offset < 0 && -offset > s.config.MaxClockOffset) {
 					s.log.With().Warning("peers offset is larger than max allowed clock difference",
 						log.Uint64("round", round),
 						log.Duration("offset", offset),
 						log.Duration("max_offset", s.config.MaxClockOffset),
 					)
-					if atomic.AddUint32(&s.errCnt, 1) == uint32(s.config.MaxOffsetErrors) {
+					errCnt := atomic.AddUint32(&s.errCnt, 1)
+					if errCnt == 1 || errCnt == uint32(s.config.MaxOffsetErrors) {
+						events.EmitClockSkew(offset, s.config.MaxClockOffset, errCnt == uint32(s.config.MaxOffsetErrors))
+					}
+					if errCnt == uint32(s.config.MaxOffsetErrors) {
 						return clockError{
 							err:     ErrPeersNotSynced,
 							details: clockErrorDetails{Drift: offset},
@@ -246,6 +256,17 @@ func (s *Sync) run() error {
 	}
 }
 
+// LastOffset returns the offset measured with peers in the most recent successful round.
+// The second return value is false if no round has succeeded yet.
+func (s *Sync) LastOffset() (time.Duration, bool) {
+	return time.Duration(s.offset.Load()), s.measured.Load()
+}
+
+// MaxClockOffset returns the configured max allowed difference between local and peers clocks.
+func (s *Sync) MaxClockOffset() time.Duration {
+	return s.config.MaxClockOffset
+}
+
 // GetOffset computes offset from received response. The method is stateless and safe to use concurrently.
 func (s *Sync) GetOffset(ctx context.Context, id uint64, prs []p2p.Peer) (time.Duration, error) {
 	var (
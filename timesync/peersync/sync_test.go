@@ -122,6 +122,9 @@ func TestSyncTerminateOnError(t *testing.T) {
 	case <-time.After(100 * time.Millisecond):
 		require.FailNow(t, "timed out waiting for sync to fail")
 	}
+	offset, measured := sync.LastOffset()
+	require.True(t, measured)
+	require.Greater(t, offset.Abs(), config.MaxClockOffset)
 }
 
 func TestSyncSimulateMultiple(t *testing.T) {
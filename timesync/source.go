@@ -0,0 +1,156 @@
+package timesync
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// SourceReal uses the system clock.
+	SourceReal = "real"
+	// SourceAccelerated runs the clock faster than the system clock by the configured speed.
+	SourceAccelerated = "accelerated"
+	// SourceStepped stops the clock, it is moved forward only by explicit calls to Advance.
+	SourceStepped = "stepped"
+)
+
+// ErrNotStepped is returned if the clock is advanced when the time source is not stepped.
+var ErrNotStepped = errors.New("time source is not stepped")
+
+// SourceConfig selects the source of time for the layer clock. Accelerated and stepped sources
+// are meant for integration environments that need to fast-forward epochs without patching the binary.
+type SourceConfig struct {
+	Mode string `mapstructure:"mode"`
+	// Speed is the ratio of the simulated time to the real time in the accelerated mode.
+	Speed float64 `mapstructure:"speed"`
+	// Start is the simulated time when the node starts (RFC3339), current time if empty.
+	// Not used in the real mode.
+	Start string `mapstructure:"start"`
+}
+
+// DefaultSourceConfig returns the config for the real time source.
+func DefaultSourceConfig() SourceConfig {
+	return SourceConfig{
+		Mode:  SourceReal,
+		Speed: 1,
+	}
+}
+
+// TimeSource provides the time for the layer clock and for the components that wait for protocol deadlines.
+type TimeSource struct {
+	clockwork.Clock
+	mode string
+}
+
+// NewTimeSource creates the time source from the config.
+func NewTimeSource(cfg SourceConfig) (*TimeSource, error) {
+	start := time.Now()
+	if cfg.Start != "" && cfg.Mode != SourceReal {
+		parsed, err := time.Parse(time.RFC3339, cfg.Start)
+		if err != nil {
+			return nil, fmt.Errorf("parse start time %s: %w", cfg.Start, err)
+		}
+		start = parsed
+	}
+	switch cfg.Mode {
+	case SourceReal, "":
+		return &TimeSource{Clock: clockwork.NewRealClock(), mode: SourceReal}, nil
+	case SourceAccelerated:
+		if cfg.Speed <= 0 {
+			return nil, fmt.Errorf("speed of the accelerated clock must be positive, got %v", cfg.Speed)
+		}
+		return &TimeSource{Clock: newAcceleratedClock(clockwork.NewRealClock(), start, cfg.Speed), mode: cfg.Mode}, nil
+	case SourceStepped:
+		return &TimeSource{Clock: clockwork.NewFakeClockAt(start), mode: cfg.Mode}, nil
+	}
+	return nil, fmt.Errorf("unknown time source %q", cfg.Mode)
+}
+
+// Mode returns the mode of the time source.
+func (s *TimeSource) Mode() string {
+	return s.mode
+}
+
+// Advance moves the stepped clock forward by d and notifies all waiters whose deadline has passed.
+func (s *TimeSource) Advance(d time.Duration) (time.Time, error) {
+	fake, ok := s.Clock.(clockwork.FakeClock)
+	if !ok {
+		return time.Time{}, ErrNotStepped
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("clock can't be moved back (%v)", d)
+	}
+	fake.Advance(d)
+	return fake.Now(), nil
+}
+
+// acceleratedClock runs speed times faster than the underlying clock, starting from the start time.
+type acceleratedClock struct {
+	clock     clockwork.Clock
+	start     time.Time
+	realStart time.Time
+	speed     float64
+}
+
+func newAcceleratedClock(clock clockwork.Clock, start time.Time, speed float64) *acceleratedClock {
+	return &acceleratedClock{
+		clock:     clock,
+		start:     start,
+		realStart: clock.Now(),
+		speed:     speed,
+	}
+}
+
+// scale converts the simulated duration into the duration of the underlying clock.
+func (c *acceleratedClock) scale(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
+
+func (c *acceleratedClock) Now() time.Time {
+	return c.start.Add(time.Duration(float64(c.clock.Since(c.realStart)) * c.speed))
+}
+
+func (c *acceleratedClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *acceleratedClock) After(d time.Duration) <-chan time.Time {
+	return c.clock.After(c.scale(d))
+}
+
+func (c *acceleratedClock) Sleep(d time.Duration) {
+	c.clock.Sleep(c.scale(d))
+}
+
+func (c *acceleratedClock) NewTicker(d time.Duration) clockwork.Ticker {
+	return acceleratedTicker{Ticker: c.clock.NewTicker(c.scale(d)), clock: c}
+}
+
+func (c *acceleratedClock) NewTimer(d time.Duration) clockwork.Timer {
+	return acceleratedTimer{Timer: c.clock.NewTimer(c.scale(d)), clock: c}
+}
+
+func (c *acceleratedClock) AfterFunc(d time.Duration, f func()) clockwork.Timer {
+	return acceleratedTimer{Timer: c.clock.AfterFunc(c.scale(d), f), clock: c}
+}
+
+type acceleratedTicker struct {
+	clockwork.Ticker
+	clock *acceleratedClock
+}
+
+func (t acceleratedTicker) Reset(d time.Duration) {
+	t.Ticker.Reset(t.clock.scale(d))
+}
+
+type acceleratedTimer struct {
+	clockwork.Timer
+	clock *acceleratedClock
+}
+
+func (t acceleratedTimer) Reset(d time.Duration) bool {
+	return t.Timer.Reset(t.clock.scale(d))
+}
@@ -0,0 +1,90 @@
+package timesync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestTimeSource_Stepped(t *testing.T) {
+	genesis := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	source, err := NewTimeSource(SourceConfig{Mode: SourceStepped, Start: genesis.Format(time.RFC3339)})
+	require.NoError(t, err)
+	require.Equal(t, SourceStepped, source.Mode())
+
+	clock, err := NewClock(
+		WithClock(source),
+		WithLayerDuration(time.Minute),
+		WithTickInterval(time.Second),
+		WithGenesisTime(genesis),
+		WithLogger(zaptest.NewLogger(t)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(clock.Close)
+
+	require.Equal(t, types.LayerID(0), clock.CurrentLayer())
+	awaited := clock.AwaitLayer(types.LayerID(3))
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-awaited:
+		require.FailNow(t, "layer must not be ticked while the clock is stopped")
+	default:
+	}
+
+	now, err := source.Advance(3 * time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, genesis.Add(3*time.Minute), now)
+	require.Equal(t, types.LayerID(3), clock.CurrentLayer())
+	select {
+	case <-awaited:
+	case <-time.After(time.Second):
+		require.FailNow(t, "layer wasn't ticked after the clock was advanced")
+	}
+
+	_, err = source.Advance(-time.Second)
+	require.Error(t, err)
+}
+
+func TestTimeSource_Accelerated(t *testing.T) {
+	genesis := time.Now()
+	source, err := NewTimeSource(SourceConfig{Mode: SourceAccelerated, Speed: 1000})
+	require.NoError(t, err)
+	_, err = source.Advance(time.Second)
+	require.ErrorIs(t, err, ErrNotStepped)
+
+	clock, err := NewClock(
+		WithClock(source),
+		WithLayerDuration(time.Minute),
+		WithTickInterval(time.Second),
+		WithGenesisTime(genesis),
+		WithLogger(zaptest.NewLogger(t)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(clock.Close)
+
+	// 5 layers of a minute take 300ms
+	select {
+	case <-clock.AwaitLayer(types.LayerID(5)):
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "accelerated clock didn't reach the layer")
+	}
+	require.GreaterOrEqual(t, clock.CurrentLayer(), types.LayerID(5))
+}
+
+func TestTimeSource_Config(t *testing.T) {
+	source, err := NewTimeSource(DefaultSourceConfig())
+	require.NoError(t, err)
+	require.Equal(t, SourceReal, source.Mode())
+	require.WithinDuration(t, time.Now(), source.Now(), time.Second)
+
+	_, err = NewTimeSource(SourceConfig{Mode: SourceAccelerated})
+	require.ErrorContains(t, err, "must be positive")
+	_, err = NewTimeSource(SourceConfig{Mode: "unknown"})
+	require.ErrorContains(t, err, "unknown time source")
+	_, err = NewTimeSource(SourceConfig{Mode: SourceStepped, Start: "yesterday"})
+	require.ErrorContains(t, err, "parse start time")
+}
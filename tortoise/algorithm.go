@@ -12,6 +12,7 @@ import (
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/common/types/result"
 	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/sql"
 )
 
 // Config for protocol parameters.
@@ -33,7 +34,19 @@ type Config struct {
 	// CollectDetails sets numbers of layers to collect details.
 	// Must be less than WindowSize.
 	CollectDetails uint32 `mapstructure:"tortoise-collect-details"`
-	LayerSize      uint32
+	// SpillMemory is a budget in bytes for ballots and votes kept in memory.
+	// If the estimated size of the processed layers exceeds the budget, ballots from the oldest layers
+	// are moved to the disk, as long as the store is provided with WithSpillStore.
+	// Ballots from the last processed layer are always kept in memory. Zero disables spilling.
+	SpillMemory uint64 `mapstructure:"tortoise-spill-memory"`
+	// ReplayCheck replays the sliding window from the ballots stored in the database on startup
+	// and refuses to start if recomputed validity of the blocks doesn't match the stored validity.
+	ReplayCheck bool `mapstructure:"tortoise-replay-check"`
+	// SnapshotInterval is a number of processed layers between snapshots of the state.
+	// The state is restored from the snapshot on restart if the store is provided with WithSnapshotStore.
+	// Zero disables snapshots.
+	SnapshotInterval uint32 `mapstructure:"tortoise-snapshot-interval"`
+	LayerSize        uint32
 }
 
 // DefaultConfig for Tortoise.
@@ -57,6 +70,12 @@ type Tortoise struct {
 	mu     sync.Mutex
 	trtl   *turtle
 	tracer *tracer
+
+	spillDB *sql.Database
+
+	snapshotDB *sql.Database
+	// last processed layer that was snapshotted
+	snapshotted types.LayerID
 }
 
 // Opt for configuring tortoise.
@@ -83,6 +102,22 @@ func WithTracer(opts ...TraceOpt) Opt {
 	}
 }
 
+// WithSpillStore sets the database for ballots that are moved out of memory.
+// It is used only if SpillMemory is not zero.
+func WithSpillStore(db *sql.Database) Opt {
+	return func(t *Tortoise) {
+		t.spillDB = db
+	}
+}
+
+// WithSnapshotStore sets the database for snapshots of the state.
+// It is used only if SnapshotInterval is not zero.
+func WithSnapshotStore(db *sql.Database) Opt {
+	return func(t *Tortoise) {
+		t.snapshotDB = db
+	}
+}
+
 // New creates Tortoise instance.
 func New(atxdata *atxsdata.Data, opts ...Opt) (*Tortoise, error) {
 	t := &Tortoise{
@@ -109,6 +144,13 @@ func New(atxdata *atxsdata.Data, opts ...Opt) (*Tortoise, error) {
 		)
 	}
 	t.trtl = newTurtle(t.logger, t.cfg, atxdata)
+	if t.spillDB != nil && t.cfg.SpillMemory > 0 {
+		spill, err := newSpiller(t.spillDB, t.logger)
+		if err != nil {
+			return nil, fmt.Errorf("init spill store: %w", err)
+		}
+		t.trtl.spill = spill
+	}
 	if t.tracer != nil {
 		t.tracer.On(&ConfigTrace{
 			Hdist:                    t.cfg.Hdist,
@@ -278,6 +320,14 @@ func (t *Tortoise) EncodeVotes(
 
 // TallyVotes up to the specified layer.
 func (t *Tortoise) TallyVotes(ctx context.Context, lid types.LayerID) {
+	if snapshot := t.tallyVotes(ctx, lid); snapshot != nil {
+		if err := t.writeSnapshot(snapshot); err != nil {
+			t.logger.Error("failed to write tortoise snapshot", zap.Error(err))
+		}
+	}
+}
+
+func (t *Tortoise) tallyVotes(ctx context.Context, lid types.LayerID) *snapshot {
 	start := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -288,6 +338,7 @@ func (t *Tortoise) TallyVotes(ctx context.Context, lid types.LayerID) {
 	if t.tracer != nil {
 		t.tracer.On(&TallyTrace{Layer: lid})
 	}
+	return t.takeSnapshot()
 }
 
 // OnAtx is expected to be called before ballots that use this atx.
@@ -412,7 +463,7 @@ type BallotData struct {
 func (t *Tortoise) GetBallot(id types.BallotID) *BallotData {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	info := t.trtl.ballotRefs[id]
+	info := t.trtl.ballotRef(id)
 	if info == nil {
 		return nil
 	}
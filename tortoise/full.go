@@ -73,7 +73,7 @@ func (f *full) countBallot(logger *zap.Logger, ballot *ballotInfo) {
 func (f *full) countForLateBlock(block *blockInfo) {
 	start := time.Now()
 	for lid := block.layer.Add(1); !lid.After(f.counted); lid = lid.Add(1) {
-		for _, ballot := range f.ballots[lid] {
+		for _, ballot := range f.layerBallots(lid) {
 			if block.height > ballot.reference.height {
 				continue
 			}
@@ -105,7 +105,7 @@ func (f *full) countDelayed(logger *zap.Logger, lid types.LayerID) {
 
 func (f *full) countVotes(logger *zap.Logger) {
 	for lid := f.counted.Add(1); !lid.After(f.processed); lid = lid.Add(1) {
-		for _, ballot := range f.ballots[lid] {
+		for _, ballot := range f.layerBallots(lid) {
 			f.countBallot(logger, ballot)
 		}
 	}
@@ -0,0 +1,131 @@
+package tortoise
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Progress is a snapshot of the tortoise state that explains why layers after the verified layer
+// are not verified yet.
+type Progress struct {
+	Mode      Mode
+	Last      types.LayerID
+	Processed types.LayerID
+	Verified  types.LayerID
+	Evicted   types.LayerID
+	// Pending is the lowest layer with opinion that changed since the last call to Updates.
+	// It is zero if there are no changes.
+	Pending types.LayerID
+	// Undecided are layers after the verified layer that are waiting for verification, in ascending order.
+	Undecided []LayerProgress
+}
+
+// LayerProgress compares the weight counted for the layer with the threshold required to verify it.
+type LayerProgress struct {
+	Layer          types.LayerID
+	HareTerminated bool
+	Opinion        types.Hash32
+	// Threshold is the weight that needs to be crossed to verify the layer.
+	Threshold float64
+	// Margin is the weight of ballots that agree with the local opinion on the layer,
+	// counted in verifying mode. Uncounted weight is already subtracted from it.
+	Margin float64
+	// Uncounted is the expected weight of ballots that weren't received yet.
+	Uncounted float64
+	// Empty is the weight of ballots that vote for the layer to be empty, counted in full mode.
+	Empty  float64
+	Blocks []BlockOpinion
+}
+
+// BlockOpinion is the local opinion on the block.
+type BlockOpinion struct {
+	Header types.Vote
+	// Data is true if the block is available locally.
+	Data     bool
+	Hare     string
+	Validity string
+	// Margin is the weight of ballots that support the block minus the weight of ballots
+	// against it, counted in full mode.
+	Margin float64
+	// Vote is the vote on the block that will be encoded in the next ballot, Reason explains how it was chosen.
+	Vote   string
+	Reason string
+}
+
+// Progress returns the state of verification for at most limit layers after the verified layer,
+// up to and including the last processed layer.
+func (t *Tortoise) Progress(limit int) Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	progress := Progress{
+		Mode:      Verifying,
+		Last:      t.trtl.last,
+		Processed: t.trtl.processed,
+		Verified:  t.trtl.verified,
+		Evicted:   t.trtl.evicted,
+		Pending:   t.trtl.pending,
+	}
+	if t.trtl.isFull {
+		progress.Mode = Full
+	}
+	for lid := max(t.trtl.verified, t.trtl.evicted).Add(1); !lid.After(t.trtl.processed); lid = lid.Add(1) {
+		if len(progress.Undecided) == limit {
+			break
+		}
+		layer := t.trtl.layer(lid)
+		lp := LayerProgress{
+			Layer:          lid,
+			HareTerminated: layer.hareTerminated,
+			Opinion:        layer.opinion,
+			Empty:          layer.empty.Float(),
+		}
+		// votes for the layer are expected only from ballots in the later layers
+		if lid.Before(t.trtl.last) {
+			margin, uncounted := t.trtl.verifying.margin(lid)
+			lp.Threshold = t.trtl.globalThreshold(t.cfg, lid).Float()
+			lp.Margin = margin.Float()
+			if uncounted.Float() > 0 {
+				lp.Uncounted = uncounted.Float()
+			}
+		}
+		for _, block := range layer.blocks {
+			lp.Blocks = append(lp.Blocks, t.blockOpinion(block))
+		}
+		progress.Undecided = append(progress.Undecided, lp)
+	}
+	return progress
+}
+
+// BlockOpinion returns the local opinion on the block in the layer.
+// It returns false if the block is unknown or the layer is outside of the sliding window.
+func (t *Tortoise) BlockOpinion(lid types.LayerID, id types.BlockID) (BlockOpinion, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !lid.After(t.trtl.evicted) || lid.After(t.trtl.last) {
+		return BlockOpinion{}, false
+	}
+	for _, block := range t.trtl.layer(lid).blocks {
+		if block.id == id {
+			return t.blockOpinion(block), true
+		}
+	}
+	return BlockOpinion{}, false
+}
+
+func (t *Tortoise) blockOpinion(block *blockInfo) BlockOpinion {
+	opinion := BlockOpinion{
+		Header:   block.header(),
+		Data:     block.data,
+		Hare:     block.hare.String(),
+		Validity: block.validity.String(),
+		Margin:   block.margin.Float(),
+	}
+	vote, reason, err := t.trtl.getFullVote(t.trtl.verified, t.trtl.last.Add(1), block)
+	if err != nil {
+		opinion.Vote = abstain.String()
+		opinion.Reason = err.Error()
+	} else {
+		opinion.Vote = vote.String()
+		opinion.Reason = reason.String()
+	}
+	return opinion
+}
@@ -0,0 +1,79 @@
+package tortoise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/tortoise/sim"
+)
+
+func TestProgress(t *testing.T) {
+	const size = 4
+	ctx := context.Background()
+	cfg := defaultTestConfig()
+	cfg.LayerSize = size
+
+	s := sim.New(sim.WithLayerSize(size))
+	s.Setup(sim.WithSetupMinerRange(size, size))
+	tortoise := tortoiseFromSimState(t, s.GetState(0), WithConfig(cfg), WithLogger(logtest.New(t)))
+
+	var last types.LayerID
+	for i := 0; i < 3; i++ {
+		last = s.Next(sim.WithNumBlocks(1))
+		tortoise.TallyVotes(ctx, last)
+	}
+	progress := tortoise.Progress(10)
+	require.Equal(t, Mode(Verifying), progress.Mode)
+	require.Equal(t, last, progress.Processed)
+	require.Equal(t, last.Sub(1), progress.Verified)
+	require.Len(t, progress.Undecided, 1)
+	require.Equal(t, last, progress.Undecided[0].Layer)
+
+	stuck := last.Add(1)
+	for i := 0; i < 3; i++ {
+		last = s.Next(sim.WithNumBlocks(1), sim.WithoutHareOutput())
+		tortoise.TallyVotes(ctx, last)
+	}
+	progress = tortoise.Progress(10)
+	require.Less(t, progress.Verified, stuck)
+	require.NotEmpty(t, progress.Undecided)
+	require.Equal(t, last, progress.Undecided[len(progress.Undecided)-1].Layer)
+	// verification is stuck as margin doesn't cross the threshold
+	require.Equal(t, progress.Verified.Add(1), progress.Undecided[0].Layer)
+	require.Less(t, progress.Undecided[0].Margin, progress.Undecided[0].Threshold)
+	var nohare *LayerProgress
+	for i := range progress.Undecided {
+		layer := &progress.Undecided[i]
+		require.Len(t, layer.Blocks, 1)
+		if layer.Layer < stuck {
+			require.True(t, layer.HareTerminated)
+			continue
+		}
+		require.False(t, layer.HareTerminated)
+		require.Equal(t, "abstain", layer.Blocks[0].Hare)
+		require.Equal(t, "abstain", layer.Blocks[0].Vote)
+		require.Equal(t, reasonHareOutput.String(), layer.Blocks[0].Reason)
+		if nohare == nil {
+			nohare = layer
+		}
+	}
+	require.NotNil(t, nohare)
+	require.Len(t, tortoise.Progress(1).Undecided, 1)
+
+	ids, err := blocks.IDsInLayer(s.GetState(0).DB, stuck)
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	opinion, exists := tortoise.BlockOpinion(stuck, ids[0])
+	require.True(t, exists)
+	require.Equal(t, nohare.Blocks[0], opinion)
+
+	_, exists = tortoise.BlockOpinion(stuck, types.RandomBlockID())
+	require.False(t, exists)
+	_, exists = tortoise.BlockOpinion(last.Add(10), ids[0])
+	require.False(t, exists)
+}
@@ -39,6 +39,36 @@ var (
 		"Number of epochs in the state",
 		[]string{},
 	).WithLabelValues()
+	spilledLayers = metrics.NewGauge(
+		"spilled_layers",
+		namespace,
+		"Number of layers with ballots moved to the disk",
+		[]string{},
+	).WithLabelValues()
+	spillMemory = metrics.NewGauge(
+		"spill_memory",
+		namespace,
+		"Estimated size in bytes of the ballots and votes in memory that can be spilled",
+		[]string{},
+	).WithLabelValues()
+	spilledBallots = metrics.NewCounter(
+		"spilled_ballots",
+		namespace,
+		"Number of ballots moved to the disk",
+		[]string{},
+	).WithLabelValues()
+	reloadedBallots = metrics.NewCounter(
+		"reloaded_ballots",
+		namespace,
+		"Number of ballots loaded back from the disk",
+		[]string{},
+	).WithLabelValues()
+	snapshotLayer = metrics.NewGauge(
+		"snapshot_layer",
+		namespace,
+		"Last processed layer in the stored snapshot",
+		[]string{},
+	).WithLabelValues()
 	malfeasantNumber = metrics.NewGauge(
 		"malfeasant",
 		namespace,
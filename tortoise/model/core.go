@@ -179,7 +179,7 @@ func (c *core) OnMessage(m Messenger, event Message) {
 		if err != nil {
 			c.logger.With().Fatal("failed is malicious lookup", log.Err(err))
 		}
-		c.atxdata.AddFromHeader(vAtx.ToHeader(), 0, malicious)
+		c.atxdata.AddFromHeader(vAtx.ToHeader(), 0, vAtx.SmesherID, malicious)
 	case MessageBeacon:
 		beacons.Add(c.cdb, ev.EpochID+1, ev.Beacon)
 	case MessageCoinflip:
@@ -34,6 +34,15 @@ func Recover(
 	if err != nil {
 		return nil, fmt.Errorf("failed to load latest known layer: %w", err)
 	}
+	if trtl.snapshotDB != nil && trtl.cfg.SnapshotInterval > 0 && trtl.tracer == nil {
+		restored, err := trtl.restoreSnapshot(db, atxdata)
+		if err != nil {
+			return nil, fmt.Errorf("restore snapshot: %w", err)
+		}
+		if restored {
+			return trtl, recoverFromSnapshot(ctx, trtl, db, atxdata, last, current)
+		}
+	}
 	applied, err := layers.GetLastApplied(db)
 	if err != nil {
 		return nil, fmt.Errorf("get last applied: %w", err)
@@ -114,8 +123,41 @@ func Recover(
 		return trtl, nil
 	}
 	trtl.TallyVotes(ctx, last)
-	// find topmost layer that was already applied with same result
-	// and reset pending so that result for that layer is not returned
+	if err := resetPending(trtl, db, valid, start); err != nil {
+		return nil, err
+	}
+	return trtl, nil
+}
+
+// recoverFromSnapshot loads data that was added after the snapshot was written and tallies votes.
+func recoverFromSnapshot(
+	ctx context.Context,
+	trtl *Tortoise,
+	db sql.Executor,
+	atxdata *atxsdata.Data,
+	last, current types.LayerID,
+) error {
+	malicious, err := identities.GetMalicious(db)
+	if err != nil {
+		return fmt.Errorf("recover malicious %w", err)
+	}
+	for _, id := range malicious {
+		trtl.OnMalfeasance(id)
+	}
+	if err := catchUp(ctx, trtl, db, atxdata, last); err != nil {
+		return err
+	}
+	valid, err := blocks.LastValid(db)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return fmt.Errorf("get last valid: %w", err)
+	}
+	trtl.TallyVotes(ctx, min(last, current))
+	return resetPending(trtl, db, valid, trtl.trtl.evicted.Add(1))
+}
+
+// resetPending finds topmost layer that was already applied with same result
+// and resets pending so that result for that layer is not returned.
+func resetPending(trtl *Tortoise, db sql.Executor, valid, start types.LayerID) error {
 	for prev := valid; prev >= start; prev-- {
 		opinion, err := layers.GetAggregatedHash(db, prev)
 		if err == nil && opinion != types.EmptyLayerHash {
@@ -124,10 +166,10 @@ func Recover(
 			}
 		}
 		if err != nil && !errors.Is(err, sql.ErrNotFound) {
-			return nil, fmt.Errorf("check opinion %w", err)
+			return fmt.Errorf("check opinion %w", err)
 		}
 	}
-	return trtl, nil
+	return nil
 }
 
 func recoverEpoch(target types.EpochID, trtl *Tortoise, db sql.Executor, atxdata *atxsdata.Data) error {
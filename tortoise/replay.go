@@ -0,0 +1,244 @@
+package tortoise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/go-spacemesh/atxsdata"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/common/types/result"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/identities"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+)
+
+// ReplayMismatch is a difference between the state recomputed by the replay and the state stored in the database.
+type ReplayMismatch struct {
+	Layer types.LayerID
+	// Block is empty if the mismatch is in the opinion on the whole layer.
+	Block    types.BlockID
+	Stored   string
+	Replayed string
+}
+
+func (m ReplayMismatch) String() string {
+	if m.Block == types.EmptyBlockID {
+		return fmt.Sprintf("layer %d: stored %s replayed %s", m.Layer, m.Stored, m.Replayed)
+	}
+	return fmt.Sprintf("layer %d block %s: stored %s replayed %s", m.Layer, m.Block, m.Stored, m.Replayed)
+}
+
+// ReplayResult compares the tortoise state recomputed from the database with the stored state.
+type ReplayResult struct {
+	// First and Last are the first and the last replayed layers.
+	First, Last types.LayerID
+	// Verified is the verified layer computed by the replay.
+	Verified types.LayerID
+	// StoredVerified is the last layer with a valid block in the database.
+	StoredVerified types.LayerID
+	Mismatches     []ReplayMismatch
+}
+
+// Consistent is true if the replay reached the stored verified layer and
+// validity of every block decided by the replay matches the stored validity.
+func (r *ReplayResult) Consistent() bool {
+	return len(r.Mismatches) == 0 && !r.Verified.Before(r.StoredVerified)
+}
+
+// Replay reconstructs the tortoise state from ballots, blocks, activations and hare outputs
+// stored in the database, without using stored validity, and compares the result with the database.
+//
+// Replay starts from the first layer of the epoch that contains from. If from is not after the effective
+// genesis all layers are replayed, otherwise replay starts from the stored opinion of the previous layer.
+// Atxdata is expected to contain activations for all replayed epochs.
+func Replay(
+	ctx context.Context,
+	db sql.Executor,
+	atxdata *atxsdata.Data,
+	from types.LayerID,
+	opts ...Opt,
+) (*ReplayResult, error) {
+	trtl, err := New(atxdata, opts...)
+	if err != nil {
+		return nil, err
+	}
+	last, err := ballots.LatestLayer(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest known layer: %w", err)
+	}
+	rst := &ReplayResult{First: types.GetEffectiveGenesis() + 1, Last: last}
+	if from.After(types.GetEffectiveGenesis()) {
+		// reference ballots are in the first layer of the epoch, they track beacon and eligibilities.
+		window := from.GetEpoch().FirstLayer()
+		if window > rst.First {
+			opinion, err := layers.GetAggregatedHash(db, window)
+			if err != nil {
+				return nil, fmt.Errorf("opinion for layer %d: %w", window, err)
+			}
+			prev, err := layers.GetAggregatedHash(db, window-1)
+			if err != nil {
+				return nil, fmt.Errorf("opinion for layer %d: %w", window-1, err)
+			}
+			trtl.RecoverFrom(window, opinion, prev)
+			rst.First = window
+		}
+	}
+	stored, err := blocks.LastValid(db)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return nil, fmt.Errorf("get last valid: %w", err)
+	}
+	rst.StoredVerified = stored
+
+	malicious, err := identities.GetMalicious(db)
+	if err != nil {
+		return nil, fmt.Errorf("recover malicious %w", err)
+	}
+	for _, id := range malicious {
+		trtl.OnMalfeasance(id)
+	}
+	if types.GetEffectiveGenesis() != types.FirstEffectiveGenesis() {
+		if err := recoverEpoch(types.GetEffectiveGenesis().Add(1).GetEpoch(), trtl, db, atxdata); err != nil {
+			return nil, err
+		}
+	}
+
+	// results are compared with the database once the layer is outside of the sliding window,
+	// as tortoise will not change opinion on it anymore.
+	results := map[types.LayerID]result.Layer{}
+	next := rst.First
+	for lid := rst.First; !lid.After(last); lid = lid.Add(1) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		if err := replayLayer(trtl, db, atxdata, lid); err != nil {
+			return nil, fmt.Errorf("failed to replay layer %d: %w", lid, err)
+		}
+		trtl.TallyVotes(ctx, lid)
+		for _, layer := range trtl.Updates() {
+			results[layer.Layer] = layer
+			if layer.Verified {
+				trtl.OnApplied(layer.Layer, layer.Opinion)
+			}
+		}
+		verified := trtl.LatestComplete()
+		for ; !next.After(verified) && next.Add(trtl.cfg.WindowSize).Before(lid); next = next.Add(1) {
+			if err := rst.compareLayer(db, results, next); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rst.Verified = trtl.LatestComplete()
+	for ; !next.After(rst.Verified); next = next.Add(1) {
+		if err := rst.compareLayer(db, results, next); err != nil {
+			return nil, err
+		}
+	}
+	return rst, nil
+}
+
+// replayLayer loads the layer the same way as RecoverLayer, but without stored validity of the blocks.
+func replayLayer(
+	trtl *Tortoise,
+	db sql.Executor,
+	atxdata *atxsdata.Data,
+	lid types.LayerID,
+) error {
+	if lid.FirstInEpoch() {
+		if err := recoverEpoch(lid.GetEpoch(), trtl, db, atxdata); err != nil {
+			return err
+		}
+	}
+	blocksrst, err := blocks.Layer(db, lid)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocksrst {
+		trtl.OnBlock(block.ToVote())
+	}
+	hare, err := certificates.GetHareOutput(db, lid)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return err
+	}
+	if err == nil {
+		trtl.OnHareOutput(lid, hare)
+	}
+	ballotsrst, err := ballots.LayerNoMalicious(db, lid)
+	if err != nil {
+		return err
+	}
+	for _, ballot := range ballotsrst {
+		if ballot.EpochData != nil {
+			trtl.OnRecoveredBallot(ballot.ToTortoiseData())
+		}
+	}
+	for _, ballot := range ballotsrst {
+		if ballot.EpochData == nil {
+			trtl.OnRecoveredBallot(ballot.ToTortoiseData())
+		}
+	}
+	coin, err := layers.GetWeakCoin(db, lid)
+	if err != nil && !errors.Is(err, sql.ErrNotFound) {
+		return err
+	} else if err == nil {
+		trtl.OnWeakCoin(lid, coin)
+	}
+	return nil
+}
+
+func (r *ReplayResult) compareLayer(db sql.Executor, results map[types.LayerID]result.Layer, lid types.LayerID) error {
+	layer, exists := results[lid]
+	if !exists {
+		return nil
+	}
+	delete(results, lid)
+	return r.compare(db, layer)
+}
+
+func (r *ReplayResult) compare(db sql.Executor, layer result.Layer) error {
+	if layer.Verified {
+		opinion, err := layers.GetAggregatedHash(db, layer.Layer)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return fmt.Errorf("opinion for layer %d: %w", layer.Layer, err)
+		}
+		if err == nil && opinion != types.EmptyLayerHash && opinion != layer.Opinion {
+			r.Mismatches = append(r.Mismatches, ReplayMismatch{
+				Layer:    layer.Layer,
+				Stored:   opinion.ShortString(),
+				Replayed: layer.Opinion.ShortString(),
+			})
+		}
+	}
+	for _, block := range layer.Blocks {
+		if !block.Valid && !block.Invalid {
+			continue
+		}
+		valid, err := blocks.IsValid(db, block.Header.ID)
+		if errors.Is(err, sql.ErrNotFound) || errors.Is(err, blocks.ErrValidityNotDecided) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if valid != block.Valid {
+			r.Mismatches = append(r.Mismatches, ReplayMismatch{
+				Layer:    layer.Layer,
+				Block:    block.Header.ID,
+				Stored:   validityString(valid),
+				Replayed: validityString(block.Valid),
+			})
+		}
+	}
+	return nil
+}
+
+func validityString(valid bool) string {
+	if valid {
+		return "valid"
+	}
+	return "invalid"
+}
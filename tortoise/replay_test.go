@@ -0,0 +1,88 @@
+package tortoise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/tortoise/sim"
+)
+
+func TestReplay(t *testing.T) {
+	const size = 4
+	ctx := context.Background()
+	cfg := defaultTestConfig()
+	cfg.LayerSize = size
+	cfg.WindowSize = 10
+
+	s := sim.New(sim.WithLayerSize(size))
+	s.Setup(sim.WithSetupMinerRange(size, size))
+	state := s.GetState(0)
+	db := state.DB.Executor
+	trtl := tortoiseFromSimState(t, state, WithConfig(cfg), WithLogger(logtest.New(t)))
+
+	var last types.LayerID
+	for i := 0; i < 30; i++ {
+		last = s.Next(sim.WithNumBlocks(1))
+		trtl.TallyVotes(ctx, last)
+		for _, layer := range trtl.Updates() {
+			for _, block := range layer.Blocks {
+				if block.Valid {
+					require.NoError(t, blocks.SetValid(db, block.Header.ID))
+				} else if block.Invalid {
+					require.NoError(t, blocks.SetInvalid(db, block.Header.ID))
+				}
+			}
+			if layer.Verified {
+				require.NoError(t, layers.SetMeshHash(db, layer.Layer, layer.Opinion))
+				trtl.OnApplied(layer.Layer, layer.Opinion)
+			}
+		}
+	}
+	require.Equal(t, last.Sub(1), trtl.LatestComplete())
+
+	replay := func(tb testing.TB, from types.LayerID) *ReplayResult {
+		rst, err := Replay(ctx, db, state.Atxdata, from, WithConfig(cfg), WithLogger(logtest.New(tb)))
+		require.NoError(tb, err)
+		return rst
+	}
+
+	t.Run("consistent", func(t *testing.T) {
+		rst := replay(t, 0)
+		require.Equal(t, types.GetEffectiveGenesis()+1, rst.First)
+		require.Equal(t, last, rst.Last)
+		require.Equal(t, last.Sub(1), rst.Verified)
+		require.Equal(t, last.Sub(1), rst.StoredVerified)
+		require.Empty(t, rst.Mismatches)
+		require.True(t, rst.Consistent())
+	})
+	t.Run("from window", func(t *testing.T) {
+		from := last.Sub(cfg.WindowSize)
+		rst := replay(t, from)
+		require.Equal(t, from.GetEpoch().FirstLayer(), rst.First)
+		require.Equal(t, last.Sub(1), rst.Verified)
+		require.True(t, rst.Consistent(), "%v", rst.Mismatches)
+	})
+	t.Run("tampered", func(t *testing.T) {
+		tampered := last.Sub(cfg.WindowSize + 5)
+		ids, err := blocks.IDsInLayer(db, tampered)
+		require.NoError(t, err)
+		require.Len(t, ids, 1)
+		require.NoError(t, blocks.SetInvalid(db, ids[0]))
+		require.NoError(t, layers.SetMeshHash(db, tampered.Add(1), types.RandomHash()))
+
+		rst := replay(t, 0)
+		require.False(t, rst.Consistent())
+		require.Len(t, rst.Mismatches, 2)
+		require.Equal(t, ReplayMismatch{
+			Layer: tampered, Block: ids[0], Stored: "invalid", Replayed: "valid",
+		}, rst.Mismatches[0])
+		require.Equal(t, tampered.Add(1), rst.Mismatches[1].Layer)
+		require.Equal(t, types.EmptyBlockID, rst.Mismatches[1].Block)
+	})
+}
@@ -42,7 +42,7 @@ func (s *State) OnBeacon(eid types.EpochID, beacon types.Beacon) {
 // OnActivationTx callback to store activation transaction.
 func (s *State) OnActivationTx(atx *types.VerifiedActivationTx) {
 	// TODO: consider using actual values for nonce and malicious if needed
-	s.Atxdata.AddFromHeader(atx.ToHeader(), 0, false)
+	s.Atxdata.AddFromHeader(atx.ToHeader(), 0, atx.SmesherID, false)
 	if err := atxs.Add(s.DB, atx); err != nil {
 		s.logger.With().Panic("failed to add atx", log.Err(err))
 	}
@@ -0,0 +1,473 @@
+package tortoise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spacemeshos/fixed"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/atxsdata"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/atxs"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/beacons"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/certificates"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/tortoisesnapshot"
+)
+
+var errSnapshotMismatch = errors.New("snapshot doesn't match")
+
+// snapshot is the encoded state of the tortoise. It is encoded under the tortoise lock,
+// and written to the disk after the lock is released.
+type snapshot struct {
+	header *tortoisesnapshot.Header
+	layers []*tortoisesnapshot.Layer
+}
+
+// takeSnapshot encodes the state if SnapshotInterval layers were processed since the previous snapshot.
+func (t *Tortoise) takeSnapshot() *snapshot {
+	if t.snapshotDB == nil || t.cfg.SnapshotInterval == 0 {
+		return nil
+	}
+	if t.snapshotted == 0 {
+		// state right after restart is either restored from the snapshot or recomputed from the database
+		t.snapshotted = t.trtl.processed
+		return nil
+	}
+	if t.trtl.processed < t.snapshotted.Add(t.cfg.SnapshotInterval) {
+		return nil
+	}
+	snap := t.trtl.snapshot()
+	if snap != nil {
+		t.snapshotted = t.trtl.processed
+	}
+	return snap
+}
+
+// Snapshot writes the state if layers were processed since the previous snapshot, without waiting
+// for SnapshotInterval layers. It is called on shutdown, so that the state is restored from the snapshot
+// after restart instead of being recomputed from the database.
+func (t *Tortoise) Snapshot() error {
+	t.mu.Lock()
+	var snap *snapshot
+	if t.snapshotDB != nil && t.cfg.SnapshotInterval != 0 && t.trtl.processed > t.snapshotted {
+		snap = t.trtl.snapshot()
+		if snap != nil {
+			t.snapshotted = t.trtl.processed
+		}
+	}
+	t.mu.Unlock()
+	if snap == nil {
+		return nil
+	}
+	return t.writeSnapshot(snap)
+}
+
+func (t *Tortoise) writeSnapshot(snap *snapshot) error {
+	start := time.Now()
+	if err := t.snapshotDB.WithTx(context.Background(), func(tx *sql.Tx) error {
+		return tortoisesnapshot.Write(tx, snap.header, snap.layers)
+	}); err != nil {
+		return fmt.Errorf("write tortoise snapshot: %w", err)
+	}
+	snapshotLayer.Set(float64(snap.header.Processed))
+	t.logger.Info("wrote tortoise snapshot",
+		zap.Uint32("processed", snap.header.Processed.Uint32()),
+		zap.Int("layers", len(snap.layers)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+// snapshot encodes all layers in the sliding window. Snapshot is not taken while some ballots
+// are waiting for the beacon, as they are not counted yet.
+func (t *turtle) snapshot() *snapshot {
+	if t.retriable.Len() > 0 {
+		return nil
+	}
+	header := &tortoisesnapshot.Header{
+		Hdist:            t.Hdist,
+		Zdist:            t.Zdist,
+		WindowSize:       t.WindowSize,
+		LayerSize:        t.LayerSize,
+		LayersPerEpoch:   types.GetLayersPerEpoch(),
+		EffectiveGenesis: types.GetEffectiveGenesis(),
+		Last:             t.last,
+		Verified:         t.verified,
+		Processed:        t.processed,
+		Evicted:          t.evicted,
+		Pending:          t.pending,
+		Full:             t.isFull,
+		Counted:          t.full.counted,
+	}
+	copy(header.LocalThreshold[:], t.localThreshold.Bytes())
+	copy(header.TotalGoodWeight[:], t.verifying.totalGoodWeight.Bytes())
+	for eid, epoch := range t.epochs {
+		encoded := tortoisesnapshot.Epoch{Epoch: eid, Height: epoch.height}
+		copy(encoded.Weight[:], epoch.weight.Bytes())
+		if epoch.beacon != nil {
+			encoded.HasBeacon = true
+			encoded.Beacon = *epoch.beacon
+		}
+		header.Epochs = append(header.Epochs, encoded)
+	}
+	sort.Slice(header.Epochs, func(i, j int) bool {
+		return header.Epochs[i].Epoch < header.Epochs[j].Epoch
+	})
+	for lid, delayed := range t.full.delayed {
+		encoded := tortoisesnapshot.Delayed{Layer: lid}
+		for _, ballot := range delayed {
+			encoded.Ballots = append(encoded.Ballots, ballot.id)
+		}
+		header.Delayed = append(header.Delayed, encoded)
+	}
+	sort.Slice(header.Delayed, func(i, j int) bool {
+		return header.Delayed[i].Layer < header.Delayed[j].Layer
+	})
+
+	encoded := make([]*tortoisesnapshot.Layer, 0, len(t.layers.data))
+	for _, layer := range t.layers.data {
+		record := &tortoisesnapshot.Layer{
+			Layer:           layer.lid,
+			HareTerminated:  layer.hareTerminated,
+			Coinflip:        uint8(layer.coinflip + 1),
+			Opinion:         layer.opinion,
+			ReferenceHeight: layer.verifying.referenceHeight,
+		}
+		copy(record.Empty[:], layer.empty.Bytes())
+		copy(record.GoodUncounted[:], layer.verifying.goodUncounted.Bytes())
+		if layer.prevOpinion != nil {
+			record.HasPrevOpinion = true
+			record.PrevOpinion = *layer.prevOpinion
+		}
+		for _, block := range layer.blocks {
+			stored := tortoisesnapshot.Block{
+				Header:   block.header(),
+				Hare:     uint8(block.hare + 1),
+				Validity: uint8(block.validity + 1),
+				Data:     block.data,
+			}
+			copy(stored.Margin[:], block.margin.Bytes())
+			record.Blocks = append(record.Blocks, stored)
+		}
+		encoded = append(encoded, record)
+	}
+	// votes are shared by the ballots, each vote is stored once in the layer that it votes on
+	stored := map[types.Hash32]struct{}{}
+	for i, layer := range t.layers.data {
+		for _, ballot := range t.layerBallots(layer.lid) {
+			encoded[i].Ballots = append(encoded[i].Ballots, *encodeBallot(ballot))
+			for lvote := ballot.votes.tail; lvote != nil && lvote.lid.After(t.evicted); lvote = lvote.prev {
+				if _, exists := stored[lvote.opinion]; exists {
+					break
+				}
+				stored[lvote.opinion] = struct{}{}
+				record := encoded[lvote.lid-t.evicted-1]
+				record.Votes = append(record.Votes, *encodeVote(lvote))
+			}
+		}
+	}
+	return &snapshot{header: header, layers: encoded}
+}
+
+// restoreSnapshot replaces the state with the stored snapshot, if the snapshot is consistent with the database.
+// It returns false if the snapshot doesn't exist or can't be used.
+func (t *Tortoise) restoreSnapshot(db sql.Executor, atxdata *atxsdata.Data) (bool, error) {
+	header, err := tortoisesnapshot.GetHeader(t.snapshotDB)
+	if errors.Is(err, sql.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	start := time.Now()
+	restored, err := t.decodeSnapshot(header, atxdata)
+	if err == nil {
+		err = restored.validateSnapshot(db)
+	}
+	if errors.Is(err, errSnapshotMismatch) {
+		t.logger.Warn("tortoise snapshot can't be restored", zap.Error(err))
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	restored.spill = t.trtl.spill
+	t.trtl = restored
+	t.snapshotted = header.Processed
+	t.logger.Info("restored tortoise snapshot",
+		zap.Uint32("processed", header.Processed.Uint32()),
+		zap.Uint32("verified", header.Verified.Uint32()),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return true, nil
+}
+
+func (t *Tortoise) decodeSnapshot(header *tortoisesnapshot.Header, atxdata *atxsdata.Data) (*turtle, error) {
+	switch {
+	case header.Hdist != t.cfg.Hdist,
+		header.Zdist != t.cfg.Zdist,
+		header.WindowSize != t.cfg.WindowSize,
+		header.LayerSize != t.cfg.LayerSize:
+		return nil, fmt.Errorf("%w: config changed", errSnapshotMismatch)
+	case header.LayersPerEpoch != types.GetLayersPerEpoch(),
+		header.EffectiveGenesis != types.GetEffectiveGenesis():
+		return nil, fmt.Errorf("%w: genesis changed", errSnapshotMismatch)
+	}
+	trtl := newTurtle(t.logger, t.cfg, atxdata)
+	trtl.last = header.Last
+	trtl.verified = header.Verified
+	trtl.processed = header.Processed
+	trtl.evicted = header.Evicted
+	trtl.pending = header.Pending
+	trtl.localThreshold = fixed.FromBytes(header.LocalThreshold[:])
+	trtl.verifying.totalGoodWeight = fixed.FromBytes(header.TotalGoodWeight[:])
+	trtl.isFull = header.Full
+	trtl.full.counted = header.Counted
+	trtl.layers = layerSlice{}
+	trtl.epochs = map[types.EpochID]*epochInfo{}
+	for _, epoch := range header.Epochs {
+		// activations for epochs that didn't start yet may still arrive, they are loaded from the database
+		if epoch.Epoch > header.Processed.GetEpoch() {
+			continue
+		}
+		info := &epochInfo{weight: fixed.FromBytes(epoch.Weight[:]), height: epoch.Height}
+		if epoch.HasBeacon {
+			beacon := epoch.Beacon
+			info.beacon = &beacon
+		}
+		trtl.epochs[epoch.Epoch] = info
+	}
+
+	loaded := map[types.Hash32]*layerVote{}
+	var derr error
+	if err := tortoisesnapshot.IterateLayers(t.snapshotDB, func(record *tortoisesnapshot.Layer) bool {
+		if expected := trtl.evicted.Add(uint32(len(trtl.layers.data)) + 1); record.Layer != expected {
+			derr = fmt.Errorf("%w: layer %d is expected, got %d", errSnapshotMismatch, expected, record.Layer)
+			return false
+		}
+		trtl.decodeLayer(record, loaded)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if derr != nil {
+		return nil, derr
+	}
+	for _, delayed := range header.Delayed {
+		for _, id := range delayed.Ballots {
+			if ballot := trtl.ballotRefs[id]; ballot != nil {
+				trtl.full.delayed[delayed.Layer] = append(trtl.full.delayed[delayed.Layer], ballot)
+			}
+		}
+	}
+	return trtl, nil
+}
+
+func (t *turtle) decodeLayer(record *tortoisesnapshot.Layer, loaded map[types.Hash32]*layerVote) {
+	layer := t.layer(record.Layer)
+	layer.empty = fixed.FromBytes(record.Empty[:])
+	layer.hareTerminated = record.HareTerminated
+	layer.coinflip = sign(record.Coinflip) - 1
+	layer.opinion = record.Opinion
+	layer.verifying.goodUncounted = fixed.FromBytes(record.GoodUncounted[:])
+	layer.verifying.referenceHeight = record.ReferenceHeight
+	if record.HasPrevOpinion {
+		if prev := record.Layer.Sub(1); prev.After(t.evicted) {
+			layer.prevOpinion = &t.layer(prev).opinion
+		} else {
+			opinion := record.PrevOpinion
+			layer.prevOpinion = &opinion
+		}
+	}
+	for _, stored := range record.Blocks {
+		block := newBlockInfo(stored.Header)
+		block.hare = sign(stored.Hare) - 1
+		block.validity = sign(stored.Validity) - 1
+		block.margin = fixed.FromBytes(stored.Margin[:])
+		block.data = stored.Data
+		layer.blocks = append(layer.blocks, block)
+		blocksNumber.Inc()
+	}
+	// votes are stored in the layer that they vote on, therefore previous votes are already decoded
+	for i := range record.Votes {
+		stored := &record.Votes[i]
+		lvote := t.decodeStoredVote(stored, loaded[stored.Prev])
+		loaded[lvote.opinion] = lvote
+	}
+	for i := range record.Ballots {
+		stored := &record.Ballots[i]
+		ballot := decodeStoredBallot(stored)
+		if stored.Opinion != (types.Hash32{}) {
+			ballot.votes.tail = loaded[stored.Opinion]
+		}
+		t.addBallot(ballot)
+		layer.opinions[ballot.opinion()] = ballot.votes
+	}
+}
+
+// validateSnapshot checks that the database wasn't changed in a way that is inconsistent with the snapshot.
+func (t *turtle) validateSnapshot(db sql.Executor) error {
+	last, err := ballots.LatestLayer(db)
+	if err != nil {
+		return fmt.Errorf("failed to load latest known layer: %w", err)
+	}
+	if t.processed.After(last) {
+		return fmt.Errorf("%w: processed layer %d is after the latest layer %d", errSnapshotMismatch, t.processed, last)
+	}
+	if t.verified.After(t.evicted) {
+		opinion, err := layers.GetAggregatedHash(db, t.verified)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return err
+		}
+		if err == nil && opinion != types.EmptyLayerHash && opinion != t.layer(t.verified).opinion {
+			return fmt.Errorf("%w: opinion in verified layer %d", errSnapshotMismatch, t.verified)
+		}
+	}
+	for lid := t.evicted.Add(1); !lid.After(t.verified); lid = lid.Add(1) {
+		for _, block := range t.layer(lid).blocks {
+			if block.validity == abstain {
+				continue
+			}
+			valid, err := blocks.IsValid(db, block.id)
+			if errors.Is(err, sql.ErrNotFound) || errors.Is(err, blocks.ErrValidityNotDecided) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if valid != (block.validity == support) {
+				return fmt.Errorf("%w: validity of block %s in layer %d", errSnapshotMismatch, block.id, lid)
+			}
+		}
+	}
+	return nil
+}
+
+// catchUp loads data that was added to the database after the snapshot was written.
+func catchUp(
+	ctx context.Context,
+	trtl *Tortoise,
+	db sql.Executor,
+	atxdata *atxsdata.Data,
+	last types.LayerID,
+) error {
+	trtl.mu.Lock()
+	evicted, processed := trtl.trtl.evicted, trtl.trtl.processed
+	trtl.mu.Unlock()
+
+	latest, err := atxs.LatestEpoch(db)
+	if err != nil {
+		return fmt.Errorf("failed to load latest epoch: %w", err)
+	}
+	// recoverEpoch expects target epoch
+	for eid := evicted.Add(1).GetEpoch(); eid <= max(latest+1, last.GetEpoch()); eid++ {
+		if eid > processed.GetEpoch() {
+			if err := recoverEpoch(eid, trtl, db, atxdata); err != nil {
+				return err
+			}
+			continue
+		}
+		beacon, err := beacons.Get(db, eid)
+		if err == nil && beacon != types.EmptyBeacon {
+			trtl.OnBeacon(eid, beacon)
+		}
+	}
+	trtl.UpdateLastLayer(last)
+	for lid := evicted.Add(1); !lid.After(last); lid = lid.Add(1) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := catchUpLayer(trtl, db, lid); err != nil {
+			return fmt.Errorf("failed to load tortoise state at layer %d: %w", lid, err)
+		}
+	}
+	return nil
+}
+
+func catchUpLayer(trtl *Tortoise, db sql.Executor, lid types.LayerID) error {
+	knownBlocks, knownBallots, coinflip := trtl.layerContent(lid)
+	ids, err := blocks.IDsInLayer(db, lid)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if knownBlocks[id] {
+			continue
+		}
+		block, err := blocks.Get(db, id)
+		if err != nil {
+			return err
+		}
+		trtl.OnBlock(block.ToVote())
+	}
+	if trtl.WithinHdist(lid) {
+		hare, err := certificates.GetHareOutput(db, lid)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			trtl.OnHareOutput(lid, hare)
+		}
+	}
+	bids, err := ballots.IDsInLayer(db, lid)
+	if err != nil {
+		return err
+	}
+	var missing []*types.Ballot
+	for _, id := range bids {
+		if _, exists := knownBallots[id]; exists {
+			continue
+		}
+		ballot, err := ballots.Get(db, id)
+		if err != nil {
+			return err
+		}
+		if !ballot.IsMalicious() {
+			missing = append(missing, ballot)
+		}
+	}
+	// reference ballots are loaded first, the same as in RecoverLayer
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].EpochData != nil && missing[j].EpochData == nil
+	})
+	for _, ballot := range missing {
+		trtl.OnRecoveredBallot(ballot.ToTortoiseData())
+	}
+	if coinflip == neutral {
+		coin, err := layers.GetWeakCoin(db, lid)
+		if err != nil && !errors.Is(err, sql.ErrNotFound) {
+			return err
+		} else if err == nil {
+			trtl.OnWeakCoin(lid, coin)
+		}
+	}
+	return nil
+}
+
+// layerContent returns blocks with the data available locally, ballots and the weak coin in the layer.
+func (t *Tortoise) layerContent(lid types.LayerID) (map[types.BlockID]bool, map[types.BallotID]struct{}, sign) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !lid.After(t.trtl.evicted) {
+		return nil, nil, neutral
+	}
+	layer := t.trtl.layer(lid)
+	blocks := make(map[types.BlockID]bool, len(layer.blocks))
+	for _, block := range layer.blocks {
+		blocks[block.id] = block.data
+	}
+	ballots := map[types.BallotID]struct{}{}
+	for _, ballot := range t.trtl.layerBallots(lid) {
+		ballots[ballot.id] = struct{}{}
+	}
+	return blocks, ballots, layer.coinflip
+}
@@ -0,0 +1,194 @@
+package tortoise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/blocks"
+	"github.com/spacemeshos/go-spacemesh/sql/layers"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/tortoisesnapshot"
+	"github.com/spacemeshos/go-spacemesh/tortoise/sim"
+)
+
+func applyUpdates(tb testing.TB, trtl *Tortoise, db sql.Executor) {
+	for _, layer := range trtl.Updates() {
+		for _, block := range layer.Blocks {
+			if block.Valid {
+				require.NoError(tb, blocks.SetValid(db, block.Header.ID))
+			} else if block.Invalid {
+				require.NoError(tb, blocks.SetInvalid(db, block.Header.ID))
+			}
+		}
+		if layer.Verified {
+			require.NoError(tb, layers.SetMeshHash(db, layer.Layer, layer.Opinion))
+			trtl.OnApplied(layer.Layer, layer.Opinion)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	const (
+		size     = 4
+		interval = 5
+	)
+	for _, tc := range []struct {
+		desc     string
+		opts     []sim.NextOpt
+		tamper   bool
+		restored bool
+	}{
+		{desc: "healthy", restored: true},
+		{desc: "without hare", opts: []sim.NextOpt{sim.WithoutHareOutput()}, restored: true},
+		{desc: "tampered validity", tamper: true},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := defaultTestConfig()
+			cfg.LayerSize = size
+			cfg.Hdist = 4
+			cfg.Zdist = 2
+			cfg.WindowSize = 20
+			cfg.SnapshotInterval = interval
+
+			s := sim.New(sim.WithLayerSize(size))
+			s.Setup(sim.WithSetupMinerRange(size, size))
+			state := s.GetState(0)
+			db := state.DB.Executor
+			local := localsql.InMemory()
+
+			snapshotted := tortoiseFromSimState(t, state, WithConfig(cfg),
+				WithLogger(logtest.New(t).Named("snapshotted")), WithSnapshotStore(local.Database))
+			var last types.LayerID
+			for i := 0; i < 33; i++ {
+				last = s.Next(sim.WithNumBlocks(1))
+				snapshotted.TallyVotes(ctx, last)
+				applyUpdates(t, snapshotted.Tortoise, db)
+			}
+			header, err := tortoisesnapshot.GetHeader(local)
+			require.NoError(t, err)
+			require.Less(t, header.Processed, last)
+
+			// layers that were added while the node was offline
+			for i := 0; i < 3; i++ {
+				last = s.Next(append([]sim.NextOpt{sim.WithNumBlocks(1)}, tc.opts...)...)
+			}
+			if tc.tamper {
+				ids, err := blocks.IDsInLayer(db, header.Verified)
+				require.NoError(t, err)
+				require.NotEmpty(t, ids)
+				require.NoError(t, blocks.SetInvalid(db, ids[0]))
+			}
+
+			expected, err := Recover(ctx, db, state.Atxdata, last,
+				WithConfig(cfg), WithLogger(logtest.New(t).Named("expected")))
+			require.NoError(t, err)
+			core, logs := observer.New(zapcore.InfoLevel)
+			restored, err := Recover(ctx, db, state.Atxdata, last,
+				WithConfig(cfg),
+				WithLogger(log.NewFromLog(zap.New(core))),
+				WithSnapshotStore(local.Database),
+			)
+			require.NoError(t, err)
+			if tc.restored {
+				require.Equal(t, 1, logs.FilterMessage("restored tortoise snapshot").Len())
+			} else {
+				require.Equal(t, 1, logs.FilterMessage("tortoise snapshot can't be restored").Len())
+			}
+			require.Equal(t, expected.LatestComplete(), restored.LatestComplete())
+			require.Equal(t, expected.Updates(), restored.Updates())
+
+			eadapter := &recoveryAdapter{TB: t, Tortoise: expected, db: db, atxdata: state.Atxdata, next: last + 1}
+			radapter := &recoveryAdapter{TB: t, Tortoise: restored, db: db, atxdata: state.Atxdata, next: last + 1}
+			for i := 0; i < 10; i++ {
+				last = s.Next(sim.WithNumBlocks(1))
+				eadapter.TallyVotes(ctx, last)
+				radapter.TallyVotes(ctx, last)
+				require.Equal(t, expected.LatestComplete(), restored.LatestComplete(), "layer %s", last)
+				require.Equal(t, expected.Updates(), restored.Updates(), "layer %s", last)
+
+				eopinion, eerr := expected.EncodeVotes(ctx)
+				ropinion, rerr := restored.EncodeVotes(ctx)
+				require.Equal(t, eerr, rerr, "layer %s", last)
+				require.Equal(t, eopinion, ropinion, "layer %s", last)
+			}
+		})
+	}
+}
+
+func TestSnapshotConfigChanged(t *testing.T) {
+	const size = 4
+	ctx := context.Background()
+	cfg := defaultTestConfig()
+	cfg.LayerSize = size
+	cfg.SnapshotInterval = 2
+
+	s := sim.New(sim.WithLayerSize(size))
+	s.Setup(sim.WithSetupMinerRange(size, size))
+	state := s.GetState(0)
+	local := localsql.InMemory()
+	trtl := tortoiseFromSimState(t, state, WithConfig(cfg), WithLogger(logtest.New(t)),
+		WithSnapshotStore(local.Database))
+	var last types.LayerID
+	for i := 0; i < 10; i++ {
+		last = s.Next()
+		trtl.TallyVotes(ctx, last)
+	}
+	_, err := tortoisesnapshot.GetHeader(local)
+	require.NoError(t, err)
+
+	cfg.Hdist++
+	expected, err := Recover(ctx, state.DB.Executor, state.Atxdata, last,
+		WithConfig(cfg), WithLogger(logtest.New(t)))
+	require.NoError(t, err)
+	core, logs := observer.New(zapcore.InfoLevel)
+	restored, err := Recover(ctx, state.DB.Executor, state.Atxdata, last,
+		WithConfig(cfg), WithLogger(log.NewFromLog(zap.New(core))), WithSnapshotStore(local.Database))
+	require.NoError(t, err)
+	require.Equal(t, 1, logs.FilterMessage("tortoise snapshot can't be restored").Len())
+	require.Equal(t, expected.LatestComplete(), restored.LatestComplete())
+}
+
+func TestSnapshotOnShutdown(t *testing.T) {
+	const size = 4
+	ctx := context.Background()
+	cfg := defaultTestConfig()
+	cfg.LayerSize = size
+	cfg.SnapshotInterval = 100
+
+	s := sim.New(sim.WithLayerSize(size))
+	s.Setup(sim.WithSetupMinerRange(size, size))
+	state := s.GetState(0)
+	local := localsql.InMemory()
+	trtl := tortoiseFromSimState(t, state, WithConfig(cfg), WithLogger(logtest.New(t)),
+		WithSnapshotStore(local.Database))
+	var last types.LayerID
+	for i := 0; i < 10; i++ {
+		last = s.Next()
+		trtl.TallyVotes(ctx, last)
+	}
+	_, err := tortoisesnapshot.GetHeader(local)
+	require.ErrorIs(t, err, sql.ErrNotFound)
+
+	require.NoError(t, trtl.Snapshot())
+	header, err := tortoisesnapshot.GetHeader(local)
+	require.NoError(t, err)
+	require.Equal(t, last, header.Processed)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	restored, err := Recover(ctx, state.DB.Executor, state.Atxdata, last,
+		WithConfig(cfg), WithLogger(log.NewFromLog(zap.New(core))), WithSnapshotStore(local.Database))
+	require.NoError(t, err)
+	require.Equal(t, 1, logs.FilterMessage("restored tortoise snapshot").Len())
+	require.Equal(t, trtl.LatestComplete(), restored.LatestComplete())
+}
@@ -0,0 +1,366 @@
+package tortoise
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"unsafe"
+
+	"github.com/spacemeshos/fixed"
+	"go.uber.org/zap"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/sql"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql/spill"
+)
+
+// spiller moves ballots from layers that are unlikely to be accessed again to the disk.
+// Ballots are counted before they are spilled, and they are loaded back only if votes
+// need to be recounted (e.g. opinion changed in the old layer or tortoise switched to full mode).
+type spiller struct {
+	db     *sql.Database
+	logger *zap.Logger
+	// last layer with spilled ballots
+	last types.LayerID
+	// opinions that are already stored, by layer. votes are shared by ballots
+	// and they need to be stored only once.
+	opinions hashSet
+	// opinions of the spilled ballots, by layer of the ballot
+	tails hashSet
+}
+
+type hashSet map[types.LayerID]map[types.Hash32]struct{}
+
+func (s hashSet) has(lid types.LayerID, hash types.Hash32) bool {
+	_, exists := s[lid][hash]
+	return exists
+}
+
+func (s hashSet) add(lid types.LayerID, hash types.Hash32) {
+	layer, exists := s[lid]
+	if !exists {
+		layer = map[types.Hash32]struct{}{}
+		s[lid] = layer
+	}
+	layer[hash] = struct{}{}
+}
+
+func (s hashSet) evict(windowStart types.LayerID) {
+	for lid := range s {
+		if lid.Before(windowStart) {
+			delete(s, lid)
+		}
+	}
+}
+
+func newSpiller(db *sql.Database, logger *zap.Logger) (*spiller, error) {
+	// spilled state is valid only for the instance that wrote it
+	if err := spill.Clear(db); err != nil {
+		return nil, err
+	}
+	return &spiller{
+		db:       db,
+		logger:   logger,
+		opinions: hashSet{},
+		tails:    hashSet{},
+	}, nil
+}
+
+func (s *spiller) evict(windowStart types.LayerID) {
+	s.opinions.evict(windowStart)
+	s.tails.evict(windowStart)
+	if err := spill.Prune(s.db, windowStart); err != nil {
+		s.logger.Error("failed to prune spilled ballots", zap.Error(err))
+	}
+}
+
+var (
+	// ballotSize is the estimated memory footprint of the ballot in the state,
+	// including the reference info and the entries in the indexes.
+	ballotSize = uint64(unsafe.Sizeof(ballotInfo{}) + unsafe.Sizeof(referenceInfo{}) + unsafe.Sizeof(big.Rat{}) +
+		unsafe.Sizeof(types.BallotID{}) + 2*unsafe.Sizeof(uintptr(0)))
+	// opinionSize is the estimated memory footprint of the unique opinion in the layer.
+	// Votes are shared by ballots with the same opinion, so they are accounted once per layer.
+	opinionSize = uint64(unsafe.Sizeof(layerVote{}) + unsafe.Sizeof(types.Hash32{}) + unsafe.Sizeof(votes{}) +
+		unsafe.Sizeof(uintptr(0)))
+)
+
+// layerMemory returns the estimated size of the ballots and votes in the layer that are kept in memory.
+func (s *state) layerMemory(lid types.LayerID) uint64 {
+	return uint64(len(s.ballots[lid]))*ballotSize + uint64(len(s.layer(lid).opinions))*opinionSize
+}
+
+// spillCold moves ballots from the oldest layers to the disk until the estimated size
+// of the processed layers fits into SpillMemory.
+func (t *turtle) spillCold() {
+	if t.spill == nil || t.SpillMemory == 0 || t.retriable.Len() > 0 {
+		return
+	}
+	var size uint64
+	for lid := t.evicted.Add(1); !lid.After(t.processed); lid = lid.Add(1) {
+		size += t.layerMemory(lid)
+	}
+	spillMemory.Set(float64(size))
+	target := t.evicted
+	for lid := t.evicted.Add(1); lid.Before(t.processed) && size > t.SpillMemory; lid = lid.Add(1) {
+		size -= t.layerMemory(lid)
+		target = lid
+	}
+	if target == t.evicted {
+		return
+	}
+	var spilled []types.LayerID
+	err := t.spill.db.WithTx(context.Background(), func(tx *sql.Tx) error {
+		// all layers are checked as ballots may arrive late into the layer that was already spilled
+		for lid := t.evicted.Add(1); !lid.After(target); lid = lid.Add(1) {
+			if len(t.ballots[lid]) == 0 {
+				continue
+			}
+			for _, ballot := range t.ballots[lid] {
+				if err := t.spillBallot(tx, ballot); err != nil {
+					return err
+				}
+			}
+			spilled = append(spilled, lid)
+		}
+		return nil
+	})
+	if err != nil {
+		t.logger.Error("failed to spill ballots", zap.Error(err))
+		return
+	}
+	for _, lid := range spilled {
+		ballots := t.ballots[lid]
+		for _, ballot := range ballots {
+			delete(t.ballotRefs, ballot.id)
+		}
+		delete(t.ballots, lid)
+		t.layer(lid).opinions = map[types.Hash32]votes{}
+		ballotsNumber.Sub(float64(len(ballots)))
+		spilledBallots.Add(float64(len(ballots)))
+	}
+	t.spill.last = max(t.spill.last, target)
+	spilledLayers.Set(float64(t.spill.last - t.evicted))
+	spillMemory.Set(float64(size))
+}
+
+func (t *turtle) evictSpilled(windowStart types.LayerID) {
+	if t.spill == nil {
+		return
+	}
+	t.spill.evict(windowStart)
+	if t.spill.last.Before(windowStart) {
+		spilledLayers.Set(0)
+	} else {
+		spilledLayers.Set(float64(t.spill.last - windowStart + 1))
+	}
+}
+
+func (t *turtle) spillBallot(tx *sql.Tx, ballot *ballotInfo) error {
+	for lvote := ballot.votes.tail; lvote != nil && lvote.lid.After(t.evicted); lvote = lvote.prev {
+		if t.spill.opinions.has(lvote.lid, lvote.opinion) {
+			break
+		}
+		if err := spill.AddOpinion(tx, encodeVote(lvote)); err != nil {
+			return err
+		}
+		t.spill.opinions.add(lvote.lid, lvote.opinion)
+	}
+	if ballot.votes.tail != nil {
+		t.spill.tails.add(ballot.layer, ballot.opinion())
+	}
+	return spill.AddBallot(tx, encodeBallot(ballot))
+}
+
+func encodeBallot(ballot *ballotInfo) *spill.Ballot {
+	stored := &spill.Ballot{
+		ID:        ballot.id,
+		Layer:     ballot.layer,
+		BaseID:    ballot.base.id,
+		BaseLayer: ballot.base.layer,
+		Malicious: ballot.malicious,
+		BadBeacon: ballot.conditions.badBeacon,
+		Opinion:   ballot.opinion(),
+	}
+	copy(stored.Weight[:], ballot.weight.Bytes())
+	if ref := ballot.reference; ref != nil {
+		stored.Smesher = ref.smesher
+		stored.ATXID = ref.atxid
+		stored.ExpectedBallots = ref.expectedBallots
+		stored.Beacon = ref.beacon
+		stored.WeightNum = ref.weight.Num().Uint64()
+		stored.WeightDenom = ref.weight.Denom().Uint64()
+		stored.Height = ref.height
+	}
+	return stored
+}
+
+func encodeVote(lvote *layerVote) *spill.Opinion {
+	opinion := &spill.Opinion{
+		Hash:  lvote.opinion,
+		Layer: lvote.lid,
+		Vote:  uint8(lvote.vote + 1),
+	}
+	for _, block := range lvote.supported {
+		opinion.Supported = append(opinion.Supported, block.header())
+	}
+	if lvote.prev != nil {
+		opinion.Prev = lvote.prev.opinion
+	}
+	return opinion
+}
+
+func (s *state) isSpilled(lid types.LayerID) bool {
+	return s.spill != nil && lid.After(s.evicted) && !lid.After(s.spill.last)
+}
+
+// layerBallots returns all ballots in the layer, spilled ballots are loaded from the disk.
+// Loaded ballots are not added back to the state.
+func (s *state) layerBallots(lid types.LayerID) []*ballotInfo {
+	if !s.isSpilled(lid) {
+		return s.ballots[lid]
+	}
+	stored, err := spill.LayerBallots(s.spill.db, lid)
+	if err != nil {
+		s.spill.logger.Panic("failed to load spilled ballots", zap.Uint32("lid", lid.Uint32()), zap.Error(err))
+	}
+	if len(stored) == 0 {
+		return s.ballots[lid]
+	}
+	loaded := make(map[types.Hash32]*layerVote)
+	ballots := make([]*ballotInfo, 0, len(stored)+len(s.ballots[lid]))
+	for _, ballot := range stored {
+		ballots = append(ballots, s.loadBallot(ballot, loaded))
+	}
+	reloadedBallots.Add(float64(len(stored)))
+	// ballots that were added after the layer was spilled
+	return append(ballots, s.ballots[lid]...)
+}
+
+// ballotRef returns the ballot by id, the ballot is loaded from the disk if it was spilled.
+func (s *state) ballotRef(id types.BallotID) *ballotInfo {
+	if ballot, exists := s.ballotRefs[id]; exists {
+		return ballot
+	}
+	if s.spill == nil || !s.spill.last.After(s.evicted) {
+		return nil
+	}
+	stored, err := spill.GetBallot(s.spill.db, id)
+	if errors.Is(err, sql.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		s.spill.logger.Panic("failed to load spilled ballot", zap.Stringer("ballot", id), zap.Error(err))
+	}
+	if !stored.Layer.After(s.evicted) {
+		return nil
+	}
+	reloadedBallots.Inc()
+	return s.loadBallot(stored, map[types.Hash32]*layerVote{})
+}
+
+// layerBallotRef is the same as ballotRef, but the disk is not accessed if the layer of the ballot wasn't spilled.
+func (s *state) layerBallotRef(id types.BallotID, lid types.LayerID) *ballotInfo {
+	if !s.isSpilled(lid) {
+		return s.ballotRefs[id]
+	}
+	return s.ballotRef(id)
+}
+
+// layerOpinion returns votes of the ballots in the layer with the opinion.
+func (s *state) layerOpinion(layer *layerInfo, opinion types.Hash32) (votes, bool) {
+	if existing, exists := layer.opinions[opinion]; exists {
+		return existing, true
+	}
+	if !s.isSpilled(layer.lid) || !s.spill.tails.has(layer.lid, opinion) {
+		return votes{}, false
+	}
+	tail := s.loadVotes(opinion, map[types.Hash32]*layerVote{})
+	return votes{tail: tail}, tail != nil
+}
+
+func (s *state) loadBallot(stored *spill.Ballot, loaded map[types.Hash32]*layerVote) *ballotInfo {
+	ballot := decodeStoredBallot(stored)
+	if stored.Opinion != (types.Hash32{}) {
+		ballot.votes.tail = s.loadVotes(stored.Opinion, loaded)
+	}
+	return ballot
+}
+
+// decodeStoredBallot decodes the stored ballot without votes.
+func decodeStoredBallot(stored *spill.Ballot) *ballotInfo {
+	ballot := &ballotInfo{
+		id:    stored.ID,
+		layer: stored.Layer,
+		base: baseInfo{
+			id:    stored.BaseID,
+			layer: stored.BaseLayer,
+		},
+		malicious:  stored.Malicious,
+		weight:     fixed.FromBytes(stored.Weight[:]),
+		conditions: conditions{badBeacon: stored.BadBeacon},
+	}
+	if stored.WeightDenom != 0 {
+		ballot.reference = &referenceInfo{
+			smesher:         stored.Smesher,
+			atxid:           stored.ATXID,
+			expectedBallots: stored.ExpectedBallots,
+			beacon:          stored.Beacon,
+			weight: new(big.Rat).SetFrac(
+				new(big.Int).SetUint64(stored.WeightNum),
+				new(big.Int).SetUint64(stored.WeightDenom),
+			),
+			height: stored.Height,
+		}
+	}
+	return ballot
+}
+
+// loadVotes loads the chain of votes that ends with the opinion. Votes in the evicted layers are not loaded,
+// and votes that were loaded before are reused.
+func (s *state) loadVotes(opinion types.Hash32, loaded map[types.Hash32]*layerVote) *layerVote {
+	var (
+		chain []*spill.Opinion
+		prev  *layerVote
+	)
+	for current := opinion; current != (types.Hash32{}); {
+		if lvote, exists := loaded[current]; exists {
+			prev = lvote
+			break
+		}
+		stored, err := spill.GetOpinion(s.spill.db, current)
+		if errors.Is(err, sql.ErrNotFound) {
+			break
+		} else if err != nil {
+			s.spill.logger.Panic("failed to load spilled opinion", zap.Error(err))
+		}
+		if !stored.Layer.After(s.evicted) {
+			break
+		}
+		chain = append(chain, stored)
+		current = stored.Prev
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		lvote := s.decodeStoredVote(chain[i], prev)
+		loaded[lvote.opinion] = lvote
+		prev = lvote
+	}
+	return prev
+}
+
+// decodeStoredVote decodes the stored vote, supported blocks are replaced with blocks from the state.
+func (s *state) decodeStoredVote(stored *spill.Opinion, prev *layerVote) *layerVote {
+	lvote := &layerVote{
+		lid:     stored.Layer,
+		opinion: stored.Hash,
+		vote:    sign(stored.Vote) - 1,
+		prev:    prev,
+	}
+	for _, header := range stored.Supported {
+		block := s.getBlock(header)
+		if block == nil {
+			block = newBlockInfo(header)
+		}
+		lvote.supported = append(lvote.supported, block)
+	}
+	return lvote
+}
@@ -0,0 +1,105 @@
+package tortoise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log/logtest"
+	"github.com/spacemeshos/go-spacemesh/sql/ballots"
+	"github.com/spacemeshos/go-spacemesh/sql/localsql"
+	"github.com/spacemeshos/go-spacemesh/tortoise/sim"
+)
+
+func TestSpill(t *testing.T) {
+	const size = 4
+	type phase struct {
+		layers int
+		opts   []sim.NextOpt
+	}
+	for _, tc := range []struct {
+		desc   string
+		phases []phase
+	}{
+		{
+			desc:   "healthy",
+			phases: []phase{{layers: 40}},
+		},
+		{
+			desc: "hare failure",
+			phases: []phase{
+				{layers: 5},
+				{layers: 15, opts: []sim.NextOpt{sim.WithNumBlocks(1), sim.WithEmptyHareOutput()}},
+				{layers: 20},
+			},
+		},
+		{
+			desc: "without hare",
+			phases: []phase{
+				{layers: 5},
+				{layers: 10, opts: []sim.NextOpt{sim.WithNumBlocks(1), sim.WithoutHareOutput()}},
+				{layers: 20},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := defaultTestConfig()
+			cfg.LayerSize = size
+			cfg.Hdist = 4
+			cfg.Zdist = 2
+			// enough for a couple of layers with unique opinions
+			cfg.SpillMemory = 2 * uint64(size) * (ballotSize + opinionSize)
+
+			s := sim.New(sim.WithLayerSize(size))
+			s.Setup(sim.WithSetupMinerRange(size, size))
+
+			expected := tortoiseFromSimState(t, s.GetState(0), WithConfig(cfg),
+				WithLogger(logtest.New(t).Named("memory")))
+			spilled := tortoiseFromSimState(t, s.GetState(0), WithConfig(cfg),
+				WithLogger(logtest.New(t).Named("spilled")), WithSpillStore(localsql.InMemory().Database))
+			require.NotNil(t, spilled.trtl.spill)
+
+			var last types.LayerID
+			for _, phase := range tc.phases {
+				for i := 0; i < phase.layers; i++ {
+					last = s.Next(phase.opts...)
+					expected.TallyVotes(ctx, last)
+					spilled.TallyVotes(ctx, last)
+
+					require.Equal(t, expected.LatestComplete(), spilled.LatestComplete(), "layer %s", last)
+					require.Equal(t, expected.Updates(), spilled.Updates(), "layer %s", last)
+					eopinion, eerr := expected.EncodeVotes(ctx)
+					sopinion, serr := spilled.EncodeVotes(ctx)
+					require.Equal(t, eerr, serr, "layer %s", last)
+					require.Equal(t, eopinion, sopinion, "layer %s", last)
+				}
+			}
+			require.Equal(t, last.Sub(1), spilled.LatestComplete())
+
+			trtl := spilled.trtl
+			require.True(t, trtl.spill.last.After(trtl.evicted))
+			require.True(t, trtl.spill.last.Before(trtl.processed))
+			var size uint64
+			for lid := trtl.spill.last.Add(1); !lid.After(trtl.processed); lid = lid.Add(1) {
+				size += trtl.layerMemory(lid)
+			}
+			require.LessOrEqual(t, size, cfg.SpillMemory)
+			for lid := range trtl.ballots {
+				require.Greater(t, lid, trtl.spill.last)
+			}
+			old := trtl.spill.last
+			require.Empty(t, trtl.ballots[old])
+			stored, err := ballots.Layer(s.GetState(0).DB, old)
+			require.NoError(t, err)
+			require.NotEmpty(t, stored)
+			require.Len(t, trtl.layerBallots(old), len(stored))
+			for _, ballot := range stored {
+				require.Equal(t, expected.GetBallot(ballot.ID()), spilled.GetBallot(ballot.ID()))
+			}
+		})
+	}
+}
@@ -61,6 +61,10 @@ type (
 		// to efficiently find base and reference ballots
 		ballotRefs map[types.BallotID]*ballotInfo
 
+		// spill is set if ballots from old layers are moved to the disk.
+		// such ballots should be accessed with layerBallots and ballotRef.
+		spill *spiller
+
 		// malnodes is a collection with all nodes that equivocated in history.
 		// each node id is 32 bytes. 100 000 of such nodes is only about ~3MB
 		malnodes map[types.NodeID]struct{}
@@ -118,6 +118,7 @@ func (t *turtle) evict() {
 	}
 	t.evicted = windowStart.Sub(1)
 	evictedLayer.Set(float64(t.evicted))
+	t.evictSpilled(windowStart)
 }
 
 // EncodeVotes by choosing base ballot and explicit votes.
@@ -138,7 +139,7 @@ func (t *turtle) EncodeVotes(ctx context.Context, conf *encodeConf) (*types.Opin
 		if lid == types.GetEffectiveGenesis() {
 			choices = []*ballotInfo{{layer: types.GetEffectiveGenesis()}}
 		} else {
-			choices = t.ballots[lid]
+			choices = t.layerBallots(lid)
 		}
 		for _, base := range choices {
 			if base.malicious {
@@ -321,6 +322,8 @@ func (t *turtle) updateLast(last types.LayerID) {
 }
 
 func (t *turtle) tallyVotes(ctx context.Context, last types.LayerID) {
+	// deferred calls run in reverse order, ballots are spilled after eviction
+	defer t.spillCold()
 	defer t.evict()
 
 	t.logger.Debug("on layer", zap.Uint32("last", last.Uint32()))
@@ -495,7 +498,7 @@ func (t *turtle) runFull() (verified, changed types.LayerID) {
 	if !t.isFull {
 		t.switchModes()
 		for counted := max(t.full.counted.Add(1), t.evicted.Add(1)); !counted.After(t.processed); counted = counted.Add(1) {
-			for _, ballot := range t.ballots[counted] {
+			for _, ballot := range t.layerBallots(counted) {
 				t.full.countBallot(t.logger, ballot)
 			}
 			t.full.countDelayed(t.logger, counted)
@@ -643,7 +646,7 @@ func (t *turtle) onOpinionChange(lid types.LayerID, early bool) {
 		t.pending = min(t.pending, changed)
 		t.verifying.resetWeights(lid)
 		for target := lid.Add(1); !target.After(t.processed); target = target.Add(1) {
-			t.verifying.countVotes(t.logger, t.ballots[target])
+			t.verifying.countVotes(t.logger, t.layerBallots(target))
 		}
 	}
 }
@@ -681,7 +684,7 @@ func (t *turtle) decodeBallot(ballot *types.BallotTortoiseData) (*ballotInfo, ty
 	if !ballot.Layer.After(t.evicted) {
 		return nil, 0, nil
 	}
-	if info, exist := t.state.ballotRefs[ballot.ID]; exist {
+	if info := t.state.layerBallotRef(ballot.ID, ballot.Layer); info != nil {
 		return info, 0, nil
 	}
 
@@ -698,7 +701,7 @@ func (t *turtle) decodeBallot(ballot *types.BallotTortoiseData) (*ballotInfo, ty
 
 	if ballot.Opinion.Votes.Base == types.EmptyBallotID {
 		base = &ballotInfo{layer: types.GetEffectiveGenesis()}
-	} else if stored := t.state.ballotRefs[ballot.Opinion.Votes.Base]; stored != nil {
+	} else if stored := t.state.ballotRef(ballot.Opinion.Votes.Base); stored != nil {
 		base = stored
 	}
 	if base == nil {
@@ -724,8 +727,8 @@ func (t *turtle) decodeBallot(ballot *types.BallotTortoiseData) (*ballotInfo, ty
 		}
 	} else if ballot.Ref != nil {
 		ptr := *ballot.Ref
-		ref, exists := t.state.ballotRefs[ptr]
-		if !exists {
+		ref := t.state.ballotRef(ptr)
+		if ref == nil {
 			t.logger.Warn("ref ballot not in state",
 				zap.Stringer("ref", ptr),
 			)
@@ -766,7 +769,7 @@ func (t *turtle) decodeBallot(ballot *types.BallotTortoiseData) (*ballotInfo, ty
 
 	layer := t.layer(binfo.layer)
 
-	existing, exists := layer.opinions[ballot.Opinion.Hash]
+	existing, exists := t.layerOpinion(layer, ballot.Opinion.Hash)
 	var min types.LayerID
 	if exists {
 		binfo.votes = existing
@@ -798,13 +801,13 @@ func (t *turtle) storeBallot(ballot *ballotInfo, offset types.LayerID) error {
 	if !ballot.layer.After(t.evicted) {
 		return nil
 	}
-	if _, exists := t.ballotRefs[ballot.id]; exists {
+	if t.layerBallotRef(ballot.id, ballot.layer) != nil {
 		return fmt.Errorf("%w: %s", ErrBallotExists, ballot.id)
 	}
 
 	t.state.addBallot(ballot)
 	layer := t.layer(ballot.layer)
-	existing, exists := layer.opinions[ballot.opinion()]
+	existing, exists := t.layerOpinion(layer, ballot.opinion())
 	if exists {
 		ballot.votes = existing
 	} else {
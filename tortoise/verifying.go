@@ -70,22 +70,29 @@ func (v *verifying) countVotes(logger *zap.Logger, ballots []*ballotInfo) {
 	}
 }
 
-func (v *verifying) verify(logger *zap.Logger, lid types.LayerID) (bool, bool) {
+// margin is the weight of good ballots that vote for the layer, reduced by the weight
+// that is expected but wasn't counted yet.
+func (v *verifying) margin(lid types.LayerID) (margin, uncounted weight) {
 	layer := v.layer(lid)
-	if !layer.hareTerminated {
-		logger.Debug("hare is not terminated")
-		return false, false
-	}
-
-	margin := v.totalGoodWeight.
+	margin = v.totalGoodWeight.
 		Sub(layer.verifying.goodUncounted)
-	uncounted := v.expectedWeight(v.Config, lid).
+	uncounted = v.expectedWeight(v.Config, lid).
 		Sub(margin)
 	// GreaterThan(zero) returns true even if value with negative sign
 	if uncounted.Float() > 0 {
 		margin = margin.Sub(uncounted)
 	}
+	return margin, uncounted
+}
+
+func (v *verifying) verify(logger *zap.Logger, lid types.LayerID) (bool, bool) {
+	layer := v.layer(lid)
+	if !layer.hareTerminated {
+		logger.Debug("hare is not terminated")
+		return false, false
+	}
 
+	margin, uncounted := v.margin(lid)
 	threshold := v.globalThreshold(v.Config, lid)
 	if crossesThreshold(margin, threshold) != support {
 		logger.Debug("doesn't cross global threshold",
@@ -0,0 +1,106 @@
+// Package tracing provides optional OpenTelemetry tracing for the paths that handle
+// gossip and fetched data (receipt, validation, dependency fetch and database write).
+//
+// Spans are not created until New installs the provider that exports spans to the otlp collector,
+// so that instrumented code doesn't pay for tracing when it is disabled.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentation = "github.com/spacemeshos/go-spacemesh"
+
+var (
+	tracer  = otel.Tracer(instrumentation)
+	enabled atomic.Bool
+)
+
+// Config for tracing.
+type Config struct {
+	Enable bool `mapstructure:"enable"`
+	// Endpoint of the otlp grpc collector.
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure disables tls for the connection to the collector.
+	Insecure bool `mapstructure:"insecure"`
+	// SampleRatio is the fraction of traces that are started on this node and exported.
+	SampleRatio float64 `mapstructure:"sample-ratio"`
+}
+
+// DefaultConfig for tracing.
+func DefaultConfig() Config {
+	return Config{
+		Endpoint:    "localhost:4317",
+		Insecure:    true,
+		SampleRatio: 0.1,
+	}
+}
+
+// Provider exports spans to the otlp collector.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// New creates the provider and installs it as the global tracer provider.
+func New(ctx context.Context, cfg Config, service string, attrs ...attribute.KeyValue) (*Provider, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			append(attrs, attribute.String("service.name", service))...,
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	enabled.Store(true)
+	return &Provider{provider: provider}, nil
+}
+
+// Shutdown flushes the remaining spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	enabled.Store(false)
+	return p.provider.Shutdown(ctx)
+}
+
+// StartSpan starts a span that is a child of the span in the context.
+// If tracing is disabled the context is returned as is with a no-op span.
+func StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, name, opts...)
+}
+
+// EndSpan records the error, if any, and ends the span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Link returns a link to the span in the context, links are used to connect
+// spans that are processed together (e.g. hashes fetched in a single batch) with the requesters.
+func Link(ctx context.Context) (trace.Link, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	return trace.Link{SpanContext: sc}, sc.IsValid()
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx := context.Background()
+	disabledCtx, span := StartSpan(ctx, "disabled")
+	require.Equal(t, ctx, disabledCtx)
+	require.False(t, span.IsRecording())
+
+	enabled.Store(true)
+	t.Cleanup(func() { enabled.Store(false) })
+
+	_, ok := Link(context.Background())
+	require.False(t, ok)
+
+	ctx, parent := StartSpan(context.Background(), "parent")
+	_, child := StartSpan(ctx, "child")
+	EndSpan(child, errors.New("invalid"))
+	EndSpan(parent, nil)
+
+	link, ok := Link(ctx)
+	require.True(t, ok)
+	_, batch := StartSpan(context.Background(), "batch", trace.WithLinks(link))
+	EndSpan(batch, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "child", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "invalid", spans[0].Status().Description)
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	require.Equal(t, "parent", spans[1].Name())
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	require.Equal(t, "batch", spans[2].Name())
+	require.False(t, spans[2].Parent().IsValid())
+	require.Len(t, spans[2].Links(), 1)
+	require.Equal(t, spans[1].SpanContext().TraceID(), spans[2].Links()[0].SpanContext.TraceID())
+}
@@ -23,10 +23,14 @@ const (
 )
 
 var (
-	errBadNonce            = errors.New("bad nonce")
+	// ErrBadNonce is returned if the nonce of a tx is lower than the next nonce of its principal.
+	ErrBadNonce            = errors.New("bad nonce")
 	errInsufficientBalance = errors.New("insufficient balance")
-	errTooManyNonce        = errors.New("account has too many nonce pending")
-	errLayerNotInOrder     = errors.New("layers not applied in order")
+	// ErrTooManyNonce is returned if the principal of a locally submitted tx has too many pending txs.
+	ErrTooManyNonce = errors.New("account has too many nonce pending")
+	// errNonceScheme is wrapped in ErrBadNonce if the template of the principal defines its own nonce scheme.
+	// Txs are ordered by sequential nonces in the cache, therefore such txs are not admitted.
+	errNonceScheme = errors.New("nonce scheme of the template is not supported by the mempool")
 )
 
 // a candidate for the mempool.
@@ -71,6 +75,9 @@ type accountCache struct {
 	// TODO: evict accounts that only has DB-only txs
 	// https://github.com/spacemeshos/go-spacemesh/issues/3668
 	moreInDB bool
+	// nonceScheme is true if the template of the principal defines its own replay protection.
+	// it is refreshed together with the nonce and the balance of the principal.
+	nonceScheme bool
 
 	cachedTXs map[types.TransactionID]*NanoTX // shared with the cache instance
 }
@@ -92,7 +99,7 @@ func (ac *accountCache) availBalance() uint64 {
 func (ac *accountCache) precheck(logger log.Log, ntx *NanoTX) (*list.Element, *candidate, error) {
 	if ac.txsByNonce.Len() >= maxTXsPerAcct {
 		ac.moreInDB = true
-		return nil, nil, errTooManyNonce
+		return nil, nil, ErrTooManyNonce
 	}
 	balance := ac.startBalance
 	var prev *list.Element
@@ -151,6 +158,13 @@ func (ac *accountCache) accept(logger log.Log, ntx *NanoTX, blockSeed []byte) er
 	ac.cachedTXs[ntx.ID] = ntx
 
 	if replaced != nil {
+		events.ReportMempool(events.MempoolEvent{
+			Kind:       events.MempoolReplaced,
+			ID:         replaced.ID,
+			Principal:  replaced.Principal,
+			Nonce:      replaced.Nonce,
+			ReplacedBy: ntx.ID,
+		})
 		logger.With().Debug("better transaction replaced for nonce",
 			log.Stringer("better", ntx.ID),
 			log.Stringer("replaced", replaced.ID),
@@ -187,6 +201,13 @@ func (ac *accountCache) accept(logger log.Log, ntx *NanoTX, blockSeed []byte) er
 		next = next.Next()
 		removed := ac.txsByNonce.Remove(rm).(*candidate)
 		delete(ac.cachedTXs, removed.id())
+		events.ReportMempool(events.MempoolEvent{
+			Kind:      events.MempoolEvicted,
+			ID:        removed.id(),
+			Principal: removed.best.Principal,
+			Nonce:     removed.nonce(),
+			Reason:    fmt.Sprintf("%s after transaction %s", errInsufficientBalance, ntx.ID),
+		})
 		logger.With().Debug("tx made infeasible by new/better transaction",
 			removed.id(),
 			log.Uint64("nonce", removed.nonce()),
@@ -250,7 +271,7 @@ func (ac *accountCache) addBatch(logger log.Log, nonce2TXs map[uint64][]*NanoTX,
 			log.Uint64("fee", best.Fee()))
 
 		if err := ac.accept(logger, best, blockSeed); err != nil {
-			if errors.Is(err, errTooManyNonce) {
+			if errors.Is(err, ErrTooManyNonce) {
 				break
 			}
 			continue
@@ -280,18 +301,23 @@ func findBest(ntxs []*NanoTX, balance uint64, blockSeed []byte) *NanoTX {
 }
 
 // adding a tx to the account cache. possible outcomes:
+//   - template of the principal defines its own nonce scheme: reject from cache
 //   - nonce is smaller than the next nonce in state: reject from cache
 //   - too many txs present: reject from cache
 //   - nonce already exists in the cache:
 //     if it is better than the best candidate in that nonce group, swap
 //   - nonce not present: add to cache.
 func (ac *accountCache) add(logger log.Log, tx *types.Transaction, received time.Time) error {
+	if ac.nonceScheme {
+		logger.With().Debug("nonce scheme not supported", tx.ID, log.Uint64("tx_nonce", tx.Nonce))
+		return fmt.Errorf("%w: %w", ErrBadNonce, errNonceScheme)
+	}
 	if tx.Nonce < ac.startNonce {
 		logger.With().Debug("nonce too small",
 			tx.ID,
 			log.Uint64("next_nonce", ac.startNonce),
 			log.Uint64("tx_nonce", tx.Nonce))
-		return errBadNonce
+		return ErrBadNonce
 	}
 
 	ntx := NewNanoTX(&types.MeshTransaction{
@@ -303,7 +329,7 @@ func (ac *accountCache) add(logger log.Log, tx *types.Transaction, received time
 
 	err := ac.accept(logger, ntx, nil)
 	if err != nil {
-		if errors.Is(err, errTooManyNonce) {
+		if errors.Is(err, ErrTooManyNonce) {
 			mempoolTxCount.WithLabelValues(tooManyNonce).Inc()
 		} else if errors.Is(err, errInsufficientBalance) {
 			mempoolTxCount.WithLabelValues(balanceTooSmall).Inc()
@@ -320,6 +346,10 @@ func (ac *accountCache) addPendingFromNonce(
 	nonce uint64,
 	applied types.LayerID,
 ) error {
+	if ac.nonceScheme {
+		ac.moreInDB = false
+		return nil
+	}
 	mtxs, err := transactions.GetAcctPendingFromNonce(db, ac.addr, nonce)
 	if err != nil {
 		logger.With().Error("failed to get more pending txs from db", log.Err(err))
@@ -403,24 +433,76 @@ func (ac *accountCache) resetAfterApply(
 ) error {
 	logger = logger.WithFields(ac.addr)
 	logger.With().Debug("resetting to nonce", log.Uint64("nonce", nextNonce))
+	before := make(map[types.TransactionID]*NanoTX, ac.txsByNonce.Len())
 	for e := ac.txsByNonce.Front(); e != nil; e = e.Next() {
-		delete(ac.cachedTXs, e.Value.(*candidate).id())
+		cand := e.Value.(*candidate)
+		before[cand.id()] = cand.best
+		delete(ac.cachedTXs, cand.id())
 	}
 	ac.txsByNonce = list.New()
 	ac.startNonce = nextNonce
 	ac.startBalance = newBalance
-	return ac.addPendingFromNonce(logger, db, ac.startNonce, applied)
+	if err := ac.addPendingFromNonce(logger, db, ac.startNonce, applied); err != nil {
+		return err
+	}
+	ac.reportReset(before)
+	return nil
+}
+
+// reportReset reports transactions that were admitted to the mempool after the account was reset,
+// and transactions that were replaced or evicted because the updated balance doesn't cover them.
+// Transactions with nonces below the next nonce of the account left the mempool because the nonce was applied.
+func (ac *accountCache) reportReset(before map[types.TransactionID]*NanoTX) {
+	byNonce := make(map[uint64]types.TransactionID, ac.txsByNonce.Len())
+	for e := ac.txsByNonce.Front(); e != nil; e = e.Next() {
+		cand := e.Value.(*candidate)
+		byNonce[cand.nonce()] = cand.id()
+		if _, exists := before[cand.id()]; exists {
+			delete(before, cand.id())
+			continue
+		}
+		events.ReportMempool(events.MempoolEvent{
+			Kind:      events.MempoolAdmitted,
+			ID:        cand.id(),
+			Principal: ac.addr,
+			Nonce:     cand.nonce(),
+		})
+	}
+	for _, ntx := range before {
+		if ntx.Nonce < ac.startNonce {
+			continue
+		}
+		ev := events.MempoolEvent{
+			Kind:      events.MempoolEvicted,
+			ID:        ntx.ID,
+			Principal: ac.addr,
+			Nonce:     ntx.Nonce,
+			Reason:    errInsufficientBalance.Error(),
+		}
+		if better, exists := byNonce[ntx.Nonce]; exists {
+			ev.Kind = events.MempoolReplaced
+			ev.Reason = ""
+			ev.ReplacedBy = better
+		}
+		events.ReportMempool(ev)
+	}
 }
 
 func (ac *accountCache) shouldEvict() bool {
 	return ac.txsByNonce.Len() == 0 && !ac.moreInDB
 }
 
-type stateFunc func(types.Address) (uint64, uint64)
+type (
+	stateFunc  func(types.Address) (uint64, uint64)
+	schemeFunc func(types.Address) bool
+)
 
 type Cache struct {
 	logger log.Log
 	stateF stateFunc
+	// schemeF returns true if the template of the principal defines its own nonce scheme.
+	// if not set, all principals use sequential nonces.
+	schemeF schemeFunc
 
 	mu        sync.Mutex
 	pending   map[types.Address]*accountCache
@@ -534,11 +616,16 @@ func (c *Cache) createAcctIfNotPresent(addr types.Address) {
 			startNonce:   nextNonce,
 			startBalance: balance,
 			txsByNonce:   list.New(),
+			nonceScheme:  c.nonceScheme(addr),
 			cachedTXs:    c.cachedTXs,
 		}
 	}
 }
 
+func (c *Cache) nonceScheme(addr types.Address) bool {
+	return c.schemeF != nil && c.schemeF(addr)
+}
+
 func (c *Cache) MoreInDB(addr types.Address) bool {
 	acct, ok := c.pending[addr]
 	if !ok {
@@ -560,10 +647,10 @@ func (c *Cache) cleanupAccounts(accounts map[types.Address]struct{}) {
 //     a tx rejected due to insufficient balance MAY become feasible after a layer is applied (principal
 //     received incoming funds). when we receive a errInsufficientBalance tx, we should store it in db and
 //     re-evaluate it after each layer is applied.
-//   - errTooManyNonce: when a principal has way too many nonces, we don't want to blow up the memory. they should
+//   - ErrTooManyNonce: when a principal has way too many nonces, we don't want to blow up the memory. they should
 //     be stored in db and retrieved after each earlier nonce is applied.
 func acceptable(err error) bool {
-	return err == nil || errors.Is(err, errInsufficientBalance) || errors.Is(err, errTooManyNonce)
+	return err == nil || errors.Is(err, errInsufficientBalance) || errors.Is(err, ErrTooManyNonce)
 }
 
 func (c *Cache) Add(
@@ -580,6 +667,7 @@ func (c *Cache) Add(
 	defer c.cleanupAccounts(map[types.Address]struct{}{principal: {}})
 	logger := c.logger.WithContext(ctx).WithFields(principal)
 	err := c.pending[principal].add(logger, tx, received)
+	reportAdded(tx, err, c.has(tx.ID))
 	if acceptable(err) {
 		err = nil
 		mempoolTxCount.WithLabelValues(accepted).Inc()
@@ -592,6 +680,24 @@ func (c *Cache) Add(
 	return err
 }
 
+func reportAdded(tx *types.Transaction, err error, admitted bool) {
+	ev := events.MempoolEvent{
+		Kind:      events.MempoolAdmitted,
+		ID:        tx.ID,
+		Principal: tx.Principal,
+		Nonce:     tx.Nonce,
+	}
+	switch {
+	case err != nil:
+		ev.Kind = events.MempoolRejected
+		ev.Reason = err.Error()
+	case !admitted:
+		ev.Kind = events.MempoolRejected
+		ev.Reason = "better transaction with the same nonce exists"
+	}
+	events.ReportMempool(ev)
+}
+
 // Get gets a transaction from the cache.
 func (c *Cache) Get(tid types.TransactionID) *NanoTX {
 	c.mu.Lock()
@@ -599,6 +705,16 @@ func (c *Cache) Get(tid types.TransactionID) *NanoTX {
 	return c.cachedTXs[tid]
 }
 
+// TooManyPending returns true if the principal has as many pending transactions as the cache keeps
+// for a single account. New transactions of the principal are kept only in the database
+// until earlier transactions are applied.
+func (c *Cache) TooManyPending(principal types.Address) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ac, exists := c.pending[principal]
+	return exists && ac.txsByNonce.Len() >= maxTXsPerAcct
+}
+
 // Has returns true if transaction exists in the cache.
 func (c *Cache) Has(tid types.TransactionID) bool {
 	c.mu.Lock()
@@ -678,6 +794,8 @@ func (c *Cache) applyEmptyLayer(db *sql.Database, lid types.LayerID) error {
 }
 
 // ApplyLayer retires the applied transactions from the cache and updates the balances.
+// Results of the applied transactions are expected to be persisted together with the state
+// before the cache is updated. Order of the applied layers is enforced by the executor.
 func (c *Cache) ApplyLayer(
 	ctx context.Context,
 	db *sql.Database,
@@ -687,10 +805,6 @@ func (c *Cache) ApplyLayer(
 	ineffective []types.Transaction,
 ) error {
 	logger := c.logger.WithContext(ctx).WithFields(lid, bid)
-	if err := checkApplyOrder(logger, db, lid); err != nil {
-		return err
-	}
-
 	if bid == types.EmptyBlockID {
 		return c.applyEmptyLayer(db, lid)
 	}
@@ -702,20 +816,6 @@ func (c *Cache) ApplyLayer(
 	toReset := make(map[types.Address]struct{})
 	byPrincipal := make(map[types.Address]struct{})
 
-	// commmit results before reporting them
-	// TODO(dshulyak) save results in vm
-	if err := db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
-		for _, rst := range results {
-			err := transactions.AddResult(dbtx, rst.ID, &rst.TransactionResult)
-			if err != nil {
-				return fmt.Errorf("add result tx=%s nonce=%d %w", rst.ID, rst.Nonce, err)
-			}
-		}
-		return nil
-	}); err != nil {
-		return fmt.Errorf("add results %w", err)
-	}
-
 	for _, rst := range results {
 		byPrincipal[rst.Principal] = struct{}{}
 		toCleanup[rst.Principal] = struct{}{}
@@ -759,6 +859,7 @@ func (c *Cache) ApplyLayer(
 			log.Uint64("nonce", nextNonce),
 			log.Uint64("balance", balance))
 		t0 := time.Now()
+		c.pending[principal].nonceScheme = c.nonceScheme(principal)
 		if err := c.pending[principal].resetAfterApply(logger, db, nextNonce, balance, lid); err != nil {
 			logger.With().Error("failed to reset cache for principal", principal, log.Err(err))
 			return err
@@ -777,6 +878,7 @@ func (c *Cache) ApplyLayer(
 	for principal := range toReset {
 		nextNonce, balance := c.stateF(principal)
 		t2 := time.Now()
+		c.pending[principal].nonceScheme = c.nonceScheme(principal)
 		if err := c.pending[principal].resetAfterApply(logger, db, nextNonce, balance, lid); err != nil {
 			logger.With().Error("failed to reset cache for principal", principal, log.Err(err))
 			return err
@@ -826,22 +928,6 @@ func (c *Cache) GetMempool(logger log.Log) map[types.Address][]*NanoTX {
 	return all
 }
 
-// checkApplyOrder returns an error if layers were not applied in order.
-func checkApplyOrder(logger log.Log, db *sql.Database, toApply types.LayerID) error {
-	lastApplied, err := layers.GetLastApplied(db)
-	if err != nil {
-		logger.With().Error("failed to get last applied layer", log.Err(err))
-		return fmt.Errorf("cache get last applied %w", err)
-	}
-	if toApply != lastApplied.Add(1) {
-		logger.With().Error("layer not applied in order",
-			log.Stringer("expected", lastApplied.Add(1)),
-			log.Stringer("to_apply", toApply))
-		return errLayerNotInOrder
-	}
-	return nil
-}
-
 func addToProposal(db *sql.Database, lid types.LayerID, pid types.ProposalID, tids []types.TransactionID) error {
 	return db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
 		for _, tid := range tids {
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log/logtest"
 	"github.com/spacemeshos/go-spacemesh/signing"
 	"github.com/spacemeshos/go-spacemesh/sql"
@@ -103,6 +104,20 @@ func checkTXStateFromDB(t *testing.T, db *sql.Database, txs []*types.MeshTransac
 	}
 }
 
+// addResults persists results of the applied transactions,
+// as it is done by the executor before the cache is updated.
+func addResults(t *testing.T, db *sql.Database, results []types.TransactionWithResult) {
+	t.Helper()
+	require.NoError(t, db.WithTx(context.Background(), func(dbtx *sql.Tx) error {
+		for _, rst := range results {
+			if err := transactions.AddResult(dbtx, rst.ID, &rst.TransactionResult); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
 func checkTXNotInDB(t *testing.T, db *sql.Database, tid types.TransactionID) {
 	_, err := transactions.Get(db, tid)
 	require.ErrorIs(t, err, sql.ErrNotFound)
@@ -317,6 +332,7 @@ func TestCache_Account_HappyFlow(t *testing.T) {
 	}
 	ta.balance += income
 	applied := makeResults(lid, bid, mtxs[0].Transaction, mtxs[1].Transaction)
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 
 	for _, mtx := range mtxs[:2] {
@@ -390,6 +406,7 @@ func TestCache_Account_TXInMultipleLayers(t *testing.T) {
 	ta.nonce++
 	ta.balance = ta.balance - mtxs[0].Spending() + income
 	applied := makeResults(lid, bid0, mtxs[0].Transaction)
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid0, applied, []types.Transaction{}))
 	checkNoTX(t, tc.Cache, mtxs[0].ID)
 	checkTX(t, tc.Cache, mtxs[1].ID, lid.Add(1), types.EmptyBlockID)
@@ -646,6 +663,133 @@ func TestCache_Account_Add_SuperiorReplacesInferior_EvictLaterNonce(t *testing.T
 	checkTXStateFromDB(t, tc.db, append(mtxs, better), types.MEMPOOL)
 }
 
+func receiveMempoolEvents(
+	t *testing.T,
+	sub *events.BufferedSubscription[events.MempoolEvent],
+	n int,
+) []events.MempoolEvent {
+	t.Helper()
+	rst := make([]events.MempoolEvent, 0, n)
+	for len(rst) < n {
+		select {
+		case ev := <-sub.Out():
+			rst = append(rst, ev)
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for mempool events", "received %d of %d", len(rst), n)
+		}
+	}
+	select {
+	case ev := <-sub.Out():
+		require.FailNow(t, "unexpected mempool event", "%+v", ev)
+	case <-time.After(10 * time.Millisecond):
+	}
+	return rst
+}
+
+func TestCache_MempoolEvents(t *testing.T) {
+	events.InitializeReporter()
+	t.Cleanup(events.CloseEventReporter)
+
+	tc, ta := createSingleAccountTestCache(t)
+	mtxs := genAndSaveTXs(t, tc.db, ta.signer, ta.nonce, ta.nonce+1, time.Now())
+	buildSingleAccountCache(t, tc, ta, mtxs)
+	sub, err := events.Subscribe[events.MempoolEvent]()
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	// better tx replaces the tx with the same nonce and makes the tx with the next nonce infeasible
+	better := newTx(t, ta.nonce, ta.balance-(defaultFee+1)*defaultGas, defaultFee+1, ta.signer)
+	require.NoError(t, tc.Add(context.Background(), tc.db, better, time.Now(), false))
+	require.Equal(t, []events.MempoolEvent{
+		{
+			Kind:       events.MempoolReplaced,
+			ID:         mtxs[0].ID,
+			Principal:  ta.principal,
+			Nonce:      ta.nonce,
+			ReplacedBy: better.ID,
+		},
+		{
+			Kind:      events.MempoolEvicted,
+			ID:        mtxs[1].ID,
+			Principal: ta.principal,
+			Nonce:     ta.nonce + 1,
+			Reason:    "insufficient balance after transaction " + better.ID.String(),
+		},
+		{
+			Kind:      events.MempoolAdmitted,
+			ID:        better.ID,
+			Principal: ta.principal,
+			Nonce:     ta.nonce,
+		},
+	}, receiveMempoolEvents(t, sub, 3))
+
+	worse := newTx(t, ta.nonce, defaultAmount, defaultFee, ta.signer)
+	require.NoError(t, tc.Add(context.Background(), tc.db, worse, time.Now(), false))
+	tooSmall := newTx(t, ta.nonce-1, defaultAmount, defaultFee, ta.signer)
+	require.ErrorIs(t, tc.Add(context.Background(), tc.db, tooSmall, time.Now(), false), ErrBadNonce)
+	require.Equal(t, []events.MempoolEvent{
+		{
+			Kind:      events.MempoolRejected,
+			ID:        worse.ID,
+			Principal: ta.principal,
+			Nonce:     ta.nonce,
+			Reason:    "better transaction with the same nonce exists",
+		},
+		{
+			Kind:      events.MempoolRejected,
+			ID:        tooSmall.ID,
+			Principal: ta.principal,
+			Nonce:     ta.nonce - 1,
+			Reason:    ErrBadNonce.Error(),
+		},
+	}, receiveMempoolEvents(t, sub, 2))
+
+	// the account receives funds, the infeasible tx is admitted again
+	lid := types.LayerID(97)
+	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
+	ta.balance += ta.balance
+	require.NoError(t, tc.Cache.ApplyLayer(context.Background(), tc.db, lid, types.BlockID{1, 2, 3}, nil, nil))
+	require.Equal(t, []events.MempoolEvent{{
+		Kind:      events.MempoolAdmitted,
+		ID:        mtxs[1].ID,
+		Principal: ta.principal,
+		Nonce:     ta.nonce + 1,
+	}}, receiveMempoolEvents(t, sub, 1))
+}
+
+func TestCache_Account_Add_NonceScheme(t *testing.T) {
+	tc, ta := createSingleAccountTestCache(t)
+	scheme := false
+	tc.schemeF = func(principal types.Address) bool {
+		require.Equal(t, ta.principal, principal)
+		return scheme
+	}
+	buildSingleAccountCache(t, tc, ta, nil)
+
+	tx := newTx(t, ta.nonce, defaultAmount, defaultFee, ta.signer)
+	require.NoError(t, tc.Add(context.Background(), tc.db, tx, time.Now(), false))
+	checkTX(t, tc.Cache, tx.ID, 0, types.EmptyBlockID)
+
+	// the template defines its own nonce scheme once the account is spawned
+	scheme = true
+	lid := types.LayerID(97)
+	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
+	bid := types.BlockID{1}
+	require.NoError(t, tc.LinkTXsWithBlock(tc.db, lid, bid, []types.TransactionID{tx.ID}))
+	ta.nonce++
+	ta.balance -= tx.Spending()
+	applied := makeResults(lid, bid, *tx)
+	addResults(t, tc.db, applied)
+	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
+	checkNoTX(t, tc.Cache, tx.ID)
+
+	tx = newTx(t, ta.nonce, defaultAmount, defaultFee, ta.signer)
+	err := tc.Add(context.Background(), tc.db, tx, time.Now(), false)
+	require.ErrorIs(t, err, ErrBadNonce)
+	require.ErrorIs(t, err, errNonceScheme)
+	checkNoTX(t, tc.Cache, tx.ID)
+}
+
 func TestCache_Account_Add_UpdateHeader(t *testing.T) {
 	tc, ta := createSingleAccountTestCache(t)
 	buildSingleAccountCache(t, tc, ta, nil)
@@ -661,7 +805,7 @@ func TestCache_Account_Add_UpdateHeader(t *testing.T) {
 	require.Nil(t, got.TxHeader)
 
 	// update header and cache during execution
-	require.ErrorIs(t, tc.Add(context.Background(), tc.db, tx, time.Now(), true), errBadNonce)
+	require.ErrorIs(t, tc.Add(context.Background(), tc.db, tx, time.Now(), true), ErrBadNonce)
 	got, err = transactions.Get(tc.db, tx.ID)
 	require.NoError(t, err)
 	require.NotNil(t, got.TxHeader)
@@ -672,7 +816,7 @@ func TestCache_Account_Add_NonceTooSmall(t *testing.T) {
 	buildSingleAccountCache(t, tc, ta, nil)
 
 	tx := newTx(t, ta.nonce-1, defaultAmount, defaultFee, ta.signer)
-	require.ErrorIs(t, tc.Add(context.Background(), tc.db, tx, time.Now(), false), errBadNonce)
+	require.ErrorIs(t, tc.Add(context.Background(), tc.db, tx, time.Now(), false), ErrBadNonce)
 	checkNoTX(t, tc.Cache, tx.ID)
 	checkProjection(t, tc.Cache, ta.principal, ta.nonce, ta.balance)
 	checkMempool(t, tc.Cache, nil)
@@ -838,6 +982,7 @@ func TestCache_Account_AppliedTXsNotInCache(t *testing.T) {
 	ta.nonce = newNextNonce + 2
 	ta.balance = newBalance - mtxs[1].Spending() - mtxs[2].Spending()
 
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 	checkProjection(t, tc.Cache, ta.principal, ta.nonce, ta.balance)
 	checkMempool(t, tc.Cache, nil)
@@ -860,6 +1005,7 @@ func TestCache_Account_TooManyNonceAfterApply(t *testing.T) {
 	applied := makeResults(lid, bid, mtxs[0].Transaction)
 	// more txs arrived
 	saveTXs(t, tc.db, mtxs[1:])
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 
 	pending := mtxs[1:]
@@ -907,6 +1053,7 @@ func TestCache_Account_BalanceRelaxedAfterApply(t *testing.T) {
 	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
 	bid := types.BlockID{1, 2, 3}
 	applied := makeResults(lid, bid, mtx.Transaction)
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 	// all pending txs are added to cache now
 	newNextNonce = ta.nonce + uint64(len(pending))
@@ -950,6 +1097,7 @@ func TestCache_Account_BalanceRelaxedAfterApply_EvictLaterNonce(t *testing.T) {
 	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
 	bid := types.BlockID{1, 2, 3}
 	applied := makeResults(lid, bid, mtxs[0].Transaction)
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 	checkProjection(t, tc.Cache, ta.principal, ta.nonce+1, 0)
 	expectedMempool = map[types.Address][]*types.MeshTransaction{ta.principal: {better}}
@@ -973,6 +1121,7 @@ func TestCache_Account_EvictedAfterApply(t *testing.T) {
 	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(1), types.RandomBlockID()))
 	bid := types.BlockID{1, 2, 3}
 	applied := makeResults(lid, bid, mtx.Transaction)
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 	checkProjection(t, tc.Cache, ta.principal, newNextNonce, newBalance)
 	checkMempool(t, tc.Cache, nil)
@@ -1000,6 +1149,7 @@ func TestCache_Account_NotEvictedAfterApplyDueToNonceGap(t *testing.T) {
 		Received:    time.Now(),
 	}
 	saveTXs(t, tc.db, []*types.MeshTransaction{pendingInsufficient})
+	addResults(t, tc.db, applied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, applied, []types.Transaction{}))
 	checkProjection(t, tc.Cache, ta.principal, newNextNonce, newBalance)
 	checkMempool(t, tc.Cache, nil)
@@ -1255,6 +1405,7 @@ func TestCache_ApplyLayerAndRevert(t *testing.T) {
 		accounts[principal].balance = newBalance
 		allApplied = append(allApplied, applied...)
 	}
+	addResults(t, tc.db, allApplied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, allApplied, []types.Transaction{}))
 	checkTXStateFromDB(t, tc.db, appliedMTXs, types.APPLIED)
 	checkTXStateFromDB(t, tc.db, allPendingMTXs, types.MEMPOOL)
@@ -1319,6 +1470,7 @@ func TestCache_ApplyLayerWithSkippedTXs(t *testing.T) {
 	require.NoError(t, transactions.Add(tc.db, skippedNotInCache, time.Now()))
 	allSkipped = append(allSkipped, *skippedNotInCache)
 
+	addResults(t, tc.db, allApplied)
 	require.NoError(t, tc.ApplyLayer(context.Background(), tc.db, lid, bid, allApplied, allSkipped))
 	checkTXStateFromDB(t, tc.db, appliedMTXs, types.APPLIED)
 	checkTXStateFromDB(t, tc.db, allPendingMTXs, types.MEMPOOL)
@@ -1332,15 +1484,6 @@ func TestCache_ApplyLayerWithSkippedTXs(t *testing.T) {
 	checkMempoolSize(t, tc.Cache, len(allPendingMTXs))
 }
 
-func TestCache_ApplyLayer_OutOfOrder(t *testing.T) {
-	tc, accounts := createCache(t, 100)
-	buildSmallCache(t, tc, accounts, 10)
-	lid := types.LayerID(97)
-	require.NoError(t, layers.SetApplied(tc.db, lid.Sub(2), types.RandomBlockID()))
-	err := tc.ApplyLayer(context.Background(), tc.db, lid, types.BlockID{1, 2, 3}, nil, []types.Transaction{})
-	require.ErrorIs(t, err, errLayerNotInOrder)
-}
-
 func TestCache_GetMempool(t *testing.T) {
 	tc, accounts := createCache(t, 100)
 	mtxsByAccount := buildSmallCache(t, tc, accounts, 10)
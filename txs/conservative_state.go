@@ -20,6 +20,7 @@ import (
 type CSConfig struct {
 	BlockGasLimit     uint64
 	NumTXsPerProposal int
+	Priority          PriorityConfig
 }
 
 func defaultCSConfig() CSConfig {
@@ -69,9 +70,18 @@ func NewConservativeState(state vmState, db *sql.Database, opts ...ConservativeS
 		opt(cs)
 	}
 	cs.cache = NewCache(cs.getState, cs.logger)
+	cs.cache.schemeF = cs.hasNonceScheme
 	return cs
 }
 
+func (cs *ConservativeState) hasNonceScheme(addr types.Address) bool {
+	scheme, err := cs.vmState.HasNonceScheme(addr)
+	if err != nil {
+		cs.logger.With().Fatal("failed to get nonce scheme", log.Err(err))
+	}
+	return scheme
+}
+
 func (cs *ConservativeState) getState(addr types.Address) (uint64, uint64) {
 	nonce, err := cs.vmState.GetNonce(addr)
 	if err != nil {
@@ -85,12 +95,36 @@ func (cs *ConservativeState) getState(addr types.Address) (uint64, uint64) {
 }
 
 // SelectProposalTXs picks a specific number of random txs for miner to pack in a proposal.
+// Prioritized transactions, if configured, are picked first and precede all other txs.
 func (cs *ConservativeState) SelectProposalTXs(lid types.LayerID, numEligibility int) []types.TransactionID {
 	logger := cs.logger.WithFields(lid)
-	mi := newMempoolIterator(logger, cs.cache, cs.cfg.BlockGasLimit)
-	predictedBlock, byAddrAndNonce := mi.PopAll()
 	numTXs := numEligibility * cs.cfg.NumTXsPerProposal
-	return getProposalTXs(logger.WithFields(lid), numTXs, predictedBlock, byAddrAndNonce)
+	if !cs.cfg.Priority.enabled() {
+		mi := newMempoolIterator(logger, cs.cache, cs.cfg.BlockGasLimit)
+		predictedBlock, byAddrAndNonce := mi.PopAll()
+		return getProposalTXs(logger.WithFields(lid), numTXs, predictedBlock, byAddrAndNonce)
+	}
+
+	txs := mempoolSnapshot(cs.cache.GetMempool(logger))
+	budget := cs.cfg.BlockGasLimit / 100 * uint64(min(cs.cfg.Priority.GasShare, 100))
+	priority, used := selectPriorityTXs(logger, txs, cs.cfg.Priority, budget)
+	if len(priority) > numTXs {
+		priority = priority[:numTXs]
+	}
+	rst := make([]types.TransactionID, 0, numTXs)
+	for _, ntx := range priority {
+		rst = append(rst, ntx.ID)
+	}
+	if len(priority) > 0 {
+		logger.With().Info("selected prioritized txs",
+			log.Int("num_txs", len(priority)),
+			log.Uint64("gas", used),
+			log.Uint64("gas_budget", budget),
+		)
+	}
+	mi := newMempoolIterator(logger, txs, cs.cfg.BlockGasLimit-used)
+	predictedBlock, byAddrAndNonce := mi.PopAll()
+	return append(rst, getProposalTXs(logger, numTXs-len(priority), predictedBlock, byAddrAndNonce)...)
 }
 
 func getProposalTXs(
@@ -171,6 +205,11 @@ func (cs *ConservativeState) AddToDB(tx *types.Transaction) error {
 	return transactions.Add(cs.db, tx, time.Now())
 }
 
+// TooManyPending returns true if the principal has too many pending transactions in the cache.
+func (cs *ConservativeState) TooManyPending(principal types.Address) bool {
+	return cs.cache.TooManyPending(principal)
+}
+
 // HasTx returns true if transaction exists in the database.
 func (cs *ConservativeState) HasTx(tid types.TransactionID) (bool, error) {
 	has, err := transactions.Has(cs.db, tid)
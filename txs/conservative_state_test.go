@@ -97,6 +97,7 @@ func createTestState(t *testing.T, gasLimit uint64) *testConState {
 	id, err := peer.IDFromPublicKey(pub)
 	require.NoError(t, err)
 
+	mvm.EXPECT().HasNonceScheme(gomock.Any()).Return(false, nil).AnyTimes()
 	return &testConState{
 		ConservativeState: NewConservativeState(mvm, db,
 			WithCSConfig(cfg),
@@ -333,6 +334,51 @@ func TestSelectProposalTXs_TwoPrincipals(t *testing.T) {
 	}
 }
 
+func TestSelectProposalTXs_Priority(t *testing.T) {
+	// block fits 10 txs, half of the gas can be used by prioritized txs
+	tcs := createTestState(t, defaultGas*10)
+	lid := types.LayerID(97)
+	addTXs := func(n int) []*types.Transaction {
+		signer, err := signing.NewEdSigner()
+		require.NoError(t, err)
+		addr := types.GenerateAddress(signer.PublicKey().Bytes())
+		tcs.mvm.EXPECT().GetBalance(addr).Return(defaultBalance, nil).Times(1)
+		tcs.mvm.EXPECT().GetNonce(addr).Return(uint64(0), nil).Times(1)
+		txs := make([]*types.Transaction, 0, n)
+		for i := 0; i < n; i++ {
+			tx := newTx(t, uint64(i), defaultAmount, defaultFee, signer)
+			require.NoError(t, tcs.AddToCache(context.Background(), tx, time.Now()))
+			txs = append(txs, tx)
+		}
+		return txs
+	}
+	byAddr := addTXs(3)
+	byID := addTXs(4)
+	tooLate := addTXs(2)
+	for i := 0; i < 10; i++ {
+		addTXs(1)
+	}
+	tcs.cfg.Priority = PriorityConfig{
+		Addresses: []types.Address{byAddr[0].Principal},
+		// tx with nonce 1 is prioritized together with nonce 0.
+		// when the budget is exhausted no other txs are prioritized.
+		TXs:      []types.TransactionID{byID[1].ID, tooLate[0].ID},
+		GasShare: 50,
+	}
+	expected := []types.TransactionID{byAddr[0].ID, byAddr[1].ID, byAddr[2].ID, byID[0].ID, byID[1].ID}
+
+	got := tcs.SelectProposalTXs(lid, 1)
+	require.Len(t, got, 10)
+	require.Equal(t, expected, got[:len(expected)])
+	for _, tid := range got[len(expected):] {
+		require.NotContains(t, expected, tid)
+	}
+
+	// prioritized txs are bounded by the number of txs in the proposal
+	tcs.cfg.NumTXsPerProposal = 2
+	require.Equal(t, expected[:2], tcs.SelectProposalTXs(lid, 1))
+}
+
 func TestGetProjection(t *testing.T) {
 	tcs := createConservativeState(t)
 	signer, err := signing.NewEdSigner()
@@ -378,7 +424,7 @@ func TestAddToCache_BadNonceNotPersisted(t *testing.T) {
 	}
 	tcs.mvm.EXPECT().GetBalance(tx.Principal).Return(defaultBalance, nil).Times(1)
 	tcs.mvm.EXPECT().GetNonce(tx.Principal).Return(tx.Nonce+1, nil).Times(1)
-	require.ErrorIs(t, tcs.AddToCache(context.Background(), tx, time.Now()), errBadNonce)
+	require.ErrorIs(t, tcs.AddToCache(context.Background(), tx, time.Now()), ErrBadNonce)
 	checkTXNotInDB(t, tcs.db, tx.ID)
 }
 
@@ -492,6 +538,7 @@ func TestUpdateCache_UpdateHeader(t *testing.T) {
 	}
 	tcs.mvm.EXPECT().GetBalance(tx.Principal).Return(defaultBalance-(defaultAmount+defaultFee), nil)
 	tcs.mvm.EXPECT().GetNonce(tx.Principal).Return(nonce+1, nil)
+	addResults(t, tcs.db, executed)
 	require.NoError(t, tcs.UpdateCache(context.Background(), lid, block.ID(), executed, nil))
 
 	got, err = transactions.Get(tcs.db, tx.ID)
@@ -520,6 +567,7 @@ func TestUpdateCache(t *testing.T) {
 		tcs.mvm.EXPECT().GetBalance(tx.Principal).Return(defaultBalance-(defaultAmount+defaultFee), nil)
 		tcs.mvm.EXPECT().GetNonce(tx.Principal).Return(nonce+1, nil)
 	}
+	addResults(t, tcs.db, executed)
 	require.NoError(t, tcs.UpdateCache(context.Background(), lid, block.ID(), executed, nil))
 
 	for _, id := range ids {
@@ -622,6 +670,7 @@ func TestConsistentHandling(t *testing.T) {
 			}
 		}
 		for _, instance := range instances {
+			addResults(t, instance.db, results)
 			require.NoError(t, instance.UpdateCache(context.Background(), block.LayerIndex, block.ID(), results, nil))
 			require.NoError(t, layers.SetApplied(instance.db, block.LayerIndex, block.ID()))
 		}
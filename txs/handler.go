@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/events"
 	"github.com/spacemeshos/go-spacemesh/log"
 	"github.com/spacemeshos/go-spacemesh/p2p"
 	"github.com/spacemeshos/go-spacemesh/p2p/pubsub"
@@ -17,10 +18,14 @@ import (
 )
 
 var (
-	errWrongHash   = fmt.Errorf("%w: incorrect hash", pubsub.ErrValidationReject)
-	errDuplicateTX = errors.New("tx already exists")
-	errParse       = errors.New("failed to parse tx")
-	errVerify      = errors.New("failed to verify tx")
+	errWrongHash = fmt.Errorf("%w: incorrect hash", pubsub.ErrValidationReject)
+
+	// ErrDuplicateTX is returned if the tx is already known to the node.
+	ErrDuplicateTX = errors.New("tx already exists")
+	// ErrParse is returned if the tx is malformed or can't be executed by its principal.
+	ErrParse = errors.New("failed to parse tx")
+	// ErrVerify is returned if the signature of the tx is invalid.
+	ErrVerify = errors.New("failed to verify tx")
 )
 
 // TxHandler handles the transactions received via gossip or sync.
@@ -43,13 +48,13 @@ func updateMetrics(err error, counter *prometheus.CounterVec) {
 	switch {
 	case err == nil:
 		counter.WithLabelValues(saved).Inc()
-	case errors.Is(err, errDuplicateTX):
+	case errors.Is(err, ErrDuplicateTX):
 		counter.WithLabelValues(duplicate).Inc()
-	case errors.Is(err, errBadNonce):
+	case errors.Is(err, ErrBadNonce):
 		counter.WithLabelValues(rejectedBadNonce).Inc()
-	case errors.Is(err, errParse):
+	case errors.Is(err, ErrParse):
 		counter.WithLabelValues(cantParse).Inc()
-	case errors.Is(err, errVerify):
+	case errors.Is(err, ErrVerify):
 		counter.WithLabelValues(cantVerify).Inc()
 	default:
 		counter.WithLabelValues(rejectedInternalErr).Inc()
@@ -62,7 +67,7 @@ func (th *TxHandler) HandleGossipTransaction(ctx context.Context, peer p2p.Peer,
 		return nil
 	}
 
-	err := th.VerifyAndCacheTx(ctx, msg)
+	err := th.verifyAndCache(ctx, types.Hash32{}, msg, false)
 	updateMetrics(err, gossipTxCount)
 	if err != nil {
 		th.logger.WithContext(ctx).With().Warning("failed to handle tx", log.Err(err))
@@ -78,45 +83,53 @@ func (th *TxHandler) HandleProposalTransaction(
 	_ p2p.Peer,
 	msg []byte,
 ) error {
-	err := th.verifyAndCache(ctx, expHash, msg)
+	err := th.verifyAndCache(ctx, expHash, msg, false)
 	updateMetrics(err, proposalTxCount)
-	if errors.Is(err, errDuplicateTX) {
+	if errors.Is(err, ErrDuplicateTX) {
 		return nil
 	}
 	return err
 }
 
+// VerifyAndCacheTx verifies and caches the transaction submitted to the node locally, e.g. with the api.
 func (th *TxHandler) VerifyAndCacheTx(ctx context.Context, msg []byte) error {
-	return th.verifyAndCache(ctx, types.Hash32{}, msg)
+	return th.verifyAndCache(ctx, types.Hash32{}, msg, true)
 }
 
-func (th *TxHandler) verifyAndCache(ctx context.Context, expHash types.Hash32, msg []byte) error {
+// verifyAndCache verifies the transaction and adds it to the cache. Transactions received from peers
+// are stored even if their principal has too many pending transactions, they are added to the cache
+// after earlier transactions are applied. Local transactions are rejected with ErrTooManyNonce instead,
+// so that the client can retry the submission later.
+func (th *TxHandler) verifyAndCache(ctx context.Context, expHash types.Hash32, msg []byte, local bool) error {
 	raw := types.NewRawTx(msg)
 	mtx, err := th.state.GetMeshTransaction(raw.ID)
 	if err != nil && !errors.Is(err, sql.ErrNotFound) {
 		return fmt.Errorf("get tx %w", err)
 	}
 	if mtx != nil && mtx.TxHeader != nil {
-		return errDuplicateTX
+		return ErrDuplicateTX
 	}
 
 	req := th.state.Validation(raw)
 	header, err := req.Parse()
 	if err != nil {
-		return fmt.Errorf("%w: %s (err: %s)", errParse, raw.ID, err)
+		return reject(raw.ID, nil, fmt.Errorf("%w: %s (err: %s)", ErrParse, raw.ID, err))
 	}
 	tx := &types.Transaction{RawTx: raw, TxHeader: header}
 	if expHash != (types.Hash32{}) && tx.ID.Hash32() != expHash {
 		return fmt.Errorf("%w: proposal tx want %s, got %s", errWrongHash, expHash.ShortString(), tx.ID.ShortString())
 	}
 	if header.LayerLimits.Min != 0 || header.LayerLimits.Max != 0 {
-		return fmt.Errorf("%w: layers limits are not enabled %s", errParse, raw.ID)
+		return reject(raw.ID, header, fmt.Errorf("%w: layers limits are not enabled %s", ErrParse, raw.ID))
 	}
 	if header.GasPrice == 0 || header.Fee() == 0 {
-		return fmt.Errorf("%w: zero gas price %s", errParse, raw.ID)
+		return reject(raw.ID, header, fmt.Errorf("%w: zero gas price %s", ErrParse, raw.ID))
 	}
 	if !req.Verify() {
-		return fmt.Errorf("%w: %s", errVerify, raw.ID)
+		return reject(raw.ID, header, fmt.Errorf("%w: %s", ErrVerify, raw.ID))
+	}
+	if local && th.state.TooManyPending(header.Principal) {
+		return reject(raw.ID, header, fmt.Errorf("%w: %s", ErrTooManyNonce, header.Principal))
 	}
 	if err := th.state.AddToCache(ctx, tx, time.Now()); err != nil {
 		th.logger.WithContext(ctx).With().Warning("failed to add tx to conservative cache",
@@ -128,6 +141,22 @@ func (th *TxHandler) verifyAndCache(ctx context.Context, expHash types.Hash32, m
 	return nil
 }
 
+// reject reports the transaction that is rejected before it is added to the mempool.
+// Header is nil if the transaction can't be parsed.
+func reject(id types.TransactionID, header *types.TxHeader, err error) error {
+	ev := events.MempoolEvent{
+		Kind:   events.MempoolRejected,
+		ID:     id,
+		Reason: err.Error(),
+	}
+	if header != nil {
+		ev.Principal = header.Principal
+		ev.Nonce = header.Nonce
+	}
+	events.ReportMempool(ev)
+	return err
+}
+
 // HandleBlockTransaction handles transactions received as a reference to a block.
 func (th *TxHandler) HandleBlockTransaction(_ context.Context, expHash types.Hash32, _ p2p.Peer, data []byte) error {
 	raw := types.NewRawTx(data)
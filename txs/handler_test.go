@@ -265,6 +265,31 @@ func Test_HandleOwnGossip(t *testing.T) {
 	)
 }
 
+func Test_VerifyAndCacheTx_TooManyPending(t *testing.T) {
+	_, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+	id, err := peer.IDFromPublicKey(pub)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	cstate := NewMockconservativeState(ctrl)
+	th := NewTxHandler(cstate, id, logtest.New(t))
+
+	signer, err := signing.NewEdSigner()
+	require.NoError(t, err)
+	tx := newTx(t, 3, 10, 1, signer)
+
+	req := smocks.NewMockValidationRequest(ctrl)
+	req.EXPECT().Parse().Return(tx.TxHeader, nil)
+	req.EXPECT().Verify().Return(true)
+	cstate.EXPECT().GetMeshTransaction(tx.ID).Return(nil, nil)
+	cstate.EXPECT().Validation(tx.RawTx).Return(req)
+	cstate.EXPECT().TooManyPending(tx.Principal).Return(true)
+
+	err = th.VerifyAndCacheTx(context.Background(), tx.Raw)
+	require.ErrorIs(t, err, ErrTooManyNonce)
+}
+
 func Test_HandleProposal(t *testing.T) {
 	for _, tc := range []struct {
 		desc                     string
@@ -15,6 +15,7 @@ type conservativeState interface {
 	HasTx(types.TransactionID) (bool, error)
 	Validation(types.RawTx) system.ValidationRequest
 	AddToCache(context.Context, *types.Transaction, time.Time) error
+	TooManyPending(types.Address) bool
 	AddToDB(*types.Transaction) error
 	GetMeshTransaction(types.TransactionID) (*types.MeshTransaction, error)
 }
@@ -27,6 +28,7 @@ type vmState interface {
 	GetAllAccounts() ([]*types.Account, error)
 	GetBalance(types.Address) (uint64, error)
 	GetNonce(types.Address) (types.Nonce, error)
+	HasNonceScheme(types.Address) (bool, error)
 }
 
 type conStateCache interface {
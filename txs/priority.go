@@ -0,0 +1,103 @@
+package txs
+
+import (
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/go-spacemesh/log"
+)
+
+// PriorityConfig defines local transactions that are packed into the node's own proposals
+// before any other transaction from the mempool.
+type PriorityConfig struct {
+	// Addresses whose transactions are prioritized, in nonce order.
+	Addresses []types.Address
+	// TXs that are prioritized. Transactions of the same principal with lower nonces
+	// are prioritized together with them, as they have to be executed first.
+	TXs []types.TransactionID
+	// GasShare is the percentage of the block gas limit that can be used by prioritized transactions.
+	GasShare uint8
+}
+
+func (cfg PriorityConfig) enabled() bool {
+	return cfg.GasShare > 0 && (len(cfg.Addresses) > 0 || len(cfg.TXs) > 0)
+}
+
+// mempoolSnapshot is a snapshot of transactions that are eligible for a proposal.
+type mempoolSnapshot map[types.Address][]*NanoTX
+
+// GetMempool implements conStateCache.
+func (m mempoolSnapshot) GetMempool(log.Log) map[types.Address][]*NanoTX {
+	return m
+}
+
+// take removes the first n transactions of the principal from the mempool
+// and returns them if they all fit into the gas budget.
+func (m mempoolSnapshot) take(principal types.Address, n int, budget uint64) ([]*NanoTX, uint64) {
+	var gas uint64
+	for _, ntx := range m[principal][:n] {
+		if ntx.MaxGas > budget-gas {
+			return nil, 0
+		}
+		gas += ntx.MaxGas
+	}
+	taken := m[principal][:n]
+	if len(m[principal]) == n {
+		delete(m, principal)
+	} else {
+		m[principal] = m[principal][n:]
+	}
+	return taken, gas
+}
+
+// selectPriorityTXs removes prioritized transactions from the mempool and returns them in the order
+// they were configured, while they fit into the gas budget. It also returns the gas used by them.
+func selectPriorityTXs(
+	logger log.Log,
+	txs mempoolSnapshot,
+	cfg PriorityConfig,
+	budget uint64,
+) ([]*NanoTX, uint64) {
+	var (
+		rst  []*NanoTX
+		used uint64
+	)
+	for _, addr := range cfg.Addresses {
+		for len(txs[addr]) > 0 {
+			taken, gas := txs.take(addr, 1, budget-used)
+			if taken == nil {
+				break
+			}
+			rst = append(rst, taken...)
+			used += gas
+		}
+	}
+	if len(cfg.TXs) > 0 {
+		principals := make(map[types.TransactionID]types.Address)
+		for addr, ntxs := range txs {
+			for _, ntx := range ntxs {
+				principals[ntx.ID] = addr
+			}
+		}
+		for _, tid := range cfg.TXs {
+			addr, exists := principals[tid]
+			if !exists {
+				continue
+			}
+			for i, ntx := range txs[addr] {
+				if ntx.ID != tid {
+					continue
+				}
+				taken, gas := txs.take(addr, i+1, budget-used)
+				if taken == nil {
+					logger.With().Debug("prioritized tx doesn't fit into the gas budget",
+						tid,
+						log.Uint64("gas_left", budget-used),
+					)
+				}
+				rst = append(rst, taken...)
+				used += gas
+				break
+			}
+		}
+	}
+	return rst, used
+}
@@ -197,6 +197,44 @@ func (c *MockconservativeStateHasTxCall) DoAndReturn(f func(types.TransactionID)
 	return c
 }
 
+// TooManyPending mocks base method.
+func (m *MockconservativeState) TooManyPending(arg0 types.Address) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TooManyPending", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// TooManyPending indicates an expected call of TooManyPending.
+func (mr *MockconservativeStateMockRecorder) TooManyPending(arg0 any) *MockconservativeStateTooManyPendingCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TooManyPending", reflect.TypeOf((*MockconservativeState)(nil).TooManyPending), arg0)
+	return &MockconservativeStateTooManyPendingCall{Call: call}
+}
+
+// MockconservativeStateTooManyPendingCall wrap *gomock.Call
+type MockconservativeStateTooManyPendingCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockconservativeStateTooManyPendingCall) Return(arg0 bool) *MockconservativeStateTooManyPendingCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockconservativeStateTooManyPendingCall) Do(f func(types.Address) bool) *MockconservativeStateTooManyPendingCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockconservativeStateTooManyPendingCall) DoAndReturn(f func(types.Address) bool) *MockconservativeStateTooManyPendingCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Validation mocks base method.
 func (m *MockconservativeState) Validation(arg0 types.RawTx) system.ValidationRequest {
 	m.ctrl.T.Helper()
@@ -492,6 +530,45 @@ func (c *MockvmStateGetStateRootCall) DoAndReturn(f func() (types.Hash32, error)
 	return c
 }
 
+// HasNonceScheme mocks base method.
+func (m *MockvmState) HasNonceScheme(arg0 types.Address) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasNonceScheme", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasNonceScheme indicates an expected call of HasNonceScheme.
+func (mr *MockvmStateMockRecorder) HasNonceScheme(arg0 any) *MockvmStateHasNonceSchemeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasNonceScheme", reflect.TypeOf((*MockvmState)(nil).HasNonceScheme), arg0)
+	return &MockvmStateHasNonceSchemeCall{Call: call}
+}
+
+// MockvmStateHasNonceSchemeCall wrap *gomock.Call
+type MockvmStateHasNonceSchemeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockvmStateHasNonceSchemeCall) Return(arg0 bool, arg1 error) *MockvmStateHasNonceSchemeCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockvmStateHasNonceSchemeCall) Do(f func(types.Address) (bool, error)) *MockvmStateHasNonceSchemeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockvmStateHasNonceSchemeCall) DoAndReturn(f func(types.Address) (bool, error)) *MockvmStateHasNonceSchemeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Validation mocks base method.
 func (m *MockvmState) Validation(arg0 types.RawTx) system.ValidationRequest {
 	m.ctrl.T.Helper()